@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+	"github.com/Excoriate/daggerx/pkg/configx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// runApko runs the apko subcommands.
+func runApko(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || args[0] != "build-command" {
+		fmt.Fprintln(stderr, "Usage: daggerx apko build-command [-publish] [-json] [-strict] SPEC")
+		return errUsage
+	}
+
+	fs := newFlagSet("apko build-command", "apko build-command [-publish] [-json] [-strict] SPEC", stderr)
+	publish := fs.Bool("publish", false, "print the apko publish command instead of the build command")
+	asJSON := fs.Bool("json", false, "print the command as a JSON array")
+	strict := fs.Bool("strict", false, "fail on warnings")
+	if err := parseFlags(fs, args[1:], 1); err != nil {
+		return err
+	}
+
+	builder, err := loadApko(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	builder.WithStrict(*strict)
+
+	generate := builder.BuildCommand
+	if *publish {
+		generate = builder.PublishCommand
+	}
+
+	cmd, err := generate()
+	if err != nil {
+		return err
+	}
+
+	reportWarnings(stderr, builder.Warnings())
+
+	if *asJSON {
+		return json.NewEncoder(stdout).Encode(cmd)
+	}
+
+	fmt.Fprintln(stdout, cmdx.ShellJoin(cmd))
+
+	return nil
+}
+
+// loadApko loads the apko builder of the spec file at 'path'.
+func loadApko(path string) (*apkox.ApkoBuilder, error) {
+	doc, err := configx.NewLoader().Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	builder, ok := doc.Builder.(*apkox.ApkoBuilder)
+	if !ok {
+		return nil, fmt.Errorf("spec %s has kind %s, want %s", path, doc.Kind, configx.KindApko)
+	}
+
+	return builder, nil
+}
+
+// reportWarnings prints the warnings to 'stderr'.
+func reportWarnings(stderr io.Writer, warnings []errorsx.Warning) {
+	for _, w := range warnings {
+		fmt.Fprintf(stderr, "warning: %s\n", w)
+	}
+}
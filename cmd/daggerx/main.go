@@ -0,0 +1,119 @@
+// Command daggerx exposes the daggerx builders on the command line, to try out the commands they
+// generate locally before wiring them into a Dagger module. Builders are configured with configx
+// spec files.
+//
+// Usage:
+//
+//	daggerx apko build-command [-publish] [-json] SPEC
+//	daggerx validate-config SPEC...
+//	daggerx plan [-arch ARCHS] [-json] SPEC...
+//	daggerx schemas [-o DIR]
+//
+// Commands are printed quoted for sh. Warnings go to stderr; with -strict they fail the command.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// exitUsage is the exit code of invalid invocations.
+const exitUsage = 2
+
+// usage is the help of the command.
+const usage = `Usage: daggerx <command> [flags] [arguments]
+
+Commands:
+  apko build-command   print the apko command of a spec file
+  validate-config      validate spec files and the builders they configure
+  plan                 simulate the builds of spec files, architecture by architecture
+  schemas              write the JSON Schemas of the spec files
+
+Run "daggerx <command> -h" for the flags of a command.
+`
+
+var (
+	// errUsage reports an invalid invocation, already described on stderr.
+	errUsage = errors.New("usage")
+	// errInvalid reports a failure already described on stderr.
+	errInvalid = errors.New("invalid")
+)
+
+// command is a subcommand of the CLI.
+type command func(args []string, stdout, stderr io.Writer) error
+
+// commands holds the subcommands by name.
+var commands = map[string]command{
+	"apko":            runApko,
+	"validate-config": runValidateConfig,
+	"plan":            runPlan,
+	"schemas":         runSchemas,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the CLI with 'args' and returns its exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprint(stderr, usage)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return 0
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "daggerx: unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+
+	err := cmd(args[1:], stdout, stderr)
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, errInvalid):
+		return 1
+	default:
+		fmt.Fprintf(stderr, "daggerx: %v\n", err)
+		return 1
+	}
+}
+
+// newFlagSet creates the flag set of the subcommand 'name', printing its usage to 'stderr'.
+func newFlagSet(name, synopsis string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: daggerx %s\n", synopsis)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// parseFlags parses the flags of a subcommand, requiring at least 'minArgs' arguments.
+func parseFlags(fs *flag.FlagSet, args []string, minArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+
+	if fs.NArg() < minArgs {
+		fs.Usage()
+		return errUsage
+	}
+
+	return nil
+}
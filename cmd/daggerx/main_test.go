@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runCLI(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestApkoBuildCommand(t *testing.T) {
+	code, stdout, _ := runCLI("apko", "build-command", "testdata/base.yaml")
+	require.Equal(t, 0, code)
+	assert.Equal(t, "apko build --sbom=false --vcs=false apko.yaml registry.example.com/base:1.2.3 /out/base.tar\n", stdout)
+
+	code, stdout, _ = runCLI("apko", "build-command", "-publish", "-json", "testdata/base.yaml")
+	require.Equal(t, 0, code)
+
+	var cmd []string
+	require.NoError(t, json.Unmarshal([]byte(stdout), &cmd))
+	assert.Equal(t, []string{"apko", "publish"}, cmd[:2])
+}
+
+func TestApkoBuildCommandWarnings(t *testing.T) {
+	code, stdout, stderr := runCLI("apko", "build-command", "testdata/latest.yaml")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, "registry.example.com/base:latest")
+	assert.Contains(t, stderr, "warning:")
+
+	code, stdout, stderr = runCLI("apko", "build-command", "-strict", "testdata/latest.yaml")
+	assert.Equal(t, 1, code)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "latest")
+}
+
+func TestValidateConfig(t *testing.T) {
+	code, stdout, _ := runCLI("validate-config", "testdata/base.yaml")
+	require.Equal(t, 0, code)
+	assert.Equal(t, "testdata/base.yaml: ok\n", stdout)
+
+	code, stdout, stderr := runCLI("validate-config", "testdata/base.yaml", "testdata/invalid.yaml")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "testdata/base.yaml: ok\n", stdout)
+	assert.Contains(t, stderr, "outputImag")
+	assert.Contains(t, stderr, "1 of 2 spec files are invalid")
+}
+
+func TestPlan(t *testing.T) {
+	code, stdout, _ := runCLI("plan", "-arch", "amd64,arm64", "testdata/base.yaml")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, "+ base/x86_64")
+	assert.Contains(t, stdout, "+ base/aarch64")
+	assert.Contains(t, stdout, "/out/base-aarch64.tar")
+	assert.Contains(t, stdout, "Nothing was executed.")
+
+	code, _, stderr := runCLI("plan", "-arch", "mips", "testdata/base.yaml")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "mips")
+}
+
+func TestSchemas(t *testing.T) {
+	dir := t.TempDir()
+
+	code, stdout, _ := runCLI("schemas", "-o", dir)
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, filepath.Join(dir, "apko.schema.json"))
+
+	data, err := os.ReadFile(filepath.Join(dir, "pipeline-plan.schema.json"))
+	require.NoError(t, err)
+	assert.True(t, json.Valid(data))
+}
+
+func TestUsage(t *testing.T) {
+	code, _, stderr := runCLI()
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "Usage: daggerx")
+
+	code, _, _ = runCLI("unknown")
+	assert.Equal(t, exitUsage, code)
+
+	code, _, _ = runCLI("plan")
+	assert.Equal(t, exitUsage, code)
+
+	code, _, _ = runCLI("help")
+	assert.Equal(t, 0, code)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/drydockx"
+	"github.com/Excoriate/daggerx/pkg/pipeline"
+)
+
+// runPlan simulates the builds of spec files (see drydockx) without running anything: one step
+// per spec file, or per spec file and architecture with -arch.
+func runPlan(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("plan", "plan [-arch ARCHS] [-json] SPEC...", stderr)
+	archs := fs.String("arch", "", `comma-separated architectures to build each spec for, or "all"`)
+	asJSON := fs.Bool("json", false, "print the simulation as JSON")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+
+	var set apkox.ArchitectureSet
+	if *archs != "" {
+		var err error
+		if set, err = apkox.ParseArchitectures(*archs); err != nil {
+			return err
+		}
+	}
+
+	p := pipeline.New("daggerx")
+	for _, path := range fs.Args() {
+		builder, err := loadApko(path)
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if err := addBuildNodes(p, name, builder, set); err != nil {
+			return err
+		}
+	}
+
+	sim, err := drydockx.Simulate(p)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		data, err := sim.JSON()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, string(data))
+		return err
+	}
+
+	fmt.Fprint(stdout, sim)
+
+	return nil
+}
+
+// addBuildNodes adds the build of 'builder' to 'p' as the node 'name', or as one node per
+// architecture of 'archs' (e.g. "base/aarch64") when the set is not empty.
+func addBuildNodes(p *pipeline.Pipeline, name string, builder *apkox.ApkoBuilder, archs apkox.ArchitectureSet) error {
+	ids := []string{name}
+	builders := []*apkox.ApkoBuilder{builder}
+	if len(archs) > 0 {
+		ids = ids[:0]
+		builders = builder.ForArchitectures(archs...)
+		for _, arch := range archs {
+			ids = append(ids, name+"/"+string(arch))
+		}
+	}
+
+	for i, b := range builders {
+		cmd, err := b.BuildCommand()
+		if err != nil {
+			return fmt.Errorf("failed to generate the command of %s: %w", ids[i], err)
+		}
+
+		node := pipeline.Node{ID: ids[i], Command: cmd}
+		if tarball := b.Spec().OutputTarball; tarball != "" {
+			node.Artifacts = []string{tarball}
+		}
+
+		if err := p.AddNode(node); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
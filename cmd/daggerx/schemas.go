@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Excoriate/daggerx/pkg/configx"
+)
+
+// runSchemas writes the JSON Schemas of the spec files (see configx.GenerateSchemas).
+func runSchemas(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("schemas", "schemas [-o DIR]", stderr)
+	dir := fs.String("o", ".", "directory to write the schemas to")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	schemas, err := configx.GenerateSchemas()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create schema directory: %w", err)
+	}
+
+	files := make([]string, 0, len(schemas))
+	for file := range schemas {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		path := filepath.Join(*dir, file)
+		if err := os.WriteFile(path, schemas[file], 0o644); err != nil {
+			return fmt.Errorf("failed to write schema: %w", err)
+		}
+		fmt.Fprintln(stdout, path)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/configx"
+)
+
+// runValidateConfig validates spec files: their schema, then the command of the builder they
+// configure, so options that only conflict with each other are caught too.
+func runValidateConfig(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("validate-config", "validate-config [-strict] SPEC...", stderr)
+	strict := fs.Bool("strict", false, "fail on warnings")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+
+	loader := configx.NewLoader()
+	failed := 0
+	for _, path := range fs.Args() {
+		if err := validateSpec(loader, path, *strict, stderr); err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			failed++
+			continue
+		}
+
+		fmt.Fprintf(stdout, "%s: ok\n", path)
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(stderr, "%d of %d spec files are invalid\n", failed, fs.NArg())
+		return errInvalid
+	}
+
+	return nil
+}
+
+// validateSpec loads the spec file at 'path' and generates the command of its builder.
+func validateSpec(loader *configx.Loader, path string, strict bool, stderr io.Writer) error {
+	doc, err := loader.Load(path)
+	if err != nil {
+		return err
+	}
+
+	builder, ok := doc.Builder.(*apkox.ApkoBuilder)
+	if !ok {
+		return nil
+	}
+
+	if _, err := builder.WithStrict(strict).BuildCommand(); err != nil {
+		return err
+	}
+
+	reportWarnings(stderr, builder.Warnings())
+
+	return nil
+}
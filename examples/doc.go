@@ -0,0 +1,27 @@
+// Package examples holds reference pipelines built only from the public APIs of this module, as
+// executable code rather than documentation snippets. Each pipeline is tested against a
+// pipelinetest.Executor, so the examples keep compiling and behaving as documented while the
+// builders they combine evolve: they are the integration tests of the whole feature surface.
+//
+// The reference pipelines are:
+//   - WolfiRelease: build a Wolfi-based image with apko, scan it with trivy, publish it and sign it
+//     with cosign.
+//   - MelangeImage: build an APK package with melange, then an apko image installing it from the
+//     local repository melange writes to.
+//
+// Run executes any of them, stage by stage, with an execx.Executor.
+//
+// Example usage:
+//
+//	p, err := examples.WolfiRelease(examples.WolfiReleaseOpts{
+//	    ConfigFile: "apko.yaml",
+//	    Repository: "registry.example.com/app",
+//	    Tag:        "v1.2.0",
+//	    SigningKey: signingx.NewKMSKey("awskms:///alias/release"),
+//	})
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	reports, err := examples.Run(ctx, p, execx.NewLocalExecutor())
+package examples
@@ -0,0 +1,135 @@
+package examples
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/goldenx"
+	"github.com/Excoriate/daggerx/pkg/pipeline/pipelinetest"
+	"github.com/Excoriate/daggerx/pkg/signingx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	apkoBuild   = pipelinetest.Prefix("apko", "build")
+	apkoPublish = pipelinetest.Prefix("apko", "publish")
+	trivyScan   = pipelinetest.Prefix("trivy", "image")
+	cosignSign  = pipelinetest.Prefix("cosign", "sign")
+	melange     = pipelinetest.Prefix("melange", "build")
+)
+
+func wolfiRelease(t *testing.T) WolfiReleaseOpts {
+	t.Helper()
+
+	return WolfiReleaseOpts{
+		ConfigFile: "/mnt/apko.yaml",
+		Repository: "registry.example.com/app",
+		Tag:        "v1.2.0",
+		CacheDir:   "/mnt/var/cache/apko",
+		SigningKey: signingx.NewKMSKey("awskms:///alias/release"),
+	}
+}
+
+func TestWolfiRelease(t *testing.T) {
+	p, err := WolfiRelease(wolfiRelease(t))
+	require.NoError(t, err)
+
+	exec := pipelinetest.NewExecutor().
+		On(apkoBuild).
+		On(trivyScan, pipelinetest.Response{Stdout: "no vulnerabilities"}).
+		On(apkoPublish, pipelinetest.Response{Stdout: "registry.example.com/app@sha256:abc"}).
+		On(cosignSign)
+
+	reports, err := Run(context.Background(), p, exec)
+	require.NoError(t, err)
+	assert.Len(t, reports, 4)
+
+	exec.AssertOrder(t, apkoBuild, trivyScan, apkoPublish, cosignSign)
+	exec.AssertMounted(t, apkoBuild, "/mnt/var/cache/apko")
+	exec.AssertMounted(t, apkoPublish, "/mnt/var/cache/apko")
+	exec.AssertAllUsed(t)
+}
+
+func TestWolfiReleaseFailedScanStopsRelease(t *testing.T) {
+	p, err := WolfiRelease(wolfiRelease(t))
+	require.NoError(t, err)
+
+	exec := pipelinetest.NewExecutor().
+		On(apkoBuild).
+		On(trivyScan, pipelinetest.Response{ExitCode: 1, Stderr: "CVE-2024-0001 (CRITICAL)"})
+
+	reports, err := Run(context.Background(), p, exec)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "wolfi-release")
+	assert.Len(t, reports, 2)
+
+	exec.AssertNotRun(t, apkoPublish)
+	exec.AssertNotRun(t, cosignSign)
+}
+
+func TestMelangeImage(t *testing.T) {
+	p, err := MelangeImage(MelangeImageOpts{
+		PackageConfig: "/mnt/hello.yaml",
+		ImageConfig:   "/mnt/apko.yaml",
+		Image:         "registry.example.com/hello",
+		Arch:          "x86_64",
+		SigningKey:    "/mnt/melange.rsa",
+	})
+	require.NoError(t, err)
+
+	exec := pipelinetest.NewExecutor().On(melange).On(apkoBuild)
+
+	_, err = Run(context.Background(), p, exec)
+	require.NoError(t, err)
+
+	exec.AssertOrder(t, melange, apkoBuild)
+	exec.AssertAllUsed(t)
+
+	build := exec.Calls(apkoBuild)
+	require.Len(t, build, 1)
+	assert.Contains(t, build[0].Command, "/mnt/melange.rsa.pub")
+	assert.Contains(t, build[0].Command, "/mnt/packages")
+}
+
+// TestPipelines guards the commands of the reference pipelines: a golden file change is a change
+// of the commands generated through the public APIs.
+func TestPipelines(t *testing.T) {
+	release, err := WolfiRelease(wolfiRelease(t))
+	require.NoError(t, err)
+
+	image, err := MelangeImage(MelangeImageOpts{
+		PackageConfig: "/mnt/hello.yaml",
+		ImageConfig:   "/mnt/apko.yaml",
+		Image:         "registry.example.com/hello",
+		SigningKey:    "/mnt/melange.rsa",
+	})
+	require.NoError(t, err)
+
+	g := goldenx.New(t)
+	for name, p := range map[string]interface{ ExportJSON() ([]byte, error) }{
+		"wolfi-release": release,
+		"melange-image": image,
+	} {
+		data, err := p.ExportJSON()
+		require.NoError(t, err)
+		g.AssertString(name, string(data))
+	}
+}
+
+func TestOptionsErrors(t *testing.T) {
+	opts := wolfiRelease(t)
+	opts.SigningKey = nil
+	_, err := WolfiRelease(opts)
+	assert.ErrorIs(t, err, errorsx.ErrMissingField)
+
+	_, err = WolfiRelease(WolfiReleaseOpts{Repository: "app", Tag: "v1"})
+	assert.ErrorIs(t, err, errorsx.ErrMissingField)
+
+	_, err = MelangeImage(MelangeImageOpts{ImageConfig: "/mnt/apko.yaml", Image: "app", SigningKey: "/mnt/melange.rsa"})
+	assert.ErrorIs(t, err, errorsx.ErrMissingField, "melange requires its config file")
+
+	_, err = MelangeImage(MelangeImageOpts{ImageConfig: "/mnt/apko.yaml", Image: "app"})
+	assert.ErrorIs(t, err, errorsx.ErrMissingField)
+}
@@ -0,0 +1,95 @@
+package examples
+
+import (
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/builderx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/melangex"
+	"github.com/Excoriate/daggerx/pkg/pipeline"
+)
+
+// MelangeImageOpts configures the MelangeImage pipeline.
+type MelangeImageOpts struct {
+	// PackageConfig is the path of the melange configuration in the container.
+	PackageConfig string
+	// ImageConfig is the path of the apko configuration installing the package.
+	ImageConfig string
+	// Image is the repository of the built image, tagged "latest".
+	Image string
+	// Arch is the architecture the package and the image are built for, e.g. "x86_64".
+	Arch string
+	// SigningKey is the path of the private key melange signs the package with. apko verifies the
+	// package with the public key melange writes next to it, at SigningKey + ".pub".
+	SigningKey string
+	// Tarball is the path the image tarball is built to. It defaults to apkox.GetOutputTarPath
+	// under fixtures.MntPrefix.
+	Tarball string
+}
+
+// MelangeImage returns the pipeline building an image from a package built in the same run:
+//   - package: melange builds and signs the package into melangex.GetOutputDir.
+//   - image: apko builds the image, with the melange output directory as a build repository and
+//     the public signing key as a keyring, next to the Wolfi keyring verifying the base packages.
+//
+// Returns:
+//   - The pipeline.
+//   - An error if an option is missing, or a builder fails to generate its command.
+func MelangeImage(opts MelangeImageOpts) (*pipeline.Pipeline, error) {
+	if opts.ImageConfig == "" {
+		return nil, errorsx.MissingField(builderName, "imageConfig", "apko config file is required")
+	}
+
+	if opts.Image == "" {
+		return nil, errorsx.MissingField(builderName, "image", "image reference is required")
+	}
+
+	if opts.SigningKey == "" {
+		return nil, errorsx.MissingField(builderName, "signingKey", "signing key is required")
+	}
+
+	tarball := opts.Tarball
+	if tarball == "" {
+		tarball = apkox.GetOutputTarPath(fixtures.MntPrefix)
+	}
+
+	outDir := melangex.GetOutputDir("")
+
+	pkg := melangex.NewMelangeBuilder().
+		WithConfigFile(opts.PackageConfig).
+		WithOutDir(outDir).
+		WithSigningKey(opts.SigningKey)
+	if opts.Arch != "" {
+		pkg = pkg.WithArch(opts.Arch)
+	}
+
+	image := apkox.NewApkoBuilder().
+		WithConfigFile(opts.ImageConfig).
+		WithOutputImage(opts.Image).
+		WithOutputTarball(tarball).
+		WithKeyRingWolfi().
+		WithKeyring(opts.SigningKey + ".pub").
+		WithBuildRepositoryAppend(outDir)
+	if opts.Arch != "" {
+		image = image.WithBuildArch(apkox.Architecture(opts.Arch))
+	}
+
+	pkgNode, err := builderx.Node("package", pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	imageNode, err := builderx.Node("image", image, "package")
+	if err != nil {
+		return nil, err
+	}
+
+	p := pipeline.New("melange-image")
+	for _, node := range []pipeline.Node{pkgNode, imageNode} {
+		if err := p.AddNode(node); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
@@ -0,0 +1,47 @@
+package examples
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Excoriate/daggerx/pkg/execx"
+	"github.com/Excoriate/daggerx/pkg/pipeline"
+	"github.com/Excoriate/daggerx/pkg/planx"
+)
+
+// Run executes the pipeline 'p' with 'executor', one stage at a time (see pipeline.Stages): the
+// nodes of a stage run concurrently as a fail-fast plan, and the first failing stage stops the
+// pipeline, so no node runs before the nodes it depends on succeeded.
+//
+// Returns:
+//   - The reports of the executed stages, in order.
+//   - An error if the pipeline is invalid, or a stage fails.
+func Run(ctx context.Context, p *pipeline.Pipeline, executor execx.Executor) ([]*planx.Report, error) {
+	stages, err := p.Stages()
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []*planx.Report
+	for i, stage := range stages {
+		plan := planx.NewPlan(fmt.Sprintf("%s-stage-%d", p.Name(), i+1)).WithFailFast(true)
+
+		for _, id := range stage {
+			node, _ := p.Node(id)
+			if err := plan.AddTask(planx.Task{ID: node.ID, Command: node.Command, Env: node.Env, Mounts: node.Mounts}); err != nil {
+				return reports, err
+			}
+		}
+
+		report, err := plan.Execute(ctx, executor)
+		if report != nil {
+			reports = append(reports, report)
+		}
+
+		if err != nil {
+			return reports, fmt.Errorf("pipeline %s: %w", p.Name(), err)
+		}
+	}
+
+	return reports, nil
+}
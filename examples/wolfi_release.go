@@ -0,0 +1,124 @@
+package examples
+
+import (
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/builderx"
+	"github.com/Excoriate/daggerx/pkg/cosignx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/pipeline"
+	"github.com/Excoriate/daggerx/pkg/signingx"
+	"github.com/Excoriate/daggerx/pkg/trivyx"
+)
+
+// builderName identifies the reference pipelines in errorsx errors.
+const builderName = "examples"
+
+// WolfiReleaseOpts configures the WolfiRelease pipeline.
+type WolfiReleaseOpts struct {
+	// ConfigFile is the path of the apko configuration in the container.
+	ConfigFile string
+	// Repository is the repository the release is published to, e.g. "registry.example.com/app".
+	Repository string
+	// Tag is the tag of the release, e.g. "v1.2.0".
+	Tag string
+	// Tarball is the path the image tarball is built to and scanned from. It defaults to
+	// apkox.GetOutputTarPath under fixtures.MntPrefix.
+	Tarball string
+	// CacheDir is the apko cache directory, mounted as a cache volume. It may be empty.
+	CacheDir string
+	// Severities holds the vulnerability severities failing the scan. It defaults to HIGH and
+	// CRITICAL.
+	Severities []string
+	// SigningKey is the key the published image is signed with.
+	SigningKey *signingx.KeyProvider
+}
+
+// WolfiRelease returns the pipeline releasing a Wolfi-based image:
+//   - build: apko builds the image tarball with its SBOM, verifying packages with the Wolfi keyring.
+//   - scan: trivy scans the tarball, and fails on findings of the configured severities.
+//   - publish: apko publishes the image once the scan passed.
+//   - sign: cosign signs the published image. Signing follows publishing because cosign signs
+//     images in a registry, never tarballs.
+//
+// Returns:
+//   - The release pipeline.
+//   - An error if an option is missing, or a builder fails to generate its command.
+func WolfiRelease(opts WolfiReleaseOpts) (*pipeline.Pipeline, error) {
+	if opts.ConfigFile == "" {
+		return nil, errorsx.MissingField(builderName, "configFile", "apko config file is required")
+	}
+
+	if opts.Repository == "" {
+		return nil, errorsx.MissingField(builderName, "repository", "image repository is required")
+	}
+
+	if opts.Tag == "" {
+		return nil, errorsx.MissingField(builderName, "tag", "release tag is required")
+	}
+
+	if opts.SigningKey == nil {
+		return nil, errorsx.MissingField(builderName, "signingKey", "signing key is required")
+	}
+
+	tarball := opts.Tarball
+	if tarball == "" {
+		tarball = apkox.GetOutputTarPath(fixtures.MntPrefix)
+	}
+
+	severities := opts.Severities
+	if len(severities) == 0 {
+		severities = []string{"HIGH", "CRITICAL"}
+	}
+
+	image := apkox.NewApkoBuilder().
+		WithConfigFile(opts.ConfigFile).
+		WithOutputImage(opts.Repository).
+		WithTag(opts.Tag).
+		WithOutputTarball(tarball).
+		WithKeyRingWolfi().
+		WithSBOM(true)
+	if opts.CacheDir != "" {
+		image = image.WithCacheDir(opts.CacheDir)
+	}
+
+	build, err := builderx.Node("build", image)
+	if err != nil {
+		return nil, err
+	}
+	build.Mounts = append(build.Mounts, image.CacheMount()...)
+
+	scan, err := builderx.Node("scan", trivyx.NewTrivyBuilder(tarball).
+		WithSeverity(severities...).
+		WithExtraArg("--exit-code=1").
+		WithExtraArg("--input"), "build")
+	if err != nil {
+		return nil, err
+	}
+
+	publishCmd, err := image.PublishCommand()
+	if err != nil {
+		return nil, err
+	}
+	publish := pipeline.Node{
+		ID:        "publish",
+		Command:   publishCmd,
+		Env:       image.Env(),
+		Mounts:    append(image.Mounts(), image.CacheMount()...),
+		DependsOn: []string{"scan"},
+	}
+
+	sign, err := builderx.Node("sign", cosignx.NewSignBuilder(opts.Repository+":"+opts.Tag).WithKeyProvider(opts.SigningKey), "publish")
+	if err != nil {
+		return nil, err
+	}
+
+	p := pipeline.New("wolfi-release")
+	for _, node := range []pipeline.Node{build, scan, publish, sign} {
+		if err := p.AddNode(node); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
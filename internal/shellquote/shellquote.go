@@ -0,0 +1,43 @@
+// Package shellquote quotes arguments for POSIX sh. It is the single implementation behind
+// cmdx.ShellQuote and cmdx.ShellJoin, shared with the packages cmdx itself depends on, such as
+// types.
+package shellquote
+
+import "strings"
+
+// Quote quotes the argument 's' for sh: arguments made of letters, digits and the characters
+// "/.-_=:" are returned as is, any other argument is single-quoted, each of its single quotes
+// closing the quoted string, escaped, and reopening it. The quoted argument is never expanded by
+// sh.
+func Quote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool { return !safe(r) }) < 0 {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Join quotes the arguments 'args' with Quote and joins them with spaces into a sh command line.
+// A first argument holding '=' is always quoted, as sh reads it as a variable assignment
+// otherwise.
+func Join(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = Quote(arg)
+		if i == 0 && quoted[i] == arg && strings.Contains(arg, "=") {
+			quoted[i] = "'" + arg + "'"
+		}
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// safe reports whether the rune 'r' is left unquoted by Quote.
+func safe(r rune) bool {
+	switch r {
+	case '/', '.', '-', '_', '=', ':':
+		return true
+	}
+
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
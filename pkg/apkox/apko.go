@@ -2,40 +2,44 @@
 // APKO is a tool designed to create OCI (Open Container Initiative) images using Alpine Linux packages. This package
 // offers a high-level interface to configure, build, and manage these images efficiently.
 //
-// The builderx package includes support for various CPU architectures, configuration management, and keyring handling
+// The apkox package includes support for various CPU architectures, configuration management, and keyring handling
 // for package verification. It is designed to be used in a variety of build environments and supports customization
 // through additional arguments and caching mechanisms.
 //
 // Key Features:
 // - Support for multiple CPU architectures (x86_64, aarch64, armv7, ppc64le, s390x).
-// - Configuration management for APKO builds.
+// - Configuration management for APKO builds, including the resolution of include/extends chains.
 // - Keyring handling for package verification.
-// - Caching mechanisms to optimize build processes.
+// - Preflight checks of the repositories and keyrings before the container build starts.
+// - Hardening of configurations against a minimum checklist (non-root user, no shell, no package manager).
+// - Machine-readable build metadata files written next to the output tarball.
+// - Guards against shell metacharacters in user-supplied values for commands run by `sh -c`.
+// - Per-architecture tags (e.g. "1.2.3-arm64"), keeping the tag itself for the manifest list.
+// - Caching mechanisms to optimize build processes, recording in the build metadata whether caches were warm.
+// - Explicit opt-in of development-only insecure options, always warned about and refused for production builds.
 // - High-level interface for building and managing APKO images.
 //
 // Example usage:
 //
 //	import (
-//	    "github.com/Excoriate/daggerx/pkg/builderx"
+//	    "github.com/Excoriate/daggerx/pkg/apkox"
 //	)
 //
 //	func main() {
-//	    builder := builderx.ApkoBuilder{
-//	        configFile:   "path/to/config.yaml",
-//	        outputImage:  "myimage:latest",
-//	        outputTarball: "path/to/output.tar",
-//	        keyringPaths: []string{
-//	            builderx.ApkoWolfiSigninRsaKeyPath,
-//	            builderx.ApkoAlpineSigninRsaKeyPath,
-//	        },
-//	        cacheDir:  "/path/to/cache",
-//	        extraArgs: []string{"--some-flag"},
-//	    }
+//	    builder := apkox.NewApkoBuilder().
+//	        WithConfigFile("path/to/config.yaml").
+//	        WithOutputImage("myimage").
+//	        WithTag("latest").
+//	        WithOutputTarball("path/to/output.tar").
+//	        WithKeyRingWolfi().
+//	        WithKeyRingAlpine().
+//	        WithCacheDir("/path/to/cache").
+//	        WithExtraArg("--some-flag")
 //
-//	    // Use the builder to create an APKO image
-//	    err := builder.Build()
+//	    // Generate the command building the APKO image
+//	    cmd, err := builder.BuildCommand()
 //	    if err != nil {
-//	        fmt.Println("Error building APKO image:", err)
+//	        fmt.Println("Error generating the APKO command:", err)
 //	    }
 //	}
 //
@@ -43,10 +47,19 @@
 package apkox
 
 import (
-	"fmt"
+	"context"
+	"log/slog"
+	"path"
 	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
 	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/mapx"
+	"github.com/Excoriate/daggerx/pkg/types"
 )
 
 // Architecture represents supported CPU architectures for APKO builds
@@ -71,6 +84,14 @@ const (
 	ApkoAlpineSigninRsaKeyPath = "/etc/apk/keys/alpine-devel@lists.alpinelinux.org-4a6a0840.rsa.pub"
 )
 
+// builderName identifies the APKO builder in errorsx errors.
+const builderName = "apko"
+
+// maxSingleFlagArgs is the number of arguments of the flags BuildCommand emits at most once
+// (--cache-dir, --arch, --layering-*, --sbom*, --vcs, --offline, --log-level, --build-date and
+// --lockfile, with their values, and --image-refs for publish commands).
+const maxSingleFlagArgs = 21
+
 // ApkoBuilder represents a builder for APKO (Alpine Package Keeper for OCI) images.
 // It encapsulates all the configuration options and settings needed to build an APKO image.
 type ApkoBuilder struct {
@@ -104,15 +125,22 @@ type ApkoBuilder struct {
 	// buildArch specifies the architecture to build for.
 	buildArch string
 
-	// buildContext is the build context directory.
+	// buildContext is the host directory mounted as the build context.
 	buildContext string
 
+	// buildRepositoryAppend are the repositories appended at build time only, not recorded in
+	// the image.
+	buildRepositoryAppend []string
+
 	// debug enables debug mode for verbose output.
 	debug bool
 
 	// keyringAppendPlaintext is a slice of plaintext keys to append to the keyring.
 	keyringAppendPlaintext []string
 
+	// keyringAppendSecrets are the names of the secrets holding keys to append to the keyring.
+	keyringAppendSecrets []string
+
 	// noNetwork disables network access during the build.
 	noNetwork bool
 
@@ -135,6 +163,78 @@ type ApkoBuilder struct {
 	logLevel      string
 	logPolicy     []string
 	workdir       string
+
+	// inlineConfig is the YAML configuration materialized at configFile, if set.
+	inlineConfig string
+
+	// tagErr is the error of the tag strategy, reported by BuildCommand.
+	tagErr error
+
+	// releaseRegistries are the registries for which "latest" tags are refused.
+	releaseRegistries []string
+
+	// archTagTemplate renders the per-architecture tag of the image. Empty tags the image with
+	// the tag itself.
+	archTagTemplate string
+
+	// layeringStrategy is the strategy used to split the image into layers.
+	layeringStrategy LayerStrategy
+
+	// layeringBudget is the maximum number of additional layers.
+	layeringBudget int
+
+	// extraArgsPolicy decides how extra arguments duplicating typed options are handled.
+	extraArgsPolicy ExtraArgsPolicy
+
+	// shellMode tells whether the command runs through sh -c, and how values are guarded.
+	shellMode ShellMode
+
+	// accounts are merged into the accounts section of the inline configuration.
+	accounts Accounts
+
+	// requireNonRoot requires the configuration to run the image as a user other than root.
+	requireNonRoot bool
+
+	// environment entries are applied to the environment section of the inline configuration.
+	environment []EnvEntry
+
+	// paths are merged into the paths section of the inline configuration.
+	paths []PathEntry
+
+	// certificates are merged into the additional certificates of the inline configuration.
+	certificates []Certificate
+
+	// packageRules are applied to the packages of the inline configuration for buildArch.
+	packageRules []PackageRule
+
+	// apkoVersion is the apko release the command targets, checked against Capabilities.
+	apkoVersion string
+
+	// imageRefs is the file apko publish writes the pushed references to.
+	imageRefs string
+
+	// buildInfoPath is the file BuildCommandWithBuildInfo writes the build metadata to. Empty
+	// writes it next to the output tarball.
+	buildInfoPath string
+
+	// cacheProvenance records the caches of the build, and whether they were warm, in the build
+	// metadata.
+	cacheProvenance bool
+
+	// insecure holds the development-only options weakening the supply chain guarantees.
+	insecure InsecureOptions
+
+	// production refuses the insecure options.
+	production bool
+
+	// warnings are the warnings of the options, reported by Warnings.
+	warnings []errorsx.Warning
+
+	// strict turns the warnings into errors of BuildCommand.
+	strict bool
+
+	// logger receives the generated commands and validation warnings. It may be nil.
+	logger *slog.Logger
 }
 
 // WithBuildArch sets the build architecture for the APKO build.
@@ -184,7 +284,7 @@ func (b *ApkoBuilder) WithKeyring(keyringPath string) *ApkoBuilder {
 }
 
 // WithWolfiKeyring adds the Wolfi keyring to the APKO build.
-// It sets the wolfiKeyring field to true.
+// It sets the wolfiKeyring field to true; the Wolfi signing key is appended with --keyring-append.
 // It returns the updated ApkoBuilder instance.
 func (b *ApkoBuilder) WithWolfiKeyring() *ApkoBuilder {
 	b.wolfiKeyring = true
@@ -192,7 +292,7 @@ func (b *ApkoBuilder) WithWolfiKeyring() *ApkoBuilder {
 }
 
 // WithAlpineKeyring adds the Alpine keyring to the APKO build.
-// It sets the alpineKeyring field to true.
+// It sets the alpineKeyring field to true; the Alpine signing key is appended with --keyring-append.
 // It returns the updated ApkoBuilder instance.
 func (b *ApkoBuilder) WithAlpineKeyring() *ApkoBuilder {
 	b.alpineKeyring = true
@@ -202,7 +302,10 @@ func (b *ApkoBuilder) WithAlpineKeyring() *ApkoBuilder {
 // WithArchitecture sets the build architecture for the APKO build.
 // It takes a string parameter 'arch' which is the desired build architecture.
 // It returns the updated ApkoBuilder instance.
+//
+// Deprecated: use WithBuildArch. The use of WithArchitecture is reported by Warnings.
 func (b *ApkoBuilder) WithArchitecture(arch string) *ApkoBuilder {
+	b.deprecate("WithArchitecture", "WithBuildArch")
 	b.buildArch = arch
 	return b
 }
@@ -223,26 +326,56 @@ func (b *ApkoBuilder) WithExtraArg(arg string) *ApkoBuilder {
 	return b
 }
 
-// WithBuildContext sets the build context directory
+// WithBuildContext sets the host directory mounted as the build context, at the working
+// directory (see WithWorkdir) or fixtures.MntPrefix; Mounts returns the directory mount.
+//
+// The build context used to be passed to --build-repository-append as well. Build repositories
+// are now set with WithBuildRepositoryAppend; until then, BuildCommand logs a deprecation
+// warning, also reported by Warnings, when a build context is set without build repositories.
 func (b *ApkoBuilder) WithBuildContext(dir string) *ApkoBuilder {
 	b.buildContext = dir
 	return b
 }
 
-// WithDebug enables debug output
+// WithBuildRepositoryAppend appends a repository used at build time only (e.g. a local
+// repository of melange packages): apko installs packages from it without recording it in the
+// image's /etc/apk/repositories.
+func (b *ApkoBuilder) WithBuildRepositoryAppend(repo string) *ApkoBuilder {
+	b.buildRepositoryAppend = append(b.buildRepositoryAppend, repo)
+	return b
+}
+
+// buildContextTarget returns the path the build context is mounted at.
+func (b *ApkoBuilder) buildContextTarget() string {
+	if b.workdir != "" {
+		return b.workdir
+	}
+
+	return fixtures.MntPrefix
+}
+
+// WithDebug enables debug output, emitted as --log-level debug.
+//
+// Deprecated: use WithLogLevel("debug"). The use of WithDebug is reported by Warnings.
 func (b *ApkoBuilder) WithDebug() *ApkoBuilder {
+	b.deprecate("WithDebug", "WithLogLevel")
 	b.debug = true
 	return b
 }
 
-// WithKeyringAppendPlaintext appends a plaintext keyring
+// WithKeyringAppendPlaintext appends a plaintext keyring. The key is materialized as a file (see
+// Mounts) appended with --keyring-append, so it isn't exposed by the command or the process list,
+// and it is redacted from the logged commands.
 func (b *ApkoBuilder) WithKeyringAppendPlaintext(keyring string) *ApkoBuilder {
 	b.keyringAppendPlaintext = append(b.keyringAppendPlaintext, keyring)
 	return b
 }
 
-// WithNoNetwork disables network access during the build
+// WithNoNetwork disables network access during the build, emitted as --offline.
+//
+// Deprecated: use WithOffline. The use of WithNoNetwork is reported by Warnings.
 func (b *ApkoBuilder) WithNoNetwork() *ApkoBuilder {
+	b.deprecate("WithNoNetwork", "WithOffline")
 	b.noNetwork = true
 	return b
 }
@@ -253,33 +386,38 @@ func (b *ApkoBuilder) WithRepositoryAppend(repo string) *ApkoBuilder {
 	return b
 }
 
-// WithTimestamp sets the timestamp for the build
+// WithTimestamp sets the timestamp for the build, emitted as --build-date.
+//
+// Deprecated: use WithBuildDate. The use of WithTimestamp is reported by Warnings.
 func (b *ApkoBuilder) WithTimestamp(timestamp string) *ApkoBuilder {
+	b.deprecate("WithTimestamp", "WithBuildDate")
 	b.timestamp = timestamp
 	return b
 }
 
-// WithTag adds a tag to the APKO build.
+// WithTag adds a tag to the APKO build, replacing a tag computed by WithTagStrategy.
 // If no tag is provided, it defaults to "latest".
 // It returns the updated ApkoBuilder instance.
 func (b *ApkoBuilder) WithTag(tag string) *ApkoBuilder {
 	b.tag = tag
+	b.tagErr = nil
 	return b
 }
 
-// WithAnnotations adds OCI annotations to the APKO build.
+// WithAnnotations adds OCI annotations to the APKO build, emitted as --annotations "key:value" in
+// key order. Keys holding ':' or ',', and values holding ',', fail BuildCommand.
 func (b *ApkoBuilder) WithAnnotations(annotations map[string]string) *ApkoBuilder {
 	b.annotations = annotations
 	return b
 }
 
-// WithBuildDate sets the build date for the APKO build.
+// WithBuildDate sets the build date for the APKO build, emitted with --build-date.
 func (b *ApkoBuilder) WithBuildDate(date string) *ApkoBuilder {
 	b.buildDate = date
 	return b
 }
 
-// WithLockfile sets the lockfile path for the APKO build.
+// WithLockfile sets the lockfile path for the APKO build, emitted with --lockfile.
 func (b *ApkoBuilder) WithLockfile(path string) *ApkoBuilder {
 	b.lockfile = path
 	return b
@@ -291,7 +429,9 @@ func (b *ApkoBuilder) WithOffline() *ApkoBuilder {
 	return b
 }
 
-// WithPackageAppend adds extra packages to the APKO build.
+// WithPackageAppend adds extra packages to the APKO build, optionally with a version constraint
+// (e.g. "curl=8.5.0-r0", "curl>=8.5"), emitted normalized with --package-append. Malformed
+// constraints fail BuildCommand (see ParsePackageConstraint).
 func (b *ApkoBuilder) WithPackageAppend(packages ...string) *ApkoBuilder {
 	b.packageAppend = append(b.packageAppend, packages...)
 	return b
@@ -303,7 +443,8 @@ func (b *ApkoBuilder) WithSBOM(enable bool) *ApkoBuilder {
 	return b
 }
 
-// WithSBOMFormats sets the SBOM formats for the APKO build.
+// WithSBOMFormats sets the SBOM formats for the APKO build, emitted with --sbom-formats when
+// SBOM generation is enabled; otherwise, the formats are ignored with a warning.
 func (b *ApkoBuilder) WithSBOMFormats(formats ...string) *ApkoBuilder {
 	b.sbomFormats = formats
 	return b
@@ -327,7 +468,7 @@ func (b *ApkoBuilder) WithLogLevel(level string) *ApkoBuilder {
 	return b
 }
 
-// WithLogPolicy sets the log policy for the APKO build.
+// WithLogPolicy sets the log policy for the APKO build, emitted with --log-policy.
 func (b *ApkoBuilder) WithLogPolicy(policies ...string) *ApkoBuilder {
 	b.logPolicy = policies
 	return b
@@ -339,70 +480,321 @@ func (b *ApkoBuilder) WithWorkdir(dir string) *ApkoBuilder {
 	return b
 }
 
+// WithLogger sets the logger receiving the generated commands, validation warnings and timing.
+func (b *ApkoBuilder) WithLogger(logger *slog.Logger) *ApkoBuilder {
+	b.logger = logger
+	return b
+}
+
 // BuildCommand generates the APKO build command based on the current configuration of the ApkoBuilder.
 // It returns a slice of strings representing the command and an error if any required fields are missing.
+// In strict mode (see WithStrict), the warnings of the configuration are returned as an error.
+func (b *ApkoBuilder) BuildCommand() ([]string, error) {
+	return b.command(false)
+}
+
+// command generates the apko build command, or the apko publish command when 'publish' is set,
+// which pushes the image instead of writing the output tarball.
+func (b *ApkoBuilder) command(publish bool) ([]string, error) {
+	cmd, _, err := b.generate(publish)
+	return cmd, err
+}
+
+// generate generates the command as command does, and collects the warnings of the
+// configuration: those of the options, then those of the generation. In strict mode, the
+// warnings are returned as an error.
 //
 //nolint:funlen // TODO: Refactor this function to make it more readable
-func (b *ApkoBuilder) BuildCommand() ([]string, error) {
+func (b *ApkoBuilder) generate(publish bool) ([]string, []errorsx.Warning, error) {
+	ctx := context.Background()
+	subcommand := "build"
+	if publish {
+		subcommand = "publish"
+	}
+	defer logx.Timer(ctx, b.logger, builderName, subcommand+" command")()
+
 	if b.configFile == "" {
-		return nil, fmt.Errorf("config file is required")
+		return nil, nil, errorsx.MissingField(builderName, "configFile", "config file is required")
 	}
 
 	if b.outputImage == "" {
-		return nil, fmt.Errorf("output image name is required")
+		return nil, nil, errorsx.MissingField(builderName, "outputImage", "output image name is required")
 	}
 
-	if b.outputTarball == "" {
-		return nil, fmt.Errorf("output tarball path is required")
+	if !publish && b.outputTarball == "" {
+		return nil, nil, errorsx.MissingField(builderName, "outputTarball", "output tarball path is required")
 	}
 
-	// Default tag if not set
+	if err := b.validateInlineConfig(); err != nil {
+		return nil, nil, err
+	}
+
+	packages, err := b.validatePackages()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := b.validateKeyringFiles(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := b.validateAnnotations(); err != nil {
+		return nil, nil, err
+	}
+
+	logLevel, err := b.effectiveLogLevel()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	buildDate, err := b.effectiveBuildDate()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	configFile, _, err := b.effectiveConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := b.validateLayering(); err != nil {
+		return nil, nil, err
+	}
+
+	if b.tagErr != nil {
+		return nil, nil, b.tagErr
+	}
+
+	warnings := slices.Clone(b.warnings)
+
+	// Default tag if not set. The builder is left unchanged, so concurrent calls don't race.
 	if b.tag == "" {
-		b.tag = "latest"
+		logx.Warn(ctx, b.logger, builderName, "no tag set, defaulting to latest", "image", b.outputImage)
+		warnings = append(warnings, errorsx.NewWarning(builderName, "tag",
+			"no tag set for %s, defaulting to latest", b.outputImage))
 	}
+	tag := b.effectiveTag()
 
-	// Start with base command
-	cmd := []string{"apko", "build"}
+	if err := b.validateReleaseTag(tag); err != nil {
+		return nil, nil, err
+	}
+
+	tag, err = b.archTag(tag)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	// Add all flags before positional arguments
+	if err := b.validateShellMode(); err != nil {
+		return nil, nil, err
+	}
+
+	insecure, err := b.validateInsecure(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings = append(warnings, insecure...)
+
+	// Collect the flags emitted by typed options; they come before positional arguments. The
+	// slice is sized for the repeated flags and the single ones, so matrix builds generating
+	// thousands of commands don't regrow it.
+	keyrings := b.keyrings()
+	keyringFiles := b.keyringFileFlags()
+	flags := make([]string, 0,
+		2*(len(keyrings)+len(b.buildRepositoryAppend)+len(b.repositoryAppend)+len(packages)+
+			len(b.sbomFormats)+len(b.logPolicy)+len(b.annotations))+len(keyringFiles)+maxSingleFlagArgs)
 	if b.cacheDir != "" {
-		cmd = append(cmd, "--cache-dir", b.cacheDir)
+		flags = append(flags, "--cache-dir", b.cacheDir)
 	}
 
-	for _, k := range b.keyringPaths {
-		cmd = append(cmd, "--keyring-append", k)
+	for _, k := range keyrings {
+		flags = append(flags, "--keyring-append", k)
 	}
+	flags = append(flags, keyringFiles...)
 
 	if b.buildArch != "" {
-		cmd = append(cmd, "--arch", b.buildArch)
+		flags = append(flags, "--arch", b.buildArch)
+	}
+
+	if b.buildContext != "" && len(b.buildRepositoryAppend) == 0 {
+		logx.Warn(ctx, b.logger, builderName,
+			"the build context is only mounted and no longer adds --build-repository-append, "+
+				"use WithBuildRepositoryAppend", "buildContext", b.buildContext)
+		warnings = append(warnings, errorsx.Warning{
+			Builder:     builderName,
+			Field:       "WithBuildContext",
+			Message:     "the build context no longer adds --build-repository-append, use WithBuildRepositoryAppend",
+			Deprecated:  true,
+			Replacement: "WithBuildRepositoryAppend",
+		})
 	}
 
-	if b.buildContext != "" {
-		cmd = append(cmd, "--build-repository-append", b.buildContext)
+	for _, r := range b.buildRepositoryAppend {
+		flags = append(flags, "--build-repository-append", r)
+	}
+
+	for _, r := range b.repositoryAppend {
+		flags = append(flags, "--repository-append", r)
+	}
+
+	for _, p := range packages {
+		flags = append(flags, "--package-append", p)
+	}
+
+	flags = append(flags, b.layeringFlags()...)
+
+	if b.sbom && b.sbomPath != "" {
+		flags = append(flags, "--sbom-path", b.sbomPath)
+	}
+
+	if b.sbom {
+		for _, f := range b.sbomFormats {
+			flags = append(flags, "--sbom-formats", f)
+		}
+	} else if len(b.sbomFormats) > 0 {
+		logx.Warn(ctx, b.logger, builderName, "SBOM generation is disabled, ignoring the SBOM formats",
+			"sbomFormats", b.sbomFormats)
+		warnings = append(warnings, errorsx.NewWarning(builderName, "sbomFormats",
+			"SBOM generation is disabled, ignoring the SBOM formats %v", b.sbomFormats))
 	}
 
 	// Add other flags
 	if !b.sbom {
-		cmd = append(cmd, "--sbom=false")
+		flags = append(flags, "--sbom=false")
 	}
 
 	if !b.vcs {
-		cmd = append(cmd, "--vcs=false")
+		flags = append(flags, "--vcs=false")
 	}
 
-	// Add all other flags...
+	if b.offline || b.noNetwork {
+		flags = append(flags, "--offline")
+	}
+
+	if b.insecure.IgnoreSignatures {
+		flags = append(flags, ignoreSignaturesFlag)
+	}
+
+	if logLevel != "" {
+		flags = append(flags, "--log-level", logLevel)
+	}
 
-	// Add the three required positional arguments last:
+	for _, p := range b.logPolicy {
+		flags = append(flags, "--log-policy", p)
+	}
+
+	if buildDate != "" {
+		flags = append(flags, "--build-date", buildDate)
+	}
+
+	if b.lockfile != "" {
+		flags = append(flags, "--lockfile", b.lockfile)
+	}
+
+	flags = append(flags, mapx.Flags("--annotations", b.annotations, ":")...)
+
+	if publish && b.imageRefs != "" {
+		flags = append(flags, "--image-refs", b.imageRefs)
+	}
+
+	if err := b.validateCapabilities(flags); err != nil {
+		return nil, nil, err
+	}
+
+	flags, extraArgs, duplicates, err := b.resolveExtraArgs(ctx, flags)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings = append(warnings, duplicates...)
+
+	if b.strict && len(warnings) > 0 {
+		return nil, warnings, errorsx.Strict(warnings)
+	}
+
+	// Start with base command
+	cmd := make([]string, 0, 2+len(flags)+3+len(extraArgs))
+	cmd = append(cmd, "apko", subcommand)
+	cmd = append(cmd, flags...)
+
+	// Add the required positional arguments last:
 	// 1. config file
 	// 2. image reference with tag
-	// 3. output path
-	imageRef := fmt.Sprintf("%s:%s", b.outputImage, b.tag)
-	cmd = append(cmd, b.configFile, imageRef, b.outputTarball)
+	// 3. output path, for builds only
+	cmd = append(cmd, configFile, b.outputImage+":"+tag)
+	if !publish {
+		cmd = append(cmd, b.outputTarball)
+	}
 
 	// Add any extra arguments at the very end
-	cmd = append(cmd, b.extraArgs...)
+	cmd = append(cmd, extraArgs...)
 
-	return cmd, nil
+	if err := b.validateShellSafety(cmd, tag); err != nil {
+		return nil, nil, err
+	}
+
+	cmd = b.shellWrap(cmd)
+
+	logx.Command(ctx, b.logger, builderName, cmd, b.keyringAppendPlaintext...)
+
+	return cmd, warnings, nil
+}
+
+// keyrings returns the keyrings appended with --keyring-append: those of WithKeyring, then the
+// signing keys of the presets enabled by WithWolfiKeyring and WithAlpineKeyring, without
+// duplicates.
+func (b *ApkoBuilder) keyrings() []string {
+	keyrings := slices.Clone(b.keyringPaths)
+	if b.wolfiKeyring {
+		keyrings = append(keyrings, ApkoWolfiSigninRsaKeyPath)
+	}
+
+	if b.alpineKeyring {
+		keyrings = append(keyrings, ApkoAlpineSigninRsaKeyPath)
+	}
+
+	return dedupe(keyrings)
+}
+
+// effectiveLogLevel returns the log level emitted with --log-level: that of WithLogLevel, or
+// "debug" for the deprecated WithDebug.
+func (b *ApkoBuilder) effectiveLogLevel() (string, error) {
+	if !b.debug {
+		return b.logLevel, nil
+	}
+
+	if b.logLevel != "" && b.logLevel != "debug" {
+		return "", errorsx.ConflictingOptions(builderName, []string{"debug", "logLevel"},
+			"debug output conflicts with log level %q", b.logLevel)
+	}
+
+	return "debug", nil
+}
+
+// effectiveBuildDate returns the build date emitted with --build-date: that of WithBuildDate, or
+// the timestamp of the deprecated WithTimestamp.
+func (b *ApkoBuilder) effectiveBuildDate() (string, error) {
+	if b.timestamp == "" {
+		return b.buildDate, nil
+	}
+
+	if b.buildDate != "" && b.buildDate != b.timestamp {
+		return "", errorsx.ConflictingOptions(builderName, []string{"timestamp", "buildDate"},
+			"timestamp %q conflicts with build date %q", b.timestamp, b.buildDate)
+	}
+
+	return b.timestamp, nil
+}
+
+// validateAnnotations checks that the annotations can be passed to --annotations, which splits
+// its values on ',' and each of them on the first ':'.
+func (b *ApkoBuilder) validateAnnotations() error {
+	for _, k := range mapx.SortedKeys(b.annotations) {
+		if k == "" || strings.ContainsAny(k, ":,") || strings.Contains(b.annotations[k], ",") {
+			return errorsx.InvalidValue(builderName, "annotations", k+":"+b.annotations[k],
+				"invalid annotation %q: keys cannot be empty or hold ':' or ',', values cannot hold ','", k)
+		}
+	}
+
+	return nil
 }
 
 // GetKeyringInfoForPreset returns the keyring information based on the preset.
@@ -421,7 +813,7 @@ func GetKeyringInfoForPreset(preset string) (KeyringInfo, error) {
 			KeyPath: "/etc/apk/keys/wolfi-signing.rsa.pub",
 		}, nil
 	default:
-		return KeyringInfo{}, fmt.Errorf("unsupported preset: %s", preset)
+		return KeyringInfo{}, errorsx.Unsupported(builderName, "preset", preset, "unsupported preset: %s", preset)
 	}
 }
 
@@ -433,33 +825,38 @@ func GetCacheDir(mntPrefix string) string {
 		mntPrefix = fixtures.MntPrefix
 	}
 
-	return filepath.Join(mntPrefix, "var", "cache", "apko")
+	return path.Join(mntPrefix, "var", "cache", "apko")
 }
 
 // GetApkoConfigOrPreset returns the configuration file path if it is valid.
-// It takes two string parameters: 'mntPrefix' which is the mount prefix, and 'cfgFile' which is the configuration file path.
+// It takes two string parameters: 'mntPrefix' which is the mount prefix, and 'cfgFile' which is
+// either a configuration file path or a preset name ("wolfi-base", "alpine-base", "static").
 // If 'mntPrefix' is empty, it defaults to fixtures.MntPrefix.
+// For presets, it returns the path under 'mntPrefix' where the preset configuration must be
+// materialized; use ResolveApkoConfigOrPreset to also get its content.
 // If 'cfgFile' is empty, it returns an error indicating that the config file is required.
 // If 'cfgFile' does not have an extension, it returns an error indicating that the config file must have an extension.
 // If 'cfgFile' does not have a .yaml or .yml extension, it returns an error indicating that the config file must have a .yaml or .yml extension.
 // It returns the configuration file path if all checks pass, otherwise it returns an error.
 func GetApkoConfigOrPreset(mntPrefix, cfgFile string) (string, error) {
-	if mntPrefix == "" {
-		mntPrefix = fixtures.MntPrefix
-	}
+	path, _, err := ResolveApkoConfigOrPreset(mntPrefix, cfgFile)
+	return path, err
+}
 
+// validateConfigFilePath checks that 'cfgFile' is a YAML configuration file path.
+func validateConfigFilePath(cfgFile string) (string, error) {
 	if cfgFile == "" {
-		return "", fmt.Errorf("config file is required")
+		return "", errorsx.MissingField(builderName, "configFile", "config file is required")
 	}
 
 	ext := filepath.Ext(cfgFile)
 	if ext == "" {
-		return "", fmt.Errorf("config file must have an extension")
+		return "", errorsx.InvalidValue(builderName, "configFile", cfgFile, "config file must have an extension")
 	}
 
 	// Check if the file extension is .yaml or .yml
 	if ext != ".yaml" && ext != ".yml" {
-		return "", fmt.Errorf("config file must have a .yaml or .yml extension")
+		return "", errorsx.InvalidValue(builderName, "configFile", cfgFile, "config file must have a .yaml or .yml extension")
 	}
 
 	return cfgFile, nil
@@ -469,7 +866,7 @@ func GetApkoConfigOrPreset(mntPrefix, cfgFile string) (string, error) {
 // It takes a string parameter 'mntPrefix' which is the mount prefix.
 // It returns the full path to the output tar file.
 func GetOutputTarPath(mntPrefix string) string {
-	return filepath.Join(mntPrefix, "image.tar")
+	return path.Join(mntPrefix, "image.tar")
 }
 
 // WithKeyRingWolfi adds the Wolfi keyring to the APKO build.
@@ -477,9 +874,12 @@ func GetOutputTarPath(mntPrefix string) string {
 // Returns the updated ApkoBuilder instance.
 func (b *ApkoBuilder) WithKeyRingWolfi() *ApkoBuilder {
 	wolfiKeyInfo, err := GetKeyringInfoForPreset("wolfi")
-	if err == nil {
-		b.keyringPaths = append(b.keyringPaths, wolfiKeyInfo.KeyPath)
+	if err != nil {
+		b.warnings = append(b.warnings, errorsx.NewWarning(builderName, "keyringPaths",
+			"wolfi keyring not added: %v", err))
+		return b
 	}
+	b.keyringPaths = append(b.keyringPaths, wolfiKeyInfo.KeyPath)
 	return b
 }
 
@@ -488,9 +888,12 @@ func (b *ApkoBuilder) WithKeyRingWolfi() *ApkoBuilder {
 // Returns the updated ApkoBuilder instance.
 func (b *ApkoBuilder) WithKeyRingAlpine() *ApkoBuilder {
 	alpineKeyInfo, err := GetKeyringInfoForPreset("alpine")
-	if err == nil {
-		b.keyringPaths = append(b.keyringPaths, alpineKeyInfo.KeyPath)
+	if err != nil {
+		b.warnings = append(b.warnings, errorsx.NewWarning(builderName, "keyringPaths",
+			"alpine keyring not added: %v", err))
+		return b
 	}
+	b.keyringPaths = append(b.keyringPaths, alpineKeyInfo.KeyPath)
 	return b
 }
 
@@ -499,3 +902,27 @@ type KeyringInfo struct {
 	KeyURL  string
 	KeyPath string
 }
+
+// Validate checks the configuration of the builder as BuildCommand does, strict mode included,
+// without logging the command.
+func (b *ApkoBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, _, err := quiet.generate(false)
+	return err
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *ApkoBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the apko build and publish commands.
+func (b *ApkoBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewApkoBuilder(),
+		explainx.Generate("BuildCommand", b.BuildCommand),
+		explainx.Generate("PublishCommand", b.PublishCommand),
+	)
+}
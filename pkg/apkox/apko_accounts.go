@@ -0,0 +1,275 @@
+package apkox
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+const (
+	// MaxAccountID is the highest UID or GID accepted for accounts. Higher IDs don't map in the
+	// default user namespace of rootless container runtimes, and 65535 is reserved.
+	MaxAccountID = 65534
+	// NonRootID is the UID and GID of the conventional "nonroot" account of distroless images.
+	NonRootID = 65532
+)
+
+// accountNameRegex matches valid user and group names.
+var accountNameRegex = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+// Group is a group of the accounts section of an APKO configuration.
+type Group struct {
+	// GroupName is the name of the group.
+	GroupName string `json:"groupname" yaml:"groupname"`
+	// GID is the ID of the group.
+	GID int `json:"gid" yaml:"gid"`
+	// Members are the names of the users belonging to the group.
+	Members []string `json:"members,omitempty" yaml:"members,omitempty"`
+}
+
+// User is a user of the accounts section of an APKO configuration.
+type User struct {
+	// UserName is the name of the user.
+	UserName string `json:"username" yaml:"username"`
+	// UID is the ID of the user.
+	UID int `json:"uid" yaml:"uid"`
+	// GID is the ID of the primary group of the user.
+	GID int `json:"gid" yaml:"gid"`
+	// Shell is the login shell of the user.
+	Shell string `json:"shell,omitempty" yaml:"shell,omitempty"`
+	// HomeDir is the home directory of the user.
+	HomeDir string `json:"homedir,omitempty" yaml:"homedir,omitempty"`
+}
+
+// Accounts is the accounts section of an APKO configuration.
+type Accounts struct {
+	// Groups are the groups created in the image.
+	Groups []Group `json:"groups,omitempty" yaml:"groups,omitempty"`
+	// Users are the users created in the image.
+	Users []User `json:"users,omitempty" yaml:"users,omitempty"`
+	// RunAs is the user, by name or UID, the image runs as.
+	RunAs string `json:"run-as,omitempty" yaml:"run-as,omitempty"`
+}
+
+// empty reports whether the accounts declare nothing.
+func (a Accounts) empty() bool {
+	return len(a.Groups) == 0 && len(a.Users) == 0 && a.RunAs == ""
+}
+
+// WithGroup declares a group in the accounts section of the configuration, replacing a group of
+// the same name. Account options mutate the inline configuration (see WithInlineConfig).
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithGroup(name string, gid int, members ...string) *ApkoBuilder {
+	b.accounts.Groups = append(b.accounts.Groups, Group{GroupName: name, GID: gid, Members: members})
+	return b
+}
+
+// WithUser declares a user in the accounts section of the configuration, replacing a user of the
+// same name.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithUser(user User) *ApkoBuilder {
+	b.accounts.Users = append(b.accounts.Users, user)
+	return b
+}
+
+// WithRunAs sets the user, by name or UID, the image runs as. Names must be declared by WithUser
+// or by the configuration.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithRunAs(user string) *ApkoBuilder {
+	b.accounts.RunAs = user
+	return b
+}
+
+// WithNonRootUser declares the group and the user 'name' with 'id' as UID and GID, runs the image
+// as 'id' and requires it not to be root (see WithRequireNonRoot). The numeric run-as user lets
+// Kubernetes verify runAsNonRoot.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithNonRootUser(name string, id int) *ApkoBuilder {
+	return b.WithGroup(name, id).
+		WithUser(User{UserName: name, UID: id, GID: id}).
+		WithRunAs(strconv.Itoa(id)).
+		WithRequireNonRoot()
+}
+
+// WithRequireNonRoot makes BuildCommand fail unless the configuration runs the image as a user
+// other than root.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithRequireNonRoot() *ApkoBuilder {
+	b.requireNonRoot = true
+	return b
+}
+
+// MergeAccounts returns the mutation merging 'accounts' into the accounts section of the
+// configuration: groups and users replace those of the same name, and a run-as user replaces the
+// configured one.
+func MergeAccounts(accounts Accounts) ConfigMutation {
+	return func(doc map[string]any) error {
+		if err := accounts.validate(); err != nil {
+			return err
+		}
+
+		var merged Accounts
+		if err := decodeSection(doc, "accounts", &merged); err != nil {
+			return err
+		}
+
+		for _, g := range accounts.Groups {
+			merged.Groups = upsert(merged.Groups, g, func(o Group) bool { return o.GroupName == g.GroupName })
+		}
+
+		for _, u := range accounts.Users {
+			merged.Users = upsert(merged.Users, u, func(o User) bool { return o.UserName == u.UserName })
+		}
+
+		if accounts.RunAs != "" {
+			merged.RunAs = accounts.RunAs
+		}
+
+		if err := merged.validateReferences(); err != nil {
+			return err
+		}
+
+		doc["accounts"] = merged
+
+		return nil
+	}
+}
+
+// RequireNonRoot returns the mutation checking that the configuration runs the image as a user
+// other than root. It doesn't modify the configuration.
+func RequireNonRoot() ConfigMutation {
+	return func(doc map[string]any) error {
+		var accounts Accounts
+		if err := decodeSection(doc, "accounts", &accounts); err != nil {
+			return err
+		}
+
+		uid, err := accounts.runAsUID()
+		if err != nil {
+			return err
+		}
+
+		if uid == 0 {
+			return errorsx.InvalidValue(builderName, "runAs", accounts.RunAs, "image must not run as root")
+		}
+
+		return nil
+	}
+}
+
+// validate checks the names and IDs of the accounts.
+func (a Accounts) validate() error {
+	for _, g := range a.Groups {
+		if !accountNameRegex.MatchString(g.GroupName) {
+			return errorsx.InvalidValue(builderName, "groups", g.GroupName, "invalid group name: %q", g.GroupName)
+		}
+
+		if err := validateAccountID("groups", g.GID); err != nil {
+			return err
+		}
+
+		for _, m := range g.Members {
+			if !accountNameRegex.MatchString(m) {
+				return errorsx.InvalidValue(builderName, "groups", m, "invalid member %q of group %s", m, g.GroupName)
+			}
+		}
+	}
+
+	for _, u := range a.Users {
+		if !accountNameRegex.MatchString(u.UserName) {
+			return errorsx.InvalidValue(builderName, "users", u.UserName, "invalid user name: %q", u.UserName)
+		}
+
+		if err := validateAccountID("users", u.UID); err != nil {
+			return err
+		}
+
+		if err := validateAccountID("users", u.GID); err != nil {
+			return err
+		}
+	}
+
+	if a.RunAs != "" && !accountNameRegex.MatchString(a.RunAs) {
+		id, err := strconv.Atoi(a.RunAs)
+		if err != nil {
+			return errorsx.InvalidValue(builderName, "runAs", a.RunAs, "run-as must be a user name or a UID: %q", a.RunAs)
+		}
+
+		if err := validateAccountID("runAs", id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateReferences checks that users and groups have unique IDs, and that a run-as user name is
+// declared.
+func (a Accounts) validateReferences() error {
+	uids := map[int]string{}
+	for _, u := range a.Users {
+		if other, ok := uids[u.UID]; ok {
+			return errorsx.ConflictingOptions(builderName, []string{"users"},
+				"users %s and %s share UID %d", other, u.UserName, u.UID)
+		}
+		uids[u.UID] = u.UserName
+	}
+
+	gids := map[int]string{}
+	for _, g := range a.Groups {
+		if other, ok := gids[g.GID]; ok {
+			return errorsx.ConflictingOptions(builderName, []string{"groups"},
+				"groups %s and %s share GID %d", other, g.GroupName, g.GID)
+		}
+		gids[g.GID] = g.GroupName
+	}
+
+	if a.RunAs != "" {
+		if _, err := a.runAsUID(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runAsUID returns the UID of the run-as user. Images without a run-as user run as root.
+func (a Accounts) runAsUID() (int, error) {
+	if a.RunAs == "" || a.RunAs == "root" {
+		return 0, nil
+	}
+
+	if id, err := strconv.Atoi(a.RunAs); err == nil {
+		return id, nil
+	}
+
+	for _, u := range a.Users {
+		if u.UserName == a.RunAs {
+			return u.UID, nil
+		}
+	}
+
+	return 0, errorsx.InvalidValue(builderName, "runAs", a.RunAs, "run-as user %s is not declared", a.RunAs)
+}
+
+// validateAccountID checks that 'id' is within the accepted UID and GID range.
+func validateAccountID(field string, id int) error {
+	if id < 0 || id > MaxAccountID {
+		return errorsx.InvalidValue(builderName, field, id, "account ID %d is out of range [0, %d]", id, MaxAccountID)
+	}
+
+	return nil
+}
+
+// upsert replaces the first element of 'items' matching 'match' with 'item', or appends it.
+func upsert[T any](items []T, item T, match func(T) bool) []T {
+	for i := range items {
+		if match(items[i]) {
+			items[i] = item
+			return items
+		}
+	}
+
+	return append(items, item)
+}
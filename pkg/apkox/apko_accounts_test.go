@@ -0,0 +1,145 @@
+package apkox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"gopkg.in/yaml.v3"
+)
+
+// decodeAccounts returns the accounts section of a YAML configuration.
+func decodeAccounts(t *testing.T, config string) Accounts {
+	t.Helper()
+
+	var doc struct {
+		Accounts Accounts `yaml:"accounts"`
+	}
+	if err := yaml.Unmarshal([]byte(config), &doc); err != nil {
+		t.Fatalf("Failed to decode config: %v", err)
+	}
+
+	return doc.Accounts
+}
+
+func TestApkoBuilderWithNonRootUser(t *testing.T) {
+	b := NewApkoBuilder().
+		WithInlineConfig(testInlineConfig).
+		WithOutputImage("my-image").
+		WithTag("v1").
+		WithOutputTarball("output.tar").
+		WithNonRootUser("app", 1000)
+
+	cmd, err := b.BuildCommand()
+	if err != nil {
+		t.Fatalf("BuildCommand() returned unexpected error: %v", err)
+	}
+
+	mounts := b.Mounts()
+	if len(mounts) != 1 {
+		t.Fatalf("Expected one inline mount, got %v", mounts)
+	}
+
+	if mounts[0].Target == GetInlineConfigPath("", testInlineConfig) || cmd[len(cmd)-3] != mounts[0].Target {
+		t.Errorf("Expected the command to use the mutated config at %s, got %v", mounts[0].Target, cmd)
+	}
+
+	if !strings.Contains(mounts[0].Content, "wolfi-base") {
+		t.Errorf("Expected the mutated config to keep the packages, got:\n%s", mounts[0].Content)
+	}
+
+	accounts := decodeAccounts(t, mounts[0].Content)
+	want := Accounts{
+		Groups: []Group{{GroupName: "app", GID: 1000}},
+		Users:  []User{{UserName: "app", UID: 1000, GID: 1000}},
+		RunAs:  "1000",
+	}
+	if !accountsEqual(accounts, want) {
+		t.Errorf("Accounts = %+v, want %+v", accounts, want)
+	}
+}
+
+func TestMergeAccounts(t *testing.T) {
+	preset, err := GetConfigPreset(ConfigPresetStatic)
+	if err != nil {
+		t.Fatalf("GetConfigPreset returned unexpected error: %v", err)
+	}
+
+	out, err := MutateConfig(preset, MergeAccounts(Accounts{
+		Users: []User{{UserName: "nonroot", UID: 65532, GID: 65532, HomeDir: "/home/nonroot"}},
+		Groups: []Group{
+			{GroupName: "app", GID: 1000, Members: []string{"nonroot"}},
+		},
+		RunAs: "nonroot",
+	}), RequireNonRoot())
+	if err != nil {
+		t.Fatalf("MutateConfig returned unexpected error: %v", err)
+	}
+
+	accounts := decodeAccounts(t, string(out))
+	if len(accounts.Users) != 1 || accounts.Users[0].HomeDir != "/home/nonroot" {
+		t.Errorf("Expected the nonroot user to be replaced, got %+v", accounts.Users)
+	}
+
+	if len(accounts.Groups) != 2 || accounts.Groups[1].GroupName != "app" {
+		t.Errorf("Expected the app group to be appended, got %+v", accounts.Groups)
+	}
+
+	if accounts.RunAs != "nonroot" {
+		t.Errorf("RunAs = %s, want nonroot", accounts.RunAs)
+	}
+}
+
+func TestAccountsValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		mutations []ConfigMutation
+		field     string
+		kind      error
+	}{
+		{"UID too high", []ConfigMutation{MergeAccounts(Accounts{Users: []User{{UserName: "app", UID: 70000}}})}, "users", errorsx.ErrInvalidValue},
+		{"Negative GID", []ConfigMutation{MergeAccounts(Accounts{Groups: []Group{{GroupName: "app", GID: -1}}})}, "groups", errorsx.ErrInvalidValue},
+		{"Invalid user name", []ConfigMutation{MergeAccounts(Accounts{Users: []User{{UserName: "App User"}}})}, "users", errorsx.ErrInvalidValue},
+		{"Invalid member", []ConfigMutation{MergeAccounts(Accounts{Groups: []Group{{GroupName: "app", Members: []string{"Bob"}}}})}, "groups", errorsx.ErrInvalidValue},
+		{"Run-as out of range", []ConfigMutation{MergeAccounts(Accounts{RunAs: "65535"})}, "runAs", errorsx.ErrInvalidValue},
+		{"Undeclared run-as", []ConfigMutation{MergeAccounts(Accounts{RunAs: "app"})}, "runAs", errorsx.ErrInvalidValue},
+		{"Shared UID", []ConfigMutation{MergeAccounts(Accounts{Users: []User{{UserName: "a", UID: 1}, {UserName: "b", UID: 1}}})}, "", errorsx.ErrConflictingOptions},
+		{"Root run-as", []ConfigMutation{MergeAccounts(Accounts{RunAs: "0"}), RequireNonRoot()}, "runAs", errorsx.ErrInvalidValue},
+		{"No run-as", []ConfigMutation{RequireNonRoot()}, "runAs", errorsx.ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MutateConfig([]byte(testInlineConfig), tt.mutations...)
+			if !errors.Is(err, tt.kind) {
+				t.Fatalf("Expected %v, got %v", tt.kind, err)
+			}
+
+			if field, _ := errorsx.FieldOf(err); field != tt.field {
+				t.Errorf("Expected field %q, got %q", tt.field, field)
+			}
+		})
+	}
+}
+
+func TestApkoBuilderAccountsRequireInlineConfig(t *testing.T) {
+	_, err := NewApkoBuilder().
+		WithConfigFile("apko.yaml").
+		WithOutputImage("my-image").
+		WithTag("v1").
+		WithOutputTarball("output.tar").
+		WithRequireNonRoot().
+		BuildCommand()
+	if !errors.Is(err, errorsx.ErrConflictingOptions) {
+		t.Errorf("Expected a conflicting options error, got %v", err)
+	}
+}
+
+// accountsEqual reports whether two accounts sections are equal, ignoring nil and empty members.
+func accountsEqual(a, b Accounts) bool {
+	ya, _ := yaml.Marshal(a)
+	yb, _ := yaml.Marshal(b)
+
+	return string(ya) == string(yb)
+}
@@ -0,0 +1,129 @@
+package apkox
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// allArchitectures lists the supported architectures in their canonical order.
+var allArchitectures = []Architecture{ArchX8664, ArchAarch64, ArchArmv7, ArchPpc64le, ArchS390x}
+
+// architectureAliases maps the Go, Docker and Debian names of the architectures to the apko names.
+var architectureAliases = map[string]Architecture{
+	"amd64":   ArchX8664,
+	"x86-64":  ArchX8664,
+	"x64":     ArchX8664,
+	"arm64":   ArchAarch64,
+	"armhf":   ArchArmv7,
+	"arm":     ArchArmv7,
+	"arm/v7":  ArchArmv7,
+	"ppc64el": ArchPpc64le,
+}
+
+// AllArchitectures returns the supported architectures in their canonical order.
+func AllArchitectures() []Architecture {
+	return slices.Clone(allArchitectures)
+}
+
+// ParseArchitecture parses an architecture name, case-insensitively. It accepts the apko names,
+// their Go and Docker aliases (e.g. "amd64" for x86_64, "arm64" for aarch64) and OCI platforms
+// (e.g. "linux/arm64").
+//
+// Returns:
+//   - The apko architecture.
+//   - An error if the name is empty or unknown.
+func ParseArchitecture(s string) (Architecture, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == "" {
+		return "", errorsx.MissingField(builderName, "arch", "architecture is required")
+	}
+
+	name = strings.TrimPrefix(name, "linux/")
+
+	if arch, ok := architectureAliases[name]; ok {
+		return arch, nil
+	}
+
+	if arch := Architecture(name); slices.Contains(allArchitectures, arch) {
+		return arch, nil
+	}
+
+	return "", errorsx.Unsupported(builderName, "arch", s, "unsupported architecture: %s", s)
+}
+
+// ArchitectureSet is a set of architectures, kept deduplicated and in canonical order.
+type ArchitectureSet []Architecture
+
+// NewArchitectureSet returns the set of 'archs'.
+func NewArchitectureSet(archs ...Architecture) ArchitectureSet {
+	set := ArchitectureSet{}
+	for _, arch := range allArchitectures {
+		if slices.Contains(archs, arch) {
+			set = append(set, arch)
+		}
+	}
+
+	return set
+}
+
+// ParseArchitectures parses user input into a set of architectures. Each value may hold several
+// comma-separated names (see ParseArchitecture); "all" stands for every supported architecture.
+//
+// Returns:
+//   - The set of architectures.
+//   - An error if no architecture is given or a name is unknown.
+func ParseArchitectures(values ...string) (ArchitectureSet, error) {
+	var archs []Architecture
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(name), "all") {
+				archs = append(archs, allArchitectures...)
+				continue
+			}
+
+			arch, err := ParseArchitecture(name)
+			if err != nil {
+				return nil, err
+			}
+			archs = append(archs, arch)
+		}
+	}
+
+	if len(archs) == 0 {
+		return nil, errorsx.MissingField(builderName, "arch", "at least one architecture is required")
+	}
+
+	return NewArchitectureSet(archs...), nil
+}
+
+// Contains reports whether the set contains 'arch'.
+func (s ArchitectureSet) Contains(arch Architecture) bool {
+	return slices.Contains(s, arch)
+}
+
+// Union returns the architectures in 's' or 'other'.
+func (s ArchitectureSet) Union(other ArchitectureSet) ArchitectureSet {
+	return NewArchitectureSet(append(slices.Clone(s), other...)...)
+}
+
+// Intersect returns the architectures in both 's' and 'other'.
+func (s ArchitectureSet) Intersect(other ArchitectureSet) ArchitectureSet {
+	return NewArchitectureSet(slices.DeleteFunc(slices.Clone(s), func(a Architecture) bool { return !other.Contains(a) })...)
+}
+
+// Difference returns the architectures in 's' but not in 'other'.
+func (s ArchitectureSet) Difference(other ArchitectureSet) ArchitectureSet {
+	return NewArchitectureSet(slices.DeleteFunc(slices.Clone(s), other.Contains)...)
+}
+
+// Strings returns the names of the architectures.
+func (s ArchitectureSet) Strings() []string {
+	names := make([]string, len(s))
+	for i, arch := range s {
+		names[i] = string(arch)
+	}
+
+	return names
+}
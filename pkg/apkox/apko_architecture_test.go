@@ -0,0 +1,106 @@
+package apkox
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+func TestParseArchitecture(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Architecture
+		kind  error
+	}{
+		{input: "x86_64", want: ArchX8664},
+		{input: "amd64", want: ArchX8664},
+		{input: " AMD64 ", want: ArchX8664},
+		{input: "linux/amd64", want: ArchX8664},
+		{input: "arm64", want: ArchAarch64},
+		{input: "aarch64", want: ArchAarch64},
+		{input: "linux/arm/v7", want: ArchArmv7},
+		{input: "armhf", want: ArchArmv7},
+		{input: "ppc64le", want: ArchPpc64le},
+		{input: "s390x", want: ArchS390x},
+		{input: "", kind: errorsx.ErrMissingField},
+		{input: "riscv64", kind: errorsx.ErrUnsupported},
+		{input: "windows/amd64", kind: errorsx.ErrUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseArchitecture(tt.input)
+			if tt.kind != nil {
+				if !errors.Is(err, tt.kind) {
+					t.Errorf("Expected %v, got %v", tt.kind, err)
+				}
+				return
+			}
+
+			if err != nil || got != tt.want {
+				t.Errorf("ParseArchitecture(%q) = %s, %v, want %s", tt.input, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseArchitectures(t *testing.T) {
+	set, err := ParseArchitectures("arm64,amd64", "x86_64")
+	if err != nil {
+		t.Fatalf("ParseArchitectures returned unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(set, ArchitectureSet{ArchX8664, ArchAarch64}) {
+		t.Errorf("ParseArchitectures() = %v", set)
+	}
+
+	all, err := ParseArchitectures("all")
+	if err != nil || !reflect.DeepEqual([]Architecture(all), AllArchitectures()) {
+		t.Errorf("ParseArchitectures(all) = %v, %v", all, err)
+	}
+
+	if _, err := ParseArchitectures(); !errors.Is(err, errorsx.ErrMissingField) {
+		t.Errorf("Expected a missing field error, got %v", err)
+	}
+
+	if _, err := ParseArchitectures("amd64,,arm64"); !errors.Is(err, errorsx.ErrMissingField) {
+		t.Errorf("Expected a missing field error for empty names, got %v", err)
+	}
+}
+
+func TestArchitectureSet(t *testing.T) {
+	a := NewArchitectureSet(ArchAarch64, ArchX8664, ArchAarch64)
+	b := NewArchitectureSet(ArchS390x, ArchAarch64)
+
+	if !reflect.DeepEqual(a, ArchitectureSet{ArchX8664, ArchAarch64}) {
+		t.Errorf("NewArchitectureSet() = %v", a)
+	}
+
+	if got := a.Union(b); !reflect.DeepEqual(got, ArchitectureSet{ArchX8664, ArchAarch64, ArchS390x}) {
+		t.Errorf("Union() = %v", got)
+	}
+
+	if got := a.Intersect(b); !reflect.DeepEqual(got, ArchitectureSet{ArchAarch64}) {
+		t.Errorf("Intersect() = %v", got)
+	}
+
+	if got := a.Difference(b); !reflect.DeepEqual(got, ArchitectureSet{ArchX8664}) {
+		t.Errorf("Difference() = %v", got)
+	}
+
+	if !a.Contains(ArchX8664) || a.Contains(ArchArmv7) {
+		t.Error("Contains() returned unexpected results")
+	}
+
+	if got := a.Strings(); !reflect.DeepEqual(got, []string{"x86_64", "aarch64"}) {
+		t.Errorf("Strings() = %v", got)
+	}
+
+	all := AllArchitectures()
+	all[0] = "changed"
+	if AllArchitectures()[0] != ArchX8664 {
+		t.Error("Expected AllArchitectures to return a copy")
+	}
+}
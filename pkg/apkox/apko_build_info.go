@@ -0,0 +1,216 @@
+package apkox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/checksumx"
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+)
+
+// BuildInfoBuilder identifies the builder of the build metadata files.
+const BuildInfoBuilder = "github.com/Excoriate/daggerx/pkg/apkox"
+
+// buildInfoModule is the module whose version is the version of the builder.
+const buildInfoModule = "github.com/Excoriate/daggerx"
+
+// Placeholders of the build metadata filled in by the generated command when the build runs.
+const (
+	startedPlaceholder    = "@STARTED_AT@"
+	finishedPlaceholder   = "@FINISHED_AT@"
+	configHashPlaceholder = "@CONFIG_HASH@"
+)
+
+// BuildInfo is the machine-readable metadata of a build, written next to the output tarball so
+// downstream tooling can discover the inputs of the build without re-deriving them.
+type BuildInfo struct {
+	// Builder identifies the builder that generated the command (BuildInfoBuilder).
+	Builder string `json:"builder"`
+	// BuilderVersion is the version of the daggerx module, or "devel" when unknown.
+	BuilderVersion string `json:"builderVersion"`
+	// ApkoVersion is the apko release the command targets, if set with WithApkoVersion.
+	ApkoVersion string `json:"apkoVersion,omitempty"`
+	// ConfigFile is the path of the configuration the command uses.
+	ConfigFile string `json:"configFile"`
+	// ConfigHash is the digest of the configuration (e.g. "sha256:..."). It is computed by the
+	// command for configuration files, so it is empty in the value returned by
+	// BuildCommandWithBuildInfo, and set in the written file.
+	ConfigHash string `json:"configHash"`
+	// Command is the apko build command.
+	Command []string `json:"command"`
+	// Image is the output image name, without tag.
+	Image string `json:"image"`
+	// ImageRef is the reference of the output image with its tag.
+	ImageRef string `json:"imageRef"`
+	// Tags are the tags of the output image.
+	Tags []string `json:"tags"`
+	// Tarball is the path of the output tarball.
+	Tarball string `json:"tarball"`
+	// Archs are the built architectures, when known (see BuildSummary).
+	Archs []Architecture `json:"archs,omitempty"`
+	// SBOMPaths are the paths of the SBOMs apko writes, if any.
+	SBOMPaths []string `json:"sbomPaths,omitempty"`
+	// Caches are the caches of the build, when recorded with WithCacheProvenance.
+	Caches []CacheProvenance `json:"caches,omitempty"`
+	// StartedAt is when the build started, in RFC 3339 UTC. Set in the written file only.
+	StartedAt string `json:"startedAt"`
+	// FinishedAt is when the build finished, in RFC 3339 UTC. Set in the written file only.
+	FinishedAt string `json:"finishedAt"`
+}
+
+// ParseBuildInfo parses a build metadata file written by the command of
+// BuildCommandWithBuildInfo.
+//
+// Returns:
+//   - The build metadata.
+//   - An error if the data is not a build metadata file.
+func ParseBuildInfo(data []byte) (*BuildInfo, error) {
+	var info BuildInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("invalid apko build info: %w", err)
+	}
+
+	return &info, nil
+}
+
+// GetBuildInfoPath returns the path of the build metadata file of the output tarball 'tarball':
+// the tarball path with the ".build-info.json" extension (e.g. "/mnt/image.build-info.json").
+func GetBuildInfoPath(tarball string) string {
+	return strings.TrimSuffix(tarball, filepath.Ext(tarball)) + ".build-info.json"
+}
+
+// WithBuildInfoPath sets the file BuildCommandWithBuildInfo writes the build metadata to,
+// instead of the path next to the output tarball (see GetBuildInfoPath).
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithBuildInfoPath(path string) *ApkoBuilder {
+	b.buildInfoPath = path
+	return b
+}
+
+// BuildInfoPath returns the file the build metadata is written to.
+func (b *ApkoBuilder) BuildInfoPath() string {
+	if b.buildInfoPath != "" {
+		return b.buildInfoPath
+	}
+
+	return GetBuildInfoPath(b.outputTarball)
+}
+
+// BuildCommandWithBuildInfo generates a `sh -c` command running the build command, as
+// BuildCommand generates it, then writing its build metadata to BuildInfoPath: the builder
+// version, the configuration hash, the command, the outputs and the start and finish times of
+// the build. The metadata is only written when the build succeeds. The command requires date,
+// sed and, for configuration files, sha256sum, which busybox provides.
+//
+// Returns:
+//   - The command building the image and writing the build metadata.
+//   - The build metadata known before the build runs: without timestamps, and without
+//     configuration hash for configuration files.
+//   - An error if the configuration is invalid.
+func (b *ApkoBuilder) BuildCommandWithBuildInfo() ([]string, *BuildInfo, error) {
+	cmd, summary, err := b.BuildCommandWithSummary()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, content, err := b.effectiveConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := &BuildInfo{
+		Builder:        BuildInfoBuilder,
+		BuilderVersion: builderVersion(),
+		ApkoVersion:    b.apkoVersion,
+		ConfigFile:     summary.ConfigFile,
+		Command:        cmd,
+		Image:          summary.Image,
+		ImageRef:       summary.ImageRef,
+		Tags:           summary.Tags,
+		Tarball:        summary.Tarball,
+		Archs:          summary.Archs,
+		SBOMPaths:      summary.SBOMPaths,
+		Caches:         b.caches(),
+	}
+
+	if content != "" {
+		digest, err := checksumx.Bytes(checksumx.SHA256, []byte(content))
+		if err != nil {
+			return nil, nil, err
+		}
+		info.ConfigHash = digest.String()
+	}
+
+	template := *info
+	template.StartedAt, template.FinishedAt = startedPlaceholder, finishedPlaceholder
+	template.Caches = nil
+	if template.ConfigHash == "" {
+		template.ConfigHash = configHashPlaceholder
+	}
+
+	data, err := json.Marshal(template)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode apko build info: %w", err)
+	}
+
+	cachesBefore, cachesAfter, cachesSubst, caches, err := cacheProvenanceSteps(info.Caches)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(info.Caches) > 0 {
+		data = append(append(data[:len(data)-1], `,"caches":`...), append(caches, '}')...)
+	}
+
+	path := b.BuildInfoPath()
+	steps := append(cachesBefore,
+		`started=$(date -u +%Y-%m-%dT%H:%M:%SZ)`,
+		cmdx.ShellJoin(cmd),
+		`finished=$(date -u +%Y-%m-%dT%H:%M:%SZ)`,
+	)
+	steps = append(steps, cachesAfter...)
+
+	subst := []string{`-e "s/` + startedPlaceholder + `/$started/"`, `-e "s/` + finishedPlaceholder + `/$finished/"`}
+	subst = append(subst, cachesSubst...)
+	if info.ConfigHash == "" {
+		steps = append(steps, `hash=$(sha256sum `+cmdx.ShellQuote(info.ConfigFile)+` | cut -d ' ' -f 1)`)
+		subst = append(subst, `-e "s/`+configHashPlaceholder+`/sha256:$hash/"`)
+	}
+
+	steps = append(steps,
+		"mkdir -p "+cmdx.ShellQuote(filepath.Dir(path)),
+		fmt.Sprintf("printf '%%s\\n' %s | sed %s > %s", cmdx.ShellQuote(string(data)), strings.Join(subst, " "),
+			cmdx.ShellQuote(path)))
+
+	wrapped := []string{"sh", "-c", strings.Join(steps, " && ")}
+	logx.Command(context.Background(), b.logger, builderName, wrapped)
+
+	return wrapped, info, nil
+}
+
+// builderVersion returns the version of the daggerx module in the running binary, or "devel".
+func builderVersion() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "devel"
+	}
+
+	if bi.Main.Path == buildInfoModule && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		return bi.Main.Version
+	}
+
+	for _, dep := range bi.Deps {
+		if dep.Path == buildInfoModule {
+			if dep.Replace != nil && dep.Replace.Version != "" {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+
+	return "devel"
+}
@@ -0,0 +1,179 @@
+package apkox
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/checksumx"
+)
+
+// runWithFakeApko runs 'cmd' with an apko on the PATH that succeeds, or fails if 'fail'.
+func runWithFakeApko(t *testing.T, cmd []string, fail bool) error {
+	t.Helper()
+
+	bin := t.TempDir()
+	script := "#!/bin/sh\nexit 0\n"
+	if fail {
+		script = "#!/bin/sh\nexit 3\n"
+	}
+	if err := os.WriteFile(filepath.Join(bin, "apko"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	c := exec.Command(cmd[0], cmd[1:]...)
+	c.Env = append(os.Environ(), "PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	return c.Run()
+}
+
+func TestGetBuildInfoPath(t *testing.T) {
+	tests := map[string]string{
+		"/mnt/image.tar":      "/mnt/image.build-info.json",
+		"/out/app-arm64.tar":  "/out/app-arm64.build-info.json",
+		"/out/image":          "/out/image.build-info.json",
+		"/out/image.v1.0.tar": "/out/image.v1.0.build-info.json",
+	}
+
+	for tarball, want := range tests {
+		if got := GetBuildInfoPath(tarball); got != want {
+			t.Errorf("GetBuildInfoPath(%q) = %q, want %q", tarball, got, want)
+		}
+	}
+
+	b := NewApkoBuilder().WithOutputTarball("/mnt/image.tar")
+	if got := b.BuildInfoPath(); got != "/mnt/image.build-info.json" {
+		t.Errorf("BuildInfoPath() = %q", got)
+	}
+
+	if got := b.WithBuildInfoPath("/mnt/meta.json").BuildInfoPath(); got != "/mnt/meta.json" {
+		t.Errorf("BuildInfoPath() = %q, want the configured path", got)
+	}
+}
+
+func TestBuildCommandWithBuildInfo(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "apko.yaml")
+	if err := os.WriteFile(config, []byte("contents:\n  packages: [busybox]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewApkoBuilder().
+		WithConfigFile(config).
+		WithOutputImage("example/app").
+		WithTag("v1").
+		WithOutputTarball(filepath.Join(dir, "out", "image.tar")).
+		WithApkoVersion("0.25.1")
+
+	cmd, info, err := b.BuildCommandWithBuildInfo()
+	if err != nil {
+		t.Fatalf("BuildCommandWithBuildInfo() error = %v", err)
+	}
+
+	if cmd[0] != "sh" || cmd[1] != "-c" {
+		t.Fatalf("command = %v, want a sh -c command", cmd)
+	}
+
+	if info.ConfigHash != "" || info.StartedAt != "" || info.Builder != BuildInfoBuilder || info.ApkoVersion != "0.25.1" {
+		t.Errorf("info = %+v", info)
+	}
+
+	before := time.Now().UTC().Add(-time.Second)
+	if err := runWithFakeApko(t, cmd, false); err != nil {
+		t.Fatalf("command error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "out", "image.build-info.json"))
+	if err != nil {
+		t.Fatalf("build info not written: %v", err)
+	}
+
+	written, err := ParseBuildInfo(data)
+	if err != nil {
+		t.Fatalf("ParseBuildInfo() error = %v", err)
+	}
+
+	digest, err := checksumx.File(checksumx.SHA256, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written.ConfigHash != digest.String() {
+		t.Errorf("ConfigHash = %q, want %q", written.ConfigHash, digest)
+	}
+
+	started, err := time.Parse(time.RFC3339, written.StartedAt)
+	if err != nil || started.Before(before.Truncate(time.Second)) {
+		t.Errorf("StartedAt = %q (%v)", written.StartedAt, err)
+	}
+
+	if _, err := time.Parse(time.RFC3339, written.FinishedAt); err != nil {
+		t.Errorf("FinishedAt = %q (%v)", written.FinishedAt, err)
+	}
+
+	if written.ImageRef != "example/app:v1" || !strings.Contains(strings.Join(written.Command, " "), "apko build") {
+		t.Errorf("written = %+v", written)
+	}
+}
+
+func TestBuildCommandWithBuildInfoInlineConfig(t *testing.T) {
+	dir := t.TempDir()
+	inline := "contents:\n  packages: [busybox]\narchs: [x86_64]\n"
+
+	b := NewApkoBuilder().
+		WithInlineConfig(inline).
+		WithOutputImage("example/app").
+		WithOutputTarball("/mnt/image.tar").
+		WithBuildInfoPath(filepath.Join(dir, "info.json"))
+
+	cmd, info, err := b.BuildCommandWithBuildInfo()
+	if err != nil {
+		t.Fatalf("BuildCommandWithBuildInfo() error = %v", err)
+	}
+
+	digest, err := checksumx.Bytes(checksumx.SHA256, []byte(inline))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ConfigHash != digest.String() {
+		t.Errorf("ConfigHash = %q, want %q", info.ConfigHash, digest)
+	}
+
+	if strings.Contains(cmd[2], "sha256sum") {
+		t.Errorf("command hashes an inline config: %s", cmd[2])
+	}
+
+	if err := runWithFakeApko(t, cmd, true); err == nil {
+		t.Fatal("command error = nil, want the build failure")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "info.json")); !os.IsNotExist(err) {
+		t.Errorf("build info written after a failed build: %v", err)
+	}
+
+	if err := runWithFakeApko(t, cmd, false); err != nil {
+		t.Fatalf("command error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "info.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if regexp.MustCompile(`@[A-Z_]+@`).Match(data) {
+		t.Errorf("build info has unfilled placeholders: %s", data)
+	}
+}
+
+func TestBuildCommandWithBuildInfoInvalid(t *testing.T) {
+	if _, _, err := NewApkoBuilder().BuildCommandWithBuildInfo(); err == nil {
+		t.Error("BuildCommandWithBuildInfo() error = nil, want an error")
+	}
+
+	if _, err := ParseBuildInfo([]byte("{")); err == nil {
+		t.Error("ParseBuildInfo() error = nil, want an error")
+	}
+}
@@ -0,0 +1,81 @@
+package apkox
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+)
+
+// CacheProvenance records a cache a build used, so differences of build times across runners
+// can be traced to cold caches.
+type CacheProvenance struct {
+	// Path is the cache directory.
+	Path string `json:"path"`
+	// Volume is the key of the cache volume mounted at Path by CacheMount.
+	Volume string `json:"volume,omitempty"`
+	// Warm reports whether the cache held entries when the build started. Set in the written
+	// file only.
+	Warm bool `json:"warm"`
+	// SizeKBBefore is the size of the cache when the build started, in KiB. Set in the written
+	// file only.
+	SizeKBBefore int64 `json:"sizeKBBefore"`
+	// SizeKBAfter is the size of the cache when the build finished, in KiB. Set in the written
+	// file only.
+	SizeKBAfter int64 `json:"sizeKBAfter"`
+}
+
+// WithCacheProvenance makes BuildCommandWithBuildInfo record the caches of the build in the
+// build metadata: the cache directory, its cache volume, whether it held entries when the build
+// started, and its size before and after the build. The command then also requires ls and du.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithCacheProvenance(enabled bool) *ApkoBuilder {
+	b.cacheProvenance = enabled
+	return b
+}
+
+// caches returns the caches of the build, when the cache provenance is enabled.
+func (b *ApkoBuilder) caches() []CacheProvenance {
+	if !b.cacheProvenance || b.cacheDir == "" {
+		return nil
+	}
+
+	return []CacheProvenance{{Path: b.cacheDir, Volume: CacheVolumeKey}}
+}
+
+// cacheProvenanceSteps returns the shell steps measuring the caches before and after the build,
+// the sed expressions filling in their placeholders, and the JSON of the caches holding them.
+func cacheProvenanceSteps(caches []CacheProvenance) (before, after, subst []string, data []byte, err error) {
+	entries := make([]string, 0, len(caches))
+	for i, c := range caches {
+		dir := cmdx.ShellQuote(c.Path)
+		warm, sizeBefore, sizeAfter := fmt.Sprintf("cache_warm_%d", i), fmt.Sprintf("cache_before_%d", i),
+			fmt.Sprintf("cache_after_%d", i)
+
+		before = append(before,
+			fmt.Sprintf(`%s=false && if [ -n "$(ls -A %s 2>/dev/null)" ]; then %s=true; fi`, warm, dir, warm),
+			fmt.Sprintf(`%s=$(du -sk %s 2>/dev/null | cut -f 1)`, sizeBefore, dir))
+		after = append(after, fmt.Sprintf(`%s=$(du -sk %s 2>/dev/null | cut -f 1)`, sizeAfter, dir))
+
+		for _, v := range []string{warm, sizeBefore, sizeAfter} {
+			subst = append(subst, fmt.Sprintf(`-e "s/@%s@/${%s:-0}/"`, strings.ToUpper(v), v))
+		}
+
+		path, err := json.Marshal(c.Path)
+		if err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("failed to encode apko cache provenance: %w", err)
+		}
+
+		volume, err := json.Marshal(c.Volume)
+		if err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("failed to encode apko cache provenance: %w", err)
+		}
+
+		// The placeholders stand for JSON booleans and numbers, so they are not quoted.
+		entries = append(entries, fmt.Sprintf(`{"path":%s,"volume":%s,"warm":@%s@,"sizeKBBefore":@%s@,"sizeKBAfter":@%s@}`,
+			path, volume, strings.ToUpper(warm), strings.ToUpper(sizeBefore), strings.ToUpper(sizeAfter)))
+	}
+
+	return before, after, subst, []byte("[" + strings.Join(entries, ",") + "]"), nil
+}
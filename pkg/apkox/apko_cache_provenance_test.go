@@ -0,0 +1,92 @@
+package apkox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildInfoCacheProvenance(t *testing.T) {
+	dir := t.TempDir()
+	cache := filepath.Join(dir, "cache dir")
+
+	b := NewApkoBuilder().
+		WithInlineConfig("contents:\n  packages: [busybox]\n").
+		WithOutputImage("example/app").
+		WithOutputTarball(filepath.Join(dir, "image.tar")).
+		WithCacheDir(cache).
+		WithCacheProvenance(true)
+
+	cmd, info, err := b.BuildCommandWithBuildInfo()
+	if err != nil {
+		t.Fatalf("BuildCommandWithBuildInfo() error = %v", err)
+	}
+
+	if len(info.Caches) != 1 || info.Caches[0].Path != cache || info.Caches[0].Volume != CacheVolumeKey {
+		t.Fatalf("Caches = %+v, want the cache directory", info.Caches)
+	}
+
+	run := func() CacheProvenance {
+		t.Helper()
+
+		if err := runWithFakeApko(t, cmd, false); err != nil {
+			t.Fatalf("command error = %v", err)
+		}
+
+		data, err := os.ReadFile(b.BuildInfoPath())
+		if err != nil {
+			t.Fatalf("build info not written: %v", err)
+		}
+
+		written, err := ParseBuildInfo(data)
+		if err != nil {
+			t.Fatalf("ParseBuildInfo() error = %v", err)
+		}
+
+		if len(written.Caches) != 1 {
+			t.Fatalf("written Caches = %+v, want one cache", written.Caches)
+		}
+
+		return written.Caches[0]
+	}
+
+	// The cache directory doesn't exist yet.
+	if got := run(); got.Warm || got.SizeKBBefore != 0 || got.Path != cache {
+		t.Errorf("cold cache = %+v, want a cold empty cache", got)
+	}
+
+	if err := os.MkdirAll(filepath.Join(cache, "x86_64"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cache, "x86_64", "APKINDEX.tar.gz"), make([]byte, 8192), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := run(); !got.Warm || got.SizeKBBefore == 0 || got.SizeKBAfter == 0 {
+		t.Errorf("warm cache = %+v, want a warm cache with its size", got)
+	}
+}
+
+func TestBuildInfoCacheProvenanceDisabled(t *testing.T) {
+	b := NewApkoBuilder().
+		WithConfigFile("apko.yaml").
+		WithOutputImage("example/app").
+		WithOutputTarball("/out/image.tar").
+		WithCacheDir("/cache")
+
+	_, info, err := b.BuildCommandWithBuildInfo()
+	if err != nil {
+		t.Fatalf("BuildCommandWithBuildInfo() error = %v", err)
+	}
+	if info.Caches != nil {
+		t.Errorf("Caches = %+v, want none without WithCacheProvenance", info.Caches)
+	}
+
+	_, info, err = b.WithCacheDir("").WithCacheProvenance(true).BuildCommandWithBuildInfo()
+	if err != nil {
+		t.Fatalf("BuildCommandWithBuildInfo() error = %v", err)
+	}
+	if info.Caches != nil {
+		t.Errorf("Caches = %+v, want none without a cache directory", info.Caches)
+	}
+}
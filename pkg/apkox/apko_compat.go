@@ -0,0 +1,101 @@
+package apkox
+
+import (
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/versionx"
+)
+
+// Capability is a flag of apko build and the first apko release supporting it.
+type Capability struct {
+	// Option is the builder option emitting the flag, named after its ApkoSpec field.
+	Option string
+	// Flag is the apko build flag (e.g. "--layering-strategy").
+	Flag string
+	// MinVersion is the first apko release supporting the flag (e.g. "0.25.0").
+	MinVersion string
+}
+
+// Capabilities are the flags emitted by the typed options that not every apko release supports.
+// Flags left out (e.g. --keyring-append, --arch or --sbom) are supported by all the releases
+// the builder targets.
+var Capabilities = []Capability{
+	{Option: "cacheDir", Flag: "--cache-dir", MinVersion: "0.10.0"},
+	{Option: "buildRepositoryAppend", Flag: "--build-repository-append", MinVersion: "0.10.0"},
+	{Option: "vcs", Flag: "--vcs", MinVersion: "0.10.0"},
+	{Option: "logLevel", Flag: "--log-level", MinVersion: "0.11.0"},
+	{Option: "offline", Flag: "--offline", MinVersion: "0.13.0"},
+	{Option: "layeringStrategy", Flag: "--layering-strategy", MinVersion: "0.25.0"},
+	{Option: "layeringBudget", Flag: "--layering-budget", MinVersion: "0.25.0"},
+}
+
+// WithApkoVersion sets the apko release (e.g. "0.19.6" or "v0.25.1") the command targets.
+// BuildCommand then fails when a configured option emits a flag the release doesn't support,
+// instead of apko failing inside the container. No version is checked by default.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithApkoVersion(version string) *ApkoBuilder {
+	b.apkoVersion = version
+	return b
+}
+
+// CapabilityOf returns the capability of the apko build flag 'flag', and whether it is gated.
+func CapabilityOf(flag string) (Capability, bool) {
+	flag, _, _ = strings.Cut(flag, "=")
+	for _, c := range Capabilities {
+		if c.Flag == flag {
+			return c, true
+		}
+	}
+
+	return Capability{}, false
+}
+
+// Supports reports whether the apko release 'version' supports the build flag 'flag'. Flags
+// without a capability are supported by all releases.
+//
+// Returns:
+//   - Whether the flag is supported.
+//   - An error if 'version' is not a semantic version.
+func Supports(version, flag string) (bool, error) {
+	v, err := versionx.Parse(version)
+	if err != nil {
+		return false, errorsx.InvalidValue(builderName, "apkoVersion", version,
+			"invalid apko version %q: %v", version, err)
+	}
+
+	c, ok := CapabilityOf(flag)
+	if !ok {
+		return true, nil
+	}
+
+	return !v.LessThan(versionx.MustParse(c.MinVersion)), nil
+}
+
+// validateCapabilities checks that the apko version of the builder, if set, supports the typed
+// 'flags'.
+func (b *ApkoBuilder) validateCapabilities(flags []string) error {
+	if b.apkoVersion == "" {
+		return nil
+	}
+
+	for _, f := range flags {
+		if !strings.HasPrefix(f, "--") {
+			continue
+		}
+
+		ok, err := Supports(b.apkoVersion, f)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			c, _ := CapabilityOf(f)
+			return errorsx.Unsupported(builderName, c.Option, b.apkoVersion,
+				"option %s (%s) requires apko %s or later, targeting %s",
+				c.Option, c.Flag, c.MinVersion, b.apkoVersion)
+		}
+	}
+
+	return nil
+}
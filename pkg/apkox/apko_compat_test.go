@@ -0,0 +1,112 @@
+package apkox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/versionx"
+)
+
+func TestSupports(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		flag    string
+		want    bool
+		wantErr bool
+	}{
+		{name: "Ungated flag", version: "0.1.0", flag: "--arch", want: true},
+		{name: "Minimum version", version: "0.25.0", flag: "--layering-strategy", want: true},
+		{name: "Newer version", version: "v1.2.3", flag: "--layering-budget", want: true},
+		{name: "Older version", version: "0.24.9", flag: "--layering-strategy", want: false},
+		{name: "Flag with value", version: "0.9.0", flag: "--vcs=false", want: false},
+		{name: "Prerelease of the minimum version", version: "0.13.0-rc.1", flag: "--offline", want: false},
+		{name: "Invalid version", version: "latest", flag: "--offline", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Supports(tt.version, tt.flag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Supports() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Supports() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapabilitiesAreValid(t *testing.T) {
+	for _, c := range Capabilities {
+		if !versionx.IsValid(c.MinVersion) {
+			t.Errorf("Capability %s has an invalid minimum version %q", c.Flag, c.MinVersion)
+		}
+	}
+}
+
+func TestApkoBuilderWithApkoVersion(t *testing.T) {
+	newBuilder := func() *ApkoBuilder {
+		return NewApkoBuilder().
+			WithConfigFile("config.yaml").
+			WithOutputImage("my-image").
+			WithTag("v1").
+			WithOutputTarball("output.tar").
+			WithSBOM(true).
+			WithVCS(true)
+	}
+
+	tests := []struct {
+		name      string
+		builder   *ApkoBuilder
+		wantField string
+		wantKind  error
+	}{
+		{
+			name:    "No version",
+			builder: newBuilder().WithLayering(LayerStrategyOrigin, 0),
+		},
+		{
+			name:    "Supported options",
+			builder: newBuilder().WithApkoVersion("0.25.0").WithLayering(LayerStrategyOrigin, 5).WithOffline(),
+		},
+		{
+			name:      "Unsupported layering",
+			builder:   newBuilder().WithApkoVersion("0.19.6").WithLayering(LayerStrategyOrigin, 0),
+			wantField: "layeringStrategy",
+			wantKind:  errorsx.ErrUnsupported,
+		},
+		{
+			name:      "Unsupported VCS flag",
+			builder:   newBuilder().WithApkoVersion("0.9.0").WithVCS(false),
+			wantField: "vcs",
+			wantKind:  errorsx.ErrUnsupported,
+		},
+		{
+			name:      "Invalid version",
+			builder:   newBuilder().WithApkoVersion("next").WithOffline(),
+			wantField: "apkoVersion",
+			wantKind:  errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.BuildCommand()
+			if tt.wantKind == nil {
+				if err != nil {
+					t.Fatalf("BuildCommand() unexpected error: %v", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.wantKind) {
+				t.Fatalf("BuildCommand() error = %v, want %v", err, tt.wantKind)
+			}
+			if field, _ := errorsx.FieldOf(err); field != tt.wantField {
+				t.Errorf("BuildCommand() error field = %q, want %q", field, tt.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package apkox
+
+import (
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigMutation modifies a decoded APKO configuration document in place.
+type ConfigMutation func(doc map[string]any) error
+
+// MutateConfig applies 'mutations', in order, to the YAML configuration 'config'. It lets
+// configurations kept as files receive the same settings as the builder options mutating inline
+// configurations (e.g. WithUser).
+//
+// Returns:
+//   - The mutated YAML configuration. Keys are sorted and comments are not preserved.
+//   - An error if the configuration is not a YAML mapping or a mutation fails.
+func MutateConfig(config []byte, mutations ...ConfigMutation) ([]byte, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(config, &doc); err != nil {
+		return nil, errorsx.InvalidValue(builderName, "config", "", "invalid config: %w", err)
+	}
+
+	if doc == nil {
+		doc = map[string]any{}
+	}
+
+	for _, m := range mutations {
+		if err := m(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, errorsx.InvalidValue(builderName, "config", "", "failed to encode config: %w", err)
+	}
+
+	return out, nil
+}
+
+// decodeSection decodes the section 'key' of 'doc' into 'out'. Sections set by earlier mutations
+// hold typed values, which are converted through YAML as well.
+func decodeSection(doc map[string]any, key string, out any) error {
+	section, ok := doc[key]
+	if !ok || section == nil {
+		return nil
+	}
+
+	data, err := yaml.Marshal(section)
+	if err != nil {
+		return errorsx.InvalidValue(builderName, key, "", "failed to encode %s: %w", key, err)
+	}
+
+	if err := yaml.Unmarshal(data, out); err != nil {
+		return errorsx.InvalidValue(builderName, key, "", "invalid %s section: %w", key, err)
+	}
+
+	return nil
+}
+
+// configMutations returns the mutations the builder options apply to the configuration, and the
+// names of these options.
+func (b *ApkoBuilder) configMutations() ([]ConfigMutation, []string) {
+	var (
+		mutations []ConfigMutation
+		options   []string
+	)
+
+	if !b.accounts.empty() {
+		mutations = append(mutations, MergeAccounts(b.accounts))
+		options = append(options, "accounts")
+	}
+
+	if len(b.environment) > 0 {
+		mutations = append(mutations, MergeEnvironment(b.environment...))
+		options = append(options, "environment")
+	}
+
+	if len(b.paths) > 0 {
+		mutations = append(mutations, MergePaths(b.paths...))
+		options = append(options, "paths")
+	}
+
+	if len(b.certificates) > 0 {
+		mutations = append(mutations, MergeCertificates(b.certificates...))
+		options = append(options, "certificates")
+	}
+
+	if len(b.packageRules) > 0 {
+		mutations = append(mutations, ApplyPackageRules(Architecture(b.buildArch), b.packageRules...))
+		options = append(options, "packageRules")
+	}
+
+	if b.requireNonRoot {
+		mutations = append(mutations, RequireNonRoot())
+		options = append(options, "requireNonRoot")
+	}
+
+	return mutations, options
+}
+
+// effectiveConfig returns the path and content of the configuration the command uses: the inline
+// configuration with the account, environment, path, certificate and package rule options
+// applied, materialized at a path derived from the mutated content. Without mutations, it returns
+// the configured file and inline configuration.
+func (b *ApkoBuilder) effectiveConfig() (string, string, error) {
+	mutations, options := b.configMutations()
+	if len(mutations) == 0 {
+		return b.configFile, b.inlineConfig, nil
+	}
+
+	if len(b.packageRules) > 0 && b.buildArch == "" {
+		return "", "", errorsx.MissingField(builderName, "arch",
+			"package rules require an architecture; use ForArchitectures to build each architecture")
+	}
+
+	if b.inlineConfig == "" {
+		return "", "", errorsx.ConflictingOptions(builderName, append([]string{"configFile"}, options...),
+			"%s options require an inline config or a config preset; apply them to config files with MutateConfig",
+			strings.Join(options, ", "))
+	}
+
+	out, err := MutateConfig([]byte(b.inlineConfig), mutations...)
+	if err != nil {
+		return "", "", err
+	}
+
+	return GetInlineConfigPath(fixtures.MntPrefix, string(out)), string(out), nil
+}
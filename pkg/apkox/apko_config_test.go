@@ -0,0 +1,64 @@
+package apkox
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/goldenx"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMutateConfigGolden(t *testing.T) {
+	config := `contents:
+  packages: [wolfi-base, curl]
+environment:
+  PATH: /usr/bin:/bin
+`
+
+	out, err := MutateConfig([]byte(config),
+		MergeEnvironment(EnvEntry{Name: pathEnvVar, Value: "/opt/bin", Mode: EnvModePrepend}),
+		MergeAccounts(Accounts{
+			Users:  []User{{UserName: "nonroot", UID: 65532, GID: 65532}},
+			Groups: []Group{{GroupName: "nonroot", GID: 65532}},
+			RunAs:  "nonroot",
+		}),
+		RequireNonRoot(),
+	)
+	if err != nil {
+		t.Fatalf("MutateConfig returned unexpected error: %v", err)
+	}
+
+	goldenx.New(t).AssertYAML("mutate-config", out)
+}
+
+func FuzzMutateConfig(f *testing.F) {
+	for _, config := range []string{
+		"contents:\n  packages: [wolfi-base]\n",
+		"environment:\n  PATH: /bin\naccounts:\n  run-as: nonroot\n",
+		"environment: [a, b]\n",
+		"accounts: 1\n",
+		"- a\n- b\n",
+		"&a [*a]\n",
+		"",
+		"\x00",
+	} {
+		f.Add(config)
+	}
+
+	f.Fuzz(func(t *testing.T, config string) {
+		out, err := MutateConfig([]byte(config),
+			MergeEnvironment(EnvEntry{Name: pathEnvVar, Value: "/opt/bin", Mode: EnvModePrepend}),
+			RequireNonRoot(),
+		)
+		if err != nil {
+			return
+		}
+
+		var doc map[string]any
+		if err := yaml.Unmarshal(out, &doc); err != nil {
+			t.Fatalf("MutateConfig(%q) = %q, not a YAML mapping: %v", config, out, err)
+		}
+
+		// The inline config validation must not panic on the same input.
+		_ = NewApkoBuilder().WithInlineConfig(config).validateInlineConfig()
+	})
+}
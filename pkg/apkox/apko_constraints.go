@@ -0,0 +1,208 @@
+package apkox
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// ConstraintOp is the operator of a package version constraint.
+type ConstraintOp string
+
+const (
+	// OpAny is the operator of a package without a version constraint.
+	OpAny ConstraintOp = ""
+	// OpEqual pins the exact version (e.g. "curl=8.5.0-r0").
+	OpEqual ConstraintOp = "="
+	// OpFuzzy matches the versions starting with the version (e.g. "curl~8.5").
+	OpFuzzy ConstraintOp = "~"
+	// OpGreater matches the versions greater than the version.
+	OpGreater ConstraintOp = ">"
+	// OpGreaterEqual matches the versions greater than or equal to the version.
+	OpGreaterEqual ConstraintOp = ">="
+	// OpLess matches the versions less than the version.
+	OpLess ConstraintOp = "<"
+	// OpLessEqual matches the versions less than or equal to the version.
+	OpLessEqual ConstraintOp = "<="
+)
+
+// constraintOps maps the operators accepted by ParsePackageConstraint to their canonical form,
+// longest first so "<=" isn't read as "<".
+var constraintOps = []struct {
+	text string
+	op   ConstraintOp
+}{
+	{"==", OpEqual},
+	{">=", OpGreaterEqual},
+	{"=>", OpGreaterEqual},
+	{"<=", OpLessEqual},
+	{"=<", OpLessEqual},
+	{"=~", OpFuzzy},
+	{"~=", OpFuzzy},
+	{"=", OpEqual},
+	{"~", OpFuzzy},
+	{">", OpGreater},
+	{"<", OpLess},
+}
+
+var (
+	// packageNameRegex matches APK package names, including the provides of virtual packages
+	// (e.g. "so:libc.so.6", "cmd:bash").
+	packageNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9+._:-]*$`)
+
+	// packageVersionRegex matches APK versions (e.g. "8.5.0-r0", "1.2.3_git20240101-r1"), and the
+	// version prefixes of fuzzy constraints (e.g. "8.5").
+	packageVersionRegex = regexp.MustCompile(
+		`^[0-9]+(\.[0-9]+)*[a-z]?(_(alpha|beta|pre|rc|cvs|svn|git|hg|p)[0-9]*)*(-r[0-9]+)?$`)
+)
+
+// PackageConstraint is a package of an APKO configuration, with an optional version constraint.
+type PackageConstraint struct {
+	// Name is the package name.
+	Name string `json:"name"`
+	// Op is the operator of the version constraint, OpAny when the package isn't constrained.
+	Op ConstraintOp `json:"op,omitempty"`
+	// Version is the version of the constraint.
+	Version string `json:"version,omitempty"`
+}
+
+// ParsePackageConstraint parses a package with an optional version constraint, such as "curl",
+// "curl=8.5.0-r0" or "curl>=8.5". Whitespace around the name, operator and version is ignored,
+// and the alternative spellings of operators ("==", "=>", "=<", "=~", "~=") are accepted.
+//
+// Returns:
+//   - The parsed constraint. Its String method returns the normalized form.
+//   - An error if the name, operator or version is missing or malformed.
+func ParsePackageConstraint(s string) (PackageConstraint, error) {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" {
+		return PackageConstraint{}, errorsx.MissingField(builderName, "package", "package name is required")
+	}
+
+	i := strings.IndexAny(trimmed, "=<>~")
+	if i < 0 {
+		if !packageNameRegex.MatchString(trimmed) {
+			return PackageConstraint{}, invalidPackage(s, "invalid package name %q", trimmed)
+		}
+		return PackageConstraint{Name: trimmed}, nil
+	}
+
+	c := PackageConstraint{Name: strings.TrimSpace(trimmed[:i])}
+	if c.Name == "" {
+		return PackageConstraint{}, invalidPackage(s, "missing package name")
+	}
+
+	if !packageNameRegex.MatchString(c.Name) {
+		return PackageConstraint{}, invalidPackage(s, "invalid package name %q", c.Name)
+	}
+
+	rest := trimmed[i:]
+	for _, o := range constraintOps {
+		if version, ok := strings.CutPrefix(rest, o.text); ok {
+			c.Op, c.Version = o.op, strings.TrimSpace(version)
+			break
+		}
+	}
+
+	if c.Version == "" {
+		return PackageConstraint{}, invalidPackage(s, "missing version after %q", strings.TrimSpace(rest))
+	}
+
+	if !packageVersionRegex.MatchString(c.Version) {
+		return PackageConstraint{}, invalidPackage(s, "invalid version %q", c.Version)
+	}
+
+	return c, nil
+}
+
+// invalidPackage returns the error of the malformed package 's'.
+func invalidPackage(s, format string, args ...any) error {
+	return errorsx.InvalidValue(builderName, "package", s, "invalid package %q: %s", s, fmt.Sprintf(format, args...))
+}
+
+// String returns the constraint in the normalized form apko expects (e.g. "curl>=8.5").
+func (c PackageConstraint) String() string {
+	return c.Name + string(c.Op) + c.Version
+}
+
+// Pinned reports whether the constraint pins an exact version.
+func (c PackageConstraint) Pinned() bool {
+	return c.Op == OpEqual
+}
+
+// NormalizePackages parses 'packages' with ParsePackageConstraint and returns their normalized
+// forms, in order.
+//
+// Returns:
+//   - The normalized packages.
+//   - An error naming the first malformed package.
+func NormalizePackages(packages ...string) ([]string, error) {
+	normalized := make([]string, 0, len(packages))
+	for _, p := range packages {
+		c, err := ParsePackageConstraint(p)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, c.String())
+	}
+
+	return normalized, nil
+}
+
+// NormalizeConfigPackages returns the mutation normalizing the packages of the configuration
+// with NormalizePackages, failing on malformed packages.
+func NormalizeConfigPackages() ConfigMutation {
+	return func(doc map[string]any) error {
+		contents := map[string]any{}
+		if err := decodeSection(doc, "contents", &contents); err != nil {
+			return err
+		}
+
+		var packages []string
+		if err := decodeSection(contents, "packages", &packages); err != nil {
+			return err
+		}
+
+		if len(packages) == 0 {
+			return nil
+		}
+
+		normalized, err := NormalizePackages(packages...)
+		if err != nil {
+			return err
+		}
+
+		contents["packages"] = normalized
+		doc["contents"] = contents
+
+		return nil
+	}
+}
+
+// validatePackages checks the version constraints of the appended packages, the package rules
+// and the packages of the inline configuration, so malformed pins fail before the build runs.
+// It returns the normalized appended packages, emitted with --package-append.
+func (b *ApkoBuilder) validatePackages() ([]string, error) {
+	packages, err := NormalizePackages(b.packageAppend...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range b.packageRules {
+		if _, err := ParsePackageConstraint(r.Package); err != nil {
+			return nil, err
+		}
+	}
+
+	if b.inlineConfig == "" {
+		return packages, nil
+	}
+
+	if _, err := MutateConfig([]byte(b.inlineConfig), NormalizeConfigPackages()); err != nil {
+		return nil, err
+	}
+
+	return packages, nil
+}
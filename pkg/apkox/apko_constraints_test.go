@@ -0,0 +1,139 @@
+package apkox
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+func TestParsePackageConstraint(t *testing.T) {
+	tests := []struct {
+		in   string
+		want PackageConstraint
+		norm string
+	}{
+		{in: "curl", want: PackageConstraint{Name: "curl"}, norm: "curl"},
+		{in: " curl = 8.5.0-r0 ", want: PackageConstraint{Name: "curl", Op: OpEqual, Version: "8.5.0-r0"}, norm: "curl=8.5.0-r0"},
+		{in: "curl==8.5.0-r0", want: PackageConstraint{Name: "curl", Op: OpEqual, Version: "8.5.0-r0"}, norm: "curl=8.5.0-r0"},
+		{in: "curl>=8.5", want: PackageConstraint{Name: "curl", Op: OpGreaterEqual, Version: "8.5"}, norm: "curl>=8.5"},
+		{in: "curl=>8.5", want: PackageConstraint{Name: "curl", Op: OpGreaterEqual, Version: "8.5"}, norm: "curl>=8.5"},
+		{in: "curl<9", want: PackageConstraint{Name: "curl", Op: OpLess, Version: "9"}, norm: "curl<9"},
+		{in: "curl~8.5", want: PackageConstraint{Name: "curl", Op: OpFuzzy, Version: "8.5"}, norm: "curl~8.5"},
+		{in: "go-1.22=1.22.3_git20240101-r1", want: PackageConstraint{Name: "go-1.22", Op: OpEqual, Version: "1.22.3_git20240101-r1"},
+			norm: "go-1.22=1.22.3_git20240101-r1"},
+		{in: "so:libc.so.6", want: PackageConstraint{Name: "so:libc.so.6"}, norm: "so:libc.so.6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParsePackageConstraint(tt.in)
+			if err != nil {
+				t.Fatalf("ParsePackageConstraint(%q) returned unexpected error: %v", tt.in, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("ParsePackageConstraint(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+
+			if got.String() != tt.norm {
+				t.Errorf("String() = %q, want %q", got.String(), tt.norm)
+			}
+		})
+	}
+}
+
+func TestParsePackageConstraintErrors(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr error
+		msg     string
+	}{
+		{in: "", wantErr: errorsx.ErrMissingField},
+		{in: "=8.5.0-r0", wantErr: errorsx.ErrInvalidValue, msg: "missing package name"},
+		{in: "curl=", wantErr: errorsx.ErrInvalidValue, msg: "missing version"},
+		{in: "curl=latest", wantErr: errorsx.ErrInvalidValue, msg: `invalid version "latest"`},
+		{in: "curl=8.5.0-r", wantErr: errorsx.ErrInvalidValue, msg: "invalid version"},
+		{in: "curl<>8", wantErr: errorsx.ErrInvalidValue, msg: "invalid version"},
+		{in: "cu rl", wantErr: errorsx.ErrInvalidValue, msg: "invalid package name"},
+		{in: "curl;rm -rf /", wantErr: errorsx.ErrInvalidValue, msg: "invalid package name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			_, err := ParsePackageConstraint(tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParsePackageConstraint(%q) error = %v, want %v", tt.in, err, tt.wantErr)
+			}
+
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("ParsePackageConstraint(%q) error = %v, want it to contain %q", tt.in, err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestNormalizeConfigPackages(t *testing.T) {
+	out, err := MutateConfig([]byte("contents:\n  packages: [wolfi-base, 'curl == 8.5.0-r0']\n"), NormalizeConfigPackages())
+	if err != nil {
+		t.Fatalf("MutateConfig returned unexpected error: %v", err)
+	}
+
+	want := "contents:\n    packages:\n        - wolfi-base\n        - curl=8.5.0-r0\n"
+	if string(out) != want {
+		t.Errorf("Expected config %q, got %q", want, out)
+	}
+}
+
+func TestBuildCommandValidatesPackages(t *testing.T) {
+	base := func() *ApkoBuilder {
+		return NewApkoBuilder().WithConfigFile("apko.yaml").WithOutputImage("app").WithOutputTarball("app.tar")
+	}
+
+	if _, err := base().WithPackageAppend("curl>=8.5", "jq").BuildCommand(); err != nil {
+		t.Errorf("BuildCommand returned unexpected error: %v", err)
+	}
+
+	builders := map[string]*ApkoBuilder{
+		"package append": base().WithPackageAppend("curl=latest"),
+		"package rule":   base().WithArchPackage("curl=", ArchX8664),
+		"inline config":  base().WithInlineConfig("contents:\n  packages: [curl=8.5=1]\n"),
+	}
+
+	for name, b := range builders {
+		if _, err := b.BuildCommand(); !errors.Is(err, errorsx.ErrInvalidValue) {
+			t.Errorf("%s: expected an invalid value error, got %v", name, err)
+		}
+	}
+
+	if got, err := NormalizePackages(" jq ", "curl => 8"); err != nil || !reflect.DeepEqual(got, []string{"jq", "curl>=8"}) {
+		t.Errorf("NormalizePackages() = %v, %v", got, err)
+	}
+}
+
+func TestBuildCommandEmitsPackageAppend(t *testing.T) {
+	cmd, err := NewApkoBuilder().
+		WithConfigFile("apko.yaml").
+		WithOutputImage("app").
+		WithTag("v1").
+		WithOutputTarball("app.tar").
+		WithPackageAppend("pkg=1.2.3", "curl => 8.5").
+		BuildCommand()
+	if err != nil {
+		t.Fatalf("BuildCommand returned unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"apko", "build",
+		"--package-append", "pkg=1.2.3",
+		"--package-append", "curl>=8.5",
+		"--sbom=false",
+		"--vcs=false",
+		"apko.yaml", "app:v1", "app.tar",
+	}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("Command mismatch.\nExpected: %v\nGot: %v", expected, cmd)
+	}
+}
@@ -0,0 +1,24 @@
+package apkox
+
+import "github.com/Excoriate/daggerx/pkg/types"
+
+// DebugRecipe returns the interactive container reproducing the build by hand: the apko image
+// with the mounts of the build (see Mounts and CacheMount) and its working directory, and the
+// build command, left for the user to run.
+//
+// Returns:
+//   - The debug recipe.
+//   - An error if the build command cannot be generated, see BuildCommand.
+func (b *ApkoBuilder) DebugRecipe() (types.DebugRecipe, error) {
+	cmd, err := b.BuildCommand()
+	if err != nil {
+		return types.DebugRecipe{}, err
+	}
+
+	return types.DebugRecipe{
+		Image:   ApkoDefaultRepositoryURL,
+		Workdir: b.workdir,
+		Mounts:  append(b.Mounts(), b.CacheMount()...),
+		Command: cmd,
+	}, nil
+}
@@ -0,0 +1,40 @@
+package apkox
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+func TestDebugRecipe(t *testing.T) {
+	b := NewApkoBuilder().
+		WithConfigFile("config.yaml").
+		WithOutputImage("my-image").
+		WithTag("v1").
+		WithOutputTarball("output.tar").
+		WithCacheDir("/cache").
+		WithWorkdir("/work")
+
+	recipe, err := b.DebugRecipe()
+	if err != nil {
+		t.Fatalf("DebugRecipe() unexpected error: %v", err)
+	}
+
+	cmd, _ := b.BuildCommand()
+	want := types.DebugRecipe{
+		Image:   ApkoDefaultRepositoryURL,
+		Workdir: "/work",
+		Mounts:  []types.Mount{{Kind: types.MountKindCache, Source: CacheVolumeKey, Target: "/cache"}},
+		Command: cmd,
+	}
+	if !reflect.DeepEqual(recipe, want) {
+		t.Errorf("DebugRecipe() = %+v, want %+v", recipe, want)
+	}
+
+	if _, err := NewApkoBuilder().DebugRecipe(); !errors.Is(err, errorsx.ErrMissingField) {
+		t.Errorf("DebugRecipe() error = %v, want %v", err, errorsx.ErrMissingField)
+	}
+}
@@ -0,0 +1,156 @@
+package apkox
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// DefaultPath is the PATH apko sets when the configuration doesn't declare one. PATH entries are
+// appended to or prepended to it.
+const DefaultPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// pathEnvVar is the name of the PATH environment variable.
+const pathEnvVar = "PATH"
+
+// envNameRegex matches valid environment variable names.
+var envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// EnvMode decides how an environment entry combines with the value set by the configuration.
+type EnvMode string
+
+const (
+	// EnvModeOverride replaces the configured value. It is the default mode.
+	EnvModeOverride EnvMode = "override"
+	// EnvModeDefault sets the value only when the configuration doesn't.
+	EnvModeDefault EnvMode = "default"
+	// EnvModeAppend appends the value to the configured one, separated by a colon.
+	EnvModeAppend EnvMode = "append"
+	// EnvModePrepend prepends the value to the configured one, separated by a colon.
+	EnvModePrepend EnvMode = "prepend"
+)
+
+// EnvEntry is an environment variable of the runtime image.
+type EnvEntry struct {
+	// Name is the name of the variable.
+	Name string `json:"name" yaml:"name"`
+	// Value is the value of the variable.
+	Value string `json:"value" yaml:"value"`
+	// Mode decides how the value combines with the configured one. Defaults to EnvModeOverride.
+	Mode EnvMode `json:"mode,omitempty" yaml:"mode,omitempty"`
+}
+
+// WithEnv sets the environment variable 'name' of the runtime image, replacing the value set by
+// the configuration. Environment options mutate the inline configuration (see WithInlineConfig).
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithEnv(name, value string) *ApkoBuilder {
+	return b.WithEnvEntry(EnvEntry{Name: name, Value: value})
+}
+
+// WithEnvDefault sets the environment variable 'name' of the runtime image unless the
+// configuration sets it.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithEnvDefault(name, value string) *ApkoBuilder {
+	return b.WithEnvEntry(EnvEntry{Name: name, Value: value, Mode: EnvModeDefault})
+}
+
+// WithEnvEntry adds an environment entry to the runtime image. Entries apply in order.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithEnvEntry(entry EnvEntry) *ApkoBuilder {
+	b.environment = append(b.environment, entry)
+	return b
+}
+
+// WithPathAppend appends 'dirs' to the PATH of the runtime image (DefaultPath when the
+// configuration doesn't set one).
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithPathAppend(dirs ...string) *ApkoBuilder {
+	return b.WithEnvEntry(EnvEntry{Name: pathEnvVar, Value: strings.Join(dirs, ":"), Mode: EnvModeAppend})
+}
+
+// WithPathPrepend prepends 'dirs' to the PATH of the runtime image, so binaries in 'dirs' take
+// precedence.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithPathPrepend(dirs ...string) *ApkoBuilder {
+	return b.WithEnvEntry(EnvEntry{Name: pathEnvVar, Value: strings.Join(dirs, ":"), Mode: EnvModePrepend})
+}
+
+// MergeEnvironment returns the mutation applying 'entries', in order, to the environment section
+// of the configuration. Entries appending or prepending to an unset PATH start from DefaultPath.
+func MergeEnvironment(entries ...EnvEntry) ConfigMutation {
+	return func(doc map[string]any) error {
+		for _, e := range entries {
+			if err := e.validate(); err != nil {
+				return err
+			}
+		}
+
+		env := map[string]string{}
+		if err := decodeSection(doc, "environment", &env); err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			current, ok := env[e.Name]
+			if !ok && e.Name == pathEnvVar {
+				current, ok = DefaultPath, true
+			}
+
+			switch e.Mode {
+			case EnvModeDefault:
+				if !ok {
+					env[e.Name] = e.Value
+				}
+			case EnvModeAppend:
+				env[e.Name] = joinList(current, e.Value)
+			case EnvModePrepend:
+				env[e.Name] = joinList(e.Value, current)
+			default:
+				env[e.Name] = e.Value
+			}
+		}
+
+		doc["environment"] = env
+
+		return nil
+	}
+}
+
+// validate checks the name, mode and, for PATH, the directories of the entry.
+func (e EnvEntry) validate() error {
+	if !envNameRegex.MatchString(e.Name) {
+		return errorsx.InvalidValue(builderName, "environment", e.Name, "invalid environment variable name: %q", e.Name)
+	}
+
+	switch e.Mode {
+	case "", EnvModeOverride, EnvModeDefault, EnvModeAppend, EnvModePrepend:
+	default:
+		return errorsx.Unsupported(builderName, "environment", e.Mode, "unsupported environment mode %s for %s", e.Mode, e.Name)
+	}
+
+	if e.Name != pathEnvVar {
+		return nil
+	}
+
+	for _, dir := range strings.Split(e.Value, ":") {
+		if !path.IsAbs(dir) {
+			return errorsx.InvalidValue(builderName, "environment", dir, "PATH entry must be an absolute directory: %q", dir)
+		}
+	}
+
+	return nil
+}
+
+// joinList joins two colon-separated lists, skipping empty ones.
+func joinList(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	default:
+		return a + ":" + b
+	}
+}
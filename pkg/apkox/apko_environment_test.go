@@ -0,0 +1,151 @@
+package apkox
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"gopkg.in/yaml.v3"
+)
+
+// decodeEnvironment returns the environment section of a YAML configuration.
+func decodeEnvironment(t *testing.T, config string) map[string]string {
+	t.Helper()
+
+	var doc struct {
+		Environment map[string]string `yaml:"environment"`
+	}
+	if err := yaml.Unmarshal([]byte(config), &doc); err != nil {
+		t.Fatalf("Failed to decode config: %v", err)
+	}
+
+	return doc.Environment
+}
+
+func TestMergeEnvironment(t *testing.T) {
+	config := `environment:
+  LANG: C.UTF-8
+  TZ: UTC
+  SSL_CERT_DIR: /etc/ssl/certs
+`
+
+	tests := []struct {
+		name    string
+		entries []EnvEntry
+		want    map[string]string
+	}{
+		{
+			name:    "Override",
+			entries: []EnvEntry{{Name: "TZ", Value: "Europe/Madrid"}, {Name: "APP_ENV", Value: "prod", Mode: EnvModeOverride}},
+			want:    map[string]string{"LANG": "C.UTF-8", "TZ": "Europe/Madrid", "SSL_CERT_DIR": "/etc/ssl/certs", "APP_ENV": "prod"},
+		},
+		{
+			name:    "Default",
+			entries: []EnvEntry{{Name: "TZ", Value: "Europe/Madrid", Mode: EnvModeDefault}, {Name: "APP_ENV", Value: "prod", Mode: EnvModeDefault}},
+			want:    map[string]string{"LANG": "C.UTF-8", "TZ": "UTC", "SSL_CERT_DIR": "/etc/ssl/certs", "APP_ENV": "prod"},
+		},
+		{
+			name: "Append and prepend",
+			entries: []EnvEntry{
+				{Name: "SSL_CERT_DIR", Value: "/app/certs", Mode: EnvModeAppend},
+				{Name: "SSL_CERT_DIR", Value: "/run/certs", Mode: EnvModePrepend},
+				{Name: "LD_LIBRARY_PATH", Value: "/app/lib", Mode: EnvModeAppend},
+			},
+			want: map[string]string{
+				"LANG": "C.UTF-8", "TZ": "UTC",
+				"SSL_CERT_DIR":    "/run/certs:/etc/ssl/certs:/app/certs",
+				"LD_LIBRARY_PATH": "/app/lib",
+			},
+		},
+		{
+			name: "PATH from default",
+			entries: []EnvEntry{
+				{Name: "PATH", Value: "/app/bin", Mode: EnvModePrepend},
+				{Name: "PATH", Value: "/opt/tools/bin", Mode: EnvModeAppend},
+			},
+			want: map[string]string{
+				"LANG": "C.UTF-8", "TZ": "UTC", "SSL_CERT_DIR": "/etc/ssl/certs",
+				"PATH": "/app/bin:" + DefaultPath + ":/opt/tools/bin",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := MutateConfig([]byte(config), MergeEnvironment(tt.entries...))
+			if err != nil {
+				t.Fatalf("MutateConfig returned unexpected error: %v", err)
+			}
+
+			if got := decodeEnvironment(t, string(out)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Environment = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeEnvironmentValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry EnvEntry
+		kind  error
+	}{
+		{"Invalid name", EnvEntry{Name: "APP-ENV", Value: "prod"}, errorsx.ErrInvalidValue},
+		{"Unknown mode", EnvEntry{Name: "APP_ENV", Value: "prod", Mode: "merge"}, errorsx.ErrUnsupported},
+		{"Relative PATH entry", EnvEntry{Name: "PATH", Value: "/app/bin:bin", Mode: EnvModeAppend}, errorsx.ErrInvalidValue},
+		{"Empty PATH entry", EnvEntry{Name: "PATH", Mode: EnvModePrepend}, errorsx.ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MutateConfig([]byte(testInlineConfig), MergeEnvironment(tt.entry))
+			if !errors.Is(err, tt.kind) {
+				t.Fatalf("Expected %v, got %v", tt.kind, err)
+			}
+
+			if field, _ := errorsx.FieldOf(err); field != "environment" {
+				t.Errorf("Expected field environment, got %q", field)
+			}
+		})
+	}
+}
+
+func TestApkoBuilderWithEnvironment(t *testing.T) {
+	b := NewApkoBuilder().
+		WithConfigPreset(ConfigPresetStatic).
+		WithOutputImage("my-image").
+		WithTag("v1").
+		WithOutputTarball("output.tar").
+		WithEnv("APP_ENV", "prod").
+		WithEnvDefault("TZ", "UTC").
+		WithPathPrepend("/app/bin", "/app/scripts")
+
+	if _, err := b.BuildCommand(); err != nil {
+		t.Fatalf("BuildCommand() returned unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"APP_ENV": "prod",
+		"TZ":      "UTC",
+		"PATH":    "/app/bin:/app/scripts:" + DefaultPath,
+	}
+	if got := decodeEnvironment(t, b.Mounts()[0].Content); !reflect.DeepEqual(got, want) {
+		t.Errorf("Environment = %v, want %v", got, want)
+	}
+
+	if !reflect.DeepEqual(NewApkoBuilderFromSpec(b.Spec()).Mounts(), b.Mounts()) {
+		t.Error("Expected the environment to survive a spec round trip")
+	}
+
+	_, err := NewApkoBuilder().
+		WithConfigFile("apko.yaml").
+		WithOutputImage("my-image").
+		WithTag("v1").
+		WithOutputTarball("output.tar").
+		WithPathAppend("/app/bin").
+		BuildCommand()
+	if !errors.Is(err, errorsx.ErrConflictingOptions) {
+		t.Errorf("Expected a conflicting options error, got %v", err)
+	}
+}
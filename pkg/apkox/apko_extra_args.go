@@ -0,0 +1,195 @@
+package apkox
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+)
+
+// ExtraArgsPolicy decides how BuildCommand handles extra arguments duplicating a flag managed by
+// a typed option, e.g. WithExtraArg("--arch=aarch64") together with WithArchitecture("x86_64").
+type ExtraArgsPolicy string
+
+const (
+	// ExtraArgsWarn keeps both the typed flag and the extra argument and logs a warning.
+	// It is the default policy.
+	ExtraArgsWarn ExtraArgsPolicy = "warn"
+	// ExtraArgsError makes BuildCommand fail.
+	ExtraArgsError ExtraArgsPolicy = "error"
+	// ExtraArgsPreferTyped drops the duplicating extra argument.
+	ExtraArgsPreferTyped ExtraArgsPolicy = "prefer-typed"
+	// ExtraArgsPreferExtra drops the typed flag.
+	ExtraArgsPreferExtra ExtraArgsPolicy = "prefer-extra"
+)
+
+// managedFlags maps the flags emitted by typed options to the builder field setting them.
+var managedFlags = map[string]string{
+	"--cache-dir":               "cacheDir",
+	"--keyring-append":          "keyringPaths",
+	"--arch":                    "buildArch",
+	"--build-repository-append": "buildRepositoryAppend",
+	"--repository-append":       "repositoryAppend",
+	"--package-append":          "packageAppend",
+	"--layering-strategy":       "layeringStrategy",
+	"--layering-budget":         "layeringBudget",
+	"--sbom":                    "sbom",
+	"--sbom-path":               "sbomPath",
+	"--sbom-formats":            "sbomFormats",
+	"--vcs":                     "vcs",
+	"--offline":                 "offline",
+	"--log-level":               "logLevel",
+	"--log-policy":              "logPolicy",
+	"--build-date":              "buildDate",
+	"--lockfile":                "lockfile",
+	"--annotations":             "annotations",
+	"--image-refs":              "imageRefs",
+	"--ignore-signatures":       "insecure",
+}
+
+// shortFlags maps the short forms of the managed flags to their long forms.
+var shortFlags = map[string]string{
+	"-k": "--keyring-append",
+	"-r": "--repository-append",
+	"-p": "--package-append",
+}
+
+// longFlag returns the long form of the flag 'name', which may be a short form (see shortFlags).
+func longFlag(name string) string {
+	if long, ok := shortFlags[name]; ok {
+		return long
+	}
+
+	return name
+}
+
+// booleanFlags are the managed flags without a separate value argument.
+var booleanFlags = map[string]bool{
+	"--sbom":              true,
+	"--vcs":               true,
+	"--offline":           true,
+	"--ignore-signatures": true,
+}
+
+// WithExtraArgsPolicy sets how extra arguments duplicating typed options are handled.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithExtraArgsPolicy(policy ExtraArgsPolicy) *ApkoBuilder {
+	b.extraArgsPolicy = policy
+	return b
+}
+
+// flagGroup is a flag with its value arguments.
+type flagGroup struct {
+	name string
+	args []string
+}
+
+// groupFlags splits arguments into flags with their values. Groups are named after the long form
+// of their flag ("-k" is "--keyring-append"). A managed non-boolean flag without an inline value
+// ("--arch x86_64") takes the following argument as its value.
+//
+// Groups share the backing array of 'args', capped so appending to them copies.
+func groupFlags(args []string) []flagGroup {
+	groups := make([]flagGroup, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		name, _, inline := strings.Cut(args[i], "=")
+		name = longFlag(name)
+		start := i
+
+		_, managed := managedFlags[name]
+		if managed && !inline && !booleanFlags[name] && i+1 < len(args) {
+			i++
+		}
+
+		groups = append(groups, flagGroup{name: name, args: args[start : i+1 : i+1]})
+	}
+
+	return groups
+}
+
+// flattenFlags joins flag groups back into arguments, skipping the flags in 'drop'.
+func flattenFlags(groups []flagGroup, drop map[string]bool) []string {
+	args := make([]string, 0, len(groups))
+	for _, g := range groups {
+		if !drop[g.name] {
+			args = append(args, g.args...)
+		}
+	}
+
+	return args
+}
+
+// resolveExtraArgs applies the extra arguments policy to the typed flags and the extra arguments.
+//
+// Returns:
+//   - The typed flags and the extra arguments to emit.
+//   - The warnings of the extra arguments duplicating typed flags, under ExtraArgsWarn.
+//   - An error if the policy is ExtraArgsError and an extra argument duplicates a typed flag,
+//     or if the policy is unknown.
+func (b *ApkoBuilder) resolveExtraArgs(
+	ctx context.Context,
+	typed []string,
+) ([]string, []string, []errorsx.Warning, error) {
+	policy := b.extraArgsPolicy
+	if policy == "" {
+		policy = ExtraArgsWarn
+	}
+
+	switch policy {
+	case ExtraArgsWarn, ExtraArgsError, ExtraArgsPreferTyped, ExtraArgsPreferExtra:
+	default:
+		return nil, nil, nil, errorsx.Unsupported(builderName, "extraArgsPolicy", policy, "unsupported extra args policy: %s", policy)
+	}
+
+	// Without extra arguments there is nothing to dedupe; skip grouping the typed flags.
+	if len(b.extraArgs) == 0 {
+		return typed, nil, nil, nil
+	}
+
+	typedGroups := groupFlags(typed)
+	emitted := map[string]bool{}
+	for _, g := range typedGroups {
+		emitted[g.name] = true
+	}
+
+	extraGroups := groupFlags(b.extraArgs)
+	conflicts := map[string]bool{}
+	for _, g := range extraGroups {
+		if emitted[g.name] {
+			conflicts[g.name] = true
+		}
+	}
+
+	if len(conflicts) == 0 {
+		return typed, b.extraArgs, nil, nil
+	}
+
+	var warnings []errorsx.Warning
+	for _, g := range extraGroups {
+		if !conflicts[g.name] {
+			continue
+		}
+
+		switch policy {
+		case ExtraArgsError:
+			return nil, nil, nil, errorsx.ConflictingOptions(builderName, []string{managedFlags[g.name], "extraArgs"},
+				"extra argument %s duplicates a flag managed by a typed option", g.name)
+		case ExtraArgsWarn:
+			logx.Warn(ctx, b.logger, builderName, "extra argument duplicates a typed option", "flag", g.name)
+			warnings = append(warnings, errorsx.NewWarning(builderName, managedFlags[g.name],
+				"extra argument %s duplicates a typed option", g.name))
+		}
+	}
+
+	switch policy {
+	case ExtraArgsPreferTyped:
+		return typed, flattenFlags(extraGroups, conflicts), warnings, nil
+	case ExtraArgsPreferExtra:
+		return flattenFlags(typedGroups, conflicts), b.extraArgs, warnings, nil
+	default:
+		// Warned about above; emit both.
+		return typed, b.extraArgs, warnings, nil
+	}
+}
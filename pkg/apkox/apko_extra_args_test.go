@@ -0,0 +1,179 @@
+package apkox
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+func TestApkoBuilderExtraArgsPolicy(t *testing.T) {
+	both := []string{
+		"apko", "build", "--keyring-append", "/keys/a.rsa.pub", "--arch", "x86_64",
+		"config.yaml", "my-image:v1", "output.tar", "--arch", "aarch64", "--debug",
+	}
+
+	tests := []struct {
+		name       string
+		policy     ExtraArgsPolicy
+		want       []string
+		wantLog    string
+		wantErr    error
+		wantFields []string
+	}{
+		{
+			name:    "Default keeps both and warns",
+			policy:  "",
+			want:    both,
+			wantLog: "flag=--arch",
+		},
+		{
+			name:    "Warn keeps both and warns",
+			policy:  ExtraArgsWarn,
+			want:    both,
+			wantLog: "flag=--arch",
+		},
+		{
+			name:   "Prefer typed",
+			policy: ExtraArgsPreferTyped,
+			want: []string{
+				"apko", "build", "--keyring-append", "/keys/a.rsa.pub", "--arch", "x86_64",
+				"config.yaml", "my-image:v1", "output.tar", "--debug",
+			},
+		},
+		{
+			name:   "Prefer extra",
+			policy: ExtraArgsPreferExtra,
+			want: []string{
+				"apko", "build", "--keyring-append", "/keys/a.rsa.pub",
+				"config.yaml", "my-image:v1", "output.tar", "--arch", "aarch64", "--debug",
+			},
+		},
+		{
+			name:       "Error",
+			policy:     ExtraArgsError,
+			wantErr:    errorsx.ErrConflictingOptions,
+			wantFields: []string{"buildArch", "extraArgs"},
+		},
+		{
+			name:    "Unknown policy",
+			policy:  "ignore",
+			wantErr: errorsx.ErrUnsupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			cmd, err := newTestBuilder().
+				WithArchitecture("x86_64").
+				WithKeyring("/keys/a.rsa.pub").
+				WithExtraArg("--arch").
+				WithExtraArg("aarch64").
+				WithExtraArg("--debug").
+				WithExtraArgsPolicy(tt.policy).
+				WithLogger(slog.New(slog.NewTextHandler(&buf, nil))).
+				BuildCommand()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("BuildCommand() error = %v, want %v", err, tt.wantErr)
+				}
+
+				var e *errorsx.Error
+				if tt.wantFields != nil && (!errors.As(err, &e) || !reflect.DeepEqual(e.Fields, tt.wantFields)) {
+					t.Errorf("BuildCommand() error = %v, want the fields %v", err, tt.wantFields)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("BuildCommand() returned unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(cmd, tt.want) {
+				t.Errorf("BuildCommand() = %v, want %v", cmd, tt.want)
+			}
+
+			if !strings.Contains(buf.String(), tt.wantLog) {
+				t.Errorf("Expected %q in log output:\n%s", tt.wantLog, buf.String())
+			}
+		})
+	}
+
+	// Inline values and boolean flags are detected too.
+	_, err := newTestBuilder().
+		WithSBOM(false).
+		WithExtraArg("--sbom=true").
+		WithExtraArgsPolicy(ExtraArgsError).
+		BuildCommand()
+	if !errors.Is(err, errorsx.ErrConflictingOptions) {
+		t.Errorf("BuildCommand() error = %v, want %v", err, errorsx.ErrConflictingOptions)
+	}
+}
+
+func TestApkoBuilderExtraArgsShortFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		builder    *ApkoBuilder
+		extraArgs  []string
+		wantFields []string
+	}{
+		{
+			name:       "Keyring",
+			builder:    newTestBuilder().WithKeyring("/keys/a.rsa.pub"),
+			extraArgs:  []string{"-k", "/x.pub"},
+			wantFields: []string{"keyringPaths", "extraArgs"},
+		},
+		{
+			name:       "Repository",
+			builder:    newTestBuilder().WithRepositoryAppend("https://packages.wolfi.dev/os"),
+			extraArgs:  []string{"-r", "https://packages.example.com/os"},
+			wantFields: []string{"repositoryAppend", "extraArgs"},
+		},
+		{
+			name:       "Inline repository",
+			builder:    newTestBuilder().WithRepositoryAppend("https://packages.wolfi.dev/os"),
+			extraArgs:  []string{"-r=https://packages.example.com/os"},
+			wantFields: []string{"repositoryAppend", "extraArgs"},
+		},
+		{
+			name:       "Package",
+			builder:    newTestBuilder().WithPackageAppend("curl"),
+			extraArgs:  []string{"-p", "jq"},
+			wantFields: []string{"packageAppend", "extraArgs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.builder.Clone()
+			for _, arg := range tt.extraArgs {
+				b.WithExtraArg(arg)
+			}
+
+			_, err := b.Clone().WithExtraArgsPolicy(ExtraArgsError).BuildCommand()
+			var e *errorsx.Error
+			if !errors.As(err, &e) || !errors.Is(err, errorsx.ErrConflictingOptions) ||
+				!reflect.DeepEqual(e.Fields, tt.wantFields) {
+				t.Fatalf("BuildCommand() error = %v, want a conflict on %v", err, tt.wantFields)
+			}
+
+			cmd, err := b.WithExtraArgsPolicy(ExtraArgsPreferTyped).BuildCommand()
+			if err != nil {
+				t.Fatalf("BuildCommand() returned unexpected error: %v", err)
+			}
+
+			for _, arg := range tt.extraArgs {
+				if slices.Contains(cmd, arg) {
+					t.Errorf("BuildCommand() = %v, want the extra argument %q dropped", cmd, arg)
+				}
+			}
+		})
+	}
+}
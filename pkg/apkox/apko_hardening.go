@@ -0,0 +1,265 @@
+package apkox
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/mapx"
+)
+
+const (
+	// HardeningNonRoot is the check running the image as a user other than root.
+	HardeningNonRoot = "nonRoot"
+	// HardeningNoShell is the check removing the shells from the image.
+	HardeningNoShell = "noShell"
+	// HardeningNoPackageManager is the check removing apk-tools from the image.
+	HardeningNoPackageManager = "noPackageManager"
+	// HardeningLabels is the check setting the required labels (annotations) of the image.
+	HardeningLabels = "labels"
+)
+
+// nonRootName is the name of the NonRootID account hardened images run as.
+const nonRootName = "nonroot"
+
+var (
+	// shellPackages are the packages providing a shell.
+	shellPackages = []string{"busybox", "bash", "dash", "zsh", "mksh", "toybox"}
+
+	// packageManagerPackages are the packages providing the apk package manager.
+	packageManagerPackages = []string{"apk-tools"}
+
+	// basePackages maps the base meta-packages, which pull a shell and apk-tools in, to the base
+	// layouts replacing them in hardened images.
+	basePackages = map[string]string{
+		"wolfi-base":  "wolfi-baselayout",
+		"alpine-base": "alpine-baselayout",
+	}
+
+	// shellPrefixes are the entrypoint commands running a shell.
+	shellPrefixes = []string{"/bin/sh", "/bin/bash", "/bin/ash", "/bin/dash", "/bin/zsh", "/usr/bin/bash"}
+)
+
+// HardeningPreset is the checklist enforced by ApplyHardeningPreset.
+type HardeningPreset struct {
+	// AllowedShells are the shell packages kept in the image (e.g. "busybox" for debug images).
+	// Keeping a shell also keeps the base meta-packages and a shell entrypoint.
+	AllowedShells []string `json:"allowedShells,omitempty" yaml:"allowedShells,omitempty"`
+	// RequiredLabels are the labels (annotations) the image must carry, with the value set when
+	// the configuration doesn't have the label. Labels without a default value can't be fixed.
+	RequiredLabels map[string]string `json:"requiredLabels,omitempty" yaml:"requiredLabels,omitempty"`
+	// Strict reports the violations as an error instead of fixing them.
+	Strict bool `json:"strict,omitempty" yaml:"strict,omitempty"`
+}
+
+// DefaultHardeningPreset returns the minimum hardening checklist: a non-root user, no shell, no
+// package manager, and an "org.opencontainers.image.source" label, which has no default value.
+func DefaultHardeningPreset() HardeningPreset {
+	return HardeningPreset{
+		RequiredLabels: map[string]string{"org.opencontainers.image.source": ""},
+	}
+}
+
+// ApplyHardeningPreset enforces the checklist 'preset' on the YAML configuration 'config':
+//   - the image runs as a user other than root, adding the "nonroot" account (UID 65532) of
+//     ConfigPresetStatic when it runs as root;
+//   - shell packages are removed unless allowed, base meta-packages (wolfi-base, alpine-base) are
+//     replaced by their base layouts, and shell entrypoints are removed;
+//   - apk-tools is removed;
+//   - the required labels are set in the annotations.
+//
+// Returns:
+//   - The hardened configuration, see MutateConfig.
+//   - The changes made, as warnings naming the check in their field.
+//   - An error if the configuration is invalid, a required label has no default value, or, in
+//     strict mode, the checklist is violated: the error then holds the changes as ErrWarning
+//     errors (see errorsx.Strict).
+func ApplyHardeningPreset(config []byte, preset HardeningPreset) ([]byte, []errorsx.Warning, error) {
+	var changes []errorsx.Warning
+
+	out, err := MutateConfig(config, Harden(preset, &changes))
+	if err != nil {
+		return nil, changes, err
+	}
+
+	if preset.Strict && len(changes) > 0 {
+		return nil, changes, errorsx.Strict(changes)
+	}
+
+	return out, changes, nil
+}
+
+// Harden returns the mutation applying the checklist 'preset' as ApplyHardeningPreset does, and
+// appending the changes to 'changes' when it isn't nil. It ignores the strict mode of 'preset'.
+func Harden(preset HardeningPreset, changes *[]errorsx.Warning) ConfigMutation {
+	return func(doc map[string]any) error {
+		h := &hardener{preset: preset}
+
+		for _, check := range []func(map[string]any) error{h.nonRoot, h.packages, h.entrypoint, h.labels} {
+			if err := check(doc); err != nil {
+				return err
+			}
+		}
+
+		if changes != nil {
+			*changes = append(*changes, h.changes...)
+		}
+
+		return nil
+	}
+}
+
+// hardener applies a hardening preset to a configuration document, recording its changes.
+type hardener struct {
+	preset  HardeningPreset
+	changes []errorsx.Warning
+}
+
+// change records a change of the check 'check'.
+func (h *hardener) change(check, format string, args ...any) {
+	h.changes = append(h.changes, errorsx.NewWarning(builderName, check, format, args...))
+}
+
+// shellAllowed reports whether the preset keeps a shell.
+func (h *hardener) shellAllowed() bool {
+	return len(h.preset.AllowedShells) > 0
+}
+
+// nonRoot runs the image as the nonroot account when it runs as root.
+func (h *hardener) nonRoot(doc map[string]any) error {
+	var accounts Accounts
+	if err := decodeSection(doc, "accounts", &accounts); err != nil {
+		return err
+	}
+
+	uid, err := accounts.runAsUID()
+	if err != nil {
+		return err
+	}
+
+	if uid != 0 {
+		return nil
+	}
+
+	if !slices.ContainsFunc(accounts.Groups, func(g Group) bool { return g.GroupName == nonRootName }) {
+		accounts.Groups = append(accounts.Groups, Group{GroupName: nonRootName, GID: NonRootID})
+	}
+
+	if !slices.ContainsFunc(accounts.Users, func(u User) bool { return u.UserName == nonRootName }) {
+		accounts.Users = append(accounts.Users, User{UserName: nonRootName, UID: NonRootID, GID: NonRootID})
+	}
+
+	accounts.RunAs = nonRootName
+	if err := accounts.validateReferences(); err != nil {
+		return err
+	}
+
+	doc["accounts"] = accounts
+	h.change(HardeningNonRoot, "image ran as root, now runs as %s (UID %d)", nonRootName, NonRootID)
+
+	return nil
+}
+
+// packages removes the shells, unless allowed, and the package manager.
+func (h *hardener) packages(doc map[string]any) error {
+	contents := map[string]any{}
+	if err := decodeSection(doc, "contents", &contents); err != nil {
+		return err
+	}
+
+	var packages []string
+	if err := decodeSection(contents, "packages", &packages); err != nil {
+		return err
+	}
+
+	hardened := make([]string, 0, len(packages))
+	for _, p := range packages {
+		name := packageName(p)
+
+		switch {
+		case slices.Contains(packageManagerPackages, name):
+			h.change(HardeningNoPackageManager, "removed package %s", p)
+		case slices.Contains(shellPackages, name) && !slices.Contains(h.preset.AllowedShells, name):
+			h.change(HardeningNoShell, "removed package %s", p)
+		case basePackages[name] != "" && !h.shellAllowed():
+			layout := basePackages[name]
+			h.change(HardeningNoShell, "replaced package %s, which pulls a shell and apk-tools in, with %s", p, layout)
+			if !slices.ContainsFunc(packages, func(o string) bool { return packageName(o) == layout }) &&
+				!slices.Contains(hardened, layout) {
+				hardened = append(hardened, layout)
+			}
+		default:
+			hardened = append(hardened, p)
+		}
+	}
+
+	if slices.Equal(hardened, packages) {
+		return nil
+	}
+
+	contents["packages"] = hardened
+	doc["contents"] = contents
+
+	return nil
+}
+
+// entrypoint removes a shell entrypoint, unless a shell is allowed.
+func (h *hardener) entrypoint(doc map[string]any) error {
+	if h.shellAllowed() {
+		return nil
+	}
+
+	var entrypoint struct {
+		Command string `yaml:"command"`
+	}
+	if err := decodeSection(doc, "entrypoint", &entrypoint); err != nil {
+		return err
+	}
+
+	command := strings.Fields(entrypoint.Command)
+	if len(command) == 0 || !slices.Contains(shellPrefixes, command[0]) {
+		return nil
+	}
+
+	delete(doc, "entrypoint")
+	h.change(HardeningNoShell, "removed the shell entrypoint %q", entrypoint.Command)
+
+	return nil
+}
+
+// labels sets the required labels missing from the annotations.
+func (h *hardener) labels(doc map[string]any) error {
+	if len(h.preset.RequiredLabels) == 0 {
+		return nil
+	}
+
+	annotations := map[string]string{}
+	if err := decodeSection(doc, "annotations", &annotations); err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, label := range mapx.SortedKeys(h.preset.RequiredLabels) {
+		if _, ok := annotations[label]; ok {
+			continue
+		}
+
+		value := h.preset.RequiredLabels[label]
+		if value == "" {
+			missing = append(missing, label)
+			continue
+		}
+
+		annotations[label] = value
+		h.change(HardeningLabels, "set label %s to %q", label, value)
+	}
+
+	if len(missing) > 0 {
+		return errorsx.MissingField(builderName, "annotations",
+			"image must carry the labels %s", strings.Join(missing, ", "))
+	}
+
+	doc["annotations"] = annotations
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package apkox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"gopkg.in/yaml.v3"
+)
+
+func TestApplyHardeningPreset(t *testing.T) {
+	config := []byte(`contents:
+  packages:
+    - wolfi-base
+    - apk-tools
+    - bash=5.2-r0
+    - curl
+entrypoint:
+  command: /bin/sh -l
+`)
+
+	preset := HardeningPreset{RequiredLabels: map[string]string{"org.opencontainers.image.vendor": "acme"}}
+
+	out, changes, err := ApplyHardeningPreset(config, preset)
+	if err != nil {
+		t.Fatalf("ApplyHardeningPreset() error = %v", err)
+	}
+
+	var doc struct {
+		Contents struct {
+			Packages []string `yaml:"packages"`
+		} `yaml:"contents"`
+		Accounts    Accounts          `yaml:"accounts"`
+		Entrypoint  map[string]any    `yaml:"entrypoint"`
+		Annotations map[string]string `yaml:"annotations"`
+	}
+	if err := yaml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("failed to decode hardened config: %v", err)
+	}
+
+	if got := strings.Join(doc.Contents.Packages, ","); got != "wolfi-baselayout,curl" {
+		t.Errorf("packages = %s, want wolfi-baselayout,curl", got)
+	}
+
+	if doc.Accounts.RunAs != "nonroot" || len(doc.Accounts.Users) != 1 || doc.Accounts.Users[0].UID != NonRootID {
+		t.Errorf("accounts = %+v, want the nonroot account", doc.Accounts)
+	}
+
+	if doc.Entrypoint != nil {
+		t.Errorf("entrypoint = %v, want none", doc.Entrypoint)
+	}
+
+	if doc.Annotations["org.opencontainers.image.vendor"] != "acme" {
+		t.Errorf("annotations = %v, want the vendor label", doc.Annotations)
+	}
+
+	checks := map[string]int{}
+	for _, c := range changes {
+		checks[c.Field]++
+	}
+
+	want := map[string]int{HardeningNonRoot: 1, HardeningNoShell: 3, HardeningNoPackageManager: 1, HardeningLabels: 1}
+	for check, n := range want {
+		if checks[check] != n {
+			t.Errorf("changes of %s = %d, want %d (%v)", check, checks[check], n, changes)
+		}
+	}
+}
+
+func TestApplyHardeningPresetAllowedShell(t *testing.T) {
+	config := []byte(`contents:
+  packages:
+    - wolfi-base
+    - busybox
+    - bash
+entrypoint:
+  command: /bin/sh -l
+accounts:
+  run-as: 65532
+  users:
+    - username: nonroot
+      uid: 65532
+`)
+
+	out, changes, err := ApplyHardeningPreset(config, HardeningPreset{AllowedShells: []string{"busybox"}})
+	if err != nil {
+		t.Fatalf("ApplyHardeningPreset() error = %v", err)
+	}
+
+	if len(changes) != 1 || changes[0].Field != HardeningNoShell || !strings.Contains(changes[0].Message, "bash") {
+		t.Errorf("changes = %v, want the removal of bash", changes)
+	}
+
+	for _, want := range []string{"wolfi-base", "busybox", "/bin/sh -l"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("hardened config lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestApplyHardeningPresetStrict(t *testing.T) {
+	config := []byte(`contents:
+  packages:
+    - apk-tools
+`)
+
+	_, changes, err := ApplyHardeningPreset(config, HardeningPreset{Strict: true})
+	if !errors.Is(err, errorsx.ErrWarning) {
+		t.Fatalf("ApplyHardeningPreset() error = %v, want ErrWarning", err)
+	}
+
+	if len(changes) != 2 {
+		t.Errorf("changes = %v, want the run-as and apk-tools violations", changes)
+	}
+
+	hardened := []byte(`accounts:
+  run-as: "65532"
+contents:
+  packages:
+    - wolfi-baselayout
+`)
+
+	out, changes, err := ApplyHardeningPreset(hardened, HardeningPreset{Strict: true})
+	if err != nil || len(changes) != 0 {
+		t.Fatalf("ApplyHardeningPreset() = %v, %v, want no violation", changes, err)
+	}
+
+	if len(out) == 0 {
+		t.Error("ApplyHardeningPreset() returned an empty config")
+	}
+}
+
+func TestApplyHardeningPresetRequiredLabel(t *testing.T) {
+	config := []byte(`accounts:
+  run-as: "65532"
+`)
+
+	_, _, err := ApplyHardeningPreset(config, DefaultHardeningPreset())
+	if !errors.Is(err, errorsx.ErrMissingField) {
+		t.Fatalf("ApplyHardeningPreset() error = %v, want ErrMissingField", err)
+	}
+
+	labelled := append(config, []byte(`annotations:
+  org.opencontainers.image.source: https://github.com/acme/app
+`)...)
+
+	if _, changes, err := ApplyHardeningPreset(labelled, DefaultHardeningPreset()); err != nil || len(changes) != 0 {
+		t.Errorf("ApplyHardeningPreset() = %v, %v, want no change", changes, err)
+	}
+}
@@ -0,0 +1,208 @@
+package apkox
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"gopkg.in/yaml.v3"
+)
+
+// includeKeys are the top-level keys naming the configurations a configuration builds upon:
+// apko's "include", a single path, and "extends", a path or a list of paths.
+var includeKeys = []string{"include", "extends"}
+
+// ResolvedConfig is an APKO configuration with its include chain flattened.
+type ResolvedConfig struct {
+	// Config is the effective YAML configuration, without include keys. Keys are sorted and
+	// comments are not preserved.
+	Config []byte `json:"config"`
+	// Sources are the paths of the merged configurations, in merge order: included
+	// configurations first, the resolved configuration last.
+	Sources []string `json:"sources"`
+}
+
+// configReader reads the configurations of an include chain.
+type configReader struct {
+	// read reads the configuration at a path.
+	read func(name string) ([]byte, error)
+	// join resolves an include of the configuration 'from'.
+	join func(from, include string) string
+}
+
+// ResolveConfig reads the APKO configuration at 'path' and flattens its include chain. Included
+// paths are relative to the directory of the including configuration.
+//
+// Configurations are merged in order, each over the configurations it includes: mappings are
+// merged key by key, lists are concatenated without duplicates, and other values replace the
+// included ones. The result is what apko builds, so it can be validated, diffed (see specdiff),
+// or passed to WithInlineConfig.
+//
+// Returns:
+//   - The resolved configuration.
+//   - An error if a configuration cannot be read or parsed, an include is invalid, or the chain
+//     includes a configuration from itself.
+func ResolveConfig(path string) (*ResolvedConfig, error) {
+	return configReader{
+		read: os.ReadFile,
+		join: func(from, include string) string {
+			if filepath.IsAbs(include) {
+				return filepath.Clean(include)
+			}
+
+			return filepath.Join(filepath.Dir(from), include)
+		},
+	}.resolve(filepath.Clean(path))
+}
+
+// ResolveConfigFS is ResolveConfig for configurations read from 'fsys'. Paths are slash-separated
+// and relative to the root of 'fsys'.
+func ResolveConfigFS(fsys fs.FS, name string) (*ResolvedConfig, error) {
+	return configReader{
+		read: func(name string) ([]byte, error) {
+			return fs.ReadFile(fsys, name)
+		},
+		join: func(from, include string) string {
+			return path.Join(path.Dir(from), include)
+		},
+	}.resolve(path.Clean(name))
+}
+
+// resolve flattens the include chain of the configuration 'name'.
+func (r configReader) resolve(name string) (*ResolvedConfig, error) {
+	resolved := &ResolvedConfig{}
+
+	doc, err := r.load(name, nil, resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, errorsx.InvalidValue(builderName, "config", name, "failed to encode config: %w", err)
+	}
+
+	resolved.Config = out
+
+	return resolved, nil
+}
+
+// load returns the configuration 'name' merged over its includes. 'stack' holds the
+// configurations including it, to detect cycles, and the merged configurations are appended to
+// the sources of 'resolved'.
+func (r configReader) load(name string, stack []string, resolved *ResolvedConfig) (map[string]any, error) {
+	stack = append(stack, name)
+	if slices.Contains(stack[:len(stack)-1], name) {
+		return nil, errorsx.InvalidValue(builderName, "include", name,
+			"config include cycle: %s", strings.Join(stack, " -> "))
+	}
+
+	data, err := r.read(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read apko config: %w", err)
+	}
+
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errorsx.InvalidValue(builderName, "config", name, "invalid config %s: %w", name, err)
+	}
+
+	includes, err := configIncludes(name, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := map[string]any{}
+	for _, include := range includes {
+		base, err := r.load(r.join(name, include), stack, resolved)
+		if err != nil {
+			return nil, err
+		}
+
+		merged = mergeConfigMaps(merged, base)
+	}
+
+	// A configuration included by several others (a diamond) is listed once.
+	if !slices.Contains(resolved.Sources, name) {
+		resolved.Sources = append(resolved.Sources, name)
+	}
+
+	return mergeConfigMaps(merged, doc), nil
+}
+
+// configIncludes removes the include keys of 'doc' and returns the paths they name, in order.
+func configIncludes(name string, doc map[string]any) ([]string, error) {
+	var includes []string
+	for _, key := range includeKeys {
+		value, ok := doc[key]
+		if !ok {
+			continue
+		}
+		delete(doc, key)
+
+		switch v := value.(type) {
+		case string:
+			includes = append(includes, v)
+		case []any:
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return nil, errorsx.InvalidValue(builderName, key, item,
+						"invalid %s in config %s: expected paths, got %v", key, name, item)
+				}
+				includes = append(includes, s)
+			}
+		default:
+			return nil, errorsx.InvalidValue(builderName, key, value,
+				"invalid %s in config %s: expected a path or a list of paths", key, name)
+		}
+	}
+
+	for _, include := range includes {
+		if include == "" {
+			return nil, errorsx.InvalidValue(builderName, "include", include, "empty include in config %s", name)
+		}
+	}
+
+	return includes, nil
+}
+
+// mergeConfigMaps merges 'over' into 'base': nested mappings are merged, lists are concatenated
+// without duplicates, and other values of 'over' replace those of 'base'.
+func mergeConfigMaps(base, over map[string]any) map[string]any {
+	for key, value := range over {
+		current, ok := base[key]
+		if !ok {
+			base[key] = value
+			continue
+		}
+
+		switch v := value.(type) {
+		case map[string]any:
+			if m, ok := current.(map[string]any); ok {
+				base[key] = mergeConfigMaps(m, v)
+				continue
+			}
+		case []any:
+			if l, ok := current.([]any); ok {
+				for _, item := range v {
+					if !slices.ContainsFunc(l, func(o any) bool { return reflect.DeepEqual(o, item) }) {
+						l = append(l, item)
+					}
+				}
+				base[key] = l
+				continue
+			}
+		}
+
+		base[key] = value
+	}
+
+	return base
+}
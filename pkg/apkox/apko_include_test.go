@@ -0,0 +1,94 @@
+package apkox
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/goldenx"
+)
+
+func TestResolveConfigFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"base/wolfi.yaml": {Data: []byte(`contents:
+  repositories: [https://packages.wolfi.dev/os]
+  packages: [wolfi-base]
+environment:
+  LANG: C.UTF-8
+archs: [x86_64]
+`)},
+		"base/user.yaml": {Data: []byte(`include: wolfi.yaml
+accounts:
+  run-as: nonroot
+`)},
+		"app/apko.yaml": {Data: []byte(`extends: [../base/wolfi.yaml, ../base/user.yaml]
+contents:
+  packages: [wolfi-base, curl]
+environment:
+  LANG: en_US.UTF-8
+archs: [x86_64, aarch64]
+`)},
+	}
+
+	resolved, err := ResolveConfigFS(fsys, "app/apko.yaml")
+	if err != nil {
+		t.Fatalf("ResolveConfigFS returned unexpected error: %v", err)
+	}
+
+	want := []string{"base/wolfi.yaml", "base/user.yaml", "app/apko.yaml"}
+	if !reflect.DeepEqual(resolved.Sources, want) {
+		t.Errorf("Expected sources %v, got %v", want, resolved.Sources)
+	}
+
+	goldenx.New(t).AssertYAML("resolve-config", resolved.Config)
+}
+
+func TestResolveConfigFSErrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.yaml":       {Data: []byte("include: b.yaml\n")},
+		"b.yaml":       {Data: []byte("include: sub/../a.yaml\n")},
+		"bad.yaml":     {Data: []byte("extends: {path: a.yaml}\n")},
+		"empty.yaml":   {Data: []byte("include: \"\"\n")},
+		"missing.yaml": {Data: []byte("include: nope.yaml\n")},
+	}
+
+	_, err := ResolveConfigFS(fsys, "a.yaml")
+	if !errors.Is(err, errorsx.ErrInvalidValue) || !strings.Contains(err.Error(), "a.yaml -> b.yaml -> a.yaml") {
+		t.Errorf("Expected an include cycle error, got %v", err)
+	}
+
+	for _, name := range []string{"bad.yaml", "empty.yaml"} {
+		if _, err := ResolveConfigFS(fsys, name); !errors.Is(err, errorsx.ErrInvalidValue) {
+			t.Errorf("Expected an invalid value error for %s, got %v", name, err)
+		}
+	}
+
+	if _, err := ResolveConfigFS(fsys, "missing.yaml"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a not exist error, got %v", err)
+	}
+}
+
+func TestResolveConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "base.yaml"), []byte("contents:\n  packages: [wolfi-base]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "apko.yaml"), []byte("include: base.yaml\ncmd: /bin/sh\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, err := ResolveConfig(filepath.Join(dir, "apko.yaml"))
+	if err != nil {
+		t.Fatalf("ResolveConfig returned unexpected error: %v", err)
+	}
+
+	want := "cmd: /bin/sh\ncontents:\n    packages:\n        - wolfi-base\n"
+	if string(resolved.Config) != want {
+		t.Errorf("Expected config %q, got %q", want, resolved.Config)
+	}
+}
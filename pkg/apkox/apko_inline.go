@@ -0,0 +1,84 @@
+package apkox
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// GetInlineConfigPath returns the path under 'mntPrefix' where an inline APKO configuration is
+// materialized. The file name derives from the content, so identical configurations share a path.
+// If mntPrefix is empty, it defaults to fixtures.MntPrefix.
+func GetInlineConfigPath(mntPrefix, content string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	sum := sha256.Sum256([]byte(content))
+
+	return path.Join(mntPrefix, ".daggerx", "apko-"+hex.EncodeToString(sum[:6])+".yaml")
+}
+
+// WithInlineConfig uses the given YAML document as the APKO configuration, so small
+// configurations don't require a file in the host context. The config file is set to a path under
+// fixtures.MntPrefix, and Mounts returns the inline mount materializing it there.
+//
+// apko can also read its configuration from stdin with WithConfigFile("-"); that mode requires
+// the executor to pipe the document to the command.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithInlineConfig(config string) *ApkoBuilder {
+	b.inlineConfig = config
+	b.configFile = GetInlineConfigPath(fixtures.MntPrefix, config)
+	return b
+}
+
+// Mounts returns the mounts the generated command requires: the build context directory, if
+// any, the materialization of the inline configuration, with the account, environment, path,
+// certificate and package rule options applied, if any, then the plaintext and secret keyrings.
+// The inline configuration is left out when it cannot be mutated, as BuildCommand then reports
+// the error.
+func (b *ApkoBuilder) Mounts() []types.Mount {
+	var mounts []types.Mount
+
+	if b.buildContext != "" {
+		mounts = append(mounts, types.Mount{
+			Kind:   types.MountKindDirectory,
+			Source: b.buildContext,
+			Target: b.buildContextTarget(),
+		})
+	}
+
+	if path, content, err := b.effectiveConfig(); err == nil && content != "" {
+		mounts = append(mounts, types.Mount{
+			Kind:     types.MountKindInline,
+			Target:   path,
+			Content:  content,
+			ReadOnly: true,
+		})
+	}
+
+	return append(mounts, b.keyringMounts()...)
+}
+
+// validateInlineConfig checks that the inline configuration is a YAML mapping.
+func (b *ApkoBuilder) validateInlineConfig() error {
+	if b.inlineConfig == "" {
+		return nil
+	}
+
+	var doc map[string]any
+	if err := yaml.Unmarshal([]byte(b.inlineConfig), &doc); err != nil {
+		return errorsx.InvalidValue(builderName, "inlineConfig", b.configFile, "invalid inline config: %w", err)
+	}
+
+	if len(doc) == 0 {
+		return errorsx.InvalidValue(builderName, "inlineConfig", b.configFile, "inline config is empty")
+	}
+
+	return nil
+}
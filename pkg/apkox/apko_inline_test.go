@@ -0,0 +1,119 @@
+package apkox
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+const testInlineConfig = `contents:
+  packages:
+    - wolfi-base
+entrypoint:
+  command: /bin/sh
+`
+
+func TestGetInlineConfigPath(t *testing.T) {
+	path := GetInlineConfigPath("", testInlineConfig)
+	if !strings.HasPrefix(path, "/mnt/.daggerx/apko-") || !strings.HasSuffix(path, ".yaml") {
+		t.Errorf("Unexpected inline config path %s", path)
+	}
+
+	if GetInlineConfigPath("/mnt", testInlineConfig) != path {
+		t.Error("Expected identical configurations to share a path")
+	}
+
+	if GetInlineConfigPath("", "other: true") == path {
+		t.Error("Expected different configurations to have different paths")
+	}
+}
+
+func TestApkoBuilderWithInlineConfig(t *testing.T) {
+	b := NewApkoBuilder().
+		WithInlineConfig(testInlineConfig).
+		WithOutputImage("my-image").
+		WithTag("v1").
+		WithOutputTarball("output.tar").
+		WithSBOM(true).
+		WithVCS(true)
+
+	cmd, err := b.BuildCommand()
+	if err != nil {
+		t.Fatalf("BuildCommand() returned unexpected error: %v", err)
+	}
+
+	path := GetInlineConfigPath("", testInlineConfig)
+	want := []string{"apko", "build", path, "my-image:v1", "output.tar"}
+	if !reflect.DeepEqual(cmd, want) {
+		t.Errorf("BuildCommand() = %v, want %v", cmd, want)
+	}
+
+	wantMounts := []types.Mount{{Kind: types.MountKindInline, Target: path, Content: testInlineConfig, ReadOnly: true}}
+	if !reflect.DeepEqual(b.Mounts(), wantMounts) {
+		t.Errorf("Mounts() = %v, want %v", b.Mounts(), wantMounts)
+	}
+
+	if !reflect.DeepEqual(NewApkoBuilderFromSpec(b.Spec()).Mounts(), wantMounts) {
+		t.Error("Expected the inline config to survive a spec round trip")
+	}
+
+	if NewApkoBuilder().WithConfigFile("apko.yaml").Mounts() != nil {
+		t.Error("Expected no mounts without an inline config")
+	}
+}
+
+func TestApkoBuilderWithInlineConfigValidation(t *testing.T) {
+	for _, config := range []string{"contents: [", "# only a comment\n"} {
+		_, err := NewApkoBuilder().
+			WithInlineConfig(config).
+			WithOutputImage("my-image").
+			WithOutputTarball("output.tar").
+			BuildCommand()
+		if !errors.Is(err, errorsx.ErrInvalidValue) {
+			t.Errorf("BuildCommand() error = %v for config %q, want %v", err, config, errorsx.ErrInvalidValue)
+		}
+	}
+}
+
+func TestBuildContextMount(t *testing.T) {
+	b := NewApkoBuilder().
+		WithConfigFile("apko.yaml").
+		WithOutputImage("example/app").
+		WithTag("v1").
+		WithOutputTarball("image.tar").
+		WithBuildContext("/host/src").
+		WithBuildRepositoryAppend("/mnt/packages")
+
+	cmd, err := b.BuildCommand()
+	if err != nil {
+		t.Fatalf("BuildCommand() error = %v", err)
+	}
+
+	want := []string{"--build-repository-append", "/mnt/packages"}
+	if i := slices.Index(cmd, "--build-repository-append"); i < 0 || !reflect.DeepEqual(cmd[i:i+2], want) {
+		t.Errorf("BuildCommand() = %v, want %v", cmd, want)
+	}
+	if slices.Contains(cmd, "/host/src") {
+		t.Errorf("BuildCommand() = %v, the build context must only be mounted", cmd)
+	}
+
+	wantMounts := []types.Mount{{Kind: types.MountKindDirectory, Source: "/host/src", Target: fixtures.MntPrefix}}
+	if !reflect.DeepEqual(b.Mounts(), wantMounts) {
+		t.Errorf("Mounts() = %v, want %v", b.Mounts(), wantMounts)
+	}
+
+	b.WithWorkdir("/work")
+	if got := b.Mounts()[0].Target; got != "/work" {
+		t.Errorf("Mounts() target = %s, want the working directory", got)
+	}
+
+	if !reflect.DeepEqual(NewApkoBuilderFromSpec(b.Spec()).Mounts(), b.Mounts()) {
+		t.Errorf("spec round trip lost the build context")
+	}
+}
@@ -0,0 +1,115 @@
+package apkox
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+)
+
+// ignoreSignaturesFlag is the apko build flag skipping the verification of package signatures.
+const ignoreSignaturesFlag = "--ignore-signatures"
+
+// InsecureOptions are development-only behaviors weakening the supply chain guarantees of a
+// build, e.g. to iterate on packages built locally without signing them. They are only enabled
+// through WithInsecure, always reported as warnings (see Warnings), and refused in production
+// mode (see WithProduction).
+type InsecureOptions struct {
+	// IgnoreSignatures skips the verification of the package signatures (--ignore-signatures).
+	IgnoreSignatures bool
+	// AllowHTTPRepositories allows package repositories served over plain HTTP, which are refused
+	// otherwise.
+	AllowHTTPRepositories bool
+}
+
+// Enabled reports whether any insecure option is enabled.
+func (o InsecureOptions) Enabled() bool {
+	return o.IgnoreSignatures || o.AllowHTTPRepositories
+}
+
+// WithInsecure enables the development-only insecure options 'opts'. Each enabled option is
+// logged and reported as a warning whenever the command is generated, and fails the command in
+// production mode or with WithStrict.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithInsecure(opts InsecureOptions) *ApkoBuilder {
+	b.insecure = opts
+	return b
+}
+
+// WithProduction sets whether the build produces production images, which refuses the insecure
+// options (see WithInsecure), including an --ignore-signatures extra argument.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithProduction(production bool) *ApkoBuilder {
+	b.production = production
+	return b
+}
+
+// validateInsecure checks the insecure options against the production mode and the
+// repositories.
+//
+// Returns:
+//   - The warnings of the enabled insecure options.
+//   - An error if an insecure option is enabled in production mode, signatures are ignored
+//     through the extra arguments without IgnoreSignatures, or a repository is served over plain
+//     HTTP without AllowHTTPRepositories.
+func (b *ApkoBuilder) validateInsecure(ctx context.Context) ([]errorsx.Warning, error) {
+	extraIgnore := slices.ContainsFunc(b.extraArgs, func(arg string) bool {
+		name, _, _ := strings.Cut(arg, "=")
+		return name == ignoreSignaturesFlag
+	})
+
+	if b.production {
+		if b.insecure.IgnoreSignatures || extraIgnore {
+			return nil, errorsx.ConflictingOptions(builderName, []string{"production", "insecure"},
+				"production builds cannot ignore package signatures")
+		}
+
+		if b.insecure.AllowHTTPRepositories {
+			return nil, errorsx.ConflictingOptions(builderName, []string{"production", "insecure"},
+				"production builds cannot allow plain HTTP repositories")
+		}
+	}
+
+	if extraIgnore && !b.insecure.IgnoreSignatures {
+		return nil, errorsx.InvalidValue(builderName, "extraArgs", ignoreSignaturesFlag,
+			"%s requires the explicit opt-in InsecureOptions.IgnoreSignatures", ignoreSignaturesFlag)
+	}
+
+	for _, repos := range []struct {
+		field string
+		repos []string
+	}{
+		{"repositoryAppend", b.repositoryAppend},
+		{"buildRepositoryAppend", b.buildRepositoryAppend},
+	} {
+		for _, r := range repos.repos {
+			if strings.HasPrefix(strings.ToLower(r), "http://") && !b.insecure.AllowHTTPRepositories {
+				return nil, errorsx.InvalidValue(builderName, repos.field, r,
+					"repository %s is served over plain HTTP, which requires the explicit opt-in "+
+						"InsecureOptions.AllowHTTPRepositories", r)
+			}
+		}
+	}
+
+	var warnings []errorsx.Warning
+	for _, opt := range []struct {
+		enabled bool
+		field   string
+		message string
+	}{
+		{b.insecure.IgnoreSignatures, "ignoreSignatures", "package signatures are not verified"},
+		{b.insecure.AllowHTTPRepositories, "allowHTTPRepositories", "plain HTTP repositories are allowed"},
+	} {
+		if !opt.enabled {
+			continue
+		}
+
+		logx.Warn(ctx, b.logger, builderName, "insecure option enabled, never ship the image", "option", opt.field)
+		warnings = append(warnings, errorsx.NewWarning(builderName, opt.field,
+			"insecure option enabled: %s, never ship the image", opt.message))
+	}
+
+	return warnings, nil
+}
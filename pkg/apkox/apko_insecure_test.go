@@ -0,0 +1,122 @@
+package apkox
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+func TestWithInsecure(t *testing.T) {
+	b := newTestBuilder().
+		WithRepositoryAppend("http://localhost:8080/packages").
+		WithInsecure(InsecureOptions{IgnoreSignatures: true, AllowHTTPRepositories: true})
+
+	cmd, err := b.BuildCommand()
+	if err != nil {
+		t.Fatalf("BuildCommand() error = %v", err)
+	}
+
+	if !slices.Contains(cmd, "--ignore-signatures") {
+		t.Errorf("BuildCommand() = %v, want --ignore-signatures", cmd)
+	}
+
+	if got, want := warningFields(b.Warnings()), []string{"ignoreSignatures", "allowHTTPRepositories"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Warnings() fields = %v, want %v", got, want)
+	}
+
+	if _, err := b.WithStrict(true).BuildCommand(); !errors.Is(err, errorsx.ErrWarning) {
+		t.Errorf("strict BuildCommand() error = %v, want ErrWarning", err)
+	}
+}
+
+func TestInsecureRefused(t *testing.T) {
+	tests := []struct {
+		name    string
+		b       *ApkoBuilder
+		wantErr error
+	}{
+		{
+			name:    "http repository without opt-in",
+			b:       newTestBuilder().WithRepositoryAppend("http://localhost:8080/packages"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "http build repository without opt-in",
+			b:       newTestBuilder().WithBuildRepositoryAppend("HTTP://mirror.local/os"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "ignore signatures extra argument without opt-in",
+			b:       newTestBuilder().WithExtraArg("--ignore-signatures"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name: "ignore signatures in production",
+			b: newTestBuilder().WithProduction(true).
+				WithInsecure(InsecureOptions{IgnoreSignatures: true}),
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+		{
+			name: "ignore signatures extra argument in production",
+			b: newTestBuilder().WithProduction(true).
+				WithInsecure(InsecureOptions{IgnoreSignatures: true}).WithExtraArg("--ignore-signatures=true"),
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+		{
+			name: "http repositories in production",
+			b: newTestBuilder().WithProduction(true).
+				WithInsecure(InsecureOptions{AllowHTTPRepositories: true}),
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.b.BuildCommand(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("BuildCommand() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProductionWithoutInsecure(t *testing.T) {
+	b := newTestBuilder().WithProduction(true).WithRepositoryAppend("https://packages.wolfi.dev/os")
+
+	if _, err := b.BuildCommand(); err != nil {
+		t.Fatalf("BuildCommand() error = %v", err)
+	}
+
+	if w := b.Warnings(); len(w) != 0 {
+		t.Errorf("Warnings() = %v, want none", w)
+	}
+}
+
+func TestInsecureSpecAndParse(t *testing.T) {
+	b := NewApkoBuilderFromSpec(ApkoSpec{
+		ConfigFile:                    "apko.yaml",
+		OutputImage:                   "example/app",
+		OutputTarball:                 "/mnt/image.tar",
+		InsecureAllowHTTPRepositories: true,
+		Production:                    true,
+	})
+
+	if got := b.insecure; got != (InsecureOptions{AllowHTTPRepositories: true}) {
+		t.Errorf("insecure = %+v, want AllowHTTPRepositories", got)
+	}
+
+	if spec := b.Spec(); !spec.InsecureAllowHTTPRepositories || !spec.Production {
+		t.Errorf("Spec() = %+v, want the insecure options and production", spec)
+	}
+
+	parsed, err := ParseApkoCommand([]string{"apko", "build", "--ignore-signatures", "apko.yaml", "example/app:v1", "/mnt/image.tar"})
+	if err != nil {
+		t.Fatalf("ParseApkoCommand() error = %v", err)
+	}
+
+	if !parsed.insecure.IgnoreSignatures || len(parsed.extraArgs) != 0 {
+		t.Errorf("ParseApkoCommand() insecure = %+v, extra args = %v", parsed.insecure, parsed.extraArgs)
+	}
+}
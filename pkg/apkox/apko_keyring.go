@@ -0,0 +1,115 @@
+package apkox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Excoriate/daggerx/pkg/cachex"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/httpfetchx"
+	"github.com/Excoriate/daggerx/pkg/ratelimitx"
+	"github.com/Excoriate/daggerx/pkg/retryx"
+)
+
+// maxKeyringSize is the maximum size of a fetched keyring, far above the size of an RSA public key.
+const maxKeyringSize = 64 * 1024
+
+// FetchKeyring downloads the public key at 'keyURL'. See FetchKeyringContext.
+func FetchKeyring(keyURL string) ([]byte, error) {
+	return FetchKeyringContext(context.Background(), keyURL, nil)
+}
+
+// FetchKeyringContext downloads the public key at 'keyURL', honoring the cancellation and deadline
+// of 'ctx'. The URL must use HTTPS. Transient failures are retried with the default retryx policy,
+// and requests are throttled per host by ratelimitx.Default. When 'client' is nil, the client of
+// httpfetchx.Default is used.
+//
+// Returns:
+//   - The PEM-encoded public key.
+//   - An error if the URL is invalid, the download fails, or the content is not a PEM public key.
+func FetchKeyringContext(ctx context.Context, keyURL string, client *http.Client) ([]byte, error) {
+	if err := validateURL(keyURL, true); err != nil {
+		return nil, errorsx.InvalidValue(builderName, "keyring", keyURL, "invalid keyring URL: %s, error: %w", keyURL, err)
+	}
+
+	if client == nil {
+		var err error
+		if client, err = httpfetchx.Default().Client(); err != nil {
+			return nil, err
+		}
+	}
+
+	return retryx.DoValue(ctx, nil, func(ctx context.Context) ([]byte, error) {
+		return fetchKeyring(ctx, client, keyURL)
+	})
+}
+
+// fetchKeyring downloads and checks the public key at 'keyURL', once.
+func fetchKeyring(ctx context.Context, client *http.Client, keyURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, keyURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create keyring request: %w", err)
+	}
+
+	if err := ratelimitx.Default().Wait(ctx, req.URL.Host); err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch keyring %s: %w", keyURL, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch keyring %s: %w", keyURL,
+			&retryx.StatusError{StatusCode: resp.StatusCode, Status: resp.Status})
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxKeyringSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyring %s: %w", keyURL, err)
+	}
+
+	if len(data) > maxKeyringSize {
+		return nil, fmt.Errorf("keyring %s exceeds %d bytes", keyURL, maxKeyringSize)
+	}
+
+	if !bytes.Contains(data, []byte("-----BEGIN PUBLIC KEY-----")) {
+		return nil, fmt.Errorf("keyring %s is not a PEM public key", keyURL)
+	}
+
+	return data, nil
+}
+
+// FetchKeyringCached downloads the public key at 'keyURL' like FetchKeyringContext, caching it in
+// 'cache' under the "keyring:" prefix. A nil cache disables caching.
+func FetchKeyringCached(ctx context.Context, cache *cachex.Cache, keyURL string, client *http.Client) ([]byte, error) {
+	return cache.GetOrFetch(ctx, "keyring:"+keyURL, func(ctx context.Context) ([]byte, error) {
+		return FetchKeyringContext(ctx, keyURL, client)
+	})
+}
+
+// FetchKeyringForPresetContext downloads the public key of a keyring preset ("alpine" or "wolfi").
+//
+// Returns:
+//   - The keyring information of the preset and the PEM-encoded public key.
+//   - An error if the preset is unsupported or the download fails.
+func FetchKeyringForPresetContext(ctx context.Context, preset string, client *http.Client) (KeyringInfo, []byte, error) {
+	info, err := GetKeyringInfoForPreset(preset)
+	if err != nil {
+		return KeyringInfo{}, nil, err
+	}
+
+	data, err := FetchKeyringContext(ctx, info.KeyURL, client)
+	if err != nil {
+		return KeyringInfo{}, nil, err
+	}
+
+	return info, data, nil
+}
@@ -0,0 +1,101 @@
+package apkox
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// GetPlaintextKeyringPath returns the path under 'mntPrefix' where a plaintext keyring is
+// materialized. The file name derives from the key, so the same key always has the same path.
+// If mntPrefix is empty, it defaults to fixtures.MntPrefix.
+func GetPlaintextKeyringPath(mntPrefix, key string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	sum := sha256.Sum256([]byte(key))
+
+	return path.Join(mntPrefix, ".daggerx", "keyring-"+hex.EncodeToString(sum[:6])+".rsa.pub")
+}
+
+// GetSecretKeyringPath returns the path under 'mntPrefix' where the keyring held by the secret
+// 'name' is mounted. If mntPrefix is empty, it defaults to fixtures.MntPrefix.
+func GetSecretKeyringPath(mntPrefix, name string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, ".daggerx", "keyring-secret-"+name+".rsa.pub")
+}
+
+// WithKeyringAppendSecret appends the keyring held by the secret 'name'. The secret is mounted
+// as a file (see Mounts) appended with --keyring-append, so the key isn't exposed by the
+// command, the process list or the logs.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithKeyringAppendSecret(name string) *ApkoBuilder {
+	b.keyringAppendSecrets = append(b.keyringAppendSecrets, name)
+	return b
+}
+
+// keyringFileFlags returns the --keyring-append flags of the materialized plaintext and secret
+// keyrings.
+func (b *ApkoBuilder) keyringFileFlags() []string {
+	flags := make([]string, 0, 2*(len(b.keyringAppendPlaintext)+len(b.keyringAppendSecrets)))
+	for _, k := range b.keyringAppendPlaintext {
+		flags = append(flags, "--keyring-append", GetPlaintextKeyringPath(fixtures.MntPrefix, k))
+	}
+
+	for _, name := range b.keyringAppendSecrets {
+		flags = append(flags, "--keyring-append", GetSecretKeyringPath(fixtures.MntPrefix, name))
+	}
+
+	return flags
+}
+
+// keyringMounts returns the mounts materializing the plaintext and secret keyrings.
+func (b *ApkoBuilder) keyringMounts() []types.Mount {
+	var mounts []types.Mount
+	for _, k := range b.keyringAppendPlaintext {
+		mounts = append(mounts, types.Mount{
+			Kind:     types.MountKindInline,
+			Target:   GetPlaintextKeyringPath(fixtures.MntPrefix, k),
+			Content:  k,
+			ReadOnly: true,
+		})
+	}
+
+	for _, name := range b.keyringAppendSecrets {
+		mounts = append(mounts, types.Mount{
+			Kind:     types.MountKindSecret,
+			Source:   name,
+			Target:   GetSecretKeyringPath(fixtures.MntPrefix, name),
+			ReadOnly: true,
+		})
+	}
+
+	return mounts
+}
+
+// validateKeyringFiles checks the plaintext keyrings and the names of the keyring secrets.
+func (b *ApkoBuilder) validateKeyringFiles() error {
+	for _, k := range b.keyringAppendPlaintext {
+		if strings.TrimSpace(k) == "" {
+			return errorsx.InvalidValue(builderName, "keyringAppendPlaintext", "", "plaintext keyring is empty")
+		}
+	}
+
+	for _, name := range b.keyringAppendSecrets {
+		if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+			return errorsx.InvalidValue(builderName, "keyringAppendSecrets", name,
+				"invalid keyring secret name: %q", name)
+		}
+	}
+
+	return nil
+}
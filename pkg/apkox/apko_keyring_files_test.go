@@ -0,0 +1,101 @@
+package apkox
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+const testPlaintextKey = "-----BEGIN PUBLIC KEY-----\nMIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA\n-----END PUBLIC KEY-----\n"
+
+func TestGetPlaintextKeyringPath(t *testing.T) {
+	path := GetPlaintextKeyringPath("", testPlaintextKey)
+	if !strings.HasPrefix(path, "/mnt/.daggerx/keyring-") || !strings.HasSuffix(path, ".rsa.pub") {
+		t.Errorf("GetPlaintextKeyringPath() = %q, want a keyring file under /mnt/.daggerx", path)
+	}
+
+	if path != GetPlaintextKeyringPath("/mnt", testPlaintextKey) {
+		t.Error("GetPlaintextKeyringPath() must be stable for the same key")
+	}
+
+	if path == GetPlaintextKeyringPath("/mnt", "other") {
+		t.Error("GetPlaintextKeyringPath() must differ for different keys")
+	}
+}
+
+func TestKeyringFiles(t *testing.T) {
+	b := NewApkoBuilder().
+		WithConfigFile("apko.yaml").
+		WithOutputImage("example/app").
+		WithTag("v1").
+		WithOutputTarball("/mnt/image.tar").
+		WithKeyring("/etc/apk/keys/wolfi.rsa.pub").
+		WithKeyringAppendPlaintext(testPlaintextKey).
+		WithKeyringAppendSecret("melange-key")
+
+	cmd, err := b.BuildCommand()
+	if err != nil {
+		t.Fatalf("BuildCommand returned unexpected error: %v", err)
+	}
+
+	plaintextPath := GetPlaintextKeyringPath("/mnt", testPlaintextKey)
+	secretPath := "/mnt/.daggerx/keyring-secret-melange-key.rsa.pub"
+	want := []string{
+		"apko", "build",
+		"--keyring-append", "/etc/apk/keys/wolfi.rsa.pub",
+		"--keyring-append", plaintextPath,
+		"--keyring-append", secretPath,
+		"--sbom=false", "--vcs=false",
+		"apko.yaml", "example/app:v1", "/mnt/image.tar",
+	}
+	if !reflect.DeepEqual(cmd, want) {
+		t.Errorf("BuildCommand() = %v, want %v", cmd, want)
+	}
+
+	if strings.Contains(strings.Join(cmd, " "), "PUBLIC KEY") {
+		t.Errorf("BuildCommand() = %v, must not contain the plaintext key", cmd)
+	}
+
+	wantMounts := []types.Mount{
+		{Kind: types.MountKindInline, Target: plaintextPath, Content: testPlaintextKey, ReadOnly: true},
+		{Kind: types.MountKindSecret, Source: "melange-key", Target: secretPath, ReadOnly: true},
+	}
+	if !reflect.DeepEqual(b.Mounts(), wantMounts) {
+		t.Errorf("Mounts() = %v, want %v", b.Mounts(), wantMounts)
+	}
+
+	if !reflect.DeepEqual(NewApkoBuilderFromSpec(b.Spec()).Mounts(), wantMounts) {
+		t.Error("Spec() must preserve the plaintext and secret keyrings")
+	}
+
+	if c := b.Clone().WithKeyringAppendSecret("other"); len(c.Mounts()) != 3 || len(b.Mounts()) != 2 {
+		t.Error("Clone() must copy the keyring secrets")
+	}
+}
+
+func TestKeyringFilesValidation(t *testing.T) {
+	base := func() *ApkoBuilder {
+		return NewApkoBuilder().WithConfigFile("apko.yaml").WithOutputImage("example/app").WithOutputTarball("/mnt/image.tar")
+	}
+
+	tests := []struct {
+		name string
+		b    *ApkoBuilder
+	}{
+		{"empty plaintext", base().WithKeyringAppendPlaintext("  ")},
+		{"empty secret", base().WithKeyringAppendSecret("")},
+		{"secret path", base().WithKeyringAppendSecret("../key")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.b.BuildCommand(); !errors.Is(err, errorsx.ErrInvalidValue) {
+				t.Errorf("BuildCommand() error = %v, want ErrInvalidValue", err)
+			}
+		})
+	}
+}
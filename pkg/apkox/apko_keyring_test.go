@@ -0,0 +1,119 @@
+package apkox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/cachex"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+const testPublicKey = "-----BEGIN PUBLIC KEY-----\nMIIBIjANBgkq\n-----END PUBLIC KEY-----\n"
+
+func newKeyringServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	flaky := 0
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky.rsa.pub":
+			if flaky++; flaky == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(testPublicKey))
+		case "/key.rsa.pub":
+			_, _ = w.Write([]byte(testPublicKey))
+		case "/not-a-key":
+			_, _ = w.Write([]byte("<html></html>"))
+		case "/huge":
+			_, _ = w.Write([]byte(strings.Repeat("a", maxKeyringSize+1)))
+		case "/slow":
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestFetchKeyringContext(t *testing.T) {
+	srv := newKeyringServer(t)
+
+	data, err := FetchKeyringContext(context.Background(), srv.URL+"/key.rsa.pub", srv.Client())
+	if err != nil {
+		t.Fatalf("FetchKeyringContext returned unexpected error: %v", err)
+	}
+
+	if string(data) != testPublicKey {
+		t.Errorf("Unexpected keyring content %q", data)
+	}
+
+	for _, path := range []string{"/missing", "/not-a-key", "/huge"} {
+		if _, err := FetchKeyringContext(context.Background(), srv.URL+path, srv.Client()); err == nil {
+			t.Errorf("Expected error for %s, got none", path)
+		}
+	}
+}
+
+func TestFetchKeyringContextRetriesTransientFailures(t *testing.T) {
+	srv := newKeyringServer(t)
+
+	data, err := FetchKeyringContext(context.Background(), srv.URL+"/flaky.rsa.pub", srv.Client())
+	if err != nil {
+		t.Fatalf("FetchKeyringContext returned unexpected error: %v", err)
+	}
+
+	if string(data) != testPublicKey {
+		t.Errorf("Unexpected keyring content %q", data)
+	}
+}
+
+func TestFetchKeyringContextCancellation(t *testing.T) {
+	srv := newKeyringServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := FetchKeyringContext(ctx, srv.URL+"/slow", srv.Client())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline error, got %v", err)
+	}
+}
+
+func TestFetchKeyringRequiresHTTPS(t *testing.T) {
+	_, err := FetchKeyring("http://example.com/key.rsa.pub")
+	if !errors.Is(err, errorsx.ErrInvalidValue) {
+		t.Errorf("Expected an ErrInvalidValue error, got %v", err)
+	}
+}
+
+func TestFetchKeyringForPresetContext(t *testing.T) {
+	_, _, err := FetchKeyringForPresetContext(context.Background(), "debian", nil)
+	if !errors.Is(err, errorsx.ErrUnsupported) {
+		t.Errorf("Expected an ErrUnsupported error, got %v", err)
+	}
+}
+
+func TestFetchKeyringCached(t *testing.T) {
+	srv := newKeyringServer(t)
+	cache := cachex.NewCache(time.Hour)
+
+	for range 2 {
+		data, err := FetchKeyringCached(context.Background(), cache, srv.URL+"/key.rsa.pub", srv.Client())
+		if err != nil {
+			t.Fatalf("FetchKeyringCached returned unexpected error: %v", err)
+		}
+		if string(data) != testPublicKey {
+			t.Errorf("Unexpected keyring content %q", data)
+		}
+		srv.Close()
+	}
+}
@@ -0,0 +1,61 @@
+package apkox
+
+import (
+	"strconv"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// LayerStrategy is the strategy apko uses to split the image filesystem into layers.
+type LayerStrategy string
+
+const (
+	// LayerStrategyOrigin groups the packages in layers by origin package, so images sharing
+	// packages share layers in the registry.
+	LayerStrategyOrigin LayerStrategy = "origin"
+)
+
+// WithLayering splits the image into multiple layers using 'strategy', with at most 'budget'
+// additional layers. A budget of 0 lets apko pick its default budget.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithLayering(strategy LayerStrategy, budget int) *ApkoBuilder {
+	b.layeringStrategy = strategy
+	b.layeringBudget = budget
+	return b
+}
+
+// validateLayering checks the layering options of the builder.
+func (b *ApkoBuilder) validateLayering() error {
+	if b.layeringStrategy == "" {
+		if b.layeringBudget != 0 {
+			return errorsx.MissingField(builderName, "layeringStrategy", "layering budget requires a layering strategy")
+		}
+		return nil
+	}
+
+	if b.layeringStrategy != LayerStrategyOrigin {
+		return errorsx.Unsupported(builderName, "layeringStrategy", b.layeringStrategy,
+			"unsupported layering strategy: %s", b.layeringStrategy)
+	}
+
+	if b.layeringBudget < 0 {
+		return errorsx.InvalidValue(builderName, "layeringBudget", b.layeringBudget,
+			"layering budget must not be negative: %d", b.layeringBudget)
+	}
+
+	return nil
+}
+
+// layeringFlags returns the flags of the layering options.
+func (b *ApkoBuilder) layeringFlags() []string {
+	if b.layeringStrategy == "" {
+		return nil
+	}
+
+	flags := []string{"--layering-strategy", string(b.layeringStrategy)}
+	if b.layeringBudget > 0 {
+		flags = append(flags, "--layering-budget", strconv.Itoa(b.layeringBudget))
+	}
+
+	return flags
+}
@@ -0,0 +1,72 @@
+package apkox
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+func TestApkoBuilderWithLayering(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy LayerStrategy
+		budget   int
+		want     []string
+	}{
+		{
+			name: "No layering",
+			want: []string{"apko", "build", "config.yaml", "my-image:v1", "output.tar"},
+		},
+		{
+			name:     "Strategy with default budget",
+			strategy: LayerStrategyOrigin,
+			want:     []string{"apko", "build", "--layering-strategy", "origin", "config.yaml", "my-image:v1", "output.tar"},
+		},
+		{
+			name:     "Strategy with budget",
+			strategy: LayerStrategyOrigin,
+			budget:   10,
+			want: []string{
+				"apko", "build", "--layering-strategy", "origin", "--layering-budget", "10",
+				"config.yaml", "my-image:v1", "output.tar",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := newTestBuilder().WithLayering(tt.strategy, tt.budget).BuildCommand()
+			if err != nil {
+				t.Fatalf("BuildCommand() returned unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(cmd, tt.want) {
+				t.Errorf("BuildCommand() = %v, want %v", cmd, tt.want)
+			}
+		})
+	}
+}
+
+func TestApkoBuilderWithLayeringValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy LayerStrategy
+		budget   int
+		kind     error
+	}{
+		{"Budget without strategy", "", 5, errorsx.ErrMissingField},
+		{"Unknown strategy", "by-size", 0, errorsx.ErrUnsupported},
+		{"Negative budget", LayerStrategyOrigin, -1, errorsx.ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestBuilder().WithLayering(tt.strategy, tt.budget).BuildCommand()
+			if !errors.Is(err, tt.kind) {
+				t.Errorf("BuildCommand() error = %v, want %v", err, tt.kind)
+			}
+		})
+	}
+}
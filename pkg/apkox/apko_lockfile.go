@@ -0,0 +1,117 @@
+package apkox
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// ApkoLock represents an apko lockfile, as produced by `apko lock` or the --lockfile build flag.
+type ApkoLock struct {
+	// Version is the version of the lockfile format.
+	Version string `json:"version"`
+	// Contents holds the resolved keyrings, repositories and packages.
+	Contents ApkoLockContents `json:"contents"`
+}
+
+// ApkoLockContents holds the resolved contents of an apko lockfile.
+type ApkoLockContents struct {
+	// Keyring holds the resolved keyring entries.
+	Keyring []ApkoLockKeyring `json:"keyring"`
+	// Repositories holds the resolved repositories, per architecture.
+	Repositories []ApkoLockRepository `json:"repositories"`
+	// Packages holds the resolved packages, per architecture.
+	Packages []ApkoLockPackage `json:"packages"`
+}
+
+// ApkoLockKeyring is a keyring entry of an apko lockfile.
+type ApkoLockKeyring struct {
+	// Name is the name of the key.
+	Name string `json:"name"`
+	// URL is the location of the key.
+	URL string `json:"url"`
+}
+
+// ApkoLockRepository is a repository entry of an apko lockfile.
+type ApkoLockRepository struct {
+	// Name is the name of the repository.
+	Name string `json:"name"`
+	// URL is the location of the repository index.
+	URL string `json:"url"`
+	// Architecture is the architecture of the repository.
+	Architecture string `json:"architecture"`
+}
+
+// ApkoLockPackage is a package entry of an apko lockfile.
+type ApkoLockPackage struct {
+	// Name is the name of the package.
+	Name string `json:"name"`
+	// URL is the location of the package.
+	URL string `json:"url"`
+	// Version is the resolved version of the package.
+	Version string `json:"version"`
+	// Architecture is the architecture of the package.
+	Architecture string `json:"architecture"`
+	// Checksum is the APK checksum of the package ("Q1" followed by a base64 encoded SHA-1).
+	Checksum string `json:"checksum"`
+}
+
+// ParseLockfile parses the content of an apko lockfile.
+//
+// Returns:
+//   - The parsed lockfile.
+//   - An error if the content is not a valid lockfile.
+func ParseLockfile(data []byte) (*ApkoLock, error) {
+	var lock ApkoLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, errorsx.InvalidValue(builderName, "lockfile", nil, "invalid apko lockfile: %w", err)
+	}
+
+	if lock.Version == "" {
+		return nil, errorsx.MissingField(builderName, "lockfile.version", "invalid apko lockfile: version is missing")
+	}
+
+	for i, p := range lock.Contents.Packages {
+		if p.Name == "" || p.Version == "" {
+			return nil, errorsx.MissingField(builderName, fmt.Sprintf("lockfile.contents.packages[%d]", i),
+				"invalid apko lockfile: package at index %d has no name or version", i)
+		}
+	}
+
+	return &lock, nil
+}
+
+// ReadLockfile reads and parses the apko lockfile at 'path'.
+func ReadLockfile(path string) (*ApkoLock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read apko lockfile: %w", err)
+	}
+
+	return ParseLockfile(data)
+}
+
+// ChecksumDigest converts an APK checksum ("Q1" followed by a base64 encoded SHA-1) into its
+// algorithm and hexadecimal digest.
+//
+// Returns:
+//   - The algorithm ("sha1") and the hexadecimal digest.
+//   - An error if the checksum is not an APK SHA-1 checksum.
+func ChecksumDigest(checksum string) (algorithm, digest string, err error) {
+	encoded, ok := strings.CutPrefix(checksum, "Q1")
+	if !ok {
+		return "", "", errorsx.Unsupported(builderName, "checksum", checksum, "unsupported APK checksum: %s", checksum)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", "", errorsx.InvalidValue(builderName, "checksum", checksum, "invalid APK checksum %s: %w", checksum, err)
+	}
+
+	return "sha1", hex.EncodeToString(raw), nil
+}
@@ -0,0 +1,100 @@
+package apkox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testLockfile = `{
+  "version": "v1",
+  "contents": {
+    "keyring": [
+      {"name": "wolfi-signing.rsa.pub", "url": "https://packages.wolfi.dev/os/wolfi-signing.rsa.pub"}
+    ],
+    "repositories": [
+      {"name": "packages.wolfi.dev/os", "url": "https://packages.wolfi.dev/os/x86_64/APKINDEX.tar.gz", "architecture": "x86_64"}
+    ],
+    "packages": [
+      {
+        "name": "ca-certificates-bundle",
+        "url": "https://packages.wolfi.dev/os/x86_64/ca-certificates-bundle-20240315-r0.apk",
+        "version": "20240315-r0",
+        "architecture": "x86_64",
+        "checksum": "Q1qqsJ9iUMRQpp17wLvPx5ExHxXHI="
+      }
+    ]
+  }
+}`
+
+func TestParseLockfile(t *testing.T) {
+	lock, err := ParseLockfile([]byte(testLockfile))
+	if err != nil {
+		t.Fatalf("ParseLockfile returned unexpected error: %v", err)
+	}
+
+	if lock.Version != "v1" {
+		t.Errorf("Expected version v1, got %s", lock.Version)
+	}
+
+	if len(lock.Contents.Packages) != 1 || lock.Contents.Packages[0].Version != "20240315-r0" {
+		t.Errorf("Packages not parsed correctly, got %+v", lock.Contents.Packages)
+	}
+
+	if len(lock.Contents.Repositories) != 1 || lock.Contents.Repositories[0].Architecture != "x86_64" {
+		t.Errorf("Repositories not parsed correctly, got %+v", lock.Contents.Repositories)
+	}
+}
+
+func TestParseLockfileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "Invalid JSON", data: "{"},
+		{name: "Missing version", data: `{"contents": {}}`},
+		{name: "Package without version", data: `{"version": "v1", "contents": {"packages": [{"name": "curl"}]}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseLockfile([]byte(tt.data)); err == nil {
+				t.Errorf("Expected error for %s, got none", tt.name)
+			}
+		})
+	}
+}
+
+func TestReadLockfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apko.lock.json")
+	if err := os.WriteFile(path, []byte(testLockfile), 0o600); err != nil {
+		t.Fatalf("Failed to write lockfile: %v", err)
+	}
+
+	if _, err := ReadLockfile(path); err != nil {
+		t.Errorf("ReadLockfile returned unexpected error: %v", err)
+	}
+
+	if _, err := ReadLockfile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for missing lockfile, got none")
+	}
+}
+
+func TestChecksumDigest(t *testing.T) {
+	algo, digest, err := ChecksumDigest("Q1qqsJ9iUMRQpp17wLvPx5ExHxXHI=")
+	if err != nil {
+		t.Fatalf("ChecksumDigest returned unexpected error: %v", err)
+	}
+
+	if algo != "sha1" || digest != "aaab09f6250c450a69d7bc0bbcfc791311f15c72" {
+		t.Errorf("Unexpected digest %s:%s", algo, digest)
+	}
+
+	if _, _, err := ChecksumDigest("sha256-abc"); err == nil {
+		t.Error("Expected error for unsupported checksum, got none")
+	}
+
+	if _, _, err := ChecksumDigest("Q1***"); err == nil {
+		t.Error("Expected error for invalid base64, got none")
+	}
+}
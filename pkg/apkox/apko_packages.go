@@ -0,0 +1,135 @@
+package apkox
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// PackageRule restricts a package to some architectures, or excludes it from some. apko
+// configurations list the same packages for every architecture, so rules are applied by
+// generating one configuration per architecture (see ForArchitectures).
+type PackageRule struct {
+	// Package is the package name, optionally with a version constraint (e.g. "glibc=2.40-r0").
+	Package string `json:"package" yaml:"package"`
+	// Archs are the only architectures the package is included on.
+	Archs []Architecture `json:"archs,omitempty" yaml:"archs,omitempty"`
+	// ExcludeArchs are the architectures the package is removed from.
+	ExcludeArchs []Architecture `json:"excludeArchs,omitempty" yaml:"excludeArchs,omitempty"`
+}
+
+// includes reports whether the rule includes its package on 'arch'.
+func (r PackageRule) includes(arch Architecture) bool {
+	if len(r.Archs) > 0 {
+		return slices.Contains(r.Archs, arch)
+	}
+
+	return !slices.Contains(r.ExcludeArchs, arch)
+}
+
+// WithArchPackage includes 'pkg' only on 'archs'. Package rules mutate the inline configuration
+// (see WithInlineConfig) and require an architecture (see WithBuildArch and ForArchitectures).
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithArchPackage(pkg string, archs ...Architecture) *ApkoBuilder {
+	return b.WithPackageRule(PackageRule{Package: pkg, Archs: archs})
+}
+
+// WithoutArchPackage removes 'pkg' on 'archs', whether the configuration or a rule includes it.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithoutArchPackage(pkg string, archs ...Architecture) *ApkoBuilder {
+	return b.WithPackageRule(PackageRule{Package: pkg, ExcludeArchs: archs})
+}
+
+// WithPackageRule adds a package rule. Rules apply in order.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithPackageRule(rule PackageRule) *ApkoBuilder {
+	b.packageRules = append(b.packageRules, rule)
+	return b
+}
+
+// ForArchitectures returns one builder per architecture in 'archs', each a copy of the builder
+// (see Clone), warnings and keyring presets included, building only that architecture, with its package rules applied and its output tarball
+// suffixed by the architecture (e.g. "image-aarch64.tar").
+func (b *ApkoBuilder) ForArchitectures(archs ...Architecture) []*ApkoBuilder {
+	builders := make([]*ApkoBuilder, 0, len(archs))
+	for _, arch := range archs {
+		c := b.Clone().WithBuildArch(arch)
+		if b.outputTarball != "" {
+			ext := filepath.Ext(b.outputTarball)
+			c.outputTarball = strings.TrimSuffix(b.outputTarball, ext) + "-" + string(arch) + ext
+		}
+		builders = append(builders, c)
+	}
+
+	return builders
+}
+
+// ApplyPackageRules returns the mutation restricting the configuration to 'arch' and applying
+// 'rules' to its packages: included packages are added unless already listed, and excluded
+// packages are removed, whatever their version constraint.
+func ApplyPackageRules(arch Architecture, rules ...PackageRule) ConfigMutation {
+	return func(doc map[string]any) error {
+		for _, r := range rules {
+			if err := r.validate(); err != nil {
+				return err
+			}
+		}
+
+		contents := map[string]any{}
+		if err := decodeSection(doc, "contents", &contents); err != nil {
+			return err
+		}
+
+		var packages []string
+		if err := decodeSection(contents, "packages", &packages); err != nil {
+			return err
+		}
+
+		for _, r := range rules {
+			name := packageName(r.Package)
+			listed := slices.IndexFunc(packages, func(p string) bool { return packageName(p) == name }) >= 0
+
+			switch {
+			case r.includes(arch) && !listed:
+				packages = append(packages, r.Package)
+			case !r.includes(arch):
+				packages = slices.DeleteFunc(packages, func(p string) bool { return packageName(p) == name })
+			}
+		}
+
+		contents["packages"] = packages
+		doc["contents"] = contents
+		doc["archs"] = []Architecture{arch}
+
+		return nil
+	}
+}
+
+// validate checks that the rule names a package and either includes or excludes architectures.
+func (r PackageRule) validate() error {
+	if packageName(r.Package) == "" {
+		return errorsx.MissingField(builderName, "packageRules", "package rule requires a package")
+	}
+
+	if len(r.Archs) > 0 && len(r.ExcludeArchs) > 0 {
+		return errorsx.ConflictingOptions(builderName, []string{"archs", "excludeArchs"},
+			"package rule for %s cannot both include and exclude architectures", r.Package)
+	}
+
+	if len(r.Archs) == 0 && len(r.ExcludeArchs) == 0 {
+		return errorsx.MissingField(builderName, "packageRules", "package rule for %s requires architectures", r.Package)
+	}
+
+	return nil
+}
+
+// packageName returns the name of a package without its version constraint.
+func packageName(pkg string) string {
+	if i := strings.IndexAny(pkg, "=<>~"); i >= 0 {
+		pkg = pkg[:i]
+	}
+
+	return strings.TrimSpace(pkg)
+}
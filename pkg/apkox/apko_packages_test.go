@@ -0,0 +1,173 @@
+package apkox
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"gopkg.in/yaml.v3"
+)
+
+const testPackagesConfig = `contents:
+  repositories:
+    - https://packages.wolfi.dev/os
+  packages:
+    - wolfi-base
+    - glibc=2.40-r0
+archs:
+  - x86_64
+  - aarch64
+`
+
+// decodePackages returns the packages and architectures of a YAML configuration.
+func decodePackages(t *testing.T, config string) ([]string, []Architecture) {
+	t.Helper()
+
+	var doc struct {
+		Contents struct {
+			Repositories []string `yaml:"repositories"`
+			Packages     []string `yaml:"packages"`
+		} `yaml:"contents"`
+		Archs []Architecture `yaml:"archs"`
+	}
+	if err := yaml.Unmarshal([]byte(config), &doc); err != nil {
+		t.Fatalf("Failed to decode config: %v", err)
+	}
+
+	if len(doc.Contents.Repositories) != 1 {
+		t.Errorf("Expected the repositories to be kept, got %v", doc.Contents.Repositories)
+	}
+
+	return doc.Contents.Packages, doc.Archs
+}
+
+func TestApplyPackageRules(t *testing.T) {
+	rules := []PackageRule{
+		{Package: "intel-microcode", Archs: []Architecture{ArchX8664}},
+		{Package: "glibc", ExcludeArchs: []Architecture{ArchAarch64}},
+		{Package: "wolfi-base", Archs: []Architecture{ArchX8664, ArchAarch64}},
+	}
+
+	tests := []struct {
+		arch Architecture
+		want []string
+	}{
+		{ArchX8664, []string{"wolfi-base", "glibc=2.40-r0", "intel-microcode"}},
+		{ArchAarch64, []string{"wolfi-base"}},
+		{ArchArmv7, []string{"glibc=2.40-r0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.arch), func(t *testing.T) {
+			out, err := MutateConfig([]byte(testPackagesConfig), ApplyPackageRules(tt.arch, rules...))
+			if err != nil {
+				t.Fatalf("MutateConfig returned unexpected error: %v", err)
+			}
+
+			packages, archs := decodePackages(t, string(out))
+			if !reflect.DeepEqual(packages, tt.want) {
+				t.Errorf("Packages = %v, want %v", packages, tt.want)
+			}
+
+			if !reflect.DeepEqual(archs, []Architecture{tt.arch}) {
+				t.Errorf("Archs = %v, want [%s]", archs, tt.arch)
+			}
+		})
+	}
+}
+
+func TestApplyPackageRulesValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		rule PackageRule
+		kind error
+	}{
+		{"Missing package", PackageRule{Archs: []Architecture{ArchX8664}}, errorsx.ErrMissingField},
+		{"Missing archs", PackageRule{Package: "glibc"}, errorsx.ErrMissingField},
+		{"Include and exclude", PackageRule{Package: "glibc", Archs: []Architecture{ArchX8664}, ExcludeArchs: []Architecture{ArchAarch64}}, errorsx.ErrConflictingOptions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MutateConfig([]byte(testPackagesConfig), ApplyPackageRules(ArchX8664, tt.rule))
+			if !errors.Is(err, tt.kind) {
+				t.Errorf("Expected %v, got %v", tt.kind, err)
+			}
+		})
+	}
+}
+
+func TestApkoBuilderForArchitectures(t *testing.T) {
+	b := NewApkoBuilder().
+		WithInlineConfig(testPackagesConfig).
+		WithOutputImage("my-image").
+		WithTag("v1").
+		WithOutputTarball("/mnt/image.tar").
+		WithArchPackage("intel-microcode", ArchX8664).
+		WithoutArchPackage("glibc", ArchAarch64)
+
+	if _, err := b.BuildCommand(); !errors.Is(err, errorsx.ErrMissingField) {
+		t.Errorf("Expected a missing arch error, got %v", err)
+	}
+
+	builders := b.ForArchitectures(ArchX8664, ArchAarch64)
+	if len(builders) != 2 {
+		t.Fatalf("Expected 2 builders, got %d", len(builders))
+	}
+
+	want := map[Architecture][]string{
+		ArchX8664:   {"wolfi-base", "glibc=2.40-r0", "intel-microcode"},
+		ArchAarch64: {"wolfi-base"},
+	}
+
+	for i, arch := range []Architecture{ArchX8664, ArchAarch64} {
+		cmd, err := builders[i].BuildCommand()
+		if err != nil {
+			t.Fatalf("BuildCommand() for %s returned unexpected error: %v", arch, err)
+		}
+
+		if tarball := cmd[len(cmd)-1]; tarball != "/mnt/image-"+string(arch)+".tar" {
+			t.Errorf("Expected a per-arch tarball for %s, got %s", arch, tarball)
+		}
+
+		mounts := builders[i].Mounts()
+		if cmd[len(cmd)-3] != mounts[0].Target {
+			t.Errorf("Expected the %s command to use its config, got %v", arch, cmd)
+		}
+
+		if packages, _ := decodePackages(t, mounts[0].Content); !reflect.DeepEqual(packages, want[arch]) {
+			t.Errorf("Packages for %s = %v, want %v", arch, packages, want[arch])
+		}
+	}
+
+	if builders[0].Mounts()[0].Target == builders[1].Mounts()[0].Target {
+		t.Error("Expected per-arch configs to have different paths")
+	}
+}
+
+func TestApkoBuilderForArchitecturesKeepsOptions(t *testing.T) {
+	b := NewApkoBuilder().
+		WithConfigFile("apko.yaml").
+		WithOutputImage("my-image").
+		WithTag("v1").
+		WithOutputTarball("/mnt/image.tar").
+		WithWolfiKeyring().
+		WithDebug()
+
+	for _, c := range b.ForArchitectures(ArchX8664, ArchAarch64) {
+		if got := warningFields(c.Warnings()); !reflect.DeepEqual(got, []string{"WithDebug"}) {
+			t.Errorf("Warnings() fields for %s = %v, want [WithDebug]", c.buildArch, got)
+		}
+
+		cmd, err := c.BuildCommand()
+		if err != nil {
+			t.Fatalf("BuildCommand() for %s returned unexpected error: %v", c.buildArch, err)
+		}
+
+		if !slices.Contains(cmd, ApkoWolfiSigninRsaKeyPath) {
+			t.Errorf("Expected the %s command to append the Wolfi keyring, got %v", c.buildArch, cmd)
+		}
+	}
+}
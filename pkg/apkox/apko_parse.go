@@ -0,0 +1,202 @@
+package apkox
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// unmanagedValueFlags are the apko build flags that take a value argument and that no typed option
+// emits. ParseApkoCommand keeps them, with their value, in the extra arguments.
+var unmanagedValueFlags = map[string]bool{
+	"--include-paths": true,
+	"--tmp-dir":       true,
+	"--workdir":       true,
+}
+
+// ParseApkoCommand builds an ApkoBuilder from an `apko build` command line, so existing scripts
+// can move to the typed API. The flags emitted by typed options (see BuildCommand) set these
+// options; other flags, and positional arguments after the output tarball, are kept as extra
+// arguments in their original order. Flags apko doesn't document with a value argument must pass
+// their value inline ("--flag=value").
+//
+// As apko does, the parsed builder generates an SBOM and records VCS information unless the
+// command disables them, so BuildCommand round-trips the command line.
+//
+// Returns:
+//   - The builder.
+//   - An error if the command is not `apko build`, misses a positional argument, or a flag value
+//     is missing or invalid.
+func ParseApkoCommand(args []string) (*ApkoBuilder, error) {
+	if len(args) > 0 && args[0] == "apko" {
+		args = args[1:]
+	}
+
+	if len(args) == 0 || args[0] != "build" {
+		sub := ""
+		if len(args) > 0 {
+			sub = args[0]
+		}
+		return nil, errorsx.Unsupported(builderName, "command", sub,
+			"only apko build commands are supported, got %q", sub)
+	}
+
+	b := NewApkoBuilder().WithSBOM(true).WithVCS(true)
+
+	var (
+		positional []string
+		strategy   LayerStrategy
+		budget     int
+	)
+
+	args = args[1:]
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, inline := strings.Cut(arg, "=")
+		name = longFlag(name)
+
+		field, managed := managedFlags[name]
+		if !managed {
+			b.extraArgs = append(b.extraArgs, arg)
+			if unmanagedValueFlags[name] && !inline {
+				if i+1 >= len(args) {
+					return nil, errorsx.MissingField(builderName, "extraArgs", "flag %s requires a value", name)
+				}
+				i++
+				b.extraArgs = append(b.extraArgs, args[i])
+			}
+			continue
+		}
+
+		if booleanFlags[name] {
+			enabled := true
+			if inline {
+				var err error
+				if enabled, err = strconv.ParseBool(value); err != nil {
+					return nil, errorsx.InvalidValue(builderName, field, value,
+						"invalid value for %s: %q", name, value)
+				}
+			}
+
+			switch name {
+			case "--sbom":
+				b.sbom = enabled
+			case "--vcs":
+				b.vcs = enabled
+			case "--offline":
+				b.offline = enabled
+			case ignoreSignaturesFlag:
+				b.insecure.IgnoreSignatures = enabled
+			}
+			continue
+		}
+
+		if !inline {
+			if i+1 >= len(args) {
+				return nil, errorsx.MissingField(builderName, field, "flag %s requires a value", name)
+			}
+			i++
+			value = args[i]
+		}
+
+		switch name {
+		case "--cache-dir":
+			b.WithCacheDir(value)
+		case "--keyring-append":
+			b.WithKeyring(value)
+		case "--arch":
+			b.WithBuildArch(Architecture(value))
+		case "--build-repository-append":
+			b.WithBuildRepositoryAppend(value)
+		case "--repository-append":
+			b.WithRepositoryAppend(value)
+		case "--package-append":
+			b.WithPackageAppend(strings.Split(value, ",")...)
+		case "--layering-strategy":
+			strategy = LayerStrategy(value)
+		case "--layering-budget":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, errorsx.InvalidValue(builderName, field, value,
+					"invalid value for %s: %q", name, value)
+			}
+			budget = n
+		case "--sbom-path":
+			b.WithSBOMPath(value)
+		case "--sbom-formats":
+			b.sbomFormats = append(b.sbomFormats, strings.Split(value, ",")...)
+		case "--log-level":
+			b.WithLogLevel(value)
+		case "--log-policy":
+			b.logPolicy = append(b.logPolicy, strings.Split(value, ",")...)
+		case "--build-date":
+			b.WithBuildDate(value)
+		case "--lockfile":
+			b.WithLockfile(value)
+		case "--annotations":
+			if b.annotations == nil {
+				b.annotations = map[string]string{}
+			}
+			for _, annotation := range strings.Split(value, ",") {
+				key, val, ok := strings.Cut(annotation, ":")
+				if !ok {
+					return nil, errorsx.InvalidValue(builderName, field, value,
+						"invalid value for %s: %q", name, value)
+				}
+				b.annotations[key] = val
+			}
+		}
+	}
+
+	if strategy != "" || budget != 0 {
+		b.WithLayering(strategy, budget)
+	}
+
+	for i, field := range []string{"configFile", "outputImage", "outputTarball"} {
+		if len(positional) <= i {
+			return nil, errorsx.MissingField(builderName, field,
+				"apko build requires a config file, an image reference and an output tarball")
+		}
+	}
+
+	image, tag, err := splitImageRef(positional[1])
+	if err != nil {
+		return nil, err
+	}
+
+	b.WithConfigFile(positional[0]).WithOutputImage(image).WithOutputTarball(positional[2])
+	if tag != "" {
+		b.WithTag(tag)
+	}
+
+	b.extraArgs = append(b.extraArgs, positional[3:]...)
+
+	return b, nil
+}
+
+// splitImageRef splits an image reference into its repository and tag. The tag is empty when the
+// reference has none.
+func splitImageRef(ref string) (string, string, error) {
+	if strings.Contains(ref, "@") {
+		return "", "", errorsx.InvalidValue(builderName, "outputImage", ref,
+			"apko build image references cannot hold a digest: %q", ref)
+	}
+
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i], ref[i+1:], nil
+	}
+
+	return ref, "", nil
+}
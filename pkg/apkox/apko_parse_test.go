@@ -0,0 +1,132 @@
+package apkox
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+func TestParseApkoCommandRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  []string
+	}{
+		{
+			name: "Minimal",
+			cmd:  []string{"apko", "build", "apko.yaml", "example/app:v1", "image.tar"},
+		},
+		{
+			name: "Typed flags",
+			cmd: []string{
+				"apko", "build",
+				"--cache-dir", "/cache",
+				"--keyring-append", "/keys/a.pub",
+				"--keyring-append", "/keys/b.pub",
+				"--arch", "aarch64",
+				"--build-repository-append", "/mnt/packages",
+				"--repository-append", "https://packages.wolfi.dev/os",
+				"--package-append", "curl=8.5.0-r0",
+				"--layering-strategy", "origin",
+				"--layering-budget", "5",
+				"--sbom=false",
+				"--vcs=false",
+				"--offline",
+				"--log-level", "debug",
+				"--annotations", "org.opencontainers.image.vendor:acme",
+				"--annotations", "team:platform",
+				"apko.yaml", "registry.example.com:5000/app:v1", "image.tar",
+			},
+		},
+		{
+			name: "SBOM, logging and reproducibility flags",
+			cmd: []string{
+				"apko", "build",
+				"--sbom-path", "/sboms",
+				"--sbom-formats", "spdx",
+				"--log-policy", "builtin:stderr",
+				"--build-date", "2024-01-02T15:04:05Z",
+				"--lockfile", "apko.lock.json",
+				"apko.yaml", "example/app:v1", "image.tar",
+			},
+		},
+		{
+			name: "Unknown flags kept as extra arguments",
+			cmd: []string{
+				"apko", "build", "--sbom-path", "/sboms",
+				"apko.yaml", "example/app:v1", "image.tar",
+				"--include-paths", "/extra", "--debug",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := ParseApkoCommand(tt.cmd)
+			if err != nil {
+				t.Fatalf("ParseApkoCommand() error = %v", err)
+			}
+
+			got, err := b.BuildCommand()
+			if err != nil {
+				t.Fatalf("BuildCommand() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.cmd) {
+				t.Errorf("round trip mismatch.\nExpected: %v\nGot: %v", tt.cmd, got)
+			}
+		})
+	}
+}
+
+func TestParseApkoCommandOptions(t *testing.T) {
+	b, err := ParseApkoCommand([]string{
+		"build", "-k", "/keys/a.pub", "-r=https://repo", "--arch=x86_64", "--package-append", "curl",
+		"--", "apko.yaml", "localhost:5000/app", "image.tar",
+	})
+	if err != nil {
+		t.Fatalf("ParseApkoCommand() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(b.keyringPaths, []string{"/keys/a.pub"}) {
+		t.Errorf("keyringPaths = %v", b.keyringPaths)
+	}
+	if !reflect.DeepEqual(b.repositoryAppend, []string{"https://repo"}) {
+		t.Errorf("repositoryAppend = %v", b.repositoryAppend)
+	}
+	if b.buildArch != "x86_64" || b.outputImage != "localhost:5000/app" || b.tag != "" {
+		t.Errorf("arch = %q, image = %q, tag = %q", b.buildArch, b.outputImage, b.tag)
+	}
+	if !reflect.DeepEqual(b.packageAppend, []string{"curl"}) || len(b.extraArgs) != 0 {
+		t.Errorf("packageAppend = %v, extraArgs = %v", b.packageAppend, b.extraArgs)
+	}
+}
+
+func TestParseApkoCommandErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmd     []string
+		wantErr error
+	}{
+		{name: "Other subcommand", cmd: []string{"apko", "publish", "apko.yaml", "app"}, wantErr: errorsx.ErrUnsupported},
+		{name: "Empty", cmd: []string{"apko"}, wantErr: errorsx.ErrUnsupported},
+		{name: "Missing tarball", cmd: []string{"apko", "build", "apko.yaml", "app:v1"}, wantErr: errorsx.ErrMissingField},
+		{name: "Missing flag value", cmd: []string{"apko", "build", "apko.yaml", "app:v1", "image.tar", "--arch"},
+			wantErr: errorsx.ErrMissingField},
+		{name: "Invalid boolean", cmd: []string{"apko", "build", "--sbom=maybe", "apko.yaml", "app", "image.tar"},
+			wantErr: errorsx.ErrInvalidValue},
+		{name: "Invalid budget", cmd: []string{"apko", "build", "--layering-budget", "x", "apko.yaml", "app", "image.tar"},
+			wantErr: errorsx.ErrInvalidValue},
+		{name: "Digest reference", cmd: []string{"apko", "build", "apko.yaml", "app@sha256:abc", "image.tar"},
+			wantErr: errorsx.ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseApkoCommand(tt.cmd); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseApkoCommand() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package apkox
+
+import (
+	"encoding/pem"
+	"path"
+	"regexp"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// PathType is the type of an entry of the paths section of an APKO configuration.
+type PathType string
+
+const (
+	// PathTypeDirectory creates a directory.
+	PathTypeDirectory PathType = "directory"
+	// PathTypeEmptyFile creates an empty file, e.g. a configuration file written at runtime.
+	PathTypeEmptyFile PathType = "empty-file"
+	// PathTypeHardlink creates a hard link to the file at Source.
+	PathTypeHardlink PathType = "hardlink"
+	// PathTypeSymlink creates a symbolic link pointing at Source.
+	PathTypeSymlink PathType = "symlink"
+	// PathTypePermissions changes the ownership and permissions of an existing path.
+	PathTypePermissions PathType = "permissions"
+)
+
+// maxPermissions is the highest accepted permission value (0o7777).
+const maxPermissions = 0o7777
+
+// certificateNameRegex matches valid names of additional certificates.
+var certificateNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// PathEntry is an entry of the paths section of an APKO configuration.
+type PathEntry struct {
+	// Path is the absolute path in the image.
+	Path string `json:"path" yaml:"path"`
+	// Type is the type of the entry.
+	Type PathType `json:"type" yaml:"type"`
+	// Source is the path the link points at, for hardlinks and symlinks.
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+	// UID is the owner of the path.
+	UID int `json:"uid,omitempty" yaml:"uid,omitempty"`
+	// GID is the group of the path.
+	GID int `json:"gid,omitempty" yaml:"gid,omitempty"`
+	// Permissions are the permissions of the path (e.g. 0o755).
+	Permissions int `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+	// Recursive applies the ownership and permissions to the content of directories.
+	Recursive bool `json:"recursive,omitempty" yaml:"recursive,omitempty"`
+}
+
+// Certificate is an additional CA certificate appended to the CA bundle of the image.
+type Certificate struct {
+	// Name identifies the certificate.
+	Name string `json:"name" yaml:"name"`
+	// Content holds the PEM-encoded certificates.
+	Content string `json:"content" yaml:"content"`
+}
+
+// WithPathEntry adds an entry to the paths section of the configuration, replacing an entry for
+// the same path. Path options mutate the inline configuration (see WithInlineConfig).
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithPathEntry(entry PathEntry) *ApkoBuilder {
+	b.paths = append(b.paths, entry)
+	return b
+}
+
+// WithDirectory creates the directory 'dir', owned by 'uid' and 'gid', with 'permissions'.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithDirectory(dir string, uid, gid, permissions int) *ApkoBuilder {
+	return b.WithPathEntry(PathEntry{Path: dir, Type: PathTypeDirectory, UID: uid, GID: gid, Permissions: permissions})
+}
+
+// WithSymlink creates the symbolic link 'link' pointing at 'target'.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithSymlink(link, target string) *ApkoBuilder {
+	return b.WithPathEntry(PathEntry{Path: link, Type: PathTypeSymlink, Source: target})
+}
+
+// WithCertificate appends the PEM-encoded certificates 'content' to the CA bundle of the image,
+// replacing a certificate of the same name. apko embeds the content in the image at build time,
+// so private CAs don't require a package.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithCertificate(name, content string) *ApkoBuilder {
+	b.certificates = append(b.certificates, Certificate{Name: name, Content: content})
+	return b
+}
+
+// MergePaths returns the mutation merging 'entries' into the paths section of the configuration,
+// replacing the entries for the same paths. Hardlinks must not point at a directory or a link
+// declared by the merged section.
+func MergePaths(entries ...PathEntry) ConfigMutation {
+	return func(doc map[string]any) error {
+		for _, e := range entries {
+			if err := e.validate(); err != nil {
+				return err
+			}
+		}
+
+		var merged []PathEntry
+		if err := decodeSection(doc, "paths", &merged); err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			merged = upsert(merged, e, func(o PathEntry) bool { return o.Path == e.Path })
+		}
+
+		if err := validatePathSources(merged); err != nil {
+			return err
+		}
+
+		doc["paths"] = merged
+
+		return nil
+	}
+}
+
+// MergeCertificates returns the mutation merging 'certs' into the additional certificates of the
+// configuration, replacing the certificates of the same name.
+func MergeCertificates(certs ...Certificate) ConfigMutation {
+	return func(doc map[string]any) error {
+		for _, c := range certs {
+			if err := c.validate(); err != nil {
+				return err
+			}
+		}
+
+		var section struct {
+			Additional []Certificate `yaml:"additional,omitempty"`
+		}
+		if err := decodeSection(doc, "certificates", &section); err != nil {
+			return err
+		}
+
+		for _, c := range certs {
+			section.Additional = upsert(section.Additional, c, func(o Certificate) bool { return o.Name == c.Name })
+		}
+
+		doc["certificates"] = section
+
+		return nil
+	}
+}
+
+// validate checks the path, type, source, ownership and permissions of the entry.
+func (e PathEntry) validate() error {
+	if !path.IsAbs(e.Path) || path.Clean(e.Path) != e.Path {
+		return errorsx.InvalidValue(builderName, "paths", e.Path, "path must be absolute and clean: %q", e.Path)
+	}
+
+	switch e.Type {
+	case PathTypeHardlink, PathTypeSymlink:
+		if e.Source == "" {
+			return errorsx.MissingField(builderName, "paths", "%s %s requires a source", e.Type, e.Path)
+		}
+		if e.Type == PathTypeHardlink && !path.IsAbs(e.Source) {
+			return errorsx.InvalidValue(builderName, "paths", e.Source, "hardlink source must be absolute: %q", e.Source)
+		}
+	case PathTypeDirectory, PathTypeEmptyFile, PathTypePermissions:
+		if e.Source != "" {
+			return errorsx.InvalidValue(builderName, "paths", e.Source, "%s %s cannot have a source", e.Type, e.Path)
+		}
+	default:
+		return errorsx.Unsupported(builderName, "paths", e.Type, "unsupported path type %s for %s", e.Type, e.Path)
+	}
+
+	if err := validateAccountID("paths", e.UID); err != nil {
+		return err
+	}
+
+	if err := validateAccountID("paths", e.GID); err != nil {
+		return err
+	}
+
+	if e.Permissions < 0 || e.Permissions > maxPermissions {
+		return errorsx.InvalidValue(builderName, "paths", e.Permissions, "invalid permissions %#o for %s", e.Permissions, e.Path)
+	}
+
+	return nil
+}
+
+// validatePathSources checks that no hardlink points at a directory or a link declared by the
+// section, which apko cannot hardlink to.
+func validatePathSources(entries []PathEntry) error {
+	kinds := make(map[string]PathType, len(entries))
+	for _, e := range entries {
+		kinds[e.Path] = e.Type
+	}
+
+	for _, e := range entries {
+		if e.Type != PathTypeHardlink {
+			continue
+		}
+
+		switch kinds[e.Source] {
+		case PathTypeDirectory, PathTypeSymlink, PathTypeHardlink:
+			return errorsx.InvalidValue(builderName, "paths", e.Source,
+				"hardlink %s cannot point at the %s %s", e.Path, kinds[e.Source], e.Source)
+		}
+	}
+
+	return nil
+}
+
+// validate checks the name of the certificate and that its content holds PEM certificates only.
+func (c Certificate) validate() error {
+	if !certificateNameRegex.MatchString(c.Name) {
+		return errorsx.InvalidValue(builderName, "certificates", c.Name, "invalid certificate name: %q", c.Name)
+	}
+
+	rest, found := []byte(c.Content), false
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			return errorsx.InvalidValue(builderName, "certificates", c.Name,
+				"certificate %s holds a %s block", c.Name, block.Type)
+		}
+		found = true
+	}
+
+	if !found {
+		return errorsx.InvalidValue(builderName, "certificates", c.Name, "certificate %s holds no PEM certificate", c.Name)
+	}
+
+	return nil
+}
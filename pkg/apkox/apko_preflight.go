@@ -0,0 +1,342 @@
+package apkox
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/cachex"
+	"github.com/Excoriate/daggerx/pkg/httpfetchx"
+	"github.com/Excoriate/daggerx/pkg/ratelimitx"
+	"github.com/Excoriate/daggerx/pkg/retryx"
+)
+
+// PreflightKind is the kind of resource checked by Preflight.
+type PreflightKind string
+
+const (
+	// PreflightRepository is an APK repository, checked through its index.
+	PreflightRepository PreflightKind = "repository"
+	// PreflightKeyring is a keyring, checked by downloading and parsing its public key.
+	PreflightKeyring PreflightKind = "keyring"
+)
+
+// PreflightStatus is the outcome of a preflight check.
+type PreflightStatus string
+
+const (
+	// PreflightOK reports a reachable repository or a valid key.
+	PreflightOK PreflightStatus = "ok"
+	// PreflightFailed reports an unreachable repository, or a key that can't be fetched or parsed.
+	PreflightFailed PreflightStatus = "failed"
+	// PreflightSkipped reports a resource that can't be checked outside the build container
+	// (e.g. a local repository or a keyring path), or a check skipped in offline mode.
+	PreflightSkipped PreflightStatus = "skipped"
+)
+
+// Diagnostic is the result of the preflight check of a repository or keyring.
+type Diagnostic struct {
+	// Kind is the kind of the checked resource.
+	Kind PreflightKind `json:"kind"`
+	// Target is the resource as configured (e.g. "@testing https://dl-cdn.alpinelinux.org/alpine/edge/testing").
+	Target string `json:"target"`
+	// URL is the URL checked, if any: the repository index or the key.
+	URL string `json:"url,omitempty"`
+	// Status is the outcome of the check.
+	Status PreflightStatus `json:"status"`
+	// StatusCode is the HTTP status code of the last response, if any.
+	StatusCode int `json:"statusCode,omitempty"`
+	// Message describes the failure or the reason the check was skipped.
+	Message string `json:"message,omitempty"`
+	// Duration is the duration of the check.
+	Duration time.Duration `json:"duration"`
+}
+
+// String describes the diagnostic (e.g. "repository https://example.com/os: failed: 404 Not Found").
+func (d Diagnostic) String() string {
+	s := fmt.Sprintf("%s %s: %s", d.Kind, d.Target, d.Status)
+	if d.Message != "" {
+		s += ": " + d.Message
+	}
+
+	return s
+}
+
+// PreflightReport holds the diagnostics of Preflight, in check order: repositories first, then
+// keyrings.
+type PreflightReport struct {
+	// Diagnostics are the results of the checks.
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// Failed returns the diagnostics of the failed checks.
+func (r *PreflightReport) Failed() []Diagnostic {
+	var failed []Diagnostic
+	for _, d := range r.Diagnostics {
+		if d.Status == PreflightFailed {
+			failed = append(failed, d)
+		}
+	}
+
+	return failed
+}
+
+// Passed reports whether no check failed. Skipped checks don't fail the report.
+func (r *PreflightReport) Passed() bool {
+	return len(r.Failed()) == 0
+}
+
+// Err returns an error joining the failed checks, or nil if every check passed or was skipped.
+func (r *PreflightReport) Err() error {
+	var errs []error
+	for _, d := range r.Failed() {
+		errs = append(errs, errors.New(d.String()))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Preflight checks, before the container build starts, that the repositories and keyrings of the
+// builder are usable. See PreflightCached.
+func (b *ApkoBuilder) Preflight(ctx context.Context) (*PreflightReport, error) {
+	return b.PreflightCached(ctx, nil, nil)
+}
+
+// PreflightCached checks, before the expensive container build starts, that the repositories and
+// keyrings of the builder and its inline configuration are usable:
+//   - Remote repositories must serve the APKINDEX.tar.gz of the build architecture (x86_64 when
+//     none is set), checked with a HEAD request.
+//   - Keyring URLs, including the keys of the preset keyrings, must download and parse as PEM
+//     public keys; plaintext keyrings must parse.
+//
+// Local repositories and keyring paths only exist inside the build container; their checks are
+// skipped, as are the network checks in offline mode. Successful checks are cached in 'cache'
+// (the keys are shared with FetchKeyringCached); a nil cache disables caching. When 'client' is
+// nil, the client of httpfetchx.Default is used. Requests honor 'ctx', are retried with the
+// default retryx policy, and are throttled per host by ratelimitx.Default.
+//
+// Returns:
+//   - The diagnostics of every check. A failed check doesn't return an error; see
+//     PreflightReport.Err.
+//   - An error if the architecture or the inline configuration is invalid, or the default client
+//     can't be created.
+func (b *ApkoBuilder) PreflightCached(ctx context.Context, cache *cachex.Cache,
+	client *http.Client) (*PreflightReport, error) {
+	arch := ArchX8664
+	if b.buildArch != "" {
+		var err error
+		if arch, err = ParseArchitecture(b.buildArch); err != nil {
+			return nil, err
+		}
+	}
+
+	repositories, keyrings, err := b.preflightTargets()
+	if err != nil {
+		return nil, err
+	}
+
+	if client == nil && !b.offline {
+		if client, err = httpfetchx.Default().Client(); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &PreflightReport{}
+	for _, repo := range repositories {
+		report.Diagnostics = append(report.Diagnostics, b.checkRepository(ctx, cache, client, repo, arch))
+	}
+
+	for _, keyring := range keyrings {
+		report.Diagnostics = append(report.Diagnostics, b.checkKeyring(ctx, cache, client, keyring))
+	}
+
+	for _, key := range b.keyringAppendPlaintext {
+		d := Diagnostic{Kind: PreflightKeyring, Target: "plaintext keyring", Status: PreflightOK}
+		if err := parsePublicKey([]byte(key)); err != nil {
+			d.Status, d.Message = PreflightFailed, err.Error()
+		}
+		report.Diagnostics = append(report.Diagnostics, d)
+	}
+
+	return report, nil
+}
+
+// preflightTargets returns the repositories and keyrings the build uses: those of the builder
+// options, the keyrings being those the command appends (see keyrings), then those of the inline
+// configuration, deduplicated.
+func (b *ApkoBuilder) preflightTargets() ([]string, []string, error) {
+	var contents struct {
+		Repositories []string `yaml:"repositories"`
+		Keyring      []string `yaml:"keyring"`
+	}
+
+	if b.inlineConfig != "" {
+		if _, err := MutateConfig([]byte(b.inlineConfig), func(doc map[string]any) error {
+			return decodeSection(doc, "contents", &contents)
+		}); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	repositories := dedupe(append(append(append([]string(nil), b.repositoryAppend...),
+		b.buildRepositoryAppend...), contents.Repositories...))
+
+	keyrings := dedupe(append(b.keyrings(), contents.Keyring...))
+
+	return repositories, keyrings, nil
+}
+
+// checkRepository checks that the repository 'repo' serves the index of 'arch'.
+func (b *ApkoBuilder) checkRepository(ctx context.Context, cache *cachex.Cache, client *http.Client,
+	repo string, arch Architecture) Diagnostic {
+	d := Diagnostic{Kind: PreflightRepository, Target: repo}
+
+	base := repo
+	if strings.HasPrefix(base, "@") {
+		if _, rest, ok := strings.Cut(base, " "); ok {
+			base = strings.TrimSpace(rest)
+		}
+	}
+
+	if !isRemote(base) {
+		d.Status, d.Message = PreflightSkipped, "local repository, only available in the build container"
+		return d
+	}
+
+	d.URL = strings.TrimSuffix(base, "/") + "/" + string(arch) + "/APKINDEX.tar.gz"
+	if b.offline {
+		d.Status, d.Message = PreflightSkipped, "offline mode"
+		return d
+	}
+
+	start := time.Now()
+	_, err := cache.GetOrFetch(ctx, "apkindex:"+d.URL, func(ctx context.Context) ([]byte, error) {
+		return retryx.DoValue(ctx, nil, func(ctx context.Context) ([]byte, error) {
+			return []byte(http.StatusText(http.StatusOK)), headIndex(ctx, client, d.URL)
+		})
+	})
+	d.Duration = time.Since(start)
+
+	return finishDiagnostic(d, err)
+}
+
+// headIndex sends a HEAD request for the repository index at 'indexURL', once.
+func headIndex(ctx context.Context, client *http.Client, indexURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, indexURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create index request: %w", err)
+	}
+
+	if err := ratelimitx.Default().Wait(ctx, req.URL.Host); err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach repository index %s: %w", indexURL, err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to reach repository index %s: %w", indexURL,
+			&retryx.StatusError{StatusCode: resp.StatusCode, Status: resp.Status})
+	}
+
+	return nil
+}
+
+// checkKeyring checks that the keyring 'keyring' (a URL, a "path=url" pair, or a path inside the
+// build container) downloads and parses.
+func (b *ApkoBuilder) checkKeyring(ctx context.Context, cache *cachex.Cache, client *http.Client,
+	keyring string) Diagnostic {
+	d := Diagnostic{Kind: PreflightKeyring, Target: keyring}
+
+	keyURL := keyring
+	if _, u, ok := strings.Cut(keyring, "="); ok {
+		keyURL = u
+	}
+
+	for _, preset := range []string{"wolfi", "alpine"} {
+		if info, err := GetKeyringInfoForPreset(preset); err == nil && info.KeyPath == keyURL {
+			keyURL = info.KeyURL
+		}
+	}
+
+	if !isRemote(keyURL) {
+		d.Status, d.Message = PreflightSkipped, "keyring path, only available in the build container"
+		return d
+	}
+
+	d.URL = keyURL
+	if b.offline {
+		d.Status, d.Message = PreflightSkipped, "offline mode"
+		return d
+	}
+
+	start := time.Now()
+	data, err := FetchKeyringCached(ctx, cache, keyURL, client)
+	if err == nil {
+		err = parsePublicKey(data)
+	}
+	d.Duration = time.Since(start)
+
+	return finishDiagnostic(d, err)
+}
+
+// finishDiagnostic sets the status of 'd' from the error of its check.
+func finishDiagnostic(d Diagnostic, err error) Diagnostic {
+	if err == nil {
+		d.Status = PreflightOK
+		return d
+	}
+
+	d.Status, d.Message = PreflightFailed, err.Error()
+
+	var statusErr *retryx.StatusError
+	if errors.As(err, &statusErr) {
+		d.StatusCode = statusErr.StatusCode
+	}
+
+	return d
+}
+
+// parsePublicKey checks that 'data' holds a PEM-encoded PKIX public key.
+func parsePublicKey(data []byte) error {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return fmt.Errorf("not a PEM public key")
+	}
+
+	if _, err := x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+
+	return nil
+}
+
+// isRemote reports whether 's' is an HTTP or HTTPS URL.
+func isRemote(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// dedupe returns 'values' without empty values and duplicates, in order.
+func dedupe(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := values[:0]
+	for _, v := range values {
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+
+	return out
+}
@@ -0,0 +1,229 @@
+package apkox
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/cachex"
+)
+
+func newTestPEMKey(t *testing.T) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func newPreflightServer(t *testing.T, key string) (*httptest.Server, *int) {
+	t.Helper()
+
+	requests := 0
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case r.Method == http.MethodHead && r.URL.Path == "/os/aarch64/APKINDEX.tar.gz":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/key.rsa.pub":
+			_, _ = w.Write([]byte(key))
+		case r.URL.Path == "/broken.rsa.pub":
+			_, _ = w.Write([]byte(testPublicKey))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &requests
+}
+
+func statuses(report *PreflightReport) []string {
+	var out []string
+	for _, d := range report.Diagnostics {
+		out = append(out, string(d.Kind)+" "+string(d.Status))
+	}
+
+	return out
+}
+
+func TestPreflight(t *testing.T) {
+	key := newTestPEMKey(t)
+	srv, _ := newPreflightServer(t, key)
+
+	t.Run("reports every repository and keyring", func(t *testing.T) {
+		b := NewApkoBuilder().
+			WithBuildArch(ArchAarch64).
+			WithRepositoryAppend(srv.URL + "/os").
+			WithRepositoryAppend("@local /mnt/packages").
+			WithBuildRepositoryAppend(srv.URL + "/missing").
+			WithKeyring(srv.URL + "/key.rsa.pub").
+			WithKeyring("/etc/apk/keys/local.rsa.pub").
+			WithKeyring("/etc/apk/keys/broken.rsa.pub=" + srv.URL + "/broken.rsa.pub").
+			WithKeyringAppendPlaintext(key)
+
+		report, err := b.PreflightCached(context.Background(), nil, srv.Client())
+		if err != nil {
+			t.Fatalf("PreflightCached() error = %v", err)
+		}
+
+		want := []string{
+			"repository ok", "repository skipped", "repository failed",
+			"keyring ok", "keyring skipped", "keyring failed", "keyring ok",
+		}
+		if got := statuses(report); !reflect.DeepEqual(got, want) {
+			t.Errorf("statuses = %v, want %v", got, want)
+		}
+
+		if got := report.Diagnostics[0].URL; got != srv.URL+"/os/aarch64/APKINDEX.tar.gz" {
+			t.Errorf("index URL = %q", got)
+		}
+
+		if got := report.Diagnostics[2].StatusCode; got != http.StatusNotFound {
+			t.Errorf("StatusCode = %d, want %d", got, http.StatusNotFound)
+		}
+
+		if report.Passed() {
+			t.Error("Passed() = true, want false")
+		}
+
+		if len(report.Failed()) != 2 {
+			t.Errorf("Failed() = %v, want 2 diagnostics", report.Failed())
+		}
+
+		err = report.Err()
+		if err == nil || !strings.Contains(err.Error(), "repository "+srv.URL+"/missing: failed") {
+			t.Errorf("Err() = %v", err)
+		}
+	})
+
+	t.Run("reads the inline configuration", func(t *testing.T) {
+		b := NewApkoBuilder().WithInlineConfig("contents:\n  repositories:\n    - " + srv.URL +
+			"/os\n  keyring:\n    - " + srv.URL + "/key.rsa.pub\n").WithBuildArch(ArchAarch64)
+
+		report, err := b.PreflightCached(context.Background(), nil, srv.Client())
+		if err != nil {
+			t.Fatalf("PreflightCached() error = %v", err)
+		}
+
+		if got, want := statuses(report), []string{"repository ok", "keyring ok"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("statuses = %v, want %v", got, want)
+		}
+
+		if report.Err() != nil {
+			t.Errorf("Err() = %v, want nil", report.Err())
+		}
+	})
+
+	t.Run("rejects an invalid plaintext keyring", func(t *testing.T) {
+		report, err := NewApkoBuilder().WithKeyringAppendPlaintext("not a key").Preflight(context.Background())
+		if err != nil {
+			t.Fatalf("Preflight() error = %v", err)
+		}
+
+		if got, want := statuses(report), []string{"keyring failed"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("statuses = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("skips the network checks offline", func(t *testing.T) {
+		b := NewApkoBuilder().WithOffline().WithRepositoryAppend(srv.URL + "/missing").WithKeyRingWolfi()
+
+		report, err := b.Preflight(context.Background())
+		if err != nil {
+			t.Fatalf("Preflight() error = %v", err)
+		}
+
+		for _, d := range report.Diagnostics {
+			if d.Status != PreflightSkipped || d.Message != "offline mode" {
+				t.Errorf("diagnostic = %v, want skipped offline", d)
+			}
+		}
+
+		if got := report.Diagnostics[1].URL; got != "https://packages.wolfi.dev/os/wolfi-signing.rsa.pub" {
+			t.Errorf("preset keyring URL = %q", got)
+		}
+	})
+
+	t.Run("checks the keyrings the command appends", func(t *testing.T) {
+		b := NewApkoBuilder().WithOffline().WithKeyRingWolfi().WithWolfiKeyring().WithAlpineKeyring()
+
+		_, keyrings, err := b.preflightTargets()
+		if err != nil {
+			t.Fatalf("preflightTargets() error = %v", err)
+		}
+
+		if want := b.keyrings(); !reflect.DeepEqual(keyrings, want) {
+			t.Errorf("preflight keyrings = %v, want the command keyrings %v", keyrings, want)
+		}
+
+		report, err := b.Preflight(context.Background())
+		if err != nil {
+			t.Fatalf("Preflight() error = %v", err)
+		}
+
+		var urls []string
+		for _, d := range report.Diagnostics {
+			urls = append(urls, d.URL)
+		}
+		want := []string{
+			"https://packages.wolfi.dev/os/wolfi-signing.rsa.pub",
+			"https://alpinelinux.org/keys/alpine-devel@lists.alpinelinux.org-4a6a0840.rsa.pub",
+		}
+		if !reflect.DeepEqual(urls, want) {
+			t.Errorf("keyring URLs = %v, want %v", urls, want)
+		}
+	})
+
+	t.Run("rejects an invalid configuration", func(t *testing.T) {
+		if _, err := NewApkoBuilder().WithInlineConfig("contents: [").Preflight(context.Background()); err == nil {
+			t.Error("Preflight() error = nil, want an error")
+		}
+
+		if _, err := NewApkoBuilder().WithArchitecture("sparc").Preflight(context.Background()); err == nil {
+			t.Error("Preflight() error = nil, want an error")
+		}
+	})
+}
+
+func TestPreflightCache(t *testing.T) {
+	srv, requests := newPreflightServer(t, newTestPEMKey(t))
+	cache := cachex.NewCache(time.Hour)
+
+	b := NewApkoBuilder().
+		WithBuildArch(ArchAarch64).
+		WithRepositoryAppend(srv.URL + "/os").
+		WithKeyring(srv.URL + "/key.rsa.pub")
+
+	for i := 0; i < 2; i++ {
+		report, err := b.PreflightCached(context.Background(), cache, srv.Client())
+		if err != nil {
+			t.Fatalf("PreflightCached() error = %v", err)
+		}
+
+		if !report.Passed() {
+			t.Fatalf("Passed() = false: %v", report.Err())
+		}
+	}
+
+	if *requests != 2 {
+		t.Errorf("requests = %d, want 2", *requests)
+	}
+}
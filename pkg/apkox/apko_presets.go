@@ -0,0 +1,131 @@
+package apkox
+
+import (
+	"sort"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+const (
+	// ConfigPresetWolfiBase is a Wolfi image with a shell and the apk package manager.
+	ConfigPresetWolfiBase = "wolfi-base"
+	// ConfigPresetAlpineBase is an Alpine image with a shell and the apk package manager.
+	ConfigPresetAlpineBase = "alpine-base"
+	// ConfigPresetStatic is a minimal Wolfi image for static binaries, running as a non-root user.
+	ConfigPresetStatic = "static"
+)
+
+// configPresets holds the APKO configurations of the named presets.
+var configPresets = map[string]string{
+	ConfigPresetWolfiBase: `contents:
+  keyring:
+    - https://packages.wolfi.dev/os/wolfi-signing.rsa.pub
+  repositories:
+    - https://packages.wolfi.dev/os
+  packages:
+    - wolfi-base
+entrypoint:
+  command: /bin/sh -l
+archs:
+  - x86_64
+  - aarch64
+`,
+	ConfigPresetAlpineBase: `contents:
+  keyring:
+    - https://alpinelinux.org/keys/alpine-devel@lists.alpinelinux.org-4a6a0840.rsa.pub
+  repositories:
+    - https://dl-cdn.alpinelinux.org/alpine/edge/main
+  packages:
+    - alpine-base
+entrypoint:
+  command: /bin/sh -l
+archs:
+  - x86_64
+  - aarch64
+`,
+	ConfigPresetStatic: `contents:
+  keyring:
+    - https://packages.wolfi.dev/os/wolfi-signing.rsa.pub
+  repositories:
+    - https://packages.wolfi.dev/os
+  packages:
+    - wolfi-baselayout
+    - ca-certificates-bundle
+    - tzdata
+accounts:
+  groups:
+    - groupname: nonroot
+      gid: 65532
+  users:
+    - username: nonroot
+      uid: 65532
+      gid: 65532
+  run-as: 65532
+archs:
+  - x86_64
+  - aarch64
+`,
+}
+
+// ConfigPresets returns the names of the configuration presets, sorted.
+func ConfigPresets() []string {
+	names := make([]string, 0, len(configPresets))
+	for name := range configPresets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// GetConfigPreset returns the APKO configuration of a named preset.
+//
+// Returns:
+//   - The YAML configuration of the preset.
+//   - An error if the preset is unknown.
+func GetConfigPreset(name string) ([]byte, error) {
+	config, ok := configPresets[name]
+	if !ok {
+		return nil, errorsx.Unsupported(builderName, "configFile", name, "unsupported config preset: %s", name)
+	}
+
+	return []byte(config), nil
+}
+
+// ResolveApkoConfigOrPreset resolves a configuration file path or a preset name.
+// Preset names ("wolfi-base", "alpine-base", "static") resolve to the path under 'mntPrefix'
+// where the configuration must be materialized (see GetInlineConfigPath), and to its content.
+// Any other value is validated as a configuration file path, as GetApkoConfigOrPreset does.
+//
+// Returns:
+//   - The configuration file path.
+//   - The configuration content for presets, nil for file paths.
+//   - An error if the configuration file path is invalid.
+func ResolveApkoConfigOrPreset(mntPrefix, cfgOrPreset string) (string, []byte, error) {
+	if config, ok := configPresets[cfgOrPreset]; ok {
+		return GetInlineConfigPath(mntPrefix, config), []byte(config), nil
+	}
+
+	path, err := validateConfigFilePath(cfgOrPreset)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return path, nil, nil
+}
+
+// WithConfigPreset uses the configuration of a named preset as an inline configuration
+// (see WithInlineConfig). Unknown presets are reported by Warnings, and BuildCommand then
+// reports the missing configuration.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithConfigPreset(name string) *ApkoBuilder {
+	config, ok := configPresets[name]
+	if !ok {
+		b.warnings = append(b.warnings, errorsx.NewWarning(builderName, "configPreset",
+			"unknown config preset %q", name))
+		return b
+	}
+	b.WithInlineConfig(config)
+	return b
+}
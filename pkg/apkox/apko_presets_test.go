@@ -0,0 +1,81 @@
+package apkox
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfigPresets(t *testing.T) {
+	want := []string{ConfigPresetAlpineBase, ConfigPresetStatic, ConfigPresetWolfiBase}
+	if !reflect.DeepEqual(ConfigPresets(), want) {
+		t.Errorf("ConfigPresets() = %v, want %v", ConfigPresets(), want)
+	}
+
+	for _, name := range ConfigPresets() {
+		config, err := GetConfigPreset(name)
+		if err != nil {
+			t.Fatalf("GetConfigPreset(%s) returned unexpected error: %v", name, err)
+		}
+
+		var doc struct {
+			Contents struct {
+				Repositories []string `yaml:"repositories"`
+				Packages     []string `yaml:"packages"`
+			} `yaml:"contents"`
+		}
+		if err := yaml.Unmarshal(config, &doc); err != nil {
+			t.Fatalf("Preset %s is not valid YAML: %v", name, err)
+		}
+
+		if len(doc.Contents.Repositories) == 0 || len(doc.Contents.Packages) == 0 {
+			t.Errorf("Preset %s has no repositories or packages", name)
+		}
+	}
+
+	if _, err := GetConfigPreset("debian"); !errors.Is(err, errorsx.ErrUnsupported) {
+		t.Errorf("GetConfigPreset() error = %v, want %v", err, errorsx.ErrUnsupported)
+	}
+}
+
+func TestResolveApkoConfigOrPreset(t *testing.T) {
+	path, content, err := ResolveApkoConfigOrPreset("/src", ConfigPresetStatic)
+	if err != nil {
+		t.Fatalf("ResolveApkoConfigOrPreset() returned unexpected error: %v", err)
+	}
+
+	want, _ := GetConfigPreset(ConfigPresetStatic)
+	if string(content) != string(want) || path != GetInlineConfigPath("/src", string(want)) {
+		t.Errorf("ResolveApkoConfigOrPreset() = %s, %q", path, content)
+	}
+
+	path, content, err = ResolveApkoConfigOrPreset("/src", "apko.yaml")
+	if err != nil || path != "apko.yaml" || content != nil {
+		t.Errorf("ResolveApkoConfigOrPreset() = %s, %q, %v for a file path", path, content, err)
+	}
+
+	if _, _, err := ResolveApkoConfigOrPreset("/src", "wolfi"); !errors.Is(err, errorsx.ErrInvalidValue) {
+		t.Errorf("ResolveApkoConfigOrPreset() error = %v, want %v", err, errorsx.ErrInvalidValue)
+	}
+
+	if got, _ := GetApkoConfigOrPreset("", ConfigPresetWolfiBase); got == "" {
+		t.Error("Expected GetApkoConfigOrPreset to resolve presets")
+	}
+}
+
+func TestApkoBuilderWithConfigPreset(t *testing.T) {
+	b := NewApkoBuilder().WithConfigPreset(ConfigPresetWolfiBase)
+	want, _ := GetConfigPreset(ConfigPresetWolfiBase)
+
+	mounts := b.Mounts()
+	if len(mounts) != 1 || mounts[0].Content != string(want) || b.configFile != mounts[0].Target {
+		t.Errorf("Unexpected mounts %v for config %s", mounts, b.configFile)
+	}
+
+	if NewApkoBuilder().WithConfigPreset("debian").Mounts() != nil {
+		t.Error("Expected unknown presets to be ignored")
+	}
+}
@@ -0,0 +1,77 @@
+package apkox
+
+import (
+	"path"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/checksumx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// PublishedRef is a reference pushed by apko publish, pinned to its digest.
+type PublishedRef struct {
+	// Ref is the digest reference (e.g. "registry.example.com/app@sha256:...").
+	Ref string `json:"ref"`
+	// Repository is the repository of the reference (e.g. "registry.example.com/app").
+	Repository string `json:"repository"`
+	// Digest is the digest of the pushed image or index (e.g. "sha256:...").
+	Digest string `json:"digest"`
+}
+
+// WithImageRefs sets the file apko publish writes the references it pushed to, one digest
+// reference per line (see ParseImageRefs). It is only used by PublishCommand.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithImageRefs(path string) *ApkoBuilder {
+	b.imageRefs = path
+	return b
+}
+
+// PublishCommand generates the apko publish command, which builds the image as BuildCommand
+// does and pushes it to the output image instead of writing the output tarball, which is not
+// required.
+//
+// Returns:
+//   - The apko publish command.
+//   - An error if the configuration is invalid.
+func (b *ApkoBuilder) PublishCommand() ([]string, error) {
+	return b.command(true)
+}
+
+// GetImageRefsPath returns the path of the image references file written by apko publish.
+// It takes a string parameter 'mntPrefix' which is the mount prefix.
+func GetImageRefsPath(mntPrefix string) string {
+	return path.Join(mntPrefix, "image-refs")
+}
+
+// ParseImageRefs parses the image references file written by apko publish: one digest
+// reference per line, with blank lines ignored.
+//
+// Returns:
+//   - The published references, in order.
+//   - An error if a line is not a reference pinned to a valid digest.
+func ParseImageRefs(data []byte) ([]PublishedRef, error) {
+	var refs []PublishedRef
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		repository, digest, ok := strings.Cut(line, "@")
+		if !ok || repository == "" {
+			return nil, errorsx.InvalidValue(builderName, "imageRefs", line,
+				"line %d is not a digest reference: %q", i+1, line)
+		}
+
+		d, err := checksumx.ParseDigest(digest)
+		if err != nil {
+			return nil, errorsx.InvalidValue(builderName, "imageRefs", line,
+				"line %d has an invalid digest: %v", i+1, err)
+		}
+
+		refs = append(refs, PublishedRef{Ref: repository + "@" + d.String(), Repository: repository, Digest: d.String()})
+	}
+
+	return refs, nil
+}
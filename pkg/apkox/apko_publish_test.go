@@ -0,0 +1,123 @@
+package apkox
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+const (
+	testIndexDigest = "sha256:" + "1111111111111111111111111111111111111111111111111111111111111111"
+	testImageDigest = "sha256:" + "2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+func TestPublishCommand(t *testing.T) {
+	b := NewApkoBuilder().
+		WithConfigFile("apko.yaml").
+		WithOutputImage("registry.example.com/app").
+		WithTag("v1").
+		WithImageRefs("/mnt/image-refs")
+
+	cmd, err := b.PublishCommand()
+	if err != nil {
+		t.Fatalf("PublishCommand returned unexpected error: %v", err)
+	}
+
+	want := []string{
+		"apko", "publish", "--sbom=false", "--vcs=false", "--image-refs", "/mnt/image-refs",
+		"apko.yaml", "registry.example.com/app:v1",
+	}
+	if !reflect.DeepEqual(cmd, want) {
+		t.Errorf("PublishCommand() = %v, want %v", cmd, want)
+	}
+
+	// The build command requires the output tarball, and doesn't write image references.
+	if _, err := b.BuildCommand(); !errors.Is(err, errorsx.ErrMissingField) {
+		t.Errorf("BuildCommand() error = %v, want ErrMissingField", err)
+	}
+
+	cmd, err = b.WithOutputTarball("/mnt/image.tar").BuildCommand()
+	if err != nil {
+		t.Fatalf("BuildCommand returned unexpected error: %v", err)
+	}
+	if strings.Contains(strings.Join(cmd, " "), "--image-refs") {
+		t.Errorf("BuildCommand() = %v, must not contain --image-refs", cmd)
+	}
+}
+
+func TestPublishCommandExtraArgsConflict(t *testing.T) {
+	_, err := NewApkoBuilder().
+		WithConfigFile("apko.yaml").
+		WithOutputImage("registry.example.com/app").
+		WithImageRefs("/mnt/image-refs").
+		WithExtraArg("--image-refs=/tmp/refs").
+		WithExtraArgsPolicy(ExtraArgsError).
+		PublishCommand()
+	if !errors.Is(err, errorsx.ErrConflictingOptions) {
+		t.Errorf("PublishCommand() error = %v, want ErrConflictingOptions", err)
+	}
+}
+
+func TestGetImageRefsPath(t *testing.T) {
+	if got := GetImageRefsPath("/mnt"); got != "/mnt/image-refs" {
+		t.Errorf("GetImageRefsPath() = %q, want /mnt/image-refs", got)
+	}
+}
+
+func TestParseImageRefs(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []PublishedRef
+		wantErr bool
+	}{
+		{
+			name: "digest without algorithm",
+			data: "registry.example.com/app@" + testIndexDigest + "\n\n  registry.example.com/app@" +
+				strings.ToUpper(testImageDigest[7:]) + "\n",
+			wantErr: true,
+		},
+		{
+			name: "refs",
+			data: "registry.example.com/app@" + testIndexDigest + "\n\nlocalhost:5000/app@" + testImageDigest + "\n",
+			want: []PublishedRef{
+				{
+					Ref:        "registry.example.com/app@" + testIndexDigest,
+					Repository: "registry.example.com/app",
+					Digest:     testIndexDigest,
+				},
+				{
+					Ref:        "localhost:5000/app@" + testImageDigest,
+					Repository: "localhost:5000/app",
+					Digest:     testImageDigest,
+				},
+			},
+		},
+		{name: "empty", data: "\n"},
+		{name: "tag reference", data: "registry.example.com/app:v1\n", wantErr: true},
+		{name: "invalid digest", data: "registry.example.com/app@sha256:abc\n", wantErr: true},
+		{name: "missing repository", data: "@" + testIndexDigest, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseImageRefs([]byte(tt.data))
+			if tt.wantErr {
+				if !errors.Is(err, errorsx.ErrInvalidValue) {
+					t.Errorf("ParseImageRefs() error = %v, want ErrInvalidValue", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseImageRefs returned unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseImageRefs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package apkox
+
+import (
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// ShellMode tells BuildCommand whether the command runs through `sh -c`, where shell
+// metacharacters in user-supplied values (e.g. a tag of "v1; rm -rf /") would inject commands.
+type ShellMode string
+
+const (
+	// ShellModeNone runs the command as an argument vector, which no value can escape. It is the
+	// default mode.
+	ShellModeNone ShellMode = ""
+	// ShellModeReject makes BuildCommand fail when an argument of the generated command holds a
+	// shell metacharacter.
+	ShellModeReject ShellMode = "reject"
+	// ShellModeEscape makes BuildCommand return the command wrapped in `sh -c`, with every
+	// argument quoted.
+	ShellModeEscape ShellMode = "escape"
+)
+
+// shellMetacharacters are the characters sh interprets in an unquoted word: command separators,
+// pipes, substitutions, redirections, quotes and escapes, the whitespace splitting words, the
+// glob, tilde and brace expansions, and comments.
+const shellMetacharacters = ";|&`$()<>\\'\"\n\r \t*?[]~{}#"
+
+// WithShellMode sets whether the command runs through `sh -c`, and how BuildCommand guards the
+// user-supplied values (config paths, tags, image names, extra arguments...) against command
+// injection: by rejecting shell metacharacters, or by quoting every argument of a `sh -c`
+// command.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithShellMode(mode ShellMode) *ApkoBuilder {
+	b.shellMode = mode
+	return b
+}
+
+// validateShellMode checks that the shell mode is supported.
+func (b *ApkoBuilder) validateShellMode() error {
+	switch b.shellMode {
+	case ShellModeNone, ShellModeReject, ShellModeEscape:
+		return nil
+	default:
+		return errorsx.Unsupported(builderName, "shellMode", b.shellMode, "unsupported shell mode: %s", b.shellMode)
+	}
+}
+
+// validateShellSafety rejects the arguments of the generated command 'cmd' holding shell
+// metacharacters, in ShellModeReject. The final arguments are checked, so no option escapes the
+// check; the error names the option the argument comes from when it is known. 'tag' is the tag
+// of the image reference.
+func (b *ApkoBuilder) validateShellSafety(cmd []string, tag string) error {
+	if b.shellMode != ShellModeReject {
+		return nil
+	}
+
+	for _, arg := range cmd {
+		if i := strings.IndexAny(arg, shellMetacharacters); i >= 0 {
+			field := b.shellField(arg, tag)
+			return errorsx.InvalidValue(builderName, field, arg,
+				"%s holds the shell metacharacter %q, refusing to generate a command run by sh -c",
+				field, arg[i])
+		}
+	}
+
+	return nil
+}
+
+// shellField returns the option the command argument 'arg' comes from, or "command" when it is
+// not known.
+func (b *ApkoBuilder) shellField(arg, tag string) string {
+	fields := []struct {
+		name   string
+		values []string
+	}{
+		{"extraArgs", b.extraArgs},
+		{"tag", []string{tag}},
+		{"outputImage", []string{b.outputImage}},
+		{"configFile", []string{b.configFile}},
+		{"outputTarball", []string{b.outputTarball}},
+		{"cacheDir", []string{b.cacheDir}},
+		{"buildArch", []string{b.buildArch}},
+		{"keyringPaths", b.keyringPaths},
+		{"buildRepositoryAppend", b.buildRepositoryAppend},
+		{"repositoryAppend", b.repositoryAppend},
+		{"sbomPath", []string{b.sbomPath}},
+		{"logLevel", []string{b.logLevel}},
+		{"imageRefs", []string{b.imageRefs}},
+	}
+
+	for _, f := range fields {
+		for _, v := range f.values {
+			if v != "" && strings.Contains(arg, v) && strings.ContainsAny(v, shellMetacharacters) {
+				return f.name
+			}
+		}
+	}
+
+	return "command"
+}
+
+// shellWrap returns 'cmd' as a `sh -c` command with every argument quoted, in ShellModeEscape.
+func (b *ApkoBuilder) shellWrap(cmd []string) []string {
+	if b.shellMode != ShellModeEscape {
+		return cmd
+	}
+
+	return []string{"sh", "-c", cmdx.ShellJoin(cmd)}
+}
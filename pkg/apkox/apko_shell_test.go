@@ -0,0 +1,101 @@
+package apkox
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+func TestApkoBuilderWithShellModeReject(t *testing.T) {
+	tests := []struct {
+		name      string
+		builder   *ApkoBuilder
+		wantField string
+	}{
+		{name: "Tag", builder: newTestBuilder().WithTag("v1; rm -rf /"), wantField: "tag"},
+		{name: "Image", builder: newTestBuilder().WithOutputImage("app|nc evil 80"), wantField: "outputImage"},
+		{name: "Config", builder: newTestBuilder().WithConfigFile("$(curl evil)"), wantField: "configFile"},
+		{name: "Tarball", builder: newTestBuilder().WithOutputTarball("/out/`id`.tar"), wantField: "outputTarball"},
+		{name: "Extra arg", builder: newTestBuilder().WithExtraArg("--log-level=info && id"), wantField: "extraArgs"},
+		{name: "Repository", builder: newTestBuilder().WithRepositoryAppend("https://r > /etc/passwd"), wantField: "repositoryAppend"},
+		{name: "Tag with extra arguments", builder: newTestBuilder().WithTag("v1 --keyring-append /tmp/evil"), wantField: "tag"},
+		{name: "Glob", builder: newTestBuilder().WithOutputTarball("/out/*.tar"), wantField: "outputTarball"},
+		{name: "Tab", builder: newTestBuilder().WithCacheDir("/cache\t--offline"), wantField: "cacheDir"},
+		{name: "Comment", builder: newTestBuilder().WithExtraArg("#"), wantField: "extraArgs"},
+		{name: "Tilde", builder: newTestBuilder().WithKeyring("~/key.pub"), wantField: "keyringPaths"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.WithShellMode(ShellModeReject).BuildCommand()
+
+			var xerr *errorsx.Error
+			if !errors.As(err, &xerr) || !errors.Is(err, errorsx.ErrInvalidValue) {
+				t.Fatalf("BuildCommand() error = %v, want %v", err, errorsx.ErrInvalidValue)
+			}
+			if xerr.Field != tt.wantField {
+				t.Errorf("BuildCommand() error field = %s, want %s", xerr.Field, tt.wantField)
+			}
+
+			// Commands run as an argument vector are not checked.
+			if _, err := tt.builder.WithShellMode(ShellModeNone).BuildCommand(); err != nil {
+				t.Errorf("BuildCommand() without shell mode returned unexpected error: %v", err)
+			}
+		})
+	}
+
+	if _, err := newTestBuilder().WithShellMode(ShellModeReject).BuildCommand(); err != nil {
+		t.Errorf("BuildCommand() returned unexpected error for safe values: %v", err)
+	}
+
+	_, err := newTestBuilder().WithShellMode("maybe").BuildCommand()
+	if !errors.Is(err, errorsx.ErrUnsupported) {
+		t.Errorf("BuildCommand() error = %v, want %v", err, errorsx.ErrUnsupported)
+	}
+}
+
+func TestApkoBuilderShellSafetyChecksFinalArguments(t *testing.T) {
+	b := newTestBuilder().WithShellMode(ShellModeReject)
+
+	// Arguments of options not mapped to a field are still checked.
+	err := b.validateShellSafety([]string{"apko", "build", "--annotations=a:b c"}, "v1.0.0")
+
+	var xerr *errorsx.Error
+	if !errors.As(err, &xerr) || !errors.Is(err, errorsx.ErrInvalidValue) {
+		t.Fatalf("validateShellSafety() error = %v, want %v", err, errorsx.ErrInvalidValue)
+	}
+	if xerr.Field != "command" {
+		t.Errorf("validateShellSafety() error field = %s, want command", xerr.Field)
+	}
+}
+
+func TestApkoBuilderWithShellModeEscape(t *testing.T) {
+	cmd, err := newTestBuilder().
+		WithShellMode(ShellModeEscape).
+		WithExtraArg("--annotations=title:it's; id").
+		BuildCommand()
+	if err != nil {
+		t.Fatalf("BuildCommand() returned unexpected error: %v", err)
+	}
+
+	want := `apko build config.yaml my-image:v1 output.tar '--annotations=title:it'\''s; id'`
+	if len(cmd) != 3 || cmd[0] != "sh" || cmd[1] != "-c" || cmd[2] != want {
+		t.Fatalf("BuildCommand() = %q, want sh -c %q", cmd, want)
+	}
+
+	// sh parses the extra argument as a single word, without running "id".
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh is not available")
+	}
+
+	out, err := exec.Command(sh, "-c", "set -- "+cmd[2]+`; printf '%s|%s' "$#" "$6"`).Output()
+	if err != nil {
+		t.Fatalf("sh failed: %v", err)
+	}
+	if got := string(out); got != "6|--annotations=title:it's; id" {
+		t.Errorf("sh parsed the command as %q", got)
+	}
+}
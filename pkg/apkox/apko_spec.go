@@ -0,0 +1,205 @@
+package apkox
+
+// ApkoSpec is the declarative form of an ApkoBuilder configuration, as read from YAML or JSON
+// spec files. Field names follow the builder options.
+type ApkoSpec struct {
+	ConfigFile                    string            `json:"configFile" yaml:"configFile"`
+	OutputImage                   string            `json:"outputImage" yaml:"outputImage"`
+	Tag                           string            `json:"tag,omitempty" yaml:"tag,omitempty"`
+	OutputTarball                 string            `json:"outputTarball" yaml:"outputTarball"`
+	Keyrings                      []string          `json:"keyrings,omitempty" yaml:"keyrings,omitempty"`
+	WolfiKeyring                  bool              `json:"wolfiKeyring,omitempty" yaml:"wolfiKeyring,omitempty"`
+	AlpineKeyring                 bool              `json:"alpineKeyring,omitempty" yaml:"alpineKeyring,omitempty"`
+	CacheDir                      string            `json:"cacheDir,omitempty" yaml:"cacheDir,omitempty"`
+	ExtraArgs                     []string          `json:"extraArgs,omitempty" yaml:"extraArgs,omitempty"`
+	Arch                          string            `json:"arch,omitempty" yaml:"arch,omitempty"`
+	BuildContext                  string            `json:"buildContext,omitempty" yaml:"buildContext,omitempty"`
+	BuildRepositoryAppend         []string          `json:"buildRepositoryAppend,omitempty" yaml:"buildRepositoryAppend,omitempty"`
+	Debug                         bool              `json:"debug,omitempty" yaml:"debug,omitempty"`
+	KeyringAppendPlaintext        []string          `json:"keyringAppendPlaintext,omitempty" yaml:"keyringAppendPlaintext,omitempty"`
+	KeyringAppendSecrets          []string          `json:"keyringAppendSecrets,omitempty" yaml:"keyringAppendSecrets,omitempty"`
+	NoNetwork                     bool              `json:"noNetwork,omitempty" yaml:"noNetwork,omitempty"`
+	RepositoryAppend              []string          `json:"repositoryAppend,omitempty" yaml:"repositoryAppend,omitempty"`
+	Timestamp                     string            `json:"timestamp,omitempty" yaml:"timestamp,omitempty"`
+	Annotations                   map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	BuildDate                     string            `json:"buildDate,omitempty" yaml:"buildDate,omitempty"`
+	Lockfile                      string            `json:"lockfile,omitempty" yaml:"lockfile,omitempty"`
+	Offline                       bool              `json:"offline,omitempty" yaml:"offline,omitempty"`
+	PackageAppend                 []string          `json:"packageAppend,omitempty" yaml:"packageAppend,omitempty"`
+	SBOM                          bool              `json:"sbom,omitempty" yaml:"sbom,omitempty"`
+	SBOMFormats                   []string          `json:"sbomFormats,omitempty" yaml:"sbomFormats,omitempty"`
+	SBOMPath                      string            `json:"sbomPath,omitempty" yaml:"sbomPath,omitempty"`
+	VCS                           bool              `json:"vcs,omitempty" yaml:"vcs,omitempty"`
+	LogLevel                      string            `json:"logLevel,omitempty" yaml:"logLevel,omitempty"`
+	LogPolicy                     []string          `json:"logPolicy,omitempty" yaml:"logPolicy,omitempty"`
+	Workdir                       string            `json:"workdir,omitempty" yaml:"workdir,omitempty"`
+	LayeringStrategy              LayerStrategy     `json:"layeringStrategy,omitempty" yaml:"layeringStrategy,omitempty"`
+	LayeringBudget                int               `json:"layeringBudget,omitempty" yaml:"layeringBudget,omitempty"`
+	ReleaseRegistries             []string          `json:"releaseRegistries,omitempty" yaml:"releaseRegistries,omitempty"`
+	ArchTagTemplate               string            `json:"archTagTemplate,omitempty" yaml:"archTagTemplate,omitempty"`
+	ShellMode                     ShellMode         `json:"shellMode,omitempty" yaml:"shellMode,omitempty"`
+	InlineConfig                  string            `json:"inlineConfig,omitempty" yaml:"inlineConfig,omitempty"`
+	ExtraArgsPolicy               ExtraArgsPolicy   `json:"extraArgsPolicy,omitempty" yaml:"extraArgsPolicy,omitempty"`
+	Groups                        []Group           `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Users                         []User            `json:"users,omitempty" yaml:"users,omitempty"`
+	RunAs                         string            `json:"runAs,omitempty" yaml:"runAs,omitempty"`
+	RequireNonRoot                bool              `json:"requireNonRoot,omitempty" yaml:"requireNonRoot,omitempty"`
+	Environment                   []EnvEntry        `json:"environment,omitempty" yaml:"environment,omitempty"`
+	Paths                         []PathEntry       `json:"paths,omitempty" yaml:"paths,omitempty"`
+	Certificates                  []Certificate     `json:"certificates,omitempty" yaml:"certificates,omitempty"`
+	PackageRules                  []PackageRule     `json:"packageRules,omitempty" yaml:"packageRules,omitempty"`
+	ApkoVersion                   string            `json:"apkoVersion,omitempty" yaml:"apkoVersion,omitempty"`
+	ImageRefs                     string            `json:"imageRefs,omitempty" yaml:"imageRefs,omitempty"`
+	BuildInfoPath                 string            `json:"buildInfoPath,omitempty" yaml:"buildInfoPath,omitempty"`
+	CacheProvenance               bool              `json:"cacheProvenance,omitempty" yaml:"cacheProvenance,omitempty"`
+	InsecureIgnoreSignatures      bool              `json:"insecureIgnoreSignatures,omitempty" yaml:"insecureIgnoreSignatures,omitempty"`
+	InsecureAllowHTTPRepositories bool              `json:"insecureAllowHTTPRepositories,omitempty" yaml:"insecureAllowHTTPRepositories,omitempty"`
+	Production                    bool              `json:"production,omitempty" yaml:"production,omitempty"`
+	Strict                        bool              `json:"strict,omitempty" yaml:"strict,omitempty"`
+}
+
+// NewApkoBuilderFromSpec creates an ApkoBuilder configured from the spec.
+func NewApkoBuilderFromSpec(spec ApkoSpec) *ApkoBuilder {
+	b := &ApkoBuilder{
+		configFile:             spec.ConfigFile,
+		outputImage:            spec.OutputImage,
+		tag:                    spec.Tag,
+		outputTarball:          spec.OutputTarball,
+		keyringPaths:           append([]string(nil), spec.Keyrings...),
+		cacheDir:               spec.CacheDir,
+		extraArgs:              append([]string(nil), spec.ExtraArgs...),
+		buildArch:              spec.Arch,
+		buildContext:           spec.BuildContext,
+		buildRepositoryAppend:  append([]string(nil), spec.BuildRepositoryAppend...),
+		debug:                  spec.Debug,
+		keyringAppendPlaintext: append([]string(nil), spec.KeyringAppendPlaintext...),
+		keyringAppendSecrets:   append([]string(nil), spec.KeyringAppendSecrets...),
+		noNetwork:              spec.NoNetwork,
+		repositoryAppend:       append([]string(nil), spec.RepositoryAppend...),
+		timestamp:              spec.Timestamp,
+		annotations:            copyStringMap(spec.Annotations),
+		buildDate:              spec.BuildDate,
+		lockfile:               spec.Lockfile,
+		offline:                spec.Offline,
+		packageAppend:          append([]string(nil), spec.PackageAppend...),
+		sbom:                   spec.SBOM,
+		sbomFormats:            append([]string(nil), spec.SBOMFormats...),
+		sbomPath:               spec.SBOMPath,
+		vcs:                    spec.VCS,
+		logLevel:               spec.LogLevel,
+		logPolicy:              append([]string(nil), spec.LogPolicy...),
+		workdir:                spec.Workdir,
+		layeringStrategy:       spec.LayeringStrategy,
+		layeringBudget:         spec.LayeringBudget,
+		releaseRegistries:      append([]string(nil), spec.ReleaseRegistries...),
+		archTagTemplate:        spec.ArchTagTemplate,
+		shellMode:              spec.ShellMode,
+		extraArgsPolicy:        spec.ExtraArgsPolicy,
+		accounts: Accounts{
+			Groups: append([]Group(nil), spec.Groups...),
+			Users:  append([]User(nil), spec.Users...),
+			RunAs:  spec.RunAs,
+		},
+		requireNonRoot:  spec.RequireNonRoot,
+		environment:     append([]EnvEntry(nil), spec.Environment...),
+		paths:           append([]PathEntry(nil), spec.Paths...),
+		certificates:    append([]Certificate(nil), spec.Certificates...),
+		packageRules:    append([]PackageRule(nil), spec.PackageRules...),
+		apkoVersion:     spec.ApkoVersion,
+		imageRefs:       spec.ImageRefs,
+		buildInfoPath:   spec.BuildInfoPath,
+		cacheProvenance: spec.CacheProvenance,
+		insecure: InsecureOptions{
+			IgnoreSignatures:      spec.InsecureIgnoreSignatures,
+			AllowHTTPRepositories: spec.InsecureAllowHTTPRepositories,
+		},
+		production: spec.Production,
+		strict:     spec.Strict,
+	}
+
+	if spec.InlineConfig != "" {
+		b.WithInlineConfig(spec.InlineConfig)
+	}
+
+	// The keyring presets resolve to key paths, as WithKeyRingWolfi and WithKeyRingAlpine do.
+	if spec.WolfiKeyring {
+		b.WithKeyRingWolfi()
+	}
+	if spec.AlpineKeyring {
+		b.WithKeyRingAlpine()
+	}
+
+	return b
+}
+
+// Spec returns the declarative form of the builder configuration. Keyring presets are reported
+// as the key paths they resolve to.
+func (b *ApkoBuilder) Spec() ApkoSpec {
+	return ApkoSpec{
+		ConfigFile:                    b.configFile,
+		OutputImage:                   b.outputImage,
+		Tag:                           b.tag,
+		OutputTarball:                 b.outputTarball,
+		Keyrings:                      append([]string(nil), b.keyringPaths...),
+		CacheDir:                      b.cacheDir,
+		ExtraArgs:                     append([]string(nil), b.extraArgs...),
+		Arch:                          b.buildArch,
+		BuildContext:                  b.buildContext,
+		BuildRepositoryAppend:         append([]string(nil), b.buildRepositoryAppend...),
+		Debug:                         b.debug,
+		KeyringAppendPlaintext:        append([]string(nil), b.keyringAppendPlaintext...),
+		KeyringAppendSecrets:          append([]string(nil), b.keyringAppendSecrets...),
+		NoNetwork:                     b.noNetwork,
+		RepositoryAppend:              append([]string(nil), b.repositoryAppend...),
+		Timestamp:                     b.timestamp,
+		Annotations:                   copyStringMap(b.annotations),
+		BuildDate:                     b.buildDate,
+		Lockfile:                      b.lockfile,
+		Offline:                       b.offline,
+		PackageAppend:                 append([]string(nil), b.packageAppend...),
+		SBOM:                          b.sbom,
+		SBOMFormats:                   append([]string(nil), b.sbomFormats...),
+		SBOMPath:                      b.sbomPath,
+		VCS:                           b.vcs,
+		LogLevel:                      b.logLevel,
+		LogPolicy:                     append([]string(nil), b.logPolicy...),
+		Workdir:                       b.workdir,
+		LayeringStrategy:              b.layeringStrategy,
+		LayeringBudget:                b.layeringBudget,
+		ReleaseRegistries:             append([]string(nil), b.releaseRegistries...),
+		ArchTagTemplate:               b.archTagTemplate,
+		ShellMode:                     b.shellMode,
+		InlineConfig:                  b.inlineConfig,
+		ExtraArgsPolicy:               b.extraArgsPolicy,
+		Groups:                        append([]Group(nil), b.accounts.Groups...),
+		Users:                         append([]User(nil), b.accounts.Users...),
+		RunAs:                         b.accounts.RunAs,
+		RequireNonRoot:                b.requireNonRoot,
+		Environment:                   append([]EnvEntry(nil), b.environment...),
+		Paths:                         append([]PathEntry(nil), b.paths...),
+		Certificates:                  append([]Certificate(nil), b.certificates...),
+		PackageRules:                  append([]PackageRule(nil), b.packageRules...),
+		ApkoVersion:                   b.apkoVersion,
+		ImageRefs:                     b.imageRefs,
+		BuildInfoPath:                 b.buildInfoPath,
+		CacheProvenance:               b.cacheProvenance,
+		InsecureIgnoreSignatures:      b.insecure.IgnoreSignatures,
+		InsecureAllowHTTPRepositories: b.insecure.AllowHTTPRepositories,
+		Production:                    b.production,
+		Strict:                        b.strict,
+	}
+}
+
+// copyStringMap returns a copy of 'm', or nil if 'm' is empty.
+func copyStringMap(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+
+	return out
+}
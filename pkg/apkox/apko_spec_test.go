@@ -0,0 +1,64 @@
+package apkox
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewApkoBuilderFromSpec(t *testing.T) {
+	spec := ApkoSpec{
+		ConfigFile:    "apko.yaml",
+		OutputImage:   "example/base",
+		Tag:           "v1",
+		OutputTarball: "/mnt/image.tar",
+		CacheDir:      "/cache",
+		Arch:          "aarch64",
+		WolfiKeyring:  true,
+		ExtraArgs:     []string{"--debug"},
+	}
+
+	cmd, err := NewApkoBuilderFromSpec(spec).BuildCommand()
+	if err != nil {
+		t.Fatalf("BuildCommand returned unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"apko", "build",
+		"--cache-dir", "/cache",
+		"--keyring-append", ApkoWolfiSigninRsaKeyPath,
+		"--arch", "aarch64",
+		"--sbom=false",
+		"--vcs=false",
+		"apko.yaml", "example/base:v1", "/mnt/image.tar",
+		"--debug",
+	}
+
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("BuildCommand() = %v, want %v", cmd, expected)
+	}
+}
+
+func TestApkoBuilderSpecRoundTrip(t *testing.T) {
+	b := NewApkoBuilder().
+		WithConfigFile("apko.yaml").
+		WithOutputImage("example/base").
+		WithOutputTarball("/mnt/image.tar").
+		WithKeyring("/keys/custom.rsa.pub").
+		WithAnnotations(map[string]string{"org.opencontainers.image.source": "https://example.com"}).
+		WithPackageAppend("curl").
+		WithSBOM(true)
+
+	spec := b.Spec()
+	if spec.ConfigFile != "apko.yaml" || !spec.SBOM || spec.Annotations["org.opencontainers.image.source"] != "https://example.com" {
+		t.Errorf("Unexpected spec %+v", spec)
+	}
+
+	if !reflect.DeepEqual(NewApkoBuilderFromSpec(spec).Spec(), spec) {
+		t.Errorf("Expected spec to survive a round trip, got %+v", NewApkoBuilderFromSpec(spec).Spec())
+	}
+
+	spec.Annotations["changed"] = "yes"
+	if _, ok := b.Spec().Annotations["changed"]; ok {
+		t.Error("Expected Spec to return a copy of the annotations")
+	}
+}
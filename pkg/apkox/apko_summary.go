@@ -0,0 +1,119 @@
+package apkox
+
+import (
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BuildSummary describes the outputs of the generated build command, so publish, scan and attest
+// steps can be wired from structured data rather than re-deriving paths.
+type BuildSummary struct {
+	// Image is the output image name, without tag.
+	Image string `json:"image"`
+	// ImageRef is the reference of the output image with its tag (e.g. "example/app:v1").
+	ImageRef string `json:"imageRef"`
+	// Tags are the tags of the output image.
+	Tags []string `json:"tags"`
+	// Tarball is the path of the output tarball.
+	Tarball string `json:"tarball"`
+	// ConfigFile is the path of the configuration the command uses.
+	ConfigFile string `json:"configFile"`
+	// SBOMPaths are the paths of the SBOMs apko writes: one per architecture, and the index of
+	// multi-arch builds. Empty when SBOM generation is disabled.
+	SBOMPaths []string `json:"sbomPaths,omitempty"`
+	// Archs are the built architectures. Empty when they come from a configuration file, which
+	// the builder doesn't read.
+	Archs []Architecture `json:"archs,omitempty"`
+	// CacheDir is the APKO cache directory, if any.
+	CacheDir string `json:"cacheDir,omitempty"`
+}
+
+// BuildCommandWithSummary generates the build command, as BuildCommand does, and the summary of
+// its outputs.
+//
+// Returns:
+//   - The apko build command.
+//   - The summary of the outputs of the command.
+//   - An error if the configuration is invalid.
+func (b *ApkoBuilder) BuildCommandWithSummary() ([]string, *BuildSummary, error) {
+	cmd, err := b.BuildCommand()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	configFile, content, err := b.effectiveConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tag, err := b.archTag(b.effectiveTag())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	summary := &BuildSummary{
+		Image:      b.outputImage,
+		ImageRef:   b.outputImage + ":" + tag,
+		Tags:       []string{tag},
+		Tarball:    b.outputTarball,
+		ConfigFile: configFile,
+		Archs:      b.summaryArchs(content),
+		CacheDir:   b.cacheDir,
+	}
+
+	if b.sbom {
+		summary.SBOMPaths = sbomPaths(b.sbomDir(), summary.Archs)
+	}
+
+	return cmd, summary, nil
+}
+
+// summaryArchs returns the built architectures: the build architecture if set, otherwise the
+// architectures of the inline configuration.
+func (b *ApkoBuilder) summaryArchs(config string) []Architecture {
+	if b.buildArch != "" {
+		if arch, err := ParseArchitecture(b.buildArch); err == nil {
+			return []Architecture{arch}
+		}
+		return []Architecture{Architecture(b.buildArch)}
+	}
+
+	var doc struct {
+		Archs []string `yaml:"archs"`
+	}
+	if config == "" || yaml.Unmarshal([]byte(config), &doc) != nil {
+		return nil
+	}
+
+	set, err := ParseArchitectures(doc.Archs...)
+	if err != nil {
+		return nil
+	}
+
+	return set
+}
+
+// sbomDir returns the directory apko writes the SBOMs to: the SBOM path, or the directory of the
+// output tarball.
+func (b *ApkoBuilder) sbomDir() string {
+	if b.sbomPath != "" {
+		return b.sbomPath
+	}
+
+	return filepath.Dir(b.outputTarball)
+}
+
+// sbomPaths returns the paths of the SPDX SBOMs apko writes to 'dir' for 'archs'.
+func sbomPaths(dir string, archs []Architecture) []string {
+	paths := make([]string, 0, len(archs)+1)
+	if len(archs) > 1 {
+		paths = append(paths, filepath.Join(dir, "sbom-index.spdx.json"))
+	}
+
+	for _, arch := range archs {
+		paths = append(paths, filepath.Join(dir, "sbom-"+string(arch)+".spdx.json"))
+	}
+
+	return paths
+}
@@ -0,0 +1,81 @@
+package apkox
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+func TestBuildCommandWithSummary(t *testing.T) {
+	b := NewApkoBuilder().
+		WithConfigPreset(ConfigPresetWolfiBase).
+		WithOutputImage("example/app").
+		WithTag("v1").
+		WithOutputTarball("/mnt/out/image.tar").
+		WithCacheDir("/cache").
+		WithSBOM(true)
+
+	cmd, summary, err := b.BuildCommandWithSummary()
+	if err != nil {
+		t.Fatalf("BuildCommandWithSummary returned unexpected error: %v", err)
+	}
+
+	want := &BuildSummary{
+		Image:      "example/app",
+		ImageRef:   "example/app:v1",
+		Tags:       []string{"v1"},
+		Tarball:    "/mnt/out/image.tar",
+		ConfigFile: cmd[len(cmd)-3],
+		SBOMPaths: []string{
+			"/mnt/out/sbom-index.spdx.json",
+			"/mnt/out/sbom-x86_64.spdx.json",
+			"/mnt/out/sbom-aarch64.spdx.json",
+		},
+		Archs:    []Architecture{ArchX8664, ArchAarch64},
+		CacheDir: "/cache",
+	}
+
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("Summary = %+v, want %+v", summary, want)
+	}
+}
+
+func TestBuildCommandWithSummarySingleArch(t *testing.T) {
+	b := NewApkoBuilder().
+		WithConfigFile("apko.yaml").
+		WithOutputImage("example/app").
+		WithOutputTarball("/mnt/image.tar").
+		WithArchitecture("arm64").
+		WithSBOM(true).
+		WithSBOMPath("/mnt/sboms")
+
+	cmd, summary, err := b.BuildCommandWithSummary()
+	if err != nil {
+		t.Fatalf("BuildCommandWithSummary returned unexpected error: %v", err)
+	}
+
+	if summary.ImageRef != "example/app:latest" || !reflect.DeepEqual(summary.Archs, []Architecture{ArchAarch64}) {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+
+	if !reflect.DeepEqual(summary.SBOMPaths, []string{"/mnt/sboms/sbom-aarch64.spdx.json"}) {
+		t.Errorf("SBOMPaths = %v", summary.SBOMPaths)
+	}
+
+	want := []string{"apko", "build", "--arch", "arm64", "--sbom-path", "/mnt/sboms", "--vcs=false", "apko.yaml", "example/app:latest", "/mnt/image.tar"}
+	if !reflect.DeepEqual(cmd, want) {
+		t.Errorf("BuildCommand() = %v, want %v", cmd, want)
+	}
+
+	_, summary, err = NewApkoBuilder().WithConfigFile("apko.yaml").WithOutputImage("example/app").WithOutputTarball("/mnt/image.tar").BuildCommandWithSummary()
+	if err != nil || summary.SBOMPaths != nil || summary.Archs != nil {
+		t.Errorf("Expected no SBOMs nor archs, got %+v, %v", summary, err)
+	}
+
+	_, _, err = NewApkoBuilder().WithOutputImage("example/app").BuildCommandWithSummary()
+	if !errors.Is(err, errorsx.ErrMissingField) {
+		t.Errorf("Expected a missing field error, got %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package apkox
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// Clone returns a deep copy of the builder: options added to the copy don't change the builder,
+// and the other way round. Matrix generators clone a base builder per combination.
+func (b *ApkoBuilder) Clone() *ApkoBuilder {
+	c := *b
+
+	c.keyringPaths = slices.Clone(b.keyringPaths)
+	c.extraArgs = slices.Clone(b.extraArgs)
+	c.keyringAppendPlaintext = slices.Clone(b.keyringAppendPlaintext)
+	c.keyringAppendSecrets = slices.Clone(b.keyringAppendSecrets)
+	c.buildRepositoryAppend = slices.Clone(b.buildRepositoryAppend)
+	c.repositoryAppend = slices.Clone(b.repositoryAppend)
+	c.annotations = maps.Clone(b.annotations)
+	c.packageAppend = slices.Clone(b.packageAppend)
+	c.sbomFormats = slices.Clone(b.sbomFormats)
+	c.logPolicy = slices.Clone(b.logPolicy)
+	c.releaseRegistries = slices.Clone(b.releaseRegistries)
+	c.accounts.Groups = slices.Clone(b.accounts.Groups)
+	for i := range c.accounts.Groups {
+		c.accounts.Groups[i].Members = slices.Clone(c.accounts.Groups[i].Members)
+	}
+	c.accounts.Users = slices.Clone(b.accounts.Users)
+	c.environment = slices.Clone(b.environment)
+	c.paths = slices.Clone(b.paths)
+	c.certificates = slices.Clone(b.certificates)
+	c.packageRules = slices.Clone(b.packageRules)
+	for i := range c.packageRules {
+		c.packageRules[i].Archs = slices.Clone(c.packageRules[i].Archs)
+		c.packageRules[i].ExcludeArchs = slices.Clone(c.packageRules[i].ExcludeArchs)
+	}
+	c.warnings = slices.Clone(b.warnings)
+
+	return &c
+}
+
+// SyncApkoBuilder shares an ApkoBuilder across goroutines. ApkoBuilder is not safe for
+// concurrent use, as its options mutate it; SyncApkoBuilder serializes the changes (Update)
+// against the reads (BuildCommand, Validate, Mounts, Env, Clone), so a base builder can be shared
+// by parallel matrix generation, and passed as a builderx.Builder.
+//
+// Example usage:
+//
+//	base := apkox.NewSyncApkoBuilder(apkox.NewApkoBuilder().WithConfigFile("apko.yaml"))
+//
+//	for _, arch := range archs {
+//	    go func() {
+//	        cmd, err := base.Clone().WithBuildArch(arch).BuildCommand()
+//	        // ...
+//	    }()
+//	}
+type SyncApkoBuilder struct {
+	mu sync.RWMutex
+	b  *ApkoBuilder
+}
+
+// NewSyncApkoBuilder creates a SyncApkoBuilder sharing a copy of 'b', so later changes to 'b'
+// don't race with it.
+func NewSyncApkoBuilder(b *ApkoBuilder) *SyncApkoBuilder {
+	return &SyncApkoBuilder{b: b.Clone()}
+}
+
+// Update applies 'fn' to the shared builder, excluding the other calls.
+// It returns the SyncApkoBuilder instance.
+func (s *SyncApkoBuilder) Update(fn func(b *ApkoBuilder)) *SyncApkoBuilder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fn(s.b)
+
+	return s
+}
+
+// Clone returns a copy of the shared builder, which the caller may change freely.
+func (s *SyncApkoBuilder) Clone() *ApkoBuilder {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.b.Clone()
+}
+
+// BuildCommand generates the command of the shared builder, see ApkoBuilder.BuildCommand.
+func (s *SyncApkoBuilder) BuildCommand() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.b.BuildCommand()
+}
+
+// Warnings returns the warnings of the shared builder, see ApkoBuilder.Warnings.
+func (s *SyncApkoBuilder) Warnings() []errorsx.Warning {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.b.Warnings()
+}
+
+// Validate checks the configuration of the shared builder, see ApkoBuilder.Validate.
+func (s *SyncApkoBuilder) Validate() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.b.Validate()
+}
+
+// Env returns the environment variables of the shared builder, see ApkoBuilder.Env.
+func (s *SyncApkoBuilder) Env() []types.DaggerEnvVars {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.b.Env()
+}
+
+// Mounts returns the mounts of the shared builder, see ApkoBuilder.Mounts.
+func (s *SyncApkoBuilder) Mounts() []types.Mount {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.b.Mounts()
+}
+
+// Explain describes the options of a snapshot of the builder (see ApkoBuilder.Explain).
+func (s *SyncApkoBuilder) Explain() explainx.Explanation {
+	return s.Clone().Explain()
+}
@@ -0,0 +1,153 @@
+package apkox
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/explainx"
+)
+
+func TestApkoBuilderClone(t *testing.T) {
+	base := NewApkoBuilder().
+		WithConfigFile("config.yaml").
+		WithOutputImage("my-image").
+		WithOutputTarball("output.tar").
+		WithKeyring("/keys/a.rsa.pub").
+		WithAnnotations(map[string]string{"team": "platform"}).
+		WithGroup("app", 1000, "app").
+		WithPackageRule(PackageRule{Package: "intel-microcode", Archs: []Architecture{ArchX8664}})
+
+	c := base.Clone()
+	c.WithKeyring("/keys/b.rsa.pub").WithBuildArch(ArchAarch64)
+	c.annotations["team"] = "other"
+	c.accounts.Groups[0].Members[0] = "other"
+	c.packageRules[0].Archs[0] = ArchAarch64
+
+	if !reflect.DeepEqual(base.keyringPaths, []string{"/keys/a.rsa.pub"}) {
+		t.Errorf("Clone() shares keyrings: %v", base.keyringPaths)
+	}
+	if base.buildArch != "" || base.annotations["team"] != "platform" {
+		t.Errorf("Clone() shares options: arch %q, annotations %v", base.buildArch, base.annotations)
+	}
+	if base.accounts.Groups[0].Members[0] != "app" {
+		t.Errorf("Clone() shares group members: %v", base.accounts.Groups[0].Members)
+	}
+	if base.packageRules[0].Archs[0] != ArchX8664 {
+		t.Errorf("Clone() shares package rule architectures: %v", base.packageRules[0].Archs)
+	}
+}
+
+func TestBuildCommandLeavesBuilderUnchanged(t *testing.T) {
+	b := NewApkoBuilder().
+		WithConfigFile("config.yaml").
+		WithOutputImage("my-image").
+		WithOutputTarball("output.tar")
+
+	if _, err := b.BuildCommand(); err != nil {
+		t.Fatalf("BuildCommand() unexpected error: %v", err)
+	}
+
+	if b.tag != "" {
+		t.Errorf("BuildCommand() set the tag to %q", b.tag)
+	}
+}
+
+// TestSyncApkoBuilderConcurrent shares a builder across goroutines; run it with -race.
+func TestSyncApkoBuilderConcurrent(t *testing.T) {
+	shared := NewSyncApkoBuilder(NewApkoBuilder().
+		WithConfigFile("config.yaml").
+		WithOutputImage("my-image").
+		WithOutputTarball("output.tar"))
+
+	archs := []Architecture{ArchX8664, ArchAarch64, ArchArmv7, ArchPpc64le, ArchS390x}
+
+	var wg sync.WaitGroup
+	for i, arch := range archs {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			cmd, err := shared.Clone().WithBuildArch(arch).BuildCommand()
+			if err != nil {
+				t.Errorf("BuildCommand() unexpected error: %v", err)
+				return
+			}
+			if !containsSequence(cmd, "--arch", string(arch)) {
+				t.Errorf("BuildCommand() = %v, want --arch %s", cmd, arch)
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			shared.Update(func(b *ApkoBuilder) {
+				b.WithRepositoryAppend("https://packages.example.com/" + string(rune('a'+i)))
+			})
+			if _, err := shared.BuildCommand(); err != nil {
+				t.Errorf("BuildCommand() unexpected error: %v", err)
+			}
+			_ = shared.Mounts()
+		}()
+	}
+	wg.Wait()
+
+	if got := len(shared.Clone().repositoryAppend); got != len(archs) {
+		t.Errorf("Update() applied %d changes, want %d", got, len(archs))
+	}
+}
+
+// TestApkoBuilderSharedBuildCommand generates commands of one builder from goroutines; calls
+// that don't change the builder are safe for concurrent use.
+func TestApkoBuilderSharedBuildCommand(t *testing.T) {
+	b := NewApkoBuilder().
+		WithConfigFile("config.yaml").
+		WithOutputImage("my-image").
+		WithOutputTarball("output.tar")
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := b.BuildCommand(); err != nil {
+				t.Errorf("BuildCommand() unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// containsSequence reports whether 'args' holds 'seq' in a row.
+func containsSequence(args []string, seq ...string) bool {
+	for i := 0; i+len(seq) <= len(args); i++ {
+		if reflect.DeepEqual(args[i:i+len(seq)], seq) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestApkoBuilderExplain(t *testing.T) {
+	b := NewApkoBuilder().WithConfigFile("apko.yaml").WithOutputImage("app").WithOutputTarball("/out/app.tar").
+		WithTag("v1")
+
+	e := NewSyncApkoBuilder(b).Explain()
+	if len(e.Set()) != 4 {
+		t.Errorf("Explain() set options = %v, want 4 options", e.Set())
+	}
+
+	o, ok := e.Option("configFile")
+	if !ok || o.Source != explainx.SourceSet || !reflect.DeepEqual(o.Flags, []string{"apko.yaml"}) {
+		t.Errorf("Explain() configFile = %+v, want set with flag apko.yaml", o)
+	}
+
+	if len(e.Commands) != 2 || e.Commands[0].Name != "BuildCommand" || e.Commands[0].Error != "" ||
+		e.Commands[1].Name != "PublishCommand" {
+		t.Errorf("Explain() commands = %+v, want BuildCommand and PublishCommand", e.Commands)
+	}
+}
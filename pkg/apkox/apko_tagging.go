@@ -0,0 +1,185 @@
+package apkox
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/gitmetax"
+	"github.com/Excoriate/daggerx/pkg/versionx"
+)
+
+// TagStrategy computes the image tag for a stage of the delivery process.
+type TagStrategy string
+
+const (
+	// TagStrategyDev tags development builds "dev-<short sha>", with a "-dirty" suffix for
+	// uncommitted changes.
+	TagStrategyDev TagStrategy = "dev"
+	// TagStrategySHA tags builds "sha-<short sha>". The working tree must be clean.
+	TagStrategySHA TagStrategy = "sha"
+	// TagStrategyRelease tags builds with the semantic version of the git tag. The working tree
+	// must be clean.
+	TagStrategyRelease TagStrategy = "release"
+)
+
+// invalidTagCharsRegex matches the characters not allowed in an OCI tag.
+var invalidTagCharsRegex = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// WithTagStrategy sets the tag of the output image as computed by 'strategy' from the git
+// metadata. To tag a release with an explicit version instead of the checked out tag, set the
+// Tag of the metadata. Errors computing the tag are reported by BuildCommand.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithTagStrategy(strategy TagStrategy, metadata gitmetax.Metadata) *ApkoBuilder {
+	b.tag, b.tagErr = computeTag(strategy, metadata)
+	return b
+}
+
+// WithReleaseRegistries sets the registries (e.g. "registry.example.com" or
+// "registry.example.com/releases") for which BuildCommand refuses to produce "latest" tags.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithReleaseRegistries(registries ...string) *ApkoBuilder {
+	b.releaseRegistries = append(b.releaseRegistries, registries...)
+	return b
+}
+
+// computeTag computes the tag of a strategy.
+func computeTag(strategy TagStrategy, metadata gitmetax.Metadata) (string, error) {
+	switch strategy {
+	case TagStrategyDev:
+		if metadata.ShortSHA == "" {
+			return "", errorsx.MissingField(builderName, "tag", "tag strategy %s requires a commit sha", strategy)
+		}
+		tag := "dev-" + metadata.ShortSHA
+		if metadata.Dirty {
+			tag += "-dirty"
+		}
+		return tag, nil
+	case TagStrategySHA:
+		if metadata.ShortSHA == "" {
+			return "", errorsx.MissingField(builderName, "tag", "tag strategy %s requires a commit sha", strategy)
+		}
+		if metadata.Dirty {
+			return "", errorsx.InvalidValue(builderName, "tag", metadata.ShortSHA,
+				"tag strategy %s requires a clean working tree", strategy)
+		}
+		return "sha-" + metadata.ShortSHA, nil
+	case TagStrategyRelease:
+		if metadata.Tag == "" {
+			return "", errorsx.MissingField(builderName, "tag", "tag strategy %s requires a git tag or an explicit version", strategy)
+		}
+		if _, err := versionx.Parse(metadata.Tag); err != nil {
+			return "", errorsx.InvalidValue(builderName, "tag", metadata.Tag,
+				"tag strategy %s requires a semantic version: %w", strategy, err)
+		}
+		if metadata.Dirty {
+			return "", errorsx.InvalidValue(builderName, "tag", metadata.Tag,
+				"tag strategy %s requires a clean working tree", strategy)
+		}
+		// Build metadata ("+build.1") is not allowed in OCI tags.
+		return invalidTagCharsRegex.ReplaceAllString(metadata.Tag, "_"), nil
+	default:
+		return "", errorsx.Unsupported(builderName, "tagStrategy", strategy, "unsupported tag strategy: %s", strategy)
+	}
+}
+
+// effectiveTag returns the tag of the output image: the tag set, or "latest".
+func (b *ApkoBuilder) effectiveTag() string {
+	if b.tag == "" {
+		return "latest"
+	}
+
+	return b.tag
+}
+
+// validateReleaseTag rejects the "latest" tag pushed to a release registry.
+func (b *ApkoBuilder) validateReleaseTag(tag string) error {
+	if tag != "latest" {
+		return nil
+	}
+
+	for _, registry := range b.releaseRegistries {
+		registry = strings.TrimSuffix(registry, "/")
+		if registry != "" && strings.HasPrefix(b.outputImage, registry+"/") {
+			return errorsx.ConflictingOptions(builderName, []string{"tag", "releaseRegistries"},
+				"refusing to push the latest tag of %s to release registry %s", b.outputImage, registry)
+		}
+	}
+
+	return nil
+}
+
+// DefaultArchTagTemplate is the default template of the per-architecture tags: the image "1.2.3"
+// built for x86_64 is tagged "1.2.3-amd64".
+const DefaultArchTagTemplate = "{{tag}}-{{arch}}"
+
+// maxTagLength is the maximum length of an OCI tag.
+const maxTagLength = 128
+
+// ociArchNames maps the architectures to the names used in per-architecture tags, as registries
+// and docker display them.
+var ociArchNames = map[Architecture]string{
+	ArchX8664:   "amd64",
+	ArchAarch64: "arm64",
+	ArchArmv7:   "armv7",
+	ArchPpc64le: "ppc64le",
+	ArchS390x:   "s390x",
+}
+
+// WithArchTagSuffix tags the image built for the build architecture with the tag rendered by
+// 'template', keeping the tag itself for the manifest list assembled from the per-architecture
+// images. The template holds the "{{tag}}" and "{{arch}}" placeholders, where "{{arch}}" is the
+// OCI name of the architecture (amd64, arm64, armv7, ppc64le or s390x). An empty template is
+// DefaultArchTagTemplate. BuildCommand requires a build architecture, as set by ForArchitectures.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithArchTagSuffix(template string) *ApkoBuilder {
+	if template == "" {
+		template = DefaultArchTagTemplate
+	}
+
+	b.archTagTemplate = template
+	return b
+}
+
+// ArchTag renders the per-architecture tag of 'tag' for 'arch' with 'template' (see
+// WithArchTagSuffix). The architecture may be given by any name ParseArchitecture accepts.
+//
+// Returns:
+//   - The per-architecture tag.
+//   - An error if the architecture is unknown, the template has no "{{arch}}" placeholder, or the
+//     rendered tag is not a valid OCI tag.
+func ArchTag(template, tag, arch string) (string, error) {
+	if !strings.Contains(template, "{{arch}}") {
+		return "", errorsx.InvalidValue(builderName, "archTagTemplate", template,
+			"arch tag template must hold the {{arch}} placeholder")
+	}
+
+	parsed, err := ParseArchitecture(arch)
+	if err != nil {
+		return "", err
+	}
+
+	rendered := strings.NewReplacer("{{tag}}", tag, "{{arch}}", ociArchNames[parsed]).Replace(template)
+	if invalidTagCharsRegex.MatchString(rendered) || len(rendered) > maxTagLength ||
+		strings.HasPrefix(rendered, ".") || strings.HasPrefix(rendered, "-") {
+		return "", errorsx.InvalidValue(builderName, "archTagTemplate", rendered,
+			"arch tag template renders an invalid tag: %s", rendered)
+	}
+
+	return rendered, nil
+}
+
+// archTag returns the tag of the image built for the build architecture: 'tag' rendered with the
+// arch tag template, or 'tag' itself when no template is set.
+func (b *ApkoBuilder) archTag(tag string) (string, error) {
+	if b.archTagTemplate == "" {
+		return tag, nil
+	}
+
+	if b.buildArch == "" {
+		return "", errorsx.MissingField(builderName, "buildArch",
+			"per-architecture tags require a build architecture")
+	}
+
+	return ArchTag(b.archTagTemplate, tag, b.buildArch)
+}
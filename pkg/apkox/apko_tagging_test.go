@@ -0,0 +1,201 @@
+package apkox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/gitmetax"
+)
+
+// releaseImage is the image of the tagging tests, which clear the tag of newTestBuilder so the
+// tag strategy applies.
+const releaseImage = "registry.example.com/releases/base"
+
+func TestApkoBuilderWithTagStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy TagStrategy
+		metadata gitmetax.Metadata
+		wantRef  string
+		wantErr  error
+	}{
+		{
+			name:     "Dev",
+			strategy: TagStrategyDev,
+			metadata: gitmetax.Metadata{ShortSHA: "abc1234"},
+			wantRef:  releaseImage + ":dev-abc1234",
+		},
+		{
+			name:     "Dev dirty",
+			strategy: TagStrategyDev,
+			metadata: gitmetax.Metadata{ShortSHA: "abc1234", Dirty: true},
+			wantRef:  releaseImage + ":dev-abc1234-dirty",
+		},
+		{
+			name:     "SHA",
+			strategy: TagStrategySHA,
+			metadata: gitmetax.Metadata{ShortSHA: "abc1234"},
+			wantRef:  releaseImage + ":sha-abc1234",
+		},
+		{
+			name:     "SHA dirty",
+			strategy: TagStrategySHA,
+			metadata: gitmetax.Metadata{ShortSHA: "abc1234", Dirty: true},
+			wantErr:  errorsx.ErrInvalidValue,
+		},
+		{
+			name:     "Release",
+			strategy: TagStrategyRelease,
+			metadata: gitmetax.Metadata{ShortSHA: "abc1234", Tag: "v1.2.3+build.7"},
+			wantRef:  releaseImage + ":v1.2.3_build.7",
+		},
+		{
+			name:     "Release without tag",
+			strategy: TagStrategyRelease,
+			metadata: gitmetax.Metadata{ShortSHA: "abc1234"},
+			wantErr:  errorsx.ErrMissingField,
+		},
+		{
+			name:     "Release with non semver tag",
+			strategy: TagStrategyRelease,
+			metadata: gitmetax.Metadata{Tag: "nightly"},
+			wantErr:  errorsx.ErrInvalidValue,
+		},
+		{
+			name:     "Unknown strategy",
+			strategy: "weekly",
+			wantErr:  errorsx.ErrUnsupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := newTestBuilder().WithOutputImage(releaseImage).WithTag("").
+				WithTagStrategy(tt.strategy, tt.metadata).
+				BuildCommand()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("BuildCommand() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("BuildCommand() returned unexpected error: %v", err)
+			}
+
+			if got := cmd[len(cmd)-2]; got != tt.wantRef {
+				t.Errorf("BuildCommand() image reference = %s, want %s", got, tt.wantRef)
+			}
+		})
+	}
+}
+
+func TestApkoBuilderWithTagOverridesStrategy(t *testing.T) {
+	cmd, err := newTestBuilder().WithOutputImage(releaseImage).
+		WithTagStrategy(TagStrategyRelease, gitmetax.Metadata{}).
+		WithTag("v2.0.0").
+		BuildCommand()
+	if err != nil {
+		t.Fatalf("BuildCommand() returned unexpected error: %v", err)
+	}
+
+	if got := cmd[len(cmd)-2]; got != releaseImage+":v2.0.0" {
+		t.Errorf("BuildCommand() image reference = %s", got)
+	}
+}
+
+func TestApkoBuilderWithReleaseRegistries(t *testing.T) {
+	_, err := newTestBuilder().WithOutputImage(releaseImage).WithTag("").
+		WithReleaseRegistries("registry.example.com/releases/").
+		BuildCommand()
+	if !errors.Is(err, errorsx.ErrConflictingOptions) {
+		t.Errorf("BuildCommand() error = %v, want %v", err, errorsx.ErrConflictingOptions)
+	}
+
+	_, err = newTestBuilder().WithOutputImage(releaseImage).WithTag("").
+		WithReleaseRegistries("registry.example.com/dev").
+		BuildCommand()
+	if err != nil {
+		t.Errorf("BuildCommand() returned unexpected error for a non-release registry: %v", err)
+	}
+
+	_, err = newTestBuilder().WithOutputImage(releaseImage).
+		WithReleaseRegistries("registry.example.com").
+		WithTag("v1.0.0").
+		BuildCommand()
+	if err != nil {
+		t.Errorf("BuildCommand() returned unexpected error for a versioned tag: %v", err)
+	}
+}
+
+func TestApkoBuilderWithArchTagSuffix(t *testing.T) {
+	builders := newTestBuilder().WithOutputImage(releaseImage).
+		WithTag("1.2.3").
+		WithArchTagSuffix("").
+		ForArchitectures(ArchX8664, ArchAarch64)
+
+	want := []string{releaseImage + ":1.2.3-amd64", releaseImage + ":1.2.3-arm64"}
+	for i, b := range builders {
+		cmd, err := b.BuildCommand()
+		if err != nil {
+			t.Fatalf("BuildCommand() returned unexpected error: %v", err)
+		}
+
+		if got := cmd[len(cmd)-2]; got != want[i] {
+			t.Errorf("BuildCommand() image reference = %s, want %s", got, want[i])
+		}
+
+		_, summary, err := b.BuildCommandWithSummary()
+		if err != nil {
+			t.Fatalf("BuildCommandWithSummary() returned unexpected error: %v", err)
+		}
+
+		if summary.ImageRef != want[i] {
+			t.Errorf("BuildCommandWithSummary() image reference = %s, want %s", summary.ImageRef, want[i])
+		}
+	}
+
+	_, err := newTestBuilder().WithOutputImage(releaseImage).WithTag("1.2.3").WithArchTagSuffix("").BuildCommand()
+	if !errors.Is(err, errorsx.ErrMissingField) {
+		t.Errorf("BuildCommand() without build arch error = %v, want %v", err, errorsx.ErrMissingField)
+	}
+}
+
+func TestArchTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		tag      string
+		arch     string
+		want     string
+		wantErr  error
+	}{
+		{name: "Default", template: DefaultArchTagTemplate, tag: "1.2.3", arch: "x86_64", want: "1.2.3-amd64"},
+		{name: "Alias", template: DefaultArchTagTemplate, tag: "1.2.3", arch: "linux/arm64", want: "1.2.3-arm64"},
+		{name: "Prefix", template: "{{arch}}-{{tag}}", tag: "dev-abc1234", arch: "armv7", want: "armv7-dev-abc1234"},
+		{name: "No arch placeholder", template: "{{tag}}-build", tag: "1.2.3", arch: "x86_64", wantErr: errorsx.ErrInvalidValue},
+		{name: "Invalid tag", template: "{{tag}}/{{arch}}", tag: "1.2.3", arch: "x86_64", wantErr: errorsx.ErrInvalidValue},
+		{name: "Unknown arch", template: DefaultArchTagTemplate, tag: "1.2.3", arch: "mips", wantErr: errorsx.ErrUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ArchTag(tt.template, tt.tag, tt.arch)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ArchTag() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ArchTag() returned unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ArchTag() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,15 @@
 package apkox
 
 import (
+	"bytes"
+	"errors"
+	"log/slog"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
 )
 
 func TestApkoBuilder(t *testing.T) {
@@ -90,6 +97,13 @@ func TestApkoBuilder(t *testing.T) {
 		}
 	})
 
+	t.Run("WithBuildRepositoryAppend", func(t *testing.T) {
+		builder := NewApkoBuilder().WithBuildRepositoryAppend("/mnt/packages")
+		if !reflect.DeepEqual(builder.buildRepositoryAppend, []string{"/mnt/packages"}) {
+			t.Errorf("Build repositories not set correctly, got %v", builder.buildRepositoryAppend)
+		}
+	})
+
 	t.Run("WithDebug", func(t *testing.T) {
 		builder := NewApkoBuilder().WithDebug()
 		if !builder.debug {
@@ -259,6 +273,25 @@ func TestApkoBuilder(t *testing.T) {
 		}
 	})
 
+	t.Run("BuildCommand_TypedErrors", func(t *testing.T) {
+		_, err := NewApkoBuilder().WithConfigFile("config.yaml").WithOutputImage("my-image").BuildCommand()
+		if !errors.Is(err, errorsx.ErrMissingField) {
+			t.Fatalf("Expected an ErrMissingField error, got: %v", err)
+		}
+
+		if field, _ := errorsx.FieldOf(err); field != "outputTarball" {
+			t.Errorf("Expected the outputTarball field, got %q", field)
+		}
+
+		if _, err := GetKeyringInfoForPreset("debian"); !errors.Is(err, errorsx.ErrUnsupported) {
+			t.Errorf("Expected an ErrUnsupported error, got: %v", err)
+		}
+
+		if err := IsKeyringFormatValid([]string{"http://example.com/key.pub"}); !errors.Is(err, errorsx.ErrInvalidValue) {
+			t.Errorf("Expected an ErrInvalidValue error, got: %v", err)
+		}
+	})
+
 	t.Run("WithTag", func(t *testing.T) {
 		builder := NewApkoBuilder().WithTag("v1.0.0")
 		if builder.tag != "v1.0.0" {
@@ -516,3 +549,260 @@ func TestApkoBuilderCommand(t *testing.T) {
 		t.Errorf("Command mismatch.\nExpected: %v\nGot: %v", expected, cmd)
 	}
 }
+
+func TestApkoBuilderCommandSortsAnnotations(t *testing.T) {
+	orders := [][]string{{"team", "env", "app"}, {"app", "team", "env"}, {"env", "app", "team"}}
+	values := map[string]string{"app": "api", "env": "prod", "team": "platform"}
+	want := []string{
+		"apko", "build",
+		"--sbom=false",
+		"--vcs=false",
+		"--annotations", "app:api",
+		"--annotations", "env:prod",
+		"--annotations", "team:platform",
+		"config.yaml",
+		"my-image:v1.0.0",
+		"output.tar",
+	}
+
+	for _, order := range orders {
+		annotations := map[string]string{}
+		for _, k := range order {
+			annotations[k] = values[k]
+		}
+
+		for range 20 {
+			got, err := NewApkoBuilder().
+				WithConfigFile("config.yaml").
+				WithOutputImage("my-image").
+				WithTag("v1.0.0").
+				WithOutputTarball("output.tar").
+				WithAnnotations(annotations).
+				BuildCommand()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("Command mismatch for order %v.\nExpected: %v\nGot: %v", order, want, got)
+			}
+		}
+	}
+}
+
+func TestApkoBuilderCommandInvalidAnnotations(t *testing.T) {
+	for _, annotations := range []map[string]string{{"": "x"}, {"a:b": "x"}, {"a": "x,y"}} {
+		_, err := NewApkoBuilder().
+			WithConfigFile("config.yaml").
+			WithOutputImage("my-image").
+			WithOutputTarball("output.tar").
+			WithAnnotations(annotations).
+			BuildCommand()
+		if !errors.Is(err, errorsx.ErrInvalidValue) {
+			t.Errorf("BuildCommand() with annotations %v error = %v, want %v", annotations, err, errorsx.ErrInvalidValue)
+		}
+	}
+}
+
+func TestApkoBuilderCommandOfflineAndLogLevel(t *testing.T) {
+	cmd, err := NewApkoBuilder().
+		WithConfigFile("config.yaml").
+		WithOutputImage("my-image").
+		WithTag("v1.0.0").
+		WithOutputTarball("output.tar").
+		WithOffline().
+		WithLogLevel("debug").
+		BuildCommand()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"apko", "build",
+		"--sbom=false",
+		"--vcs=false",
+		"--offline",
+		"--log-level", "debug",
+		"config.yaml",
+		"my-image:v1.0.0",
+		"output.tar",
+	}
+
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("Command mismatch.\nExpected: %v\nGot: %v", expected, cmd)
+	}
+}
+
+func TestApkoBuilderCommandOptions(t *testing.T) {
+	cmd, err := NewApkoBuilder().
+		WithConfigFile("config.yaml").
+		WithOutputImage("my-image").
+		WithTag("v1.0.0").
+		WithOutputTarball("output.tar").
+		WithKeyRingWolfi().
+		WithWolfiKeyring().
+		WithAlpineKeyring().
+		WithSBOM(true).
+		WithSBOMFormats("spdx", "cyclonedx").
+		WithVCS(true).
+		WithLogLevel("warn").
+		WithLogPolicy("builtin:stderr", "/mnt/apko.log").
+		WithBuildDate("2024-01-02T15:04:05Z").
+		WithLockfile("/mnt/apko.lock.json").
+		BuildCommand()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"apko", "build",
+		"--keyring-append", ApkoWolfiSigninRsaKeyPath,
+		"--keyring-append", ApkoAlpineSigninRsaKeyPath,
+		"--sbom-formats", "spdx",
+		"--sbom-formats", "cyclonedx",
+		"--log-level", "warn",
+		"--log-policy", "builtin:stderr",
+		"--log-policy", "/mnt/apko.log",
+		"--build-date", "2024-01-02T15:04:05Z",
+		"--lockfile", "/mnt/apko.lock.json",
+		"config.yaml",
+		"my-image:v1.0.0",
+		"output.tar",
+	}
+
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("Command mismatch.\nExpected: %v\nGot: %v", expected, cmd)
+	}
+}
+
+func TestApkoBuilderCommandDeprecatedOptions(t *testing.T) {
+	base := func() *ApkoBuilder {
+		return NewApkoBuilder().
+			WithConfigFile("config.yaml").
+			WithOutputImage("my-image").
+			WithTag("v1.0.0").
+			WithOutputTarball("output.tar")
+	}
+
+	cmd, err := base().WithDebug().WithNoNetwork().WithTimestamp("2024-01-02T15:04:05Z").BuildCommand()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"apko", "build",
+		"--sbom=false",
+		"--vcs=false",
+		"--offline",
+		"--log-level", "debug",
+		"--build-date", "2024-01-02T15:04:05Z",
+		"config.yaml",
+		"my-image:v1.0.0",
+		"output.tar",
+	}
+
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("Command mismatch.\nExpected: %v\nGot: %v", expected, cmd)
+	}
+
+	conflicts := map[string]*ApkoBuilder{
+		"debug and log level":      base().WithDebug().WithLogLevel("info"),
+		"timestamp and build date": base().WithTimestamp("2024-01-02T15:04:05Z").WithBuildDate("2025-01-01T00:00:00Z"),
+	}
+
+	for name, b := range conflicts {
+		if _, err := b.BuildCommand(); !errors.Is(err, errorsx.ErrConflictingOptions) {
+			t.Errorf("%s: BuildCommand() error = %v, want %v", name, err, errorsx.ErrConflictingOptions)
+		}
+	}
+}
+
+func TestApkoBuilderWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	_, err := NewApkoBuilder().
+		WithLogger(logger).
+		WithConfigFile("config.yaml").
+		WithOutputImage("my-image").
+		WithOutputTarball("/mnt/image.tar").
+		WithKeyringAppendPlaintext("PLAINTEXT-KEY").
+		WithExtraArg("PLAINTEXT-KEY").
+		BuildCommand()
+	if err != nil {
+		t.Fatalf("BuildCommand returned unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"no tag set, defaulting to latest", "generated command", "builder=apko", "operation=\"build command\""} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in log output:\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(out, "PLAINTEXT-KEY") {
+		t.Errorf("Expected plaintext keys to be redacted:\n%s", out)
+	}
+}
+
+// newTestBuilder returns a valid builder shared by the tests: config.yaml built as my-image:v1
+// into output.tar, with the SBOM and VCS defaults of apko, so commands only hold the flags of the
+// options under test.
+func newTestBuilder() *ApkoBuilder {
+	return NewApkoBuilder().
+		WithConfigFile("config.yaml").
+		WithOutputImage("my-image").
+		WithTag("v1").
+		WithOutputTarball("output.tar").
+		WithSBOM(true).
+		WithVCS(true)
+}
+
+// newBenchmarkBuilder returns a builder of a large configuration, with many keyrings and
+// repositories, as generated by matrix builds.
+func newBenchmarkBuilder() *ApkoBuilder {
+	b := NewApkoBuilder().
+		WithConfigFile("config.yaml").
+		WithOutputImage("registry.example.com/team/image").
+		WithTag("v1.2.3").
+		WithOutputTarball("/mnt/out/image.tar").
+		WithCacheDir("/mnt/var/cache/apko").
+		WithBuildArch(ArchAarch64).
+		WithSBOM(true).
+		WithSBOMPath("/mnt/out/sbom").
+		WithLogLevel("debug")
+
+	for i := range 16 {
+		b.WithKeyring("/etc/apk/keys/key-" + strconv.Itoa(i) + ".rsa.pub")
+		b.WithRepositoryAppend("https://packages.example.com/repo-" + strconv.Itoa(i))
+	}
+
+	return b
+}
+
+func BenchmarkBuildCommand(b *testing.B) {
+	builder := newBenchmarkBuilder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := builder.BuildCommand(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBuildCommandExtraArgs(b *testing.B) {
+	builder := newBenchmarkBuilder().
+		WithExtraArg("--arch=x86_64").
+		WithExtraArg("--debug").
+		WithExtraArgsPolicy(ExtraArgsPreferTyped)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := builder.BuildCommand(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
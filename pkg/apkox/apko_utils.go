@@ -1,8 +1,9 @@
 package apkox
 
 import (
-	"fmt"
 	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
 )
 
 // KeyringPars holds the parameters for a keyring.
@@ -19,11 +20,11 @@ type KeyringPars struct {
 // It returns the path as a string and an error if the format is invalid.
 func (k *KeyringPars) GetPathFromKeyring(keyring string) (string, error) {
 	if keyring == "" {
-		return "", fmt.Errorf("keyring string is empty")
+		return "", errorsx.MissingField(builderName, "keyring", "keyring string is empty")
 	}
 	parts := strings.SplitN(keyring, "=", 2)
 	if len(parts) != 2 {
-		return "", fmt.Errorf("invalid keyring format: %s", keyring)
+		return "", errorsx.InvalidValue(builderName, "keyring", keyring, "invalid keyring format: %s", keyring)
 	}
 	return parts[0], nil
 }
@@ -33,15 +34,15 @@ func (k *KeyringPars) GetPathFromKeyring(keyring string) (string, error) {
 // It returns the URL as a string and an error if the format is invalid.
 func (k *KeyringPars) GetURLFromKeyring(keyring string, enforceHTTPS bool) (string, error) {
 	if keyring == "" {
-		return "", fmt.Errorf("keyring string is empty")
+		return "", errorsx.MissingField(builderName, "keyring", "keyring string is empty")
 	}
 	parts := strings.SplitN(keyring, "=", 2)
 	if len(parts) != 2 {
-		return "", fmt.Errorf("invalid keyring format: %s", keyring)
+		return "", errorsx.InvalidValue(builderName, "keyring", keyring, "invalid keyring format: %s", keyring)
 	}
 	url := parts[1]
 	if enforceHTTPS && !strings.HasPrefix(url, "https://") {
-		return "", fmt.Errorf("URL does not use HTTPS: %s", url)
+		return "", errorsx.InvalidValue(builderName, "keyring", url, "URL does not use HTTPS: %s", url)
 	}
 	return url, nil
 }
@@ -55,7 +56,7 @@ func ParseKeyring(keyring string) (KeyringSkeleton, error) {
 	case 2:
 		path := parts[0]
 		if !strings.HasPrefix(path, "/etc/apk/keys/") {
-			return KeyringSkeleton{}, fmt.Errorf("invalid keyring path: %s", path)
+			return KeyringSkeleton{}, errorsx.InvalidValue(builderName, "keyring", path, "invalid keyring path: %s", path)
 		}
 		return KeyringSkeleton{
 			Path: path,
@@ -66,7 +67,7 @@ func ParseKeyring(keyring string) (KeyringSkeleton, error) {
 			URL: parts[0],
 		}, nil
 	default:
-		return KeyringSkeleton{}, fmt.Errorf("invalid keyring format: %s", keyring)
+		return KeyringSkeleton{}, errorsx.InvalidValue(builderName, "keyring", keyring, "invalid keyring format: %s", keyring)
 	}
 }
 
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
 )
 
 // IsKeyringFormatValid validates the format of the provided keyrings.
@@ -33,17 +35,17 @@ func IsKeyringFormatValid(keyrings []string, enforceHTTPS ...bool) error {
 		case 2:
 			path := parts[0]
 			if !strings.HasPrefix(path, "/etc/apk/keys/") {
-				return fmt.Errorf("invalid keyring path: %s", path)
+				return errorsx.InvalidValue(builderName, "keyring", path, "invalid keyring path: %s", path)
 			}
 			urlStr = parts[1]
 		case 1:
 			urlStr = parts[0]
 		default:
-			return fmt.Errorf("invalid keyring format: %s", keyring)
+			return errorsx.InvalidValue(builderName, "keyring", keyring, "invalid keyring format: %s", keyring)
 		}
 
 		if err := validateURL(urlStr, httpsRequired); err != nil {
-			return fmt.Errorf("invalid keyring URL: %s, error: %w", urlStr, err)
+			return errorsx.InvalidValue(builderName, "keyring", urlStr, "invalid keyring URL: %s, error: %w", urlStr, err)
 		}
 	}
 	return nil
@@ -0,0 +1,50 @@
+package apkox
+
+import (
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+const (
+	// CacheVolumeKey is the cache volume key of the APKO cache.
+	CacheVolumeKey = "apko-cache"
+	// WarmupImage is the image reference of the throwaway build warming the cache.
+	WarmupImage = "apko-cache-warmup"
+	// WarmupTarball is the output tarball of the throwaway build warming the cache.
+	WarmupTarball = "/tmp/apko-cache-warmup.tar"
+)
+
+// WarmCacheCommand generates an `apko build` invocation populating the cache directory with the
+// keyrings, indexes and packages of the configuration, to run once ahead of parallel (matrix)
+// builds sharing the cache volume (see CacheMount). The build has the configuration, keyrings,
+// repositories and architecture of the builder, writes a throwaway tarball (WarmupTarball), and
+// skips the SBOM, VCS detection, layering and release tags, which don't affect the cache.
+//
+// Returns:
+//   - The command.
+//   - An error if the cache directory or the config file is missing, or BuildCommand fails.
+func (b *ApkoBuilder) WarmCacheCommand() ([]string, error) {
+	if b.cacheDir == "" {
+		return nil, errorsx.MissingField(builderName, "cacheDir",
+			"cache directory is required to warm the cache")
+	}
+
+	w := NewApkoBuilderFromSpec(b.Spec()).WithLogger(b.logger)
+	w.outputImage, w.tag, w.outputTarball = WarmupImage, "warmup", WarmupTarball
+	w.sbom, w.sbomPath, w.vcs = false, "", false
+	w.offline = false
+	w.layeringStrategy, w.layeringBudget = "", 0
+	w.releaseRegistries = nil
+
+	return w.BuildCommand()
+}
+
+// CacheMount returns the cache volume mount of the cache directory, keyed CacheVolumeKey, shared
+// by the warm-up and the builds it prepares. It returns nil without a cache directory.
+func (b *ApkoBuilder) CacheMount() []types.Mount {
+	if b.cacheDir == "" {
+		return nil
+	}
+
+	return []types.Mount{{Kind: types.MountKindCache, Source: CacheVolumeKey, Target: b.cacheDir}}
+}
@@ -0,0 +1,117 @@
+package apkox
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+func TestWarmCacheCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *ApkoBuilder
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "replaces the outputs and keeps the package sources",
+			builder: NewApkoBuilder().
+				WithConfigFile("config.yaml").
+				WithOutputImage("my-image").
+				WithTag("v1").
+				WithOutputTarball("output.tar").
+				WithCacheDir("/cache").
+				WithKeyring("/keys/a.rsa.pub").
+				WithBuildArch(ArchAarch64).
+				WithRepositoryAppend("https://packages.wolfi.dev/os"),
+			want: []string{
+				"apko", "build", "--cache-dir", "/cache", "--keyring-append", "/keys/a.rsa.pub",
+				"--arch", "aarch64", "--repository-append", "https://packages.wolfi.dev/os",
+				"--sbom=false", "--vcs=false",
+				"config.yaml", "apko-cache-warmup:warmup", WarmupTarball,
+			},
+		},
+		{
+			name: "drops the sbom, layering and offline flags",
+			builder: NewApkoBuilder().
+				WithConfigFile("config.yaml").
+				WithOutputImage("my-image").
+				WithOutputTarball("output.tar").
+				WithCacheDir("/cache").
+				WithSBOM(true).
+				WithSBOMPath("/sbom").
+				WithVCS(true).
+				WithOffline().
+				WithLayering(LayerStrategyOrigin, 10),
+			want: []string{
+				"apko", "build", "--cache-dir", "/cache", "--sbom=false", "--vcs=false",
+				"config.yaml", "apko-cache-warmup:warmup", WarmupTarball,
+			},
+		},
+		{
+			name: "requires a cache directory",
+			builder: NewApkoBuilder().
+				WithConfigFile("config.yaml").
+				WithOutputImage("my-image").
+				WithOutputTarball("output.tar"),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "requires a config file",
+			builder: NewApkoBuilder().WithCacheDir("/cache"),
+			wantErr: errorsx.ErrMissingField,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.builder.WarmCacheCommand()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("WarmCacheCommand() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("WarmCacheCommand() unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WarmCacheCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWarmCacheCommandKeepsBuilder(t *testing.T) {
+	b := NewApkoBuilder().
+		WithConfigFile("config.yaml").
+		WithOutputImage("my-image").
+		WithTag("v1").
+		WithOutputTarball("output.tar").
+		WithCacheDir("/cache")
+
+	if _, err := b.WarmCacheCommand(); err != nil {
+		t.Fatalf("WarmCacheCommand() unexpected error: %v", err)
+	}
+
+	if b.outputImage != "my-image" || b.tag != "v1" || b.outputTarball != "output.tar" {
+		t.Errorf("WarmCacheCommand() changed the builder outputs: %s:%s %s",
+			b.outputImage, b.tag, b.outputTarball)
+	}
+}
+
+func TestCacheMount(t *testing.T) {
+	if got := NewApkoBuilder().CacheMount(); got != nil {
+		t.Errorf("CacheMount() = %v, want nil", got)
+	}
+
+	want := []types.Mount{{Kind: types.MountKindCache, Source: CacheVolumeKey, Target: "/cache"}}
+	if got := NewApkoBuilder().WithCacheDir("/cache").CacheMount(); !reflect.DeepEqual(got, want) {
+		t.Errorf("CacheMount() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,43 @@
+package apkox
+
+import (
+	"slices"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// WithStrict sets whether BuildCommand fails with the warnings of the configuration (see
+// Warnings) instead of working around them.
+// It returns the updated ApkoBuilder instance.
+func (b *ApkoBuilder) WithStrict(strict bool) *ApkoBuilder {
+	b.strict = strict
+	return b
+}
+
+// Warnings returns the configuration mistakes the builder works around: the options it could
+// not apply (e.g. unknown presets), and the defaults and duplicates of the generated command
+// (e.g. the latest tag, extra arguments duplicating typed options), and the uses of deprecated
+// options with their replacements (see errorsx.Deprecations). The command is generated
+// without logging; when it fails, only the warnings of the options are returned, as the
+// error is reported by BuildCommand.
+func (b *ApkoBuilder) Warnings() []errorsx.Warning {
+	quiet := *b
+	quiet.logger = nil
+	quiet.strict = false
+
+	_, warnings, err := quiet.generate(false)
+	if err != nil {
+		return slices.Clone(b.warnings)
+	}
+
+	return warnings
+}
+
+// deprecate records the use of the deprecated option 'option' in the warnings of the options,
+// once, suggesting the option 'replacement'.
+func (b *ApkoBuilder) deprecate(option, replacement string) {
+	w := errorsx.NewDeprecation(builderName, option, replacement)
+	if !slices.Contains(b.warnings, w) {
+		b.warnings = append(b.warnings, w)
+	}
+}
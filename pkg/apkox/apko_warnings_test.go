@@ -0,0 +1,147 @@
+package apkox
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// warningFields returns the fields of the warnings, in order.
+func warningFields(warnings []errorsx.Warning) []string {
+	var fields []string
+	for _, w := range warnings {
+		fields = append(fields, w.Field)
+	}
+
+	return fields
+}
+
+func TestWarnings(t *testing.T) {
+	tests := []struct {
+		name string
+		b    *ApkoBuilder
+		want []string
+	}{
+		{
+			name: "none",
+			b: NewApkoBuilder().WithConfigFile("apko.yaml").WithOutputImage("example/app").
+				WithTag("v1").WithOutputTarball("/mnt/image.tar"),
+		},
+		{
+			name: "latest tag",
+			b:    NewApkoBuilder().WithConfigFile("apko.yaml").WithOutputImage("example/app").WithOutputTarball("/mnt/image.tar"),
+			want: []string{"tag"},
+		},
+		{
+			name: "duplicate extra argument",
+			b: NewApkoBuilder().WithConfigFile("apko.yaml").WithOutputImage("example/app").
+				WithTag("v1").WithOutputTarball("/mnt/image.tar").
+				WithBuildArch(ArchX8664).WithExtraArg("--arch=aarch64"),
+			want: []string{"buildArch"},
+		},
+		{
+			name: "unknown preset",
+			b: NewApkoBuilder().WithConfigPreset("debian").WithConfigFile("apko.yaml").
+				WithOutputImage("example/app").WithTag("v1").WithOutputTarball("/mnt/image.tar"),
+			want: []string{"configPreset"},
+		},
+		{
+			name: "deprecated architecture",
+			b: NewApkoBuilder().WithConfigFile("apko.yaml").WithOutputImage("example/app").
+				WithTag("v1").WithOutputTarball("/mnt/image.tar").WithArchitecture("aarch64"),
+			want: []string{"WithArchitecture"},
+		},
+		{
+			name: "deprecated build context repository",
+			b: NewApkoBuilder().WithConfigFile("apko.yaml").WithOutputImage("example/app").
+				WithTag("v1").WithOutputTarball("/mnt/image.tar").WithBuildContext("/src"),
+			want: []string{"WithBuildContext"},
+		},
+		{
+			name: "deprecated debug, network and timestamp options",
+			b: NewApkoBuilder().WithConfigFile("apko.yaml").WithOutputImage("example/app").
+				WithTag("v1").WithOutputTarball("/mnt/image.tar").
+				WithDebug().WithNoNetwork().WithTimestamp("2024-01-02T15:04:05Z"),
+			want: []string{"WithDebug", "WithNoNetwork", "WithTimestamp"},
+		},
+		{
+			name: "ignored SBOM formats",
+			b: NewApkoBuilder().WithConfigFile("apko.yaml").WithOutputImage("example/app").
+				WithTag("v1").WithOutputTarball("/mnt/image.tar").WithSBOMFormats("spdx"),
+			want: []string{"sbomFormats"},
+		},
+		{
+			name: "invalid configuration",
+			b:    NewApkoBuilder().WithConfigPreset("debian"),
+			want: []string{"configPreset"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := warningFields(tt.b.Warnings()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Warnings() fields = %v, want %v", got, tt.want)
+			}
+
+			if len(tt.want) == 0 {
+				return
+			}
+
+			_, err := tt.b.Clone().WithStrict(true).BuildCommand()
+			if tt.name != "invalid configuration" && !errors.Is(err, errorsx.ErrWarning) {
+				t.Errorf("strict BuildCommand() error = %v, want ErrWarning", err)
+			}
+		})
+	}
+}
+
+func TestWithStrict(t *testing.T) {
+	b := NewApkoBuilder().
+		WithConfigFile("apko.yaml").
+		WithOutputImage("example/app").
+		WithOutputTarball("/mnt/image.tar")
+
+	if _, err := b.BuildCommand(); err != nil {
+		t.Fatalf("BuildCommand returned unexpected error: %v", err)
+	}
+
+	_, err := b.WithStrict(true).BuildCommand()
+	if field, _ := errorsx.FieldOf(err); !errors.Is(err, errorsx.ErrWarning) || field != "tag" {
+		t.Errorf("strict BuildCommand() error = %v, want ErrWarning on tag", err)
+	}
+
+	if !NewApkoBuilderFromSpec(b.Spec()).strict {
+		t.Error("Spec() must preserve strict mode")
+	}
+
+	if _, err := b.WithTag("v1").BuildCommand(); err != nil {
+		t.Errorf("strict BuildCommand() without warnings returned unexpected error: %v", err)
+	}
+}
+
+func TestDeprecations(t *testing.T) {
+	b := NewApkoBuilder().WithConfigFile("apko.yaml").WithOutputImage("example/app").WithTag("v1").
+		WithOutputTarball("/mnt/image.tar").WithArchitecture("x86_64").WithArchitecture("aarch64")
+
+	got := errorsx.Deprecations(b.Warnings())
+	want := []errorsx.Warning{errorsx.NewDeprecation(builderName, "WithArchitecture", "WithBuildArch")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Deprecations() = %v, want %v", got, want)
+	}
+
+	cmd, err := b.BuildCommand()
+	if err != nil || !reflect.DeepEqual(cmd[2:4], []string{"--arch", "aarch64"}) {
+		t.Errorf("BuildCommand() = %v, %v, want the deprecated option applied", cmd, err)
+	}
+
+	parsed, err := ParseApkoCommand(cmd)
+	if err != nil {
+		t.Fatalf("ParseApkoCommand() error = %v", err)
+	}
+
+	if got := errorsx.Deprecations(parsed.Warnings()); got != nil {
+		t.Errorf("Deprecations() of the parsed command = %v, want nil", got)
+	}
+}
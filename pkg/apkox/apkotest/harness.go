@@ -0,0 +1,302 @@
+// Package apkotest runs the commands generated by apkox against a real apko binary, so flag drift
+// against new apko releases is caught by tests rather than by pipelines.
+//
+// The integration tests are opt-in: they are built with the "integration" build tag, and skipped
+// unless IntegrationEnv is set to 1. The apko binary is BinaryEnv, or apko on the PATH, or the
+// release VersionEnv (DefaultVersion by default) installed with installerx:
+//
+//	DAGGERX_APKO_INTEGRATION=1 go test -tags integration ./pkg/apkox/apkotest/
+//
+// Example usage:
+//
+//	func TestBuild(t *testing.T) {
+//	    h := apkotest.New(t, nil)
+//
+//	    b := h.Builder(apkotest.MinimalConfig).WithSBOM(true)
+//	    h.Run(context.Background(), b)
+//
+//	    apkotest.AssertTarball(t, h.Tarball())
+//	    apkotest.AssertSBOM(t, h.SBOMDir(), "wolfi-baselayout")
+//	}
+package apkotest
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/execx"
+	"github.com/Excoriate/daggerx/pkg/installerx"
+	"github.com/Excoriate/daggerx/pkg/syftx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+const (
+	// IntegrationEnv enables the integration tests when set to 1.
+	IntegrationEnv = "DAGGERX_APKO_INTEGRATION"
+	// BinaryEnv is the path of the apko binary the tests run.
+	BinaryEnv = "DAGGERX_APKO_BIN"
+	// VersionEnv is the apko release installed when no binary is found.
+	VersionEnv = "DAGGERX_APKO_VERSION"
+	// DefaultVersion is the apko release installed when VersionEnv is not set.
+	DefaultVersion = "0.19.6"
+	// Image is the name of the images built by the harness.
+	Image = "apkotest.local/image"
+)
+
+// MinimalConfig is a tiny apko configuration, installing the Wolfi base layout only.
+const MinimalConfig = `contents:
+  keyring:
+    - https://packages.wolfi.dev/os/wolfi-signing.rsa.pub
+  repositories:
+    - https://packages.wolfi.dev/os
+  packages:
+    - wolfi-baselayout
+`
+
+// RequireIntegration skips the test unless IntegrationEnv is set to 1.
+func RequireIntegration(t testing.TB) {
+	t.Helper()
+
+	if os.Getenv(IntegrationEnv) != "1" {
+		t.Skipf("set %s=1 to run the apko integration tests", IntegrationEnv)
+	}
+}
+
+// Harness builds images with a real apko binary in a temporary directory.
+type Harness struct {
+	t        testing.TB
+	executor execx.Executor
+	dir      string
+	binary   string
+}
+
+// New creates a Harness running the commands with 'executor', or on the local host when nil. It
+// skips the test unless the integration tests are enabled, see RequireIntegration.
+func New(t testing.TB, executor execx.Executor) *Harness {
+	t.Helper()
+	RequireIntegration(t)
+
+	dir := t.TempDir()
+	if executor == nil {
+		executor = execx.NewLocalExecutor().WithDir(dir)
+	}
+
+	return &Harness{t: t, executor: executor, dir: dir}
+}
+
+// Dir returns the temporary directory holding the configurations and outputs.
+func (h *Harness) Dir() string {
+	return h.dir
+}
+
+// Tarball returns the path of the image tarball of the builders of the harness.
+func (h *Harness) Tarball() string {
+	return filepath.Join(h.dir, "image.tar")
+}
+
+// SBOMDir returns the directory of the SBOMs of the builders of the harness.
+func (h *Harness) SBOMDir() string {
+	return filepath.Join(h.dir, "sbom")
+}
+
+// Binary returns the apko binary run by the harness: BinaryEnv, or apko on the PATH, or the
+// release installed with installerx on first use.
+func (h *Harness) Binary(ctx context.Context) string {
+	h.t.Helper()
+
+	if h.binary != "" {
+		return h.binary
+	}
+
+	if bin := os.Getenv(BinaryEnv); bin != "" {
+		h.binary = bin
+		return bin
+	}
+
+	if bin, err := exec.LookPath("apko"); err == nil {
+		h.binary = bin
+		return bin
+	}
+
+	version := os.Getenv(VersionEnv)
+	if version == "" {
+		version = DefaultVersion
+	}
+
+	binDir := filepath.Join(h.dir, "bin")
+	if err := os.MkdirAll(binDir, 0o755); err != nil {
+		h.t.Fatalf("failed to create %s: %v", binDir, err)
+	}
+
+	script, err := installerx.GetApkoInstallCommand(installerx.ApkoInstallParams{
+		Version:    version,
+		InstallDir: binDir,
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+	})
+	if err != nil {
+		h.t.Fatalf("failed to generate the apko install command: %v", err)
+	}
+
+	h.run(ctx, types.DaggerCMD{"sh", "-c", script})
+	h.binary = filepath.Join(binDir, "apko")
+
+	return h.binary
+}
+
+// WriteConfig writes the apko configuration 'content' to 'name' in the harness directory, and
+// returns its path.
+func (h *Harness) WriteConfig(name, content string) string {
+	h.t.Helper()
+
+	path := filepath.Join(h.dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		h.t.Fatalf("failed to write %s: %v", path, err)
+	}
+
+	return path
+}
+
+// Builder returns a builder of the configuration 'config' for the host architecture, writing
+// the image to Tarball and its SBOMs, when enabled, to SBOMDir.
+func (h *Harness) Builder(config string) *apkox.ApkoBuilder {
+	h.t.Helper()
+
+	arch, err := apkox.ParseArchitecture(runtime.GOARCH)
+	if err != nil {
+		h.t.Fatalf("unsupported host architecture: %v", err)
+	}
+
+	return apkox.NewApkoBuilder().
+		WithConfigFile(h.WriteConfig("apko.yaml", config)).
+		WithOutputImage(Image).
+		WithTag("test").
+		WithOutputTarball(h.Tarball()).
+		WithCacheDir(filepath.Join(h.dir, "cache")).
+		WithBuildArch(arch).
+		WithSBOMPath(h.SBOMDir())
+}
+
+// Run generates the build command of 'b' and runs it with the apko binary of the harness. The
+// test fails if the command cannot be generated or fails.
+func (h *Harness) Run(ctx context.Context, b *apkox.ApkoBuilder) *execx.Result {
+	h.t.Helper()
+
+	cmd, err := b.BuildCommand()
+	if err != nil {
+		h.t.Fatalf("failed to generate the apko command: %v", err)
+	}
+
+	if b.Spec().SBOMPath != "" {
+		if err := os.MkdirAll(b.Spec().SBOMPath, 0o755); err != nil {
+			h.t.Fatalf("failed to create the SBOM directory: %v", err)
+		}
+	}
+
+	return h.RunCommand(ctx, cmd)
+}
+
+// RunCommand runs the generated apko command 'cmd' (e.g. of ApkoBuilder.WarmCacheCommand) with
+// the apko binary of the harness. The test fails if the command fails.
+func (h *Harness) RunCommand(ctx context.Context, cmd []string) *execx.Result {
+	h.t.Helper()
+
+	cmd = slices.Clone(cmd)
+	if len(cmd) > 0 && cmd[0] == "apko" {
+		cmd[0] = h.Binary(ctx)
+	}
+
+	return h.run(ctx, cmd)
+}
+
+// run runs 'cmd' with the executor of the harness, and fails the test unless it succeeds.
+func (h *Harness) run(ctx context.Context, cmd types.DaggerCMD) *execx.Result {
+	h.t.Helper()
+
+	res, err := h.executor.Run(ctx, cmd, nil, nil)
+	if err != nil {
+		h.t.Fatalf("failed to run %s: %v", cmd[0], err)
+	}
+
+	if !res.Succeeded() {
+		h.t.Fatalf("%s exited with code %d:\n%s", strings.Join(cmd, " "), res.ExitCode, res.Stderr)
+	}
+
+	return res
+}
+
+// AssertTarball checks that 'path' is an image tarball, holding a manifest.json or an index.json
+// entry, and returns the names of its entries.
+func AssertTarball(t testing.TB, path string) []string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open the image tarball: %v", err)
+	}
+	defer f.Close()
+
+	var names []string
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid image tarball %s: %v", path, err)
+		}
+		names = append(names, hdr.Name)
+	}
+
+	if !slices.Contains(names, "manifest.json") && !slices.Contains(names, "index.json") {
+		t.Errorf("image tarball %s holds no manifest.json nor index.json: %v", path, names)
+	}
+
+	return names
+}
+
+// AssertSBOM checks that 'dir' holds SPDX SBOMs, and that they list 'packages'. It returns the
+// paths of the SBOMs.
+func AssertSBOM(t testing.TB, dir string, packages ...string) []string {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.spdx.json"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no SPDX SBOM in %s", dir)
+	}
+
+	listed := map[string]bool{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", path, err)
+		}
+
+		pkgs, err := syftx.ParseSBOMPackages(data)
+		if err != nil {
+			t.Fatalf("invalid SBOM %s: %v", path, err)
+		}
+		for _, p := range pkgs {
+			listed[p.Name] = true
+		}
+	}
+
+	for _, p := range packages {
+		if !listed[p] {
+			t.Errorf("the SBOMs in %s don't list the package %s", dir, p)
+		}
+	}
+
+	return paths
+}
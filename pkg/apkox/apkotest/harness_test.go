@@ -0,0 +1,97 @@
+package apkotest
+
+import (
+	"archive/tar"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/execx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingExecutor records the commands it runs, and succeeds.
+type recordingExecutor struct {
+	cmds []types.DaggerCMD
+}
+
+func (e *recordingExecutor) Run(
+	_ context.Context,
+	cmd types.DaggerCMD,
+	_ []types.DaggerEnvVars,
+	_ []types.Mount,
+) (*execx.Result, error) {
+	e.cmds = append(e.cmds, cmd)
+	return &execx.Result{}, nil
+}
+
+func TestRequireIntegration(t *testing.T) {
+	t.Setenv(IntegrationEnv, "")
+
+	reached := false
+	t.Run("skipped", func(t *testing.T) {
+		RequireIntegration(t)
+		reached = true
+	})
+
+	assert.False(t, reached, "RequireIntegration() must skip the test")
+}
+
+func TestHarnessRun(t *testing.T) {
+	t.Setenv(BinaryEnv, "/opt/apko/bin/apko")
+
+	executor := &recordingExecutor{}
+	h := &Harness{t: t, executor: executor, dir: t.TempDir()}
+
+	h.Run(context.Background(), h.Builder(MinimalConfig).WithSBOM(true))
+
+	require.Len(t, executor.cmds, 1)
+	cmd := executor.cmds[0]
+	assert.Equal(t, "/opt/apko/bin/apko", cmd[0])
+	assert.Contains(t, cmd, "--sbom-path")
+	assert.Equal(t, []string{filepath.Join(h.Dir(), "apko.yaml"), Image + ":test", h.Tarball()}, []string(cmd[len(cmd)-3:]))
+
+	config, err := os.ReadFile(filepath.Join(h.Dir(), "apko.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, MinimalConfig, string(config))
+	assert.DirExists(t, h.SBOMDir())
+
+	h.RunCommand(context.Background(), []string{"ls", "-l"})
+	assert.Equal(t, types.DaggerCMD{"ls", "-l"}, executor.cmds[1])
+}
+
+func TestAssertTarball(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.tar")
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	tw := tar.NewWriter(f)
+	for _, name := range []string{"manifest.json", "sha256:abc"} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: 2}))
+		_, err := tw.Write([]byte("{}"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, []string{"manifest.json", "sha256:abc"}, AssertTarball(t, path))
+}
+
+func TestAssertSBOM(t *testing.T) {
+	dir := t.TempDir()
+	sbom := `{
+  "spdxVersion": "SPDX-2.3",
+  "documentDescribes": ["SPDXRef-image"],
+  "packages": [
+    {"SPDXID": "SPDXRef-image", "name": "image"},
+    {"SPDXID": "SPDXRef-pkg", "name": "wolfi-baselayout", "versionInfo": "20230201-r15"}
+  ]
+}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sbom-x86_64.spdx.json"), []byte(sbom), 0o644))
+
+	paths := AssertSBOM(t, dir, "wolfi-baselayout")
+	assert.Equal(t, []string{filepath.Join(dir, "sbom-x86_64.spdx.json")}, paths)
+}
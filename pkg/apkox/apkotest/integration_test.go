@@ -0,0 +1,57 @@
+//go:build integration
+
+package apkotest
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/execx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationBuild(t *testing.T) {
+	ctx := context.Background()
+	h := New(t, nil)
+
+	h.Run(ctx, h.Builder(MinimalConfig).WithSBOM(true).WithLogLevel("warn"))
+
+	AssertTarball(t, h.Tarball())
+	AssertSBOM(t, h.SBOMDir(), "wolfi-baselayout")
+}
+
+func TestIntegrationBuildWithoutSBOM(t *testing.T) {
+	ctx := context.Background()
+	h := New(t, nil)
+
+	h.Run(ctx, h.Builder(MinimalConfig).WithVCS(false))
+
+	AssertTarball(t, h.Tarball())
+	_, err := os.Stat(h.SBOMDir())
+	assert.True(t, os.IsNotExist(err), "no SBOM directory expected")
+}
+
+func TestIntegrationWarmCache(t *testing.T) {
+	ctx := context.Background()
+	h := New(t, nil)
+
+	cmd, err := h.Builder(MinimalConfig).WarmCacheCommand()
+	require.NoError(t, err)
+	h.RunCommand(ctx, cmd)
+
+	entries, err := os.ReadDir(h.Dir() + "/cache")
+	require.NoError(t, err)
+	assert.NotEmpty(t, entries, "the warm-up must populate the cache directory")
+}
+
+func TestIntegrationVersion(t *testing.T) {
+	ctx := context.Background()
+	h := New(t, nil)
+
+	res, err := execx.NewLocalExecutor().Run(ctx, types.DaggerCMD{h.Binary(ctx), "version"}, nil, nil)
+	require.NoError(t, err)
+	assert.True(t, res.Succeeded(), res.Stderr)
+}
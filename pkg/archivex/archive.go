@@ -0,0 +1,343 @@
+// Package archivex provides builders for the commands creating and extracting tar, tar.gz and
+// zip archives inside containers, for installers downloading release archives and for steps
+// exporting artifacts.
+//
+// Archives are reproducible by default: entries are sorted by name, modification times are set
+// to DefaultMTime, and owners are recorded as numeric IDs 0 (tar) or left out (zip), so the same
+// files always produce the same archive digest. Reproducible tar archives require GNU tar, as
+// BusyBox tar has no --sort option.
+//
+// Example usage:
+//
+//	cmd, err := archivex.NewArchiveBuilder().
+//	    WithSourceDir("/mnt/dist").
+//	    WithOutput("/mnt/out/app.tar.gz").
+//	    WithExclude("*.map").
+//	    CreateCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	extract, err := archivex.NewExtractBuilder().
+//	    WithArchive("/tmp/tool.tar.gz").
+//	    WithDestination("/usr/local/bin").
+//	    WithStripComponents(1).
+//	    ExtractCommand()
+package archivex
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/builderx"
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/filesystemx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/timex"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the archive builders in errorsx errors.
+const builderName = "archive"
+
+// Format is an archive format.
+type Format string
+
+const (
+	// FormatTar is an uncompressed tar archive.
+	FormatTar Format = "tar"
+	// FormatTarGz is a gzip-compressed tar archive.
+	FormatTarGz Format = "tar.gz"
+	// FormatZip is a zip archive.
+	FormatZip Format = "zip"
+)
+
+// DefaultMTime is the modification time of the entries of reproducible archives. It is the
+// earliest time zip archives can record.
+var DefaultMTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// DetectFormat returns the format of the archive 'name' from its extension (".tar", ".tar.gz",
+// ".tgz" or ".zip", case-insensitive).
+//
+// Returns:
+//   - The format.
+//   - An error if the extension is not a supported archive extension.
+func DetectFormat(name string) (Format, error) {
+	lower := strings.ToLower(name)
+
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return FormatTarGz, nil
+	case strings.HasSuffix(lower, ".tar"):
+		return FormatTar, nil
+	case strings.HasSuffix(lower, ".zip"):
+		return FormatZip, nil
+	default:
+		return "", errorsx.Unsupported(builderName, "format", name, "unsupported archive extension: %q", name)
+	}
+}
+
+// resolveFormat returns 'format', or the format detected from 'name' when it is empty.
+func resolveFormat(format Format, name string) (Format, error) {
+	switch format {
+	case "":
+		return DetectFormat(name)
+	case FormatTar, FormatTarGz, FormatZip:
+		return format, nil
+	default:
+		return "", errorsx.Unsupported(builderName, "format", format, "unsupported archive format: %q", format)
+	}
+}
+
+// ArchiveBuilder generates the command creating an archive.
+type ArchiveBuilder struct {
+	// format is the archive format. It is detected from the output name when empty.
+	format Format
+
+	// sourceDir is the directory the entries are relative to.
+	sourceDir string
+
+	// paths are the entries to archive, relative to sourceDir.
+	paths []string
+
+	// output is the path of the archive to write.
+	output string
+
+	// excludes are the patterns of the entries left out of the archive.
+	excludes []string
+
+	// reproducible makes the archive content depend on the archived files only.
+	reproducible bool
+
+	// mtime is the modification time of the entries of reproducible archives.
+	mtime time.Time
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+var _ builderx.Builder = (*ArchiveBuilder)(nil)
+
+// NewArchiveBuilder creates an ArchiveBuilder creating reproducible archives.
+func NewArchiveBuilder() *ArchiveBuilder {
+	return &ArchiveBuilder{reproducible: true, mtime: DefaultMTime}
+}
+
+// WithFormat sets the archive format. It is detected from the output name by default.
+func (b *ArchiveBuilder) WithFormat(format Format) *ArchiveBuilder {
+	b.format = format
+	return b
+}
+
+// WithSourceDir sets the directory the entries are relative to. It defaults to the working
+// directory of the command.
+func (b *ArchiveBuilder) WithSourceDir(dir string) *ArchiveBuilder {
+	b.sourceDir = dir
+	return b
+}
+
+// WithPath adds entries to archive, relative to the source directory. The whole source directory
+// is archived when no entry is added.
+func (b *ArchiveBuilder) WithPath(paths ...string) *ArchiveBuilder {
+	b.paths = append(b.paths, paths...)
+	return b
+}
+
+// WithOutput sets the path of the archive to write.
+func (b *ArchiveBuilder) WithOutput(output string) *ArchiveBuilder {
+	b.output = output
+	return b
+}
+
+// WithExclude adds patterns of entries left out of the archive (e.g. "*.map").
+func (b *ArchiveBuilder) WithExclude(patterns ...string) *ArchiveBuilder {
+	b.excludes = append(b.excludes, patterns...)
+	return b
+}
+
+// WithReproducible sets whether the archive is reproducible. It is by default.
+func (b *ArchiveBuilder) WithReproducible(reproducible bool) *ArchiveBuilder {
+	b.reproducible = reproducible
+	return b
+}
+
+// WithMTime sets the modification time of the entries of reproducible archives. It defaults to
+// DefaultMTime.
+func (b *ArchiveBuilder) WithMTime(mtime time.Time) *ArchiveBuilder {
+	b.mtime = mtime
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *ArchiveBuilder) WithLogger(logger *slog.Logger) *ArchiveBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks that the builder configuration is complete and consistent, and returns the
+// archive format.
+func (b *ArchiveBuilder) validate() (Format, error) {
+	if b.output == "" {
+		return "", errorsx.MissingField(builderName, "output", "output archive is required")
+	}
+
+	format, err := resolveFormat(b.format, b.output)
+	if err != nil {
+		return "", err
+	}
+
+	for _, p := range b.paths {
+		if err := filesystemx.ValidateRelative(p); err != nil {
+			return "", err
+		}
+	}
+
+	if format == FormatZip {
+		if b.sourceDir != "" && !path.IsAbs(b.output) {
+			return "", errorsx.InvalidValue(builderName, "output", b.output,
+				"zip archives of a source directory need an absolute output path, got %q", b.output)
+		}
+
+		if b.reproducible && b.mtime.Before(DefaultMTime) {
+			return "", errorsx.InvalidValue(builderName, "mtime", b.mtime,
+				"zip archives cannot record times before %s", DefaultMTime.Format(time.RFC3339))
+		}
+	}
+
+	return format, nil
+}
+
+// CreateCommand generates the command creating the archive. Tar archives are written by tar;
+// zip archives by a `sh -c` script running zip from the source directory, which, when the archive
+// is reproducible, sets the modification time of the source files to the archive time.
+//
+// Returns:
+//   - The command.
+//   - An error if the output is missing, the format is unsupported, or an entry is not relative.
+func (b *ArchiveBuilder) CreateCommand() ([]string, error) {
+	ctx := context.Background()
+
+	format, err := b.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := b.paths
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	var cmd []string
+	if format == FormatZip {
+		cmd = b.zipCommand(paths)
+	} else {
+		cmd = b.tarCommand(format, paths)
+	}
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// tarCommand generates the tar command creating the archive.
+func (b *ArchiveBuilder) tarCommand(format Format, paths []string) []string {
+	cmd := []string{"tar"}
+
+	if b.reproducible {
+		cmd = append(cmd,
+			"--sort=name",
+			"--mtime=@"+timex.Epoch(b.mtime),
+			"--owner=0",
+			"--group=0",
+			"--numeric-owner",
+		)
+	}
+
+	if format == FormatTarGz {
+		if b.reproducible {
+			// gzip -n leaves the name and time of the input out of the gzip header.
+			cmd = append(cmd, "--use-compress-program=gzip -n")
+		} else {
+			cmd = append(cmd, "-z")
+		}
+	}
+
+	for _, p := range b.excludes {
+		cmd = append(cmd, "--exclude="+p)
+	}
+
+	cmd = append(cmd, "-cf", b.output)
+
+	if b.sourceDir != "" {
+		cmd = append(cmd, "-C", b.sourceDir)
+	}
+
+	return append(cmd, paths...)
+}
+
+// zipCommand generates the script creating the zip archive.
+func (b *ArchiveBuilder) zipCommand(paths []string) []string {
+	entries := cmdx.ShellJoin(paths)
+
+	var steps []string
+	if b.sourceDir != "" {
+		steps = append(steps, "cd "+cmdx.ShellQuote(b.sourceDir))
+	}
+
+	var zip string
+	if b.reproducible {
+		// zip stores entries in the order it reads names, and has no option to fix their times:
+		// the names are sorted and the times set beforehand. -X leaves owners out.
+		steps = append(steps, "find "+entries+" -exec touch -h -d @"+
+			timex.Epoch(b.mtime)+" {} +")
+		zip = "find " + entries + " -print | LC_ALL=C sort | zip -q -X -D -@ " + cmdx.ShellQuote(b.output)
+	} else {
+		zip = "zip -q -r " + cmdx.ShellQuote(b.output) + " " + entries
+	}
+
+	if len(b.excludes) > 0 {
+		zip += " -x"
+		for _, p := range b.excludes {
+			zip += " " + cmdx.ShellQuote(p)
+		}
+	}
+
+	return []string{"sh", "-c", strings.Join(append(steps, zip), " && ")}
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *ArchiveBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// BuildCommand generates the command creating the archive, as CreateCommand does.
+func (b *ArchiveBuilder) BuildCommand() ([]string, error) {
+	return b.CreateCommand()
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *ArchiveBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *ArchiveBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the archive creation command.
+func (b *ArchiveBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("archive create", b, NewArchiveBuilder(),
+		explainx.Generate("CreateCommand", b.CreateCommand))
+}
@@ -0,0 +1,113 @@
+package archivex
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		want Format
+	}{
+		{"app.tar.gz", FormatTarGz},
+		{"APP.TGZ", FormatTarGz},
+		{"rootfs.tar", FormatTar},
+		{"terraform_1.9.0_linux_amd64.zip", FormatZip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectFormat(tt.name)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := DetectFormat("app.tar.xz")
+	assert.True(t, errors.Is(err, errorsx.ErrUnsupported))
+}
+
+func TestCreateCommandTar(t *testing.T) {
+	cmd, err := NewArchiveBuilder().
+		WithSourceDir("/mnt/dist").
+		WithOutput("/mnt/out/app.tar.gz").
+		WithExclude("*.map").
+		CreateCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"tar", "--sort=name", "--mtime=@315532800", "--owner=0", "--group=0", "--numeric-owner",
+		"--use-compress-program=gzip -n", "--exclude=*.map",
+		"-cf", "/mnt/out/app.tar.gz", "-C", "/mnt/dist", ".",
+	}, cmd)
+
+	cmd, err = NewArchiveBuilder().
+		WithFormat(FormatTar).
+		WithOutput("rootfs").
+		WithPath("bin", "etc").
+		WithMTime(time.Unix(1700000000, 0)).
+		CreateCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"tar", "--sort=name", "--mtime=@1700000000", "--owner=0", "--group=0", "--numeric-owner",
+		"-cf", "rootfs", "bin", "etc",
+	}, cmd)
+
+	cmd, err = NewArchiveBuilder().WithOutput("app.tgz").WithReproducible(false).CreateCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tar", "-z", "-cf", "app.tgz", "."}, cmd)
+}
+
+func TestCreateCommandZip(t *testing.T) {
+	cmd, err := NewArchiveBuilder().
+		WithSourceDir("/mnt/dist").
+		WithPath("bin", "README.md").
+		WithOutput("/mnt/out/app.zip").
+		WithExclude("*.map").
+		CreateCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sh", "-c",
+		"cd /mnt/dist && find bin README.md -exec touch -h -d @315532800 {} + && " +
+			"find bin README.md -print | LC_ALL=C sort | zip -q -X -D -@ /mnt/out/app.zip -x '*.map'",
+	}, cmd)
+
+	cmd, err = NewArchiveBuilder().WithOutput("my app.zip").WithReproducible(false).CreateCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sh", "-c", "zip -q -r 'my app.zip' ."}, cmd)
+}
+
+func TestCreateCommandValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *ArchiveBuilder
+		kind    error
+	}{
+		{"Missing output", NewArchiveBuilder(), errorsx.ErrMissingField},
+		{"Unknown extension", NewArchiveBuilder().WithOutput("app.rar"), errorsx.ErrUnsupported},
+		{"Unknown format", NewArchiveBuilder().WithOutput("app").WithFormat("7z"), errorsx.ErrUnsupported},
+		{"Escaping path", NewArchiveBuilder().WithOutput("app.tar").WithPath("../etc"), errorsx.ErrInvalidValue},
+		{"Absolute path", NewArchiveBuilder().WithOutput("app.tar").WithPath("/etc"), errorsx.ErrInvalidValue},
+		{
+			"Relative zip output",
+			NewArchiveBuilder().WithSourceDir("/mnt/dist").WithOutput("app.zip"),
+			errorsx.ErrInvalidValue,
+		},
+		{
+			"Zip time before 1980",
+			NewArchiveBuilder().WithOutput("app.zip").WithMTime(time.Unix(0, 0)),
+			errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.CreateCommand()
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package archivex
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"github.com/Excoriate/daggerx/pkg/builderx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// ExtractBuilder generates the command extracting an archive.
+type ExtractBuilder struct {
+	// format is the archive format. It is detected from the archive name when empty.
+	format Format
+
+	// archive is the path of the archive to extract.
+	archive string
+
+	// destination is the directory the entries are extracted to.
+	destination string
+
+	// stripComponents is the number of leading path components removed from the entries.
+	stripComponents int
+
+	// members are the entries to extract. Every entry is extracted when empty.
+	members []string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+var _ builderx.Builder = (*ExtractBuilder)(nil)
+
+// NewExtractBuilder creates an empty ExtractBuilder.
+func NewExtractBuilder() *ExtractBuilder {
+	return &ExtractBuilder{}
+}
+
+// WithFormat sets the archive format. It is detected from the archive name by default.
+func (b *ExtractBuilder) WithFormat(format Format) *ExtractBuilder {
+	b.format = format
+	return b
+}
+
+// WithArchive sets the path of the archive to extract.
+func (b *ExtractBuilder) WithArchive(archive string) *ExtractBuilder {
+	b.archive = archive
+	return b
+}
+
+// WithDestination sets the directory the entries are extracted to. It defaults to the working
+// directory of the command. Tar requires the directory to exist.
+func (b *ExtractBuilder) WithDestination(dir string) *ExtractBuilder {
+	b.destination = dir
+	return b
+}
+
+// WithStripComponents removes the 'n' leading path components of the entries, e.g. the
+// "tool-1.2.3/" directory of release archives. Only tar archives support it.
+func (b *ExtractBuilder) WithStripComponents(n int) *ExtractBuilder {
+	b.stripComponents = n
+	return b
+}
+
+// WithMember adds entries to extract. Every entry is extracted when none is added.
+func (b *ExtractBuilder) WithMember(members ...string) *ExtractBuilder {
+	b.members = append(b.members, members...)
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *ExtractBuilder) WithLogger(logger *slog.Logger) *ExtractBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks that the builder configuration is complete and consistent, and returns the
+// archive format.
+func (b *ExtractBuilder) validate() (Format, error) {
+	if b.archive == "" {
+		return "", errorsx.MissingField(builderName, "archive", "archive to extract is required")
+	}
+
+	format, err := resolveFormat(b.format, b.archive)
+	if err != nil {
+		return "", err
+	}
+
+	if b.stripComponents < 0 {
+		return "", errorsx.InvalidValue(builderName, "stripComponents", b.stripComponents,
+			"strip components must not be negative, got %d", b.stripComponents)
+	}
+
+	if format == FormatZip && b.stripComponents > 0 {
+		return "", errorsx.Unsupported(builderName, "stripComponents", b.stripComponents,
+			"zip archives cannot strip path components")
+	}
+
+	return format, nil
+}
+
+// ExtractCommand generates the command extracting the archive. Tar doesn't restore the owners
+// recorded in the archive, so extracted files belong to the user running the command.
+//
+// Returns:
+//   - The tar or unzip command.
+//   - An error if the archive is missing, the format is unsupported, or the options are
+//     inconsistent.
+func (b *ExtractBuilder) ExtractCommand() ([]string, error) {
+	ctx := context.Background()
+
+	format, err := b.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	var cmd []string
+
+	switch format {
+	case FormatZip:
+		cmd = append([]string{"unzip", "-q", "-o", b.archive}, b.members...)
+		if b.destination != "" {
+			cmd = append(cmd, "-d", b.destination)
+		}
+	default:
+		flags := "-xf"
+		if format == FormatTarGz {
+			flags = "-xzf"
+		}
+
+		cmd = []string{"tar", flags, b.archive, "--no-same-owner"}
+		if b.destination != "" {
+			cmd = append(cmd, "-C", b.destination)
+		}
+
+		if b.stripComponents > 0 {
+			cmd = append(cmd, "--strip-components="+strconv.Itoa(b.stripComponents))
+		}
+
+		cmd = append(cmd, b.members...)
+	}
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *ExtractBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// BuildCommand generates the command extracting the archive, as ExtractCommand does.
+func (b *ExtractBuilder) BuildCommand() ([]string, error) {
+	return b.ExtractCommand()
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *ExtractBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *ExtractBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the extraction command.
+func (b *ExtractBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("archive extract", b, NewExtractBuilder(),
+		explainx.Generate("ExtractCommand", b.ExtractCommand))
+}
@@ -0,0 +1,66 @@
+package archivex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *ExtractBuilder
+		want    []string
+	}{
+		{
+			"Tar.gz with strip components",
+			NewExtractBuilder().WithArchive("/tmp/tool.tar.gz").WithDestination("/usr/local/bin").
+				WithStripComponents(1).WithMember("tool-1.2.3/tool"),
+			[]string{
+				"tar", "-xzf", "/tmp/tool.tar.gz", "--no-same-owner", "-C", "/usr/local/bin",
+				"--strip-components=1", "tool-1.2.3/tool",
+			},
+		},
+		{
+			"Tar with explicit format",
+			NewExtractBuilder().WithArchive("/tmp/rootfs").WithFormat(FormatTar),
+			[]string{"tar", "-xf", "/tmp/rootfs", "--no-same-owner"},
+		},
+		{
+			"Zip",
+			NewExtractBuilder().WithArchive("/tmp/terraform.zip").WithDestination("/tmp").WithMember("terraform"),
+			[]string{"unzip", "-q", "-o", "/tmp/terraform.zip", "terraform", "-d", "/tmp"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.ExtractCommand()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestExtractCommandValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *ExtractBuilder
+		kind    error
+	}{
+		{"Missing archive", NewExtractBuilder(), errorsx.ErrMissingField},
+		{"Unknown extension", NewExtractBuilder().WithArchive("tool.7z"), errorsx.ErrUnsupported},
+		{"Negative strip components", NewExtractBuilder().WithArchive("tool.tar").WithStripComponents(-1), errorsx.ErrInvalidValue},
+		{"Zip strip components", NewExtractBuilder().WithArchive("tool.zip").WithStripComponents(1), errorsx.ErrUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.ExtractCommand()
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+		})
+	}
+}
@@ -0,0 +1,225 @@
+// Package artifactx provides a manifest of the artifacts produced by builders (image tarballs,
+// SBOMs, lockfiles, reports, test videos and screenshots) and an export plan, so a pipeline can
+// export every artifact to a host directory or to an OCI registry with one call.
+//
+// Builders register their outputs into a Collector. The collector can then compute the export
+// steps to a host directory, export the files from a Dagger container, or generate the `oras push`
+// command that publishes all artifacts to a registry.
+//
+// Example usage:
+//
+//	c := artifactx.NewCollector()
+//	_ = c.Register(artifactx.Artifact{Kind: artifactx.KindImageTarball, Path: "/mnt/image.tar"})
+//	_ = c.Register(artifactx.Artifact{Kind: artifactx.KindSBOM, Path: "/mnt/sbom-x86_64.spdx.json"})
+//
+//	if err := c.ExportToHost(ctx, ctr, "./dist"); err != nil {
+//	    // handle error
+//	}
+//
+//	cmd, err := c.PushCommand("registry.example.com/artifacts:v1.0.0")
+package artifactx
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Kind is the kind of an artifact.
+type Kind string
+
+const (
+	// KindImageTarball is an OCI image tarball.
+	KindImageTarball Kind = "image-tarball"
+	// KindSBOM is a software bill of materials.
+	KindSBOM Kind = "sbom"
+	// KindLockfile is a resolved dependency lockfile.
+	KindLockfile Kind = "lockfile"
+	// KindReport is a build, scan or test report.
+	KindReport Kind = "report"
+	// KindTestOutput holds the attachments of a test run: videos, screenshots and traces.
+	KindTestOutput Kind = "test-output"
+)
+
+const (
+	// MediaTypeTar is the media type of tarballs.
+	MediaTypeTar = "application/x-tar"
+	// MediaTypeJSON is the media type of JSON documents.
+	MediaTypeJSON = "application/json"
+	// MediaTypeSPDXJSON is the media type of SPDX JSON documents.
+	MediaTypeSPDXJSON = "application/spdx+json"
+	// MediaTypeCycloneDXJSON is the media type of CycloneDX JSON documents.
+	MediaTypeCycloneDXJSON = "application/vnd.cyclonedx+json"
+	// MediaTypeMarkdown is the media type of Markdown documents.
+	MediaTypeMarkdown = "text/markdown"
+	// MediaTypeSARIF is the media type of SARIF reports of static analysis and IaC scanners.
+	MediaTypeSARIF = "application/sarif+json"
+	// MediaTypeXML is the media type of XML documents, such as JUnit reports.
+	MediaTypeXML = "application/xml"
+	// MediaTypeHTML is the media type of HTML documents.
+	MediaTypeHTML = "text/html"
+	// MediaTypeTarGzip is the media type of directories, which oras pushes as gzipped tarballs.
+	MediaTypeTarGzip = "application/vnd.oci.image.layer.v1.tar+gzip"
+	// MediaTypeOctetStream is the generic binary media type.
+	MediaTypeOctetStream = "application/octet-stream"
+)
+
+// Artifact describes a file produced by a builder.
+type Artifact struct {
+	// Kind is the kind of the artifact.
+	Kind Kind `json:"kind"`
+	// Path is the path of the artifact inside the build container.
+	Path string `json:"path"`
+	// MediaType is the media type of the artifact. When empty, it is inferred from the kind and extension.
+	MediaType string `json:"mediaType"`
+	// Digest is the digest of the artifact content (e.g. "sha256:..."), when known.
+	Digest string `json:"digest,omitempty"`
+	// Directory reports whether the artifact is a directory (e.g. the videos of a test run)
+	// rather than a file.
+	Directory bool `json:"directory,omitempty"`
+}
+
+// Name returns the file name of the artifact.
+func (a *Artifact) Name() string {
+	return path.Base(a.Path)
+}
+
+// Validate checks that the artifact is complete.
+func (a *Artifact) Validate() error {
+	switch a.Kind {
+	case KindImageTarball, KindSBOM, KindLockfile, KindReport, KindTestOutput:
+	default:
+		return fmt.Errorf("unsupported artifact kind: %q", a.Kind)
+	}
+
+	if a.Path == "" {
+		return fmt.Errorf("artifact path is required")
+	}
+
+	if a.Digest != "" {
+		algo, hex, ok := strings.Cut(a.Digest, ":")
+		if !ok || algo == "" || hex == "" {
+			return fmt.Errorf("invalid artifact digest: %s", a.Digest)
+		}
+	}
+
+	return nil
+}
+
+// InferMediaType returns the media type of an artifact from its kind and file extension.
+func InferMediaType(kind Kind, filePath string) string {
+	name := strings.ToLower(path.Base(filePath))
+
+	switch {
+	case strings.HasSuffix(name, ".spdx.json"):
+		return MediaTypeSPDXJSON
+	case strings.HasSuffix(name, ".cdx.json"), strings.HasSuffix(name, ".cyclonedx.json"):
+		return MediaTypeCycloneDXJSON
+	case strings.HasSuffix(name, ".sarif"), strings.HasSuffix(name, ".sarif.json"):
+		return MediaTypeSARIF
+	case strings.HasSuffix(name, ".json"):
+		return MediaTypeJSON
+	case strings.HasSuffix(name, ".md"):
+		return MediaTypeMarkdown
+	case strings.HasSuffix(name, ".xml"):
+		return MediaTypeXML
+	case strings.HasSuffix(name, ".html"):
+		return MediaTypeHTML
+	case strings.HasSuffix(name, ".tar"):
+		return MediaTypeTar
+	}
+
+	switch kind {
+	case KindImageTarball:
+		return MediaTypeTar
+	case KindSBOM:
+		return MediaTypeSPDXJSON
+	case KindLockfile, KindReport:
+		return MediaTypeJSON
+	default:
+		return MediaTypeOctetStream
+	}
+}
+
+// Collector collects the artifacts registered by builders.
+type Collector struct {
+	// artifacts holds the registered artifacts, in registration order.
+	artifacts []Artifact
+	// paths indexes the registered artifact paths.
+	paths map[string]bool
+}
+
+// NewCollector creates an empty Collector.
+func NewCollector() *Collector {
+	return &Collector{
+		paths: map[string]bool{},
+	}
+}
+
+// Register adds an artifact to the collector, inferring its media type if empty. Directories
+// default to MediaTypeTarGzip.
+//
+// Returns:
+//   - An error if the artifact is invalid or its path is already registered.
+func (c *Collector) Register(a Artifact) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+
+	if c.paths[a.Path] {
+		return fmt.Errorf("artifact %s is already registered", a.Path)
+	}
+
+	switch {
+	case a.MediaType != "":
+	case a.Directory:
+		a.MediaType = MediaTypeTarGzip
+	default:
+		a.MediaType = InferMediaType(a.Kind, a.Path)
+	}
+
+	c.paths[a.Path] = true
+	c.artifacts = append(c.artifacts, a)
+
+	return nil
+}
+
+// Artifacts returns the registered artifacts, in registration order.
+func (c *Collector) Artifacts() []Artifact {
+	return append([]Artifact(nil), c.artifacts...)
+}
+
+// ByKind returns the registered artifacts of the given kind, in registration order.
+func (c *Collector) ByKind(kind Kind) []Artifact {
+	var out []Artifact
+	for _, a := range c.artifacts {
+		if a.Kind == kind {
+			out = append(out, a)
+		}
+	}
+
+	return out
+}
+
+// SetDigest records the digest of a registered artifact.
+func (c *Collector) SetDigest(artifactPath, digest string) error {
+	for i := range c.artifacts {
+		if c.artifacts[i].Path == artifactPath {
+			c.artifacts[i].Digest = digest
+			return c.artifacts[i].Validate()
+		}
+	}
+
+	return fmt.Errorf("artifact %s is not registered", artifactPath)
+}
+
+// sortedArtifacts returns the artifacts sorted by path, for deterministic outputs.
+func (c *Collector) sortedArtifacts() []Artifact {
+	out := c.Artifacts()
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Path < out[j].Path
+	})
+
+	return out
+}
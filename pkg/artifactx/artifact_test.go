@@ -0,0 +1,60 @@
+package artifactx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferMediaType(t *testing.T) {
+	tests := []struct {
+		kind Kind
+		path string
+		want string
+	}{
+		{KindImageTarball, "/mnt/image.tar", MediaTypeTar},
+		{KindSBOM, "/mnt/sbom-x86_64.spdx.json", MediaTypeSPDXJSON},
+		{KindSBOM, "/mnt/sbom.cdx.json", MediaTypeCycloneDXJSON},
+		{KindSBOM, "/mnt/sbom", MediaTypeSPDXJSON},
+		{KindLockfile, "/mnt/apko.lock.json", MediaTypeJSON},
+		{KindReport, "/mnt/report.md", MediaTypeMarkdown},
+		{KindReport, "/mnt/report", MediaTypeJSON},
+		{KindReport, "/mnt/junit.xml", MediaTypeXML},
+		{KindReport, "/mnt/checkov/results_sarif.sarif", MediaTypeSARIF},
+		{KindReport, "/mnt/tfsec.sarif.json", MediaTypeSARIF},
+		{KindReport, "/mnt/report/index.html", MediaTypeHTML},
+		{KindTestOutput, "/mnt/trace.zip", MediaTypeOctetStream},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, InferMediaType(tt.kind, tt.path))
+		})
+	}
+}
+
+func TestCollectorRegister(t *testing.T) {
+	c := NewCollector()
+	require.NoError(t, c.Register(Artifact{Kind: KindImageTarball, Path: "/mnt/image.tar"}))
+	require.NoError(t, c.Register(Artifact{Kind: KindSBOM, Path: "/mnt/sbom.spdx.json", Digest: "sha256:abc"}))
+
+	assert.EqualError(t, c.Register(Artifact{Kind: KindSBOM, Path: "/mnt/sbom.spdx.json"}),
+		"artifact /mnt/sbom.spdx.json is already registered")
+	assert.EqualError(t, c.Register(Artifact{Kind: "binary", Path: "/mnt/app"}), `unsupported artifact kind: "binary"`)
+	assert.EqualError(t, c.Register(Artifact{Kind: KindReport}), "artifact path is required")
+	assert.EqualError(t, c.Register(Artifact{Kind: KindReport, Path: "/r.json", Digest: "abc"}), "invalid artifact digest: abc")
+
+	artifacts := c.Artifacts()
+	require.Len(t, artifacts, 2)
+	assert.Equal(t, MediaTypeTar, artifacts[0].MediaType)
+	assert.Equal(t, "image.tar", artifacts[0].Name())
+	assert.Len(t, c.ByKind(KindSBOM), 1)
+
+	require.NoError(t, c.Register(Artifact{Kind: KindTestOutput, Path: "/mnt/videos", Directory: true}))
+	assert.Equal(t, MediaTypeTarGzip, c.ByKind(KindTestOutput)[0].MediaType)
+
+	require.NoError(t, c.SetDigest("/mnt/image.tar", "sha256:def"))
+	assert.Equal(t, "sha256:def", c.Artifacts()[0].Digest)
+	assert.EqualError(t, c.SetDigest("/mnt/missing", "sha256:def"), "artifact /mnt/missing is not registered")
+}
@@ -0,0 +1,113 @@
+package artifactx
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"dagger.io/dagger"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// ExportStep describes the export of a single artifact.
+type ExportStep struct {
+	// Artifact is the exported artifact.
+	Artifact Artifact
+	// Destination is the destination path of the artifact.
+	Destination string
+}
+
+// ExportPlan returns the steps exporting every artifact into the directory 'dir'.
+// Artifacts are exported under their file name; when several artifacts share a file name, the
+// artifact kind is used as a subdirectory to keep destinations unique.
+//
+// Returns:
+//   - The export steps, sorted by artifact path.
+//   - An error if the directory is empty or two artifacts would still collide.
+func (c *Collector) ExportPlan(dir string) ([]ExportStep, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("export directory is required")
+	}
+
+	artifacts := c.sortedArtifacts()
+
+	names := make(map[string]int, len(artifacts))
+	for _, a := range artifacts {
+		names[a.Name()]++
+	}
+
+	steps := make([]ExportStep, 0, len(artifacts))
+	seen := make(map[string]string, len(artifacts))
+	for _, a := range artifacts {
+		dest := filepath.Join(dir, a.Name())
+		if names[a.Name()] > 1 {
+			dest = filepath.Join(dir, string(a.Kind), a.Name())
+		}
+
+		if other, ok := seen[dest]; ok {
+			return nil, fmt.Errorf("artifacts %s and %s would both be exported to %s", other, a.Path, dest)
+		}
+		seen[dest] = a.Path
+
+		steps = append(steps, ExportStep{Artifact: a, Destination: dest})
+	}
+
+	return steps, nil
+}
+
+// ExportToHost exports every artifact from the container to the host directory 'dir',
+// following the ExportPlan. Directory artifacts are exported with their content.
+func (c *Collector) ExportToHost(ctx context.Context, ctr *dagger.Container, dir string) error {
+	if ctr == nil {
+		return fmt.Errorf("container is required")
+	}
+
+	steps, err := c.ExportPlan(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range steps {
+		var err error
+		if s.Artifact.Directory {
+			_, err = ctr.Directory(s.Artifact.Path).Export(ctx, s.Destination)
+		} else {
+			_, err = ctr.File(s.Artifact.Path).Export(ctx, s.Destination)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to export artifact %s: %w", s.Artifact.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// PushCommand returns the `oras push` command that publishes every artifact to the OCI
+// reference 'ref', each file annotated with its media type. oras rejects absolute paths, so
+// artifact paths are made relative to '/' and the command must run with '/' as working directory.
+// oras packs directory artifacts into gzipped tarballs.
+//
+// Returns:
+//   - The oras command.
+//   - An error if the reference is empty or there is no artifact to push.
+func (c *Collector) PushCommand(ref string) (types.DaggerCMD, error) {
+	if ref == "" {
+		return nil, fmt.Errorf("reference is required")
+	}
+
+	artifacts := c.sortedArtifacts()
+	if len(artifacts) == 0 {
+		return nil, fmt.Errorf("no artifact to push")
+	}
+
+	cmd := make(types.DaggerCMD, 0, len(artifacts)+3)
+	cmd = append(cmd, "oras", "push", ref)
+	for _, a := range artifacts {
+		cmd = append(cmd, fmt.Sprintf("%s:%s", strings.TrimPrefix(path.Clean(a.Path), "/"), a.MediaType))
+	}
+
+	return cmd, nil
+}
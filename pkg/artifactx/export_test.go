@@ -0,0 +1,67 @@
+package artifactx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCollector(t *testing.T) *Collector {
+	t.Helper()
+
+	c := NewCollector()
+	require.NoError(t, c.Register(Artifact{Kind: KindSBOM, Path: "/mnt/x86_64/sbom.spdx.json"}))
+	require.NoError(t, c.Register(Artifact{Kind: KindImageTarball, Path: "/mnt/image.tar"}))
+	require.NoError(t, c.Register(Artifact{Kind: KindReport, Path: "/mnt/reports/sbom.spdx.json"}))
+
+	return c
+}
+
+func TestExportPlan(t *testing.T) {
+	steps, err := newTestCollector(t).ExportPlan("dist")
+	require.NoError(t, err)
+
+	var destinations []string
+	for _, s := range steps {
+		destinations = append(destinations, s.Destination)
+	}
+
+	assert.Equal(t, []string{"dist/image.tar", "dist/report/sbom.spdx.json", "dist/sbom/sbom.spdx.json"}, destinations)
+
+	_, err = NewCollector().ExportPlan("")
+	assert.EqualError(t, err, "export directory is required")
+}
+
+func TestExportPlanCollision(t *testing.T) {
+	c := NewCollector()
+	require.NoError(t, c.Register(Artifact{Kind: KindSBOM, Path: "/mnt/x86_64/sbom.json"}))
+	require.NoError(t, c.Register(Artifact{Kind: KindSBOM, Path: "/mnt/aarch64/sbom.json"}))
+
+	_, err := c.ExportPlan("dist")
+	assert.EqualError(t, err, "artifacts /mnt/aarch64/sbom.json and /mnt/x86_64/sbom.json would both be exported to dist/sbom/sbom.json")
+}
+
+func TestExportToHostRequiresContainer(t *testing.T) {
+	err := newTestCollector(t).ExportToHost(context.Background(), nil, "dist")
+	assert.EqualError(t, err, "container is required")
+}
+
+func TestPushCommand(t *testing.T) {
+	cmd, err := newTestCollector(t).PushCommand("registry.example.com/artifacts:v1")
+	require.NoError(t, err)
+	assert.Equal(t, types.DaggerCMD{
+		"oras", "push", "registry.example.com/artifacts:v1",
+		"mnt/image.tar:application/x-tar",
+		"mnt/reports/sbom.spdx.json:application/spdx+json",
+		"mnt/x86_64/sbom.spdx.json:application/spdx+json",
+	}, cmd)
+
+	_, err = NewCollector().PushCommand("registry.example.com/artifacts:v1")
+	assert.EqualError(t, err, "no artifact to push")
+
+	_, err = NewCollector().PushCommand("")
+	assert.EqualError(t, err, "reference is required")
+}
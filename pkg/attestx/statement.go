@@ -0,0 +1,207 @@
+// Package attestx wraps predicates (SBOMs, provenance, vulnerability scan results, or any custom
+// predicate) into in-toto Statement envelopes bound to the digests of the images and artifacts
+// they describe, ready for `cosign attest` and `cosign attest-blob`.
+//
+// Subjects name the artifacts of the statement. They are computed from OCI image tarballs (the
+// digests of index.json, as apko writes them), from image references (resolved through their
+// registry unless pinned by digest), or from files.
+//
+// Example usage:
+//
+//	subjects, err := attestx.SubjectsFromTarball("registry.example.com/app", "/mnt/image.tar")
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	statement, err := attestx.NewStatementBuilder(attestx.PredicateSPDX).
+//	    WithPredicateJSON(sbom).
+//	    WithSubjects(subjects...).
+//	    JSON()
+//
+//	cmd := attestx.AttestCommand("registry.example.com/app@"+subjects[0].DigestString(),
+//	    attestx.PredicateSPDX, "/mnt/sbom.spdx.json")
+package attestx
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/provenancex"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the statement builder in errorsx errors.
+const builderName = "attest"
+
+// StatementType is the type of in-toto v1 statements.
+const StatementType = provenancex.StatementType
+
+const (
+	// PredicateSLSAProvenance is the type of SLSA v1 provenance predicates, see provenancex.
+	PredicateSLSAProvenance = provenancex.PredicateType
+	// PredicateSPDX is the type of SPDX SBOM predicates.
+	PredicateSPDX = "https://spdx.dev/Document"
+	// PredicateCycloneDX is the type of CycloneDX SBOM predicates.
+	PredicateCycloneDX = "https://cyclonedx.org/bom"
+	// PredicateVuln is the type of cosign vulnerability scan predicates.
+	PredicateVuln = "https://cosign.sigstore.dev/attestation/vuln/v1"
+)
+
+// cosignTypes maps the predicate types to the names `cosign attest --type` knows them by.
+var cosignTypes = map[string]string{
+	PredicateSLSAProvenance: provenancex.CosignPredicateType,
+	PredicateSPDX:           "spdxjson",
+	PredicateCycloneDX:      "cyclonedx",
+	PredicateVuln:           "vuln",
+}
+
+// CosignType returns the `cosign attest --type` value of 'predicateType': its cosign name for
+// the predicate types cosign knows, and the type itself otherwise, which cosign accepts as is.
+func CosignType(predicateType string) string {
+	if name, ok := cosignTypes[predicateType]; ok {
+		return name
+	}
+
+	return predicateType
+}
+
+// Statement is an in-toto v1 statement.
+type Statement struct {
+	// Type is StatementType.
+	Type string `json:"_type"`
+	// Subject are the artifacts the predicate is about.
+	Subject []Subject `json:"subject"`
+	// PredicateType identifies the schema of the predicate.
+	PredicateType string `json:"predicateType"`
+	// Predicate is the JSON encoding of the predicate.
+	Predicate json.RawMessage `json:"predicate"`
+}
+
+// StatementBuilder builds a Statement.
+type StatementBuilder struct {
+	// predicateType identifies the schema of the predicate.
+	predicateType string
+
+	// predicate is the JSON encoding of the predicate.
+	predicate json.RawMessage
+
+	// subjects are the artifacts the predicate is about.
+	subjects []Subject
+
+	// err is the error of the predicate encoding, reported by Build.
+	err error
+}
+
+// NewStatementBuilder creates a StatementBuilder of a predicate of type 'predicateType' (e.g.
+// PredicateSPDX, or the URI of a custom predicate).
+func NewStatementBuilder(predicateType string) *StatementBuilder {
+	return &StatementBuilder{predicateType: predicateType}
+}
+
+// WithPredicate sets the predicate, encoded to JSON. Encoding errors are reported by Build.
+func (b *StatementBuilder) WithPredicate(predicate any) *StatementBuilder {
+	b.predicate, b.err = json.Marshal(predicate)
+	if b.err != nil {
+		b.err = errorsx.InvalidValue(builderName, "predicate", nil, "failed to encode predicate: %v", b.err)
+	}
+
+	return b
+}
+
+// WithPredicateJSON sets the JSON encoded predicate (e.g. an SBOM or a grype report). Invalid
+// JSON is reported by Build.
+func (b *StatementBuilder) WithPredicateJSON(data []byte) *StatementBuilder {
+	b.predicate, b.err = json.RawMessage(data), nil
+	if !json.Valid(data) {
+		b.err = errorsx.InvalidValue(builderName, "predicate", nil, "predicate is not valid JSON")
+	}
+
+	return b
+}
+
+// WithSubjects adds subjects to the statement.
+func (b *StatementBuilder) WithSubjects(subjects ...Subject) *StatementBuilder {
+	b.subjects = append(b.subjects, subjects...)
+	return b
+}
+
+// Build validates the configuration and returns the statement.
+//
+// Returns:
+//   - The statement.
+//   - An error if the predicate type, the predicate or the subjects are missing, a subject is
+//     invalid, or the predicate couldn't be encoded.
+func (b *StatementBuilder) Build() (*Statement, error) {
+	if b.predicateType == "" {
+		return nil, errorsx.MissingField(builderName, "predicateType", "predicate type is required")
+	}
+
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	if b.predicate == nil {
+		return nil, errorsx.MissingField(builderName, "predicate", "predicate is required")
+	}
+
+	if len(b.subjects) == 0 {
+		return nil, errorsx.MissingField(builderName, "subject", "at least one subject is required")
+	}
+
+	for _, s := range b.subjects {
+		if err := s.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Statement{
+		Type:          StatementType,
+		Subject:       append([]Subject(nil), b.subjects...),
+		PredicateType: b.predicateType,
+		Predicate:     b.predicate,
+	}, nil
+}
+
+// JSON returns the indented JSON encoding of the statement, with the secrets registered with
+// the default logx redactor scrubbed.
+func (b *StatementBuilder) JSON() ([]byte, error) {
+	s, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode statement: %w", err)
+	}
+
+	return logx.Default().Bytes(data), nil
+}
+
+// AttestCommand returns the `cosign attest` command attaching the predicate file at
+// 'predicatePath', of type 'predicateType', to the image 'imageRef'. Cosign wraps the predicate
+// in a statement whose subject is the digest of the image, so 'imageRef' should be pinned by
+// digest.
+func AttestCommand(imageRef, predicateType, predicatePath string) types.DaggerCMD {
+	return types.DaggerCMD{
+		"cosign", "attest", "--yes",
+		"--type", CosignType(predicateType),
+		"--predicate", predicatePath,
+		imageRef,
+	}
+}
+
+// AttestBlobCommand returns the `cosign attest-blob` command signing the predicate file at
+// 'predicatePath', of type 'predicateType', for the file 'blobPath', and writing the bundle to
+// 'bundlePath'.
+func AttestBlobCommand(blobPath, predicateType, predicatePath, bundlePath string) types.DaggerCMD {
+	return types.DaggerCMD{
+		"cosign", "attest-blob", "--yes",
+		"--type", CosignType(predicateType),
+		"--predicate", predicatePath,
+		"--bundle", bundlePath,
+		blobPath,
+	}
+}
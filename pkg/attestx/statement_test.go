@@ -0,0 +1,73 @@
+package attestx
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testDigest = "sha256:" + strings.Repeat("ab", 32)
+
+func TestStatementBuilder(t *testing.T) {
+	subject, err := NewSubject("registry.example.com/app", testDigest)
+	require.NoError(t, err)
+
+	data, err := NewStatementBuilder(PredicateVuln).
+		WithPredicate(map[string]any{"scanner": map[string]string{"uri": "pkg:github/anchore/grype"}}).
+		WithSubjects(subject).
+		JSON()
+	require.NoError(t, err)
+
+	var s Statement
+	require.NoError(t, json.Unmarshal(data, &s))
+	assert.Equal(t, StatementType, s.Type)
+	assert.Equal(t, PredicateVuln, s.PredicateType)
+	assert.Equal(t, []Subject{{Name: "registry.example.com/app", Digest: map[string]string{"sha256": strings.Repeat("ab", 32)}}}, s.Subject)
+	assert.JSONEq(t, `{"scanner":{"uri":"pkg:github/anchore/grype"}}`, string(s.Predicate))
+}
+
+func TestStatementBuilderErrors(t *testing.T) {
+	subject, err := NewSubject("app", testDigest)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		builder *StatementBuilder
+		want    error
+	}{
+		{name: "Missing predicate type", builder: NewStatementBuilder("").WithPredicateJSON([]byte("{}")).WithSubjects(subject), want: errorsx.ErrMissingField},
+		{name: "Missing predicate", builder: NewStatementBuilder(PredicateSPDX).WithSubjects(subject), want: errorsx.ErrMissingField},
+		{name: "Invalid predicate JSON", builder: NewStatementBuilder(PredicateSPDX).WithPredicateJSON([]byte("{")).WithSubjects(subject), want: errorsx.ErrInvalidValue},
+		{name: "Unencodable predicate", builder: NewStatementBuilder(PredicateSPDX).WithPredicate(func() {}).WithSubjects(subject), want: errorsx.ErrInvalidValue},
+		{name: "Missing subjects", builder: NewStatementBuilder(PredicateSPDX).WithPredicateJSON([]byte("{}")), want: errorsx.ErrMissingField},
+		{name: "Invalid subject", builder: NewStatementBuilder(PredicateSPDX).WithPredicateJSON([]byte("{}")).
+			WithSubjects(Subject{Name: "app", Digest: map[string]string{"sha256": "abc"}}), want: errorsx.ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Build()
+			assert.True(t, errors.Is(err, tt.want), "Build() error = %v, want %v", err, tt.want)
+		})
+	}
+}
+
+func TestAttestCommands(t *testing.T) {
+	assert.Equal(t, types.DaggerCMD{
+		"cosign", "attest", "--yes", "--type", "spdxjson", "--predicate", "/mnt/sbom.json", "app@" + testDigest,
+	}, AttestCommand("app@"+testDigest, PredicateSPDX, "/mnt/sbom.json"))
+
+	assert.Equal(t, types.DaggerCMD{
+		"cosign", "attest-blob", "--yes", "--type", "https://example.com/custom/v1",
+		"--predicate", "/mnt/p.json", "--bundle", "/mnt/out.bundle", "/mnt/app.tar.gz",
+	}, AttestBlobCommand("/mnt/app.tar.gz", "https://example.com/custom/v1", "/mnt/p.json", "/mnt/out.bundle"))
+
+	assert.Equal(t, "slsaprovenance1", CosignType(PredicateSLSAProvenance))
+	assert.Equal(t, "cyclonedx", CosignType(PredicateCycloneDX))
+}
@@ -0,0 +1,174 @@
+package attestx
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/checksumx"
+	"github.com/Excoriate/daggerx/pkg/containerx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// Subject is an artifact an in-toto statement is about.
+type Subject struct {
+	// Name is the name of the artifact: the repository of an image (e.g.
+	// "registry.example.com/app"), or the file name of a file.
+	Name string `json:"name"`
+	// Digest maps the digest algorithms of the artifact to its hexadecimal digests.
+	Digest map[string]string `json:"digest"`
+}
+
+// NewSubject returns the subject 'name' of the OCI digest 'digest' ("sha256:<hex>").
+//
+// Returns:
+//   - The subject.
+//   - An error if the digest is malformed or its algorithm unsupported.
+func NewSubject(name, digest string) (Subject, error) {
+	d, err := checksumx.ParseDigest(digest)
+	if err != nil {
+		return Subject{}, err
+	}
+
+	return Subject{Name: name, Digest: map[string]string{string(d.Algorithm): d.Hex}}, nil
+}
+
+// DigestString returns the OCI digest of the subject ("sha256:<hex>"), preferring SHA-256, or an
+// empty string if it has no digest.
+func (s Subject) DigestString() string {
+	if hex, ok := s.Digest[string(checksumx.SHA256)]; ok {
+		return string(checksumx.SHA256) + ":" + hex
+	}
+
+	for _, alg := range checksumx.Algorithms() {
+		if hex, ok := s.Digest[string(alg)]; ok {
+			return string(alg) + ":" + hex
+		}
+	}
+
+	return ""
+}
+
+// Validate checks that the subject has a name and well-formed digests.
+func (s Subject) Validate() error {
+	if s.Name == "" {
+		return errorsx.MissingField(builderName, "subject", "subject name is required")
+	}
+
+	if len(s.Digest) == 0 {
+		return errorsx.MissingField(builderName, "subject", "subject %s requires a digest", s.Name)
+	}
+
+	for alg, hex := range s.Digest {
+		if _, err := checksumx.ParseDigest(alg + ":" + hex); err != nil {
+			return errorsx.InvalidValue(builderName, "subject", s.Name, "invalid digest of subject %s: %v", s.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// SubjectFromFile returns the subject of the file at 'filePath', named after its base name, with
+// its SHA-256 digest.
+func SubjectFromFile(filePath string) (Subject, error) {
+	d, err := checksumx.File(checksumx.SHA256, filePath)
+	if err != nil {
+		return Subject{}, err
+	}
+
+	return NewSubject(path.Base(filePath), d.String())
+}
+
+// SubjectFromRef returns the subject of the image 'imageRef', named after its repository, with
+// the digest of its manifest resolved with containerx.ResolveDigestContext: references pinned by
+// digest are not resolved, and 'client', when nil, defaults to the client of httpfetchx.Default.
+//
+// Returns:
+//   - The subject.
+//   - An error if the reference is invalid or its digest can't be resolved.
+func SubjectFromRef(ctx context.Context, imageRef string, client *http.Client) (Subject, error) {
+	digest, err := containerx.ResolveDigestContext(ctx, imageRef, client)
+	if err != nil {
+		return Subject{}, err
+	}
+
+	return NewSubject(RepositoryName(imageRef), digest)
+}
+
+// RepositoryName returns the image reference 'imageRef' without its tag and digest (e.g.
+// "registry.example.com/app" for "registry.example.com/app:v1@sha256:...").
+func RepositoryName(imageRef string) string {
+	name, _, _ := strings.Cut(imageRef, "@")
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+
+	return name
+}
+
+// ociIndex is the index.json of an OCI image layout.
+type ociIndex struct {
+	Manifests []struct {
+		Digest string `json:"digest"`
+	} `json:"manifests"`
+}
+
+// SubjectsFromTarball returns the subjects of the OCI image layout tarball at 'tarball' (as
+// written by apko build), named 'name': one per manifest or index listed by its index.json, so
+// the digests are the ones the registry reports once the image is pushed.
+//
+// Returns:
+//   - The subjects.
+//   - An error if the tarball can't be read, holds no index.json (docker tarballs don't record
+//     the digests of their manifests), or its index lists no manifest.
+func SubjectsFromTarball(name, tarball string) ([]Subject, error) {
+	f, err := os.Open(tarball)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image tarball: %w", err)
+	}
+	defer f.Close()
+
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, errorsx.Unsupported(builderName, "tarball", tarball,
+				"image tarball %s is not an OCI image layout: no index.json", tarball)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image tarball: %w", err)
+		}
+
+		if path.Clean(hdr.Name) != "index.json" {
+			continue
+		}
+
+		var index ociIndex
+		if err := json.NewDecoder(tr).Decode(&index); err != nil {
+			return nil, fmt.Errorf("invalid index.json in %s: %w", tarball, err)
+		}
+
+		if len(index.Manifests) == 0 {
+			return nil, errorsx.InvalidValue(builderName, "tarball", tarball,
+				"index.json of %s lists no manifest", tarball)
+		}
+
+		subjects := make([]Subject, 0, len(index.Manifests))
+		for _, m := range index.Manifests {
+			s, err := NewSubject(name, m.Digest)
+			if err != nil {
+				return nil, err
+			}
+			subjects = append(subjects, s)
+		}
+
+		return subjects, nil
+	}
+}
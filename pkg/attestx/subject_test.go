@@ -0,0 +1,101 @@
+package attestx
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTarball writes a tarball holding 'files'.
+func writeTarball(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	p := filepath.Join(t.TempDir(), "image.tar")
+	f, err := os.Create(p)
+	require.NoError(t, err)
+
+	tw := tar.NewWriter(f)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, f.Close())
+
+	return p
+}
+
+func TestSubjectsFromTarball(t *testing.T) {
+	other := "sha256:" + strings.Repeat("cd", 32)
+	tarball := writeTarball(t, map[string]string{
+		"oci-layout": `{"imageLayoutVersion":"1.0.0"}`,
+		"index.json": `{"schemaVersion":2,"manifests":[{"digest":"` + testDigest + `"},{"digest":"` + other + `"}]}`,
+	})
+
+	subjects, err := SubjectsFromTarball("registry.example.com/app", tarball)
+	require.NoError(t, err)
+	require.Len(t, subjects, 2)
+	assert.Equal(t, "registry.example.com/app", subjects[0].Name)
+	assert.Equal(t, testDigest, subjects[0].DigestString())
+	assert.Equal(t, other, subjects[1].DigestString())
+
+	_, err = SubjectsFromTarball("app", writeTarball(t, map[string]string{"manifest.json": "[]"}))
+	assert.True(t, errors.Is(err, errorsx.ErrUnsupported))
+
+	_, err = SubjectsFromTarball("app", writeTarball(t, map[string]string{"index.json": `{"manifests":[]}`}))
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+
+	_, err = SubjectsFromTarball("app", filepath.Join(t.TempDir(), "missing.tar"))
+	assert.Error(t, err)
+}
+
+func TestSubjectFromFile(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "app.tar.gz")
+	require.NoError(t, os.WriteFile(p, []byte("test"), 0o644))
+
+	s, err := SubjectFromFile(p)
+	require.NoError(t, err)
+	assert.Equal(t, Subject{
+		Name:   "app.tar.gz",
+		Digest: map[string]string{"sha256": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"},
+	}, s)
+}
+
+func TestSubjectFromRef(t *testing.T) {
+	s, err := SubjectFromRef(context.Background(), "registry.example.com:5000/team/app:v1@"+testDigest, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "registry.example.com:5000/team/app", s.Name)
+	assert.Equal(t, testDigest, s.DigestString())
+}
+
+func TestRepositoryName(t *testing.T) {
+	tests := map[string]string{
+		"app":                                 "app",
+		"app:v1":                              "app",
+		"localhost:5000/app":                  "localhost:5000/app",
+		"localhost:5000/app:v1@" + testDigest: "localhost:5000/app",
+		"registry.example.com/team/app@" + testDigest: "registry.example.com/team/app",
+	}
+
+	for ref, want := range tests {
+		assert.Equal(t, want, RepositoryName(ref), ref)
+	}
+}
+
+func TestSubjectValidate(t *testing.T) {
+	assert.True(t, errors.Is(Subject{}.Validate(), errorsx.ErrMissingField))
+	assert.True(t, errors.Is(Subject{Name: "app"}.Validate(), errorsx.ErrMissingField))
+	assert.Empty(t, Subject{Name: "app"}.DigestString())
+
+	_, err := NewSubject("app", "md5:abc")
+	assert.Error(t, err)
+}
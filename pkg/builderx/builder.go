@@ -0,0 +1,122 @@
+// Package builderx defines the Builder interface shared by the command builders of this module,
+// so pipeline and executor layers treat every tool uniformly: a builder validates its
+// configuration, generates its command, and reports the mounts and environment variables the
+// command requires, and a description of its options (see explainx).
+//
+// The helpers of the package turn any builder into a pipeline node, a plan task, or an executed
+// command, with the requirements of the builder attached.
+//
+// Example usage:
+//
+//	builders := map[string]builderx.Builder{
+//	    "lint":  hadolintx.NewHadolintBuilder("Dockerfile"),
+//	    "image": apkox.NewApkoBuilder().WithConfigFile("apko.yaml").WithOutputImage("app").
+//	        WithOutputTarball("/out/app.tar"),
+//	}
+//
+//	p := pipeline.New("release")
+//	for _, id := range []string{"lint", "image"} {
+//	    node, err := builderx.Node(id, builders[id])
+//	    if err != nil {
+//	        // handle error
+//	    }
+//	    _ = p.AddNode(node)
+//	}
+package builderx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Excoriate/daggerx/pkg/execx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/pipeline"
+	"github.com/Excoriate/daggerx/pkg/planx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// Builder is implemented by the command builders of this module, such as apkox.ApkoBuilder. The
+// builders generating several commands build their main one (e.g. rubyx.BundlerBuilder builds
+// bundle install), and the mode-based builders of sqlmigratex implement it through ForMode.
+type Builder interface {
+	// Validate checks the configuration of the builder, as BuildCommand does.
+	Validate() error
+	// BuildCommand generates the command of the builder.
+	BuildCommand() ([]string, error)
+	// Mounts returns the mounts the command requires.
+	Mounts() []types.Mount
+	// Env returns the environment variables the command requires.
+	Env() []types.DaggerEnvVars
+	// Explain describes the options of the builder and the command they generate.
+	Explain() explainx.Explanation
+}
+
+// SecretBuilder is a Builder whose command requires secrets, returned by Secrets.
+type SecretBuilder interface {
+	Builder
+	// Secrets returns the secrets the command requires.
+	Secrets() []*secretsx.SecretRef
+}
+
+// Secrets returns the secrets of the builder 'b', or nil if it isn't a SecretBuilder.
+func Secrets(b Builder) []*secretsx.SecretRef {
+	if s, ok := b.(SecretBuilder); ok {
+		return s.Secrets()
+	}
+
+	return nil
+}
+
+// Node returns the pipeline node 'id' running the command of the builder 'b' after the nodes
+// 'dependsOn', with the environment variables, mounts and secret names of the builder.
+//
+// Returns:
+//   - The pipeline node.
+//   - An error if the builder fails to generate its command.
+func Node(id string, b Builder, dependsOn ...string) (pipeline.Node, error) {
+	cmd, err := b.BuildCommand()
+	if err != nil {
+		return pipeline.Node{}, fmt.Errorf("node %s: %w", id, err)
+	}
+
+	node := pipeline.Node{ID: id, Command: cmd, Env: b.Env(), Mounts: b.Mounts(), DependsOn: dependsOn}
+	for _, s := range Secrets(b) {
+		if s != nil {
+			node.Secrets = append(node.Secrets, s.Name)
+		}
+	}
+
+	return node, nil
+}
+
+// Task returns the plan task 'id' running the command of the builder 'b', with the environment
+// variables and mounts of the builder.
+//
+// Returns:
+//   - The plan task.
+//   - An error if the builder fails to generate its command.
+func Task(id string, b Builder) (planx.Task, error) {
+	cmd, err := b.BuildCommand()
+	if err != nil {
+		return planx.Task{}, fmt.Errorf("task %s: %w", id, err)
+	}
+
+	return planx.Task{ID: id, Command: cmd, Env: b.Env(), Mounts: b.Mounts()}, nil
+}
+
+// Run generates the command of the builder 'b' and runs it with 'executor', with the environment
+// variables and mounts of the builder. Secrets are provided by the executor, e.g. through the
+// environment of the local host.
+//
+// Returns:
+//   - The result of the command, as the executor returns it.
+//   - An error if the builder fails to generate its command, or the executor to run it.
+func Run(ctx context.Context, executor execx.Executor, b Builder) (*execx.Result, error) {
+	cmd, err := b.BuildCommand()
+	if err != nil {
+		return nil, err
+	}
+
+	return executor.Run(ctx, cmd, b.Env(), b.Mounts())
+}
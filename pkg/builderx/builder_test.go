@@ -0,0 +1,193 @@
+package builderx_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/archivex"
+	"github.com/Excoriate/daggerx/pkg/builderx"
+	"github.com/Excoriate/daggerx/pkg/buildxpkg"
+	"github.com/Excoriate/daggerx/pkg/cachewarmx"
+	"github.com/Excoriate/daggerx/pkg/checkovx"
+	"github.com/Excoriate/daggerx/pkg/cosignx"
+	"github.com/Excoriate/daggerx/pkg/dependencytrackx"
+	"github.com/Excoriate/daggerx/pkg/dockerauthx"
+	"github.com/Excoriate/daggerx/pkg/dotnetx"
+	"github.com/Excoriate/daggerx/pkg/e2ex"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/execx"
+	"github.com/Excoriate/daggerx/pkg/gcsx"
+	"github.com/Excoriate/daggerx/pkg/ghapix"
+	"github.com/Excoriate/daggerx/pkg/gitx"
+	"github.com/Excoriate/daggerx/pkg/golangx"
+	"github.com/Excoriate/daggerx/pkg/goldenx"
+	"github.com/Excoriate/daggerx/pkg/hadolintx"
+	"github.com/Excoriate/daggerx/pkg/helmx"
+	"github.com/Excoriate/daggerx/pkg/imagepromote"
+	"github.com/Excoriate/daggerx/pkg/kox"
+	"github.com/Excoriate/daggerx/pkg/loadtestx"
+	"github.com/Excoriate/daggerx/pkg/melangex"
+	"github.com/Excoriate/daggerx/pkg/notaryx"
+	"github.com/Excoriate/daggerx/pkg/opax"
+	"github.com/Excoriate/daggerx/pkg/phpx"
+	"github.com/Excoriate/daggerx/pkg/protosx"
+	"github.com/Excoriate/daggerx/pkg/rubyx"
+	"github.com/Excoriate/daggerx/pkg/s3x"
+	"github.com/Excoriate/daggerx/pkg/semgrepx"
+	"github.com/Excoriate/daggerx/pkg/shellcheckx"
+	"github.com/Excoriate/daggerx/pkg/slackx"
+	"github.com/Excoriate/daggerx/pkg/smoketestx"
+	"github.com/Excoriate/daggerx/pkg/sqlmigratex"
+	"github.com/Excoriate/daggerx/pkg/structuretestx"
+	"github.com/Excoriate/daggerx/pkg/terraformx"
+	"github.com/Excoriate/daggerx/pkg/tfsecx"
+	"github.com/Excoriate/daggerx/pkg/trivyx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/Excoriate/daggerx/pkg/webhookx"
+	"github.com/Excoriate/daggerx/pkg/yamlfmtx"
+	"github.com/Excoriate/daggerx/pkg/yamllintx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// builders are the command builders of the module, which must all implement builderx.Builder.
+var builders = []builderx.Builder{
+	apkox.NewApkoBuilder(),
+	apkox.NewSyncApkoBuilder(apkox.NewApkoBuilder()),
+	archivex.NewArchiveBuilder(),
+	archivex.NewExtractBuilder(),
+	buildxpkg.NewBuildxBuilder("."),
+	cachewarmx.NewRemoteCacheBuilder("s3://ci-cache/app", "go"),
+	checkovx.NewCheckovBuilder("."),
+	cosignx.NewSignBuilder("app"),
+	cosignx.NewVerifyBuilder("app"),
+	dependencytrackx.NewUploadBuilder("https://dtrack.example.com", "bom.json"),
+	dockerauthx.NewLoginBuilder(),
+	dotnetx.NewBuildBuilder("app.csproj"),
+	e2ex.NewPlaywrightBuilder(),
+	e2ex.NewCypressBuilder(),
+	gcsx.NewCopyBuilder("gs://bucket"),
+	ghapix.NewReleaseBuilder("org/app", "v1.0.0"),
+	ghapix.NewPRCommentBuilder("org/app", 1),
+	gitx.NewPushBuilder("origin"),
+	gitx.NewCommitBuilder("release"),
+	gitx.NewTagBuilder("v1.0.0"),
+	golangx.NewVulncheckBuilder(),
+	golangx.NewGoBuildBuilder(),
+	golangx.NewGoModBuilder(),
+	hadolintx.NewHadolintBuilder(),
+	helmx.NewDependencyUpdateBuilder("chart"),
+	helmx.NewRegistryLoginBuilder("ghcr.io"),
+	imagepromote.NewPromotionBuilder("registry.example.com/app@sha256:"+strings.Repeat("a", 64), "ghcr.io/org/app"),
+	kox.NewKoBuilder(),
+	loadtestx.NewK6Builder("script.js"),
+	melangex.NewMelangeBuilder(),
+	notaryx.NewSignBuilder("app"),
+	notaryx.NewVerifyBuilder("app"),
+	opax.NewConftestBuilder(),
+	opax.NewEvalBuilder("data.main.allow"),
+	phpx.NewComposerInstallBuilder(),
+	protosx.NewProtocBuilder(),
+	protosx.NewBufBuilder("proto"),
+	rubyx.NewBundlerBuilder(),
+	s3x.NewCopyBuilder("dist", "s3://bucket"),
+	semgrepx.NewSemgrepBuilder("."),
+	shellcheckx.NewShellcheckBuilder(),
+	slackx.NewSendBuilder(nil),
+	smoketestx.NewSmokeTestBuilder(),
+	sqlmigratex.NewGooseBuilder("db/migrations").ForMode(sqlmigratex.ModeApply),
+	sqlmigratex.NewAtlasBuilder("db/migrations").ForMode(sqlmigratex.ModeDryRun),
+	sqlmigratex.NewFlywayBuilder("db/migrations").ForMode(sqlmigratex.ModeStatus),
+	structuretestx.NewStructureTestBuilder("app"),
+	terraformx.NewWorkspaceBuilder("staging"),
+	tfsecx.NewTrivyConfigBuilder("."),
+	tfsecx.NewTfsecBuilder("."),
+	trivyx.NewTrivyBuilder("app"),
+	webhookx.NewSendBuilder(nil),
+	yamlfmtx.NewYamlfmtBuilder(),
+	yamllintx.NewYamllintBuilder(),
+}
+
+// recordingExecutor records the runs of the commands.
+type recordingExecutor struct {
+	cmd    types.DaggerCMD
+	env    []types.DaggerEnvVars
+	mounts []types.Mount
+}
+
+func (e *recordingExecutor) Run(_ context.Context, cmd types.DaggerCMD, env []types.DaggerEnvVars,
+	mounts []types.Mount) (*execx.Result, error) {
+	e.cmd, e.env, e.mounts = cmd, env, mounts
+	return &execx.Result{}, nil
+}
+
+func TestBuilders(t *testing.T) {
+	for _, b := range builders {
+		e := b.Explain()
+		t.Run(e.Builder, func(t *testing.T) {
+			_, err := b.BuildCommand()
+			assert.Equal(t, err, b.Validate())
+		})
+	}
+}
+
+func TestBuildersGolden(t *testing.T) {
+	g := goldenx.New(t)
+	for _, b := range builders {
+		e := b.Explain()
+		g.AssertString("builders/"+strings.ReplaceAll(e.Builder, " ", "-"), e.String())
+	}
+}
+
+func TestNode(t *testing.T) {
+	b := trivyx.NewTrivyBuilder("registry.example.com/app:v1")
+
+	node, err := builderx.Node("scan", b, "build")
+	require.NoError(t, err)
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, types.DaggerCMD(cmd), node.Command)
+	assert.Equal(t, b.Mounts(), node.Mounts)
+	assert.Equal(t, []string{"build"}, node.DependsOn)
+	assert.Empty(t, node.Secrets)
+
+	sign := cosignx.NewSignBuilder("registry.example.com/app@sha256:abc").WithIdentityTokenEnv("CI_ID_TOKEN")
+	node, err = builderx.Node("sign", sign)
+	require.NoError(t, err)
+	assert.Equal(t, []string{sign.Secrets()[0].Name}, node.Secrets)
+
+	_, err = builderx.Node("lint", hadolintx.NewHadolintBuilder())
+	assert.ErrorIs(t, err, errorsx.ErrMissingField)
+	assert.ErrorContains(t, err, "node lint: ")
+}
+
+func TestTask(t *testing.T) {
+	b := hadolintx.NewHadolintBuilder("Dockerfile")
+
+	task, err := builderx.Task("lint", b)
+	require.NoError(t, err)
+	assert.Equal(t, "lint", task.ID)
+	assert.Equal(t, "hadolint", task.Command[0])
+
+	_, err = builderx.Task("lint", hadolintx.NewHadolintBuilder())
+	assert.ErrorIs(t, err, errorsx.ErrMissingField)
+}
+
+func TestRun(t *testing.T) {
+	b := trivyx.NewTrivyBuilder("registry.example.com/app:v1")
+	executor := &recordingExecutor{}
+
+	res, err := builderx.Run(context.Background(), executor, b)
+	require.NoError(t, err)
+	assert.True(t, res.Succeeded())
+	assert.Equal(t, "trivy", executor.cmd[0])
+	assert.Equal(t, b.Mounts(), executor.mounts)
+
+	executor = &recordingExecutor{}
+	_, err = builderx.Run(context.Background(), executor, hadolintx.NewHadolintBuilder())
+	assert.ErrorIs(t, err, errorsx.ErrMissingField)
+	assert.Nil(t, executor.cmd)
+}
@@ -0,0 +1,269 @@
+// Package buildxpkg provides a builder for `docker buildx build`, with typed remote cache
+// backends (registry, GitHub Actions, S3 and local) rendered into valid --cache-from and
+// --cache-to values.
+//
+// Build secrets and SSH agents are declared with WithSecret and WithSSH; Secrets and Mounts report
+// what the executor must provide to the buildx container.
+//
+// Example usage:
+//
+//	cache := buildxpkg.RegistryCache("registry.example.com/app:buildcache").WithMode(buildxpkg.CacheModeMax)
+//
+//	builder := buildxpkg.NewBuildxBuilder(".").
+//	    WithTag("registry.example.com/app:v1").
+//	    WithPlatform("linux/amd64", "linux/arm64").
+//	    WithCacheFrom(cache).
+//	    WithCacheTo(cache).
+//	    WithPush(true)
+//
+//	cmd, err := builder.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+package buildxpkg
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/mapx"
+	"github.com/Excoriate/daggerx/pkg/timex"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the buildx builder in errorsx errors.
+const builderName = "buildx"
+
+// BuildxBuilder generates the docker buildx build command of an image.
+type BuildxBuilder struct {
+	// contextDir is the build context.
+	contextDir string
+
+	// file is the path of the Dockerfile.
+	file string
+
+	// tags are the image tags.
+	tags []string
+
+	// platforms are the target platforms.
+	platforms []string
+
+	// buildArgs are the build-time variables.
+	buildArgs map[string]string
+
+	// target is the target build stage.
+	target string
+
+	// push pushes the image to the registry.
+	push bool
+
+	// load loads the image into the docker daemon.
+	load bool
+
+	// cacheFrom are the cache backends imported from.
+	cacheFrom []*Cache
+
+	// cacheTo are the cache backends exported to.
+	cacheTo []*Cache
+
+	// secrets are the secrets exposed to the build.
+	secrets []buildSecret
+
+	// sshAgents are the SSH agents exposed to the build.
+	sshAgents []sshAgent
+
+	// extraArgs are additional arguments appended before the build context.
+	extraArgs []string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewBuildxBuilder creates a BuildxBuilder building the context 'contextDir'.
+func NewBuildxBuilder(contextDir string) *BuildxBuilder {
+	return &BuildxBuilder{contextDir: contextDir, buildArgs: map[string]string{}}
+}
+
+// WithFile sets the path of the Dockerfile.
+func (b *BuildxBuilder) WithFile(file string) *BuildxBuilder {
+	b.file = file
+	return b
+}
+
+// WithTag adds an image tag.
+func (b *BuildxBuilder) WithTag(tag string) *BuildxBuilder {
+	b.tags = append(b.tags, tag)
+	return b
+}
+
+// WithPlatform adds target platforms (e.g. "linux/arm64").
+func (b *BuildxBuilder) WithPlatform(platforms ...string) *BuildxBuilder {
+	b.platforms = append(b.platforms, platforms...)
+	return b
+}
+
+// WithBuildArg sets a build-time variable.
+func (b *BuildxBuilder) WithBuildArg(key, value string) *BuildxBuilder {
+	b.buildArgs[key] = value
+	return b
+}
+
+// WithSourceDateEpoch sets the SOURCE_DATE_EPOCH build argument to 't', which BuildKit uses as
+// the time of the image and layer metadata for reproducible builds.
+func (b *BuildxBuilder) WithSourceDateEpoch(t time.Time) *BuildxBuilder {
+	return b.WithBuildArg(timex.SourceDateEpochEnv, timex.Epoch(t))
+}
+
+// WithTarget sets the target build stage.
+func (b *BuildxBuilder) WithTarget(target string) *BuildxBuilder {
+	b.target = target
+	return b
+}
+
+// WithPush pushes the image to the registry.
+func (b *BuildxBuilder) WithPush(push bool) *BuildxBuilder {
+	b.push = push
+	return b
+}
+
+// WithLoad loads the image into the docker daemon.
+func (b *BuildxBuilder) WithLoad(load bool) *BuildxBuilder {
+	b.load = load
+	return b
+}
+
+// WithCacheFrom adds a cache backend the build imports from.
+func (b *BuildxBuilder) WithCacheFrom(cache *Cache) *BuildxBuilder {
+	b.cacheFrom = append(b.cacheFrom, cache)
+	return b
+}
+
+// WithCacheTo adds a cache backend the build exports to.
+func (b *BuildxBuilder) WithCacheTo(cache *Cache) *BuildxBuilder {
+	b.cacheTo = append(b.cacheTo, cache)
+	return b
+}
+
+// WithExtraArg adds an argument appended before the build context.
+func (b *BuildxBuilder) WithExtraArg(arg string) *BuildxBuilder {
+	b.extraArgs = append(b.extraArgs, arg)
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *BuildxBuilder) WithLogger(logger *slog.Logger) *BuildxBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks that the builder configuration is complete and consistent.
+func (b *BuildxBuilder) validate() error {
+	if b.contextDir == "" {
+		return errorsx.MissingField(builderName, "contextDir", "build context is required")
+	}
+
+	if b.push && b.load {
+		return errorsx.ConflictingOptions(builderName, []string{"push", "load"}, "push and load are mutually exclusive")
+	}
+
+	if b.load && len(b.platforms) > 1 {
+		return errorsx.ConflictingOptions(builderName, []string{"load", "platforms"},
+			"multi-platform images cannot be loaded into the docker daemon")
+	}
+
+	return b.validateSecrets()
+}
+
+// BuildCommand generates the docker buildx build command.
+//
+// Returns:
+//   - The docker buildx build command.
+//   - An error if the build context is missing, the options are inconsistent, or a cache backend or
+//     a secret is invalid.
+func (b *BuildxBuilder) BuildCommand() ([]string, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"docker", "buildx", "build"}
+
+	if b.file != "" {
+		cmd = append(cmd, "--file", b.file)
+	}
+
+	for _, t := range b.tags {
+		cmd = append(cmd, "--tag", t)
+	}
+
+	if len(b.platforms) > 0 {
+		cmd = append(cmd, "--platform", strings.Join(b.platforms, ","))
+	}
+
+	cmd = append(cmd, mapx.Flags("--build-arg", b.buildArgs, "=")...)
+
+	if b.target != "" {
+		cmd = append(cmd, "--target", b.target)
+	}
+
+	for _, c := range b.cacheFrom {
+		value, err := c.CacheFrom()
+		if err != nil {
+			return nil, err
+		}
+		cmd = append(cmd, "--cache-from", value)
+	}
+
+	for _, c := range b.cacheTo {
+		value, err := c.CacheTo()
+		if err != nil {
+			return nil, err
+		}
+		cmd = append(cmd, "--cache-to", value)
+	}
+
+	cmd = append(cmd, b.secretFlags()...)
+
+	if b.push {
+		cmd = append(cmd, "--push")
+	}
+
+	if b.load {
+		cmd = append(cmd, "--load")
+	}
+
+	cmd = append(cmd, b.extraArgs...)
+	cmd = append(cmd, b.contextDir)
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *BuildxBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Env returns the environment variables the generated command requires: none. The
+// credentials are secrets (see Secrets).
+func (b *BuildxBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the docker buildx build command.
+func (b *BuildxBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewBuildxBuilder(""), explainx.Generate("BuildCommand", b.BuildCommand))
+}
@@ -0,0 +1,63 @@
+package buildxpkg
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildxBuilderBuildCommand(t *testing.T) {
+	cache := RegistryCache("registry.example.com/app:buildcache").WithMode(CacheModeMax)
+
+	cmd, err := NewBuildxBuilder(".").
+		WithFile("build/Dockerfile").
+		WithTag("registry.example.com/app:v1").
+		WithPlatform("linux/amd64", "linux/arm64").
+		WithBuildArg("VERSION", "1.0.0").
+		WithBuildArg("COMMIT", "abc123").
+		WithSourceDateEpoch(time.Unix(1700000000, 0)).
+		WithTarget("runtime").
+		WithCacheFrom(cache).
+		WithCacheTo(cache).
+		WithPush(true).
+		BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"docker", "buildx", "build",
+		"--file", "build/Dockerfile",
+		"--tag", "registry.example.com/app:v1",
+		"--platform", "linux/amd64,linux/arm64",
+		"--build-arg", "COMMIT=abc123",
+		"--build-arg", "SOURCE_DATE_EPOCH=1700000000",
+		"--build-arg", "VERSION=1.0.0",
+		"--target", "runtime",
+		"--cache-from", "type=registry,ref=registry.example.com/app:buildcache",
+		"--cache-to", "type=registry,mode=max,ref=registry.example.com/app:buildcache",
+		"--push",
+		".",
+	}, cmd)
+}
+
+func TestBuildxBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *BuildxBuilder
+		kind    error
+	}{
+		{"Missing context", NewBuildxBuilder(""), errorsx.ErrMissingField},
+		{"Push and load", NewBuildxBuilder(".").WithPush(true).WithLoad(true), errorsx.ErrConflictingOptions},
+		{"Load multi-platform", NewBuildxBuilder(".").WithLoad(true).WithPlatform("linux/amd64", "linux/arm64"), errorsx.ErrConflictingOptions},
+		{"Invalid cache", NewBuildxBuilder(".").WithCacheTo(S3Cache("", "eu-west-1")), errorsx.ErrMissingField},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.BuildCommand()
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package buildxpkg
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// CacheType is a buildx cache backend type.
+type CacheType string
+
+const (
+	// CacheRegistry stores the cache in a registry image.
+	CacheRegistry CacheType = "registry"
+	// CacheGHA stores the cache in the GitHub Actions cache.
+	CacheGHA CacheType = "gha"
+	// CacheS3 stores the cache in an S3 bucket.
+	CacheS3 CacheType = "s3"
+	// CacheLocal stores the cache in a local directory.
+	CacheLocal CacheType = "local"
+)
+
+// CacheMode is the amount of layers exported to the cache.
+type CacheMode string
+
+const (
+	// CacheModeMin exports the layers of the final image only. It is the buildx default.
+	CacheModeMin CacheMode = "min"
+	// CacheModeMax exports the layers of every stage.
+	CacheModeMax CacheMode = "max"
+)
+
+// requiredCacheAttrs lists the attributes each backend requires, per direction.
+var requiredCacheAttrs = map[CacheType]struct{ from, to []string }{
+	CacheRegistry: {from: []string{"ref"}, to: []string{"ref"}},
+	CacheGHA:      {},
+	CacheS3:       {from: []string{"bucket", "region"}, to: []string{"bucket", "region"}},
+	CacheLocal:    {from: []string{"src"}, to: []string{"dest"}},
+}
+
+// exportOnlyCacheAttrs are the attributes only valid when exporting the cache.
+var exportOnlyCacheAttrs = map[string]bool{
+	"mode":              true,
+	"compression":       true,
+	"compression-level": true,
+	"force-compression": true,
+	"image-manifest":    true,
+	"oci-mediatypes":    true,
+	"ignore-error":      true,
+	"dest":              true,
+}
+
+// Cache describes a buildx cache backend, rendered into --cache-from and --cache-to values.
+type Cache struct {
+	// Type is the backend type.
+	Type CacheType
+	// Attrs holds the backend attributes.
+	Attrs map[string]string
+}
+
+// RegistryCache returns a cache stored in the registry image 'ref'.
+func RegistryCache(ref string) *Cache {
+	return &Cache{Type: CacheRegistry, Attrs: map[string]string{"ref": ref}}
+}
+
+// GHACache returns a cache stored in the GitHub Actions cache under 'scope'. An empty scope uses
+// the buildx default. The cache URL and token are read from the runner environment unless set
+// with WithAttr("url", ...) and WithAttr("token", ...).
+func GHACache(scope string) *Cache {
+	c := &Cache{Type: CacheGHA, Attrs: map[string]string{}}
+	if scope != "" {
+		c.Attrs["scope"] = scope
+	}
+
+	return c
+}
+
+// S3Cache returns a cache stored in the S3 bucket 'bucket' of 'region'. Credentials are read
+// from the AWS environment of the builder.
+func S3Cache(bucket, region string) *Cache {
+	return &Cache{Type: CacheS3, Attrs: map[string]string{"bucket": bucket, "region": region}}
+}
+
+// LocalCache returns a cache stored in the local directory 'dir', imported from and exported to
+// the same directory.
+func LocalCache(dir string) *Cache {
+	return &Cache{Type: CacheLocal, Attrs: map[string]string{"src": dir, "dest": dir}}
+}
+
+// WithAttr sets a backend attribute.
+func (c *Cache) WithAttr(key, value string) *Cache {
+	if c.Attrs == nil {
+		c.Attrs = map[string]string{}
+	}
+	c.Attrs[key] = value
+
+	return c
+}
+
+// WithMode sets the amount of layers exported to the cache.
+func (c *Cache) WithMode(mode CacheMode) *Cache {
+	return c.WithAttr("mode", string(mode))
+}
+
+// WithIgnoreError makes cache export failures non-fatal.
+func (c *Cache) WithIgnoreError(ignore bool) *Cache {
+	if ignore {
+		return c.WithAttr("ignore-error", "true")
+	}
+
+	delete(c.Attrs, "ignore-error")
+	return c
+}
+
+// validate checks the backend type and the attributes required for a direction.
+func (c *Cache) validate(field string, export bool) error {
+	required, ok := requiredCacheAttrs[c.Type]
+	if !ok {
+		return errorsx.Unsupported(builderName, field, c.Type, "unsupported cache type: %q", c.Type)
+	}
+
+	keys := required.from
+	if export {
+		keys = required.to
+	}
+
+	for _, k := range keys {
+		if c.Attrs[k] == "" {
+			return errorsx.MissingField(builderName, field, "%s cache requires the %s attribute", c.Type, k)
+		}
+	}
+
+	if mode, ok := c.Attrs["mode"]; ok && mode != string(CacheModeMin) && mode != string(CacheModeMax) {
+		return errorsx.InvalidValue(builderName, field, mode, "cache mode must be min or max, got %q", mode)
+	}
+
+	return nil
+}
+
+// render renders the type and the attributes valid for a direction, sorted by key.
+func (c *Cache) render(export bool) string {
+	keys := make([]string, 0, len(c.Attrs))
+	for k := range c.Attrs {
+		if c.Type == CacheLocal && ((export && k == "src") || (!export && k == "dest")) {
+			continue
+		}
+		if !export && exportOnlyCacheAttrs[k] {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := []string{"type=" + string(c.Type)}
+	for _, k := range keys {
+		parts = append(parts, k+"="+c.Attrs[k])
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// CacheFrom renders the cache into a --cache-from value, e.g. "type=registry,ref=example.com/app:cache".
+// Export-only attributes, such as the mode, are omitted.
+//
+// Returns:
+//   - The --cache-from value.
+//   - An error if the type is unsupported or a required attribute is missing.
+func (c *Cache) CacheFrom() (string, error) {
+	if err := c.validate("cacheFrom", false); err != nil {
+		return "", err
+	}
+
+	return c.render(false), nil
+}
+
+// CacheTo renders the cache into a --cache-to value, e.g. "type=registry,mode=max,ref=example.com/app:cache".
+//
+// Returns:
+//   - The --cache-to value.
+//   - An error if the type is unsupported, a required attribute is missing or the mode is invalid.
+func (c *Cache) CacheTo() (string, error) {
+	if err := c.validate("cacheTo", true); err != nil {
+		return "", err
+	}
+
+	return c.render(true), nil
+}
@@ -0,0 +1,74 @@
+package buildxpkg
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		cache    *Cache
+		wantFrom string
+		wantTo   string
+	}{
+		{
+			name:     "Registry",
+			cache:    RegistryCache("registry.example.com/app:cache").WithMode(CacheModeMax).WithIgnoreError(true),
+			wantFrom: "type=registry,ref=registry.example.com/app:cache",
+			wantTo:   "type=registry,ignore-error=true,mode=max,ref=registry.example.com/app:cache",
+		},
+		{
+			name:     "GHA",
+			cache:    GHACache("build-amd64"),
+			wantFrom: "type=gha,scope=build-amd64",
+			wantTo:   "type=gha,scope=build-amd64",
+		},
+		{
+			name:     "S3",
+			cache:    S3Cache("ci-cache", "eu-west-1").WithAttr("prefix", "app/").WithMode(CacheModeMin),
+			wantFrom: "type=s3,bucket=ci-cache,prefix=app/,region=eu-west-1",
+			wantTo:   "type=s3,bucket=ci-cache,mode=min,prefix=app/,region=eu-west-1",
+		},
+		{
+			name:     "Local",
+			cache:    LocalCache("/tmp/.buildx-cache"),
+			wantFrom: "type=local,src=/tmp/.buildx-cache",
+			wantTo:   "type=local,dest=/tmp/.buildx-cache",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, err := tt.cache.CacheFrom()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFrom, from)
+
+			to, err := tt.cache.CacheTo()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTo, to)
+		})
+	}
+}
+
+func TestCacheValidation(t *testing.T) {
+	_, err := RegistryCache("").CacheFrom()
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+
+	_, err = S3Cache("ci-cache", "").CacheTo()
+	assert.ErrorContains(t, err, "s3 cache requires the region attribute")
+
+	_, err = (&Cache{Type: CacheLocal, Attrs: map[string]string{"src": "/cache"}}).CacheTo()
+	field, _ := errorsx.FieldOf(err)
+	assert.Equal(t, "cacheTo", field)
+
+	_, err = RegistryCache("img:cache").WithMode("all").CacheTo()
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+
+	_, err = (&Cache{Type: "azblob"}).CacheFrom()
+	assert.True(t, errors.Is(err, errorsx.ErrUnsupported))
+}
@@ -0,0 +1,153 @@
+package buildxpkg
+
+import (
+	"path"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// DefaultSSHID is the SSH ID Dockerfiles use with `RUN --mount=type=ssh` when none is given.
+const DefaultSSHID = "default"
+
+// buildSecret is a secret exposed to `RUN --mount=type=secret,id=<id>` instructions.
+type buildSecret struct {
+	// id is the secret ID referenced by the Dockerfile.
+	id string
+	// ref describes how the secret is provided to the buildx container.
+	ref *secretsx.SecretRef
+}
+
+// sshAgent is an SSH agent socket exposed to `RUN --mount=type=ssh,id=<id>` instructions.
+type sshAgent struct {
+	// id is the SSH ID referenced by the Dockerfile.
+	id string
+	// socket is the host path of the agent socket. It is empty when the agent of the buildx
+	// container (SSH_AUTH_SOCK) is used.
+	socket string
+}
+
+// GetSSHSocketPath returns the conventional path of the SSH agent socket 'id' inside the buildx
+// container. If mntPrefix is empty, fixtures.MntPrefix is used.
+func GetSSHSocketPath(mntPrefix, id string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, "ssh", id+".sock")
+}
+
+// WithSecret exposes the secret 'ref' to the build under 'id'. File secrets are passed with
+// `src=`, environment secrets with `env=`; the secret itself must be provided to the buildx
+// container, see Secrets and Mounts.
+func (b *BuildxBuilder) WithSecret(id string, ref *secretsx.SecretRef) *BuildxBuilder {
+	b.secrets = append(b.secrets, buildSecret{id: id, ref: ref})
+	return b
+}
+
+// WithSSH exposes the SSH agent listening on the host socket 'socket' to the build under 'id'.
+// An empty id defaults to DefaultSSHID; an empty socket forwards the agent of the buildx
+// container (SSH_AUTH_SOCK). Host sockets are mounted at GetSSHSocketPath("", id).
+func (b *BuildxBuilder) WithSSH(id, socket string) *BuildxBuilder {
+	if id == "" {
+		id = DefaultSSHID
+	}
+	b.sshAgents = append(b.sshAgents, sshAgent{id: id, socket: socket})
+	return b
+}
+
+// Secrets returns the secrets the generated command requires, so they can be translated into
+// Dagger secrets.
+func (b *BuildxBuilder) Secrets() []*secretsx.SecretRef {
+	refs := make([]*secretsx.SecretRef, 0, len(b.secrets))
+	for _, s := range b.secrets {
+		refs = append(refs, s.ref)
+	}
+
+	return refs
+}
+
+// Mounts returns the mounts the generated command requires: the file secrets and the SSH agent
+// sockets.
+func (b *BuildxBuilder) Mounts() []types.Mount {
+	var mounts []types.Mount
+
+	for _, s := range b.secrets {
+		if s.ref == nil {
+			continue
+		}
+		if m, ok := s.ref.MountRequirement(); ok {
+			mounts = append(mounts, m)
+		}
+	}
+
+	for _, a := range b.sshAgents {
+		if a.socket != "" {
+			mounts = append(mounts, types.Mount{
+				Kind:   types.MountKindSocket,
+				Source: a.socket,
+				Target: GetSSHSocketPath("", a.id),
+			})
+		}
+	}
+
+	return mounts
+}
+
+// validateSecrets checks that the secrets and SSH agents are complete and their IDs unique.
+func (b *BuildxBuilder) validateSecrets() error {
+	ids := map[string]bool{}
+	for _, s := range b.secrets {
+		if s.id == "" {
+			return errorsx.MissingField(builderName, "secrets", "secret id is required")
+		}
+
+		if ids[s.id] {
+			return errorsx.ConflictingOptions(builderName, []string{"secrets"}, "secret id %s is declared twice", s.id)
+		}
+		ids[s.id] = true
+
+		if s.ref == nil {
+			return errorsx.MissingField(builderName, "secrets", "secret %s requires a reference", s.id)
+		}
+
+		if err := s.ref.Validate(); err != nil {
+			return err
+		}
+	}
+
+	sshIDs := map[string]bool{}
+	for _, a := range b.sshAgents {
+		if sshIDs[a.id] {
+			return errorsx.ConflictingOptions(builderName, []string{"ssh"}, "ssh id %s is declared twice", a.id)
+		}
+		sshIDs[a.id] = true
+	}
+
+	return nil
+}
+
+// secretFlags renders the --secret and --ssh flags.
+func (b *BuildxBuilder) secretFlags() []string {
+	var flags []string
+
+	for _, s := range b.secrets {
+		value := "id=" + s.id + ",env=" + s.ref.Target
+		if s.ref.Mount == secretsx.MountAsFile {
+			value = "id=" + s.id + ",src=" + s.ref.Target
+		}
+		flags = append(flags, "--secret", value)
+	}
+
+	for _, a := range b.sshAgents {
+		value := a.id
+		if a.socket != "" {
+			value += "=" + GetSSHSocketPath("", a.id)
+		}
+		flags = append(flags, "--ssh", value)
+	}
+
+	return flags
+}
@@ -0,0 +1,68 @@
+package buildxpkg
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildxBuilderSecrets(t *testing.T) {
+	npmrc := secretsx.FromFile("npmrc", "/home/ci/.npmrc").AsFile("/run/secrets/npmrc")
+	token := secretsx.FromEnv("github-token", "GITHUB_TOKEN")
+
+	b := NewBuildxBuilder(".").
+		WithSecret("npmrc", npmrc).
+		WithSecret("gh", token).
+		WithSSH("", "").
+		WithSSH("deploy", "/run/user/1000/ssh-agent.sock")
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"docker", "buildx", "build",
+		"--secret", "id=npmrc,src=/run/secrets/npmrc",
+		"--secret", "id=gh,env=GITHUB_TOKEN",
+		"--ssh", "default",
+		"--ssh", "deploy=/mnt/ssh/deploy.sock",
+		".",
+	}, cmd)
+
+	assert.Equal(t, []*secretsx.SecretRef{npmrc, token}, b.Secrets())
+	assert.Equal(t, []types.Mount{
+		{Kind: types.MountKindSecret, Source: "npmrc", Target: "/run/secrets/npmrc", ReadOnly: true},
+		{Kind: types.MountKindSocket, Source: "/run/user/1000/ssh-agent.sock", Target: "/mnt/ssh/deploy.sock"},
+	}, b.Mounts())
+}
+
+func TestBuildxBuilderSecretsValidation(t *testing.T) {
+	token := secretsx.FromEnv("github-token", "GITHUB_TOKEN")
+
+	tests := []struct {
+		name    string
+		builder *BuildxBuilder
+		kind    error
+	}{
+		{"Missing id", NewBuildxBuilder(".").WithSecret("", token), errorsx.ErrMissingField},
+		{"Missing reference", NewBuildxBuilder(".").WithSecret("gh", nil), errorsx.ErrMissingField},
+		{"Duplicate id", NewBuildxBuilder(".").WithSecret("gh", token).WithSecret("gh", token), errorsx.ErrConflictingOptions},
+		{"Invalid reference", NewBuildxBuilder(".").WithSecret("gh", secretsx.FromCommand("gh", "gh auth token")), errorsx.ErrUnsupported},
+		{"Duplicate ssh id", NewBuildxBuilder(".").WithSSH("", "").WithSSH(DefaultSSHID, "/tmp/agent.sock"), errorsx.ErrConflictingOptions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.BuildCommand()
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+		})
+	}
+}
+
+func TestGetSSHSocketPath(t *testing.T) {
+	assert.Equal(t, "/mnt/ssh/default.sock", GetSSHSocketPath("", DefaultSSHID))
+	assert.Equal(t, "/work/ssh/deploy.sock", GetSSHSocketPath("/work", "deploy"))
+}
@@ -0,0 +1,340 @@
+// Package cachewarmx provides a builder coordinating the export of cache volumes and directories
+// to object storage at the end of a pipeline, and their import at its start, for runners without
+// a persistent Dagger cache (e.g. ephemeral CI runners).
+//
+// Every entry is archived as a tar.gz (see archivex) with a sha256sum checksum file next to it,
+// under '<prefix>/<key>/<name>.tar.gz', in Amazon S3 (s3://) or Google Cloud Storage (gs://).
+// The import downloads and verifies every archive before extracting it; a missing or corrupted
+// archive is a cache miss, which the pipeline survives unless WithFailOnMiss is set.
+//
+// The generated scripts run the AWS CLI or gcloud; the container needs their credentials, which
+// WithSecret declares (e.g. the Secrets of an s3x.UploadBuilder).
+//
+// Example usage:
+//
+//	cache := cachewarmx.NewRemoteCacheBuilder("s3://acme-ci-cache/checkout", "go-"+goSumDigest).
+//	    WithVolume("go-mod", "go-mod", "/go/pkg/mod").
+//	    WithDirectory("go-build", "/root/.cache/go-build")
+//
+//	restore, err := cache.ImportCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	// Run restore at the start of the pipeline and cache.ExportCommand() at its end, both
+//	// with cache.Mounts().
+package cachewarmx
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/archivex"
+	"github.com/Excoriate/daggerx/pkg/builderx"
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the remote cache builder in errorsx errors.
+const builderName = "cachewarm"
+
+// WorkDir is the directory the generated scripts write the archives and checksums to.
+const WorkDir = "/tmp/daggerx-cachewarm"
+
+// Store is an object storage holding the remote cache.
+type Store string
+
+const (
+	// StoreS3 is Amazon S3, or an S3 compatible object storage, accessed with the AWS CLI.
+	StoreS3 Store = "s3"
+	// StoreGCS is Google Cloud Storage, accessed with gcloud storage.
+	StoreGCS Store = "gs"
+)
+
+var (
+	// prefixRegex matches the remote cache prefixes: an S3 or Cloud Storage bucket, and an
+	// optional object prefix.
+	prefixRegex = regexp.MustCompile(`^(s3|gs)://[a-z0-9][a-z0-9._-]{1,220}[a-z0-9](/[^\s'"]*)?$`)
+	// nameRegex matches the cache keys and entry names, used in object names.
+	nameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+)
+
+// Entry is a directory or cache volume exported to the remote cache.
+type Entry struct {
+	// Name is the name of the entry, unique in the cache (e.g. "go-mod").
+	Name string
+	// Path is the directory inside the container.
+	Path string
+	// Volume is the key of the cache volume mounted at Path. Empty for a plain directory.
+	Volume string
+}
+
+// RemoteCacheBuilder generates the scripts importing and exporting cache entries from and to
+// object storage.
+type RemoteCacheBuilder struct {
+	// prefix is the URI the cache objects are stored under.
+	prefix string
+
+	// key identifies the cache content (e.g. a digest of the lockfiles).
+	key string
+
+	// entries are the exported directories and cache volumes.
+	entries []Entry
+
+	// failOnMiss fails the import when an entry is missing or corrupted.
+	failOnMiss bool
+
+	// secrets are the credentials of the object storage.
+	secrets []*secretsx.SecretRef
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+var _ builderx.SecretBuilder = (*RemoteCacheBuilder)(nil)
+
+// NewRemoteCacheBuilder creates a RemoteCacheBuilder storing the cache 'key' (e.g. a digest of
+// the lockfiles, so a dependency change starts a new cache) under the URI 'prefix' (e.g.
+// "s3://acme-ci-cache/checkout", "gs://acme-ci-cache/checkout").
+func NewRemoteCacheBuilder(prefix, key string) *RemoteCacheBuilder {
+	return &RemoteCacheBuilder{prefix: strings.TrimSuffix(prefix, "/"), key: key}
+}
+
+// WithDirectory adds the directory 'dir' to the cache, as the entry 'name'.
+func (b *RemoteCacheBuilder) WithDirectory(name, dir string) *RemoteCacheBuilder {
+	b.entries = append(b.entries, Entry{Name: name, Path: dir})
+	return b
+}
+
+// WithVolume adds the cache volume 'volume', mounted at 'dir', to the cache, as the entry 'name'.
+func (b *RemoteCacheBuilder) WithVolume(name, volume, dir string) *RemoteCacheBuilder {
+	b.entries = append(b.entries, Entry{Name: name, Path: dir, Volume: volume})
+	return b
+}
+
+// WithFailOnMiss sets whether the import fails when an entry is missing or corrupted. Misses
+// are only reported by default.
+func (b *RemoteCacheBuilder) WithFailOnMiss(fail bool) *RemoteCacheBuilder {
+	b.failOnMiss = fail
+	return b
+}
+
+// WithSecret adds the secrets holding the credentials of the object storage (e.g. exposed as
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY).
+func (b *RemoteCacheBuilder) WithSecret(refs ...*secretsx.SecretRef) *RemoteCacheBuilder {
+	b.secrets = append(b.secrets, refs...)
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *RemoteCacheBuilder) WithLogger(logger *slog.Logger) *RemoteCacheBuilder {
+	b.logger = logger
+	return b
+}
+
+// Store returns the object storage of the cache, from the scheme of the prefix.
+func (b *RemoteCacheBuilder) Store() Store {
+	scheme, _, _ := strings.Cut(b.prefix, "://")
+	return Store(scheme)
+}
+
+// ArchiveURL returns the URI of the archive of the entry 'name'.
+func (b *RemoteCacheBuilder) ArchiveURL(name string) string {
+	return b.prefix + "/" + b.key + "/" + archiveName(name)
+}
+
+// ChecksumURL returns the URI of the checksum file of the entry 'name'.
+func (b *RemoteCacheBuilder) ChecksumURL(name string) string {
+	return b.ArchiveURL(name) + ".sha256"
+}
+
+// archiveName returns the file name of the archive of the entry 'name'.
+func archiveName(name string) string {
+	return name + ".tar.gz"
+}
+
+// validate checks the options of the cache.
+func (b *RemoteCacheBuilder) validate() error {
+	if !prefixRegex.MatchString(b.prefix) {
+		return errorsx.InvalidValue(builderName, "prefix", b.prefix,
+			"prefix must be an S3 or Cloud Storage URI (s3://bucket/prefix, gs://bucket/prefix), got %q", b.prefix)
+	}
+
+	if !nameRegex.MatchString(b.key) {
+		return errorsx.InvalidValue(builderName, "key", b.key, "invalid cache key: %q", b.key)
+	}
+
+	if len(b.entries) == 0 {
+		return errorsx.MissingField(builderName, "entries", "at least one directory or volume is required")
+	}
+
+	seen := map[string]bool{}
+	for _, e := range b.entries {
+		if !nameRegex.MatchString(e.Name) || seen[e.Name] {
+			return errorsx.InvalidValue(builderName, "entries", e.Name,
+				"entry names must be valid and unique, got %q", e.Name)
+		}
+		seen[e.Name] = true
+
+		if !path.IsAbs(e.Path) {
+			return errorsx.InvalidValue(builderName, "entries", e.Path, "entry %s needs an absolute path", e.Name)
+		}
+	}
+
+	for _, s := range b.secrets {
+		if err := s.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyCommand generates the command copying 'src' to 'dst' with the CLI of the store.
+func (b *RemoteCacheBuilder) copyCommand(src, dst string) string {
+	if b.Store() == StoreGCS {
+		return cmdx.ShellJoin([]string{"gcloud", "storage", "cp", src, dst})
+	}
+
+	return cmdx.ShellJoin([]string{"aws", "s3", "cp", src, dst, "--only-show-errors"})
+}
+
+// ImportCommand generates the script downloading, verifying and extracting every entry into its
+// directory, at the start of the pipeline. A missing or corrupted entry is reported on the
+// standard error, and fails the script with WithFailOnMiss.
+//
+// Returns:
+//   - The import command.
+//   - An error if the prefix, key or an entry is invalid, no entry is set, or a secret is
+//     invalid.
+func (b *RemoteCacheBuilder) ImportCommand() ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	steps := []string{"mkdir -p " + WorkDir}
+	for _, e := range b.entries {
+		archive := path.Join(WorkDir, archiveName(e.Name))
+
+		extract, err := archivex.NewExtractBuilder().WithArchive(archive).WithDestination(e.Path).ExtractCommand()
+		if err != nil {
+			return nil, err
+		}
+
+		miss := fmt.Sprintf("echo %s >&2", cmdx.ShellQuote("cachewarm: cache miss for "+e.Name))
+		if b.failOnMiss {
+			miss += "; exit 1"
+		}
+
+		steps = append(steps, fmt.Sprintf(
+			"if %s && %s && (cd %s && sha256sum -c --status %s); then %s && %s; else %s; fi",
+			b.copyCommand(b.ArchiveURL(e.Name), archive),
+			b.copyCommand(b.ChecksumURL(e.Name), archive+".sha256"),
+			WorkDir, cmdx.ShellQuote(archiveName(e.Name)+".sha256"),
+			"mkdir -p "+cmdx.ShellQuote(e.Path), cmdx.ShellJoin(extract), miss))
+	}
+
+	return b.script(steps), nil
+}
+
+// ExportCommand generates the script archiving every entry, writing its checksum file and
+// uploading both, at the end of the pipeline.
+//
+// Returns:
+//   - The export command.
+//   - An error if the prefix, key or an entry is invalid, no entry is set, or a secret is
+//     invalid.
+func (b *RemoteCacheBuilder) ExportCommand() ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	steps := []string{"mkdir -p " + WorkDir}
+	for _, e := range b.entries {
+		name := archiveName(e.Name)
+		archive := path.Join(WorkDir, name)
+
+		create, err := archivex.NewArchiveBuilder().
+			WithReproducible(false).
+			WithSourceDir(e.Path).
+			WithOutput(archive).
+			CreateCommand()
+		if err != nil {
+			return nil, err
+		}
+
+		steps = append(steps,
+			cmdx.ShellJoin(create),
+			fmt.Sprintf("(cd %s && sha256sum %s > %s)", WorkDir, cmdx.ShellQuote(name), cmdx.ShellQuote(name+".sha256")),
+			b.copyCommand(archive, b.ArchiveURL(e.Name)),
+			b.copyCommand(archive+".sha256", b.ChecksumURL(e.Name)),
+		)
+	}
+
+	return b.script(steps), nil
+}
+
+// script joins the steps into a sh command, removing the work directory at the end.
+func (b *RemoteCacheBuilder) script(steps []string) []string {
+	steps = append(steps, "rm -rf "+WorkDir)
+	cmd := []string{"sh", "-c", strings.Join(steps, " && ")}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd
+}
+
+// Mounts returns the mounts the scripts require: the cache volumes of the entries.
+func (b *RemoteCacheBuilder) Mounts() []types.Mount {
+	var mounts []types.Mount
+	for _, e := range b.entries {
+		if e.Volume != "" {
+			mounts = append(mounts, types.Mount{Kind: types.MountKindCache, Source: e.Volume, Target: e.Path})
+		}
+	}
+
+	return mounts
+}
+
+// Secrets returns the secrets the scripts require: the credentials of the object storage.
+func (b *RemoteCacheBuilder) Secrets() []*secretsx.SecretRef {
+	return append([]*secretsx.SecretRef(nil), b.secrets...)
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *RemoteCacheBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// BuildCommand generates the import script run at the start of the pipeline, as ImportCommand
+// does.
+func (b *RemoteCacheBuilder) BuildCommand() ([]string, error) {
+	return b.ImportCommand()
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *RemoteCacheBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the import and export commands.
+func (b *RemoteCacheBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewRemoteCacheBuilder("", ""),
+		explainx.Generate("ImportCommand", b.ImportCommand),
+		explainx.Generate("ExportCommand", b.ExportCommand),
+	)
+}
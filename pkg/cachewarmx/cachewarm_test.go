@@ -0,0 +1,152 @@
+package cachewarmx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteCacheBuilderURLs(t *testing.T) {
+	b := NewRemoteCacheBuilder("s3://acme-ci-cache/checkout/", "go-abc")
+	assert.Equal(t, StoreS3, b.Store())
+	assert.Equal(t, "s3://acme-ci-cache/checkout/go-abc/go-mod.tar.gz", b.ArchiveURL("go-mod"))
+	assert.Equal(t, "s3://acme-ci-cache/checkout/go-abc/go-mod.tar.gz.sha256", b.ChecksumURL("go-mod"))
+
+	assert.Equal(t, StoreGCS, NewRemoteCacheBuilder("gs://acme-ci-cache", "k").Store())
+}
+
+func TestRemoteCacheBuilderImportCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *RemoteCacheBuilder
+		want    string
+	}{
+		{
+			name:    "s3",
+			builder: NewRemoteCacheBuilder("s3://acme-ci-cache", "go-abc").WithVolume("go-mod", "go-mod", "/go/pkg/mod"),
+			want: "mkdir -p /tmp/daggerx-cachewarm && " +
+				"if aws s3 cp s3://acme-ci-cache/go-abc/go-mod.tar.gz /tmp/daggerx-cachewarm/go-mod.tar.gz " +
+				"--only-show-errors && aws s3 cp s3://acme-ci-cache/go-abc/go-mod.tar.gz.sha256 " +
+				"/tmp/daggerx-cachewarm/go-mod.tar.gz.sha256 --only-show-errors && " +
+				"(cd /tmp/daggerx-cachewarm && sha256sum -c --status go-mod.tar.gz.sha256); " +
+				"then mkdir -p /go/pkg/mod && tar -xzf /tmp/daggerx-cachewarm/go-mod.tar.gz --no-same-owner -C /go/pkg/mod; " +
+				"else echo 'cachewarm: cache miss for go-mod' >&2; fi && rm -rf /tmp/daggerx-cachewarm",
+		},
+		{
+			name: "gcs failing on miss",
+			builder: NewRemoteCacheBuilder("gs://acme-ci-cache", "node-abc").
+				WithDirectory("npm", "/root/.npm").
+				WithFailOnMiss(true),
+			want: "mkdir -p /tmp/daggerx-cachewarm && " +
+				"if gcloud storage cp gs://acme-ci-cache/node-abc/npm.tar.gz /tmp/daggerx-cachewarm/npm.tar.gz && " +
+				"gcloud storage cp gs://acme-ci-cache/node-abc/npm.tar.gz.sha256 /tmp/daggerx-cachewarm/npm.tar.gz.sha256 && " +
+				"(cd /tmp/daggerx-cachewarm && sha256sum -c --status npm.tar.gz.sha256); " +
+				"then mkdir -p /root/.npm && tar -xzf /tmp/daggerx-cachewarm/npm.tar.gz --no-same-owner -C /root/.npm; " +
+				"else echo 'cachewarm: cache miss for npm' >&2; exit 1; fi && rm -rf /tmp/daggerx-cachewarm",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.ImportCommand()
+			require.NoError(t, err)
+			assert.Equal(t, []string{"sh", "-c", tt.want}, cmd)
+		})
+	}
+}
+
+func TestRemoteCacheBuilderExportCommand(t *testing.T) {
+	cmd, err := NewRemoteCacheBuilder("s3://acme-ci-cache", "go-abc").
+		WithVolume("go-mod", "go-mod", "/go/pkg/mod").
+		WithDirectory("go-build", "/root/.cache/go-build").
+		ExportCommand()
+	require.NoError(t, err)
+	require.Len(t, cmd, 3)
+
+	steps := strings.Split(cmd[2], " && ")
+	assert.Equal(t, []string{
+		"mkdir -p /tmp/daggerx-cachewarm",
+		"tar -z -cf /tmp/daggerx-cachewarm/go-mod.tar.gz -C /go/pkg/mod .",
+		"(cd /tmp/daggerx-cachewarm",
+		"sha256sum go-mod.tar.gz > go-mod.tar.gz.sha256)",
+		"aws s3 cp /tmp/daggerx-cachewarm/go-mod.tar.gz s3://acme-ci-cache/go-abc/go-mod.tar.gz --only-show-errors",
+		"aws s3 cp /tmp/daggerx-cachewarm/go-mod.tar.gz.sha256 s3://acme-ci-cache/go-abc/go-mod.tar.gz.sha256 " +
+			"--only-show-errors",
+		"tar -z -cf /tmp/daggerx-cachewarm/go-build.tar.gz -C /root/.cache/go-build .",
+		"(cd /tmp/daggerx-cachewarm",
+		"sha256sum go-build.tar.gz > go-build.tar.gz.sha256)",
+		"aws s3 cp /tmp/daggerx-cachewarm/go-build.tar.gz s3://acme-ci-cache/go-abc/go-build.tar.gz --only-show-errors",
+		"aws s3 cp /tmp/daggerx-cachewarm/go-build.tar.gz.sha256 s3://acme-ci-cache/go-abc/go-build.tar.gz.sha256 " +
+			"--only-show-errors",
+		"rm -rf /tmp/daggerx-cachewarm",
+	}, steps)
+}
+
+func TestRemoteCacheBuilderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *RemoteCacheBuilder
+		wantErr error
+	}{
+		{
+			name:    "invalid prefix",
+			builder: NewRemoteCacheBuilder("https://cache.example.com", "k").WithDirectory("npm", "/root/.npm"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "invalid key",
+			builder: NewRemoteCacheBuilder("s3://acme-ci-cache", "go/abc").WithDirectory("npm", "/root/.npm"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "missing entries",
+			builder: NewRemoteCacheBuilder("s3://acme-ci-cache", "k"),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name: "duplicate entry",
+			builder: NewRemoteCacheBuilder("s3://acme-ci-cache", "k").
+				WithDirectory("npm", "/root/.npm").
+				WithDirectory("npm", "/app/node_modules"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "relative entry path",
+			builder: NewRemoteCacheBuilder("s3://acme-ci-cache", "k").WithDirectory("npm", ".npm"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name: "invalid secret",
+			builder: NewRemoteCacheBuilder("s3://acme-ci-cache", "k").
+				WithDirectory("npm", "/root/.npm").
+				WithSecret(&secretsx.SecretRef{}),
+			wantErr: errorsx.ErrMissingField,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.ImportCommand()
+			require.ErrorIs(t, err, tt.wantErr)
+
+			_, err = tt.builder.ExportCommand()
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestRemoteCacheBuilderMountsAndSecrets(t *testing.T) {
+	key := secretsx.FromEnv("aws-access-key-id", "AWS_ACCESS_KEY_ID")
+	b := NewRemoteCacheBuilder("s3://acme-ci-cache", "k").
+		WithVolume("go-mod", "go-mod", "/go/pkg/mod").
+		WithDirectory("go-build", "/root/.cache/go-build").
+		WithSecret(key)
+
+	assert.Equal(t, []types.Mount{{Kind: types.MountKindCache, Source: "go-mod", Target: "/go/pkg/mod"}}, b.Mounts())
+	assert.Equal(t, []*secretsx.SecretRef{key}, b.Secrets())
+}
@@ -0,0 +1,226 @@
+// Package cachex provides the cache shared by the helpers performing remote metadata lookups
+// (digest resolution, release lookups, keyring fetches), so a pipeline hits the network once per
+// value instead of once per call.
+//
+// Entries expire after a TTL. The cache is in-memory by default and, with WithDir, also persists
+// entries on disk, typically under GetCacheDir, so they survive across executions sharing a
+// Dagger cache volume.
+//
+// Example usage:
+//
+//	cache, err := cachex.NewCache(time.Hour).WithDir(cachex.GetCacheDir(fixtures.MntPrefix))
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	digest, err := containerx.ResolveDigestCached(ctx, cache, "cgr.dev/chainguard/static:latest", nil)
+package cachex
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/ioutilx"
+	"github.com/Excoriate/daggerx/pkg/timex"
+)
+
+// DefaultTTL is the time to live of the entries of a cache created with a non-positive TTL.
+const DefaultTTL = time.Hour
+
+// entry is a cached value, as stored in memory and on disk.
+type entry struct {
+	Key       string    `json:"key"`
+	Value     []byte    `json:"value"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Cache is a concurrency-safe TTL cache of byte values, optionally persisted on disk.
+// A nil *Cache is valid and caches nothing.
+type Cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	dir     string
+	entries map[string]entry
+	now     timex.Clock
+}
+
+// GetCacheDir returns the directory of the on-disk cache. If mntPrefix is empty, fixtures.MntPrefix is used.
+func GetCacheDir(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return filepath.Join(mntPrefix, "var", "cache", "daggerx")
+}
+
+// NewCache creates an in-memory cache whose entries expire after 'ttl'. A non-positive TTL
+// defaults to DefaultTTL.
+func NewCache(ttl time.Duration) *Cache {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	return &Cache{
+		ttl:     ttl,
+		entries: map[string]entry{},
+		now:     timex.System,
+	}
+}
+
+// WithDir persists the entries in 'dir', creating it if needed.
+//
+// Returns:
+//   - The cache.
+//   - An error if the directory cannot be created.
+func (c *Cache) WithDir(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory %s: %w", dir, err)
+	}
+
+	c.dir = dir
+
+	return c, nil
+}
+
+// TTL returns the time to live of the entries.
+func (c *Cache) TTL() time.Duration {
+	return c.ttl
+}
+
+// Get returns the value of 'key' if it is cached and not expired. Values found on disk are
+// loaded in memory.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+
+	if e, ok := c.entries[key]; ok {
+		if now.Before(e.ExpiresAt) {
+			return e.Value, true
+		}
+		delete(c.entries, key)
+	}
+
+	e, ok := c.readFile(key)
+	if !ok || !now.Before(e.ExpiresAt) {
+		return nil, false
+	}
+
+	c.entries[key] = e
+
+	return e.Value, true
+}
+
+// Set caches the value of 'key' for the TTL of the cache.
+//
+// Returns:
+//   - An error if the entry cannot be written on disk; the in-memory entry is kept regardless.
+func (c *Cache) Set(key string, value []byte) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := entry{
+		Key:       key,
+		Value:     append([]byte(nil), value...),
+		ExpiresAt: c.now().Add(c.ttl),
+	}
+	c.entries[key] = e
+
+	return c.writeFile(e)
+}
+
+// Delete removes the entry of 'key' from memory and disk.
+func (c *Cache) Delete(key string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+	if c.dir != "" {
+		_ = os.Remove(c.path(key))
+	}
+}
+
+// GetOrFetch returns the cached value of 'key', calling 'fetch' and caching its result on a miss.
+// Fetch errors are not cached, and failures to persist the value on disk are ignored.
+//
+// Returns:
+//   - The cached or fetched value.
+//   - The error returned by 'fetch'.
+func (c *Cache) GetOrFetch(ctx context.Context, key string, fetch func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	if value, ok := c.Get(key); ok {
+		return value, nil
+	}
+
+	value, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = c.Set(key, value)
+
+	return value, nil
+}
+
+// path returns the file of the on-disk entry of 'key'.
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// readFile reads the on-disk entry of 'key'. Unreadable or corrupted entries are treated as misses.
+func (c *Cache) readFile(key string) (entry, bool) {
+	if c.dir == "" {
+		return entry{}, false
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return entry{}, false
+	}
+
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil || e.Key != key {
+		return entry{}, false
+	}
+
+	return e, true
+}
+
+// writeFile atomically writes the on-disk entry.
+func (c *Cache) writeFile(e entry) error {
+	if c.dir == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to serialize cache entry %s: %w", e.Key, err)
+	}
+
+	if err := ioutilx.WriteFileAtomic(c.path(e.Key), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write cache entry %s: %w", e.Key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package cachex
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCache returns a cache whose clock is advanced by the returned function.
+func newTestCache(ttl time.Duration) (*Cache, func(time.Duration)) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewCache(ttl)
+	c.now = func() time.Time { return now }
+
+	return c, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestGetCacheDir(t *testing.T) {
+	assert.Equal(t, "/mnt/var/cache/daggerx", GetCacheDir(""))
+	assert.Equal(t, "/src/var/cache/daggerx", GetCacheDir("/src"))
+}
+
+func TestCacheTTL(t *testing.T) {
+	c, advance := newTestCache(time.Minute)
+	require.NoError(t, c.Set("digest", []byte("sha256:abc")))
+
+	value, ok := c.Get("digest")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("sha256:abc"), value)
+
+	advance(time.Minute)
+	_, ok = c.Get("digest")
+	assert.False(t, ok)
+
+	assert.Equal(t, DefaultTTL, NewCache(0).TTL())
+}
+
+func TestCacheOnDisk(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+
+	c, err := NewCache(time.Hour).WithDir(dir)
+	require.NoError(t, err)
+	require.NoError(t, c.Set("keyring", []byte("key")))
+
+	// A new cache on the same directory sees the entry.
+	other, err := NewCache(time.Hour).WithDir(dir)
+	require.NoError(t, err)
+	value, ok := other.Get("keyring")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("key"), value)
+
+	other.Delete("keyring")
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestCacheIgnoresCorruptedFiles(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewCache(time.Hour).WithDir(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(c.path("key"), []byte("{"), 0o600))
+	_, ok := c.Get("key")
+	assert.False(t, ok)
+}
+
+func TestGetOrFetch(t *testing.T) {
+	c, _ := newTestCache(time.Hour)
+	calls := 0
+	fetch := func(context.Context) ([]byte, error) {
+		calls++
+		return []byte("v1.2.3"), nil
+	}
+
+	for range 2 {
+		value, err := c.GetOrFetch(context.Background(), "release", fetch)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1.2.3"), value)
+	}
+	assert.Equal(t, 1, calls)
+
+	_, err := c.GetOrFetch(context.Background(), "failing", func(context.Context) ([]byte, error) {
+		return nil, errors.New("offline")
+	})
+	assert.EqualError(t, err, "offline")
+	_, ok := c.Get("failing")
+	assert.False(t, ok)
+}
+
+func TestNilCache(t *testing.T) {
+	var c *Cache
+	require.NoError(t, c.Set("key", []byte("value")))
+	_, ok := c.Get("key")
+	assert.False(t, ok)
+
+	value, err := c.GetOrFetch(context.Background(), "key", func(context.Context) ([]byte, error) {
+		return []byte("value"), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+}
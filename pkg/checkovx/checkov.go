@@ -0,0 +1,311 @@
+// Package checkovx provides a builder for the Checkov scans of infrastructure as code: the
+// `checkov` command of a terraform directory with its skipped checks and SARIF output, and the
+// evaluation of the SARIF report against a maximum severity, so the findings join the scans of
+// the run report (see reportx) and gate the pipeline (see policyx).
+//
+// Checks are skipped for the whole scan with WithSkipCheck, or for a resource with a skip
+// annotation in the terraform code (see SkipAnnotation).
+//
+// Example usage:
+//
+//	checkov := checkovx.NewCheckovBuilder("/mnt/infra").
+//	    WithSkipCheck("CKV_AWS_18").
+//	    WithMaxSeverity(policyx.SeverityMedium)
+//
+//	cmd, err := checkov.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	// Run cmd, then read the SARIF report at checkov.SARIFPath().
+//	if err := checkov.AddScan(report, sarif); err != nil {
+//	    // handle error
+//	}
+//	result, err := checkov.Evaluate(sarif)
+package checkovx
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the checkov builder in errorsx errors.
+const builderName = "checkov"
+
+// CheckovDefaultImage is the default container image providing checkov.
+const CheckovDefaultImage = "bridgecrew/checkov:3.2.334"
+
+// SARIFFileName is the name of the SARIF report checkov writes into its output directory.
+const SARIFFileName = "results_sarif.sarif"
+
+// CheckovFrameworks are the IaC frameworks of the scans.
+var CheckovFrameworks = []string{"terraform", "terraform_plan", "cloudformation", "kubernetes", "helm", "all"}
+
+// checkIDRegex matches checkov check IDs (e.g. "CKV_AWS_18", "CKV2_AWS_6").
+var checkIDRegex = regexp.MustCompile(`^CKV[0-9]*_[A-Z0-9]+_[0-9]+$`)
+
+// GetOutputDir returns the conventional directory of the checkov reports inside the container.
+// If mntPrefix is empty, fixtures.MntPrefix is used.
+func GetOutputDir(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, "checkov")
+}
+
+// SkipAnnotation returns the comment skipping the check 'id' for the resource it is placed in,
+// with the reason 'reason' (e.g. "#checkov:skip=CKV_AWS_18:Access logs are shipped elsewhere").
+func SkipAnnotation(id, reason string) string {
+	if reason == "" {
+		return "#checkov:skip=" + id
+	}
+
+	return "#checkov:skip=" + id + ":" + reason
+}
+
+// CheckovBuilder generates the checkov command of an IaC directory.
+type CheckovBuilder struct {
+	// dir is the scanned directory.
+	dir string
+
+	// framework is the IaC framework of the scan.
+	framework string
+
+	// checks are the only checks run. Empty runs every check.
+	checks []string
+
+	// skipChecks are the checks skipped for the whole scan.
+	skipChecks []string
+
+	// externalChecksDirs are directories of custom checks.
+	externalChecksDirs []string
+
+	// softFail exits successfully regardless of the failed checks.
+	softFail bool
+
+	// outputDir is the directory of the SARIF report.
+	outputDir string
+
+	// maxSeverity is the highest severity of the findings passing the evaluation.
+	maxSeverity policyx.Severity
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewCheckovBuilder creates a CheckovBuilder scanning the terraform directory 'dir', writing its
+// reports to GetOutputDir("") and failing the evaluation on high and critical findings.
+func NewCheckovBuilder(dir string) *CheckovBuilder {
+	return &CheckovBuilder{
+		dir:         dir,
+		framework:   "terraform",
+		outputDir:   GetOutputDir(""),
+		maxSeverity: policyx.SeverityMedium,
+	}
+}
+
+// WithFramework sets the IaC framework of the scan, one of CheckovFrameworks.
+func (b *CheckovBuilder) WithFramework(framework string) *CheckovBuilder {
+	b.framework = framework
+	return b
+}
+
+// WithCheck restricts the scan to the checks 'ids'.
+func (b *CheckovBuilder) WithCheck(ids ...string) *CheckovBuilder {
+	b.checks = append(b.checks, ids...)
+	return b
+}
+
+// WithSkipCheck skips the checks 'ids' for the whole scan.
+func (b *CheckovBuilder) WithSkipCheck(ids ...string) *CheckovBuilder {
+	b.skipChecks = append(b.skipChecks, ids...)
+	return b
+}
+
+// WithExternalChecksDir adds directories of custom checks.
+func (b *CheckovBuilder) WithExternalChecksDir(dirs ...string) *CheckovBuilder {
+	b.externalChecksDirs = append(b.externalChecksDirs, dirs...)
+	return b
+}
+
+// WithSoftFail sets whether checkov exits successfully regardless of the failed checks, leaving
+// the decision to Evaluate.
+func (b *CheckovBuilder) WithSoftFail(softFail bool) *CheckovBuilder {
+	b.softFail = softFail
+	return b
+}
+
+// WithOutputDir sets the directory of the SARIF report.
+func (b *CheckovBuilder) WithOutputDir(dir string) *CheckovBuilder {
+	b.outputDir = dir
+	return b
+}
+
+// WithMaxSeverity sets the highest severity of the findings passing the evaluation. It defaults
+// to medium.
+func (b *CheckovBuilder) WithMaxSeverity(severity policyx.Severity) *CheckovBuilder {
+	b.maxSeverity = severity
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *CheckovBuilder) WithLogger(logger *slog.Logger) *CheckovBuilder {
+	b.logger = logger
+	return b
+}
+
+// SARIFPath returns the path of the SARIF report.
+func (b *CheckovBuilder) SARIFPath() string {
+	return filepath.Join(b.outputDir, SARIFFileName)
+}
+
+// validate checks the options of the scan.
+func (b *CheckovBuilder) validate() error {
+	if b.dir == "" {
+		return errorsx.MissingField(builderName, "dir", "scanned directory is required")
+	}
+
+	if b.outputDir == "" {
+		return errorsx.MissingField(builderName, "outputDir", "output directory is required")
+	}
+
+	if !slices.Contains(CheckovFrameworks, b.framework) {
+		return errorsx.InvalidValue(builderName, "framework", b.framework, "unsupported checkov framework: %q", b.framework)
+	}
+
+	for _, id := range slices.Concat(b.checks, b.skipChecks) {
+		if !checkIDRegex.MatchString(id) {
+			return errorsx.InvalidValue(builderName, "checks", id, "invalid checkov check ID: %q", id)
+		}
+	}
+
+	for _, id := range b.skipChecks {
+		if slices.Contains(b.checks, id) {
+			return errorsx.ConflictingOptions(builderName, []string{"checks", "skipChecks"},
+				"check %s is both run and skipped", id)
+		}
+	}
+
+	return nil
+}
+
+// BuildCommand generates the checkov command, writing the CLI output and the SARIF report.
+//
+// Returns:
+//   - The checkov command.
+//   - An error if the directory or output directory is missing, the framework is unsupported, a
+//     check ID is invalid, or a check is both run and skipped.
+func (b *CheckovBuilder) BuildCommand() ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"checkov", "-d", b.dir, "--framework", b.framework}
+	if len(b.checks) > 0 {
+		cmd = append(cmd, "--check", strings.Join(b.checks, ","))
+	}
+
+	if len(b.skipChecks) > 0 {
+		cmd = append(cmd, "--skip-check", strings.Join(b.skipChecks, ","))
+	}
+
+	for _, d := range b.externalChecksDirs {
+		cmd = append(cmd, "--external-checks-dir", d)
+	}
+
+	if b.softFail {
+		cmd = append(cmd, "--soft-fail")
+	}
+
+	cmd = append(cmd, "--compact", "-o", "cli", "-o", "sarif", "--output-file-path", b.outputDir)
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Findings parses the SARIF report 'sarif' of the scan.
+//
+// Returns:
+//   - The findings, located by file and line.
+//   - An error if the report can't be parsed.
+func (b *CheckovBuilder) Findings(sarif []byte) ([]policyx.Finding, error) {
+	return policyx.ParseSARIFReport(sarif)
+}
+
+// Evaluate parses the SARIF report 'sarif' and fails on the findings more severe than the
+// maximum severity.
+//
+// Returns:
+//   - The result of the evaluation.
+//   - An error if the report can't be parsed.
+func (b *CheckovBuilder) Evaluate(sarif []byte) (policyx.Result, error) {
+	findings, err := b.Findings(sarif)
+	if err != nil {
+		return policyx.Result{}, err
+	}
+
+	return policyx.NewPolicy().WithMaxSeverity(b.maxSeverity).Evaluate(policyx.Input{Findings: findings}), nil
+}
+
+// AddScan parses the SARIF report 'sarif' and adds its findings to 'report', as a checkov scan
+// of the scanned directory.
+//
+// Returns:
+//   - An error if the report can't be parsed.
+func (b *CheckovBuilder) AddScan(report *reportx.Report, sarif []byte) error {
+	findings, err := b.Findings(sarif)
+	if err != nil {
+		return err
+	}
+
+	report.AddScan(builderName, b.dir, findings)
+
+	return nil
+}
+
+// Artifacts returns the artifacts of the scan: the SARIF report.
+func (b *CheckovBuilder) Artifacts() []artifactx.Artifact {
+	return []artifactx.Artifact{{Kind: artifactx.KindReport, Path: b.SARIFPath()}}
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *CheckovBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *CheckovBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *CheckovBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the checkov command.
+func (b *CheckovBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewCheckovBuilder(""), explainx.Generate("BuildCommand", b.BuildCommand))
+}
@@ -0,0 +1,142 @@
+package checkovx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSARIF = `{"version": "2.1.0", "runs": [{
+	"tool": {"driver": {"name": "Checkov", "rules": [{"id": "CKV_AWS_18"}, {"id": "CKV_AWS_144"}]}},
+	"results": [
+		{"ruleId": "CKV_AWS_18", "level": "error", "locations": [{"physicalLocation": {
+			"artifactLocation": {"uri": "s3.tf"}, "region": {"startLine": 1}}}]},
+		{"ruleId": "CKV_AWS_144", "level": "warning", "locations": [{"physicalLocation": {
+			"artifactLocation": {"uri": "s3.tf"}, "region": {"startLine": 1}}}]}
+	]
+}]}`
+
+func TestGetOutputDir(t *testing.T) {
+	assert.Equal(t, "/mnt/checkov", GetOutputDir(""))
+	assert.Equal(t, "/work/checkov", GetOutputDir("/work"))
+}
+
+func TestSkipAnnotation(t *testing.T) {
+	assert.Equal(t, "#checkov:skip=CKV_AWS_18:Logs are shipped elsewhere",
+		SkipAnnotation("CKV_AWS_18", "Logs are shipped elsewhere"))
+	assert.Equal(t, "#checkov:skip=CKV_AWS_18", SkipAnnotation("CKV_AWS_18", ""))
+}
+
+func TestCheckovBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *CheckovBuilder
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "defaults",
+			builder: NewCheckovBuilder("/mnt/infra"),
+			want: []string{
+				"checkov", "-d", "/mnt/infra", "--framework", "terraform",
+				"--compact", "-o", "cli", "-o", "sarif", "--output-file-path", "/mnt/checkov",
+			},
+		},
+		{
+			name: "checks and options",
+			builder: NewCheckovBuilder("/mnt/infra").
+				WithFramework("terraform_plan").
+				WithCheck("CKV_AWS_18", "CKV2_AWS_6").
+				WithSkipCheck("CKV_AWS_144").
+				WithExternalChecksDir("/mnt/checks").
+				WithSoftFail(true).
+				WithOutputDir("/mnt/reports"),
+			want: []string{
+				"checkov", "-d", "/mnt/infra", "--framework", "terraform_plan",
+				"--check", "CKV_AWS_18,CKV2_AWS_6", "--skip-check", "CKV_AWS_144",
+				"--external-checks-dir", "/mnt/checks", "--soft-fail",
+				"--compact", "-o", "cli", "-o", "sarif", "--output-file-path", "/mnt/reports",
+			},
+		},
+		{
+			name:    "missing directory",
+			builder: NewCheckovBuilder(""),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "missing output directory",
+			builder: NewCheckovBuilder("/mnt/infra").WithOutputDir(""),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "unsupported framework",
+			builder: NewCheckovBuilder("/mnt/infra").WithFramework("pulumi"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "invalid check ID",
+			builder: NewCheckovBuilder("/mnt/infra").WithSkipCheck("AWS_18"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "check run and skipped",
+			builder: NewCheckovBuilder("/mnt/infra").WithCheck("CKV_AWS_18").WithSkipCheck("CKV_AWS_18"),
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestCheckovBuilderEvaluate(t *testing.T) {
+	result, err := NewCheckovBuilder("/mnt/infra").Evaluate([]byte(testSARIF))
+	require.NoError(t, err)
+	assert.False(t, result.Passed)
+	require.Len(t, result.Violations, 1)
+	assert.Equal(t, policyx.RuleMaxSeverity, result.Violations[0].Rule)
+	assert.Equal(t, "CKV_AWS_18 in s3.tf:1 has severity high, above the maximum of medium",
+		result.Violations[0].Message)
+
+	result, err = NewCheckovBuilder("/mnt/infra").WithMaxSeverity(policyx.SeverityHigh).Evaluate([]byte(testSARIF))
+	require.NoError(t, err)
+	assert.True(t, result.Passed)
+
+	_, err = NewCheckovBuilder("/mnt/infra").Evaluate([]byte("{"))
+	assert.Error(t, err)
+}
+
+func TestCheckovBuilderAddScan(t *testing.T) {
+	report := reportx.NewReport("iac")
+	require.NoError(t, NewCheckovBuilder("/mnt/infra").AddScan(report, []byte(testSARIF)))
+
+	require.Len(t, report.Scans, 1)
+	assert.Equal(t, "checkov", report.Scans[0].Scanner)
+	assert.Equal(t, "/mnt/infra", report.Scans[0].Target)
+	assert.Equal(t, map[string]int{"high": 1, "medium": 1}, report.Scans[0].Counts)
+
+	assert.Error(t, NewCheckovBuilder("/mnt/infra").AddScan(report, []byte("{")))
+	assert.Len(t, report.Scans, 1)
+}
+
+func TestCheckovBuilderArtifacts(t *testing.T) {
+	b := NewCheckovBuilder("/mnt/infra")
+	assert.Equal(t, "/mnt/checkov/results_sarif.sarif", b.SARIFPath())
+	assert.Equal(t, []artifactx.Artifact{{Kind: artifactx.KindReport, Path: "/mnt/checkov/results_sarif.sarif"}},
+		b.Artifacts())
+}
@@ -0,0 +1,298 @@
+// Package checksumx provides the digest utilities shared by installers, cache keys and artifact
+// reports: SHA-256, SHA-512 and BLAKE2b hashing of byte slices, streams, files and directory
+// trees, with digests formatted as OCI digests ("sha256:<hex>").
+//
+// Directory trees are hashed merkle-style: a file hashes its content, a symbolic link its target,
+// and a directory the sorted list of its entries with their types, names and digests. The digest
+// of a tree therefore changes with any content, name or structure change, and only with those.
+//
+// Example usage:
+//
+//	d, err := checksumx.File(checksumx.SHA256, "/mnt/image.tar")
+//	if err != nil {
+//	    // handle error
+//	}
+//	fmt.Println(d) // sha256:9f86d08...
+//
+//	if err := checksumx.VerifyFile("/mnt/terraform.zip", expected); err != nil {
+//	    // checksum mismatch
+//	}
+package checksumx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"golang.org/x/crypto/blake2b"
+)
+
+// builderName identifies the checksum helpers in errorsx errors.
+const builderName = "checksum"
+
+// Algorithm is a digest algorithm, named as in OCI digests.
+type Algorithm string
+
+const (
+	// SHA256 is the SHA-256 algorithm, the default algorithm of OCI digests.
+	SHA256 Algorithm = "sha256"
+	// SHA512 is the SHA-512 algorithm.
+	SHA512 Algorithm = "sha512"
+	// BLAKE2b256 is the BLAKE2b algorithm with a 256-bit digest.
+	BLAKE2b256 Algorithm = "blake2b-256"
+	// BLAKE2b512 is the BLAKE2b algorithm with a 512-bit digest, as computed by b2sum.
+	BLAKE2b512 Algorithm = "blake2b-512"
+)
+
+// ErrMismatch reports content whose digest differs from the expected one.
+var ErrMismatch = errors.New("checksum mismatch")
+
+// Algorithms returns the supported algorithms.
+func Algorithms() []Algorithm {
+	return []Algorithm{SHA256, SHA512, BLAKE2b256, BLAKE2b512}
+}
+
+// New returns a new hash of the algorithm.
+//
+// Returns:
+//   - The hash.
+//   - An error if the algorithm is unsupported.
+func (a Algorithm) New() (hash.Hash, error) {
+	switch a {
+	case SHA256:
+		return sha256.New(), nil
+	case SHA512:
+		return sha512.New(), nil
+	case BLAKE2b256:
+		return blake2b.New256(nil)
+	case BLAKE2b512:
+		return blake2b.New512(nil)
+	default:
+		return nil, errorsx.Unsupported(builderName, "algorithm", a, "unsupported digest algorithm: %q", a)
+	}
+}
+
+// size returns the size in bytes of the digests of the algorithm.
+func (a Algorithm) size() int {
+	switch a {
+	case SHA512, BLAKE2b512:
+		return 64
+	default:
+		return 32
+	}
+}
+
+// Digest is the digest of some content.
+type Digest struct {
+	// Algorithm is the algorithm of the digest.
+	Algorithm Algorithm
+	// Hex is the lowercase hexadecimal encoding of the digest.
+	Hex string
+}
+
+// String returns the OCI digest ("<algorithm>:<hex>").
+func (d Digest) String() string {
+	return string(d.Algorithm) + ":" + d.Hex
+}
+
+// ParseDigest parses an OCI digest ("sha256:<hex>") of a supported algorithm.
+//
+// Returns:
+//   - The digest, with its hexadecimal encoding lowercased.
+//   - An error if the digest is malformed or its algorithm unsupported.
+func ParseDigest(s string) (Digest, error) {
+	alg, encoded, ok := strings.Cut(s, ":")
+	if !ok {
+		return Digest{}, errorsx.InvalidValue(builderName, "digest", s, "digest must be <algorithm>:<hex>: %q", s)
+	}
+
+	d := Digest{Algorithm: Algorithm(alg), Hex: strings.ToLower(encoded)}
+	if _, err := d.Algorithm.New(); err != nil {
+		return Digest{}, err
+	}
+
+	if raw, err := hex.DecodeString(d.Hex); err != nil || len(raw) != d.Algorithm.size() {
+		return Digest{}, errorsx.InvalidValue(builderName, "digest", s,
+			"%s digests hold %d hexadecimal characters: %q", alg, 2*d.Algorithm.size(), s)
+	}
+
+	return d, nil
+}
+
+// Bytes returns the digest of 'data'.
+func Bytes(alg Algorithm, data []byte) (Digest, error) {
+	return Reader(alg, bytes.NewReader(data))
+}
+
+// Reader returns the digest of the content read from 'r', until EOF.
+func Reader(alg Algorithm, r io.Reader) (Digest, error) {
+	h, err := alg.New()
+	if err != nil {
+		return Digest{}, err
+	}
+
+	if _, err := io.Copy(h, r); err != nil {
+		return Digest{}, fmt.Errorf("failed to hash content: %w", err)
+	}
+
+	return Digest{Algorithm: alg, Hex: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// File returns the digest of the content of the file at 'path'.
+func File(alg Algorithm, path string) (Digest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Digest{}, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	d, err := Reader(alg, f)
+	if err != nil {
+		return Digest{}, fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+
+	return d, nil
+}
+
+// Dir returns the merkle digest of the directory tree at 'root'. Symbolic links are not
+// followed, and file permissions and times are left out.
+func Dir(alg Algorithm, root string) (Digest, error) {
+	info, err := os.Lstat(root)
+	if err != nil {
+		return Digest{}, fmt.Errorf("failed to stat %s: %w", root, err)
+	}
+
+	if !info.IsDir() {
+		return Digest{}, errorsx.InvalidValue(builderName, "root", root, "%s is not a directory", root)
+	}
+
+	return dirDigest(alg, root)
+}
+
+// dirDigest hashes the sorted entries of the directory 'dir', recursively.
+func dirDigest(alg Algorithm, dir string) (Digest, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return Digest{}, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var listing bytes.Buffer
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+
+		var (
+			kind string
+			d    Digest
+		)
+
+		switch {
+		case e.IsDir():
+			kind = "dir"
+			d, err = dirDigest(alg, path)
+		case e.Type()&os.ModeSymlink != 0:
+			kind = "link"
+			var target string
+			if target, err = os.Readlink(path); err == nil {
+				d, err = Bytes(alg, []byte(filepath.ToSlash(target)))
+			}
+		default:
+			kind = "file"
+			d, err = File(alg, path)
+		}
+		if err != nil {
+			return Digest{}, err
+		}
+
+		fmt.Fprintf(&listing, "%s %s\x00%s\n", kind, e.Name(), d.Hex)
+	}
+
+	return Bytes(alg, listing.Bytes())
+}
+
+// Verify checks that the content read from 'r' has the digest 'expected' ("sha256:<hex>").
+//
+// Returns:
+//   - An error wrapping ErrMismatch if the digests differ, or an error if 'expected' is invalid
+//     or the content cannot be read.
+func Verify(r io.Reader, expected string) error {
+	want, err := ParseDigest(expected)
+	if err != nil {
+		return err
+	}
+
+	got, err := Reader(want.Algorithm, r)
+	if err != nil {
+		return err
+	}
+
+	if got.Hex != want.Hex {
+		return fmt.Errorf("%w: got %s, want %s", ErrMismatch, got, want)
+	}
+
+	return nil
+}
+
+// VerifyFile checks that the file at 'path' has the digest 'expected', see Verify.
+func VerifyFile(path, expected string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	if err := Verify(f, expected); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
+}
+
+// checkTools maps the algorithms to the coreutils tool checking their digests.
+var checkTools = map[Algorithm]string{
+	SHA256:     "sha256sum",
+	SHA512:     "sha512sum",
+	BLAKE2b512: "b2sum",
+}
+
+// VerifyCommand returns the shell command checking that the file at 'path' has the digest
+// 'expected' inside a container, with the coreutils checksum tools. It is meant for the
+// generated install scripts, which download files in the container.
+//
+// Returns:
+//   - The command, failing with a non-zero status on mismatch.
+//   - An error if 'expected' or 'path' is invalid, or the algorithm has no coreutils tool
+//     (blake2b-256).
+func VerifyCommand(path, expected string) (string, error) {
+	if path == "" || strings.ContainsAny(path, "'\n") {
+		return "", errorsx.InvalidValue(builderName, "path", path, "invalid path to verify: %q", path)
+	}
+
+	d, err := ParseDigest(expected)
+	if err != nil {
+		return "", err
+	}
+
+	tool, ok := checkTools[d.Algorithm]
+	if !ok {
+		return "", errorsx.Unsupported(builderName, "algorithm", d.Algorithm,
+			"no checksum tool verifies %s digests", d.Algorithm)
+	}
+
+	return fmt.Sprintf("echo '%s  %s' | %s -c -", d.Hex, path, tool), nil
+}
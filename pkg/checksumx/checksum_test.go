@@ -0,0 +1,119 @@
+package checksumx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		alg  Algorithm
+		want string
+	}{
+		{alg: SHA256, want: "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{alg: SHA512, want: "sha512:9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca7" +
+			"2323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043"},
+		{alg: BLAKE2b256, want: "blake2b-256:324dcf027dd4a30a932c441f365a25e86b173defa4b8e58948253471b81b72cf"},
+		{alg: BLAKE2b512, want: "blake2b-512:e4cfa39a3d37be31c59609e807970799caa68a19bfaa15135f165085e01d41a6" +
+			"5ba1e1b146aeb6bd0092b49eac214c103ccfa3a365954bbbe52f74a2b3620c94"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.alg), func(t *testing.T) {
+			d, err := Bytes(tt.alg, []byte("hello"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, d.String())
+
+			parsed, err := ParseDigest(tt.want)
+			require.NoError(t, err)
+			assert.Equal(t, d, parsed)
+		})
+	}
+
+	_, err := Bytes("md5", []byte("hello"))
+	assert.ErrorIs(t, err, errorsx.ErrUnsupported)
+}
+
+func TestParseDigest(t *testing.T) {
+	d, err := ParseDigest("sha256:" + strings.Repeat("AB", 32))
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("ab", 32), d.Hex)
+
+	for _, s := range []string{"sha256", "sha256:abc", "sha256:" + strings.Repeat("zz", 32), "sha512:" + strings.Repeat("ab", 32)} {
+		_, err := ParseDigest(s)
+		assert.ErrorIs(t, err, errorsx.ErrInvalidValue, s)
+	}
+
+	_, err = ParseDigest("md5:" + strings.Repeat("ab", 16))
+	assert.ErrorIs(t, err, errorsx.ErrUnsupported)
+}
+
+func TestFileAndVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "asset")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0o644))
+
+	d, err := File(SHA256, path)
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", d.String())
+
+	require.NoError(t, VerifyFile(path, d.String()))
+
+	err = VerifyFile(path, "sha256:"+strings.Repeat("00", 32))
+	assert.ErrorIs(t, err, ErrMismatch)
+
+	_, err = File(SHA256, filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestDir(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sub", "empty"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "sub", "b.txt"), []byte("b"), 0o644))
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(root, "link")))
+
+	first, err := Dir(SHA256, root)
+	require.NoError(t, err)
+
+	again, err := Dir(SHA256, root)
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+
+	copyRoot := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(copyRoot, "sub", "empty"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(copyRoot, "sub", "b.txt"), []byte("b"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(copyRoot, "a.txt"), []byte("a"), 0o600))
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(copyRoot, "link")))
+
+	copied, err := Dir(SHA256, copyRoot)
+	require.NoError(t, err)
+	assert.Equal(t, first, copied, "permissions and creation order are left out")
+
+	require.NoError(t, os.Remove(filepath.Join(root, "sub", "empty")))
+	changed, err := Dir(SHA256, root)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, changed, "structure changes change the digest")
+
+	_, err = Dir(SHA256, filepath.Join(root, "a.txt"))
+	assert.ErrorIs(t, err, errorsx.ErrInvalidValue)
+}
+
+func TestVerifyCommand(t *testing.T) {
+	hex := strings.Repeat("ab", 64)
+
+	cmd, err := VerifyCommand("/tmp/terraform.zip", "blake2b-512:"+hex)
+	require.NoError(t, err)
+	assert.Equal(t, "echo '"+hex+"  /tmp/terraform.zip' | b2sum -c -", cmd)
+
+	_, err = VerifyCommand("/tmp/terraform.zip", "blake2b-256:"+strings.Repeat("ab", 32))
+	assert.ErrorIs(t, err, errorsx.ErrUnsupported)
+
+	_, err = VerifyCommand("/tmp/it's.zip", "sha512:"+hex)
+	assert.ErrorIs(t, err, errorsx.ErrInvalidValue)
+}
@@ -80,7 +80,12 @@ func GenerateCommand(command string, args ...string) (*types.DaggerCMD, error) {
 		// Handle arguments with spaces
 		if strings.Contains(arg, " ") && !strings.HasPrefix(arg, "'") && !strings.HasPrefix(arg, "\"") {
 			parts := strings.SplitN(arg, " ", 2)
-			cmdWithArgs = append(cmdWithArgs, parts[0], parts[1])
+			for _, part := range parts {
+				// A leading or trailing space must not yield an empty argument.
+				if part != "" {
+					cmdWithArgs = append(cmdWithArgs, part)
+				}
+			}
 		} else {
 			cmdWithArgs = append(cmdWithArgs, arg)
 		}
@@ -114,6 +119,9 @@ func ConvertCMDToString(cmd *types.DaggerCMD) string {
 // GenerateShCommand generates a command wrapped for execution using `sh -c`.
 // It ensures that arguments with spaces are correctly handled.
 //
+// The words are joined as is, so sh interprets the shell syntax they hold: `&&` chains commands
+// and `$VAR` expands. Use GenerateQuotedShCommand for words rendered from untrusted input.
+//
 // Parameters:
 //   - command: A string representing the main command to be executed.
 //   - args: A variadic slice of strings representing the arguments for the command.
@@ -150,6 +158,68 @@ func GenerateShCommand(command string, args ...string) (string, error) {
 	return fmt.Sprintf("sh -c \"%s\"", cmdString), nil
 }
 
+// GenerateQuotedShCommand generates a command wrapped for execution using `sh -c`, as
+// GenerateShCommand does, for words rendered from untrusted input. The command and each argument
+// are single-quoted when needed (see ShellQuote), and the script is escaped inside its double
+// quotes, so every word reaches the command as is, without splitting, globbing or expansion.
+// Shell operators such as `&&` are passed as plain arguments too.
+//
+// Parameters:
+//   - command: A string representing the main command to be executed.
+//   - args: A variadic slice of strings representing the arguments for the command.
+//
+// Returns:
+//   - A string containing the complete command wrapped for `sh -c` execution.
+//   - An error if the main command is empty, or if the command or an argument contains a NUL
+//     byte, which no command argument can hold.
+//
+// Example:
+//
+//	cmd, err := GenerateQuotedShCommand("echo", "Hello, World!", "$HOME")
+//	if err != nil {
+//	    // handle error
+//	}
+//	fmt.Println(cmd) // Output: sh -c "echo 'Hello, World!' '\$HOME'"
+func GenerateQuotedShCommand(command string, args ...string) (string, error) {
+	if command == "" {
+		return "", fmt.Errorf("command cannot be empty")
+	}
+
+	if err := validateShWord(command); err != nil {
+		return "", err
+	}
+
+	cmdWithArgs := types.DaggerCMD{command}
+
+	for _, arg := range args {
+		if arg == "" {
+			continue
+		}
+		if err := validateShWord(arg); err != nil {
+			return "", err
+		}
+		cmdWithArgs = append(cmdWithArgs, arg)
+	}
+
+	// The quoted words are escaped within the double quotes, so they reach the inner shell unchanged.
+	script := shDoubleQuoteEscaper.Replace(ShellJoin(cmdWithArgs))
+	//nolint: gocritic // It's okay to use fmt.Sprintf here
+	return fmt.Sprintf("sh -c \"%s\"", script), nil
+}
+
+// shDoubleQuoteEscaper escapes the characters sh interprets within double quotes.
+var shDoubleQuoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+
+// validateShWord checks that 'word' can be passed as a command argument: arguments are C strings,
+// which cannot hold NUL bytes.
+func validateShWord(word string) error {
+	if strings.ContainsRune(word, 0) {
+		return fmt.Errorf("command and arguments cannot contain NUL bytes: %q", word)
+	}
+
+	return nil
+}
+
 // GenerateSHCommandAsDaggerCMD generates a command wrapped for execution using `sh -c` and returns a DaggerCMD.
 //
 // Parameters:
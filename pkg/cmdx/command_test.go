@@ -1,7 +1,10 @@
 package cmdx
 
 import (
+	"bytes"
+	"os/exec"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -312,3 +315,185 @@ func TestGenerateDaggerCMDFromStr(t *testing.T) {
 		})
 	}
 }
+
+func FuzzGenerateCommand(f *testing.F) {
+	for _, arg := range []string{"plan", "apply --auto-approve", " leading", "trailing ", "\"quoted arg\"", "a  b", " "} {
+		f.Add("terraform", arg)
+	}
+
+	f.Fuzz(func(t *testing.T, command, arg string) {
+		cmd, err := GenerateCommand(command, arg)
+		if err != nil {
+			return
+		}
+
+		if (*cmd)[0] != command {
+			t.Fatalf("GenerateCommand(%q, %q) = %q, want command first", command, arg, *cmd)
+		}
+
+		for _, a := range (*cmd)[1:] {
+			if a == "" {
+				t.Fatalf("GenerateCommand(%q, %q) = %q, want no empty argument", command, arg, *cmd)
+			}
+		}
+	})
+}
+
+func TestGenerateQuotedShCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		args     []string
+		expected string
+		hasError bool
+	}{
+		{
+			name:     "Argument with spaces",
+			command:  "echo",
+			args:     []string{"Hello, World!"},
+			expected: "sh -c \"echo 'Hello, World!'\"",
+		},
+		{
+			name:     "Shell syntax passed as arguments",
+			command:  "echo",
+			args:     []string{"Hello", "&&", "echo", "$HOME"},
+			expected: "sh -c \"echo Hello '&&' echo '\\$HOME'\"",
+		},
+		{
+			name:     "Empty command",
+			command:  "",
+			args:     []string{"run", "main.go"},
+			hasError: true,
+		},
+		{
+			name:     "NUL byte",
+			command:  "echo",
+			args:     []string{"a\x00b"},
+			hasError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := GenerateQuotedShCommand(tt.command, tt.args...)
+			if tt.hasError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestGenerateQuotedShCommandRunsVerbatim(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	args := []string{"$HOME", "$(id)", "`id`", "a\" && echo pwned #", "it's", "*", "\\", "line\nbreak"}
+
+	cmd, err := GenerateQuotedShCommand("printf", append([]string{"%s\\n"}, args...)...)
+	assert.NoError(t, err)
+
+	out, err := exec.Command("sh", "-c", cmd).Output()
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Join(args, "\n")+"\n", string(out))
+}
+
+func FuzzGenerateQuotedShCommand(f *testing.F) {
+	for _, arg := range []string{"Hello, World!", "$HOME", "$(id)", "`id`", "a\" && rm -rf / #", "\\\"", "it's", "*", "x\x00y"} {
+		f.Add("echo", arg)
+	}
+	f.Add("FOO=bar", "baz")
+
+	f.Fuzz(func(t *testing.T, command, arg string) {
+		cmd, err := GenerateQuotedShCommand(command, arg)
+		if err != nil {
+			return
+		}
+
+		want := []string{command}
+		if arg != "" {
+			want = append(want, arg)
+		}
+
+		got, ok := parseShCommand(cmd)
+		if !ok || !reflect.DeepEqual(got, want) {
+			t.Fatalf("GenerateQuotedShCommand(%q, %q) = %q, parsed as %q, want %q without expansion", command, arg, cmd, got, want)
+		}
+	})
+}
+
+// parseShCommand parses a `sh -c "script"` command as sh does, for the constructs
+// GenerateQuotedShCommand renders: it returns the words of the script, and false if sh would expand,
+// split or glob any part of it, or read its first word as a variable assignment.
+func parseShCommand(cmd string) ([]string, bool) {
+	quoted, ok := strings.CutPrefix(cmd, `sh -c "`)
+	if !ok || !strings.HasSuffix(quoted, `"`) {
+		return nil, false
+	}
+	quoted = quoted[:len(quoted)-1]
+
+	// Unescape the double-quoted script.
+	var script []byte
+	for i := 0; i < len(quoted); i++ {
+		switch c := quoted[i]; c {
+		case '$', '`', '"':
+			return nil, false
+		case '\\':
+			if i+1 < len(quoted) && quoted[i+1] == '\n' {
+				// A line continuation.
+				i++
+				continue
+			}
+			if i+1 < len(quoted) && strings.IndexByte("$`\"\\", quoted[i+1]) >= 0 {
+				i++
+				c = quoted[i]
+			}
+			script = append(script, c)
+		default:
+			script = append(script, c)
+		}
+	}
+
+	// Split the script into words.
+	var words []string
+	for i := 0; i < len(script); {
+		if script[i] == ' ' {
+			i++
+			continue
+		}
+
+		var word []byte
+		bare := true
+		for i < len(script) && script[i] != ' ' {
+			switch c := script[i]; {
+			case c == '\'':
+				end := bytes.IndexByte(script[i+1:], '\'')
+				if end < 0 {
+					return nil, false
+				}
+				word = append(word, script[i+1:i+1+end]...)
+				i += end + 2
+				bare = false
+			case c == '\\' && i+1 < len(script):
+				word = append(word, script[i+1])
+				i += 2
+				bare = false
+			case strings.IndexByte("/.-_=:", c) >= 0 || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9'):
+				word = append(word, c)
+				i++
+			default:
+				return nil, false
+			}
+		}
+
+		if len(words) == 0 && bare && bytes.IndexByte(word, '=') >= 0 {
+			return nil, false
+		}
+		words = append(words, string(word))
+	}
+
+	return words, true
+}
@@ -3,6 +3,8 @@ package cmdx
 import (
 	"fmt"
 	"time"
+
+	"github.com/Excoriate/daggerx/pkg/mapx"
 )
 
 // BuildCurlCommand generates a curl command string based on the provided parameters.
@@ -25,8 +27,9 @@ func BuildCurlCommand(
 ) string {
 	curlCmd := fmt.Sprintf("curl -m %d", int(timeout.Seconds()))
 
-	for key, value := range headers {
-		curlCmd += fmt.Sprintf(" -H '%s: %s'", key, value)
+	// Headers are sorted, so the same headers always generate the same command.
+	for _, header := range mapx.Pairs(headers, ": ") {
+		curlCmd += fmt.Sprintf(" -H '%s'", header)
 	}
 
 	switch authType {
@@ -84,3 +84,14 @@ func TestBuildCurlCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildCurlCommandSortsHeaders(t *testing.T) {
+	headers := map[string]string{"X-B": "2", "X-C": "3", "X-A": "1"}
+	want := "curl -m 10 -H 'X-A: 1' -H 'X-B: 2' -H 'X-C: 3' 'https://api.example.com'"
+
+	for range 20 {
+		if got := BuildCurlCommand("https://api.example.com", headers, 10*time.Second, "", ""); got != want {
+			t.Fatalf("BuildCurlCommand() = %v, want %v", got, want)
+		}
+	}
+}
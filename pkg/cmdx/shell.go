@@ -0,0 +1,39 @@
+package cmdx
+
+import "github.com/Excoriate/daggerx/internal/shellquote"
+
+// ShellQuote quotes an argument for sh, so it is passed to the command as is, without splitting,
+// globbing or expansion.
+//
+// Parameters:
+//   - s: The argument to quote.
+//
+// Returns:
+//   - The argument unchanged when it is only made of letters, digits and the characters
+//     "/.-_=:", or the argument single-quoted, each of its single quotes closing the quoted
+//     string, escaped, and reopening it.
+//
+// Example:
+//
+//	fmt.Println(ShellQuote("main.go"))   // Output: main.go
+//	fmt.Println(ShellQuote("it's $HOME")) // Output: 'it'\''s $HOME'
+func ShellQuote(s string) string {
+	return shellquote.Quote(s)
+}
+
+// ShellJoin quotes the arguments of a command with ShellQuote and joins them into a sh command
+// line, which sh splits back into the original arguments.
+//
+// Parameters:
+//   - args: The command and its arguments.
+//
+// Returns:
+//   - The sh command line. A command holding '=' is quoted, as sh reads it as a variable
+//     assignment otherwise.
+//
+// Example:
+//
+//	fmt.Println(ShellJoin([]string{"echo", "Hello, World!"})) // Output: echo 'Hello, World!'
+func ShellJoin(args []string) string {
+	return shellquote.Join(args)
+}
@@ -0,0 +1,50 @@
+package cmdx
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		name     string
+		arg      string
+		expected string
+	}{
+		{name: "Plain word", arg: "main.go", expected: "main.go"},
+		{name: "Flag with value", arg: "--out-dir=/mnt/packages", expected: "--out-dir=/mnt/packages"},
+		{name: "Empty", arg: "", expected: "''"},
+		{name: "Space", arg: "Hello, World!", expected: "'Hello, World!'"},
+		{name: "Expansion", arg: "$HOME $(id) `id`", expected: "'$HOME $(id) `id`'"},
+		{name: "Glob", arg: "*.apk", expected: "'*.apk'"},
+		{name: "Single quote", arg: "it's", expected: `'it'\''s'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ShellQuote(tt.arg))
+		})
+	}
+}
+
+func TestShellJoin(t *testing.T) {
+	assert.Equal(t, "echo 'Hello, World!' --verbose", ShellJoin([]string{"echo", "Hello, World!", "--verbose"}))
+	assert.Equal(t, "'FOO=bar' baz=qux", ShellJoin([]string{"FOO=bar", "baz=qux"}))
+	assert.Equal(t, "", ShellJoin(nil))
+}
+
+func TestShellJoinRoundTrip(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	args := []string{"a b", "it's", "$HOME", "$(id)", "`id`", "*", "~", "", "a\\b", "line\nbreak", "\"q\""}
+
+	out, err := exec.Command("sh", "-c", ShellJoin(append([]string{"printf", "%s\\0"}, args...))).Output()
+	require.NoError(t, err)
+	assert.Equal(t, args, strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00"))
+}
@@ -0,0 +1,113 @@
+// Package compatx helps code written against the upstream daggerx API (the earlier releases of
+// github.com/Excoriate/daggerx) keep generating the commands it expects. The module path is
+// unchanged, so that code keeps its imports; compatx gathers the names it relies on, and adapters
+// restoring the upstream behaviours where they diverged.
+//
+// The names (the ApkoBuilder with its architectures and keyring paths, the envvars conversions
+// and the command types) are type aliases, constants and thin wrappers of those of this module,
+// so values pass freely between compatx and the packages defining them. The behaviours that
+// diverged:
+//
+//   - WithBuildContext no longer adds the build context as a build repository
+//     (--build-repository-append); WithUpstreamBuildContext restores it.
+//   - The options upstream accepted but dropped from the command (e.g. WithOffline,
+//     WithRepositoryAppend, WithLogLevel) now emit their flags.
+//   - Validation errors wrap the errorsx sentinels; their messages are unchanged.
+//
+// Example usage:
+//
+//	// was: apkox.NewApkoBuilder().WithBuildContext(dir)
+//	builder := compatx.WithUpstreamBuildContext(compatx.NewApkoBuilder(), dir).
+//	    WithConfigFile("apko.yaml").
+//	    WithOutputImage("registry.example.com/app").
+//	    WithOutputTarball("/out/app.tar")
+//
+//	env, err := compatx.ToDaggerEnvVarsFromSlice([]string{"GOOS=linux"})
+package compatx
+
+import (
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/envvars"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// ApkoBuilder is the upstream apko builder.
+type ApkoBuilder = apkox.ApkoBuilder
+
+// Architecture is the upstream apko architecture.
+type Architecture = apkox.Architecture
+
+// DaggerEnvVars is the upstream environment variable type.
+type DaggerEnvVars = types.DaggerEnvVars
+
+// DaggerCMD is the upstream command type.
+type DaggerCMD = types.DaggerCMD
+
+const (
+	// ArchX8664 is the upstream x86_64 architecture.
+	ArchX8664 = apkox.ArchX8664
+	// ArchAarch64 is the upstream aarch64 architecture.
+	ArchAarch64 = apkox.ArchAarch64
+	// ArchArmv7 is the upstream armv7 architecture.
+	ArchArmv7 = apkox.ArchArmv7
+	// ArchPpc64le is the upstream ppc64le architecture.
+	ArchPpc64le = apkox.ArchPpc64le
+	// ArchS390x is the upstream s390x architecture.
+	ArchS390x = apkox.ArchS390x
+	// ApkoDefaultRepositoryURL is the upstream default apko image.
+	ApkoDefaultRepositoryURL = apkox.ApkoDefaultRepositoryURL
+	// ApkoWolfiSigninRsaKeyPath is the upstream path of the Wolfi signing key.
+	ApkoWolfiSigninRsaKeyPath = apkox.ApkoWolfiSigninRsaKeyPath
+	// ApkoAlpineSigninRsaKeyPath is the upstream path of the Alpine signing key.
+	ApkoAlpineSigninRsaKeyPath = apkox.ApkoAlpineSigninRsaKeyPath
+)
+
+// NewApkoBuilder creates an apko builder, as the upstream apkox.NewApkoBuilder does.
+func NewApkoBuilder() *ApkoBuilder {
+	return apkox.NewApkoBuilder()
+}
+
+// WithUpstreamBuildContext sets the build context of 'b' with the upstream behaviour: the
+// directory is also appended as a build repository, so its packages are installable. Prefer
+// WithBuildContext and WithBuildRepositoryAppend in new code.
+// It returns the updated ApkoBuilder instance.
+func WithUpstreamBuildContext(b *ApkoBuilder, dir string) *ApkoBuilder {
+	return b.WithBuildContext(dir).WithBuildRepositoryAppend(dir)
+}
+
+// GetKeyringInfoForPreset returns the keyring of a preset, as the upstream function does.
+func GetKeyringInfoForPreset(preset string) (apkox.KeyringInfo, error) {
+	return apkox.GetKeyringInfoForPreset(preset)
+}
+
+// GetCacheDir returns the apko cache directory under 'mntPrefix', as the upstream function does.
+func GetCacheDir(mntPrefix string) string {
+	return apkox.GetCacheDir(mntPrefix)
+}
+
+// GetApkoConfigOrPreset returns the configuration file or preset, as the upstream function does.
+func GetApkoConfigOrPreset(mntPrefix, cfgFile string) (string, error) {
+	return apkox.GetApkoConfigOrPreset(mntPrefix, cfgFile)
+}
+
+// GetOutputTarPath returns the output tarball path under 'mntPrefix', as the upstream function
+// does.
+func GetOutputTarPath(mntPrefix string) string {
+	return apkox.GetOutputTarPath(mntPrefix)
+}
+
+// ToDaggerEnvVarsFromStr converts "key=value" pairs separated by commas, as the upstream function
+// does.
+func ToDaggerEnvVarsFromStr(envVars string) ([]DaggerEnvVars, error) {
+	return envvars.ToDaggerEnvVarsFromStr(envVars)
+}
+
+// ToDaggerEnvVarsFromMap converts a map of environment variables, as the upstream function does.
+func ToDaggerEnvVarsFromMap(envVarsMap map[string]string) ([]DaggerEnvVars, error) {
+	return envvars.ToDaggerEnvVarsFromMap(envVarsMap)
+}
+
+// ToDaggerEnvVarsFromSlice converts "key=value" strings, as the upstream function does.
+func ToDaggerEnvVarsFromSlice(envVarsSlice []string) ([]DaggerEnvVars, error) {
+	return envvars.ToDaggerEnvVarsFromSlice(envVarsSlice)
+}
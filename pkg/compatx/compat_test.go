@@ -0,0 +1,51 @@
+package compatx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpstreamApkoBuildCommand(t *testing.T) {
+	// The command the upstream builder generates for these options.
+	want := []string{
+		"apko", "build", "--cache-dir", "/cache", "--keyring-append", ApkoWolfiSigninRsaKeyPath,
+		"--arch", "aarch64", "--build-repository-append", "/ctx", "--sbom=false", "--vcs=false",
+		"apko.yaml", "registry.example.com/app:v1", "/out/app.tar",
+	}
+
+	var b *apkox.ApkoBuilder = WithUpstreamBuildContext(NewApkoBuilder(), "/ctx").
+		WithConfigFile("apko.yaml").
+		WithOutputImage("registry.example.com/app").
+		WithOutputTarball("/out/app.tar").
+		WithTag("v1").
+		WithCacheDir("/cache").
+		WithKeyring(ApkoWolfiSigninRsaKeyPath).
+		WithBuildArch(ArchAarch64)
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, want, cmd)
+	assert.Empty(t, b.Warnings(), "the upstream build context is not reported as deprecated")
+}
+
+func TestUpstreamErrors(t *testing.T) {
+	_, err := NewApkoBuilder().BuildCommand()
+	require.EqualError(t, err, "config file is required")
+}
+
+func TestUpstreamEnvVars(t *testing.T) {
+	env, err := ToDaggerEnvVarsFromSlice([]string{"GOOS=linux"})
+	require.NoError(t, err)
+	assert.Equal(t, []DaggerEnvVars{{Name: "GOOS", Value: "linux"}}, env)
+
+	env, err = ToDaggerEnvVarsFromStr("A=1,B=2")
+	require.NoError(t, err)
+	assert.Len(t, env, 2)
+
+	env, err = ToDaggerEnvVarsFromMap(map[string]string{"A": "1"})
+	require.NoError(t, err)
+	assert.Equal(t, []DaggerEnvVars{{Name: "A", Value: "1"}}, env)
+}
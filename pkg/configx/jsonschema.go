@@ -0,0 +1,167 @@
+package configx
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// JSONSchemaDialect is the JSON Schema dialect of the generated schemas.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// PipelinePlanSchemaFile is the name of the schema of the pipeline plans exported by
+// pipeline.Pipeline.ExportJSON.
+const PipelinePlanSchemaFile = "pipeline-plan.schema.json"
+
+// SchemaFile returns the name of the schema of the spec files of 'kind' (e.g. "apko.schema.json").
+func SchemaFile(kind string) string {
+	return kind + ".schema.json"
+}
+
+// JSONSchema returns the JSON Schema of the mappings the schema validates. Required strings must
+// hold a non-blank character and required lists an element, as Validate requires.
+func (s Schema) JSONSchema() map[string]any {
+	properties := make(map[string]any, len(s))
+	for name, field := range s {
+		properties[name] = field.jsonSchema()
+	}
+
+	out := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if required := s.requiredFields(); len(required) > 0 {
+		out["required"] = required
+	}
+
+	return out
+}
+
+// jsonSchema returns the JSON Schema of the values of the field.
+func (f Field) jsonSchema() map[string]any {
+	switch f.Type {
+	case TypeString:
+		out := map[string]any{"type": "string"}
+		if f.Required {
+			out["pattern"] = `\S`
+		}
+		if len(f.Enum) > 0 {
+			out["enum"] = f.Enum
+		}
+		return out
+	case TypeBool:
+		return map[string]any{"type": "boolean"}
+	case TypeInt:
+		return map[string]any{"type": "integer"}
+	case TypeStringList:
+		return f.jsonArray(map[string]any{"type": "string"})
+	case TypeStringMap:
+		return map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}}
+	case TypeObjectList:
+		return f.jsonArray(f.Items.JSONSchema())
+	default:
+		return map[string]any{}
+	}
+}
+
+// jsonArray returns the JSON Schema of the list field with elements 'items'.
+func (f Field) jsonArray(items map[string]any) map[string]any {
+	out := map[string]any{"type": "array", "items": items}
+	if f.Required {
+		out["minItems"] = 1
+	}
+
+	return out
+}
+
+// GenerateSchemas returns the JSON Schema documents of the spec formats, by file name: the
+// schema of the spec files of every registered kind (see SchemaFile), and the schema of the
+// pipeline plans (PipelinePlanSchemaFile). Editors use them to complete and validate the YAML
+// users write, e.g. with a "# yaml-language-server: $schema=apko.schema.json" comment.
+//
+// Returns:
+//   - The indented JSON documents by file name.
+//   - An error if a schema cannot be encoded.
+func (l *Loader) GenerateSchemas() (map[string][]byte, error) {
+	docs := map[string]map[string]any{
+		PipelinePlanSchemaFile: pipelinePlanSchema(),
+	}
+
+	for _, name := range l.Kinds() {
+		spec := l.kinds[name].Schema.JSONSchema()
+		docs[SchemaFile(name)] = map[string]any{
+			"$schema":              JSONSchemaDialect,
+			"title":                fmt.Sprintf("daggerx %s spec", name),
+			"type":                 "object",
+			"additionalProperties": false,
+			"required":             []string{"kind", "spec"},
+			"properties": map[string]any{
+				"apiVersion": map[string]any{"type": "string", "const": APIVersion},
+				"kind":       map[string]any{"type": "string", "const": name},
+				"spec":       spec,
+			},
+		}
+	}
+
+	out := make(map[string][]byte, len(docs))
+	for file, doc := range docs {
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode schema %s: %w", file, err)
+		}
+		out[file] = append(data, '\n')
+	}
+
+	return out, nil
+}
+
+// GenerateSchemas returns the JSON Schema documents of the built-in spec formats (see
+// Loader.GenerateSchemas).
+func GenerateSchemas() (map[string][]byte, error) {
+	return NewLoader().GenerateSchemas()
+}
+
+// pipelinePlanSchema returns the schema of the plans exported by pipeline.Pipeline.ExportJSON.
+func pipelinePlanSchema() map[string]any {
+	stringList := map[string]any{"type": "array", "items": map[string]any{"type": "string"}}
+
+	node := Schema{
+		"id":        {Type: TypeString, Required: true},
+		"command":   {Type: TypeStringList, Required: true},
+		"secrets":   {Type: TypeStringList},
+		"dependsOn": {Type: TypeStringList},
+		"artifacts": {Type: TypeStringList},
+		"env": {Type: TypeObjectList, Items: Schema{
+			"name":   {Type: TypeString, Required: true},
+			"value":  {Type: TypeString},
+			"expand": {Type: TypeBool},
+		}},
+		"mounts": {Type: TypeObjectList, Items: Schema{
+			"kind":     {Type: TypeString, Required: true, Enum: []string{"directory", "file", "cache", "secret", "inline", "socket"}},
+			"source":   {Type: TypeString},
+			"target":   {Type: TypeString, Required: true},
+			"readOnly": {Type: TypeBool},
+			"content":  {Type: TypeString},
+		}},
+	}.JSONSchema()
+
+	// Fields hold no single object, so the resources estimate of a node is described apart.
+	node["properties"].(map[string]any)["resources"] = Schema{
+		"milliCPU":  {Type: TypeInt},
+		"memoryMiB": {Type: TypeInt},
+		"diskMiB":   {Type: TypeInt},
+	}.JSONSchema()
+
+	return map[string]any{
+		"$schema":              JSONSchemaDialect,
+		"title":                "daggerx pipeline plan",
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"name", "nodes", "stages"},
+		"properties": map[string]any{
+			"name":   map[string]any{"type": "string"},
+			"nodes":  map[string]any{"type": "array", "items": node},
+			"stages": map[string]any{"type": "array", "items": stringList},
+		},
+	}
+}
@@ -0,0 +1,182 @@
+package configx
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/pipeline"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// jsonSchemaNode is the subset of JSON Schema the tests inspect.
+type jsonSchemaNode struct {
+	Schema               string                    `json:"$schema"`
+	Type                 string                    `json:"type"`
+	Const                string                    `json:"const"`
+	Pattern              string                    `json:"pattern"`
+	Enum                 []string                  `json:"enum"`
+	Required             []string                  `json:"required"`
+	AdditionalProperties any                       `json:"additionalProperties"`
+	Properties           map[string]jsonSchemaNode `json:"properties"`
+	Items                *jsonSchemaNode           `json:"items"`
+}
+
+func decodeSchema(t *testing.T, schemas map[string][]byte, file string) jsonSchemaNode {
+	t.Helper()
+
+	data, ok := schemas[file]
+	if !ok {
+		t.Fatalf("GenerateSchemas() has no %s", file)
+	}
+
+	var node jsonSchemaNode
+	if err := json.Unmarshal(data, &node); err != nil {
+		t.Fatalf("Failed to decode %s: %v", file, err)
+	}
+
+	return node
+}
+
+func TestGenerateSchemasApko(t *testing.T) {
+	schemas, err := GenerateSchemas()
+	if err != nil {
+		t.Fatalf("GenerateSchemas() returned unexpected error: %v", err)
+	}
+
+	doc := decodeSchema(t, schemas, SchemaFile(KindApko))
+	if doc.Schema != JSONSchemaDialect {
+		t.Errorf("$schema = %s, want %s", doc.Schema, JSONSchemaDialect)
+	}
+	if got := doc.Properties["kind"].Const; got != KindApko {
+		t.Errorf("kind const = %s, want %s", got, KindApko)
+	}
+	if got := doc.Properties["apiVersion"].Const; got != APIVersion {
+		t.Errorf("apiVersion const = %s, want %s", got, APIVersion)
+	}
+
+	spec := doc.Properties["spec"]
+	if spec.AdditionalProperties != false {
+		t.Errorf("spec additionalProperties = %v, want false", spec.AdditionalProperties)
+	}
+	if !slices.Equal(spec.Required, []string{"outputImage", "outputTarball"}) {
+		t.Errorf("spec required = %v", spec.Required)
+	}
+	if got := spec.Properties["outputImage"].Pattern; got != `\S` {
+		t.Errorf("outputImage pattern = %q, want non-blank", got)
+	}
+	if !slices.Contains(spec.Properties["arch"].Enum, "aarch64") {
+		t.Errorf("arch enum = %v", spec.Properties["arch"].Enum)
+	}
+	if got := spec.Properties["users"].Items.Required; !slices.Equal(got, []string{"gid", "uid", "username"}) {
+		t.Errorf("users items required = %v", got)
+	}
+
+	// Every field of the spec is published, so editors don't flag valid files.
+	specType := reflect.TypeOf(apkox.ApkoSpec{})
+	for i := range specType.NumField() {
+		name, _, _ := strings.Cut(specType.Field(i).Tag.Get("json"), ",")
+		if _, ok := spec.Properties[name]; !ok {
+			t.Errorf("schema has no property for ApkoSpec field %s", name)
+		}
+	}
+}
+
+func TestGenerateSchemasPipelinePlan(t *testing.T) {
+	schemas, err := GenerateSchemas()
+	if err != nil {
+		t.Fatalf("GenerateSchemas() returned unexpected error: %v", err)
+	}
+
+	doc := decodeSchema(t, schemas, PipelinePlanSchemaFile)
+
+	p := pipeline.New("release")
+	if err := p.AddNode(pipeline.Node{
+		ID:        "build",
+		Command:   types.DaggerCMD{"apko", "build"},
+		Env:       []types.DaggerEnvVars{{Name: "A", Value: "b", Expand: true}},
+		Mounts:    []types.Mount{{Kind: types.MountKindInline, Target: "/etc/x", Content: "x", ReadOnly: true}},
+		Secrets:   []string{"token"},
+		Artifacts: []string{"/out/image.tar"},
+		Resources: &pipeline.Resources{MilliCPU: 500, MemoryMiB: 256, DiskMiB: 64},
+	}); err != nil {
+		t.Fatalf("AddNode() returned unexpected error: %v", err)
+	}
+	if err := p.AddNode(pipeline.Node{ID: "scan", Command: types.DaggerCMD{"grype"}, DependsOn: []string{"build"}}); err != nil {
+		t.Fatalf("AddNode() returned unexpected error: %v", err)
+	}
+
+	data, err := p.ExportJSON()
+	if err != nil {
+		t.Fatalf("ExportJSON() returned unexpected error: %v", err)
+	}
+
+	var plan map[string]any
+	if err := json.Unmarshal(data, &plan); err != nil {
+		t.Fatalf("Failed to decode plan: %v", err)
+	}
+
+	// Every key of an exported plan is described by the schema.
+	for key := range plan {
+		if _, ok := doc.Properties[key]; !ok {
+			t.Errorf("schema has no property for plan field %s", key)
+		}
+	}
+
+	node := doc.Properties["nodes"].Items
+	for _, n := range plan["nodes"].([]any) {
+		for key, value := range n.(map[string]any) {
+			prop, ok := node.Properties[key]
+			if !ok {
+				t.Errorf("schema has no property for node field %s", key)
+				continue
+			}
+
+			if key == "resources" {
+				for k := range value.(map[string]any) {
+					if _, ok := prop.Properties[k]; !ok {
+						t.Errorf("schema has no property for resources field %s", k)
+					}
+				}
+			}
+
+			if key != "env" && key != "mounts" {
+				continue
+			}
+			for _, item := range value.([]any) {
+				for k := range item.(map[string]any) {
+					if _, ok := prop.Items.Properties[k]; !ok {
+						t.Errorf("schema has no property for %s field %s", key, k)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateSchemasRegisteredKinds(t *testing.T) {
+	loader := NewLoader()
+	if err := loader.Register("custom", Kind{
+		Schema:  Schema{"name": {Type: TypeString, Required: true}},
+		Factory: func(*yaml.Node) (any, error) { return nil, nil },
+	}); err != nil {
+		t.Fatalf("Register() returned unexpected error: %v", err)
+	}
+
+	schemas, err := loader.GenerateSchemas()
+	if err != nil {
+		t.Fatalf("GenerateSchemas() returned unexpected error: %v", err)
+	}
+
+	doc := decodeSchema(t, schemas, "custom.schema.json")
+	if got := doc.Properties["spec"].Properties["name"].Type; got != "string" {
+		t.Errorf("custom spec name type = %s, want string", got)
+	}
+	if len(schemas) != 3 {
+		t.Errorf("GenerateSchemas() returned %d schemas, want 3", len(schemas))
+	}
+}
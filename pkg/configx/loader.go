@@ -0,0 +1,319 @@
+// Package configx loads declarative spec files describing daggerx builders, validates them against
+// a schema with line and column positions, and constructs the corresponding builder, enabling
+// config-driven pipelines.
+//
+// Spec files are YAML or JSON documents with a kind and a spec:
+//
+//	apiVersion: daggerx/v1
+//	kind: apko
+//	spec:
+//	  configFile: apko.yaml
+//	  outputImage: registry.example.com/base
+//	  outputTarball: /mnt/image.tar
+//	  wolfiKeyring: true
+//
+// Example usage:
+//
+//	doc, err := configx.NewLoader().Load("build.yaml")
+//	if err != nil {
+//	    // handle error, a *configx.ValidationError lists every invalid field
+//	}
+//
+//	builder, ok := doc.Builder.(*apkox.ApkoBuilder)
+//
+// GenerateSchemas returns the JSON Schema documents of the spec files and of the pipeline plans,
+// for editor completion and validation outside of Go.
+package configx
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"gopkg.in/yaml.v3"
+)
+
+// APIVersion is the supported apiVersion of spec files.
+const APIVersion = "daggerx/v1"
+
+// KindApko is the kind of apko builder specs.
+const KindApko = "apko"
+
+// Factory constructs a builder from the validated spec node.
+type Factory func(spec *yaml.Node) (any, error)
+
+// Kind describes a builder kind: the schema of its spec and the factory constructing it.
+type Kind struct {
+	// Schema validates the spec of the kind.
+	Schema Schema
+	// Factory constructs the builder.
+	Factory Factory
+}
+
+// Document is a loaded spec file.
+type Document struct {
+	// APIVersion is the apiVersion of the file.
+	APIVersion string
+	// Kind is the builder kind.
+	Kind string
+	// Builder is the constructed builder (e.g. *apkox.ApkoBuilder for the apko kind).
+	Builder any
+}
+
+// Loader loads spec files for the registered kinds.
+type Loader struct {
+	kinds map[string]Kind
+}
+
+// NewLoader creates a Loader with the built-in kinds registered.
+func NewLoader() *Loader {
+	l := &Loader{kinds: map[string]Kind{}}
+	l.kinds[KindApko] = apkoKind()
+
+	return l
+}
+
+// Register registers a builder kind, replacing any kind with the same name.
+func (l *Loader) Register(name string, kind Kind) error {
+	if name == "" {
+		return fmt.Errorf("kind name is required")
+	}
+
+	if kind.Schema == nil || kind.Factory == nil {
+		return fmt.Errorf("kind %s requires a schema and a factory", name)
+	}
+
+	l.kinds[name] = kind
+
+	return nil
+}
+
+// Kinds returns the names of the registered kinds, sorted.
+func (l *Loader) Kinds() []string {
+	names := make([]string, 0, len(l.kinds))
+	for name := range l.kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Load reads, validates and loads the spec file at 'path'.
+func (l *Loader) Load(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec file: %w", err)
+	}
+
+	return l.LoadBytes(path, data)
+}
+
+// LoadBytes validates and loads the spec 'data', using 'source' to name it in errors.
+//
+// Returns:
+//   - The loaded document, with its builder constructed.
+//   - A *ValidationError if the document does not match the schema of its kind, or another
+//     error if it cannot be parsed or its kind is not registered.
+func (l *Loader) LoadBytes(source string, data []byte) (*Document, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("spec %s is empty", source)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse spec %s: %w", source, err)
+	}
+
+	if len(root.Content) == 0 {
+		return nil, fmt.Errorf("spec %s is empty", source)
+	}
+
+	doc := root.Content[0]
+	envelope := &Document{}
+
+	errs := validateEnvelope(doc, l.Kinds())
+	kindNode := lookup(doc, "kind")
+	specNode := lookup(doc, "spec")
+
+	if len(errs) == 0 {
+		envelope.Kind = kindNode.Value
+		if v := lookup(doc, "apiVersion"); v != nil {
+			envelope.APIVersion = v.Value
+		}
+		errs = l.kinds[envelope.Kind].Schema.Validate("spec", specNode)
+	}
+
+	if len(errs) > 0 {
+		return nil, &ValidationError{Source: source, Errors: trimRoot(errs)}
+	}
+
+	builder, err := l.kinds[envelope.Kind].Factory(specNode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build %s from spec %s: %w", envelope.Kind, source, err)
+	}
+	envelope.Builder = builder
+
+	return envelope, nil
+}
+
+// validateEnvelope checks the top-level fields of a document. The spec field is validated by the
+// schema of its kind.
+func validateEnvelope(node *yaml.Node, kinds []string) []*FieldError {
+	if node.Kind != yaml.MappingNode {
+		return []*FieldError{newFieldError("document", node, "expected a mapping")}
+	}
+
+	errs := Schema{
+		"apiVersion": {Type: TypeString, Enum: []string{APIVersion}},
+		"kind":       {Type: TypeString, Required: true, Enum: kinds},
+	}.Validate("", withoutKey(node, "spec"))
+
+	spec := lookup(node, "spec")
+	if spec == nil {
+		errs = append(errs, newFieldError(".spec", node, "required field is missing"))
+	} else if spec.Kind != yaml.MappingNode {
+		errs = append(errs, newFieldError(".spec", spec, "expected a mapping"))
+	}
+
+	return errs
+}
+
+// apkoKind returns the built-in apko kind.
+func apkoKind() Kind {
+	return Kind{
+		Schema: Schema{
+			"configFile":                    {Type: TypeString},
+			"inlineConfig":                  {Type: TypeString},
+			"outputImage":                   {Type: TypeString, Required: true},
+			"tag":                           {Type: TypeString},
+			"outputTarball":                 {Type: TypeString, Required: true},
+			"keyrings":                      {Type: TypeStringList},
+			"wolfiKeyring":                  {Type: TypeBool},
+			"alpineKeyring":                 {Type: TypeBool},
+			"cacheDir":                      {Type: TypeString},
+			"extraArgs":                     {Type: TypeStringList},
+			"arch":                          {Type: TypeString, Enum: []string{"x86_64", "aarch64", "armv7", "ppc64le", "s390x"}},
+			"buildContext":                  {Type: TypeString},
+			"buildRepositoryAppend":         {Type: TypeStringList},
+			"debug":                         {Type: TypeBool},
+			"keyringAppendPlaintext":        {Type: TypeStringList},
+			"keyringAppendSecrets":          {Type: TypeStringList},
+			"noNetwork":                     {Type: TypeBool},
+			"repositoryAppend":              {Type: TypeStringList},
+			"timestamp":                     {Type: TypeString},
+			"annotations":                   {Type: TypeStringMap},
+			"buildDate":                     {Type: TypeString},
+			"lockfile":                      {Type: TypeString},
+			"offline":                       {Type: TypeBool},
+			"packageAppend":                 {Type: TypeStringList},
+			"sbom":                          {Type: TypeBool},
+			"sbomFormats":                   {Type: TypeStringList},
+			"sbomPath":                      {Type: TypeString},
+			"vcs":                           {Type: TypeBool},
+			"logLevel":                      {Type: TypeString},
+			"logPolicy":                     {Type: TypeStringList},
+			"workdir":                       {Type: TypeString},
+			"layeringStrategy":              {Type: TypeString, Enum: []string{"origin"}},
+			"layeringBudget":                {Type: TypeInt},
+			"releaseRegistries":             {Type: TypeStringList},
+			"archTagTemplate":               {Type: TypeString},
+			"shellMode":                     {Type: TypeString, Enum: []string{"reject", "escape"}},
+			"apkoVersion":                   {Type: TypeString},
+			"imageRefs":                     {Type: TypeString},
+			"buildInfoPath":                 {Type: TypeString},
+			"cacheProvenance":               {Type: TypeBool},
+			"insecureIgnoreSignatures":      {Type: TypeBool},
+			"insecureAllowHTTPRepositories": {Type: TypeBool},
+			"production":                    {Type: TypeBool},
+			"strict":                        {Type: TypeBool},
+			"extraArgsPolicy":               {Type: TypeString, Enum: []string{"warn", "error", "prefer-typed", "prefer-extra"}},
+			"groups": {Type: TypeObjectList, Items: Schema{
+				"groupname": {Type: TypeString, Required: true},
+				"gid":       {Type: TypeInt, Required: true},
+				"members":   {Type: TypeStringList},
+			}},
+			"users": {Type: TypeObjectList, Items: Schema{
+				"username": {Type: TypeString, Required: true},
+				"uid":      {Type: TypeInt, Required: true},
+				"gid":      {Type: TypeInt, Required: true},
+				"shell":    {Type: TypeString},
+				"homedir":  {Type: TypeString},
+			}},
+			"runAs":          {Type: TypeString},
+			"requireNonRoot": {Type: TypeBool},
+			"environment": {Type: TypeObjectList, Items: Schema{
+				"name":  {Type: TypeString, Required: true},
+				"value": {Type: TypeString},
+				"mode":  {Type: TypeString, Enum: []string{"override", "default", "append", "prepend"}},
+			}},
+			"paths": {Type: TypeObjectList, Items: Schema{
+				"path":        {Type: TypeString, Required: true},
+				"type":        {Type: TypeString, Required: true, Enum: []string{"directory", "empty-file", "hardlink", "symlink", "permissions"}},
+				"source":      {Type: TypeString},
+				"uid":         {Type: TypeInt},
+				"gid":         {Type: TypeInt},
+				"permissions": {Type: TypeInt},
+				"recursive":   {Type: TypeBool},
+			}},
+			"certificates": {Type: TypeObjectList, Items: Schema{
+				"name":    {Type: TypeString, Required: true},
+				"content": {Type: TypeString, Required: true},
+			}},
+			"packageRules": {Type: TypeObjectList, Items: Schema{
+				"package":      {Type: TypeString, Required: true},
+				"archs":        {Type: TypeStringList},
+				"excludeArchs": {Type: TypeStringList},
+			}},
+		},
+		Factory: func(node *yaml.Node) (any, error) {
+			var spec apkox.ApkoSpec
+			if err := node.Decode(&spec); err != nil {
+				return nil, err
+			}
+
+			return apkox.NewApkoBuilderFromSpec(spec), nil
+		},
+	}
+}
+
+// lookup returns the value node of 'key' in a mapping node, or nil.
+func lookup(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// withoutKey returns a copy of a mapping node without the entry 'key'.
+func withoutKey(node *yaml.Node, key string) *yaml.Node {
+	out := *node
+	out.Content = nil
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != key {
+			out.Content = append(out.Content, node.Content[i], node.Content[i+1])
+		}
+	}
+
+	return &out
+}
+
+// trimRoot removes the leading dot of top-level field paths.
+func trimRoot(errs []*FieldError) []*FieldError {
+	for _, fe := range errs {
+		if len(fe.Path) > 0 && fe.Path[0] == '.' {
+			fe.Path = fe.Path[1:]
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,231 @@
+package configx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"gopkg.in/yaml.v3"
+)
+
+const validApkoSpec = `apiVersion: daggerx/v1
+kind: apko
+spec:
+  configFile: apko.yaml
+  outputImage: example/base
+  outputTarball: /mnt/image.tar
+  tag: v1
+  arch: aarch64
+  wolfiKeyring: true
+  annotations:
+    org.opencontainers.image.source: https://example.com
+`
+
+func TestLoadBytesYAML(t *testing.T) {
+	doc, err := NewLoader().LoadBytes("build.yaml", []byte(validApkoSpec))
+	if err != nil {
+		t.Fatalf("LoadBytes returned unexpected error: %v", err)
+	}
+
+	if doc.Kind != KindApko || doc.APIVersion != APIVersion {
+		t.Errorf("Unexpected document %+v", doc)
+	}
+
+	b, ok := doc.Builder.(*apkox.ApkoBuilder)
+	if !ok {
+		t.Fatalf("Expected an *apkox.ApkoBuilder, got %T", doc.Builder)
+	}
+
+	cmd, err := b.BuildCommand()
+	if err != nil {
+		t.Fatalf("BuildCommand returned unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"apko", "build",
+		"--keyring-append", apkox.ApkoWolfiSigninRsaKeyPath,
+		"--arch", "aarch64",
+		"--sbom=false", "--vcs=false",
+		"--annotations", "org.opencontainers.image.source:https://example.com",
+		"apko.yaml", "example/base:v1", "/mnt/image.tar",
+	}
+	if !reflect.DeepEqual(cmd, expected) {
+		t.Errorf("BuildCommand() = %v, want %v", cmd, expected)
+	}
+}
+
+func TestLoadBytesJSON(t *testing.T) {
+	data := `{"kind": "apko", "spec": {"configFile": "apko.yaml", "outputImage": "example/base", "outputTarball": "/mnt/image.tar", "sbom": true}}`
+
+	doc, err := NewLoader().LoadBytes("build.json", []byte(data))
+	if err != nil {
+		t.Fatalf("LoadBytes returned unexpected error: %v", err)
+	}
+
+	if !doc.Builder.(*apkox.ApkoBuilder).Spec().SBOM {
+		t.Error("Expected sbom to be enabled")
+	}
+}
+
+func TestLoadBytesApkoConfigOptions(t *testing.T) {
+	data := `kind: apko
+spec:
+  inlineConfig: "contents: {packages: [wolfi-base]}"
+  outputImage: example/base
+  outputTarball: /mnt/image.tar
+  groups:
+    - groupname: app
+      gid: 1000
+  users:
+    - username: app
+      uid: 1000
+      gid: 1000
+  runAs: app
+  requireNonRoot: true
+  environment:
+    - name: PATH
+      value: /app/bin
+      mode: prepend
+`
+
+	doc, err := NewLoader().LoadBytes("build.yaml", []byte(data))
+	if err != nil {
+		t.Fatalf("LoadBytes returned unexpected error: %v", err)
+	}
+
+	spec := doc.Builder.(*apkox.ApkoBuilder).Spec()
+	if len(spec.Users) != 1 || spec.Users[0].UID != 1000 || spec.RunAs != "app" || !spec.RequireNonRoot {
+		t.Errorf("Unexpected accounts in spec %+v", spec)
+	}
+
+	if len(spec.Environment) != 1 || spec.Environment[0].Mode != apkox.EnvModePrepend {
+		t.Errorf("Unexpected environment in spec %+v", spec.Environment)
+	}
+
+	_, err = NewLoader().LoadBytes("build.yaml", []byte(strings.Replace(data, "mode: prepend", "mode: merge", 1)))
+	if err == nil || !strings.Contains(err.Error(), "spec.environment[0].mode: must be one of") {
+		t.Errorf("Expected an invalid mode error, got %v", err)
+	}
+
+	_, err = NewLoader().LoadBytes("build.yaml", []byte(strings.Replace(data, "uid: 1000", "uid: app", 1)))
+	if err == nil || !strings.Contains(err.Error(), "spec.users[0].uid: expected an integer") {
+		t.Errorf("Expected an invalid uid error, got %v", err)
+	}
+}
+
+func TestLoadBytesValidationErrors(t *testing.T) {
+	data := `kind: apko
+spec:
+  configFile: apko.yaml
+  outputImag: example/base
+  outputTarball: 42
+  arch: riscv
+  keyrings: /keys/a.pub
+`
+
+	_, err := NewLoader().LoadBytes("build.yaml", []byte(data))
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected a *ValidationError, got %v", err)
+	}
+
+	want := []string{
+		`build.yaml:4:3: spec.outputImag: unknown field, did you mean "outputImage"?`,
+		`build.yaml:5:18: spec.outputTarball: expected a string`,
+		`build.yaml:6:9: spec.arch: must be one of x86_64, aarch64, armv7, ppc64le, s390x, got "riscv"`,
+		`build.yaml:7:13: spec.keyrings: expected a list of strings`,
+		`build.yaml:3:3: spec.outputImage: required field is missing`,
+	}
+
+	msg := err.Error()
+	for _, w := range want {
+		if !strings.Contains(msg, w) {
+			t.Errorf("Expected error to contain %q, got:\n%s", w, msg)
+		}
+	}
+}
+
+func TestLoadBytesEnvelopeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "Empty", data: "  \n", want: "is empty"},
+		{name: "Comments only", data: "# nothing\n", want: "is empty"},
+		{name: "Invalid YAML", data: "kind: [", want: "failed to parse"},
+		{name: "Not a mapping", data: "- apko", want: "document: expected a mapping"},
+		{name: "Unknown kind", data: "kind: melange\nspec: {}", want: `kind: must be one of apko, got "melange"`},
+		{name: "Missing spec", data: "kind: apko", want: "spec: required field is missing"},
+		{name: "Invalid apiVersion", data: "apiVersion: v2\nkind: apko\nspec: {}", want: "apiVersion: must be one of daggerx/v1"},
+		{name: "Spec not a mapping", data: "kind: apko\nspec: apko.yaml", want: "spec: expected a mapping"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLoader().LoadBytes("build.yaml", []byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoaderRegister(t *testing.T) {
+	l := NewLoader()
+
+	if err := l.Register("", Kind{}); err == nil {
+		t.Error("Expected error for empty name, got none")
+	}
+
+	if err := l.Register("scan", Kind{}); err == nil {
+		t.Error("Expected error for kind without schema, got none")
+	}
+
+	err := l.Register("scan", Kind{
+		Schema: Schema{"image": {Type: TypeString, Required: true}},
+		Factory: func(node *yaml.Node) (any, error) {
+			var spec struct {
+				Image string `yaml:"image"`
+			}
+			err := node.Decode(&spec)
+			return spec.Image, err
+		},
+	})
+	if err != nil {
+		t.Fatalf("Register returned unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(l.Kinds(), []string{"apko", "scan"}) {
+		t.Errorf("Kinds() = %v", l.Kinds())
+	}
+
+	doc, err := l.LoadBytes("scan.yaml", []byte("kind: scan\nspec:\n  image: alpine\n"))
+	if err != nil {
+		t.Fatalf("LoadBytes returned unexpected error: %v", err)
+	}
+
+	if doc.Builder != "alpine" {
+		t.Errorf("Unexpected builder %v", doc.Builder)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "build.yaml")
+	if err := os.WriteFile(path, []byte(validApkoSpec), 0o600); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+
+	if _, err := NewLoader().Load(path); err != nil {
+		t.Errorf("Load returned unexpected error: %v", err)
+	}
+
+	if _, err := NewLoader().Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Expected error for missing file, got none")
+	}
+}
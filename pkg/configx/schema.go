@@ -0,0 +1,271 @@
+package configx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Type is the type of a schema field.
+type Type string
+
+const (
+	// TypeString is a scalar string.
+	TypeString Type = "string"
+	// TypeBool is a scalar boolean.
+	TypeBool Type = "bool"
+	// TypeInt is a scalar integer.
+	TypeInt Type = "int"
+	// TypeStringList is a sequence of strings.
+	TypeStringList Type = "[]string"
+	// TypeStringMap is a mapping of strings to strings.
+	TypeStringMap Type = "map[string]string"
+	// TypeObjectList is a sequence of mappings, each validated against the Items schema.
+	TypeObjectList Type = "[]object"
+)
+
+// Field describes a field of a spec.
+type Field struct {
+	// Type is the type of the field.
+	Type Type
+	// Required marks fields that must be set to a non-empty value.
+	Required bool
+	// Enum lists the allowed values of string fields, when set.
+	Enum []string
+	// Items is the schema of the elements of TypeObjectList fields.
+	Items Schema
+}
+
+// Schema maps the field names of a spec to their description. Fields absent from the schema are
+// rejected.
+type Schema map[string]Field
+
+// FieldError is a validation error located in the spec file.
+type FieldError struct {
+	// Path is the dotted path of the field (e.g. "spec.outputImage").
+	Path string
+	// Line is the 1-based line of the field, 0 when unknown.
+	Line int
+	// Column is the 1-based column of the field, 0 when unknown.
+	Column int
+	// Message describes the error.
+	Message string
+}
+
+// Error returns the error with its position.
+func (e *FieldError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Path, e.Message)
+	}
+
+	return fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// ValidationError holds every validation error of a spec file.
+type ValidationError struct {
+	// Source is the name of the validated file.
+	Source string
+	// Errors holds the field errors, sorted by position.
+	Errors []*FieldError
+}
+
+// Error returns the field errors, one per line, prefixed by the source name.
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		lines = append(lines, fmt.Sprintf("%s:%s", e.Source, fe.Error()))
+	}
+
+	return fmt.Sprintf("invalid spec %s:\n%s", e.Source, strings.Join(lines, "\n"))
+}
+
+// Validate checks the mapping node 'node', found at 'path', against the schema.
+//
+// Returns:
+//   - The field errors, sorted by position; empty when the node is valid.
+func (s Schema) Validate(path string, node *yaml.Node) []*FieldError {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return []*FieldError{newFieldError(path, node, "expected a mapping")}
+	}
+
+	var errs []*FieldError
+	seen := map[string]bool{}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		fieldPath := path + "." + key.Value
+
+		field, ok := s[key.Value]
+		if !ok {
+			errs = append(errs, newFieldError(fieldPath, key, fmt.Sprintf("unknown field%s", suggest(key.Value, s))))
+			continue
+		}
+
+		if seen[key.Value] {
+			errs = append(errs, newFieldError(fieldPath, key, "duplicate field"))
+			continue
+		}
+		seen[key.Value] = true
+
+		if fe := field.check(fieldPath, value); fe != nil {
+			errs = append(errs, fe)
+		}
+	}
+
+	for _, name := range s.requiredFields() {
+		if !seen[name] {
+			errs = append(errs, newFieldError(path+"."+name, node, "required field is missing"))
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Line != errs[j].Line {
+			return errs[i].Line < errs[j].Line
+		}
+		return errs[i].Column < errs[j].Column
+	})
+
+	return errs
+}
+
+// requiredFields returns the names of the required fields, sorted.
+func (s Schema) requiredFields() []string {
+	var names []string
+	for name, f := range s {
+		if f.Required {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// check validates a value node against the field description.
+func (f Field) check(path string, value *yaml.Node) *FieldError {
+	switch f.Type {
+	case TypeString:
+		if !isScalar(value, "!!str") {
+			return newFieldError(path, value, "expected a string")
+		}
+		if f.Required && strings.TrimSpace(value.Value) == "" {
+			return newFieldError(path, value, "must not be empty")
+		}
+		if len(f.Enum) > 0 && !contains(f.Enum, value.Value) {
+			return newFieldError(path, value, fmt.Sprintf("must be one of %s, got %q", strings.Join(f.Enum, ", "), value.Value))
+		}
+	case TypeBool:
+		if !isScalar(value, "!!bool") {
+			return newFieldError(path, value, "expected a boolean")
+		}
+	case TypeInt:
+		if !isScalar(value, "!!int") {
+			return newFieldError(path, value, "expected an integer")
+		}
+	case TypeStringList:
+		if value.Kind != yaml.SequenceNode {
+			return newFieldError(path, value, "expected a list of strings")
+		}
+		for i, item := range value.Content {
+			if !isScalar(item, "!!str") {
+				return newFieldError(fmt.Sprintf("%s[%d]", path, i), item, "expected a string")
+			}
+		}
+		if f.Required && len(value.Content) == 0 {
+			return newFieldError(path, value, "must not be empty")
+		}
+	case TypeStringMap:
+		if value.Kind != yaml.MappingNode {
+			return newFieldError(path, value, "expected a mapping of strings")
+		}
+		for i := 1; i < len(value.Content); i += 2 {
+			if !isScalar(value.Content[i], "!!str") {
+				return newFieldError(path+"."+value.Content[i-1].Value, value.Content[i], "expected a string")
+			}
+		}
+	case TypeObjectList:
+		if value.Kind != yaml.SequenceNode {
+			return newFieldError(path, value, "expected a list of mappings")
+		}
+		for i, item := range value.Content {
+			if errs := f.Items.Validate(fmt.Sprintf("%s[%d]", path, i), item); len(errs) > 0 {
+				return errs[0]
+			}
+		}
+		if f.Required && len(value.Content) == 0 {
+			return newFieldError(path, value, "must not be empty")
+		}
+	default:
+		return newFieldError(path, value, fmt.Sprintf("unsupported schema type %q", f.Type))
+	}
+
+	return nil
+}
+
+// isScalar reports whether the node is a scalar with the given resolved tag.
+func isScalar(node *yaml.Node, tag string) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == tag
+}
+
+// newFieldError creates a FieldError positioned at the node.
+func newFieldError(path string, node *yaml.Node, msg string) *FieldError {
+	fe := &FieldError{Path: path, Message: msg}
+	if node != nil {
+		fe.Line, fe.Column = node.Line, node.Column
+	}
+
+	return fe
+}
+
+// suggest returns a hint naming the schema field closest to 'name', if any is close enough.
+func suggest(name string, s Schema) string {
+	best, bestDist := "", 3
+	for candidate := range s {
+		if d := levenshtein(strings.ToLower(name), strings.ToLower(candidate)); d < bestDist ||
+			(d == bestDist && best != "" && candidate < best) {
+			best, bestDist = candidate, d
+		}
+	}
+
+	if best == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(", did you mean %q?", best)
+}
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+
+	return prev[len(b)]
+}
+
+// contains reports whether 'values' contains 'v'.
+func contains(values []string, v string) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+
+	return false
+}
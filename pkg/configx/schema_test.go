@@ -0,0 +1,83 @@
+package configx
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func parseNode(t *testing.T, data string) *yaml.Node {
+	t.Helper()
+
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(data), &root); err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	return root.Content[0]
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schema := Schema{
+		"name":   {Type: TypeString, Required: true},
+		"debug":  {Type: TypeBool},
+		"port":   {Type: TypeInt},
+		"tags":   {Type: TypeStringList},
+		"labels": {Type: TypeStringMap},
+		"users": {Type: TypeObjectList, Items: Schema{
+			"name": {Type: TypeString, Required: true},
+			"uid":  {Type: TypeInt},
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		data     string
+		wantPath []string
+	}{
+		{name: "Valid", data: "name: x\ndebug: true\nport: 80\ntags: [a, b]\nlabels: {a: b}\nusers: [{name: a, uid: 1}]"},
+		{name: "Empty required", data: "name: ''", wantPath: []string{"s.name"}},
+		{name: "Wrong bool", data: "name: x\ndebug: yes please", wantPath: []string{"s.debug"}},
+		{name: "Wrong int", data: "name: x\nport: eighty", wantPath: []string{"s.port"}},
+		{name: "Wrong list item", data: "name: x\ntags: [a, [b]]", wantPath: []string{"s.tags[1]"}},
+		{name: "Wrong map value", data: "name: x\nlabels: {a: [b]}", wantPath: []string{"s.labels.a"}},
+		{name: "Wrong object list", data: "name: x\nusers: {name: a}", wantPath: []string{"s.users"}},
+		{name: "Wrong object field", data: "name: x\nusers: [{name: a}, {name: b, uid: one}]", wantPath: []string{"s.users[1].uid"}},
+		{name: "Missing object field", data: "name: x\nusers: [{uid: 1}]", wantPath: []string{"s.users[0].name"}},
+		{name: "Duplicate", data: "name: x\nname: y", wantPath: []string{"s.name"}},
+		{name: "Not a mapping", data: "[a]", wantPath: []string{"s"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := schema.Validate("s", parseNode(t, tt.data))
+
+			if len(errs) != len(tt.wantPath) {
+				t.Fatalf("Expected %d errors, got %v", len(tt.wantPath), errs)
+			}
+
+			for i, fe := range errs {
+				if fe.Path != tt.wantPath[i] {
+					t.Errorf("Error %d: expected path %s, got %s (%s)", i, tt.wantPath[i], fe.Path, fe)
+				}
+			}
+		})
+	}
+}
+
+func TestFieldErrorWithoutPosition(t *testing.T) {
+	fe := &FieldError{Path: "spec", Message: "boom"}
+	if fe.Error() != "spec: boom" {
+		t.Errorf("Error() = %s", fe.Error())
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	if d := levenshtein("outputimag", "outputimage"); d != 1 {
+		t.Errorf("levenshtein() = %d, want 1", d)
+	}
+
+	if suggest("zzzzzz", Schema{"name": {}}) != "" {
+		t.Error("Expected no suggestion for distant names")
+	}
+}
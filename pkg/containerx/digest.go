@@ -0,0 +1,277 @@
+package containerx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/cachex"
+	"github.com/Excoriate/daggerx/pkg/httpfetchx"
+	"github.com/Excoriate/daggerx/pkg/ratelimitx"
+	"github.com/Excoriate/daggerx/pkg/retryx"
+)
+
+const (
+	dockerHubRegistry    = "docker.io"
+	dockerHubAPIRegistry = "registry-1.docker.io"
+)
+
+// manifestMediaTypes are the manifest media types accepted when resolving a digest, so
+// registries return the digest of the index for multi-platform images.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// imageReference is a parsed image reference.
+type imageReference struct {
+	registry   string
+	repository string
+	tag        string
+	digest     string
+}
+
+// parseImageReference splits an image reference into its registry, repository, tag and digest.
+// References without a registry default to Docker Hub, and references without a tag or digest
+// default to "latest". Each component is validated, as references may come from untrusted input.
+func parseImageReference(imageRef string) (imageReference, error) {
+	if imageRef == "" {
+		return imageReference{}, fmt.Errorf("image reference cannot be empty")
+	}
+
+	var ref imageReference
+
+	name := imageRef
+	if before, digest, ok := strings.Cut(name, "@"); ok {
+		if !validateDigest(digest) {
+			return imageReference{}, fmt.Errorf("invalid digest in image reference: %s", imageRef)
+		}
+		name, ref.digest = before, digest
+	}
+
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.tag = name[:i], name[i+1:]
+	}
+
+	if ref.tag == "" && ref.digest == "" {
+		ref.tag = "latest"
+	}
+
+	ref.registry = dockerHubRegistry
+	if first, rest, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.registry, name = first, rest
+	}
+
+	if name == "" {
+		return imageReference{}, fmt.Errorf("invalid image reference: %s", imageRef)
+	}
+
+	// The components are joined into the manifest URL, so references from untrusted input must
+	// not smuggle URL syntax (e.g. "?", "#" or "..") into it.
+	if !validateRegistry(ref.registry) {
+		return imageReference{}, fmt.Errorf("invalid registry in image reference: %s", imageRef)
+	}
+
+	for _, part := range strings.Split(name, "/") {
+		if !validateNamespaceOrRepo(part) {
+			return imageReference{}, fmt.Errorf("invalid repository in image reference: %s", imageRef)
+		}
+	}
+
+	if ref.tag != "" && !validateTag(ref.tag) {
+		return imageReference{}, fmt.Errorf("invalid tag in image reference: %s", imageRef)
+	}
+
+	if ref.registry == dockerHubRegistry {
+		ref.registry = dockerHubAPIRegistry
+		if !strings.Contains(name, "/") {
+			name = "library/" + name
+		}
+	}
+
+	ref.repository = name
+
+	return ref, nil
+}
+
+// ResolveDigest resolves the manifest digest of an image. See ResolveDigestContext.
+func ResolveDigest(imageRef string) (string, error) {
+	return ResolveDigestContext(context.Background(), imageRef, nil)
+}
+
+// ResolveDigestContext resolves the manifest digest of an image by querying its registry,
+// honoring the cancellation and deadline of 'ctx'. Anonymous bearer tokens are requested when
+// the registry demands them, and transient failures are retried with the default retryx policy.
+// Requests are throttled per registry by ratelimitx.Default. When 'client' is nil, the client of
+// httpfetchx.Default is used.
+//
+// References already pinned by digest ("repo@sha256:...") are returned without a request.
+//
+// Returns:
+//   - The digest of the manifest, e.g. "sha256:...".
+//   - An error if the reference is invalid or the registry cannot be queried.
+func ResolveDigestContext(ctx context.Context, imageRef string, client *http.Client) (string, error) {
+	ref, err := parseImageReference(imageRef)
+	if err != nil {
+		return "", err
+	}
+
+	if ref.digest != "" {
+		return ref.digest, nil
+	}
+
+	if client == nil {
+		if client, err = httpfetchx.Default().Client(); err != nil {
+			return "", err
+		}
+	}
+
+	return retryx.DoValue(ctx, nil, func(ctx context.Context) (string, error) {
+		return resolveDigest(ctx, client, ref, imageRef)
+	})
+}
+
+// resolveDigest queries the registry of 'ref' for its manifest digest, once.
+func resolveDigest(ctx context.Context, client *http.Client, ref imageReference, imageRef string) (string, error) {
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.registry, ref.repository, ref.tag)
+
+	resp, err := headManifest(ctx, client, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := fetchToken(ctx, client, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", fmt.Errorf("failed to authenticate to %s: %w", ref.registry, err)
+		}
+
+		if resp, err = headManifest(ctx, client, manifestURL, token); err != nil {
+			return "", err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to resolve digest of %s: %w", imageRef,
+			&retryx.StatusError{StatusCode: resp.StatusCode, Status: resp.Status})
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if !validateDigest(digest) {
+		return "", fmt.Errorf("registry returned an invalid digest for %s: %q", imageRef, digest)
+	}
+
+	return digest, nil
+}
+
+// ResolveDigestCached resolves the manifest digest of an image like ResolveDigestContext,
+// caching the result in 'cache' under the "digest:" prefix. A nil cache disables caching.
+func ResolveDigestCached(ctx context.Context, cache *cachex.Cache, imageRef string, client *http.Client) (string, error) {
+	value, err := cache.GetOrFetch(ctx, "digest:"+imageRef, func(ctx context.Context) ([]byte, error) {
+		digest, err := ResolveDigestContext(ctx, imageRef, client)
+		return []byte(digest), err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
+
+// headManifest sends a HEAD request for a manifest, with a bearer token when 'token' is set.
+func headManifest(ctx context.Context, client *http.Client, manifestURL, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create manifest request: %w", err)
+	}
+
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	if err := ratelimitx.Default().Wait(ctx, req.URL.Host); err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query manifest %s: %w", manifestURL, err)
+	}
+	_ = resp.Body.Close()
+
+	return resp, nil
+}
+
+// fetchToken requests an anonymous bearer token from the realm of a WWW-Authenticate challenge.
+func fetchToken(ctx context.Context, client *http.Client, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported authentication challenge: %q", challenge)
+	}
+
+	values := url.Values{}
+	var realm string
+	for _, param := range strings.Split(params, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"`)
+		if key == "realm" {
+			realm = value
+		} else {
+			values.Set(key, value)
+		}
+	}
+
+	if realm == "" {
+		return "", fmt.Errorf("authentication challenge has no realm: %q", challenge)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+values.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+
+	if err := ratelimitx.Default().Wait(ctx, req.URL.Host); err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request token: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to request token: %w",
+			&retryx.StatusError{StatusCode: resp.StatusCode, Status: resp.Status})
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	if body.Token != "" {
+		return body.Token, nil
+	}
+
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+
+	return "", fmt.Errorf("token response contains no token")
+}
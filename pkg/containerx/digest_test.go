@@ -0,0 +1,174 @@
+package containerx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/cachex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestParseImageReference(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      string
+		expected imageReference
+	}{
+		{"Official image", "alpine", imageReference{registry: "registry-1.docker.io", repository: "library/alpine", tag: "latest"}},
+		{"Docker Hub namespace", "docker.io/bitnami/redis:7", imageReference{registry: "registry-1.docker.io", repository: "bitnami/redis", tag: "7"}},
+		{"Registry with port", "localhost:5000/app:v1", imageReference{registry: "localhost:5000", repository: "app", tag: "v1"}},
+		{"Pinned by digest", "cgr.dev/chainguard/static@" + testDigest, imageReference{registry: "cgr.dev", repository: "chainguard/static", digest: testDigest}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := parseImageReference(tt.ref)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ref)
+		})
+	}
+
+	for _, ref := range []string{"", "alpine@sha256:short", "localhost:5000/"} {
+		_, err := parseImageReference(ref)
+		assert.Error(t, err, ref)
+	}
+}
+
+func newRegistryServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var srv *httptest.Server
+	flaky := 0
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/org/flaky/manifests/v1":
+			if flaky++; flaky == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", testDigest)
+		case r.URL.Path == "/token":
+			assert.Equal(t, "repository:org/app:pull", r.URL.Query().Get("scope"))
+			_, _ = w.Write([]byte(`{"token":"anonymous"}`))
+		case r.Header.Get("Authorization") != "Bearer anonymous":
+			w.Header().Set("WWW-Authenticate",
+				fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:org/app:pull"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/org/app/manifests/v1":
+			assert.Equal(t, http.MethodHead, r.Method)
+			assert.Contains(t, r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json")
+			w.Header().Set("Docker-Content-Digest", testDigest)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestResolveDigestContext(t *testing.T) {
+	srv := newRegistryServer(t)
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	digest, err := ResolveDigestContext(context.Background(), host+"/org/app:v1", srv.Client())
+	require.NoError(t, err)
+	assert.Equal(t, testDigest, digest)
+
+	_, err = ResolveDigestContext(context.Background(), host+"/org/app:missing", srv.Client())
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestResolveDigestContextRetriesTransientFailures(t *testing.T) {
+	srv := newRegistryServer(t)
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	digest, err := ResolveDigestContext(context.Background(), host+"/org/flaky:v1", srv.Client())
+	require.NoError(t, err)
+	assert.Equal(t, testDigest, digest)
+}
+
+func TestResolveDigestContextPinned(t *testing.T) {
+	digest, err := ResolveDigest("alpine@" + testDigest)
+	require.NoError(t, err)
+	assert.Equal(t, testDigest, digest)
+}
+
+func TestResolveDigestContextCancelled(t *testing.T) {
+	srv := newRegistryServer(t)
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := ResolveDigestContext(ctx, host+"/org/app:v1", srv.Client())
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestResolveDigestCached(t *testing.T) {
+	srv := newRegistryServer(t)
+	host := strings.TrimPrefix(srv.URL, "https://")
+	cache := cachex.NewCache(time.Hour)
+
+	digest, err := ResolveDigestCached(context.Background(), cache, host+"/org/app:v1", srv.Client())
+	require.NoError(t, err)
+	assert.Equal(t, testDigest, digest)
+
+	// The registry is gone; the digest is served from the cache.
+	srv.Close()
+	digest, err = ResolveDigestCached(context.Background(), cache, host+"/org/app:v1", srv.Client())
+	require.NoError(t, err)
+	assert.Equal(t, testDigest, digest)
+}
+
+func FuzzParseImageReference(f *testing.F) {
+	for _, ref := range []string{
+		"alpine", "docker.io/bitnami/redis:7", "localhost:5000/app:v1", "cgr.dev/chainguard/static@" + testDigest,
+		"ghcr.io/org/app:v1@" + testDigest, "localhost:5000/", "a:b/c:d", "registry.example.com/app?x=1#y",
+	} {
+		f.Add(ref)
+	}
+
+	f.Fuzz(func(t *testing.T, imageRef string) {
+		ref, err := parseImageReference(imageRef)
+		if err != nil {
+			return
+		}
+
+		// The components are joined into the manifest URL, so they must not hold URL syntax.
+		if !validateRegistry(ref.registry) && ref.registry != "localhost" {
+			t.Fatalf("parseImageReference(%q) registry = %q", imageRef, ref.registry)
+		}
+
+		for _, part := range strings.Split(ref.repository, "/") {
+			if !validateNamespaceOrRepo(part) {
+				t.Fatalf("parseImageReference(%q) repository = %q", imageRef, ref.repository)
+			}
+		}
+
+		if ref.tag == "" && ref.digest == "" || ref.tag != "" && !validateTag(ref.tag) ||
+			ref.digest != "" && !validateDigest(ref.digest) {
+			t.Fatalf("parseImageReference(%q) tag = %q, digest = %q", imageRef, ref.tag, ref.digest)
+		}
+
+		name := ref.registry + "/" + ref.repository
+		if ref.tag != "" {
+			name += ":" + ref.tag
+		}
+		if ref.digest != "" {
+			name += "@" + ref.digest
+		}
+
+		again, err := parseImageReference(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, ref, again, name)
+	})
+}
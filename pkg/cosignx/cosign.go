@@ -0,0 +1,528 @@
+// Package cosignx provides builders for cosign, the Sigstore tool signing and verifying container
+// images, with support for key-based and keyless (OIDC) signing against public or private Sigstore
+// instances.
+//
+// Keyless signing obtains a short-lived certificate from Fulcio for the identity of an OIDC token
+// and records the signature in the Rekor transparency log. Enterprises running their own Sigstore
+// instances point the builders at them with WithFulcioURL, WithRekorURL and WithOIDCIssuer.
+// Verification can run without network access from a bundle written at signing time.
+//
+// Example usage:
+//
+//	sign := cosignx.NewSignBuilder("registry.example.com/app@sha256:...").
+//	    WithFulcioURL("https://fulcio.sigstore.example.com").
+//	    WithRekorURL("https://rekor.sigstore.example.com").
+//	    WithOIDCIssuer("https://token.actions.githubusercontent.com").
+//	    WithIdentityTokenEnv("ACTIONS_ID_TOKEN").
+//	    WithBundle("/mnt/app.bundle")
+//
+//	cmd, err := sign.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	for _, ref := range sign.Secrets() {
+//	    // translate ref into a Dagger secret
+//	}
+package cosignx
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/mapx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/signingx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the cosign builders in errorsx errors.
+const builderName = "cosign"
+
+// CosignDefaultImage is the default container image providing cosign.
+const CosignDefaultImage = "cgr.dev/chainguard/cosign"
+
+// IdentityTokenEnvVar is the environment variable cosign reads the OIDC identity token from.
+const IdentityTokenEnvVar = "SIGSTORE_ID_TOKEN"
+
+// identityTokenSecret is the name of the secret holding the OIDC identity token.
+const identityTokenSecret = "cosign-identity-token"
+
+// SignBuilder generates the cosign sign command of an image.
+type SignBuilder struct {
+	// imageRef is the reference of the signed image, preferably pinned by digest.
+	imageRef string
+
+	// key is the private key signing the image. Signing is keyless when it is empty.
+	key string
+
+	// keyProvider references the private key signing the image, instead of key. It may be nil.
+	keyProvider *signingx.KeyProvider
+
+	// fulcioURL is the Fulcio instance issuing keyless signing certificates.
+	fulcioURL string
+
+	// rekorURL is the Rekor transparency log recording the signature.
+	rekorURL string
+
+	// oidcIssuer is the OIDC provider issuing the identity token.
+	oidcIssuer string
+
+	// oidcClientID is the OIDC client ID requesting the identity token.
+	oidcClientID string
+
+	// identityTokenEnv is the host environment variable holding the OIDC identity token.
+	identityTokenEnv string
+
+	// bundle is the path where the signature bundle for offline verification is written.
+	bundle string
+
+	// annotations are added to the signature.
+	annotations map[string]string
+
+	// recursive signs every image of a multi-platform index.
+	recursive bool
+
+	// extraArgs are additional arguments appended before the image reference.
+	extraArgs []string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewSignBuilder creates a SignBuilder signing the image 'imageRef'.
+func NewSignBuilder(imageRef string) *SignBuilder {
+	return &SignBuilder{imageRef: imageRef, annotations: map[string]string{}}
+}
+
+// WithKey sets the private key signing the image (a path or a KMS URI), disabling keyless signing.
+func (b *SignBuilder) WithKey(key string) *SignBuilder {
+	b.key = key
+	return b
+}
+
+// WithKeyProvider sets the reference of the private key signing the image (a key file, a host
+// environment variable or a KMS URI), disabling keyless signing. Environment keys and key
+// passwords are provided to cosign as secrets, see Secrets.
+func (b *SignBuilder) WithKeyProvider(provider *signingx.KeyProvider) *SignBuilder {
+	b.keyProvider = provider
+	return b
+}
+
+// WithFulcioURL sets the Fulcio instance issuing keyless signing certificates.
+func (b *SignBuilder) WithFulcioURL(fulcioURL string) *SignBuilder {
+	b.fulcioURL = fulcioURL
+	return b
+}
+
+// WithRekorURL sets the Rekor transparency log recording the signature.
+func (b *SignBuilder) WithRekorURL(rekorURL string) *SignBuilder {
+	b.rekorURL = rekorURL
+	return b
+}
+
+// WithOIDCIssuer sets the OIDC provider issuing the identity token.
+func (b *SignBuilder) WithOIDCIssuer(issuer string) *SignBuilder {
+	b.oidcIssuer = issuer
+	return b
+}
+
+// WithOIDCClientID sets the OIDC client ID requesting the identity token.
+func (b *SignBuilder) WithOIDCClientID(clientID string) *SignBuilder {
+	b.oidcClientID = clientID
+	return b
+}
+
+// WithIdentityTokenEnv sets the host environment variable holding the OIDC identity token.
+// The token is provided to cosign as a secret, see Secrets.
+func (b *SignBuilder) WithIdentityTokenEnv(envVar string) *SignBuilder {
+	b.identityTokenEnv = envVar
+	return b
+}
+
+// WithBundle sets the path where the signature bundle is written, allowing offline verification
+// with VerifyBuilder.WithBundle.
+func (b *SignBuilder) WithBundle(path string) *SignBuilder {
+	b.bundle = path
+	return b
+}
+
+// WithAnnotation adds an annotation to the signature.
+func (b *SignBuilder) WithAnnotation(key, value string) *SignBuilder {
+	b.annotations[key] = value
+	return b
+}
+
+// WithRecursive signs every image of a multi-platform index.
+func (b *SignBuilder) WithRecursive(recursive bool) *SignBuilder {
+	b.recursive = recursive
+	return b
+}
+
+// WithExtraArg adds an argument appended before the image reference.
+func (b *SignBuilder) WithExtraArg(arg string) *SignBuilder {
+	b.extraArgs = append(b.extraArgs, arg)
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *SignBuilder) WithLogger(logger *slog.Logger) *SignBuilder {
+	b.logger = logger
+	return b
+}
+
+// Secrets returns the secrets the generated command requires: the OIDC identity token, exposed
+// as IdentityTokenEnvVar, when WithIdentityTokenEnv is set, and the secrets of the key provider
+// (see signingx.KeyProvider.CosignSecrets).
+func (b *SignBuilder) Secrets() []*secretsx.SecretRef {
+	var secrets []*secretsx.SecretRef
+	if b.identityTokenEnv != "" {
+		secrets = append(secrets, secretsx.FromEnv(identityTokenSecret, b.identityTokenEnv).AsEnv(IdentityTokenEnvVar))
+	}
+
+	if b.keyProvider != nil {
+		secrets = append(secrets, b.keyProvider.CosignSecrets()...)
+	}
+
+	return secrets
+}
+
+// validate checks that the builder configuration is complete and consistent.
+func (b *SignBuilder) validate() error {
+	if b.imageRef == "" {
+		return errorsx.MissingField(builderName, "imageRef", "image reference is required")
+	}
+
+	if b.keyProvider != nil {
+		if b.key != "" {
+			return errorsx.ConflictingOptions(builderName, []string{"key", "keyProvider"},
+				"key and key provider cannot be combined")
+		}
+
+		if err := b.keyProvider.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if b.key != "" || b.keyProvider != nil {
+		keyless := []struct{ field, value string }{
+			{"fulcioURL", b.fulcioURL},
+			{"oidcIssuer", b.oidcIssuer},
+			{"identityTokenEnv", b.identityTokenEnv},
+		}
+		for _, opt := range keyless {
+			if opt.value != "" {
+				return errorsx.ConflictingOptions(builderName, []string{"key", opt.field},
+					"%s configures keyless signing and cannot be combined with a key", opt.field)
+			}
+		}
+	}
+
+	return validateURLs(map[string]string{
+		"fulcioURL":  b.fulcioURL,
+		"rekorURL":   b.rekorURL,
+		"oidcIssuer": b.oidcIssuer,
+	})
+}
+
+// BuildCommand generates the cosign sign command.
+//
+// Returns:
+//   - The cosign sign command.
+//   - An error if the image reference is missing, a key is combined with keyless options, or a
+//     Sigstore URL is not a valid HTTPS URL.
+func (b *SignBuilder) BuildCommand() ([]string, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"cosign", "sign", "--yes"}
+
+	if b.key != "" {
+		cmd = append(cmd, "--key", b.key)
+	}
+
+	if b.keyProvider != nil {
+		key, err := b.keyProvider.CosignKey()
+		if err != nil {
+			return nil, err
+		}
+		cmd = append(cmd, "--key", key)
+	}
+
+	if b.fulcioURL != "" {
+		cmd = append(cmd, "--fulcio-url", b.fulcioURL)
+	}
+
+	if b.rekorURL != "" {
+		cmd = append(cmd, "--rekor-url", b.rekorURL)
+	}
+
+	if b.oidcIssuer != "" {
+		cmd = append(cmd, "--oidc-issuer", b.oidcIssuer)
+	}
+
+	if b.oidcClientID != "" {
+		cmd = append(cmd, "--oidc-client-id", b.oidcClientID)
+	}
+
+	if b.bundle != "" {
+		cmd = append(cmd, "--bundle", b.bundle)
+	}
+
+	cmd = append(cmd, mapx.Flags("--annotations", b.annotations, "=")...)
+
+	if b.recursive {
+		cmd = append(cmd, "--recursive")
+	}
+
+	cmd = append(cmd, b.extraArgs...)
+	cmd = append(cmd, b.imageRef)
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// VerifyBuilder generates the cosign verify command of an image.
+type VerifyBuilder struct {
+	// imageRef is the reference of the verified image.
+	imageRef string
+
+	// key is the public key verifying the signature. Verification is keyless when it is empty.
+	key string
+
+	// rekorURL is the Rekor transparency log the signature is looked up in.
+	rekorURL string
+
+	// trustedRoot is the Sigstore trusted root of a private Sigstore instance.
+	trustedRoot string
+
+	// bundle is the signature bundle written at signing time.
+	bundle string
+
+	// offline verifies the signature from the bundle without contacting Rekor.
+	offline bool
+
+	// policy constrains the accepted signatures.
+	policy *VerifyPolicy
+
+	// extraArgs are additional arguments appended before the image reference.
+	extraArgs []string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewVerifyBuilder creates a VerifyBuilder verifying the image 'imageRef'.
+func NewVerifyBuilder(imageRef string) *VerifyBuilder {
+	return &VerifyBuilder{imageRef: imageRef}
+}
+
+// WithKey sets the public key verifying the signature (a path or a KMS URI).
+func (b *VerifyBuilder) WithKey(key string) *VerifyBuilder {
+	b.key = key
+	return b
+}
+
+// WithRekorURL sets the Rekor transparency log the signature is looked up in.
+func (b *VerifyBuilder) WithRekorURL(rekorURL string) *VerifyBuilder {
+	b.rekorURL = rekorURL
+	return b
+}
+
+// WithTrustedRoot sets the Sigstore trusted root of a private Sigstore instance, holding its
+// Fulcio certificates and Rekor keys.
+func (b *VerifyBuilder) WithTrustedRoot(path string) *VerifyBuilder {
+	b.trustedRoot = path
+	return b
+}
+
+// WithBundle sets the signature bundle written at signing time.
+func (b *VerifyBuilder) WithBundle(path string) *VerifyBuilder {
+	b.bundle = path
+	return b
+}
+
+// WithOffline verifies the signature from the bundle without contacting Rekor.
+func (b *VerifyBuilder) WithOffline(offline bool) *VerifyBuilder {
+	b.offline = offline
+	return b
+}
+
+// WithPolicy sets the policy constraining the accepted signatures. Keyless verification requires
+// a policy constraining the certificate identity and issuer.
+func (b *VerifyBuilder) WithPolicy(policy *VerifyPolicy) *VerifyBuilder {
+	b.policy = policy
+	return b
+}
+
+// WithExtraArg adds an argument appended before the image reference.
+func (b *VerifyBuilder) WithExtraArg(arg string) *VerifyBuilder {
+	b.extraArgs = append(b.extraArgs, arg)
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *VerifyBuilder) WithLogger(logger *slog.Logger) *VerifyBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks that the builder configuration is complete and consistent.
+func (b *VerifyBuilder) validate() error {
+	if b.imageRef == "" {
+		return errorsx.MissingField(builderName, "imageRef", "image reference is required")
+	}
+
+	if b.key == "" && (b.policy == nil || !b.policy.hasIdentity()) {
+		return errorsx.MissingField(builderName, "policy",
+			"keyless verification requires a policy constraining the certificate identity and issuer")
+	}
+
+	if b.offline && b.bundle == "" {
+		return errorsx.MissingField(builderName, "bundle", "offline verification requires a bundle")
+	}
+
+	if b.offline && b.rekorURL != "" {
+		return errorsx.ConflictingOptions(builderName, []string{"offline", "rekorURL"},
+			"offline verification does not contact Rekor")
+	}
+
+	return validateURLs(map[string]string{"rekorURL": b.rekorURL})
+}
+
+// BuildCommand generates the cosign verify command.
+//
+// Returns:
+//   - The cosign verify command.
+//   - An error if the image reference is missing, keyless verification lacks an identity policy,
+//     offline verification lacks a bundle, or the Rekor URL is not a valid HTTPS URL.
+func (b *VerifyBuilder) BuildCommand() ([]string, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"cosign", "verify"}
+
+	if b.key != "" {
+		cmd = append(cmd, "--key", b.key)
+	}
+
+	if b.rekorURL != "" {
+		cmd = append(cmd, "--rekor-url", b.rekorURL)
+	}
+
+	if b.trustedRoot != "" {
+		cmd = append(cmd, "--trusted-root", b.trustedRoot)
+	}
+
+	if b.bundle != "" {
+		cmd = append(cmd, "--bundle", b.bundle)
+	}
+
+	if b.offline {
+		cmd = append(cmd, "--offline")
+	}
+
+	if b.policy != nil {
+		flags, err := b.policy.Flags()
+		if err != nil {
+			return nil, err
+		}
+		cmd = append(cmd, flags...)
+	}
+
+	cmd = append(cmd, b.extraArgs...)
+	cmd = append(cmd, b.imageRef)
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// validateURLs checks that the non-empty Sigstore URLs, keyed by field, are absolute HTTPS URLs.
+func validateURLs(urls map[string]string) error {
+	fields := make([]string, 0, len(urls))
+	for field := range urls {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		raw := urls[field]
+		if raw == "" {
+			continue
+		}
+
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" || !strings.EqualFold(u.Scheme, "https") {
+			return errorsx.InvalidValue(builderName, field, raw, "%s must be an https URL, got %s", field, raw)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *SignBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *SignBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none. The
+// credentials are secrets (see Secrets).
+func (b *SignBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *VerifyBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *VerifyBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *VerifyBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the cosign sign command.
+func (b *SignBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("cosign sign", b, NewSignBuilder(""), explainx.Generate("BuildCommand", b.BuildCommand))
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the cosign verify command.
+func (b *VerifyBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("cosign verify", b, NewVerifyBuilder(""), explainx.Generate("BuildCommand", b.BuildCommand))
+}
@@ -0,0 +1,154 @@
+package cosignx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/signingx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignBuilderKeyless(t *testing.T) {
+	b := NewSignBuilder("registry.example.com/app@sha256:abc").
+		WithFulcioURL("https://fulcio.sigstore.example.com").
+		WithRekorURL("https://rekor.sigstore.example.com").
+		WithOIDCIssuer("https://token.actions.githubusercontent.com").
+		WithOIDCClientID("sigstore").
+		WithIdentityTokenEnv("ACTIONS_ID_TOKEN").
+		WithBundle("/mnt/app.bundle").
+		WithAnnotation("run", "42").
+		WithAnnotation("commit", "abc123").
+		WithRecursive(true)
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"cosign", "sign", "--yes",
+		"--fulcio-url", "https://fulcio.sigstore.example.com",
+		"--rekor-url", "https://rekor.sigstore.example.com",
+		"--oidc-issuer", "https://token.actions.githubusercontent.com",
+		"--oidc-client-id", "sigstore",
+		"--bundle", "/mnt/app.bundle",
+		"--annotations", "commit=abc123",
+		"--annotations", "run=42",
+		"--recursive",
+		"registry.example.com/app@sha256:abc",
+	}, cmd)
+
+	secrets := b.Secrets()
+	require.Len(t, secrets, 1)
+	assert.Equal(t, secretsx.SourceEnv, secrets[0].Source)
+	assert.Equal(t, "ACTIONS_ID_TOKEN", secrets[0].Ref)
+	assert.Equal(t, secretsx.MountAsEnv, secrets[0].Mount)
+	assert.Equal(t, IdentityTokenEnvVar, secrets[0].Target)
+}
+
+func TestSignBuilderWithKey(t *testing.T) {
+	b := NewSignBuilder("registry.example.com/app:v1").WithKey("/mnt/cosign.key")
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cosign", "sign", "--yes", "--key", "/mnt/cosign.key", "registry.example.com/app:v1"}, cmd)
+	assert.Empty(t, b.Secrets())
+}
+
+func TestSignBuilderWithKeyProvider(t *testing.T) {
+	b := NewSignBuilder("registry.example.com/app:v1").
+		WithKeyProvider(signingx.NewEnvKey("SIGNING_KEY").WithPasswordEnv("SIGNING_PASSWORD"))
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cosign", "sign", "--yes", "--key", "env://COSIGN_PRIVATE_KEY", "registry.example.com/app:v1"}, cmd)
+
+	secrets := b.Secrets()
+	require.Len(t, secrets, 2)
+	assert.Equal(t, "SIGNING_KEY", secrets[0].Ref)
+	assert.Equal(t, signingx.CosignKeyEnvVar, secrets[0].Target)
+	assert.Equal(t, signingx.CosignPasswordEnvVar, secrets[1].Target)
+
+	cmd, err = NewSignBuilder("registry.example.com/app:v1").
+		WithKeyProvider(signingx.NewKMSKey("awskms:///alias/release")).
+		BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cosign", "sign", "--yes", "--key", "awskms:///alias/release", "registry.example.com/app:v1"}, cmd)
+
+	_, err = NewSignBuilder("registry.example.com/app:v1").
+		WithKey("/mnt/cosign.key").
+		WithKeyProvider(signingx.NewFileKey("/mnt/other.key")).
+		BuildCommand()
+	require.ErrorIs(t, err, errorsx.ErrConflictingOptions)
+
+	_, err = NewSignBuilder("registry.example.com/app:v1").
+		WithKeyProvider(signingx.NewKMSKey("hashivault://release")).
+		WithIdentityTokenEnv("ACTIONS_ID_TOKEN").
+		BuildCommand()
+	require.ErrorIs(t, err, errorsx.ErrConflictingOptions)
+
+	_, err = NewSignBuilder("registry.example.com/app:v1").
+		WithKeyProvider(signingx.NewKMSKey("gcpkms://release")).
+		BuildCommand()
+	require.ErrorIs(t, err, errorsx.ErrInvalidValue)
+}
+
+func TestSignBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *SignBuilder
+		kind    error
+		field   string
+	}{
+		{"Missing image", NewSignBuilder(""), errorsx.ErrMissingField, "imageRef"},
+		{"Key with Fulcio", NewSignBuilder("img").WithKey("k").WithFulcioURL("https://fulcio.example.com"), errorsx.ErrConflictingOptions, ""},
+		{"Plain HTTP Rekor", NewSignBuilder("img").WithRekorURL("http://rekor.example.com"), errorsx.ErrInvalidValue, "rekorURL"},
+		{"Relative issuer", NewSignBuilder("img").WithOIDCIssuer("issuer"), errorsx.ErrInvalidValue, "oidcIssuer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.BuildCommand()
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+			if tt.field != "" {
+				field, _ := errorsx.FieldOf(err)
+				assert.Equal(t, tt.field, field)
+			}
+		})
+	}
+}
+
+func TestVerifyBuilder(t *testing.T) {
+	cmd, err := NewVerifyBuilder("registry.example.com/app@sha256:abc").
+		WithTrustedRoot("/mnt/trusted_root.json").
+		WithBundle("/mnt/app.bundle").
+		WithOffline(true).
+		WithPolicy(NewVerifyPolicy().
+			WithIdentityRegexp(`^https://github\.com/example/`).
+			WithIssuer("https://token.actions.githubusercontent.com")).
+		BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"cosign", "verify",
+		"--trusted-root", "/mnt/trusted_root.json",
+		"--bundle", "/mnt/app.bundle",
+		"--offline",
+		"--certificate-identity-regexp", `^https://github\.com/example/`,
+		"--certificate-oidc-issuer", "https://token.actions.githubusercontent.com",
+		"registry.example.com/app@sha256:abc",
+	}, cmd)
+
+	cmd, err = NewVerifyBuilder("img").WithKey("/mnt/cosign.pub").WithRekorURL("https://rekor.example.com").BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cosign", "verify", "--key", "/mnt/cosign.pub", "--rekor-url", "https://rekor.example.com", "img"}, cmd)
+
+	_, err = NewVerifyBuilder("img").BuildCommand()
+	field, _ := errorsx.FieldOf(err)
+	assert.Equal(t, "policy", field)
+
+	_, err = NewVerifyBuilder("img").WithKey("k").WithOffline(true).BuildCommand()
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+
+	_, err = NewVerifyBuilder("img").WithKey("k").WithOffline(true).WithBundle("b").WithRekorURL("https://rekor.example.com").BuildCommand()
+	assert.True(t, errors.Is(err, errorsx.ErrConflictingOptions))
+}
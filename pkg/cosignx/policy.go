@@ -0,0 +1,320 @@
+package cosignx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/mapx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+)
+
+const (
+	// RuleSignature requires at least one signature.
+	RuleSignature policyx.Rule = "signature"
+	// RuleIdentity constrains the certificate identity of the signature.
+	RuleIdentity policyx.Rule = "certificate-identity"
+	// RuleIssuer constrains the OIDC issuer of the signature certificate.
+	RuleIssuer policyx.Rule = "certificate-oidc-issuer"
+	// RuleAnnotation requires an annotation on the signature.
+	RuleAnnotation policyx.Rule = "annotation"
+	// RuleRekor requires the signature to be recorded in the Rekor transparency log.
+	RuleRekor policyx.Rule = "rekor"
+)
+
+// VerifyPolicy constrains the signatures accepted by cosign verify. It renders into the
+// `cosign verify` flags enforcing it, and evaluates the JSON output of cosign verify, so gate
+// steps can check signatures verified elsewhere.
+type VerifyPolicy struct {
+	identity       string
+	identityRegexp string
+	issuer         string
+	issuerRegexp   string
+	annotations    map[string]string
+	rekorRequired  bool
+}
+
+// NewVerifyPolicy creates a VerifyPolicy without constraints. Signatures must be recorded in
+// Rekor unless WithRekorRequired(false) is set.
+func NewVerifyPolicy() *VerifyPolicy {
+	return &VerifyPolicy{annotations: map[string]string{}, rekorRequired: true}
+}
+
+// WithIdentity requires the certificate identity to be exactly 'identity'.
+func (p *VerifyPolicy) WithIdentity(identity string) *VerifyPolicy {
+	p.identity = identity
+	return p
+}
+
+// WithIdentityRegexp requires the certificate identity to match 'expr'.
+func (p *VerifyPolicy) WithIdentityRegexp(expr string) *VerifyPolicy {
+	p.identityRegexp = expr
+	return p
+}
+
+// WithIssuer requires the certificate OIDC issuer to be exactly 'issuer'.
+func (p *VerifyPolicy) WithIssuer(issuer string) *VerifyPolicy {
+	p.issuer = issuer
+	return p
+}
+
+// WithIssuerRegexp requires the certificate OIDC issuer to match 'expr'.
+func (p *VerifyPolicy) WithIssuerRegexp(expr string) *VerifyPolicy {
+	p.issuerRegexp = expr
+	return p
+}
+
+// WithAnnotation requires the signature to carry the annotation 'key' set to 'value'.
+func (p *VerifyPolicy) WithAnnotation(key, value string) *VerifyPolicy {
+	p.annotations[key] = value
+	return p
+}
+
+// WithRekorRequired sets whether signatures must be recorded in the Rekor transparency log.
+// Disabling it renders `--insecure-ignore-tlog=true`.
+func (p *VerifyPolicy) WithRekorRequired(required bool) *VerifyPolicy {
+	p.rekorRequired = required
+	return p
+}
+
+// hasIdentity reports whether the policy constrains both the identity and the issuer, as keyless
+// verification requires.
+func (p *VerifyPolicy) hasIdentity() bool {
+	return (p.identity != "" || p.identityRegexp != "") && (p.issuer != "" || p.issuerRegexp != "")
+}
+
+// validate checks that the policy constraints are consistent and the expressions compile.
+func (p *VerifyPolicy) validate() error {
+	if p.identity != "" && p.identityRegexp != "" {
+		return errorsx.ConflictingOptions(builderName, []string{"identity", "identityRegexp"},
+			"identity and identity regexp are mutually exclusive")
+	}
+
+	if p.issuer != "" && p.issuerRegexp != "" {
+		return errorsx.ConflictingOptions(builderName, []string{"issuer", "issuerRegexp"},
+			"issuer and issuer regexp are mutually exclusive")
+	}
+
+	exprs := []struct{ field, expr string }{
+		{"identityRegexp", p.identityRegexp},
+		{"issuerRegexp", p.issuerRegexp},
+	}
+	for _, e := range exprs {
+		if _, err := regexp.Compile(e.expr); err != nil {
+			return errorsx.InvalidValue(builderName, e.field, e.expr, "invalid regular expression: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Flags renders the policy into the `cosign verify` flags enforcing it.
+//
+// Returns:
+//   - The cosign verify flags.
+//   - An error if exclusive constraints are combined or a regular expression is invalid.
+func (p *VerifyPolicy) Flags() ([]string, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	var flags []string
+
+	if p.identity != "" {
+		flags = append(flags, "--certificate-identity", p.identity)
+	}
+
+	if p.identityRegexp != "" {
+		flags = append(flags, "--certificate-identity-regexp", p.identityRegexp)
+	}
+
+	if p.issuer != "" {
+		flags = append(flags, "--certificate-oidc-issuer", p.issuer)
+	}
+
+	if p.issuerRegexp != "" {
+		flags = append(flags, "--certificate-oidc-issuer-regexp", p.issuerRegexp)
+	}
+
+	flags = append(flags, mapx.Flags("--annotations", p.annotations, "=")...)
+
+	if !p.rekorRequired {
+		flags = append(flags, "--insecure-ignore-tlog=true")
+	}
+
+	return flags, nil
+}
+
+// Signature is a signature verified by cosign, as reported by its JSON output.
+type Signature struct {
+	// DockerReference is the reference of the signed image.
+	DockerReference string
+	// ManifestDigest is the digest of the signed image manifest.
+	ManifestDigest string
+	// Identity is the certificate identity (subject) of keyless signatures.
+	Identity string
+	// Issuer is the certificate OIDC issuer of keyless signatures.
+	Issuer string
+	// Annotations holds the annotations of the signature.
+	Annotations map[string]string
+	// InTransparencyLog reports whether the signature carries a Rekor bundle.
+	InTransparencyLog bool
+}
+
+// verifyOutput is a signature entry of the cosign verify JSON output.
+type verifyOutput struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+	Optional map[string]any `json:"optional"`
+}
+
+// ParseVerifyOutput parses the JSON output of cosign verify, either a JSON array of signatures or
+// one JSON document per signature.
+//
+// Returns:
+//   - The verified signatures.
+//   - An error if the output is not valid JSON.
+func ParseVerifyOutput(data []byte) ([]Signature, error) {
+	var entries []verifyOutput
+
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse cosign verify output: %w", err)
+		}
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		for {
+			var entry verifyOutput
+			if err := dec.Decode(&entry); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to parse cosign verify output: %w", err)
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	signatures := make([]Signature, 0, len(entries))
+	for _, e := range entries {
+		sig := Signature{
+			DockerReference: e.Critical.Identity.DockerReference,
+			ManifestDigest:  e.Critical.Image.DockerManifestDigest,
+			Annotations:     map[string]string{},
+		}
+
+		for k, v := range e.Optional {
+			switch k {
+			case "Subject":
+				sig.Identity, _ = v.(string)
+			case "Issuer":
+				sig.Issuer, _ = v.(string)
+			case "Bundle":
+				sig.InTransparencyLog = v != nil
+			default:
+				if s, ok := v.(string); ok {
+					sig.Annotations[k] = s
+				}
+			}
+		}
+
+		signatures = append(signatures, sig)
+	}
+
+	return signatures, nil
+}
+
+// Evaluate evaluates the policy against the JSON output of cosign verify. The evaluation passes
+// when at least one signature satisfies every constraint; otherwise the violations of each
+// signature are reported.
+//
+// Returns:
+//   - The evaluation result.
+//   - An error if the policy is invalid or the output cannot be parsed.
+func (p *VerifyPolicy) Evaluate(output []byte) (policyx.Result, error) {
+	if err := p.validate(); err != nil {
+		return policyx.Result{}, err
+	}
+
+	signatures, err := ParseVerifyOutput(output)
+	if err != nil {
+		return policyx.Result{}, err
+	}
+
+	if len(signatures) == 0 {
+		return policyx.Result{Violations: []policyx.Violation{{Rule: RuleSignature, Message: "no signature found"}}}, nil
+	}
+
+	var violations []policyx.Violation
+	for i, sig := range signatures {
+		sigViolations := p.evaluateSignature(sig)
+		if len(sigViolations) == 0 {
+			return policyx.Result{Passed: true}, nil
+		}
+
+		for _, v := range sigViolations {
+			v.Message = fmt.Sprintf("signature %d: %s", i, v.Message)
+			violations = append(violations, v)
+		}
+	}
+
+	return policyx.Result{Violations: violations}, nil
+}
+
+// evaluateSignature returns the constraints 'sig' does not satisfy.
+func (p *VerifyPolicy) evaluateSignature(sig Signature) []policyx.Violation {
+	var violations []policyx.Violation
+
+	switch {
+	case p.identity != "" && sig.Identity != p.identity:
+		violations = append(violations, policyx.Violation{
+			Rule:    RuleIdentity,
+			Message: fmt.Sprintf("identity %q is not %q", sig.Identity, p.identity),
+		})
+	case p.identityRegexp != "" && !regexp.MustCompile(p.identityRegexp).MatchString(sig.Identity):
+		violations = append(violations, policyx.Violation{
+			Rule:    RuleIdentity,
+			Message: fmt.Sprintf("identity %q does not match %q", sig.Identity, p.identityRegexp),
+		})
+	}
+
+	switch {
+	case p.issuer != "" && sig.Issuer != p.issuer:
+		violations = append(violations, policyx.Violation{
+			Rule:    RuleIssuer,
+			Message: fmt.Sprintf("issuer %q is not %q", sig.Issuer, p.issuer),
+		})
+	case p.issuerRegexp != "" && !regexp.MustCompile(p.issuerRegexp).MatchString(sig.Issuer):
+		violations = append(violations, policyx.Violation{
+			Rule:    RuleIssuer,
+			Message: fmt.Sprintf("issuer %q does not match %q", sig.Issuer, p.issuerRegexp),
+		})
+	}
+
+	for _, k := range mapx.SortedKeys(p.annotations) {
+		if got, ok := sig.Annotations[k]; !ok || got != p.annotations[k] {
+			violations = append(violations, policyx.Violation{
+				Rule:    RuleAnnotation,
+				Message: fmt.Sprintf("annotation %s=%s is required", k, p.annotations[k]),
+			})
+		}
+	}
+
+	if p.rekorRequired && !sig.InTransparencyLog {
+		violations = append(violations, policyx.Violation{
+			Rule:    RuleRekor,
+			Message: "signature is not recorded in the Rekor transparency log",
+		})
+	}
+
+	return violations
+}
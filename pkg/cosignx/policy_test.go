@@ -0,0 +1,125 @@
+package cosignx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testVerifyOutput = `[
+  {
+    "critical": {
+      "identity": {"docker-reference": "registry.example.com/app"},
+      "image": {"docker-manifest-digest": "sha256:abc"},
+      "type": "cosign container image signature"
+    },
+    "optional": {
+      "Bundle": {"SignedEntryTimestamp": "MEUC", "Payload": {"logIndex": 42}},
+      "Issuer": "https://token.actions.githubusercontent.com",
+      "Subject": "https://github.com/example/app/.github/workflows/release.yaml@refs/heads/main",
+      "env": "prod"
+    }
+  }
+]`
+
+func TestVerifyPolicyFlags(t *testing.T) {
+	flags, err := NewVerifyPolicy().
+		WithIdentity("release@example.com").
+		WithIssuerRegexp(`^https://accounts\.example\.com$`).
+		WithAnnotation("env", "prod").
+		WithAnnotation("commit", "abc123").
+		WithRekorRequired(false).
+		Flags()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"--certificate-identity", "release@example.com",
+		"--certificate-oidc-issuer-regexp", `^https://accounts\.example\.com$`,
+		"--annotations", "commit=abc123",
+		"--annotations", "env=prod",
+		"--insecure-ignore-tlog=true",
+	}, flags)
+
+	_, err = NewVerifyPolicy().WithIdentity("a").WithIdentityRegexp("b").Flags()
+	assert.True(t, errors.Is(err, errorsx.ErrConflictingOptions))
+
+	_, err = NewVerifyPolicy().WithIdentityRegexp("(").Flags()
+	field, _ := errorsx.FieldOf(err)
+	assert.Equal(t, "identityRegexp", field)
+}
+
+func TestParseVerifyOutput(t *testing.T) {
+	sigs, err := ParseVerifyOutput([]byte(testVerifyOutput))
+	require.NoError(t, err)
+	require.Len(t, sigs, 1)
+	assert.Equal(t, Signature{
+		DockerReference:   "registry.example.com/app",
+		ManifestDigest:    "sha256:abc",
+		Identity:          "https://github.com/example/app/.github/workflows/release.yaml@refs/heads/main",
+		Issuer:            "https://token.actions.githubusercontent.com",
+		Annotations:       map[string]string{"env": "prod"},
+		InTransparencyLog: true,
+	}, sigs[0])
+
+	sigs, err = ParseVerifyOutput([]byte(`{"optional": {"Subject": "a"}}
+{"optional": {"Subject": "b"}}`))
+	require.NoError(t, err)
+	require.Len(t, sigs, 2)
+	assert.Equal(t, "b", sigs[1].Identity)
+
+	_, err = ParseVerifyOutput([]byte("[{"))
+	assert.Error(t, err)
+}
+
+func TestVerifyPolicyEvaluate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *VerifyPolicy
+		passed bool
+		rules  []string
+	}{
+		{
+			name: "Matching identity",
+			policy: NewVerifyPolicy().
+				WithIdentityRegexp(`^https://github\.com/example/`).
+				WithIssuer("https://token.actions.githubusercontent.com").
+				WithAnnotation("env", "prod"),
+			passed: true,
+		},
+		{
+			name:   "Wrong identity",
+			policy: NewVerifyPolicy().WithIdentityRegexp(`^https://github\.com/other/`),
+			rules:  []string{"certificate-identity"},
+		},
+		{
+			name:   "Wrong issuer and missing annotation",
+			policy: NewVerifyPolicy().WithIssuer("https://accounts.google.com").WithAnnotation("env", "dev"),
+			rules:  []string{"certificate-oidc-issuer", "annotation"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.policy.Evaluate([]byte(testVerifyOutput))
+			require.NoError(t, err)
+			assert.Equal(t, tt.passed, result.Passed)
+
+			var rules []string
+			for _, v := range result.Violations {
+				rules = append(rules, string(v.Rule))
+			}
+			assert.Equal(t, tt.rules, rules)
+		})
+	}
+
+	result, err := NewVerifyPolicy().Evaluate([]byte(`[{"optional": {}}]`))
+	require.NoError(t, err)
+	assert.False(t, result.Passed)
+	assert.EqualError(t, result.Err(), "policy failed: [rekor] signature 0: signature is not recorded in the Rekor transparency log")
+
+	result, err = NewVerifyPolicy().Evaluate([]byte(`[]`))
+	require.NoError(t, err)
+	assert.False(t, result.Passed)
+}
@@ -0,0 +1,261 @@
+// Package cranex provides command generators for crane, the tool interacting with container
+// registries, and parsers for the output of its digest and manifest commands, so pipelines can
+// decide in Go whether an image was already pushed or provides the expected platforms.
+//
+// Example usage:
+//
+//	res, err := executor.Run(ctx, cranex.DigestCommand("registry.example.com/app:v1", ""), nil, nil)
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	if cranex.IsNotFound(res.Stderr) {
+//	    // the image was never pushed
+//	}
+//
+//	pushed, err := cranex.DigestMatches(res.Stdout, localDigest)
+package cranex
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// builderName identifies the crane helpers in errorsx errors.
+const builderName = "crane"
+
+// CraneDefaultImage is the default container image providing crane.
+const CraneDefaultImage = "gcr.io/go-containerregistry/crane"
+
+// Media types of the manifests returned by crane manifest.
+const (
+	// MediaTypeOCIIndex is the media type of OCI image indexes.
+	MediaTypeOCIIndex = "application/vnd.oci.image.index.v1+json"
+	// MediaTypeOCIManifest is the media type of OCI image manifests.
+	MediaTypeOCIManifest = "application/vnd.oci.image.manifest.v1+json"
+	// MediaTypeDockerManifestList is the media type of Docker manifest lists.
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	// MediaTypeDockerManifest is the media type of Docker image manifests.
+	MediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// digestRegex matches sha256 digests.
+var digestRegex = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// notFoundMarkers are the registry errors crane reports for missing images.
+var notFoundMarkers = []string{"MANIFEST_UNKNOWN", "NAME_UNKNOWN", "404 Not Found"}
+
+// DigestCommand generates the `crane digest` command of the image 'ref'. A non-empty platform
+// (e.g. "linux/arm64") resolves the digest of that platform instead of the index.
+func DigestCommand(ref, platform string) []string {
+	cmd := []string{"crane", "digest"}
+	if platform != "" {
+		cmd = append(cmd, "--platform", platform)
+	}
+
+	return append(cmd, ref)
+}
+
+// ManifestCommand generates the `crane manifest` command of the image 'ref'. A non-empty
+// platform (e.g. "linux/arm64") fetches the manifest of that platform instead of the index.
+func ManifestCommand(ref, platform string) []string {
+	cmd := []string{"crane", "manifest"}
+	if platform != "" {
+		cmd = append(cmd, "--platform", platform)
+	}
+
+	return append(cmd, ref)
+}
+
+// CopyCommand generates the `crane copy` command copying the image 'src', with every platform,
+// to 'dst'. Registries on localhost are reached over plain HTTP.
+func CopyCommand(src, dst string) []string {
+	return []string{"crane", "copy", src, dst}
+}
+
+// ParseDigest parses the output of crane digest.
+//
+// Returns:
+//   - The digest.
+//   - An error if the output is not a single sha256 digest.
+func ParseDigest(output string) (string, error) {
+	digest := strings.TrimSpace(output)
+	if !digestRegex.MatchString(digest) {
+		return "", errorsx.InvalidValue(builderName, "digest", digest, "crane digest output is not a sha256 digest: %q", digest)
+	}
+
+	return digest, nil
+}
+
+// DigestMatches reports whether the output of crane digest is the digest 'expected', i.e.
+// whether the image was already pushed.
+//
+// Returns:
+//   - Whether the digests match.
+//   - An error if the output is not a digest.
+func DigestMatches(output, expected string) (bool, error) {
+	digest, err := ParseDigest(output)
+	if err != nil {
+		return false, err
+	}
+
+	return digest == strings.TrimSpace(expected), nil
+}
+
+// IsNotFound reports whether the standard error of a crane command reports a missing image.
+func IsNotFound(stderr string) bool {
+	for _, marker := range notFoundMarkers {
+		if strings.Contains(stderr, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Platform is the platform of an image in an index.
+type Platform struct {
+	// OS is the operating system (e.g. "linux").
+	OS string `json:"os"`
+	// Architecture is the CPU architecture (e.g. "arm64").
+	Architecture string `json:"architecture"`
+	// Variant is the CPU variant (e.g. "v8").
+	Variant string `json:"variant,omitempty"`
+}
+
+// String returns the platform as os/arch[/variant].
+func (p Platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+
+	return s
+}
+
+// Descriptor describes content referenced by a manifest.
+type Descriptor struct {
+	// MediaType is the media type of the content.
+	MediaType string `json:"mediaType"`
+	// Digest is the digest of the content.
+	Digest string `json:"digest"`
+	// Size is the size of the content, in bytes.
+	Size int64 `json:"size"`
+	// Platform is the platform of indexed images.
+	Platform *Platform `json:"platform,omitempty"`
+	// Annotations holds the annotations of the content.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Manifest is an image manifest or index, as printed by crane manifest.
+type Manifest struct {
+	// SchemaVersion is the version of the manifest schema.
+	SchemaVersion int `json:"schemaVersion"`
+	// MediaType is the media type of the manifest.
+	MediaType string `json:"mediaType"`
+	// Config is the image configuration of image manifests.
+	Config *Descriptor `json:"config,omitempty"`
+	// Layers holds the layers of image manifests.
+	Layers []Descriptor `json:"layers,omitempty"`
+	// Manifests holds the indexed manifests of indexes.
+	Manifests []Descriptor `json:"manifests,omitempty"`
+	// Annotations holds the annotations of the manifest.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ParseManifest parses the output of crane manifest. Manifests without a media type are
+// detected as indexes or image manifests from their content.
+//
+// Returns:
+//   - The parsed manifest.
+//   - An error if the output is not a valid manifest.
+func ParseManifest(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse crane manifest output: %w", err)
+	}
+
+	if m.SchemaVersion != 2 {
+		return nil, errorsx.Unsupported(builderName, "schemaVersion", m.SchemaVersion,
+			"unsupported manifest schema version %d", m.SchemaVersion)
+	}
+
+	if m.MediaType == "" {
+		switch {
+		case m.Manifests != nil:
+			m.MediaType = MediaTypeOCIIndex
+		case m.Config != nil:
+			m.MediaType = MediaTypeOCIManifest
+		default:
+			return nil, errorsx.InvalidValue(builderName, "mediaType", "", "manifest is neither an index nor an image manifest")
+		}
+	}
+
+	return &m, nil
+}
+
+// IsIndex reports whether the manifest is an index of per-platform manifests.
+func (m *Manifest) IsIndex() bool {
+	return m.MediaType == MediaTypeOCIIndex || m.MediaType == MediaTypeDockerManifestList
+}
+
+// Platforms returns the platforms of the indexed images, as os/arch[/variant], in index order.
+// Entries without a platform, such as attestation manifests, are skipped.
+func (m *Manifest) Platforms() []string {
+	var platforms []string
+	for _, d := range m.Manifests {
+		if d.Platform != nil && d.Platform.OS != "unknown" {
+			platforms = append(platforms, d.Platform.String())
+		}
+	}
+
+	return platforms
+}
+
+// HasPlatform reports whether the index provides an image for 'platform' (os/arch[/variant]).
+func (m *Manifest) HasPlatform(platform string) bool {
+	for _, p := range m.Platforms() {
+		if p == platform {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MissingPlatforms returns the platforms of 'required' the index does not provide.
+func (m *Manifest) MissingPlatforms(required ...string) []string {
+	var missing []string
+	for _, p := range required {
+		if !m.HasPlatform(p) {
+			missing = append(missing, p)
+		}
+	}
+
+	return missing
+}
+
+// PlatformDigest returns the digest of the image of 'platform' in the index.
+func (m *Manifest) PlatformDigest(platform string) (string, bool) {
+	for _, d := range m.Manifests {
+		if d.Platform != nil && d.Platform.String() == platform {
+			return d.Digest, true
+		}
+	}
+
+	return "", false
+}
+
+// LayerDigests returns the digests of the layers of an image manifest, in order.
+func (m *Manifest) LayerDigests() []string {
+	digests := make([]string, 0, len(m.Layers))
+	for _, l := range m.Layers {
+		digests = append(digests, l.Digest)
+	}
+
+	return digests
+}
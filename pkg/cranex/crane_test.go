@@ -0,0 +1,96 @@
+package cranex
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testDigest = "sha256:" + strings.Repeat("a", 64)
+
+const testIndex = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.index.v1+json",
+  "manifests": [
+    {"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:amd", "size": 100, "platform": {"os": "linux", "architecture": "amd64"}},
+    {"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:arm", "size": 100, "platform": {"os": "linux", "architecture": "arm64", "variant": "v8"}},
+    {"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:att", "size": 50, "platform": {"os": "unknown", "architecture": "unknown"}}
+  ]
+}`
+
+const testImageManifest = `{
+  "schemaVersion": 2,
+  "config": {"mediaType": "application/vnd.oci.image.config.v1+json", "digest": "sha256:cfg", "size": 10},
+  "layers": [
+    {"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": "sha256:l1", "size": 1000},
+    {"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": "sha256:l2", "size": 2000}
+  ]
+}`
+
+func TestCommands(t *testing.T) {
+	assert.Equal(t, []string{"crane", "digest", "img:v1"}, DigestCommand("img:v1", ""))
+	assert.Equal(t, []string{"crane", "digest", "--platform", "linux/arm64", "img:v1"}, DigestCommand("img:v1", "linux/arm64"))
+	assert.Equal(t, []string{"crane", "manifest", "img:v1"}, ManifestCommand("img:v1", ""))
+	assert.Equal(t, []string{"crane", "manifest", "--platform", "linux/amd64", "img:v1"}, ManifestCommand("img:v1", "linux/amd64"))
+	assert.Equal(t, []string{"crane", "copy", "img:v1", "localhost:5000/img:v1"}, CopyCommand("img:v1", "localhost:5000/img:v1"))
+}
+
+func TestParseDigest(t *testing.T) {
+	digest, err := ParseDigest(testDigest + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, testDigest, digest)
+
+	_, err = ParseDigest("Error: MANIFEST_UNKNOWN")
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+
+	match, err := DigestMatches(testDigest+"\n", testDigest)
+	require.NoError(t, err)
+	assert.True(t, match)
+
+	match, err = DigestMatches(testDigest, "sha256:"+strings.Repeat("b", 64))
+	require.NoError(t, err)
+	assert.False(t, match)
+}
+
+func TestIsNotFound(t *testing.T) {
+	assert.True(t, IsNotFound(`Error: GET https://registry.example.com/v2/app/manifests/v1: MANIFEST_UNKNOWN: manifest unknown`))
+	assert.False(t, IsNotFound("Error: UNAUTHORIZED: authentication required"))
+}
+
+func TestParseManifestIndex(t *testing.T) {
+	m, err := ParseManifest([]byte(testIndex))
+	require.NoError(t, err)
+	assert.True(t, m.IsIndex())
+	assert.Equal(t, []string{"linux/amd64", "linux/arm64/v8"}, m.Platforms())
+	assert.True(t, m.HasPlatform("linux/arm64/v8"))
+	assert.Equal(t, []string{"linux/s390x"}, m.MissingPlatforms("linux/amd64", "linux/s390x"))
+
+	digest, ok := m.PlatformDigest("linux/amd64")
+	assert.True(t, ok)
+	assert.Equal(t, "sha256:amd", digest)
+}
+
+func TestParseManifestImage(t *testing.T) {
+	m, err := ParseManifest([]byte(testImageManifest))
+	require.NoError(t, err)
+	assert.False(t, m.IsIndex())
+	assert.Equal(t, MediaTypeOCIManifest, m.MediaType)
+	assert.Equal(t, "sha256:cfg", m.Config.Digest)
+	assert.Equal(t, []string{"sha256:l1", "sha256:l2"}, m.LayerDigests())
+	assert.Empty(t, m.Platforms())
+}
+
+func TestParseManifestErrors(t *testing.T) {
+	_, err := ParseManifest([]byte("{"))
+	assert.Error(t, err)
+
+	_, err = ParseManifest([]byte(`{"schemaVersion": 1}`))
+	assert.True(t, errors.Is(err, errorsx.ErrUnsupported))
+
+	_, err = ParseManifest([]byte(`{"schemaVersion": 2}`))
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+}
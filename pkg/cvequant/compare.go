@@ -0,0 +1,167 @@
+package cvequant
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/policyx"
+)
+
+// RuleNewFinding limits the severity of the vulnerabilities new since the previous scan.
+const RuleNewFinding policyx.Rule = "new-finding"
+
+// Comparison is the difference between the previous and the current scan of an image.
+type Comparison struct {
+	// Image is the scanned image repository.
+	Image string `json:"image"`
+	// PreviousDigest is the digest of the previous scan, empty without previous scan.
+	PreviousDigest string `json:"previousDigest,omitempty"`
+	// CurrentDigest is the digest of the current scan.
+	CurrentDigest string `json:"currentDigest"`
+	// Baseline reports whether there was no previous scan: every finding is then new.
+	Baseline bool `json:"baseline"`
+	// New holds the findings of the current scan absent from the previous one.
+	New []policyx.Finding `json:"new,omitempty"`
+	// Fixed holds the findings of the previous scan absent from the current one.
+	Fixed []policyx.Finding `json:"fixed,omitempty"`
+	// Persisting holds the findings of both scans, as reported by the current one.
+	Persisting []policyx.Finding `json:"persisting,omitempty"`
+}
+
+// findingKey identifies a vulnerability of a package across scans: upgrading a package that
+// remains vulnerable doesn't make its vulnerability new.
+type findingKey struct {
+	id  string
+	pkg string
+}
+
+// Compare compares the current scan summary 'current' to the previous one, 'previous', which
+// may be nil for the first scan of an image. Findings are matched by vulnerability and package.
+func Compare(previous, current *Summary) Comparison {
+	c := Comparison{Baseline: previous == nil}
+	if current != nil {
+		c.Image, c.CurrentDigest = current.Image, current.Digest
+	}
+
+	prev := map[findingKey]bool{}
+	if previous != nil {
+		c.PreviousDigest = previous.Digest
+		for _, f := range previous.Findings {
+			prev[findingKey{f.ID, f.Package}] = true
+		}
+	}
+
+	cur := map[findingKey]bool{}
+	if current != nil {
+		for _, f := range current.Findings {
+			key := findingKey{f.ID, f.Package}
+			if cur[key] {
+				continue
+			}
+			cur[key] = true
+
+			if prev[key] {
+				c.Persisting = append(c.Persisting, f)
+			} else {
+				c.New = append(c.New, f)
+			}
+		}
+	}
+
+	if previous != nil {
+		fixed := map[findingKey]bool{}
+		for _, f := range previous.Findings {
+			key := findingKey{f.ID, f.Package}
+			if !cur[key] && !fixed[key] {
+				fixed[key] = true
+				c.Fixed = append(c.Fixed, f)
+			}
+		}
+	}
+
+	sortFindings(c.New)
+	sortFindings(c.Fixed)
+	sortFindings(c.Persisting)
+
+	return c
+}
+
+// Delta returns the change of the number of findings per severity, from the previous to the
+// current scan, leaving out the severities without change.
+func (c Comparison) Delta() map[string]int {
+	delta := map[string]int{}
+	for _, f := range c.New {
+		delta[f.Severity.String()]++
+	}
+
+	for _, f := range c.Fixed {
+		delta[f.Severity.String()]--
+	}
+
+	for k, v := range delta {
+		if v == 0 {
+			delete(delta, k)
+		}
+	}
+
+	return delta
+}
+
+// String describes the comparison (e.g. "2 new (1 critical, 1 high), 3 fixed, 12 persisting").
+func (c Comparison) String() string {
+	s := fmt.Sprintf("%d new", len(c.New))
+	if counts := countBySeverity(c.New); counts != "" {
+		s += " (" + counts + ")"
+	}
+
+	return fmt.Sprintf("%s, %d fixed, %d persisting", s, len(c.Fixed), len(c.Persisting))
+}
+
+// countBySeverity describes the number of findings per severity, the most severe first.
+func countBySeverity(findings []policyx.Finding) string {
+	counts := map[policyx.Severity]int{}
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+
+	severities := make([]policyx.Severity, 0, len(counts))
+	for s := range counts {
+		severities = append(severities, s)
+	}
+	sort.Slice(severities, func(i, j int) bool { return severities[i] > severities[j] })
+
+	parts := make([]string, len(severities))
+	for i, s := range severities {
+		parts[i] = fmt.Sprintf("%d %s", counts[s], s)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Result evaluates the comparison: every new finding above 'maxSeverity' is a RuleNewFinding
+// violation, the most severe first. Result(policyx.SeverityHigh) is the "no new criticals"
+// gate. Without previous scan, every finding is new.
+func (c Comparison) Result(maxSeverity policyx.Severity) policyx.Result {
+	result := policyx.Result{Passed: true}
+	for _, f := range c.New {
+		if f.Severity <= maxSeverity {
+			continue
+		}
+
+		since := "is new"
+		if c.PreviousDigest != "" {
+			since = "is new since " + c.PreviousDigest
+		}
+
+		result.Violations = append(result.Violations, policyx.Violation{
+			Rule: RuleNewFinding,
+			Message: fmt.Sprintf("%s in %s %s, with severity %s, above the maximum of %s",
+				f.ID, strings.TrimSpace(f.Package+" "+f.Version), since, f.Severity, maxSeverity),
+		})
+	}
+
+	result.Passed = len(result.Violations) == 0
+
+	return result
+}
@@ -0,0 +1,69 @@
+package cvequant
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	previous := newTestSummary(t, digestA, day,
+		policyx.Finding{ID: "CVE-2024-0001", Package: "openssl", Version: "3.1.0", Severity: policyx.SeverityHigh},
+		policyx.Finding{ID: "CVE-2024-0002", Package: "zlib", Version: "1.3", Severity: policyx.SeverityLow},
+	)
+	current := newTestSummary(t, digestB, day.Add(time.Hour),
+		policyx.Finding{ID: "CVE-2024-0001", Package: "openssl", Version: "3.1.1", Severity: policyx.SeverityHigh},
+		policyx.Finding{ID: "CVE-2024-0003", Package: "curl", Version: "8.5", Severity: policyx.SeverityCritical},
+		policyx.Finding{ID: "CVE-2024-0004", Package: "busybox", Version: "1.36", Severity: policyx.SeverityMedium},
+	)
+
+	c := Compare(previous, current)
+	assert.Equal(t, "registry.example.com/app", c.Image)
+	assert.Equal(t, digestA, c.PreviousDigest)
+	assert.Equal(t, digestB, c.CurrentDigest)
+	assert.False(t, c.Baseline)
+	assert.Equal(t, []policyx.Finding{
+		{ID: "CVE-2024-0003", Package: "curl", Version: "8.5", Severity: policyx.SeverityCritical},
+		{ID: "CVE-2024-0004", Package: "busybox", Version: "1.36", Severity: policyx.SeverityMedium},
+	}, c.New)
+	assert.Equal(t, []policyx.Finding{
+		{ID: "CVE-2024-0002", Package: "zlib", Version: "1.3", Severity: policyx.SeverityLow},
+	}, c.Fixed)
+	assert.Equal(t, []policyx.Finding{
+		{ID: "CVE-2024-0001", Package: "openssl", Version: "3.1.1", Severity: policyx.SeverityHigh},
+	}, c.Persisting)
+	assert.Equal(t, map[string]int{"critical": 1, "medium": 1, "low": -1}, c.Delta())
+	assert.Equal(t, "2 new (1 critical, 1 medium), 1 fixed, 1 persisting", c.String())
+
+	result := c.Result(policyx.SeverityHigh)
+	assert.False(t, result.Passed)
+	assert.Equal(t, []policyx.Violation{{
+		Rule: RuleNewFinding,
+		Message: "CVE-2024-0003 in curl 8.5 is new since " + digestA +
+			", with severity critical, above the maximum of high",
+	}}, result.Violations)
+
+	assert.True(t, c.Result(policyx.SeverityCritical).Passed)
+	assert.Len(t, c.Result(policyx.SeverityLow).Violations, 2)
+}
+
+func TestCompareBaseline(t *testing.T) {
+	current := newTestSummary(t, digestB, time.Time{},
+		policyx.Finding{ID: "CVE-2024-0003", Package: "curl", Severity: policyx.SeverityCritical},
+	)
+
+	c := Compare(nil, current)
+	assert.True(t, c.Baseline)
+	assert.Len(t, c.New, 1)
+	assert.Empty(t, c.Fixed)
+	assert.Equal(t, []policyx.Violation{{
+		Rule:    RuleNewFinding,
+		Message: "CVE-2024-0003 in curl is new, with severity critical, above the maximum of high",
+	}}, c.Result(policyx.SeverityHigh).Violations)
+
+	assert.Equal(t, "0 new, 0 fixed, 1 persisting", Compare(current, current).String())
+}
@@ -0,0 +1,313 @@
+// Package cvequant persists the vulnerability scan summaries of images across pipeline runs,
+// keyed by image and digest, and compares the current scan of an image to the previous one, so
+// pipelines can gate on what changed rather than on the absolute state: new vulnerabilities fail
+// the build ("no new criticals"), while the known ones are tracked as debt.
+//
+// Summaries are JSON files under a results directory, one per image and digest, so the directory
+// can be exported as an artifact at the end of a run and imported at the start of the next one.
+//
+// Example usage:
+//
+//	store := cvequant.NewStore("/mnt/scan-results")
+//
+//	current, err := cvequant.NewSummary("registry.example.com/app", digest, "grype", findings)
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	previous, err := store.Latest(current.Image)
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	comparison := cvequant.Compare(previous, current)
+//	if _, err := store.Save(current); err != nil {
+//	    // handle error
+//	}
+//
+//	if result := comparison.Result(policyx.SeverityHigh); !result.Passed {
+//	    return result.Err()
+//	}
+package cvequant
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/ioutilx"
+	"github.com/Excoriate/daggerx/pkg/namingx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+)
+
+// builderName identifies the scan trend helpers in errorsx errors.
+const builderName = "cvequant"
+
+// digestRegex matches sha256 digests.
+var digestRegex = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// Summary is the persisted summary of the vulnerability scan of an image digest.
+type Summary struct {
+	// Image is the scanned image repository (e.g. "registry.example.com/app").
+	Image string `json:"image"`
+	// Digest is the digest of the scanned image.
+	Digest string `json:"digest"`
+	// Scanner is the name of the scanner (e.g. "grype").
+	Scanner string `json:"scanner"`
+	// GeneratedAt is the time of the scan.
+	GeneratedAt time.Time `json:"generatedAt"`
+	// Counts holds the number of findings per severity.
+	Counts map[string]int `json:"counts"`
+	// Findings holds the findings, deduplicated and sorted by severity, ID and package.
+	Findings []policyx.Finding `json:"findings,omitempty"`
+}
+
+// NewSummary creates the summary of the scan of 'image' at 'digest' by 'scanner', generated now.
+//
+// Returns:
+//   - The summary.
+//   - An error if the image is missing or the digest is not a sha256 digest.
+func NewSummary(image, digest, scanner string, findings []policyx.Finding) (*Summary, error) {
+	if err := validateKey(image, digest); err != nil {
+		return nil, err
+	}
+
+	s := &Summary{Image: image, Digest: digest, Scanner: scanner, GeneratedAt: time.Now().UTC()}
+	s.setFindings(findings)
+
+	return s, nil
+}
+
+// validateKey checks the image and digest keying a summary.
+func validateKey(image, digest string) error {
+	if image == "" {
+		return errorsx.MissingField(builderName, "image", "image is required")
+	}
+
+	if !digestRegex.MatchString(digest) {
+		return errorsx.InvalidValue(builderName, "digest", digest, "digest must be a sha256 digest: %q", digest)
+	}
+
+	return nil
+}
+
+// FromScanResult creates the summary of the scan result 'scan' of a report, for 'image' at
+// 'digest'. See NewSummary.
+func FromScanResult(image, digest string, scan reportx.ScanResult) (*Summary, error) {
+	return NewSummary(image, digest, scan.Scanner, scan.Findings)
+}
+
+// setFindings sets the findings of the summary, deduplicated and sorted, and their counts.
+func (s *Summary) setFindings(findings []policyx.Finding) {
+	seen := map[policyx.Finding]bool{}
+	s.Findings = nil
+	s.Counts = map[string]int{}
+
+	for _, f := range findings {
+		if seen[f] {
+			continue
+		}
+		seen[f] = true
+
+		s.Findings = append(s.Findings, f)
+		s.Counts[f.Severity.String()]++
+	}
+
+	sortFindings(s.Findings)
+}
+
+// sortFindings sorts 'findings' by decreasing severity, then ID, package and version.
+func sortFindings(findings []policyx.Finding) {
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity != b.Severity {
+			return a.Severity > b.Severity
+		}
+
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+
+		return a.Version < b.Version
+	})
+}
+
+// Store persists the summaries under a results directory: one subdirectory per image, named
+// after it, holding one file per digest.
+type Store struct {
+	// dir is the results directory.
+	dir string
+}
+
+// NewStore creates a Store of the results directory 'dir'.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the results directory.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Artifact returns the results directory as a report artifact, to export at the end of a run and
+// import at the start of the next one.
+func (s *Store) Artifact() artifactx.Artifact {
+	return artifactx.Artifact{Kind: artifactx.KindReport, Path: s.dir, Directory: true}
+}
+
+// Path returns the path of the summary of 'image' at 'digest'.
+//
+// Returns:
+//   - The path of the summary.
+//   - An error if the image is missing or has no valid file name character, or the digest is not
+//     a sha256 digest.
+func (s *Store) Path(image, digest string) (string, error) {
+	if err := validateKey(image, digest); err != nil {
+		return "", err
+	}
+
+	dir, err := s.imageDir(image)
+	if err != nil {
+		return "", err
+	}
+
+	name, err := namingx.Format(namingx.ArtifactName, digest)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, name+".json"), nil
+}
+
+// imageDir returns the directory of the summaries of 'image'.
+func (s *Store) imageDir(image string) (string, error) {
+	name, err := namingx.Format(namingx.ArtifactName, image)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(s.dir, name), nil
+}
+
+// Save writes 'summary', replacing the summary of the same image and digest.
+//
+// Returns:
+//   - The path of the written summary.
+//   - An error if the summary is invalid or can't be written.
+func (s *Store) Save(summary *Summary) (string, error) {
+	if summary == nil {
+		return "", errorsx.MissingField(builderName, "summary", "summary is required")
+	}
+
+	path, err := s.Path(summary.Image, summary.Digest)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize scan summary of %s: %w", summary.Image, err)
+	}
+
+	if err := ioutilx.WriteFileAtomic(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write scan summary of %s: %w", summary.Image, err)
+	}
+
+	return path, nil
+}
+
+// Load reads the summary of 'image' at 'digest'.
+//
+// Returns:
+//   - The summary, or nil if there is none.
+//   - An error if the summary can't be read or parsed.
+func (s *Store) Load(image, digest string) (*Summary, error) {
+	path, err := s.Path(image, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := readSummary(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	return summary, err
+}
+
+// History returns the summaries of 'image', from the oldest to the most recent scan.
+//
+// Returns:
+//   - The summaries, empty if the image was never saved.
+//   - An error if a summary can't be read or parsed.
+func (s *Store) History(image string) ([]*Summary, error) {
+	dir, err := s.imageDir(image)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scan summaries of %s: %w", image, err)
+	}
+
+	var history []*Summary
+	for _, p := range paths {
+		summary, err := readSummary(p)
+		if err != nil {
+			return nil, err
+		}
+
+		// Distinct images may share a directory name; only the summaries of 'image' count.
+		if summary.Image == image {
+			history = append(history, summary)
+		}
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].GeneratedAt.Before(history[j].GeneratedAt)
+	})
+
+	return history, nil
+}
+
+// Latest returns the most recent summary of 'image', to compare the current scan to.
+//
+// Returns:
+//   - The most recent summary, or nil if the image was never saved.
+//   - An error if a summary can't be read or parsed.
+func (s *Store) Latest(image string) (*Summary, error) {
+	history, err := s.History(image)
+	if err != nil || len(history) == 0 {
+		return nil, err
+	}
+
+	return history[len(history)-1], nil
+}
+
+// readSummary reads and parses the summary at 'path'.
+func readSummary(path string) (*Summary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scan summary %s: %w", path, err)
+	}
+
+	var summary Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, fmt.Errorf("invalid scan summary %s: %w", path, err)
+	}
+
+	return &summary, nil
+}
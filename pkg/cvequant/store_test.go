@@ -0,0 +1,126 @@
+package cvequant
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	digestA = "sha256:" + strings.Repeat("a", 64)
+	digestB = "sha256:" + strings.Repeat("b", 64)
+)
+
+func newTestSummary(t *testing.T, digest string, at time.Time, findings ...policyx.Finding) *Summary {
+	t.Helper()
+
+	s, err := NewSummary("registry.example.com/app", digest, "grype", findings)
+	require.NoError(t, err)
+	s.GeneratedAt = at
+
+	return s
+}
+
+func TestNewSummary(t *testing.T) {
+	s, err := NewSummary("registry.example.com/app", digestA, "grype", []policyx.Finding{
+		{ID: "CVE-2024-0002", Package: "zlib", Version: "1.3", Severity: policyx.SeverityLow},
+		{ID: "CVE-2024-0001", Package: "openssl", Version: "3.1", Severity: policyx.SeverityCritical},
+		{ID: "CVE-2024-0002", Package: "zlib", Version: "1.3", Severity: policyx.SeverityLow},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []policyx.Finding{
+		{ID: "CVE-2024-0001", Package: "openssl", Version: "3.1", Severity: policyx.SeverityCritical},
+		{ID: "CVE-2024-0002", Package: "zlib", Version: "1.3", Severity: policyx.SeverityLow},
+	}, s.Findings)
+	assert.Equal(t, map[string]int{"critical": 1, "low": 1}, s.Counts)
+	assert.False(t, s.GeneratedAt.IsZero())
+
+	_, err = NewSummary("", digestA, "grype", nil)
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+
+	_, err = NewSummary("registry.example.com/app", "latest", "grype", nil)
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+
+	s, err = FromScanResult("registry.example.com/app", digestA, reportx.ScanResult{Scanner: "trivy"})
+	require.NoError(t, err)
+	assert.Equal(t, "trivy", s.Scanner)
+}
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	latest, err := store.Latest("registry.example.com/app")
+	require.NoError(t, err)
+	assert.Nil(t, latest)
+
+	older := newTestSummary(t, digestA, day, policyx.Finding{ID: "CVE-2024-0001", Package: "openssl"})
+	newer := newTestSummary(t, digestB, day.Add(time.Hour))
+
+	path, err := store.Save(newer)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "registry.example.com-app", "sha256-"+strings.Repeat("b", 64)+".json"), path)
+
+	_, err = store.Save(older)
+	require.NoError(t, err)
+
+	latest, err = store.Latest("registry.example.com/app")
+	require.NoError(t, err)
+	assert.Equal(t, digestB, latest.Digest)
+
+	history, err := store.History("registry.example.com/app")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, older, history[0])
+
+	loaded, err := store.Load("registry.example.com/app", digestA)
+	require.NoError(t, err)
+	assert.Equal(t, older, loaded)
+
+	loaded, err = store.Load("registry.example.com/other", digestA)
+	require.NoError(t, err)
+	assert.Nil(t, loaded)
+
+	// A rescan of a digest replaces its summary.
+	rescan := newTestSummary(t, digestA, day.Add(2*time.Hour))
+	_, err = store.Save(rescan)
+	require.NoError(t, err)
+
+	latest, err = store.Latest("registry.example.com/app")
+	require.NoError(t, err)
+	assert.Equal(t, rescan, latest)
+
+	assert.Equal(t, artifactx.Artifact{Kind: artifactx.KindReport, Path: dir, Directory: true}, store.Artifact())
+	assert.Equal(t, dir, store.Dir())
+}
+
+func TestStoreErrors(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	_, err := store.Save(nil)
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+
+	_, err = store.Save(&Summary{Image: "app", Digest: "sha256:abc"})
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+
+	_, err = store.Path("///", digestA)
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(store.Dir(), "app"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(store.Dir(), "app", "broken.json"), []byte("{"), 0o644))
+
+	_, err = store.Latest("app")
+	assert.ErrorContains(t, err, "invalid scan summary")
+}
@@ -0,0 +1,369 @@
+// Package dependencytrackx provides command builders uploading SBOMs to Dependency-Track, which
+// then tracks the vulnerabilities of their components: the curl command of the BOM upload, with
+// the API key exposed as a secret, the automatic creation of the project and the tags of its
+// version, and the command polling the processing of the upload.
+//
+// Dependency-Track accepts CycloneDX BOMs only. The SPDX SBOMs apko generates are converted
+// first with ConvertCommand.
+//
+// Example usage:
+//
+//	upload := dependencytrackx.NewUploadBuilder("https://dtrack.example.com", "/mnt/sbom.cdx.json").
+//	    WithAPIKey(secretsx.FromEnv("dtrack-api-key", "DTRACK_API_KEY")).
+//	    WithProject("checkout", "1.4.0").
+//	    WithAutoCreate(true).
+//	    WithTags("team-payments", "production")
+//
+//	cmd, err := upload.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	// Run cmd with upload.Secrets(), then poll the processing of the upload.
+//	token, err := dependencytrackx.ParseUploadResponse(stdout)
+//	poll, err := upload.ProcessingCommand(token)
+package dependencytrackx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the Dependency-Track builders in errorsx errors.
+const builderName = "dependencytrack"
+
+// APIKeyEnvVar is the environment variable the commands read the API key from.
+const APIKeyEnvVar = "DTRACK_API_KEY"
+
+// uuidRegex matches the UUIDs of projects and upload tokens.
+var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ConvertCommand returns the syft command converting the SPDX SBOM at 'spdxPath' (e.g. generated
+// by apko) to the CycloneDX BOM at 'outPath'.
+func ConvertCommand(spdxPath, outPath string) []string {
+	return []string{"syft", "convert", spdxPath, "-o", "cyclonedx-json=" + outPath}
+}
+
+// UploadBuilder generates the curl commands uploading a BOM to Dependency-Track.
+type UploadBuilder struct {
+	// serverURL is the URL of the Dependency-Track API server.
+	serverURL string
+
+	// bomPath is the path of the CycloneDX BOM.
+	bomPath string
+
+	// apiKey is the secret holding the API key.
+	apiKey *secretsx.SecretRef
+
+	// projectUUID is the UUID of an existing project.
+	projectUUID string
+
+	// projectName is the name of the project.
+	projectName string
+
+	// projectVersion is the version of the project.
+	projectVersion string
+
+	// autoCreate creates the project version when it doesn't exist.
+	autoCreate bool
+
+	// tags are the tags of the project version.
+	tags []string
+
+	// parentName is the name of the parent project.
+	parentName string
+
+	// parentVersion is the version of the parent project.
+	parentVersion string
+
+	// latest marks the project version as the latest one.
+	latest bool
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewUploadBuilder creates an UploadBuilder uploading the CycloneDX BOM at 'bomPath' to the
+// Dependency-Track API server at 'serverURL'.
+func NewUploadBuilder(serverURL, bomPath string) *UploadBuilder {
+	return &UploadBuilder{serverURL: strings.TrimSuffix(serverURL, "/"), bomPath: bomPath}
+}
+
+// WithAPIKey sets the secret holding the API key, which requires the BOM_UPLOAD permission (and
+// PROJECT_CREATION_UPLOAD for automatic creation). It is exposed as APIKeyEnvVar; 'ref' is left
+// unchanged.
+func (b *UploadBuilder) WithAPIKey(ref *secretsx.SecretRef) *UploadBuilder {
+	if ref == nil {
+		b.apiKey = nil
+		return b
+	}
+
+	key := *ref
+	b.apiKey = key.AsEnv(APIKeyEnvVar)
+	return b
+}
+
+// WithProjectUUID uploads the BOM to the existing project 'uuid'.
+func (b *UploadBuilder) WithProjectUUID(uuid string) *UploadBuilder {
+	b.projectUUID = uuid
+	return b
+}
+
+// WithProject uploads the BOM to the version 'version' of the project 'name'.
+func (b *UploadBuilder) WithProject(name, version string) *UploadBuilder {
+	b.projectName = name
+	b.projectVersion = version
+	return b
+}
+
+// WithAutoCreate sets whether the project version is created when it doesn't exist.
+func (b *UploadBuilder) WithAutoCreate(autoCreate bool) *UploadBuilder {
+	b.autoCreate = autoCreate
+	return b
+}
+
+// WithTags adds tags of the project version (e.g. the team or environment).
+func (b *UploadBuilder) WithTags(tags ...string) *UploadBuilder {
+	b.tags = append(b.tags, tags...)
+	return b
+}
+
+// WithParent sets the parent project of an automatically created project version.
+func (b *UploadBuilder) WithParent(name, version string) *UploadBuilder {
+	b.parentName = name
+	b.parentVersion = version
+	return b
+}
+
+// WithLatest sets whether the project version is marked as the latest one of the project.
+func (b *UploadBuilder) WithLatest(latest bool) *UploadBuilder {
+	b.latest = latest
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *UploadBuilder) WithLogger(logger *slog.Logger) *UploadBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks the options of the upload.
+func (b *UploadBuilder) validate() error {
+	if b.serverURL == "" {
+		return errorsx.MissingField(builderName, "serverURL", "Dependency-Track server URL is required")
+	}
+
+	if u, err := url.Parse(b.serverURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errorsx.InvalidValue(builderName, "serverURL", b.serverURL, "invalid Dependency-Track server URL: %q", b.serverURL)
+	}
+
+	if b.bomPath == "" {
+		return errorsx.MissingField(builderName, "bomPath", "BOM path is required")
+	}
+
+	if b.apiKey == nil {
+		return errorsx.MissingField(builderName, "apiKey", "API key is required")
+	}
+
+	if err := b.apiKey.Validate(); err != nil {
+		return err
+	}
+
+	if b.projectUUID != "" {
+		if b.projectName != "" || b.autoCreate || len(b.tags) > 0 || b.parentName != "" {
+			return errorsx.ConflictingOptions(builderName, []string{"projectUUID", "project"},
+				"an existing project is identified by its UUID, not its name and version")
+		}
+
+		if !uuidRegex.MatchString(b.projectUUID) {
+			return errorsx.InvalidValue(builderName, "projectUUID", b.projectUUID, "invalid project UUID: %q", b.projectUUID)
+		}
+
+		return nil
+	}
+
+	if b.projectName == "" || b.projectVersion == "" {
+		return errorsx.MissingField(builderName, "project", "project UUID, or project name and version, are required")
+	}
+
+	if (b.parentName != "" || len(b.tags) > 0) && !b.autoCreate {
+		return errorsx.ConflictingOptions(builderName, []string{"autoCreate", "parent", "tags"},
+			"the parent and tags are set on automatically created project versions only")
+	}
+
+	for _, t := range b.tags {
+		if t == "" || strings.Contains(t, ",") {
+			return errorsx.InvalidValue(builderName, "tags", t, "tags must be non-empty and without commas")
+		}
+	}
+
+	return nil
+}
+
+// BuildCommand generates the curl command uploading the BOM. It prints the JSON response of the
+// server, holding the token of the upload (see ParseUploadResponse), and fails on error
+// responses.
+//
+// Returns:
+//   - The upload command.
+//   - An error if the server URL, BOM path, API key or project are missing or invalid, or the
+//     project options are inconsistent.
+func (b *UploadBuilder) BuildCommand() ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	curl := []string{"curl", "-sS", "--fail-with-body", "-X", "POST", b.serverURL + "/api/v1/bom"}
+
+	var fields []string
+	if b.projectUUID != "" {
+		fields = append(fields, "project="+b.projectUUID)
+	} else {
+		fields = append(fields, "projectName="+b.projectName, "projectVersion="+b.projectVersion)
+	}
+
+	if b.autoCreate {
+		fields = append(fields, "autoCreate=true")
+	}
+
+	if len(b.tags) > 0 {
+		fields = append(fields, "projectTags="+strings.Join(b.tags, ","))
+	}
+
+	if b.parentName != "" {
+		fields = append(fields, "parentName="+b.parentName)
+		if b.parentVersion != "" {
+			fields = append(fields, "parentVersion="+b.parentVersion)
+		}
+	}
+
+	if b.latest {
+		fields = append(fields, "isLatest=true")
+	}
+
+	// Form strings are sent literally: values starting with @ or < are not read from files.
+	for _, f := range fields {
+		curl = append(curl, "--form-string", f)
+	}
+
+	curl = append(curl, "-F", "bom=@"+b.bomPath)
+
+	cmd := []string{"sh", "-c", fmt.Sprintf(`%s -H "X-Api-Key: $%s"`, cmdx.ShellJoin(curl), APIKeyEnvVar)}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// ProcessingCommand generates the curl command querying whether the upload 'token' is still
+// being processed (see ParseProcessingResponse).
+//
+// Returns:
+//   - The query command.
+//   - An error if the token is invalid, or the server URL or API key are missing or invalid.
+func (b *UploadBuilder) ProcessingCommand(token string) ([]string, error) {
+	if !uuidRegex.MatchString(token) {
+		return nil, errorsx.InvalidValue(builderName, "token", token, "invalid upload token: %q", token)
+	}
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	curl := []string{"curl", "-sS", "--fail-with-body", b.serverURL + "/api/v1/event/token/" + token}
+	cmd := []string{"sh", "-c", fmt.Sprintf(`%s -H "X-Api-Key: $%s"`, cmdx.ShellJoin(curl), APIKeyEnvVar)}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Secrets returns the secrets the commands require: the API key.
+func (b *UploadBuilder) Secrets() []*secretsx.SecretRef {
+	if b.apiKey == nil {
+		return nil
+	}
+
+	return []*secretsx.SecretRef{b.apiKey}
+}
+
+// ParseUploadResponse parses the response of the BOM upload.
+//
+// Returns:
+//   - The token of the upload.
+//   - An error if the response is not a BOM upload response.
+func ParseUploadResponse(data []byte) (string, error) {
+	var resp struct {
+		Token string `json:"token"`
+	}
+
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return "", fmt.Errorf("invalid Dependency-Track upload response: %w", err)
+	}
+
+	if resp.Token == "" {
+		return "", fmt.Errorf("invalid Dependency-Track upload response: no token")
+	}
+
+	return resp.Token, nil
+}
+
+// ParseProcessingResponse parses the response of the processing query of an upload.
+//
+// Returns:
+//   - Whether the upload is still being processed.
+//   - An error if the response is not a processing query response.
+func ParseProcessingResponse(data []byte) (bool, error) {
+	var resp struct {
+		Processing *bool `json:"processing"`
+	}
+
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return false, fmt.Errorf("invalid Dependency-Track processing response: %w", err)
+	}
+
+	if resp.Processing == nil {
+		return false, fmt.Errorf("invalid Dependency-Track processing response: no processing status")
+	}
+
+	return *resp.Processing, nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *UploadBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *UploadBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none. The
+// credentials are secrets (see Secrets).
+func (b *UploadBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the upload command.
+func (b *UploadBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewUploadBuilder("", ""), explainx.Generate("BuildCommand", b.BuildCommand))
+}
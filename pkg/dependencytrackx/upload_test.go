@@ -0,0 +1,172 @@
+package dependencytrackx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testServer = "https://dtrack.example.com/"
+	testUUID   = "3c7f4b2e-9a1d-4e8f-b6c5-0d2a1e3f4b5c"
+)
+
+func testAPIKey() *secretsx.SecretRef {
+	return secretsx.FromEnv("dtrack-api-key", "CI_DTRACK_KEY")
+}
+
+func TestConvertCommand(t *testing.T) {
+	assert.Equal(t,
+		[]string{"syft", "convert", "/mnt/sbom-x86_64.spdx.json", "-o", "cyclonedx-json=/mnt/sbom.cdx.json"},
+		ConvertCommand("/mnt/sbom-x86_64.spdx.json", "/mnt/sbom.cdx.json"))
+}
+
+func TestUploadBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *UploadBuilder
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "project name and version",
+			builder: NewUploadBuilder(testServer, "/mnt/sbom.cdx.json").WithAPIKey(testAPIKey()).WithProject("checkout", "1.4.0"),
+			want: "curl -sS --fail-with-body -X POST https://dtrack.example.com/api/v1/bom " +
+				"--form-string projectName=checkout --form-string projectVersion=1.4.0 " +
+				`-F 'bom=@/mnt/sbom.cdx.json' -H "X-Api-Key: $DTRACK_API_KEY"`,
+		},
+		{
+			name: "auto-created project version",
+			builder: NewUploadBuilder(testServer, "/mnt/sbom.cdx.json").
+				WithAPIKey(testAPIKey()).
+				WithProject("checkout", "1.4.0").
+				WithAutoCreate(true).
+				WithTags("team-payments", "production").
+				WithParent("shop", "2024").
+				WithLatest(true),
+			want: "curl -sS --fail-with-body -X POST https://dtrack.example.com/api/v1/bom " +
+				"--form-string projectName=checkout --form-string projectVersion=1.4.0 " +
+				"--form-string autoCreate=true --form-string 'projectTags=team-payments,production' " +
+				"--form-string parentName=shop --form-string parentVersion=2024 --form-string isLatest=true " +
+				`-F 'bom=@/mnt/sbom.cdx.json' -H "X-Api-Key: $DTRACK_API_KEY"`,
+		},
+		{
+			name:    "project UUID",
+			builder: NewUploadBuilder(testServer, "/mnt/my sbom.json").WithAPIKey(testAPIKey()).WithProjectUUID(testUUID),
+			want: "curl -sS --fail-with-body -X POST https://dtrack.example.com/api/v1/bom " +
+				"--form-string project=" + testUUID + ` -F 'bom=@/mnt/my sbom.json' -H "X-Api-Key: $DTRACK_API_KEY"`,
+		},
+		{
+			name:    "missing server URL",
+			builder: NewUploadBuilder("", "/mnt/sbom.cdx.json").WithAPIKey(testAPIKey()).WithProject("a", "1"),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "invalid server URL",
+			builder: NewUploadBuilder("dtrack.example.com", "/mnt/sbom.cdx.json").WithAPIKey(testAPIKey()).WithProject("a", "1"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "missing BOM",
+			builder: NewUploadBuilder(testServer, "").WithAPIKey(testAPIKey()).WithProject("a", "1"),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "missing API key",
+			builder: NewUploadBuilder(testServer, "/mnt/sbom.cdx.json").WithProject("a", "1"),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "missing project version",
+			builder: NewUploadBuilder(testServer, "/mnt/sbom.cdx.json").WithAPIKey(testAPIKey()).WithProject("a", ""),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name: "project UUID and name",
+			builder: NewUploadBuilder(testServer, "/mnt/sbom.cdx.json").WithAPIKey(testAPIKey()).
+				WithProjectUUID(testUUID).WithProject("a", "1"),
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+		{
+			name:    "invalid project UUID",
+			builder: NewUploadBuilder(testServer, "/mnt/sbom.cdx.json").WithAPIKey(testAPIKey()).WithProjectUUID("checkout"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "tags without auto-creation",
+			builder: NewUploadBuilder(testServer, "/mnt/sbom.cdx.json").WithAPIKey(testAPIKey()).WithProject("a", "1").WithTags("x"),
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+		{
+			name: "tag with a comma",
+			builder: NewUploadBuilder(testServer, "/mnt/sbom.cdx.json").WithAPIKey(testAPIKey()).WithProject("a", "1").
+				WithAutoCreate(true).WithTags("a,b"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, []string{"sh", "-c", tt.want}, cmd)
+		})
+	}
+}
+
+func TestUploadBuilderProcessingCommand(t *testing.T) {
+	b := NewUploadBuilder(testServer, "/mnt/sbom.cdx.json").WithAPIKey(testAPIKey()).WithProject("checkout", "1.4.0")
+
+	cmd, err := b.ProcessingCommand(testUUID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sh", "-c", "curl -sS --fail-with-body https://dtrack.example.com/api/v1/event/token/" +
+		testUUID + ` -H "X-Api-Key: $DTRACK_API_KEY"`}, cmd)
+
+	_, err = b.ProcessingCommand("not-a-token")
+	require.ErrorIs(t, err, errorsx.ErrInvalidValue)
+}
+
+func TestUploadBuilderSecrets(t *testing.T) {
+	ref := testAPIKey()
+	b := NewUploadBuilder(testServer, "/mnt/sbom.cdx.json").WithAPIKey(ref)
+
+	secrets := b.Secrets()
+	require.Len(t, secrets, 1)
+	assert.Equal(t, APIKeyEnvVar, secrets[0].Target)
+	assert.Equal(t, "CI_DTRACK_KEY", ref.Target)
+
+	assert.Nil(t, NewUploadBuilder(testServer, "/mnt/sbom.cdx.json").Secrets())
+}
+
+func TestParseUploadResponse(t *testing.T) {
+	token, err := ParseUploadResponse([]byte(`{"token": "` + testUUID + `"}`))
+	require.NoError(t, err)
+	assert.Equal(t, testUUID, token)
+
+	_, err = ParseUploadResponse([]byte(`{}`))
+	assert.Error(t, err)
+
+	_, err = ParseUploadResponse([]byte(`Unauthorized`))
+	assert.Error(t, err)
+}
+
+func TestParseProcessingResponse(t *testing.T) {
+	processing, err := ParseProcessingResponse([]byte(`{"processing": true}`))
+	require.NoError(t, err)
+	assert.True(t, processing)
+
+	processing, err = ParseProcessingResponse([]byte(`{"processing": false}`))
+	require.NoError(t, err)
+	assert.False(t, processing)
+
+	_, err = ParseProcessingResponse([]byte(`{}`))
+	assert.Error(t, err)
+}
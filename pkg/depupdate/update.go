@@ -0,0 +1,339 @@
+// Package depupdate proposes bumps of the package pins of apko configurations, for
+// renovate-style automation inside Dagger modules: it looks up the pinned packages
+// ("curl=8.5.0-r0") in the indexes of the configured repositories (see repositoryx), and
+// produces the updated configuration with a changelog of the bumps.
+//
+// A pin is bumped to the latest version available for every architecture the configuration
+// builds, so the updated configuration builds on all of them. Only exact pins are bumped: other
+// constraints (e.g. "curl>=8.5") already follow the repository.
+//
+// Example usage:
+//
+//	client := repositoryx.NewClient()
+//	result, err := depupdate.NewUpdater(client).Propose(ctx, config)
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	if !result.Changelog.Empty() {
+//	    _ = os.WriteFile("apko.yaml", result.Config, 0o644)
+//	    fmt.Print(result.Changelog.Markdown()) // e.g. the body of a pull request
+//	}
+package depupdate
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/repositoryx"
+	"gopkg.in/yaml.v3"
+)
+
+// builderName identifies the updater in errorsx errors.
+const builderName = "depupdate"
+
+// IndexSource returns the index of a repository architecture. repositoryx.Client implements it.
+type IndexSource interface {
+	Index(ctx context.Context, repository string, arch apkox.Architecture) (*repositoryx.Index, error)
+}
+
+// Update is the bump of a package pin.
+type Update struct {
+	// Package is the package name.
+	Package string `json:"package"`
+	// From is the pinned version.
+	From string `json:"from"`
+	// To is the proposed version.
+	To string `json:"to"`
+}
+
+// Changelog lists the bumps of a proposal, and the pins which couldn't be looked up.
+type Changelog struct {
+	// Updates are the bumped pins, in configuration order.
+	Updates []Update `json:"updates,omitempty"`
+	// Unresolved are the pinned packages missing from the index of an architecture.
+	Unresolved []string `json:"unresolved,omitempty"`
+}
+
+// Empty reports whether the changelog has no bump.
+func (c Changelog) Empty() bool {
+	return len(c.Updates) == 0
+}
+
+// String renders the changelog as text, one line per bump.
+func (c Changelog) String() string {
+	if c.Empty() && len(c.Unresolved) == 0 {
+		return "No package updates.\n"
+	}
+
+	var b strings.Builder
+	for _, u := range c.Updates {
+		fmt.Fprintf(&b, "~ %s: %s -> %s\n", u.Package, u.From, u.To)
+	}
+
+	for _, name := range c.Unresolved {
+		fmt.Fprintf(&b, "? %s: not found\n", name)
+	}
+
+	return b.String()
+}
+
+// Markdown renders the changelog as a Markdown section, suitable for pull request bodies.
+func (c Changelog) Markdown() string {
+	var b strings.Builder
+
+	b.WriteString("## Package updates\n\n")
+	if c.Empty() {
+		b.WriteString("No package updates.\n")
+	} else {
+		b.WriteString("| Package | From | To |\n| --- | --- | --- |\n")
+		for _, u := range c.Updates {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", u.Package, u.From, u.To)
+		}
+	}
+
+	if len(c.Unresolved) > 0 {
+		fmt.Fprintf(&b, "\nNot found in the repositories: %s.\n", strings.Join(c.Unresolved, ", "))
+	}
+
+	return b.String()
+}
+
+// Result is a bump proposal.
+type Result struct {
+	// Config is the updated YAML configuration. Keys are sorted and comments are not preserved
+	// (see apkox.MutateConfig). It is the original configuration when nothing is bumped.
+	Config []byte `json:"config"`
+	// Changelog lists the bumps.
+	Changelog Changelog `json:"changelog"`
+}
+
+// Updater proposes bumps of package pins.
+type Updater struct {
+	// source returns the repository indexes.
+	source IndexSource
+
+	// repositories are the repositories looked up. Empty uses the configured HTTP(S) ones.
+	repositories []string
+
+	// archs are the architectures looked up. Empty uses the configured ones, or x86_64.
+	archs []apkox.Architecture
+
+	// ignore are the packages never bumped.
+	ignore []string
+}
+
+// NewUpdater creates an updater reading the indexes from 'source'.
+func NewUpdater(source IndexSource) *Updater {
+	return &Updater{source: source}
+}
+
+// WithRepositories sets the repositories looked up, instead of those of the configuration.
+// It returns the updated Updater instance.
+func (u *Updater) WithRepositories(repositories ...string) *Updater {
+	u.repositories = append(u.repositories, repositories...)
+	return u
+}
+
+// WithArchitectures sets the architectures looked up, instead of those of the configuration.
+// It returns the updated Updater instance.
+func (u *Updater) WithArchitectures(archs ...apkox.Architecture) *Updater {
+	u.archs = append(u.archs, archs...)
+	return u
+}
+
+// WithIgnore excludes the packages 'names' from the bumps.
+// It returns the updated Updater instance.
+func (u *Updater) WithIgnore(names ...string) *Updater {
+	u.ignore = append(u.ignore, names...)
+	return u
+}
+
+// configDocument is the part of an apko configuration the updater reads.
+type configDocument struct {
+	Contents struct {
+		Repositories []string `yaml:"repositories"`
+		Packages     []string `yaml:"packages"`
+	} `yaml:"contents"`
+	Archs []string `yaml:"archs"`
+}
+
+// Propose looks up the pinned packages of the apko configuration 'config' and bumps those with a
+// newer version available for every architecture.
+//
+// Returns:
+//   - The proposal: the updated configuration and its changelog.
+//   - An error if the configuration or a package constraint is invalid, it has no repository to
+//     look up, or an index cannot be fetched.
+func (u *Updater) Propose(ctx context.Context, config []byte) (*Result, error) {
+	var doc configDocument
+	if err := yaml.Unmarshal(config, &doc); err != nil {
+		return nil, errorsx.InvalidValue(builderName, "config", nil, "invalid config: %w", err)
+	}
+
+	repositories, err := u.lookupRepositories(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	archs, err := u.lookupArchitectures(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make(map[apkox.Architecture][]*repositoryx.Index, len(archs))
+	for _, arch := range archs {
+		for _, repository := range repositories {
+			index, err := u.source.Index(ctx, repository, arch)
+			if err != nil {
+				return nil, err
+			}
+			indexes[arch] = append(indexes[arch], index)
+		}
+	}
+
+	result := &Result{Config: config}
+	for _, p := range doc.Contents.Packages {
+		c, err := apkox.ParsePackageConstraint(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if !c.Pinned() || slices.Contains(u.ignore, c.Name) {
+			continue
+		}
+
+		latest, ok := latestForAll(indexes, archs, c.Name)
+		if !ok {
+			result.Changelog.Unresolved = append(result.Changelog.Unresolved, c.Name)
+			continue
+		}
+
+		if repositoryx.CompareVersions(latest, c.Version) > 0 {
+			result.Changelog.Updates = append(result.Changelog.Updates, Update{Package: c.Name, From: c.Version, To: latest})
+		}
+	}
+
+	if result.Changelog.Empty() {
+		return result, nil
+	}
+
+	if result.Config, err = apkox.MutateConfig(config, ApplyUpdates(result.Changelog.Updates...)); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// lookupRepositories returns the repositories of the updater, or the HTTP(S) repositories of
+// the configuration, without their "@tag" prefix.
+func (u *Updater) lookupRepositories(doc configDocument) ([]string, error) {
+	if len(u.repositories) > 0 {
+		return u.repositories, nil
+	}
+
+	var repositories []string
+	for _, r := range doc.Contents.Repositories {
+		if strings.HasPrefix(r, "@") {
+			_, r, _ = strings.Cut(r, " ")
+		}
+		r = strings.TrimSpace(r)
+
+		if strings.HasPrefix(r, "https://") || strings.HasPrefix(r, "http://") {
+			repositories = append(repositories, r)
+		}
+	}
+
+	if len(repositories) == 0 {
+		return nil, errorsx.MissingField(builderName, "repositories",
+			"config has no HTTP(S) repository to look up; use WithRepositories")
+	}
+
+	return repositories, nil
+}
+
+// lookupArchitectures returns the architectures of the updater, or those of the configuration,
+// or x86_64.
+func (u *Updater) lookupArchitectures(doc configDocument) ([]apkox.Architecture, error) {
+	if len(u.archs) > 0 {
+		return u.archs, nil
+	}
+
+	if len(doc.Archs) == 0 {
+		return []apkox.Architecture{apkox.ArchX8664}, nil
+	}
+
+	set, err := apkox.ParseArchitectures(doc.Archs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+// latestForAll returns the latest version of the package 'name' available in the indexes of
+// every architecture, and whether there is one.
+func latestForAll(indexes map[apkox.Architecture][]*repositoryx.Index, archs []apkox.Architecture,
+	name string) (string, bool) {
+	var common []string
+	for i, arch := range archs {
+		var versions []string
+		for _, index := range indexes[arch] {
+			for _, p := range index.Versions(name) {
+				versions = append(versions, p.Version)
+			}
+		}
+
+		if i == 0 {
+			common = versions
+		} else {
+			common = slices.DeleteFunc(common, func(v string) bool { return !slices.Contains(versions, v) })
+		}
+	}
+
+	if len(common) == 0 {
+		return "", false
+	}
+
+	return slices.MaxFunc(common, repositoryx.CompareVersions), true
+}
+
+// ApplyUpdates returns the mutation replacing the pins of the configuration packages bumped by
+// 'updates'.
+func ApplyUpdates(updates ...Update) apkox.ConfigMutation {
+	return func(doc map[string]any) error {
+		contents, ok := doc["contents"].(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		packages, ok := contents["packages"].([]any)
+		if !ok {
+			return nil
+		}
+
+		for i, item := range packages {
+			s, ok := item.(string)
+			if !ok {
+				continue
+			}
+
+			c, err := apkox.ParsePackageConstraint(s)
+			if err != nil {
+				return err
+			}
+
+			for _, update := range updates {
+				if c.Pinned() && c.Name == update.Package && c.Version == update.From {
+					packages[i] = apkox.PackageConstraint{Name: c.Name, Op: apkox.OpEqual, Version: update.To}.String()
+				}
+			}
+		}
+
+		return nil
+	}
+}
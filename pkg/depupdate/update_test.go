@@ -0,0 +1,105 @@
+package depupdate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/repositoryx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSource serves indexes built from "name=version" entries, by architecture.
+type fakeSource struct {
+	packages map[apkox.Architecture][]string
+	requests []string
+}
+
+func (s *fakeSource) Index(_ context.Context, repository string, arch apkox.Architecture) (*repositoryx.Index, error) {
+	s.requests = append(s.requests, repository+"/"+string(arch))
+
+	packages, ok := s.packages[arch]
+	if !ok {
+		return nil, fmt.Errorf("no index for %s", arch)
+	}
+
+	index := &repositoryx.Index{}
+	for _, p := range packages {
+		name, version, _ := strings.Cut(p, "=")
+		index.Packages = append(index.Packages, repositoryx.Package{Name: name, Version: version})
+	}
+
+	return index, nil
+}
+
+const testConfig = `contents:
+  repositories:
+    - https://packages.wolfi.dev/os
+    - "@local /work/packages"
+  packages:
+    - wolfi-base
+    - curl=8.5.0-r0
+    - jq=1.7.1-r0
+    - git>=2.40
+    - busybox=1.36.1-r0
+    - openssl=3.3.0-r0
+archs: [x86_64, aarch64]
+`
+
+func TestPropose(t *testing.T) {
+	source := &fakeSource{packages: map[apkox.Architecture][]string{
+		apkox.ArchX8664: {
+			"curl=8.5.0-r0", "curl=8.9.0-r0", "curl=8.10.1-r0",
+			"jq=1.7.1-r0", "git=2.45.0-r0", "busybox=1.36.1-r0", "busybox=1.37.0-r0",
+		},
+		// curl 8.10.1 isn't built for aarch64 yet, and openssl is missing.
+		apkox.ArchAarch64: {"curl=8.5.0-r0", "curl=8.9.0-r0", "jq=1.7.1-r0", "busybox=1.37.0-r0"},
+	}}
+
+	result, err := NewUpdater(source).WithIgnore("busybox").Propose(context.Background(), []byte(testConfig))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"https://packages.wolfi.dev/os/x86_64", "https://packages.wolfi.dev/os/aarch64"}, source.requests)
+	assert.Equal(t, []Update{{Package: "curl", From: "8.5.0-r0", To: "8.9.0-r0"}}, result.Changelog.Updates)
+	assert.Equal(t, []string{"openssl"}, result.Changelog.Unresolved)
+	assert.Contains(t, string(result.Config), "- curl=8.9.0-r0\n")
+	assert.Contains(t, string(result.Config), "- busybox=1.36.1-r0\n")
+
+	assert.Equal(t, "~ curl: 8.5.0-r0 -> 8.9.0-r0\n? openssl: not found\n", result.Changelog.String())
+	assert.Equal(t, "## Package updates\n\n| Package | From | To |\n| --- | --- | --- |\n| curl | 8.5.0-r0 | 8.9.0-r0 |\n"+
+		"\nNot found in the repositories: openssl.\n", result.Changelog.Markdown())
+}
+
+func TestProposeUpToDate(t *testing.T) {
+	source := &fakeSource{packages: map[apkox.Architecture][]string{apkox.ArchArmv7: {"curl=8.5.0-r0"}}}
+
+	config := []byte("contents:\n  packages: [curl=8.5.0-r0]\n")
+	result, err := NewUpdater(source).WithRepositories("https://example.com/os").
+		WithArchitectures(apkox.ArchArmv7).Propose(context.Background(), config)
+	require.NoError(t, err)
+
+	assert.True(t, result.Changelog.Empty())
+	assert.Equal(t, config, result.Config)
+	assert.Equal(t, "No package updates.\n", result.Changelog.String())
+}
+
+func TestProposeErrors(t *testing.T) {
+	source := &fakeSource{packages: map[apkox.Architecture][]string{}}
+	ctx := context.Background()
+
+	_, err := NewUpdater(source).Propose(ctx, []byte("contents:\n  packages: [curl]\n"))
+	assert.ErrorIs(t, err, errorsx.ErrMissingField)
+
+	_, err = NewUpdater(source).WithRepositories("https://example.com/os").Propose(ctx, []byte("contents: [\n"))
+	assert.ErrorIs(t, err, errorsx.ErrInvalidValue)
+
+	_, err = NewUpdater(source).WithRepositories("https://example.com/os").Propose(ctx, []byte("archs: [sparc]\n"))
+	assert.ErrorIs(t, err, errorsx.ErrUnsupported)
+
+	_, err = NewUpdater(source).WithRepositories("https://example.com/os").Propose(ctx, []byte("archs: [x86_64]\n"))
+	assert.ErrorContains(t, err, "no index for x86_64")
+}
@@ -0,0 +1,399 @@
+// Package dockerauthx generates the command chains obtaining short-lived registry tokens from
+// cloud CLIs (aws ecr get-login-password, gcloud auth print-access-token, az acr login) and
+// writing them to a docker config, so builders pushing to ECR, GCR/Artifact Registry or ACR
+// authenticate without long-lived credentials.
+//
+// The chain prints the expiry of the tokens, which ParseLogin reads back, so long pipelines can
+// refresh the config before a late push fails.
+//
+// Example usage:
+//
+//	lb := dockerauthx.NewLoginBuilder(dockerauthx.ECR("123456789012", "eu-west-1"))
+//
+//	cmd, err := lb.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	res, err := executor.Run(ctx, cmd, lb.Env(), nil)
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	login, err := dockerauthx.ParseLogin(res.Stdout)
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	if login.NeedsRefresh(time.Now(), 10*time.Minute) {
+//	    // run the chain again before pushing
+//	}
+package dockerauthx
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the registry login builder in errorsx errors.
+const builderName = "dockerauth"
+
+// DockerConfigEnvVar is the environment variable docker, crane and buildx read the config
+// directory from.
+const DockerConfigEnvVar = "DOCKER_CONFIG"
+
+// expiryPrefix prefixes the line of the chain output holding the expiry unix timestamp.
+const expiryPrefix = "expires_at="
+
+// RegistryKind is the cloud registry service issuing the token.
+type RegistryKind string
+
+const (
+	// KindECR is Amazon Elastic Container Registry.
+	KindECR RegistryKind = "ecr"
+	// KindGCR is Google Container Registry and Artifact Registry.
+	KindGCR RegistryKind = "gcr"
+	// KindACR is Azure Container Registry.
+	KindACR RegistryKind = "acr"
+)
+
+// Token lifetimes of the registry services.
+const (
+	// ECRTokenTTL is the lifetime of ECR authorization tokens.
+	ECRTokenTTL = 12 * time.Hour
+	// GCRTokenTTL is the lifetime of gcloud access tokens.
+	GCRTokenTTL = time.Hour
+	// ACRTokenTTL is the lifetime of ACR refresh tokens.
+	ACRTokenTTL = 3 * time.Hour
+)
+
+var (
+	// awsAccountRegex matches AWS account IDs.
+	awsAccountRegex = regexp.MustCompile(`^\d{12}$`)
+	// awsRegionRegex matches AWS regions.
+	awsRegionRegex = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d$`)
+	// gcrHostRegex matches GCR and Artifact Registry hosts.
+	gcrHostRegex = regexp.MustCompile(`^([a-z]+\.)?gcr\.io$|^[a-z0-9-]+-docker\.pkg\.dev$`)
+	// acrNameRegex matches ACR registry names.
+	acrNameRegex = regexp.MustCompile(`^[a-zA-Z0-9]{5,50}$`)
+)
+
+// Registry describes a cloud registry and how its token is obtained.
+type Registry struct {
+	// Kind is the registry service.
+	Kind RegistryKind
+	// Host is the registry host written to the docker config.
+	Host string
+	// Region is the AWS region of ECR registries.
+	Region string
+	// Name is the ACR registry name.
+	Name string
+}
+
+// ECR describes the ECR registry of the AWS account 'accountID' in 'region'. The token is
+// obtained with the AWS credentials of the container environment.
+func ECR(accountID, region string) Registry {
+	return Registry{
+		Kind:   KindECR,
+		Host:   fmt.Sprintf("%s.dkr.ecr.%s.amazonaws.com", accountID, region),
+		Region: region,
+	}
+}
+
+// GCR describes the GCR or Artifact Registry host 'host' (e.g. "europe-docker.pkg.dev"). The
+// token is obtained with the gcloud credentials of the container environment.
+func GCR(host string) Registry {
+	return Registry{Kind: KindGCR, Host: host}
+}
+
+// ACR describes the ACR registry 'name'. The token is obtained with the az credentials of the
+// container environment.
+func ACR(name string) Registry {
+	return Registry{Kind: KindACR, Host: strings.ToLower(name) + ".azurecr.io", Name: name}
+}
+
+// validate checks that the registry is well-formed, so its values are safe in the chain.
+func (r Registry) validate() error {
+	switch r.Kind {
+	case KindECR:
+		account, _, _ := strings.Cut(r.Host, ".")
+		if !awsAccountRegex.MatchString(account) {
+			return errorsx.InvalidValue(builderName, "accountID", account, "invalid AWS account ID: %q", account)
+		}
+
+		if !awsRegionRegex.MatchString(r.Region) {
+			return errorsx.InvalidValue(builderName, "region", r.Region, "invalid AWS region: %q", r.Region)
+		}
+	case KindGCR:
+		if !gcrHostRegex.MatchString(r.Host) {
+			return errorsx.InvalidValue(builderName, "host", r.Host, "not a GCR or Artifact Registry host: %q", r.Host)
+		}
+	case KindACR:
+		if !acrNameRegex.MatchString(r.Name) {
+			return errorsx.InvalidValue(builderName, "name", r.Name, "invalid ACR registry name: %q", r.Name)
+		}
+	default:
+		return errorsx.Unsupported(builderName, "kind", r.Kind, "unsupported registry kind: %q", r.Kind)
+	}
+
+	return nil
+}
+
+// tokenCommand returns the shell command printing the registry token.
+func (r Registry) tokenCommand() string {
+	switch r.Kind {
+	case KindECR:
+		return "aws ecr get-login-password --region " + r.Region
+	case KindGCR:
+		return "gcloud auth print-access-token"
+	default:
+		return "az acr login --name " + r.Name + " --expose-token --output tsv --query accessToken"
+	}
+}
+
+// username returns the username the registry expects with its token.
+func (r Registry) username() string {
+	switch r.Kind {
+	case KindECR:
+		return "AWS"
+	case KindGCR:
+		return "oauth2accesstoken"
+	default:
+		return "00000000-0000-0000-0000-000000000000"
+	}
+}
+
+// ttl returns the lifetime of the registry token.
+func (r Registry) ttl() time.Duration {
+	switch r.Kind {
+	case KindECR:
+		return ECRTokenTTL
+	case KindGCR:
+		return GCRTokenTTL
+	default:
+		return ACRTokenTTL
+	}
+}
+
+// TokenCommand returns the command printing the registry token on its standard output, e.g. to
+// cache the token across publish steps (see ttlcachex.TokenCache).
+//
+// Returns:
+//   - The sh command running the cloud CLI.
+//   - An error if the registry is invalid.
+func (r Registry) TokenCommand() (types.DaggerCMD, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	return types.DaggerCMD{"sh", "-c", r.tokenCommand()}, nil
+}
+
+// Username returns the username the registry expects with its token.
+func (r Registry) Username() string {
+	return r.username()
+}
+
+// TokenTTL returns the lifetime of the registry token.
+func (r Registry) TokenTTL() time.Duration {
+	return r.ttl()
+}
+
+// GetDockerConfigDir returns the conventional docker config directory inside the container.
+// If mntPrefix is empty, fixtures.MntPrefix is used.
+func GetDockerConfigDir(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, ".docker")
+}
+
+// LoginBuilder generates the command chain logging in to cloud registries.
+type LoginBuilder struct {
+	// registries are the registries logged in to.
+	registries []Registry
+
+	// configDir is the docker config directory the chain writes config.json to.
+	configDir string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewLoginBuilder creates a LoginBuilder logging in to 'registries'. The docker config is
+// written to GetDockerConfigDir("").
+func NewLoginBuilder(registries ...Registry) *LoginBuilder {
+	return &LoginBuilder{registries: registries, configDir: GetDockerConfigDir("")}
+}
+
+// WithRegistry adds a registry to log in to.
+func (b *LoginBuilder) WithRegistry(r Registry) *LoginBuilder {
+	b.registries = append(b.registries, r)
+	return b
+}
+
+// WithConfigDir sets the docker config directory.
+func (b *LoginBuilder) WithConfigDir(dir string) *LoginBuilder {
+	b.configDir = dir
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *LoginBuilder) WithLogger(logger *slog.Logger) *LoginBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks that the builder configuration is complete and consistent.
+func (b *LoginBuilder) validate() error {
+	if len(b.registries) == 0 {
+		return errorsx.MissingField(builderName, "registries", "at least one registry is required")
+	}
+
+	if !strings.HasPrefix(b.configDir, "/") {
+		return errorsx.InvalidValue(builderName, "configDir", b.configDir, "docker config directory must be absolute")
+	}
+
+	seen := map[string]bool{}
+	for _, r := range b.registries {
+		if err := r.validate(); err != nil {
+			return err
+		}
+
+		if seen[r.Host] {
+			return errorsx.ConflictingOptions(builderName, []string{"registries"}, "registry %s is declared twice", r.Host)
+		}
+		seen[r.Host] = true
+	}
+
+	return nil
+}
+
+// TTL returns the lifetime of the docker config: the shortest token lifetime of the registries.
+func (b *LoginBuilder) TTL() time.Duration {
+	var ttl time.Duration
+	for _, r := range b.registries {
+		if ttl == 0 || r.ttl() < ttl {
+			ttl = r.ttl()
+		}
+	}
+
+	return ttl
+}
+
+// BuildCommand generates the sh chain fetching each registry token, writing the docker config
+// with every registry, and printing the config expiry as an "expires_at=<unix>" line. Tokens
+// never appear in the command line. The config replaces any existing config.json.
+//
+// Returns:
+//   - The sh command running the chain.
+//   - An error if no registry is set, a registry is invalid or declared twice, or the config
+//     directory is not absolute.
+func (b *LoginBuilder) BuildCommand() ([]string, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	lines := []string{"set -eu"}
+	auths := make([]string, 0, len(b.registries))
+	for i, r := range b.registries {
+		// The token is assigned on its own so that a failing CLI stops the chain.
+		lines = append(lines,
+			fmt.Sprintf("token%d=$(%s)", i, r.tokenCommand()),
+			fmt.Sprintf(`auth%d=$(printf '%s:%%s' "$token%d" | base64 | tr -d '\n')`, i, r.username(), i))
+		auths = append(auths, fmt.Sprintf(`"%s":{"auth":"'"$auth%d"'"}`, r.Host, i))
+	}
+
+	configPath := filepath.Join(b.configDir, "config.json")
+	lines = append(lines,
+		"mkdir -p "+cmdx.ShellQuote(b.configDir),
+		fmt.Sprintf(`printf '%%s\n' '{"auths":{%s}}' > %s`, strings.Join(auths, ","), cmdx.ShellQuote(configPath)),
+		fmt.Sprintf(`echo "%s$(( $(date +%%s) + %d ))"`, expiryPrefix, int64(b.TTL().Seconds())),
+	)
+
+	cmd := []string{"sh", "-c", strings.Join(lines, "\n")}
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Env returns the DOCKER_CONFIG environment variable pointing the registry clients at the
+// written config.
+func (b *LoginBuilder) Env() []types.DaggerEnvVars {
+	return []types.DaggerEnvVars{{Name: DockerConfigEnvVar, Value: b.configDir}}
+}
+
+// Login is the outcome of a login chain.
+type Login struct {
+	// ExpiresAt is when the first token of the docker config expires.
+	ExpiresAt time.Time
+}
+
+// ParseLogin reads the expiry printed by the login chain from its standard output.
+//
+// Returns:
+//   - The login outcome.
+//   - An error if the output holds no valid expiry line.
+func ParseLogin(stdout string) (*Login, error) {
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		value, ok := strings.CutPrefix(strings.TrimSpace(lines[i]), expiryPrefix)
+		if !ok {
+			continue
+		}
+
+		unix, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, errorsx.InvalidValue(builderName, "expires_at", value, "invalid login expiry: %q", value)
+		}
+
+		return &Login{ExpiresAt: time.Unix(unix, 0).UTC()}, nil
+	}
+
+	return nil, errorsx.MissingField(builderName, "expires_at", "login output has no expiry")
+}
+
+// NeedsRefresh reports whether the tokens expire within 'margin' of 'now', so the chain must run
+// again before the next push.
+func (l *Login) NeedsRefresh(now time.Time, margin time.Duration) bool {
+	return !now.Add(margin).Before(l.ExpiresAt)
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *LoginBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *LoginBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the login command.
+func (b *LoginBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewLoginBuilder(), explainx.Generate("BuildCommand", b.BuildCommand))
+}
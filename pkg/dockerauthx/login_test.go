@@ -0,0 +1,130 @@
+package dockerauthx
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginBuilderBuildCommand(t *testing.T) {
+	b := NewLoginBuilder(ECR("123456789012", "eu-west-1")).WithRegistry(GCR("europe-docker.pkg.dev"))
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	require.Len(t, cmd, 3)
+	assert.Equal(t, []string{"sh", "-c"}, cmd[:2])
+	assert.Equal(t, `set -eu
+token0=$(aws ecr get-login-password --region eu-west-1)
+auth0=$(printf 'AWS:%s' "$token0" | base64 | tr -d '\n')
+token1=$(gcloud auth print-access-token)
+auth1=$(printf 'oauth2accesstoken:%s' "$token1" | base64 | tr -d '\n')
+mkdir -p /mnt/.docker
+printf '%s\n' '{"auths":{"123456789012.dkr.ecr.eu-west-1.amazonaws.com":{"auth":"'"$auth0"'"},"europe-docker.pkg.dev":{"auth":"'"$auth1"'"}}}' > /mnt/.docker/config.json
+echo "expires_at=$(( $(date +%s) + 3600 ))"`, cmd[2])
+
+	assert.Equal(t, GCRTokenTTL, b.TTL())
+	assert.Equal(t, []types.DaggerEnvVars{{Name: DockerConfigEnvVar, Value: "/mnt/.docker"}}, b.Env())
+}
+
+func TestLoginBuilderRunsChain(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "az"), []byte("#!/bin/sh\necho acr-token\n"), 0o755))
+
+	configDir := filepath.Join(t.TempDir(), "docker config")
+	cmd, err := NewLoginBuilder(ACR("acmeregistry")).WithConfigDir(configDir).BuildCommand()
+	require.NoError(t, err)
+
+	c := exec.Command(cmd[0], cmd[1:]...)
+	c.Env = append(os.Environ(), "PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	out, err := c.Output()
+	require.NoError(t, err)
+
+	login, err := ParseLogin(string(out))
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(ACRTokenTTL), login.ExpiresAt, time.Minute)
+	assert.False(t, strings.Contains(cmd[2], "acr-token"))
+
+	data, err := os.ReadFile(filepath.Join(configDir, "config.json"))
+	require.NoError(t, err)
+
+	var config struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	require.NoError(t, json.Unmarshal(data, &config))
+
+	auth, err := base64.StdEncoding.DecodeString(config.Auths["acmeregistry.azurecr.io"].Auth)
+	require.NoError(t, err)
+	assert.Equal(t, "00000000-0000-0000-0000-000000000000:acr-token", string(auth))
+}
+
+func TestLoginBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *LoginBuilder
+		field   string
+		kind    error
+	}{
+		{"Missing registries", NewLoginBuilder(), "registries", errorsx.ErrMissingField},
+		{"Relative config dir", NewLoginBuilder(GCR("gcr.io")).WithConfigDir(".docker"), "configDir", errorsx.ErrInvalidValue},
+		{"Invalid account", NewLoginBuilder(ECR("1234", "eu-west-1")), "accountID", errorsx.ErrInvalidValue},
+		{"Invalid region", NewLoginBuilder(ECR("123456789012", "eu-west-1; id")), "region", errorsx.ErrInvalidValue},
+		{"Invalid GCR host", NewLoginBuilder(GCR("registry.example.com")), "host", errorsx.ErrInvalidValue},
+		{"Invalid ACR name", NewLoginBuilder(ACR("acme-registry")), "name", errorsx.ErrInvalidValue},
+		{"Unknown kind", NewLoginBuilder(Registry{Kind: "quay"}), "kind", errorsx.ErrUnsupported},
+		{"Duplicate registry", NewLoginBuilder(GCR("gcr.io"), GCR("gcr.io")), "", errorsx.ErrConflictingOptions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.BuildCommand()
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+			field, _ := errorsx.FieldOf(err)
+			assert.Equal(t, tt.field, field)
+		})
+	}
+}
+
+func TestParseLogin(t *testing.T) {
+	login, err := ParseLogin("Login Succeeded\nexpires_at=1760000000\n")
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1760000000, 0).UTC(), login.ExpiresAt)
+
+	assert.False(t, login.NeedsRefresh(login.ExpiresAt.Add(-time.Hour), 10*time.Minute))
+	assert.True(t, login.NeedsRefresh(login.ExpiresAt.Add(-5*time.Minute), 10*time.Minute))
+
+	_, err = ParseLogin("Login Succeeded\n")
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+
+	_, err = ParseLogin("expires_at=soon")
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+}
+
+func TestRegistryTokenCommand(t *testing.T) {
+	acr := ACR("myregistry")
+
+	cmd, err := acr.TokenCommand()
+	require.NoError(t, err)
+	assert.Equal(t, types.DaggerCMD{"sh", "-c", "az acr login --name myregistry --expose-token --output tsv --query accessToken"}, cmd)
+	assert.Equal(t, "00000000-0000-0000-0000-000000000000", acr.Username())
+	assert.Equal(t, ACRTokenTTL, acr.TokenTTL())
+
+	_, err = GCR("quay.io").TokenCommand()
+	require.ErrorIs(t, err, errorsx.ErrInvalidValue)
+}
@@ -0,0 +1,464 @@
+// Package dotnetx provides builders for the dotnet CLI commands of .NET pipelines: restore,
+// build, test and publish, with their configuration, target framework, runtime identifier and
+// output paths, and the NuGet configuration, sources and credentials of private feeds backed by
+// secretsx references.
+//
+// Example usage:
+//
+//	credentials := secretsx.FromEnv("nuget-credentials", "NUGET_CREDENTIALS")
+//
+//	publish := dotnetx.NewPublishBuilder("src/Api/Api.csproj").
+//	    WithConfiguration("Release").
+//	    WithRuntime("linux-musl-x64").
+//	    WithSelfContained(true).
+//	    WithSourceCredentials("internal", credentials)
+//
+//	cmd, err := publish.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	env, mounts := publish.Env(), publish.Mounts()
+package dotnetx
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the dotnet builders in errorsx errors.
+const builderName = "dotnet"
+
+// DotnetDefaultImage is the default container image providing the .NET SDK.
+const DotnetDefaultImage = "mcr.microsoft.com/dotnet/sdk:8.0"
+
+// PackagesVolumeKey is the cache volume key of the NuGet packages directory.
+const PackagesVolumeKey = "dotnet-nuget-packages"
+
+// Command is a dotnet CLI command.
+type Command string
+
+const (
+	// CommandRestore restores the NuGet dependencies of a project.
+	CommandRestore Command = "restore"
+	// CommandBuild builds a project.
+	CommandBuild Command = "build"
+	// CommandTest runs the tests of a project.
+	CommandTest Command = "test"
+	// CommandPublish publishes a project and its dependencies for deployment.
+	CommandPublish Command = "publish"
+)
+
+// Verbosities are the MSBuild verbosity levels.
+var Verbosities = []string{"quiet", "minimal", "normal", "detailed", "diagnostic"}
+
+// ridRegex matches runtime identifiers (e.g. "linux-x64", "linux-musl-arm64", "win-x64").
+var ridRegex = regexp.MustCompile(`^[a-z][a-z0-9.]*(-[a-z0-9.]+)+$`)
+
+// propertyNameRegex matches MSBuild property names.
+var propertyNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// GetPublishDir returns the conventional directory of the published application inside the
+// container. If mntPrefix is empty, fixtures.MntPrefix is used.
+func GetPublishDir(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, "publish")
+}
+
+// GetTestResultsDir returns the conventional directory of the test results inside the
+// container. If mntPrefix is empty, fixtures.MntPrefix is used.
+func GetTestResultsDir(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, "test-results")
+}
+
+// DotnetBuilder generates a dotnet restore, build, test or publish command.
+type DotnetBuilder struct {
+	// command is the dotnet command.
+	command Command
+
+	// project is the project or solution file, or its directory. Empty uses the working
+	// directory.
+	project string
+
+	// configuration is the build configuration (e.g. "Release").
+	configuration string
+
+	// framework is the target framework (e.g. "net8.0").
+	framework string
+
+	// runtime is the runtime identifier (e.g. "linux-x64").
+	runtime string
+
+	// output is the output directory.
+	output string
+
+	// selfContained publishes the .NET runtime with the application. Nil keeps the SDK default.
+	selfContained *bool
+
+	// noRestore skips the implicit restore.
+	noRestore bool
+
+	// noBuild skips the implicit build of test and publish.
+	noBuild bool
+
+	// properties are the MSBuild properties.
+	properties map[string]string
+
+	// verbosity is the MSBuild verbosity.
+	verbosity string
+
+	// filter selects the tests to run.
+	filter string
+
+	// testLoggers are the test loggers (e.g. "trx;LogFileName=results.trx").
+	testLoggers []string
+
+	// resultsDir is the directory of the test results.
+	resultsDir string
+
+	// packagesDir is the NuGet packages directory, backed by a cache volume.
+	packagesDir string
+
+	// nuget holds the NuGet configuration, sources and credentials.
+	nuget nugetOptions
+
+	// extraArgs are additional arguments appended at the end of the command.
+	extraArgs []string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewDotnetBuilder creates a DotnetBuilder running 'command' on 'project' (a project or
+// solution file, or its directory; empty uses the working directory).
+func NewDotnetBuilder(command Command, project string) *DotnetBuilder {
+	return &DotnetBuilder{command: command, project: project}
+}
+
+// NewRestoreBuilder creates a DotnetBuilder running dotnet restore on 'project'.
+func NewRestoreBuilder(project string) *DotnetBuilder {
+	return NewDotnetBuilder(CommandRestore, project)
+}
+
+// NewBuildBuilder creates a DotnetBuilder running dotnet build on 'project'.
+func NewBuildBuilder(project string) *DotnetBuilder {
+	return NewDotnetBuilder(CommandBuild, project)
+}
+
+// NewTestBuilder creates a DotnetBuilder running dotnet test on 'project'. Results are written
+// to GetTestResultsDir("").
+func NewTestBuilder(project string) *DotnetBuilder {
+	b := NewDotnetBuilder(CommandTest, project)
+	b.resultsDir = GetTestResultsDir("")
+	return b
+}
+
+// NewPublishBuilder creates a DotnetBuilder running dotnet publish on 'project'. The
+// application is published to GetPublishDir("").
+func NewPublishBuilder(project string) *DotnetBuilder {
+	b := NewDotnetBuilder(CommandPublish, project)
+	b.output = GetPublishDir("")
+	return b
+}
+
+// WithConfiguration sets the build configuration (e.g. "Release").
+func (b *DotnetBuilder) WithConfiguration(configuration string) *DotnetBuilder {
+	b.configuration = configuration
+	return b
+}
+
+// WithFramework sets the target framework (e.g. "net8.0") of multi-targeted projects.
+func (b *DotnetBuilder) WithFramework(framework string) *DotnetBuilder {
+	b.framework = framework
+	return b
+}
+
+// WithRuntime sets the runtime identifier (e.g. "linux-x64", "linux-musl-arm64").
+func (b *DotnetBuilder) WithRuntime(rid string) *DotnetBuilder {
+	b.runtime = rid
+	return b
+}
+
+// WithOutput sets the output directory of build, test and publish.
+func (b *DotnetBuilder) WithOutput(dir string) *DotnetBuilder {
+	b.output = dir
+	return b
+}
+
+// WithSelfContained sets whether build and publish include the .NET runtime with the
+// application. It requires a runtime identifier.
+func (b *DotnetBuilder) WithSelfContained(selfContained bool) *DotnetBuilder {
+	b.selfContained = &selfContained
+	return b
+}
+
+// WithNoRestore skips the implicit restore of build, test and publish, when a restore step
+// ran before.
+func (b *DotnetBuilder) WithNoRestore(noRestore bool) *DotnetBuilder {
+	b.noRestore = noRestore
+	return b
+}
+
+// WithNoBuild skips the implicit build of test and publish, when a build step ran before.
+func (b *DotnetBuilder) WithNoBuild(noBuild bool) *DotnetBuilder {
+	b.noBuild = noBuild
+	return b
+}
+
+// WithProperty sets the MSBuild property 'name' (e.g. "Version") to 'value'.
+func (b *DotnetBuilder) WithProperty(name, value string) *DotnetBuilder {
+	if b.properties == nil {
+		b.properties = map[string]string{}
+	}
+
+	b.properties[name] = value
+	return b
+}
+
+// WithVerbosity sets the MSBuild verbosity, one of Verbosities.
+func (b *DotnetBuilder) WithVerbosity(verbosity string) *DotnetBuilder {
+	b.verbosity = verbosity
+	return b
+}
+
+// WithFilter sets the expression selecting the tests to run (e.g. "Category!=Integration").
+func (b *DotnetBuilder) WithFilter(filter string) *DotnetBuilder {
+	b.filter = filter
+	return b
+}
+
+// WithTestLogger adds a test logger (e.g. "trx;LogFileName=results.trx").
+func (b *DotnetBuilder) WithTestLogger(logger string) *DotnetBuilder {
+	b.testLoggers = append(b.testLoggers, logger)
+	return b
+}
+
+// WithResultsDir sets the directory of the test results.
+func (b *DotnetBuilder) WithResultsDir(dir string) *DotnetBuilder {
+	b.resultsDir = dir
+	return b
+}
+
+// WithPackagesDir sets the NuGet packages directory (NUGET_PACKAGES), backed by the cache
+// volume PackagesVolumeKey (see Mounts), so restores are shared across runs.
+func (b *DotnetBuilder) WithPackagesDir(dir string) *DotnetBuilder {
+	b.packagesDir = dir
+	return b
+}
+
+// WithExtraArg adds an argument appended at the end of the command.
+func (b *DotnetBuilder) WithExtraArg(arg string) *DotnetBuilder {
+	b.extraArgs = append(b.extraArgs, arg)
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *DotnetBuilder) WithLogger(logger *slog.Logger) *DotnetBuilder {
+	b.logger = logger
+	return b
+}
+
+// supports lists the commands supporting the options not every command supports.
+var supports = map[string][]Command{
+	"configuration": {CommandBuild, CommandTest, CommandPublish},
+	"framework":     {CommandBuild, CommandTest, CommandPublish},
+	"output":        {CommandBuild, CommandTest, CommandPublish},
+	"selfContained": {CommandBuild, CommandPublish},
+	"noRestore":     {CommandBuild, CommandTest, CommandPublish},
+	"noBuild":       {CommandTest, CommandPublish},
+	"filter":        {CommandTest},
+	"testLoggers":   {CommandTest},
+	"resultsDir":    {CommandTest},
+	"sources":       {CommandRestore, CommandBuild, CommandPublish},
+	"nugetConfig":   {CommandRestore},
+}
+
+// validate checks that the builder configuration is complete and supported by the command.
+func (b *DotnetBuilder) validate() error {
+	switch b.command {
+	case CommandRestore, CommandBuild, CommandTest, CommandPublish:
+	default:
+		return errorsx.Unsupported(builderName, "command", b.command, "unsupported dotnet command: %q", b.command)
+	}
+
+	// Options set on a command not supporting them would be rejected by the CLI.
+	set := []struct {
+		option string
+		isSet  bool
+	}{
+		{"configuration", b.configuration != ""},
+		{"framework", b.framework != ""},
+		{"output", b.output != ""},
+		{"selfContained", b.selfContained != nil},
+		{"noRestore", b.noRestore},
+		{"noBuild", b.noBuild},
+		{"filter", b.filter != ""},
+		{"testLoggers", len(b.testLoggers) > 0},
+		{"resultsDir", b.resultsDir != ""},
+		{"sources", len(b.nuget.sources) > 0},
+		{"nugetConfig", b.nuget.configFile != "" || b.nuget.configSecret != nil},
+	}
+	for _, o := range set {
+		if o.isSet && !slices.Contains(supports[o.option], b.command) {
+			return errorsx.Unsupported(builderName, o.option, b.command, "dotnet %s does not support %s", b.command, o.option)
+		}
+	}
+
+	if b.runtime != "" && !ridRegex.MatchString(b.runtime) {
+		return errorsx.InvalidValue(builderName, "runtime", b.runtime, "invalid runtime identifier: %q", b.runtime)
+	}
+
+	if b.selfContained != nil && b.runtime == "" {
+		return errorsx.ConflictingOptions(builderName, []string{"selfContained", "runtime"},
+			"self-contained output requires a runtime identifier")
+	}
+
+	if b.noBuild && b.noRestore {
+		return errorsx.ConflictingOptions(builderName, []string{"noBuild", "noRestore"},
+			"--no-build implies --no-restore, set only one of them")
+	}
+
+	if b.verbosity != "" && !slices.Contains(Verbosities, b.verbosity) {
+		return errorsx.InvalidValue(builderName, "verbosity", b.verbosity, "unsupported verbosity: %q", b.verbosity)
+	}
+
+	for name := range b.properties {
+		if !propertyNameRegex.MatchString(name) {
+			return errorsx.InvalidValue(builderName, "properties", name, "invalid MSBuild property name: %q", name)
+		}
+	}
+
+	return b.nuget.validate()
+}
+
+// BuildCommand generates the dotnet command.
+//
+// Returns:
+//   - The dotnet command.
+//   - An error if the command is unsupported, an option is invalid or not supported by the
+//     command, or a NuGet secret is invalid.
+func (b *DotnetBuilder) BuildCommand() ([]string, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"dotnet", string(b.command)}
+	if b.project != "" {
+		cmd = append(cmd, b.project)
+	}
+
+	single := []struct{ flag, value string }{
+		{"--configuration", b.configuration},
+		{"--framework", b.framework},
+		{"--runtime", b.runtime},
+		{"--output", b.output},
+		{"--filter", b.filter},
+		{"--results-directory", b.resultsDir},
+		{"--verbosity", b.verbosity},
+	}
+	for _, f := range single {
+		if f.value != "" {
+			cmd = append(cmd, f.flag, f.value)
+		}
+	}
+
+	if b.selfContained != nil {
+		if *b.selfContained {
+			cmd = append(cmd, "--self-contained")
+		} else {
+			cmd = append(cmd, "--no-self-contained")
+		}
+	}
+
+	if b.noRestore {
+		cmd = append(cmd, "--no-restore")
+	}
+
+	if b.noBuild {
+		cmd = append(cmd, "--no-build")
+	}
+
+	for _, l := range b.testLoggers {
+		cmd = append(cmd, "--logger", l)
+	}
+
+	cmd = append(cmd, b.nuget.flags()...)
+
+	names := make([]string, 0, len(b.properties))
+	for name := range b.properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cmd = append(cmd, "-p:"+name+"="+b.properties[name])
+	}
+
+	cmd = append(cmd, b.extraArgs...)
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Env returns the environment variables of the command: the CLI telemetry and banner opt-outs,
+// and the NuGet packages directory. The NuGet source credentials are secrets (see Secrets).
+func (b *DotnetBuilder) Env() []types.DaggerEnvVars {
+	env := []types.DaggerEnvVars{
+		{Name: "DOTNET_CLI_TELEMETRY_OPTOUT", Value: "1"},
+		{Name: "DOTNET_NOLOGO", Value: "1"},
+	}
+
+	if b.packagesDir != "" {
+		env = append(env, types.DaggerEnvVars{Name: "NUGET_PACKAGES", Value: b.packagesDir})
+	}
+
+	return env
+}
+
+// Mounts returns the mounts of the command: the cache volume of the NuGet packages directory,
+// and the NuGet secrets mounted as files.
+func (b *DotnetBuilder) Mounts() []types.Mount {
+	var mounts []types.Mount
+	if b.packagesDir != "" {
+		mounts = append(mounts, types.Mount{Kind: types.MountKindCache, Source: PackagesVolumeKey, Target: b.packagesDir})
+	}
+
+	return append(mounts, b.nuget.mounts()...)
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *DotnetBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the dotnet command.
+func (b *DotnetBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewDotnetBuilder(b.command, ""),
+		explainx.Generate("BuildCommand", b.BuildCommand))
+}
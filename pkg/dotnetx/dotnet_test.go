@@ -0,0 +1,144 @@
+package dotnetx
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirs(t *testing.T) {
+	assert.Equal(t, "/mnt/publish", GetPublishDir(""))
+	assert.Equal(t, "/src/publish", GetPublishDir("/src"))
+	assert.Equal(t, "/mnt/test-results", GetTestResultsDir(""))
+}
+
+func TestDotnetBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  *DotnetBuilder
+		expected []string
+	}{
+		{
+			name:     "Restore",
+			builder:  NewRestoreBuilder("App.sln").WithRuntime("linux-x64").WithVerbosity("minimal"),
+			expected: []string{"dotnet", "restore", "App.sln", "--runtime", "linux-x64", "--verbosity", "minimal"},
+		},
+		{
+			name: "Build",
+			builder: NewBuildBuilder("").
+				WithConfiguration("Release").
+				WithFramework("net8.0").
+				WithNoRestore(true).
+				WithProperty("Version", "1.2.3").
+				WithProperty("ContinuousIntegrationBuild", "true"),
+			expected: []string{
+				"dotnet", "build", "--configuration", "Release", "--framework", "net8.0", "--no-restore",
+				"-p:ContinuousIntegrationBuild=true", "-p:Version=1.2.3",
+			},
+		},
+		{
+			name: "Test",
+			builder: NewTestBuilder("tests/Api.Tests").
+				WithConfiguration("Release").
+				WithFilter("Category!=Integration").
+				WithTestLogger("trx;LogFileName=results.trx").
+				WithNoBuild(true),
+			expected: []string{
+				"dotnet", "test", "tests/Api.Tests", "--configuration", "Release",
+				"--filter", "Category!=Integration", "--results-directory", "/mnt/test-results",
+				"--no-build", "--logger", "trx;LogFileName=results.trx",
+			},
+		},
+		{
+			name: "Publish",
+			builder: NewPublishBuilder("src/Api/Api.csproj").
+				WithConfiguration("Release").
+				WithRuntime("linux-musl-arm64").
+				WithSelfContained(true).
+				WithProperty("PublishSingleFile", "true").
+				WithExtraArg("--nologo"),
+			expected: []string{
+				"dotnet", "publish", "src/Api/Api.csproj", "--configuration", "Release",
+				"--runtime", "linux-musl-arm64", "--output", "/mnt/publish", "--self-contained",
+				"-p:PublishSingleFile=true", "--nologo",
+			},
+		},
+		{
+			name:    "Framework-dependent publish",
+			builder: NewPublishBuilder("").WithOutput("/out").WithRuntime("linux-x64").WithSelfContained(false),
+			expected: []string{
+				"dotnet", "publish", "--runtime", "linux-x64", "--output", "/out", "--no-self-contained",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cmd)
+		})
+	}
+}
+
+func TestDotnetBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *DotnetBuilder
+		kind    error
+		field   string
+	}{
+		{"Unsupported command", NewDotnetBuilder("pack", ""), errorsx.ErrUnsupported, "command"},
+		{"Restore configuration", NewRestoreBuilder("").WithConfiguration("Release"), errorsx.ErrUnsupported, "configuration"},
+		{"Build filter", NewBuildBuilder("").WithFilter("Category=Unit"), errorsx.ErrUnsupported, "filter"},
+		{"Build no-build", NewBuildBuilder("").WithNoBuild(true), errorsx.ErrUnsupported, "noBuild"},
+		{"Test self-contained", NewTestBuilder("").WithRuntime("linux-x64").WithSelfContained(true), errorsx.ErrUnsupported, "selfContained"},
+		{"Invalid runtime", NewBuildBuilder("").WithRuntime("linux"), errorsx.ErrInvalidValue, "runtime"},
+		{"Self-contained without runtime", NewPublishBuilder("").WithSelfContained(true), errorsx.ErrConflictingOptions, ""},
+		{"No build and no restore", NewPublishBuilder("").WithNoBuild(true).WithNoRestore(true), errorsx.ErrConflictingOptions, ""},
+		{"Invalid verbosity", NewBuildBuilder("").WithVerbosity("loud"), errorsx.ErrInvalidValue, "verbosity"},
+		{"Invalid property", NewBuildBuilder("").WithProperty("Bad Name", "x"), errorsx.ErrInvalidValue, "properties"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.BuildCommand()
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.kind), "expected %v, got %v", tt.kind, err)
+			if tt.field != "" {
+				field, _ := errorsx.FieldOf(err)
+				assert.Equal(t, tt.field, field)
+			}
+		})
+	}
+}
+
+func TestDotnetBuilderEnvAndMounts(t *testing.T) {
+	b := NewRestoreBuilder("")
+	assert.Equal(t, []types.DaggerEnvVars{
+		{Name: "DOTNET_CLI_TELEMETRY_OPTOUT", Value: "1"},
+		{Name: "DOTNET_NOLOGO", Value: "1"},
+	}, b.Env())
+	assert.Nil(t, b.Mounts())
+
+	b.WithPackagesDir("/root/.nuget/packages")
+	assert.Contains(t, b.Env(), types.DaggerEnvVars{Name: "NUGET_PACKAGES", Value: "/root/.nuget/packages"})
+	assert.Equal(t, []types.Mount{
+		{Kind: types.MountKindCache, Source: PackagesVolumeKey, Target: "/root/.nuget/packages"},
+	}, b.Mounts())
+}
+
+func TestDotnetBuilderWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	_, err := NewBuildBuilder("").WithLogger(logger).BuildCommand()
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "builder=dotnet")
+}
@@ -0,0 +1,159 @@
+package dotnetx
+
+import (
+	"regexp"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// SourceCredentialsEnvPrefix prefixes the environment variables NuGet reads the credentials of
+// a package source from: NuGetPackageSourceCredentials_<source> holds
+// "Username=<user>;Password=<token>".
+const SourceCredentialsEnvPrefix = "NuGetPackageSourceCredentials_"
+
+// sourceNameRegex matches the package source names usable in environment variable names.
+var sourceNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// nugetOptions are the NuGet configuration, sources and credentials of a command.
+type nugetOptions struct {
+	// sources are the package sources (--source), replacing the configured ones.
+	sources []string
+
+	// configFile is the NuGet configuration file (--configfile).
+	configFile string
+
+	// configSecret is the secret holding a NuGet configuration file with credentials.
+	configSecret *secretsx.SecretRef
+
+	// credentials are the secrets holding the credentials of package sources, exposed as
+	// SourceCredentialsEnvPrefix environment variables.
+	credentials []*secretsx.SecretRef
+}
+
+// WithSource adds a package source (e.g. "https://api.nuget.org/v3/index.json"). Sources
+// replace those of the NuGet configuration.
+func (b *DotnetBuilder) WithSource(sources ...string) *DotnetBuilder {
+	b.nuget.sources = append(b.nuget.sources, sources...)
+	return b
+}
+
+// WithNuGetConfig sets the NuGet configuration file of the restore (e.g. "nuget.config").
+func (b *DotnetBuilder) WithNuGetConfig(path string) *DotnetBuilder {
+	b.nuget.configFile = path
+	return b
+}
+
+// WithNuGetConfigSecret uses the NuGet configuration file held by 'ref', for configurations
+// embedding feed credentials. The secret must be mounted as a file (see secretsx.AsFile).
+func (b *DotnetBuilder) WithNuGetConfigSecret(ref *secretsx.SecretRef) *DotnetBuilder {
+	b.nuget.configSecret = ref
+	return b
+}
+
+// WithSourceCredentials uses the credentials held by 'ref' ("Username=<user>;Password=<token>")
+// for the package source named 'source' in the NuGet configuration. The secret is exposed as
+// the environment variable NuGet reads them from, so they never appear in the command or the
+// configuration; 'ref' is left unchanged.
+func (b *DotnetBuilder) WithSourceCredentials(source string, ref *secretsx.SecretRef) *DotnetBuilder {
+	var credentials *secretsx.SecretRef
+	if ref != nil {
+		c := *ref
+		credentials = c.AsEnv(SourceCredentialsEnvPrefix + source)
+	}
+
+	b.nuget.credentials = append(b.nuget.credentials, credentials)
+	return b
+}
+
+// validate checks the NuGet options.
+func (n *nugetOptions) validate() error {
+	if n.configFile != "" && n.configSecret != nil {
+		return errorsx.ConflictingOptions(builderName, []string{"nugetConfig", "nugetConfigSecret"},
+			"set either a NuGet configuration file or a NuGet configuration secret")
+	}
+
+	if n.configSecret != nil {
+		if err := n.configSecret.Validate(); err != nil {
+			return err
+		}
+
+		if n.configSecret.Mount != secretsx.MountAsFile {
+			return errorsx.InvalidValue(builderName, "nugetConfigSecret", n.configSecret.Name,
+				"NuGet configuration secret %s must be mounted as a file", n.configSecret.Name)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, c := range n.credentials {
+		if c == nil {
+			return errorsx.MissingField(builderName, "sourceCredentials", "source credentials secret is required")
+		}
+
+		source := c.Target[len(SourceCredentialsEnvPrefix):]
+		if !sourceNameRegex.MatchString(source) {
+			return errorsx.InvalidValue(builderName, "sourceCredentials", source,
+				"package source name must be usable in an environment variable name: %q", source)
+		}
+
+		if seen[source] {
+			return errorsx.InvalidValue(builderName, "sourceCredentials", source,
+				"duplicate credentials for package source %s", source)
+		}
+		seen[source] = true
+
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flags returns the NuGet flags of the command.
+func (n *nugetOptions) flags() []string {
+	var flags []string
+	for _, s := range n.sources {
+		flags = append(flags, "--source", s)
+	}
+
+	switch {
+	case n.configFile != "":
+		flags = append(flags, "--configfile", n.configFile)
+	case n.configSecret != nil:
+		flags = append(flags, "--configfile", n.configSecret.Target)
+	}
+
+	return flags
+}
+
+// mounts returns the mount of the NuGet configuration secret.
+func (n *nugetOptions) mounts() []types.Mount {
+	if n.configSecret == nil {
+		return nil
+	}
+
+	if m, ok := n.configSecret.MountRequirement(); ok {
+		return []types.Mount{m}
+	}
+
+	return nil
+}
+
+// Secrets returns the secrets the command requires: the NuGet configuration secret and the
+// source credentials.
+func (b *DotnetBuilder) Secrets() []*secretsx.SecretRef {
+	var secrets []*secretsx.SecretRef
+	if b.nuget.configSecret != nil {
+		secrets = append(secrets, b.nuget.configSecret)
+	}
+
+	for _, c := range b.nuget.credentials {
+		if c != nil {
+			secrets = append(secrets, c)
+		}
+	}
+
+	return secrets
+}
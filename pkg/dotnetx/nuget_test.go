@@ -0,0 +1,84 @@
+package dotnetx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNuGetSources(t *testing.T) {
+	cmd, err := NewRestoreBuilder("").
+		WithSource("https://api.nuget.org/v3/index.json", "https://nuget.example.com/v3/index.json").
+		WithNuGetConfig("nuget.config").
+		BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"dotnet", "restore",
+		"--source", "https://api.nuget.org/v3/index.json",
+		"--source", "https://nuget.example.com/v3/index.json",
+		"--configfile", "nuget.config",
+	}, cmd)
+}
+
+func TestNuGetConfigSecret(t *testing.T) {
+	config := secretsx.FromFile("nuget-config", "/home/ci/nuget.config").AsFile("/run/secrets/nuget.config")
+
+	b := NewRestoreBuilder("App.sln").WithNuGetConfigSecret(config)
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dotnet", "restore", "App.sln", "--configfile", "/run/secrets/nuget.config"}, cmd)
+	assert.Equal(t, []*secretsx.SecretRef{config}, b.Secrets())
+	assert.Equal(t, []types.Mount{
+		{Kind: types.MountKindSecret, Source: "nuget-config", Target: "/run/secrets/nuget.config", ReadOnly: true},
+	}, b.Mounts())
+}
+
+func TestSourceCredentials(t *testing.T) {
+	credentials := secretsx.FromEnv("nuget-credentials", "NUGET_CREDENTIALS")
+
+	b := NewPublishBuilder("").WithSourceCredentials("internal", credentials)
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.NotContains(t, cmd, "NUGET_CREDENTIALS")
+
+	secrets := b.Secrets()
+	require.Len(t, secrets, 1)
+	assert.Equal(t, secretsx.MountAsEnv, secrets[0].Mount)
+	assert.Equal(t, "NuGetPackageSourceCredentials_internal", secrets[0].Target)
+	assert.Equal(t, "NUGET_CREDENTIALS", credentials.Target, "the secret reference must be left unchanged")
+	assert.Nil(t, b.Mounts())
+}
+
+func TestNuGetValidation(t *testing.T) {
+	config := secretsx.FromFile("nuget-config", "/home/ci/nuget.config").AsFile("/run/secrets/nuget.config")
+	credentials := secretsx.FromEnv("nuget-credentials", "NUGET_CREDENTIALS")
+
+	tests := []struct {
+		name    string
+		builder *DotnetBuilder
+		kind    error
+	}{
+		{"Config on build", NewBuildBuilder("").WithNuGetConfig("nuget.config"), errorsx.ErrUnsupported},
+		{"Sources on test", NewTestBuilder("").WithSource("https://nuget.example.com"), errorsx.ErrUnsupported},
+		{"Config file and secret", NewRestoreBuilder("").WithNuGetConfig("nuget.config").WithNuGetConfigSecret(config), errorsx.ErrConflictingOptions},
+		{"Config secret as env", NewRestoreBuilder("").WithNuGetConfigSecret(secretsx.FromEnv("nuget-config", "NUGET_CONFIG")), errorsx.ErrInvalidValue},
+		{"Missing credentials", NewRestoreBuilder("").WithSourceCredentials("internal", nil), errorsx.ErrMissingField},
+		{"Invalid source name", NewRestoreBuilder("").WithSourceCredentials("my-feed", credentials), errorsx.ErrInvalidValue},
+		{"Duplicate source", NewRestoreBuilder("").WithSourceCredentials("internal", credentials).WithSourceCredentials("internal", credentials), errorsx.ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.BuildCommand()
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.kind), "expected %v, got %v", tt.kind, err)
+		})
+	}
+}
@@ -0,0 +1,291 @@
+// Package drydockx simulates pipelines end to end without executing anything: it walks the plan
+// of a pipeline.Pipeline stage by stage and lists, for every node, the command, mounts,
+// environment, secrets and artifacts it would use, with an estimated cache key, and flags the
+// problems it can detect offline. It is effectively `terraform plan` for daggerx pipelines.
+//
+// Cache keys are estimated from what the plan knows: the command, environment, mounts and
+// secret names of a node, and the cache keys of the nodes it depends on, so a change upstream
+// changes every key downstream. The content of host directories and files is not read: two
+// simulations with equal keys only run equal commands on the same paths.
+//
+// Commands and environment values are scrubbed of the secrets registered with the redactor (the
+// default logx redactor unless set), so simulations can be shared in pull requests.
+//
+// Example usage:
+//
+//	sim, err := drydockx.NewSimulator().Simulate(p)
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	fmt.Print(sim) // the plan, stage by stage
+//	data, err := sim.JSON()
+package drydockx
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/memox"
+	"github.com/Excoriate/daggerx/pkg/pipeline"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// Step is the simulation of a pipeline node.
+type Step struct {
+	// ID is the identifier of the node.
+	ID string `json:"id"`
+	// Stage is the 1-based stage of the node; nodes of a stage can run in parallel.
+	Stage int `json:"stage"`
+	// Command is the command the node would run, with the secrets redacted.
+	Command []string `json:"command"`
+	// Env holds the environment variables of the command, with the secrets redacted.
+	Env []types.DaggerEnvVars `json:"env,omitempty"`
+	// Mounts holds the mounts of the command. The content of inline mounts is redacted too.
+	Mounts []types.Mount `json:"mounts,omitempty"`
+	// Secrets holds the names of the secrets the command requires.
+	Secrets []string `json:"secrets,omitempty"`
+	// Artifacts holds the paths of the files the command would produce.
+	Artifacts []string `json:"artifacts,omitempty"`
+	// DependsOn holds the identifiers of the nodes the node waits for.
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// CacheKey is the estimated cache key of the node (see the package documentation).
+	CacheKey string `json:"cacheKey"`
+}
+
+// Simulation is the simulated execution of a pipeline.
+type Simulation struct {
+	// Pipeline is the name of the pipeline.
+	Pipeline string `json:"pipeline"`
+	// Stages holds the node identifiers grouped by stage.
+	Stages [][]string `json:"stages"`
+	// Steps holds the simulated nodes, in execution order.
+	Steps []Step `json:"steps"`
+	// Secrets holds the names of every secret the pipeline requires, sorted.
+	Secrets []string `json:"secrets,omitempty"`
+	// Artifacts holds the paths of every artifact the pipeline produces, in execution order.
+	Artifacts []string `json:"artifacts,omitempty"`
+	// Warnings holds the problems detected offline, such as artifacts produced twice.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Simulator simulates pipelines.
+type Simulator struct {
+	// redactor scrubs the secrets from commands and environment values. A nil redactor is the
+	// default logx redactor.
+	redactor *logx.Redactor
+}
+
+// NewSimulator creates a simulator scrubbing the secrets registered with the default logx redactor.
+func NewSimulator() *Simulator {
+	return &Simulator{}
+}
+
+// WithRedactor sets the redactor scrubbing the secrets from the simulation.
+// It returns the updated Simulator instance.
+func (s *Simulator) WithRedactor(redactor *logx.Redactor) *Simulator {
+	s.redactor = redactor
+	return s
+}
+
+// cacheInput is the content hashed into the cache key of a node.
+type cacheInput struct {
+	Command   types.DaggerCMD       `json:"command"`
+	Env       []types.DaggerEnvVars `json:"env,omitempty"`
+	Mounts    []types.Mount         `json:"mounts,omitempty"`
+	Secrets   []string              `json:"secrets,omitempty"`
+	Artifacts []string              `json:"artifacts,omitempty"`
+	Upstream  []string              `json:"upstream,omitempty"`
+}
+
+// Simulate walks the plan of 'p' without executing anything.
+//
+// Returns:
+//   - The simulation.
+//   - An error if the pipeline is invalid (unknown dependency or cycle).
+func (s *Simulator) Simulate(p *pipeline.Pipeline) (*Simulation, error) {
+	plan, err := p.Plan()
+	if err != nil {
+		return nil, err
+	}
+
+	redactor := s.redactor
+	if redactor == nil {
+		redactor = logx.Default()
+	}
+
+	stageOf := map[string]int{}
+	for i, stage := range plan.Stages {
+		for _, id := range stage {
+			stageOf[id] = i + 1
+		}
+	}
+
+	sim := &Simulation{Pipeline: plan.Name, Stages: plan.Stages}
+	keys := make(map[string]string, len(plan.Nodes))
+	producers := map[string]string{}
+	secrets := map[string]bool{}
+
+	for _, n := range plan.Nodes {
+		upstream := make([]string, 0, len(n.DependsOn))
+		for _, dep := range n.DependsOn {
+			upstream = append(upstream, keys[dep])
+		}
+		slices.Sort(upstream)
+
+		key, err := memox.Key(cacheInput{
+			Command:   n.Command,
+			Env:       n.Env,
+			Mounts:    n.Mounts,
+			Secrets:   n.Secrets,
+			Artifacts: n.Artifacts,
+			Upstream:  upstream,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate the cache key of node %s: %w", n.ID, err)
+		}
+		keys[n.ID] = key
+
+		step := Step{
+			ID:        n.ID,
+			Stage:     stageOf[n.ID],
+			Command:   redactor.Command(n.Command),
+			Secrets:   n.Secrets,
+			Artifacts: n.Artifacts,
+			DependsOn: n.DependsOn,
+			CacheKey:  key,
+		}
+
+		for _, v := range n.Env {
+			v.Value = redactor.String(v.Value)
+			step.Env = append(step.Env, v)
+		}
+
+		for _, m := range n.Mounts {
+			m.Content = redactor.String(m.Content)
+			step.Mounts = append(step.Mounts, m)
+
+			if m.Kind == types.MountKindSecret && !slices.Contains(n.Secrets, m.Source) {
+				sim.Warnings = append(sim.Warnings, fmt.Sprintf(
+					"node %s mounts secret %s without declaring it in its secrets", n.ID, m.Source))
+			}
+		}
+
+		for _, name := range n.Secrets {
+			secrets[name] = true
+		}
+
+		for _, artifact := range n.Artifacts {
+			if other, ok := producers[artifact]; ok {
+				sim.Warnings = append(sim.Warnings, fmt.Sprintf(
+					"artifact %s is produced by both %s and %s", artifact, other, n.ID))
+				continue
+			}
+			producers[artifact] = n.ID
+			sim.Artifacts = append(sim.Artifacts, artifact)
+		}
+
+		sim.Steps = append(sim.Steps, step)
+	}
+
+	for name := range secrets {
+		sim.Secrets = append(sim.Secrets, name)
+	}
+	slices.Sort(sim.Secrets)
+
+	return sim, nil
+}
+
+// Simulate simulates 'p' with a default Simulator.
+func Simulate(p *pipeline.Pipeline) (*Simulation, error) {
+	return NewSimulator().Simulate(p)
+}
+
+// JSON returns the simulation as indented JSON.
+func (s *Simulation) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode simulation: %w", err)
+	}
+
+	return data, nil
+}
+
+// Step returns the simulated node 'id'.
+func (s *Simulation) Step(id string) (Step, bool) {
+	for _, step := range s.Steps {
+		if step.ID == id {
+			return step, true
+		}
+	}
+
+	return Step{}, false
+}
+
+// String renders the simulation as text, stage by stage, ending with a summary line.
+func (s *Simulation) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Pipeline %q: %d steps in %d stages\n", s.Pipeline, len(s.Steps), len(s.Stages))
+
+	stage := 0
+	for _, step := range s.Steps {
+		if step.Stage != stage {
+			stage = step.Stage
+			fmt.Fprintf(&b, "\nStage %d:\n", stage)
+		}
+
+		fmt.Fprintf(&b, "  + %s\n", step.ID)
+		writeField(&b, "command", strings.Join(step.Command, " "))
+		writeField(&b, "depends on", strings.Join(step.DependsOn, ", "))
+
+		for _, v := range step.Env {
+			writeField(&b, "env", v.Name+"="+v.Value)
+		}
+
+		for _, m := range step.Mounts {
+			writeField(&b, "mount", describeMount(m))
+		}
+
+		writeField(&b, "secrets", strings.Join(step.Secrets, ", "))
+		writeField(&b, "artifacts", strings.Join(step.Artifacts, ", "))
+		writeField(&b, "cache key", step.CacheKey)
+	}
+
+	for _, w := range s.Warnings {
+		fmt.Fprintf(&b, "\nWarning: %s", w)
+	}
+	if len(s.Warnings) > 0 {
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "\nPlan: %d steps, %d stages, %d secrets, %d artifacts. Nothing was executed.\n",
+		len(s.Steps), len(s.Stages), len(s.Secrets), len(s.Artifacts))
+
+	return b.String()
+}
+
+// writeField writes an indented "name: value" line, unless the value is empty.
+func writeField(b *strings.Builder, name, value string) {
+	if value != "" {
+		fmt.Fprintf(b, "      %-11s %s\n", name+":", value)
+	}
+}
+
+// describeMount renders a mount as "<kind> <source> -> <target>".
+func describeMount(m types.Mount) string {
+	source := m.Source
+	if m.Kind == types.MountKindInline {
+		source = fmt.Sprintf("(%d bytes)", len(m.Content))
+	}
+
+	desc := fmt.Sprintf("%s %s -> %s", m.Kind, source, m.Target)
+	if m.ReadOnly {
+		desc += " (read-only)"
+	}
+
+	return desc
+}
@@ -0,0 +1,112 @@
+package drydockx
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/goldenx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/pipeline"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPipeline(t *testing.T, apkoArgs ...string) *pipeline.Pipeline {
+	t.Helper()
+
+	p := pipeline.New("release")
+	require.NoError(t, p.AddNode(pipeline.Node{
+		ID:      "apko",
+		Command: append(types.DaggerCMD{"apko", "build", "apko.yaml"}, apkoArgs...),
+		Env:     []types.DaggerEnvVars{{Name: "REGISTRY_TOKEN", Value: "s3cr3t"}},
+		Mounts: []types.Mount{
+			{Kind: types.MountKindDirectory, Source: "./src", Target: "/work", ReadOnly: true},
+			{Kind: types.MountKindCache, Source: "apko-cache", Target: "/var/cache/apko"},
+		},
+		Artifacts: []string{"/out/image.tar"},
+	}))
+	require.NoError(t, p.AddNode(pipeline.Node{
+		ID:        "sbom",
+		Command:   types.DaggerCMD{"syft", "/out/image.tar"},
+		DependsOn: []string{"apko"},
+		Artifacts: []string{"/out/sbom.json"},
+	}))
+	require.NoError(t, p.AddNode(pipeline.Node{
+		ID:        "sign",
+		Command:   types.DaggerCMD{"cosign", "sign", "--key", "env://COSIGN_KEY"},
+		Mounts:    []types.Mount{{Kind: types.MountKindSecret, Source: "cosign-key", Target: "/run/secrets/key"}},
+		Secrets:   []string{"cosign-key"},
+		DependsOn: []string{"apko"},
+	}))
+
+	return p
+}
+
+func TestSimulate(t *testing.T) {
+	sim, err := NewSimulator().
+		WithRedactor(logx.NewRedactor().AddValues("s3cr3t")).
+		Simulate(newTestPipeline(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{{"apko"}, {"sbom", "sign"}}, sim.Stages)
+	assert.Equal(t, []string{"cosign-key"}, sim.Secrets)
+	assert.Equal(t, []string{"/out/image.tar", "/out/sbom.json"}, sim.Artifacts)
+	assert.Empty(t, sim.Warnings)
+
+	apko, ok := sim.Step("apko")
+	require.True(t, ok)
+	assert.Equal(t, 1, apko.Stage)
+	assert.Equal(t, logx.Redacted, apko.Env[0].Value)
+
+	data, err := sim.JSON()
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "s3cr3t")
+	assert.True(t, json.Valid(data))
+
+	goldenx.New(t).AssertString("simulation", sim.String())
+}
+
+func TestSimulateCacheKeysPropagate(t *testing.T) {
+	base, err := Simulate(newTestPipeline(t))
+	require.NoError(t, err)
+
+	again, err := Simulate(newTestPipeline(t))
+	require.NoError(t, err)
+
+	changed, err := Simulate(newTestPipeline(t, "--arch", "aarch64"))
+	require.NoError(t, err)
+
+	for i, step := range base.Steps {
+		assert.Equal(t, step.CacheKey, again.Steps[i].CacheKey, "keys are deterministic")
+		assert.NotEqual(t, step.CacheKey, changed.Steps[i].CacheKey, "a change upstream changes the key of %s", step.ID)
+	}
+}
+
+func TestSimulateWarnings(t *testing.T) {
+	p := pipeline.New("conflicts")
+	require.NoError(t, p.AddNode(pipeline.Node{ID: "a", Command: types.DaggerCMD{"a"}, Artifacts: []string{"/out/x"}}))
+	require.NoError(t, p.AddNode(pipeline.Node{
+		ID:        "b",
+		Command:   types.DaggerCMD{"b"},
+		Artifacts: []string{"/out/x"},
+		Mounts:    []types.Mount{{Kind: types.MountKindSecret, Source: "token", Target: "/run/token"}},
+	}))
+
+	sim, err := Simulate(p)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"node b mounts secret token without declaring it in its secrets",
+		"artifact /out/x is produced by both a and b",
+	}, sim.Warnings)
+	assert.Contains(t, sim.String(), "Warning: artifact /out/x is produced by both a and b")
+}
+
+func TestSimulateInvalidPipeline(t *testing.T) {
+	p := pipeline.New("invalid")
+	require.NoError(t, p.AddNode(pipeline.Node{ID: "a", Command: types.DaggerCMD{"a"}, DependsOn: []string{"missing"}}))
+
+	_, err := Simulate(p)
+	require.Error(t, err)
+}
@@ -0,0 +1,76 @@
+package e2ex
+
+import (
+	"slices"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// Browser is a browser engine run by the end-to-end tests.
+type Browser string
+
+const (
+	// BrowserChromium is Chromium (also Chrome and Edge).
+	BrowserChromium Browser = "chromium"
+	// BrowserFirefox is Firefox.
+	BrowserFirefox Browser = "firefox"
+	// BrowserWebKit is WebKit, run by playwright only.
+	BrowserWebKit Browser = "webkit"
+	// BrowserElectron is the Electron browser bundled with cypress, run under Xvfb.
+	BrowserElectron Browser = "electron"
+)
+
+// browserPackages maps the browsers to the Debian (bookworm) packages of their shared libraries
+// and fonts.
+var browserPackages = map[Browser][]string{
+	BrowserChromium: {
+		"fonts-liberation", "libasound2", "libatk-bridge2.0-0", "libatk1.0-0", "libatspi2.0-0",
+		"libcairo2", "libcups2", "libdbus-1-3", "libdrm2", "libgbm1", "libglib2.0-0", "libnspr4",
+		"libnss3", "libpango-1.0-0", "libx11-6", "libxcb1", "libxcomposite1", "libxdamage1",
+		"libxext6", "libxfixes3", "libxkbcommon0", "libxrandr2",
+	},
+	BrowserFirefox: {
+		"libasound2", "libcairo-gobject2", "libcairo2", "libdbus-1-3", "libdbus-glib-1-2",
+		"libfontconfig1", "libfreetype6", "libgdk-pixbuf-2.0-0", "libglib2.0-0", "libgtk-3-0",
+		"libpango-1.0-0", "libpangocairo-1.0-0", "libx11-6", "libx11-xcb1", "libxcb-shm0", "libxcb1",
+		"libxcomposite1", "libxcursor1", "libxdamage1", "libxext6", "libxfixes3", "libxi6",
+		"libxrandr2", "libxrender1", "libxtst6",
+	},
+	BrowserWebKit: {
+		"libenchant-2-2", "libepoxy0", "libevent-2.1-7", "libflite1", "libgles2",
+		"libgstreamer-gl1.0-0", "libgstreamer-plugins-bad1.0-0", "libgstreamer-plugins-base1.0-0",
+		"libgstreamer1.0-0", "libgtk-3-0", "libharfbuzz-icu0", "libhyphen0", "libicu72",
+		"libjpeg62-turbo", "liblcms2-2", "libmanette-0.2-0", "libopus0", "libsecret-1-0",
+		"libsoup-3.0-0", "libwebpdemux2", "libwoff1", "libxslt1.1",
+	},
+	BrowserElectron: {
+		"libasound2", "libgbm1", "libgtk-3-0", "libgtk2.0-0", "libnotify4", "libnss3", "libxss1",
+		"libxtst6", "xauth", "xvfb",
+	},
+}
+
+// BrowserPackages returns the Debian (bookworm) packages the browsers need in the runner image,
+// sorted and deduplicated, e.g. for an `apt-get install` step. Images with the browsers
+// preinstalled (such as the official playwright and cypress images) don't need them.
+//
+// Returns:
+//   - The packages.
+//   - An error if no browser is given or a browser is unknown.
+func BrowserPackages(browsers ...Browser) ([]string, error) {
+	if len(browsers) == 0 {
+		return nil, errorsx.MissingField(builderName, "browsers", "at least one browser is required")
+	}
+
+	var packages []string
+	for _, b := range browsers {
+		pkgs, ok := browserPackages[b]
+		if !ok {
+			return nil, errorsx.InvalidValue(builderName, "browsers", b, "unknown browser: %q", b)
+		}
+		packages = append(packages, pkgs...)
+	}
+
+	slices.Sort(packages)
+
+	return slices.Compact(packages), nil
+}
@@ -0,0 +1,32 @@
+package e2ex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrowserPackages(t *testing.T) {
+	chromium, err := BrowserPackages(BrowserChromium)
+	require.NoError(t, err)
+	assert.Contains(t, chromium, "libnss3")
+	assert.IsIncreasing(t, chromium)
+
+	both, err := BrowserPackages(BrowserChromium, BrowserFirefox, BrowserChromium)
+	require.NoError(t, err)
+	assert.Contains(t, both, "libgtk-3-0")
+	assert.IsIncreasing(t, both, "packages are sorted and deduplicated")
+
+	electron, err := BrowserPackages(BrowserElectron)
+	require.NoError(t, err)
+	assert.Contains(t, electron, "xvfb")
+
+	_, err = BrowserPackages()
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+
+	_, err = BrowserPackages("safari")
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+}
@@ -0,0 +1,285 @@
+package e2ex
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// CypressDefaultImage is the default container image providing cypress and its browsers.
+const CypressDefaultImage = "cypress/included:13.17.0"
+
+// CypressRecordKeyEnvVar is the environment variable cypress reads the Cypress Cloud record key
+// from.
+const CypressRecordKeyEnvVar = "CYPRESS_RECORD_KEY"
+
+// CypressBrowsers are the browsers cypress runs: electron (bundled), and the browsers installed
+// in the image.
+var CypressBrowsers = []string{"electron", "chrome", "chromium", "edge", "firefox"}
+
+// CypressBuilder generates the cypress run command of a project, in headless mode. Screenshots
+// of the failed tests, videos and JUnit reports are written under the artifacts directory.
+type CypressBuilder struct {
+	// project is the project directory. Empty uses the working directory.
+	project string
+
+	// configFile is the cypress configuration file.
+	configFile string
+
+	// browser is the browser running the tests.
+	browser string
+
+	// specs are the spec patterns to run.
+	specs []string
+
+	// env are the cypress environment variables (Cypress.env).
+	env map[string]string
+
+	// video records a video of every spec.
+	video bool
+
+	// junit writes a JUnit report per spec.
+	junit bool
+
+	// recordKey is the secret holding the Cypress Cloud record key. The run is recorded when set.
+	recordKey *secretsx.SecretRef
+
+	// artifactsDir is the directory of the videos, screenshots and reports.
+	artifactsDir string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewCypressBuilder creates a CypressBuilder writing its artifacts to GetArtifactsDir("").
+func NewCypressBuilder() *CypressBuilder {
+	return &CypressBuilder{env: map[string]string{}, artifactsDir: GetArtifactsDir("")}
+}
+
+// WithProject sets the project directory.
+func (b *CypressBuilder) WithProject(dir string) *CypressBuilder {
+	b.project = dir
+	return b
+}
+
+// WithConfigFile sets the cypress configuration file (e.g. "cypress.config.ts").
+func (b *CypressBuilder) WithConfigFile(path string) *CypressBuilder {
+	b.configFile = path
+	return b
+}
+
+// WithBrowser sets the browser running the tests, one of CypressBrowsers. It defaults to
+// electron.
+func (b *CypressBuilder) WithBrowser(browser string) *CypressBuilder {
+	b.browser = browser
+	return b
+}
+
+// WithSpec adds spec patterns to run (e.g. "cypress/e2e/checkout/**").
+func (b *CypressBuilder) WithSpec(specs ...string) *CypressBuilder {
+	b.specs = append(b.specs, specs...)
+	return b
+}
+
+// WithEnv sets the cypress environment variable 'key' (read with Cypress.env).
+func (b *CypressBuilder) WithEnv(key, value string) *CypressBuilder {
+	b.env[key] = value
+	return b
+}
+
+// WithVideo sets whether a video of every spec is recorded.
+func (b *CypressBuilder) WithVideo(video bool) *CypressBuilder {
+	b.video = video
+	return b
+}
+
+// WithJUnit sets whether a JUnit report is written per spec.
+func (b *CypressBuilder) WithJUnit(junit bool) *CypressBuilder {
+	b.junit = junit
+	return b
+}
+
+// WithRecordKey records the run to Cypress Cloud with the record key held by 'ref'. The secret
+// is exposed as CypressRecordKeyEnvVar; 'ref' is left unchanged.
+func (b *CypressBuilder) WithRecordKey(ref *secretsx.SecretRef) *CypressBuilder {
+	if ref == nil {
+		b.recordKey = nil
+		return b
+	}
+
+	key := *ref
+	b.recordKey = key.AsEnv(CypressRecordKeyEnvVar)
+	return b
+}
+
+// WithArtifactsDir sets the directory of the videos, screenshots and reports.
+func (b *CypressBuilder) WithArtifactsDir(dir string) *CypressBuilder {
+	b.artifactsDir = dir
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *CypressBuilder) WithLogger(logger *slog.Logger) *CypressBuilder {
+	b.logger = logger
+	return b
+}
+
+// ScreenshotsDir returns the directory of the screenshots.
+func (b *CypressBuilder) ScreenshotsDir() string {
+	return filepath.Join(b.artifactsDir, "screenshots")
+}
+
+// VideosDir returns the directory of the videos.
+func (b *CypressBuilder) VideosDir() string {
+	return filepath.Join(b.artifactsDir, "videos")
+}
+
+// ReportsDir returns the directory of the JUnit reports.
+func (b *CypressBuilder) ReportsDir() string {
+	return filepath.Join(b.artifactsDir, "reports")
+}
+
+// BuildCommand generates the cypress run command.
+//
+// Returns:
+//   - The cypress run command.
+//   - An error if the artifacts directory is missing, the browser is unsupported, an environment
+//     variable has an empty key, or the record key is invalid.
+func (b *CypressBuilder) BuildCommand() ([]string, error) {
+	if b.artifactsDir == "" {
+		return nil, errorsx.MissingField(builderName, "artifactsDir", "artifacts directory is required")
+	}
+
+	if b.browser != "" && !slices.Contains(CypressBrowsers, b.browser) {
+		return nil, errorsx.InvalidValue(builderName, "browser", b.browser, "unsupported cypress browser: %q", b.browser)
+	}
+
+	if _, ok := b.env[""]; ok {
+		return nil, errorsx.InvalidValue(builderName, "env", "", "cypress environment variables require a key")
+	}
+
+	if b.recordKey != nil {
+		if err := b.recordKey.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	cmd := []string{"npx", "cypress", "run", "--headless"}
+	if b.project != "" {
+		cmd = append(cmd, "--project", b.project)
+	}
+
+	if b.configFile != "" {
+		cmd = append(cmd, "--config-file", b.configFile)
+	}
+
+	if b.browser != "" {
+		cmd = append(cmd, "--browser", b.browser)
+	}
+
+	if len(b.specs) > 0 {
+		cmd = append(cmd, "--spec", strings.Join(b.specs, ","))
+	}
+
+	config := []string{"screenshotsFolder=" + b.ScreenshotsDir()}
+	if b.video {
+		config = append(config, "video=true", "videosFolder="+b.VideosDir())
+	}
+	cmd = append(cmd, "--config", strings.Join(config, ","))
+
+	if len(b.env) > 0 {
+		keys := make([]string, 0, len(b.env))
+		for k := range b.env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		pairs := make([]string, len(keys))
+		for i, k := range keys {
+			pairs[i] = k + "=" + b.env[k]
+		}
+		cmd = append(cmd, "--env", strings.Join(pairs, ","))
+	}
+
+	if b.junit {
+		cmd = append(cmd, "--reporter", "junit",
+			"--reporter-options", "mochaFile="+filepath.Join(b.ReportsDir(), "junit-[hash].xml"))
+	}
+
+	if b.recordKey != nil {
+		cmd = append(cmd, "--record")
+	}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Env returns the environment variables of the run: CI. The record key is a secret (see
+// Secrets).
+func (b *CypressBuilder) Env() []types.DaggerEnvVars {
+	return []types.DaggerEnvVars{{Name: "CI", Value: "true"}}
+}
+
+// Secrets returns the secrets the run requires: the record key, if any.
+func (b *CypressBuilder) Secrets() []*secretsx.SecretRef {
+	if b.recordKey == nil {
+		return nil
+	}
+
+	return []*secretsx.SecretRef{b.recordKey}
+}
+
+// Artifacts returns the artifacts of the run: the screenshots directory, the videos directory
+// when videos are recorded, and the reports directory when JUnit reports are written.
+func (b *CypressBuilder) Artifacts() []artifactx.Artifact {
+	artifacts := []artifactx.Artifact{{Kind: artifactx.KindTestOutput, Path: b.ScreenshotsDir(), Directory: true}}
+	if b.video {
+		artifacts = append(artifacts, artifactx.Artifact{Kind: artifactx.KindTestOutput, Path: b.VideosDir(), Directory: true})
+	}
+
+	if b.junit {
+		artifacts = append(artifacts, artifactx.Artifact{Kind: artifactx.KindReport, Path: b.ReportsDir(), Directory: true})
+	}
+
+	return artifacts
+}
+
+// RegisterArtifacts registers the artifacts of the run into the collector 'c'.
+//
+// Returns:
+//   - An error if an artifact is already registered.
+func (b *CypressBuilder) RegisterArtifacts(c *artifactx.Collector) error {
+	return register(c, b.Artifacts())
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *CypressBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *CypressBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the cypress run command.
+func (b *CypressBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("cypress", b, NewCypressBuilder(), explainx.Generate("BuildCommand", b.BuildCommand))
+}
@@ -0,0 +1,91 @@
+package e2ex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCypressBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *CypressBuilder
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "defaults",
+			builder: NewCypressBuilder(),
+			want: []string{
+				"npx", "cypress", "run", "--headless", "--config", "screenshotsFolder=/mnt/e2e/screenshots",
+			},
+		},
+		{
+			name: "all options",
+			builder: NewCypressBuilder().
+				WithProject("web").
+				WithConfigFile("cypress.config.ts").
+				WithBrowser("chrome").
+				WithSpec("cypress/e2e/checkout/**", "cypress/e2e/login.cy.ts").
+				WithEnv("baseUrl", "http://web:3000").
+				WithEnv("apiUrl", "http://api:8080").
+				WithVideo(true).
+				WithJUnit(true).
+				WithRecordKey(secretsx.FromEnv("cypress-key", "CI_CYPRESS_KEY")),
+			want: []string{
+				"npx", "cypress", "run", "--headless", "--project", "web", "--config-file", "cypress.config.ts",
+				"--browser", "chrome", "--spec", "cypress/e2e/checkout/**,cypress/e2e/login.cy.ts",
+				"--config", "screenshotsFolder=/mnt/e2e/screenshots,video=true,videosFolder=/mnt/e2e/videos",
+				"--env", "apiUrl=http://api:8080,baseUrl=http://web:3000",
+				"--reporter", "junit", "--reporter-options", "mochaFile=/mnt/e2e/reports/junit-[hash].xml",
+				"--record",
+			},
+		},
+		{name: "unknown browser", builder: NewCypressBuilder().WithBrowser("safari"), wantErr: errorsx.ErrInvalidValue},
+		{name: "empty env key", builder: NewCypressBuilder().WithEnv("", "x"), wantErr: errorsx.ErrInvalidValue},
+		{name: "missing artifacts dir", builder: NewCypressBuilder().WithArtifactsDir(""), wantErr: errorsx.ErrMissingField},
+		{
+			name:    "invalid record key",
+			builder: NewCypressBuilder().WithRecordKey(&secretsx.SecretRef{Name: "key", Source: secretsx.SourceEnv}),
+			wantErr: errorsx.ErrMissingField,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestCypressBuilderSecretsAndArtifacts(t *testing.T) {
+	key := secretsx.FromEnv("cypress-key", "CI_CYPRESS_KEY")
+	b := NewCypressBuilder().WithRecordKey(key).WithVideo(true).WithJUnit(true)
+
+	require.Len(t, b.Secrets(), 1)
+	assert.Equal(t, CypressRecordKeyEnvVar, b.Secrets()[0].Target)
+	assert.Equal(t, "CI_CYPRESS_KEY", key.Target)
+	assert.Nil(t, NewCypressBuilder().Secrets())
+
+	assert.Equal(t, []artifactx.Artifact{
+		{Kind: artifactx.KindTestOutput, Path: "/mnt/e2e/screenshots", Directory: true},
+		{Kind: artifactx.KindTestOutput, Path: "/mnt/e2e/videos", Directory: true},
+		{Kind: artifactx.KindReport, Path: "/mnt/e2e/reports", Directory: true},
+	}, b.Artifacts())
+	assert.Len(t, NewCypressBuilder().Artifacts(), 1)
+
+	c := artifactx.NewCollector()
+	require.NoError(t, b.RegisterArtifacts(c))
+	assert.Len(t, c.ByKind(artifactx.KindTestOutput), 2)
+}
@@ -0,0 +1,55 @@
+// Package e2ex provides builders for the headless browser tests of end-to-end pipelines:
+// playwright test and cypress run, with their videos, screenshots and reports written under a
+// conventional artifacts directory and registered in the artifact manifest (see artifactx), and
+// the system packages the browsers need in the runner image.
+//
+// Example usage:
+//
+//	pw := e2ex.NewPlaywrightBuilder().
+//	    WithProject("chromium").
+//	    WithShard(1, 4).
+//	    WithReporter("list", "junit", "html")
+//
+//	cmd, err := pw.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	collector := artifactx.NewCollector()
+//	if err := pw.RegisterArtifacts(collector); err != nil {
+//	    // handle error
+//	}
+//
+//	packages, err := e2ex.BrowserPackages(e2ex.BrowserChromium)
+package e2ex
+
+import (
+	"path"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+)
+
+// builderName identifies the end-to-end test builders in errorsx errors.
+const builderName = "e2e"
+
+// GetArtifactsDir returns the conventional directory of the test artifacts inside the
+// container. If mntPrefix is empty, fixtures.MntPrefix is used.
+func GetArtifactsDir(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, "e2e")
+}
+
+// register registers 'artifacts' into the collector 'c', stopping at the first error.
+func register(c *artifactx.Collector, artifacts []artifactx.Artifact) error {
+	for _, a := range artifacts {
+		if err := c.Register(a); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package e2ex
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetArtifactsDir(t *testing.T) {
+	assert.Equal(t, "/mnt/e2e", GetArtifactsDir(""))
+	assert.Equal(t, "/work/e2e", GetArtifactsDir("/work"))
+}
+
+func TestRegister(t *testing.T) {
+	c := artifactx.NewCollector()
+	a := artifactx.Artifact{Kind: artifactx.KindReport, Path: "/mnt/e2e/junit.xml"}
+
+	require.NoError(t, register(c, []artifactx.Artifact{a}))
+	assert.EqualError(t, register(c, []artifactx.Artifact{a}), "artifact /mnt/e2e/junit.xml is already registered")
+}
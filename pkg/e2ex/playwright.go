@@ -0,0 +1,325 @@
+package e2ex
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// PlaywrightDefaultImage is the default container image providing playwright and its browsers.
+const PlaywrightDefaultImage = "mcr.microsoft.com/playwright:v1.49.1-noble"
+
+// PlaywrightReporters are the built-in reporters of playwright test.
+var PlaywrightReporters = []string{"list", "line", "dot", "github", "junit", "html", "json", "blob"}
+
+// playwrightBrowsers are the browsers playwright installs.
+var playwrightBrowsers = []Browser{BrowserChromium, BrowserFirefox, BrowserWebKit}
+
+// PlaywrightBuilder generates the playwright test command of a project. The attachments of the
+// tests (videos, screenshots and traces, as enabled in the playwright configuration) are written
+// to the test-results directory, and the reports of the file reporters next to it.
+type PlaywrightBuilder struct {
+	// config is the playwright configuration file.
+	config string
+
+	// files filter the test files.
+	files []string
+
+	// projects are the projects to run.
+	projects []string
+
+	// grep runs the tests matching this regular expression only.
+	grep string
+
+	// shardIndex is the 1-based index of the shard to run.
+	shardIndex int
+
+	// shardTotal is the total number of shards. Zero runs every test.
+	shardTotal int
+
+	// workers is the number of parallel workers. Zero uses the playwright default.
+	workers int
+
+	// retries is the number of retries of the failed tests.
+	retries int
+
+	// reporters are the reporters of the run.
+	reporters []string
+
+	// artifactsDir is the directory of the test results and reports.
+	artifactsDir string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewPlaywrightBuilder creates a PlaywrightBuilder writing its artifacts to
+// GetArtifactsDir("").
+func NewPlaywrightBuilder() *PlaywrightBuilder {
+	return &PlaywrightBuilder{artifactsDir: GetArtifactsDir("")}
+}
+
+// WithConfig sets the playwright configuration file (e.g. "playwright.config.ts").
+func (b *PlaywrightBuilder) WithConfig(config string) *PlaywrightBuilder {
+	b.config = config
+	return b
+}
+
+// WithFiles filters the test files (e.g. "tests/checkout").
+func (b *PlaywrightBuilder) WithFiles(files ...string) *PlaywrightBuilder {
+	b.files = append(b.files, files...)
+	return b
+}
+
+// WithProject adds projects of the playwright configuration to run (e.g. "chromium").
+func (b *PlaywrightBuilder) WithProject(projects ...string) *PlaywrightBuilder {
+	b.projects = append(b.projects, projects...)
+	return b
+}
+
+// WithGrep runs the tests whose title matches the regular expression 'pattern' only.
+func (b *PlaywrightBuilder) WithGrep(pattern string) *PlaywrightBuilder {
+	b.grep = pattern
+	return b
+}
+
+// WithShard runs the shard 'index' (1-based) of 'total'.
+func (b *PlaywrightBuilder) WithShard(index, total int) *PlaywrightBuilder {
+	b.shardIndex = index
+	b.shardTotal = total
+	return b
+}
+
+// WithWorkers sets the number of parallel workers.
+func (b *PlaywrightBuilder) WithWorkers(workers int) *PlaywrightBuilder {
+	b.workers = workers
+	return b
+}
+
+// WithRetries sets the number of retries of the failed tests.
+func (b *PlaywrightBuilder) WithRetries(retries int) *PlaywrightBuilder {
+	b.retries = retries
+	return b
+}
+
+// WithReporter adds reporters of the run, among PlaywrightReporters. The junit, html, json and
+// blob reporters write their report under the artifacts directory.
+func (b *PlaywrightBuilder) WithReporter(reporters ...string) *PlaywrightBuilder {
+	b.reporters = append(b.reporters, reporters...)
+	return b
+}
+
+// WithArtifactsDir sets the directory of the test results and reports.
+func (b *PlaywrightBuilder) WithArtifactsDir(dir string) *PlaywrightBuilder {
+	b.artifactsDir = dir
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *PlaywrightBuilder) WithLogger(logger *slog.Logger) *PlaywrightBuilder {
+	b.logger = logger
+	return b
+}
+
+// OutputDir returns the directory of the test attachments (playwright --output).
+func (b *PlaywrightBuilder) OutputDir() string {
+	return filepath.Join(b.artifactsDir, "test-results")
+}
+
+// reportPaths maps the file reporters to the environment variable of their output path, and
+// whether the output is a directory.
+var reportPaths = map[string]struct {
+	envVar string
+	name   string
+	dir    bool
+}{
+	"junit": {"PLAYWRIGHT_JUNIT_OUTPUT_NAME", "junit.xml", false},
+	"html":  {"PLAYWRIGHT_HTML_OUTPUT_DIR", "playwright-report", true},
+	"json":  {"PLAYWRIGHT_JSON_OUTPUT_NAME", "results.json", false},
+	"blob":  {"PLAYWRIGHT_BLOB_OUTPUT_DIR", "blob-report", true},
+}
+
+// validate checks the options of the run.
+func (b *PlaywrightBuilder) validate() error {
+	if b.artifactsDir == "" {
+		return errorsx.MissingField(builderName, "artifactsDir", "artifacts directory is required")
+	}
+
+	if b.shardTotal != 0 || b.shardIndex != 0 {
+		if b.shardTotal < 1 || b.shardIndex < 1 || b.shardIndex > b.shardTotal {
+			return errorsx.InvalidValue(builderName, "shard", fmt.Sprintf("%d/%d", b.shardIndex, b.shardTotal),
+				"shard index must be between 1 and the total number of shards")
+		}
+	}
+
+	if b.workers < 0 {
+		return errorsx.InvalidValue(builderName, "workers", b.workers, "workers cannot be negative")
+	}
+
+	if b.retries < 0 {
+		return errorsx.InvalidValue(builderName, "retries", b.retries, "retries cannot be negative")
+	}
+
+	for i, r := range b.reporters {
+		if !slices.Contains(PlaywrightReporters, r) {
+			return errorsx.InvalidValue(builderName, "reporters", r, "unsupported playwright reporter: %q", r)
+		}
+
+		if slices.Contains(b.reporters[:i], r) {
+			return errorsx.InvalidValue(builderName, "reporters", r, "duplicate playwright reporter: %q", r)
+		}
+	}
+
+	return nil
+}
+
+// BuildCommand generates the playwright test command.
+//
+// Returns:
+//   - The playwright test command.
+//   - An error if the artifacts directory is missing, or the shard, workers, retries or
+//     reporters are invalid.
+func (b *PlaywrightBuilder) BuildCommand() ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"npx", "playwright", "test"}
+	cmd = append(cmd, b.files...)
+
+	if b.config != "" {
+		cmd = append(cmd, "--config="+b.config)
+	}
+
+	for _, p := range b.projects {
+		cmd = append(cmd, "--project="+p)
+	}
+
+	if b.grep != "" {
+		cmd = append(cmd, "--grep="+b.grep)
+	}
+
+	if b.shardTotal > 0 {
+		cmd = append(cmd, fmt.Sprintf("--shard=%d/%d", b.shardIndex, b.shardTotal))
+	}
+
+	if b.workers > 0 {
+		cmd = append(cmd, "--workers="+strconv.Itoa(b.workers))
+	}
+
+	if b.retries > 0 {
+		cmd = append(cmd, "--retries="+strconv.Itoa(b.retries))
+	}
+
+	if len(b.reporters) > 0 {
+		cmd = append(cmd, "--reporter="+strings.Join(b.reporters, ","))
+	}
+
+	cmd = append(cmd, "--output="+b.OutputDir())
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// InstallCommand generates the command installing the playwright browsers and, with 'withDeps',
+// their system packages (see BrowserPackages for images built without apt at run time).
+//
+// Returns:
+//   - The playwright install command.
+//   - An error if a browser is not supported by playwright.
+func (b *PlaywrightBuilder) InstallCommand(withDeps bool, browsers ...Browser) ([]string, error) {
+	cmd := []string{"npx", "playwright", "install"}
+	if withDeps {
+		cmd = append(cmd, "--with-deps")
+	}
+
+	for _, br := range browsers {
+		if !slices.Contains(playwrightBrowsers, br) {
+			return nil, errorsx.Unsupported(builderName, "browsers", br, "playwright does not support the %s browser", br)
+		}
+		cmd = append(cmd, string(br))
+	}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Env returns the environment variables of the run: CI, and the output paths of the file
+// reporters. The html reporter never opens the report.
+func (b *PlaywrightBuilder) Env() []types.DaggerEnvVars {
+	env := []types.DaggerEnvVars{{Name: "CI", Value: "true"}}
+	for _, r := range b.reporters {
+		p, ok := reportPaths[r]
+		if !ok {
+			continue
+		}
+
+		env = append(env, types.DaggerEnvVars{Name: p.envVar, Value: filepath.Join(b.artifactsDir, p.name)})
+		if r == "html" {
+			env = append(env, types.DaggerEnvVars{Name: "PLAYWRIGHT_HTML_OPEN", Value: "never"})
+		}
+	}
+
+	return env
+}
+
+// Artifacts returns the artifacts of the run: the test-results directory, and the reports of
+// the file reporters.
+func (b *PlaywrightBuilder) Artifacts() []artifactx.Artifact {
+	artifacts := []artifactx.Artifact{{Kind: artifactx.KindTestOutput, Path: b.OutputDir(), Directory: true}}
+	for _, r := range b.reporters {
+		p, ok := reportPaths[r]
+		if !ok {
+			continue
+		}
+
+		artifacts = append(artifacts, artifactx.Artifact{
+			Kind:      artifactx.KindReport,
+			Path:      filepath.Join(b.artifactsDir, p.name),
+			Directory: p.dir,
+		})
+	}
+
+	return artifacts
+}
+
+// RegisterArtifacts registers the artifacts of the run into the collector 'c'.
+//
+// Returns:
+//   - An error if an artifact is already registered.
+func (b *PlaywrightBuilder) RegisterArtifacts(c *artifactx.Collector) error {
+	return register(c, b.Artifacts())
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *PlaywrightBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *PlaywrightBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the playwright test command.
+func (b *PlaywrightBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("playwright", b, NewPlaywrightBuilder(), explainx.Generate("BuildCommand", b.BuildCommand))
+}
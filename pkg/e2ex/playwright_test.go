@@ -0,0 +1,102 @@
+package e2ex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaywrightBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *PlaywrightBuilder
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "defaults",
+			builder: NewPlaywrightBuilder(),
+			want:    []string{"npx", "playwright", "test", "--output=/mnt/e2e/test-results"},
+		},
+		{
+			name: "all options",
+			builder: NewPlaywrightBuilder().
+				WithFiles("tests/checkout").
+				WithConfig("playwright.config.ts").
+				WithProject("chromium", "firefox").
+				WithGrep("@smoke").
+				WithShard(2, 4).
+				WithWorkers(2).
+				WithRetries(1).
+				WithReporter("list", "junit").
+				WithArtifactsDir("/work/e2e"),
+			want: []string{
+				"npx", "playwright", "test", "tests/checkout", "--config=playwright.config.ts",
+				"--project=chromium", "--project=firefox", "--grep=@smoke", "--shard=2/4", "--workers=2",
+				"--retries=1", "--reporter=list,junit", "--output=/work/e2e/test-results",
+			},
+		},
+		{name: "shard out of range", builder: NewPlaywrightBuilder().WithShard(5, 4), wantErr: errorsx.ErrInvalidValue},
+		{name: "shard without total", builder: NewPlaywrightBuilder().WithShard(1, 0), wantErr: errorsx.ErrInvalidValue},
+		{name: "negative workers", builder: NewPlaywrightBuilder().WithWorkers(-1), wantErr: errorsx.ErrInvalidValue},
+		{name: "negative retries", builder: NewPlaywrightBuilder().WithRetries(-1), wantErr: errorsx.ErrInvalidValue},
+		{name: "unknown reporter", builder: NewPlaywrightBuilder().WithReporter("allure"), wantErr: errorsx.ErrInvalidValue},
+		{name: "duplicate reporter", builder: NewPlaywrightBuilder().WithReporter("html", "html"), wantErr: errorsx.ErrInvalidValue},
+		{name: "missing artifacts dir", builder: NewPlaywrightBuilder().WithArtifactsDir(""), wantErr: errorsx.ErrMissingField},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestPlaywrightBuilderInstallCommand(t *testing.T) {
+	b := NewPlaywrightBuilder()
+
+	cmd, err := b.InstallCommand(true, BrowserChromium, BrowserWebKit)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"npx", "playwright", "install", "--with-deps", "chromium", "webkit"}, cmd)
+
+	cmd, err = b.InstallCommand(false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"npx", "playwright", "install"}, cmd)
+
+	_, err = b.InstallCommand(true, BrowserElectron)
+	assert.True(t, errors.Is(err, errorsx.ErrUnsupported))
+}
+
+func TestPlaywrightBuilderEnvAndArtifacts(t *testing.T) {
+	b := NewPlaywrightBuilder().WithReporter("list", "junit", "html")
+
+	assert.Equal(t, []types.DaggerEnvVars{
+		{Name: "CI", Value: "true"},
+		{Name: "PLAYWRIGHT_JUNIT_OUTPUT_NAME", Value: "/mnt/e2e/junit.xml"},
+		{Name: "PLAYWRIGHT_HTML_OUTPUT_DIR", Value: "/mnt/e2e/playwright-report"},
+		{Name: "PLAYWRIGHT_HTML_OPEN", Value: "never"},
+	}, b.Env())
+
+	assert.Equal(t, []artifactx.Artifact{
+		{Kind: artifactx.KindTestOutput, Path: "/mnt/e2e/test-results", Directory: true},
+		{Kind: artifactx.KindReport, Path: "/mnt/e2e/junit.xml"},
+		{Kind: artifactx.KindReport, Path: "/mnt/e2e/playwright-report", Directory: true},
+	}, b.Artifacts())
+
+	c := artifactx.NewCollector()
+	require.NoError(t, b.RegisterArtifacts(c))
+	require.Len(t, c.Artifacts(), 3)
+	assert.Equal(t, artifactx.MediaTypeXML, c.ByKind(artifactx.KindReport)[0].MediaType)
+	assert.Equal(t, artifactx.MediaTypeTarGzip, c.ByKind(artifactx.KindTestOutput)[0].MediaType)
+}
@@ -41,6 +41,7 @@ package envvars
 import (
 	"errors"
 	"fmt"
+	"github.com/Excoriate/daggerx/pkg/mapx"
 	"github.com/Excoriate/daggerx/pkg/types"
 	"strings"
 )
@@ -80,7 +81,7 @@ func ToDaggerEnvVarsFromStr(envVars string) ([]types.DaggerEnvVars, error) {
 //
 // Returns:
 //   - A slice of DaggerEnvVars, each containing the name and value of an environment variable.
-//   - An error if the input map is empty or contains an empty key.
+//   - An error if the input map is empty, contains an empty key, or holds a name with '=' or a NUL byte.
 //
 // Example:
 //
@@ -95,14 +96,20 @@ func ToDaggerEnvVarsFromMap(envVarsMap map[string]string) ([]types.DaggerEnvVars
 		return nil, errors.New("input map is empty")
 	}
 
+	// Variables are sorted by name, so the same map always yields the same slice.
 	var envVars []types.DaggerEnvVars
-	for key, value := range envVarsMap {
+	for _, key := range mapx.SortedKeys(envVarsMap) {
 		if key == "" {
 			return nil, errors.New("found empty key in map")
 		}
+
+		// Processes cannot receive names with '=' or NUL bytes, nor values with NUL bytes.
+		if strings.ContainsAny(key, "=\x00") || strings.ContainsRune(envVarsMap[key], 0) {
+			return nil, fmt.Errorf("invalid environment variable in map: %q", key)
+		}
 		envVars = append(envVars, types.DaggerEnvVars{
 			Name:  key,
-			Value: value,
+			Value: envVarsMap[key],
 		})
 	}
 	return envVars, nil
@@ -147,6 +154,10 @@ func ToDaggerEnvVarsFromSlice(envVarsSlice []string) ([]types.DaggerEnvVars, err
 			return nil, fmt.Errorf("empty key in environment variable: %s", envVar)
 		}
 
+		if strings.ContainsRune(envVar, 0) {
+			return nil, fmt.Errorf("NUL byte in environment variable: %q", envVar)
+		}
+
 		envVars = append(envVars, types.DaggerEnvVars{
 			Name:  key,
 			Value: value,
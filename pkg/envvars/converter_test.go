@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/Excoriate/daggerx/pkg/types"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -128,3 +129,24 @@ func equalDaggerEnvVars(a, b []types.DaggerEnvVars) bool {
 	}
 	return true
 }
+
+func FuzzToDaggerEnvVarsFromStr(f *testing.F) {
+	for _, s := range []string{"FOO=bar,BAZ=qux", "FOO=a=b", " FOO = bar ", "=bar", "FOO", ",,FOO=bar,", "FOO=\x00"} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		envVars, err := ToDaggerEnvVarsFromStr(s)
+		if err != nil {
+			return
+		}
+
+		// The variables are passed to os/exec and Dagger, which reject names with '=' and NUL bytes.
+		for _, v := range envVars {
+			if v.Name == "" || strings.TrimSpace(v.Name) != v.Name || strings.ContainsAny(v.Name, "=,\x00") ||
+				strings.ContainsRune(v.Value, 0) {
+				t.Fatalf("ToDaggerEnvVarsFromStr(%q) = invalid variable %q=%q", s, v.Name, v.Value)
+			}
+		}
+	})
+}
@@ -0,0 +1,115 @@
+// Package errorsx provides the error taxonomy shared by the daggerx builders, so callers can branch
+// on failure causes with errors.Is and errors.As instead of matching error strings.
+//
+// Every error carries its cause category (one of the Err* sentinels), the builder that produced
+// it, and the field or option involved. The message is the human-readable text the builders
+// have always returned.
+//
+// Example usage:
+//
+//	_, err := apkox.NewApkoBuilder().BuildCommand()
+//	if errors.Is(err, errorsx.ErrMissingField) {
+//	    var e *errorsx.Error
+//	    if errors.As(err, &e) {
+//	        fmt.Println("missing", e.Field, "in", e.Builder)
+//	    }
+//	}
+package errorsx
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrMissingField reports a required field or option that is not set.
+	ErrMissingField = errors.New("missing field")
+	// ErrInvalidValue reports a field or option set to an invalid value.
+	ErrInvalidValue = errors.New("invalid value")
+	// ErrConflictingOptions reports options that cannot be used together.
+	ErrConflictingOptions = errors.New("conflicting options")
+	// ErrUnsupported reports a value or feature the builder does not support.
+	ErrUnsupported = errors.New("unsupported")
+	// ErrWarning reports a warning turned into an error by a builder in strict mode.
+	ErrWarning = errors.New("warning")
+)
+
+// Error is a builder error with its cause category and context.
+type Error struct {
+	// Kind is the cause category, one of the Err* sentinels.
+	Kind error
+	// Builder names the builder or component that produced the error (e.g. "apko").
+	Builder string
+	// Field names the field or option involved, when there is one.
+	Field string
+	// Fields names the options involved in an ErrConflictingOptions error.
+	Fields []string
+	// Value is the offending value, when there is one.
+	Value any
+	// msg is the error message.
+	msg string
+	// cause is the wrapped error, if any.
+	cause error
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	return e.msg
+}
+
+// Is reports whether 'target' is the cause category of the error.
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+// Unwrap returns the error wrapped with %w in the message, if any.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// newError formats the message like fmt.Errorf, keeping the %w-wrapped cause.
+func newError(kind error, builder, field string, value any, format string, args ...any) *Error {
+	formatted := fmt.Errorf(format, args...)
+
+	return &Error{
+		Kind:    kind,
+		Builder: builder,
+		Field:   field,
+		Value:   value,
+		msg:     formatted.Error(),
+		cause:   errors.Unwrap(formatted),
+	}
+}
+
+// MissingField returns an ErrMissingField error for 'field' of 'builder'.
+func MissingField(builder, field, format string, args ...any) *Error {
+	return newError(ErrMissingField, builder, field, nil, format, args...)
+}
+
+// InvalidValue returns an ErrInvalidValue error for the 'value' of 'field' of 'builder'.
+func InvalidValue(builder, field string, value any, format string, args ...any) *Error {
+	return newError(ErrInvalidValue, builder, field, value, format, args...)
+}
+
+// ConflictingOptions returns an ErrConflictingOptions error for the 'fields' of 'builder'.
+func ConflictingOptions(builder string, fields []string, format string, args ...any) *Error {
+	e := newError(ErrConflictingOptions, builder, "", nil, format, args...)
+	e.Fields = append([]string(nil), fields...)
+
+	return e
+}
+
+// Unsupported returns an ErrUnsupported error for the 'value' of 'field' of 'builder'.
+func Unsupported(builder, field string, value any, format string, args ...any) *Error {
+	return newError(ErrUnsupported, builder, field, value, format, args...)
+}
+
+// FieldOf returns the field of the first *Error in the chain of 'err'.
+func FieldOf(err error) (string, bool) {
+	var e *Error
+	if !errors.As(err, &e) {
+		return "", false
+	}
+
+	return e.Field, true
+}
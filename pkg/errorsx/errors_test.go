@@ -0,0 +1,77 @@
+package errorsx
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *Error
+		kind error
+		msg  string
+	}{
+		{name: "Missing field", err: MissingField("apko", "configFile", "config file is required"), kind: ErrMissingField, msg: "config file is required"},
+		{name: "Invalid value", err: InvalidValue("service", "ports", 0, "service %s has an invalid port: %d", "db", 0), kind: ErrInvalidValue, msg: "service db has an invalid port: 0"},
+		{name: "Conflicting options", err: ConflictingOptions("apko", []string{"offline", "repositoryAppend"}, "offline builds cannot append repositories"), kind: ErrConflictingOptions, msg: "offline builds cannot append repositories"},
+		{name: "Unsupported", err: Unsupported("apko", "preset", "debian", "unsupported preset: %s", "debian"), kind: ErrUnsupported, msg: "unsupported preset: debian"},
+	}
+
+	kinds := []error{ErrMissingField, ErrInvalidValue, ErrConflictingOptions, ErrUnsupported}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.err.Error() != tt.msg {
+				t.Errorf("Error() = %q, want %q", tt.err.Error(), tt.msg)
+			}
+
+			wrapped := fmt.Errorf("build failed: %w", tt.err)
+			for _, k := range kinds {
+				if got := errors.Is(wrapped, k); got != (k == tt.kind) {
+					t.Errorf("errors.Is(err, %v) = %v", k, got)
+				}
+			}
+
+			var e *Error
+			if !errors.As(wrapped, &e) || e.Builder == "" {
+				t.Errorf("errors.As failed or lost context: %+v", e)
+			}
+		})
+	}
+}
+
+func TestErrorWrapsCause(t *testing.T) {
+	err := InvalidValue("apko", "lockfile", "/x", "failed to read apko lockfile: %w", fs.ErrNotExist)
+
+	if !errors.Is(err, fs.ErrNotExist) || !errors.Is(err, ErrInvalidValue) {
+		t.Error("Expected the error to match both its cause and its kind")
+	}
+
+	if err.Error() != "failed to read apko lockfile: file does not exist" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+}
+
+func TestFieldOf(t *testing.T) {
+	field, ok := FieldOf(fmt.Errorf("wrapped: %w", MissingField("apko", "outputImage", "output image name is required")))
+	if !ok || field != "outputImage" {
+		t.Errorf("FieldOf() = %q, %v", field, ok)
+	}
+
+	if _, ok := FieldOf(errors.New("plain")); ok {
+		t.Error("Expected FieldOf to fail on plain errors")
+	}
+}
+
+func TestConflictingOptionsFields(t *testing.T) {
+	fields := []string{"a", "b"}
+	err := ConflictingOptions("x", fields, "a and b conflict")
+	fields[0] = "changed"
+
+	if err.Fields[0] != "a" {
+		t.Error("Expected Fields to be copied")
+	}
+}
@@ -0,0 +1,79 @@
+package errorsx
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Warning is a configuration mistake a builder works around instead of failing: an option it
+// can't apply, or a default it falls back to. Builders expose their warnings with a Warnings
+// accessor, so mistakes are never swallowed, and fail with them in strict mode (see Strict).
+type Warning struct {
+	// Builder names the builder or component that produced the warning (e.g. "apko").
+	Builder string `json:"builder"`
+	// Field names the field or option involved, when there is one.
+	Field string `json:"field,omitempty"`
+	// Message is the human-readable description of the warning.
+	Message string `json:"message"`
+	// Deprecated reports that the warning is about a deprecated option: it still applies, but
+	// will be removed.
+	Deprecated bool `json:"deprecated,omitempty"`
+	// Replacement names the option to use instead of the deprecated option, when there is one.
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// NewWarning returns a warning for 'field' of 'builder', with the message formatted like
+// fmt.Sprintf.
+func NewWarning(builder, field, format string, args ...any) Warning {
+	return Warning{Builder: builder, Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// NewDeprecation returns the warning recording the use of the deprecated option 'option' of
+// 'builder', suggesting 'replacement' when it isn't empty (e.g. "WithArchitecture is deprecated,
+// use WithBuildArch instead").
+func NewDeprecation(builder, option, replacement string) Warning {
+	w := Warning{Builder: builder, Field: option, Message: option + " is deprecated", Deprecated: true,
+		Replacement: replacement}
+	if replacement != "" {
+		w.Message += ", use " + replacement + " instead"
+	}
+
+	return w
+}
+
+// Deprecations returns the warnings of 'warnings' about deprecated options.
+func Deprecations(warnings []Warning) []Warning {
+	var deprecations []Warning
+	for _, w := range warnings {
+		if w.Deprecated {
+			deprecations = append(deprecations, w)
+		}
+	}
+
+	return deprecations
+}
+
+// String returns the message of the warning.
+func (w Warning) String() string {
+	return w.Message
+}
+
+// Err returns the warning as an ErrWarning error.
+func (w Warning) Err() *Error {
+	return newError(ErrWarning, w.Builder, w.Field, nil, "%s", w.Message)
+}
+
+// Strict returns the warnings as an error, or nil if there are none. The error matches
+// ErrWarning with errors.Is, and each warning is an *Error in its chain.
+func Strict(warnings []Warning) error {
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	errs := make([]error, 0, len(warnings))
+	for _, w := range warnings {
+		errs = append(errs, w.Err())
+	}
+
+	return errors.Join(errs...)
+}
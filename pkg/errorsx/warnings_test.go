@@ -0,0 +1,62 @@
+package errorsx
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWarning(t *testing.T) {
+	w := NewWarning("apko", "tag", "no tag set for %s, defaulting to latest", "example/app")
+
+	if w.String() != "no tag set for example/app, defaulting to latest" {
+		t.Errorf("String() = %q", w.String())
+	}
+
+	err := w.Err()
+	if !errors.Is(err, ErrWarning) || err.Builder != "apko" || err.Field != "tag" || err.Error() != w.Message {
+		t.Errorf("Err() = %#v, want an ErrWarning error for apko tag", err)
+	}
+}
+
+func TestStrict(t *testing.T) {
+	if err := Strict(nil); err != nil {
+		t.Errorf("Strict(nil) = %v, want nil", err)
+	}
+
+	err := Strict([]Warning{
+		NewWarning("apko", "configPreset", "unknown config preset %q", "debian"),
+		NewWarning("apko", "tag", "no tag set"),
+	})
+	if !errors.Is(err, ErrWarning) {
+		t.Fatalf("Strict() = %v, want ErrWarning", err)
+	}
+
+	if field, ok := FieldOf(err); !ok || field != "configPreset" {
+		t.Errorf("FieldOf(Strict()) = %q, %v, want configPreset", field, ok)
+	}
+
+	if err.Error() != "unknown config preset \"debian\"\nno tag set" {
+		t.Errorf("Strict().Error() = %q", err.Error())
+	}
+}
+
+func TestNewDeprecation(t *testing.T) {
+	w := NewDeprecation("apko", "WithArchitecture", "WithBuildArch")
+	if !w.Deprecated || w.Field != "WithArchitecture" || w.Replacement != "WithBuildArch" ||
+		w.Message != "WithArchitecture is deprecated, use WithBuildArch instead" {
+		t.Errorf("NewDeprecation() = %#v", w)
+	}
+
+	if w := NewDeprecation("apko", "WithDebug", ""); w.Message != "WithDebug is deprecated" {
+		t.Errorf("NewDeprecation() without replacement = %q", w.Message)
+	}
+
+	warnings := []Warning{NewWarning("apko", "tag", "no tag set"), w}
+	if got := Deprecations(warnings); len(got) != 1 || got[0] != w {
+		t.Errorf("Deprecations() = %v, want %v", got, []Warning{w})
+	}
+
+	if got := Deprecations(warnings[:1]); got != nil {
+		t.Errorf("Deprecations() without deprecation = %v, want nil", got)
+	}
+}
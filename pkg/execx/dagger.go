@@ -0,0 +1,282 @@
+package execx
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"dagger.io/dagger"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// DaggerExecutor runs commands inside a Dagger container.
+//
+// Mount sources are resolved from the directories, files and caches registered on the executor.
+// When a source is not registered and a client is available, directories and files are read from
+// the host, and caches are created from the mount Source used as cache volume key.
+type DaggerExecutor struct {
+	// client is the Dagger client used to resolve host paths and cache volumes. It may be nil.
+	client *dagger.Client
+	// container is the base container in which commands are executed.
+	container *dagger.Container
+	// directories maps mount sources to pre-resolved Dagger directories.
+	directories map[string]*dagger.Directory
+	// files maps mount sources to pre-resolved Dagger files.
+	files map[string]*dagger.File
+	// caches maps mount sources to pre-resolved Dagger cache volumes.
+	caches map[string]*dagger.CacheVolume
+	// secrets maps secret names to Dagger secrets.
+	secrets map[string]*dagger.Secret
+	// sockets maps mount sources to pre-resolved Dagger sockets.
+	sockets map[string]*dagger.Socket
+	// logger receives the executed commands and their timing. It may be nil.
+	logger *slog.Logger
+	// lineHandler receives the output lines once the command completed. It may be nil.
+	lineHandler LineHandler
+	// tailLines is the number of output lines kept in Result.Tail.
+	tailLines int
+}
+
+// NewDaggerExecutor creates a new DaggerExecutor running commands in the given container.
+//
+// Parameters:
+//   - client: The Dagger client used to resolve unregistered mount sources. It may be nil when every
+//     mount source is registered, which is the case inside Dagger modules without host access.
+//   - container: The base container in which commands are executed.
+func NewDaggerExecutor(client *dagger.Client, container *dagger.Container) *DaggerExecutor {
+	return &DaggerExecutor{
+		client:      client,
+		container:   container,
+		directories: map[string]*dagger.Directory{},
+		files:       map[string]*dagger.File{},
+		caches:      map[string]*dagger.CacheVolume{},
+		secrets:     map[string]*dagger.Secret{},
+		sockets:     map[string]*dagger.Socket{},
+		tailLines:   DefaultTailLines,
+	}
+}
+
+// WithDirectory registers the Dagger directory used for mounts whose Source is 'source'.
+func (e *DaggerExecutor) WithDirectory(source string, dir *dagger.Directory) *DaggerExecutor {
+	e.directories[source] = dir
+	return e
+}
+
+// WithFile registers the Dagger file used for mounts whose Source is 'source'.
+func (e *DaggerExecutor) WithFile(source string, file *dagger.File) *DaggerExecutor {
+	e.files[source] = file
+	return e
+}
+
+// WithCache registers the Dagger cache volume used for mounts whose Source is 'source'.
+func (e *DaggerExecutor) WithCache(source string, cache *dagger.CacheVolume) *DaggerExecutor {
+	e.caches[source] = cache
+	return e
+}
+
+// WithSecret registers the Dagger secret used for secret mounts whose Source is 'name'.
+// Secrets are never resolved implicitly and must always be registered.
+func (e *DaggerExecutor) WithSecret(name string, secret *dagger.Secret) *DaggerExecutor {
+	e.secrets[name] = secret
+	return e
+}
+
+// WithSocket registers the Dagger socket used for socket mounts whose Source is 'source'.
+func (e *DaggerExecutor) WithSocket(source string, socket *dagger.Socket) *DaggerExecutor {
+	e.sockets[source] = socket
+	return e
+}
+
+// WithLogger sets the logger receiving the executed commands and their timing at debug level.
+func (e *DaggerExecutor) WithLogger(logger *slog.Logger) *DaggerExecutor {
+	e.logger = logger
+	return e
+}
+
+// WithLineHandler sets the handler receiving the output lines, in addition to the handler of the
+// context (see ContextWithLineHandler). Dagger returns the output once the command completed, so
+// the lines are passed then; the Dagger TUI shows the live output.
+func (e *DaggerExecutor) WithLineHandler(handler LineHandler) *DaggerExecutor {
+	e.lineHandler = handler
+	return e
+}
+
+// WithTailLines sets the number of output lines kept in Result.Tail. Zero disables the tail.
+func (e *DaggerExecutor) WithTailLines(lines int) *DaggerExecutor {
+	e.tailLines = lines
+	return e
+}
+
+// Prepare returns the base container with the environment variables and mounts applied,
+// without executing any command.
+//
+// Returns:
+//   - The prepared container.
+//   - An error if a mount source cannot be resolved.
+func (e *DaggerExecutor) Prepare(env []types.DaggerEnvVars, mounts []types.Mount) (*dagger.Container, error) {
+	if e.container == nil {
+		return nil, fmt.Errorf("dagger executor requires a container")
+	}
+
+	ctr := e.container
+	for _, v := range env {
+		ctr = ctr.WithEnvVariable(v.Name, v.Value, dagger.ContainerWithEnvVariableOpts{
+			Expand: v.Expand,
+		})
+	}
+
+	for _, m := range mounts {
+		var err error
+		if ctr, err = e.mount(ctr, m); err != nil {
+			return nil, err
+		}
+	}
+
+	return ctr, nil
+}
+
+// Debug returns the container of the debug recipe 'recipe', prepared with its environment, mounts
+// and working directory, with an interactive terminal running its shell. The recipe command isn't
+// run: users run it by hand from the terminal. The executor container is used, whatever the
+// image of the recipe.
+//
+// Returns:
+//   - The container opening the terminal when evaluated (e.g. with Sync).
+//   - An error if the container cannot be prepared.
+func (e *DaggerExecutor) Debug(recipe types.DebugRecipe) (*dagger.Container, error) {
+	ctr, err := e.Prepare(recipe.Env, recipe.Mounts)
+	if err != nil {
+		return nil, err
+	}
+
+	if recipe.Workdir != "" {
+		ctr = ctr.WithWorkdir(recipe.Workdir)
+	}
+
+	return ctr.Terminal(dagger.ContainerTerminalOpts{Cmd: recipe.ShellCommand()}), nil
+}
+
+// Run executes the command inside the Dagger container.
+// Non-zero exit codes are captured in the Result instead of failing the execution.
+//
+// Parameters:
+//   - ctx: The context used to evaluate the Dagger pipeline.
+//   - cmd: The command to execute.
+//   - env: Environment variables to set in the container.
+//   - mounts: Mounts to provide to the container.
+//
+// Returns:
+//   - A Result with the captured output and exit code.
+//   - An error if the container cannot be prepared or evaluated.
+func (e *DaggerExecutor) Run(
+	ctx context.Context,
+	cmd types.DaggerCMD,
+	env []types.DaggerEnvVars,
+	mounts []types.Mount,
+) (*Result, error) {
+	if err := validateCommand(cmd); err != nil {
+		return nil, err
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to run command %q: %w", cmd[0], err)
+	}
+
+	ctr, err := e.Prepare(env, mounts)
+	if err != nil {
+		return nil, err
+	}
+
+	logx.Command(ctx, e.logger, "dagger", cmd)
+	defer logx.Timer(ctx, e.logger, "dagger", "run "+cmd[0])()
+
+	ctr = ctr.WithExec(cmd, dagger.ContainerWithExecOpts{
+		Expect: dagger.ReturnTypeAny,
+	})
+
+	exitCode, err := ctr.ExitCode(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command %q: %w", cmd[0], err)
+	}
+
+	stdout, err := ctr.Stdout(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stdout of command %q: %w", cmd[0], err)
+	}
+
+	stderr, err := ctr.Stderr(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stderr of command %q: %w", cmd[0], err)
+	}
+
+	tail := NewTailBuffer(e.tailLines)
+	replayOutput(combineHandlers(e.lineHandler, lineHandlerFromContext(ctx), tail.Add), stdout, stderr)
+
+	return &Result{
+		Stdout:   stdout,
+		Stderr:   stderr,
+		ExitCode: exitCode,
+		Tail:     tail.Lines(),
+	}, nil
+}
+
+// mount applies a single mount to the container.
+func (e *DaggerExecutor) mount(ctr *dagger.Container, m types.Mount) (*dagger.Container, error) {
+	if m.Target == "" {
+		return nil, fmt.Errorf("mount target is required for source %s", m.Source)
+	}
+
+	switch m.Kind {
+	case types.MountKindDirectory:
+		dir, ok := e.directories[m.Source]
+		if !ok {
+			if e.client == nil {
+				return nil, fmt.Errorf("no directory registered for mount source %s", m.Source)
+			}
+			dir = e.client.Host().Directory(m.Source)
+		}
+		return ctr.WithMountedDirectory(m.Target, dir), nil
+	case types.MountKindFile:
+		file, ok := e.files[m.Source]
+		if !ok {
+			if e.client == nil {
+				return nil, fmt.Errorf("no file registered for mount source %s", m.Source)
+			}
+			file = e.client.Host().File(m.Source)
+		}
+		return ctr.WithMountedFile(m.Target, file), nil
+	case types.MountKindCache:
+		cache, ok := e.caches[m.Source]
+		if !ok {
+			if e.client == nil {
+				return nil, fmt.Errorf("no cache volume registered for mount source %s", m.Source)
+			}
+			cache = e.client.CacheVolume(m.Source)
+		}
+		return ctr.WithMountedCache(m.Target, cache), nil
+	case types.MountKindSecret:
+		secret, ok := e.secrets[m.Source]
+		if !ok {
+			return nil, fmt.Errorf("no secret registered for mount source %s", m.Source)
+		}
+		return ctr.WithMountedSecret(m.Target, secret), nil
+	case types.MountKindInline:
+		return ctr.WithNewFile(m.Target, m.Content), nil
+	case types.MountKindSocket:
+		socket, ok := e.sockets[m.Source]
+		if !ok {
+			if e.client == nil {
+				return nil, fmt.Errorf("no socket registered for mount source %s", m.Source)
+			}
+			socket = e.client.Host().UnixSocket(m.Source)
+		}
+		return ctr.WithUnixSocket(m.Target, socket), nil
+	default:
+		return nil, fmt.Errorf("unsupported mount kind: %s", m.Kind)
+	}
+}
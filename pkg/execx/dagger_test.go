@@ -0,0 +1,34 @@
+package execx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDaggerExecutorValidation(t *testing.T) {
+	t.Run("Empty command", func(t *testing.T) {
+		_, err := NewDaggerExecutor(nil, nil).Run(context.Background(), types.DaggerCMD{}, nil, nil)
+		assert.EqualError(t, err, "command cannot be empty")
+	})
+
+	t.Run("Missing container", func(t *testing.T) {
+		_, err := NewDaggerExecutor(nil, nil).Run(context.Background(), types.DaggerCMD{"true"}, nil, nil)
+		assert.EqualError(t, err, "dagger executor requires a container")
+	})
+
+	t.Run("Debug without container", func(t *testing.T) {
+		_, err := NewDaggerExecutor(nil, nil).Debug(types.DebugRecipe{Command: types.DaggerCMD{"true"}})
+		assert.EqualError(t, err, "dagger executor requires a container")
+	})
+
+	t.Run("Cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := NewDaggerExecutor(nil, nil).Run(ctx, types.DaggerCMD{"true"}, nil, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,82 @@
+// Package execx provides adapters to execute the commands generated by the builders in this module.
+//
+// Builders such as apkox.ApkoBuilder only generate commands. The Executor interface defined in this
+// package lets callers optionally run those commands, either on the local host through os/exec, or
+// inside a Dagger container, which enables end-to-end tests of the generated commands.
+// RetryExecutor wraps another executor to retry transient registry and network failures.
+//
+// Executors keep the last lines of the output in Result.Tail for error summaries, and pass the
+// output lines to LineHandler callbacks, which keeps long builds observable; TaggedOutput prefixes
+// the lines of parallel tasks with their task.
+//
+// Example usage:
+//
+//	cmd, err := apkox.NewApkoBuilder().
+//	    WithConfigFile("config.yaml").
+//	    WithOutputImage("my-image").
+//	    WithOutputTarball("image.tar").
+//	    BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	res, err := execx.NewLocalExecutor().Run(ctx, cmd, nil, nil)
+//	if err != nil {
+//	    // handle error
+//	}
+//	fmt.Println(res.ExitCode, res.Stdout)
+package execx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// Result holds the outcome of an executed command.
+type Result struct {
+	// Stdout is the captured standard output of the command.
+	Stdout string
+	// Stderr is the captured standard error of the command.
+	Stderr string
+	// ExitCode is the exit code returned by the command.
+	ExitCode int
+	// Tail holds the last lines of the output, lines of the standard error being prefixed with
+	// "stderr: ". It is empty when the executor keeps no tail.
+	Tail []string
+}
+
+// Succeeded reports whether the command exited with a zero exit code.
+func (r *Result) Succeeded() bool {
+	return r != nil && r.ExitCode == 0
+}
+
+// Summary returns the tail of the output as an indented block for error messages, or an empty
+// string when there is no tail.
+func (r *Result) Summary() string {
+	if r == nil || len(r.Tail) == 0 {
+		return ""
+	}
+
+	return "  " + strings.Join(r.Tail, "\n  ")
+}
+
+// Executor runs a generated command with the given environment variables and mounts.
+//
+// Implementations must return a non-nil Result whenever the command was started, even if it
+// exited with a non-zero exit code. The returned error is reserved for failures to run the
+// command at all (invalid input, unsupported mounts, cancelled context, engine errors).
+type Executor interface {
+	Run(ctx context.Context, cmd types.DaggerCMD, env []types.DaggerEnvVars, mounts []types.Mount) (*Result, error)
+}
+
+// validateCommand checks that the command is runnable.
+func validateCommand(cmd types.DaggerCMD) error {
+	if len(cmd) == 0 || cmd[0] == "" {
+		return fmt.Errorf("command cannot be empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package execx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// LocalExecutor runs commands on the local host using os/exec.
+//
+// The host filesystem cannot be remapped, so mounts are only checked: directory and file mounts
+// must exist and their Target must be empty or equal to their Source. Cache mounts are not
+// supported by the local executor.
+type LocalExecutor struct {
+	// dir is the working directory of the executed commands.
+	dir string
+	// inheritEnv indicates whether the current process environment is passed to the commands.
+	inheritEnv bool
+	// logger receives the executed commands and their timing. It may be nil.
+	logger *slog.Logger
+	// lineHandler receives the output lines as they are written. It may be nil.
+	lineHandler LineHandler
+	// tailLines is the number of output lines kept in Result.Tail.
+	tailLines int
+}
+
+// NewLocalExecutor creates a new LocalExecutor that inherits the current process environment and
+// keeps the last DefaultTailLines output lines.
+func NewLocalExecutor() *LocalExecutor {
+	return &LocalExecutor{
+		inheritEnv: true,
+		tailLines:  DefaultTailLines,
+	}
+}
+
+// WithDir sets the working directory of the executed commands.
+func (e *LocalExecutor) WithDir(dir string) *LocalExecutor {
+	e.dir = dir
+	return e
+}
+
+// WithLogger sets the logger receiving the executed commands and their timing at debug level.
+func (e *LocalExecutor) WithLogger(logger *slog.Logger) *LocalExecutor {
+	e.logger = logger
+	return e
+}
+
+// WithLineHandler sets the handler receiving the output lines while the command runs, in
+// addition to the handler of the context (see ContextWithLineHandler).
+func (e *LocalExecutor) WithLineHandler(handler LineHandler) *LocalExecutor {
+	e.lineHandler = handler
+	return e
+}
+
+// WithTailLines sets the number of output lines kept in Result.Tail. Zero disables the tail.
+func (e *LocalExecutor) WithTailLines(lines int) *LocalExecutor {
+	e.tailLines = lines
+	return e
+}
+
+// WithCleanEnv disables the inheritance of the current process environment.
+func (e *LocalExecutor) WithCleanEnv() *LocalExecutor {
+	e.inheritEnv = false
+	return e
+}
+
+// Run executes the command on the local host.
+//
+// Parameters:
+//   - ctx: The context controlling the lifetime of the command. Cancelling it kills the process.
+//   - cmd: The command to execute, the first element being the binary.
+//   - env: Environment variables to set. Values with Expand set are expanded against the resulting environment.
+//   - mounts: Mount requirements, validated against the host filesystem.
+//
+// Returns:
+//   - A Result with the captured output and exit code.
+//   - An error if the command cannot be started or the mounts are not satisfiable.
+func (e *LocalExecutor) Run(
+	ctx context.Context,
+	cmd types.DaggerCMD,
+	env []types.DaggerEnvVars,
+	mounts []types.Mount,
+) (*Result, error) {
+	if err := validateCommand(cmd); err != nil {
+		return nil, err
+	}
+
+	if err := validateLocalMounts(mounts); err != nil {
+		return nil, err
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to run command %q: %w", cmd[0], err)
+	}
+
+	logx.Command(ctx, e.logger, "local", cmd)
+	defer logx.Timer(ctx, e.logger, "local", "run "+cmd[0])()
+
+	//nolint:gosec // Running caller-provided commands is the purpose of this executor.
+	c := exec.CommandContext(ctx, cmd[0], cmd[1:]...)
+	c.Dir = e.dir
+	c.Env = e.buildEnv(env)
+
+	tail := NewTailBuffer(e.tailLines)
+	handler := combineHandlers(e.lineHandler, lineHandlerFromContext(ctx), tail.Add)
+
+	var stdout, stderr bytes.Buffer
+	stdoutLines := NewLineWriter(StreamStdout, handler)
+	stderrLines := NewLineWriter(StreamStderr, handler)
+	c.Stdout = io.MultiWriter(&stdout, stdoutLines)
+	c.Stderr = io.MultiWriter(&stderr, stderrLines)
+
+	err := c.Run()
+	stdoutLines.Flush()
+	stderrLines.Flush()
+
+	res := &Result{
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
+		Tail:   tail.Lines(),
+	}
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, fmt.Errorf("failed to run command %q: %w", cmd[0], ctxErr)
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return res, nil
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+		return res, nil
+	default:
+		return nil, fmt.Errorf("failed to run command %q: %w", cmd[0], err)
+	}
+}
+
+// buildEnv computes the environment of the executed command.
+func (e *LocalExecutor) buildEnv(env []types.DaggerEnvVars) []string {
+	// A non-nil slice prevents os/exec from falling back to the process environment.
+	base := []string{}
+	if e.inheritEnv {
+		base = os.Environ()
+	}
+
+	values := make(map[string]string, len(env))
+	for _, kv := range base {
+		if name, value, ok := strings.Cut(kv, "="); ok {
+			values[name] = value
+		}
+	}
+
+	for _, v := range env {
+		value := v.Value
+		if v.Expand {
+			value = os.Expand(value, func(name string) string {
+				return values[name]
+			})
+		}
+
+		values[v.Name] = value
+		base = append(base, v.Name+"="+value)
+	}
+
+	return base
+}
+
+// validateLocalMounts checks that the mounts can be satisfied by the host filesystem.
+func validateLocalMounts(mounts []types.Mount) error {
+	for _, m := range mounts {
+		switch m.Kind {
+		case types.MountKindDirectory, types.MountKindFile:
+			if m.Target != "" && m.Target != m.Source {
+				return fmt.Errorf("local executor cannot remap mount %s to %s", m.Source, m.Target)
+			}
+
+			info, err := os.Stat(m.Source)
+			if err != nil {
+				return fmt.Errorf("mount source %s is not accessible: %w", m.Source, err)
+			}
+
+			if m.Kind == types.MountKindDirectory && !info.IsDir() {
+				return fmt.Errorf("mount source %s is not a directory", m.Source)
+			}
+		default:
+			return fmt.Errorf("local executor does not support %s mounts", m.Kind)
+		}
+	}
+
+	return nil
+}
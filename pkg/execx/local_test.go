@@ -0,0 +1,181 @@
+package execx
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalExecutorRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		cmd        types.DaggerCMD
+		env        []types.DaggerEnvVars
+		wantStdout string
+		wantStderr string
+		wantCode   int
+		wantErr    bool
+	}{
+		{
+			name:       "Successful command",
+			cmd:        types.DaggerCMD{"sh", "-c", "echo hello"},
+			wantStdout: "hello\n",
+		},
+		{
+			name:       "Non-zero exit code is captured",
+			cmd:        types.DaggerCMD{"sh", "-c", "echo failed >&2; exit 3"},
+			wantStderr: "failed\n",
+			wantCode:   3,
+		},
+		{
+			name:       "Environment variables are set",
+			cmd:        types.DaggerCMD{"sh", "-c", "echo $FOO"},
+			env:        []types.DaggerEnvVars{{Name: "FOO", Value: "bar"}},
+			wantStdout: "bar\n",
+		},
+		{
+			name: "Expanded environment variables",
+			cmd:  types.DaggerCMD{"sh", "-c", "echo $BAZ"},
+			env: []types.DaggerEnvVars{
+				{Name: "FOO", Value: "bar"},
+				{Name: "BAZ", Value: "${FOO}-qux", Expand: true},
+			},
+			wantStdout: "bar-qux\n",
+		},
+		{
+			name:    "Empty command",
+			cmd:     types.DaggerCMD{},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown binary",
+			cmd:     types.DaggerCMD{"daggerx-binary-that-does-not-exist"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := NewLocalExecutor().Run(context.Background(), tt.cmd, tt.env, nil)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStdout, res.Stdout)
+			assert.Equal(t, tt.wantStderr, res.Stderr)
+			assert.Equal(t, tt.wantCode, res.ExitCode)
+			assert.Equal(t, tt.wantCode == 0, res.Succeeded())
+		})
+	}
+}
+
+func TestLocalExecutorCleanEnv(t *testing.T) {
+	t.Setenv("DAGGERX_EXEC_TEST", "inherited")
+
+	res, err := NewLocalExecutor().WithCleanEnv().Run(context.Background(),
+		types.DaggerCMD{"/bin/sh", "-c", "echo -n $DAGGERX_EXEC_TEST"}, nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, res.Stdout)
+}
+
+func TestLocalExecutorWithDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "marker"), []byte("ok"), 0o600))
+
+	res, err := NewLocalExecutor().WithDir(dir).Run(context.Background(), types.DaggerCMD{"cat", "marker"}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", res.Stdout)
+}
+
+func TestLocalExecutorContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := NewLocalExecutor().Run(ctx, types.DaggerCMD{"sleep", "5"}, nil, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestLocalExecutorContextCancelledBeforeStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dir := t.TempDir()
+	_, err := NewLocalExecutor().Run(ctx, types.DaggerCMD{"touch", dir + "/marker"}, nil, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NoFileExists(t, dir+"/marker")
+}
+
+func TestLocalExecutorMounts(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.txt")
+	require.NoError(t, os.WriteFile(file, []byte("content"), 0o600))
+
+	tests := []struct {
+		name    string
+		mounts  []types.Mount
+		wantErr bool
+	}{
+		{
+			name:   "Existing directory without target",
+			mounts: []types.Mount{{Kind: types.MountKindDirectory, Source: dir}},
+		},
+		{
+			name:   "Existing file with same target",
+			mounts: []types.Mount{{Kind: types.MountKindFile, Source: file, Target: file}},
+		},
+		{
+			name:    "Remapped target",
+			mounts:  []types.Mount{{Kind: types.MountKindDirectory, Source: dir, Target: "/mnt"}},
+			wantErr: true,
+		},
+		{
+			name:    "Missing source",
+			mounts:  []types.Mount{{Kind: types.MountKindDirectory, Source: filepath.Join(dir, "missing")}},
+			wantErr: true,
+		},
+		{
+			name:    "File used as directory",
+			mounts:  []types.Mount{{Kind: types.MountKindDirectory, Source: file}},
+			wantErr: true,
+		},
+		{
+			name:    "Cache mounts are unsupported",
+			mounts:  []types.Mount{{Kind: types.MountKindCache, Source: "apko-cache", Target: "/cache"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLocalExecutor().Run(context.Background(), types.DaggerCMD{"true"}, nil, tt.mounts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLocalExecutorWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	res, err := NewLocalExecutor().WithLogger(logger).Run(context.Background(),
+		types.DaggerCMD{"sh", "-c", "true", "--token", "abc"}, nil, nil)
+	require.NoError(t, err)
+	assert.True(t, res.Succeeded())
+
+	out := buf.String()
+	assert.Contains(t, out, "builder=local")
+	assert.Contains(t, out, "operation=\"run sh\"")
+	assert.NotContains(t, out, "abc")
+}
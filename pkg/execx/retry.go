@@ -0,0 +1,109 @@
+package execx
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/retryx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// TransientMarkers are the messages that registry clients (crane, apko, docker, cosign) print on
+// standard error for transient registry and network failures.
+var TransientMarkers = []string{
+	"TOOMANYREQUESTS",
+	"429 Too Many Requests",
+	"502 Bad Gateway",
+	"503 Service Unavailable",
+	"504 Gateway Timeout",
+	"connection reset by peer",
+	"i/o timeout",
+	"TLS handshake timeout",
+	"unexpected EOF",
+}
+
+// errRetryResult is returned to the retry policy when a command result requests a retry.
+var errRetryResult = retryx.Retryable(errors.New("command failed with a transient error"))
+
+// RetryOnStderr returns a result classifier requesting a retry of failed commands whose standard
+// error contains one of 'markers'.
+func RetryOnStderr(markers ...string) func(*Result) bool {
+	return func(res *Result) bool {
+		if res.Succeeded() {
+			return false
+		}
+
+		for _, m := range markers {
+			if strings.Contains(res.Stderr, m) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// RetryExecutor runs commands with another Executor, retrying the transient failures: the
+// retryable errors of the executor (see retryx.IsRetryable), and the failed commands whose result
+// the classifier accepts.
+type RetryExecutor struct {
+	// inner runs the commands.
+	inner Executor
+	// policy is the retry policy. A nil policy is the default retryx policy.
+	policy *retryx.Policy
+	// retryResult reports whether a command result is a transient failure.
+	retryResult func(*Result) bool
+}
+
+// NewRetryExecutor creates a RetryExecutor running commands with 'inner' under 'policy' (the
+// default retryx policy when nil). Failed commands are retried when their standard error holds
+// one of TransientMarkers.
+func NewRetryExecutor(inner Executor, policy *retryx.Policy) *RetryExecutor {
+	return &RetryExecutor{
+		inner:       inner,
+		policy:      policy,
+		retryResult: RetryOnStderr(TransientMarkers...),
+	}
+}
+
+// WithRetryResult sets the classifier of the command results retried. A nil classifier retries
+// executor errors only.
+func (e *RetryExecutor) WithRetryResult(retryResult func(*Result) bool) *RetryExecutor {
+	e.retryResult = retryResult
+	return e
+}
+
+// Run executes the command with the inner executor, retrying transient failures.
+//
+// Returns:
+//   - The result of the last attempt, when the command was started.
+//   - The error of the inner executor when it couldn't run the command, after the retries.
+func (e *RetryExecutor) Run(
+	ctx context.Context,
+	cmd types.DaggerCMD,
+	env []types.DaggerEnvVars,
+	mounts []types.Mount,
+) (*Result, error) {
+	var last *Result
+
+	err := retryx.Do(ctx, e.policy, func(ctx context.Context) error {
+		res, err := e.inner.Run(ctx, cmd, env, mounts)
+		if err != nil {
+			return err
+		}
+
+		last = res
+		if e.retryResult != nil && e.retryResult(res) {
+			return errRetryResult
+		}
+
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errRetryResult) {
+		return nil, err
+	}
+
+	return last, nil
+}
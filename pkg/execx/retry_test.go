@@ -0,0 +1,91 @@
+package execx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/retryx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedExecutor returns its results and errors in order, one per run.
+type scriptedExecutor struct {
+	results []*Result
+	errs    []error
+	runs    int
+}
+
+func (e *scriptedExecutor) Run(_ context.Context, _ types.DaggerCMD, _ []types.DaggerEnvVars, _ []types.Mount) (*Result, error) {
+	i := e.runs
+	e.runs++
+
+	return e.results[i], e.errs[i]
+}
+
+func fastPolicy() *retryx.Policy {
+	return retryx.NewPolicy().WithBackoff(time.Millisecond, time.Millisecond)
+}
+
+func TestRetryExecutorRetriesTransientResults(t *testing.T) {
+	inner := &scriptedExecutor{
+		results: []*Result{
+			{ExitCode: 1, Stderr: "GET https://registry.example.com/v2/: 503 Service Unavailable"},
+			{ExitCode: 0, Stdout: "sha256:abc"},
+		},
+		errs: []error{nil, nil},
+	}
+
+	res, err := NewRetryExecutor(inner, fastPolicy()).Run(context.Background(), types.DaggerCMD{"crane", "digest", "app"}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:abc", res.Stdout)
+	assert.Equal(t, 2, inner.runs)
+}
+
+func TestRetryExecutorReturnsLastResult(t *testing.T) {
+	transient := &Result{ExitCode: 1, Stderr: "connection reset by peer"}
+	inner := &scriptedExecutor{
+		results: []*Result{transient, transient, transient},
+		errs:    []error{nil, nil, nil},
+	}
+
+	res, err := NewRetryExecutor(inner, fastPolicy()).Run(context.Background(), types.DaggerCMD{"crane"}, nil, nil)
+	require.NoError(t, err, "failed commands are reported through their result")
+	assert.Equal(t, transient, res)
+	assert.Equal(t, retryx.DefaultMaxAttempts, inner.runs)
+}
+
+func TestRetryExecutorDoesNotRetryPermanentFailures(t *testing.T) {
+	inner := &scriptedExecutor{
+		results: []*Result{{ExitCode: 1, Stderr: "MANIFEST_UNKNOWN: manifest unknown"}},
+		errs:    []error{nil},
+	}
+
+	res, err := NewRetryExecutor(inner, fastPolicy()).Run(context.Background(), types.DaggerCMD{"crane"}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.ExitCode)
+	assert.Equal(t, 1, inner.runs)
+
+	invalid := errors.New("command cannot be empty")
+	inner = &scriptedExecutor{results: []*Result{nil}, errs: []error{invalid}}
+
+	_, err = NewRetryExecutor(inner, fastPolicy()).Run(context.Background(), nil, nil, nil)
+	assert.Equal(t, invalid, err)
+	assert.Equal(t, 1, inner.runs)
+}
+
+func TestRetryExecutorRetriesRetryableErrors(t *testing.T) {
+	inner := &scriptedExecutor{
+		results: []*Result{nil, {ExitCode: 1, Stderr: "503 Service Unavailable"}},
+		errs:    []error{retryx.Retryable(errors.New("engine connection lost")), nil},
+	}
+
+	res, err := NewRetryExecutor(inner, fastPolicy()).WithRetryResult(nil).
+		Run(context.Background(), types.DaggerCMD{"crane"}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.ExitCode, "results are not retried without a classifier")
+	assert.Equal(t, 2, inner.runs)
+}
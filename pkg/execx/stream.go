@@ -0,0 +1,218 @@
+package execx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// DefaultTailLines is the number of output lines the executors keep in Result.Tail by default.
+const DefaultTailLines = 20
+
+// maxLineLength is the length above which a line without newline is passed to the handlers in
+// several parts, bounding the memory used by progress bars and other unterminated output.
+const maxLineLength = 64 << 10
+
+// Stream identifies an output stream of a command.
+type Stream string
+
+const (
+	// StreamStdout is the standard output.
+	StreamStdout Stream = "stdout"
+	// StreamStderr is the standard error.
+	StreamStderr Stream = "stderr"
+)
+
+// LineHandler receives the output lines of a command, without their trailing newline, as they
+// are written. Handlers of the local executor are called from the goroutines copying the output
+// of the process, one per stream: handlers shared by both streams must be safe for concurrent use.
+type LineHandler func(stream Stream, line string)
+
+// lineHandlerKey is the context key of the line handler of ContextWithLineHandler.
+type lineHandlerKey struct{}
+
+// ContextWithLineHandler returns a copy of 'ctx' carrying 'handler', which the executors call in
+// addition to their own handler. It lets callers running the same executor for several tasks,
+// such as planx.Plan, tag the lines with the task that printed them.
+func ContextWithLineHandler(ctx context.Context, handler LineHandler) context.Context {
+	return context.WithValue(ctx, lineHandlerKey{}, handler)
+}
+
+// lineHandlerFromContext returns the line handler carried by 'ctx', or nil.
+func lineHandlerFromContext(ctx context.Context) LineHandler {
+	handler, _ := ctx.Value(lineHandlerKey{}).(LineHandler)
+	return handler
+}
+
+// combineHandlers returns a handler calling every non-nil handler of 'handlers', or nil if there
+// is none.
+func combineHandlers(handlers ...LineHandler) LineHandler {
+	var active []LineHandler
+	for _, h := range handlers {
+		if h != nil {
+			active = append(active, h)
+		}
+	}
+
+	switch len(active) {
+	case 0:
+		return nil
+	case 1:
+		return active[0]
+	default:
+		return func(stream Stream, line string) {
+			for _, h := range active {
+				h(stream, line)
+			}
+		}
+	}
+}
+
+// LineWriter is an io.Writer splitting the bytes written into lines, passed to a LineHandler.
+// The last line, when not terminated by a newline, is passed by Flush. Carriage returns ending
+// lines ("\r\n") are removed. A LineWriter is not safe for concurrent use.
+type LineWriter struct {
+	// stream is the stream the lines are reported on.
+	stream Stream
+	// handler receives the lines.
+	handler LineHandler
+	// buf holds the bytes of the current, unterminated line.
+	buf []byte
+}
+
+// NewLineWriter creates a LineWriter passing the lines of 'stream' to 'handler'.
+func NewLineWriter(stream Stream, handler LineHandler) *LineWriter {
+	return &LineWriter{stream: stream, handler: handler}
+}
+
+// Write splits 'p' into lines and passes the complete ones to the handler. It never fails.
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	rest := w.buf
+	for {
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			break
+		}
+
+		w.emit(rest[:i])
+		rest = rest[i+1:]
+	}
+
+	for len(rest) > maxLineLength {
+		w.emit(rest[:maxLineLength])
+		rest = rest[maxLineLength:]
+	}
+
+	// Move the unterminated line to the start of the buffer, which is reused for the whole output.
+	w.buf = append(w.buf[:0], rest...)
+
+	return len(p), nil
+}
+
+// Flush passes the unterminated last line, if any, to the handler.
+func (w *LineWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.emit(w.buf)
+		w.buf = nil
+	}
+}
+
+// emit passes a line to the handler.
+func (w *LineWriter) emit(line []byte) {
+	if w.handler != nil {
+		w.handler(w.stream, string(bytes.TrimSuffix(line, []byte("\r"))))
+	}
+}
+
+// TailBuffer is a ring buffer keeping the last lines of the output of a command, for error
+// summaries. It is safe for concurrent use.
+type TailBuffer struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	full  bool
+}
+
+// NewTailBuffer creates a TailBuffer keeping the last 'size' lines. A size below 1 keeps none.
+func NewTailBuffer(size int) *TailBuffer {
+	return &TailBuffer{lines: make([]string, max(size, 0))}
+}
+
+// Add records a line of 'stream'; lines of the standard error are prefixed with "stderr: ".
+// It has the LineHandler signature.
+func (b *TailBuffer) Add(stream Stream, line string) {
+	if len(b.lines) == 0 {
+		return
+	}
+
+	if stream == StreamStderr {
+		line = string(StreamStderr) + ": " + line
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lines[b.next] = line
+	b.next = (b.next + 1) % len(b.lines)
+	b.full = b.full || b.next == 0
+}
+
+// Lines returns the recorded lines, oldest first.
+func (b *TailBuffer) Lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.full {
+		return append([]string(nil), b.lines[:b.next]...)
+	}
+
+	return append(append([]string(nil), b.lines[b.next:]...), b.lines[:b.next]...)
+}
+
+// TaggedOutput writes the output lines of parallel tasks to a single writer, prefixing each line
+// with the tag of its task ("[apko-x86_64] ..."). Lines are written whole, so the output of
+// concurrent tasks interleaves by line only.
+type TaggedOutput struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewTaggedOutput creates a TaggedOutput writing to 'w'.
+func NewTaggedOutput(w io.Writer) *TaggedOutput {
+	return &TaggedOutput{w: w}
+}
+
+// Handler returns the line handler writing the lines of the task 'tag'. Lines of the standard
+// error are tagged "[tag stderr]".
+func (o *TaggedOutput) Handler(tag string) LineHandler {
+	return func(stream Stream, line string) {
+		prefix := tag
+		if stream == StreamStderr {
+			prefix += " " + string(StreamStderr)
+		}
+
+		o.mu.Lock()
+		defer o.mu.Unlock()
+
+		// Write errors can't be reported to the command; the output is best effort.
+		_, _ = fmt.Fprintf(o.w, "[%s] %s\n", prefix, line)
+	}
+}
+
+// replayOutput passes the captured output of a command to 'handler', line by line, for the
+// executors which only get the output once the command completed.
+func replayOutput(handler LineHandler, stdout, stderr string) {
+	for _, s := range []struct {
+		stream Stream
+		output string
+	}{{StreamStdout, stdout}, {StreamStderr, stderr}} {
+		w := NewLineWriter(s.stream, handler)
+		_, _ = io.Copy(w, strings.NewReader(s.output))
+		w.Flush()
+	}
+}
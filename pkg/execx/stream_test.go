@@ -0,0 +1,133 @@
+package execx
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lineRecorder records the lines passed to its handler.
+type lineRecorder struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (r *lineRecorder) handle(stream Stream, line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, string(stream)+":"+line)
+}
+
+func TestLineWriter(t *testing.T) {
+	rec := &lineRecorder{}
+	w := NewLineWriter(StreamStdout, rec.handle)
+
+	for _, chunk := range []string{"hel", "lo\nwor", "ld\r\n\n", "last"} {
+		n, err := w.Write([]byte(chunk))
+		require.NoError(t, err)
+		assert.Equal(t, len(chunk), n)
+	}
+
+	assert.Equal(t, []string{"stdout:hello", "stdout:world", "stdout:"}, rec.lines)
+
+	w.Flush()
+	assert.Equal(t, "stdout:last", rec.lines[len(rec.lines)-1])
+}
+
+func TestLineWriterSplitsLongLines(t *testing.T) {
+	rec := &lineRecorder{}
+	w := NewLineWriter(StreamStderr, rec.handle)
+
+	_, err := w.Write(bytes.Repeat([]byte("x"), maxLineLength+10))
+	require.NoError(t, err)
+	w.Flush()
+
+	require.Len(t, rec.lines, 2)
+	assert.Len(t, rec.lines[1], len("stderr:")+10)
+}
+
+func TestTailBuffer(t *testing.T) {
+	tail := NewTailBuffer(3)
+	assert.Empty(t, tail.Lines())
+
+	tail.Add(StreamStdout, "one")
+	tail.Add(StreamStderr, "two")
+	assert.Equal(t, []string{"one", "stderr: two"}, tail.Lines())
+
+	tail.Add(StreamStdout, "three")
+	tail.Add(StreamStdout, "four")
+	assert.Equal(t, []string{"stderr: two", "three", "four"}, tail.Lines())
+
+	disabled := NewTailBuffer(0)
+	disabled.Add(StreamStdout, "one")
+	assert.Empty(t, disabled.Lines())
+}
+
+func TestTaggedOutput(t *testing.T) {
+	var out bytes.Buffer
+	tagged := NewTaggedOutput(&out)
+
+	var wg sync.WaitGroup
+	for _, tag := range []string{"a", "b"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h := tagged.Handler(tag)
+			for range 50 {
+				h(StreamStdout, "line")
+			}
+			h(StreamStderr, "oops")
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	require.Len(t, lines, 102)
+	for _, line := range lines {
+		assert.Contains(t, []string{"[a] line", "[b] line", "[a stderr] oops", "[b stderr] oops"}, line)
+	}
+}
+
+func TestLocalExecutorStreamsLines(t *testing.T) {
+	rec := &lineRecorder{}
+	ctxRec := &lineRecorder{}
+	ctx := ContextWithLineHandler(context.Background(), ctxRec.handle)
+
+	res, err := NewLocalExecutor().
+		WithLineHandler(rec.handle).
+		Run(ctx, types.DaggerCMD{"sh", "-c", "echo one; echo two; echo boom >&2"}, nil, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "one\ntwo\n", res.Stdout)
+	// The streams are copied concurrently: only the order of the lines of a stream is known.
+	assert.ElementsMatch(t, []string{"stdout:one", "stdout:two", "stderr:boom"}, rec.lines)
+	assert.ElementsMatch(t, rec.lines, ctxRec.lines)
+}
+
+func TestLocalExecutorTail(t *testing.T) {
+	res, err := NewLocalExecutor().
+		WithTailLines(2).
+		Run(context.Background(), types.DaggerCMD{"sh", "-c", "for i in 1 2 3 4; do echo $i >&2; done; exit 1"}, nil, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"stderr: 3", "stderr: 4"}, res.Tail)
+	assert.Equal(t, "  stderr: 3\n  stderr: 4", res.Summary())
+
+	res, err = NewLocalExecutor().WithTailLines(0).Run(context.Background(), types.DaggerCMD{"sh", "-c", "echo one"}, nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, res.Tail)
+	assert.Empty(t, res.Summary())
+}
+
+func TestReplayOutput(t *testing.T) {
+	rec := &lineRecorder{}
+	replayOutput(rec.handle, "a\nb", "c\n")
+
+	assert.Equal(t, []string{"stdout:a", "stdout:b", "stderr:c"}, rec.lines)
+}
@@ -0,0 +1,497 @@
+// Package explainx makes builders self-describing: Explain reads the options configured on a
+// builder, compares them with those of a freshly constructed builder, and relates them to the
+// flags of the generated commands, so pipelines can surface how a command came to be (e.g. in
+// `dagger call ... explain` functions) without reading the builder code.
+//
+// Builders expose it as an Explain method:
+//
+//	func (b *HadolintBuilder) Explain() explainx.Explanation {
+//	    return explainx.Explain(builderName, b, NewHadolintBuilder(),
+//	        explainx.Generate("BuildCommand", b.BuildCommand))
+//	}
+//
+// Example usage:
+//
+//	explanation := hadolintx.NewHadolintBuilder("Dockerfile").WithIgnore("DL3008").Explain()
+//	fmt.Println(explanation)
+//	// hadolint
+//	//   files = [Dockerfile] (set, default: none) -> Dockerfile
+//	//   ignores = [DL3008] (set, default: none) -> --ignore DL3008
+//	//   maxSeverity = medium (default)
+//	//   BuildCommand: hadolint --format json --no-color --ignore DL3008 Dockerfile
+//
+// Option values are read by reflection from the builder fields, so every option is covered as
+// soon as it is added to a builder. Secret references are described by name, and string fields
+// named after passwords, tokens or secrets are redacted.
+package explainx
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/Excoriate/daggerx/pkg/planx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+)
+
+// Source is where the value of an option comes from.
+type Source string
+
+const (
+	// SourceDefault is the value set by the constructor of the builder, or the zero value.
+	SourceDefault Source = "default"
+	// SourceSet is a value set by a constructor argument or an option of the builder.
+	SourceSet Source = "set"
+)
+
+// redacted replaces the values of sensitive string fields.
+const redacted = "<redacted>"
+
+// maxDepth is the depth of the nested values described.
+const maxDepth = 4
+
+// sensitiveRegex matches the names of fields holding sensitive strings.
+var sensitiveRegex = regexp.MustCompile(`(?i)(password|passwd|token|secret|credential)`)
+
+// Option describes an option of a builder.
+type Option struct {
+	// Name is the name of the option, the name of the builder field.
+	Name string `json:"name"`
+	// Value is the configured value, empty when unset.
+	Value string `json:"value"`
+	// Default is the value of a freshly constructed builder.
+	Default string `json:"default"`
+	// Source is where the value comes from.
+	Source Source `json:"source"`
+	// Flags are the arguments of the generated commands carrying the value of the option, or
+	// named after it.
+	Flags []string `json:"flags,omitempty"`
+}
+
+// Command is a command generated by a builder.
+type Command struct {
+	// Name is the name of the generator (e.g. "BuildCommand").
+	Name string `json:"name"`
+	// Args are the arguments of the command, empty when it can't be generated.
+	Args []string `json:"args,omitempty"`
+	// Error is the error of the generator, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Generate returns the command 'name' generated by 'generate'.
+func Generate(name string, generate func() ([]string, error)) Command {
+	args, err := generate()
+	if err != nil {
+		return Command{Name: name, Error: err.Error()}
+	}
+
+	return Command{Name: name, Args: args}
+}
+
+// PlanCommands returns the commands of the tasks of 'plan', named after their identifiers, or
+// the error 'err' of the generation of the plan, as a command named "Plan".
+func PlanCommands(plan *planx.Plan, err error) []Command {
+	if err != nil {
+		return []Command{{Name: "Plan", Error: err.Error()}}
+	}
+
+	if plan == nil {
+		return nil
+	}
+
+	tasks := plan.Tasks()
+	commands := make([]Command, len(tasks))
+	for i, t := range tasks {
+		commands[i] = Command{Name: t.ID, Args: t.Command}
+	}
+
+	return commands
+}
+
+// Explanation describes the configuration of a builder and the commands it generates.
+type Explanation struct {
+	// Builder is the name of the builder.
+	Builder string `json:"builder"`
+	// Options describes the options with a value or a default, in declaration order.
+	Options []Option `json:"options"`
+	// Commands are the generated commands.
+	Commands []Command `json:"commands,omitempty"`
+}
+
+// Set returns the options whose value differs from the default.
+func (e Explanation) Set() []Option {
+	var set []Option
+	for _, o := range e.Options {
+		if o.Source == SourceSet {
+			set = append(set, o)
+		}
+	}
+
+	return set
+}
+
+// Option returns the option 'name', and whether the builder has it.
+func (e Explanation) Option(name string) (Option, bool) {
+	for _, o := range e.Options {
+		if o.Name == name {
+			return o, true
+		}
+	}
+
+	return Option{}, false
+}
+
+// String renders the explanation for humans: one line per option, then one per command.
+func (e Explanation) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.Builder)
+
+	for _, o := range e.Options {
+		fmt.Fprintf(&sb, "\n  %s = %s (%s", o.Name, orNone(o.Value), o.Source)
+		if o.Source == SourceSet {
+			fmt.Fprintf(&sb, ", default: %s", orNone(o.Default))
+		}
+		sb.WriteString(")")
+
+		if len(o.Flags) > 0 {
+			sb.WriteString(" -> " + strings.Join(o.Flags, ", "))
+		}
+	}
+
+	for _, c := range e.Commands {
+		if c.Error != "" {
+			fmt.Fprintf(&sb, "\n  %s: error: %s", c.Name, c.Error)
+			continue
+		}
+		fmt.Fprintf(&sb, "\n  %s: %s", c.Name, strings.Join(c.Args, " "))
+	}
+
+	return sb.String()
+}
+
+// orNone returns 'value', or "none" when empty.
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+
+	return value
+}
+
+// Explain describes the builder 'builder', a pointer to a struct, against 'defaults', a freshly
+// constructed builder of the same type: every field with a value or a default is an option, set
+// when it differs from the default. The flags of the options are looked up in 'commands'.
+// Loggers, mutexes and functions are left out.
+func Explain(name string, builder, defaults any, commands ...Command) Explanation {
+	e := Explanation{Builder: name, Commands: commands}
+
+	v := structValue(builder)
+	if !v.IsValid() {
+		return e
+	}
+
+	d := structValue(defaults)
+	if d.IsValid() && d.Type() != v.Type() {
+		d = reflect.Value{}
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if skipped(field.Type) {
+			continue
+		}
+
+		value, leaves := describe(readable(v.Field(i)), field.Name, 0)
+
+		def := ""
+		if d.IsValid() {
+			def, _ = describe(readable(d.Field(i)), field.Name, 0)
+		}
+
+		if value == "" && def == "" {
+			continue
+		}
+
+		o := Option{Name: field.Name, Value: value, Default: def, Source: SourceDefault}
+		if value != def {
+			o.Source = SourceSet
+		}
+
+		if value != "" {
+			o.Flags = flagsOf(field.Name, leaves, commands)
+		}
+
+		e.Options = append(e.Options, o)
+	}
+
+	return e
+}
+
+// structValue returns the struct 'v' points to, or an invalid value.
+func structValue(v any) reflect.Value {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+
+	return rv.Elem()
+}
+
+// readable returns 'v' so its value can be read even when it is an unexported field. Builders
+// keep their options in unexported fields; Explain reads them without modifying them.
+func readable(v reflect.Value) reflect.Value {
+	if v.CanInterface() || !v.CanAddr() {
+		return v
+	}
+
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+// skipped reports whether fields of type 't' are left out of explanations.
+func skipped(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return true
+	case reflect.Ptr:
+		return skipped(t.Elem())
+	}
+
+	switch t.PkgPath() {
+	case "log/slog", "sync":
+		return true
+	}
+
+	return false
+}
+
+var (
+	durationType  = reflect.TypeOf(time.Duration(0))
+	timeType      = reflect.TypeOf(time.Time{})
+	secretRefType = reflect.TypeOf(secretsx.SecretRef{})
+	stringerType  = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// describe renders the value 'v' of the field 'name', and returns the strings it holds, to look
+// them up in the commands.
+func describe(v reflect.Value, name string, depth int) (string, []string) {
+	if !v.IsValid() {
+		return "", nil
+	}
+
+	if depth > maxDepth {
+		return "...", nil
+	}
+
+	switch v.Type() {
+	case timeType, secretRefType:
+		if !v.CanInterface() {
+			return "<" + v.Type().String() + ">", nil
+		}
+	}
+
+	switch v.Type() {
+	case durationType:
+		if v.Int() == 0 {
+			return "", nil
+		}
+		d := time.Duration(v.Int()).String()
+		return d, []string{d}
+	case timeType:
+		t, _ := v.Interface().(time.Time)
+		if t.IsZero() {
+			return "", nil
+		}
+		return t.UTC().Format(time.RFC3339), nil
+	case secretRefType:
+		ref, _ := v.Interface().(secretsx.SecretRef)
+		return "secret " + ref.Name, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return "", nil
+		}
+		return describe(readable(v.Elem()), name, depth)
+	case reflect.String:
+		s := v.String()
+		if s != "" && sensitiveRegex.MatchString(name) {
+			return redacted, nil
+		}
+		if s == "" {
+			return "", nil
+		}
+		if v.Type() != reflect.TypeOf("") && v.Type().Implements(stringerType) && v.CanInterface() {
+			s = v.Interface().(fmt.Stringer).String()
+		}
+		return s, []string{s}
+	case reflect.Bool:
+		if !v.Bool() {
+			return "", nil
+		}
+		return "true", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type().Implements(stringerType) && v.CanInterface() {
+			return v.Interface().(fmt.Stringer).String(), nil
+		}
+		if v.Int() == 0 {
+			return "", nil
+		}
+		s := strconv.FormatInt(v.Int(), 10)
+		return s, []string{s}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() == 0 {
+			return "", nil
+		}
+		s := strconv.FormatUint(v.Uint(), 10)
+		return s, []string{s}
+	case reflect.Float32, reflect.Float64:
+		if v.Float() == 0 {
+			return "", nil
+		}
+		s := strconv.FormatFloat(v.Float(), 'g', -1, 64)
+		return s, []string{s}
+	case reflect.Slice, reflect.Array:
+		return describeList(v, name, depth)
+	case reflect.Map:
+		return describeMap(v, name, depth)
+	case reflect.Struct:
+		return describeStruct(v, depth)
+	}
+
+	return "", nil
+}
+
+// describeList renders the slice or array 'v'.
+func describeList(v reflect.Value, name string, depth int) (string, []string) {
+	if v.Len() == 0 {
+		return "", nil
+	}
+
+	if v.Type().Elem().Kind() == reflect.Uint8 {
+		return fmt.Sprintf("<%d bytes>", v.Len()), nil
+	}
+
+	var (
+		items  []string
+		leaves []string
+	)
+	for i := 0; i < v.Len(); i++ {
+		item, l := describe(readable(v.Index(i)), name, depth+1)
+		items = append(items, item)
+		leaves = append(leaves, l...)
+	}
+
+	return "[" + strings.Join(items, ", ") + "]", leaves
+}
+
+// describeMap renders the map 'v', sorted by key.
+func describeMap(v reflect.Value, name string, depth int) (string, []string) {
+	if v.Len() == 0 {
+		return "", nil
+	}
+
+	var (
+		items  []string
+		leaves []string
+	)
+	iter := v.MapRange()
+	for iter.Next() {
+		key, _ := describe(readable(iter.Key()), "", depth+1)
+		value, l := describe(readable(iter.Value()), key, depth+1)
+		items = append(items, key+": "+value)
+		leaves = append(leaves, l...)
+		leaves = append(leaves, key+"="+value)
+	}
+	sort.Strings(items)
+
+	return "{" + strings.Join(items, ", ") + "}", leaves
+}
+
+// describeStruct renders the struct 'v', leaving out the fields without value.
+func describeStruct(v reflect.Value, depth int) (string, []string) {
+	var (
+		items  []string
+		leaves []string
+	)
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if skipped(field.Type) {
+			continue
+		}
+
+		value, l := describe(readable(v.Field(i)), field.Name, depth+1)
+		if value == "" {
+			continue
+		}
+
+		items = append(items, field.Name+": "+value)
+		leaves = append(leaves, l...)
+	}
+
+	if len(items) == 0 {
+		return "", nil
+	}
+
+	return "{" + strings.Join(items, ", ") + "}", leaves
+}
+
+// flagsOf returns the arguments of 'commands' carrying one of the values 'leaves' of the option
+// 'name' ("--flag value", "--flag=value" or a positional value), or named after the option.
+func flagsOf(name string, leaves []string, commands []Command) []string {
+	var flags []string
+	seen := map[string]bool{}
+	add := func(flag string) {
+		if !seen[flag] {
+			seen[flag] = true
+			flags = append(flags, flag)
+		}
+	}
+
+	option := normalize(name)
+	for _, c := range commands {
+		for i := 1; i < len(c.Args); i++ {
+			arg := c.Args[i]
+
+			if strings.HasPrefix(arg, "-") {
+				flagName, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+				if n := normalize(flagName); n != "" && (n == option || n+"s" == option) {
+					if next := i + 1; !strings.Contains(arg, "=") && next < len(c.Args) &&
+						!strings.HasPrefix(c.Args[next], "-") {
+						add(arg + " " + c.Args[next])
+						i = next
+					} else {
+						add(arg)
+					}
+					continue
+				}
+			}
+
+			for _, leaf := range leaves {
+				switch {
+				case arg == leaf:
+					if prev := c.Args[i-1]; i > 1 && strings.HasPrefix(prev, "-") && !strings.Contains(prev, "=") {
+						add(prev + " " + arg)
+					} else {
+						add(arg)
+					}
+				case strings.HasPrefix(arg, "-") && strings.HasSuffix(arg, "="+leaf):
+					add(arg)
+				}
+			}
+		}
+	}
+
+	return flags
+}
+
+// normalize returns the name 'name' lowercased, without dashes and underscores, so option names
+// compare to flag names (e.g. "noNetwork" and "no-network").
+func normalize(name string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(name))
+}
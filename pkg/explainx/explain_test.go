@@ -0,0 +1,144 @@
+package explainx
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/planx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type level int
+
+func (l level) String() string {
+	return [...]string{"low", "medium", "high"}[l]
+}
+
+type fakeBuilder struct {
+	files       []string
+	configFile  string
+	ignores     []string
+	noNetwork   bool
+	jobs        int
+	timeout     time.Duration
+	maxLevel    level
+	labels      map[string]string
+	apiToken    string
+	password    *secretsx.SecretRef
+	mtime       time.Time
+	unsetOption string
+	logger      *slog.Logger
+	hook        func()
+}
+
+func newFakeBuilder(files ...string) *fakeBuilder {
+	return &fakeBuilder{files: files, maxLevel: 1, labels: map[string]string{}}
+}
+
+func (b *fakeBuilder) command() ([]string, error) {
+	cmd := []string{"fake", "--config", b.configFile}
+	for _, i := range b.ignores {
+		cmd = append(cmd, "--ignore="+i)
+	}
+	if b.noNetwork {
+		cmd = append(cmd, "--no-network")
+	}
+	cmd = append(cmd, "--jobs", "4", "--timeout", b.timeout.String())
+	for k, v := range b.labels {
+		cmd = append(cmd, "--label", k+"="+v)
+	}
+
+	return append(cmd, b.files...), nil
+}
+
+func TestExplain(t *testing.T) {
+	b := newFakeBuilder("a.yaml", "b.yaml")
+	b.configFile = "fake.yaml"
+	b.ignores = []string{"R1", "R2"}
+	b.noNetwork = true
+	b.jobs = 4
+	b.timeout = time.Minute
+	b.labels["team"] = "platform"
+	b.apiToken = "s3cr3t"
+	b.password = secretsx.FromEnv("REGISTRY_PASSWORD", "REGISTRY_PASSWORD")
+	b.mtime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	b.logger = slog.Default()
+
+	e := Explain("fake", b, newFakeBuilder(), Generate("BuildCommand", b.command))
+
+	var names []string
+	for _, o := range e.Options {
+		names = append(names, o.Name)
+	}
+	assert.Equal(t, []string{
+		"files", "configFile", "ignores", "noNetwork", "jobs", "timeout", "maxLevel", "labels", "apiToken",
+		"password", "mtime",
+	}, names)
+
+	tests := []struct {
+		name   string
+		want   string
+		source Source
+		flags  []string
+	}{
+		{name: "files", want: "[a.yaml, b.yaml]", source: SourceSet, flags: []string{"a.yaml", "b.yaml"}},
+		{name: "configFile", want: "fake.yaml", source: SourceSet, flags: []string{"--config fake.yaml"}},
+		{name: "ignores", want: "[R1, R2]", source: SourceSet, flags: []string{"--ignore=R1", "--ignore=R2"}},
+		{name: "noNetwork", want: "true", source: SourceSet, flags: []string{"--no-network"}},
+		{name: "jobs", want: "4", source: SourceSet, flags: []string{"--jobs 4"}},
+		{name: "timeout", want: "1m0s", source: SourceSet, flags: []string{"--timeout 1m0s"}},
+		{name: "maxLevel", want: "medium", source: SourceDefault},
+		{name: "labels", want: "{team: platform}", source: SourceSet, flags: []string{"--label team=platform"}},
+		{name: "apiToken", want: "<redacted>", source: SourceSet},
+		{name: "password", want: "secret REGISTRY_PASSWORD", source: SourceSet},
+		{name: "mtime", want: "2024-01-02T03:04:05Z", source: SourceSet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, ok := e.Option(tt.name)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, o.Value)
+			assert.Equal(t, tt.source, o.Source)
+			assert.Equal(t, tt.flags, o.Flags)
+		})
+	}
+
+	assert.Len(t, e.Set(), 10)
+	assert.NotContains(t, e.String(), "s3cr3t")
+	assert.Contains(t, e.String(), "\n  maxLevel = medium (default)")
+	assert.Contains(t, e.String(), "\n  configFile = fake.yaml (set, default: none) -> --config fake.yaml")
+	assert.Contains(t, e.String(), "\n  BuildCommand: fake --config fake.yaml")
+}
+
+func TestExplainInvalid(t *testing.T) {
+	e := Explain("fake", nil, nil, Generate("BuildCommand", func() ([]string, error) {
+		return nil, errors.New("image is required")
+	}))
+
+	assert.Empty(t, e.Options)
+	assert.Equal(t, "fake\n  BuildCommand: error: image is required", e.String())
+
+	e = Explain("fake", newFakeBuilder("a.yaml"), "not a builder")
+	o, ok := e.Option("files")
+	require.True(t, ok)
+	assert.Equal(t, SourceSet, o.Source)
+}
+
+func TestPlanCommands(t *testing.T) {
+	plan := planx.NewPlan("smoke")
+	require.NoError(t, plan.AddTask(planx.Task{ID: "version", Command: types.DaggerCMD{"app", "--version"}}))
+
+	assert.Equal(t, []Command{{Name: "version", Args: []string{"app", "--version"}}}, PlanCommands(plan, nil))
+	assert.Equal(t, []Command{{Name: "Plan", Error: "invalid"}}, PlanCommands(nil, errors.New("invalid")))
+	assert.Nil(t, PlanCommands(nil, nil))
+
+	e := Explain("smoke", newFakeBuilder(), nil, PlanCommands(plan, nil)...)
+	assert.True(t, strings.HasSuffix(e.String(), "\n  version: app --version"))
+}
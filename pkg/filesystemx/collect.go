@@ -0,0 +1,101 @@
+package filesystemx
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/checksumx"
+	"github.com/Excoriate/daggerx/pkg/workspacex"
+)
+
+// FileEntry is a file selected by CollectFiles.
+type FileEntry struct {
+	// Path is the slash-separated path of the file, relative to the collection root.
+	Path string `json:"path"`
+	// Size is the size of the file in bytes.
+	Size int64 `json:"size"`
+	// Mode holds the permission and type bits of the file.
+	Mode fs.FileMode `json:"mode"`
+	// Digest is the "sha256:<hex>" digest of the file content, or of the link target for
+	// symbolic links.
+	Digest string `json:"digest"`
+}
+
+// CollectFiles selects the files under the host directory 'root' matching 'includes' and not
+// matching 'excludes' or the patterns of 'ignoreFile', with the same .dockerignore semantics as
+// workspacex.BuildContext, and hashes their content. A relative 'ignoreFile' is resolved against
+// 'root'; an empty one is skipped.
+//
+// Symbolic links are not followed: their digest is the digest of their target path, so the result
+// doesn't depend on files outside the root.
+//
+// Returns:
+//   - The selected files, sorted by path, so the result is deterministic.
+//   - An error if a pattern is invalid, or the ignore file or a file cannot be read.
+func CollectFiles(root string, includes, excludes []string, ignoreFile string) ([]FileEntry, error) {
+	bc := workspacex.NewBuildContext(root).WithInclude(includes...).WithExclude(excludes...)
+	if ignoreFile != "" {
+		if err := bc.WithIgnoreFile(ignoreFile); err != nil {
+			return nil, err
+		}
+	}
+
+	paths, err := bc.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]FileEntry, 0, len(paths))
+	for _, p := range paths {
+		entry, err := collectFile(root, p)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// TreeDigest returns the "sha256:<hex>" digest of a file list, combining the path and the digest
+// of each file. It changes whenever a file is added, removed, renamed or modified, which makes it
+// suitable for build context hashes and cache keys; sizes and permissions are left out.
+func TreeDigest(entries []FileEntry) string {
+	var listing strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&listing, "%s\x00%s\n", e.Path, e.Digest)
+	}
+
+	d, _ := checksumx.Bytes(checksumx.SHA256, []byte(listing.String()))
+
+	return d.String()
+}
+
+// collectFile describes and hashes the file 'rel' under 'root'.
+func collectFile(root, rel string) (FileEntry, error) {
+	path := filepath.Join(root, filepath.FromSlash(rel))
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return FileEntry{}, fmt.Errorf("failed to stat %s: %w", rel, err)
+	}
+
+	var d checksumx.Digest
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return FileEntry{}, fmt.Errorf("failed to read link %s: %w", rel, err)
+		}
+		d, err = checksumx.Bytes(checksumx.SHA256, []byte(filepath.ToSlash(target)))
+		if err != nil {
+			return FileEntry{}, err
+		}
+	} else if d, err = checksumx.File(checksumx.SHA256, path); err != nil {
+		return FileEntry{}, err
+	}
+
+	return FileEntry{Path: rel, Size: info.Size(), Mode: info.Mode(), Digest: d.String()}, nil
+}
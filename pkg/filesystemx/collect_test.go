@@ -0,0 +1,98 @@
+package filesystemx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	return root
+}
+
+func paths(entries []FileEntry) []string {
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = e.Path
+	}
+
+	return out
+}
+
+func TestCollectFiles(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"apko.yaml":           "contents: {}",
+		"README.md":           "# base",
+		"docs/guide.md":       "guide",
+		"src/main.go":         "package main",
+		"src/main_test.go":    "package main",
+		"node_modules/x/a.js": "x",
+		".dockerignore":       "node_modules\n*.md\n!README.md\n",
+	})
+
+	entries, err := CollectFiles(root, nil, []string{"**/*_test.go"}, ".dockerignore")
+	require.NoError(t, err)
+	assert.Equal(t, []string{".dockerignore", "README.md", "apko.yaml", "docs/guide.md", "src/main.go"},
+		paths(entries), "*.md only matches at the root, as in .dockerignore files")
+
+	main := entries[4]
+	assert.Equal(t, int64(len("package main")), main.Size)
+	assert.Equal(t, "sha256:512843855fcc92a51c810b1b58e0731c01eac9a6a23c157bfa02aad71edffbe7", main.Digest)
+
+	entries, err = CollectFiles(root, []string{"src/**"}, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/main.go", "src/main_test.go"}, paths(entries))
+}
+
+func TestCollectFilesSymlink(t *testing.T) {
+	root := writeTree(t, map[string]string{"a.txt": "a"})
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(root, "link")))
+
+	entries, err := CollectFiles(root, nil, nil, "")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.NotEqual(t, entries[0].Digest, entries[1].Digest, "links hash their target path")
+	assert.NotZero(t, entries[1].Mode&os.ModeSymlink)
+}
+
+func TestCollectFilesErrors(t *testing.T) {
+	root := writeTree(t, map[string]string{"a.txt": "a"})
+
+	_, err := CollectFiles(root, nil, nil, ".missingignore")
+	assert.Error(t, err)
+
+	_, err = CollectFiles("", nil, nil, "")
+	assert.Error(t, err)
+}
+
+func TestTreeDigest(t *testing.T) {
+	root := writeTree(t, map[string]string{"a.txt": "a", "b/c.txt": "c"})
+
+	first, err := CollectFiles(root, nil, nil, "")
+	require.NoError(t, err)
+	second, err := CollectFiles(root, nil, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, TreeDigest(first), TreeDigest(second))
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("changed"), 0o644))
+	changed, err := CollectFiles(root, nil, nil, "")
+	require.NoError(t, err)
+	assert.NotEqual(t, TreeDigest(first), TreeDigest(changed))
+
+	require.NoError(t, os.Rename(filepath.Join(root, "b", "c.txt"), filepath.Join(root, "b", "d.txt")))
+	renamed, err := CollectFiles(root, nil, nil, "")
+	require.NoError(t, err)
+	assert.NotEqual(t, TreeDigest(changed), TreeDigest(renamed))
+}
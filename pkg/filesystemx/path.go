@@ -0,0 +1,120 @@
+// Package filesystemx provides the path helpers builders use to place user-supplied paths inside
+// the container filesystem, so a relative path from a module argument cannot escape the mount
+// prefix with ".." segments or replace it with an absolute path.
+//
+// Paths are container paths: they use forward slashes whatever the host operating system.
+//
+// CollectFiles selects and hashes the files of a host directory deterministically, for build
+// context hashes, cache keys and artifact manifests.
+//
+// Example usage:
+//
+//	p, err := filesystemx.JoinUnderMount("configs", userPath)
+//	if errors.Is(err, filesystemx.ErrPathTraversal) {
+//	    // userPath was "../../etc/passwd" or similar
+//	}
+//
+//	files, err := filesystemx.CollectFiles("./images/base", nil, []string{"**/*.md"}, ".dockerignore")
+//	key := filesystemx.TreeDigest(files)
+package filesystemx
+
+import (
+	"errors"
+	"path"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+)
+
+// builderName identifies the path helpers in errorsx errors.
+const builderName = "filesystem"
+
+var (
+	// ErrPathTraversal reports a path escaping its base directory.
+	ErrPathTraversal = errors.New("path escapes its base directory")
+	// ErrAbsolutePath reports an absolute path where a relative path is expected.
+	ErrAbsolutePath = errors.New("path must be relative")
+)
+
+// ValidateRelative checks that 'p' is a relative path that stays under the directory it is
+// joined to: it must not be absolute, hold NUL bytes, or climb above its base with "..".
+func ValidateRelative(p string) error {
+	if strings.ContainsRune(p, 0) {
+		return errorsx.InvalidValue(builderName, "path", p, "path holds a NUL byte: %q", p)
+	}
+
+	if path.IsAbs(p) || strings.HasPrefix(p, `\`) {
+		return errorsx.InvalidValue(builderName, "path", p, "%w: %q", ErrAbsolutePath, p)
+	}
+
+	if clean := path.Clean(p); clean == ".." || strings.HasPrefix(clean, "../") {
+		return errorsx.InvalidValue(builderName, "path", p, "%w: %q", ErrPathTraversal, p)
+	}
+
+	return nil
+}
+
+// SafeJoin joins the relative paths 'elems' under the absolute directory 'base'.
+//
+// Returns:
+//   - The cleaned joined path, within 'base'.
+//   - An error if 'base' is not absolute, or an element is invalid (see ValidateRelative) or
+//     makes the result escape 'base'.
+func SafeJoin(base string, elems ...string) (string, error) {
+	if !path.IsAbs(base) {
+		return "", errorsx.InvalidValue(builderName, "base", base, "base directory must be absolute: %q", base)
+	}
+
+	for _, e := range elems {
+		if err := ValidateRelative(e); err != nil {
+			return "", err
+		}
+	}
+
+	joined := path.Join(append([]string{base}, elems...)...)
+	if !IsWithin(base, joined) {
+		return "", errorsx.InvalidValue(builderName, "path", joined, "%w: %q is outside %s",
+			ErrPathTraversal, joined, base)
+	}
+
+	return joined, nil
+}
+
+// JoinUnderMount joins the relative paths 'elems' under fixtures.MntPrefix, see SafeJoin.
+func JoinUnderMount(elems ...string) (string, error) {
+	return SafeJoin(fixtures.MntPrefix, elems...)
+}
+
+// ResolveUnder resolves 'p' under the absolute directory 'base': relative paths are joined to
+// 'base', and absolute paths are accepted when they already are within 'base'.
+//
+// Returns:
+//   - The cleaned path, within 'base'.
+//   - An error if the path is outside 'base'.
+func ResolveUnder(base, p string) (string, error) {
+	if !path.IsAbs(p) {
+		return SafeJoin(base, p)
+	}
+
+	if strings.ContainsRune(p, 0) {
+		return "", errorsx.InvalidValue(builderName, "path", p, "path holds a NUL byte: %q", p)
+	}
+
+	if !IsWithin(base, p) {
+		return "", errorsx.InvalidValue(builderName, "path", p, "%w: %q is outside %s", ErrPathTraversal, p, base)
+	}
+
+	return path.Clean(p), nil
+}
+
+// IsWithin reports whether the path 'target', once cleaned, is 'base' or a path under it.
+// Unlike a prefix check, "/mntx" and "/mnt/../etc" are not within "/mnt".
+func IsWithin(base, target string) bool {
+	base, target = path.Clean(base), path.Clean(target)
+	if base == "/" {
+		return path.IsAbs(target)
+	}
+
+	return target == base || strings.HasPrefix(target, base+"/")
+}
@@ -0,0 +1,74 @@
+package filesystemx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSafeJoin(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		elems   []string
+		want    string
+		wantErr error
+	}{
+		{name: "Relative path", base: "/mnt", elems: []string{"configs", "apko.yaml"}, want: "/mnt/configs/apko.yaml"},
+		{name: "Inner parent segments", base: "/mnt", elems: []string{"a/../b/./c.yaml"}, want: "/mnt/b/c.yaml"},
+		{name: "Empty element", base: "/mnt", elems: []string{""}, want: "/mnt"},
+		{name: "Traversal", base: "/mnt", elems: []string{"../etc/passwd"}, wantErr: ErrPathTraversal},
+		{name: "Split traversal", base: "/mnt/src", elems: []string{"a", "../.."}, wantErr: ErrPathTraversal},
+		{name: "Absolute injection", base: "/mnt", elems: []string{"/etc/passwd"}, wantErr: ErrAbsolutePath},
+		{name: "Windows absolute injection", base: "/mnt", elems: []string{`\etc`}, wantErr: ErrAbsolutePath},
+		{name: "NUL byte", base: "/mnt", elems: []string{"a\x00b"}, wantErr: errorsx.ErrInvalidValue},
+		{name: "Relative base", base: "mnt", elems: []string{"a"}, wantErr: errorsx.ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SafeJoin(tt.base, tt.elems...)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.ErrorIs(t, err, errorsx.ErrInvalidValue)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestJoinUnderMount(t *testing.T) {
+	got, err := JoinUnderMount("src", "main.go")
+	require.NoError(t, err)
+	assert.Equal(t, "/mnt/src/main.go", got)
+
+	_, err = JoinUnderMount("..", "mnt2")
+	assert.ErrorIs(t, err, ErrPathTraversal)
+}
+
+func TestResolveUnder(t *testing.T) {
+	got, err := ResolveUnder("/mnt", "/mnt/./src/")
+	require.NoError(t, err)
+	assert.Equal(t, "/mnt/src", got)
+
+	got, err = ResolveUnder("/mnt", "src")
+	require.NoError(t, err)
+	assert.Equal(t, "/mnt/src", got)
+
+	_, err = ResolveUnder("/mnt", "/mnt/../etc")
+	assert.ErrorIs(t, err, ErrPathTraversal)
+}
+
+func TestIsWithin(t *testing.T) {
+	assert.True(t, IsWithin("/mnt", "/mnt"))
+	assert.True(t, IsWithin("/mnt/", "/mnt/a/b"))
+	assert.True(t, IsWithin("/", "/etc"))
+	assert.False(t, IsWithin("/mnt", "/mntx"))
+	assert.False(t, IsWithin("/mnt", "/mnt/../etc"))
+	assert.False(t, IsWithin("/mnt", "mnt/a"))
+}
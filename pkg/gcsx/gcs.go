@@ -0,0 +1,365 @@
+// Package gcsx provides a builder for the `gcloud storage cp` and `gsutil cp` commands exporting
+// artifacts to Google Cloud Storage, with the customer-managed encryption key, cache-control and
+// storage options set consistently, and the service account key described as a secret (see
+// secretsx) mounted as a file rather than passed on the command line.
+//
+// Example usage:
+//
+//	upload := gcsx.NewCopyBuilder("gs://acme-artifacts/checkout/v1.2.0/", "/mnt/dist").
+//	    WithRecursive(true).
+//	    WithKMSKey("projects/acme/locations/europe/keyRings/ci/cryptoKeys/artifacts").
+//	    WithCacheControl("public, max-age=31536000, immutable").
+//	    WithCredentialsFile(secretsx.FromEnv("gcp-key", "CI_GCP_SERVICE_ACCOUNT_KEY"))
+//
+//	cmd, err := upload.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	res, err := executor.Run(ctx, cmd, upload.Env(), nil)
+package gcsx
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the GCS builder in errorsx errors.
+const builderName = "gcs"
+
+// GcloudDefaultImage is the default container image providing gcloud and gsutil.
+const GcloudDefaultImage = "gcr.io/google.com/cloudsdktool/google-cloud-cli:502.0.0-slim"
+
+const (
+	// CredentialsEnvVar is the environment variable the Google client libraries read the path of
+	// the service account key from.
+	CredentialsEnvVar = "GOOGLE_APPLICATION_CREDENTIALS"
+	// CredentialFileOverrideEnvVar is the environment variable gcloud, and gsutil run through
+	// gcloud, read the path of the service account key from.
+	CredentialFileOverrideEnvVar = "CLOUDSDK_AUTH_CREDENTIAL_FILE_OVERRIDE"
+)
+
+// Tool is the CLI uploading the artifacts.
+type Tool string
+
+const (
+	// ToolGcloud uploads with gcloud storage cp.
+	ToolGcloud Tool = "gcloud"
+	// ToolGsutil uploads with gsutil cp, for the images predating gcloud storage.
+	ToolGsutil Tool = "gsutil"
+)
+
+// StorageClasses are the storage classes of the uploaded objects.
+var StorageClasses = []string{"STANDARD", "NEARLINE", "COLDLINE", "ARCHIVE"}
+
+var (
+	// gsURIRegex matches Cloud Storage URIs: the bucket, and an optional object prefix.
+	gsURIRegex = regexp.MustCompile(`^gs://[a-z0-9][a-z0-9._-]{1,220}[a-z0-9](/.*)?$`)
+	// kmsKeyRegex matches the resource names of Cloud KMS keys.
+	kmsKeyRegex = regexp.MustCompile(`^projects/[^/]+/locations/[^/]+/keyRings/[^/]+/cryptoKeys/[^/]+$`)
+)
+
+// GetCredentialsPath returns the conventional path of the service account key inside the
+// container. If mntPrefix is empty, fixtures.MntPrefix is used.
+func GetCredentialsPath(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, ".gcp", "credentials.json")
+}
+
+// CopyBuilder generates the gcloud storage cp or gsutil cp command uploading artifacts to Cloud
+// Storage.
+type CopyBuilder struct {
+	// tool is the CLI uploading the artifacts.
+	tool Tool
+
+	// destination is the Cloud Storage URI.
+	destination string
+
+	// sources are the local files or directories.
+	sources []string
+
+	// recursive copies directories.
+	recursive bool
+
+	// noClobber skips the objects that already exist.
+	noClobber bool
+
+	// cacheControl is the Cache-Control header of the objects.
+	cacheControl string
+
+	// contentType is the Content-Type of the objects. Empty guesses it from the file extensions.
+	contentType string
+
+	// storageClass is the storage class of the objects.
+	storageClass string
+
+	// kmsKey is the Cloud KMS key encrypting the objects.
+	kmsKey string
+
+	// credentials is the secret holding the service account key.
+	credentials *secretsx.SecretRef
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewCopyBuilder creates a CopyBuilder copying the local files or directories 'sources' to the
+// Cloud Storage URI 'destination' (e.g. "gs://acme-artifacts/checkout/") with gcloud storage.
+func NewCopyBuilder(destination string, sources ...string) *CopyBuilder {
+	return &CopyBuilder{tool: ToolGcloud, destination: destination, sources: sources}
+}
+
+// WithTool sets the CLI uploading the artifacts. It defaults to ToolGcloud.
+func (b *CopyBuilder) WithTool(tool Tool) *CopyBuilder {
+	b.tool = tool
+	return b
+}
+
+// WithRecursive sets whether directories are copied.
+func (b *CopyBuilder) WithRecursive(recursive bool) *CopyBuilder {
+	b.recursive = recursive
+	return b
+}
+
+// WithNoClobber sets whether the objects that already exist are skipped, so released artifacts
+// are never overwritten.
+func (b *CopyBuilder) WithNoClobber(noClobber bool) *CopyBuilder {
+	b.noClobber = noClobber
+	return b
+}
+
+// WithCacheControl sets the Cache-Control header of the objects (e.g. "public, max-age=300").
+func (b *CopyBuilder) WithCacheControl(cacheControl string) *CopyBuilder {
+	b.cacheControl = cacheControl
+	return b
+}
+
+// WithContentType sets the Content-Type of the objects. The CLIs otherwise guess it from the
+// file extensions.
+func (b *CopyBuilder) WithContentType(contentType string) *CopyBuilder {
+	b.contentType = contentType
+	return b
+}
+
+// WithStorageClass sets the storage class of the objects, one of StorageClasses.
+func (b *CopyBuilder) WithStorageClass(class string) *CopyBuilder {
+	b.storageClass = class
+	return b
+}
+
+// WithKMSKey sets the Cloud KMS key encrypting the objects (CMEK), by resource name
+// (projects/P/locations/L/keyRings/R/cryptoKeys/K). The bucket default encryption is used
+// otherwise.
+func (b *CopyBuilder) WithKMSKey(key string) *CopyBuilder {
+	b.kmsKey = key
+	return b
+}
+
+// WithCredentialsFile sets the secret holding the service account key. It is mounted at
+// GetCredentialsPath(""); 'ref' is left unchanged. Without credentials, the CLIs use the
+// ambient credentials (e.g. workload identity).
+func (b *CopyBuilder) WithCredentialsFile(ref *secretsx.SecretRef) *CopyBuilder {
+	if ref == nil {
+		b.credentials = nil
+		return b
+	}
+
+	key := *ref
+	b.credentials = key.AsFile(GetCredentialsPath(""))
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *CopyBuilder) WithLogger(logger *slog.Logger) *CopyBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks the options of the upload.
+func (b *CopyBuilder) validate() error {
+	if b.tool != ToolGcloud && b.tool != ToolGsutil {
+		return errorsx.Unsupported(builderName, "tool", b.tool, "unsupported tool: %q", b.tool)
+	}
+
+	if len(b.sources) == 0 {
+		return errorsx.MissingField(builderName, "sources", "at least one source path is required")
+	}
+
+	if slices.Contains(b.sources, "") {
+		return errorsx.InvalidValue(builderName, "sources", "", "source paths cannot be empty")
+	}
+
+	if !gsURIRegex.MatchString(b.destination) {
+		return errorsx.InvalidValue(builderName, "destination", b.destination,
+			"destination must be a Cloud Storage URI (gs://bucket/prefix), got %q", b.destination)
+	}
+
+	for _, h := range []struct{ field, value string }{
+		{"cacheControl", b.cacheControl},
+		{"contentType", b.contentType},
+	} {
+		if strings.ContainsAny(h.value, "\r\n") {
+			return errorsx.InvalidValue(builderName, h.field, h.value, "invalid %s header", h.field)
+		}
+	}
+
+	if b.storageClass != "" && !slices.Contains(StorageClasses, b.storageClass) {
+		return errorsx.InvalidValue(builderName, "storageClass", b.storageClass,
+			"unsupported storage class: %q", b.storageClass)
+	}
+
+	if b.kmsKey != "" && !kmsKeyRegex.MatchString(b.kmsKey) {
+		return errorsx.InvalidValue(builderName, "kmsKey", b.kmsKey, "invalid Cloud KMS key name: %q", b.kmsKey)
+	}
+
+	if b.credentials != nil {
+		if err := b.credentials.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BuildCommand generates the gcloud storage cp or gsutil cp command.
+//
+// Returns:
+//   - The upload command.
+//   - An error if the tool is unsupported, no source is set, the destination is not a Cloud
+//     Storage URI, a header, the storage class or the KMS key is invalid, or the credentials are
+//     invalid.
+func (b *CopyBuilder) BuildCommand() ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	var cmd []string
+	if b.tool == ToolGsutil {
+		cmd = b.gsutilCommand()
+	} else {
+		cmd = b.gcloudCommand()
+	}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// gcloudCommand generates the gcloud storage cp command.
+func (b *CopyBuilder) gcloudCommand() []string {
+	cmd := []string{"gcloud", "storage", "cp"}
+	if b.recursive {
+		cmd = append(cmd, "--recursive")
+	}
+
+	if b.noClobber {
+		cmd = append(cmd, "--no-clobber")
+	}
+
+	for _, o := range []struct{ flag, value string }{
+		{"--cache-control", b.cacheControl},
+		{"--content-type", b.contentType},
+		{"--storage-class", b.storageClass},
+		{"--encryption-key", b.kmsKey},
+	} {
+		if o.value != "" {
+			cmd = append(cmd, o.flag+"="+o.value)
+		}
+	}
+
+	cmd = append(cmd, b.sources...)
+
+	return append(cmd, b.destination)
+}
+
+// gsutilCommand generates the gsutil cp command. The headers and the KMS key are top-level
+// options of gsutil.
+func (b *CopyBuilder) gsutilCommand() []string {
+	cmd := []string{"gsutil", "-m"}
+	if b.cacheControl != "" {
+		cmd = append(cmd, "-h", "Cache-Control:"+b.cacheControl)
+	}
+
+	if b.contentType != "" {
+		cmd = append(cmd, "-h", "Content-Type:"+b.contentType)
+	}
+
+	if b.kmsKey != "" {
+		cmd = append(cmd, "-o", "GSUtil:encryption_key="+b.kmsKey)
+	}
+
+	cmd = append(cmd, "cp")
+	if b.recursive {
+		cmd = append(cmd, "-r")
+	}
+
+	if b.noClobber {
+		cmd = append(cmd, "-n")
+	}
+
+	if b.storageClass != "" {
+		cmd = append(cmd, "-s", b.storageClass)
+	}
+
+	cmd = append(cmd, b.sources...)
+
+	return append(cmd, b.destination)
+}
+
+// Env returns the environment variables of the upload: the path of the service account key,
+// when set, and the prompts disabled.
+func (b *CopyBuilder) Env() []types.DaggerEnvVars {
+	env := []types.DaggerEnvVars{{Name: "CLOUDSDK_CORE_DISABLE_PROMPTS", Value: "1"}}
+	if b.credentials != nil {
+		env = append(env,
+			types.DaggerEnvVars{Name: CredentialsEnvVar, Value: b.credentials.Target},
+			types.DaggerEnvVars{Name: CredentialFileOverrideEnvVar, Value: b.credentials.Target},
+		)
+	}
+
+	return env
+}
+
+// Secrets returns the secrets the upload requires: the service account key, if any.
+func (b *CopyBuilder) Secrets() []*secretsx.SecretRef {
+	if b.credentials == nil {
+		return nil
+	}
+
+	return []*secretsx.SecretRef{b.credentials}
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *CopyBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *CopyBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the gcloud storage command.
+func (b *CopyBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewCopyBuilder(""), explainx.Generate("BuildCommand", b.BuildCommand))
+}
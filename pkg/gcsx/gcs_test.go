@@ -0,0 +1,135 @@
+package gcsx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKMSKey = "projects/acme/locations/europe/keyRings/ci/cryptoKeys/artifacts"
+
+func TestGetCredentialsPath(t *testing.T) {
+	assert.Equal(t, "/mnt/.gcp/credentials.json", GetCredentialsPath(""))
+	assert.Equal(t, "/work/.gcp/credentials.json", GetCredentialsPath("/work"))
+}
+
+func TestCopyBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *CopyBuilder
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "gcloud",
+			builder: NewCopyBuilder("gs://acme-artifacts/checkout/", "/mnt/app.tar.gz", "/mnt/app.sbom.json"),
+			want: []string{
+				"gcloud", "storage", "cp", "/mnt/app.tar.gz", "/mnt/app.sbom.json", "gs://acme-artifacts/checkout/",
+			},
+		},
+		{
+			name: "gcloud with options",
+			builder: NewCopyBuilder("gs://acme-artifacts/checkout/", "/mnt/dist").
+				WithRecursive(true).
+				WithNoClobber(true).
+				WithCacheControl("public, max-age=300").
+				WithContentType("text/html").
+				WithStorageClass("NEARLINE").
+				WithKMSKey(testKMSKey),
+			want: []string{
+				"gcloud", "storage", "cp", "--recursive", "--no-clobber", "--cache-control=public, max-age=300",
+				"--content-type=text/html", "--storage-class=NEARLINE", "--encryption-key=" + testKMSKey,
+				"/mnt/dist", "gs://acme-artifacts/checkout/",
+			},
+		},
+		{
+			name: "gsutil with options",
+			builder: NewCopyBuilder("gs://acme-artifacts/checkout/", "/mnt/dist").
+				WithTool(ToolGsutil).
+				WithRecursive(true).
+				WithNoClobber(true).
+				WithCacheControl("no-cache").
+				WithContentType("text/html").
+				WithStorageClass("NEARLINE").
+				WithKMSKey(testKMSKey),
+			want: []string{
+				"gsutil", "-m", "-h", "Cache-Control:no-cache", "-h", "Content-Type:text/html",
+				"-o", "GSUtil:encryption_key=" + testKMSKey, "cp", "-r", "-n", "-s", "NEARLINE",
+				"/mnt/dist", "gs://acme-artifacts/checkout/",
+			},
+		},
+		{
+			name:    "unsupported tool",
+			builder: NewCopyBuilder("gs://acme-artifacts", "/mnt/app").WithTool("rclone"),
+			wantErr: errorsx.ErrUnsupported,
+		},
+		{
+			name:    "missing sources",
+			builder: NewCopyBuilder("gs://acme-artifacts"),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "empty source",
+			builder: NewCopyBuilder("gs://acme-artifacts", ""),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "invalid destination",
+			builder: NewCopyBuilder("s3://acme-artifacts", "/mnt/app"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "invalid header",
+			builder: NewCopyBuilder("gs://acme-artifacts", "/mnt/app").WithContentType("text/html\r\n"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "unsupported storage class",
+			builder: NewCopyBuilder("gs://acme-artifacts", "/mnt/app").WithStorageClass("STANDARD_IA"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "invalid KMS key",
+			builder: NewCopyBuilder("gs://acme-artifacts", "/mnt/app").WithKMSKey("alias/artifacts"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestCopyBuilderCredentials(t *testing.T) {
+	ref := secretsx.FromEnv("gcp-key", "CI_GCP_SERVICE_ACCOUNT_KEY")
+	b := NewCopyBuilder("gs://acme-artifacts", "/mnt/app").WithCredentialsFile(ref)
+
+	secrets := b.Secrets()
+	require.Len(t, secrets, 1)
+	assert.Equal(t, secretsx.MountAsFile, secrets[0].Mount)
+	assert.Equal(t, GetCredentialsPath(""), secrets[0].Target)
+	assert.Equal(t, secretsx.MountAsEnv, ref.Mount)
+
+	assert.Equal(t, []types.DaggerEnvVars{
+		{Name: "CLOUDSDK_CORE_DISABLE_PROMPTS", Value: "1"},
+		{Name: CredentialsEnvVar, Value: GetCredentialsPath("")},
+		{Name: CredentialFileOverrideEnvVar, Value: GetCredentialsPath("")},
+	}, b.Env())
+
+	plain := NewCopyBuilder("gs://acme-artifacts", "/mnt/app")
+	assert.Nil(t, plain.Secrets())
+	assert.Len(t, plain.Env(), 1)
+}
@@ -0,0 +1,150 @@
+package ghapix
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// PRCommentBuilder generates the gh pr comment command of a pull request.
+type PRCommentBuilder struct {
+	auth
+
+	// number is the number of the pull request.
+	number int
+
+	// body is the markdown body of the comment.
+	body string
+
+	// editLast edits the last comment of the user instead of adding one.
+	editLast bool
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewPRCommentBuilder creates a PRCommentBuilder commenting on the pull request 'number' of the
+// repository 'repo' ("owner/repo").
+func NewPRCommentBuilder(repo string, number int) *PRCommentBuilder {
+	return &PRCommentBuilder{auth: auth{repo: repo}, number: number}
+}
+
+// WithToken sets the secret holding the GitHub token. It is exposed as TokenEnvVar; 'ref' is
+// left unchanged.
+func (b *PRCommentBuilder) WithToken(ref *secretsx.SecretRef) *PRCommentBuilder {
+	b.setToken(ref)
+	return b
+}
+
+// WithBody sets the markdown body of the comment.
+func (b *PRCommentBuilder) WithBody(markdown string) *PRCommentBuilder {
+	b.body = markdown
+	return b
+}
+
+// WithReport sets the body of the comment to the markdown of the run report 'report'.
+func (b *PRCommentBuilder) WithReport(report *reportx.Report) *PRCommentBuilder {
+	if report == nil {
+		return b
+	}
+
+	return b.WithBody(report.Markdown())
+}
+
+// WithEditLast sets whether the last comment of the token user is edited, creating it if there
+// is none, so reruns update the comment instead of adding one.
+func (b *PRCommentBuilder) WithEditLast(editLast bool) *PRCommentBuilder {
+	b.editLast = editLast
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *PRCommentBuilder) WithLogger(logger *slog.Logger) *PRCommentBuilder {
+	b.logger = logger
+	return b
+}
+
+// BodyPath returns the path of the body of the comment inside the container.
+func (b *PRCommentBuilder) BodyPath() string {
+	return GetBodyPath("", "pr-comment", b.body)
+}
+
+// BuildCommand generates the gh pr comment command.
+//
+// Returns:
+//   - The gh pr comment command.
+//   - An error if the repository, token or body are missing or invalid, or the pull request
+//     number is not positive.
+func (b *PRCommentBuilder) BuildCommand() ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	if b.number <= 0 {
+		return nil, errorsx.InvalidValue(builderName, "number", b.number, "pull request number must be positive")
+	}
+
+	if b.body == "" {
+		return nil, errorsx.MissingField(builderName, "body", "comment body is required")
+	}
+
+	if err := validateBody("body", b.body); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{
+		"gh", "pr", "comment", strconv.Itoa(b.number), "--repo", b.repo, "--body-file", b.BodyPath(),
+	}
+
+	if b.editLast {
+		cmd = append(cmd, "--edit-last", "--create-if-none")
+	}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Mounts returns the mounts the generated command requires: the inline mount of the body.
+func (b *PRCommentBuilder) Mounts() []types.Mount {
+	if b.body == "" {
+		return nil
+	}
+
+	return []types.Mount{{Kind: types.MountKindInline, Target: b.BodyPath(), Content: b.body, ReadOnly: true}}
+}
+
+// Secrets returns the secrets the command requires: the token.
+func (b *PRCommentBuilder) Secrets() []*secretsx.SecretRef {
+	return b.secrets()
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *PRCommentBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Env returns the environment variables the generated command requires: none. The
+// credentials are secrets (see Secrets).
+func (b *PRCommentBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the gh pr comment command.
+func (b *PRCommentBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("gh pr comment", b, NewPRCommentBuilder("", 0),
+		explainx.Generate("BuildCommand", b.BuildCommand))
+}
@@ -0,0 +1,77 @@
+package ghapix
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPRCommentBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  *PRCommentBuilder
+		wantArgs []string
+		wantErr  error
+	}{
+		{
+			name:     "comment",
+			builder:  NewPRCommentBuilder("acme/checkout", 42).WithToken(testToken()).WithBody("## Report"),
+			wantArgs: []string{"gh", "pr", "comment", "42", "--repo", "acme/checkout", "--body-file"},
+		},
+		{
+			name:    "missing token",
+			builder: NewPRCommentBuilder("acme/checkout", 42).WithBody("## Report"),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "invalid number",
+			builder: NewPRCommentBuilder("acme/checkout", 0).WithToken(testToken()).WithBody("## Report"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "missing body",
+			builder: NewPRCommentBuilder("acme/checkout", 42).WithToken(testToken()),
+			wantErr: errorsx.ErrMissingField,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, append(tt.wantArgs, tt.builder.BodyPath()), cmd)
+		})
+	}
+}
+
+func TestPRCommentBuilderEditLast(t *testing.T) {
+	b := NewPRCommentBuilder("acme/checkout", 42).WithToken(testToken()).WithBody("## Report").WithEditLast(true)
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--edit-last", "--create-if-none"}, cmd[len(cmd)-2:])
+}
+
+func TestPRCommentBuilderWithReport(t *testing.T) {
+	report := reportx.NewReport("build")
+	b := NewPRCommentBuilder("acme/checkout", 42).WithToken(testToken()).WithReport(report)
+
+	assert.Equal(t, []types.Mount{{
+		Kind:     types.MountKindInline,
+		Target:   b.BodyPath(),
+		Content:  report.Markdown(),
+		ReadOnly: true,
+	}}, b.Mounts())
+
+	assert.Nil(t, NewPRCommentBuilder("acme/checkout", 42).WithReport(nil).Mounts())
+	assert.Len(t, b.Secrets(), 1)
+}
@@ -0,0 +1,115 @@
+// Package ghapix provides gh CLI command builders for the final publishing steps of a pipeline:
+// creating a GitHub release with the artifacts of the artifact manifest (see artifactx), and
+// commenting on a pull request with the markdown of the run report (see reportx). The token is
+// exposed to gh as a secret, and the markdown bodies are written into the container with inline
+// mounts, so neither appears in the commands.
+//
+// Example usage:
+//
+//	comment := ghapix.NewPRCommentBuilder("acme/checkout", 42).
+//	    WithToken(secretsx.FromEnv("github-token", "GITHUB_TOKEN")).
+//	    WithReport(report).
+//	    WithEditLast(true)
+//
+//	cmd, err := comment.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	res, err := executor.Run(ctx, cmd, nil, comment.Mounts())
+package ghapix
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"regexp"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+)
+
+// builderName identifies the gh builders in errorsx errors.
+const builderName = "gh"
+
+// GHDefaultImage is the default container image providing gh.
+const GHDefaultImage = "ghcr.io/cli/cli:2.63.2"
+
+// TokenEnvVar is the environment variable gh reads the token from.
+const TokenEnvVar = "GH_TOKEN"
+
+// MaxBodyLength is the maximum length of the body of a comment or release, in characters.
+const MaxBodyLength = 65536
+
+// repoRegex matches "owner/repo" repository names.
+var repoRegex = regexp.MustCompile(`^[A-Za-z0-9-]+/[A-Za-z0-9._-]+$`)
+
+// GetBodyPath returns the path inside the container of the markdown body 'content' of a comment
+// or release named 'kind' (e.g. "pr-comment"). The path depends on the content, so different
+// bodies don't overwrite each other. If mntPrefix is empty, fixtures.MntPrefix is used.
+func GetBodyPath(mntPrefix, kind, content string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	sum := sha256.Sum256([]byte(content))
+
+	return path.Join(mntPrefix, ".daggerx", kind+"-"+hex.EncodeToString(sum[:6])+".md")
+}
+
+// auth holds the repository and token shared by the builders.
+type auth struct {
+	// repo is the "owner/repo" repository.
+	repo string
+
+	// token is the secret holding the GitHub token.
+	token *secretsx.SecretRef
+}
+
+// setToken exposes a copy of 'ref' as TokenEnvVar, leaving 'ref' unchanged.
+func (a *auth) setToken(ref *secretsx.SecretRef) {
+	if ref == nil {
+		a.token = nil
+		return
+	}
+
+	token := *ref
+	a.token = token.AsEnv(TokenEnvVar)
+}
+
+// validate checks the repository and token.
+func (a *auth) validate() error {
+	if a.repo == "" {
+		return errorsx.MissingField(builderName, "repo", "repository is required")
+	}
+
+	if !repoRegex.MatchString(a.repo) {
+		return errorsx.InvalidValue(builderName, "repo", a.repo, "repository must be owner/repo, got %q", a.repo)
+	}
+
+	if a.token == nil {
+		return errorsx.MissingField(builderName, "token", "GitHub token is required")
+	}
+
+	return a.token.Validate()
+}
+
+// secrets returns the token, if any.
+func (a *auth) secrets() []*secretsx.SecretRef {
+	if a.token == nil {
+		return nil
+	}
+
+	return []*secretsx.SecretRef{a.token}
+}
+
+// validateBody checks the length of the body 'field'.
+func validateBody(field, body string) error {
+	if n := len([]rune(body)); n > MaxBodyLength {
+		return errorsx.InvalidValue(builderName, field, n, "%s has %d characters, above the maximum of %d",
+			field, n, MaxBodyLength)
+	}
+
+	return nil
+}
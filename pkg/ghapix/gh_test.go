@@ -0,0 +1,68 @@
+package ghapix
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testToken() *secretsx.SecretRef {
+	return secretsx.FromEnv("github-token", "GITHUB_TOKEN")
+}
+
+func TestGetBodyPath(t *testing.T) {
+	path := GetBodyPath("", "pr-comment", "## Report")
+	assert.True(t, strings.HasPrefix(path, "/mnt/.daggerx/pr-comment-"), path)
+	assert.True(t, strings.HasSuffix(path, ".md"), path)
+
+	assert.Equal(t, path, GetBodyPath("/mnt", "pr-comment", "## Report"))
+	assert.NotEqual(t, path, GetBodyPath("", "pr-comment", "## Other report"))
+	assert.True(t, strings.HasPrefix(GetBodyPath("/work", "release-notes", "x"), "/work/.daggerx/release-notes-"))
+}
+
+func TestAuthValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    auth
+		wantErr error
+	}{
+		{name: "valid", auth: auth{repo: "acme/checkout.api", token: testToken()}},
+		{name: "missing repository", auth: auth{token: testToken()}, wantErr: errorsx.ErrMissingField},
+		{name: "invalid repository", auth: auth{repo: "checkout", token: testToken()}, wantErr: errorsx.ErrInvalidValue},
+		{name: "missing token", auth: auth{repo: "acme/checkout"}, wantErr: errorsx.ErrMissingField},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.auth.validate()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthSetToken(t *testing.T) {
+	ref := testToken()
+
+	var a auth
+	a.setToken(ref)
+	require.Len(t, a.secrets(), 1)
+	assert.Equal(t, TokenEnvVar, a.secrets()[0].Target)
+	assert.Equal(t, "GITHUB_TOKEN", ref.Target)
+
+	a.setToken(nil)
+	assert.Nil(t, a.secrets())
+}
+
+func TestValidateBody(t *testing.T) {
+	require.NoError(t, validateBody("body", strings.Repeat("é", MaxBodyLength)))
+	require.ErrorIs(t, validateBody("body", strings.Repeat("a", MaxBodyLength+1)), errorsx.ErrInvalidValue)
+}
@@ -0,0 +1,280 @@
+package ghapix
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// ReleaseBuilder generates the gh release create command of a tag.
+type ReleaseBuilder struct {
+	auth
+
+	// tag is the tag of the release.
+	tag string
+
+	// title is the title of the release. Empty uses the tag.
+	title string
+
+	// notes is the markdown of the release notes.
+	notes string
+
+	// generateNotes generates the release notes from the merged pull requests.
+	generateNotes bool
+
+	// target is the branch or commit the tag is created from, when it doesn't exist.
+	target string
+
+	// draft creates the release as a draft.
+	draft bool
+
+	// prerelease marks the release as a pre-release.
+	prerelease bool
+
+	// verifyTag fails if the tag doesn't exist yet.
+	verifyTag bool
+
+	// assets are the artifacts uploaded with the release.
+	assets []artifactx.Artifact
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewReleaseBuilder creates a ReleaseBuilder creating the release of the tag 'tag' of the
+// repository 'repo' ("owner/repo").
+func NewReleaseBuilder(repo, tag string) *ReleaseBuilder {
+	return &ReleaseBuilder{auth: auth{repo: repo}, tag: tag}
+}
+
+// WithToken sets the secret holding the GitHub token. It is exposed as TokenEnvVar; 'ref' is
+// left unchanged.
+func (b *ReleaseBuilder) WithToken(ref *secretsx.SecretRef) *ReleaseBuilder {
+	b.setToken(ref)
+	return b
+}
+
+// WithTitle sets the title of the release. It defaults to the tag.
+func (b *ReleaseBuilder) WithTitle(title string) *ReleaseBuilder {
+	b.title = title
+	return b
+}
+
+// WithNotes sets the markdown of the release notes.
+func (b *ReleaseBuilder) WithNotes(markdown string) *ReleaseBuilder {
+	b.notes = markdown
+	return b
+}
+
+// WithReport sets the release notes to the markdown of the run report 'report'.
+func (b *ReleaseBuilder) WithReport(report *reportx.Report) *ReleaseBuilder {
+	if report == nil {
+		return b
+	}
+
+	return b.WithNotes(report.Markdown())
+}
+
+// WithGenerateNotes sets whether the release notes are generated from the merged pull requests.
+// Notes set with WithNotes are prepended to the generated ones.
+func (b *ReleaseBuilder) WithGenerateNotes(generate bool) *ReleaseBuilder {
+	b.generateNotes = generate
+	return b
+}
+
+// WithTarget sets the branch or commit the tag is created from, when it doesn't exist.
+func (b *ReleaseBuilder) WithTarget(target string) *ReleaseBuilder {
+	b.target = target
+	return b
+}
+
+// WithDraft sets whether the release is created as a draft.
+func (b *ReleaseBuilder) WithDraft(draft bool) *ReleaseBuilder {
+	b.draft = draft
+	return b
+}
+
+// WithPrerelease sets whether the release is marked as a pre-release.
+func (b *ReleaseBuilder) WithPrerelease(prerelease bool) *ReleaseBuilder {
+	b.prerelease = prerelease
+	return b
+}
+
+// WithVerifyTag sets whether the command fails if the tag doesn't exist yet.
+func (b *ReleaseBuilder) WithVerifyTag(verify bool) *ReleaseBuilder {
+	b.verifyTag = verify
+	return b
+}
+
+// WithAssets adds artifacts uploaded with the release.
+func (b *ReleaseBuilder) WithAssets(artifacts ...artifactx.Artifact) *ReleaseBuilder {
+	b.assets = append(b.assets, artifacts...)
+	return b
+}
+
+// WithArtifacts adds the artifacts of the collector 'c' of the kinds 'kinds' as release assets,
+// or every artifact if none is given.
+func (b *ReleaseBuilder) WithArtifacts(c *artifactx.Collector, kinds ...artifactx.Kind) *ReleaseBuilder {
+	if c == nil {
+		return b
+	}
+
+	if len(kinds) == 0 {
+		return b.WithAssets(c.Artifacts()...)
+	}
+
+	for _, k := range kinds {
+		b.assets = append(b.assets, c.ByKind(k)...)
+	}
+
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *ReleaseBuilder) WithLogger(logger *slog.Logger) *ReleaseBuilder {
+	b.logger = logger
+	return b
+}
+
+// NotesPath returns the path of the release notes inside the container.
+func (b *ReleaseBuilder) NotesPath() string {
+	return GetBodyPath("", "release-notes", b.notes)
+}
+
+// validate checks the options of the release.
+func (b *ReleaseBuilder) validate() error {
+	if err := b.auth.validate(); err != nil {
+		return err
+	}
+
+	if b.tag == "" {
+		return errorsx.MissingField(builderName, "tag", "release tag is required")
+	}
+
+	if strings.HasPrefix(b.tag, "-") {
+		return errorsx.InvalidValue(builderName, "tag", b.tag, "invalid release tag: %q", b.tag)
+	}
+
+	if b.verifyTag && b.target != "" {
+		return errorsx.ConflictingOptions(builderName, []string{"verifyTag", "target"},
+			"the target creates a missing tag, which verify-tag forbids")
+	}
+
+	if err := validateBody("notes", b.notes); err != nil {
+		return err
+	}
+
+	seen := make(map[string]string, len(b.assets))
+	for _, a := range b.assets {
+		if a.Directory {
+			return errorsx.Unsupported(builderName, "assets", a.Path,
+				"release assets are files; archive the directory %s first", a.Path)
+		}
+
+		if other, ok := seen[a.Name()]; ok {
+			return errorsx.InvalidValue(builderName, "assets", a.Path,
+				"release assets %s and %s have the same name %s", other, a.Path, a.Name())
+		}
+		seen[a.Name()] = a.Path
+	}
+
+	return nil
+}
+
+// BuildCommand generates the gh release create command, uploading the assets.
+//
+// Returns:
+//   - The gh release create command.
+//   - An error if the repository, token or tag are missing or invalid, the tag options conflict,
+//     the notes are too long, or an asset is a directory or has the name of another asset.
+func (b *ReleaseBuilder) BuildCommand() ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"gh", "release", "create", b.tag}
+	for _, a := range b.assets {
+		cmd = append(cmd, a.Path)
+	}
+
+	cmd = append(cmd, "--repo", b.repo)
+	if b.title != "" {
+		cmd = append(cmd, "--title", b.title)
+	}
+
+	if b.notes != "" {
+		cmd = append(cmd, "--notes-file", b.NotesPath())
+	} else if !b.generateNotes {
+		cmd = append(cmd, "--notes", "")
+	}
+
+	if b.generateNotes {
+		cmd = append(cmd, "--generate-notes")
+	}
+
+	if b.target != "" {
+		cmd = append(cmd, "--target", b.target)
+	}
+
+	if b.draft {
+		cmd = append(cmd, "--draft")
+	}
+
+	if b.prerelease {
+		cmd = append(cmd, "--prerelease")
+	}
+
+	if b.verifyTag {
+		cmd = append(cmd, "--verify-tag")
+	}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Mounts returns the mounts the generated command requires: the inline mount of the notes, if
+// any.
+func (b *ReleaseBuilder) Mounts() []types.Mount {
+	if b.notes == "" {
+		return nil
+	}
+
+	return []types.Mount{{Kind: types.MountKindInline, Target: b.NotesPath(), Content: b.notes, ReadOnly: true}}
+}
+
+// Secrets returns the secrets the command requires: the token.
+func (b *ReleaseBuilder) Secrets() []*secretsx.SecretRef {
+	return b.secrets()
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *ReleaseBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Env returns the environment variables the generated command requires: none. The
+// credentials are secrets (see Secrets).
+func (b *ReleaseBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the gh release command.
+func (b *ReleaseBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("gh release", b, NewReleaseBuilder("", ""),
+		explainx.Generate("BuildCommand", b.BuildCommand))
+}
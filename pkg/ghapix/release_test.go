@@ -0,0 +1,140 @@
+package ghapix
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCollector(t *testing.T) *artifactx.Collector {
+	t.Helper()
+
+	c := artifactx.NewCollector()
+	require.NoError(t, c.Register(artifactx.Artifact{Kind: artifactx.KindImageTarball, Path: "/mnt/image.tar"}))
+	require.NoError(t, c.Register(artifactx.Artifact{Kind: artifactx.KindSBOM, Path: "/mnt/sbom.spdx.json"}))
+
+	return c
+}
+
+func TestReleaseBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder func(t *testing.T) *ReleaseBuilder
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "defaults",
+			builder: func(*testing.T) *ReleaseBuilder {
+				return NewReleaseBuilder("acme/checkout", "v1.4.0").WithToken(testToken())
+			},
+			want: []string{"gh", "release", "create", "v1.4.0", "--repo", "acme/checkout", "--notes", ""},
+		},
+		{
+			name: "artifacts and options",
+			builder: func(t *testing.T) *ReleaseBuilder {
+				return NewReleaseBuilder("acme/checkout", "v1.4.0-rc.1").
+					WithToken(testToken()).
+					WithArtifacts(testCollector(t), artifactx.KindSBOM, artifactx.KindImageTarball).
+					WithTitle("Checkout 1.4.0 RC1").
+					WithGenerateNotes(true).
+					WithTarget("main").
+					WithDraft(true).
+					WithPrerelease(true)
+			},
+			want: []string{
+				"gh", "release", "create", "v1.4.0-rc.1", "/mnt/sbom.spdx.json", "/mnt/image.tar",
+				"--repo", "acme/checkout", "--title", "Checkout 1.4.0 RC1", "--generate-notes",
+				"--target", "main", "--draft", "--prerelease",
+			},
+		},
+		{
+			name: "every artifact with verified tag",
+			builder: func(t *testing.T) *ReleaseBuilder {
+				return NewReleaseBuilder("acme/checkout", "v1.4.0").
+					WithToken(testToken()).
+					WithArtifacts(testCollector(t)).
+					WithGenerateNotes(true).
+					WithVerifyTag(true)
+			},
+			want: []string{
+				"gh", "release", "create", "v1.4.0", "/mnt/image.tar", "/mnt/sbom.spdx.json",
+				"--repo", "acme/checkout", "--generate-notes", "--verify-tag",
+			},
+		},
+		{
+			name: "missing tag",
+			builder: func(*testing.T) *ReleaseBuilder {
+				return NewReleaseBuilder("acme/checkout", "").WithToken(testToken())
+			},
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name: "invalid tag",
+			builder: func(*testing.T) *ReleaseBuilder {
+				return NewReleaseBuilder("acme/checkout", "--draft").WithToken(testToken())
+			},
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name: "target with verified tag",
+			builder: func(*testing.T) *ReleaseBuilder {
+				return NewReleaseBuilder("acme/checkout", "v1").WithToken(testToken()).WithTarget("main").WithVerifyTag(true)
+			},
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+		{
+			name: "directory asset",
+			builder: func(*testing.T) *ReleaseBuilder {
+				return NewReleaseBuilder("acme/checkout", "v1").WithToken(testToken()).
+					WithAssets(artifactx.Artifact{Kind: artifactx.KindTestOutput, Path: "/mnt/e2e", Directory: true})
+			},
+			wantErr: errorsx.ErrUnsupported,
+		},
+		{
+			name: "assets with the same name",
+			builder: func(*testing.T) *ReleaseBuilder {
+				return NewReleaseBuilder("acme/checkout", "v1").WithToken(testToken()).WithAssets(
+					artifactx.Artifact{Kind: artifactx.KindSBOM, Path: "/mnt/amd64/sbom.spdx.json"},
+					artifactx.Artifact{Kind: artifactx.KindSBOM, Path: "/mnt/arm64/sbom.spdx.json"},
+				)
+			},
+			wantErr: errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder(t).BuildCommand()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestReleaseBuilderNotes(t *testing.T) {
+	b := NewReleaseBuilder("acme/checkout", "v1.4.0").WithToken(testToken()).WithNotes("## Changes")
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gh", "release", "create", "v1.4.0", "--repo", "acme/checkout", "--notes-file", b.NotesPath()}, cmd)
+
+	assert.Equal(t, []types.Mount{{
+		Kind:     types.MountKindInline,
+		Target:   b.NotesPath(),
+		Content:  "## Changes",
+		ReadOnly: true,
+	}}, b.Mounts())
+
+	assert.Nil(t, NewReleaseBuilder("acme/checkout", "v1.4.0").Mounts())
+	assert.Len(t, b.Secrets(), 1)
+}
@@ -35,8 +35,10 @@ package githubx
 import (
 	"context"
 	"fmt"
-	"net/http"
 
+	"github.com/Excoriate/daggerx/pkg/cachex"
+	"github.com/Excoriate/daggerx/pkg/httpfetchx"
+	"github.com/Excoriate/daggerx/pkg/ratelimitx"
 	"github.com/google/go-github/github"
 	"golang.org/x/oauth2"
 )
@@ -47,18 +49,35 @@ import (
 //   - A string representing the latest release tag.
 //   - An error if the latest release cannot be fetched.
 func (gh *GHClient) FetchLatestRelease() (string, error) {
-	ctx := context.Background()
+	return gh.FetchLatestReleaseContext(context.Background())
+}
+
+// FetchLatestReleaseContext fetches the latest release from the GitHub repository, honoring the
+// cancellation and deadline of 'ctx'. Requests are sent with the client of httpfetchx.Default,
+// and throttled by ratelimitx.Default.
+//
+// Returns:
+//   - A string representing the latest release tag.
+//   - An error if the latest release cannot be fetched or the context is done.
+func (gh *GHClient) FetchLatestReleaseContext(ctx context.Context) (string, error) {
+	tc, err := httpfetchx.Default().Client()
+	if err != nil {
+		return "", err
+	}
 
-	var tc *http.Client
 	if gh.cfg.GetToken() != "" {
 		ts := oauth2.StaticTokenSource(
 			&oauth2.Token{AccessToken: gh.cfg.GetToken()},
 		)
-		tc = oauth2.NewClient(ctx, ts)
+		tc = oauth2.NewClient(context.WithValue(ctx, oauth2.HTTPClient, tc), ts)
 	}
 
 	client := github.NewClient(tc)
 
+	if err := ratelimitx.Default().Wait(ctx, client.BaseURL.Host); err != nil {
+		return "", err
+	}
+
 	release, _, err := client.Repositories.GetLatestRelease(ctx, gh.cfg.GetOwner(), gh.cfg.GetRepo())
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch the latest release: %w", err)
@@ -66,3 +85,23 @@ func (gh *GHClient) FetchLatestRelease() (string, error) {
 
 	return release.GetTagName(), nil
 }
+
+// FetchLatestReleaseCached fetches the latest release like FetchLatestReleaseContext, caching the
+// tag in 'cache' under the "release:<owner>/<repo>" key. A nil cache disables caching.
+//
+// Returns:
+//   - A string representing the latest release tag.
+//   - An error if the latest release cannot be fetched.
+func (gh *GHClient) FetchLatestReleaseCached(ctx context.Context, cache *cachex.Cache) (string, error) {
+	key := fmt.Sprintf("release:%s/%s", gh.cfg.GetOwner(), gh.cfg.GetRepo())
+
+	value, err := cache.GetOrFetch(ctx, key, func(ctx context.Context) ([]byte, error) {
+		tag, err := gh.FetchLatestReleaseContext(ctx)
+		return []byte(tag), err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
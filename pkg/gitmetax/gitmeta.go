@@ -0,0 +1,230 @@
+// Package gitmetax extracts repository metadata (branch, commit sha, tag, dirty flag and commit
+// timestamp) used by image tagging, OCI labels and reproducible-build timestamps.
+//
+// The package generates the git plumbing commands and parses their outputs, so the commands can
+// run anywhere an execx.Executor can: on the host, or inside a Dagger container with the
+// repository mounted.
+//
+// Example usage:
+//
+//	meta, err := gitmetax.Extract(ctx, execx.NewLocalExecutor(), ".")
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	builder.WithBuildDate(meta.SourceDateEpoch()).
+//	    WithAnnotations(meta.Labels())
+package gitmetax
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/execx"
+	"github.com/Excoriate/daggerx/pkg/timex"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// ShortSHALength is the length of abbreviated commit shas.
+const ShortSHALength = 7
+
+// Metadata holds the metadata of a git repository checkout.
+type Metadata struct {
+	// Branch is the checked out branch, empty on a detached HEAD.
+	Branch string `json:"branch,omitempty"`
+	// SHA is the full sha of the HEAD commit.
+	SHA string `json:"sha"`
+	// ShortSHA is the abbreviated sha of the HEAD commit.
+	ShortSHA string `json:"shortSha"`
+	// Tag is the tag pointing at the HEAD commit, if any.
+	Tag string `json:"tag,omitempty"`
+	// Dirty reports whether the working tree has uncommitted changes.
+	Dirty bool `json:"dirty"`
+	// CommitTime is the committer timestamp of the HEAD commit.
+	CommitTime time.Time `json:"commitTime"`
+	// RemoteURL is the URL of the "origin" remote, if configured.
+	RemoteURL string `json:"remoteUrl,omitempty"`
+}
+
+// Commands holds the git commands used to extract the metadata of a repository.
+type Commands struct {
+	// SHA prints the full sha of HEAD.
+	SHA types.DaggerCMD
+	// Branch prints the checked out branch, or "HEAD" when detached.
+	Branch types.DaggerCMD
+	// Tag prints the tag pointing at HEAD, failing when there is none.
+	Tag types.DaggerCMD
+	// Status prints the porcelain status, empty for a clean working tree.
+	Status types.DaggerCMD
+	// CommitTime prints the committer timestamp of HEAD as Unix seconds.
+	CommitTime types.DaggerCMD
+	// RemoteURL prints the URL of the "origin" remote, failing when there is none.
+	RemoteURL types.DaggerCMD
+}
+
+// NewCommands returns the git commands extracting the metadata of the repository at 'repoPath'.
+func NewCommands(repoPath string) Commands {
+	git := func(args ...string) types.DaggerCMD {
+		return append(types.DaggerCMD{"git", "-C", repoPath}, args...)
+	}
+
+	return Commands{
+		SHA:        git("rev-parse", "HEAD"),
+		Branch:     git("rev-parse", "--abbrev-ref", "HEAD"),
+		Tag:        git("describe", "--tags", "--exact-match", "HEAD"),
+		Status:     git("status", "--porcelain"),
+		CommitTime: git("log", "-1", "--format=%ct", "HEAD"),
+		RemoteURL:  git("config", "--get", "remote.origin.url"),
+	}
+}
+
+// Extract runs the git commands against the repository at 'repoPath' with the executor and
+// parses their outputs. A missing tag or remote is not an error.
+//
+// Returns:
+//   - The metadata of the repository.
+//   - An error if the path is not a git repository or an output cannot be parsed.
+func Extract(ctx context.Context, ex execx.Executor, repoPath string) (*Metadata, error) {
+	if ex == nil {
+		return nil, fmt.Errorf("executor is required")
+	}
+
+	if repoPath == "" {
+		return nil, fmt.Errorf("repository path is required")
+	}
+
+	cmds := NewCommands(repoPath)
+
+	sha, err := runRequired(ctx, ex, cmds.SHA)
+	if err != nil {
+		return nil, err
+	}
+
+	branch, err := runRequired(ctx, ex, cmds.Branch)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := runRequired(ctx, ex, cmds.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	ts, err := runRequired(ctx, ex, cmds.CommitTime)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := runOptional(ctx, ex, cmds.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := runOptional(ctx, ex, cmds.RemoteURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(sha, branch, tag, status, ts, remote)
+}
+
+// Parse builds the metadata from the outputs of the commands returned by NewCommands.
+func Parse(shaOut, branchOut, tagOut, statusOut, commitTimeOut, remoteOut string) (*Metadata, error) {
+	sha := strings.TrimSpace(shaOut)
+	if len(sha) < ShortSHALength {
+		return nil, fmt.Errorf("invalid commit sha: %q", sha)
+	}
+
+	secs, err := strconv.ParseInt(strings.TrimSpace(commitTimeOut), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit timestamp %q: %w", strings.TrimSpace(commitTimeOut), err)
+	}
+
+	branch := strings.TrimSpace(branchOut)
+	if branch == "HEAD" {
+		branch = ""
+	}
+
+	return &Metadata{
+		Branch:     branch,
+		SHA:        sha,
+		ShortSHA:   sha[:ShortSHALength],
+		Tag:        strings.TrimSpace(tagOut),
+		Dirty:      strings.TrimSpace(statusOut) != "",
+		CommitTime: time.Unix(secs, 0).UTC(),
+		RemoteURL:  strings.TrimSpace(remoteOut),
+	}, nil
+}
+
+// IsDetached reports whether HEAD is detached.
+func (m *Metadata) IsDetached() bool {
+	return m.Branch == ""
+}
+
+// SourceDateEpoch returns the commit timestamp as Unix seconds, the SOURCE_DATE_EPOCH value of
+// reproducible builds.
+func (m *Metadata) SourceDateEpoch() string {
+	return timex.Epoch(m.CommitTime)
+}
+
+// Version returns the tag pointing at HEAD, or the short sha when there is none. A "-dirty"
+// suffix is appended for working trees with uncommitted changes.
+func (m *Metadata) Version() string {
+	v := m.Tag
+	if v == "" {
+		v = m.ShortSHA
+	}
+
+	if m.Dirty {
+		v += "-dirty"
+	}
+
+	return v
+}
+
+// Labels returns the OCI image annotations describing the checkout.
+func (m *Metadata) Labels() map[string]string {
+	labels := map[string]string{
+		"org.opencontainers.image.revision": m.SHA,
+		"org.opencontainers.image.created":  timex.RFC3339(m.CommitTime),
+		"org.opencontainers.image.version":  m.Version(),
+	}
+
+	if m.RemoteURL != "" {
+		labels["org.opencontainers.image.source"] = m.RemoteURL
+	}
+
+	return labels
+}
+
+// runRequired runs a command that must succeed and returns its stdout.
+func runRequired(ctx context.Context, ex execx.Executor, cmd types.DaggerCMD) (string, error) {
+	res, err := ex.Run(ctx, cmd, nil, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s: %w", strings.Join(cmd, " "), err)
+	}
+
+	if !res.Succeeded() {
+		return "", fmt.Errorf("%s exited with code %d: %s", strings.Join(cmd, " "), res.ExitCode,
+			strings.TrimSpace(res.Stderr))
+	}
+
+	return res.Stdout, nil
+}
+
+// runOptional runs a command whose failure means the value is absent and returns its stdout.
+func runOptional(ctx context.Context, ex execx.Executor, cmd types.DaggerCMD) (string, error) {
+	res, err := ex.Run(ctx, cmd, nil, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s: %w", strings.Join(cmd, " "), err)
+	}
+
+	if !res.Succeeded() {
+		return "", nil
+	}
+
+	return res.Stdout, nil
+}
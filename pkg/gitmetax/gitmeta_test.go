@@ -0,0 +1,153 @@
+package gitmetax
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/execx"
+)
+
+const testSHA = "0123456789abcdef0123456789abcdef01234567"
+
+func TestParse(t *testing.T) {
+	m, err := Parse(testSHA+"\n", "main\n", "", "", "1700000000\n", "https://github.com/x/y.git\n")
+	if err != nil {
+		t.Fatalf("Parse returned unexpected error: %v", err)
+	}
+
+	if m.ShortSHA != "0123456" || m.Branch != "main" || m.Dirty || m.Tag != "" {
+		t.Errorf("Unexpected metadata %+v", m)
+	}
+
+	if m.SourceDateEpoch() != "1700000000" {
+		t.Errorf("SourceDateEpoch() = %s", m.SourceDateEpoch())
+	}
+
+	if m.Version() != "0123456" {
+		t.Errorf("Version() = %s", m.Version())
+	}
+
+	labels := m.Labels()
+	if labels["org.opencontainers.image.revision"] != testSHA ||
+		labels["org.opencontainers.image.source"] != "https://github.com/x/y.git" ||
+		labels["org.opencontainers.image.created"] != "2023-11-14T22:13:20Z" {
+		t.Errorf("Unexpected labels %v", labels)
+	}
+}
+
+func TestParseDetachedDirtyTag(t *testing.T) {
+	m, err := Parse(testSHA, "HEAD", "v1.2.0", " M main.go", "1700000000", "")
+	if err != nil {
+		t.Fatalf("Parse returned unexpected error: %v", err)
+	}
+
+	if !m.IsDetached() || !m.Dirty {
+		t.Errorf("Expected a detached dirty checkout, got %+v", m)
+	}
+
+	if m.Version() != "v1.2.0-dirty" {
+		t.Errorf("Version() = %s", m.Version())
+	}
+
+	if _, ok := m.Labels()["org.opencontainers.image.source"]; ok {
+		t.Error("Expected no source label without remote")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse("abc", "main", "", "", "1", ""); err == nil {
+		t.Error("Expected error for short sha, got none")
+	}
+
+	if _, err := Parse(testSHA, "main", "", "", "yesterday", ""); err == nil {
+		t.Error("Expected error for invalid timestamp, got none")
+	}
+}
+
+func TestNewCommands(t *testing.T) {
+	cmds := NewCommands("/src")
+
+	want := []string{"git", "-C", "/src", "log", "-1", "--format=%ct", "HEAD"}
+	if len(cmds.CommitTime) != len(want) {
+		t.Fatalf("CommitTime = %v, want %v", cmds.CommitTime, want)
+	}
+	for i := range want {
+		if cmds.CommitTime[i] != want[i] {
+			t.Errorf("CommitTime = %v, want %v", cmds.CommitTime, want)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+			"GIT_COMMITTER_DATE=2024-01-02T03:04:05Z")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+	}
+
+	git("init", "-q", "-b", "main")
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello"), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	git("add", "README.md")
+	git("commit", "-q", "-m", "initial")
+	git("tag", "v0.1.0")
+
+	m, err := Extract(context.Background(), execx.NewLocalExecutor(), dir)
+	if err != nil {
+		t.Fatalf("Extract returned unexpected error: %v", err)
+	}
+
+	if m.Branch != "main" || m.Tag != "v0.1.0" || m.Dirty || len(m.SHA) != 40 {
+		t.Errorf("Unexpected metadata %+v", m)
+	}
+
+	if !m.CommitTime.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("Unexpected commit time %s", m.CommitTime)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("changed"), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	m, err = Extract(context.Background(), execx.NewLocalExecutor(), dir)
+	if err != nil {
+		t.Fatalf("Extract returned unexpected error: %v", err)
+	}
+
+	if !m.Dirty {
+		t.Error("Expected a dirty working tree")
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	if _, err := Extract(context.Background(), nil, "."); err == nil {
+		t.Error("Expected error for nil executor, got none")
+	}
+
+	if _, err := Extract(context.Background(), execx.NewLocalExecutor(), ""); err == nil {
+		t.Error("Expected error for empty path, got none")
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	if _, err := Extract(context.Background(), execx.NewLocalExecutor(), t.TempDir()); err == nil {
+		t.Error("Expected error outside a git repository, got none")
+	}
+}
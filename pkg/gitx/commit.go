@@ -0,0 +1,245 @@
+// Package gitx provides builders for the git commands of release automation: committing version
+// bumps, creating signed tags and pushing them with credentials described by secretsx references.
+//
+// Identities are passed through the GIT_AUTHOR_* and GIT_COMMITTER_* environment variables and
+// credentials through secrets, so containers need no git configuration and no credential ever
+// appears in a command line.
+//
+// Example usage:
+//
+//	bot := gitx.Identity{Name: "release-bot", Email: "release-bot@example.com"}
+//
+//	commit, err := gitx.NewCommitBuilder("chore(release): v1.3.0").WithAuthor(bot).WithAll(true).BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	tag, err := gitx.NewTagBuilder("v1.3.0").WithMessage("v1.3.0").WithTagger(bot).BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	push := gitx.NewPushBuilder("https://github.com/example/app.git").
+//	    WithRefs("main", "v1.3.0").
+//	    WithAuth(gitx.TokenAuth(secretsx.FromEnv("github-token", "GITHUB_TOKEN"), ""))
+package gitx
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the git builders in errorsx errors.
+const builderName = "git"
+
+// GitDefaultImage is the default container image providing git.
+const GitDefaultImage = "alpine/git"
+
+// Identity is the name and email of a commit author, committer or tagger.
+type Identity struct {
+	// Name is the identity name.
+	Name string
+	// Email is the identity email.
+	Email string
+}
+
+// IsZero reports whether the identity is unset.
+func (i Identity) IsZero() bool {
+	return i.Name == "" && i.Email == ""
+}
+
+// validate checks that the identity 'field' has a name and a valid email.
+func (i Identity) validate(field string) error {
+	if i.Name == "" {
+		return errorsx.MissingField(builderName, field, "%s name is required", field)
+	}
+
+	if !strings.Contains(i.Email, "@") || strings.ContainsAny(i.Email, "<> ") {
+		return errorsx.InvalidValue(builderName, field, i.Email, "%s email is invalid: %q", field, i.Email)
+	}
+
+	return nil
+}
+
+// env returns the environment variables of the identity for the git role 'role' ("AUTHOR" or
+// "COMMITTER").
+func (i Identity) env(role string) []types.DaggerEnvVars {
+	return []types.DaggerEnvVars{
+		{Name: "GIT_" + role + "_NAME", Value: i.Name},
+		{Name: "GIT_" + role + "_EMAIL", Value: i.Email},
+	}
+}
+
+// gitCommand returns the git command prefix of the repository 'repoPath' with the configuration
+// overrides 'config' ("key=value").
+func gitCommand(repoPath string, config ...string) []string {
+	cmd := []string{"git"}
+	if repoPath != "" {
+		cmd = append(cmd, "-C", repoPath)
+	}
+
+	for _, c := range config {
+		cmd = append(cmd, "-c", c)
+	}
+
+	return cmd
+}
+
+// CommitBuilder generates the `git commit` command of a release commit.
+type CommitBuilder struct {
+	// message is the commit message.
+	message string
+
+	// repoPath is the path of the repository, passed as -C.
+	repoPath string
+
+	// author is the commit author.
+	author Identity
+
+	// committer is the committer. It defaults to the author.
+	committer Identity
+
+	// all stages modified and deleted tracked files before committing.
+	all bool
+
+	// signoff adds a Signed-off-by trailer.
+	signoff bool
+
+	// allowEmpty allows commits without changes.
+	allowEmpty bool
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewCommitBuilder creates a CommitBuilder committing with the message 'message'.
+func NewCommitBuilder(message string) *CommitBuilder {
+	return &CommitBuilder{message: message}
+}
+
+// WithRepoPath sets the path of the repository.
+func (b *CommitBuilder) WithRepoPath(path string) *CommitBuilder {
+	b.repoPath = path
+	return b
+}
+
+// WithAuthor sets the commit author.
+func (b *CommitBuilder) WithAuthor(author Identity) *CommitBuilder {
+	b.author = author
+	return b
+}
+
+// WithCommitter sets the committer. It defaults to the author.
+func (b *CommitBuilder) WithCommitter(committer Identity) *CommitBuilder {
+	b.committer = committer
+	return b
+}
+
+// WithAll stages modified and deleted tracked files before committing (git commit --all).
+func (b *CommitBuilder) WithAll(all bool) *CommitBuilder {
+	b.all = all
+	return b
+}
+
+// WithSignoff adds a Signed-off-by trailer for the committer.
+func (b *CommitBuilder) WithSignoff(signoff bool) *CommitBuilder {
+	b.signoff = signoff
+	return b
+}
+
+// WithAllowEmpty allows creating a commit without changes.
+func (b *CommitBuilder) WithAllowEmpty(allow bool) *CommitBuilder {
+	b.allowEmpty = allow
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *CommitBuilder) WithLogger(logger *slog.Logger) *CommitBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks that the builder configuration is complete.
+func (b *CommitBuilder) validate() error {
+	if strings.TrimSpace(b.message) == "" {
+		return errorsx.MissingField(builderName, "message", "commit message is required")
+	}
+
+	if err := b.author.validate("author"); err != nil {
+		return err
+	}
+
+	if !b.committer.IsZero() {
+		return b.committer.validate("committer")
+	}
+
+	return nil
+}
+
+// BuildCommand generates the git commit command. The identities are provided by Env.
+//
+// Returns:
+//   - The git commit command.
+//   - An error if the message or the author is missing or invalid.
+func (b *CommitBuilder) BuildCommand() ([]string, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := append(gitCommand(b.repoPath), "commit", "--message", b.message)
+
+	if b.all {
+		cmd = append(cmd, "--all")
+	}
+
+	if b.signoff {
+		cmd = append(cmd, "--signoff")
+	}
+
+	if b.allowEmpty {
+		cmd = append(cmd, "--allow-empty")
+	}
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Env returns the GIT_AUTHOR_* and GIT_COMMITTER_* environment variables of the commit.
+func (b *CommitBuilder) Env() []types.DaggerEnvVars {
+	committer := b.committer
+	if committer.IsZero() {
+		committer = b.author
+	}
+
+	return append(b.author.env("AUTHOR"), committer.env("COMMITTER")...)
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *CommitBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *CommitBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the git commit command.
+func (b *CommitBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("git commit", b, NewCommitBuilder(""), explainx.Generate("BuildCommand", b.BuildCommand))
+}
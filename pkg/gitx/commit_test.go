@@ -0,0 +1,65 @@
+package gitx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var bot = Identity{Name: "release-bot", Email: "release-bot@example.com"}
+
+func TestCommitBuilderBuildCommand(t *testing.T) {
+	b := NewCommitBuilder("chore(release): v1.3.0").
+		WithRepoPath("/src").
+		WithAuthor(bot).
+		WithAll(true).
+		WithSignoff(true).
+		WithAllowEmpty(true)
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"git", "-C", "/src", "commit", "--message", "chore(release): v1.3.0", "--all", "--signoff", "--allow-empty",
+	}, cmd)
+
+	assert.Equal(t, []types.DaggerEnvVars{
+		{Name: "GIT_AUTHOR_NAME", Value: "release-bot"},
+		{Name: "GIT_AUTHOR_EMAIL", Value: "release-bot@example.com"},
+		{Name: "GIT_COMMITTER_NAME", Value: "release-bot"},
+		{Name: "GIT_COMMITTER_EMAIL", Value: "release-bot@example.com"},
+	}, b.Env())
+
+	b.WithCommitter(Identity{Name: "ci", Email: "ci@example.com"})
+	assert.Equal(t, types.DaggerEnvVars{Name: "GIT_COMMITTER_NAME", Value: "ci"}, b.Env()[2])
+}
+
+func TestCommitBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *CommitBuilder
+		field   string
+		kind    error
+	}{
+		{"Missing message", NewCommitBuilder("  ").WithAuthor(bot), "message", errorsx.ErrMissingField},
+		{"Missing author", NewCommitBuilder("msg"), "author", errorsx.ErrMissingField},
+		{"Invalid author email", NewCommitBuilder("msg").WithAuthor(Identity{Name: "bot", Email: "bot"}), "author", errorsx.ErrInvalidValue},
+		{
+			"Invalid committer",
+			NewCommitBuilder("msg").WithAuthor(bot).WithCommitter(Identity{Email: "ci@example.com"}),
+			"committer", errorsx.ErrMissingField,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.BuildCommand()
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+			field, _ := errorsx.FieldOf(err)
+			assert.Equal(t, tt.field, field)
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package gitx
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// DefaultTokenUsername is the username sent with tokens when none is given. GitHub and GitLab
+// accept any username with a token.
+const DefaultTokenUsername = "x-access-token"
+
+// AuthKind describes how git authenticates to the remote.
+type AuthKind string
+
+const (
+	// AuthToken authenticates over HTTPS with a token.
+	AuthToken AuthKind = "token"
+	// AuthSSHKey authenticates over SSH with a private key.
+	AuthSSHKey AuthKind = "ssh-key"
+)
+
+// envVarNameRegex matches the environment variable names sh can expand.
+var envVarNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// scpLikeURLRegex matches scp-like SSH remotes (e.g. "git@github.com:example/app.git").
+var scpLikeURLRegex = regexp.MustCompile(`^[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:[^/]`)
+
+// Auth describes the credentials of a git remote.
+type Auth struct {
+	// Kind is how git authenticates.
+	Kind AuthKind
+	// Secret holds the token, exposed as an environment variable, or the SSH private key,
+	// mounted as a file.
+	Secret *secretsx.SecretRef
+	// Username is the username sent with the token.
+	Username string
+	// KnownHostsPath is the known_hosts file checked against the remote host key. When empty,
+	// the host key is accepted on first use.
+	KnownHostsPath string
+}
+
+// TokenAuth authenticates over HTTPS with the token held by 'ref', which must be exposed as an
+// environment variable. An empty username defaults to DefaultTokenUsername. The token is handed
+// to git by a credential helper, so it never appears in the command line or the remote URL.
+func TokenAuth(ref *secretsx.SecretRef, username string) *Auth {
+	if username == "" {
+		username = DefaultTokenUsername
+	}
+
+	return &Auth{Kind: AuthToken, Secret: ref, Username: username}
+}
+
+// SSHKeyAuth authenticates over SSH with the private key held by 'ref', which must be mounted as
+// a file. An empty knownHostsPath accepts the host key on first use.
+func SSHKeyAuth(ref *secretsx.SecretRef, knownHostsPath string) *Auth {
+	return &Auth{Kind: AuthSSHKey, Secret: ref, KnownHostsPath: knownHostsPath}
+}
+
+// validate checks that the credentials suit the remote 'remote'.
+func (a *Auth) validate(remote string) error {
+	if a.Secret == nil {
+		return errorsx.MissingField(builderName, "auth", "%s authentication requires a secret", a.Kind)
+	}
+
+	if err := a.Secret.Validate(); err != nil {
+		return err
+	}
+
+	switch a.Kind {
+	case AuthToken:
+		if a.Secret.Mount != secretsx.MountAsEnv || !envVarNameRegex.MatchString(a.Secret.Target) {
+			return errorsx.InvalidValue(builderName, "auth", a.Secret.Name,
+				"token secret %s must be exposed as an environment variable", a.Secret.Name)
+		}
+
+		if a.Username == "" || strings.ContainsAny(a.Username, " \t\n;&|<>()$`'\"\\") {
+			return errorsx.InvalidValue(builderName, "auth", a.Username, "invalid token username: %q", a.Username)
+		}
+
+		if !strings.HasPrefix(remote, "https://") {
+			return errorsx.ConflictingOptions(builderName, []string{"auth", "remote"},
+				"token authentication requires an https:// remote, got %q", remote)
+		}
+	case AuthSSHKey:
+		if a.Secret.Mount != secretsx.MountAsFile {
+			return errorsx.InvalidValue(builderName, "auth", a.Secret.Name,
+				"SSH key secret %s must be mounted as a file", a.Secret.Name)
+		}
+
+		if !strings.HasPrefix(remote, "ssh://") && !scpLikeURLRegex.MatchString(remote) {
+			return errorsx.ConflictingOptions(builderName, []string{"auth", "remote"},
+				"SSH key authentication requires an SSH remote, got %q", remote)
+		}
+	default:
+		return errorsx.Unsupported(builderName, "auth", a.Kind, "unsupported authentication: %q", a.Kind)
+	}
+
+	return nil
+}
+
+// config returns the git configuration overrides of the credentials.
+func (a *Auth) config() []string {
+	if a.Kind != AuthToken {
+		return nil
+	}
+
+	// The helper runs in a shell, which expands the token from the environment when git asks
+	// for credentials.
+	return []string{fmt.Sprintf(`credential.helper=!f() { echo username=%s; echo "password=$%s"; }; f`,
+		a.Username, a.Secret.Target)}
+}
+
+// env returns the environment variables of the credentials.
+func (a *Auth) env() []types.DaggerEnvVars {
+	if a.Kind != AuthSSHKey {
+		return nil
+	}
+
+	ssh := "ssh -i " + a.Secret.Target + " -o IdentitiesOnly=yes"
+	if a.KnownHostsPath != "" {
+		ssh += " -o StrictHostKeyChecking=yes -o UserKnownHostsFile=" + a.KnownHostsPath
+	} else {
+		ssh += " -o StrictHostKeyChecking=accept-new"
+	}
+
+	return []types.DaggerEnvVars{{Name: "GIT_SSH_COMMAND", Value: ssh}}
+}
+
+// PushBuilder generates the `git push` command of release refs.
+type PushBuilder struct {
+	// remote is the remote URL or name.
+	remote string
+
+	// repoPath is the path of the repository, passed as -C.
+	repoPath string
+
+	// refs are the pushed refspecs.
+	refs []string
+
+	// followTags pushes the annotated tags reachable from the pushed refs.
+	followTags bool
+
+	// forceWithLease replaces the remote refs if they match the local tracking refs.
+	forceWithLease bool
+
+	// atomic updates every remote ref or none.
+	atomic bool
+
+	// auth holds the credentials of the remote. It may be nil.
+	auth *Auth
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewPushBuilder creates a PushBuilder pushing to 'remote', a URL or a configured remote name.
+// Credentials require a URL.
+func NewPushBuilder(remote string) *PushBuilder {
+	return &PushBuilder{remote: remote}
+}
+
+// WithRepoPath sets the path of the repository.
+func (b *PushBuilder) WithRepoPath(path string) *PushBuilder {
+	b.repoPath = path
+	return b
+}
+
+// WithRefs adds refspecs to push (e.g. "main", "v1.3.0", "HEAD:refs/heads/release").
+func (b *PushBuilder) WithRefs(refs ...string) *PushBuilder {
+	b.refs = append(b.refs, refs...)
+	return b
+}
+
+// WithFollowTags also pushes the annotated tags reachable from the pushed refs.
+func (b *PushBuilder) WithFollowTags(follow bool) *PushBuilder {
+	b.followTags = follow
+	return b
+}
+
+// WithForceWithLease replaces remote refs that match the local remote-tracking refs.
+func (b *PushBuilder) WithForceWithLease(force bool) *PushBuilder {
+	b.forceWithLease = force
+	return b
+}
+
+// WithAtomic updates every remote ref or none, so a release commit is never pushed without its tag.
+func (b *PushBuilder) WithAtomic(atomic bool) *PushBuilder {
+	b.atomic = atomic
+	return b
+}
+
+// WithAuth sets the credentials of the remote.
+func (b *PushBuilder) WithAuth(auth *Auth) *PushBuilder {
+	b.auth = auth
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *PushBuilder) WithLogger(logger *slog.Logger) *PushBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks that the builder configuration is complete and consistent.
+func (b *PushBuilder) validate() error {
+	if b.remote == "" {
+		return errorsx.MissingField(builderName, "remote", "remote is required")
+	}
+
+	if u, err := url.Parse(b.remote); err == nil && u.User != nil && strings.HasPrefix(b.remote, "https://") {
+		return errorsx.InvalidValue(builderName, "remote", u.Redacted(),
+			"remote URL cannot embed credentials, use WithAuth")
+	}
+
+	if len(b.refs) == 0 && !b.followTags {
+		return errorsx.MissingField(builderName, "refs", "at least one ref is required")
+	}
+
+	if b.auth != nil {
+		return b.auth.validate(b.remote)
+	}
+
+	return nil
+}
+
+// BuildCommand generates the git push command. The credentials are provided by Env, Secrets and
+// Mounts.
+//
+// Returns:
+//   - The git push command.
+//   - An error if the remote or refs are missing, or the credentials do not suit the remote.
+func (b *PushBuilder) BuildCommand() ([]string, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	var config []string
+	if b.auth != nil {
+		config = b.auth.config()
+	}
+
+	cmd := append(gitCommand(b.repoPath, config...), "push")
+
+	if b.atomic {
+		cmd = append(cmd, "--atomic")
+	}
+
+	if b.followTags {
+		cmd = append(cmd, "--follow-tags")
+	}
+
+	if b.forceWithLease {
+		cmd = append(cmd, "--force-with-lease")
+	}
+
+	cmd = append(cmd, b.remote)
+	cmd = append(cmd, b.refs...)
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Env returns the environment variables of the credentials (GIT_SSH_COMMAND for SSH keys).
+func (b *PushBuilder) Env() []types.DaggerEnvVars {
+	if b.auth == nil {
+		return nil
+	}
+
+	return b.auth.env()
+}
+
+// Secrets returns the secret of the credentials, if any.
+func (b *PushBuilder) Secrets() []*secretsx.SecretRef {
+	if b.auth == nil || b.auth.Secret == nil {
+		return nil
+	}
+
+	return []*secretsx.SecretRef{b.auth.Secret}
+}
+
+// Mounts returns the mount of the SSH private key, if any.
+func (b *PushBuilder) Mounts() []types.Mount {
+	if b.auth == nil || b.auth.Secret == nil {
+		return nil
+	}
+
+	if m, ok := b.auth.Secret.MountRequirement(); ok {
+		return []types.Mount{m}
+	}
+
+	return nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *PushBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the git push command.
+func (b *PushBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("git push", b, NewPushBuilder(""), explainx.Generate("BuildCommand", b.BuildCommand))
+}
@@ -0,0 +1,233 @@
+package gitx
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// TagBuilder generates the `git tag` command of a release tag.
+type TagBuilder struct {
+	// name is the tag name.
+	name string
+
+	// message is the tag message. Setting it creates an annotated tag.
+	message string
+
+	// repoPath is the path of the repository, passed as -C.
+	repoPath string
+
+	// tagger is the tagger identity.
+	tagger Identity
+
+	// sign signs the tag with GPG.
+	sign bool
+
+	// keyID is the GPG key used to sign the tag. Empty uses the key of the tagger email.
+	keyID string
+
+	// sshSigningKey is the secret holding the SSH private key used to sign the tag.
+	sshSigningKey *secretsx.SecretRef
+
+	// ref is the commit the tag points at. Empty tags HEAD.
+	ref string
+
+	// force replaces an existing tag.
+	force bool
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewTagBuilder creates a TagBuilder creating the tag 'name'.
+func NewTagBuilder(name string) *TagBuilder {
+	return &TagBuilder{name: name}
+}
+
+// WithMessage sets the tag message, creating an annotated tag.
+func (b *TagBuilder) WithMessage(message string) *TagBuilder {
+	b.message = message
+	return b
+}
+
+// WithRepoPath sets the path of the repository.
+func (b *TagBuilder) WithRepoPath(path string) *TagBuilder {
+	b.repoPath = path
+	return b
+}
+
+// WithTagger sets the tagger identity.
+func (b *TagBuilder) WithTagger(tagger Identity) *TagBuilder {
+	b.tagger = tagger
+	return b
+}
+
+// WithSign signs the tag with GPG (git tag -s), using the key 'keyID' (git tag -u) when set.
+// The GPG keyring must be available in the container.
+func (b *TagBuilder) WithSign(keyID string) *TagBuilder {
+	b.sign = true
+	b.keyID = keyID
+	return b
+}
+
+// WithSSHSigningKey signs the tag with the SSH private key held by 'ref', which is mounted as a
+// file. It needs no GPG agent in the container.
+func (b *TagBuilder) WithSSHSigningKey(ref *secretsx.SecretRef) *TagBuilder {
+	b.sshSigningKey = ref
+	return b
+}
+
+// WithRef sets the commit the tag points at. The tag points at HEAD by default.
+func (b *TagBuilder) WithRef(ref string) *TagBuilder {
+	b.ref = ref
+	return b
+}
+
+// WithForce replaces an existing tag of the same name.
+func (b *TagBuilder) WithForce(force bool) *TagBuilder {
+	b.force = force
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *TagBuilder) WithLogger(logger *slog.Logger) *TagBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks that the builder configuration is complete and consistent.
+func (b *TagBuilder) validate() error {
+	if b.name == "" {
+		return errorsx.MissingField(builderName, "name", "tag name is required")
+	}
+
+	if strings.ContainsAny(b.name, " ~^:?*[\\") || strings.HasPrefix(b.name, "-") {
+		return errorsx.InvalidValue(builderName, "name", b.name, "invalid tag name: %q", b.name)
+	}
+
+	signed := b.sign || b.sshSigningKey != nil
+	if signed && strings.TrimSpace(b.message) == "" {
+		return errorsx.MissingField(builderName, "message", "signed tags require a message")
+	}
+
+	if b.sign && b.sshSigningKey != nil {
+		return errorsx.ConflictingOptions(builderName, []string{"sign", "sshSigningKey"},
+			"a tag is signed either with GPG or with an SSH key")
+	}
+
+	if b.sshSigningKey != nil {
+		if err := b.sshSigningKey.Validate(); err != nil {
+			return err
+		}
+
+		if b.sshSigningKey.Mount != secretsx.MountAsFile {
+			return errorsx.InvalidValue(builderName, "sshSigningKey", b.sshSigningKey.Name,
+				"SSH signing key %s must be mounted as a file", b.sshSigningKey.Name)
+		}
+	}
+
+	if b.message != "" || signed {
+		return b.tagger.validate("tagger")
+	}
+
+	return nil
+}
+
+// BuildCommand generates the git tag command. The tagger identity is provided by Env.
+//
+// Returns:
+//   - The git tag command.
+//   - An error if the name is invalid, or a signed tag has no message or tagger.
+func (b *TagBuilder) BuildCommand() ([]string, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	var config []string
+	if b.sshSigningKey != nil {
+		config = []string{"gpg.format=ssh", "user.signingkey=" + b.sshSigningKey.Target}
+	}
+
+	cmd := append(gitCommand(b.repoPath, config...), "tag")
+
+	switch {
+	case b.keyID != "":
+		cmd = append(cmd, "--local-user", b.keyID)
+	case b.sign || b.sshSigningKey != nil:
+		cmd = append(cmd, "--sign")
+	case b.message != "":
+		cmd = append(cmd, "--annotate")
+	}
+
+	if b.message != "" {
+		cmd = append(cmd, "--message", b.message)
+	}
+
+	if b.force {
+		cmd = append(cmd, "--force")
+	}
+
+	cmd = append(cmd, b.name)
+	if b.ref != "" {
+		cmd = append(cmd, b.ref)
+	}
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Env returns the GIT_COMMITTER_* environment variables of the tagger.
+func (b *TagBuilder) Env() []types.DaggerEnvVars {
+	if b.tagger.IsZero() {
+		return nil
+	}
+
+	return b.tagger.env("COMMITTER")
+}
+
+// Secrets returns the SSH signing key, if any.
+func (b *TagBuilder) Secrets() []*secretsx.SecretRef {
+	if b.sshSigningKey == nil {
+		return nil
+	}
+
+	return []*secretsx.SecretRef{b.sshSigningKey}
+}
+
+// Mounts returns the mount of the SSH signing key, if any.
+func (b *TagBuilder) Mounts() []types.Mount {
+	if b.sshSigningKey == nil {
+		return nil
+	}
+
+	if m, ok := b.sshSigningKey.MountRequirement(); ok {
+		return []types.Mount{m}
+	}
+
+	return nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *TagBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the git tag command.
+func (b *TagBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("git tag", b, NewTagBuilder(""), explainx.Generate("BuildCommand", b.BuildCommand))
+}
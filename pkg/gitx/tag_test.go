@@ -0,0 +1,102 @@
+package gitx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagBuilderBuildCommand(t *testing.T) {
+	signingKey := secretsx.FromEnv("signing-key", "SSH_SIGNING_KEY").AsFile("/run/secrets/signing-key")
+
+	tests := []struct {
+		name     string
+		builder  *TagBuilder
+		expected []string
+	}{
+		{
+			name:     "Lightweight",
+			builder:  NewTagBuilder("v1.3.0"),
+			expected: []string{"git", "tag", "v1.3.0"},
+		},
+		{
+			name:     "Annotated",
+			builder:  NewTagBuilder("v1.3.0").WithMessage("Release v1.3.0").WithTagger(bot).WithRef("abc123"),
+			expected: []string{"git", "tag", "--annotate", "--message", "Release v1.3.0", "v1.3.0", "abc123"},
+		},
+		{
+			name:     "GPG signed",
+			builder:  NewTagBuilder("v1.3.0").WithMessage("v1.3.0").WithTagger(bot).WithSign(""),
+			expected: []string{"git", "tag", "--sign", "--message", "v1.3.0", "v1.3.0"},
+		},
+		{
+			name:     "GPG signed with key",
+			builder:  NewTagBuilder("v1.3.0").WithMessage("v1.3.0").WithTagger(bot).WithSign("ABCD1234").WithForce(true),
+			expected: []string{"git", "tag", "--local-user", "ABCD1234", "--message", "v1.3.0", "--force", "v1.3.0"},
+		},
+		{
+			name:    "SSH signed",
+			builder: NewTagBuilder("v1.3.0").WithRepoPath("/src").WithMessage("v1.3.0").WithTagger(bot).WithSSHSigningKey(signingKey),
+			expected: []string{
+				"git", "-C", "/src", "-c", "gpg.format=ssh", "-c", "user.signingkey=/run/secrets/signing-key",
+				"tag", "--sign", "--message", "v1.3.0", "v1.3.0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cmd)
+		})
+	}
+
+	b := NewTagBuilder("v1.3.0").WithMessage("v1.3.0").WithTagger(bot).WithSSHSigningKey(signingKey)
+	assert.Equal(t, []types.DaggerEnvVars{
+		{Name: "GIT_COMMITTER_NAME", Value: "release-bot"},
+		{Name: "GIT_COMMITTER_EMAIL", Value: "release-bot@example.com"},
+	}, b.Env())
+	assert.Equal(t, []*secretsx.SecretRef{signingKey}, b.Secrets())
+	assert.Equal(t, []types.Mount{
+		{Kind: types.MountKindSecret, Source: "signing-key", Target: "/run/secrets/signing-key", ReadOnly: true},
+	}, b.Mounts())
+	assert.Nil(t, NewTagBuilder("v1").Env())
+}
+
+func TestTagBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *TagBuilder
+		kind    error
+	}{
+		{"Missing name", NewTagBuilder(""), errorsx.ErrMissingField},
+		{"Invalid name", NewTagBuilder("v1.3.0 rc"), errorsx.ErrInvalidValue},
+		{"Option-like name", NewTagBuilder("-d"), errorsx.ErrInvalidValue},
+		{"Signed without message", NewTagBuilder("v1").WithTagger(bot).WithSign(""), errorsx.ErrMissingField},
+		{"Annotated without tagger", NewTagBuilder("v1").WithMessage("v1"), errorsx.ErrMissingField},
+		{
+			"GPG and SSH signing",
+			NewTagBuilder("v1").WithMessage("v1").WithTagger(bot).WithSign("").
+				WithSSHSigningKey(secretsx.FromFile("key", "/run/key")),
+			errorsx.ErrConflictingOptions,
+		},
+		{
+			"SSH key as env",
+			NewTagBuilder("v1").WithMessage("v1").WithTagger(bot).WithSSHSigningKey(secretsx.FromEnv("key", "KEY")),
+			errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.BuildCommand()
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+		})
+	}
+}
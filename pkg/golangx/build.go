@@ -0,0 +1,456 @@
+package golangx
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/planx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the go builders in errorsx errors.
+const builderName = "go"
+
+// GoDefaultImage is the default container image providing the go toolchain.
+const GoDefaultImage = "golang:1.23-alpine"
+
+// GetBuildOutputDir returns the conventional directory of the built binaries inside the
+// container. If mntPrefix is empty, fixtures.MntPrefix is used.
+func GetBuildOutputDir(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, "dist")
+}
+
+// XFlag returns the linker flag setting the string variable 'symbol' (e.g. "main.version") to
+// 'value', quoting the value when it contains whitespace: with single quotes, or double quotes
+// when the value holds a single quote. The go command has no escapes in -ldflags, so a value
+// with whitespace and both quotes can't be set.
+func XFlag(symbol, value string) string {
+	if !strings.ContainsAny(value, " \t\n\r") {
+		return fmt.Sprintf("-X %s=%s", symbol, value)
+	}
+
+	if strings.Contains(value, "'") {
+		return fmt.Sprintf(`-X "%s=%s"`, symbol, value)
+	}
+
+	return fmt.Sprintf("-X '%s=%s'", symbol, value)
+}
+
+// BuildTarget is the build of the packages for one platform.
+type BuildTarget struct {
+	// Platform is the target platform ("os/arch[/variant]"), empty for the platform of the container.
+	Platform string
+	// Command is the go build command.
+	Command []string
+	// Env holds the GOOS, GOARCH, variant and CGO_ENABLED environment variables.
+	Env []types.DaggerEnvVars
+	// OutputPaths are the paths of the built binaries, in package order.
+	OutputPaths []string
+}
+
+// goVariable is a -X linker variable, kept in insertion order.
+type goVariable struct {
+	symbol string
+	value  string
+}
+
+// GoBuildBuilder generates the go build commands of main packages, for one or more target
+// platforms.
+type GoBuildBuilder struct {
+	// packages are the built main packages.
+	packages []string
+
+	// binaryName overrides the name of the binary of a single package.
+	binaryName string
+
+	// outputDir is the directory of the built binaries.
+	outputDir string
+
+	// platforms are the target platforms. Empty builds for the platform of the container.
+	platforms []string
+
+	// ldflags are additional linker flags.
+	ldflags []string
+
+	// variables are the -X linker variables.
+	variables []goVariable
+
+	// stripped omits the symbol table and debug information (-s -w).
+	stripped bool
+
+	// trimpath removes file system paths from the binaries.
+	trimpath bool
+
+	// tags are the build tags.
+	tags []string
+
+	// cgo enables or disables cgo. Nil keeps the toolchain default.
+	cgo *bool
+
+	// extraArgs are additional arguments appended before the packages.
+	extraArgs []string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewGoBuildBuilder creates a GoBuildBuilder building the main packages 'packages'
+// (e.g. "./cmd/api"). Binaries are written to GetBuildOutputDir("").
+func NewGoBuildBuilder(packages ...string) *GoBuildBuilder {
+	return &GoBuildBuilder{packages: packages, outputDir: GetBuildOutputDir("")}
+}
+
+// WithBinaryName sets the name of the binary. It requires a single package and is needed when
+// the package is the module root, e.g. ".".
+func (b *GoBuildBuilder) WithBinaryName(name string) *GoBuildBuilder {
+	b.binaryName = name
+	return b
+}
+
+// WithOutputDir sets the directory of the built binaries.
+func (b *GoBuildBuilder) WithOutputDir(dir string) *GoBuildBuilder {
+	b.outputDir = dir
+	return b
+}
+
+// WithPlatform adds target platforms (e.g. "linux/amd64", "linux/arm/v7"). Each platform gets
+// its own command and output subdirectory.
+func (b *GoBuildBuilder) WithPlatform(platforms ...string) *GoBuildBuilder {
+	b.platforms = append(b.platforms, platforms...)
+	return b
+}
+
+// WithLdflags adds linker flags.
+func (b *GoBuildBuilder) WithLdflags(flags ...string) *GoBuildBuilder {
+	b.ldflags = append(b.ldflags, flags...)
+	return b
+}
+
+// WithVariable sets the string variable 'symbol' (e.g. "main.version") at link time.
+func (b *GoBuildBuilder) WithVariable(symbol, value string) *GoBuildBuilder {
+	b.variables = append(b.variables, goVariable{symbol: symbol, value: value})
+	return b
+}
+
+// WithStripped omits the symbol table and debug information from the binaries (-s -w).
+func (b *GoBuildBuilder) WithStripped(stripped bool) *GoBuildBuilder {
+	b.stripped = stripped
+	return b
+}
+
+// WithTrimpath removes file system paths from the binaries, for reproducible builds.
+func (b *GoBuildBuilder) WithTrimpath(trimpath bool) *GoBuildBuilder {
+	b.trimpath = trimpath
+	return b
+}
+
+// WithTags adds build tags.
+func (b *GoBuildBuilder) WithTags(tags ...string) *GoBuildBuilder {
+	b.tags = append(b.tags, tags...)
+	return b
+}
+
+// WithCGO enables or disables cgo (CGO_ENABLED). Static binaries for distroless and apko images
+// are built with cgo disabled.
+func (b *GoBuildBuilder) WithCGO(enabled bool) *GoBuildBuilder {
+	b.cgo = &enabled
+	return b
+}
+
+// WithExtraArg adds an argument appended before the packages.
+func (b *GoBuildBuilder) WithExtraArg(arg string) *GoBuildBuilder {
+	b.extraArgs = append(b.extraArgs, arg)
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *GoBuildBuilder) WithLogger(logger *slog.Logger) *GoBuildBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks that the builder configuration is complete and consistent.
+func (b *GoBuildBuilder) validate() error {
+	if len(b.packages) == 0 {
+		return errorsx.MissingField(builderName, "packages", "at least one package is required")
+	}
+
+	if b.outputDir == "" {
+		return errorsx.MissingField(builderName, "outputDir", "output directory is required")
+	}
+
+	if b.binaryName != "" && len(b.packages) > 1 {
+		return errorsx.ConflictingOptions(builderName, []string{"binaryName", "packages"},
+			"a binary name requires a single package")
+	}
+
+	seen := map[string]bool{}
+	for _, pkg := range b.packages {
+		if strings.Contains(pkg, "...") {
+			return errorsx.InvalidValue(builderName, "packages", pkg, "package patterns are not supported: %q", pkg)
+		}
+
+		name := b.binary(pkg)
+		if name == "" {
+			return errorsx.MissingField(builderName, "binaryName", "package %q requires a binary name", pkg)
+		}
+
+		if seen[name] {
+			return errorsx.ConflictingOptions(builderName, []string{"packages"}, "packages build the same binary %q", name)
+		}
+		seen[name] = true
+	}
+
+	for _, p := range b.platforms {
+		if _, err := platformEnv(p); err != nil {
+			return err
+		}
+	}
+
+	return validateVariables(b.variables)
+}
+
+// binary returns the binary name of the package 'pkg': the binary name override, or the last
+// element of the package path. It is empty for the module root.
+func (b *GoBuildBuilder) binary(pkg string) string {
+	if b.binaryName != "" {
+		return b.binaryName
+	}
+
+	name := path.Base(strings.TrimSuffix(pkg, "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+
+	return name
+}
+
+// ldflagsValue returns the -ldflags value, empty when no linker flag is set.
+func (b *GoBuildBuilder) ldflagsValue() string {
+	var flags []string
+	if b.stripped {
+		flags = append(flags, "-s", "-w")
+	}
+
+	flags = append(flags, b.ldflags...)
+
+	for _, v := range b.variables {
+		flags = append(flags, XFlag(v.symbol, v.value))
+	}
+
+	return strings.Join(flags, " ")
+}
+
+// target generates the build of the platform 'platform', empty for the container platform.
+func (b *GoBuildBuilder) target(platform string) (BuildTarget, error) {
+	env, err := platformEnv(platform)
+	if err != nil {
+		return BuildTarget{}, err
+	}
+
+	if b.cgo != nil {
+		if *b.cgo {
+			env = append(env, WithGoCgoEnabled())
+		} else {
+			env = append(env, WithGoCgoDisabled())
+		}
+	}
+
+	dir := b.outputDir
+	if platform != "" {
+		dir = filepath.Join(dir, strings.ReplaceAll(platform, "/", "_"))
+	}
+
+	suffix := ""
+	if strings.HasPrefix(platform, "windows/") {
+		suffix = ".exe"
+	}
+
+	var outputs []string
+	for _, pkg := range b.packages {
+		outputs = append(outputs, filepath.Join(dir, b.binary(pkg)+suffix))
+	}
+
+	cmd := []string{"go", "build"}
+
+	if b.trimpath {
+		cmd = append(cmd, "-trimpath")
+	}
+
+	if len(b.tags) > 0 {
+		cmd = append(cmd, "-tags", strings.Join(b.tags, ","))
+	}
+
+	if ldflags := b.ldflagsValue(); ldflags != "" {
+		cmd = append(cmd, "-ldflags", ldflags)
+	}
+
+	// A single package is written to its binary path; several packages to the directory, which
+	// go build expresses with a trailing separator.
+	output := outputs[0]
+	if len(b.packages) > 1 {
+		output = dir + "/"
+	}
+
+	cmd = append(cmd, "-o", output)
+	cmd = append(cmd, b.extraArgs...)
+	cmd = append(cmd, b.packages...)
+
+	return BuildTarget{Platform: platform, Command: cmd, Env: env, OutputPaths: outputs}, nil
+}
+
+// Targets generates one build per target platform, or a single build for the platform of the
+// container when no platform is set.
+//
+// Returns:
+//   - The builds, in platform order.
+//   - An error if no package is set, a binary name cannot be derived, a platform is invalid, or a
+//     linker variable can't be quoted.
+func (b *GoBuildBuilder) Targets() ([]BuildTarget, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	platforms := b.platforms
+	if len(platforms) == 0 {
+		platforms = []string{""}
+	}
+
+	var targets []BuildTarget
+	for _, p := range platforms {
+		t, err := b.target(p)
+		if err != nil {
+			return nil, err
+		}
+
+		logx.Command(ctx, b.logger, builderName, t.Command)
+		targets = append(targets, t)
+	}
+
+	return targets, nil
+}
+
+// BuildCommand generates the go build command of a single target. The target environment is
+// provided by Targets.
+//
+// Returns:
+//   - The go build command.
+//   - An error if the configuration is invalid or several platforms are set (see Targets and Plan).
+func (b *GoBuildBuilder) BuildCommand() ([]string, error) {
+	if len(b.platforms) > 1 {
+		return nil, errorsx.ConflictingOptions(builderName, []string{"platforms"},
+			"several platforms require one build per platform, use Targets or Plan instead")
+	}
+
+	targets, err := b.Targets()
+	if err != nil {
+		return nil, err
+	}
+
+	return targets[0].Command, nil
+}
+
+// Plan generates a plan building each target platform in its own task. Task IDs are the
+// platforms with dashes, e.g. "linux-arm64", or "native" without platform.
+//
+// Returns:
+//   - The build plan.
+//   - An error if the configuration is invalid or a platform is repeated.
+func (b *GoBuildBuilder) Plan(name string) (*planx.Plan, error) {
+	targets, err := b.Targets()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := planx.NewPlan(name)
+	for _, t := range targets {
+		id := "native"
+		if t.Platform != "" {
+			id = strings.ReplaceAll(t.Platform, "/", "-")
+		}
+
+		if err := plan.AddTask(planx.Task{ID: id, Command: t.Command, Env: t.Env}); err != nil {
+			return nil, errorsx.ConflictingOptions(builderName, []string{"platforms"}, "%v", err)
+		}
+	}
+
+	return plan, nil
+}
+
+// platformEnv returns the GOOS, GOARCH and variant environment variables of 'platform'. The arm
+// variant ("v7") sets GOARM ("7") and the amd64 variant ("v3") sets GOAMD64.
+func platformEnv(platform string) ([]types.DaggerEnvVars, error) {
+	if platform == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return nil, errorsx.InvalidValue(builderName, "platforms", platform, "platform must be os/arch[/variant]: %q", platform)
+	}
+
+	env := []types.DaggerEnvVars{
+		{Name: "GOOS", Value: parts[0]},
+		{Name: "GOARCH", Value: parts[1]},
+	}
+
+	if len(parts) == 2 {
+		return env, nil
+	}
+
+	switch variant := parts[2]; parts[1] {
+	case "arm":
+		env = append(env, types.DaggerEnvVars{Name: "GOARM", Value: strings.TrimPrefix(variant, "v")})
+	case "amd64":
+		env = append(env, types.DaggerEnvVars{Name: "GOAMD64", Value: variant})
+	case "arm64":
+		// "linux/arm64/v8" is how OCI platforms spell the arm64 baseline.
+		if variant != "v8" {
+			return nil, errorsx.Unsupported(builderName, "platforms", platform, "variant of arm64 is not supported: %q", platform)
+		}
+	default:
+		return nil, errorsx.Unsupported(builderName, "platforms", platform, "variant of %s is not supported: %q", parts[1], platform)
+	}
+
+	return env, nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *GoBuildBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *GoBuildBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *GoBuildBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the go build command.
+func (b *GoBuildBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("go build", b, NewGoBuildBuilder(), explainx.Generate("BuildCommand", b.BuildCommand))
+}
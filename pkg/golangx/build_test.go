@@ -0,0 +1,153 @@
+package golangx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/planx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoBuildBuilderTargets(t *testing.T) {
+	b := NewGoBuildBuilder("./cmd/api").
+		WithPlatform("linux/amd64", "linux/arm/v7", "windows/amd64").
+		WithTrimpath(true).
+		WithStripped(true).
+		WithTags("netgo", "osusergo").
+		WithVariable("main.version", "1.3.0").
+		WithVariable("main.commit", "abc123").
+		WithCGO(false)
+
+	targets, err := b.Targets()
+	require.NoError(t, err)
+	require.Len(t, targets, 3)
+
+	ldflags := "-s -w -X main.version=1.3.0 -X main.commit=abc123"
+	assert.Equal(t, BuildTarget{
+		Platform: "linux/amd64",
+		Command: []string{
+			"go", "build", "-trimpath", "-tags", "netgo,osusergo", "-ldflags", ldflags,
+			"-o", "/mnt/dist/linux_amd64/api", "./cmd/api",
+		},
+		Env: []types.DaggerEnvVars{
+			{Name: "GOOS", Value: "linux"},
+			{Name: "GOARCH", Value: "amd64"},
+			{Name: "CGO_ENABLED", Value: "0"},
+		},
+		OutputPaths: []string{"/mnt/dist/linux_amd64/api"},
+	}, targets[0])
+
+	assert.Equal(t, []types.DaggerEnvVars{
+		{Name: "GOOS", Value: "linux"},
+		{Name: "GOARCH", Value: "arm"},
+		{Name: "GOARM", Value: "7"},
+		{Name: "CGO_ENABLED", Value: "0"},
+	}, targets[1].Env)
+	assert.Equal(t, []string{"/mnt/dist/linux_arm_v7/api"}, targets[1].OutputPaths)
+	assert.Equal(t, []string{"/mnt/dist/windows_amd64/api.exe"}, targets[2].OutputPaths)
+
+	_, err = b.BuildCommand()
+	assert.True(t, errors.Is(err, errorsx.ErrConflictingOptions))
+
+	plan, err := b.Plan("binaries")
+	require.NoError(t, err)
+	ids := []string{}
+	for _, task := range plan.Tasks() {
+		ids = append(ids, task.ID)
+	}
+	assert.Equal(t, []string{"linux-amd64", "linux-arm-v7", "windows-amd64"}, ids)
+}
+
+func TestGoBuildBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  *GoBuildBuilder
+		expected []string
+		outputs  []string
+	}{
+		{
+			name:     "Native",
+			builder:  NewGoBuildBuilder("./cmd/api"),
+			expected: []string{"go", "build", "-o", "/mnt/dist/api", "./cmd/api"},
+			outputs:  []string{"/mnt/dist/api"},
+		},
+		{
+			name:     "Module root",
+			builder:  NewGoBuildBuilder(".").WithBinaryName("app").WithOutputDir("bin").WithLdflags("-extldflags=-static"),
+			expected: []string{"go", "build", "-ldflags", "-extldflags=-static", "-o", "bin/app", "."},
+			outputs:  []string{"bin/app"},
+		},
+		{
+			name: "Several packages",
+			builder: NewGoBuildBuilder("./cmd/api", "github.com/example/app/cmd/worker").
+				WithPlatform("linux/arm64/v8").
+				WithVariable("main.banner", "hello world").
+				WithExtraArg("-buildvcs=false"),
+			expected: []string{
+				"go", "build", "-ldflags", "-X 'main.banner=hello world'", "-o", "/mnt/dist/linux_arm64_v8/",
+				"-buildvcs=false", "./cmd/api", "github.com/example/app/cmd/worker",
+			},
+			outputs: []string{"/mnt/dist/linux_arm64_v8/api", "/mnt/dist/linux_arm64_v8/worker"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cmd)
+
+			targets, err := tt.builder.Targets()
+			require.NoError(t, err)
+			assert.Equal(t, tt.outputs, targets[0].OutputPaths)
+		})
+	}
+
+	plan, err := NewGoBuildBuilder("./cmd/api").WithCGO(true).Plan("native")
+	require.NoError(t, err)
+	assert.Equal(t, []planx.Task{{
+		ID:      "native",
+		Command: types.DaggerCMD{"go", "build", "-o", "/mnt/dist/api", "./cmd/api"},
+		Env:     []types.DaggerEnvVars{{Name: "CGO_ENABLED", Value: "1"}},
+	}}, plan.Tasks())
+}
+
+func TestGoBuildBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *GoBuildBuilder
+		kind    error
+	}{
+		{"Missing packages", NewGoBuildBuilder(), errorsx.ErrMissingField},
+		{"Missing output dir", NewGoBuildBuilder("./cmd/api").WithOutputDir(""), errorsx.ErrMissingField},
+		{"Module root without name", NewGoBuildBuilder("."), errorsx.ErrMissingField},
+		{"Binary name with packages", NewGoBuildBuilder("./cmd/api", "./cmd/worker").WithBinaryName("app"), errorsx.ErrConflictingOptions},
+		{"Duplicate binaries", NewGoBuildBuilder("./cmd/api", "./internal/api"), errorsx.ErrConflictingOptions},
+		{"Package pattern", NewGoBuildBuilder("./cmd/..."), errorsx.ErrInvalidValue},
+		{"Invalid platform", NewGoBuildBuilder("./cmd/api").WithPlatform("linux"), errorsx.ErrInvalidValue},
+		{"Unsupported variant", NewGoBuildBuilder("./cmd/api").WithPlatform("linux/riscv64/v1"), errorsx.ErrUnsupported},
+		{"Duplicate platform", NewGoBuildBuilder("./cmd/api").WithPlatform("linux/amd64", "linux/amd64"), errorsx.ErrConflictingOptions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Plan("build")
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+		})
+	}
+}
+
+func TestXFlag(t *testing.T) {
+	assert.Equal(t, "-X main.version=1.3.0", XFlag("main.version", "1.3.0"))
+	assert.Equal(t, "-X 'main.banner=hello world'", XFlag("main.banner", "hello world"))
+	assert.Equal(t, `-X "main.banner=it's here"`, XFlag("main.banner", "it's here"))
+	assert.Equal(t, "-X main.banner=it's", XFlag("main.banner", "it's"))
+}
+
+func TestGetBuildOutputDir(t *testing.T) {
+	assert.Equal(t, "/mnt/dist", GetBuildOutputDir(""))
+	assert.Equal(t, "/work/dist", GetBuildOutputDir("/work"))
+}
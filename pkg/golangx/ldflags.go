@@ -0,0 +1,129 @@
+package golangx
+
+import (
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/gitmetax"
+	"github.com/Excoriate/daggerx/pkg/timex"
+)
+
+// DefaultBuildInfoPackage is the package of the build metadata variables by default.
+const DefaultBuildInfoPackage = "main"
+
+// BuildInfo holds the metadata injected into binaries at link time, into the string variables
+// version, commit, date and builtBy of a package, as goreleaser does.
+type BuildInfo struct {
+	// Version is the version of the build (e.g. "v1.3.0", or "abc1234-dirty").
+	Version string
+	// Commit is the commit sha of the build.
+	Commit string
+	// Date is the date of the build, in RFC 3339.
+	Date string
+	// BuiltBy identifies what built the binary (e.g. "dagger").
+	BuiltBy string
+}
+
+// BuildInfoFromGit returns the build metadata of the checkout 'meta': its version (see
+// gitmetax.Metadata.Version), commit sha and commit date, so rebuilds of a commit produce the
+// same binary, built by 'builtBy'.
+func BuildInfoFromGit(meta *gitmetax.Metadata, builtBy string) BuildInfo {
+	if meta == nil {
+		return BuildInfo{BuiltBy: builtBy}
+	}
+
+	info := BuildInfo{Version: meta.Version(), Commit: meta.SHA, BuiltBy: builtBy}
+	if !meta.CommitTime.IsZero() {
+		info.Date = timex.RFC3339(meta.CommitTime)
+	}
+
+	return info
+}
+
+// variables returns the linker variables of the metadata in the package 'pkg'
+// (DefaultBuildInfoPackage when empty), skipping empty values.
+func (i BuildInfo) variables(pkg string) []goVariable {
+	if pkg == "" {
+		pkg = DefaultBuildInfoPackage
+	}
+
+	var vars []goVariable
+	for _, v := range []goVariable{
+		{symbol: "version", value: i.Version},
+		{symbol: "commit", value: i.Commit},
+		{symbol: "date", value: i.Date},
+		{symbol: "builtBy", value: i.BuiltBy},
+	} {
+		if v.value != "" {
+			vars = append(vars, goVariable{symbol: pkg + "." + v.symbol, value: v.value})
+		}
+	}
+
+	return vars
+}
+
+// XFlags returns the -X linker flags setting the metadata in the package 'pkg' (e.g.
+// "github.com/acme/app/internal/version"; DefaultBuildInfoPackage when empty). Empty values are
+// skipped.
+//
+// Returns:
+//   - The linker flags, each quoted for the go command (see XFlag).
+//   - An error if a value can't be quoted for the go command.
+func (i BuildInfo) XFlags(pkg string) ([]string, error) {
+	vars := i.variables(pkg)
+	if err := validateVariables(vars); err != nil {
+		return nil, err
+	}
+
+	flags := make([]string, len(vars))
+	for n, v := range vars {
+		flags[n] = XFlag(v.symbol, v.value)
+	}
+
+	return flags, nil
+}
+
+// Ldflags returns the -ldflags value setting the metadata in the package 'pkg'. See XFlags.
+func (i BuildInfo) Ldflags(pkg string) (string, error) {
+	flags, err := i.XFlags(pkg)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(flags, " "), nil
+}
+
+// WithBuildInfo sets the build metadata 'info' at link time, in the package 'pkg'
+// (DefaultBuildInfoPackage when empty). Empty values are skipped.
+func (b *GoBuildBuilder) WithBuildInfo(info BuildInfo, pkg string) *GoBuildBuilder {
+	b.variables = append(b.variables, info.variables(pkg)...)
+	return b
+}
+
+// validateVariables checks that the values of 'vars' can be quoted for the go command, which
+// doesn't support escapes in -ldflags.
+func validateVariables(vars []goVariable) error {
+	for _, v := range vars {
+		if strings.ContainsAny(v.value, " \t\n\r") && strings.Contains(v.value, "'") &&
+			strings.Contains(v.value, `"`) {
+			return errorsx.InvalidValue(builderName, "variables", v.value,
+				"value of %s holds whitespace and both quotes, which -ldflags can't express", v.symbol)
+		}
+	}
+
+	return nil
+}
+
+// ShellCommand renders 'cmd' as a command line for `sh -c`, single-quoting the arguments when
+// needed, so quoted -ldflags values reach the go command unchanged (e.g. to chain the build with
+// other commands in a container).
+func ShellCommand(cmd []string) string {
+	return cmdx.ShellJoin(cmd)
+}
+
+// ShellCommand returns the `sh -c` command running the build of the target. The target
+// environment still comes from Env.
+func (t BuildTarget) ShellCommand() []string {
+	return []string{"sh", "-c", ShellCommand(t.Command)}
+}
@@ -0,0 +1,109 @@
+package golangx
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/gitmetax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildInfoFromGit(t *testing.T) {
+	meta := &gitmetax.Metadata{
+		SHA:        "abc1234def",
+		ShortSHA:   "abc1234",
+		Tag:        "v1.3.0",
+		CommitTime: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	assert.Equal(t, BuildInfo{
+		Version: "v1.3.0",
+		Commit:  "abc1234def",
+		Date:    "2024-05-01T12:00:00Z",
+		BuiltBy: "dagger",
+	}, BuildInfoFromGit(meta, "dagger"))
+
+	assert.Equal(t, BuildInfo{BuiltBy: "dagger"}, BuildInfoFromGit(nil, "dagger"))
+}
+
+func TestBuildInfoLdflags(t *testing.T) {
+	tests := []struct {
+		name     string
+		info     BuildInfo
+		pkg      string
+		expected string
+	}{
+		{
+			name:     "Default package",
+			info:     BuildInfo{Version: "v1.3.0", Commit: "abc1234", Date: "2024-05-01T12:00:00Z", BuiltBy: "dagger"},
+			expected: "-X main.version=v1.3.0 -X main.commit=abc1234 -X main.date=2024-05-01T12:00:00Z -X main.builtBy=dagger",
+		},
+		{
+			name:     "Skips empty values",
+			info:     BuildInfo{Version: "abc1234-dirty"},
+			pkg:      "github.com/acme/app/internal/version",
+			expected: "-X github.com/acme/app/internal/version.version=abc1234-dirty",
+		},
+		{
+			name:     "Quotes whitespace",
+			info:     BuildInfo{BuiltBy: "ci runner"},
+			expected: "-X 'main.builtBy=ci runner'",
+		},
+		{
+			name:     "Quotes whitespace and single quotes",
+			info:     BuildInfo{BuiltBy: "bob's runner"},
+			expected: `-X "main.builtBy=bob's runner"`,
+		},
+		{
+			name:     "Empty",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ldflags, err := tt.info.Ldflags(tt.pkg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ldflags)
+		})
+	}
+
+	_, err := BuildInfo{BuiltBy: `bob's "runner"`}.Ldflags("")
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+}
+
+func TestGoBuildBuilderWithBuildInfo(t *testing.T) {
+	b := NewGoBuildBuilder("./cmd/api").
+		WithStripped(true).
+		WithBuildInfo(BuildInfo{Version: "v1.3.0", BuiltBy: "bob's runner"}, "")
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"go", "build", "-ldflags", `-s -w -X main.version=v1.3.0 -X "main.builtBy=bob's runner"`,
+		"-o", "/mnt/dist/api", "./cmd/api",
+	}, cmd)
+
+	_, err = NewGoBuildBuilder("./cmd/api").WithBuildInfo(BuildInfo{Version: `a 'b' "c"`}, "").BuildCommand()
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+}
+
+func TestShellCommand(t *testing.T) {
+	cmd := []string{"go", "build", "-ldflags", `-X main.version=v1 -X "main.builtBy=bob's runner"`, "./cmd/api"}
+
+	line := ShellCommand(cmd)
+	assert.Equal(t, `go build -ldflags '-X main.version=v1 -X "main.builtBy=bob'\''s runner"' ./cmd/api`, line)
+	assert.Equal(t, []string{"sh", "-c", line}, BuildTarget{Command: cmd}.ShellCommand())
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	out, err := exec.Command("sh", "-c", `printf '%s\n' `+ShellCommand(cmd[3:4])).Output()
+	require.NoError(t, err)
+	assert.Equal(t, cmd[3]+"\n", string(out))
+}
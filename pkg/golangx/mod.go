@@ -0,0 +1,145 @@
+package golangx
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Excoriate/daggerx/pkg/builderx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+const (
+	// ModCacheVolumeKey is the cache volume key of the module cache (GOMODCACHE).
+	ModCacheVolumeKey = "go-mod"
+	// BuildCacheVolumeKey is the cache volume key of the build cache (GOCACHE).
+	BuildCacheVolumeKey = "go-build"
+)
+
+// CacheMounts returns the cache volumes of the module cache and the build cache, mounted at
+// fixtures.GoCacheModPathDefault and fixtures.GoCacheBuildPathDefault.
+func CacheMounts() []types.Mount {
+	return []types.Mount{
+		{Kind: types.MountKindCache, Source: ModCacheVolumeKey, Target: fixtures.GoCacheModPathDefault},
+		{Kind: types.MountKindCache, Source: BuildCacheVolumeKey, Target: fixtures.GoCacheBuildPathDefault},
+	}
+}
+
+// CacheEnv returns the GOMODCACHE and GOCACHE environment variables pointing at CacheMounts.
+func CacheEnv() []types.DaggerEnvVars {
+	return []types.DaggerEnvVars{
+		{Name: "GOMODCACHE", Value: fixtures.GoCacheModPathDefault},
+		{Name: "GOCACHE", Value: fixtures.GoCacheBuildPathDefault},
+	}
+}
+
+// GoModBuilder generates the go mod commands of the standard CI hygiene stages: downloading and
+// verifying the dependencies, and checking that go.mod and go.sum are tidy. Commands run in the
+// module directory.
+type GoModBuilder struct {
+	// tidyWithGitDiff checks tidiness with `go mod tidy` and `git diff` instead of `go mod tidy -diff`.
+	tidyWithGitDiff bool
+
+	// modules are the modules downloaded by DownloadCommand. Empty downloads every dependency.
+	modules []string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+var _ builderx.Builder = (*GoModBuilder)(nil)
+
+// NewGoModBuilder creates a GoModBuilder.
+func NewGoModBuilder() *GoModBuilder {
+	return &GoModBuilder{}
+}
+
+// WithModules restricts DownloadCommand to 'modules' (e.g. "golang.org/x/sync@v0.8.0").
+func (b *GoModBuilder) WithModules(modules ...string) *GoModBuilder {
+	b.modules = append(b.modules, modules...)
+	return b
+}
+
+// WithTidyGitDiff checks tidiness by running `go mod tidy` and `git diff`, for toolchains older
+// than go 1.23, which lack `go mod tidy -diff`. It requires git and the repository in the container.
+func (b *GoModBuilder) WithTidyGitDiff(enabled bool) *GoModBuilder {
+	b.tidyWithGitDiff = enabled
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *GoModBuilder) WithLogger(logger *slog.Logger) *GoModBuilder {
+	b.logger = logger
+	return b
+}
+
+// log logs and returns 'cmd'.
+func (b *GoModBuilder) log(cmd []string) []string {
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+	return cmd
+}
+
+// DownloadCommand generates the `go mod download` command, warming the module cache.
+//
+// Returns:
+//   - The go mod download command.
+//   - An error if a module is empty.
+func (b *GoModBuilder) DownloadCommand() ([]string, error) {
+	for _, m := range b.modules {
+		if m == "" {
+			return nil, errorsx.InvalidValue(builderName, "modules", m, "module cannot be empty")
+		}
+	}
+
+	return b.log(append([]string{"go", "mod", "download"}, b.modules...)), nil
+}
+
+// VerifyCommand generates the `go mod verify` command, checking that the cached dependencies
+// match go.sum.
+func (b *GoModBuilder) VerifyCommand() []string {
+	return b.log([]string{"go", "mod", "verify"})
+}
+
+// TidyCheckCommand generates a command failing when go.mod or go.sum are not tidy:
+// `go mod tidy -diff`, or `go mod tidy` followed by `git diff --exit-code` with WithTidyGitDiff.
+func (b *GoModBuilder) TidyCheckCommand() []string {
+	if b.tidyWithGitDiff {
+		return b.log([]string{"sh", "-c", "go mod tidy && git diff --exit-code -- go.mod go.sum"})
+	}
+
+	return b.log([]string{"go", "mod", "tidy", "-diff"})
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *GoModBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// BuildCommand generates the go mod download command, as DownloadCommand does.
+func (b *GoModBuilder) BuildCommand() ([]string, error) {
+	return b.DownloadCommand()
+}
+
+// Mounts returns the cache volumes the download warms: CacheMounts.
+func (b *GoModBuilder) Mounts() []types.Mount {
+	return CacheMounts()
+}
+
+// Env returns the environment variables pointing go at the cache volumes: CacheEnv.
+func (b *GoModBuilder) Env() []types.DaggerEnvVars {
+	return CacheEnv()
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the go mod download command.
+func (b *GoModBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("go mod", b, NewGoModBuilder(), explainx.Generate("DownloadCommand", b.DownloadCommand))
+}
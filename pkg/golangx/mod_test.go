@@ -0,0 +1,44 @@
+package golangx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoModBuilder(t *testing.T) {
+	b := NewGoModBuilder()
+
+	cmd, err := b.DownloadCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go", "mod", "download"}, cmd)
+
+	assert.Equal(t, []string{"go", "mod", "verify"}, b.VerifyCommand())
+	assert.Equal(t, []string{"go", "mod", "tidy", "-diff"}, b.TidyCheckCommand())
+
+	b.WithTidyGitDiff(true).WithModules("golang.org/x/sync@v0.8.0")
+	assert.Equal(t, []string{"sh", "-c", "go mod tidy && git diff --exit-code -- go.mod go.sum"}, b.TidyCheckCommand())
+
+	cmd, err = b.DownloadCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go", "mod", "download", "golang.org/x/sync@v0.8.0"}, cmd)
+
+	_, err = NewGoModBuilder().WithModules("").DownloadCommand()
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+}
+
+func TestCacheMounts(t *testing.T) {
+	assert.Equal(t, []types.Mount{
+		{Kind: types.MountKindCache, Source: ModCacheVolumeKey, Target: "/go/pkg/mod"},
+		{Kind: types.MountKindCache, Source: BuildCacheVolumeKey, Target: "/go/build-cache"},
+	}, CacheMounts())
+
+	assert.Equal(t, []types.DaggerEnvVars{
+		{Name: "GOMODCACHE", Value: "/go/pkg/mod"},
+		{Name: "GOCACHE", Value: "/go/build-cache"},
+	}, CacheEnv())
+}
@@ -6,6 +6,12 @@
 // The primary function in this package is IsGoModule, which checks if a given
 // path contains a Go module by looking for the presence of a go.mod file.
 //
+// GoBuildBuilder generates the go build commands of main packages, with one
+// command, environment and set of output paths per target platform. BuildInfo
+// injects the version, commit, date and builder of the build (e.g. from
+// gitmetax) with -X linker flags, and ShellCommand renders the commands with
+// their quoted -ldflags for `sh -c`.
+//
 // Example usage:
 //
 //	package main
@@ -0,0 +1,178 @@
+package golangx
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// GovulncheckPackage is the package of govulncheck, run with `go run` when a version is set.
+const GovulncheckPackage = "golang.org/x/vuln/cmd/govulncheck"
+
+// VulncheckFormat is a govulncheck output format.
+type VulncheckFormat string
+
+const (
+	// VulncheckText is the human-readable output. It is the default.
+	VulncheckText VulncheckFormat = "text"
+	// VulncheckJSON is the JSON message stream.
+	VulncheckJSON VulncheckFormat = "json"
+	// VulncheckSARIF is the SARIF report.
+	VulncheckSARIF VulncheckFormat = "sarif"
+	// VulncheckOpenVEX is the OpenVEX document.
+	VulncheckOpenVEX VulncheckFormat = "openvex"
+)
+
+// VulncheckBuilder generates the govulncheck command of a module or binary.
+type VulncheckBuilder struct {
+	// patterns are the scanned package patterns, or the binary path in binary mode.
+	patterns []string
+
+	// binary scans a compiled binary instead of the source.
+	binary bool
+
+	// format is the output format.
+	format VulncheckFormat
+
+	// tags are the build tags of the source scan.
+	tags []string
+
+	// version runs govulncheck with `go run GovulncheckPackage@version` when set.
+	version string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewVulncheckBuilder creates a VulncheckBuilder scanning the package patterns 'patterns' of the
+// module in the working directory. No pattern scans "./...".
+func NewVulncheckBuilder(patterns ...string) *VulncheckBuilder {
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	return &VulncheckBuilder{patterns: patterns}
+}
+
+// NewBinaryVulncheckBuilder creates a VulncheckBuilder scanning the compiled binary at 'path'.
+func NewBinaryVulncheckBuilder(path string) *VulncheckBuilder {
+	return &VulncheckBuilder{patterns: []string{path}, binary: true}
+}
+
+// WithFormat sets the output format.
+func (b *VulncheckBuilder) WithFormat(format VulncheckFormat) *VulncheckBuilder {
+	b.format = format
+	return b
+}
+
+// WithTags adds build tags to the source scan.
+func (b *VulncheckBuilder) WithTags(tags ...string) *VulncheckBuilder {
+	b.tags = append(b.tags, tags...)
+	return b
+}
+
+// WithVersion runs the govulncheck version 'version' (e.g. "v1.1.3" or "latest") with `go run`,
+// so the container needs only the go toolchain.
+func (b *VulncheckBuilder) WithVersion(version string) *VulncheckBuilder {
+	b.version = version
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *VulncheckBuilder) WithLogger(logger *slog.Logger) *VulncheckBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks that the builder configuration is consistent.
+func (b *VulncheckBuilder) validate() error {
+	switch b.format {
+	case "", VulncheckText, VulncheckJSON, VulncheckSARIF, VulncheckOpenVEX:
+	default:
+		return errorsx.Unsupported(builderName, "format", b.format, "unsupported govulncheck format: %q", b.format)
+	}
+
+	for _, p := range b.patterns {
+		if p == "" {
+			return errorsx.MissingField(builderName, "patterns", "govulncheck target cannot be empty")
+		}
+	}
+
+	if b.binary && len(b.tags) > 0 {
+		return errorsx.ConflictingOptions(builderName, []string{"binary", "tags"}, "build tags only apply to source scans")
+	}
+
+	if strings.ContainsAny(b.version, "@ ") {
+		return errorsx.InvalidValue(builderName, "version", b.version, "invalid govulncheck version: %q", b.version)
+	}
+
+	return nil
+}
+
+// BuildCommand generates the govulncheck command. With the text format, govulncheck exits
+// non-zero when vulnerable code is reachable; the other formats always exit zero.
+//
+// Returns:
+//   - The govulncheck command.
+//   - An error if the format is unsupported or a target is empty.
+func (b *VulncheckBuilder) BuildCommand() ([]string, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"govulncheck"}
+	if b.version != "" {
+		cmd = []string{"go", "run", GovulncheckPackage + "@" + b.version}
+	}
+
+	if b.binary {
+		cmd = append(cmd, "-mode", "binary")
+	}
+
+	if b.format != "" {
+		cmd = append(cmd, "-format", string(b.format))
+	}
+
+	if len(b.tags) > 0 {
+		cmd = append(cmd, "-tags", strings.Join(b.tags, ","))
+	}
+
+	cmd = append(cmd, b.patterns...)
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *VulncheckBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *VulncheckBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *VulncheckBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the govulncheck command.
+func (b *VulncheckBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("govulncheck", b, NewVulncheckBuilder(), explainx.Generate("BuildCommand", b.BuildCommand))
+}
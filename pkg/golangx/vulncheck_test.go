@@ -0,0 +1,62 @@
+package golangx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVulncheckBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  *VulncheckBuilder
+		expected []string
+	}{
+		{
+			name:     "Default",
+			builder:  NewVulncheckBuilder(),
+			expected: []string{"govulncheck", "./..."},
+		},
+		{
+			name:     "Source with options",
+			builder:  NewVulncheckBuilder("./cmd/...").WithFormat(VulncheckSARIF).WithTags("integration").WithVersion("v1.1.3"),
+			expected: []string{"go", "run", "golang.org/x/vuln/cmd/govulncheck@v1.1.3", "-format", "sarif", "-tags", "integration", "./cmd/..."},
+		},
+		{
+			name:     "Binary",
+			builder:  NewBinaryVulncheckBuilder("/mnt/dist/api").WithFormat(VulncheckJSON),
+			expected: []string{"govulncheck", "-mode", "binary", "-format", "json", "/mnt/dist/api"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cmd)
+		})
+	}
+}
+
+func TestVulncheckBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *VulncheckBuilder
+		kind    error
+	}{
+		{"Unsupported format", NewVulncheckBuilder().WithFormat("xml"), errorsx.ErrUnsupported},
+		{"Empty binary", NewBinaryVulncheckBuilder(""), errorsx.ErrMissingField},
+		{"Binary with tags", NewBinaryVulncheckBuilder("/bin/app").WithTags("netgo"), errorsx.ErrConflictingOptions},
+		{"Invalid version", NewVulncheckBuilder().WithVersion("pkg@v1"), errorsx.ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.BuildCommand()
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package goldenx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// diffLine is a line of a diff, with its operation: ' ' (unchanged), '-' (removed) or '+'
+// (added).
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// Diff returns a line diff from 'want' to 'got': removed lines are prefixed with "-", added
+// lines with "+", and unchanged lines with a space. Runs of unchanged lines farther than three
+// lines from a change are collapsed into a "@@ line N @@" marker, N being the line of 'want'
+// where the next shown run starts. It returns an empty string when both are equal.
+func Diff(want, got string) string {
+	if want == got {
+		return ""
+	}
+
+	lines := diffLines(splitLines(want), splitLines(got))
+
+	// show marks the lines within diffContext of a change.
+	show := make([]bool, len(lines))
+	for i, l := range lines {
+		if l.op == ' ' {
+			continue
+		}
+		for j := max(0, i-diffContext); j <= min(len(lines)-1, i+diffContext); j++ {
+			show[j] = true
+		}
+	}
+
+	var (
+		sb      strings.Builder
+		line    = 1
+		skipped bool
+	)
+	for i, l := range lines {
+		if !show[i] {
+			skipped = true
+		} else {
+			if skipped || i == 0 {
+				fmt.Fprintf(&sb, "@@ line %d @@\n", line)
+				skipped = false
+			}
+			fmt.Fprintf(&sb, "%c %s\n", l.op, l.text)
+		}
+
+		if l.op != '+' {
+			line++
+		}
+	}
+
+	return sb.String()
+}
+
+// splitLines splits 's' into lines, without the empty line following a final newline.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines returns the edit script from 'a' to 'b' along their longest common subsequence.
+func diffLines(a, b []string) []diffLine {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, diffLine{op: ' ', text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{op: '-', text: a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{op: '+', text: b[j]})
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		lines = append(lines, diffLine{op: '-', text: a[i]})
+	}
+
+	for ; j < len(b); j++ {
+		lines = append(lines, diffLine{op: '+', text: b[j]})
+	}
+
+	return lines
+}
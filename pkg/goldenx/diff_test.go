@@ -0,0 +1,25 @@
+package goldenx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	assert.Empty(t, Diff("a\nb\n", "a\nb\n"))
+
+	assert.Equal(t, "@@ line 1 @@\n  a\n- b\n+ c\n  d\n", Diff("a\nb\nd\n", "a\nc\nd\n"))
+	assert.Equal(t, "@@ line 1 @@\n+ a\n", Diff("", "a\n"))
+
+	var want, got []string
+	for i := 0; i < 20; i++ {
+		want = append(want, string(rune('a'+i)))
+	}
+	got = append(got, want...)
+	got[10] = "changed"
+
+	assert.Equal(t, "@@ line 8 @@\n  h\n  i\n  j\n- k\n+ changed\n  l\n  m\n  n\n",
+		Diff(strings.Join(want, "\n"), strings.Join(got, "\n")))
+}
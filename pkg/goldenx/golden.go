@@ -0,0 +1,227 @@
+// Package goldenx compares the commands and configurations generated by builders with golden
+// files, so regressions show up as readable line diffs rather than brittle string assertions.
+//
+// Golden files live under the testdata directory of the package (DefaultDir), named after the
+// assertion with the Extension suffix. Running the tests with the -update flag, or with UpdateEnv
+// set to 1, rewrites the golden files from the current output:
+//
+//	go test ./pkg/apkox/ -update
+//
+// Output is normalized before it is compared or written: line endings are converted to "\n",
+// trailing whitespace is trimmed, the replacements are applied (e.g. temporary directories), and
+// secret values, registered with WithSecrets or with the default logx Redactor, are replaced by
+// logx.Redacted, so golden files never hold secrets.
+//
+// Example usage:
+//
+//	func TestBuildCommand(t *testing.T) {
+//	    cmd, err := NewApkoBuilder().WithConfigFile("apko.yaml").WithOutputImage("app").
+//	        WithOutputTarball("/out/app.tar").BuildCommand()
+//	    require.NoError(t, err)
+//
+//	    goldenx.New(t).WithSecrets(token).AssertCommand("build-command", cmd)
+//	}
+package goldenx
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/filesystemx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// UpdateEnv rewrites the golden files when set to 1, like the -update flag.
+	UpdateEnv = "DAGGERX_UPDATE_GOLDEN"
+	// DefaultDir is the directory of the golden files, relative to the package under test.
+	DefaultDir = "testdata"
+	// Extension is the suffix of the golden files.
+	Extension = ".golden"
+)
+
+// update is the -update flag of the test binaries importing the package.
+var update = flag.Bool("update", false, "rewrite golden files from the current output")
+
+// Updating reports whether golden files are rewritten rather than compared.
+func Updating() bool {
+	return *update || os.Getenv(UpdateEnv) == "1"
+}
+
+// replacement replaces a volatile value of the output with a stable placeholder.
+type replacement struct {
+	value       string
+	placeholder string
+}
+
+// Golden compares output with the golden files of a test.
+type Golden struct {
+	t            testing.TB
+	dir          string
+	redactor     *logx.Redactor
+	replacements []replacement
+}
+
+// New creates a Golden comparing output with the golden files of DefaultDir.
+func New(t testing.TB) *Golden {
+	return &Golden{
+		t:        t,
+		dir:      DefaultDir,
+		redactor: logx.NewRedactor(),
+	}
+}
+
+// WithDir sets the directory of the golden files.
+// It returns the updated Golden instance.
+func (g *Golden) WithDir(dir string) *Golden {
+	g.dir = dir
+	return g
+}
+
+// WithSecrets registers secret values, replaced by logx.Redacted in the output.
+// It returns the updated Golden instance.
+func (g *Golden) WithSecrets(values ...string) *Golden {
+	g.redactor.AddValues(values...)
+	return g
+}
+
+// WithReplacement replaces every occurrence of 'value' in the output with 'placeholder', for
+// values changing between runs such as temporary directories. Empty values are ignored.
+// It returns the updated Golden instance.
+func (g *Golden) WithReplacement(value, placeholder string) *Golden {
+	if value != "" {
+		g.replacements = append(g.replacements, replacement{value: value, placeholder: placeholder})
+	}
+
+	return g
+}
+
+// Path returns the path of the golden file 'name'.
+func (g *Golden) Path(name string) string {
+	return filepath.Join(g.dir, filepath.FromSlash(name)+Extension)
+}
+
+// Assert compares the normalized 'got' with the golden file 'name', failing the test with a line
+// diff when they differ. When updating, the golden file is rewritten instead.
+// It returns whether the output matches the golden file.
+func (g *Golden) Assert(name string, got []byte) bool {
+	g.t.Helper()
+
+	if err := filesystemx.ValidateRelative(name); err != nil {
+		g.t.Fatalf("invalid golden file name: %v", err)
+	}
+
+	path := g.Path(name)
+	actual := g.normalize(string(got))
+
+	if Updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			g.t.Fatalf("failed to create golden file directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(actual), 0o644); err != nil {
+			g.t.Fatalf("failed to write golden file %s: %v", path, err)
+		}
+
+		return true
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		g.t.Errorf("golden file %s does not exist; run the tests with -update to create it", path)
+		return false
+	}
+	if err != nil {
+		g.t.Fatalf("failed to read golden file %s: %v", path, err)
+	}
+
+	// Golden files are normalized too, e.g. for checkouts converting line endings.
+	want := normalizeLines(string(data))
+	if want == actual {
+		return true
+	}
+
+	g.t.Errorf("output differs from golden file %s (run the tests with -update to accept it):\n%s",
+		path, Diff(want, actual))
+
+	return false
+}
+
+// AssertString is Assert for strings.
+func (g *Golden) AssertString(name, got string) bool {
+	g.t.Helper()
+	return g.Assert(name, []byte(got))
+}
+
+// AssertCommand compares the command 'cmd', one argument per line, with the golden file 'name'.
+// The values of sensitive flags and variables are redacted with logx.RedactCommand.
+func (g *Golden) AssertCommand(name string, cmd []string) bool {
+	g.t.Helper()
+	return g.AssertString(name, strings.Join(logx.RedactCommand(cmd), "\n"))
+}
+
+// AssertYAML compares the YAML document 'data' with the golden file 'name'. The document is
+// re-encoded with sorted keys and a two-space indentation, so only semantic changes show up.
+func (g *Golden) AssertYAML(name string, data []byte) bool {
+	g.t.Helper()
+
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		g.t.Fatalf("invalid YAML output for golden file %s: %v", name, err)
+	}
+
+	var sb strings.Builder
+	enc := yaml.NewEncoder(&sb)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		g.t.Fatalf("failed to encode YAML output for golden file %s: %v", name, err)
+	}
+	_ = enc.Close()
+
+	return g.AssertString(name, sb.String())
+}
+
+// AssertJSON compares the indented JSON encoding of 'v' with the golden file 'name'.
+func (g *Golden) AssertJSON(name string, v any) bool {
+	g.t.Helper()
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		g.t.Fatalf("failed to encode JSON output for golden file %s: %v", name, err)
+	}
+
+	return g.Assert(name, data)
+}
+
+// normalize redacts the secrets of 's', applies the replacements, and normalizes its lines.
+func (g *Golden) normalize(s string) string {
+	s = logx.Default().String(g.redactor.String(s))
+	for _, r := range g.replacements {
+		s = strings.ReplaceAll(s, r.value, r.placeholder)
+	}
+
+	return normalizeLines(s)
+}
+
+// normalizeLines converts the line endings of 's' to "\n", trims trailing whitespace, and ends
+// non-empty output with a single newline.
+func normalizeLines(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+
+	s = strings.TrimRight(strings.Join(lines, "\n"), "\n")
+	if s == "" {
+		return ""
+	}
+
+	return s + "\n"
+}
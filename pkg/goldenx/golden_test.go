@@ -0,0 +1,77 @@
+package goldenx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingTB records the errors of a test instead of failing it.
+type recordingTB struct {
+	*testing.T
+	errors []string
+}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssert(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(UpdateEnv, "1")
+
+	g := New(t).WithDir(dir).WithSecrets("s3cr3t-token").WithReplacement(dir, "$DIR")
+	assert.True(t, g.AssertCommand("cmd/login", []string{
+		"login", "--password", "hunter2", "--token=s3cr3t-token", dir + "/config.json",
+	}))
+
+	data, err := os.ReadFile(filepath.Join(dir, "cmd", "login.golden"))
+	require.NoError(t, err)
+	assert.Equal(t, "login\n--password\n***\n--token=***\n$DIR/config.json\n", string(data))
+
+	t.Setenv(UpdateEnv, "")
+
+	rec := &recordingTB{T: t}
+	g = New(rec).WithDir(dir).WithSecrets("another-token").WithReplacement(dir, "$DIR")
+	assert.True(t, g.AssertCommand("cmd/login", []string{
+		"login", "--password", "other", "--token=another-token", dir + "/config.json",
+	}))
+	assert.Empty(t, rec.errors)
+
+	assert.False(t, g.AssertCommand("cmd/login", []string{"login", "--password", "other"}))
+	require.Len(t, rec.errors, 1)
+	assert.Contains(t, rec.errors[0], "login.golden")
+	assert.Contains(t, rec.errors[0], "- --token=***\n- $DIR/config.json\n")
+
+	rec.errors = nil
+	assert.False(t, g.AssertString("missing", "x"))
+	require.Len(t, rec.errors, 1)
+	assert.Contains(t, rec.errors[0], "-update")
+}
+
+func TestAssertNormalizes(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.golden"),
+		[]byte("a: 1\r\nb:\r\n  - x  \r\n"), 0o644))
+
+	rec := &recordingTB{T: t}
+	g := New(rec).WithDir(dir)
+	assert.True(t, g.AssertYAML("config", []byte("b: [x]\na: 1\n")))
+	assert.True(t, g.AssertString("config", "a: 1\nb:\n  - x\n\n\n"))
+	assert.Empty(t, rec.errors)
+}
+
+func TestAssertJSON(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(UpdateEnv, "1")
+
+	assert.True(t, New(t).WithDir(dir).AssertJSON("spec", map[string]any{"b": []string{"x"}, "a": 1}))
+
+	data, err := os.ReadFile(filepath.Join(dir, "spec.golden"))
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"a\": 1,\n  \"b\": [\n    \"x\"\n  ]\n}\n", string(data))
+}
@@ -0,0 +1,354 @@
+// Package grypex provides helpers for grype, the vulnerability scanner, such as generating grype
+// ignore rules and OpenVEX statements from accepted-risk waivers.
+//
+// A waiver accepts the risk of a vulnerability in a package, with a VEX justification and an
+// expiry date. Lapsed waivers fail validation, so builds stop passing once the accepted risk must
+// be reviewed again.
+//
+// Example usage:
+//
+//	waivers := grypex.NewWaiverSet().
+//	    WithAuthor("security@example.com").
+//	    WithProduct("pkg:oci/app?repository_url=registry.example.com/app").
+//	    Add(grypex.Waiver{
+//	        Vulnerability: "CVE-2024-0001",
+//	        Package:       "openssl",
+//	        Justification: grypex.JustificationVulnerableCodeNotInExecutePath,
+//	        Statement:     "TLS is terminated by the ingress",
+//	        Expires:       time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC),
+//	    })
+//
+//	mounts, flags, err := waivers.Files("/mnt/.grype.yaml", "/mnt/waivers.vex.json")
+//	if err != nil {
+//	    // handle error: a waiver is invalid or lapsed
+//	}
+//
+//	cmd := append([]string{"grype", "registry.example.com/app:v1"}, flags...)
+//	res, err := executor.Run(ctx, cmd, nil, mounts)
+package grypex
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/timex"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// builderName identifies the grype helpers in errorsx errors.
+const builderName = "grype"
+
+// openVEXContext is the JSON-LD context of OpenVEX documents.
+const openVEXContext = "https://openvex.dev/ns/v0.2.0"
+
+// Justification is an OpenVEX justification of a not_affected status.
+type Justification string
+
+const (
+	// JustificationComponentNotPresent states the vulnerable component is not included.
+	JustificationComponentNotPresent Justification = "component_not_present"
+	// JustificationVulnerableCodeNotPresent states the vulnerable code is not included.
+	JustificationVulnerableCodeNotPresent Justification = "vulnerable_code_not_present"
+	// JustificationVulnerableCodeNotInExecutePath states the vulnerable code cannot be executed.
+	JustificationVulnerableCodeNotInExecutePath Justification = "vulnerable_code_not_in_execute_path"
+	// JustificationVulnerableCodeCannotBeControlledByAdversary states the vulnerable code cannot be
+	// reached by an attacker.
+	JustificationVulnerableCodeCannotBeControlledByAdversary Justification = "vulnerable_code_cannot_be_controlled_by_adversary"
+	// JustificationInlineMitigationsAlreadyExist states built-in mitigations prevent exploitation.
+	JustificationInlineMitigationsAlreadyExist Justification = "inline_mitigations_already_exist"
+)
+
+// justifications holds the valid OpenVEX justifications.
+var justifications = map[Justification]bool{
+	JustificationComponentNotPresent:                         true,
+	JustificationVulnerableCodeNotPresent:                    true,
+	JustificationVulnerableCodeNotInExecutePath:              true,
+	JustificationVulnerableCodeCannotBeControlledByAdversary: true,
+	JustificationInlineMitigationsAlreadyExist:               true,
+}
+
+// Waiver accepts the risk of a vulnerability in a package.
+type Waiver struct {
+	// Vulnerability is the vulnerability identifier (e.g. "CVE-2024-0001").
+	Vulnerability string
+	// Package is the name of the affected package. An empty package waives the vulnerability in
+	// every package.
+	Package string
+	// Justification explains why the image is not affected.
+	Justification Justification
+	// Statement details the justification.
+	Statement string
+	// Expires is the date the waiver lapses. A zero value never lapses.
+	Expires time.Time
+}
+
+// WaiverSet holds the accepted-risk waivers of an image.
+type WaiverSet struct {
+	waivers []Waiver
+	author  string
+	product string
+	// now returns the current time; it is replaced in tests.
+	now timex.Clock
+}
+
+// NewWaiverSet creates an empty WaiverSet.
+func NewWaiverSet() *WaiverSet {
+	return &WaiverSet{now: timex.System}
+}
+
+// WithAuthor sets the author of the generated OpenVEX documents.
+func (s *WaiverSet) WithAuthor(author string) *WaiverSet {
+	s.author = author
+	return s
+}
+
+// WithProduct sets the product (an image reference or purl) the OpenVEX statements apply to.
+// Grype only applies statements whose product matches the scanned image.
+func (s *WaiverSet) WithProduct(product string) *WaiverSet {
+	s.product = product
+	return s
+}
+
+// Add adds waivers to the set.
+func (s *WaiverSet) Add(waivers ...Waiver) *WaiverSet {
+	s.waivers = append(s.waivers, waivers...)
+	return s
+}
+
+// Waivers returns the waivers of the set.
+func (s *WaiverSet) Waivers() []Waiver {
+	return s.waivers
+}
+
+// Validate checks that every waiver names a vulnerability and a valid justification, and that
+// none has lapsed.
+//
+// Returns:
+//   - An error describing the first invalid waiver, or listing the lapsed waivers.
+func (s *WaiverSet) Validate() error {
+	now := s.now()
+
+	var lapsed []string
+	for _, w := range s.waivers {
+		if w.Vulnerability == "" {
+			return errorsx.MissingField(builderName, "vulnerability", "waiver requires a vulnerability")
+		}
+
+		if !justifications[w.Justification] {
+			return errorsx.InvalidValue(builderName, "justification", w.Justification,
+				"waiver of %s has an invalid justification: %q", w.Vulnerability, w.Justification)
+		}
+
+		if !w.Expires.IsZero() && !now.Before(w.Expires) {
+			lapsed = append(lapsed, fmt.Sprintf("%s (expired %s)", waiverName(w), w.Expires.Format(time.DateOnly)))
+		}
+	}
+
+	if len(lapsed) > 0 {
+		return errorsx.InvalidValue(builderName, "expires", lapsed, "waivers lapsed: %s", strings.Join(lapsed, ", "))
+	}
+
+	return nil
+}
+
+// waiverName identifies a waiver in messages.
+func waiverName(w Waiver) string {
+	if w.Package == "" {
+		return w.Vulnerability
+	}
+
+	return w.Vulnerability + " in " + w.Package
+}
+
+// ignoreRule is a rule of the ignore section of a grype configuration.
+type ignoreRule struct {
+	Vulnerability string             `yaml:"vulnerability"`
+	Reason        string             `yaml:"reason,omitempty"`
+	Package       *ignoreRulePackage `yaml:"package,omitempty"`
+}
+
+// ignoreRulePackage is the package matcher of a grype ignore rule.
+type ignoreRulePackage struct {
+	Name string `yaml:"name"`
+}
+
+// GrypeConfig renders the waivers into a grype configuration ignoring the waived vulnerabilities.
+//
+// Returns:
+//   - The grype configuration YAML.
+//   - An error if a waiver is invalid or lapsed.
+func (s *WaiverSet) GrypeConfig() ([]byte, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	config := struct {
+		Ignore []ignoreRule `yaml:"ignore"`
+	}{Ignore: []ignoreRule{}}
+
+	for _, w := range s.waivers {
+		rule := ignoreRule{Vulnerability: w.Vulnerability, Reason: w.Statement}
+		if rule.Reason == "" {
+			rule.Reason = string(w.Justification)
+		}
+		if w.Package != "" {
+			rule.Package = &ignoreRulePackage{Name: w.Package}
+		}
+		config.Ignore = append(config.Ignore, rule)
+	}
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode grype configuration: %w", err)
+	}
+
+	return data, nil
+}
+
+// vexDocument is an OpenVEX document.
+type vexDocument struct {
+	Context    string         `json:"@context"`
+	ID         string         `json:"@id"`
+	Author     string         `json:"author"`
+	Timestamp  string         `json:"timestamp"`
+	Version    int            `json:"version"`
+	Statements []vexStatement `json:"statements"`
+}
+
+// vexStatement is a statement of an OpenVEX document.
+type vexStatement struct {
+	Vulnerability   vexVulnerability `json:"vulnerability"`
+	Products        []vexProduct     `json:"products"`
+	Status          string           `json:"status"`
+	Justification   string           `json:"justification"`
+	ImpactStatement string           `json:"impact_statement,omitempty"`
+}
+
+// vexVulnerability identifies the vulnerability of a VEX statement.
+type vexVulnerability struct {
+	Name string `json:"name"`
+}
+
+// vexProduct identifies a product or subcomponent of a VEX statement.
+type vexProduct struct {
+	ID            string       `json:"@id"`
+	Subcomponents []vexProduct `json:"subcomponents,omitempty"`
+}
+
+// OpenVEX renders the waivers into an OpenVEX document stating the product is not affected by the
+// waived vulnerabilities. The document ID is derived from its statements, so the same waivers
+// always produce the same ID.
+//
+// Returns:
+//   - The OpenVEX JSON document.
+//   - An error if the product is missing, or a waiver is invalid or lapsed.
+func (s *WaiverSet) OpenVEX() ([]byte, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	if s.product == "" {
+		return nil, errorsx.MissingField(builderName, "product", "VEX statements require a product")
+	}
+
+	statements := make([]vexStatement, 0, len(s.waivers))
+	for _, w := range s.waivers {
+		st := vexStatement{
+			Vulnerability:   vexVulnerability{Name: w.Vulnerability},
+			Status:          "not_affected",
+			Justification:   string(w.Justification),
+			ImpactStatement: w.Statement,
+		}
+
+		product := vexProduct{ID: s.product}
+		if w.Package != "" {
+			product.Subcomponents = []vexProduct{{ID: w.Package}}
+		}
+		st.Products = []vexProduct{product}
+
+		statements = append(statements, st)
+	}
+
+	sort.SliceStable(statements, func(i, j int) bool {
+		return statements[i].Vulnerability.Name < statements[j].Vulnerability.Name
+	})
+
+	encoded, err := json.Marshal(statements)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode VEX statements: %w", err)
+	}
+	sum := sha256.Sum256(encoded)
+
+	doc := vexDocument{
+		Context:    openVEXContext,
+		ID:         "https://openvex.dev/docs/public/vex-" + hex.EncodeToString(sum[:]),
+		Author:     s.author,
+		Timestamp:  s.now().UTC().Format(time.RFC3339),
+		Version:    1,
+		Statements: statements,
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode VEX document: %w", err)
+	}
+
+	return data, nil
+}
+
+// Flags returns the grype flags wiring in the grype configuration at 'configPath' and the VEX
+// document at 'vexPath'. Empty paths are skipped.
+func Flags(configPath, vexPath string) []string {
+	var flags []string
+
+	if configPath != "" {
+		flags = append(flags, "--config", configPath)
+	}
+
+	if vexPath != "" {
+		flags = append(flags, "--vex", vexPath)
+	}
+
+	return flags
+}
+
+// Files renders the waivers into the grype configuration at 'configPath' and the OpenVEX
+// document at 'vexPath' as inline mounts, along with the grype flags wiring them in. An empty
+// path skips the corresponding file.
+//
+// Returns:
+//   - The inline mounts of the files.
+//   - The grype flags.
+//   - An error if a waiver is invalid or lapsed, or the VEX document lacks a product.
+func (s *WaiverSet) Files(configPath, vexPath string) ([]types.Mount, []string, error) {
+	if err := s.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	var mounts []types.Mount
+
+	if configPath != "" {
+		config, err := s.GrypeConfig()
+		if err != nil {
+			return nil, nil, err
+		}
+		mounts = append(mounts, types.Mount{Kind: types.MountKindInline, Target: configPath, Content: string(config), ReadOnly: true})
+	}
+
+	if vexPath != "" {
+		vex, err := s.OpenVEX()
+		if err != nil {
+			return nil, nil, err
+		}
+		mounts = append(mounts, types.Mount{Kind: types.MountKindInline, Target: vexPath, Content: string(vex), ReadOnly: true})
+	}
+
+	return mounts, Flags(configPath, vexPath), nil
+}
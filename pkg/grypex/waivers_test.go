@@ -0,0 +1,144 @@
+package grypex
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/timex"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestWaiverSet(waivers ...Waiver) *WaiverSet {
+	s := NewWaiverSet().
+		WithAuthor("security@example.com").
+		WithProduct("pkg:oci/app").
+		Add(waivers...)
+	s.now = timex.Fixed(testNow)
+
+	return s
+}
+
+var (
+	opensslWaiver = Waiver{
+		Vulnerability: "CVE-2024-0002",
+		Package:       "openssl",
+		Justification: JustificationVulnerableCodeNotInExecutePath,
+		Statement:     "TLS is terminated by the ingress",
+		Expires:       time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC),
+	}
+	anyPackageWaiver = Waiver{
+		Vulnerability: "CVE-2024-0001",
+		Justification: JustificationComponentNotPresent,
+	}
+)
+
+func TestWaiverSetGrypeConfig(t *testing.T) {
+	data, err := newTestWaiverSet(opensslWaiver, anyPackageWaiver).GrypeConfig()
+	require.NoError(t, err)
+	assert.Equal(t, `ignore:
+    - vulnerability: CVE-2024-0002
+      reason: TLS is terminated by the ingress
+      package:
+        name: openssl
+    - vulnerability: CVE-2024-0001
+      reason: component_not_present
+`, string(data))
+}
+
+func TestWaiverSetOpenVEX(t *testing.T) {
+	s := newTestWaiverSet(opensslWaiver, anyPackageWaiver)
+
+	data, err := s.OpenVEX()
+	require.NoError(t, err)
+
+	var doc vexDocument
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, openVEXContext, doc.Context)
+	assert.Equal(t, "security@example.com", doc.Author)
+	assert.Equal(t, "2025-03-01T12:00:00Z", doc.Timestamp)
+	assert.Equal(t, []vexStatement{
+		{
+			Vulnerability: vexVulnerability{Name: "CVE-2024-0001"},
+			Products:      []vexProduct{{ID: "pkg:oci/app"}},
+			Status:        "not_affected",
+			Justification: "component_not_present",
+		},
+		{
+			Vulnerability:   vexVulnerability{Name: "CVE-2024-0002"},
+			Products:        []vexProduct{{ID: "pkg:oci/app", Subcomponents: []vexProduct{{ID: "openssl"}}}},
+			Status:          "not_affected",
+			Justification:   "vulnerable_code_not_in_execute_path",
+			ImpactStatement: "TLS is terminated by the ingress",
+		},
+	}, doc.Statements)
+
+	again, err := newTestWaiverSet(anyPackageWaiver, opensslWaiver).OpenVEX()
+	require.NoError(t, err)
+	var other vexDocument
+	require.NoError(t, json.Unmarshal(again, &other))
+	assert.Equal(t, doc.ID, other.ID)
+
+	_, err = newTestWaiverSet(opensslWaiver).WithProduct("").OpenVEX()
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+}
+
+func TestWaiverSetValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		waiver Waiver
+		field  string
+		msg    string
+	}{
+		{name: "Missing vulnerability", waiver: Waiver{Justification: JustificationComponentNotPresent}, field: "vulnerability"},
+		{name: "Invalid justification", waiver: Waiver{Vulnerability: "CVE-1", Justification: "trust me"}, field: "justification"},
+		{
+			name:   "Lapsed",
+			waiver: Waiver{Vulnerability: "CVE-1", Package: "zlib", Justification: JustificationComponentNotPresent, Expires: testNow},
+			field:  "expires",
+			msg:    "waivers lapsed: CVE-1 in zlib (expired 2025-03-01)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newTestWaiverSet(opensslWaiver, tt.waiver).Validate()
+			field, ok := errorsx.FieldOf(err)
+			require.True(t, ok, "got %v", err)
+			assert.Equal(t, tt.field, field)
+			if tt.msg != "" {
+				assert.ErrorContains(t, err, tt.msg)
+			}
+
+			_, _, err = newTestWaiverSet(tt.waiver).Files("/mnt/.grype.yaml", "")
+			assert.Error(t, err)
+		})
+	}
+
+	assert.NoError(t, newTestWaiverSet(opensslWaiver, anyPackageWaiver).Validate())
+}
+
+func TestWaiverSetFiles(t *testing.T) {
+	s := newTestWaiverSet(opensslWaiver)
+
+	mounts, flags, err := s.Files("/mnt/.grype.yaml", "/mnt/waivers.vex.json")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--config", "/mnt/.grype.yaml", "--vex", "/mnt/waivers.vex.json"}, flags)
+
+	require.Len(t, mounts, 2)
+	config, err := s.GrypeConfig()
+	require.NoError(t, err)
+	assert.Equal(t, types.Mount{Kind: types.MountKindInline, Target: "/mnt/.grype.yaml", Content: string(config), ReadOnly: true}, mounts[0])
+	assert.Equal(t, "/mnt/waivers.vex.json", mounts[1].Target)
+
+	mounts, flags, err = s.Files("", "/mnt/waivers.vex.json")
+	require.NoError(t, err)
+	assert.Len(t, mounts, 1)
+	assert.Equal(t, []string{"--vex", "/mnt/waivers.vex.json"}, flags)
+}
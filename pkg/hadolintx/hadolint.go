@@ -0,0 +1,296 @@
+// Package hadolintx provides a builder for the hadolint lint of Dockerfiles: the `hadolint`
+// command with its ignored rules, configuration file and trusted registries, with JSON output,
+// and the parser of that output into findings, so the lint joins the scans of the run report
+// (see reportx) and gates the pipeline (see policyx).
+//
+// hadolint levels map to severities: error to high, warning to medium, info to low and style to
+// negligible.
+//
+// Example usage:
+//
+//	lint := hadolintx.NewHadolintBuilder("Dockerfile").
+//	    WithIgnore("DL3008").
+//	    WithTrustedRegistry("registry.example.com")
+//
+//	cmd, err := lint.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	// Run cmd, then parse its standard output.
+//	if err := lint.AddScan(report, stdout); err != nil {
+//	    // handle error
+//	}
+//	result, err := lint.Evaluate(stdout)
+package hadolintx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the hadolint builder in errorsx errors.
+const builderName = "hadolint"
+
+// HadolintDefaultImage is the default container image providing hadolint.
+const HadolintDefaultImage = "hadolint/hadolint:v2.12.0-alpine"
+
+// Level is the level of a hadolint rule.
+type Level string
+
+const (
+	// LevelError reports instructions that break the build or the image.
+	LevelError Level = "error"
+	// LevelWarning reports instructions that likely misbehave.
+	LevelWarning Level = "warning"
+	// LevelInfo reports instructions that may be improved.
+	LevelInfo Level = "info"
+	// LevelStyle reports stylistic issues.
+	LevelStyle Level = "style"
+)
+
+// levelSeverities are the severities of the findings of each level.
+var levelSeverities = map[Level]policyx.Severity{
+	LevelError:   policyx.SeverityHigh,
+	LevelWarning: policyx.SeverityMedium,
+	LevelInfo:    policyx.SeverityLow,
+	LevelStyle:   policyx.SeverityNegligible,
+}
+
+// Severity returns the severity of the findings of the level.
+func (l Level) Severity() policyx.Severity {
+	return levelSeverities[l]
+}
+
+// ruleRegex matches hadolint rules: its own (e.g. "DL3008") and the shellcheck checks of the
+// RUN instructions (e.g. "SC2086").
+var ruleRegex = regexp.MustCompile(`^(DL|SC)[0-9]{4}$`)
+
+// Issue is an issue hadolint reports in a Dockerfile.
+type Issue struct {
+	// File is the path of the Dockerfile.
+	File string `json:"file"`
+	// Line is the line of the instruction.
+	Line int `json:"line"`
+	// Column is the column of the instruction.
+	Column int `json:"column"`
+	// Level is the level of the rule.
+	Level Level `json:"level"`
+	// Code is the rule (e.g. "DL3008").
+	Code string `json:"code"`
+	// Message explains the issue.
+	Message string `json:"message"`
+}
+
+// ParseOutput parses the JSON output of hadolint (--format json).
+//
+// Returns:
+//   - The issues, in output order.
+//   - An error if the output is not a hadolint JSON output.
+func ParseOutput(data []byte) ([]Issue, error) {
+	var issues []Issue
+	if err := json.Unmarshal(data, &issues); err != nil {
+		return nil, fmt.Errorf("invalid hadolint output: %w", err)
+	}
+
+	return issues, nil
+}
+
+// Findings converts the issues to findings: the ID is the rule (e.g. "DL3008"), the package the
+// location of the instruction (e.g. "Dockerfile:3").
+func Findings(issues []Issue) []policyx.Finding {
+	findings := make([]policyx.Finding, len(issues))
+	for i, is := range issues {
+		findings[i] = policyx.Finding{
+			ID:       is.Code,
+			Package:  is.File + ":" + strconv.Itoa(is.Line),
+			Severity: is.Level.Severity(),
+		}
+	}
+
+	return findings
+}
+
+// HadolintBuilder generates the hadolint command of Dockerfiles.
+type HadolintBuilder struct {
+	// files are the linted Dockerfiles.
+	files []string
+
+	// configFile is the hadolint configuration file.
+	configFile string
+
+	// ignores are the ignored rules.
+	ignores []string
+
+	// trustedRegistries are the registries the base images may be pulled from (DL3026).
+	trustedRegistries []string
+
+	// failureThreshold is the lowest level failing the command. Empty uses hadolint's default.
+	failureThreshold Level
+
+	// maxSeverity is the highest severity of the findings passing the evaluation.
+	maxSeverity policyx.Severity
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewHadolintBuilder creates a HadolintBuilder linting the Dockerfiles 'files', failing the
+// evaluation on errors.
+func NewHadolintBuilder(files ...string) *HadolintBuilder {
+	return &HadolintBuilder{files: files, maxSeverity: policyx.SeverityMedium}
+}
+
+// WithConfigFile sets the hadolint configuration file (e.g. ".hadolint.yaml"). Options of the
+// builder are added to those of the file.
+func (b *HadolintBuilder) WithConfigFile(path string) *HadolintBuilder {
+	b.configFile = path
+	return b
+}
+
+// WithIgnore ignores the rules 'rules' (e.g. "DL3008").
+func (b *HadolintBuilder) WithIgnore(rules ...string) *HadolintBuilder {
+	b.ignores = append(b.ignores, rules...)
+	return b
+}
+
+// WithTrustedRegistry adds registries the base images may be pulled from.
+func (b *HadolintBuilder) WithTrustedRegistry(registries ...string) *HadolintBuilder {
+	b.trustedRegistries = append(b.trustedRegistries, registries...)
+	return b
+}
+
+// WithFailureThreshold sets the lowest level failing the command. The evaluation of the output
+// is independent of it (see WithMaxSeverity).
+func (b *HadolintBuilder) WithFailureThreshold(level Level) *HadolintBuilder {
+	b.failureThreshold = level
+	return b
+}
+
+// WithMaxSeverity sets the highest severity of the findings passing the evaluation. It defaults
+// to medium, so errors fail.
+func (b *HadolintBuilder) WithMaxSeverity(severity policyx.Severity) *HadolintBuilder {
+	b.maxSeverity = severity
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *HadolintBuilder) WithLogger(logger *slog.Logger) *HadolintBuilder {
+	b.logger = logger
+	return b
+}
+
+// BuildCommand generates the hadolint command, with JSON output.
+//
+// Returns:
+//   - The hadolint command.
+//   - An error if no Dockerfile is set, an ignored rule is invalid, or the failure threshold is
+//     unsupported.
+func (b *HadolintBuilder) BuildCommand() ([]string, error) {
+	if len(b.files) == 0 {
+		return nil, errorsx.MissingField(builderName, "files", "at least one Dockerfile is required")
+	}
+
+	for _, r := range b.ignores {
+		if !ruleRegex.MatchString(r) {
+			return nil, errorsx.InvalidValue(builderName, "ignores", r, "invalid hadolint rule: %q", r)
+		}
+	}
+
+	if _, ok := levelSeverities[b.failureThreshold]; b.failureThreshold != "" && !ok {
+		return nil, errorsx.InvalidValue(builderName, "failureThreshold", b.failureThreshold,
+			"unsupported hadolint level: %q", b.failureThreshold)
+	}
+
+	cmd := []string{"hadolint", "--format", "json", "--no-color"}
+	if b.configFile != "" {
+		cmd = append(cmd, "--config", b.configFile)
+	}
+
+	for _, r := range b.ignores {
+		cmd = append(cmd, "--ignore", r)
+	}
+
+	for _, r := range b.trustedRegistries {
+		cmd = append(cmd, "--trusted-registry", r)
+	}
+
+	if b.failureThreshold != "" {
+		cmd = append(cmd, "--failure-threshold", string(b.failureThreshold))
+	}
+
+	cmd = append(cmd, b.files...)
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Evaluate parses the JSON output of the command and fails on the findings more severe than
+// the maximum severity.
+//
+// Returns:
+//   - The result of the evaluation.
+//   - An error if the output can't be parsed.
+func (b *HadolintBuilder) Evaluate(output []byte) (policyx.Result, error) {
+	issues, err := ParseOutput(output)
+	if err != nil {
+		return policyx.Result{}, err
+	}
+
+	return policyx.NewPolicy().WithMaxSeverity(b.maxSeverity).Evaluate(policyx.Input{Findings: Findings(issues)}), nil
+}
+
+// AddScan parses the JSON output of the command and adds its findings to 'report', as a
+// hadolint scan of the Dockerfiles.
+//
+// Returns:
+//   - An error if the output can't be parsed.
+func (b *HadolintBuilder) AddScan(report *reportx.Report, output []byte) error {
+	issues, err := ParseOutput(output)
+	if err != nil {
+		return err
+	}
+
+	report.AddScan(builderName, strings.Join(b.files, " "), Findings(issues))
+
+	return nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *HadolintBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *HadolintBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *HadolintBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the hadolint command.
+func (b *HadolintBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewHadolintBuilder(), explainx.Generate("BuildCommand", b.BuildCommand))
+}
@@ -0,0 +1,158 @@
+package hadolintx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOutput = `[
+	{"code": "DL3006", "column": 1, "file": "Dockerfile", "level": "warning", "line": 1,
+	 "message": "Always tag the version of an image explicitly"},
+	{"code": "DL3008", "column": 1, "file": "Dockerfile", "level": "warning", "line": 3,
+	 "message": "Pin versions in apt get install"},
+	{"code": "DL3000", "column": 1, "file": "Dockerfile", "level": "error", "line": 5,
+	 "message": "Use absolute WORKDIR"}
+]`
+
+func TestLevelSeverity(t *testing.T) {
+	assert.Equal(t, policyx.SeverityHigh, LevelError.Severity())
+	assert.Equal(t, policyx.SeverityMedium, LevelWarning.Severity())
+	assert.Equal(t, policyx.SeverityLow, LevelInfo.Severity())
+	assert.Equal(t, policyx.SeverityNegligible, LevelStyle.Severity())
+	assert.Equal(t, policyx.SeverityUnknown, Level("none").Severity())
+}
+
+func TestParseOutput(t *testing.T) {
+	issues, err := ParseOutput([]byte(testOutput))
+	require.NoError(t, err)
+	require.Len(t, issues, 3)
+	assert.Equal(t, Issue{
+		File: "Dockerfile", Line: 5, Column: 1, Level: LevelError, Code: "DL3000",
+		Message: "Use absolute WORKDIR",
+	}, issues[2])
+
+	issues, err = ParseOutput([]byte(`[]`))
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+
+	_, err = ParseOutput([]byte(`{"comments": []}`))
+	assert.Error(t, err)
+}
+
+func TestFindings(t *testing.T) {
+	issues, err := ParseOutput([]byte(testOutput))
+	require.NoError(t, err)
+
+	assert.Equal(t, []policyx.Finding{
+		{ID: "DL3006", Package: "Dockerfile:1", Severity: policyx.SeverityMedium},
+		{ID: "DL3008", Package: "Dockerfile:3", Severity: policyx.SeverityMedium},
+		{ID: "DL3000", Package: "Dockerfile:5", Severity: policyx.SeverityHigh},
+	}, Findings(issues))
+}
+
+func TestHadolintBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *HadolintBuilder
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "defaults",
+			builder: NewHadolintBuilder("Dockerfile"),
+			want:    []string{"hadolint", "--format", "json", "--no-color", "Dockerfile"},
+		},
+		{
+			name: "options",
+			builder: NewHadolintBuilder("Dockerfile", "build/Dockerfile.ci").
+				WithConfigFile(".hadolint.yaml").
+				WithIgnore("DL3008", "SC2086").
+				WithTrustedRegistry("registry.example.com").
+				WithFailureThreshold(LevelError),
+			want: []string{
+				"hadolint", "--format", "json", "--no-color", "--config", ".hadolint.yaml",
+				"--ignore", "DL3008", "--ignore", "SC2086", "--trusted-registry", "registry.example.com",
+				"--failure-threshold", "error", "Dockerfile", "build/Dockerfile.ci",
+			},
+		},
+		{
+			name:    "no Dockerfile",
+			builder: NewHadolintBuilder(),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "invalid ignored rule",
+			builder: NewHadolintBuilder("Dockerfile").WithIgnore("dl3008"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "unsupported failure threshold",
+			builder: NewHadolintBuilder("Dockerfile").WithFailureThreshold("fatal"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestHadolintBuilderEvaluate(t *testing.T) {
+	result, err := NewHadolintBuilder("Dockerfile").Evaluate([]byte(testOutput))
+	require.NoError(t, err)
+	assert.False(t, result.Passed)
+	require.Len(t, result.Violations, 1)
+	assert.Equal(t, "DL3000 in Dockerfile:5 has severity high, above the maximum of medium",
+		result.Violations[0].Message)
+
+	result, err = NewHadolintBuilder("Dockerfile").WithMaxSeverity(policyx.SeverityLow).Evaluate([]byte(testOutput))
+	require.NoError(t, err)
+	assert.Len(t, result.Violations, 3)
+
+	_, err = NewHadolintBuilder("Dockerfile").Evaluate([]byte("{"))
+	assert.Error(t, err)
+}
+
+func TestHadolintBuilderAddScan(t *testing.T) {
+	report := reportx.NewReport("lint")
+	require.NoError(t, NewHadolintBuilder("Dockerfile").AddScan(report, []byte(testOutput)))
+
+	require.Len(t, report.Scans, 1)
+	assert.Equal(t, "hadolint", report.Scans[0].Scanner)
+	assert.Equal(t, "Dockerfile", report.Scans[0].Target)
+	assert.Equal(t, map[string]int{"high": 1, "medium": 2}, report.Scans[0].Counts)
+
+	assert.Error(t, NewHadolintBuilder("Dockerfile").AddScan(report, []byte("{")))
+}
+
+func TestHadolintBuilderExplain(t *testing.T) {
+	e := NewHadolintBuilder("Dockerfile").WithIgnore("DL3008").Explain()
+
+	ignores, ok := e.Option("ignores")
+	require.True(t, ok)
+	assert.Equal(t, explainx.SourceSet, ignores.Source)
+	assert.Equal(t, []string{"--ignore DL3008"}, ignores.Flags)
+
+	severity, ok := e.Option("maxSeverity")
+	require.True(t, ok)
+	assert.Equal(t, explainx.SourceDefault, severity.Source)
+
+	require.Len(t, e.Commands, 1)
+	assert.Equal(t, []string{"hadolint", "--format", "json", "--no-color", "--ignore", "DL3008", "Dockerfile"},
+		e.Commands[0].Args)
+}
@@ -0,0 +1,141 @@
+package helmx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/versionx"
+	"gopkg.in/yaml.v3"
+)
+
+// ChartFileName is the name of the chart metadata file.
+const ChartFileName = "Chart.yaml"
+
+// ociScheme is the scheme of OCI registry references.
+const ociScheme = "oci://"
+
+// ChartDependency is a dependency declared in Chart.yaml.
+type ChartDependency struct {
+	// Name is the name of the dependency chart.
+	Name string `yaml:"name"`
+	// Version is the version or version range of the dependency.
+	Version string `yaml:"version"`
+	// Repository is the repository URL of the dependency (https:// or oci://).
+	Repository string `yaml:"repository"`
+	// Alias is the name the dependency is installed under.
+	Alias string `yaml:"alias,omitempty"`
+}
+
+// Chart is the subset of Chart.yaml used to compute package names and push destinations.
+type Chart struct {
+	// APIVersion is the chart API version. Charts pushed to OCI registries use "v2".
+	APIVersion string `yaml:"apiVersion"`
+	// Name is the chart name.
+	Name string `yaml:"name"`
+	// Version is the SemVer 2 chart version.
+	Version string `yaml:"version"`
+	// AppVersion is the version of the packaged application.
+	AppVersion string `yaml:"appVersion,omitempty"`
+	// Type is "application" or "library".
+	Type string `yaml:"type,omitempty"`
+	// Dependencies are the chart dependencies.
+	Dependencies []ChartDependency `yaml:"dependencies,omitempty"`
+}
+
+// ParseChart parses and validates the content of a Chart.yaml file.
+//
+// Returns:
+//   - The parsed chart.
+//   - An error if the content is not valid YAML, or the name or version is missing or invalid.
+func ParseChart(data []byte) (*Chart, error) {
+	var c Chart
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ChartFileName, err)
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// LoadChart reads and parses the Chart.yaml of the chart in 'chartDir'.
+func LoadChart(chartDir string) (*Chart, error) {
+	data, err := os.ReadFile(filepath.Join(chartDir, ChartFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chart: %w", err)
+	}
+
+	return ParseChart(data)
+}
+
+// Validate checks that the chart can be packaged and pushed to an OCI registry.
+func (c *Chart) Validate() error {
+	if c.APIVersion != "v2" {
+		return errorsx.Unsupported(builderName, "apiVersion", c.APIVersion,
+			"chart apiVersion %q is not supported by OCI registries, use v2", c.APIVersion)
+	}
+
+	if c.Name == "" {
+		return errorsx.MissingField(builderName, "name", "chart name is required")
+	}
+
+	if c.Version == "" {
+		return errorsx.MissingField(builderName, "version", "chart %s has no version", c.Name)
+	}
+
+	if strings.HasPrefix(c.Version, "v") || !versionx.IsValid(c.Version) {
+		return errorsx.InvalidValue(builderName, "version", c.Version, "chart %s version is not SemVer 2: %q", c.Name, c.Version)
+	}
+
+	return nil
+}
+
+// PackageFileName returns the name of the archive `helm package` produces, e.g. "app-1.2.3.tgz".
+func (c *Chart) PackageFileName() string {
+	return fmt.Sprintf("%s-%s.tgz", c.Name, c.Version)
+}
+
+// Reference returns the reference the chart is pushed to under the registry namespace 'remote'
+// (e.g. "oci://registry.example.com/charts"), e.g. "oci://registry.example.com/charts/app:1.2.3".
+// Helm replaces '+' with '_' in OCI tags, since '+' is not allowed in tags.
+func (c *Chart) Reference(remote string) (string, error) {
+	remote, err := normalizeRemote(remote)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s:%s", remote, c.Name, strings.ReplaceAll(c.Version, "+", "_")), nil
+}
+
+// PushCommand generates the `helm push` command of the chart package to the registry namespace
+// 'remote'. The package is expected in the working directory, as produced by `helm package`.
+//
+// Returns:
+//   - The helm push command.
+//   - An error if the remote is empty or is not an OCI reference.
+func (c *Chart) PushCommand(remote string) ([]string, error) {
+	remote, err := normalizeRemote(remote)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{"helm", "push", c.PackageFileName(), remote}, nil
+}
+
+// normalizeRemote checks that 'remote' is an OCI reference and strips its trailing slash.
+func normalizeRemote(remote string) (string, error) {
+	if remote == "" {
+		return "", errorsx.MissingField(builderName, "remote", "push destination is required")
+	}
+
+	if !strings.HasPrefix(remote, ociScheme) {
+		return "", errorsx.InvalidValue(builderName, "remote", remote, "push destination must start with %s", ociScheme)
+	}
+
+	return strings.TrimSuffix(remote, "/"), nil
+}
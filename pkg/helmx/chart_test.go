@@ -0,0 +1,93 @@
+package helmx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testChart = `apiVersion: v2
+name: app
+description: Example application
+type: application
+version: 1.2.3+build.7
+appVersion: "2.0.1"
+dependencies:
+  - name: postgresql
+    version: "~15.5.0"
+    repository: oci://registry-1.docker.io/bitnamicharts
+`
+
+func TestParseChart(t *testing.T) {
+	chart, err := ParseChart([]byte(testChart))
+	require.NoError(t, err)
+
+	assert.Equal(t, "app", chart.Name)
+	assert.Equal(t, "1.2.3+build.7", chart.Version)
+	assert.Equal(t, "2.0.1", chart.AppVersion)
+	assert.Equal(t, []ChartDependency{{
+		Name:       "postgresql",
+		Version:    "~15.5.0",
+		Repository: "oci://registry-1.docker.io/bitnamicharts",
+	}}, chart.Dependencies)
+
+	assert.Equal(t, "app-1.2.3+build.7.tgz", chart.PackageFileName())
+
+	ref, err := chart.Reference("oci://registry.example.com/charts/")
+	require.NoError(t, err)
+	assert.Equal(t, "oci://registry.example.com/charts/app:1.2.3_build.7", ref)
+
+	cmd, err := chart.PushCommand("oci://registry.example.com/charts")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"helm", "push", "app-1.2.3+build.7.tgz", "oci://registry.example.com/charts"}, cmd)
+
+	_, err = chart.PushCommand("registry.example.com/charts")
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+
+	_, err = chart.Reference("")
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+}
+
+func TestParseChartValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		field string
+		kind  error
+	}{
+		{"API v1", "apiVersion: v1\nname: app\nversion: 1.0.0\n", "apiVersion", errorsx.ErrUnsupported},
+		{"Missing name", "apiVersion: v2\nversion: 1.0.0\n", "name", errorsx.ErrMissingField},
+		{"Missing version", "apiVersion: v2\nname: app\n", "version", errorsx.ErrMissingField},
+		{"Prefixed version", "apiVersion: v2\nname: app\nversion: v1.0.0\n", "version", errorsx.ErrInvalidValue},
+		{"Invalid version", "apiVersion: v2\nname: app\nversion: \"1.0\"\n", "version", errorsx.ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseChart([]byte(tt.data))
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+			field, _ := errorsx.FieldOf(err)
+			assert.Equal(t, tt.field, field)
+		})
+	}
+
+	_, err := ParseChart([]byte("name: [app"))
+	assert.Error(t, err)
+}
+
+func TestLoadChart(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ChartFileName), []byte(testChart), 0o600))
+
+	chart, err := LoadChart(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "app", chart.Name)
+
+	_, err = LoadChart(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
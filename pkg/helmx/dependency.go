@@ -0,0 +1,149 @@
+package helmx
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// DependencyAction is a `helm dependency` subcommand.
+type DependencyAction string
+
+const (
+	// DependencyUpdate resolves the dependencies of Chart.yaml, rewrites Chart.lock and
+	// downloads them to charts/.
+	DependencyUpdate DependencyAction = "update"
+	// DependencyBuild downloads the dependencies pinned in Chart.lock to charts/.
+	DependencyBuild DependencyAction = "build"
+)
+
+// DependencyBuilder generates the `helm dependency update` and `helm dependency build` commands
+// of a chart.
+type DependencyBuilder struct {
+	// action is the dependency subcommand.
+	action DependencyAction
+
+	// chartDir is the directory of the chart.
+	chartDir string
+
+	// skipRefresh does not refresh the local repository cache.
+	skipRefresh bool
+
+	// verify verifies the packages against signatures.
+	verify bool
+
+	// keyring is the keyring used to verify the packages.
+	keyring string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewDependencyUpdateBuilder creates a DependencyBuilder running `helm dependency update` on the
+// chart in 'chartDir'.
+func NewDependencyUpdateBuilder(chartDir string) *DependencyBuilder {
+	return &DependencyBuilder{action: DependencyUpdate, chartDir: chartDir}
+}
+
+// NewDependencyBuildBuilder creates a DependencyBuilder running `helm dependency build` on the
+// chart in 'chartDir'. It reproduces the dependencies pinned in Chart.lock and is preferred in CI.
+func NewDependencyBuildBuilder(chartDir string) *DependencyBuilder {
+	return &DependencyBuilder{action: DependencyBuild, chartDir: chartDir}
+}
+
+// WithSkipRefresh skips refreshing the local repository cache, e.g. when every dependency is
+// pulled from an OCI registry.
+func (b *DependencyBuilder) WithSkipRefresh(skip bool) *DependencyBuilder {
+	b.skipRefresh = skip
+	return b
+}
+
+// WithVerify verifies the downloaded packages against their signatures using 'keyring'. An
+// empty keyring uses the helm default.
+func (b *DependencyBuilder) WithVerify(keyring string) *DependencyBuilder {
+	b.verify = true
+	b.keyring = keyring
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *DependencyBuilder) WithLogger(logger *slog.Logger) *DependencyBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks that the builder configuration is complete.
+func (b *DependencyBuilder) validate() error {
+	switch b.action {
+	case DependencyUpdate, DependencyBuild:
+	default:
+		return errorsx.Unsupported(builderName, "action", b.action, "unsupported dependency action: %q", b.action)
+	}
+
+	if b.chartDir == "" {
+		return errorsx.MissingField(builderName, "chartDir", "chart directory is required")
+	}
+
+	return nil
+}
+
+// BuildCommand generates the helm dependency command.
+//
+// Returns:
+//   - The helm dependency update or build command.
+//   - An error if the chart directory is missing.
+func (b *DependencyBuilder) BuildCommand() ([]string, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"helm", "dependency", string(b.action), b.chartDir}
+
+	if b.skipRefresh {
+		cmd = append(cmd, "--skip-refresh")
+	}
+
+	if b.verify {
+		cmd = append(cmd, "--verify")
+		if b.keyring != "" {
+			cmd = append(cmd, "--keyring", b.keyring)
+		}
+	}
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *DependencyBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *DependencyBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *DependencyBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the helm dependency command.
+func (b *DependencyBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("helm dependency", b, NewDependencyUpdateBuilder(""),
+		explainx.Generate("BuildCommand", b.BuildCommand))
+}
@@ -0,0 +1,45 @@
+package helmx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencyBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  *DependencyBuilder
+		expected []string
+	}{
+		{
+			name:     "Update",
+			builder:  NewDependencyUpdateBuilder("charts/app"),
+			expected: []string{"helm", "dependency", "update", "charts/app"},
+		},
+		{
+			name:     "Build with options",
+			builder:  NewDependencyBuildBuilder("charts/app").WithSkipRefresh(true).WithVerify("/keys/pubring.gpg"),
+			expected: []string{"helm", "dependency", "build", "charts/app", "--skip-refresh", "--verify", "--keyring", "/keys/pubring.gpg"},
+		},
+		{
+			name:     "Verify with default keyring",
+			builder:  NewDependencyBuildBuilder(".").WithVerify(""),
+			expected: []string{"helm", "dependency", "build", ".", "--verify"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cmd)
+		})
+	}
+
+	_, err := NewDependencyUpdateBuilder("").BuildCommand()
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+}
@@ -0,0 +1,203 @@
+// Package helmx provides builders for helm commands used when publishing charts to OCI
+// registries: registry login backed by secretsx references, chart dependency update and build,
+// and Chart.yaml parsing to compute push destinations.
+//
+// Example usage:
+//
+//	password := secretsx.FromEnv("registry-password", "REGISTRY_PASSWORD")
+//
+//	login, err := helmx.NewRegistryLoginBuilder("registry.example.com").
+//	    WithUsername("ci").
+//	    WithPassword(password).
+//	    BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	chart, err := helmx.LoadChart("charts/app")
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	push, err := chart.PushCommand("oci://registry.example.com/charts")
+package helmx
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the helm builders in errorsx errors.
+const builderName = "helm"
+
+// HelmDefaultImage is the default container image providing helm.
+const HelmDefaultImage = "alpine/helm"
+
+// envVarNameRegex matches the environment variable names sh can expand.
+var envVarNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// RegistryLoginBuilder generates the `helm registry login` command of an OCI registry. The
+// password is read from a secret on the standard input, so it never appears in the command.
+type RegistryLoginBuilder struct {
+	// host is the registry host (e.g. "registry.example.com").
+	host string
+
+	// username is the registry username.
+	username string
+
+	// password is the secret holding the registry password or token.
+	password *secretsx.SecretRef
+
+	// insecure allows connecting to the registry over plain HTTP.
+	insecure bool
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewRegistryLoginBuilder creates a RegistryLoginBuilder for the registry 'host'. An "oci://"
+// scheme is accepted and stripped.
+func NewRegistryLoginBuilder(host string) *RegistryLoginBuilder {
+	return &RegistryLoginBuilder{host: strings.TrimPrefix(host, ociScheme)}
+}
+
+// WithUsername sets the registry username.
+func (b *RegistryLoginBuilder) WithUsername(username string) *RegistryLoginBuilder {
+	b.username = username
+	return b
+}
+
+// WithPassword sets the secret holding the registry password or token.
+func (b *RegistryLoginBuilder) WithPassword(ref *secretsx.SecretRef) *RegistryLoginBuilder {
+	b.password = ref
+	return b
+}
+
+// WithInsecure allows connecting to the registry over plain HTTP.
+func (b *RegistryLoginBuilder) WithInsecure(insecure bool) *RegistryLoginBuilder {
+	b.insecure = insecure
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *RegistryLoginBuilder) WithLogger(logger *slog.Logger) *RegistryLoginBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks that the builder configuration is complete.
+func (b *RegistryLoginBuilder) validate() error {
+	if b.host == "" {
+		return errorsx.MissingField(builderName, "host", "registry host is required")
+	}
+
+	if strings.Contains(b.host, "/") {
+		return errorsx.InvalidValue(builderName, "host", b.host, "registry host cannot contain a path")
+	}
+
+	if b.username == "" {
+		return errorsx.MissingField(builderName, "username", "registry username is required")
+	}
+
+	if b.password == nil {
+		return errorsx.MissingField(builderName, "password", "registry password secret is required")
+	}
+
+	if err := b.password.Validate(); err != nil {
+		return err
+	}
+
+	if b.password.Mount == secretsx.MountAsEnv && !envVarNameRegex.MatchString(b.password.Target) {
+		return errorsx.InvalidValue(builderName, "password", b.password.Target,
+			"password secret must be exposed as a valid environment variable name")
+	}
+
+	return nil
+}
+
+// BuildCommand generates the registry login command. The password secret is piped to
+// `--password-stdin` from its environment variable or mounted file, so the command runs with sh.
+//
+// Returns:
+//   - The sh command running helm registry login.
+//   - An error if the host, username or password is missing or invalid.
+func (b *RegistryLoginBuilder) BuildCommand() ([]string, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	login := []string{"helm", "registry", "login", b.host, "--username", b.username, "--password-stdin"}
+	if b.insecure {
+		login = append(login, "--insecure")
+	}
+
+	var script string
+	if b.password.Mount == secretsx.MountAsFile {
+		script = fmt.Sprintf("%s < %s", cmdx.ShellJoin(login), cmdx.ShellQuote(b.password.Target))
+	} else {
+		script = fmt.Sprintf(`printf '%%s' "$%s" | %s`, b.password.Target, cmdx.ShellJoin(login))
+	}
+
+	cmd := []string{"sh", "-c", script}
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Secrets returns the secrets the login command requires.
+func (b *RegistryLoginBuilder) Secrets() []*secretsx.SecretRef {
+	if b.password == nil {
+		return nil
+	}
+
+	return []*secretsx.SecretRef{b.password}
+}
+
+// Mounts returns the mount of the password secret when it is provided as a file.
+func (b *RegistryLoginBuilder) Mounts() []types.Mount {
+	if b.password == nil {
+		return nil
+	}
+
+	if m, ok := b.password.MountRequirement(); ok {
+		return []types.Mount{m}
+	}
+
+	return nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *RegistryLoginBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Env returns the environment variables the generated command requires: none. The
+// credentials are secrets (see Secrets).
+func (b *RegistryLoginBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the helm registry login command.
+func (b *RegistryLoginBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("helm registry login", b, NewRegistryLoginBuilder(""),
+		explainx.Generate("BuildCommand", b.BuildCommand))
+}
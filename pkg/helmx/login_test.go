@@ -0,0 +1,76 @@
+package helmx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryLoginBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  *RegistryLoginBuilder
+		expected string
+		mounts   []types.Mount
+	}{
+		{
+			name: "Env secret",
+			builder: NewRegistryLoginBuilder("oci://registry.example.com").
+				WithUsername("ci").
+				WithPassword(secretsx.FromEnv("registry-password", "REGISTRY_PASSWORD")),
+			expected: `printf '%s' "$REGISTRY_PASSWORD" | helm registry login registry.example.com --username ci --password-stdin`,
+		},
+		{
+			name: "File secret",
+			builder: NewRegistryLoginBuilder("localhost:5000").
+				WithUsername("ci").
+				WithPassword(secretsx.FromFile("registry-password", "/home/ci/token").AsFile("/run/secrets/registry token")).
+				WithInsecure(true),
+			expected: "helm registry login localhost:5000 --username ci --password-stdin --insecure < '/run/secrets/registry token'",
+			mounts: []types.Mount{
+				{Kind: types.MountKindSecret, Source: "registry-password", Target: "/run/secrets/registry token", ReadOnly: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			require.NoError(t, err)
+			assert.Equal(t, []string{"sh", "-c", tt.expected}, cmd)
+			assert.Len(t, tt.builder.Secrets(), 1)
+			assert.Equal(t, tt.mounts, tt.builder.Mounts())
+		})
+	}
+}
+
+func TestRegistryLoginBuilderValidation(t *testing.T) {
+	password := secretsx.FromEnv("registry-password", "REGISTRY_PASSWORD")
+
+	tests := []struct {
+		name    string
+		builder *RegistryLoginBuilder
+		kind    error
+	}{
+		{"Missing host", NewRegistryLoginBuilder("").WithUsername("ci").WithPassword(password), errorsx.ErrMissingField},
+		{"Host with path", NewRegistryLoginBuilder("registry.example.com/charts").WithUsername("ci").WithPassword(password), errorsx.ErrInvalidValue},
+		{"Missing username", NewRegistryLoginBuilder("registry.example.com").WithPassword(password), errorsx.ErrMissingField},
+		{"Missing password", NewRegistryLoginBuilder("registry.example.com").WithUsername("ci"), errorsx.ErrMissingField},
+		{"Invalid env target", NewRegistryLoginBuilder("registry.example.com").WithUsername("ci").
+			WithPassword(secretsx.FromEnv("registry-password", "REGISTRY_PASSWORD").AsEnv("$(id)")), errorsx.ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.BuildCommand()
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+		})
+	}
+
+	assert.Nil(t, NewRegistryLoginBuilder("registry.example.com").Secrets())
+}
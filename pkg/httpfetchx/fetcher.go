@@ -0,0 +1,423 @@
+// Package httpfetchx provides the HTTP fetcher of this module: a client configured once per
+// pipeline with per-host credentials (bearer token or basic auth), a custom CA bundle and a
+// proxy, downloading artifacts with retries, host throttling and resumed transfers.
+//
+// The proxy defaults to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables. The
+// keyring downloads (apkox), the digest resolution (containerx) and the release lookups
+// (githubx) use the client of the Default fetcher when they are given no client.
+//
+// Example usage:
+//
+//	fetcher := httpfetchx.NewFetcher().
+//	    WithCABundle(corporateCA).
+//	    WithBearerToken("artifacts.example.com", token)
+//	httpfetchx.SetDefault(fetcher)
+//
+//	if err := fetcher.Download(ctx, "https://artifacts.example.com/tool.tar.gz", "/tmp/tool.tar.gz"); err != nil {
+//	    // handle error
+//	}
+package httpfetchx
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/ratelimitx"
+	"github.com/Excoriate/daggerx/pkg/retryx"
+)
+
+// builderName identifies the fetcher in errorsx errors.
+const builderName = "fetcher"
+
+const (
+	// DefaultUserAgent is the User-Agent header of the requests.
+	DefaultUserAgent = "daggerx"
+	// DefaultMaxSize is the maximum size of the responses read in memory by Get.
+	DefaultMaxSize = 32 << 20
+)
+
+// credential holds the credentials of a host.
+type credential struct {
+	bearer   string
+	username string
+	password string
+}
+
+// Fetcher sends HTTP requests with the configured credentials, CA bundle and proxy. It is safe
+// for concurrent use once configured; options set after the first request are ignored.
+type Fetcher struct {
+	// credentials are the credentials of the hosts, by lowercase host.
+	credentials map[string]credential
+
+	// caBundle holds the PEM certificates trusted in addition to the system ones.
+	caBundle []byte
+
+	// proxy selects the proxy of the requests.
+	proxy func(*http.Request) (*url.URL, error)
+
+	// timeout is the timeout of the requests. Zero means no timeout.
+	timeout time.Duration
+
+	// userAgent is the User-Agent header of the requests.
+	userAgent string
+
+	// maxSize is the maximum size of the responses read by Get.
+	maxSize int64
+
+	// policy retries the transient failures. A nil policy is the default retryx policy.
+	policy *retryx.Policy
+
+	// limiter throttles the requests sent by Get and Download.
+	limiter *ratelimitx.HostLimiter
+
+	// err records an invalid option, returned by Client.
+	err error
+
+	// mu guards client.
+	mu sync.Mutex
+
+	// client is the client returned by Client, created on first use.
+	client *http.Client
+}
+
+// NewFetcher creates a Fetcher using the proxy of the environment, the system CA certificates,
+// the default retryx policy and ratelimitx.Default.
+func NewFetcher() *Fetcher {
+	return &Fetcher{
+		credentials: map[string]credential{},
+		proxy:       http.ProxyFromEnvironment,
+		userAgent:   DefaultUserAgent,
+		maxSize:     DefaultMaxSize,
+		limiter:     ratelimitx.Default(),
+	}
+}
+
+// WithBearerToken authenticates the requests to 'host' (e.g. "artifacts.example.com", with its
+// port when it isn't the default one) with a bearer token. Requests setting their own
+// Authorization header keep it.
+func (f *Fetcher) WithBearerToken(host, token string) *Fetcher {
+	f.credentials[strings.ToLower(host)] = credential{bearer: token}
+	return f
+}
+
+// WithBasicAuth authenticates the requests to 'host' with basic authentication, see
+// WithBearerToken.
+func (f *Fetcher) WithBasicAuth(host, username, password string) *Fetcher {
+	f.credentials[strings.ToLower(host)] = credential{username: username, password: password}
+	return f
+}
+
+// WithCABundle trusts the PEM certificates of 'pem' in addition to the system ones, e.g. the CA
+// of a TLS-intercepting corporate proxy.
+func (f *Fetcher) WithCABundle(pem []byte) *Fetcher {
+	if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+		f.err = errorsx.InvalidValue(builderName, "caBundle", len(pem), "CA bundle holds no PEM certificate")
+	}
+	f.caBundle = append(f.caBundle, pem...)
+	return f
+}
+
+// WithCAFile trusts the PEM certificates of the file at 'path', see WithCABundle.
+func (f *Fetcher) WithCAFile(path string) *Fetcher {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		f.err = fmt.Errorf("failed to read CA bundle: %w", err)
+		return f
+	}
+
+	return f.WithCABundle(pem)
+}
+
+// WithProxy sends the requests through the proxy 'proxyURL' instead of the proxy of the
+// environment. An empty URL disables proxies.
+func (f *Fetcher) WithProxy(proxyURL string) *Fetcher {
+	if proxyURL == "" {
+		f.proxy = nil
+		return f
+	}
+
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.Host == "" {
+		f.err = errorsx.InvalidValue(builderName, "proxy", proxyURL, "invalid proxy URL: %q", proxyURL)
+		return f
+	}
+
+	f.proxy = http.ProxyURL(u)
+	return f
+}
+
+// WithTimeout sets the timeout of the requests, body included. There is none by default.
+func (f *Fetcher) WithTimeout(timeout time.Duration) *Fetcher {
+	f.timeout = timeout
+	return f
+}
+
+// WithUserAgent sets the User-Agent header of the requests. It defaults to DefaultUserAgent.
+func (f *Fetcher) WithUserAgent(userAgent string) *Fetcher {
+	f.userAgent = userAgent
+	return f
+}
+
+// WithMaxSize sets the maximum size of the responses read by Get. It defaults to DefaultMaxSize.
+func (f *Fetcher) WithMaxSize(size int64) *Fetcher {
+	f.maxSize = size
+	return f
+}
+
+// WithRetryPolicy sets the policy retrying the transient failures of Get and Download.
+func (f *Fetcher) WithRetryPolicy(policy *retryx.Policy) *Fetcher {
+	f.policy = policy
+	return f
+}
+
+// WithRateLimiter sets the limiter throttling Get and Download. A nil limiter disables
+// throttling.
+func (f *Fetcher) WithRateLimiter(limiter *ratelimitx.HostLimiter) *Fetcher {
+	f.limiter = limiter
+	return f
+}
+
+// Client returns an HTTP client sending requests with the credentials, CA bundle, proxy and
+// timeout of the fetcher. It doesn't retry nor throttle requests, so callers doing it themselves
+// can use it.
+//
+// Returns:
+//   - The client.
+//   - An error if an option of the fetcher is invalid.
+func (f *Fetcher) Client() (*http.Client, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	if f.client != nil {
+		return f.client, nil
+	}
+
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.Proxy = f.proxy
+
+	if len(f.caBundle) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pool.AppendCertsFromPEM(f.caBundle)
+		base.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	f.client = &http.Client{
+		Transport: &authTransport{fetcher: f, base: base},
+		Timeout:   f.timeout,
+	}
+
+	return f.client, nil
+}
+
+// authTransport sets the User-Agent header and the credentials of the requests.
+type authTransport struct {
+	fetcher *Fetcher
+	base    http.RoundTripper
+}
+
+// RoundTrip sends the request with the headers of the fetcher.
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cred, hasCred := t.fetcher.credentials[strings.ToLower(req.URL.Host)]
+	if req.Header.Get("User-Agent") == "" || (hasCred && req.Header.Get("Authorization") == "") {
+		req = req.Clone(req.Context())
+	}
+
+	if req.Header.Get("User-Agent") == "" && t.fetcher.userAgent != "" {
+		req.Header.Set("User-Agent", t.fetcher.userAgent)
+	}
+
+	if hasCred && req.Header.Get("Authorization") == "" {
+		if cred.bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+cred.bearer)
+		} else {
+			req.SetBasicAuth(cred.username, cred.password)
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+// Get downloads the content at 'rawURL' in memory, retrying transient failures.
+//
+// Returns:
+//   - The content.
+//   - An error if an option is invalid, the request fails or doesn't return 200 OK, or the
+//     content exceeds the maximum size.
+func (f *Fetcher) Get(ctx context.Context, rawURL string) ([]byte, error) {
+	client, err := f.Client()
+	if err != nil {
+		return nil, err
+	}
+
+	return retryx.DoValue(ctx, f.policy, func(ctx context.Context) ([]byte, error) {
+		resp, err := f.send(ctx, client, rawURL, 0)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch %s: %w", rawURL,
+				&retryx.StatusError{StatusCode: resp.StatusCode, Status: resp.Status})
+		}
+
+		data, err := io.ReadAll(io.LimitReader(resp.Body, f.maxSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", rawURL, err)
+		}
+
+		if int64(len(data)) > f.maxSize {
+			return nil, retryx.Permanent(fmt.Errorf("%s exceeds %d bytes", rawURL, f.maxSize))
+		}
+
+		return data, nil
+	})
+}
+
+// Download downloads the content at 'rawURL' to the file 'dest', retrying transient failures.
+// The content is written to "<dest>.part" and renamed once complete; retries and later calls
+// resume an existing part file with a range request when the server supports it.
+//
+// Returns:
+//   - An error if an option is invalid, the transfer fails after the retries, or the file
+//     cannot be written.
+func (f *Fetcher) Download(ctx context.Context, rawURL, dest string) error {
+	client, err := f.Client()
+	if err != nil {
+		return err
+	}
+
+	part := dest + ".part"
+
+	err = retryx.Do(ctx, f.policy, func(ctx context.Context) error {
+		return f.downloadPart(ctx, client, rawURL, part)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(part, dest); err != nil {
+		return fmt.Errorf("failed to move download to %s: %w", dest, err)
+	}
+
+	return nil
+}
+
+// downloadPart downloads the content at 'rawURL' to the part file 'part', resuming it when it
+// exists.
+func (f *Fetcher) downloadPart(ctx context.Context, client *http.Client, rawURL, part string) error {
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+
+	resp, err := f.send(ctx, client, rawURL, offset)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		// The server ignored the range: the content is downloaded again.
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		if !strings.HasPrefix(resp.Header.Get("Content-Range"), "bytes "+strconv.FormatInt(offset, 10)+"-") {
+			_ = os.Remove(part)
+			return retryx.Retryable(fmt.Errorf("server resumed %s at an unexpected range %q",
+				rawURL, resp.Header.Get("Content-Range")))
+		}
+		flags = os.O_WRONLY | os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The part file already holds the whole content.
+		return nil
+	default:
+		return fmt.Errorf("failed to download %s: %w", rawURL,
+			&retryx.StatusError{StatusCode: resp.StatusCode, Status: resp.Status})
+	}
+
+	file, err := os.OpenFile(part, flags, 0o644)
+	if err != nil {
+		return retryx.Permanent(fmt.Errorf("failed to open %s: %w", part, err))
+	}
+
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to download %s: %w", rawURL, err)
+	}
+
+	if err := file.Close(); err != nil {
+		return retryx.Permanent(fmt.Errorf("failed to write %s: %w", part, err))
+	}
+
+	return nil
+}
+
+// send throttles and sends a GET request for 'rawURL', from the byte 'offset' when positive.
+func (f *Fetcher) send(ctx context.Context, client *http.Client, rawURL string, offset int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, retryx.Permanent(errorsx.InvalidValue(builderName, "url", rawURL, "invalid URL %q: %w", rawURL, err))
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+
+	if err := f.limiter.Wait(ctx, req.URL.Host); err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+
+	return resp, nil
+}
+
+var (
+	// defaultFetcher is the fetcher set by SetDefault.
+	defaultFetcher atomic.Pointer[Fetcher]
+	// fallbackFetcher is returned by Default when no fetcher is set.
+	fallbackFetcher = sync.OnceValue(NewFetcher)
+)
+
+// Default returns the fetcher of the pipeline, set by SetDefault, or a shared fetcher created by
+// NewFetcher.
+func Default() *Fetcher {
+	if f := defaultFetcher.Load(); f != nil {
+		return f
+	}
+
+	return fallbackFetcher()
+}
+
+// SetDefault sets the fetcher returned by Default. A nil fetcher restores the default one.
+func SetDefault(f *Fetcher) {
+	defaultFetcher.Store(f)
+}
@@ -0,0 +1,214 @@
+package httpfetchx
+
+import (
+	"bytes"
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/retryx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testContent = bytes.Repeat([]byte("0123456789"), 1000)
+
+func fastPolicy() *retryx.Policy {
+	return retryx.NewPolicy().WithBackoff(time.Millisecond, time.Millisecond)
+}
+
+// serverCA returns the PEM certificate of a TLS test server.
+func serverCA(srv *httptest.Server) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+}
+
+func TestGetWithCABundleAndCredentials(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, DefaultUserAgent, r.Header.Get("User-Agent"))
+		if r.Header.Get("Authorization") != "Bearer s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("keyring"))
+	}))
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	_, err := NewFetcher().WithRetryPolicy(fastPolicy()).Get(context.Background(), srv.URL)
+	assert.ErrorContains(t, err, "certificate", "the test CA is not trusted by default")
+
+	data, err := NewFetcher().WithCABundle(serverCA(srv)).WithBearerToken(host, "s3cr3t").
+		Get(context.Background(), srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "keyring", string(data))
+
+	_, err = NewFetcher().WithCABundle(serverCA(srv)).WithBearerToken("other.example.com", "s3cr3t").
+		Get(context.Background(), srv.URL)
+	var status *retryx.StatusError
+	require.ErrorAs(t, err, &status, "credentials are sent to their host only")
+	assert.Equal(t, http.StatusUnauthorized, status.StatusCode)
+}
+
+func TestClientBasicAuthAndProxy(t *testing.T) {
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Add(1)
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "ci", user)
+		assert.Equal(t, "pass", pass)
+		assert.Equal(t, "artifacts.example.com", r.URL.Host, "the proxy receives absolute URLs")
+		_, _ = w.Write([]byte("via proxy"))
+	}))
+	t.Cleanup(proxy.Close)
+
+	f := NewFetcher().WithProxy(proxy.URL).WithBasicAuth("ARTIFACTS.example.com", "ci", "pass")
+	data, err := f.Get(context.Background(), "http://artifacts.example.com/tool")
+	require.NoError(t, err)
+	assert.Equal(t, "via proxy", string(data))
+	assert.Equal(t, int32(1), proxied.Load())
+
+	client, err := f.Client()
+	require.NoError(t, err)
+	again, err := f.Client()
+	require.NoError(t, err)
+	assert.Same(t, client, again)
+}
+
+func TestInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		fetcher *Fetcher
+		kind    error
+	}{
+		{"CA bundle without certificate", NewFetcher().WithCABundle([]byte("not a certificate")), errorsx.ErrInvalidValue},
+		{"Relative proxy", NewFetcher().WithProxy("proxy.example.com"), errorsx.ErrInvalidValue},
+		{"Missing CA file", NewFetcher().WithCAFile(filepath.Join(t.TempDir(), "ca.pem")), os.ErrNotExist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.fetcher.Client()
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+
+			_, err = tt.fetcher.Get(context.Background(), "https://example.com")
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+		})
+	}
+}
+
+func TestGetLimits(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(testContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	_, err := NewFetcher().WithMaxSize(100).WithRetryPolicy(fastPolicy()).Get(context.Background(), srv.URL)
+	assert.ErrorContains(t, err, "exceeds 100 bytes")
+	assert.Equal(t, int32(1), calls.Load())
+
+	_, err = NewFetcher().WithRetryPolicy(fastPolicy()).Get(context.Background(), srv.URL+"/missing")
+	assert.ErrorContains(t, err, "404")
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+// newResumableServer serves testContent, and cuts the first full transfer in the middle.
+func newResumableServer(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	var (
+		cut    atomic.Bool
+		ranges []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if r.Header.Get("Range") == "" && !cut.Swap(true) {
+			w.Header().Set("Content-Length", strconv.Itoa(len(testContent)))
+			_, _ = w.Write(testContent[:len(testContent)/2])
+			return
+		}
+		http.ServeContent(w, r, "tool.tar.gz", time.Time{}, bytes.NewReader(testContent))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &ranges
+}
+
+func TestDownloadResumesInterruptedTransfers(t *testing.T) {
+	srv, ranges := newResumableServer(t)
+	dest := filepath.Join(t.TempDir(), "tool.tar.gz")
+
+	require.NoError(t, NewFetcher().WithRetryPolicy(fastPolicy()).Download(context.Background(), srv.URL, dest))
+
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, testContent, data)
+	assert.Equal(t, []string{"", "bytes=5000-"}, *ranges)
+	assert.NoFileExists(t, dest+".part")
+}
+
+func TestDownloadResumesPartFiles(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "tool.tar.gz", time.Time{}, bytes.NewReader(testContent))
+	}))
+	t.Cleanup(srv.Close)
+
+	dest := filepath.Join(t.TempDir(), "tool.tar.gz")
+
+	require.NoError(t, os.WriteFile(dest+".part", testContent[:1234], 0o644))
+	require.NoError(t, NewFetcher().Download(context.Background(), srv.URL, dest))
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, testContent, data)
+
+	// A complete part file is not downloaded again.
+	require.NoError(t, os.WriteFile(dest+".part", testContent, 0o644))
+	require.NoError(t, NewFetcher().Download(context.Background(), srv.URL, dest))
+	data, err = os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, testContent, data)
+}
+
+func TestDownloadWithoutRangeSupport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(testContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	dest := filepath.Join(t.TempDir(), "tool.tar.gz")
+	require.NoError(t, os.WriteFile(dest+".part", []byte("stale"), 0o644))
+
+	require.NoError(t, NewFetcher().Download(context.Background(), srv.URL, dest))
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, testContent, data, "the stale part file is replaced")
+}
+
+func TestDefault(t *testing.T) {
+	t.Cleanup(func() { SetDefault(nil) })
+
+	assert.Same(t, Default(), Default())
+
+	f := NewFetcher().WithUserAgent("pipeline/1.0")
+	SetDefault(f)
+	assert.Same(t, f, Default())
+
+	SetDefault(nil)
+	assert.NotSame(t, f, Default())
+}
@@ -0,0 +1,427 @@
+// Package imagepromote generates the promotion of a digest-pinned image from one registry to
+// another (e.g. from the dev registry to the prod registry) as a planx plan: the optional cosign
+// verification of the source, guards refusing to move immutable tags, the copy with crane or
+// skopeo, the tags, the verification that every promoted reference resolves to the source digest,
+// and the promotion annotations, attached with cosign sign so the digest never changes.
+//
+// The plan runs its tasks in order, one at a time, and stops at the first failure. Registry
+// credentials are configured separately (see dockerauthx).
+//
+// Example usage:
+//
+//	promotion := imagepromote.NewPromotionBuilder(
+//	    "dev.example.com/app@sha256:...", "prod.example.com/app").
+//	    WithTags("v1.3.0").
+//	    WithMutableTags("stable").
+//	    WithVerify("cosign.pub", nil).
+//	    WithAnnotation("environment", "prod").
+//	    WithSigner(func(s *cosignx.SignBuilder) *cosignx.SignBuilder {
+//	        return s.WithKey("env://COSIGN_PRIVATE_KEY")
+//	    })
+//
+//	plan, err := promotion.Plan("promote-app")
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	report, err := plan.Execute(ctx, executor)
+package imagepromote
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/builderx"
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+	"github.com/Excoriate/daggerx/pkg/cosignx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/planx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the promotion builder in errorsx errors.
+const builderName = "imagepromote"
+
+// PromotedFromAnnotation is the annotation recording the source of a promoted image.
+const PromotedFromAnnotation = "dev.daggerx.promoted-from"
+
+// Tool is the tool copying the image between registries.
+type Tool string
+
+const (
+	// ToolCrane copies with crane (see cranex.CraneDefaultImage).
+	ToolCrane Tool = "crane"
+	// ToolSkopeo copies with skopeo, preserving the digests.
+	ToolSkopeo Tool = "skopeo"
+)
+
+var (
+	// digestRegex matches sha256 digests.
+	digestRegex = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+	// tagRegex matches OCI tags.
+	tagRegex = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+)
+
+// PromotionBuilder generates the promotion plan of a digest-pinned image.
+type PromotionBuilder struct {
+	// source is the promoted image, pinned by digest.
+	source string
+
+	// target is the repository the image is promoted to.
+	target string
+
+	// tool is the tool copying the image.
+	tool Tool
+
+	// tags are the immutable tags of the promoted image: an existing tag must already point at
+	// the digest.
+	tags []string
+
+	// mutableTags are the tags moved to the promoted image (e.g. "stable").
+	mutableTags []string
+
+	// verifyKey is the key verifying the signature of the source.
+	verifyKey string
+
+	// verifyPolicy is the policy verifying the signature of the source.
+	verifyPolicy *cosignx.VerifyPolicy
+
+	// verify enables the verification of the source signature.
+	verify bool
+
+	// annotations are the annotations attached to the promoted image.
+	annotations map[string]string
+
+	// signer configures the cosign sign command attaching the annotations.
+	signer func(*cosignx.SignBuilder) *cosignx.SignBuilder
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+var _ builderx.SecretBuilder = (*PromotionBuilder)(nil)
+
+// NewPromotionBuilder creates a PromotionBuilder promoting 'source', an image pinned by digest
+// (e.g. "dev.example.com/app@sha256:..."; a tag before the digest is ignored), to the repository
+// 'target' (e.g. "prod.example.com/app"), with crane.
+func NewPromotionBuilder(source, target string) *PromotionBuilder {
+	return &PromotionBuilder{source: source, target: target, tool: ToolCrane, annotations: map[string]string{}}
+}
+
+// WithTool sets the tool copying the image, ToolCrane or ToolSkopeo.
+func (b *PromotionBuilder) WithTool(tool Tool) *PromotionBuilder {
+	b.tool = tool
+	return b
+}
+
+// WithTags adds immutable tags of the promoted image (e.g. "v1.3.0"). The promotion fails before
+// copying anything when one of them already points at another digest.
+func (b *PromotionBuilder) WithTags(tags ...string) *PromotionBuilder {
+	b.tags = append(b.tags, tags...)
+	return b
+}
+
+// WithMutableTags adds tags moved to the promoted image, wherever they pointed before (e.g.
+// "stable", "prod").
+func (b *PromotionBuilder) WithMutableTags(tags ...string) *PromotionBuilder {
+	b.mutableTags = append(b.mutableTags, tags...)
+	return b
+}
+
+// WithVerify verifies the cosign signature of the source before the copy, with the key 'key' or,
+// when empty, keylessly with 'policy', which may also constrain the key-based verification.
+func (b *PromotionBuilder) WithVerify(key string, policy *cosignx.VerifyPolicy) *PromotionBuilder {
+	b.verify, b.verifyKey, b.verifyPolicy = true, key, policy
+	return b
+}
+
+// WithAnnotation sets the annotation 'key' attached to the promoted image. Annotations are
+// attached with cosign sign (see WithSigner), with PromotedFromAnnotation, since changing the
+// manifest annotations would change the digest.
+func (b *PromotionBuilder) WithAnnotation(key, value string) *PromotionBuilder {
+	b.annotations[key] = value
+	return b
+}
+
+// WithSigner sets how the cosign sign command attaching the annotations is configured (e.g. its
+// key, or its keyless options). Without signer, the command signs keylessly with the defaults of
+// cosign.
+func (b *PromotionBuilder) WithSigner(configure func(*cosignx.SignBuilder) *cosignx.SignBuilder) *PromotionBuilder {
+	b.signer = configure
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *PromotionBuilder) WithLogger(logger *slog.Logger) *PromotionBuilder {
+	b.logger = logger
+	return b
+}
+
+// Digest returns the digest of the source, empty if the source isn't pinned by digest.
+func (b *PromotionBuilder) Digest() string {
+	_, digest, _ := strings.Cut(b.source, "@")
+	return digest
+}
+
+// TargetRef returns the reference of the promoted image, pinned by the digest of the source.
+func (b *PromotionBuilder) TargetRef() string {
+	return b.target + "@" + b.Digest()
+}
+
+// validate checks the options of the promotion.
+func (b *PromotionBuilder) validate() error {
+	name, digest, ok := strings.Cut(b.source, "@")
+	if b.source == "" {
+		return errorsx.MissingField(builderName, "source", "source image is required")
+	}
+
+	if !ok || name == "" || !digestRegex.MatchString(digest) {
+		return errorsx.InvalidValue(builderName, "source", b.source,
+			"source image must be pinned by a sha256 digest: %q", b.source)
+	}
+
+	if b.target == "" {
+		return errorsx.MissingField(builderName, "target", "target repository is required")
+	}
+
+	if strings.Contains(b.target, "@") || strings.LastIndex(b.target, ":") > strings.LastIndex(b.target, "/") {
+		return errorsx.InvalidValue(builderName, "target", b.target,
+			"target must be a repository, without tag or digest: %q", b.target)
+	}
+
+	if b.tool != ToolCrane && b.tool != ToolSkopeo {
+		return errorsx.Unsupported(builderName, "tool", b.tool, "unsupported copy tool: %q", b.tool)
+	}
+
+	seen := map[string]bool{}
+	for _, tag := range append(append([]string(nil), b.tags...), b.mutableTags...) {
+		if !tagRegex.MatchString(tag) {
+			return errorsx.InvalidValue(builderName, "tags", tag, "invalid tag: %q", tag)
+		}
+
+		if seen[tag] {
+			return errorsx.ConflictingOptions(builderName, []string{"tags", "mutableTags"},
+				"tag %q is set more than once", tag)
+		}
+		seen[tag] = true
+	}
+
+	if b.tool == ToolSkopeo && len(seen) == 0 {
+		return errorsx.MissingField(builderName, "tags", "skopeo copies to a tag; at least one tag is required")
+	}
+
+	if _, ok := b.annotations[""]; ok {
+		return errorsx.InvalidValue(builderName, "annotations", "", "annotations require a key")
+	}
+
+	if b.signer != nil && len(b.annotations) == 0 {
+		return errorsx.MissingField(builderName, "annotations", "a signer requires annotations to attach")
+	}
+
+	return nil
+}
+
+// Plan generates the promotion plan. Its tasks run in order, one at a time, and the plan stops at
+// the first failure:
+//   - "verify-source": the cosign verification of the source, with WithVerify.
+//   - "guard-<tag>": per immutable tag, fails if the tag exists in the target and points at
+//     another digest.
+//   - "copy": the copy of the source, with every platform, to the first tag (immutable tags
+//     first) or, without tags, to the digest.
+//   - "tag-<tag>": per other tag, tags the promoted digest.
+//   - "verify-digest" and "verify-<tag>": fail unless the promoted digest and every tag resolve
+//     to the source digest.
+//   - "annotate": attaches the annotations with cosign sign, with WithAnnotation.
+//
+// Returns:
+//   - The promotion plan.
+//   - An error if the source isn't pinned by digest, the target isn't a repository, the tool or a
+//     tag is invalid, skopeo has no tag, or the verification or signing options are invalid.
+func (b *PromotionBuilder) Plan(name string) (*planx.Plan, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	digest := b.Digest()
+	tags := append(append([]string(nil), b.tags...), b.mutableTags...)
+
+	var tasks []planx.Task
+	if b.verify {
+		cmd, err := cosignx.NewVerifyBuilder(b.source).
+			WithKey(b.verifyKey).
+			WithPolicy(b.verifyPolicy).
+			WithLogger(b.logger).
+			BuildCommand()
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, planx.Task{ID: "verify-source", Command: cmd})
+	}
+
+	for _, tag := range b.tags {
+		ref := b.target + ":" + tag
+		tasks = append(tasks, planx.Task{ID: "guard-" + tag, Command: shellCommand(fmt.Sprintf(
+			`if { %s; } 2>/dev/null; then [ "$d" = %s ] || { echo %s >&2; exit 1; }; fi`,
+			b.digestScript(ref), digest,
+			cmdx.ShellQuote(fmt.Sprintf("imagepromote: %s is immutable and points at another digest", ref))))})
+	}
+
+	dst := b.TargetRef()
+	if len(tags) > 0 {
+		dst = b.target + ":" + tags[0]
+	}
+	tasks = append(tasks, planx.Task{ID: "copy", Command: b.copyCommand(b.source, dst)})
+
+	for i, tag := range tags {
+		if i == 0 {
+			continue
+		}
+		tasks = append(tasks, planx.Task{ID: "tag-" + tag, Command: b.tagCommand(tag)})
+	}
+
+	verify := []struct{ id, ref string }{{"verify-digest", b.TargetRef()}}
+	for _, tag := range tags {
+		verify = append(verify, struct{ id, ref string }{"verify-" + tag, b.target + ":" + tag})
+	}
+
+	for _, v := range verify {
+		tasks = append(tasks, planx.Task{ID: v.id, Command: shellCommand(fmt.Sprintf(
+			`%s && [ "$d" = %s ] || { echo %s >&2; exit 1; }`,
+			b.digestScript(v.ref), digest,
+			cmdx.ShellQuote(fmt.Sprintf("imagepromote: %s does not resolve to %s", v.ref, digest))))})
+	}
+
+	if len(b.annotations) > 0 {
+		cmd, err := b.signBuilder().BuildCommand()
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, planx.Task{ID: "annotate", Command: cmd})
+	}
+
+	plan := planx.NewPlan(name).WithConcurrency(1).WithFailFast(true)
+	for _, t := range tasks {
+		logx.Command(ctx, b.logger, builderName, t.Command)
+
+		if err := plan.AddTask(t); err != nil {
+			return nil, errorsx.ConflictingOptions(builderName, []string{"tags"}, "%v", err)
+		}
+	}
+
+	return plan, nil
+}
+
+// Secrets returns the secrets the promotion requires: those of the cosign sign command attaching
+// the annotations, if any.
+func (b *PromotionBuilder) Secrets() []*secretsx.SecretRef {
+	if len(b.annotations) == 0 {
+		return nil
+	}
+
+	return b.signBuilder().Secrets()
+}
+
+// signBuilder returns the cosign sign builder attaching the annotations to the promoted digest.
+func (b *PromotionBuilder) signBuilder() *cosignx.SignBuilder {
+	sign := cosignx.NewSignBuilder(b.TargetRef()).WithLogger(b.logger)
+	if b.signer != nil {
+		sign = b.signer(sign)
+	}
+
+	keys := make([]string, 0, len(b.annotations))
+	for k := range b.annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		sign.WithAnnotation(k, b.annotations[k])
+	}
+
+	return sign.WithAnnotation(PromotedFromAnnotation, b.source)
+}
+
+// copyCommand returns the command copying 'src', with every platform, to 'dst', preserving its
+// digest.
+func (b *PromotionBuilder) copyCommand(src, dst string) []string {
+	if b.tool == ToolSkopeo {
+		return []string{"skopeo", "copy", "--all", "--preserve-digests", "docker://" + src, "docker://" + dst}
+	}
+
+	return []string{"crane", "copy", src, dst}
+}
+
+// tagCommand returns the command tagging the promoted digest with 'tag'.
+func (b *PromotionBuilder) tagCommand(tag string) []string {
+	if b.tool == ToolSkopeo {
+		return b.copyCommand(b.TargetRef(), b.target+":"+tag)
+	}
+
+	return []string{"crane", "tag", b.TargetRef(), tag}
+}
+
+// digestScript returns the sh commands setting d to the digest of the manifest of 'ref',
+// failing when it can't be fetched. skopeo reports the digest of the platform image, so the
+// digest of the raw manifest is computed instead.
+func (b *PromotionBuilder) digestScript(ref string) string {
+	if b.tool == ToolSkopeo {
+		return fmt.Sprintf(`m=$(mktemp) && skopeo inspect --raw %s > "$m" && d="sha256:$(sha256sum "$m" | cut -d ' ' -f 1)"`,
+			cmdx.ShellQuote("docker://"+ref))
+	}
+
+	return fmt.Sprintf(`d=$(crane digest %s)`, cmdx.ShellQuote(ref))
+}
+
+// shellCommand returns the sh command running 'script'.
+func shellCommand(script string) []string {
+	return []string{"sh", "-c", script}
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *PromotionBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// BuildCommand generates the sh command running the tasks of the promotion plan one after
+// another, stopping at the first failure (see planx.Plan.Script).
+//
+// Returns:
+//   - The sh command.
+//   - An error if the promotion is invalid, as for Plan.
+func (b *PromotionBuilder) BuildCommand() ([]string, error) {
+	plan, err := b.Plan(builderName)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.Script()
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *PromotionBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *PromotionBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the commands of the tasks
+// of its promotion plan.
+func (b *PromotionBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewPromotionBuilder("", ""), explainx.PlanCommands(b.Plan(builderName))...)
+}
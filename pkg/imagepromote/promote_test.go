@@ -0,0 +1,239 @@
+package imagepromote
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/cosignx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func taskIDs(t *testing.T, b *PromotionBuilder) []string {
+	t.Helper()
+
+	plan, err := b.Plan("promote")
+	require.NoError(t, err)
+
+	var ids []string
+	for _, task := range plan.Tasks() {
+		ids = append(ids, task.ID)
+	}
+
+	return ids
+}
+
+func TestPromotionBuilderPlan(t *testing.T) {
+	b := NewPromotionBuilder("dev.example.com/app:v1.3.0@"+testDigest, "prod.example.com/app").
+		WithTags("v1.3.0").
+		WithMutableTags("stable").
+		WithVerify("cosign.pub", nil).
+		WithAnnotation("environment", "prod").
+		WithSigner(func(s *cosignx.SignBuilder) *cosignx.SignBuilder {
+			return s.WithKey("env://COSIGN_PRIVATE_KEY")
+		})
+
+	plan, err := b.Plan("promote")
+	require.NoError(t, err)
+	assert.Equal(t, 1, plan.Concurrency())
+
+	tasks := plan.Tasks()
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	assert.Equal(t, []string{
+		"verify-source", "guard-v1.3.0", "copy", "tag-stable", "verify-digest", "verify-v1.3.0",
+		"verify-stable", "annotate",
+	}, ids)
+
+	target := "prod.example.com/app@" + testDigest
+	assert.Equal(t, []string{"cosign", "verify", "--key", "cosign.pub", "dev.example.com/app:v1.3.0@" + testDigest},
+		[]string(tasks[0].Command))
+	assert.Equal(t, []string{"crane", "copy", "dev.example.com/app:v1.3.0@" + testDigest, "prod.example.com/app:v1.3.0"},
+		[]string(tasks[2].Command))
+	assert.Equal(t, []string{"crane", "tag", target, "stable"}, []string(tasks[3].Command))
+	assert.Equal(t, []string{
+		"sh", "-c", `d=$(crane digest '` + target + `') && [ "$d" = ` + testDigest + ` ] || ` +
+			`{ echo 'imagepromote: ` + target + ` does not resolve to ` + testDigest + `' >&2; exit 1; }`,
+	}, []string(tasks[4].Command))
+	assert.Equal(t, []string{
+		"cosign", "sign", "--yes", "--key", "env://COSIGN_PRIVATE_KEY",
+		"--annotations", PromotedFromAnnotation + "=dev.example.com/app:v1.3.0@" + testDigest,
+		"--annotations", "environment=prod", target,
+	}, []string(tasks[7].Command))
+
+	assert.Equal(t, target, b.TargetRef())
+	assert.Equal(t, testDigest, b.Digest())
+}
+
+func TestPromotionBuilderPlanVariants(t *testing.T) {
+	src := "dev.example.com/app@" + testDigest
+
+	assert.Equal(t, []string{"copy", "verify-digest"}, taskIDs(t, NewPromotionBuilder(src, "prod.example.com/app")))
+
+	plan, err := NewPromotionBuilder(src, "prod.example.com/app").WithTool(ToolSkopeo).WithTags("v1", "v1.3").Plan("p")
+	require.NoError(t, err)
+
+	tasks := plan.Tasks()
+	assert.Equal(t, []string{
+		"skopeo", "copy", "--all", "--preserve-digests", "docker://" + src, "docker://prod.example.com/app:v1",
+	}, []string(tasks[2].Command))
+	assert.Equal(t, []string{
+		"skopeo", "copy", "--all", "--preserve-digests", "docker://prod.example.com/app@" + testDigest,
+		"docker://prod.example.com/app:v1.3",
+	}, []string(tasks[3].Command))
+	assert.Contains(t, tasks[4].Command[2], "skopeo inspect --raw 'docker://prod.example.com/app@"+testDigest+"'")
+}
+
+func TestPromotionBuilderBuildCommand(t *testing.T) {
+	b := NewPromotionBuilder("dev.example.com/app@"+testDigest, "prod.example.com/app").WithMutableTags("stable")
+
+	plan, err := b.Plan(builderName)
+	require.NoError(t, err)
+	want, err := plan.Script()
+	require.NoError(t, err)
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string(want), cmd)
+	assert.True(t, strings.HasPrefix(cmd[2], "crane copy 'dev.example.com/app@"+testDigest+"' prod.example.com/app:stable && "))
+
+	_, err = NewPromotionBuilder("dev.example.com/app:v1", "prod.example.com/app").BuildCommand()
+	assert.ErrorIs(t, err, errorsx.ErrInvalidValue)
+}
+
+func TestPromotionBuilderValidation(t *testing.T) {
+	src := "dev.example.com/app@" + testDigest
+
+	tests := []struct {
+		name    string
+		builder *PromotionBuilder
+		err     error
+	}{
+		{"Missing source", NewPromotionBuilder("", "prod.example.com/app"), errorsx.ErrMissingField},
+		{
+			"Tag only source",
+			NewPromotionBuilder("dev.example.com/app:v1", "prod.example.com/app"),
+			errorsx.ErrInvalidValue,
+		},
+		{
+			"Bad digest",
+			NewPromotionBuilder("dev.example.com/app@sha256:abc", "prod.example.com/app"),
+			errorsx.ErrInvalidValue,
+		},
+		{"Missing target", NewPromotionBuilder(src, ""), errorsx.ErrMissingField},
+		{"Tagged target", NewPromotionBuilder(src, "prod.example.com/app:v1"), errorsx.ErrInvalidValue},
+		{"Pinned target", NewPromotionBuilder(src, "prod.example.com/app@"+testDigest), errorsx.ErrInvalidValue},
+		{"Tool", NewPromotionBuilder(src, "prod.example.com/app").WithTool("oras"), errorsx.ErrUnsupported},
+		{"Invalid tag", NewPromotionBuilder(src, "prod.example.com/app").WithTags("-v1"), errorsx.ErrInvalidValue},
+		{
+			"Repeated tag",
+			NewPromotionBuilder(src, "prod.example.com/app").WithTags("v1").WithMutableTags("v1"),
+			errorsx.ErrConflictingOptions,
+		},
+		{
+			"Skopeo without tag",
+			NewPromotionBuilder(src, "prod.example.com/app").WithTool(ToolSkopeo),
+			errorsx.ErrMissingField,
+		},
+		{
+			"Empty annotation key",
+			NewPromotionBuilder(src, "prod.example.com/app").WithAnnotation("", "x"),
+			errorsx.ErrInvalidValue,
+		},
+		{
+			"Signer without annotations",
+			NewPromotionBuilder(src, "prod.example.com/app").WithSigner(func(s *cosignx.SignBuilder) *cosignx.SignBuilder {
+				return s
+			}),
+			errorsx.ErrMissingField,
+		},
+		{
+			"Keyless verification without policy",
+			NewPromotionBuilder(src, "prod.example.com/app").WithVerify("", nil),
+			errorsx.ErrMissingField,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Plan("promote")
+			assert.True(t, errors.Is(err, tt.err), "got %v", err)
+		})
+	}
+}
+
+func TestPromotionBuilderSecrets(t *testing.T) {
+	b := NewPromotionBuilder("dev.example.com/app@"+testDigest, "prod.example.com/app")
+	assert.Nil(t, b.Secrets())
+
+	b.WithAnnotation("environment", "prod").WithSigner(func(s *cosignx.SignBuilder) *cosignx.SignBuilder {
+		return s.WithIdentityTokenEnv("CI_ID_TOKEN")
+	})
+
+	secrets := b.Secrets()
+	require.Len(t, secrets, 1)
+	assert.Equal(t, secretsx.MountAsEnv, secrets[0].Mount)
+	assert.Equal(t, cosignx.IdentityTokenEnvVar, secrets[0].Target)
+}
+
+// TestGuardScript runs the guard of an immutable tag with a fake crane.
+func TestGuardScript(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	plan, err := NewPromotionBuilder("dev.example.com/app@"+testDigest, "prod.example.com/app").WithTags("v1").Plan("p")
+	require.NoError(t, err)
+	guard := plan.Tasks()[0].Command
+
+	for _, tt := range []struct {
+		name   string
+		crane  string
+		failed bool
+	}{
+		{"Missing tag", "echo 'MANIFEST_UNKNOWN' >&2; exit 1", false},
+		{"Same digest", "echo " + testDigest, false},
+		{"Other digest", "echo sha256:ffff", true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "crane"), []byte("#!/bin/sh\n"+tt.crane+"\n"), 0o755))
+
+			cmd := exec.Command(guard[0], guard[1:]...)
+			cmd.Env = append(os.Environ(), "PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+			out, err := cmd.CombinedOutput()
+
+			assert.Equal(t, tt.failed, err != nil, string(out))
+			if tt.failed {
+				assert.True(t, strings.Contains(string(out), "is immutable"), string(out))
+			}
+		})
+	}
+}
+
+func TestPromotionBuilderExplain(t *testing.T) {
+	e := NewPromotionBuilder("dev.example.com/app@"+testDigest, "prod.example.com/app").WithTags("v1.3.0").Explain()
+
+	tags, ok := e.Option("tags")
+	require.True(t, ok)
+	assert.Equal(t, explainx.SourceSet, tags.Source)
+
+	require.NotEmpty(t, e.Commands)
+	assert.Equal(t, "guard-v1.3.0", e.Commands[0].Name)
+	assert.Empty(t, e.Commands[0].Error)
+
+	e = NewPromotionBuilder("dev.example.com/app:v1", "prod.example.com/app").Explain()
+	require.Len(t, e.Commands, 1)
+	assert.Contains(t, e.Commands[0].Error, "sha256 digest")
+}
@@ -0,0 +1,56 @@
+package installerx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ApkoInstallParams represents the parameters for installing apko
+type ApkoInstallParams struct {
+	// Version of apko to install (e.g., "0.19.6")
+	Version string
+	// InstallDir is the directory to install apko. If empty, defaults to DefaultInstallDir
+	InstallDir string
+	// OS is the target operating system, "linux" or "darwin" (e.g. for the apko binary of a
+	// macOS host). If empty, defaults to DefaultOS
+	OS string
+	// Arch is the target architecture, "amd64" or "arm64" ("x86_64" and "aarch64" are accepted).
+	// If empty, defaults to DefaultArch
+	Arch string
+	// Checksum is the OCI digest of the release archive. When set, the archive is verified
+	// before it is extracted
+	Checksum string
+}
+
+// GetApkoInstallCommand returns the command installing the apko release binary of a specific
+// version from GitHub.
+//
+// Parameters:
+// - params: ApkoInstallParams struct containing installation parameters
+//
+// Returns:
+// - A string representing the installation command
+// - An error if the version is missing or the checksum is invalid
+func GetApkoInstallCommand(params ApkoInstallParams) (string, error) {
+	if params.Version == "" {
+		return "", fmt.Errorf("version is required")
+	}
+
+	platform := targetPlatform(params.OS, params.Arch)
+
+	// Release archives hold the binary in a directory named after the archive.
+	dir := fmt.Sprintf("apko_%s_%s_%s", strings.TrimPrefix(params.Version, "v"), platform.OS, platform.Arch)
+
+	return GetGitHubAssetInstallCommand(GitHubAssetParams{
+		Owner:        "chainguard-dev",
+		Repo:         "apko",
+		Version:      params.Version,
+		AssetPattern: "apko_{version}_{os}_{arch}.tar.gz",
+		InstallDir:   params.InstallDir,
+		BinaryName:   "apko",
+		OS:           platform.OS,
+		Arch:         platform.Arch,
+		ExtractPath:  dir + "/apko",
+		Checksum:     params.Checksum,
+	})
+}
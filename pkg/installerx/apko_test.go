@@ -0,0 +1,76 @@
+package installerx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetApkoInstallCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  ApkoInstallParams
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "Default parameters",
+			params: ApkoInstallParams{Version: "0.19.6"},
+			want: `set -ex
+curl -fL https://github.com/chainguard-dev/apko/releases/download/v0.19.6/apko_0.19.6_linux_amd64.tar.gz -o /tmp/apko_0.19.6_linux_amd64.tar.gz
+cd /tmp && tar -xzf apko_0.19.6_linux_amd64.tar.gz
+mv /tmp/apko_0.19.6_linux_amd64/apko /usr/local/bin/apko
+chmod +x /usr/local/bin/apko
+rm -f /tmp/apko_0.19.6_linux_amd64.tar.gz`,
+		},
+		{
+			name:   "Custom install directory and architecture",
+			params: ApkoInstallParams{Version: "v0.19.6", InstallDir: "/opt/bin", Arch: "arm64"},
+			want: `set -ex
+curl -fL https://github.com/chainguard-dev/apko/releases/download/v0.19.6/apko_0.19.6_linux_arm64.tar.gz -o /tmp/apko_0.19.6_linux_arm64.tar.gz
+cd /tmp && tar -xzf apko_0.19.6_linux_arm64.tar.gz
+mv /tmp/apko_0.19.6_linux_arm64/apko /opt/bin/apko
+chmod +x /opt/bin/apko
+rm -f /tmp/apko_0.19.6_linux_arm64.tar.gz`,
+		},
+		{
+			name:   "macOS host on Apple silicon",
+			params: ApkoInstallParams{Version: "0.19.6", InstallDir: "/opt/bin", OS: "darwin", Arch: "aarch64"},
+			want: `set -ex
+curl -fL https://github.com/chainguard-dev/apko/releases/download/v0.19.6/apko_0.19.6_darwin_arm64.tar.gz -o /tmp/apko_0.19.6_darwin_arm64.tar.gz
+cd /tmp && tar -xzf apko_0.19.6_darwin_arm64.tar.gz
+mv /tmp/apko_0.19.6_darwin_arm64/apko /opt/bin/apko
+chmod +x /opt/bin/apko
+rm -f /tmp/apko_0.19.6_darwin_arm64.tar.gz`,
+		},
+		{
+			name:    "Missing version",
+			params:  ApkoInstallParams{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetApkoInstallCommand(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetApkoInstallCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetApkoInstallCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetApkoInstallCommandChecksum(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	got, err := GetApkoInstallCommand(ApkoInstallParams{Version: "0.19.6", Checksum: digest})
+	if err != nil {
+		t.Fatalf("GetApkoInstallCommand() unexpected error: %v", err)
+	}
+
+	if !strings.Contains(got, "sha256sum") {
+		t.Errorf("GetApkoInstallCommand() = %v, want a checksum verification", got)
+	}
+}
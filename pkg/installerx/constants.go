@@ -1,6 +1,27 @@
 package installerx
 
+import "github.com/Excoriate/daggerx/pkg/platformx"
+
 const (
 	// DefaultInstallDir is the default directory for installing binaries
 	DefaultInstallDir = "/usr/local/bin"
+	// DefaultOS is the default target operating system, the one of the containers
+	DefaultOS = platformx.OSLinux
+	// DefaultArch is the default target architecture
+	DefaultArch = "amd64"
 )
+
+// targetPlatform returns the platform of the 'osName' and 'arch' parameters, DefaultOS and
+// DefaultArch when empty. The architecture is normalized to the Go name the release assets use
+// (e.g. "arm64" for "aarch64").
+func targetPlatform(osName, arch string) platformx.Platform {
+	if osName == "" {
+		osName = DefaultOS
+	}
+
+	if arch == "" {
+		arch = DefaultArch
+	}
+
+	return platformx.Platform{OS: osName, Arch: platformx.NormalizeArch(arch)}
+}
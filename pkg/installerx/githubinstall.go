@@ -2,8 +2,12 @@ package installerx
 
 import (
 	"fmt"
-	"path/filepath"
+	"path"
 	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/archivex"
+	"github.com/Excoriate/daggerx/pkg/checksumx"
+	"github.com/Excoriate/daggerx/pkg/platformx"
 )
 
 // GitHubAssetParams represents parameters for downloading a GitHub release asset
@@ -23,13 +27,17 @@ type GitHubAssetParams struct {
 	InstallDir string
 	// BinaryName is the name of the binary to be installed after extraction
 	BinaryName string
-	// OS target operating system (defaults to "linux")
+	// OS target operating system (defaults to "linux"). On "windows", the binary is installed
+	// with the ".exe" suffix
 	OS string
-	// Arch target architecture (defaults to "amd64")
+	// Arch target architecture (defaults to "amd64"), as named in the asset pattern
 	Arch string
 	// ExtractPath specifies the path to the binary within the archive
 	// If empty, BinaryName will be used
 	ExtractPath string
+	// Checksum is the OCI digest of the asset (e.g. "sha256:<hex>"). When set, the downloaded
+	// asset is verified before it is extracted or installed
+	Checksum string
 }
 
 // GetGitHubAssetInstallCommand generates a command to download and install a GitHub release asset
@@ -67,15 +75,20 @@ func GetGitHubAssetInstallCommand(params GitHubAssetParams) (string, error) {
 	}
 
 	if params.OS == "" {
-		params.OS = "linux"
+		params.OS = DefaultOS
 	}
 
 	if params.Arch == "" {
-		params.Arch = "amd64"
+		params.Arch = DefaultArch
+	}
+
+	binary := params.BinaryName
+	if suffix := (platformx.Platform{OS: params.OS}).ExeSuffix(); !strings.HasSuffix(binary, suffix) {
+		binary += suffix
 	}
 
 	if params.ExtractPath == "" {
-		params.ExtractPath = params.BinaryName
+		params.ExtractPath = binary
 	}
 
 	// Ensure version has 'v' prefix
@@ -96,32 +109,46 @@ func GetGitHubAssetInstallCommand(params GitHubAssetParams) (string, error) {
 		asset = params.AssetName
 	}
 
-	installPath := filepath.Join(params.InstallDir, params.BinaryName)
-	isTarGz := strings.HasSuffix(strings.ToLower(asset), ".tar.gz") || strings.HasSuffix(strings.ToLower(asset), ".tgz")
-	isZip := strings.HasSuffix(strings.ToLower(asset), ".zip")
+	installPath := path.Join(params.InstallDir, binary)
+	// Assets without an archive extension are direct binaries.
+	format, _ := archivex.DetectFormat(asset)
 
 	// Build the command based on the asset type
-	var command string
+	var command, download string
 
 	switch {
-	case isTarGz:
+	case format == archivex.FormatTarGz:
 		command = fmt.Sprintf(`set -ex
 curl -fL https://github.com/%[1]s/%[2]s/releases/download/%[3]s/%[4]s -o /tmp/%[4]s
 cd /tmp && tar -xzf %[4]s
 mv /tmp/%[5]s %[6]s
 chmod +x %[6]s
 rm -f /tmp/%[4]s`, params.Owner, params.Repo, version, asset, params.ExtractPath, installPath)
-	case isZip:
+		download = "/tmp/" + asset
+	case format == archivex.FormatZip:
 		command = fmt.Sprintf(`set -ex
 curl -fL https://github.com/%[1]s/%[2]s/releases/download/%[3]s/%[4]s -o /tmp/%[4]s
 cd /tmp && unzip -o %[4]s
 mv /tmp/%[5]s %[6]s
 chmod +x %[6]s
 rm -f /tmp/%[4]s`, params.Owner, params.Repo, version, asset, params.ExtractPath, installPath)
+		download = "/tmp/" + asset
 	default:
 		command = fmt.Sprintf(`set -ex
 curl -fL https://github.com/%[1]s/%[2]s/releases/download/%[3]s/%[4]s -o %[5]s
 chmod +x %[5]s`, params.Owner, params.Repo, version, asset, installPath)
+		download = installPath
+	}
+
+	if params.Checksum != "" {
+		verify, err := checksumx.VerifyCommand(download, params.Checksum)
+		if err != nil {
+			return "", fmt.Errorf("invalid checksum: %w", err)
+		}
+
+		// Verify right after the download line.
+		lines := strings.SplitN(command, "\n", 3)
+		command = strings.Join(append(lines[:2], append([]string{verify}, lines[2:]...)...), "\n")
 	}
 
 	return strings.TrimSpace(command), nil
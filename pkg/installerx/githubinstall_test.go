@@ -43,6 +43,24 @@ curl -fL https://github.com/gruntwork-io/terragrunt/releases/download/v0.38.0/te
 chmod +x /usr/local/bin/terragrunt`,
 			wantErr: false,
 		},
+		{
+			name: "zip archive for a windows host",
+			params: GitHubAssetParams{
+				Owner:        "terraform-docs",
+				Repo:         "terraform-docs",
+				Version:      "0.19.0",
+				AssetPattern: "terraform-docs-v{version}-{os}-{arch}.zip",
+				BinaryName:   "terraform-docs",
+				OS:           "windows",
+			},
+			want: `set -ex
+curl -fL https://github.com/terraform-docs/terraform-docs/releases/download/v0.19.0/terraform-docs-v0.19.0-windows-amd64.zip -o /tmp/terraform-docs-v0.19.0-windows-amd64.zip
+cd /tmp && unzip -o terraform-docs-v0.19.0-windows-amd64.zip
+mv /tmp/terraform-docs.exe /usr/local/bin/terraform-docs.exe
+chmod +x /usr/local/bin/terraform-docs.exe
+rm -f /tmp/terraform-docs-v0.19.0-windows-amd64.zip`,
+			wantErr: false,
+		},
 		{
 			name: "direct asset name without pattern",
 			params: GitHubAssetParams{
@@ -105,6 +123,53 @@ rm -f /tmp/tool-1.0.0.tgz`,
 			},
 			wantErr: true,
 		},
+		{
+			name: "direct binary download with checksum",
+			params: GitHubAssetParams{
+				Owner:        "gruntwork-io",
+				Repo:         "terragrunt",
+				Version:      "v0.38.0",
+				AssetPattern: "terragrunt_{os}_{arch}",
+				BinaryName:   "terragrunt",
+				Checksum:     "sha256:" + strings.Repeat("ab", 32),
+			},
+			want: `set -ex
+curl -fL https://github.com/gruntwork-io/terragrunt/releases/download/v0.38.0/terragrunt_linux_amd64 -o /usr/local/bin/terragrunt
+echo '` + strings.Repeat("ab", 32) + `  /usr/local/bin/terragrunt' | sha256sum -c -
+chmod +x /usr/local/bin/terragrunt`,
+			wantErr: false,
+		},
+		{
+			name: "tarball with checksum",
+			params: GitHubAssetParams{
+				Owner:      "cli",
+				Repo:       "cli",
+				Version:    "v2.0.0",
+				AssetName:  "gh_2.0.0_linux_amd64.tar.gz",
+				BinaryName: "gh",
+				Checksum:   "sha512:" + strings.Repeat("cd", 64),
+			},
+			want: `set -ex
+curl -fL https://github.com/cli/cli/releases/download/v2.0.0/gh_2.0.0_linux_amd64.tar.gz -o /tmp/gh_2.0.0_linux_amd64.tar.gz
+echo '` + strings.Repeat("cd", 64) + `  /tmp/gh_2.0.0_linux_amd64.tar.gz' | sha512sum -c -
+cd /tmp && tar -xzf gh_2.0.0_linux_amd64.tar.gz
+mv /tmp/gh /usr/local/bin/gh
+chmod +x /usr/local/bin/gh
+rm -f /tmp/gh_2.0.0_linux_amd64.tar.gz`,
+			wantErr: false,
+		},
+		{
+			name: "invalid checksum",
+			params: GitHubAssetParams{
+				Owner:      "cli",
+				Repo:       "cli",
+				Version:    "v2.0.0",
+				AssetName:  "gh",
+				BinaryName: "gh",
+				Checksum:   "md5:abc",
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
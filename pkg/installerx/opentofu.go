@@ -2,7 +2,7 @@ package installerx
 
 import (
 	"fmt"
-	"path/filepath"
+	"path"
 	"strings"
 )
 
@@ -12,6 +12,12 @@ type OpenTofuInstallParams struct {
 	Version string
 	// InstallDir is the directory to install OpenTofu. If empty, defaults to DefaultInstallDir
 	InstallDir string
+	// OS is the target operating system, e.g. "darwin" for a binary run on a macOS host. If
+	// empty, defaults to DefaultOS
+	OS string
+	// Arch is the target architecture, e.g. "arm64" or "aarch64". If empty, defaults to
+	// DefaultArch
+	Arch string
 }
 
 // GetOpenTofuInstallCommand returns a string representing the command
@@ -27,17 +33,18 @@ func GetOpenTofuInstallCommand(params OpenTofuInstallParams) string {
 		params.InstallDir = DefaultInstallDir
 	}
 
-	installPath := filepath.Join(params.InstallDir, "opentofu")
+	platform := targetPlatform(params.OS, params.Arch)
+	installPath := path.Join(params.InstallDir, "opentofu"+platform.ExeSuffix())
 
 	command := fmt.Sprintf(`set -ex
 echo "Downloading OpenTofu..."
-curl -L "https://github.com/opentofu/opentofu/releases/download/v%[1]s/tofu_%[1]s_linux_amd64.zip" -o /tmp/opentofu.zip
+curl -L "https://github.com/opentofu/opentofu/releases/download/v%[1]s/tofu_%[1]s_%[2]s_%[3]s.zip" -o /tmp/opentofu.zip
 unzip /tmp/opentofu.zip -d /tmp
-mv /tmp/tofu %[2]s
-chmod +x %[2]s
+mv /tmp/tofu%[4]s %[5]s
+chmod +x %[5]s
 rm /tmp/opentofu.zip
 echo "OpenTofu installation completed successfully"
-%[2]s version`, params.Version, installPath)
+%[5]s version`, params.Version, platform.OS, platform.Arch, platform.ExeSuffix(), installPath)
 
 	return strings.TrimSpace(command)
 }
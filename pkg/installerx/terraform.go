@@ -2,7 +2,7 @@ package installerx
 
 import (
 	"fmt"
-	"path/filepath"
+	"path"
 	"strings"
 )
 
@@ -12,6 +12,12 @@ type TerraformInstallParams struct {
 	Version string
 	// InstallDir is the directory to install Terraform. If empty, defaults to DefaultInstallDir
 	InstallDir string
+	// OS is the target operating system, e.g. "darwin" for a binary run on a macOS host. If
+	// empty, defaults to DefaultOS
+	OS string
+	// Arch is the target architecture, e.g. "arm64" or "aarch64". If empty, defaults to
+	// DefaultArch
+	Arch string
 }
 
 // GetTerraformInstallCommand returns a string representing the command
@@ -27,14 +33,16 @@ func GetTerraformInstallCommand(params TerraformInstallParams) string {
 		params.InstallDir = DefaultInstallDir
 	}
 
-	installPath := filepath.Join(params.InstallDir, "terraform")
+	platform := targetPlatform(params.OS, params.Arch)
+	binary := "terraform" + platform.ExeSuffix()
+	installPath := path.Join(params.InstallDir, binary)
 
 	command := fmt.Sprintf(`set -ex
-curl -L https://releases.hashicorp.com/terraform/%[1]s/terraform_%[1]s_linux_amd64.zip -o /tmp/terraform.zip
+curl -L https://releases.hashicorp.com/terraform/%[1]s/terraform_%[1]s_%[2]s_%[3]s.zip -o /tmp/terraform.zip
 unzip /tmp/terraform.zip -d /tmp
-mv /tmp/terraform %[2]s
-chmod +x %[2]s
-rm /tmp/terraform.zip`, params.Version, installPath)
+mv /tmp/%[4]s %[5]s
+chmod +x %[5]s
+rm /tmp/terraform.zip`, params.Version, platform.OS, platform.Arch, binary, installPath)
 
 	return strings.TrimSpace(command)
 }
@@ -33,6 +33,20 @@ curl -L https://releases.hashicorp.com/terraform/1.1.0/terraform_1.1.0_linux_amd
 unzip /tmp/terraform.zip -d /tmp
 mv /tmp/terraform /custom/bin/terraform
 chmod +x /custom/bin/terraform
+rm /tmp/terraform.zip`,
+		},
+		{
+			name: "macOS host on Apple silicon",
+			params: TerraformInstallParams{
+				Version: "1.1.0",
+				OS:      "darwin",
+				Arch:    "arm64",
+			},
+			want: `set -ex
+curl -L https://releases.hashicorp.com/terraform/1.1.0/terraform_1.1.0_darwin_arm64.zip -o /tmp/terraform.zip
+unzip /tmp/terraform.zip -d /tmp
+mv /tmp/terraform /usr/local/bin/terraform
+chmod +x /usr/local/bin/terraform
 rm /tmp/terraform.zip`,
 		},
 	}
@@ -2,7 +2,7 @@ package installerx
 
 import (
 	"fmt"
-	"path/filepath"
+	"path"
 	"strings"
 )
 
@@ -12,6 +12,12 @@ type TerragruntInstallParams struct {
 	Version string
 	// InstallDir is the directory to install Terragrunt. If empty, defaults to "/usr/local/bin"
 	InstallDir string
+	// OS is the target operating system, e.g. "darwin" for a binary run on a macOS host. If
+	// empty, defaults to DefaultOS
+	OS string
+	// Arch is the target architecture, e.g. "arm64" or "aarch64". If empty, defaults to
+	// DefaultArch
+	Arch string
 }
 
 // GetTerragruntInstallCommand returns a string representing the command
@@ -27,11 +33,12 @@ func GetTerragruntInstallCommand(params TerragruntInstallParams) string {
 		params.InstallDir = "/usr/local/bin"
 	}
 
-	installPath := filepath.Join(params.InstallDir, "terragrunt")
+	platform := targetPlatform(params.OS, params.Arch)
+	installPath := path.Join(params.InstallDir, "terragrunt"+platform.ExeSuffix())
 
 	command := fmt.Sprintf(`set -ex
-curl -L https://github.com/gruntwork-io/terragrunt/releases/download/v%s/terragrunt_linux_amd64 -o %s
-chmod +x %s`, params.Version, installPath, installPath)
+curl -L https://github.com/gruntwork-io/terragrunt/releases/download/v%s/terragrunt_%s_%s%s -o %s
+chmod +x %s`, params.Version, platform.OS, platform.Arch, platform.ExeSuffix(), installPath, installPath)
 
 	return strings.TrimSpace(command)
 }
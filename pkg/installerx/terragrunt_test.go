@@ -29,6 +29,18 @@ chmod +x /usr/local/bin/terragrunt`,
 curl -L https://github.com/gruntwork-io/terragrunt/releases/download/v0.39.0/terragrunt_linux_amd64 -o /custom/bin/terragrunt
 chmod +x /custom/bin/terragrunt`,
 		},
+		{
+			name: "Windows host",
+			params: TerragruntInstallParams{
+				Version:    "0.39.0",
+				InstallDir: "/custom/bin",
+				OS:         "windows",
+				Arch:       "x86_64",
+			},
+			want: `set -ex
+curl -L https://github.com/gruntwork-io/terragrunt/releases/download/v0.39.0/terragrunt_windows_amd64.exe -o /custom/bin/terragrunt.exe
+chmod +x /custom/bin/terragrunt.exe`,
+		},
 	}
 
 	for _, tt := range tests {
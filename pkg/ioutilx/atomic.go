@@ -0,0 +1,82 @@
+// Package ioutilx provides the file helpers shared by the features generating files for a
+// pipeline: atomic writes of generated configurations, keys and reports, so readers never see a
+// partial file, and temporary workspaces under the mount prefix, holding the files a pipeline
+// materializes (inline apko configurations, keyrings, downloaded installers) until it cleans
+// them up.
+//
+// Example usage:
+//
+//	ws, err := ioutilx.NewWorkspace(fixtures.MntPrefix, "release")
+//	if err != nil {
+//	    // handle error
+//	}
+//	defer ws.Cleanup()
+//
+//	// Inline mounts (e.g. apkox.ApkoBuilder.Mounts) become files of the workspace.
+//	mounts, err := ws.Materialize(builder.Mounts())
+package ioutilx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic writes 'data' to 'path' with the permissions 'perm', atomically: the data is
+// written to a temporary file of the same directory, then renamed over 'path', so readers see
+// either the previous content or the new one. The parent directories are created.
+//
+// Returns:
+//   - An error if the file cannot be written. 'path' is unchanged then.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return WriteAtomic(path, perm, func(w io.Writer) error {
+		_, err := io.Copy(w, bytes.NewReader(data))
+		return err
+	})
+}
+
+// WriteAtomic writes the content 'write' produces to 'path' with the permissions 'perm',
+// atomically (see WriteFileAtomic). Nothing is written to 'path' if 'write' fails.
+//
+// Returns:
+//   - An error if 'write' fails or the file cannot be written.
+func WriteAtomic(path string, perm os.FileMode, write func(w io.Writer) error) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if err := write(tmp); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
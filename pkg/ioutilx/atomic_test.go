@@ -0,0 +1,50 @@
+package ioutilx
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config.yaml")
+
+	require.NoError(t, WriteFileAtomic(path, []byte("first"), 0o600))
+	require.NoError(t, WriteFileAtomic(path, []byte("second"), 0o640))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary file is left behind")
+}
+
+func TestWriteAtomicFailureKeepsContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.prom")
+	require.NoError(t, WriteFileAtomic(path, []byte("previous"), 0o644))
+
+	err := WriteAtomic(path, 0o644, func(w io.Writer) error {
+		_, _ = w.Write([]byte("partial"))
+		return errors.New("encoder failed")
+	})
+	require.ErrorContains(t, err, "encoder failed")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "previous", string(data))
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
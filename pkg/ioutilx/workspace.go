@@ -0,0 +1,185 @@
+package ioutilx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// WorkspacesDir is the directory of the workspaces, relative to the mount prefix.
+const WorkspacesDir = ".daggerx/workspaces"
+
+// Workspace is a temporary directory holding the files a pipeline materializes.
+type Workspace struct {
+	// root is the directory of the workspace.
+	root string
+}
+
+// GetWorkspacesDir returns the directory of the workspaces under 'mntPrefix'. If mntPrefix is
+// empty, fixtures.MntPrefix is used.
+func GetWorkspacesDir(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return filepath.Join(mntPrefix, WorkspacesDir)
+}
+
+// NewWorkspace creates a workspace for the pipeline 'name' under GetWorkspacesDir(mntPrefix), in
+// a directory of its own, so concurrent pipelines don't share files.
+//
+// Returns:
+//   - The workspace. Call Cleanup to remove it.
+//   - An error if the directory cannot be created.
+func NewWorkspace(mntPrefix, name string) (*Workspace, error) {
+	dir := GetWorkspacesDir(mntPrefix)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create workspace %s: %w", name, err)
+	}
+
+	root, err := os.MkdirTemp(dir, sanitizeName(name)+"-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace %s: %w", name, err)
+	}
+
+	return &Workspace{root: root}, nil
+}
+
+// Root returns the directory of the workspace.
+func (w *Workspace) Root() string {
+	return w.root
+}
+
+// Path returns the path of 'rel' in the workspace.
+//
+// Returns:
+//   - The absolute path.
+//   - An error if 'rel' is absolute or escapes the workspace (e.g. "../x").
+func (w *Workspace) Path(rel string) (string, error) {
+	if filepath.IsAbs(rel) || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("path %s is outside of workspace %s", rel, w.root)
+	}
+
+	return filepath.Join(w.root, rel), nil
+}
+
+// WriteFile writes 'data' to 'rel' in the workspace, atomically (see WriteFileAtomic).
+//
+// Returns:
+//   - The absolute path of the file.
+//   - An error if 'rel' escapes the workspace or the file cannot be written.
+func (w *Workspace) WriteFile(rel string, data []byte, perm os.FileMode) (string, error) {
+	path, err := w.Path(rel)
+	if err != nil {
+		return "", err
+	}
+
+	if err := WriteFileAtomic(path, data, perm); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// Materialize writes the content of the inline mounts (e.g. the inline apko configuration and
+// the plaintext keyrings of apkox.ApkoBuilder.Mounts) to files of the workspace, readable by
+// their owner only, and returns the mounts with each inline mount replaced by a read-only file
+// mount of its file, at the same target. Other mounts are returned unchanged.
+//
+// Returns:
+//   - The mounts, for executors mounting host files.
+//   - An error if a file cannot be written.
+func (w *Workspace) Materialize(mounts []types.Mount) ([]types.Mount, error) {
+	out := make([]types.Mount, 0, len(mounts))
+	for _, m := range mounts {
+		if m.Kind != types.MountKindInline {
+			out = append(out, m)
+			continue
+		}
+
+		sum := sha256.Sum256([]byte(m.Target))
+		rel := filepath.Join("mounts", hex.EncodeToString(sum[:6])+"-"+filepath.Base(m.Target))
+
+		path, err := w.WriteFile(rel, []byte(m.Content), 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to materialize mount %s: %w", m.Target, err)
+		}
+
+		out = append(out, types.Mount{Kind: types.MountKindFile, Source: path, Target: m.Target, ReadOnly: true})
+	}
+
+	return out, nil
+}
+
+// Cleanup removes the workspace and its files. It is safe to call several times, and on a nil
+// workspace.
+func (w *Workspace) Cleanup() error {
+	if w == nil || w.root == "" {
+		return nil
+	}
+
+	if err := os.RemoveAll(w.root); err != nil {
+		return fmt.Errorf("failed to remove workspace %s: %w", w.root, err)
+	}
+
+	return nil
+}
+
+// CleanupStale removes the workspaces under GetWorkspacesDir(mntPrefix) last modified more than
+// 'maxAge' ago, left over by pipelines that did not clean up (e.g. killed runs).
+//
+// Returns:
+//   - The number of workspaces removed.
+//   - An error if the workspaces cannot be listed or removed.
+func CleanupStale(mntPrefix string, maxAge time.Duration) (int, error) {
+	dir := GetWorkspacesDir(mntPrefix)
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to list workspaces: %w", err)
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !e.IsDir() || info.ModTime().After(cutoff) {
+			continue
+		}
+
+		if err := os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			return removed, fmt.Errorf("failed to remove workspace %s: %w", e.Name(), err)
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// sanitizeName returns 'name' usable as a directory name prefix.
+func sanitizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '.' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '-'
+	}, name)
+
+	name = strings.Trim(name, "-.")
+	if name == "" {
+		return "workspace"
+	}
+
+	return name
+}
@@ -0,0 +1,103 @@
+package ioutilx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspace(t *testing.T) {
+	prefix := t.TempDir()
+
+	ws, err := NewWorkspace(prefix, "release/base images")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(ws.Root(), filepath.Join(prefix, WorkspacesDir, "release-base-images-")))
+
+	other, err := NewWorkspace(prefix, "release/base images")
+	require.NoError(t, err)
+	assert.NotEqual(t, ws.Root(), other.Root())
+
+	path, err := ws.WriteFile("keys/key.pub", []byte("key"), 0o600)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(ws.Root(), "keys", "key.pub"), path)
+
+	for _, rel := range []string{"../escape", "/etc/passwd", "a/../../b"} {
+		_, err := ws.WriteFile(rel, []byte("x"), 0o600)
+		assert.Error(t, err, rel)
+	}
+
+	require.NoError(t, ws.Cleanup())
+	require.NoError(t, ws.Cleanup(), "cleanup is idempotent")
+	_, err = os.Stat(ws.Root())
+	assert.True(t, os.IsNotExist(err))
+
+	var nilWorkspace *Workspace
+	assert.NoError(t, nilWorkspace.Cleanup())
+}
+
+func TestWorkspaceMaterialize(t *testing.T) {
+	ws, err := NewWorkspace(t.TempDir(), "apko")
+	require.NoError(t, err)
+	defer func() { _ = ws.Cleanup() }()
+
+	mounts, err := ws.Materialize([]types.Mount{
+		{Kind: types.MountKindDirectory, Source: "/src", Target: "/work"},
+		{Kind: types.MountKindInline, Target: "/mnt/.daggerx/apko.yaml", Content: "contents: {}\n"},
+		{Kind: types.MountKindSecret, Source: "key", Target: "/mnt/.daggerx/key.rsa.pub"},
+	})
+	require.NoError(t, err)
+	require.Len(t, mounts, 3)
+
+	assert.Equal(t, types.MountKindDirectory, mounts[0].Kind)
+	assert.Equal(t, types.MountKindSecret, mounts[2].Kind)
+
+	inline := mounts[1]
+	assert.Equal(t, types.MountKindFile, inline.Kind)
+	assert.Equal(t, "/mnt/.daggerx/apko.yaml", inline.Target)
+	assert.True(t, inline.ReadOnly)
+	assert.True(t, strings.HasPrefix(inline.Source, ws.Root()))
+
+	data, err := os.ReadFile(inline.Source)
+	require.NoError(t, err)
+	assert.Equal(t, "contents: {}\n", string(data))
+
+	info, err := os.Stat(inline.Source)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestCleanupStale(t *testing.T) {
+	prefix := t.TempDir()
+
+	removed, err := CleanupStale(prefix, time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+
+	stale, err := NewWorkspace(prefix, "stale")
+	require.NoError(t, err)
+	fresh, err := NewWorkspace(prefix, "fresh")
+	require.NoError(t, err)
+
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(stale.Root(), old, old))
+
+	removed, err = CleanupStale(prefix, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	_, err = os.Stat(stale.Root())
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(fresh.Root())
+	assert.NoError(t, err)
+}
+
+func TestGetWorkspacesDir(t *testing.T) {
+	assert.Equal(t, "/mnt/.daggerx/workspaces", GetWorkspacesDir(""))
+	assert.Equal(t, "/work/.daggerx/workspaces", GetWorkspacesDir("/work"))
+}
@@ -0,0 +1,332 @@
+// Package kox provides a builder for ko, the tool building container images from Go import paths
+// without a Dockerfile.
+//
+// A KoBuilder builds one or more import paths. When the import paths need different base images
+// or tags, Plan produces one planx task per import path, which planx executes concurrently.
+//
+// Example usage:
+//
+//	builder := kox.NewKoBuilder().
+//	    WithRepository("registry.example.com/team").
+//	    WithBaseImage("cgr.dev/chainguard/static").
+//	    WithTags("v1.2.3").
+//	    WithPlatform("linux/amd64", "linux/arm64").
+//	    WithImportPath("./cmd/api").
+//	    WithImportPathOptions("./cmd/migrate", kox.ImportPathOptions{
+//	        BaseImage: "cgr.dev/chainguard/glibc-dynamic",
+//	        Tags:      []string{"v1.2.3", "migrations"},
+//	    })
+//
+//	plan, err := builder.Plan("services")
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	report, err := plan.Execute(ctx, executor)
+package kox
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/planx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the ko builder in errorsx errors.
+const builderName = "ko"
+
+// KoDefaultImage is the default container image providing ko.
+const KoDefaultImage = "cgr.dev/chainguard/ko"
+
+const (
+	// RepositoryEnvVar is the environment variable holding the repository images are pushed to.
+	RepositoryEnvVar = "KO_DOCKER_REPO"
+	// BaseImageEnvVar is the environment variable holding the default base image.
+	BaseImageEnvVar = "KO_DEFAULTBASEIMAGE"
+)
+
+// ImportPathOptions overrides the build options of a single import path.
+type ImportPathOptions struct {
+	// BaseImage overrides the base image of the import path.
+	BaseImage string
+	// Tags overrides the tags of the import path.
+	Tags []string
+}
+
+// KoBuilder generates the ko build commands of Go import paths.
+type KoBuilder struct {
+	// repository is the repository images are pushed to.
+	repository string
+
+	// importPaths are the built import paths, in insertion order.
+	importPaths []string
+
+	// overrides holds the per import path options.
+	overrides map[string]ImportPathOptions
+
+	// baseImage is the default base image.
+	baseImage string
+
+	// tags are the default image tags.
+	tags []string
+
+	// platforms are the target platforms.
+	platforms []string
+
+	// bare pushes images to the repository without a path suffix.
+	bare bool
+
+	// noPush builds the images without pushing them.
+	noPush bool
+
+	// extraArgs are additional arguments appended before the import paths.
+	extraArgs []string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewKoBuilder creates an empty KoBuilder.
+func NewKoBuilder() *KoBuilder {
+	return &KoBuilder{overrides: map[string]ImportPathOptions{}}
+}
+
+// WithRepository sets the repository images are pushed to (KO_DOCKER_REPO).
+func (b *KoBuilder) WithRepository(repo string) *KoBuilder {
+	b.repository = repo
+	return b
+}
+
+// WithImportPath adds import paths to build (e.g. "./cmd/api").
+func (b *KoBuilder) WithImportPath(paths ...string) *KoBuilder {
+	for _, p := range paths {
+		b.addImportPath(p)
+	}
+	return b
+}
+
+// WithImportPathOptions adds the import path 'path', overriding its base image and tags.
+func (b *KoBuilder) WithImportPathOptions(path string, opts ImportPathOptions) *KoBuilder {
+	b.addImportPath(path)
+	b.overrides[path] = opts
+	return b
+}
+
+// WithBaseImage sets the default base image (KO_DEFAULTBASEIMAGE).
+func (b *KoBuilder) WithBaseImage(image string) *KoBuilder {
+	b.baseImage = image
+	return b
+}
+
+// WithTags adds default image tags.
+func (b *KoBuilder) WithTags(tags ...string) *KoBuilder {
+	b.tags = append(b.tags, tags...)
+	return b
+}
+
+// WithPlatform adds target platforms (e.g. "linux/arm64").
+func (b *KoBuilder) WithPlatform(platforms ...string) *KoBuilder {
+	b.platforms = append(b.platforms, platforms...)
+	return b
+}
+
+// WithBare pushes images to the repository without the import path suffix.
+func (b *KoBuilder) WithBare(bare bool) *KoBuilder {
+	b.bare = bare
+	return b
+}
+
+// WithPush sets whether images are pushed. Images are pushed by default.
+func (b *KoBuilder) WithPush(push bool) *KoBuilder {
+	b.noPush = !push
+	return b
+}
+
+// WithExtraArg adds an argument appended before the import paths.
+func (b *KoBuilder) WithExtraArg(arg string) *KoBuilder {
+	b.extraArgs = append(b.extraArgs, arg)
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *KoBuilder) WithLogger(logger *slog.Logger) *KoBuilder {
+	b.logger = logger
+	return b
+}
+
+// addImportPath adds an import path once.
+func (b *KoBuilder) addImportPath(path string) {
+	for _, p := range b.importPaths {
+		if p == path {
+			return
+		}
+	}
+	b.importPaths = append(b.importPaths, path)
+}
+
+// Env returns the environment variables the generated command requires: the repository and the
+// default base image.
+func (b *KoBuilder) Env() []types.DaggerEnvVars {
+	return b.env(b.baseImage)
+}
+
+// env returns the environment variables of a build using 'baseImage'.
+func (b *KoBuilder) env(baseImage string) []types.DaggerEnvVars {
+	var env []types.DaggerEnvVars
+
+	if b.repository != "" {
+		env = append(env, types.DaggerEnvVars{Name: RepositoryEnvVar, Value: b.repository})
+	}
+
+	if baseImage != "" {
+		env = append(env, types.DaggerEnvVars{Name: BaseImageEnvVar, Value: baseImage})
+	}
+
+	return env
+}
+
+// validate checks that the builder configuration is complete and consistent.
+func (b *KoBuilder) validate() error {
+	if len(b.importPaths) == 0 {
+		return errorsx.MissingField(builderName, "importPaths", "at least one import path is required")
+	}
+
+	if b.repository == "" && !b.noPush {
+		return errorsx.MissingField(builderName, "repository", "repository is required to push images")
+	}
+
+	for _, p := range b.importPaths {
+		if p == "" {
+			return errorsx.InvalidValue(builderName, "importPaths", p, "import path cannot be empty")
+		}
+	}
+
+	return nil
+}
+
+// command generates the ko build command of 'paths' with 'tags'.
+func (b *KoBuilder) command(tags []string, paths ...string) []string {
+	cmd := []string{"ko", "build"}
+
+	if len(b.platforms) > 0 {
+		cmd = append(cmd, "--platform", strings.Join(b.platforms, ","))
+	}
+
+	if len(tags) > 0 {
+		cmd = append(cmd, "--tags", strings.Join(tags, ","))
+	}
+
+	if b.bare {
+		cmd = append(cmd, "--bare")
+	}
+
+	if b.noPush {
+		cmd = append(cmd, "--push=false")
+	}
+
+	cmd = append(cmd, b.extraArgs...)
+
+	return append(cmd, paths...)
+}
+
+// BuildCommand generates a single ko build command building every import path.
+//
+// Returns:
+//   - The ko build command.
+//   - An error if no import path is set, the repository is missing, or an import path overrides
+//     its options, which requires one command per import path (see Plan).
+func (b *KoBuilder) BuildCommand() ([]string, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	if len(b.overrides) > 0 {
+		return nil, errorsx.ConflictingOptions(builderName, []string{"importPathOptions"},
+			"import path overrides require one build per import path, use Plan instead")
+	}
+
+	cmd := b.command(b.tags, b.importPaths...)
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Plan generates a plan building each import path in its own task, with its base image and tags
+// overrides. Task IDs are derived from the import paths, e.g. "cmd-api" for "./cmd/api".
+//
+// Returns:
+//   - The build plan.
+//   - An error if no import path is set, the repository is missing, or two import paths map to
+//     the same task ID.
+func (b *KoBuilder) Plan(name string) (*planx.Plan, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	plan := planx.NewPlan(name)
+	for _, path := range b.importPaths {
+		opts := b.overrides[path]
+
+		baseImage := b.baseImage
+		if opts.BaseImage != "" {
+			baseImage = opts.BaseImage
+		}
+
+		tags := b.tags
+		if len(opts.Tags) > 0 {
+			tags = opts.Tags
+		}
+
+		cmd := b.command(tags, path)
+		logx.Command(ctx, b.logger, builderName, cmd)
+
+		if err := plan.AddTask(planx.Task{ID: TaskID(path), Command: cmd, Env: b.env(baseImage)}); err != nil {
+			return nil, errorsx.ConflictingOptions(builderName, []string{"importPaths"}, "%v", err)
+		}
+	}
+
+	return plan, nil
+}
+
+// TaskID returns the plan task ID of an import path: the path without its leading "./", with
+// separators replaced by dashes. The module root "." maps to "root".
+func TaskID(importPath string) string {
+	id := strings.TrimPrefix(importPath, "./")
+	id = strings.Trim(id, "/.")
+	if id == "" {
+		return "root"
+	}
+
+	return strings.NewReplacer("/", "-", ".", "-").Replace(id)
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *KoBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *KoBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the ko build command.
+func (b *KoBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewKoBuilder(), explainx.Generate("BuildCommand", b.BuildCommand))
+}
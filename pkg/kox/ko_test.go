@@ -0,0 +1,104 @@
+package kox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/planx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKoBuilderBuildCommand(t *testing.T) {
+	b := NewKoBuilder().
+		WithRepository("registry.example.com/team").
+		WithBaseImage("cgr.dev/chainguard/static").
+		WithTags("v1.2.3", "latest").
+		WithPlatform("linux/amd64", "linux/arm64").
+		WithImportPath("./cmd/api", "./cmd/worker", "./cmd/api")
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"ko", "build",
+		"--platform", "linux/amd64,linux/arm64",
+		"--tags", "v1.2.3,latest",
+		"./cmd/api", "./cmd/worker",
+	}, cmd)
+
+	assert.Equal(t, []types.DaggerEnvVars{
+		{Name: RepositoryEnvVar, Value: "registry.example.com/team"},
+		{Name: BaseImageEnvVar, Value: "cgr.dev/chainguard/static"},
+	}, b.Env())
+}
+
+func TestKoBuilderPlan(t *testing.T) {
+	b := NewKoBuilder().
+		WithRepository("registry.example.com/team").
+		WithBaseImage("cgr.dev/chainguard/static").
+		WithTags("v1.2.3").
+		WithBare(true).
+		WithImportPath("./cmd/api").
+		WithImportPathOptions("./cmd/migrate", ImportPathOptions{
+			BaseImage: "cgr.dev/chainguard/glibc-dynamic",
+			Tags:      []string{"v1.2.3", "migrations"},
+		})
+
+	_, err := b.BuildCommand()
+	assert.True(t, errors.Is(err, errorsx.ErrConflictingOptions))
+
+	plan, err := b.Plan("services")
+	require.NoError(t, err)
+	assert.Equal(t, []planx.Task{
+		{
+			ID:      "cmd-api",
+			Command: types.DaggerCMD{"ko", "build", "--tags", "v1.2.3", "--bare", "./cmd/api"},
+			Env: []types.DaggerEnvVars{
+				{Name: RepositoryEnvVar, Value: "registry.example.com/team"},
+				{Name: BaseImageEnvVar, Value: "cgr.dev/chainguard/static"},
+			},
+		},
+		{
+			ID:      "cmd-migrate",
+			Command: types.DaggerCMD{"ko", "build", "--tags", "v1.2.3,migrations", "--bare", "./cmd/migrate"},
+			Env: []types.DaggerEnvVars{
+				{Name: RepositoryEnvVar, Value: "registry.example.com/team"},
+				{Name: BaseImageEnvVar, Value: "cgr.dev/chainguard/glibc-dynamic"},
+			},
+		},
+	}, plan.Tasks())
+}
+
+func TestKoBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *KoBuilder
+		kind    error
+	}{
+		{"Missing import path", NewKoBuilder().WithRepository("repo"), errorsx.ErrMissingField},
+		{"Missing repository", NewKoBuilder().WithImportPath("./cmd/api"), errorsx.ErrMissingField},
+		{"Empty import path", NewKoBuilder().WithRepository("repo").WithImportPath(""), errorsx.ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.BuildCommand()
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+		})
+	}
+
+	cmd, err := NewKoBuilder().WithPush(false).WithImportPath("./cmd/api").BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ko", "build", "--push=false", "./cmd/api"}, cmd)
+
+	_, err = NewKoBuilder().WithRepository("repo").WithImportPath("./cmd/api", "cmd/api/").Plan("dup")
+	assert.True(t, errors.Is(err, errorsx.ErrConflictingOptions))
+}
+
+func TestTaskID(t *testing.T) {
+	assert.Equal(t, "cmd-api", TaskID("./cmd/api"))
+	assert.Equal(t, "github-com-example-app-cmd-api", TaskID("github.com/example/app/cmd/api"))
+	assert.Equal(t, "root", TaskID("."))
+}
@@ -0,0 +1,256 @@
+// Package kubectlx describes how Kubernetes credentials are provided to kubectl and helm
+// containers, and derives the environment, mount and secret requirements and the command line
+// flags consistently from a single Kubeconfig.
+//
+// Three sources are supported: a kubeconfig file provided as a secret, the in-cluster service
+// account, and a kubeconfig whose user runs an exec credential plugin (e.g. `aws eks get-token`)
+// requiring its own environment.
+//
+// Example usage:
+//
+//	kc := kubectlx.FromSecret(secretsx.FromEnv("kubeconfig", "KUBECONFIG_DATA")).
+//	    WithContext("prod").
+//	    WithNamespace("apps")
+//
+//	if err := kc.Validate(); err != nil {
+//	    // handle error
+//	}
+//
+//	cmd := append([]string{"kubectl", "apply", "-f", "manifests/"}, kc.KubectlFlags()...)
+//	res, err := executor.Run(ctx, cmd, kc.Env(), kc.Mounts())
+package kubectlx
+
+import (
+	"path"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the kubeconfig helpers in errorsx errors.
+const builderName = "kubeconfig"
+
+// KubeconfigEnvVar is the environment variable kubectl and helm read the kubeconfig path from.
+const KubeconfigEnvVar = "KUBECONFIG"
+
+// Source describes where the Kubernetes credentials come from.
+type Source string
+
+const (
+	// SourceFile reads the credentials from a kubeconfig file provided as a secret.
+	SourceFile Source = "file"
+	// SourceInCluster uses the service account of the pod the container runs in.
+	SourceInCluster Source = "in-cluster"
+	// SourceExecPlugin reads a kubeconfig file whose user runs an exec credential plugin.
+	SourceExecPlugin Source = "exec"
+)
+
+// GetKubeconfigPath returns the conventional path of the kubeconfig inside the container.
+// If mntPrefix is empty, fixtures.MntPrefix is used.
+func GetKubeconfigPath(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, ".kube", "config")
+}
+
+// Kubeconfig describes how the Kubernetes credentials are provided to a container.
+type Kubeconfig struct {
+	// source is where the credentials come from.
+	source Source
+
+	// file is the secret holding the kubeconfig. It is nil for in-cluster credentials.
+	file *secretsx.SecretRef
+
+	// context is the kubeconfig context to use.
+	context string
+
+	// namespace is the default namespace.
+	namespace string
+
+	// pluginEnv holds the plain environment variables of the exec credential plugin.
+	pluginEnv []types.DaggerEnvVars
+
+	// pluginSecrets holds the secrets of the exec credential plugin, exposed as environment variables.
+	pluginSecrets []*secretsx.SecretRef
+}
+
+// FromSecret describes a kubeconfig provided by the secret 'ref'. The secret is mounted as a
+// file; secrets not already mounted as a file are mounted at GetKubeconfigPath("").
+func FromSecret(ref *secretsx.SecretRef) *Kubeconfig {
+	return &Kubeconfig{source: SourceFile, file: asKubeconfigFile(ref)}
+}
+
+// InCluster describes the in-cluster service account credentials, read by kubectl and helm from
+// the pod environment. No kubeconfig is mounted.
+func InCluster() *Kubeconfig {
+	return &Kubeconfig{source: SourceInCluster}
+}
+
+// FromExecPlugin describes a kubeconfig provided by the secret 'ref' whose user runs an exec
+// credential plugin. The plugin environment is declared with WithPluginEnv and WithPluginSecret.
+func FromExecPlugin(ref *secretsx.SecretRef) *Kubeconfig {
+	return &Kubeconfig{source: SourceExecPlugin, file: asKubeconfigFile(ref)}
+}
+
+// asKubeconfigFile mounts 'ref' at the conventional kubeconfig path unless it is already mounted
+// as a file.
+func asKubeconfigFile(ref *secretsx.SecretRef) *secretsx.SecretRef {
+	if ref != nil && ref.Mount != secretsx.MountAsFile {
+		ref.AsFile(GetKubeconfigPath(""))
+	}
+
+	return ref
+}
+
+// WithContext sets the kubeconfig context to use.
+func (k *Kubeconfig) WithContext(context string) *Kubeconfig {
+	k.context = context
+	return k
+}
+
+// WithNamespace sets the default namespace.
+func (k *Kubeconfig) WithNamespace(namespace string) *Kubeconfig {
+	k.namespace = namespace
+	return k
+}
+
+// WithPluginEnv adds an environment variable required by the exec credential plugin
+// (e.g. AWS_REGION).
+func (k *Kubeconfig) WithPluginEnv(name, value string) *Kubeconfig {
+	k.pluginEnv = append(k.pluginEnv, types.DaggerEnvVars{Name: name, Value: value})
+	return k
+}
+
+// WithPluginSecret adds a secret required by the exec credential plugin, exposed as an
+// environment variable (e.g. AWS_SECRET_ACCESS_KEY).
+func (k *Kubeconfig) WithPluginSecret(ref *secretsx.SecretRef) *Kubeconfig {
+	k.pluginSecrets = append(k.pluginSecrets, ref)
+	return k
+}
+
+// Source returns where the credentials come from.
+func (k *Kubeconfig) Source() Source {
+	return k.source
+}
+
+// Path returns the path of the kubeconfig inside the container, empty for in-cluster credentials.
+func (k *Kubeconfig) Path() string {
+	if k.file == nil {
+		return ""
+	}
+
+	return k.file.Target
+}
+
+// Validate checks that the description is complete and consistent.
+func (k *Kubeconfig) Validate() error {
+	switch k.source {
+	case SourceFile, SourceExecPlugin:
+		if k.file == nil {
+			return errorsx.MissingField(builderName, "file", "%s kubeconfig requires a secret", k.source)
+		}
+
+		if err := k.file.Validate(); err != nil {
+			return err
+		}
+	case SourceInCluster:
+		if k.context != "" {
+			return errorsx.ConflictingOptions(builderName, []string{"source", "context"},
+				"in-cluster credentials have no kubeconfig context")
+		}
+	default:
+		return errorsx.Unsupported(builderName, "source", k.source, "unsupported kubeconfig source: %q", k.source)
+	}
+
+	if k.source != SourceExecPlugin && (len(k.pluginEnv) > 0 || len(k.pluginSecrets) > 0) {
+		return errorsx.ConflictingOptions(builderName, []string{"source", "plugin"},
+			"plugin environment requires an exec plugin kubeconfig")
+	}
+
+	for _, s := range k.pluginSecrets {
+		if s == nil {
+			return errorsx.MissingField(builderName, "pluginSecrets", "plugin secret cannot be nil")
+		}
+
+		if err := s.Validate(); err != nil {
+			return err
+		}
+
+		if s.Mount != secretsx.MountAsEnv {
+			return errorsx.InvalidValue(builderName, "pluginSecrets", s.Name,
+				"plugin secret %s must be exposed as an environment variable", s.Name)
+		}
+	}
+
+	return nil
+}
+
+// KubectlFlags returns the kubectl flags selecting the kubeconfig, context and namespace.
+func (k *Kubeconfig) KubectlFlags() []string {
+	return k.flags("--context")
+}
+
+// HelmFlags returns the helm flags selecting the kubeconfig, context and namespace. Helm names
+// the context flag --kube-context.
+func (k *Kubeconfig) HelmFlags() []string {
+	return k.flags("--kube-context")
+}
+
+// flags returns the kubeconfig, context and namespace flags, using 'contextFlag' for the context.
+func (k *Kubeconfig) flags(contextFlag string) []string {
+	var flags []string
+
+	if path := k.Path(); path != "" {
+		flags = append(flags, "--kubeconfig", path)
+	}
+
+	if k.context != "" {
+		flags = append(flags, contextFlag, k.context)
+	}
+
+	if k.namespace != "" {
+		flags = append(flags, "--namespace", k.namespace)
+	}
+
+	return flags
+}
+
+// Env returns the environment variables the container requires: KUBECONFIG, pointing at the
+// mounted kubeconfig, and the plain exec plugin environment.
+func (k *Kubeconfig) Env() []types.DaggerEnvVars {
+	var env []types.DaggerEnvVars
+
+	if path := k.Path(); path != "" {
+		env = append(env, types.DaggerEnvVars{Name: KubeconfigEnvVar, Value: path})
+	}
+
+	return append(env, k.pluginEnv...)
+}
+
+// Secrets returns the secrets the container requires: the kubeconfig and the exec plugin secrets.
+func (k *Kubeconfig) Secrets() []*secretsx.SecretRef {
+	var secrets []*secretsx.SecretRef
+
+	if k.file != nil {
+		secrets = append(secrets, k.file)
+	}
+
+	return append(secrets, k.pluginSecrets...)
+}
+
+// Mounts returns the mount of the kubeconfig file.
+func (k *Kubeconfig) Mounts() []types.Mount {
+	if k.file == nil {
+		return nil
+	}
+
+	if m, ok := k.file.MountRequirement(); ok {
+		return []types.Mount{m}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package kubectlx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubeconfigFromSecret(t *testing.T) {
+	kc := FromSecret(secretsx.FromEnv("kubeconfig", "KUBECONFIG_DATA")).
+		WithContext("prod").
+		WithNamespace("apps")
+
+	require.NoError(t, kc.Validate())
+	assert.Equal(t, SourceFile, kc.Source())
+	assert.Equal(t, "/mnt/.kube/config", kc.Path())
+
+	assert.Equal(t, []string{"--kubeconfig", "/mnt/.kube/config", "--context", "prod", "--namespace", "apps"}, kc.KubectlFlags())
+	assert.Equal(t, []string{"--kubeconfig", "/mnt/.kube/config", "--kube-context", "prod", "--namespace", "apps"}, kc.HelmFlags())
+	assert.Equal(t, []types.DaggerEnvVars{{Name: KubeconfigEnvVar, Value: "/mnt/.kube/config"}}, kc.Env())
+	assert.Equal(t, []types.Mount{
+		{Kind: types.MountKindSecret, Source: "kubeconfig", Target: "/mnt/.kube/config", ReadOnly: true},
+	}, kc.Mounts())
+	assert.Len(t, kc.Secrets(), 1)
+
+	mounted := FromSecret(secretsx.FromFile("kubeconfig", "/home/ci/.kube/config").AsFile("/root/.kube/config"))
+	assert.Equal(t, "/root/.kube/config", mounted.Path())
+}
+
+func TestKubeconfigInCluster(t *testing.T) {
+	kc := InCluster().WithNamespace("apps")
+
+	require.NoError(t, kc.Validate())
+	assert.Empty(t, kc.Path())
+	assert.Equal(t, []string{"--namespace", "apps"}, kc.KubectlFlags())
+	assert.Empty(t, kc.Env())
+	assert.Empty(t, kc.Mounts())
+	assert.Empty(t, kc.Secrets())
+}
+
+func TestKubeconfigFromExecPlugin(t *testing.T) {
+	secretKey := secretsx.FromEnv("aws-secret-access-key", "AWS_SECRET_ACCESS_KEY")
+	kc := FromExecPlugin(secretsx.FromEnv("kubeconfig", "EKS_KUBECONFIG")).
+		WithPluginEnv("AWS_REGION", "eu-west-1").
+		WithPluginSecret(secretKey)
+
+	require.NoError(t, kc.Validate())
+	assert.Equal(t, []types.DaggerEnvVars{
+		{Name: KubeconfigEnvVar, Value: "/mnt/.kube/config"},
+		{Name: "AWS_REGION", Value: "eu-west-1"},
+	}, kc.Env())
+	assert.Equal(t, "kubeconfig", kc.Secrets()[0].Name)
+	assert.Equal(t, secretKey, kc.Secrets()[1])
+}
+
+func TestKubeconfigValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		kc   *Kubeconfig
+		kind error
+	}{
+		{"Missing secret", FromSecret(nil), errorsx.ErrMissingField},
+		{"In-cluster with context", InCluster().WithContext("prod"), errorsx.ErrConflictingOptions},
+		{"Plugin env without plugin", FromSecret(secretsx.FromEnv("kc", "KC")).WithPluginEnv("AWS_REGION", "eu-west-1"), errorsx.ErrConflictingOptions},
+		{
+			"Plugin secret as file",
+			FromExecPlugin(secretsx.FromEnv("kc", "KC")).WithPluginSecret(secretsx.FromEnv("key", "KEY").AsFile("/run/key")),
+			errorsx.ErrInvalidValue,
+		},
+		{"Nil plugin secret", FromExecPlugin(secretsx.FromEnv("kc", "KC")).WithPluginSecret(nil), errorsx.ErrMissingField},
+		{"Unknown source", &Kubeconfig{source: "token"}, errorsx.ErrUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.kc.Validate()
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+		})
+	}
+}
+
+func TestGetKubeconfigPath(t *testing.T) {
+	assert.Equal(t, "/mnt/.kube/config", GetKubeconfigPath(""))
+	assert.Equal(t, "/work/.kube/config", GetKubeconfigPath("/work"))
+}
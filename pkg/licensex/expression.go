@@ -0,0 +1,175 @@
+package licensex
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expression is a parsed SPDX license expression (e.g. "MIT OR (Apache-2.0 AND BSD-3-Clause)").
+type Expression struct {
+	// Op is the operator of the expression: "AND", "OR", or empty for a license.
+	Op string
+	// License is the license identifier of a license expression.
+	License string
+	// Exception is the exception of a license expression (e.g. "Classpath-exception-2.0" for
+	// "GPL-2.0-only WITH Classpath-exception-2.0").
+	Exception string
+	// Args are the operands of an operator expression.
+	Args []*Expression
+}
+
+// String renders the expression in its canonical form, with operators in uppercase and the
+// operands of nested operators in parentheses.
+func (e *Expression) String() string {
+	if e.Op == "" {
+		if e.Exception != "" {
+			return e.License + " WITH " + e.Exception
+		}
+		return e.License
+	}
+
+	args := make([]string, len(e.Args))
+	for i, a := range e.Args {
+		args[i] = a.String()
+		if a.Op != "" {
+			args[i] = "(" + args[i] + ")"
+		}
+	}
+
+	return strings.Join(args, " "+e.Op+" ")
+}
+
+// Identifiers returns the license identifiers of the expression, in order, without the
+// exceptions.
+func (e *Expression) Identifiers() []string {
+	if e.Op == "" {
+		return []string{e.License}
+	}
+
+	var ids []string
+	for _, a := range e.Args {
+		ids = append(ids, a.Identifiers()...)
+	}
+
+	return ids
+}
+
+// ParseExpression parses an SPDX license expression. Operators are case-insensitive, and AND
+// binds tighter than OR.
+//
+// Returns:
+//   - The parsed expression.
+//   - An error if the expression is empty or malformed.
+func ParseExpression(s string) (*Expression, error) {
+	p := &parser{tokens: tokenize(s)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty license expression")
+	}
+
+	e, err := p.or()
+	if err != nil {
+		return nil, fmt.Errorf("invalid license expression %q: %w", s, err)
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("invalid license expression %q: unexpected %q", s, p.tokens[p.pos])
+	}
+
+	return e, nil
+}
+
+// tokenize splits an expression into parentheses and words.
+func tokenize(s string) []string {
+	s = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(s)
+	return strings.Fields(s)
+}
+
+// parser is a recursive descent parser of license expressions.
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+// peek returns the next token in uppercase, or an empty string at the end.
+func (p *parser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+
+	return strings.ToUpper(p.tokens[p.pos])
+}
+
+// or parses: and ("OR" and)*.
+func (p *parser) or() (*Expression, error) {
+	return p.operator("OR", p.and)
+}
+
+// and parses: term ("AND" term)*.
+func (p *parser) and() (*Expression, error) {
+	return p.operator("AND", p.term)
+}
+
+// operator parses the operands of 'op' parsed by 'next'.
+func (p *parser) operator(op string, next func() (*Expression, error)) (*Expression, error) {
+	first, err := next()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []*Expression{first}
+	for p.peek() == op {
+		p.pos++
+
+		arg, err := next()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	if len(args) == 1 {
+		return first, nil
+	}
+
+	return &Expression{Op: op, Args: args}, nil
+}
+
+// term parses: "(" or ")" | license ["WITH" exception].
+func (p *parser) term() (*Expression, error) {
+	switch tok := p.peek(); tok {
+	case "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case "(":
+		p.pos++
+
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+
+		return e, nil
+	case ")", "AND", "OR", "WITH":
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+
+	e := &Expression{License: p.tokens[p.pos]}
+	p.pos++
+
+	if p.peek() == "WITH" {
+		p.pos++
+
+		switch p.peek() {
+		case "", "(", ")", "AND", "OR", "WITH":
+			return nil, fmt.Errorf("missing exception after WITH")
+		}
+		e.Exception = p.tokens[p.pos]
+		p.pos++
+	}
+
+	return e, nil
+}
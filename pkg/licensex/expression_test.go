@@ -0,0 +1,45 @@
+package licensex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExpression(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		ids     []string
+		wantErr bool
+	}{
+		{name: "License", input: "MIT", want: "MIT", ids: []string{"MIT"}},
+		{name: "Precedence", input: "MIT or Apache-2.0 and BSD-3-Clause", want: "MIT OR (Apache-2.0 AND BSD-3-Clause)",
+			ids: []string{"MIT", "Apache-2.0", "BSD-3-Clause"}},
+		{name: "Parentheses", input: "(MIT OR Apache-2.0) AND BSD-3-Clause", want: "(MIT OR Apache-2.0) AND BSD-3-Clause",
+			ids: []string{"MIT", "Apache-2.0", "BSD-3-Clause"}},
+		{name: "Exception", input: "GPL-2.0-only WITH Classpath-exception-2.0", want: "GPL-2.0-only WITH Classpath-exception-2.0",
+			ids: []string{"GPL-2.0-only"}},
+		{name: "Empty", input: " ", wantErr: true},
+		{name: "Dangling operator", input: "MIT AND", wantErr: true},
+		{name: "Unbalanced", input: "(MIT OR Apache-2.0", wantErr: true},
+		{name: "Missing operator", input: "MIT Apache-2.0", wantErr: true},
+		{name: "Missing exception", input: "GPL-2.0-only WITH", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := ParseExpression(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, e.String())
+			assert.Equal(t, tt.ids, e.Identifiers())
+		})
+	}
+}
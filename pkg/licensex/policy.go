@@ -0,0 +1,297 @@
+// Package licensex extracts the SPDX license identifiers of the packages listed in SBOMs, and
+// evaluates them against an allow/deny policy for gate steps in pipelines.
+//
+// License expressions are evaluated as SPDX defines them: a package licensed "MIT OR GPL-3.0-only"
+// is compliant when either license is, one licensed "MIT AND GPL-3.0-only" when both are.
+// Packages without a license, or with a license the policy can't classify (such as
+// LicenseRef-* identifiers or malformed expressions), are unknown; the policy's UnknownAction
+// decides whether they fail the evaluation, are reported as warnings, or are ignored.
+//
+// Example usage:
+//
+//	p := licensex.NewPolicy().
+//	    WithAllow("MIT", "Apache-2.0", "BSD-3-Clause").
+//	    WithDeny("AGPL-3.0-only").
+//	    WithUnknown(licensex.UnknownWarn)
+//
+//	result, err := p.CheckSBOM(sbom)
+//	if err != nil {
+//	    // handle error
+//	}
+//	if !result.Passed {
+//	    return result.Err()
+//	}
+package licensex
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/sbomx"
+)
+
+// Reason is the reason a package is reported.
+type Reason string
+
+const (
+	// ReasonDenied reports a package whose license is denied.
+	ReasonDenied Reason = "denied"
+	// ReasonNotAllowed reports a package whose license is not in the allow list.
+	ReasonNotAllowed Reason = "not-allowed"
+	// ReasonUnknown reports a package whose license is missing or can't be classified.
+	ReasonUnknown Reason = "unknown"
+)
+
+// UnknownAction decides how packages with unknown licenses are handled.
+type UnknownAction string
+
+const (
+	// UnknownDeny reports unknown licenses as violations. It is the default.
+	UnknownDeny UnknownAction = "deny"
+	// UnknownWarn reports unknown licenses as warnings.
+	UnknownWarn UnknownAction = "warn"
+	// UnknownAllow ignores unknown licenses.
+	UnknownAllow UnknownAction = "allow"
+)
+
+// verdict is the outcome of the evaluation of a license, ordered from the best to the worst.
+type verdict int
+
+const (
+	verdictAllowed verdict = iota
+	verdictUnknown
+	verdictNotAllowed
+	verdictDenied
+)
+
+// reasons maps the verdicts reported to their reasons.
+var reasons = map[verdict]Reason{
+	verdictUnknown:    ReasonUnknown,
+	verdictNotAllowed: ReasonNotAllowed,
+	verdictDenied:     ReasonDenied,
+}
+
+// Violation describes a package whose license doesn't satisfy the policy.
+type Violation struct {
+	// Package is the name of the package.
+	Package string `json:"package"`
+	// Version is the version of the package.
+	Version string `json:"version,omitempty"`
+	// License is the license expression of the package. It is empty when the package declares
+	// none.
+	License string `json:"license,omitempty"`
+	// Reason is the reason the package is reported.
+	Reason Reason `json:"reason"`
+}
+
+// String describes the violation.
+func (v Violation) String() string {
+	license := v.License
+	if license == "" {
+		license = "no license"
+	}
+
+	return fmt.Sprintf("%s %s: %s (%s)", v.Package, v.Version, license, v.Reason)
+}
+
+// Result is the outcome of a license policy evaluation.
+type Result struct {
+	// Passed reports whether no package violates the policy.
+	Passed bool `json:"passed"`
+	// Violations are the packages violating the policy, sorted by name and version.
+	Violations []Violation `json:"violations,omitempty"`
+	// Warnings are the packages with unknown licenses under UnknownWarn, sorted by name and
+	// version.
+	Warnings []Violation `json:"warnings,omitempty"`
+}
+
+// Err returns an error listing the violations, or nil if the evaluation passed.
+func (r Result) Err() error {
+	if r.Passed {
+		return nil
+	}
+
+	msgs := make([]string, 0, len(r.Violations))
+	for _, v := range r.Violations {
+		msgs = append(msgs, v.String())
+	}
+
+	return fmt.Errorf("license policy failed: %s", strings.Join(msgs, "; "))
+}
+
+// Policy holds the allowed and denied licenses evaluated against the packages of SBOMs.
+type Policy struct {
+	// allow are the allowed license identifiers. Every license not denied is allowed when empty.
+	allow []string
+
+	// deny are the denied license identifiers.
+	deny []string
+
+	// unknown decides how unknown licenses are handled.
+	unknown UnknownAction
+}
+
+// NewPolicy creates a Policy allowing every license, and denying unknown licenses.
+func NewPolicy() *Policy {
+	return &Policy{unknown: UnknownDeny}
+}
+
+// WithAllow adds license identifiers to the allow list. Once the list is set, licenses out of it
+// are violations. Identifiers are case-insensitive, as in SPDX.
+func (p *Policy) WithAllow(ids ...string) *Policy {
+	p.allow = append(p.allow, ids...)
+	return p
+}
+
+// WithDeny adds license identifiers to the deny list. Denied licenses are violations even when
+// they are allowed.
+func (p *Policy) WithDeny(ids ...string) *Policy {
+	p.deny = append(p.deny, ids...)
+	return p
+}
+
+// WithUnknown sets how unknown licenses are handled. It defaults to UnknownDeny.
+func (p *Policy) WithUnknown(action UnknownAction) *Policy {
+	p.unknown = action
+	return p
+}
+
+// EvaluateLicense returns the reason the license expression 'license' doesn't satisfy the
+// policy, or an empty reason when it does.
+func (p *Policy) EvaluateLicense(license string) Reason {
+	return reasons[p.evaluate(license)]
+}
+
+// evaluate returns the verdict of a license expression.
+func (p *Policy) evaluate(license string) verdict {
+	e, err := ParseExpression(license)
+	if err != nil {
+		return verdictUnknown
+	}
+
+	return p.evaluateExpression(e)
+}
+
+// evaluateExpression returns the verdict of a parsed license expression: the best verdict of
+// the alternatives of OR, and the worst verdict of the operands of AND.
+func (p *Policy) evaluateExpression(e *Expression) verdict {
+	switch e.Op {
+	case "OR":
+		best := verdictDenied
+		for _, a := range e.Args {
+			best = min(best, p.evaluateExpression(a))
+		}
+		return best
+	case "AND":
+		worst := verdictAllowed
+		for _, a := range e.Args {
+			worst = max(worst, p.evaluateExpression(a))
+		}
+		return worst
+	}
+
+	switch {
+	case containsFold(p.deny, e.License):
+		return verdictDenied
+	case containsFold(p.allow, e.License):
+		return verdictAllowed
+	case !IsKnown(e.License):
+		return verdictUnknown
+	case len(p.allow) > 0:
+		return verdictNotAllowed
+	default:
+		return verdictAllowed
+	}
+}
+
+// Evaluate evaluates the policy against the licenses of 'packages'.
+func (p *Policy) Evaluate(packages []sbomx.Package) Result {
+	var result Result
+
+	for _, pkg := range packages {
+		v := p.evaluate(pkg.License)
+		if v == verdictAllowed {
+			continue
+		}
+
+		violation := Violation{Package: pkg.Name, Version: pkg.Version, License: pkg.License, Reason: reasons[v]}
+
+		switch {
+		case v != verdictUnknown, p.unknown == UnknownDeny:
+			result.Violations = append(result.Violations, violation)
+		case p.unknown == UnknownWarn:
+			result.Warnings = append(result.Warnings, violation)
+		}
+	}
+
+	sortViolations(result.Violations)
+	sortViolations(result.Warnings)
+	result.Passed = len(result.Violations) == 0
+
+	return result
+}
+
+// CheckSBOM evaluates the policy against the packages of an SPDX or CycloneDX JSON document.
+//
+// Returns:
+//   - The result of the evaluation.
+//   - An error if the document can't be parsed.
+func (p *Policy) CheckSBOM(data []byte) (Result, error) {
+	packages, err := sbomx.ParsePackages(data)
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid SBOM: %w", err)
+	}
+
+	return p.Evaluate(packages), nil
+}
+
+// ExtractIdentifiers returns the license identifiers of the packages of an SPDX or CycloneDX
+// JSON document, sorted and deduplicated. Malformed license expressions are skipped.
+//
+// Returns:
+//   - The license identifiers.
+//   - An error if the document can't be parsed.
+func ExtractIdentifiers(data []byte) ([]string, error) {
+	packages, err := sbomx.ParsePackages(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SBOM: %w", err)
+	}
+
+	var ids []string
+	for _, pkg := range packages {
+		e, err := ParseExpression(pkg.License)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, e.Identifiers()...)
+	}
+
+	slices.Sort(ids)
+
+	return slices.Compact(ids), nil
+}
+
+// IsKnown reports whether 'id' is a license identifier a policy can classify: LicenseRef-*
+// references to licenses defined in the document and the NOASSERTION and NONE placeholders
+// are not.
+func IsKnown(id string) bool {
+	upper := strings.ToUpper(id)
+	return !strings.HasPrefix(upper, "LICENSEREF-") && !strings.HasPrefix(upper, "DOCUMENTREF-") &&
+		upper != "NOASSERTION" && upper != "NONE"
+}
+
+// containsFold reports whether 'ids' holds 'id', case-insensitively.
+func containsFold(ids []string, id string) bool {
+	return slices.ContainsFunc(ids, func(s string) bool { return strings.EqualFold(s, id) })
+}
+
+// sortViolations sorts violations by package name and version.
+func sortViolations(violations []Violation) {
+	slices.SortFunc(violations, func(a, b Violation) int {
+		if c := strings.Compare(a.Package, b.Package); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Version, b.Version)
+	})
+}
@@ -0,0 +1,92 @@
+package licensex
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/sbomx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyEvaluateLicense(t *testing.T) {
+	p := NewPolicy().WithAllow("MIT", "Apache-2.0").WithDeny("AGPL-3.0-only")
+
+	tests := map[string]Reason{
+		"MIT":                     "",
+		"mit":                     "",
+		"GPL-3.0-only":            ReasonNotAllowed,
+		"AGPL-3.0-only":           ReasonDenied,
+		"MIT OR AGPL-3.0-only":    "",
+		"MIT AND AGPL-3.0-only":   ReasonDenied,
+		"MIT AND GPL-3.0-only":    ReasonNotAllowed,
+		"MIT AND LicenseRef-acme": ReasonUnknown,
+		"LicenseRef-acme OR MIT":  "",
+		"NOASSERTION":             ReasonUnknown,
+		"":                        ReasonUnknown,
+		"MIT OR":                  ReasonUnknown,
+	}
+
+	for license, want := range tests {
+		assert.Equal(t, want, p.EvaluateLicense(license), license)
+	}
+
+	assert.Equal(t, Reason(""), NewPolicy().WithDeny("AGPL-3.0-only").EvaluateLicense("GPL-3.0-only"))
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	packages := []sbomx.Package{
+		{Name: "zlib", Version: "1.3", License: "Zlib"},
+		{Name: "glibc", Version: "2.39", License: "LGPL-2.1-or-later"},
+		{Name: "busybox", Version: "1.36", License: "GPL-2.0-only"},
+		{Name: "internal", Version: "1.0"},
+	}
+
+	p := NewPolicy().WithAllow("Zlib", "LGPL-2.1-or-later").WithDeny("GPL-2.0-only")
+
+	result := p.Evaluate(packages)
+	assert.False(t, result.Passed)
+	assert.Equal(t, []Violation{
+		{Package: "busybox", Version: "1.36", License: "GPL-2.0-only", Reason: ReasonDenied},
+		{Package: "internal", Version: "1.0", Reason: ReasonUnknown},
+	}, result.Violations)
+	assert.EqualError(t, result.Err(),
+		"license policy failed: busybox 1.36: GPL-2.0-only (denied); internal 1.0: no license (unknown)")
+
+	result = p.WithUnknown(UnknownWarn).Evaluate(packages)
+	assert.Len(t, result.Violations, 1)
+	assert.Equal(t, []Violation{{Package: "internal", Version: "1.0", Reason: ReasonUnknown}}, result.Warnings)
+
+	result = p.WithUnknown(UnknownAllow).Evaluate(packages[:2])
+	assert.True(t, result.Passed)
+	assert.NoError(t, result.Err())
+	assert.Empty(t, result.Warnings)
+}
+
+const cycloneDX = `{
+  "bomFormat": "CycloneDX",
+  "components": [
+    {"name": "app", "version": "1.0", "licenses": [{"expression": "MIT OR Apache-2.0"}]},
+    {"name": "lib", "version": "2.0", "licenses": [{"license": {"id": "BSD-3-Clause"}}]},
+    {"name": "blob", "version": "0.1"}
+  ]
+}`
+
+func TestPolicyCheckSBOM(t *testing.T) {
+	result, err := NewPolicy().WithAllow("MIT").WithUnknown(UnknownWarn).CheckSBOM([]byte(cycloneDX))
+	require.NoError(t, err)
+	assert.Equal(t, []Violation{{Package: "lib", Version: "2.0", License: "BSD-3-Clause", Reason: ReasonNotAllowed}},
+		result.Violations)
+	assert.Equal(t, []Violation{{Package: "blob", Version: "0.1", Reason: ReasonUnknown}}, result.Warnings)
+
+	_, err = NewPolicy().CheckSBOM([]byte("{}"))
+	assert.Error(t, err)
+}
+
+func TestExtractIdentifiers(t *testing.T) {
+	ids, err := ExtractIdentifiers([]byte(cycloneDX))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Apache-2.0", "BSD-3-Clause", "MIT"}, ids)
+
+	_, err = ExtractIdentifiers([]byte("not json"))
+	assert.Error(t, err)
+}
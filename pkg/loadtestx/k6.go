@@ -0,0 +1,357 @@
+// Package loadtestx provides a builder for the k6 load tests of performance gates: the `k6 run`
+// command of a script with its virtual users, duration or ramping stages, environment and
+// summary export, and a parser evaluating the thresholds of the exported summary, so a pipeline
+// can decide on the result without parsing the k6 output.
+//
+// k6 exits with ThresholdsFailedExitCode when a threshold fails; the summary tells which ones.
+//
+// Example usage:
+//
+//	k6 := loadtestx.NewK6Builder("load/checkout.js").
+//	    WithStages(
+//	        loadtestx.Stage{Duration: time.Minute, Target: 50},
+//	        loadtestx.Stage{Duration: 30 * time.Second},
+//	    ).
+//	    WithEnv("BASE_URL", "http://web:3000")
+//
+//	cmd, err := k6.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	// Run cmd, then read the summary at k6.SummaryPath().
+//	result, err := loadtestx.EvaluateSummary(summary)
+//	if err != nil {
+//	    // handle error
+//	}
+//	if !result.Passed {
+//	    return result.Err()
+//	}
+package loadtestx
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the k6 builder in errorsx errors.
+const builderName = "k6"
+
+// K6DefaultImage is the default container image providing k6.
+const K6DefaultImage = "grafana/k6:0.55.0"
+
+// ThresholdsFailedExitCode is the exit code of k6 run when a threshold fails.
+const ThresholdsFailedExitCode = 99
+
+// envVarNameRegex matches valid environment variable names.
+var envVarNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// GetSummaryPath returns the conventional path of the exported k6 summary inside the
+// container. If mntPrefix is empty, fixtures.MntPrefix is used.
+func GetSummaryPath(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, "k6-summary.json")
+}
+
+// Stage is a ramping stage of the virtual users: they ramp to Target over Duration.
+type Stage struct {
+	// Duration is the duration of the stage.
+	Duration time.Duration
+	// Target is the number of virtual users at the end of the stage.
+	Target int
+}
+
+// String renders the stage as k6 expects it (e.g. "1m0s:50").
+func (s Stage) String() string {
+	return fmt.Sprintf("%s:%d", s.Duration, s.Target)
+}
+
+// K6Builder generates the k6 run command of a script.
+type K6Builder struct {
+	// script is the path of the test script.
+	script string
+
+	// vus is the number of virtual users.
+	vus int
+
+	// duration is the duration of the test.
+	duration time.Duration
+
+	// iterations is the total number of iterations, shared by the virtual users.
+	iterations int
+
+	// stages are the ramping stages of the virtual users.
+	stages []Stage
+
+	// env are the environment variables of the script (__ENV), passed with --env.
+	env map[string]string
+
+	// secretEnv are the secrets exposed to the script as environment variables.
+	secretEnv []*secretsx.SecretRef
+
+	// tags are the tags of every metric.
+	tags map[string]string
+
+	// outputs are the outputs of the metrics (e.g. "json=metrics.json").
+	outputs []string
+
+	// summaryPath is the path the end-of-test summary is exported to.
+	summaryPath string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewK6Builder creates a K6Builder of the script 'script', exporting the summary to
+// GetSummaryPath("").
+func NewK6Builder(script string) *K6Builder {
+	return &K6Builder{
+		script:      script,
+		env:         map[string]string{},
+		tags:        map[string]string{},
+		summaryPath: GetSummaryPath(""),
+	}
+}
+
+// WithVUs sets the number of virtual users.
+func (b *K6Builder) WithVUs(vus int) *K6Builder {
+	b.vus = vus
+	return b
+}
+
+// WithDuration sets the duration of the test.
+func (b *K6Builder) WithDuration(duration time.Duration) *K6Builder {
+	b.duration = duration
+	return b
+}
+
+// WithIterations sets the total number of iterations, shared by the virtual users.
+func (b *K6Builder) WithIterations(iterations int) *K6Builder {
+	b.iterations = iterations
+	return b
+}
+
+// WithStages adds ramping stages of the virtual users. Stages replace the duration and
+// iterations.
+func (b *K6Builder) WithStages(stages ...Stage) *K6Builder {
+	b.stages = append(b.stages, stages...)
+	return b
+}
+
+// WithEnv sets the environment variable 'key' of the script, read with __ENV.
+func (b *K6Builder) WithEnv(key, value string) *K6Builder {
+	b.env[key] = value
+	return b
+}
+
+// WithSecretEnv exposes the secret held by 'ref' to the script as the environment variable
+// 'envVar', read with __ENV; it never appears in the command. 'ref' is left unchanged.
+func (b *K6Builder) WithSecretEnv(envVar string, ref *secretsx.SecretRef) *K6Builder {
+	if ref == nil {
+		return b
+	}
+
+	secret := *ref
+	b.secretEnv = append(b.secretEnv, secret.AsEnv(envVar))
+	return b
+}
+
+// WithTag sets the tag 'key' of every metric (e.g. "testid").
+func (b *K6Builder) WithTag(key, value string) *K6Builder {
+	b.tags[key] = value
+	return b
+}
+
+// WithOutput adds outputs of the metrics (e.g. "json=/mnt/k6-metrics.json",
+// "experimental-prometheus-rw").
+func (b *K6Builder) WithOutput(outputs ...string) *K6Builder {
+	b.outputs = append(b.outputs, outputs...)
+	return b
+}
+
+// WithSummaryExport sets the path the end-of-test summary is exported to.
+func (b *K6Builder) WithSummaryExport(path string) *K6Builder {
+	b.summaryPath = path
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *K6Builder) WithLogger(logger *slog.Logger) *K6Builder {
+	b.logger = logger
+	return b
+}
+
+// SummaryPath returns the path the end-of-test summary is exported to.
+func (b *K6Builder) SummaryPath() string {
+	return b.summaryPath
+}
+
+// validate checks the options of the run.
+func (b *K6Builder) validate() error {
+	if b.script == "" {
+		return errorsx.MissingField(builderName, "script", "k6 script is required")
+	}
+
+	if b.summaryPath == "" {
+		return errorsx.MissingField(builderName, "summaryPath", "summary export path is required")
+	}
+
+	for _, o := range []struct {
+		field string
+		value int
+	}{
+		{"vus", b.vus},
+		{"iterations", b.iterations},
+	} {
+		if o.value < 0 {
+			return errorsx.InvalidValue(builderName, o.field, o.value, "%s cannot be negative", o.field)
+		}
+	}
+
+	if b.duration < 0 {
+		return errorsx.InvalidValue(builderName, "duration", b.duration, "duration cannot be negative")
+	}
+
+	if len(b.stages) > 0 && (b.duration > 0 || b.iterations > 0) {
+		return errorsx.ConflictingOptions(builderName, []string{"stages", "duration", "iterations"},
+			"stages replace the duration and iterations")
+	}
+
+	for i, s := range b.stages {
+		if s.Duration <= 0 || s.Target < 0 {
+			return errorsx.InvalidValue(builderName, "stages", s.String(),
+				"stage %d requires a positive duration and a non-negative target", i)
+		}
+	}
+
+	for _, k := range sortedKeys(b.env) {
+		if !envVarNameRegex.MatchString(k) {
+			return errorsx.InvalidValue(builderName, "env", k, "invalid environment variable name: %q", k)
+		}
+	}
+
+	for _, s := range b.secretEnv {
+		if err := s.Validate(); err != nil {
+			return err
+		}
+
+		if !envVarNameRegex.MatchString(s.Target) {
+			return errorsx.InvalidValue(builderName, "secretEnv", s.Target, "invalid environment variable name: %q", s.Target)
+		}
+	}
+
+	return nil
+}
+
+// BuildCommand generates the k6 run command.
+//
+// Returns:
+//   - The k6 run command.
+//   - An error if the script or summary path is missing, a count, the duration or a stage is
+//     invalid, stages are combined with the duration or iterations, or an environment variable
+//     name is invalid.
+func (b *K6Builder) BuildCommand() ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"k6", "run"}
+	if b.vus > 0 {
+		cmd = append(cmd, "--vus", strconv.Itoa(b.vus))
+	}
+
+	if b.duration > 0 {
+		cmd = append(cmd, "--duration", b.duration.String())
+	}
+
+	if b.iterations > 0 {
+		cmd = append(cmd, "--iterations", strconv.Itoa(b.iterations))
+	}
+
+	for _, s := range b.stages {
+		cmd = append(cmd, "--stage", s.String())
+	}
+
+	for _, k := range sortedKeys(b.env) {
+		cmd = append(cmd, "--env", k+"="+b.env[k])
+	}
+
+	for _, k := range sortedKeys(b.tags) {
+		cmd = append(cmd, "--tag", k+"="+b.tags[k])
+	}
+
+	for _, o := range b.outputs {
+		cmd = append(cmd, "--out", o)
+	}
+
+	cmd = append(cmd, "--summary-export", b.summaryPath, b.script)
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Secrets returns the secrets the run requires: the secret environment variables of the script.
+func (b *K6Builder) Secrets() []*secretsx.SecretRef {
+	return append([]*secretsx.SecretRef(nil), b.secretEnv...)
+}
+
+// Env returns the environment variables of the run: the anonymous usage report is disabled.
+// The secret environment variables are not included (see Secrets).
+func (b *K6Builder) Env() []types.DaggerEnvVars {
+	return []types.DaggerEnvVars{{Name: "K6_NO_USAGE_REPORT", Value: "true"}}
+}
+
+// Artifacts returns the artifacts of the run: the exported summary.
+func (b *K6Builder) Artifacts() []artifactx.Artifact {
+	return []artifactx.Artifact{{Kind: artifactx.KindReport, Path: b.summaryPath}}
+}
+
+// sortedKeys returns the keys of 'm', sorted.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *K6Builder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *K6Builder) Mounts() []types.Mount {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the k6 run command.
+func (b *K6Builder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewK6Builder(""), explainx.Generate("BuildCommand", b.BuildCommand))
+}
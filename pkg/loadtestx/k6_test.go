@@ -0,0 +1,107 @@
+package loadtestx
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSummaryPath(t *testing.T) {
+	assert.Equal(t, "/mnt/k6-summary.json", GetSummaryPath(""))
+	assert.Equal(t, "/work/k6-summary.json", GetSummaryPath("/work"))
+}
+
+func TestK6BuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *K6Builder
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "defaults",
+			builder: NewK6Builder("load/checkout.js"),
+			want:    []string{"k6", "run", "--summary-export", "/mnt/k6-summary.json", "load/checkout.js"},
+		},
+		{
+			name: "constant load",
+			builder: NewK6Builder("load/checkout.js").
+				WithVUs(20).
+				WithDuration(90*time.Second).
+				WithEnv("BASE_URL", "http://web:3000").
+				WithEnv("API_URL", "http://api:8080").
+				WithTag("testid", "pr-42").
+				WithOutput("json=/mnt/k6-metrics.json").
+				WithSummaryExport("/work/summary.json"),
+			want: []string{
+				"k6", "run", "--vus", "20", "--duration", "1m30s",
+				"--env", "API_URL=http://api:8080", "--env", "BASE_URL=http://web:3000",
+				"--tag", "testid=pr-42", "--out", "json=/mnt/k6-metrics.json",
+				"--summary-export", "/work/summary.json", "load/checkout.js",
+			},
+		},
+		{
+			name: "stages",
+			builder: NewK6Builder("load/checkout.js").
+				WithStages(Stage{Duration: time.Minute, Target: 50}, Stage{Duration: 30 * time.Second}),
+			want: []string{
+				"k6", "run", "--stage", "1m0s:50", "--stage", "30s:0",
+				"--summary-export", "/mnt/k6-summary.json", "load/checkout.js",
+			},
+		},
+		{
+			name:    "iterations",
+			builder: NewK6Builder("smoke.js").WithVUs(1).WithIterations(10),
+			want:    []string{"k6", "run", "--vus", "1", "--iterations", "10", "--summary-export", "/mnt/k6-summary.json", "smoke.js"},
+		},
+		{name: "missing script", builder: NewK6Builder(""), wantErr: errorsx.ErrMissingField},
+		{name: "missing summary path", builder: NewK6Builder("a.js").WithSummaryExport(""), wantErr: errorsx.ErrMissingField},
+		{name: "negative vus", builder: NewK6Builder("a.js").WithVUs(-1), wantErr: errorsx.ErrInvalidValue},
+		{name: "negative duration", builder: NewK6Builder("a.js").WithDuration(-time.Second), wantErr: errorsx.ErrInvalidValue},
+		{
+			name:    "stages with duration",
+			builder: NewK6Builder("a.js").WithDuration(time.Minute).WithStages(Stage{Duration: time.Minute, Target: 5}),
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+		{name: "empty stage", builder: NewK6Builder("a.js").WithStages(Stage{Target: 5}), wantErr: errorsx.ErrInvalidValue},
+		{name: "invalid env name", builder: NewK6Builder("a.js").WithEnv("BASE-URL", "x"), wantErr: errorsx.ErrInvalidValue},
+		{
+			name:    "invalid secret env name",
+			builder: NewK6Builder("a.js").WithSecretEnv("API TOKEN", secretsx.FromEnv("token", "CI_TOKEN")),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestK6BuilderSecretsAndArtifacts(t *testing.T) {
+	token := secretsx.FromEnv("api-token", "CI_API_TOKEN")
+	b := NewK6Builder("load/checkout.js").WithSecretEnv("API_TOKEN", token).WithSecretEnv("X", nil)
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.NotContains(t, cmd, "API_TOKEN")
+
+	require.Len(t, b.Secrets(), 1)
+	assert.Equal(t, "API_TOKEN", b.Secrets()[0].Target)
+	assert.Equal(t, "CI_API_TOKEN", token.Target)
+
+	assert.Equal(t, []artifactx.Artifact{{Kind: artifactx.KindReport, Path: "/mnt/k6-summary.json"}}, b.Artifacts())
+}
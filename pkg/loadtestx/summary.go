@@ -0,0 +1,201 @@
+package loadtestx
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/policyx"
+)
+
+// RuleThreshold requires the k6 thresholds to pass.
+const RuleThreshold policyx.Rule = "threshold"
+
+// Threshold is the result of a k6 threshold.
+type Threshold struct {
+	// Metric is the name of the metric (e.g. "http_req_duration{status:200}").
+	Metric string `json:"metric"`
+	// Expression is the threshold expression (e.g. "p(95)<500").
+	Expression string `json:"expression"`
+	// Passed reports whether the threshold passed.
+	Passed bool `json:"passed"`
+}
+
+// String describes the threshold.
+func (t Threshold) String() string {
+	return fmt.Sprintf("%s: %s", t.Metric, t.Expression)
+}
+
+// Metric is a metric of a k6 summary.
+type Metric struct {
+	// Values are the aggregated values of the metric (e.g. "avg", "p(95)", "rate").
+	Values map[string]float64 `json:"values"`
+	// Thresholds are the results of the thresholds of the metric, sorted by expression.
+	Thresholds []Threshold `json:"thresholds,omitempty"`
+}
+
+// Summary is a parsed k6 end-of-test summary.
+type Summary struct {
+	// Metrics are the metrics of the summary, by name.
+	Metrics map[string]Metric `json:"metrics"`
+}
+
+// ParseSummary parses a k6 summary: the --summary-export JSON file, or the data handleSummary
+// receives serialized as JSON. The summary export records whether each threshold failed, the
+// handleSummary data whether it is ok.
+//
+// Returns:
+//   - The parsed summary.
+//   - An error if the summary is not valid JSON or has no metrics.
+func ParseSummary(data []byte) (*Summary, error) {
+	var doc struct {
+		Metrics map[string]map[string]json.RawMessage `json:"metrics"`
+	}
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid k6 summary: %w", err)
+	}
+
+	if doc.Metrics == nil {
+		return nil, fmt.Errorf("invalid k6 summary: no metrics")
+	}
+
+	summary := &Summary{Metrics: make(map[string]Metric, len(doc.Metrics))}
+	for name, fields := range doc.Metrics {
+		metric := Metric{Values: map[string]float64{}}
+
+		for key, raw := range fields {
+			switch key {
+			case "thresholds":
+				thresholds, err := parseThresholds(name, raw)
+				if err != nil {
+					return nil, err
+				}
+				metric.Thresholds = thresholds
+			case "values":
+				if err := json.Unmarshal(raw, &metric.Values); err != nil {
+					return nil, fmt.Errorf("invalid k6 summary: metric %s: %w", name, err)
+				}
+			default:
+				var v float64
+				if json.Unmarshal(raw, &v) == nil {
+					metric.Values[key] = v
+				}
+			}
+		}
+
+		summary.Metrics[name] = metric
+	}
+
+	return summary, nil
+}
+
+// parseThresholds parses the thresholds of the metric 'name': booleans reporting whether the
+// threshold failed (summary export), or objects with an "ok" field (handleSummary data).
+func parseThresholds(name string, raw json.RawMessage) ([]Threshold, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("invalid k6 summary: thresholds of %s: %w", name, err)
+	}
+
+	thresholds := make([]Threshold, 0, len(fields))
+	for expr, v := range fields {
+		var failed bool
+		if err := json.Unmarshal(v, &failed); err != nil {
+			var result struct {
+				OK *bool `json:"ok"`
+			}
+			if err := json.Unmarshal(v, &result); err != nil || result.OK == nil {
+				return nil, fmt.Errorf("invalid k6 summary: threshold %s of %s", expr, name)
+			}
+			failed = !*result.OK
+		}
+
+		thresholds = append(thresholds, Threshold{Metric: name, Expression: expr, Passed: !failed})
+	}
+
+	sort.Slice(thresholds, func(i, j int) bool {
+		return thresholds[i].Expression < thresholds[j].Expression
+	})
+
+	return thresholds, nil
+}
+
+// Thresholds returns the thresholds of every metric, sorted by metric and expression.
+func (s *Summary) Thresholds() []Threshold {
+	names := make([]string, 0, len(s.Metrics))
+	for name := range s.Metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var thresholds []Threshold
+	for _, name := range names {
+		thresholds = append(thresholds, s.Metrics[name].Thresholds...)
+	}
+
+	return thresholds
+}
+
+// Result evaluates the thresholds of the summary: every failed threshold is a RuleThreshold
+// violation, with the values of its metric the expression refers to.
+func (s *Summary) Result() policyx.Result {
+	result := policyx.Result{Passed: true}
+	for _, t := range s.Thresholds() {
+		if t.Passed {
+			continue
+		}
+
+		msg := "threshold " + t.String() + " failed"
+		if observed := s.observed(t); observed != "" {
+			msg += " (" + observed + ")"
+		}
+
+		result.Passed = false
+		result.Violations = append(result.Violations, policyx.Violation{Rule: RuleThreshold, Message: msg})
+	}
+
+	return result
+}
+
+// observed describes the value of the metric of 't' its expression refers to (e.g.
+// "p(95)=612.4"), or an empty string if the summary doesn't hold it.
+func (s *Summary) observed(t Threshold) string {
+	values := s.Metrics[t.Metric].Values
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	// Longest keys first, so "p(95)" is not taken for a prefix of "p(95.5)".
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+
+	expr := strings.ReplaceAll(t.Expression, " ", "")
+	for _, k := range keys {
+		if strings.HasPrefix(expr, k) {
+			return fmt.Sprintf("%s=%g", k, values[k])
+		}
+	}
+
+	return ""
+}
+
+// EvaluateSummary parses the k6 summary 'data' and evaluates its thresholds.
+//
+// Returns:
+//   - The result of the evaluation.
+//   - An error if the summary can't be parsed.
+func EvaluateSummary(data []byte) (policyx.Result, error) {
+	summary, err := ParseSummary(data)
+	if err != nil {
+		return policyx.Result{}, err
+	}
+
+	return summary.Result(), nil
+}
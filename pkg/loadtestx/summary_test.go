@@ -0,0 +1,96 @@
+package loadtestx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exportedSummary = `{
+  "root_group": {"name": "", "path": "", "groups": {}, "checks": {}},
+  "metrics": {
+    "http_req_duration": {
+      "avg": 231.4, "min": 12.1, "med": 198.2, "max": 1204.9, "p(90)": 480.3, "p(95)": 612.4,
+      "thresholds": {"p(95)<500": true, "avg<300": false}
+    },
+    "http_req_failed": {
+      "passes": 3, "fails": 997, "value": 0.003,
+      "thresholds": {"rate<0.01": false}
+    },
+    "iterations": {"count": 1000, "rate": 16.6}
+  }
+}`
+
+const handleSummaryData = `{
+  "metrics": {
+    "http_req_duration": {
+      "type": "trend",
+      "contains": "time",
+      "values": {"avg": 231.4, "p(95)": 412.4},
+      "thresholds": {"p(95)<500": {"ok": true}}
+    },
+    "checks": {
+      "type": "rate",
+      "values": {"rate": 0.97, "passes": 970, "fails": 30},
+      "thresholds": {"rate>0.99": {"ok": false}}
+    }
+  }
+}`
+
+func TestParseSummaryExport(t *testing.T) {
+	s, err := ParseSummary([]byte(exportedSummary))
+	require.NoError(t, err)
+
+	assert.Equal(t, 612.4, s.Metrics["http_req_duration"].Values["p(95)"])
+	assert.Equal(t, 1000.0, s.Metrics["iterations"].Values["count"])
+	assert.Equal(t, []Threshold{
+		{Metric: "http_req_duration", Expression: "avg<300", Passed: true},
+		{Metric: "http_req_duration", Expression: "p(95)<500", Passed: false},
+		{Metric: "http_req_failed", Expression: "rate<0.01", Passed: true},
+	}, s.Thresholds())
+
+	assert.Equal(t, policyx.Result{
+		Passed: false,
+		Violations: []policyx.Violation{
+			{Rule: RuleThreshold, Message: "threshold http_req_duration: p(95)<500 failed (p(95)=612.4)"},
+		},
+	}, s.Result())
+}
+
+func TestParseHandleSummaryData(t *testing.T) {
+	result, err := EvaluateSummary([]byte(handleSummaryData))
+	require.NoError(t, err)
+
+	assert.False(t, result.Passed)
+	assert.Equal(t, []policyx.Violation{
+		{Rule: RuleThreshold, Message: "threshold checks: rate>0.99 failed (rate=0.97)"},
+	}, result.Violations)
+	assert.EqualError(t, result.Err(), "policy failed: [threshold] threshold checks: rate>0.99 failed (rate=0.97)")
+}
+
+func TestEvaluateSummaryPassed(t *testing.T) {
+	result, err := EvaluateSummary([]byte(`{"metrics": {"checks": {"value": 1, "thresholds": {"rate==1": false}}}}`))
+	require.NoError(t, err)
+	assert.True(t, result.Passed)
+	assert.NoError(t, result.Err())
+
+	result, err = EvaluateSummary([]byte(`{"metrics": {"vus": {"value": 1}}}`))
+	require.NoError(t, err)
+	assert.True(t, result.Passed, "a summary without thresholds passes")
+}
+
+func TestParseSummaryErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"invalid json":      `{`,
+		"no metrics":        `{"root_group": {}}`,
+		"invalid threshold": `{"metrics": {"checks": {"thresholds": {"rate>0.9": "yes"}}}}`,
+		"invalid values":    `{"metrics": {"checks": {"values": [1]}}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseSummary([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,276 @@
+// Package localdev provides the planning side of the watch mode of Dagger modules: fast inner-loop
+// rebuilds of apko images during local development.
+//
+// A Watch maps file-change globs (configuration files, source directories, local package
+// repositories) to the nodes of a pipeline.Pipeline they feed. Given the paths a file watcher
+// reports, it returns the subset of the pipeline to re-run: the nodes whose inputs changed and
+// every node depending on them, grouped by stage. Watching the files and running the nodes is
+// left to the module.
+//
+// Example usage:
+//
+//	watch := localdev.NewWatch(p).
+//	    WithRoot(repoDir).
+//	    WithApko("apko", apkoBuilder).
+//	    WithRule("melange", "packages/**", "melange.yaml").
+//	    WithIgnore("**/*.swp")
+//
+//	rebuild, err := watch.Plan(changedPaths...)
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	for _, stage := range rebuild.Stages {
+//	    // run the nodes of the stage
+//	}
+package localdev
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/pipeline"
+	"github.com/Excoriate/daggerx/pkg/workspacex"
+)
+
+// builderName identifies the watch planner in errorsx errors.
+const builderName = "watch"
+
+// rule maps globs to the node they are inputs of.
+type rule struct {
+	// node is the identifier of the node.
+	node string
+	// globs are the globs of the inputs.
+	globs []string
+}
+
+// Watch maps file-change globs to the pipeline nodes that must re-run when matching files
+// change.
+type Watch struct {
+	// pipeline is the pipeline whose nodes are re-run.
+	pipeline *pipeline.Pipeline
+	// root is the directory the changed paths and globs are relative to.
+	root string
+	// rules holds the rules in insertion order.
+	rules []rule
+	// ignores holds the globs of the paths never triggering a rebuild.
+	ignores []string
+}
+
+// Rebuild is the subset of a pipeline to re-run after files changed.
+type Rebuild struct {
+	// Changed holds the changed paths matching a rule, relative to the root.
+	Changed []string `json:"changed,omitempty"`
+	// Triggered holds the identifiers of the nodes whose inputs changed.
+	Triggered []string `json:"triggered,omitempty"`
+	// Nodes holds the identifiers of the nodes to re-run, the triggered ones and their
+	// dependents, in topological order.
+	Nodes []string `json:"nodes,omitempty"`
+	// Stages holds the nodes to re-run grouped by stage; nodes of the same stage can run in
+	// parallel.
+	Stages [][]string `json:"stages,omitempty"`
+}
+
+// NewWatch creates a watch planner of the pipeline 'p'.
+func NewWatch(p *pipeline.Pipeline) *Watch {
+	return &Watch{pipeline: p}
+}
+
+// WithRoot sets the directory the changed paths and globs are relative to, usually the root of
+// the repository. Absolute changed paths under it are made relative to it; the others are
+// ignored.
+// It returns the updated Watch instance.
+func (w *Watch) WithRoot(dir string) *Watch {
+	w.root = dir
+	return w
+}
+
+// WithRule re-runs the node 'node' when a file matching one of 'globs' changes. Globs support
+// '*', '?' and '**', and a glob matching a directory matches everything below it.
+// It returns the updated Watch instance.
+func (w *Watch) WithRule(node string, globs ...string) *Watch {
+	w.rules = append(w.rules, rule{node: node, globs: globs})
+	return w
+}
+
+// WithApko re-runs the node 'node' when the inputs of the apko build 'b' change: its
+// configuration file, lockfile, build context and local build repositories. Its outputs (the
+// tarball, the SBOMs, the build information and the image references) are ignored, so a build
+// does not trigger itself.
+// It returns the updated Watch instance.
+func (w *Watch) WithApko(node string, b *apkox.ApkoBuilder) *Watch {
+	spec := b.Spec()
+
+	var inputs []string
+	for _, p := range append([]string{spec.ConfigFile, spec.Lockfile, spec.BuildContext}, spec.BuildRepositoryAppend...) {
+		if p != "" && !strings.Contains(p, "://") {
+			inputs = append(inputs, p)
+		}
+	}
+
+	var outputs []string
+	for _, p := range []string{spec.OutputTarball, spec.SBOMPath, spec.BuildInfoPath, spec.ImageRefs} {
+		if p != "" {
+			outputs = append(outputs, p)
+		}
+	}
+
+	return w.WithRule(node, inputs...).WithIgnore(outputs...)
+}
+
+// WithIgnore sets globs of paths that never trigger a rebuild, e.g. the outputs of the nodes or
+// the swap files of editors.
+// It returns the updated Watch instance.
+func (w *Watch) WithIgnore(globs ...string) *Watch {
+	w.ignores = append(w.ignores, globs...)
+	return w
+}
+
+// Patterns returns the globs of the rules relative to the root, deduplicated and sorted, for the
+// file watcher to subscribe to.
+func (w *Watch) Patterns() []string {
+	var globs []string
+	for _, r := range w.rules {
+		globs = append(globs, w.relativeAll(r.globs)...)
+	}
+
+	slices.Sort(globs)
+
+	return slices.Compact(globs)
+}
+
+// Plan returns the nodes to re-run after the files 'changed' changed.
+//
+// Returns:
+//   - The rebuild. It is empty when no rule matches the changed paths.
+//   - An error if a rule has no glob, names an unknown node or holds an invalid glob, or if the
+//     pipeline is invalid.
+func (w *Watch) Plan(changed ...string) (*Rebuild, error) {
+	if w.pipeline == nil {
+		return nil, errorsx.MissingField(builderName, "pipeline", "pipeline is required")
+	}
+
+	ignore, err := workspacex.NewMatcher(nil, w.relativeAll(w.ignores))
+	if err != nil {
+		return nil, errorsx.InvalidValue(builderName, "ignore", w.ignores, "invalid ignore glob: %v", err)
+	}
+
+	matchers := make([]*workspacex.Matcher, len(w.rules))
+	for i, r := range w.rules {
+		if _, ok := w.pipeline.Node(r.node); !ok {
+			return nil, errorsx.InvalidValue(builderName, "rule", r.node, "rule of unknown node %s", r.node)
+		}
+
+		if len(r.globs) == 0 {
+			return nil, errorsx.MissingField(builderName, "rule", "rule of node %s requires a glob", r.node)
+		}
+
+		globs := w.relativeAll(r.globs)
+		if len(globs) == 0 {
+			// Every input is outside of the root: no changed path can match.
+			continue
+		}
+
+		if matchers[i], err = workspacex.NewMatcher(globs, nil); err != nil {
+			return nil, errorsx.InvalidValue(builderName, "rule", r.globs, "invalid glob of node %s: %v", r.node, err)
+		}
+	}
+
+	rebuild := &Rebuild{}
+	triggered := map[string]bool{}
+	for _, p := range changed {
+		rel, ok := w.relative(p)
+		if !ok || rel == "." || ignore.Excluded(rel) {
+			continue
+		}
+
+		matched := false
+		for i, r := range w.rules {
+			if matchers[i] != nil && matchers[i].Match(rel) {
+				matched = true
+				triggered[r.node] = true
+			}
+		}
+
+		if matched && !slices.Contains(rebuild.Changed, rel) {
+			rebuild.Changed = append(rebuild.Changed, rel)
+		}
+	}
+
+	if len(triggered) == 0 {
+		return rebuild, nil
+	}
+
+	stages, err := w.pipeline.Stages()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stage := range stages {
+		for _, id := range stage {
+			if triggered[id] {
+				rebuild.Triggered = append(rebuild.Triggered, id)
+			}
+		}
+	}
+
+	if rebuild.Nodes, err = w.pipeline.Dependents(rebuild.Triggered...); err != nil {
+		return nil, err
+	}
+
+	for _, stage := range stages {
+		var rerun []string
+		for _, id := range stage {
+			if slices.Contains(rebuild.Nodes, id) {
+				rerun = append(rerun, id)
+			}
+		}
+
+		if len(rerun) > 0 {
+			rebuild.Stages = append(rebuild.Stages, rerun)
+		}
+	}
+
+	return rebuild, nil
+}
+
+// Empty reports whether the rebuild re-runs no node.
+func (r *Rebuild) Empty() bool {
+	return len(r.Nodes) == 0
+}
+
+// relative returns the path 'p' relative to the root, slash-separated, and whether it is under
+// the root. Relative paths are returned unchanged.
+func (w *Watch) relative(p string) (string, bool) {
+	if w.root == "" || !filepath.IsAbs(p) {
+		return filepath.ToSlash(p), true
+	}
+
+	rel, err := filepath.Rel(w.root, p)
+	if err != nil || !filepath.IsLocal(rel) && rel != "." {
+		return "", false
+	}
+
+	return filepath.ToSlash(rel), true
+}
+
+// relativeAll returns the globs 'globs' relative to the root (see relative), without the
+// absolute globs outside of it. The root itself becomes "**".
+func (w *Watch) relativeAll(globs []string) []string {
+	out := make([]string, 0, len(globs))
+	for _, g := range globs {
+		rel, ok := w.relative(g)
+		switch {
+		case !ok:
+		case rel == ".":
+			out = append(out, "**")
+		default:
+			out = append(out, rel)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,90 @@
+package localdev
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/pipeline"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPipeline(t *testing.T) *pipeline.Pipeline {
+	t.Helper()
+
+	p := pipeline.New("dev")
+	nodes := []pipeline.Node{
+		{ID: "melange", Command: types.DaggerCMD{"melange", "build"}},
+		{ID: "apko", Command: types.DaggerCMD{"apko", "build"}, DependsOn: []string{"melange"}},
+		{ID: "scan", Command: types.DaggerCMD{"grype", "image.tar"}, DependsOn: []string{"apko"}},
+		{ID: "load", Command: types.DaggerCMD{"docker", "load"}, DependsOn: []string{"apko"}},
+	}
+
+	for _, n := range nodes {
+		require.NoError(t, p.AddNode(n))
+	}
+
+	return p
+}
+
+func newWatch(t *testing.T) *Watch {
+	t.Helper()
+
+	apko := apkox.NewApkoBuilder().
+		WithConfigFile("/repo/apko.yaml").
+		WithOutputTarball("/repo/out/image.tar").
+		WithBuildRepositoryAppend("/repo/packages").
+		WithBuildRepositoryAppend("https://packages.wolfi.dev/os")
+
+	return NewWatch(newPipeline(t)).
+		WithRoot("/repo").
+		WithApko("apko", apko).
+		WithRule("melange", "melange.yaml", "src").
+		WithIgnore("**/*.swp")
+}
+
+func TestPlan(t *testing.T) {
+	rebuild, err := newWatch(t).Plan("/repo/apko.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, &Rebuild{
+		Changed:   []string{"apko.yaml"},
+		Triggered: []string{"apko"},
+		Nodes:     []string{"apko", "scan", "load"},
+		Stages:    [][]string{{"apko"}, {"scan", "load"}},
+	}, rebuild)
+
+	rebuild, err = newWatch(t).Plan("src/main.go", "/repo/packages/x86_64/APKINDEX.tar.gz")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/main.go", "packages/x86_64/APKINDEX.tar.gz"}, rebuild.Changed)
+	assert.Equal(t, []string{"melange", "apko"}, rebuild.Triggered)
+	assert.Equal(t, [][]string{{"melange"}, {"apko"}, {"scan", "load"}}, rebuild.Stages)
+}
+
+func TestPlanIgnoresUnrelatedChanges(t *testing.T) {
+	rebuild, err := newWatch(t).Plan(
+		"/repo/out/image.tar",
+		"/repo/src/.main.go.swp",
+		"/repo/README.md",
+		"/elsewhere/apko.yaml",
+	)
+	require.NoError(t, err)
+	assert.True(t, rebuild.Empty())
+	assert.Empty(t, rebuild.Changed)
+}
+
+func TestPatterns(t *testing.T) {
+	assert.Equal(t, []string{"apko.yaml", "melange.yaml", "packages", "src"}, newWatch(t).Patterns())
+}
+
+func TestPlanErrors(t *testing.T) {
+	_, err := NewWatch(newPipeline(t)).WithRule("missing", "src").Plan("src/a")
+	require.ErrorIs(t, err, errorsx.ErrInvalidValue)
+
+	_, err = NewWatch(newPipeline(t)).WithRule("apko").Plan("src/a")
+	require.ErrorIs(t, err, errorsx.ErrMissingField)
+
+	_, err = NewWatch(nil).Plan("src/a")
+	require.ErrorIs(t, err, errorsx.ErrMissingField)
+}
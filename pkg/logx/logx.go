@@ -0,0 +1,146 @@
+// Package logx provides the slog helpers daggerx builders use to trace what they produce:
+// generated commands (with secret redaction), validation warnings, and timings, all emitted at
+// debug level except warnings.
+//
+// Builders accept an optional *slog.Logger; when none is set, logging is discarded.
+//
+// Secret values and patterns registered with the default Redactor (see RegisterSecret) are
+// scrubbed from logged commands, and from the reports, pipeline exports and provenance predicates
+// built by other packages. Wrapping a handler with Redactor.Handler scrubs every log record.
+//
+// Example usage:
+//
+//	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+//
+//	cmd, err := apkox.NewApkoBuilder().
+//	    WithLogger(logger).
+//	    WithConfigFile("apko.yaml").
+//	    WithOutputImage("registry.example.com/base").
+//	    WithOutputTarball("/mnt/image.tar").
+//	    BuildCommand()
+//
+//	logx.RegisterSecret(token)
+//	logger = slog.New(logx.Default().Handler(slog.NewTextHandler(os.Stderr, nil)))
+package logx
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Redacted replaces redacted values in logged commands.
+const Redacted = "***"
+
+// sensitiveNameRegex matches flag and variable names whose values are secrets.
+var sensitiveNameRegex = regexp.MustCompile(`(?i)(password|passwd|secret|token|api[-_]?key|private[-_]?key|credential|auth)`)
+
+// discardHandler is a slog.Handler dropping every record.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// Discard returns a logger dropping every record.
+func Discard() *slog.Logger {
+	return slog.New(discardHandler{})
+}
+
+// OrDiscard returns 'l', or a discarding logger if 'l' is nil.
+func OrDiscard(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return Discard()
+	}
+
+	return l
+}
+
+// RedactCommand returns a copy of the command with secret values replaced by Redacted. Values
+// of flags with a sensitive name ("--password x", "--token=x"), NAME=value arguments with a
+// sensitive name, and any occurrence of the given secret values are redacted.
+func RedactCommand(cmd []string, secrets ...string) []string {
+	out := make([]string, len(cmd))
+	redactNext := false
+
+	for i, arg := range cmd {
+		switch {
+		case redactNext:
+			out[i] = Redacted
+			redactNext = false
+			continue
+		case strings.HasPrefix(arg, "-"):
+			name, _, hasValue := strings.Cut(arg, "=")
+			if sensitiveNameRegex.MatchString(name) {
+				if hasValue {
+					out[i] = name + "=" + Redacted
+				} else {
+					out[i] = arg
+					redactNext = true
+				}
+				continue
+			}
+		default:
+			if name, _, ok := strings.Cut(arg, "="); ok && sensitiveNameRegex.MatchString(name) {
+				out[i] = name + "=" + Redacted
+				continue
+			}
+		}
+
+		out[i] = redactValues(arg, secrets)
+	}
+
+	return out
+}
+
+// redactValues replaces every occurrence of the secret values in 's'.
+func redactValues(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, Redacted)
+		}
+	}
+
+	return s
+}
+
+// Command logs a generated command at debug level, redacted with RedactCommand and the default
+// Redactor.
+func Command(ctx context.Context, l *slog.Logger, builder string, cmd []string, secrets ...string) {
+	if l == nil || !l.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	l.DebugContext(ctx, "generated command",
+		slog.String("builder", builder),
+		slog.String("command", strings.Join(Default().Command(cmd, secrets...), " ")))
+}
+
+// Warn logs a validation warning of a builder.
+func Warn(ctx context.Context, l *slog.Logger, builder, msg string, attrs ...any) {
+	if l == nil {
+		return
+	}
+
+	l.WarnContext(ctx, msg, append([]any{slog.String("builder", builder)}, attrs...)...)
+}
+
+// Timer starts timing the operation 'op' of a builder. The returned function logs the elapsed
+// time at debug level when called, typically with defer.
+func Timer(ctx context.Context, l *slog.Logger, builder, op string) func() {
+	if l == nil {
+		return func() {}
+	}
+
+	start := time.Now()
+
+	return func() {
+		l.DebugContext(ctx, "operation completed",
+			slog.String("builder", builder),
+			slog.String("operation", op),
+			slog.Duration("duration", time.Since(start)))
+	}
+}
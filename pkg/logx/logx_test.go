@@ -0,0 +1,107 @@
+package logx
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestLogger(level slog.Level) (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: level})), &buf
+}
+
+func TestRedactCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmd     []string
+		secrets []string
+		want    []string
+	}{
+		{
+			name: "Sensitive flag with separate value",
+			cmd:  []string{"crane", "auth", "login", "--password", "hunter2", "-u", "me"},
+			want: []string{"crane", "auth", "login", "--password", Redacted, "-u", "me"},
+		},
+		{
+			name: "Sensitive flag with inline value",
+			cmd:  []string{"tool", "--api-key=abc", "--verbose"},
+			want: []string{"tool", "--api-key=" + Redacted, "--verbose"},
+		},
+		{
+			name: "Sensitive variable assignment",
+			cmd:  []string{"env", "GITHUB_TOKEN=abc", "PATH=/bin"},
+			want: []string{"env", "GITHUB_TOKEN=" + Redacted, "PATH=/bin"},
+		},
+		{
+			name:    "Explicit secret values",
+			cmd:     []string{"sh", "-c", "curl -H 'X-Key: s3cr3t' https://example.com"},
+			secrets: []string{"s3cr3t", ""},
+			want:    []string{"sh", "-c", "curl -H 'X-Key: " + Redacted + "' https://example.com"},
+		},
+		{
+			name: "Nothing to redact",
+			cmd:  []string{"apko", "build", "--arch", "x86_64"},
+			want: []string{"apko", "build", "--arch", "x86_64"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RedactCommand(tt.cmd, tt.secrets...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RedactCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommand(t *testing.T) {
+	l, buf := newTestLogger(slog.LevelDebug)
+	Command(context.Background(), l, "apko", []string{"apko", "--token", "abc"})
+
+	out := buf.String()
+	if !strings.Contains(out, "builder=apko") || !strings.Contains(out, `command="apko --token ***"`) {
+		t.Errorf("Unexpected log output: %s", out)
+	}
+
+	l, buf = newTestLogger(slog.LevelInfo)
+	Command(context.Background(), l, "apko", []string{"apko"})
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output above debug level, got %s", buf.String())
+	}
+
+	// A nil logger is a no-op.
+	Command(context.Background(), nil, "apko", []string{"apko"})
+}
+
+func TestWarnAndTimer(t *testing.T) {
+	l, buf := newTestLogger(slog.LevelDebug)
+
+	Warn(context.Background(), l, "apko", "tag defaulted", "tag", "latest")
+	Timer(context.Background(), l, "apko", "build")()
+
+	out := buf.String()
+	for _, want := range []string{"level=WARN", "tag=latest", "operation=build", "duration="} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in log output: %s", want, out)
+		}
+	}
+
+	Warn(context.Background(), nil, "apko", "ignored")
+	Timer(context.Background(), nil, "apko", "build")()
+}
+
+func TestOrDiscard(t *testing.T) {
+	if OrDiscard(nil).Enabled(context.Background(), slog.LevelError) {
+		t.Error("Expected the discarding logger to be disabled")
+	}
+
+	l := slog.Default()
+	if OrDiscard(l) != l {
+		t.Error("Expected OrDiscard to return the given logger")
+	}
+}
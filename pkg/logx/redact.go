@@ -0,0 +1,218 @@
+package logx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// MinRedactLength is the length under which registered values are ignored: redacting every
+// occurrence of a one or two character value would mangle the output without hiding anything.
+const MinRedactLength = 4
+
+// Redactor tracks secret values and patterns, and scrubs them from commands, serialized specs,
+// logs and reports. It is safe for concurrent use.
+type Redactor struct {
+	mu       sync.RWMutex
+	values   []string
+	patterns []*regexp.Regexp
+}
+
+// defaultRedactor is the redactor shared by every package, see Default.
+var defaultRedactor = NewRedactor()
+
+// NewRedactor creates a redactor with no registered secret.
+func NewRedactor() *Redactor {
+	return &Redactor{}
+}
+
+// Default returns the redactor shared by every package: Command, the JSON and Markdown outputs of
+// reports, pipeline exports and provenance predicates are scrubbed with it.
+func Default() *Redactor {
+	return defaultRedactor
+}
+
+// RegisterSecret registers secret values with the default redactor.
+func RegisterSecret(values ...string) {
+	defaultRedactor.AddValues(values...)
+}
+
+// RegisterPattern registers a regular expression with the default redactor.
+func RegisterPattern(pattern string) error {
+	return defaultRedactor.AddPattern(pattern)
+}
+
+// AddValues registers secret values. Values shorter than MinRedactLength are ignored. Values are
+// also registered in their JSON-escaped form, so they are found in serialized output.
+// It returns the updated Redactor instance.
+func (r *Redactor) AddValues(values ...string) *Redactor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, v := range values {
+		if len(v) < MinRedactLength {
+			continue
+		}
+
+		forms := []string{v}
+		if encoded, err := json.Marshal(v); err == nil {
+			forms = append(forms, strings.Trim(string(encoded), `"`))
+		}
+
+		for _, f := range forms {
+			if !slices.Contains(r.values, f) {
+				r.values = append(r.values, f)
+			}
+		}
+	}
+
+	// Longest values first, so a secret containing another one is redacted as a whole.
+	slices.SortStableFunc(r.values, func(a, b string) int { return len(b) - len(a) })
+
+	return r
+}
+
+// AddPattern registers a regular expression whose matches are secrets.
+//
+// Returns:
+//   - An error if the pattern doesn't compile, or matches the empty string.
+func (r *Redactor) AddPattern(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+	}
+
+	if re.MatchString("") {
+		return fmt.Errorf("redact pattern %q matches the empty string", pattern)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.patterns = append(r.patterns, re)
+
+	return nil
+}
+
+// Reset unregisters every secret.
+func (r *Redactor) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.values, r.patterns = nil, nil
+}
+
+// String replaces the registered values and the matches of the registered patterns in 's' with
+// Redacted.
+func (r *Redactor) String(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, v := range r.values {
+		s = strings.ReplaceAll(s, v, Redacted)
+	}
+
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, Redacted)
+	}
+
+	return s
+}
+
+// Bytes is String for byte slices, e.g. serialized specs and reports.
+func (r *Redactor) Bytes(b []byte) []byte {
+	return []byte(r.String(string(b)))
+}
+
+// Command returns a copy of the command redacted with RedactCommand and the registered secrets.
+func (r *Redactor) Command(cmd []string, secrets ...string) []string {
+	out := RedactCommand(cmd, secrets...)
+	for i, arg := range out {
+		out[i] = r.String(arg)
+	}
+
+	return out
+}
+
+// JSON returns the indented JSON encoding of 'v' with the registered secrets redacted, for
+// previewing builder specs.
+func (r *Redactor) JSON(v any) ([]byte, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode value: %w", err)
+	}
+
+	return r.Bytes(data), nil
+}
+
+// Handler wraps 'h' so the messages and the string, error and stringer attributes of the records
+// it handles are redacted.
+func (r *Redactor) Handler(h slog.Handler) slog.Handler {
+	return &redactingHandler{next: h, redactor: r}
+}
+
+// redactingHandler is the slog.Handler returned by Redactor.Handler.
+type redactingHandler struct {
+	next     slog.Handler
+	redactor *Redactor
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, rec slog.Record) error {
+	out := slog.NewRecord(rec.Time, rec.Level, h.redactor.String(rec.Message), rec.PC)
+	rec.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(h.redactAttr(a))
+		return true
+	})
+
+	return h.next.Handle(ctx, out)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redactAttr(a)
+	}
+
+	return &redactingHandler{next: h.next.WithAttrs(redacted), redactor: h.redactor}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name), redactor: h.redactor}
+}
+
+// redactAttr redacts the value of an attribute, recursing into groups.
+func (h *redactingHandler) redactAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.redactor.String(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		redacted := make([]any, len(group))
+		for i, ga := range group {
+			redacted[i] = h.redactAttr(ga)
+		}
+		return slog.Group(a.Key, redacted...)
+	case slog.KindAny:
+		switch x := v.Any().(type) {
+		case error:
+			return slog.String(a.Key, h.redactor.String(x.Error()))
+		case fmt.Stringer:
+			return slog.String(a.Key, h.redactor.String(x.String()))
+		case []string:
+			return slog.Any(a.Key, h.redactor.Command(x))
+		}
+	}
+
+	return slog.Attr{Key: a.Key, Value: v}
+}
@@ -0,0 +1,104 @@
+package logx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRedactorString(t *testing.T) {
+	r := NewRedactor().AddValues("s3cr3t-token", "s3cr3t", "ab", "")
+	if err := r.AddPattern(`ghp_[A-Za-z0-9]{8,}`); err != nil {
+		t.Fatalf("AddPattern() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "Registered value", in: "token=s3cr3t", want: "token=" + Redacted},
+		{name: "Longest value first", in: "s3cr3t-token", want: Redacted},
+		{name: "Pattern match", in: "auth ghp_abcdefgh1234", want: "auth " + Redacted},
+		{name: "Short values ignored", in: "ab cd", want: "ab cd"},
+		{name: "Nothing to redact", in: "apko build", want: "apko build"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.String(tt.in); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactorAddPattern(t *testing.T) {
+	r := NewRedactor()
+	for _, p := range []string{"(", ".*"} {
+		if err := r.AddPattern(p); err == nil {
+			t.Errorf("AddPattern(%q) error = nil, want an error", p)
+		}
+	}
+}
+
+func TestRedactorCommand(t *testing.T) {
+	r := NewRedactor().AddValues("hunter22")
+
+	got := r.Command([]string{"crane", "auth", "login", "-p", "hunter22", "--token", "x"})
+	want := []string{"crane", "auth", "login", "-p", Redacted, "--token", Redacted}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Command() = %v, want %v", got, want)
+	}
+}
+
+func TestRedactorJSON(t *testing.T) {
+	r := NewRedactor().AddValues(`pa"ss\word`)
+
+	data, err := r.JSON(map[string]string{"password": `pa"ss\word`})
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+
+	if strings.Contains(string(data), `ss\\word`) || !strings.Contains(string(data), Redacted) {
+		t.Errorf("JSON() = %s, want the escaped value redacted", data)
+	}
+}
+
+func TestRedactorHandler(t *testing.T) {
+	r := NewRedactor().AddValues("s3cr3t")
+
+	var buf bytes.Buffer
+	logger := slog.New(r.Handler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	logger.With("header", "Bearer s3cr3t").WithGroup("req").Debug("sending s3cr3t",
+		slog.String("url", "https://example.com?key=s3cr3t"),
+		slog.Any("err", errors.New("denied for s3cr3t")),
+		slog.Any("cmd", []string{"curl", "-H", "s3cr3t"}),
+		slog.Group("auth", slog.String("token", "s3cr3t"), slog.Int("attempt", 1)))
+
+	out := buf.String()
+	if strings.Contains(out, "s3cr3t") {
+		t.Errorf("log output leaks the secret: %s", out)
+	}
+
+	if !strings.Contains(out, "req.auth.attempt=1") {
+		t.Errorf("log output lost attributes: %s", out)
+	}
+}
+
+func TestCommandUsesDefaultRedactor(t *testing.T) {
+	t.Cleanup(Default().Reset)
+	RegisterSecret("registered-value")
+
+	logger, buf := newTestLogger(slog.LevelDebug)
+	Command(context.Background(), logger, "apko", []string{"apko", "publish", "registered-value"})
+
+	if strings.Contains(buf.String(), "registered-value") {
+		t.Errorf("Command() leaks a registered secret: %s", buf.String())
+	}
+}
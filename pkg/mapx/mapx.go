@@ -0,0 +1,42 @@
+// Package mapx renders maps (annotations, labels, build arguments, headers, backend settings)
+// into command flags in sorted key order. Go randomizes map iteration, and commands whose flags
+// change order between runs defeat Dagger layer caching; builders emitting map-driven flags go
+// through this package.
+//
+// Example usage:
+//
+//	flags := mapx.Flags("--annotations", map[string]string{"team": "platform", "env": "prod"}, "=")
+//	// ["--annotations", "env=prod", "--annotations", "team=platform"]
+package mapx
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+)
+
+// SortedKeys returns the keys of 'm' in ascending order.
+func SortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	return slices.Sorted(maps.Keys(m))
+}
+
+// Pairs returns the "key<sep>value" pairs of 'm' in key order, e.g. "env=prod" with sep "=".
+func Pairs(m map[string]string, sep string) []string {
+	pairs := make([]string, 0, len(m))
+	for _, k := range SortedKeys(m) {
+		pairs = append(pairs, k+sep+m[k])
+	}
+
+	return pairs
+}
+
+// Flags returns 'flag' followed by each pair of 'm' (see Pairs), in key order, as separate
+// arguments: ["--label", "a=1", "--label", "b=2"].
+func Flags(flag string, m map[string]string, sep string) []string {
+	flags := make([]string, 0, 2*len(m))
+	for _, p := range Pairs(m, sep) {
+		flags = append(flags, flag, p)
+	}
+
+	return flags
+}
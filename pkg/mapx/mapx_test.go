@@ -0,0 +1,45 @@
+package mapx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortedKeys(t *testing.T) {
+	assert.Equal(t, []string{"a", "b", "c"}, SortedKeys(map[string]int{"c": 3, "a": 1, "b": 2}))
+	assert.Empty(t, SortedKeys(map[string]int(nil)))
+}
+
+func TestPairs(t *testing.T) {
+	tests := []struct {
+		name string
+		m    map[string]string
+		sep  string
+		want []string
+	}{
+		{name: "sorted by key", m: map[string]string{"team": "platform", "env": "prod"}, sep: "=",
+			want: []string{"env=prod", "team=platform"}},
+		{name: "custom separator", m: map[string]string{"Accept": "application/json"}, sep: ": ",
+			want: []string{"Accept: application/json"}},
+		{name: "empty", m: nil, sep: "=", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Pairs(tt.m, tt.sep))
+		})
+	}
+}
+
+func TestFlags(t *testing.T) {
+	m := map[string]string{"b": "2", "a": "1", "c": "3"}
+	want := []string{"--label", "a=1", "--label", "b=2", "--label", "c=3"}
+
+	// Map iteration is randomized; the flags must not be.
+	for range 20 {
+		assert.Equal(t, want, Flags("--label", m, "="))
+	}
+
+	assert.Empty(t, Flags("--label", nil, "="))
+}
@@ -0,0 +1,324 @@
+// Package matrixx expands build matrices: dimensions (e.g. architecture × OS × version × feature
+// flag) are combined into concrete parameter sets, refined by include and exclude rules with the
+// semantics of the GitHub Actions strategy matrix. The parameter sets feed per-target builders,
+// and AddToPlan turns them into the tasks of a parallel plan (see planx).
+//
+// The expansion follows GitHub Actions:
+//   - Every combination of the dimension values is generated, the first dimension varying
+//     slowest.
+//   - A combination matching every key of an exclude rule is removed. Exclude rules only refer
+//     to dimensions.
+//   - An include rule is added to every remaining combination whose dimension values it doesn't
+//     overwrite; the values it adds may be overwritten by later include rules. An include rule
+//     added to no combination becomes a new combination.
+//
+// Example usage:
+//
+//	m := matrixx.NewMatrix().
+//	    WithDimension("os", "linux", "darwin").
+//	    WithDimension("arch", "amd64", "arm64").
+//	    WithFlag("cgo").
+//	    WithExclude(matrixx.Params{"os": "darwin", "cgo": "true"}).
+//	    WithInclude(matrixx.Params{"os": "linux", "arch": "arm64", "runner": "arm"})
+//
+//	err := m.AddToPlan(plan, func(p matrixx.Params) (planx.Task, error) {
+//	    cmd, err := golangx.NewGoBuildBuilder("./cmd/app").
+//	        WithPlatform(p["os"] + "/" + p["arch"]).
+//	        WithCGO(p.Bool("cgo")).
+//	        BuildCommand()
+//	    return planx.Task{Command: cmd}, err
+//	})
+package matrixx
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/planx"
+)
+
+// builderName identifies the matrix in errorsx errors.
+const builderName = "matrix"
+
+// DefaultMaxCombinations is the default maximum number of combinations of a matrix, the limit of
+// GitHub Actions.
+const DefaultMaxCombinations = 256
+
+// Params is a parameter set of a matrix: a value per dimension, and the values added by the
+// include rules.
+type Params map[string]string
+
+// Bool reports whether the parameter 'key' is a true boolean (e.g. a feature flag, see
+// Matrix.WithFlag). Missing and invalid values are false.
+func (p Params) Bool(key string) bool {
+	v, err := strconv.ParseBool(p[key])
+	return err == nil && v
+}
+
+// matches reports whether 'p' holds every value of 'rule'.
+func (p Params) matches(rule Params) bool {
+	for k, v := range rule {
+		if got, ok := p[k]; !ok || got != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Dimension is a dimension of a matrix.
+type Dimension struct {
+	// Name is the name of the dimension (e.g. "arch").
+	Name string
+	// Values are the values of the dimension, in order.
+	Values []string
+}
+
+// Matrix is a build matrix: dimensions combined into parameter sets, refined by include and
+// exclude rules.
+type Matrix struct {
+	// dimensions are the dimensions, in declaration order.
+	dimensions []Dimension
+
+	// includes are the include rules, in declaration order.
+	includes []Params
+
+	// excludes are the exclude rules.
+	excludes []Params
+
+	// maxCombinations is the maximum number of combinations.
+	maxCombinations int
+}
+
+// NewMatrix creates an empty Matrix of at most DefaultMaxCombinations combinations.
+func NewMatrix() *Matrix {
+	return &Matrix{maxCombinations: DefaultMaxCombinations}
+}
+
+// WithDimension adds the dimension 'name' with the values 'values'.
+func (m *Matrix) WithDimension(name string, values ...string) *Matrix {
+	m.dimensions = append(m.dimensions, Dimension{Name: name, Values: values})
+	return m
+}
+
+// WithFlag adds the feature flag 'name': a dimension of the values "false" and "true", read with
+// Params.Bool.
+func (m *Matrix) WithFlag(name string) *Matrix {
+	return m.WithDimension(name, "false", "true")
+}
+
+// WithInclude adds include rules, applied in order after the exclude rules.
+func (m *Matrix) WithInclude(rules ...Params) *Matrix {
+	m.includes = append(m.includes, rules...)
+	return m
+}
+
+// WithExclude adds exclude rules.
+func (m *Matrix) WithExclude(rules ...Params) *Matrix {
+	m.excludes = append(m.excludes, rules...)
+	return m
+}
+
+// WithMaxCombinations sets the maximum number of combinations. It defaults to
+// DefaultMaxCombinations.
+func (m *Matrix) WithMaxCombinations(n int) *Matrix {
+	m.maxCombinations = n
+	return m
+}
+
+// Dimensions returns the dimensions, in declaration order.
+func (m *Matrix) Dimensions() []Dimension {
+	return append([]Dimension(nil), m.dimensions...)
+}
+
+// validate checks the dimensions and rules of the matrix.
+func (m *Matrix) validate() error {
+	if len(m.dimensions) == 0 && len(m.includes) == 0 {
+		return errorsx.MissingField(builderName, "dimensions",
+			"at least one dimension or include rule is required")
+	}
+
+	if m.maxCombinations < 1 {
+		return errorsx.InvalidValue(builderName, "maxCombinations", m.maxCombinations,
+			"the maximum number of combinations must be positive")
+	}
+
+	seen := map[string]bool{}
+	for _, d := range m.dimensions {
+		if d.Name == "" || seen[d.Name] {
+			return errorsx.InvalidValue(builderName, "dimensions", d.Name,
+				"dimension names must be set and unique, got %q", d.Name)
+		}
+		seen[d.Name] = true
+
+		if len(d.Values) == 0 {
+			return errorsx.MissingField(builderName, "dimensions", "dimension %s has no values", d.Name)
+		}
+	}
+
+	for _, rule := range m.excludes {
+		if len(rule) == 0 {
+			return errorsx.InvalidValue(builderName, "excludes", rule, "exclude rules cannot be empty")
+		}
+
+		for k := range rule {
+			if !seen[k] {
+				return errorsx.InvalidValue(builderName, "excludes", k,
+					"exclude rules only refer to dimensions, got %q", k)
+			}
+		}
+	}
+
+	for _, rule := range m.includes {
+		if len(rule) == 0 {
+			return errorsx.InvalidValue(builderName, "includes", rule, "include rules cannot be empty")
+		}
+
+		if _, ok := rule[""]; ok {
+			return errorsx.InvalidValue(builderName, "includes", "", "include rules require keys")
+		}
+	}
+
+	return nil
+}
+
+// Expand expands the matrix into its parameter sets: the combinations of the dimension values,
+// in order, without the excluded ones and refined by the include rules, followed by the
+// combinations the include rules create.
+//
+// Returns:
+//   - The parameter sets.
+//   - An error if no dimension or include rule is set, a dimension has no values or a duplicate
+//     name, a rule is empty, an exclude rule refers to an unknown dimension, or the matrix
+//     exceeds the maximum number of combinations.
+func (m *Matrix) Expand() ([]Params, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+
+	var combinations []Params
+	if len(m.dimensions) > 0 {
+		combinations = []Params{{}}
+	}
+
+	for _, d := range m.dimensions {
+		next := make([]Params, 0, len(combinations)*len(d.Values))
+		for _, partial := range combinations {
+			for _, v := range d.Values {
+				p := make(Params, len(partial)+1)
+				for k, pv := range partial {
+					p[k] = pv
+				}
+				p[d.Name] = v
+				next = append(next, p)
+			}
+		}
+		combinations = next
+	}
+
+	combinations = slices.DeleteFunc(combinations, func(p Params) bool {
+		return slices.ContainsFunc(m.excludes, p.matches)
+	})
+
+	// The include rules only refine the combinations of the dimensions, not the ones they create.
+	base := len(combinations)
+	for _, rule := range m.includes {
+		added := false
+		for _, p := range combinations[:base] {
+			if !m.compatible(p, rule) {
+				continue
+			}
+
+			for k, v := range rule {
+				p[k] = v
+			}
+			added = true
+		}
+
+		if !added {
+			p := make(Params, len(rule))
+			for k, v := range rule {
+				p[k] = v
+			}
+			combinations = append(combinations, p)
+		}
+	}
+
+	if len(combinations) > m.maxCombinations {
+		return nil, errorsx.InvalidValue(builderName, "maxCombinations", len(combinations),
+			"the matrix has %d combinations, above the maximum of %d", len(combinations), m.maxCombinations)
+	}
+
+	return combinations, nil
+}
+
+// compatible reports whether the include rule 'rule' can be added to the combination 'p': it
+// doesn't overwrite any dimension value of 'p'.
+func (m *Matrix) compatible(p, rule Params) bool {
+	for _, d := range m.dimensions {
+		if v, ok := rule[d.Name]; ok && p[d.Name] != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ID identifies the parameter set 'p': its values joined with "-", the dimensions first in
+// declaration order, then the other keys in alphabetical order (e.g. "linux-arm64-false-arm").
+func (m *Matrix) ID(p Params) string {
+	keys := make([]string, 0, len(p))
+	for _, d := range m.dimensions {
+		if _, ok := p[d.Name]; ok {
+			keys = append(keys, d.Name)
+		}
+	}
+
+	var extra []string
+	for k := range p {
+		if !slices.Contains(keys, k) {
+			extra = append(extra, k)
+		}
+	}
+	sort.Strings(extra)
+
+	values := make([]string, 0, len(p))
+	for _, k := range append(keys, extra...) {
+		values = append(values, p[k])
+	}
+
+	return strings.Join(values, "-")
+}
+
+// AddToPlan expands the matrix and adds one task per parameter set to the plan 'plan', built by
+// 'build'. Tasks built without an identifier get the one of their parameter set (see ID).
+//
+// Returns:
+//   - An error if the matrix can't be expanded, or building or adding a task fails.
+func (m *Matrix) AddToPlan(plan *planx.Plan, build func(p Params) (planx.Task, error)) error {
+	combinations, err := m.Expand()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range combinations {
+		task, err := build(p)
+		if err != nil {
+			return fmt.Errorf("failed to build task for %v: %w", p, err)
+		}
+
+		if task.ID == "" {
+			task.ID = m.ID(p)
+		}
+
+		if err := plan.AddTask(task); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
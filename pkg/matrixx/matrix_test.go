@@ -0,0 +1,170 @@
+package matrixx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/planx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatrixExpand(t *testing.T) {
+	tests := []struct {
+		name   string
+		matrix *Matrix
+		want   []Params
+	}{
+		{
+			name:   "cartesian product",
+			matrix: NewMatrix().WithDimension("os", "linux", "darwin").WithDimension("arch", "amd64", "arm64"),
+			want: []Params{
+				{"os": "linux", "arch": "amd64"},
+				{"os": "linux", "arch": "arm64"},
+				{"os": "darwin", "arch": "amd64"},
+				{"os": "darwin", "arch": "arm64"},
+			},
+		},
+		{
+			name: "exclude partial match",
+			matrix: NewMatrix().
+				WithDimension("os", "linux", "darwin").
+				WithFlag("cgo").
+				WithExclude(Params{"os": "darwin", "cgo": "true"}),
+			want: []Params{
+				{"os": "linux", "cgo": "false"},
+				{"os": "linux", "cgo": "true"},
+				{"os": "darwin", "cgo": "false"},
+			},
+		},
+		{
+			name: "include extends and creates",
+			matrix: NewMatrix().
+				WithDimension("os", "linux", "darwin").
+				WithDimension("version", "1.22", "1.23").
+				WithInclude(
+					Params{"runner": "ubuntu"},
+					Params{"os": "darwin", "runner": "macos"},
+					Params{"os": "linux", "version": "1.23", "coverage": "true"},
+					Params{"os": "windows", "version": "1.23"},
+				),
+			want: []Params{
+				{"os": "linux", "version": "1.22", "runner": "ubuntu"},
+				{"os": "linux", "version": "1.23", "runner": "ubuntu", "coverage": "true"},
+				{"os": "darwin", "version": "1.22", "runner": "macos"},
+				{"os": "darwin", "version": "1.23", "runner": "macos"},
+				{"os": "windows", "version": "1.23"},
+			},
+		},
+		{
+			name: "exclude before include",
+			matrix: NewMatrix().
+				WithDimension("arch", "amd64", "arm64").
+				WithExclude(Params{"arch": "arm64"}).
+				WithInclude(Params{"arch": "arm64", "emulated": "true"}),
+			want: []Params{
+				{"arch": "amd64"},
+				{"arch": "arm64", "emulated": "true"},
+			},
+		},
+		{
+			name:   "includes only",
+			matrix: NewMatrix().WithInclude(Params{"target": "docs"}, Params{"target": "site"}),
+			want:   []Params{{"target": "docs"}, {"target": "site"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.matrix.Expand()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMatrixExpandErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		matrix  *Matrix
+		wantErr error
+	}{
+		{name: "empty", matrix: NewMatrix(), wantErr: errorsx.ErrMissingField},
+		{name: "dimension without values", matrix: NewMatrix().WithDimension("os"), wantErr: errorsx.ErrMissingField},
+		{
+			name:    "duplicate dimension",
+			matrix:  NewMatrix().WithDimension("os", "linux").WithDimension("os", "darwin"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "empty exclude",
+			matrix:  NewMatrix().WithDimension("os", "linux").WithExclude(Params{}),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "exclude of unknown dimension",
+			matrix:  NewMatrix().WithDimension("os", "linux").WithExclude(Params{"arch": "arm64"}),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "empty include",
+			matrix:  NewMatrix().WithDimension("os", "linux").WithInclude(Params{}),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name: "too many combinations",
+			matrix: NewMatrix().
+				WithDimension("os", "linux", "darwin").
+				WithDimension("arch", "amd64", "arm64").
+				WithMaxCombinations(3),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.matrix.Expand()
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParamsBool(t *testing.T) {
+	p := Params{"cgo": "true", "race": "false", "lto": "maybe"}
+	assert.True(t, p.Bool("cgo"))
+	assert.False(t, p.Bool("race"))
+	assert.False(t, p.Bool("lto"))
+	assert.False(t, p.Bool("pgo"))
+}
+
+func TestMatrixID(t *testing.T) {
+	m := NewMatrix().WithDimension("os", "linux").WithDimension("arch", "arm64")
+	assert.Equal(t, "linux-arm64", m.ID(Params{"os": "linux", "arch": "arm64"}))
+	assert.Equal(t, "linux-arm64-true-arm", m.ID(Params{"os": "linux", "arch": "arm64", "runner": "arm", "cgo": "true"}))
+	assert.Equal(t, "windows", m.ID(Params{"os": "windows"}))
+}
+
+func TestMatrixAddToPlan(t *testing.T) {
+	m := NewMatrix().
+		WithDimension("os", "linux", "darwin").
+		WithDimension("arch", "amd64").
+		WithInclude(Params{"os": "windows", "arch": "amd64"})
+
+	plan := planx.NewPlan("build")
+	err := m.AddToPlan(plan, func(p Params) (planx.Task, error) {
+		return planx.Task{Command: []string{"go", "build", "-o", "out/" + p["os"] + "/" + p["arch"]}}, nil
+	})
+	require.NoError(t, err)
+
+	var ids []string
+	for _, task := range plan.Tasks() {
+		ids = append(ids, task.ID)
+	}
+	assert.Equal(t, []string{"linux-amd64", "darwin-amd64", "windows-amd64"}, ids)
+
+	err = m.AddToPlan(planx.NewPlan("build"), func(Params) (planx.Task, error) {
+		return planx.Task{}, errors.New("boom")
+	})
+	require.Error(t, err)
+}
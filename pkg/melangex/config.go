@@ -0,0 +1,375 @@
+package melangex
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"gopkg.in/yaml.v3"
+)
+
+// packageNameRegex matches valid APK package names.
+var packageNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9._+-]*$`)
+
+// Config is a melange package configuration.
+type Config struct {
+	Package     Package      `yaml:"package"`
+	Environment Environment  `yaml:"environment,omitempty"`
+	Pipeline    []Step       `yaml:"pipeline,omitempty"`
+	Subpackages []Subpackage `yaml:"subpackages,omitempty"`
+}
+
+// Package describes the package built by a configuration.
+type Package struct {
+	Name               string        `yaml:"name"`
+	Version            string        `yaml:"version"`
+	Epoch              int           `yaml:"epoch"`
+	Description        string        `yaml:"description,omitempty"`
+	Copyright          []Copyright   `yaml:"copyright,omitempty"`
+	Dependencies       *Dependencies `yaml:"dependencies,omitempty"`
+	TargetArchitecture []string      `yaml:"target-architecture,omitempty"`
+}
+
+// Copyright is a license of a package.
+type Copyright struct {
+	License string `yaml:"license"`
+}
+
+// Dependencies holds the runtime dependencies of a package.
+type Dependencies struct {
+	Runtime []string `yaml:"runtime,omitempty"`
+}
+
+// Environment describes the build environment.
+type Environment struct {
+	Contents    Contents          `yaml:"contents,omitempty"`
+	Environment map[string]string `yaml:"environment,omitempty"`
+}
+
+// Contents holds the repositories, keys and packages of the build environment.
+type Contents struct {
+	Keyring      []string `yaml:"keyring,omitempty"`
+	Repositories []string `yaml:"repositories,omitempty"`
+	Packages     []string `yaml:"packages,omitempty"`
+}
+
+// Step is a pipeline step: a built-in or custom pipeline (Uses), a shell script (Runs), or a
+// group of nested steps (Pipeline).
+type Step struct {
+	Name     string            `yaml:"name,omitempty"`
+	If       string            `yaml:"if,omitempty"`
+	Uses     string            `yaml:"uses,omitempty"`
+	With     map[string]string `yaml:"with,omitempty"`
+	Runs     string            `yaml:"runs,omitempty"`
+	Pipeline []Step            `yaml:"pipeline,omitempty"`
+}
+
+// Subpackage is a package split from the main package build.
+type Subpackage struct {
+	Name         string        `yaml:"name"`
+	Description  string        `yaml:"description,omitempty"`
+	Dependencies *Dependencies `yaml:"dependencies,omitempty"`
+	Pipeline     []Step        `yaml:"pipeline,omitempty"`
+}
+
+// ParseConfig parses a melange package configuration.
+func ParseConfig(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse melange config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// Validate checks that the configuration names a package, a version and a build pipeline.
+func (c *Config) Validate() error {
+	if c.Package.Name == "" {
+		return errorsx.MissingField(builderName, "package.name", "package name is required")
+	}
+
+	if !packageNameRegex.MatchString(c.Package.Name) {
+		return errorsx.InvalidValue(builderName, "package.name", c.Package.Name, "invalid package name: %s", c.Package.Name)
+	}
+
+	if c.Package.Version == "" {
+		return errorsx.MissingField(builderName, "package.version", "package %s requires a version", c.Package.Name)
+	}
+
+	if c.Package.Epoch < 0 {
+		return errorsx.InvalidValue(builderName, "package.epoch", c.Package.Epoch, "package epoch must not be negative")
+	}
+
+	if len(c.Pipeline) == 0 {
+		return errorsx.MissingField(builderName, "pipeline", "package %s requires a pipeline", c.Package.Name)
+	}
+
+	if err := validateSteps("pipeline", c.Pipeline); err != nil {
+		return err
+	}
+
+	for i, sub := range c.Subpackages {
+		if sub.Name == "" {
+			return errorsx.MissingField(builderName, fmt.Sprintf("subpackages[%d].name", i), "subpackage name is required")
+		}
+		if err := validateSteps(fmt.Sprintf("subpackages[%d].pipeline", i), sub.Pipeline); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateSteps checks that every step does exactly one thing.
+func validateSteps(prefix string, steps []Step) error {
+	for i, s := range steps {
+		field := fmt.Sprintf("%s[%d]", prefix, i)
+
+		set := 0
+		for _, ok := range []bool{s.Uses != "", s.Runs != "", len(s.Pipeline) > 0} {
+			if ok {
+				set++
+			}
+		}
+
+		if set != 1 {
+			return errorsx.InvalidValue(builderName, field, s.Name, "step %s must set exactly one of uses, runs or pipeline", field)
+		}
+
+		if err := validateSteps(field+".pipeline", s.Pipeline); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// YAML returns the YAML encoding of the configuration, indented by two spaces.
+func (c *Config) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(c); err != nil {
+		return nil, fmt.Errorf("failed to encode melange config: %w", err)
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode melange config: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ConfigBuilder builds a melange package configuration.
+type ConfigBuilder struct {
+	cfg Config
+}
+
+// NewConfigBuilder creates a ConfigBuilder for the package 'name' at 'version'.
+func NewConfigBuilder(name, version string) *ConfigBuilder {
+	return &ConfigBuilder{cfg: Config{Package: Package{Name: name, Version: version}}}
+}
+
+// WithEpoch sets the package epoch.
+func (b *ConfigBuilder) WithEpoch(epoch int) *ConfigBuilder {
+	b.cfg.Package.Epoch = epoch
+	return b
+}
+
+// WithDescription sets the package description.
+func (b *ConfigBuilder) WithDescription(description string) *ConfigBuilder {
+	b.cfg.Package.Description = description
+	return b
+}
+
+// WithLicense adds a license to the package.
+func (b *ConfigBuilder) WithLicense(license string) *ConfigBuilder {
+	b.cfg.Package.Copyright = append(b.cfg.Package.Copyright, Copyright{License: license})
+	return b
+}
+
+// WithRuntimeDependency adds runtime dependencies to the package.
+func (b *ConfigBuilder) WithRuntimeDependency(packages ...string) *ConfigBuilder {
+	if b.cfg.Package.Dependencies == nil {
+		b.cfg.Package.Dependencies = &Dependencies{}
+	}
+	b.cfg.Package.Dependencies.Runtime = append(b.cfg.Package.Dependencies.Runtime, packages...)
+	return b
+}
+
+// WithTargetArchitecture restricts the architectures the package builds for.
+func (b *ConfigBuilder) WithTargetArchitecture(archs ...string) *ConfigBuilder {
+	b.cfg.Package.TargetArchitecture = append(b.cfg.Package.TargetArchitecture, archs...)
+	return b
+}
+
+// WithKeyring adds a public key verifying the build environment packages.
+func (b *ConfigBuilder) WithKeyring(keys ...string) *ConfigBuilder {
+	b.cfg.Environment.Contents.Keyring = append(b.cfg.Environment.Contents.Keyring, keys...)
+	return b
+}
+
+// WithRepository adds repositories to the build environment.
+func (b *ConfigBuilder) WithRepository(repos ...string) *ConfigBuilder {
+	b.cfg.Environment.Contents.Repositories = append(b.cfg.Environment.Contents.Repositories, repos...)
+	return b
+}
+
+// WithBuildPackage adds packages to the build environment.
+func (b *ConfigBuilder) WithBuildPackage(packages ...string) *ConfigBuilder {
+	b.cfg.Environment.Contents.Packages = append(b.cfg.Environment.Contents.Packages, packages...)
+	return b
+}
+
+// WithEnv sets an environment variable of the build environment.
+func (b *ConfigBuilder) WithEnv(name, value string) *ConfigBuilder {
+	if b.cfg.Environment.Environment == nil {
+		b.cfg.Environment.Environment = map[string]string{}
+	}
+	b.cfg.Environment.Environment[name] = value
+	return b
+}
+
+// WithStep adds a step to the build pipeline.
+func (b *ConfigBuilder) WithStep(step Step) *ConfigBuilder {
+	b.cfg.Pipeline = append(b.cfg.Pipeline, step)
+	return b
+}
+
+// WithUses adds a step running the pipeline 'uses' with the inputs 'with'.
+func (b *ConfigBuilder) WithUses(uses string, with map[string]string) *ConfigBuilder {
+	return b.WithStep(Step{Uses: uses, With: with})
+}
+
+// WithRuns adds a step running a shell script.
+func (b *ConfigBuilder) WithRuns(name, script string) *ConfigBuilder {
+	return b.WithStep(Step{Name: name, Runs: script})
+}
+
+// WithSubpackage adds a subpackage.
+func (b *ConfigBuilder) WithSubpackage(sub Subpackage) *ConfigBuilder {
+	b.cfg.Subpackages = append(b.cfg.Subpackages, sub)
+	return b
+}
+
+// Build returns the validated configuration.
+//
+// Returns:
+//   - The configuration.
+//   - An error if the configuration is incomplete or inconsistent.
+func (b *ConfigBuilder) Build() (*Config, error) {
+	cfg := b.cfg
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// GoBinaryOpts describes a Go binary packaged from a git repository.
+type GoBinaryOpts struct {
+	// Name is the package name.
+	Name string
+	// Version is the package version; the repository is checked out at tag "v<Version>".
+	Version string
+	// Repository is the git repository URL.
+	Repository string
+	// ExpectedCommit is the commit the tag must point at, for reproducibility. It is optional.
+	ExpectedCommit string
+	// Packages is the Go package to build. It defaults to ".".
+	Packages string
+	// Output is the name of the installed binary. It defaults to Name.
+	Output string
+	// Ldflags are the Go linker flags.
+	Ldflags string
+	// License is the SPDX license of the package.
+	License string
+}
+
+// NewGoBinaryConfig returns the configuration of a statically linked Go binary built with the
+// git-checkout and go/build pipelines.
+//
+// Returns:
+//   - The configuration.
+//   - An error if the options are incomplete.
+func NewGoBinaryConfig(opts GoBinaryOpts) (*Config, error) {
+	if opts.Repository == "" {
+		return nil, errorsx.MissingField(builderName, "repository", "go binary %s requires a repository", opts.Name)
+	}
+
+	checkout := map[string]string{"repository": opts.Repository, "tag": "v" + opts.Version}
+	if opts.ExpectedCommit != "" {
+		checkout["expected-commit"] = opts.ExpectedCommit
+	}
+
+	build := map[string]string{"packages": opts.Packages, "output": opts.Output}
+	if build["packages"] == "" {
+		build["packages"] = "."
+	}
+	if build["output"] == "" {
+		build["output"] = opts.Name
+	}
+	if opts.Ldflags != "" {
+		build["ldflags"] = opts.Ldflags
+	}
+
+	b := NewConfigBuilder(opts.Name, opts.Version).
+		WithBuildPackage("busybox", "ca-certificates-bundle", "go").
+		WithEnv("CGO_ENABLED", "0").
+		WithUses("git-checkout", checkout).
+		WithUses("go/build", build).
+		WithUses("strip", nil)
+
+	if opts.License != "" {
+		b.WithLicense(opts.License)
+	}
+
+	return b.Build()
+}
+
+// StaticBinaryOpts describes a prebuilt static binary packaged from a download.
+type StaticBinaryOpts struct {
+	// Name is the package name.
+	Name string
+	// Version is the package version.
+	Version string
+	// URI is the download URL of the binary.
+	URI string
+	// SHA256 is the expected checksum of the download.
+	SHA256 string
+	// Binary is the name of the installed binary. It defaults to Name.
+	Binary string
+	// License is the SPDX license of the package.
+	License string
+}
+
+// NewStaticBinaryConfig returns the configuration of a prebuilt static binary fetched from a URL
+// and installed in /usr/bin.
+//
+// Returns:
+//   - The configuration.
+//   - An error if the options are incomplete.
+func NewStaticBinaryConfig(opts StaticBinaryOpts) (*Config, error) {
+	if opts.URI == "" || opts.SHA256 == "" {
+		return nil, errorsx.MissingField(builderName, "uri", "static binary %s requires a URI and its SHA256 checksum", opts.Name)
+	}
+
+	binary := opts.Binary
+	if binary == "" {
+		binary = opts.Name
+	}
+
+	b := NewConfigBuilder(opts.Name, opts.Version).
+		WithBuildPackage("busybox").
+		WithUses("fetch", map[string]string{"uri": opts.URI, "expected-sha256": opts.SHA256, "extract": "false"}).
+		WithRuns("install", fmt.Sprintf(`install -Dm755 %s "${{targets.destdir}}/usr/bin/%s"`, path.Base(opts.URI), binary))
+
+	if opts.License != "" {
+		b.WithLicense(opts.License)
+	}
+
+	return b.Build()
+}
@@ -0,0 +1,147 @@
+package melangex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigBuilder(t *testing.T) {
+	cfg, err := NewConfigBuilder("hello", "1.2.3").
+		WithEpoch(1).
+		WithDescription("Says hello").
+		WithLicense("Apache-2.0").
+		WithRuntimeDependency("ca-certificates-bundle").
+		WithTargetArchitecture("x86_64").
+		WithKeyring("https://packages.wolfi.dev/os/wolfi-signing.rsa.pub").
+		WithRepository("https://packages.wolfi.dev/os").
+		WithBuildPackage("busybox", "go").
+		WithEnv("CGO_ENABLED", "0").
+		WithUses("go/build", map[string]string{"packages": ".", "output": "hello"}).
+		WithRuns("check", "hello --version").
+		WithSubpackage(Subpackage{Name: "hello-doc", Pipeline: []Step{{Uses: "split/manpages"}}}).
+		Build()
+	require.NoError(t, err)
+
+	data, err := cfg.YAML()
+	require.NoError(t, err)
+
+	assert.Equal(t, `package:
+  name: hello
+  version: 1.2.3
+  epoch: 1
+  description: Says hello
+  copyright:
+    - license: Apache-2.0
+  dependencies:
+    runtime:
+      - ca-certificates-bundle
+  target-architecture:
+    - x86_64
+environment:
+  contents:
+    keyring:
+      - https://packages.wolfi.dev/os/wolfi-signing.rsa.pub
+    repositories:
+      - https://packages.wolfi.dev/os
+    packages:
+      - busybox
+      - go
+  environment:
+    CGO_ENABLED: "0"
+pipeline:
+  - uses: go/build
+    with:
+      output: hello
+      packages: .
+  - name: check
+    runs: hello --version
+subpackages:
+  - name: hello-doc
+    pipeline:
+      - uses: split/manpages
+`, string(data))
+
+	parsed, err := ParseConfig(data)
+	require.NoError(t, err)
+	assert.Equal(t, cfg, parsed)
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *ConfigBuilder
+		kind    error
+	}{
+		{"Missing name", NewConfigBuilder("", "1.0.0").WithRuns("build", "make"), errorsx.ErrMissingField},
+		{"Invalid name", NewConfigBuilder("Hello World", "1.0.0").WithRuns("build", "make"), errorsx.ErrInvalidValue},
+		{"Missing version", NewConfigBuilder("hello", "").WithRuns("build", "make"), errorsx.ErrMissingField},
+		{"Missing pipeline", NewConfigBuilder("hello", "1.0.0"), errorsx.ErrMissingField},
+		{"Ambiguous step", NewConfigBuilder("hello", "1.0.0").WithStep(Step{Uses: "fetch", Runs: "make"}), errorsx.ErrInvalidValue},
+		{"Empty nested step", NewConfigBuilder("hello", "1.0.0").WithStep(Step{Pipeline: []Step{{Name: "noop"}}}), errorsx.ErrInvalidValue},
+		{"Unnamed subpackage", NewConfigBuilder("hello", "1.0.0").WithRuns("build", "make").WithSubpackage(Subpackage{}), errorsx.ErrMissingField},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Build()
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+		})
+	}
+}
+
+func TestNewGoBinaryConfig(t *testing.T) {
+	cfg, err := NewGoBinaryConfig(GoBinaryOpts{
+		Name:           "crane",
+		Version:        "0.20.0",
+		Repository:     "https://github.com/google/go-containerregistry",
+		ExpectedCommit: "abc123",
+		Packages:       "./cmd/crane",
+		License:        "Apache-2.0",
+	})
+	require.NoError(t, err)
+
+	require.Len(t, cfg.Pipeline, 3)
+	assert.Equal(t, map[string]string{
+		"repository":      "https://github.com/google/go-containerregistry",
+		"tag":             "v0.20.0",
+		"expected-commit": "abc123",
+	}, cfg.Pipeline[0].With)
+	assert.Equal(t, map[string]string{"packages": "./cmd/crane", "output": "crane"}, cfg.Pipeline[1].With)
+	assert.Equal(t, "strip", cfg.Pipeline[2].Uses)
+
+	refs, err := PipelineReferences(mustYAML(t, cfg))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"git-checkout", "go/build", "strip"}, refs)
+
+	_, err = NewGoBinaryConfig(GoBinaryOpts{Name: "crane", Version: "0.20.0"})
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+}
+
+func TestNewStaticBinaryConfig(t *testing.T) {
+	cfg, err := NewStaticBinaryConfig(StaticBinaryOpts{
+		Name:    "jq",
+		Version: "1.7.1",
+		URI:     "https://example.com/releases/jq-linux-amd64",
+		SHA256:  "deadbeef",
+	})
+	require.NoError(t, err)
+
+	require.Len(t, cfg.Pipeline, 2)
+	assert.Equal(t, `install -Dm755 jq-linux-amd64 "${{targets.destdir}}/usr/bin/jq"`, cfg.Pipeline[1].Runs)
+
+	_, err = NewStaticBinaryConfig(StaticBinaryOpts{Name: "jq", Version: "1.7.1"})
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+}
+
+func mustYAML(t *testing.T, cfg *Config) []byte {
+	t.Helper()
+
+	data, err := cfg.YAML()
+	require.NoError(t, err)
+
+	return data
+}
@@ -0,0 +1,111 @@
+package melangex
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// IndexFileName is the name of the APK index of a repository architecture.
+const IndexFileName = "APKINDEX.tar.gz"
+
+// Repository is a local APK repository as laid out by melange: one directory per architecture
+// holding the packages and their signed index (<root>/<arch>/APKINDEX.tar.gz).
+type Repository struct {
+	// Root is the root directory of the repository.
+	Root string
+}
+
+// NewRepository returns the repository rooted at 'root'. An empty root defaults to GetOutputDir(""),
+// where MelangeBuilder writes packages by default.
+func NewRepository(root string) *Repository {
+	if root == "" {
+		root = GetOutputDir("")
+	}
+
+	return &Repository{Root: root}
+}
+
+// ArchDir returns the directory of the packages of an architecture.
+func (r *Repository) ArchDir(arch string) string {
+	return filepath.Join(r.Root, arch)
+}
+
+// IndexPath returns the path of the index of an architecture.
+func (r *Repository) IndexPath(arch string) string {
+	return filepath.Join(r.ArchDir(arch), IndexFileName)
+}
+
+// PackagePath returns the path of a package file: <root>/<arch>/<name>-<version>-r<epoch>.apk.
+func (r *Repository) PackagePath(arch, name, version string, epoch int) string {
+	return filepath.Join(r.ArchDir(arch), fmt.Sprintf("%s-%s-r%d.apk", name, version, epoch))
+}
+
+// IndexCommand generates the `melange index` command indexing the packages of an architecture,
+// signed with 'signingKey' when set. Without explicit packages, every package of the architecture
+// directory is indexed; the command then runs through `sh -c` to expand the glob.
+//
+// Returns:
+//   - The index command.
+//   - An error if the architecture is empty.
+func (r *Repository) IndexCommand(arch, signingKey string, packages ...string) ([]string, error) {
+	if arch == "" {
+		return nil, errorsx.MissingField(builderName, "arch", "repository index requires an architecture")
+	}
+
+	cmd := []string{"melange", "index", "--output", r.IndexPath(arch), "--arch", arch}
+	if signingKey != "" {
+		cmd = append(cmd, "--signing-key", signingKey)
+	}
+
+	if len(packages) > 0 {
+		return append(cmd, packages...), nil
+	}
+
+	script := cmdx.ShellJoin(cmd) + " " + cmdx.ShellQuote(r.ArchDir(arch)) + "/*.apk"
+
+	return []string{"sh", "-c", script}, nil
+}
+
+// SignIndexCommand generates the `melange sign-index` command signing an existing index.
+//
+// Returns:
+//   - The signing command.
+//   - An error if the architecture or the signing key is empty.
+func (r *Repository) SignIndexCommand(arch, signingKey string) ([]string, error) {
+	if arch == "" {
+		return nil, errorsx.MissingField(builderName, "arch", "repository index requires an architecture")
+	}
+
+	if signingKey == "" {
+		return nil, errorsx.MissingField(builderName, "signingKey", "signing the index requires a signing key")
+	}
+
+	return []string{"melange", "sign-index", "--signing-key", signingKey, r.IndexPath(arch)}, nil
+}
+
+// KeygenCommand generates the `melange keygen` command creating the signing key pair at
+// 'keyPath'; the public key is written to PublicKeyPath(keyPath).
+func KeygenCommand(keyPath string) []string {
+	return []string{"melange", "keygen", keyPath}
+}
+
+// PublicKeyPath returns the path of the public key of a melange signing key.
+func PublicKeyPath(signingKey string) string {
+	return signingKey + ".pub"
+}
+
+// ConfigureApko makes the packages of the repository available to an apko build: the repository
+// is appended to the build repositories and 'publicKey' to its keyring.
+// It returns the updated ApkoBuilder instance.
+func (r *Repository) ConfigureApko(b *apkox.ApkoBuilder, publicKey string) *apkox.ApkoBuilder {
+	b.WithRepositoryAppend(r.Root)
+	if publicKey != "" {
+		b.WithKeyring(publicKey)
+	}
+
+	return b
+}
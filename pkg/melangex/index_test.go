@@ -0,0 +1,82 @@
+package melangex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryLayout(t *testing.T) {
+	repo := NewRepository("")
+	assert.Equal(t, "/mnt/packages", repo.Root)
+	assert.Equal(t, "/mnt/packages/x86_64", repo.ArchDir("x86_64"))
+	assert.Equal(t, "/mnt/packages/x86_64/APKINDEX.tar.gz", repo.IndexPath("x86_64"))
+	assert.Equal(t, "/mnt/packages/aarch64/hello-1.2.3-r1.apk", repo.PackagePath("aarch64", "hello", "1.2.3", 1))
+}
+
+func TestRepositoryIndexCommand(t *testing.T) {
+	repo := NewRepository("/work/packages")
+
+	cmd, err := repo.IndexCommand("x86_64", "melange.rsa", "/work/packages/x86_64/hello-1.0.0-r0.apk")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"melange", "index",
+		"--output", "/work/packages/x86_64/APKINDEX.tar.gz",
+		"--arch", "x86_64",
+		"--signing-key", "melange.rsa",
+		"/work/packages/x86_64/hello-1.0.0-r0.apk",
+	}, cmd)
+
+	cmd, err = NewRepository("/work/my packages").IndexCommand("x86_64", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"sh", "-c",
+		"melange index --output '/work/my packages/x86_64/APKINDEX.tar.gz' --arch x86_64 '/work/my packages/x86_64'/*.apk",
+	}, cmd)
+
+	_, err = repo.IndexCommand("", "")
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+}
+
+func TestRepositorySignIndexCommand(t *testing.T) {
+	repo := NewRepository("/work/packages")
+
+	cmd, err := repo.SignIndexCommand("aarch64", "melange.rsa")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"melange", "sign-index", "--signing-key", "melange.rsa", "/work/packages/aarch64/APKINDEX.tar.gz",
+	}, cmd)
+
+	_, err = repo.SignIndexCommand("aarch64", "")
+	field, ok := errorsx.FieldOf(err)
+	assert.True(t, ok)
+	assert.Equal(t, "signingKey", field)
+}
+
+func TestKeygenCommand(t *testing.T) {
+	assert.Equal(t, []string{"melange", "keygen", "melange.rsa"}, KeygenCommand("melange.rsa"))
+	assert.Equal(t, "melange.rsa.pub", PublicKeyPath("melange.rsa"))
+}
+
+func TestRepositoryConfigureApko(t *testing.T) {
+	repo := NewRepository("/mnt/packages")
+	b := repo.ConfigureApko(apkox.NewApkoBuilder(), "/mnt/melange.rsa.pub").
+		WithConfigFile("apko.yaml").
+		WithOutputImage("hello").
+		WithTag("dev").
+		WithOutputTarball("hello.tar")
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"apko", "build",
+		"--keyring-append", "/mnt/melange.rsa.pub",
+		"--repository-append", "/mnt/packages",
+		"--sbom=false", "--vcs=false",
+		"apko.yaml", "hello:dev", "hello.tar",
+	}, cmd)
+}
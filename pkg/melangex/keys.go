@@ -0,0 +1,197 @@
+package melangex
+
+import (
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// DefaultKeyName is the file name of the signing key managed by a KeyManager.
+const DefaultKeyName = "melange.rsa"
+
+// RetiredKeyDir is the directory, relative to the key directory, holding the rotated keys.
+const RetiredKeyDir = "retired"
+
+// GetKeyDir returns the conventional signing key directory. If mntPrefix is empty,
+// fixtures.MntPrefix is used.
+func GetKeyDir(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, "keys")
+}
+
+// KeyManager manages the key signing the packages built by melange and the index of their
+// repository, and the public keys apko and melange trust to install them. Rotated keys are kept
+// under RetiredKeyDir, and their public keys stay trusted, so packages signed before a rotation
+// still install.
+//
+// Example usage:
+//
+//	keys := melangex.NewKeyManager("").WithHostDir("./keys")
+//
+//	res, err := executor.Run(ctx, keys.EnsureCommand(), nil, keys.Mounts())
+//
+//	builder := keys.ConfigureMelange(melangex.NewMelangeBuilder().WithConfigFile("package.yaml"))
+//	apko := keys.ConfigureApko(apkox.NewApkoBuilder().WithConfigFile("apko.yaml"))
+type KeyManager struct {
+	// dir is the directory of the keys inside the container.
+	dir string
+
+	// name is the file name of the current signing key.
+	name string
+
+	// hostDir is the host directory persisting the keys, mounted at dir. It may be empty.
+	hostDir string
+
+	// retired are the identifiers of the rotated keys, oldest first.
+	retired []string
+}
+
+// NewKeyManager creates a KeyManager of the keys in 'dir'. An empty dir defaults to
+// GetKeyDir("").
+func NewKeyManager(dir string) *KeyManager {
+	if dir == "" {
+		dir = GetKeyDir("")
+	}
+
+	return &KeyManager{dir: dir, name: DefaultKeyName}
+}
+
+// WithName sets the file name of the signing key. It defaults to DefaultKeyName.
+func (m *KeyManager) WithName(name string) *KeyManager {
+	m.name = name
+	return m
+}
+
+// WithHostDir persists the keys in the host directory 'dir', mounted at the key directory by
+// Mounts, so they survive the build container.
+func (m *KeyManager) WithHostDir(dir string) *KeyManager {
+	m.hostDir = dir
+	return m
+}
+
+// WithRetiredKeys records keys rotated in earlier runs under 'ids', whose public keys stay trusted.
+func (m *KeyManager) WithRetiredKeys(ids ...string) *KeyManager {
+	m.retired = append(m.retired, ids...)
+	return m
+}
+
+// Dir returns the directory of the keys inside the container.
+func (m *KeyManager) Dir() string {
+	return m.dir
+}
+
+// SigningKey returns the path of the current signing key.
+func (m *KeyManager) SigningKey() string {
+	return filepath.Join(m.dir, m.name)
+}
+
+// PublicKey returns the path of the public key of the current signing key.
+func (m *KeyManager) PublicKey() string {
+	return PublicKeyPath(m.SigningKey())
+}
+
+// retiredKey returns the path of the signing key rotated under 'id'.
+func (m *KeyManager) retiredKey(id string) string {
+	return filepath.Join(m.dir, RetiredKeyDir, id+filepath.Ext(m.name))
+}
+
+// PublicKeys returns the public keys to trust: the current one first, then the rotated ones,
+// newest first.
+func (m *KeyManager) PublicKeys() []string {
+	keys := []string{m.PublicKey()}
+	for _, id := range slices.Backward(m.retired) {
+		keys = append(keys, PublicKeyPath(m.retiredKey(id)))
+	}
+
+	return keys
+}
+
+// Mounts returns the mount of the host key directory, if set. It is writable, so the keys the
+// commands generate are persisted.
+func (m *KeyManager) Mounts() []types.Mount {
+	if m.hostDir == "" {
+		return nil
+	}
+
+	return []types.Mount{{Kind: types.MountKindDirectory, Source: m.hostDir, Target: m.dir}}
+}
+
+// EnsureCommand generates the command creating the signing key pair, unless it exists.
+func (m *KeyManager) EnsureCommand() []string {
+	key := cmdx.ShellQuote(m.SigningKey())
+	script := "mkdir -p " + cmdx.ShellQuote(m.dir) + " && { [ -f " + key + " ] || " +
+		strings.Join(KeygenCommand(key), " ") + "; }"
+
+	return []string{"sh", "-c", script}
+}
+
+// RotateCommand generates the command moving the current key pair under RetiredKeyDir as 'id'
+// (e.g. a date), and creating a new one. The key is recorded as retired, so the keyrings
+// configured afterwards keep trusting its public key.
+//
+// Returns:
+//   - The rotation command.
+//   - An error if 'id' is empty, not a plain file name, or already retired.
+func (m *KeyManager) RotateCommand(id string) ([]string, error) {
+	if id == "" {
+		return nil, errorsx.MissingField(builderName, "keyID", "key rotation requires an identifier")
+	}
+
+	if strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return nil, errorsx.InvalidValue(builderName, "keyID", id, "key identifier must be a file name, got %q", id)
+	}
+
+	if slices.Contains(m.retired, id) {
+		return nil, errorsx.InvalidValue(builderName, "keyID", id, "key %q is already retired", id)
+	}
+
+	key, retired := m.SigningKey(), m.retiredKey(id)
+	script := strings.Join([]string{
+		"mkdir -p " + cmdx.ShellQuote(filepath.Dir(retired)),
+		"mv " + cmdx.ShellQuote(key) + " " + cmdx.ShellQuote(retired),
+		"mv " + cmdx.ShellQuote(PublicKeyPath(key)) + " " + cmdx.ShellQuote(PublicKeyPath(retired)),
+		strings.Join(KeygenCommand(cmdx.ShellQuote(key)), " "),
+	}, " && ")
+
+	m.retired = append(m.retired, id)
+
+	return []string{"sh", "-c", script}, nil
+}
+
+// ConfigureMelange makes 'b' sign the packages with the current key, and trust the public keys
+// of the manager in the build environment, so packages built earlier install in it.
+// It returns the updated MelangeBuilder instance.
+func (m *KeyManager) ConfigureMelange(b *MelangeBuilder) *MelangeBuilder {
+	b.WithSigningKey(m.SigningKey())
+	for _, k := range m.PublicKeys() {
+		b.WithKeyring(k)
+	}
+
+	return b
+}
+
+// ConfigureApko makes 'b' trust the public keys of the manager.
+// It returns the updated ApkoBuilder instance.
+func (m *KeyManager) ConfigureApko(b *apkox.ApkoBuilder) *apkox.ApkoBuilder {
+	for _, k := range m.PublicKeys() {
+		b.WithKeyring(k)
+	}
+
+	return b
+}
+
+// SignIndexCommand generates the command signing the index of 'repo' for 'arch' with the
+// current key. See Repository.SignIndexCommand.
+func (m *KeyManager) SignIndexCommand(repo *Repository, arch string) ([]string, error) {
+	return repo.SignIndexCommand(arch, m.SigningKey())
+}
@@ -0,0 +1,83 @@
+package melangex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyManagerPaths(t *testing.T) {
+	keys := NewKeyManager("")
+	assert.Equal(t, "/mnt/keys", keys.Dir())
+	assert.Equal(t, "/mnt/keys/melange.rsa", keys.SigningKey())
+	assert.Equal(t, "/mnt/keys/melange.rsa.pub", keys.PublicKey())
+	assert.Nil(t, keys.Mounts())
+
+	keys = NewKeyManager("/keys").WithName("acme.rsa").WithHostDir("./keys").WithRetiredKeys("2024", "2025")
+	assert.Equal(t, []string{
+		"/keys/acme.rsa.pub",
+		"/keys/retired/2025.rsa.pub",
+		"/keys/retired/2024.rsa.pub",
+	}, keys.PublicKeys())
+	assert.Equal(t, []types.Mount{{Kind: types.MountKindDirectory, Source: "./keys", Target: "/keys"}}, keys.Mounts())
+}
+
+func TestKeyManagerEnsureCommand(t *testing.T) {
+	assert.Equal(t, []string{
+		"sh", "-c",
+		"mkdir -p '/my keys' && { [ -f '/my keys/melange.rsa' ] || melange keygen '/my keys/melange.rsa'; }",
+	}, NewKeyManager("/my keys").EnsureCommand())
+}
+
+func TestKeyManagerRotateCommand(t *testing.T) {
+	keys := NewKeyManager("/keys")
+
+	cmd, err := keys.RotateCommand("2025-01")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"sh", "-c",
+		"mkdir -p /keys/retired && mv /keys/melange.rsa /keys/retired/2025-01.rsa && " +
+			"mv /keys/melange.rsa.pub /keys/retired/2025-01.rsa.pub && melange keygen /keys/melange.rsa",
+	}, cmd)
+	assert.Equal(t, []string{"/keys/melange.rsa.pub", "/keys/retired/2025-01.rsa.pub"}, keys.PublicKeys())
+
+	for _, id := range []string{"", "../x", "2025-01"} {
+		_, err := keys.RotateCommand(id)
+		assert.Error(t, err, id)
+	}
+
+	_, err = keys.RotateCommand("")
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+	_, err = keys.RotateCommand("2025-01")
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+}
+
+func TestKeyManagerConfigure(t *testing.T) {
+	keys := NewKeyManager("/keys").WithRetiredKeys("old")
+
+	cmd, err := keys.ConfigureMelange(NewMelangeBuilder().WithConfigFile("package.yaml")).BuildCommand()
+	require.NoError(t, err)
+	assert.Subset(t, cmd, []string{
+		"--signing-key", "/keys/melange.rsa",
+		"--keyring-append", "/keys/melange.rsa.pub", "/keys/retired/old.rsa.pub",
+	})
+
+	apko, err := keys.ConfigureApko(apkox.NewApkoBuilder().
+		WithConfigFile("apko.yaml").
+		WithOutputImage("img").
+		WithTag("v1").
+		WithOutputTarball("out.tar")).BuildCommand()
+	require.NoError(t, err)
+	assert.Subset(t, apko, []string{"--keyring-append", "/keys/melange.rsa.pub", "/keys/retired/old.rsa.pub"})
+
+	sign, err := keys.SignIndexCommand(NewRepository("/repo"), "x86_64")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"melange", "sign-index", "--signing-key", "/keys/melange.rsa", "/repo/x86_64/APKINDEX.tar.gz",
+	}, sign)
+}
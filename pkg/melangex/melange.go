@@ -0,0 +1,414 @@
+// Package melangex provides a builder for melange, the tool building APK packages from
+// declarative YAML configurations, typically consumed by apko in the same pipeline.
+//
+// The MelangeBuilder generates the `melange build` command, and reports the mounts it requires
+// (source directory and custom pipeline directories) so executors provide them consistently.
+//
+// By convention, the sources are mounted at GetSourceDir(mntPrefix), custom pipelines at
+// GetPipelineDir(mntPrefix), and packages are written to GetOutputDir(mntPrefix). The KeyManager
+// keeps the signing keys in GetKeyDir(mntPrefix).
+//
+// Example usage:
+//
+//	builder := melangex.NewMelangeBuilder().
+//	    WithConfigFile("package.yaml").
+//	    WithArch("x86_64").
+//	    WithSigningKey("/mnt/melange.rsa").
+//	    WithSourceDir("./src", "").
+//	    WithPipelineDir("./pipelines", "")
+//
+//	cmd, err := builder.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	res, err := executor.Run(ctx, cmd, nil, builder.Mounts())
+package melangex
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/signingx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the melange builder in errorsx errors.
+const builderName = "melange"
+
+// MelangeDefaultImage is the default container image providing melange.
+const MelangeDefaultImage = "cgr.dev/chainguard/melange"
+
+// directoryMount is a host directory mounted into the build container.
+type directoryMount struct {
+	// source is the host path of the directory.
+	source string
+	// target is the path of the directory inside the container.
+	target string
+}
+
+// MelangeBuilder generates the melange build command of a package.
+type MelangeBuilder struct {
+	// configFile is the path to the melange package configuration.
+	configFile string
+
+	// archs are the architectures to build for.
+	archs []string
+
+	// outDir is the directory where packages are written.
+	outDir string
+
+	// signingKey is the path of the private key signing the packages.
+	signingKey string
+
+	// signingKeyProvider references the private key signing the packages, instead of signingKey.
+	// It may be nil.
+	signingKeyProvider *signingx.KeyProvider
+
+	// keyringPaths are the public keys verifying the build environment packages.
+	keyringPaths []string
+
+	// repositoryAppend are the repositories of the build environment.
+	repositoryAppend []string
+
+	// sourceDir is the directory holding the sources of the package.
+	sourceDir *directoryMount
+
+	// pipelineDirs are the directories holding custom pipelines.
+	pipelineDirs []directoryMount
+
+	// cacheDir is the directory used for caching fetched sources.
+	cacheDir string
+
+	// runner is the melange runner (e.g. "bubblewrap", "docker", "qemu").
+	runner string
+
+	// extraArgs are additional arguments appended to the command.
+	extraArgs []string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewMelangeBuilder creates an empty MelangeBuilder.
+func NewMelangeBuilder() *MelangeBuilder {
+	return &MelangeBuilder{}
+}
+
+// GetSourceDir returns the conventional source directory. If mntPrefix is empty, fixtures.MntPrefix is used.
+func GetSourceDir(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, "src")
+}
+
+// GetPipelineDir returns the conventional custom pipeline directory. If mntPrefix is empty,
+// fixtures.MntPrefix is used.
+func GetPipelineDir(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, "pipelines")
+}
+
+// GetOutputDir returns the conventional package output directory. If mntPrefix is empty,
+// fixtures.MntPrefix is used.
+func GetOutputDir(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, "packages")
+}
+
+// GetCacheDir returns the melange cache directory. If mntPrefix is empty, fixtures.MntPrefix is used.
+func GetCacheDir(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, "var", "cache", "melange")
+}
+
+// WithConfigFile sets the package configuration file.
+func (b *MelangeBuilder) WithConfigFile(configFile string) *MelangeBuilder {
+	b.configFile = configFile
+	return b
+}
+
+// WithArch adds an architecture to build for.
+func (b *MelangeBuilder) WithArch(arch string) *MelangeBuilder {
+	b.archs = append(b.archs, arch)
+	return b
+}
+
+// WithOutDir sets the directory where packages are written. It defaults to GetOutputDir("").
+func (b *MelangeBuilder) WithOutDir(dir string) *MelangeBuilder {
+	b.outDir = dir
+	return b
+}
+
+// WithSigningKey sets the path of the private key signing the packages.
+func (b *MelangeBuilder) WithSigningKey(path string) *MelangeBuilder {
+	b.signingKey = path
+	return b
+}
+
+// WithSigningKeyProvider sets the reference of the private key signing the packages: a key file
+// or a host environment variable, provided to melange as a secret file (see Secrets). Melange
+// cannot sign with KMS keys.
+func (b *MelangeBuilder) WithSigningKeyProvider(provider *signingx.KeyProvider) *MelangeBuilder {
+	b.signingKeyProvider = provider
+	return b
+}
+
+// WithKeyring adds a public key verifying the packages of the build environment.
+func (b *MelangeBuilder) WithKeyring(path string) *MelangeBuilder {
+	b.keyringPaths = append(b.keyringPaths, path)
+	return b
+}
+
+// WithRepositoryAppend adds a repository to the build environment.
+func (b *MelangeBuilder) WithRepositoryAppend(repo string) *MelangeBuilder {
+	b.repositoryAppend = append(b.repositoryAppend, repo)
+	return b
+}
+
+// WithSourceDir mounts the host directory 'source' as the package sources at 'target'.
+// An empty target defaults to GetSourceDir("").
+func (b *MelangeBuilder) WithSourceDir(source, target string) *MelangeBuilder {
+	if target == "" {
+		target = GetSourceDir("")
+	}
+	b.sourceDir = &directoryMount{source: source, target: target}
+	return b
+}
+
+// WithPipelineDir mounts the host directory 'source' holding custom pipelines at 'target'.
+// An empty target defaults to GetPipelineDir(""); additional directories need distinct targets.
+func (b *MelangeBuilder) WithPipelineDir(source, target string) *MelangeBuilder {
+	if target == "" {
+		target = GetPipelineDir("")
+	}
+	b.pipelineDirs = append(b.pipelineDirs, directoryMount{source: source, target: target})
+	return b
+}
+
+// WithCacheDir sets the directory used for caching fetched sources.
+func (b *MelangeBuilder) WithCacheDir(dir string) *MelangeBuilder {
+	b.cacheDir = dir
+	return b
+}
+
+// WithRunner sets the melange runner (e.g. "bubblewrap", "docker", "qemu").
+func (b *MelangeBuilder) WithRunner(runner string) *MelangeBuilder {
+	b.runner = runner
+	return b
+}
+
+// WithExtraArg adds an argument appended to the command.
+func (b *MelangeBuilder) WithExtraArg(arg string) *MelangeBuilder {
+	b.extraArgs = append(b.extraArgs, arg)
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *MelangeBuilder) WithLogger(logger *slog.Logger) *MelangeBuilder {
+	b.logger = logger
+	return b
+}
+
+// Mounts returns the mounts the generated command requires: the source directory and the custom
+// pipeline directories, read-only.
+func (b *MelangeBuilder) Mounts() []types.Mount {
+	var mounts []types.Mount
+
+	if b.sourceDir != nil {
+		mounts = append(mounts, types.Mount{
+			Kind:     types.MountKindDirectory,
+			Source:   b.sourceDir.source,
+			Target:   b.sourceDir.target,
+			ReadOnly: true,
+		})
+	}
+
+	for _, d := range b.pipelineDirs {
+		mounts = append(mounts, types.Mount{
+			Kind:     types.MountKindDirectory,
+			Source:   d.source,
+			Target:   d.target,
+			ReadOnly: true,
+		})
+	}
+
+	return mounts
+}
+
+// Secrets returns the secrets the generated command requires: the secrets of the signing key
+// provider (see signingx.KeyProvider.MelangeSecrets).
+func (b *MelangeBuilder) Secrets() []*secretsx.SecretRef {
+	if b.signingKeyProvider == nil {
+		return nil
+	}
+
+	return b.signingKeyProvider.MelangeSecrets()
+}
+
+// validate checks that the builder configuration is complete and consistent.
+func (b *MelangeBuilder) validate() error {
+	if b.configFile == "" {
+		return errorsx.MissingField(builderName, "configFile", "config file is required")
+	}
+
+	if ext := filepath.Ext(b.configFile); ext != ".yaml" && ext != ".yml" {
+		return errorsx.InvalidValue(builderName, "configFile", b.configFile, "config file must have a .yaml or .yml extension")
+	}
+
+	targets := map[string]bool{}
+	for _, d := range b.pipelineDirs {
+		if d.source == "" {
+			return errorsx.MissingField(builderName, "pipelineDirs", "pipeline directory source is required")
+		}
+		if targets[d.target] {
+			return errorsx.ConflictingOptions(builderName, []string{"pipelineDirs"},
+				"pipeline directories share the target %s", d.target)
+		}
+		targets[d.target] = true
+	}
+
+	if b.sourceDir != nil && b.sourceDir.source == "" {
+		return errorsx.MissingField(builderName, "sourceDir", "source directory source is required")
+	}
+
+	if b.signingKeyProvider != nil {
+		if b.signingKey != "" {
+			return errorsx.ConflictingOptions(builderName, []string{"signingKey", "signingKeyProvider"},
+				"signing key and signing key provider cannot be combined")
+		}
+
+		if _, err := b.signingKeyProvider.MelangeKey(); err != nil {
+			return err
+		}
+	}
+
+	if b.signingKey != "" && strings.HasSuffix(b.signingKey, ".pub") {
+		return errorsx.InvalidValue(builderName, "signingKey", b.signingKey,
+			"signing key must be a private key, got public key %s", b.signingKey)
+	}
+
+	return nil
+}
+
+// BuildCommand generates the melange build command.
+//
+// Returns:
+//   - The melange build command.
+//   - An error if the configuration file is missing or the options are inconsistent.
+func (b *MelangeBuilder) BuildCommand() ([]string, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"melange", "build", b.configFile}
+
+	for _, arch := range b.archs {
+		cmd = append(cmd, "--arch", arch)
+	}
+
+	outDir := b.outDir
+	if outDir == "" {
+		outDir = GetOutputDir("")
+	}
+	cmd = append(cmd, "--out-dir", outDir)
+
+	if b.signingKey != "" {
+		cmd = append(cmd, "--signing-key", b.signingKey)
+	}
+
+	if b.signingKeyProvider != nil {
+		key, err := b.signingKeyProvider.MelangeKey()
+		if err != nil {
+			return nil, err
+		}
+		cmd = append(cmd, "--signing-key", key)
+	}
+
+	for _, k := range b.keyringPaths {
+		cmd = append(cmd, "--keyring-append", k)
+	}
+
+	for _, r := range b.repositoryAppend {
+		cmd = append(cmd, "--repository-append", r)
+	}
+
+	if b.sourceDir != nil {
+		cmd = append(cmd, "--source-dir", b.sourceDir.target)
+	}
+
+	for _, d := range b.pipelineDirs {
+		cmd = append(cmd, "--pipeline-dir", d.target)
+	}
+
+	if b.cacheDir != "" {
+		cmd = append(cmd, "--cache-dir", b.cacheDir)
+	}
+
+	if b.runner != "" {
+		cmd = append(cmd, "--runner", b.runner)
+	}
+
+	cmd = append(cmd, b.extraArgs...)
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// DebugRecipe returns the interactive container reproducing the build by hand: the melange image
+// with the mounts of the build (see Mounts), and the build command, left for the user to run.
+//
+// Returns:
+//   - The debug recipe.
+//   - An error if the build command cannot be generated, see BuildCommand.
+func (b *MelangeBuilder) DebugRecipe() (types.DebugRecipe, error) {
+	cmd, err := b.BuildCommand()
+	if err != nil {
+		return types.DebugRecipe{}, err
+	}
+
+	return types.DebugRecipe{Image: MelangeDefaultImage, Mounts: b.Mounts(), Command: cmd}, nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *MelangeBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *MelangeBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the melange build command.
+func (b *MelangeBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewMelangeBuilder(), explainx.Generate("BuildCommand", b.BuildCommand))
+}
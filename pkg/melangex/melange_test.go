@@ -0,0 +1,131 @@
+package melangex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/signingx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConventionalDirs(t *testing.T) {
+	assert.Equal(t, "/mnt/src", GetSourceDir(""))
+	assert.Equal(t, "/work/pipelines", GetPipelineDir("/work"))
+	assert.Equal(t, "/mnt/packages", GetOutputDir(""))
+	assert.Equal(t, "/mnt/var/cache/melange", GetCacheDir(""))
+}
+
+func TestBuildCommand(t *testing.T) {
+	b := NewMelangeBuilder().
+		WithConfigFile("package.yaml").
+		WithArch("x86_64").
+		WithArch("aarch64").
+		WithSigningKey("/mnt/melange.rsa").
+		WithKeyring("/etc/apk/keys/wolfi-signing.rsa.pub").
+		WithRepositoryAppend("https://packages.wolfi.dev/os").
+		WithSourceDir("./src", "").
+		WithPipelineDir("./pipelines", "").
+		WithPipelineDir("./shared", "/mnt/shared-pipelines").
+		WithCacheDir(GetCacheDir("")).
+		WithRunner("bubblewrap").
+		WithExtraArg("--debug")
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"melange", "build", "package.yaml",
+		"--arch", "x86_64", "--arch", "aarch64",
+		"--out-dir", "/mnt/packages",
+		"--signing-key", "/mnt/melange.rsa",
+		"--keyring-append", "/etc/apk/keys/wolfi-signing.rsa.pub",
+		"--repository-append", "https://packages.wolfi.dev/os",
+		"--source-dir", "/mnt/src",
+		"--pipeline-dir", "/mnt/pipelines",
+		"--pipeline-dir", "/mnt/shared-pipelines",
+		"--cache-dir", "/mnt/var/cache/melange",
+		"--runner", "bubblewrap",
+		"--debug",
+	}, cmd)
+
+	assert.Equal(t, []types.Mount{
+		{Kind: types.MountKindDirectory, Source: "./src", Target: "/mnt/src", ReadOnly: true},
+		{Kind: types.MountKindDirectory, Source: "./pipelines", Target: "/mnt/pipelines", ReadOnly: true},
+		{Kind: types.MountKindDirectory, Source: "./shared", Target: "/mnt/shared-pipelines", ReadOnly: true},
+	}, b.Mounts())
+}
+
+func TestBuildCommandValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *MelangeBuilder
+		kind    error
+	}{
+		{"Missing config", NewMelangeBuilder(), errorsx.ErrMissingField},
+		{"Invalid config extension", NewMelangeBuilder().WithConfigFile("package.json"), errorsx.ErrInvalidValue},
+		{
+			"Duplicate pipeline targets",
+			NewMelangeBuilder().WithConfigFile("package.yaml").WithPipelineDir("./a", "").WithPipelineDir("./b", ""),
+			errorsx.ErrConflictingOptions,
+		},
+		{"Empty pipeline source", NewMelangeBuilder().WithConfigFile("package.yaml").WithPipelineDir("", ""), errorsx.ErrMissingField},
+		{"Public signing key", NewMelangeBuilder().WithConfigFile("package.yaml").WithSigningKey("melange.rsa.pub"), errorsx.ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.BuildCommand()
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+		})
+	}
+}
+
+func TestBuildCommandSigningKeyProvider(t *testing.T) {
+	b := NewMelangeBuilder().
+		WithConfigFile("package.yaml").
+		WithSigningKeyProvider(signingx.NewEnvKey("MELANGE_KEY"))
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"melange", "build", "package.yaml", "--out-dir", GetOutputDir(""), "--signing-key", signingx.MelangeKeyPath,
+	}, cmd)
+
+	secrets := b.Secrets()
+	require.Len(t, secrets, 1)
+	assert.Equal(t, "MELANGE_KEY", secrets[0].Ref)
+	assert.Equal(t, signingx.MelangeKeyPath, secrets[0].Target)
+
+	_, err = NewMelangeBuilder().
+		WithConfigFile("package.yaml").
+		WithSigningKeyProvider(signingx.NewKMSKey("awskms:///alias/release")).
+		BuildCommand()
+	require.ErrorIs(t, err, errorsx.ErrUnsupported)
+
+	_, err = NewMelangeBuilder().
+		WithConfigFile("package.yaml").
+		WithSigningKey("/mnt/melange.rsa").
+		WithSigningKeyProvider(signingx.NewFileKey("/mnt/other.rsa")).
+		BuildCommand()
+	require.ErrorIs(t, err, errorsx.ErrConflictingOptions)
+
+	assert.Empty(t, NewMelangeBuilder().Secrets())
+}
+
+func TestMelangeBuilderDebugRecipe(t *testing.T) {
+	b := NewMelangeBuilder().WithConfigFile("package.yaml").WithSourceDir("./src", "")
+
+	recipe, err := b.DebugRecipe()
+	require.NoError(t, err)
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, MelangeDefaultImage, recipe.Image)
+	assert.Equal(t, types.DaggerCMD(cmd), recipe.Command)
+	assert.Equal(t, b.Mounts(), recipe.Mounts)
+
+	_, err = NewMelangeBuilder().DebugRecipe()
+	assert.ErrorIs(t, err, errorsx.ErrMissingField)
+}
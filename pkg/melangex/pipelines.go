@@ -0,0 +1,131 @@
+package melangex
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"gopkg.in/yaml.v3"
+)
+
+// builtinPipelineNamespaces are the top-level names of the pipelines shipped with melange.
+var builtinPipelineNamespaces = map[string]bool{
+	"autoconf":     true,
+	"cargo":        true,
+	"cmake":        true,
+	"fetch":        true,
+	"git-checkout": true,
+	"go":           true,
+	"maven":        true,
+	"meson":        true,
+	"npm":          true,
+	"patch":        true,
+	"pecl":         true,
+	"perl":         true,
+	"python":       true,
+	"R":            true,
+	"ruby":         true,
+	"split":        true,
+	"strip":        true,
+	"xcover":       true,
+}
+
+// IsBuiltinPipeline reports whether a `uses` reference names a pipeline shipped with melange.
+func IsBuiltinPipeline(uses string) bool {
+	namespace, _, _ := strings.Cut(uses, "/")
+	return builtinPipelineNamespaces[namespace]
+}
+
+// pipelineStep is the part of a pipeline step relevant to pipeline references.
+type pipelineStep struct {
+	Uses     string         `yaml:"uses"`
+	Pipeline []pipelineStep `yaml:"pipeline"`
+}
+
+// pipelineConfig is the part of a package configuration relevant to pipeline references.
+type pipelineConfig struct {
+	Pipeline    []pipelineStep `yaml:"pipeline"`
+	Subpackages []struct {
+		Pipeline []pipelineStep `yaml:"pipeline"`
+	} `yaml:"subpackages"`
+}
+
+// PipelineReferences returns the pipelines referenced with `uses` by a package configuration,
+// including nested steps and subpackages, sorted and deduplicated.
+//
+// Returns:
+//   - The referenced pipelines.
+//   - An error if the configuration is not valid YAML.
+func PipelineReferences(config []byte) ([]string, error) {
+	var cfg pipelineConfig
+	if err := yaml.Unmarshal(config, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse melange config: %w", err)
+	}
+
+	seen := map[string]bool{}
+	var collect func(steps []pipelineStep)
+	collect = func(steps []pipelineStep) {
+		for _, s := range steps {
+			if s.Uses != "" {
+				seen[s.Uses] = true
+			}
+			collect(s.Pipeline)
+		}
+	}
+
+	collect(cfg.Pipeline)
+	for _, sub := range cfg.Subpackages {
+		collect(sub.Pipeline)
+	}
+
+	refs := make([]string, 0, len(seen))
+	for uses := range seen {
+		refs = append(refs, uses)
+	}
+
+	sort.Strings(refs)
+
+	return refs, nil
+}
+
+// ValidatePipelines checks that every custom pipeline referenced by the package configuration
+// exists in one of the pipeline directories of the builder, read from their host sources.
+// Pipelines shipped with melange are not checked.
+//
+// Returns:
+//   - An error listing the custom pipelines found in no pipeline directory.
+func (b *MelangeBuilder) ValidatePipelines(config []byte) error {
+	refs, err := PipelineReferences(config)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, uses := range refs {
+		if IsBuiltinPipeline(uses) || b.hasPipeline(uses) {
+			continue
+		}
+		missing = append(missing, uses)
+	}
+
+	if len(missing) > 0 {
+		return errorsx.MissingField(builderName, "pipelineDirs",
+			"custom pipelines not found in the pipeline directories: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// hasPipeline reports whether a pipeline directory holds the pipeline 'uses'.
+func (b *MelangeBuilder) hasPipeline(uses string) bool {
+	for _, d := range b.pipelineDirs {
+		if info, err := os.Stat(filepath.Join(d.source, uses+".yaml")); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+
+	return false
+}
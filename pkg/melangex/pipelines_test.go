@@ -0,0 +1,61 @@
+package melangex
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPipelineConfig = `package:
+  name: hello
+  version: 1.0.0
+pipeline:
+  - uses: fetch
+  - uses: internal/setup
+  - pipeline:
+      - uses: go/build
+      - uses: internal/lint
+subpackages:
+  - name: hello-doc
+    pipeline:
+      - uses: internal/docs
+`
+
+func TestPipelineReferences(t *testing.T) {
+	refs, err := PipelineReferences([]byte(testPipelineConfig))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fetch", "go/build", "internal/docs", "internal/lint", "internal/setup"}, refs)
+
+	_, err = PipelineReferences([]byte("pipeline: ["))
+	assert.Error(t, err)
+}
+
+func TestIsBuiltinPipeline(t *testing.T) {
+	assert.True(t, IsBuiltinPipeline("go/build"))
+	assert.True(t, IsBuiltinPipeline("fetch"))
+	assert.False(t, IsBuiltinPipeline("internal/setup"))
+}
+
+func TestValidatePipelines(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "internal"), 0o755))
+	for _, name := range []string{"setup", "lint"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "internal", name+".yaml"), []byte("pipeline: []"), 0o600))
+	}
+
+	b := NewMelangeBuilder().WithPipelineDir(dir, "")
+	err := b.ValidatePipelines([]byte(testPipelineConfig))
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+	assert.ErrorContains(t, err, "custom pipelines not found in the pipeline directories: internal/docs")
+
+	other := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(other, "internal"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(other, "internal", "docs.yaml"), []byte("pipeline: []"), 0o600))
+
+	assert.NoError(t, b.WithPipelineDir(other, "/mnt/other").ValidatePipelines([]byte(testPipelineConfig)))
+}
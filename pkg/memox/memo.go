@@ -0,0 +1,168 @@
+// Package memox memoizes generated commands by the content of the builder state that produced
+// them, so pipelines rebuilding identical configurations skip regeneration and can tell Dagger
+// modules when previously produced outputs are reusable.
+//
+// The memoization key is the SHA-256 digest of the JSON serialization of the builder state, such
+// as apkox.ApkoSpec. Two builders configured identically share a key regardless of the order in
+// which their options were set.
+//
+// Example usage:
+//
+//	memo := memox.NewMemo()
+//
+//	builder := apkox.NewApkoBuilder().
+//	    WithConfigFile("apko.yaml").
+//	    WithOutputImage("registry.example.com/base").
+//	    WithOutputTarball("/out/image.tar")
+//
+//	entry, hit, err := memo.Command(builder.Spec(), builder.BuildCommand)
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	if memo.Reusable(entry.Key) {
+//	    // skip the build and reuse the recorded outputs
+//	}
+package memox
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// KeyPrefix prefixes every memoization key with the hash algorithm.
+const KeyPrefix = "sha256:"
+
+// Key returns the content-addressed key of a builder state: the SHA-256 digest of its JSON
+// serialization. Map keys are serialized in sorted order, so equal states always share a key.
+func Key(state any) (string, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize builder state: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+
+	return KeyPrefix + hex.EncodeToString(sum[:]), nil
+}
+
+// Entry is a memoized command.
+type Entry struct {
+	// Key is the content-addressed key of the builder state.
+	Key string `json:"key"`
+	// Command is the command generated for the builder state.
+	Command types.DaggerCMD `json:"command"`
+	// Outputs holds the outputs recorded for the command, e.g. the paths of produced artifacts.
+	Outputs []string `json:"outputs,omitempty"`
+	// CreatedAt is when the command was generated.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Memo is a concurrency-safe in-memory store of memoized commands.
+type Memo struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+// NewMemo creates an empty memo.
+func NewMemo() *Memo {
+	return &Memo{entries: map[string]Entry{}}
+}
+
+// Command returns the memoized command of a builder state, calling 'generate' only if the state
+// has not been seen before. Generation errors are not memoized.
+//
+// Returns:
+//   - The memo entry of the state.
+//   - Whether the entry was already memoized.
+//   - An error if the state cannot be serialized or the command cannot be generated.
+func (m *Memo) Command(state any, generate func() (types.DaggerCMD, error)) (Entry, bool, error) {
+	key, err := Key(state)
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	if entry, ok := m.Lookup(key); ok {
+		return entry, true, nil
+	}
+
+	cmd, err := generate()
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Another caller may have generated the same command concurrently; keep the first entry.
+	if entry, ok := m.entries[key]; ok {
+		return entry, true, nil
+	}
+
+	entry := Entry{
+		Key:       key,
+		Command:   append(types.DaggerCMD(nil), cmd...),
+		CreatedAt: time.Now(),
+	}
+	m.entries[key] = entry
+
+	return entry, false, nil
+}
+
+// Lookup returns the memo entry of a key.
+func (m *Memo) Lookup(key string) (Entry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+
+	return entry, ok
+}
+
+// RecordOutputs records the outputs produced by the command of a key, marking them reusable.
+//
+// Returns:
+//   - An error if the key is not memoized.
+func (m *Memo) RecordOutputs(key string, outputs ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return fmt.Errorf("no memoized command for key %s", key)
+	}
+
+	entry.Outputs = append(append([]string(nil), entry.Outputs...), outputs...)
+	m.entries[key] = entry
+
+	return nil
+}
+
+// Reusable reports whether outputs were recorded for a key, i.e. whether a Dagger module can
+// reuse them instead of running the command again.
+func (m *Memo) Reusable(key string) bool {
+	entry, ok := m.Lookup(key)
+	return ok && len(entry.Outputs) > 0
+}
+
+// Forget removes the entry of a key, e.g. after its outputs were invalidated.
+func (m *Memo) Forget(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+}
+
+// Len returns the number of memoized commands.
+func (m *Memo) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.entries)
+}
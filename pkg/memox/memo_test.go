@@ -0,0 +1,78 @@
+package memox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKey(t *testing.T) {
+	a := apkox.NewApkoBuilder().
+		WithConfigFile("apko.yaml").
+		WithAnnotations(map[string]string{"a": "1", "b": "2"}).
+		WithOutputImage("registry.example.com/base")
+	b := apkox.NewApkoBuilder().
+		WithOutputImage("registry.example.com/base").
+		WithAnnotations(map[string]string{"b": "2", "a": "1"}).
+		WithConfigFile("apko.yaml")
+
+	keyA, err := Key(a.Spec())
+	require.NoError(t, err)
+	keyB, err := Key(b.Spec())
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(keyA, KeyPrefix))
+	assert.Len(t, keyA, len(KeyPrefix)+64)
+	assert.Equal(t, keyA, keyB)
+
+	keyC, err := Key(b.WithArchitecture("aarch64").Spec())
+	require.NoError(t, err)
+	assert.NotEqual(t, keyA, keyC)
+
+	_, err = Key(func() {})
+	assert.Error(t, err)
+}
+
+func TestCommand(t *testing.T) {
+	m := NewMemo()
+	calls := 0
+	generate := func() (types.DaggerCMD, error) {
+		calls++
+		return types.DaggerCMD{"apko", "build"}, nil
+	}
+
+	entry, hit, err := m.Command(map[string]string{"config": "apko.yaml"}, generate)
+	require.NoError(t, err)
+	assert.False(t, hit)
+	assert.Equal(t, types.DaggerCMD{"apko", "build"}, entry.Command)
+
+	again, hit, err := m.Command(map[string]string{"config": "apko.yaml"}, generate)
+	require.NoError(t, err)
+	assert.True(t, hit)
+	assert.Equal(t, entry, again)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 1, m.Len())
+
+	_, _, err = m.Command("other", func() (types.DaggerCMD, error) { return nil, errors.New("boom") })
+	assert.EqualError(t, err, "boom")
+	assert.Equal(t, 1, m.Len())
+}
+
+func TestRecordOutputs(t *testing.T) {
+	m := NewMemo()
+	entry, _, err := m.Command("state", func() (types.DaggerCMD, error) { return types.DaggerCMD{"true"}, nil })
+	require.NoError(t, err)
+
+	assert.False(t, m.Reusable(entry.Key))
+	require.NoError(t, m.RecordOutputs(entry.Key, "/out/image.tar"))
+	assert.True(t, m.Reusable(entry.Key))
+
+	m.Forget(entry.Key)
+	assert.False(t, m.Reusable(entry.Key))
+	assert.EqualError(t, m.RecordOutputs(entry.Key, "/out/image.tar"), "no memoized command for key "+entry.Key)
+}
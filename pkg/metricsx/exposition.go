@@ -0,0 +1,224 @@
+package metricsx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/ioutilx"
+	"github.com/Excoriate/daggerx/pkg/mapx"
+)
+
+// ContentType is the media type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// labelEscaper escapes label values in the text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// helpEscaper escapes help texts in the text format.
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// WriteText writes the metrics in the Prometheus text exposition format. Metrics and series are
+// sorted, so equal registries write equal output.
+//
+// Returns:
+//   - An error if writing to 'w' fails.
+func (r *Registry) WriteText(w io.Writer) error {
+	if r == nil {
+		return nil
+	}
+
+	var b bytes.Buffer
+
+	r.mu.Lock()
+	for _, name := range mapx.SortedKeys(r.families) {
+		r.families[name].writeText(&b)
+	}
+	r.mu.Unlock()
+
+	if _, err := w.Write(b.Bytes()); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+
+	return nil
+}
+
+// Text returns the metrics in the Prometheus text exposition format.
+func (r *Registry) Text() string {
+	var b strings.Builder
+	_ = r.WriteText(&b)
+
+	return b.String()
+}
+
+// writeText writes the family with its series, if it has any.
+func (f *family) writeText(b *bytes.Buffer) {
+	if len(f.series) == 0 {
+		return
+	}
+
+	if f.help != "" {
+		fmt.Fprintf(b, "# HELP %s %s\n", f.name, helpEscaper.Replace(f.help))
+	}
+	fmt.Fprintf(b, "# TYPE %s %s\n", f.name, f.kind)
+
+	for _, key := range mapx.SortedKeys(f.series) {
+		s := f.series[key]
+
+		if f.kind != KindHistogram {
+			fmt.Fprintf(b, "%s%s %s\n", f.name, formatLabels(s.labels, "", ""), formatValue(s.value))
+			continue
+		}
+
+		var cumulated uint64
+		for i, upper := range f.buckets {
+			cumulated += s.counts[i]
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, formatLabels(s.labels, "le", formatValue(upper)), cumulated)
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, formatLabels(s.labels, "le", "+Inf"), s.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", f.name, formatLabels(s.labels, "", ""), formatValue(s.sum))
+		fmt.Fprintf(b, "%s_count%s %d\n", f.name, formatLabels(s.labels, "", ""), s.count)
+	}
+}
+
+// formatLabels renders a label set, with the extra label 'extra' when not empty.
+func formatLabels(labels map[string]string, extra, value string) string {
+	pairs := make([]string, 0, len(labels)+1)
+	for _, k := range mapx.SortedKeys(labels) {
+		pairs = append(pairs, k+`="`+labelEscaper.Replace(labels[k])+`"`)
+	}
+
+	if extra != "" {
+		pairs = append(pairs, extra+`="`+value+`"`)
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatValue renders a sample value.
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// WriteTextfile writes the metrics to 'path' for the textfile collector of the node_exporter.
+// The file is written atomically, through a temporary file renamed over 'path', so the collector
+// never reads a partial file; 'path' should have the ".prom" extension the collector reads.
+//
+// Returns:
+//   - An error if the file cannot be written.
+func (r *Registry) WriteTextfile(path string) error {
+	//nolint:gosec // The textfile collector runs as another user and must read the file.
+	if err := ioutilx.WriteAtomic(path, 0o644, r.WriteText); err != nil {
+		return fmt.Errorf("failed to write metrics to %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Pusher pushes metrics to a Prometheus Pushgateway.
+type Pusher struct {
+	// gateway is the base URL of the Pushgateway.
+	gateway string
+	// job is the job label of the pushed metrics.
+	job string
+	// grouping holds the grouping labels of the pushed metrics, in addition to the job.
+	grouping map[string]string
+	// client sends the requests. A nil client is a client with a 30 seconds timeout.
+	client *http.Client
+}
+
+// NewPusher creates a Pusher pushing to the Pushgateway at 'gateway' (e.g.
+// "http://pushgateway:9091") under the job 'job'.
+func NewPusher(gateway, job string) *Pusher {
+	return &Pusher{gateway: gateway, job: job, grouping: map[string]string{}}
+}
+
+// WithGrouping adds the grouping label 'name' with 'value' (e.g. "instance", the CI runner).
+// It returns the updated Pusher instance.
+func (p *Pusher) WithGrouping(name, value string) *Pusher {
+	p.grouping[name] = value
+	return p
+}
+
+// WithClient sets the HTTP client sending the requests.
+// It returns the updated Pusher instance.
+func (p *Pusher) WithClient(client *http.Client) *Pusher {
+	p.client = client
+	return p
+}
+
+// URL returns the URL the metrics are pushed to: the job and grouping labels are path segments
+// of the gateway URL.
+func (p *Pusher) URL() string {
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(p.gateway, "/"))
+	b.WriteString("/metrics/job/")
+	b.WriteString(url.PathEscape(p.job))
+
+	for _, name := range mapx.SortedKeys(p.grouping) {
+		b.WriteString("/" + url.PathEscape(name) + "/" + url.PathEscape(p.grouping[name]))
+	}
+
+	return b.String()
+}
+
+// Push replaces the metrics of the job and grouping labels on the Pushgateway with those of
+// 'registry' (HTTP PUT).
+//
+// Returns:
+//   - An error if the gateway or the job is missing, or the Pushgateway rejects the metrics.
+func (p *Pusher) Push(ctx context.Context, registry *Registry) error {
+	if p.gateway == "" {
+		return fmt.Errorf("pushgateway URL is required")
+	}
+
+	if p.job == "" {
+		return fmt.Errorf("pushgateway job is required")
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, p.URL(), strings.NewReader(registry.Text()))
+	if err != nil {
+		return fmt.Errorf("failed to push metrics: %w", err)
+	}
+	req.Header.Set("Content-Type", ContentType)
+
+	client := p.client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics to %s: %w", p.gateway, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return fmt.Errorf("failed to push metrics to %s: %s: %s", p.gateway, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
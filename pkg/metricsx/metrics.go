@@ -0,0 +1,249 @@
+// Package metricsx records metrics of daggerx-driven builds (builds by tool, build durations,
+// memoization hits and misses, scan findings) and exposes them in the Prometheus text format, for
+// teams tracking CI performance trends.
+//
+// Metrics are optional: a nil *Registry records nothing, so code paths can be instrumented
+// unconditionally. A Registry is written as a node_exporter textfile (see WriteTextfile), or
+// pushed to a Prometheus Pushgateway at the end of a CI job (see Pusher).
+//
+// Example usage:
+//
+//	metrics := metricsx.NewRegistry()
+//
+//	metrics.RecordBuild("apko", elapsed, err == nil)
+//	metrics.RecordMemo(hit)
+//	metrics.RecordScan(scan) // a reportx.ScanResult
+//
+//	if err := metrics.WriteTextfile("/var/lib/node_exporter/textfile/daggerx.prom"); err != nil {
+//	    // handle error
+//	}
+package metricsx
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/mapx"
+	"github.com/Excoriate/daggerx/pkg/progressx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+)
+
+// Metric names.
+const (
+	// BuildsTotal counts the builds by tool and status ("succeeded" or "failed").
+	BuildsTotal = "daggerx_builds_total"
+	// BuildDuration is the histogram of the build durations by tool, in seconds.
+	BuildDuration = "daggerx_build_duration_seconds"
+	// MemoLookupsTotal counts the memoization lookups by result ("hit" or "miss").
+	MemoLookupsTotal = "daggerx_memo_lookups_total"
+	// ScanFindingsTotal counts the scan findings by scanner and severity.
+	ScanFindingsTotal = "daggerx_scan_findings_total"
+)
+
+// DurationBuckets are the upper bounds of the build duration histogram buckets, in seconds: from
+// one second to one hour.
+var DurationBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200, 1800, 3600}
+
+// Kind is the type of a metric.
+type Kind string
+
+const (
+	// KindCounter is a monotonically increasing counter.
+	KindCounter Kind = "counter"
+	// KindGauge is a value that goes up and down.
+	KindGauge Kind = "gauge"
+	// KindHistogram is a distribution of observed values in buckets.
+	KindHistogram Kind = "histogram"
+)
+
+// family is a metric with its samples, by label set.
+type family struct {
+	name    string
+	kind    Kind
+	help    string
+	buckets []float64
+	series  map[string]*series
+}
+
+// series is the samples of a label set of a metric.
+type series struct {
+	labels map[string]string
+	// value is the value of counters and gauges.
+	value float64
+	// counts holds the number of observations per bucket of histograms, not cumulated.
+	counts []uint64
+	// sum and count are the sum and number of the observations of histograms.
+	sum   float64
+	count uint64
+}
+
+// Registry holds metrics. It is safe for concurrent use, and a nil Registry records nothing.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry creates a registry describing the daggerx build metrics.
+func NewRegistry() *Registry {
+	r := &Registry{families: map[string]*family{}}
+
+	r.Describe(BuildsTotal, KindCounter, "Builds run by daggerx, by tool and status.")
+	r.DescribeHistogram(BuildDuration, "Duration of the builds run by daggerx, by tool, in seconds.", DurationBuckets)
+	r.Describe(MemoLookupsTotal, KindCounter, "Memoized command lookups, by result.")
+	r.Describe(ScanFindingsTotal, KindCounter, "Findings of the scans run by daggerx, by scanner and severity.")
+
+	return r
+}
+
+// Describe declares the counter or gauge 'name' with its help text. Metrics recorded without
+// being declared are written without help text.
+func (r *Registry) Describe(name string, kind Kind, help string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f := r.family(name, kind)
+	f.kind, f.help = kind, help
+}
+
+// DescribeHistogram declares the histogram 'name' with its help text and bucket upper bounds.
+// Histograms observed without being declared use DurationBuckets, and the buckets of a histogram
+// can't change once it has observations.
+func (r *Registry) DescribeHistogram(name, help string, buckets []float64) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f := r.family(name, KindHistogram)
+	f.help = help
+	if len(f.series) == 0 {
+		f.buckets = slices.Sorted(slices.Values(buckets))
+	}
+}
+
+// family returns the family 'name', creating it with 'kind' when undeclared.
+func (r *Registry) family(name string, kind Kind) *family {
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{name: name, kind: kind, series: map[string]*series{}}
+		if kind == KindHistogram {
+			f.buckets = DurationBuckets
+		}
+		r.families[name] = f
+	}
+
+	return f
+}
+
+// seriesOf returns the series of 'labels' of the family 'f', creating it when new.
+func (f *family) seriesOf(labels map[string]string) *series {
+	key := strings.Join(mapx.Pairs(labels, "="), "\x00")
+
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: maps.Clone(labels)}
+		if f.kind == KindHistogram {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+
+	return s
+}
+
+// Add adds 'value' to the counter 'name' of the label set 'labels'. Negative values are ignored:
+// counters only increase.
+func (r *Registry) Add(name string, labels map[string]string, value float64) {
+	if r == nil || value < 0 || math.IsNaN(value) {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.family(name, KindCounter).seriesOf(labels).value += value
+}
+
+// Set sets the gauge 'name' of the label set 'labels' to 'value'.
+func (r *Registry) Set(name string, labels map[string]string, value float64) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.family(name, KindGauge).seriesOf(labels).value = value
+}
+
+// Observe records the observation 'value' in the histogram 'name' of the label set 'labels'.
+// Observations of metrics declared as counters or gauges are ignored.
+func (r *Registry) Observe(name string, labels map[string]string, value float64) {
+	if r == nil || math.IsNaN(value) {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f := r.family(name, KindHistogram)
+	if f.kind != KindHistogram {
+		return
+	}
+
+	s := f.seriesOf(labels)
+
+	if i, _ := slices.BinarySearch(f.buckets, value); i < len(f.buckets) {
+		s.counts[i]++
+	}
+	s.sum += value
+	s.count++
+}
+
+// RecordBuild records a build by the tool 'tool' (e.g. "apko") which took 'duration'.
+func (r *Registry) RecordBuild(tool string, duration time.Duration, succeeded bool) {
+	status := "failed"
+	if succeeded {
+		status = "succeeded"
+	}
+
+	r.Add(BuildsTotal, map[string]string{"tool": tool, "status": status}, 1)
+	r.Observe(BuildDuration, map[string]string{"tool": tool}, duration.Seconds())
+}
+
+// RecordProgress records the builds of the finished tasks of a plan, by builder (see progressx).
+// Pending, running and skipped tasks are not builds and are ignored.
+func (r *Registry) RecordProgress(snapshot progressx.Snapshot) {
+	for _, task := range snapshot.Tasks {
+		if task.State == progressx.StateSucceeded || task.State == progressx.StateFailed {
+			r.RecordBuild(task.Builder, task.Duration, task.State == progressx.StateSucceeded)
+		}
+	}
+}
+
+// RecordMemo records a memoization lookup, e.g. the hit returned by memox.Memo.Command.
+func (r *Registry) RecordMemo(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+
+	r.Add(MemoLookupsTotal, map[string]string{"result": result}, 1)
+}
+
+// RecordScan records the findings of a scan, by severity.
+func (r *Registry) RecordScan(scan reportx.ScanResult) {
+	for severity, n := range scan.Counts {
+		r.Add(ScanFindingsTotal, map[string]string{"scanner": scan.Scanner, "severity": severity}, float64(n))
+	}
+}
@@ -0,0 +1,147 @@
+package metricsx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/progressx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryText(t *testing.T) {
+	r := NewRegistry()
+	r.RecordBuild("apko", 3*time.Second, true)
+	r.RecordBuild("apko", 90*time.Second, false)
+	r.RecordMemo(true)
+	r.RecordMemo(false)
+	r.RecordMemo(true)
+	r.RecordScan(reportx.ScanResult{Scanner: "grype", Counts: map[string]int{"High": 2}})
+
+	want := `# HELP daggerx_build_duration_seconds Duration of the builds run by daggerx, by tool, in seconds.
+# TYPE daggerx_build_duration_seconds histogram
+daggerx_build_duration_seconds_bucket{tool="apko",le="1"} 0
+daggerx_build_duration_seconds_bucket{tool="apko",le="5"} 1
+daggerx_build_duration_seconds_bucket{tool="apko",le="10"} 1
+daggerx_build_duration_seconds_bucket{tool="apko",le="30"} 1
+daggerx_build_duration_seconds_bucket{tool="apko",le="60"} 1
+daggerx_build_duration_seconds_bucket{tool="apko",le="120"} 2
+daggerx_build_duration_seconds_bucket{tool="apko",le="300"} 2
+daggerx_build_duration_seconds_bucket{tool="apko",le="600"} 2
+daggerx_build_duration_seconds_bucket{tool="apko",le="1200"} 2
+daggerx_build_duration_seconds_bucket{tool="apko",le="1800"} 2
+daggerx_build_duration_seconds_bucket{tool="apko",le="3600"} 2
+daggerx_build_duration_seconds_bucket{tool="apko",le="+Inf"} 2
+daggerx_build_duration_seconds_sum{tool="apko"} 93
+daggerx_build_duration_seconds_count{tool="apko"} 2
+# HELP daggerx_builds_total Builds run by daggerx, by tool and status.
+# TYPE daggerx_builds_total counter
+daggerx_builds_total{status="failed",tool="apko"} 1
+daggerx_builds_total{status="succeeded",tool="apko"} 1
+# HELP daggerx_memo_lookups_total Memoized command lookups, by result.
+# TYPE daggerx_memo_lookups_total counter
+daggerx_memo_lookups_total{result="hit"} 2
+daggerx_memo_lookups_total{result="miss"} 1
+# HELP daggerx_scan_findings_total Findings of the scans run by daggerx, by scanner and severity.
+# TYPE daggerx_scan_findings_total counter
+daggerx_scan_findings_total{scanner="grype",severity="High"} 2
+`
+	assert.Equal(t, want, r.Text())
+}
+
+func TestRegistryCustomMetrics(t *testing.T) {
+	r := &Registry{families: map[string]*family{}}
+	r.Set("queue_depth", nil, 4)
+	r.Set("queue_depth", nil, 2)
+	r.Add("events_total", map[string]string{"msg": "a \"quoted\"\nline\\"}, 1)
+	r.Add("events_total", map[string]string{"msg": "a \"quoted\"\nline\\"}, -5)
+	r.Observe("events_total", nil, 1)
+
+	assert.Equal(t, `# TYPE events_total counter
+events_total{msg="a \"quoted\"\nline\\"} 1
+# TYPE queue_depth gauge
+queue_depth 2
+`, r.Text())
+}
+
+func TestNilRegistry(t *testing.T) {
+	var r *Registry
+	r.RecordBuild("apko", time.Second, true)
+	r.RecordMemo(true)
+
+	assert.Empty(t, r.Text())
+}
+
+func TestRecordProgress(t *testing.T) {
+	r := NewRegistry()
+	r.RecordProgress(progressx.Snapshot{Tasks: []progressx.TaskProgress{
+		{Builder: "apko", State: progressx.StateSucceeded, Duration: time.Second},
+		{Builder: "melange", State: progressx.StateFailed, Duration: time.Second},
+		{Builder: "cosign", State: progressx.StateSkipped},
+	}})
+
+	text := r.Text()
+	assert.Contains(t, text, `daggerx_builds_total{status="succeeded",tool="apko"} 1`)
+	assert.Contains(t, text, `daggerx_builds_total{status="failed",tool="melange"} 1`)
+	assert.NotContains(t, text, "cosign")
+}
+
+func TestWriteTextfile(t *testing.T) {
+	r := NewRegistry()
+	r.RecordMemo(true)
+
+	path := filepath.Join(t.TempDir(), "daggerx.prom")
+	require.NoError(t, r.WriteTextfile(path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, r.Text(), string(data))
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestPusher(t *testing.T) {
+	var (
+		method, path, contentType, body string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		data, _ := io.ReadAll(req.Body)
+		method, path, contentType, body = req.Method, req.URL.EscapedPath(), req.Header.Get("Content-Type"), string(data)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	r := NewRegistry()
+	r.RecordMemo(false)
+
+	pusher := NewPusher(server.URL+"/", "daggerx ci").WithGrouping("instance", "runner/1")
+	require.NoError(t, pusher.Push(context.Background(), r))
+
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/metrics/job/daggerx%20ci/instance/runner%2F1", path)
+	assert.Equal(t, ContentType, contentType)
+	assert.Equal(t, r.Text(), body)
+}
+
+func TestPusherErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "invalid metric", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	err := NewPusher(server.URL, "ci").Push(context.Background(), NewRegistry())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid metric")
+
+	require.Error(t, NewPusher("", "ci").Push(context.Background(), NewRegistry()))
+	require.Error(t, NewPusher(server.URL, "").Push(context.Background(), NewRegistry()))
+}
@@ -0,0 +1,168 @@
+// Package modulegen generates ready-to-use Dagger module skeletons that wrap the builders of this
+// module, so users of daggerx can bootstrap new modules that follow the package's conventions.
+//
+// The generated skeleton contains a dagger.json manifest and a main.go file with the module type,
+// its constructor, and functions wrapping the selected builder. Running `dagger develop` in the
+// generated directory produces the remaining SDK files.
+//
+// Example usage:
+//
+//	files, err := modulegen.Generate(modulegen.Options{
+//	    Name:    "wolfi-image",
+//	    Builder: modulegen.BuilderApko,
+//	})
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	if err := modulegen.WriteFiles("./wolfi-image", files); err != nil {
+//	    // handle error
+//	}
+package modulegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/Excoriate/daggerx/pkg/filesystemx"
+)
+
+// BuilderKind identifies the builder wrapped by the generated module.
+type BuilderKind string
+
+const (
+	// BuilderApko wraps apkox.ApkoBuilder.
+	BuilderApko BuilderKind = "apko"
+)
+
+const (
+	// DefaultEngineVersion is the Dagger engine version written to dagger.json when none is set.
+	DefaultEngineVersion = "v0.18.5"
+	// DefaultSDK is the SDK written to dagger.json.
+	DefaultSDK = "go"
+)
+
+// Options holds the settings of the generated module.
+type Options struct {
+	// Name is the module name, in lowercase kebab-case (e.g. "wolfi-image").
+	Name string
+	// Builder is the builder wrapped by the generated functions.
+	Builder BuilderKind
+	// EngineVersion is the Dagger engine version. Defaults to DefaultEngineVersion.
+	EngineVersion string
+}
+
+// File is a generated file, with a path relative to the module root.
+type File struct {
+	// Path is the path of the file relative to the module root.
+	Path string
+	// Content is the content of the file.
+	Content string
+}
+
+var moduleNameRegex = regexp.MustCompile(`^[a-z][a-z0-9]*(?:-[a-z0-9]+)*$`)
+
+// builderTemplates maps each supported builder to its main.go template.
+var builderTemplates = map[BuilderKind]string{
+	BuilderApko: apkoMainTemplate,
+}
+
+// Generate generates the files of a Dagger module skeleton.
+//
+// Parameters:
+//   - opts: The module settings.
+//
+// Returns:
+//   - The generated files, sorted by path.
+//   - An error if the name is invalid or the builder is not supported.
+func Generate(opts Options) ([]File, error) {
+	if !moduleNameRegex.MatchString(opts.Name) {
+		return nil, fmt.Errorf("invalid module name %q: expected lowercase kebab-case", opts.Name)
+	}
+
+	tmpl, ok := builderTemplates[opts.Builder]
+	if !ok {
+		return nil, fmt.Errorf("unsupported builder: %q", opts.Builder)
+	}
+
+	if opts.EngineVersion == "" {
+		opts.EngineVersion = DefaultEngineVersion
+	}
+
+	manifest, err := json.MarshalIndent(map[string]any{
+		"name":          opts.Name,
+		"engineVersion": opts.EngineVersion,
+		"sdk": map[string]string{
+			"source": DefaultSDK,
+		},
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to render dagger.json: %w", err)
+	}
+
+	t, err := template.New("main.go").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse main.go template: %w", err)
+	}
+
+	var mainGo bytes.Buffer
+	if err := t.Execute(&mainGo, map[string]string{
+		"Name": opts.Name,
+		"Type": ToTypeName(opts.Name),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to render main.go: %w", err)
+	}
+
+	return []File{
+		{Path: "dagger.json", Content: string(manifest) + "\n"},
+		{Path: "main.go", Content: mainGo.String()},
+	}, nil
+}
+
+// WriteFiles writes the generated files under the directory 'dir', creating it if needed.
+// Existing files are overwritten. File paths must be relative and stay under 'dir'.
+func WriteFiles(dir string, files []File) error {
+	if dir == "" {
+		return fmt.Errorf("output directory is required")
+	}
+
+	for _, f := range files {
+		if err := filesystemx.ValidateRelative(f.Path); err != nil {
+			return fmt.Errorf("invalid generated file path: %w", err)
+		}
+
+		path := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", f.Path, err)
+		}
+
+		if err := os.WriteFile(path, []byte(f.Content), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// ToTypeName converts a kebab-case module name into the Go type name Dagger expects.
+//
+// Example:
+//
+//	fmt.Println(ToTypeName("wolfi-image")) // Output: WolfiImage
+func ToTypeName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "-") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+
+	return b.String()
+}
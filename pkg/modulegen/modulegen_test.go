@@ -0,0 +1,94 @@
+package modulegen
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/filesystemx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	files, err := Generate(Options{Name: "wolfi-image", Builder: BuilderApko})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	assert.Equal(t, "dagger.json", files[0].Path)
+	assert.JSONEq(t, `{"name":"wolfi-image","engineVersion":"v0.18.5","sdk":{"source":"go"}}`, files[0].Content)
+
+	assert.Equal(t, "main.go", files[1].Path)
+	assert.Contains(t, files[1].Content, "type WolfiImage struct")
+	assert.Contains(t, files[1].Content, `"dagger/wolfi-image/internal/dagger"`)
+	assert.Contains(t, files[1].Content, `dag.CacheVolume("wolfi-image-apko-cache")`)
+	assert.Contains(t, files[1].Content, `tag = paramx.Or(tag, "latest")`)
+
+	_, err = parser.ParseFile(token.NewFileSet(), "main.go", files[1].Content, parser.AllErrors)
+	assert.NoError(t, err, "generated main.go must be valid Go")
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr string
+	}{
+		{
+			name:    "Empty name",
+			opts:    Options{Builder: BuilderApko},
+			wantErr: `invalid module name "": expected lowercase kebab-case`,
+		},
+		{
+			name:    "Invalid name",
+			opts:    Options{Name: "Wolfi_Image", Builder: BuilderApko},
+			wantErr: `invalid module name "Wolfi_Image": expected lowercase kebab-case`,
+		},
+		{
+			name:    "Unsupported builder",
+			opts:    Options{Name: "image", Builder: "docker"},
+			wantErr: `unsupported builder: "docker"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Generate(tt.opts)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestWriteFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "module")
+	files := []File{
+		{Path: "dagger.json", Content: "{}"},
+		{Path: "internal/doc.go", Content: "package internal"},
+	}
+
+	require.NoError(t, WriteFiles(dir, files))
+
+	content, err := os.ReadFile(filepath.Join(dir, "internal", "doc.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package internal", string(content))
+
+	assert.EqualError(t, WriteFiles("", files), "output directory is required")
+
+	err = WriteFiles(dir, []File{{Path: "../escape.go", Content: "package escape"}})
+	assert.ErrorIs(t, err, filesystemx.ErrPathTraversal)
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(dir), "escape.go"))
+}
+
+func TestToTypeName(t *testing.T) {
+	tests := map[string]string{
+		"wolfi-image": "WolfiImage",
+		"apko":        "Apko",
+		"my-apko-2":   "MyApko2",
+	}
+
+	for in, want := range tests {
+		assert.Equal(t, want, ToTypeName(in))
+	}
+}
@@ -0,0 +1,113 @@
+package modulegen
+
+// apkoMainTemplate is the main.go template of modules wrapping apkox.ApkoBuilder.
+const apkoMainTemplate = `// Package main implements the {{ .Name }} Dagger module, building OCI images with apko.
+package main
+
+import (
+	"context"
+	"dagger/{{ .Name }}/internal/dagger"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/paramx"
+)
+
+// {{ .Type }} builds OCI images with apko.
+type {{ .Type }} struct {
+	// Ctr is the container in which apko runs.
+	Ctr *dagger.Container
+}
+
+// New creates a new {{ .Type }} module.
+func New(
+	// image is the apko image used to run the builds.
+	// +optional
+	// +default="cgr.dev/chainguard/apko:latest"
+	image string,
+) *{{ .Type }} {
+	return &{{ .Type }}{
+		Ctr: dag.Container().From(image),
+	}
+}
+
+// Build builds the image described by the apko configuration file and returns the image tarball.
+func (m *{{ .Type }}) Build(
+	ctx context.Context,
+	// src is the directory containing the apko configuration file.
+	src *dagger.Directory,
+	// config is the path of the apko configuration file, relative to src, or the name of a
+	// preset ("wolfi-base", "alpine-base", "static").
+	// +optional
+	// +default="apko.yaml"
+	config string,
+	// image is the name of the output image.
+	image string,
+	// tag is the tag of the output image.
+	// +optional
+	// +default="latest"
+	tag string,
+	// arch is the architecture to build for.
+	// +optional
+	arch string,
+) (*dagger.File, error) {
+	// Dagger applies the defaults to calls through the engine only; Go callers may omit them.
+	config = paramx.Or(config, "apko.yaml")
+	tag = paramx.Or(tag, "latest")
+
+	cfgFile, cfgContent, err := apkox.ResolveApkoConfigOrPreset(fixtures.MntPrefix, config)
+	if err != nil {
+		return nil, err
+	}
+
+	outputTar := apkox.GetOutputTarPath(fixtures.MntPrefix)
+	builder := apkox.NewApkoBuilder().
+		WithConfigFile(cfgFile).
+		WithOutputImage(image).
+		WithTag(tag).
+		WithOutputTarball(outputTar).
+		WithCacheDir(apkox.GetCacheDir(fixtures.MntPrefix)).
+		WithKeyRingWolfi()
+	builder = paramx.Apply(builder, apkox.Architecture(arch), builder.WithBuildArch)
+
+	cmd, err := builder.BuildCommand()
+	if err != nil {
+		return nil, err
+	}
+
+	ctr := m.Ctr.
+		WithMountedDirectory(fixtures.MntPrefix, src).
+		WithMountedCache(apkox.GetCacheDir(fixtures.MntPrefix), dag.CacheVolume("{{ .Name }}-apko-cache"))
+
+	// Presets are materialized next to the mounted sources.
+	if cfgContent != nil {
+		ctr = ctr.WithNewFile(cfgFile, string(cfgContent))
+	}
+
+	return ctr.
+		WithWorkdir(fixtures.MntPrefix).
+		WithExec(cmd).
+		File(outputTar), nil
+}
+
+// BuildCommand returns the apko command the Build function runs, for troubleshooting.
+func (m *{{ .Type }}) BuildCommand(
+	// config is the path of the apko configuration file.
+	// +optional
+	// +default="apko.yaml"
+	config string,
+	// image is the name of the output image.
+	image string,
+	// tag is the tag of the output image.
+	// +optional
+	// +default="latest"
+	tag string,
+) ([]string, error) {
+	return apkox.NewApkoBuilder().
+		WithConfigFile(paramx.Or(config, "apko.yaml")).
+		WithOutputImage(image).
+		WithTag(paramx.Or(tag, "latest")).
+		WithOutputTarball(apkox.GetOutputTarPath(fixtures.MntPrefix)).
+		BuildCommand()
+}
+`
@@ -0,0 +1,240 @@
+// Package namingx generates deterministic names for images, cache volumes, Kubernetes resources
+// and artifacts from their components (e.g. project, environment, architecture, commit sha),
+// enforcing the length and charset constraints of the target: the OCI tag charset, the 63
+// characters of Kubernetes labels, the limits of cache keys.
+//
+// Components are sanitized (lowercased when the target requires it, disallowed characters
+// replaced with the separator) and joined with the separator. Names above the maximum length
+// are truncated and suffixed with a short hash of the full name, so distinct long names stay
+// distinct and the same components always produce the same name. Targets other than the
+// predefined ones are created with NewTarget.
+//
+// Example usage:
+//
+//	tag, err := namingx.Format(namingx.OCITag, "checkout", "staging", "arm64", metadata.ShortSHA)
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	key, err := namingx.Format(namingx.CacheKey, "checkout", "go-mod", goSumDigest)
+//	label, err := namingx.Format(namingx.KubernetesLabel, "feature/very-long-branch-name")
+package namingx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// builderName identifies the naming helpers in errorsx errors.
+const builderName = "naming"
+
+// HashLength is the length of the hash suffixing truncated names.
+const HashLength = 8
+
+// Target describes the constraints of the names of a kind of resource. Targets other than the
+// predefined ones are created with NewTarget.
+type Target struct {
+	// Name is the name of the target (e.g. "oci-tag").
+	Name string
+	// MaxLength is the maximum length of the names.
+	MaxLength int
+	// Lowercase lowercases the components.
+	Lowercase bool
+	// Separator joins the components, and replaces their disallowed characters.
+	Separator string
+
+	// invalid matches the runs of disallowed characters.
+	invalid *regexp.Regexp
+	// valid matches the valid names.
+	valid *regexp.Regexp
+}
+
+var (
+	// OCITag is the target of OCI image tags: up to 128 letters, digits, underscores, periods and
+	// dashes, not starting with a period or a dash.
+	OCITag = Target{
+		Name: "oci-tag", MaxLength: 128, Separator: "-",
+		invalid: regexp.MustCompile(`[^A-Za-z0-9_.-]+`),
+		valid:   regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`),
+	}
+	// OCIRepository is the target of a path component of OCI repository names: lowercase
+	// letters and digits, separated by periods, underscores or dashes.
+	OCIRepository = Target{
+		Name: "oci-repository", MaxLength: 128, Lowercase: true, Separator: "-",
+		invalid: regexp.MustCompile(`[^a-z0-9._-]+`),
+		valid:   regexp.MustCompile(`^[a-z0-9]+(?:[._-]+[a-z0-9]+)*$`),
+	}
+	// KubernetesLabel is the target of Kubernetes label values: up to 63 letters, digits,
+	// underscores, periods and dashes, starting and ending with a letter or digit.
+	KubernetesLabel = Target{
+		Name: "kubernetes-label", MaxLength: 63, Separator: "-",
+		invalid: regexp.MustCompile(`[^A-Za-z0-9_.-]+`),
+		valid:   regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9_.-]*[A-Za-z0-9])?$`),
+	}
+	// KubernetesName is the target of Kubernetes resource names that are DNS labels (RFC 1123):
+	// up to 63 lowercase letters, digits and dashes, starting and ending with a letter or digit.
+	KubernetesName = Target{
+		Name: "kubernetes-name", MaxLength: 63, Lowercase: true, Separator: "-",
+		invalid: regexp.MustCompile(`[^a-z0-9-]+`),
+		valid:   regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]*[a-z0-9])?$`),
+	}
+	// CacheKey is the target of cache volume and remote cache keys: up to 255 letters, digits,
+	// underscores, periods and dashes.
+	CacheKey = Target{
+		Name: "cache-key", MaxLength: 255, Separator: "-",
+		invalid: regexp.MustCompile(`[^A-Za-z0-9_.-]+`),
+		valid:   regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`),
+	}
+	// ArtifactName is the target of artifact file names: up to 255 letters, digits, underscores,
+	// periods and dashes, portable across file systems and object storages.
+	ArtifactName = Target{
+		Name: "artifact-name", MaxLength: 255, Separator: "-",
+		invalid: regexp.MustCompile(`[^A-Za-z0-9_.-]+`),
+		valid:   regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`),
+	}
+)
+
+// NewTarget creates the target 'name' whose names are made of the characters of the regular
+// expression character class 'charset' (e.g. "a-z0-9-"), joined with 'separator', up to
+// 'maxLength' characters. Set Lowercase on the returned target to lowercase the components.
+//
+// Returns:
+//   - The target.
+//   - An error if the name is empty, the charset is not a valid character class, the separator
+//     is empty or outside the charset, or the maximum length cannot hold the hash suffix of
+//     truncated names.
+func NewTarget(name, charset, separator string, maxLength int) (Target, error) {
+	if name == "" {
+		return Target{}, errorsx.MissingField(builderName, "target", "target name is required")
+	}
+
+	invalid, err := regexp.Compile("[^" + charset + "]+")
+	if err != nil || charset == "" {
+		return Target{}, errorsx.InvalidValue(builderName, "charset", charset,
+			"invalid charset %q for target %s: a regular expression character class is required", charset, name)
+	}
+
+	if separator == "" || invalid.MatchString(separator) {
+		return Target{}, errorsx.InvalidValue(builderName, "separator", separator,
+			"separator %q of target %s must be made of characters of its charset", separator, name)
+	}
+
+	t := Target{
+		Name: name, MaxLength: maxLength, Separator: separator,
+		invalid: invalid,
+		valid:   regexp.MustCompile("^[" + charset + "]+$"),
+	}
+	if err := t.check(); err != nil {
+		return Target{}, err
+	}
+
+	return t, nil
+}
+
+// check checks that the target was created by NewTarget, or is a predefined one, and that its
+// maximum length holds the hash suffix of truncated names.
+func (t Target) check() error {
+	if t.invalid == nil || t.valid == nil {
+		return errorsx.InvalidValue(builderName, "target", t.Name,
+			"target %q must be a predefined target or created with NewTarget", t.Name)
+	}
+
+	if t.MaxLength <= len(t.Separator)+HashLength {
+		return errorsx.InvalidValue(builderName, "maxLength", t.MaxLength,
+			"maximum length %d of target %s cannot hold the %d characters of the hash suffix",
+			t.MaxLength, t.Name, len(t.Separator)+HashLength)
+	}
+
+	return nil
+}
+
+// Format generates the name of the components 'components' for the target 't'. Empty components
+// are left out. Names above the maximum length of the target are truncated and suffixed with
+// the separator and the first HashLength characters of the SHA-256 of the full name.
+//
+// Returns:
+//   - The name.
+//   - An error if the target is invalid (see NewTarget), or no component has a valid character.
+func Format(t Target, components ...string) (string, error) {
+	if err := t.check(); err != nil {
+		return "", err
+	}
+
+	parts := make([]string, 0, len(components))
+	for _, c := range components {
+		if p := sanitize(t, c); p != "" {
+			parts = append(parts, p)
+		}
+	}
+
+	if len(parts) == 0 {
+		return "", errorsx.MissingField(builderName, "components",
+			"at least one component with a valid %s character is required", t.Name)
+	}
+
+	name := strings.Join(parts, t.Separator)
+	if len(name) <= t.MaxLength {
+		return name, nil
+	}
+
+	sum := sha256.Sum256([]byte(name))
+	suffix := t.Separator + hex.EncodeToString(sum[:])[:HashLength]
+
+	return trim(name[:t.MaxLength-len(suffix)]) + suffix, nil
+}
+
+// MustFormat is like Format but panics on error. It is meant for constant components.
+func MustFormat(t Target, components ...string) string {
+	name, err := Format(t, components...)
+	if err != nil {
+		panic(err)
+	}
+
+	return name
+}
+
+// Validate checks that 'name' satisfies the constraints of the target 't'.
+//
+// Returns:
+//   - An error if the target is invalid (see NewTarget), or the name is empty, too long, or has a
+//     disallowed character or boundary.
+func Validate(t Target, name string) error {
+	if err := t.check(); err != nil {
+		return err
+	}
+
+	if name == "" {
+		return errorsx.MissingField(builderName, "name", "%s is required", t.Name)
+	}
+
+	if len(name) > t.MaxLength {
+		return errorsx.InvalidValue(builderName, "name", name,
+			"%s is %d characters long, above the maximum of %d", t.Name, len(name), t.MaxLength)
+	}
+
+	if !t.valid.MatchString(name) {
+		return errorsx.InvalidValue(builderName, "name", name, "invalid %s: %q", t.Name, name)
+	}
+
+	return nil
+}
+
+// sanitize lowercases the component 'c' when the target requires it, replaces its runs of
+// disallowed characters with the separator, and trims the separators and punctuation at its
+// boundaries.
+func sanitize(t Target, c string) string {
+	if t.Lowercase {
+		c = strings.ToLower(c)
+	}
+
+	return trim(t.invalid.ReplaceAllString(c, t.Separator))
+}
+
+// trim removes the periods, underscores and dashes at the boundaries of 's'.
+func trim(s string) string {
+	return strings.Trim(s, "._-")
+}
@@ -0,0 +1,175 @@
+package namingx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     Target
+		components []string
+		want       string
+	}{
+		{
+			name:       "oci tag",
+			target:     OCITag,
+			components: []string{"checkout", "staging", "arm64", "a1b2c3d"},
+			want:       "checkout-staging-arm64-a1b2c3d",
+		},
+		{
+			name:       "oci tag keeps case and replaces invalid characters",
+			target:     OCITag,
+			components: []string{"Checkout", "feature/Login+SSO", "", "v1.2.0+build.1"},
+			want:       "Checkout-feature-Login-SSO-v1.2.0-build.1",
+		},
+		{
+			name:       "oci repository is lowercased",
+			target:     OCIRepository,
+			components: []string{"Acme", "Checkout API"},
+			want:       "acme-checkout-api",
+		},
+		{
+			name:       "kubernetes name drops periods and underscores",
+			target:     KubernetesName,
+			components: []string{"checkout_api", "v1.2"},
+			want:       "checkout-api-v1-2",
+		},
+		{
+			name:       "kubernetes label trims boundaries",
+			target:     KubernetesLabel,
+			components: []string{"-feature/", ".login."},
+			want:       "feature-login",
+		},
+		{
+			name:       "cache key",
+			target:     CacheKey,
+			components: []string{"checkout", "go mod", "sha256:abc"},
+			want:       "checkout-go-mod-sha256-abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format(tt.target, tt.components...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			require.NoError(t, Validate(tt.target, got))
+		})
+	}
+}
+
+func TestFormatTruncation(t *testing.T) {
+	branch := "feature/" + strings.Repeat("very-long-branch-name-", 5)
+
+	label, err := Format(KubernetesLabel, "checkout", branch)
+	require.NoError(t, err)
+	assert.Len(t, label, 63)
+	require.NoError(t, Validate(KubernetesLabel, label))
+	assert.True(t, strings.HasPrefix(label, "checkout-feature-very-long-branch-name-very-long-branc-"), label)
+
+	again, err := Format(KubernetesLabel, "checkout", branch)
+	require.NoError(t, err)
+	assert.Equal(t, label, again)
+
+	other, err := Format(KubernetesLabel, "checkout", branch+"x")
+	require.NoError(t, err)
+	assert.NotEqual(t, label, other)
+	assert.Equal(t, label[:len(label)-HashLength], other[:len(other)-HashLength])
+}
+
+func TestFormatTruncationTrimsBoundary(t *testing.T) {
+	// The truncated prefix ends with a separator, which is trimmed before the hash.
+	name, err := Format(KubernetesName, strings.Repeat("a", 53), "bcdefghijk")
+	require.NoError(t, err)
+	assert.Len(t, name, 62)
+	assert.Equal(t, strings.Repeat("a", 53)+"-", name[:54])
+	require.NoError(t, Validate(KubernetesName, name))
+}
+
+func TestFormatErrors(t *testing.T) {
+	_, err := Format(OCITag)
+	require.ErrorIs(t, err, errorsx.ErrMissingField)
+
+	_, err = Format(KubernetesName, "", "___", "/")
+	require.ErrorIs(t, err, errorsx.ErrMissingField)
+
+	assert.Panics(t, func() { MustFormat(OCITag, "") })
+	assert.Equal(t, "checkout", MustFormat(OCITag, "checkout"))
+}
+
+func TestNewTarget(t *testing.T) {
+	target, err := NewTarget("release-name", "a-z0-9.", ".", 24)
+	require.NoError(t, err)
+	target.Lowercase = true
+
+	name, err := Format(target, "Checkout", "API v2", strings.Repeat("x", 20))
+	require.NoError(t, err)
+	assert.Len(t, name, 24)
+	require.NoError(t, Validate(target, name))
+	assert.True(t, strings.HasPrefix(name, "checkout.api.v2."), name)
+
+	for _, tt := range []struct {
+		name      string
+		charset   string
+		separator string
+		maxLength int
+		wantErr   error
+	}{
+		{charset: "a-z", separator: "-", maxLength: 20, wantErr: errorsx.ErrMissingField},
+		{name: "empty charset", separator: "-", maxLength: 20, wantErr: errorsx.ErrInvalidValue},
+		{name: "invalid charset", charset: "z-a", separator: "-", maxLength: 20, wantErr: errorsx.ErrInvalidValue},
+		{name: "separator outside charset", charset: "a-z", separator: "-", maxLength: 20, wantErr: errorsx.ErrInvalidValue},
+		{name: "too short for the hash", charset: "a-z-", separator: "-", maxLength: 9, wantErr: errorsx.ErrInvalidValue},
+	} {
+		_, err := NewTarget(tt.name, tt.charset, tt.separator, tt.maxLength)
+		require.ErrorIs(t, err, tt.wantErr, tt.name)
+	}
+}
+
+func TestInvalidTarget(t *testing.T) {
+	short := KubernetesName
+	short.MaxLength = 4
+
+	for _, target := range []Target{{Name: "custom", MaxLength: 63, Separator: "-"}, short} {
+		_, err := Format(target, "checkout", "api")
+		require.ErrorIs(t, err, errorsx.ErrInvalidValue, target.Name)
+
+		require.ErrorIs(t, Validate(target, "checkout"), errorsx.ErrInvalidValue, target.Name)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  Target
+		value   string
+		wantErr error
+	}{
+		{name: "valid tag", target: OCITag, value: "v1.2.0"},
+		{name: "tag starting with an underscore", target: OCITag, value: "_latest"},
+		{name: "empty", target: OCITag, wantErr: errorsx.ErrMissingField},
+		{name: "tag starting with a dash", target: OCITag, value: "-latest", wantErr: errorsx.ErrInvalidValue},
+		{name: "tag too long", target: OCITag, value: strings.Repeat("a", 129), wantErr: errorsx.ErrInvalidValue},
+		{name: "uppercase kubernetes name", target: KubernetesName, value: "Checkout", wantErr: errorsx.ErrInvalidValue},
+		{name: "label ending with a period", target: KubernetesLabel, value: "v1.", wantErr: errorsx.ErrInvalidValue},
+		{name: "repository with double separator", target: OCIRepository, value: "acme--api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.target, tt.value)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,566 @@
+// Package notaryx provides builders for notation, the Notary Project tool signing and verifying
+// container images, for organizations standardized on Notary v2 signatures instead of Sigstore
+// (see cosignx).
+//
+// Notation reads its configuration from the directory named by NOTATION_CONFIG: the signing
+// keys (signingkeys.json), the trust policy (trustpolicy.json) and the trust stores holding the
+// trusted root certificates (truststore/x509/<type>/<name>/). The builders generate these files
+// and report them as mounts, with the environment pointing notation at them, so signing and
+// verification don't depend on the state of the container.
+//
+// Example usage:
+//
+//	sign := notaryx.NewSignBuilder("registry.example.com/app@sha256:...").
+//	    WithLocalKey("release", "/mnt/keys/release.key", "/mnt/keys/release.crt")
+//
+//	cmd, err := sign.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//	res, err := executor.Run(ctx, cmd, sign.Env(), sign.Mounts())
+//
+//	store := notaryx.TrustStore{Type: notaryx.StoreCA, Name: "acme", Certificates: []string{"./acme-root.crt"}}
+//	verify := notaryx.NewVerifyBuilder("registry.example.com/app@sha256:...").
+//	    WithTrustStores(store).
+//	    WithTrustPolicies(notaryx.NewTrustPolicy("acme", "registry.example.com/app").
+//	        WithTrustStores(store).
+//	        WithTrustedIdentities("x509.subject: C=US, O=Acme, CN=release"))
+package notaryx
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/url"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/mapx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the notation builders in errorsx errors.
+const builderName = "notation"
+
+// NotationDefaultImage is the default container image providing notation.
+const NotationDefaultImage = "ghcr.io/notaryproject/notation"
+
+// ConfigEnvVar is the environment variable notation reads its configuration directory from.
+const ConfigEnvVar = "NOTATION_CONFIG"
+
+// SignatureFormat is the envelope format of notation signatures.
+type SignatureFormat string
+
+const (
+	// FormatJWS is the JSON Web Signature envelope, the notation default.
+	FormatJWS SignatureFormat = "jws"
+	// FormatCOSE is the CBOR Object Signing and Encryption envelope.
+	FormatCOSE SignatureFormat = "cose"
+)
+
+// GetConfigDir returns the conventional notation configuration directory. If mntPrefix is
+// empty, fixtures.MntPrefix is used.
+func GetConfigDir(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, "notation")
+}
+
+// localKey is a signing key stored in files, registered in signingkeys.json.
+type localKey struct {
+	Name     string `json:"name"`
+	KeyPath  string `json:"keyPath"`
+	CertPath string `json:"certPath"`
+}
+
+// SignBuilder generates the notation sign command of an image.
+type SignBuilder struct {
+	// imageRef is the reference of the signed image.
+	imageRef string
+
+	// key is the name of a signing key of the notation configuration.
+	key string
+
+	// localKey is a signing key stored in files, registered under its name.
+	localKey *localKey
+
+	// plugin is the signing plugin (e.g. "com.amazonaws.signer.notation.plugin").
+	plugin string
+
+	// keyID is the identifier of the key of the plugin.
+	keyID string
+
+	// pluginConfig are the settings passed to the plugin.
+	pluginConfig map[string]string
+
+	// signatureFormat is the envelope format of the signature.
+	signatureFormat SignatureFormat
+
+	// userMetadata are the metadata added to the signature.
+	userMetadata map[string]string
+
+	// expiry is the validity of the signature. Zero means no expiry.
+	expiry time.Duration
+
+	// timestampURL is the RFC 3161 timestamping authority the signature is timestamped with.
+	timestampURL string
+
+	// timestampRootCert is the root certificate of the timestamping authority.
+	timestampRootCert string
+
+	// configDir is the notation configuration directory.
+	configDir string
+
+	// extraArgs are additional arguments appended before the image reference.
+	extraArgs []string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewSignBuilder creates a SignBuilder signing the image 'imageRef', which should be pinned by
+// digest.
+func NewSignBuilder(imageRef string) *SignBuilder {
+	return &SignBuilder{imageRef: imageRef, configDir: GetConfigDir("")}
+}
+
+// WithKey sets the name of the signing key, registered in the notation configuration.
+func (b *SignBuilder) WithKey(name string) *SignBuilder {
+	b.key = name
+	return b
+}
+
+// WithLocalKey signs with the private key at 'keyPath' and its certificate chain at
+// 'certPath', both paths inside the container (e.g. mounted with secretsx). The key is
+// registered as 'name' in the generated signingkeys.json.
+func (b *SignBuilder) WithLocalKey(name, keyPath, certPath string) *SignBuilder {
+	b.localKey = &localKey{Name: name, KeyPath: keyPath, CertPath: certPath}
+	return b
+}
+
+// WithPlugin signs with the key 'keyID' of the signing plugin 'plugin' (e.g. a KMS plugin).
+func (b *SignBuilder) WithPlugin(plugin, keyID string) *SignBuilder {
+	b.plugin = plugin
+	b.keyID = keyID
+	return b
+}
+
+// WithPluginConfig adds a setting passed to the signing plugin.
+func (b *SignBuilder) WithPluginConfig(key, value string) *SignBuilder {
+	if b.pluginConfig == nil {
+		b.pluginConfig = map[string]string{}
+	}
+	b.pluginConfig[key] = value
+
+	return b
+}
+
+// WithSignatureFormat sets the envelope format of the signature. It defaults to the notation
+// default, FormatJWS.
+func (b *SignBuilder) WithSignatureFormat(format SignatureFormat) *SignBuilder {
+	b.signatureFormat = format
+	return b
+}
+
+// WithUserMetadata adds metadata to the signature, which verification can require.
+func (b *SignBuilder) WithUserMetadata(key, value string) *SignBuilder {
+	if b.userMetadata == nil {
+		b.userMetadata = map[string]string{}
+	}
+	b.userMetadata[key] = value
+
+	return b
+}
+
+// WithExpiry sets the validity of the signature.
+func (b *SignBuilder) WithExpiry(expiry time.Duration) *SignBuilder {
+	b.expiry = expiry
+	return b
+}
+
+// WithTimestamp timestamps the signature with the RFC 3161 timestamping authority at 'tsaURL',
+// whose root certificate is at 'rootCert', so it stays valid after the certificate expires.
+func (b *SignBuilder) WithTimestamp(tsaURL, rootCert string) *SignBuilder {
+	b.timestampURL = tsaURL
+	b.timestampRootCert = rootCert
+	return b
+}
+
+// WithConfigDir sets the notation configuration directory. It defaults to GetConfigDir("").
+func (b *SignBuilder) WithConfigDir(dir string) *SignBuilder {
+	b.configDir = dir
+	return b
+}
+
+// WithExtraArg adds an argument appended before the image reference.
+func (b *SignBuilder) WithExtraArg(arg string) *SignBuilder {
+	b.extraArgs = append(b.extraArgs, arg)
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *SignBuilder) WithLogger(logger *slog.Logger) *SignBuilder {
+	b.logger = logger
+	return b
+}
+
+// Env returns the environment variables the generated command requires: NOTATION_CONFIG.
+func (b *SignBuilder) Env() []types.DaggerEnvVars {
+	return []types.DaggerEnvVars{{Name: ConfigEnvVar, Value: b.configDir}}
+}
+
+// Mounts returns the signingkeys.json file registering the local key, if set.
+func (b *SignBuilder) Mounts() []types.Mount {
+	if b.localKey == nil {
+		return nil
+	}
+
+	doc := struct {
+		Default string     `json:"default"`
+		Keys    []localKey `json:"keys"`
+	}{Default: b.localKey.Name, Keys: []localKey{*b.localKey}}
+
+	// The document only holds strings, so it always encodes.
+	data, _ := json.MarshalIndent(doc, "", "  ")
+
+	return []types.Mount{{
+		Kind:    types.MountKindInline,
+		Target:  path.Join(b.configDir, "signingkeys.json"),
+		Content: string(data),
+	}}
+}
+
+// signers returns the fields of the signing keys set.
+func (b *SignBuilder) signers() []string {
+	var fields []string
+	if b.key != "" {
+		fields = append(fields, "key")
+	}
+	if b.localKey != nil {
+		fields = append(fields, "localKey")
+	}
+	if b.plugin != "" || b.keyID != "" {
+		fields = append(fields, "plugin")
+	}
+
+	return fields
+}
+
+// validate checks that the builder configuration is complete and consistent.
+func (b *SignBuilder) validate() error {
+	if b.imageRef == "" {
+		return errorsx.MissingField(builderName, "imageRef", "image reference is required")
+	}
+
+	switch signers := b.signers(); {
+	case len(signers) == 0:
+		return errorsx.MissingField(builderName, "key", "a key, a local key or a plugin key is required")
+	case len(signers) > 1:
+		return errorsx.ConflictingOptions(builderName, signers, "only one signing key can be set")
+	}
+
+	if b.localKey != nil && (b.localKey.Name == "" || b.localKey.KeyPath == "" || b.localKey.CertPath == "") {
+		return errorsx.MissingField(builderName, "localKey", "local keys require a name, a key path and a certificate path")
+	}
+
+	if (b.plugin == "") != (b.keyID == "") {
+		return errorsx.MissingField(builderName, "plugin", "plugin keys require a plugin and a key identifier")
+	}
+
+	if len(b.pluginConfig) > 0 && b.plugin == "" {
+		return errorsx.MissingField(builderName, "plugin", "plugin settings require a plugin key")
+	}
+
+	switch b.signatureFormat {
+	case "", FormatJWS, FormatCOSE:
+	default:
+		return errorsx.Unsupported(builderName, "signatureFormat", b.signatureFormat,
+			"unsupported signature format: %q", b.signatureFormat)
+	}
+
+	if b.expiry < 0 {
+		return errorsx.InvalidValue(builderName, "expiry", b.expiry, "expiry must not be negative: %s", b.expiry)
+	}
+
+	if (b.timestampURL == "") != (b.timestampRootCert == "") {
+		return errorsx.MissingField(builderName, "timestamp",
+			"timestamping requires the authority URL and its root certificate")
+	}
+
+	if b.timestampURL != "" {
+		if u, err := url.Parse(b.timestampURL); err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+			return errorsx.InvalidValue(builderName, "timestampURL", b.timestampURL,
+				"timestamp URL must be an absolute HTTP(S) URL: %q", b.timestampURL)
+		}
+	}
+
+	return nil
+}
+
+// BuildCommand generates the notation sign command.
+//
+// Returns:
+//   - The notation sign command.
+//   - An error if the image reference or the signing key is missing, several signing keys are
+//     set, or an option is invalid.
+func (b *SignBuilder) BuildCommand() ([]string, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"notation", "sign"}
+
+	switch {
+	case b.key != "":
+		cmd = append(cmd, "--key", b.key)
+	case b.localKey != nil:
+		cmd = append(cmd, "--key", b.localKey.Name)
+	default:
+		cmd = append(cmd, "--plugin", b.plugin, "--id", b.keyID)
+	}
+
+	cmd = append(cmd, mapx.Flags("--plugin-config", b.pluginConfig, "=")...)
+
+	if b.signatureFormat != "" {
+		cmd = append(cmd, "--signature-format", string(b.signatureFormat))
+	}
+
+	cmd = append(cmd, mapx.Flags("--user-metadata", b.userMetadata, "=")...)
+
+	if b.expiry > 0 {
+		cmd = append(cmd, "--expiry", b.expiry.String())
+	}
+
+	if b.timestampURL != "" {
+		cmd = append(cmd, "--timestamp-url", b.timestampURL, "--timestamp-root-cert", b.timestampRootCert)
+	}
+
+	cmd = append(cmd, b.extraArgs...)
+	cmd = append(cmd, b.imageRef)
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// VerifyBuilder generates the notation verify command of an image.
+type VerifyBuilder struct {
+	// imageRef is the reference of the verified image.
+	imageRef string
+
+	// policies are the statements of the generated trust policy.
+	policies []*TrustPolicy
+
+	// stores are the trust stores mounted into the configuration directory.
+	stores []TrustStore
+
+	// userMetadata are the metadata the signature must hold.
+	userMetadata map[string]string
+
+	// pluginConfig are the settings passed to the verification plugin.
+	pluginConfig map[string]string
+
+	// configDir is the notation configuration directory.
+	configDir string
+
+	// extraArgs are additional arguments appended before the image reference.
+	extraArgs []string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewVerifyBuilder creates a VerifyBuilder verifying the image 'imageRef'.
+func NewVerifyBuilder(imageRef string) *VerifyBuilder {
+	return &VerifyBuilder{imageRef: imageRef, configDir: GetConfigDir("")}
+}
+
+// WithTrustPolicies adds statements to the generated trust policy. Without statements, the trust
+// policy of the configuration directory is used.
+func (b *VerifyBuilder) WithTrustPolicies(policies ...*TrustPolicy) *VerifyBuilder {
+	b.policies = append(b.policies, policies...)
+	return b
+}
+
+// WithTrustStores adds trust stores, whose certificates are mounted into the configuration
+// directory.
+func (b *VerifyBuilder) WithTrustStores(stores ...TrustStore) *VerifyBuilder {
+	b.stores = append(b.stores, stores...)
+	return b
+}
+
+// WithUserMetadata requires the signature to hold the metadata 'key' set to 'value'.
+func (b *VerifyBuilder) WithUserMetadata(key, value string) *VerifyBuilder {
+	if b.userMetadata == nil {
+		b.userMetadata = map[string]string{}
+	}
+	b.userMetadata[key] = value
+
+	return b
+}
+
+// WithPluginConfig adds a setting passed to the verification plugin.
+func (b *VerifyBuilder) WithPluginConfig(key, value string) *VerifyBuilder {
+	if b.pluginConfig == nil {
+		b.pluginConfig = map[string]string{}
+	}
+	b.pluginConfig[key] = value
+
+	return b
+}
+
+// WithConfigDir sets the notation configuration directory. It defaults to GetConfigDir("").
+func (b *VerifyBuilder) WithConfigDir(dir string) *VerifyBuilder {
+	b.configDir = dir
+	return b
+}
+
+// WithExtraArg adds an argument appended before the image reference.
+func (b *VerifyBuilder) WithExtraArg(arg string) *VerifyBuilder {
+	b.extraArgs = append(b.extraArgs, arg)
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *VerifyBuilder) WithLogger(logger *slog.Logger) *VerifyBuilder {
+	b.logger = logger
+	return b
+}
+
+// Env returns the environment variables the generated command requires: NOTATION_CONFIG.
+func (b *VerifyBuilder) Env() []types.DaggerEnvVars {
+	return []types.DaggerEnvVars{{Name: ConfigEnvVar, Value: b.configDir}}
+}
+
+// validate checks that the builder configuration is complete and consistent.
+func (b *VerifyBuilder) validate() error {
+	if b.imageRef == "" {
+		return errorsx.MissingField(builderName, "imageRef", "image reference is required")
+	}
+
+	refs := make([]string, 0, len(b.stores))
+	for _, s := range b.stores {
+		if err := s.Validate(); err != nil {
+			return err
+		}
+
+		if slices.Contains(refs, s.Ref()) {
+			return errorsx.InvalidValue(builderName, "trustStore", s.Ref(), "duplicate trust store %s", s.Ref())
+		}
+		refs = append(refs, s.Ref())
+	}
+
+	if len(b.policies) > 0 {
+		if _, err := TrustPolicyJSON(b.policies...); err != nil {
+			return err
+		}
+	}
+
+	if len(b.stores) == 0 {
+		return nil
+	}
+
+	for _, p := range b.policies {
+		for _, ref := range p.TrustStores {
+			if !slices.Contains(refs, ref) {
+				return errorsx.MissingField(builderName, "trustStore",
+					"trust policy %s references the trust store %s, which is not provided", p.Name, ref)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Mounts returns the files the generated command requires: the trust policy, when statements
+// are set, and the certificates of the trust stores. The files are left out when the
+// configuration is invalid, as BuildCommand then reports the error.
+func (b *VerifyBuilder) Mounts() []types.Mount {
+	if err := b.validate(); err != nil {
+		return nil
+	}
+
+	var mounts []types.Mount
+
+	if len(b.policies) > 0 {
+		policy, _ := TrustPolicyJSON(b.policies...)
+
+		mounts = append(mounts, types.Mount{
+			Kind:    types.MountKindInline,
+			Target:  path.Join(b.configDir, "trustpolicy.json"),
+			Content: string(policy),
+		})
+	}
+
+	for _, s := range b.stores {
+		mounts = append(mounts, s.Mounts(b.configDir)...)
+	}
+
+	return mounts
+}
+
+// BuildCommand generates the notation verify command.
+//
+// Returns:
+//   - The notation verify command.
+//   - An error if the image reference is missing, a trust store is invalid or duplicated, or a
+//     trust policy references a trust store which is not provided.
+func (b *VerifyBuilder) BuildCommand() ([]string, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"notation", "verify"}
+	cmd = append(cmd, mapx.Flags("--user-metadata", b.userMetadata, "=")...)
+	cmd = append(cmd, mapx.Flags("--plugin-config", b.pluginConfig, "=")...)
+	cmd = append(cmd, b.extraArgs...)
+	cmd = append(cmd, b.imageRef)
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *SignBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *VerifyBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the notation sign command.
+func (b *SignBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("notation sign", b, NewSignBuilder(""), explainx.Generate("BuildCommand", b.BuildCommand))
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the notation verify command.
+func (b *VerifyBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("notation verify", b, NewVerifyBuilder(""),
+		explainx.Generate("BuildCommand", b.BuildCommand))
+}
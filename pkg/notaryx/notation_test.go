@@ -0,0 +1,166 @@
+package notaryx
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const imageRef = "registry.example.com/app@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestSignBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *SignBuilder
+		want    []string
+	}{
+		{
+			name:    "Configured key",
+			builder: NewSignBuilder(imageRef).WithKey("release"),
+			want:    []string{"notation", "sign", "--key", "release", imageRef},
+		},
+		{
+			name: "Local key with options",
+			builder: NewSignBuilder(imageRef).
+				WithLocalKey("release", "/mnt/keys/release.key", "/mnt/keys/release.crt").
+				WithSignatureFormat(FormatCOSE).
+				WithUserMetadata("commit", "abc123").
+				WithUserMetadata("branch", "main").
+				WithExpiry(24*time.Hour).
+				WithTimestamp("https://timestamp.example.com", "/mnt/tsa-root.crt"),
+			want: []string{
+				"notation", "sign", "--key", "release",
+				"--signature-format", "cose",
+				"--user-metadata", "branch=main", "--user-metadata", "commit=abc123",
+				"--expiry", "24h0m0s",
+				"--timestamp-url", "https://timestamp.example.com", "--timestamp-root-cert", "/mnt/tsa-root.crt",
+				imageRef,
+			},
+		},
+		{
+			name: "Plugin key",
+			builder: NewSignBuilder(imageRef).
+				WithPlugin("com.amazonaws.signer.notation.plugin", "arn:aws:signer:us-east-1:123:/signing-profiles/release").
+				WithPluginConfig("aws-region", "us-east-1").
+				WithExtraArg("--verbose"),
+			want: []string{
+				"notation", "sign",
+				"--plugin", "com.amazonaws.signer.notation.plugin",
+				"--id", "arn:aws:signer:us-east-1:123:/signing-profiles/release",
+				"--plugin-config", "aws-region=us-east-1",
+				"--verbose", imageRef,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestSignBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *SignBuilder
+		want    error
+	}{
+		{name: "Missing image", builder: NewSignBuilder("").WithKey("release"), want: errorsx.ErrMissingField},
+		{name: "Missing key", builder: NewSignBuilder(imageRef), want: errorsx.ErrMissingField},
+		{name: "Several keys", builder: NewSignBuilder(imageRef).WithKey("a").WithPlugin("p", "id"), want: errorsx.ErrConflictingOptions},
+		{name: "Incomplete local key", builder: NewSignBuilder(imageRef).WithLocalKey("a", "/k", ""), want: errorsx.ErrMissingField},
+		{name: "Plugin without key", builder: NewSignBuilder(imageRef).WithPlugin("p", ""), want: errorsx.ErrMissingField},
+		{name: "Plugin config without plugin", builder: NewSignBuilder(imageRef).WithKey("a").WithPluginConfig("k", "v"), want: errorsx.ErrMissingField},
+		{name: "Unsupported format", builder: NewSignBuilder(imageRef).WithKey("a").WithSignatureFormat("pgp"), want: errorsx.ErrUnsupported},
+		{name: "Negative expiry", builder: NewSignBuilder(imageRef).WithKey("a").WithExpiry(-time.Hour), want: errorsx.ErrInvalidValue},
+		{name: "Timestamp without root", builder: NewSignBuilder(imageRef).WithKey("a").WithTimestamp("https://tsa", ""), want: errorsx.ErrMissingField},
+		{name: "Invalid timestamp URL", builder: NewSignBuilder(imageRef).WithKey("a").WithTimestamp("tsa", "/root.crt"), want: errorsx.ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.BuildCommand()
+			assert.True(t, errors.Is(err, tt.want), "BuildCommand() error = %v, want %v", err, tt.want)
+		})
+	}
+}
+
+func TestSignBuilderMountsAndEnv(t *testing.T) {
+	b := NewSignBuilder(imageRef).WithConfigDir("/cfg").WithLocalKey("release", "/mnt/release.key", "/mnt/release.crt")
+
+	assert.Equal(t, []types.DaggerEnvVars{{Name: ConfigEnvVar, Value: "/cfg"}}, b.Env())
+
+	mounts := b.Mounts()
+	require.Len(t, mounts, 1)
+	assert.Equal(t, types.MountKindInline, mounts[0].Kind)
+	assert.Equal(t, "/cfg/signingkeys.json", mounts[0].Target)
+	assert.JSONEq(t, `{"default":"release","keys":[{"name":"release","keyPath":"/mnt/release.key","certPath":"/mnt/release.crt"}]}`,
+		mounts[0].Content)
+
+	assert.Empty(t, NewSignBuilder(imageRef).WithKey("release").Mounts())
+}
+
+func TestVerifyBuilder(t *testing.T) {
+	store := TrustStore{Type: StoreCA, Name: "acme", Certificates: []string{"./certs/root.crt"}}
+	b := NewVerifyBuilder(imageRef).
+		WithConfigDir("/cfg").
+		WithTrustStores(store).
+		WithTrustPolicies(NewTrustPolicy("acme", "registry.example.com/app").
+			WithTrustStores(store).
+			WithTrustedIdentities("x509.subject: C=US, O=Acme, CN=release")).
+		WithUserMetadata("commit", "abc123")
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notation", "verify", "--user-metadata", "commit=abc123", imageRef}, cmd)
+
+	mounts := b.Mounts()
+	require.Len(t, mounts, 2)
+	assert.Equal(t, "/cfg/trustpolicy.json", mounts[0].Target)
+	assert.Equal(t, types.Mount{
+		Kind:     types.MountKindFile,
+		Source:   "./certs/root.crt",
+		Target:   "/cfg/truststore/x509/ca/acme/root.crt",
+		ReadOnly: true,
+	}, mounts[1])
+
+	var policy trustPolicyDocument
+	require.NoError(t, json.Unmarshal([]byte(mounts[0].Content), &policy))
+	assert.Equal(t, TrustPolicyVersion, policy.Version)
+	assert.Equal(t, []string{"ca:acme"}, policy.TrustPolicies[0].TrustStores)
+}
+
+func TestVerifyBuilderValidation(t *testing.T) {
+	store := TrustStore{Type: StoreCA, Name: "acme", Certificates: []string{"root.crt"}}
+	policy := NewTrustPolicy("other", "*").
+		WithTrustStores(TrustStore{Type: StoreCA, Name: "other"}).
+		WithTrustedIdentities("*")
+
+	_, err := NewVerifyBuilder("").BuildCommand()
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+
+	_, err = NewVerifyBuilder(imageRef).WithTrustStores(store, store).BuildCommand()
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+
+	invalid := NewVerifyBuilder(imageRef).WithTrustStores(store).WithTrustPolicies(policy)
+	_, err = invalid.BuildCommand()
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+	assert.Empty(t, invalid.Mounts())
+
+	_, err = NewVerifyBuilder(imageRef).WithTrustPolicies(policy, policy).BuildCommand()
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+
+	// Without trust stores, the stores of the configuration directory are used.
+	cmd, err := NewVerifyBuilder(imageRef).WithTrustPolicies(policy).BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notation", "verify", imageRef}, cmd)
+}
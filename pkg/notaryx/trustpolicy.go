@@ -0,0 +1,243 @@
+package notaryx
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// TrustPolicyVersion is the version of the trust policy documents written by TrustPolicy.JSON.
+const TrustPolicyVersion = "1.0"
+
+// Level is the signature verification level of a trust policy statement.
+type Level string
+
+const (
+	// LevelStrict enforces every verification check.
+	LevelStrict Level = "strict"
+	// LevelPermissive logs expiry and revocation failures instead of failing.
+	LevelPermissive Level = "permissive"
+	// LevelAudit only logs verification failures, once the signature is found.
+	LevelAudit Level = "audit"
+	// LevelSkip skips the verification.
+	LevelSkip Level = "skip"
+)
+
+// StoreType is the type of an X.509 trust store.
+type StoreType string
+
+const (
+	// StoreCA holds the root certificates of certificate authorities.
+	StoreCA StoreType = "ca"
+	// StoreSigningAuthority holds the root certificates of signing authorities.
+	StoreSigningAuthority StoreType = "signingAuthority"
+	// StoreTSA holds the root certificates of timestamping authorities.
+	StoreTSA StoreType = "tsa"
+)
+
+// TrustStore is a named set of root certificates notation trusts, laid out under the notation
+// configuration directory as truststore/x509/<type>/<name>/.
+type TrustStore struct {
+	// Type is the type of the store.
+	Type StoreType
+	// Name is the name of the store, referenced by trust policies as "<type>:<name>".
+	Name string
+	// Certificates are the host paths of the PEM or DER certificates of the store.
+	Certificates []string
+}
+
+// Ref returns the reference of the store in trust policies ("<type>:<name>").
+func (s TrustStore) Ref() string {
+	return string(s.Type) + ":" + s.Name
+}
+
+// Dir returns the directory of the store under the notation configuration directory 'configDir'.
+func (s TrustStore) Dir(configDir string) string {
+	return path.Join(configDir, "truststore", "x509", string(s.Type), s.Name)
+}
+
+// Validate checks that the store has a known type, a name and certificates.
+func (s TrustStore) Validate() error {
+	switch s.Type {
+	case StoreCA, StoreSigningAuthority, StoreTSA:
+	default:
+		return errorsx.Unsupported(builderName, "trustStore", s.Type, "unsupported trust store type: %q", s.Type)
+	}
+
+	if s.Name == "" || strings.ContainsAny(s.Name, `/\:`) {
+		return errorsx.InvalidValue(builderName, "trustStore", s.Name, "invalid trust store name: %q", s.Name)
+	}
+
+	if len(s.Certificates) == 0 {
+		return errorsx.MissingField(builderName, "trustStore", "trust store %s requires certificates", s.Ref())
+	}
+
+	return nil
+}
+
+// Mounts returns the read-only mounts of the certificates of the store under 'configDir'.
+func (s TrustStore) Mounts(configDir string) []types.Mount {
+	mounts := make([]types.Mount, 0, len(s.Certificates))
+	for _, cert := range s.Certificates {
+		mounts = append(mounts, types.Mount{
+			Kind:     types.MountKindFile,
+			Source:   cert,
+			Target:   path.Join(s.Dir(configDir), path.Base(cert)),
+			ReadOnly: true,
+		})
+	}
+
+	return mounts
+}
+
+// TrustPolicy is a statement of a notation trust policy: the signatures accepted for the
+// artifacts of its registry scopes.
+type TrustPolicy struct {
+	// Name is the name of the statement.
+	Name string `json:"name"`
+	// RegistryScopes are the repositories the statement applies to (e.g.
+	// "registry.example.com/app"), or "*" for every repository.
+	RegistryScopes []string `json:"registryScopes"`
+	// SignatureVerification is the verification level of the statement.
+	SignatureVerification SignatureVerification `json:"signatureVerification"`
+	// TrustStores are the references of the trust stores of the statement ("ca:acme").
+	TrustStores []string `json:"trustStores,omitempty"`
+	// TrustedIdentities are the identities of the accepted signing certificates (e.g.
+	// "x509.subject: C=US, O=Acme, CN=release"), or "*" for every identity of the trust stores.
+	TrustedIdentities []string `json:"trustedIdentities,omitempty"`
+}
+
+// SignatureVerification is the verification level of a trust policy statement.
+type SignatureVerification struct {
+	// Level is the verification level.
+	Level Level `json:"level"`
+	// Override changes the actions of the verification checks (e.g. "revocation": "log").
+	Override map[string]string `json:"override,omitempty"`
+}
+
+// NewTrustPolicy creates a strict trust policy statement 'name' for 'scopes'.
+func NewTrustPolicy(name string, scopes ...string) *TrustPolicy {
+	return &TrustPolicy{
+		Name:                  name,
+		RegistryScopes:        scopes,
+		SignatureVerification: SignatureVerification{Level: LevelStrict},
+	}
+}
+
+// WithLevel sets the verification level. It defaults to LevelStrict.
+func (p *TrustPolicy) WithLevel(level Level) *TrustPolicy {
+	p.SignatureVerification.Level = level
+	return p
+}
+
+// WithOverride changes the action of the verification check 'check' (e.g. "revocation") to
+// 'action' (e.g. "log").
+func (p *TrustPolicy) WithOverride(check, action string) *TrustPolicy {
+	if p.SignatureVerification.Override == nil {
+		p.SignatureVerification.Override = map[string]string{}
+	}
+	p.SignatureVerification.Override[check] = action
+
+	return p
+}
+
+// WithTrustStores adds trust stores to the statement.
+func (p *TrustPolicy) WithTrustStores(stores ...TrustStore) *TrustPolicy {
+	for _, s := range stores {
+		p.TrustStores = append(p.TrustStores, s.Ref())
+	}
+
+	return p
+}
+
+// WithTrustedIdentities adds identities of accepted signing certificates.
+func (p *TrustPolicy) WithTrustedIdentities(identities ...string) *TrustPolicy {
+	p.TrustedIdentities = append(p.TrustedIdentities, identities...)
+	return p
+}
+
+// Validate checks that the statement is complete and consistent.
+func (p *TrustPolicy) Validate() error {
+	if p.Name == "" {
+		return errorsx.MissingField(builderName, "trustPolicy", "trust policy name is required")
+	}
+
+	if len(p.RegistryScopes) == 0 {
+		return errorsx.MissingField(builderName, "trustPolicy", "trust policy %s requires registry scopes", p.Name)
+	}
+
+	if slices.Contains(p.RegistryScopes, "*") && len(p.RegistryScopes) > 1 {
+		return errorsx.InvalidValue(builderName, "trustPolicy", p.RegistryScopes,
+			"trust policy %s: the wildcard scope cannot be combined with other scopes", p.Name)
+	}
+
+	switch p.SignatureVerification.Level {
+	case LevelSkip:
+		if len(p.TrustStores) > 0 || len(p.TrustedIdentities) > 0 {
+			return errorsx.ConflictingOptions(builderName, []string{"level", "trustStores"},
+				"trust policy %s skips verification and cannot set trust stores or identities", p.Name)
+		}
+		return nil
+	case LevelStrict, LevelPermissive, LevelAudit:
+	default:
+		return errorsx.Unsupported(builderName, "level", p.SignatureVerification.Level,
+			"trust policy %s: unsupported verification level %q", p.Name, p.SignatureVerification.Level)
+	}
+
+	if len(p.TrustStores) == 0 || len(p.TrustedIdentities) == 0 {
+		return errorsx.MissingField(builderName, "trustPolicy",
+			"trust policy %s requires trust stores and trusted identities", p.Name)
+	}
+
+	return nil
+}
+
+// trustPolicyDocument is the trustpolicy.json document.
+type trustPolicyDocument struct {
+	Version       string         `json:"version"`
+	TrustPolicies []*TrustPolicy `json:"trustPolicies"`
+}
+
+// TrustPolicyJSON returns the trust policy document of 'policies'.
+//
+// Returns:
+//   - The indented JSON document.
+//   - An error if no policy is given, a policy is invalid, or two policies share a name or a
+//     registry scope.
+func TrustPolicyJSON(policies ...*TrustPolicy) ([]byte, error) {
+	if len(policies) == 0 {
+		return nil, errorsx.MissingField(builderName, "trustPolicy", "at least one trust policy is required")
+	}
+
+	names, scopes := map[string]bool{}, map[string]string{}
+	for _, p := range policies {
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+
+		if names[p.Name] {
+			return nil, errorsx.InvalidValue(builderName, "trustPolicy", p.Name, "duplicate trust policy %s", p.Name)
+		}
+		names[p.Name] = true
+
+		for _, s := range p.RegistryScopes {
+			if other, ok := scopes[s]; ok {
+				return nil, errorsx.ConflictingOptions(builderName, []string{other, p.Name},
+					"trust policies %s and %s share the registry scope %s", other, p.Name, s)
+			}
+			scopes[s] = p.Name
+		}
+	}
+
+	data, err := json.MarshalIndent(trustPolicyDocument{Version: TrustPolicyVersion, TrustPolicies: policies}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode trust policy: %w", err)
+	}
+
+	return data, nil
+}
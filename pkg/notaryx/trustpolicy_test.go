@@ -0,0 +1,78 @@
+package notaryx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustPolicyJSON(t *testing.T) {
+	store := TrustStore{Type: StoreCA, Name: "acme", Certificates: []string{"root.crt"}}
+
+	data, err := TrustPolicyJSON(
+		NewTrustPolicy("apps", "registry.example.com/app", "registry.example.com/api").
+			WithTrustStores(store).
+			WithTrustedIdentities("x509.subject: C=US, O=Acme, CN=release").
+			WithOverride("revocation", "log"),
+		NewTrustPolicy("others", "*").WithLevel(LevelSkip),
+	)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+  "version": "1.0",
+  "trustPolicies": [
+    {
+      "name": "apps",
+      "registryScopes": ["registry.example.com/app", "registry.example.com/api"],
+      "signatureVerification": {"level": "strict", "override": {"revocation": "log"}},
+      "trustStores": ["ca:acme"],
+      "trustedIdentities": ["x509.subject: C=US, O=Acme, CN=release"]
+    },
+    {
+      "name": "others",
+      "registryScopes": ["*"],
+      "signatureVerification": {"level": "skip"}
+    }
+  ]
+}`, string(data))
+}
+
+func TestTrustPolicyJSONErrors(t *testing.T) {
+	store := TrustStore{Type: StoreCA, Name: "acme", Certificates: []string{"root.crt"}}
+	valid := func(name string, scopes ...string) *TrustPolicy {
+		return NewTrustPolicy(name, scopes...).WithTrustStores(store).WithTrustedIdentities("*")
+	}
+
+	tests := []struct {
+		name     string
+		policies []*TrustPolicy
+		want     error
+	}{
+		{name: "No policies", want: errorsx.ErrMissingField},
+		{name: "Missing name", policies: []*TrustPolicy{valid("", "app")}, want: errorsx.ErrMissingField},
+		{name: "Missing scopes", policies: []*TrustPolicy{valid("a")}, want: errorsx.ErrMissingField},
+		{name: "Wildcard with scopes", policies: []*TrustPolicy{valid("a", "*", "app")}, want: errorsx.ErrInvalidValue},
+		{name: "Missing identities", policies: []*TrustPolicy{NewTrustPolicy("a", "app").WithTrustStores(store)}, want: errorsx.ErrMissingField},
+		{name: "Unsupported level", policies: []*TrustPolicy{valid("a", "app").WithLevel("lenient")}, want: errorsx.ErrUnsupported},
+		{name: "Skip with stores", policies: []*TrustPolicy{valid("a", "app").WithLevel(LevelSkip)}, want: errorsx.ErrConflictingOptions},
+		{name: "Duplicate name", policies: []*TrustPolicy{valid("a", "app"), valid("a", "api")}, want: errorsx.ErrInvalidValue},
+		{name: "Shared scope", policies: []*TrustPolicy{valid("a", "app"), valid("b", "app")}, want: errorsx.ErrConflictingOptions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := TrustPolicyJSON(tt.policies...)
+			assert.True(t, errors.Is(err, tt.want), "TrustPolicyJSON() error = %v, want %v", err, tt.want)
+		})
+	}
+}
+
+func TestTrustStoreValidate(t *testing.T) {
+	assert.NoError(t, TrustStore{Type: StoreTSA, Name: "tsa", Certificates: []string{"tsa.crt"}}.Validate())
+	assert.True(t, errors.Is(TrustStore{Type: "x509", Name: "a", Certificates: []string{"c"}}.Validate(), errorsx.ErrUnsupported))
+	assert.True(t, errors.Is(TrustStore{Type: StoreCA, Name: "a/b", Certificates: []string{"c"}}.Validate(), errorsx.ErrInvalidValue))
+	assert.True(t, errors.Is(TrustStore{Type: StoreCA, Name: "a"}.Validate(), errorsx.ErrMissingField))
+	assert.Equal(t, "/cfg/truststore/x509/signingAuthority/acme", TrustStore{Type: StoreSigningAuthority, Name: "acme"}.Dir("/cfg"))
+}
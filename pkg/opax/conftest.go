@@ -0,0 +1,327 @@
+// Package opax provides builders for the policy checks of configuration files with Open Policy
+// Agent: `conftest test` for the manifests, plans and charts rendered earlier in the pipeline,
+// and `opa eval` for queries against policy bundles. Both run with JSON output, which the
+// parsers turn into policy results so the pipeline gates on them (see policyx).
+//
+// Example usage:
+//
+//	conftest := opax.NewConftestBuilder("/mnt/rendered/deployment.yaml", "/mnt/plan.json").
+//	    WithPolicy("policy").
+//	    WithNamespace("kubernetes", "terraform").
+//	    WithFailOnWarn(true)
+//
+//	cmd, err := conftest.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	// Run cmd, then evaluate its standard output.
+//	result, err := conftest.Evaluate(stdout)
+//	if err != nil {
+//	    // handle error
+//	}
+//	if !result.Passed {
+//	    return result.Err()
+//	}
+package opax
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the policy check builders in errorsx errors.
+const builderName = "opa"
+
+// ConftestDefaultImage is the default container image providing conftest.
+const ConftestDefaultImage = "openpolicyagent/conftest:v0.56.0"
+
+const (
+	// RuleDeny reports a denied configuration (a conftest failure, or a message of a deny query).
+	RuleDeny policyx.Rule = "deny"
+	// RuleWarn reports a conftest warning, when warnings fail the check.
+	RuleWarn policyx.Rule = "warn"
+)
+
+// ConftestParsers are the input parsers of conftest.
+var ConftestParsers = []string{
+	"cue", "dockerfile", "edn", "hcl1", "hcl2", "hocon", "ignore", "ini", "json", "jsonnet",
+	"properties", "spdx", "textproto", "toml", "vcl", "xml", "yaml", "dotenv",
+}
+
+// ConftestMessage is a failure, warning or exception reported by conftest.
+type ConftestMessage struct {
+	// Msg is the message of the rule.
+	Msg string `json:"msg"`
+	// Metadata is the metadata the rule returns with the message, if any.
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// ConftestResult is the result of the policies of a namespace for a file.
+type ConftestResult struct {
+	// Filename is the name of the checked file.
+	Filename string `json:"filename"`
+	// Namespace is the namespace of the policies.
+	Namespace string `json:"namespace"`
+	// Successes is the number of passed rules.
+	Successes int `json:"successes"`
+	// Failures are the messages of the deny and violation rules.
+	Failures []ConftestMessage `json:"failures,omitempty"`
+	// Warnings are the messages of the warn rules.
+	Warnings []ConftestMessage `json:"warnings,omitempty"`
+	// Exceptions are the messages of the rules excepted by exception rules.
+	Exceptions []ConftestMessage `json:"exceptions,omitempty"`
+}
+
+// ConftestResults are the results of a conftest test run.
+type ConftestResults []ConftestResult
+
+// ParseConftestOutput parses the JSON output of conftest test (--output json).
+//
+// Returns:
+//   - The results, in output order.
+//   - An error if the output is not a conftest JSON output.
+func ParseConftestOutput(data []byte) (ConftestResults, error) {
+	var results ConftestResults
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("invalid conftest output: %w", err)
+	}
+
+	return results, nil
+}
+
+// Result evaluates the results: every failure is a RuleDeny violation and, with 'failOnWarn',
+// every warning a RuleWarn violation. Messages are prefixed with the file and namespace.
+func (r ConftestResults) Result(failOnWarn bool) policyx.Result {
+	result := policyx.Result{Passed: true}
+	for _, res := range r {
+		for _, m := range res.Failures {
+			result.Violations = append(result.Violations, policyx.Violation{
+				Rule:    RuleDeny,
+				Message: fmt.Sprintf("%s (%s): %s", res.Filename, res.Namespace, m.Msg),
+			})
+		}
+
+		if !failOnWarn {
+			continue
+		}
+
+		for _, m := range res.Warnings {
+			result.Violations = append(result.Violations, policyx.Violation{
+				Rule:    RuleWarn,
+				Message: fmt.Sprintf("%s (%s): %s", res.Filename, res.Namespace, m.Msg),
+			})
+		}
+	}
+
+	result.Passed = len(result.Violations) == 0
+
+	return result
+}
+
+// ConftestBuilder generates the conftest test command of configuration files.
+type ConftestBuilder struct {
+	// files are the checked files or directories.
+	files []string
+
+	// policies are the directories of the Rego policies.
+	policies []string
+
+	// namespaces are the namespaces of the policies evaluated.
+	namespaces []string
+
+	// allNamespaces evaluates the policies of every namespace.
+	allNamespaces bool
+
+	// data are the data files or directories of the policies.
+	data []string
+
+	// parser forces the parser of the files.
+	parser string
+
+	// combine evaluates the files together, as a single input.
+	combine bool
+
+	// failOnWarn fails the check on warnings.
+	failOnWarn bool
+
+	// updates are the URLs of the policy bundles downloaded before the check (e.g. OCI
+	// registries, git repositories).
+	updates []string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewConftestBuilder creates a ConftestBuilder checking 'files' against the policies in the
+// "policy" directory.
+func NewConftestBuilder(files ...string) *ConftestBuilder {
+	return &ConftestBuilder{files: files}
+}
+
+// WithPolicy adds directories of Rego policies. It defaults to "policy".
+func (b *ConftestBuilder) WithPolicy(dirs ...string) *ConftestBuilder {
+	b.policies = append(b.policies, dirs...)
+	return b
+}
+
+// WithNamespace adds namespaces of the policies to evaluate. It defaults to "main".
+func (b *ConftestBuilder) WithNamespace(namespaces ...string) *ConftestBuilder {
+	b.namespaces = append(b.namespaces, namespaces...)
+	return b
+}
+
+// WithAllNamespaces sets whether the policies of every namespace are evaluated.
+func (b *ConftestBuilder) WithAllNamespaces(all bool) *ConftestBuilder {
+	b.allNamespaces = all
+	return b
+}
+
+// WithData adds data files or directories of the policies.
+func (b *ConftestBuilder) WithData(paths ...string) *ConftestBuilder {
+	b.data = append(b.data, paths...)
+	return b
+}
+
+// WithParser forces the parser of the files, one of ConftestParsers. conftest otherwise infers
+// it from the file extensions.
+func (b *ConftestBuilder) WithParser(parser string) *ConftestBuilder {
+	b.parser = parser
+	return b
+}
+
+// WithCombine sets whether the files are evaluated together, as a single input.
+func (b *ConftestBuilder) WithCombine(combine bool) *ConftestBuilder {
+	b.combine = combine
+	return b
+}
+
+// WithFailOnWarn sets whether warnings fail the check.
+func (b *ConftestBuilder) WithFailOnWarn(fail bool) *ConftestBuilder {
+	b.failOnWarn = fail
+	return b
+}
+
+// WithUpdate adds policy bundles downloaded into the first policy directory before the check
+// (e.g. "oci://registry.example.com/policies:v1", "git::https://github.com/acme/policies.git").
+func (b *ConftestBuilder) WithUpdate(urls ...string) *ConftestBuilder {
+	b.updates = append(b.updates, urls...)
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *ConftestBuilder) WithLogger(logger *slog.Logger) *ConftestBuilder {
+	b.logger = logger
+	return b
+}
+
+// BuildCommand generates the conftest test command, with JSON output.
+//
+// Returns:
+//   - The conftest test command.
+//   - An error if no file is set, the parser is unsupported, or namespaces are combined with
+//     every namespace.
+func (b *ConftestBuilder) BuildCommand() ([]string, error) {
+	if len(b.files) == 0 {
+		return nil, errorsx.MissingField(builderName, "files", "at least one file is required")
+	}
+
+	if b.parser != "" && !slices.Contains(ConftestParsers, b.parser) {
+		return nil, errorsx.InvalidValue(builderName, "parser", b.parser, "unsupported conftest parser: %q", b.parser)
+	}
+
+	if b.allNamespaces && len(b.namespaces) > 0 {
+		return nil, errorsx.ConflictingOptions(builderName, []string{"namespaces", "allNamespaces"},
+			"namespaces and all namespaces are mutually exclusive")
+	}
+
+	cmd := []string{"conftest", "test"}
+	for _, p := range b.policies {
+		cmd = append(cmd, "--policy", p)
+	}
+
+	for _, ns := range b.namespaces {
+		cmd = append(cmd, "--namespace", ns)
+	}
+
+	if b.allNamespaces {
+		cmd = append(cmd, "--all-namespaces")
+	}
+
+	for _, d := range b.data {
+		cmd = append(cmd, "--data", d)
+	}
+
+	if b.parser != "" {
+		cmd = append(cmd, "--parser", b.parser)
+	}
+
+	if b.combine {
+		cmd = append(cmd, "--combine")
+	}
+
+	if b.failOnWarn {
+		cmd = append(cmd, "--fail-on-warn")
+	}
+
+	for _, u := range b.updates {
+		cmd = append(cmd, "--update", u)
+	}
+
+	cmd = append(cmd, "--output", "json", "--no-color")
+	cmd = append(cmd, b.files...)
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Evaluate parses the JSON output of the command and evaluates its results, failing on
+// warnings when the builder does.
+//
+// Returns:
+//   - The result of the evaluation.
+//   - An error if the output can't be parsed.
+func (b *ConftestBuilder) Evaluate(output []byte) (policyx.Result, error) {
+	results, err := ParseConftestOutput(output)
+	if err != nil {
+		return policyx.Result{}, err
+	}
+
+	return results.Result(b.failOnWarn), nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *ConftestBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *ConftestBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *ConftestBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the conftest test command.
+func (b *ConftestBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("conftest", b, NewConftestBuilder(), explainx.Generate("BuildCommand", b.BuildCommand))
+}
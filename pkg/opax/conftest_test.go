@@ -0,0 +1,117 @@
+package opax
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const conftestOutput = `[
+  {
+    "filename": "deployment.yaml",
+    "namespace": "kubernetes",
+    "successes": 4,
+    "failures": [{"msg": "containers must not run as root"}],
+    "warnings": [{"msg": "image tag should be pinned", "metadata": {"id": "K8S-001"}}]
+  },
+  {
+    "filename": "plan.json",
+    "namespace": "terraform",
+    "successes": 2
+  }
+]`
+
+func TestConftestBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *ConftestBuilder
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "defaults",
+			builder: NewConftestBuilder("deployment.yaml"),
+			want:    []string{"conftest", "test", "--output", "json", "--no-color", "deployment.yaml"},
+		},
+		{
+			name: "all options",
+			builder: NewConftestBuilder("/mnt/rendered", "/mnt/plan.json").
+				WithPolicy("policy", "vendor/policy").
+				WithNamespace("kubernetes", "terraform").
+				WithData("data.yaml").
+				WithParser("yaml").
+				WithCombine(true).
+				WithFailOnWarn(true).
+				WithUpdate("oci://registry.example.com/policies:v1"),
+			want: []string{
+				"conftest", "test", "--policy", "policy", "--policy", "vendor/policy",
+				"--namespace", "kubernetes", "--namespace", "terraform", "--data", "data.yaml",
+				"--parser", "yaml", "--combine", "--fail-on-warn",
+				"--update", "oci://registry.example.com/policies:v1",
+				"--output", "json", "--no-color", "/mnt/rendered", "/mnt/plan.json",
+			},
+		},
+		{
+			name:    "all namespaces",
+			builder: NewConftestBuilder("a.yaml").WithAllNamespaces(true),
+			want:    []string{"conftest", "test", "--all-namespaces", "--output", "json", "--no-color", "a.yaml"},
+		},
+		{name: "missing files", builder: NewConftestBuilder(), wantErr: errorsx.ErrMissingField},
+		{name: "unknown parser", builder: NewConftestBuilder("a.yaml").WithParser("csv"), wantErr: errorsx.ErrInvalidValue},
+		{
+			name:    "namespaces with all namespaces",
+			builder: NewConftestBuilder("a.yaml").WithNamespace("main").WithAllNamespaces(true),
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestParseConftestOutput(t *testing.T) {
+	results, err := ParseConftestOutput([]byte(conftestOutput))
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, 4, results[0].Successes)
+	assert.Equal(t, "K8S-001", results[0].Warnings[0].Metadata["id"])
+
+	assert.Equal(t, policyx.Result{
+		Passed: false,
+		Violations: []policyx.Violation{
+			{Rule: RuleDeny, Message: "deployment.yaml (kubernetes): containers must not run as root"},
+		},
+	}, results.Result(false))
+
+	assert.Len(t, results.Result(true).Violations, 2)
+	assert.Equal(t, RuleWarn, results.Result(true).Violations[1].Rule)
+
+	_, err = ParseConftestOutput([]byte(`{"filename": "a.yaml"}`))
+	assert.Error(t, err)
+}
+
+func TestConftestBuilderEvaluate(t *testing.T) {
+	result, err := NewConftestBuilder("a.yaml").WithFailOnWarn(true).Evaluate([]byte(conftestOutput))
+	require.NoError(t, err)
+	assert.Len(t, result.Violations, 2)
+
+	result, err = NewConftestBuilder("a.yaml").Evaluate([]byte(`[{"filename": "a.yaml", "namespace": "main", "successes": 1}]`))
+	require.NoError(t, err)
+	assert.True(t, result.Passed)
+
+	_, err = NewConftestBuilder("a.yaml").Evaluate([]byte(`not json`))
+	assert.Error(t, err)
+}
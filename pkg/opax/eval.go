@@ -0,0 +1,254 @@
+package opax
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// OPADefaultImage is the default container image providing opa.
+const OPADefaultImage = "openpolicyagent/opa:0.70.0-static"
+
+// EvalBuilder generates the opa eval command of a query.
+type EvalBuilder struct {
+	// query is the Rego query (e.g. "data.kubernetes.deny").
+	query string
+
+	// bundles are the policy bundles (directories or bundle tarballs).
+	bundles []string
+
+	// data are the policy or data files or directories.
+	data []string
+
+	// input is the input document file.
+	input string
+
+	// fail exits with an error when the result is undefined or empty.
+	fail bool
+
+	// failDefined exits with an error when the result is defined and not empty.
+	failDefined bool
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewEvalBuilder creates an EvalBuilder of the Rego query 'query' (e.g. "data.kubernetes.deny").
+func NewEvalBuilder(query string) *EvalBuilder {
+	return &EvalBuilder{query: query}
+}
+
+// WithBundle adds policy bundles: directories or bundle tarballs (e.g. downloaded with
+// `opa build` or from a bundle server).
+func (b *EvalBuilder) WithBundle(paths ...string) *EvalBuilder {
+	b.bundles = append(b.bundles, paths...)
+	return b
+}
+
+// WithData adds policy or data files or directories.
+func (b *EvalBuilder) WithData(paths ...string) *EvalBuilder {
+	b.data = append(b.data, paths...)
+	return b
+}
+
+// WithInput sets the input document file (e.g. a Terraform plan in JSON).
+func (b *EvalBuilder) WithInput(path string) *EvalBuilder {
+	b.input = path
+	return b
+}
+
+// WithFail sets whether the command fails when the result is undefined or empty.
+func (b *EvalBuilder) WithFail(fail bool) *EvalBuilder {
+	b.fail = fail
+	return b
+}
+
+// WithFailDefined sets whether the command fails when the result is defined and not empty, as
+// for deny queries.
+func (b *EvalBuilder) WithFailDefined(fail bool) *EvalBuilder {
+	b.failDefined = fail
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *EvalBuilder) WithLogger(logger *slog.Logger) *EvalBuilder {
+	b.logger = logger
+	return b
+}
+
+// BuildCommand generates the opa eval command, with JSON output.
+//
+// Returns:
+//   - The opa eval command.
+//   - An error if the query is missing, no bundle or data is set, or both fail modes are set.
+func (b *EvalBuilder) BuildCommand() ([]string, error) {
+	if strings.TrimSpace(b.query) == "" {
+		return nil, errorsx.MissingField(builderName, "query", "Rego query is required")
+	}
+
+	if len(b.bundles) == 0 && len(b.data) == 0 {
+		return nil, errorsx.MissingField(builderName, "bundles", "at least one bundle or data path is required")
+	}
+
+	if b.fail && b.failDefined {
+		return nil, errorsx.ConflictingOptions(builderName, []string{"fail", "failDefined"},
+			"fail and fail-defined are mutually exclusive")
+	}
+
+	cmd := []string{"opa", "eval", "--format", "json"}
+	for _, p := range b.bundles {
+		cmd = append(cmd, "--bundle", p)
+	}
+
+	for _, p := range b.data {
+		cmd = append(cmd, "--data", p)
+	}
+
+	if b.input != "" {
+		cmd = append(cmd, "--input", b.input)
+	}
+
+	if b.fail {
+		cmd = append(cmd, "--fail")
+	}
+
+	if b.failDefined {
+		cmd = append(cmd, "--fail-defined")
+	}
+
+	cmd = append(cmd, b.query)
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// ParseEvalOutput parses the JSON output of opa eval (--format json).
+//
+// Returns:
+//   - The values of the expressions of every result, in order. It is empty when the query is
+//     undefined.
+//   - An error if the output can't be parsed or reports evaluation errors.
+func ParseEvalOutput(data []byte) ([]any, error) {
+	var out struct {
+		Result []struct {
+			Expressions []struct {
+				Value any `json:"value"`
+			} `json:"expressions"`
+		} `json:"result"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("invalid opa eval output: %w", err)
+	}
+
+	if len(out.Errors) > 0 {
+		msgs := make([]string, len(out.Errors))
+		for i, e := range out.Errors {
+			msgs[i] = e.Message
+		}
+
+		return nil, fmt.Errorf("opa eval failed: %s", strings.Join(msgs, "; "))
+	}
+
+	var values []any
+	for _, r := range out.Result {
+		for _, e := range r.Expressions {
+			values = append(values, e.Value)
+		}
+	}
+
+	return values, nil
+}
+
+// EvaluateDeny parses the JSON output of opa eval of a deny query, whose value is a set of
+// messages (strings, or objects with a "msg" field), and reports every message as a RuleDeny
+// violation, sorted. An undefined or empty result passes.
+//
+// Returns:
+//   - The result of the evaluation.
+//   - An error if the output can't be parsed, reports evaluation errors, or the value is not a
+//     set of messages.
+func EvaluateDeny(data []byte) (policyx.Result, error) {
+	values, err := ParseEvalOutput(data)
+	if err != nil {
+		return policyx.Result{}, err
+	}
+
+	var msgs []string
+	for _, v := range values {
+		items, ok := v.([]any)
+		if !ok {
+			return policyx.Result{}, fmt.Errorf("deny query value must be a set of messages, got %T", v)
+		}
+
+		for _, item := range items {
+			msg, err := denyMessage(item)
+			if err != nil {
+				return policyx.Result{}, err
+			}
+			msgs = append(msgs, msg)
+		}
+	}
+
+	sort.Strings(msgs)
+
+	result := policyx.Result{Passed: len(msgs) == 0}
+	for _, m := range msgs {
+		result.Violations = append(result.Violations, policyx.Violation{Rule: RuleDeny, Message: m})
+	}
+
+	return result, nil
+}
+
+// denyMessage returns the message of an item of a deny set.
+func denyMessage(item any) (string, error) {
+	switch v := item.(type) {
+	case string:
+		return v, nil
+	case map[string]any:
+		if msg, ok := v["msg"].(string); ok {
+			return msg, nil
+		}
+	}
+
+	return "", fmt.Errorf("deny message must be a string or an object with a msg field, got %v", item)
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *EvalBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *EvalBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *EvalBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the opa eval command.
+func (b *EvalBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("opa eval", b, NewEvalBuilder(""), explainx.Generate("BuildCommand", b.BuildCommand))
+}
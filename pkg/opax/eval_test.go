@@ -0,0 +1,102 @@
+package opax
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvalBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *EvalBuilder
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "deny query",
+			builder: NewEvalBuilder("data.terraform.deny").
+				WithBundle("/mnt/policies").
+				WithData("exceptions.json").
+				WithInput("/mnt/plan.json").
+				WithFailDefined(true),
+			want: []string{
+				"opa", "eval", "--format", "json", "--bundle", "/mnt/policies", "--data", "exceptions.json",
+				"--input", "/mnt/plan.json", "--fail-defined", "data.terraform.deny",
+			},
+		},
+		{
+			name:    "allow query",
+			builder: NewEvalBuilder("data.authz.allow").WithData("policy.rego").WithFail(true),
+			want:    []string{"opa", "eval", "--format", "json", "--data", "policy.rego", "--fail", "data.authz.allow"},
+		},
+		{name: "missing query", builder: NewEvalBuilder(" ").WithData("p.rego"), wantErr: errorsx.ErrMissingField},
+		{name: "missing policies", builder: NewEvalBuilder("data.x"), wantErr: errorsx.ErrMissingField},
+		{
+			name:    "both fail modes",
+			builder: NewEvalBuilder("data.x").WithData("p.rego").WithFail(true).WithFailDefined(true),
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestParseEvalOutput(t *testing.T) {
+	values, err := ParseEvalOutput([]byte(`{"result": [{"expressions": [{"value": true, "text": "data.authz.allow"}]}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, []any{true}, values)
+
+	values, err = ParseEvalOutput([]byte(`{}`))
+	require.NoError(t, err)
+	assert.Empty(t, values)
+
+	_, err = ParseEvalOutput([]byte(`{"errors": [{"message": "rego_parse_error: unexpected eof"}]}`))
+	assert.EqualError(t, err, "opa eval failed: rego_parse_error: unexpected eof")
+
+	_, err = ParseEvalOutput([]byte(`[`))
+	assert.Error(t, err)
+}
+
+func TestEvaluateDeny(t *testing.T) {
+	result, err := EvaluateDeny([]byte(`{"result": [{"expressions": [{"value": [
+		"s3 bucket must be encrypted",
+		{"msg": "security group allows 0.0.0.0/0", "severity": "high"}
+	]}]}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, policyx.Result{
+		Passed: false,
+		Violations: []policyx.Violation{
+			{Rule: RuleDeny, Message: "s3 bucket must be encrypted"},
+			{Rule: RuleDeny, Message: "security group allows 0.0.0.0/0"},
+		},
+	}, result)
+
+	result, err = EvaluateDeny([]byte(`{"result": [{"expressions": [{"value": []}]}]}`))
+	require.NoError(t, err)
+	assert.True(t, result.Passed)
+
+	result, err = EvaluateDeny([]byte(`{}`))
+	require.NoError(t, err)
+	assert.True(t, result.Passed, "an undefined deny query passes")
+
+	_, err = EvaluateDeny([]byte(`{"result": [{"expressions": [{"value": true}]}]}`))
+	assert.Error(t, err)
+
+	_, err = EvaluateDeny([]byte(`{"result": [{"expressions": [{"value": [{"reason": "x"}]}]}]}`))
+	assert.Error(t, err)
+}
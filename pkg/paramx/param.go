@@ -0,0 +1,217 @@
+// Package paramx declares the optional parameters of Dagger module functions wrapping the builders
+// of this module: with their defaults and allowed values, as Dagger function parameters (the
+// +optional and +default pragmas), and as the Go statements applying the defaults when the
+// function is called from Go, where Dagger doesn't apply them.
+//
+// The runtime helpers (Or, Apply and Enum) replace the `if param == "" { param = default }`
+// boilerplate of module functions; Param renders the declarations and the boilerplate for code
+// generators such as modulegen.
+//
+// Example usage:
+//
+//	func (m *Module) Build(
+//	    // tag is the tag of the output image.
+//	    // +optional
+//	    // +default="latest"
+//	    tag string,
+//	    // arch is the architecture to build for.
+//	    // +optional
+//	    arch string,
+//	) ([]string, error) {
+//	    archs := paramx.NewEnum("arch", "", "x86_64", "aarch64")
+//	    if err := archs.ResolveInto(&arch); err != nil {
+//	        return nil, err
+//	    }
+//
+//	    builder := apkox.NewApkoBuilder().WithTag(paramx.Or(tag, "latest"))
+//	    builder = paramx.Apply(builder, apkox.Architecture(arch), builder.WithBuildArch)
+//	    // ...
+//	}
+package paramx
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// builderName identifies the parameters in errorsx errors.
+const builderName = "param"
+
+// Or returns 'value', or 'def' when 'value' is the zero value (e.g. an omitted string).
+func Or[T comparable](value, def T) T {
+	var zero T
+	if value == zero {
+		return def
+	}
+
+	return value
+}
+
+// OrSlice returns 'value', or 'def' when 'value' is empty.
+func OrSlice[T any](value, def []T) []T {
+	if len(value) == 0 {
+		return def
+	}
+
+	return value
+}
+
+// Apply calls the builder option 'opt' with 'value' unless it is the zero value, and returns the
+// builder it returns, or 'b' unchanged. It maps optional parameters without a default to builder
+// options: builder = paramx.Apply(builder, apkox.Architecture(arch), builder.WithBuildArch).
+func Apply[B any, T comparable](b B, value T, opt func(T) B) B {
+	var zero T
+	if value == zero {
+		return b
+	}
+
+	return opt(value)
+}
+
+// Enum is a string parameter accepting a fixed set of values.
+type Enum struct {
+	// Name is the name of the parameter, reported in errors.
+	Name string
+	// Values are the allowed values.
+	Values []string
+	// Default is the value of the omitted parameter. It may be empty, for no value.
+	Default string
+}
+
+// NewEnum creates an Enum named 'name' accepting 'values', with the default 'def'.
+func NewEnum(name, def string, values ...string) Enum {
+	return Enum{Name: name, Values: values, Default: def}
+}
+
+// Resolve returns 'value', or the default when it is empty.
+//
+// Returns:
+//   - The value of the parameter.
+//   - An error if the value is not one of the allowed values.
+func (e Enum) Resolve(value string) (string, error) {
+	value = Or(value, e.Default)
+	if value == "" || slices.Contains(e.Values, value) {
+		return value, nil
+	}
+
+	return "", errorsx.InvalidValue(builderName, e.Name, value,
+		"invalid %s %q: must be one of %s", e.Name, value, strings.Join(e.Values, ", "))
+}
+
+// ResolveInto replaces '*value' with its resolved value, see Resolve. '*value' is left unchanged
+// on error.
+func (e Enum) ResolveInto(value *string) error {
+	v, err := e.Resolve(*value)
+	if err != nil {
+		return err
+	}
+	*value = v
+
+	return nil
+}
+
+// Param declares a parameter of a Dagger module function.
+type Param struct {
+	// Name is the Go name of the parameter (e.g. "tag").
+	Name string
+	// Type is the Go type of the parameter. It defaults to "string".
+	Type string
+	// Doc is the documentation of the parameter, without comment markers. It may span lines.
+	Doc string
+	// Optional makes the parameter optional. Parameters with a default are optional.
+	Optional bool
+	// Default is the default value, as a Go literal of the parameter type without quotes for
+	// strings (e.g. "latest", "true", "3").
+	Default string
+	// Enum are the allowed values of string parameters.
+	Enum []string
+}
+
+// goType returns the Go type of the parameter.
+func (p Param) goType() string {
+	return Or(p.Type, "string")
+}
+
+// literal returns the Go literal of the default.
+func (p Param) literal() string {
+	if p.goType() == "string" {
+		return strconv.Quote(p.Default)
+	}
+
+	return p.Default
+}
+
+// Declaration renders the parameter as a Dagger function parameter: the documentation, the
+// +optional and +default pragmas, and the "name type," line, indented by a tab.
+func (p Param) Declaration() string {
+	var b strings.Builder
+
+	if p.Doc != "" {
+		for _, line := range strings.Split(strings.TrimSpace(p.Doc), "\n") {
+			b.WriteString(strings.TrimRight("\t// "+line, " ") + "\n")
+		}
+	}
+
+	if len(p.Enum) > 0 {
+		fmt.Fprintf(&b, "\t// One of: %s.\n", strings.Join(p.Enum, ", "))
+	}
+
+	if p.Optional || p.Default != "" {
+		b.WriteString("\t// +optional\n")
+	}
+
+	if p.Default != "" {
+		// Dagger reads +default values as JSON.
+		fmt.Fprintf(&b, "\t// +default=%s\n", p.literal())
+	}
+
+	fmt.Fprintf(&b, "\t%s %s,\n", p.Name, p.goType())
+
+	return b.String()
+}
+
+// Defaulting renders the Go statements applying the default of the parameter and checking its
+// allowed values, for functions returning an error last. It returns an empty string for
+// parameters without a default nor allowed values.
+func (p Param) Defaulting() string {
+	if len(p.Enum) > 0 {
+		quoted := make([]string, len(p.Enum))
+		for i, v := range p.Enum {
+			quoted[i] = strconv.Quote(v)
+		}
+
+		return fmt.Sprintf("\tif err := paramx.NewEnum(%q, %s, %s).ResolveInto(&%s); err != nil {\n"+
+			"\t\treturn nil, err\n\t}\n",
+			p.Name, p.literal(), strings.Join(quoted, ", "), p.Name)
+	}
+
+	if p.Default == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("\t%s = paramx.Or(%s, %s)\n", p.Name, p.Name, p.literal())
+}
+
+// Declarations renders the declarations of 'params', in order.
+func Declarations(params ...Param) string {
+	var b strings.Builder
+	for _, p := range params {
+		b.WriteString(p.Declaration())
+	}
+
+	return b.String()
+}
+
+// Defaultings renders the default handling of 'params', in order.
+func Defaultings(params ...Param) string {
+	var b strings.Builder
+	for _, p := range params {
+		b.WriteString(p.Defaulting())
+	}
+
+	return b.String()
+}
@@ -0,0 +1,131 @@
+package paramx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOr(t *testing.T) {
+	assert.Equal(t, "latest", Or("", "latest"))
+	assert.Equal(t, "v1", Or("v1", "latest"))
+	assert.Equal(t, 3, Or(0, 3))
+	assert.Equal(t, []string{"a"}, OrSlice(nil, []string{"a"}))
+	assert.Equal(t, []string{"b"}, OrSlice([]string{"b"}, []string{"a"}))
+}
+
+// recorder is a builder recording the values of its option.
+type recorder struct{ values []string }
+
+func (r *recorder) WithValue(v string) *recorder {
+	r.values = append(r.values, v)
+	return r
+}
+
+func TestApply(t *testing.T) {
+	r := &recorder{}
+
+	r = Apply(r, "", r.WithValue)
+	r = Apply(r, "x86_64", r.WithValue)
+
+	assert.Equal(t, []string{"x86_64"}, r.values)
+}
+
+func TestEnumResolve(t *testing.T) {
+	tests := []struct {
+		name    string
+		enum    Enum
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", enum: NewEnum("format", "json", "json", "table"), want: "json"},
+		{name: "allowed value", enum: NewEnum("format", "json", "json", "table"), value: "table", want: "table"},
+		{name: "no default", enum: NewEnum("arch", "", "x86_64"), want: ""},
+		{name: "invalid value", enum: NewEnum("format", "json", "json", "table"), value: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.enum.Resolve(tt.value)
+			if tt.wantErr {
+				require.ErrorIs(t, err, errorsx.ErrInvalidValue)
+				assert.ErrorContains(t, err, `invalid format "xml": must be one of json, table`)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEnumResolveInto(t *testing.T) {
+	formats := NewEnum("format", "json", "json", "table")
+
+	value := ""
+	require.NoError(t, formats.ResolveInto(&value))
+	assert.Equal(t, "json", value)
+
+	value = "xml"
+	require.Error(t, formats.ResolveInto(&value))
+	assert.Equal(t, "xml", value)
+}
+
+func TestParamDeclaration(t *testing.T) {
+	tests := []struct {
+		name  string
+		param Param
+		want  string
+	}{
+		{
+			name:  "required",
+			param: Param{Name: "image", Doc: "image is the name of the output image."},
+			want:  "\t// image is the name of the output image.\n\timage string,\n",
+		},
+		{
+			name:  "string default",
+			param: Param{Name: "tag", Doc: "tag is the tag.", Default: "latest"},
+			want:  "\t// tag is the tag.\n\t// +optional\n\t// +default=\"latest\"\n\ttag string,\n",
+		},
+		{
+			name:  "typed default",
+			param: Param{Name: "retries", Type: "int", Default: "3"},
+			want:  "\t// +optional\n\t// +default=3\n\tretries int,\n",
+		},
+		{
+			name:  "optional enum",
+			param: Param{Name: "arch", Doc: "arch is the architecture.\n", Optional: true, Enum: []string{"x86_64", "aarch64"}},
+			want:  "\t// arch is the architecture.\n\t// One of: x86_64, aarch64.\n\t// +optional\n\tarch string,\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.param.Declaration())
+		})
+	}
+}
+
+func TestParamDefaulting(t *testing.T) {
+	assert.Empty(t, Param{Name: "image"}.Defaulting())
+	assert.Equal(t, "\ttag = paramx.Or(tag, \"latest\")\n", Param{Name: "tag", Default: "latest"}.Defaulting())
+	assert.Equal(t, "\tretries = paramx.Or(retries, 3)\n",
+		Param{Name: "retries", Type: "int", Default: "3"}.Defaulting())
+	assert.Equal(t, "\tif err := paramx.NewEnum(\"format\", \"json\", \"json\", \"table\").ResolveInto(&format); "+
+		"err != nil {\n\t\treturn nil, err\n\t}\n",
+		Param{Name: "format", Default: "json", Enum: []string{"json", "table"}}.Defaulting())
+}
+
+func TestDeclarationsAndDefaultings(t *testing.T) {
+	params := []Param{
+		{Name: "image"},
+		{Name: "tag", Default: "latest"},
+	}
+
+	assert.Equal(t, "\timage string,\n\t// +optional\n\t// +default=\"latest\"\n\ttag string,\n",
+		Declarations(params...))
+	assert.Equal(t, "\ttag = paramx.Or(tag, \"latest\")\n", Defaultings(params...))
+}
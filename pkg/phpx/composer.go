@@ -0,0 +1,306 @@
+// Package phpx provides builders for the composer commands of PHP pipelines: installing the
+// dependencies of a composer.json with a cached composer cache directory, production installs
+// without development dependencies, and the platform requirements checked against the image.
+//
+// Example usage:
+//
+//	auth := secretsx.FromEnv("composer-auth", "COMPOSER_AUTH")
+//
+//	composer := phpx.NewComposerInstallBuilder().
+//	    WithWorkingDir("services/web").
+//	    WithNoDev(true).
+//	    WithOptimizeAutoloader(true).
+//	    WithIgnorePlatformReq("ext-gd").
+//	    WithAuth(auth)
+//
+//	cmd, err := composer.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	env, mounts := composer.Env(), composer.Mounts()
+package phpx
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"regexp"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the composer builder in errorsx errors.
+const builderName = "composer"
+
+// ComposerDefaultImage is the default container image providing composer.
+const ComposerDefaultImage = "composer:2"
+
+// CacheVolumeKey is the cache volume key of the composer cache directory.
+const CacheVolumeKey = "php-composer"
+
+// AuthEnvVar is the environment variable composer reads the JSON credentials of private
+// repositories from.
+const AuthEnvVar = "COMPOSER_AUTH"
+
+// platformReqRegex matches the platform requirements (e.g. "php", "ext-gd", "lib-icu", "ext-*").
+var platformReqRegex = regexp.MustCompile(
+	`^(php(-64bit|-ipv6|-zts|-debug)?|hhvm|composer(-plugin-api|-runtime-api)?|(ext|lib)-[A-Za-z0-9_.*-]+)$`)
+
+// GetCacheDir returns the conventional composer cache directory inside the container. If
+// mntPrefix is empty, fixtures.MntPrefix is used.
+func GetCacheDir(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, ".composer-cache")
+}
+
+// ComposerInstallBuilder generates the composer install command of a project. The command runs
+// without interaction or progress output, for CI logs.
+type ComposerInstallBuilder struct {
+	// workingDir is the directory of the composer.json. Empty uses the working directory.
+	workingDir string
+
+	// cacheDir is the composer cache directory (COMPOSER_CACHE_DIR), backed by a cache volume.
+	cacheDir string
+
+	// noDev skips the development dependencies.
+	noDev bool
+
+	// optimizeAutoloader generates a classmap autoloader.
+	optimizeAutoloader bool
+
+	// classmapAuthoritative loads classes from the classmap only.
+	classmapAuthoritative bool
+
+	// preferDist installs from distribution archives rather than sources.
+	preferDist bool
+
+	// noScripts skips the scripts of composer.json.
+	noScripts bool
+
+	// ignorePlatformReqs are the platform requirements not checked against the image.
+	ignorePlatformReqs []string
+
+	// ignoreAllPlatformReqs skips every platform requirement check.
+	ignoreAllPlatformReqs bool
+
+	// auth is the secret holding the COMPOSER_AUTH JSON credentials.
+	auth *secretsx.SecretRef
+
+	// extraArgs are additional arguments appended at the end of the command.
+	extraArgs []string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewComposerInstallBuilder creates a ComposerInstallBuilder caching the downloads in
+// GetCacheDir("").
+func NewComposerInstallBuilder() *ComposerInstallBuilder {
+	return &ComposerInstallBuilder{cacheDir: GetCacheDir("")}
+}
+
+// WithWorkingDir sets the directory of the composer.json (e.g. "services/web"), for monorepos
+// with several projects.
+func (b *ComposerInstallBuilder) WithWorkingDir(dir string) *ComposerInstallBuilder {
+	b.workingDir = dir
+	return b
+}
+
+// WithCacheDir sets the composer cache directory.
+func (b *ComposerInstallBuilder) WithCacheDir(dir string) *ComposerInstallBuilder {
+	b.cacheDir = dir
+	return b
+}
+
+// WithNoDev sets whether the development dependencies are skipped, for production images.
+func (b *ComposerInstallBuilder) WithNoDev(noDev bool) *ComposerInstallBuilder {
+	b.noDev = noDev
+	return b
+}
+
+// WithOptimizeAutoloader sets whether a classmap autoloader is generated.
+func (b *ComposerInstallBuilder) WithOptimizeAutoloader(optimize bool) *ComposerInstallBuilder {
+	b.optimizeAutoloader = optimize
+	return b
+}
+
+// WithClassmapAuthoritative sets whether classes are loaded from the classmap only. It implies
+// an optimized autoloader.
+func (b *ComposerInstallBuilder) WithClassmapAuthoritative(authoritative bool) *ComposerInstallBuilder {
+	b.classmapAuthoritative = authoritative
+	return b
+}
+
+// WithPreferDist sets whether packages are installed from distribution archives, which the
+// cache directory keeps.
+func (b *ComposerInstallBuilder) WithPreferDist(preferDist bool) *ComposerInstallBuilder {
+	b.preferDist = preferDist
+	return b
+}
+
+// WithNoScripts sets whether the scripts of composer.json are skipped.
+func (b *ComposerInstallBuilder) WithNoScripts(noScripts bool) *ComposerInstallBuilder {
+	b.noScripts = noScripts
+	return b
+}
+
+// WithIgnorePlatformReq adds platform requirements (e.g. "ext-gd", "php") not checked against
+// the image installing the dependencies, when the runtime image provides them.
+func (b *ComposerInstallBuilder) WithIgnorePlatformReq(reqs ...string) *ComposerInstallBuilder {
+	b.ignorePlatformReqs = append(b.ignorePlatformReqs, reqs...)
+	return b
+}
+
+// WithIgnorePlatformReqs sets whether every platform requirement check is skipped.
+func (b *ComposerInstallBuilder) WithIgnorePlatformReqs(ignore bool) *ComposerInstallBuilder {
+	b.ignoreAllPlatformReqs = ignore
+	return b
+}
+
+// WithAuth uses the JSON credentials of private repositories held by 'ref'. The secret is
+// exposed as AuthEnvVar, so the credentials never appear in the command or auth.json; 'ref' is
+// left unchanged.
+func (b *ComposerInstallBuilder) WithAuth(ref *secretsx.SecretRef) *ComposerInstallBuilder {
+	if ref == nil {
+		b.auth = nil
+		return b
+	}
+
+	auth := *ref
+	b.auth = auth.AsEnv(AuthEnvVar)
+	return b
+}
+
+// WithExtraArg adds an argument appended at the end of the command.
+func (b *ComposerInstallBuilder) WithExtraArg(arg string) *ComposerInstallBuilder {
+	b.extraArgs = append(b.extraArgs, arg)
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *ComposerInstallBuilder) WithLogger(logger *slog.Logger) *ComposerInstallBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks that the builder configuration is consistent.
+func (b *ComposerInstallBuilder) validate() error {
+	if b.ignoreAllPlatformReqs && len(b.ignorePlatformReqs) > 0 {
+		return errorsx.ConflictingOptions(builderName, []string{"ignorePlatformReqs", "ignorePlatformReq"},
+			"ignoring every platform requirement makes the ignored requirements redundant")
+	}
+
+	for _, r := range b.ignorePlatformReqs {
+		if !platformReqRegex.MatchString(r) {
+			return errorsx.InvalidValue(builderName, "ignorePlatformReq", r, "invalid platform requirement: %q", r)
+		}
+	}
+
+	if b.auth != nil {
+		if err := b.auth.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BuildCommand generates the composer install command.
+//
+// Returns:
+//   - The composer install command.
+//   - An error if a platform requirement or the auth secret is invalid, or all and specific
+//     platform requirements are ignored.
+func (b *ComposerInstallBuilder) BuildCommand() ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"composer", "install", "--no-interaction", "--no-progress"}
+	if b.workingDir != "" {
+		cmd = append(cmd, "--working-dir", b.workingDir)
+	}
+
+	flags := []struct {
+		flag string
+		set  bool
+	}{
+		{"--no-dev", b.noDev},
+		{"--optimize-autoloader", b.optimizeAutoloader && !b.classmapAuthoritative},
+		{"--classmap-authoritative", b.classmapAuthoritative},
+		{"--prefer-dist", b.preferDist},
+		{"--no-scripts", b.noScripts},
+		{"--ignore-platform-reqs", b.ignoreAllPlatformReqs},
+	}
+	for _, f := range flags {
+		if f.set {
+			cmd = append(cmd, f.flag)
+		}
+	}
+
+	for _, r := range b.ignorePlatformReqs {
+		cmd = append(cmd, "--ignore-platform-req="+r)
+	}
+
+	cmd = append(cmd, b.extraArgs...)
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Env returns the environment variables of the command: the composer cache directory, and
+// COMPOSER_ALLOW_SUPERUSER, as containers run composer as root. The credentials are a secret
+// (see Secrets).
+func (b *ComposerInstallBuilder) Env() []types.DaggerEnvVars {
+	env := []types.DaggerEnvVars{{Name: "COMPOSER_ALLOW_SUPERUSER", Value: "1"}}
+	if b.cacheDir != "" {
+		env = append(env, types.DaggerEnvVars{Name: "COMPOSER_CACHE_DIR", Value: b.cacheDir})
+	}
+
+	return env
+}
+
+// Secrets returns the secrets the command requires: the COMPOSER_AUTH credentials, if any.
+func (b *ComposerInstallBuilder) Secrets() []*secretsx.SecretRef {
+	if b.auth == nil {
+		return nil
+	}
+
+	return []*secretsx.SecretRef{b.auth}
+}
+
+// Mounts returns the cache volume mount of the composer cache directory, keyed CacheVolumeKey.
+func (b *ComposerInstallBuilder) Mounts() []types.Mount {
+	if b.cacheDir == "" {
+		return nil
+	}
+
+	return []types.Mount{{Kind: types.MountKindCache, Source: CacheVolumeKey, Target: b.cacheDir}}
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *ComposerInstallBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the composer install command.
+func (b *ComposerInstallBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewComposerInstallBuilder(),
+		explainx.Generate("BuildCommand", b.BuildCommand))
+}
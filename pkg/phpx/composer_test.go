@@ -0,0 +1,102 @@
+package phpx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCacheDir(t *testing.T) {
+	assert.Equal(t, "/mnt/.composer-cache", GetCacheDir(""))
+	assert.Equal(t, "/src/.composer-cache", GetCacheDir("/src"))
+}
+
+func TestComposerInstallBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  *ComposerInstallBuilder
+		expected []string
+	}{
+		{
+			name:     "Defaults",
+			builder:  NewComposerInstallBuilder(),
+			expected: []string{"composer", "install", "--no-interaction", "--no-progress"},
+		},
+		{
+			name: "Production",
+			builder: NewComposerInstallBuilder().
+				WithWorkingDir("services/web").
+				WithNoDev(true).
+				WithOptimizeAutoloader(true).
+				WithPreferDist(true).
+				WithNoScripts(true).
+				WithIgnorePlatformReq("ext-gd", "php").
+				WithExtraArg("--audit"),
+			expected: []string{
+				"composer", "install", "--no-interaction", "--no-progress", "--working-dir", "services/web",
+				"--no-dev", "--optimize-autoloader", "--prefer-dist", "--no-scripts",
+				"--ignore-platform-req=ext-gd", "--ignore-platform-req=php", "--audit",
+			},
+		},
+		{
+			name:     "Classmap authoritative",
+			builder:  NewComposerInstallBuilder().WithOptimizeAutoloader(true).WithClassmapAuthoritative(true).WithIgnorePlatformReqs(true),
+			expected: []string{"composer", "install", "--no-interaction", "--no-progress", "--classmap-authoritative", "--ignore-platform-reqs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cmd)
+		})
+	}
+}
+
+func TestComposerInstallBuilderEnv(t *testing.T) {
+	auth := secretsx.FromEnv("composer-auth", "CI_COMPOSER_AUTH")
+	b := NewComposerInstallBuilder().WithAuth(auth)
+
+	assert.Equal(t, []types.DaggerEnvVars{
+		{Name: "COMPOSER_ALLOW_SUPERUSER", Value: "1"},
+		{Name: "COMPOSER_CACHE_DIR", Value: "/mnt/.composer-cache"},
+	}, b.Env())
+	assert.Equal(t, []types.Mount{
+		{Kind: types.MountKindCache, Source: CacheVolumeKey, Target: "/mnt/.composer-cache"},
+	}, b.Mounts())
+
+	secrets := b.Secrets()
+	require.Len(t, secrets, 1)
+	assert.Equal(t, AuthEnvVar, secrets[0].Target)
+	assert.Equal(t, "CI_COMPOSER_AUTH", auth.Target, "the secret reference must be left unchanged")
+
+	b.WithCacheDir("").WithAuth(nil)
+	assert.Nil(t, b.Mounts())
+	assert.Nil(t, b.Secrets())
+}
+
+func TestComposerInstallBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *ComposerInstallBuilder
+		kind    error
+	}{
+		{"All and specific requirements", NewComposerInstallBuilder().WithIgnorePlatformReqs(true).WithIgnorePlatformReq("ext-gd"), errorsx.ErrConflictingOptions},
+		{"Invalid requirement", NewComposerInstallBuilder().WithIgnorePlatformReq("gd"), errorsx.ErrInvalidValue},
+		{"Invalid auth", NewComposerInstallBuilder().WithAuth(&secretsx.SecretRef{Name: "composer-auth"}), errorsx.ErrUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.BuildCommand()
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.kind), "expected %v, got %v", tt.kind, err)
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package pipeline
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultRunsOn is the runner of the exported GitHub Actions jobs.
+	DefaultRunsOn = "ubuntu-latest"
+	// checkoutAction checks out the repository in GitHub Actions jobs.
+	checkoutAction = "actions/checkout@v4"
+	// uploadArtifactAction uploads the artifacts of a GitHub Actions job.
+	uploadArtifactAction = "actions/upload-artifact@v4"
+	// downloadArtifactAction downloads the artifacts of another GitHub Actions job.
+	downloadArtifactAction = "actions/download-artifact@v4"
+)
+
+// invalidJobIDChars matches the characters GitHub Actions job identifiers cannot hold.
+var invalidJobIDChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// invalidEnvChars matches the characters CI variable names cannot hold.
+var invalidEnvChars = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// GitHubActionsOpts configures ExportGitHubActions.
+type GitHubActionsOpts struct {
+	// RunsOn is the runner of the jobs. Defaults to DefaultRunsOn.
+	RunsOn string
+	// Triggers are the events triggering the workflow. Defaults to workflow_dispatch.
+	Triggers []string
+}
+
+// GitLabCIOpts configures ExportGitLabCI.
+type GitLabCIOpts struct {
+	// Image is the image the jobs run in. The runner default is used when empty.
+	Image string
+	// Tags select the runners of the jobs.
+	Tags []string
+}
+
+// githubWorkflow is the GitHub Actions workflow written by ExportGitHubActions.
+type githubWorkflow struct {
+	Name string                `yaml:"name"`
+	On   map[string]any        `yaml:"on"`
+	Jobs map[string]*githubJob `yaml:"jobs"`
+}
+
+type githubJob struct {
+	Name   string            `yaml:"name"`
+	RunsOn string            `yaml:"runs-on"`
+	Needs  []string          `yaml:"needs,omitempty"`
+	Env    map[string]string `yaml:"env,omitempty"`
+	Steps  []githubStep      `yaml:"steps"`
+}
+
+type githubStep struct {
+	Name string            `yaml:"name,omitempty"`
+	Uses string            `yaml:"uses,omitempty"`
+	With map[string]string `yaml:"with,omitempty"`
+	Run  string            `yaml:"run,omitempty"`
+}
+
+// gitlabJob is a job of the GitLab CI configuration written by ExportGitLabCI.
+type gitlabJob struct {
+	Stage     string            `yaml:"stage"`
+	Image     string            `yaml:"image,omitempty"`
+	Tags      []string          `yaml:"tags,omitempty"`
+	Needs     []string          `yaml:"needs"`
+	Variables map[string]string `yaml:"variables,omitempty"`
+	Script    []string          `yaml:"script"`
+	Artifacts *gitlabArtifacts  `yaml:"artifacts,omitempty"`
+}
+
+type gitlabArtifacts struct {
+	Paths []string `yaml:"paths"`
+}
+
+// ExportGitHubActions exports the pipeline as a GitHub Actions workflow skeleton: one job per
+// node, chained by `needs`, running the node command after a checkout. Node artifacts are
+// uploaded under the job identifier and downloaded by the dependent jobs, and secrets are read
+// from repository secrets named by SecretEnvName.
+//
+// Mounts are not translated, since runners don't share the container filesystem layout; the
+// skeleton is a starting point to complete by hand. Secrets registered with the default logx
+// redactor are scrubbed from the output.
+//
+// Returns:
+//   - The workflow YAML.
+//   - An error if the pipeline is invalid, or two node identifiers map to the same job.
+func (p *Pipeline) ExportGitHubActions(opts GitHubActionsOpts) ([]byte, error) {
+	plan, err := p.Plan()
+	if err != nil {
+		return nil, err
+	}
+
+	runsOn := opts.RunsOn
+	if runsOn == "" {
+		runsOn = DefaultRunsOn
+	}
+
+	triggers := opts.Triggers
+	if len(triggers) == 0 {
+		triggers = []string{"workflow_dispatch"}
+	}
+
+	wf := githubWorkflow{Name: plan.Name, On: map[string]any{}, Jobs: map[string]*githubJob{}}
+	for _, t := range triggers {
+		wf.On[t] = map[string]any{}
+	}
+
+	jobIDs := map[string]string{}
+	for _, n := range plan.Nodes {
+		id := githubJobID(n.ID)
+		for other, otherID := range jobIDs {
+			if otherID == id {
+				return nil, fmt.Errorf("nodes %s and %s both map to the GitHub Actions job %s",
+					other, n.ID, id)
+			}
+		}
+		jobIDs[n.ID] = id
+	}
+
+	for _, n := range plan.Nodes {
+		job := &githubJob{
+			Name:   n.ID,
+			RunsOn: runsOn,
+			Env:    nodeEnv(n, func(env string) string { return "${{ secrets." + env + " }}" }),
+			Steps:  []githubStep{{Uses: checkoutAction}},
+		}
+
+		for _, dep := range n.DependsOn {
+			job.Needs = append(job.Needs, jobIDs[dep])
+
+			if d, _ := p.Node(dep); len(d.Artifacts) > 0 {
+				job.Steps = append(job.Steps, githubStep{
+					Name: "Download " + dep + " artifacts",
+					Uses: downloadArtifactAction,
+					With: map[string]string{"name": jobIDs[dep]},
+				})
+			}
+		}
+
+		job.Steps = append(job.Steps, githubStep{Name: n.ID, Run: cmdx.ShellJoin(n.Command)})
+
+		if len(n.Artifacts) > 0 {
+			job.Steps = append(job.Steps, githubStep{
+				Name: "Upload artifacts",
+				Uses: uploadArtifactAction,
+				With: map[string]string{"name": jobIDs[n.ID], "path": strings.Join(n.Artifacts, "\n")},
+			})
+		}
+
+		wf.Jobs[jobIDs[n.ID]] = job
+	}
+
+	return marshalCI(wf)
+}
+
+// ExportGitLabCI exports the pipeline as a GitLab CI configuration skeleton: one stage per
+// pipeline stage, and one job per node running the node command, with `needs` mirroring the node
+// dependencies. Node artifacts are kept as job artifacts, which GitLab passes to the dependent
+// jobs, and secrets are expected as masked CI/CD variables named by SecretEnvName.
+//
+// As with ExportGitHubActions, mounts are not translated and registered secrets are scrubbed.
+//
+// Returns:
+//   - The GitLab CI YAML.
+//   - An error if the pipeline is invalid.
+func (p *Pipeline) ExportGitLabCI(opts GitLabCIOpts) ([]byte, error) {
+	plan, err := p.Plan()
+	if err != nil {
+		return nil, err
+	}
+
+	stageOf := map[string]string{}
+	stages := make([]string, len(plan.Stages))
+	for i, stage := range plan.Stages {
+		stages[i] = fmt.Sprintf("stage-%d", i+1)
+		for _, id := range stage {
+			stageOf[id] = stages[i]
+		}
+	}
+
+	config := map[string]any{"stages": stages}
+	for _, n := range plan.Nodes {
+		job := gitlabJob{
+			Stage:     stageOf[n.ID],
+			Image:     opts.Image,
+			Tags:      opts.Tags,
+			Needs:     append([]string{}, n.DependsOn...),
+			Variables: nodeEnv(n, nil),
+			Script:    []string{cmdx.ShellJoin(n.Command)},
+		}
+
+		if len(n.Artifacts) > 0 {
+			job.Artifacts = &gitlabArtifacts{Paths: n.Artifacts}
+		}
+
+		config[n.ID] = job
+	}
+
+	return marshalCI(config)
+}
+
+// SecretEnvName returns the CI variable name holding the secret 'name' (e.g. "registry-token"
+// becomes "REGISTRY_TOKEN").
+func SecretEnvName(name string) string {
+	return invalidEnvChars.ReplaceAllString(strings.ToUpper(name), "_")
+}
+
+// nodeEnv returns the environment variables of a node. When 'secretRef' is set, the secrets of
+// the node are added as variables set to the reference it returns.
+func nodeEnv(n Node, secretRef func(env string) string) map[string]string {
+	env := map[string]string{}
+	for _, e := range n.Env {
+		env[e.Name] = e.Value
+	}
+
+	if secretRef != nil {
+		for _, s := range n.Secrets {
+			env[SecretEnvName(s)] = secretRef(SecretEnvName(s))
+		}
+	}
+
+	if len(env) == 0 {
+		return nil
+	}
+
+	return env
+}
+
+// githubJobID returns a valid GitHub Actions job identifier for the node 'id'.
+func githubJobID(id string) string {
+	jobID := invalidJobIDChars.ReplaceAllString(id, "-")
+	if jobID == "" || jobID[0] == '-' || (jobID[0] >= '0' && jobID[0] <= '9') {
+		jobID = "_" + jobID
+	}
+
+	return jobID
+}
+
+// marshalCI encodes a CI configuration, with the registered secrets scrubbed.
+func marshalCI(v any) ([]byte, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode CI configuration: %w", err)
+	}
+
+	return logx.Default().Bytes(data), nil
+}
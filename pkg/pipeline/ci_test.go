@@ -0,0 +1,137 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func newCIPipeline(t *testing.T) *Pipeline {
+	t.Helper()
+
+	p := New("release")
+	nodes := []Node{
+		{
+			ID:        "apko",
+			Command:   types.DaggerCMD{"apko", "build", "apko.yaml", "example/app:v1", "image.tar"},
+			Env:       []types.DaggerEnvVars{{Name: "SOURCE_DATE_EPOCH", Value: "0"}},
+			Artifacts: []string{"image.tar", "sbom.spdx.json"},
+		},
+		{ID: "scan", Command: types.DaggerCMD{"grype", "image.tar"}, DependsOn: []string{"apko"}},
+		{
+			ID:        "sign",
+			Command:   types.DaggerCMD{"cosign", "sign", "--annotations", "team=platform ops", "example/app:v1"},
+			Secrets:   []string{"cosign-password"},
+			DependsOn: []string{"apko"},
+		},
+	}
+
+	for _, n := range nodes {
+		require.NoError(t, p.AddNode(n))
+	}
+
+	return p
+}
+
+func TestExportGitHubActions(t *testing.T) {
+	out, err := newCIPipeline(t).ExportGitHubActions(GitHubActionsOpts{})
+	require.NoError(t, err)
+
+	var wf githubWorkflow
+	require.NoError(t, yaml.Unmarshal(out, &wf))
+
+	assert.Equal(t, "release", wf.Name)
+	assert.Contains(t, wf.On, "workflow_dispatch")
+	require.Len(t, wf.Jobs, 3)
+
+	apko := wf.Jobs["apko"]
+	assert.Equal(t, DefaultRunsOn, apko.RunsOn)
+	assert.Empty(t, apko.Needs)
+	assert.Equal(t, map[string]string{"SOURCE_DATE_EPOCH": "0"}, apko.Env)
+	assert.Equal(t, []githubStep{
+		{Uses: checkoutAction},
+		{Name: "apko", Run: "apko build apko.yaml example/app:v1 image.tar"},
+		{
+			Name: "Upload artifacts",
+			Uses: uploadArtifactAction,
+			With: map[string]string{"name": "apko", "path": "image.tar\nsbom.spdx.json"},
+		},
+	}, apko.Steps)
+
+	sign := wf.Jobs["sign"]
+	assert.Equal(t, []string{"apko"}, sign.Needs)
+	assert.Equal(t, map[string]string{"COSIGN_PASSWORD": "${{ secrets.COSIGN_PASSWORD }}"}, sign.Env)
+	assert.Equal(t, githubStep{
+		Name: "Download apko artifacts",
+		Uses: downloadArtifactAction,
+		With: map[string]string{"name": "apko"},
+	}, sign.Steps[1])
+	assert.Equal(t, "cosign sign --annotations 'team=platform ops' example/app:v1", sign.Steps[2].Run)
+}
+
+func TestExportGitHubActionsOptions(t *testing.T) {
+	p := New("build")
+	require.NoError(t, p.AddNode(Node{ID: "1st build", Command: types.DaggerCMD{"make"}}))
+
+	out, err := p.ExportGitHubActions(GitHubActionsOpts{RunsOn: "self-hosted", Triggers: []string{"push"}})
+	require.NoError(t, err)
+
+	var wf githubWorkflow
+	require.NoError(t, yaml.Unmarshal(out, &wf))
+	assert.Contains(t, wf.On, "push")
+	require.Contains(t, wf.Jobs, "_1st-build")
+	assert.Equal(t, "self-hosted", wf.Jobs["_1st-build"].RunsOn)
+
+	require.NoError(t, p.AddNode(Node{ID: "1st.build", Command: types.DaggerCMD{"make"}}))
+	_, err = p.ExportGitHubActions(GitHubActionsOpts{})
+	assert.ErrorContains(t, err, "both map to the GitHub Actions job _1st-build")
+}
+
+func TestExportGitLabCI(t *testing.T) {
+	opts := GitLabCIOpts{Image: "cgr.dev/chainguard/wolfi-base", Tags: []string{"docker"}}
+	out, err := newCIPipeline(t).ExportGitLabCI(opts)
+	require.NoError(t, err)
+
+	var config struct {
+		Stages []string  `yaml:"stages"`
+		Apko   gitlabJob `yaml:"apko"`
+		Sign   gitlabJob `yaml:"sign"`
+	}
+	require.NoError(t, yaml.Unmarshal(out, &config))
+	assert.Equal(t, []string{"stage-1", "stage-2"}, config.Stages)
+
+	apko, sign := config.Apko, config.Sign
+
+	assert.Equal(t, gitlabJob{
+		Stage:     "stage-1",
+		Image:     "cgr.dev/chainguard/wolfi-base",
+		Tags:      []string{"docker"},
+		Needs:     []string{},
+		Variables: map[string]string{"SOURCE_DATE_EPOCH": "0"},
+		Script:    []string{"apko build apko.yaml example/app:v1 image.tar"},
+		Artifacts: &gitlabArtifacts{Paths: []string{"image.tar", "sbom.spdx.json"}},
+	}, apko)
+
+	assert.Equal(t, "stage-2", sign.Stage)
+	assert.Equal(t, []string{"apko"}, sign.Needs)
+	assert.Nil(t, sign.Variables, "secrets are provided as CI/CD variables")
+}
+
+func TestExportCIInvalidPipeline(t *testing.T) {
+	p := New("broken")
+	require.NoError(t, p.AddNode(Node{ID: "a", Command: types.DaggerCMD{"true"}, DependsOn: []string{"b"}}))
+
+	_, err := p.ExportGitHubActions(GitHubActionsOpts{})
+	assert.Error(t, err)
+
+	_, err = p.ExportGitLabCI(GitLabCIOpts{})
+	assert.Error(t, err)
+}
+
+func TestSecretEnvName(t *testing.T) {
+	assert.Equal(t, "REGISTRY_TOKEN", SecretEnvName("registry-token"))
+	assert.Equal(t, "COSIGN_KEY_PEM", SecretEnvName("cosign.key.pem"))
+}
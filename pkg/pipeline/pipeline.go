@@ -0,0 +1,303 @@
+// Package pipeline provides a lightweight DAG orchestration model for the commands generated by
+// the builders in this module.
+//
+// Each node of a pipeline is a generated command plus its mount, environment and secret
+// requirements, and the identifiers of the nodes it depends on. A typical pipeline chains several
+// builders, e.g. melange build → apko build → syft → cosign.
+//
+// The package computes a topological execution order, detects the stages whose nodes can run in
+// parallel, and exports the plan as JSON for visualization, or as a GitHub Actions workflow or a
+// GitLab CI configuration skeleton for teams that need to run it without Dagger. Nodes carry
+// optional CPU, memory and disk estimates, defaulting per tool, which EstimateResources aggregates
+// per stage so orchestrators can size their runners.
+//
+// Example usage:
+//
+//	p := pipeline.New("release")
+//	_ = p.AddNode(pipeline.Node{ID: "apko", Command: apkoCmd})
+//	_ = p.AddNode(pipeline.Node{ID: "sbom", Command: syftCmd, DependsOn: []string{"apko"}})
+//	_ = p.AddNode(pipeline.Node{ID: "sign", Command: cosignCmd, DependsOn: []string{"apko"}})
+//
+//	stages, err := p.Stages()
+//	// stages: [[apko] [sbom sign]]
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// Node is a single step of a pipeline.
+type Node struct {
+	// ID uniquely identifies the node within the pipeline.
+	ID string `json:"id"`
+	// Command is the generated command executed by the node.
+	Command types.DaggerCMD `json:"command"`
+	// Env holds the environment variables the command requires.
+	Env []types.DaggerEnvVars `json:"env,omitempty"`
+	// Mounts holds the mounts the command requires.
+	Mounts []types.Mount `json:"mounts,omitempty"`
+	// Secrets holds the names of the secrets the command requires.
+	Secrets []string `json:"secrets,omitempty"`
+	// DependsOn holds the identifiers of the nodes that must complete before this node.
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// Artifacts holds the paths of the files the command produces for the dependent nodes.
+	Artifacts []string `json:"artifacts,omitempty"`
+	// Resources is the estimate of the resources the command requires. When nil, the defaults
+	// of the tool it runs apply (see ResourcesOf).
+	Resources *Resources `json:"resources,omitempty"`
+}
+
+// Pipeline is a directed acyclic graph of nodes.
+type Pipeline struct {
+	// name is the name of the pipeline.
+	name string
+	// nodes holds the nodes in insertion order, which keeps every computed order deterministic.
+	nodes []Node
+	// index maps node identifiers to their position in nodes.
+	index map[string]int
+}
+
+// Plan is the serializable representation of a pipeline.
+type Plan struct {
+	// Name is the name of the pipeline.
+	Name string `json:"name"`
+	// Nodes holds the nodes in topological order.
+	Nodes []Node `json:"nodes"`
+	// Stages holds the node identifiers grouped by stage; nodes of the same stage can run in parallel.
+	Stages [][]string `json:"stages"`
+}
+
+// New creates an empty pipeline.
+func New(name string) *Pipeline {
+	return &Pipeline{
+		name:  name,
+		index: map[string]int{},
+	}
+}
+
+// Name returns the name of the pipeline.
+func (p *Pipeline) Name() string {
+	return p.name
+}
+
+// AddNode adds a node to the pipeline.
+// Dependencies may reference nodes added later; they are checked by Validate.
+//
+// Returns:
+//   - An error if the node has no identifier, no command, or if its identifier is already used.
+func (p *Pipeline) AddNode(node Node) error {
+	if node.ID == "" {
+		return fmt.Errorf("node id is required")
+	}
+
+	if len(node.Command) == 0 {
+		return fmt.Errorf("node %s requires a command", node.ID)
+	}
+
+	if _, exists := p.index[node.ID]; exists {
+		return fmt.Errorf("node %s already exists", node.ID)
+	}
+
+	p.index[node.ID] = len(p.nodes)
+	p.nodes = append(p.nodes, node)
+
+	return nil
+}
+
+// Node returns the node with the given identifier.
+func (p *Pipeline) Node(id string) (Node, bool) {
+	i, ok := p.index[id]
+	if !ok {
+		return Node{}, false
+	}
+
+	return p.nodes[i], true
+}
+
+// Nodes returns the nodes in insertion order.
+func (p *Pipeline) Nodes() []Node {
+	return append([]Node(nil), p.nodes...)
+}
+
+// Validate checks that every dependency exists and that the graph has no cycle.
+func (p *Pipeline) Validate() error {
+	_, err := p.Stages()
+	return err
+}
+
+// TopologicalOrder returns the node identifiers in an order where every node comes after its
+// dependencies. Nodes without ordering constraints keep their insertion order.
+//
+// Returns:
+//   - The ordered node identifiers.
+//   - An error if a dependency is unknown or the graph contains a cycle.
+func (p *Pipeline) TopologicalOrder() ([]string, error) {
+	stages, err := p.Stages()
+	if err != nil {
+		return nil, err
+	}
+
+	order := make([]string, 0, len(p.nodes))
+	for _, stage := range stages {
+		order = append(order, stage...)
+	}
+
+	return order, nil
+}
+
+// Stages groups the nodes by stage. A node belongs to the stage following the latest stage of its
+// dependencies, therefore all nodes of a stage can run in parallel once previous stages completed.
+//
+// Returns:
+//   - The node identifiers of each stage, in insertion order within a stage.
+//   - An error if a dependency is unknown or the graph contains a cycle.
+func (p *Pipeline) Stages() ([][]string, error) {
+	pending := make(map[string]int, len(p.nodes))
+	dependents := make(map[string][]string, len(p.nodes))
+
+	for _, n := range p.nodes {
+		seen := map[string]bool{}
+		for _, dep := range n.DependsOn {
+			if _, ok := p.index[dep]; !ok {
+				return nil, fmt.Errorf("node %s depends on unknown node %s", n.ID, dep)
+			}
+
+			if dep == n.ID {
+				return nil, fmt.Errorf("node %s depends on itself", n.ID)
+			}
+
+			if seen[dep] {
+				continue
+			}
+
+			seen[dep] = true
+			pending[n.ID]++
+			dependents[dep] = append(dependents[dep], n.ID)
+		}
+	}
+
+	var current []string
+	for _, n := range p.nodes {
+		if pending[n.ID] == 0 {
+			current = append(current, n.ID)
+		}
+	}
+
+	var stages [][]string
+	visited := 0
+	for len(current) > 0 {
+		stages = append(stages, current)
+		visited += len(current)
+
+		ready := map[string]bool{}
+		for _, id := range current {
+			for _, dependent := range dependents[id] {
+				pending[dependent]--
+				if pending[dependent] == 0 {
+					ready[dependent] = true
+				}
+			}
+		}
+
+		var next []string
+		for _, n := range p.nodes {
+			if ready[n.ID] {
+				next = append(next, n.ID)
+			}
+		}
+		current = next
+	}
+
+	if visited != len(p.nodes) {
+		var cyclic []string
+		for _, n := range p.nodes {
+			if pending[n.ID] > 0 {
+				cyclic = append(cyclic, n.ID)
+			}
+		}
+		return nil, fmt.Errorf("pipeline %s contains a cycle involving nodes: %s", p.name, strings.Join(cyclic, ", "))
+	}
+
+	return stages, nil
+}
+
+// Plan returns the serializable representation of the pipeline.
+//
+// Returns:
+//   - The plan with nodes in topological order and their stages.
+//   - An error if the pipeline is invalid.
+func (p *Pipeline) Plan() (*Plan, error) {
+	stages, err := p.Stages()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{
+		Name:   p.name,
+		Nodes:  make([]Node, 0, len(p.nodes)),
+		Stages: stages,
+	}
+
+	for _, stage := range stages {
+		for _, id := range stage {
+			plan.Nodes = append(plan.Nodes, p.nodes[p.index[id]])
+		}
+	}
+
+	return plan, nil
+}
+
+// ExportJSON exports the pipeline plan as indented JSON, with the secrets registered with the
+// default logx redactor scrubbed from commands and environment values.
+func (p *Pipeline) ExportJSON() ([]byte, error) {
+	plan, err := p.Plan()
+	if err != nil {
+		return nil, err
+	}
+
+	return logx.Default().JSON(plan)
+}
+
+// Dependents returns the nodes 'ids' and every node depending on them, directly or through other
+// nodes: the nodes to re-run when the outputs of 'ids' change.
+//
+// Returns:
+//   - The node identifiers in topological order.
+//   - An error if an identifier is unknown or the pipeline is invalid.
+func (p *Pipeline) Dependents(ids ...string) ([]string, error) {
+	order, err := p.TopologicalOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if _, ok := p.index[id]; !ok {
+			return nil, fmt.Errorf("unknown node %s", id)
+		}
+		selected[id] = true
+	}
+
+	// Dependencies come first in the order, so a single pass reaches every dependent.
+	var out []string
+	for _, id := range order {
+		if !selected[id] {
+			for _, dep := range p.nodes[p.index[id]].DependsOn {
+				if selected[dep] {
+					selected[id] = true
+					break
+				}
+			}
+		}
+
+		if selected[id] {
+			out = append(out, id)
+		}
+	}
+
+	return out, nil
+}
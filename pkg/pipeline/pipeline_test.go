@@ -0,0 +1,172 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReleasePipeline(t *testing.T) *Pipeline {
+	t.Helper()
+
+	p := New("release")
+	nodes := []Node{
+		{ID: "sign", Command: types.DaggerCMD{"cosign", "sign"}, DependsOn: []string{"apko"}},
+		{ID: "melange", Command: types.DaggerCMD{"melange", "build"}},
+		{ID: "apko", Command: types.DaggerCMD{"apko", "build"}, DependsOn: []string{"melange"}},
+		{ID: "sbom", Command: types.DaggerCMD{"syft", "scan"}, DependsOn: []string{"apko"}},
+		{ID: "publish", Command: types.DaggerCMD{"apko", "publish"}, DependsOn: []string{"sign", "sbom"}},
+	}
+
+	for _, n := range nodes {
+		require.NoError(t, p.AddNode(n))
+	}
+
+	return p
+}
+
+func TestAddNode(t *testing.T) {
+	p := New("test")
+	require.NoError(t, p.AddNode(Node{ID: "a", Command: types.DaggerCMD{"echo"}}))
+
+	assert.EqualError(t, p.AddNode(Node{Command: types.DaggerCMD{"echo"}}), "node id is required")
+	assert.EqualError(t, p.AddNode(Node{ID: "b"}), "node b requires a command")
+	assert.EqualError(t, p.AddNode(Node{ID: "a", Command: types.DaggerCMD{"echo"}}), "node a already exists")
+
+	n, ok := p.Node("a")
+	assert.True(t, ok)
+	assert.Equal(t, "a", n.ID)
+
+	_, ok = p.Node("missing")
+	assert.False(t, ok)
+}
+
+func TestStages(t *testing.T) {
+	p := newReleasePipeline(t)
+
+	stages, err := p.Stages()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"melange"}, {"apko"}, {"sign", "sbom"}, {"publish"}}, stages)
+
+	order, err := p.TopologicalOrder()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"melange", "apko", "sign", "sbom", "publish"}, order)
+}
+
+func TestDependents(t *testing.T) {
+	p := newReleasePipeline(t)
+
+	ids, err := p.Dependents("apko")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apko", "sign", "sbom", "publish"}, ids)
+
+	ids, err = p.Dependents("sbom", "sign")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sign", "sbom", "publish"}, ids)
+
+	ids, err = p.Dependents()
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	_, err = p.Dependents("missing")
+	assert.EqualError(t, err, "unknown node missing")
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   []Node
+		wantErr string
+	}{
+		{
+			name: "Unknown dependency",
+			nodes: []Node{
+				{ID: "a", Command: types.DaggerCMD{"echo"}, DependsOn: []string{"b"}},
+			},
+			wantErr: "node a depends on unknown node b",
+		},
+		{
+			name: "Self dependency",
+			nodes: []Node{
+				{ID: "a", Command: types.DaggerCMD{"echo"}, DependsOn: []string{"a"}},
+			},
+			wantErr: "node a depends on itself",
+		},
+		{
+			name: "Cycle",
+			nodes: []Node{
+				{ID: "root", Command: types.DaggerCMD{"echo"}},
+				{ID: "a", Command: types.DaggerCMD{"echo"}, DependsOn: []string{"root", "c"}},
+				{ID: "b", Command: types.DaggerCMD{"echo"}, DependsOn: []string{"a"}},
+				{ID: "c", Command: types.DaggerCMD{"echo"}, DependsOn: []string{"b"}},
+			},
+			wantErr: "pipeline test contains a cycle involving nodes: a, b, c",
+		},
+		{
+			name: "Duplicated dependency is tolerated",
+			nodes: []Node{
+				{ID: "a", Command: types.DaggerCMD{"echo"}},
+				{ID: "b", Command: types.DaggerCMD{"echo"}, DependsOn: []string{"a", "a"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New("test")
+			for _, n := range tt.nodes {
+				require.NoError(t, p.AddNode(n))
+			}
+
+			err := p.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	p := New("build")
+	require.NoError(t, p.AddNode(Node{
+		ID:      "apko",
+		Command: types.DaggerCMD{"apko", "build"},
+		Env:     []types.DaggerEnvVars{{Name: "SOURCE_DATE_EPOCH", Value: "0"}},
+		Mounts:  []types.Mount{{Kind: types.MountKindCache, Source: "apko", Target: "/mnt/var/cache/apko"}},
+	}))
+	require.NoError(t, p.AddNode(Node{ID: "sign", Command: types.DaggerCMD{"cosign", "sign"}, DependsOn: []string{"apko"}}))
+
+	out, err := p.ExportJSON()
+	require.NoError(t, err)
+
+	var plan Plan
+	require.NoError(t, json.Unmarshal(out, &plan))
+	assert.Equal(t, "build", plan.Name)
+	assert.Equal(t, [][]string{{"apko"}, {"sign"}}, plan.Stages)
+	assert.Len(t, plan.Nodes, 2)
+	assert.Contains(t, string(out), `"kind": "cache"`)
+	assert.Contains(t, string(out), `"dependsOn": [`)
+}
+
+func TestExportJSONRedactsRegisteredSecrets(t *testing.T) {
+	t.Cleanup(logx.Default().Reset)
+	logx.RegisterSecret("ghp_token1234")
+
+	p := New("publish")
+	require.NoError(t, p.AddNode(Node{
+		ID:      "push",
+		Command: types.DaggerCMD{"git", "push"},
+		Env:     []types.DaggerEnvVars{{Name: "GIT_TOKEN", Value: "ghp_token1234"}},
+	}))
+
+	out, err := p.ExportJSON()
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "ghp_token1234")
+	assert.Contains(t, string(out), logx.Redacted)
+}
@@ -0,0 +1,329 @@
+// Package pipelinetest provides a hermetic execx.Executor for unit tests of pipeline logic, so
+// module authors can test how their pipelines react to the outcome of each command without Docker
+// or a Dagger engine.
+//
+// An Executor answers the commands it runs with scripted responses (standard output, standard
+// error, exit code or error), and records every invocation with its environment and mounts.
+// Commands without a scripted response fail, which keeps tests from silently depending on commands
+// they did not expect. The assertion helpers check the order of the invocations and their mounts.
+//
+// Example usage:
+//
+//	func TestRelease(t *testing.T) {
+//	    exec := pipelinetest.NewExecutor().
+//	        On(pipelinetest.Prefix("apko", "build"), pipelinetest.Response{Stdout: "built"}).
+//	        On(pipelinetest.Prefix("cosign", "sign"), pipelinetest.Response{ExitCode: 1, Stderr: "denied"})
+//
+//	    err := release(context.Background(), exec)
+//
+//	    require.Error(t, err)
+//	    exec.AssertOrder(t, pipelinetest.Prefix("apko", "build"), pipelinetest.Prefix("cosign", "sign"))
+//	    exec.AssertMounted(t, pipelinetest.Prefix("apko", "build"), "/mnt/var/cache/apko")
+//	}
+package pipelinetest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/execx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// Matcher selects the commands a scripted response answers.
+type Matcher func(cmd types.DaggerCMD) bool
+
+// Exact matches the command 'args', argument by argument.
+func Exact(args ...string) Matcher {
+	return func(cmd types.DaggerCMD) bool {
+		return slices.Equal(cmd, args)
+	}
+}
+
+// Prefix matches the commands starting with the arguments 'args', e.g. Prefix("apko", "build").
+func Prefix(args ...string) Matcher {
+	return func(cmd types.DaggerCMD) bool {
+		return len(cmd) >= len(args) && slices.Equal(cmd[:len(args)], args)
+	}
+}
+
+// Contains matches the commands holding the argument 'arg', e.g. Contains("--sbom").
+func Contains(arg string) Matcher {
+	return func(cmd types.DaggerCMD) bool {
+		return slices.Contains(cmd, arg)
+	}
+}
+
+// Any matches every command.
+func Any() Matcher {
+	return func(types.DaggerCMD) bool {
+		return true
+	}
+}
+
+// Response is the scripted outcome of a command.
+type Response struct {
+	// Stdout is the standard output of the command.
+	Stdout string
+	// Stderr is the standard error of the command.
+	Stderr string
+	// ExitCode is the exit code of the command.
+	ExitCode int
+	// Err is returned instead of a result, simulating a failure to run the command at all, e.g.
+	// an engine error.
+	Err error
+}
+
+// Invocation is a command run by the executor.
+type Invocation struct {
+	// Command is the command.
+	Command types.DaggerCMD
+	// Env holds the environment variables of the command.
+	Env []types.DaggerEnvVars
+	// Mounts holds the mounts of the command.
+	Mounts []types.Mount
+}
+
+// script is the responses of the commands matched by a matcher.
+type script struct {
+	// match selects the commands.
+	match Matcher
+	// responses holds the responses, consumed in order; the last one repeats.
+	responses []Response
+	// calls is the number of commands answered.
+	calls int
+}
+
+// Executor is a hermetic execx.Executor answering commands with scripted responses. It is safe
+// for concurrent use, e.g. by planx.Plan.
+type Executor struct {
+	mu sync.Mutex
+	// scripts holds the scripted responses in insertion order; the first matching one answers.
+	scripts []*script
+	// fallback answers the commands no script matches. When nil, they fail.
+	fallback *Response
+	// invocations holds the commands run, in order.
+	invocations []Invocation
+}
+
+// NewExecutor creates an executor without scripted responses, failing every command.
+func NewExecutor() *Executor {
+	return &Executor{}
+}
+
+// On answers the commands matched by 'match' with 'responses', consumed in order, the last one
+// repeating: On(m, fail, ok) scripts a command failing once, then succeeding. Without responses,
+// matched commands succeed without output. Scripts are tried in insertion order.
+// It returns the updated Executor instance.
+func (e *Executor) On(match Matcher, responses ...Response) *Executor {
+	if len(responses) == 0 {
+		responses = []Response{{}}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.scripts = append(e.scripts, &script{match: match, responses: responses})
+
+	return e
+}
+
+// WithDefault answers the commands no script matches with 'response', instead of failing them.
+// It returns the updated Executor instance.
+func (e *Executor) WithDefault(response Response) *Executor {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.fallback = &response
+
+	return e
+}
+
+// Run records the command and returns its scripted response. The output is also kept in the tail
+// of the result, as the real executors do.
+//
+// Returns:
+//   - The result of the command, unless its response has an error.
+//   - An error if the command is empty, the context is done, no script matches the command and
+//     there is no default response, or the response has an error.
+func (e *Executor) Run(ctx context.Context, cmd types.DaggerCMD, env []types.DaggerEnvVars, mounts []types.Mount) (*execx.Result, error) {
+	if len(cmd) == 0 || cmd[0] == "" {
+		return nil, fmt.Errorf("command cannot be empty")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	e.invocations = append(e.invocations, Invocation{
+		Command: slices.Clone(cmd),
+		Env:     slices.Clone(env),
+		Mounts:  slices.Clone(mounts),
+	})
+	response, ok := e.respond(cmd)
+	e.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unexpected command: %s", strings.Join(cmd, " "))
+	}
+
+	if response.Err != nil {
+		return nil, response.Err
+	}
+
+	tail := execx.NewTailBuffer(execx.DefaultTailLines)
+	for _, s := range []struct {
+		stream execx.Stream
+		output string
+	}{{execx.StreamStdout, response.Stdout}, {execx.StreamStderr, response.Stderr}} {
+		w := execx.NewLineWriter(s.stream, tail.Add)
+		_, _ = io.Copy(w, strings.NewReader(s.output))
+		w.Flush()
+	}
+
+	return &execx.Result{
+		Stdout:   response.Stdout,
+		Stderr:   response.Stderr,
+		ExitCode: response.ExitCode,
+		Tail:     tail.Lines(),
+	}, nil
+}
+
+// respond returns the response to 'cmd', and whether there is one. The caller holds the lock.
+func (e *Executor) respond(cmd types.DaggerCMD) (Response, bool) {
+	for _, s := range e.scripts {
+		if !s.match(cmd) {
+			continue
+		}
+
+		r := s.responses[min(s.calls, len(s.responses)-1)]
+		s.calls++
+
+		return r, true
+	}
+
+	if e.fallback != nil {
+		return *e.fallback, true
+	}
+
+	return Response{}, false
+}
+
+// Invocations returns the commands run, in order.
+func (e *Executor) Invocations() []Invocation {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return slices.Clone(e.invocations)
+}
+
+// Commands returns the commands run, in order, without their environment and mounts.
+func (e *Executor) Commands() []types.DaggerCMD {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	cmds := make([]types.DaggerCMD, 0, len(e.invocations))
+	for _, inv := range e.invocations {
+		cmds = append(cmds, inv.Command)
+	}
+
+	return cmds
+}
+
+// Calls returns the invocations of the commands matched by 'match', in order.
+func (e *Executor) Calls(match Matcher) []Invocation {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var calls []Invocation
+	for _, inv := range e.invocations {
+		if match(inv.Command) {
+			calls = append(calls, inv)
+		}
+	}
+
+	return calls
+}
+
+// AssertOrder checks that commands matched by 'matches' ran in that order. Other commands may run
+// in between.
+func (e *Executor) AssertOrder(t testing.TB, matches ...Matcher) {
+	t.Helper()
+
+	invocations := e.Invocations()
+
+	next := 0
+	for _, inv := range invocations {
+		if next < len(matches) && matches[next](inv.Command) {
+			next++
+		}
+	}
+
+	if next < len(matches) {
+		t.Errorf("expected command #%d of the order to run after the previous ones, ran:\n%s",
+			next+1, formatInvocations(invocations))
+	}
+}
+
+// AssertMounted checks that the first command matched by 'match' ran with a mount at 'target'.
+func (e *Executor) AssertMounted(t testing.TB, match Matcher, target string) {
+	t.Helper()
+
+	calls := e.Calls(match)
+	if len(calls) == 0 {
+		t.Errorf("expected a command mounting %s, none matched, ran:\n%s", target, formatInvocations(e.Invocations()))
+		return
+	}
+
+	for _, m := range calls[0].Mounts {
+		if m.Target == target {
+			return
+		}
+	}
+
+	t.Errorf("expected %s to mount %s, got mounts %v", strings.Join(calls[0].Command, " "), target, calls[0].Mounts)
+}
+
+// AssertNotRun checks that no command matched by 'match' ran.
+func (e *Executor) AssertNotRun(t testing.TB, match Matcher) {
+	t.Helper()
+
+	for _, inv := range e.Calls(match) {
+		t.Errorf("expected the command not to run, ran: %s", strings.Join(inv.Command, " "))
+	}
+}
+
+// AssertAllUsed checks that every script answered at least one command, which catches scripted
+// commands the pipeline no longer runs.
+func (e *Executor) AssertAllUsed(t testing.TB) {
+	t.Helper()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i, s := range e.scripts {
+		if s.calls == 0 {
+			t.Errorf("script #%d answered no command", i+1)
+		}
+	}
+}
+
+// formatInvocations returns the commands of 'invocations', one per line, for failure messages.
+func formatInvocations(invocations []Invocation) string {
+	if len(invocations) == 0 {
+		return "  (none)"
+	}
+
+	lines := make([]string, 0, len(invocations))
+	for _, inv := range invocations {
+		lines = append(lines, "  "+strings.Join(inv.Command, " "))
+	}
+
+	return strings.Join(lines, "\n")
+}
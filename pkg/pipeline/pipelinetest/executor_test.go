@@ -0,0 +1,146 @@
+package pipelinetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/planx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingT records the failures of the assertion helpers instead of failing the test.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRunScriptedResponses(t *testing.T) {
+	exec := NewExecutor().
+		On(Prefix("apko", "build"), Response{Stdout: "built\n", Stderr: "warning"}).
+		On(Exact("cosign", "sign", "img"), Response{ExitCode: 1, Stderr: "denied"}, Response{Stdout: "signed"})
+
+	ctx := context.Background()
+
+	res, err := exec.Run(ctx, types.DaggerCMD{"apko", "build", "config.yaml"}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "built\n", res.Stdout)
+	assert.True(t, res.Succeeded())
+	assert.Equal(t, []string{"built", "stderr: warning"}, res.Tail)
+
+	res, err = exec.Run(ctx, types.DaggerCMD{"cosign", "sign", "img"}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.ExitCode)
+	assert.Equal(t, "denied", res.Stderr)
+
+	for range 2 {
+		res, err = exec.Run(ctx, types.DaggerCMD{"cosign", "sign", "img"}, nil, nil)
+		require.NoError(t, err)
+		assert.True(t, res.Succeeded())
+		assert.Equal(t, "signed", res.Stdout)
+	}
+
+	_, err = exec.Run(ctx, types.DaggerCMD{"syft", "scan"}, nil, nil)
+	assert.EqualError(t, err, "unexpected command: syft scan")
+
+	assert.Len(t, exec.Invocations(), 5)
+	assert.Len(t, exec.Calls(Contains("sign")), 3)
+}
+
+func TestRunDefaultAndErrors(t *testing.T) {
+	engineErr := errors.New("engine unavailable")
+	exec := NewExecutor().
+		On(Prefix("crane"), Response{Err: engineErr}).
+		On(Prefix("echo")).
+		WithDefault(Response{Stdout: "ok"})
+
+	ctx := context.Background()
+
+	_, err := exec.Run(ctx, types.DaggerCMD{"crane", "copy"}, nil, nil)
+	assert.ErrorIs(t, err, engineErr)
+
+	res, err := exec.Run(ctx, types.DaggerCMD{"echo", "hi"}, nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, res.Stdout)
+	assert.True(t, res.Succeeded())
+
+	res, err = exec.Run(ctx, types.DaggerCMD{"syft", "scan"}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", res.Stdout)
+
+	_, err = exec.Run(ctx, types.DaggerCMD{}, nil, nil)
+	assert.EqualError(t, err, "command cannot be empty")
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = exec.Run(cancelled, types.DaggerCMD{"echo"}, nil, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	assert.Equal(t, []types.DaggerCMD{{"crane", "copy"}, {"echo", "hi"}, {"syft", "scan"}}, exec.Commands())
+}
+
+func TestAssertions(t *testing.T) {
+	exec := NewExecutor().WithDefault(Response{}).On(Prefix("grype"))
+
+	ctx := context.Background()
+	cache := []types.Mount{{Kind: types.MountKindCache, Source: "apko", Target: "/mnt/var/cache/apko"}}
+
+	_, err := exec.Run(ctx, types.DaggerCMD{"melange", "build"}, nil, nil)
+	require.NoError(t, err)
+	_, err = exec.Run(ctx, types.DaggerCMD{"apko", "build"}, nil, cache)
+	require.NoError(t, err)
+	_, err = exec.Run(ctx, types.DaggerCMD{"cosign", "sign"}, nil, nil)
+	require.NoError(t, err)
+
+	exec.AssertOrder(t, Prefix("melange"), Prefix("cosign"))
+	exec.AssertMounted(t, Prefix("apko"), "/mnt/var/cache/apko")
+	exec.AssertNotRun(t, Prefix("syft"))
+
+	rec := &recordingT{TB: t}
+	exec.AssertOrder(rec, Prefix("cosign"), Prefix("apko"))
+	exec.AssertMounted(rec, Prefix("cosign"), "/mnt/var/cache/apko")
+	exec.AssertMounted(rec, Prefix("syft"), "/out")
+	exec.AssertNotRun(rec, Prefix("apko"))
+	exec.AssertAllUsed(rec)
+
+	require.Len(t, rec.errors, 5)
+	assert.Contains(t, rec.errors[0], "expected command #2 of the order")
+	assert.Contains(t, rec.errors[0], "  apko build")
+	assert.Contains(t, rec.errors[1], "expected cosign sign to mount /mnt/var/cache/apko")
+	assert.Contains(t, rec.errors[2], "none matched")
+	assert.Equal(t, "expected the command not to run, ran: apko build", rec.errors[3])
+	assert.Equal(t, "script #1 answered no command", rec.errors[4])
+}
+
+func TestExecutorWithPlan(t *testing.T) {
+	plan := planx.NewPlan("matrix").WithConcurrency(2)
+	for _, arch := range []string{"x86_64", "aarch64"} {
+		require.NoError(t, plan.AddTask(planx.Task{
+			ID:      arch,
+			Command: types.DaggerCMD{"apko", "build", "--arch", arch},
+		}))
+	}
+
+	exec := NewExecutor().
+		On(Contains("x86_64")).
+		On(Contains("aarch64"), Response{ExitCode: 1, Stderr: "no such package"})
+
+	report, err := plan.Execute(context.Background(), exec)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stderr: no such package")
+
+	failed := report.Failed()
+	require.Len(t, failed, 1)
+	assert.Equal(t, "aarch64", failed[0].ID)
+	assert.Len(t, exec.Invocations(), 2)
+	exec.AssertAllUsed(t)
+}
@@ -0,0 +1,143 @@
+package pipeline
+
+import "path"
+
+// Resources is an estimate of the CPU, memory and disk a node requires, for orchestrators to
+// schedule pipelines without overcommitting their runners.
+type Resources struct {
+	// MilliCPU is the CPU, in thousandths of a core.
+	MilliCPU int64 `json:"milliCPU,omitempty"`
+	// MemoryMiB is the memory, in MiB.
+	MemoryMiB int64 `json:"memoryMiB,omitempty"`
+	// DiskMiB is the disk space, in MiB.
+	DiskMiB int64 `json:"diskMiB,omitempty"`
+}
+
+// DefaultResources holds the estimates of the nodes without Resources, by the name of the tool
+// their command runs.
+var DefaultResources = map[string]Resources{
+	"apko":    {MilliCPU: 2000, MemoryMiB: 2048, DiskMiB: 4096},
+	"melange": {MilliCPU: 4000, MemoryMiB: 4096, DiskMiB: 8192},
+	"docker":  {MilliCPU: 2000, MemoryMiB: 4096, DiskMiB: 10240},
+	"go":      {MilliCPU: 2000, MemoryMiB: 2048, DiskMiB: 4096},
+	"syft":    {MilliCPU: 1000, MemoryMiB: 1024, DiskMiB: 1024},
+	"grype":   {MilliCPU: 1000, MemoryMiB: 2048, DiskMiB: 2048},
+	"trivy":   {MilliCPU: 1000, MemoryMiB: 2048, DiskMiB: 2048},
+	"crane":   {MilliCPU: 500, MemoryMiB: 512, DiskMiB: 2048},
+	"cosign":  {MilliCPU: 250, MemoryMiB: 256, DiskMiB: 64},
+}
+
+// FallbackResources is the estimate of the nodes without Resources whose tool has no
+// DefaultResources.
+var FallbackResources = Resources{MilliCPU: 500, MemoryMiB: 512, DiskMiB: 1024}
+
+// ResourceEstimate is the resources a pipeline requires.
+type ResourceEstimate struct {
+	// Nodes holds the estimate of each node.
+	Nodes map[string]Resources `json:"nodes"`
+	// Stages holds the resources of each stage, whose nodes run in parallel.
+	Stages []Resources `json:"stages"`
+	// Peak is the largest requirement of the stages, per resource: the capacity running the
+	// pipeline stage by stage requires.
+	Peak Resources `json:"peak"`
+	// Total is the sum of the requirements of the nodes.
+	Total Resources `json:"total"`
+}
+
+// Add returns the sum of 'r' and 'other'.
+func (r Resources) Add(other Resources) Resources {
+	return Resources{
+		MilliCPU:  r.MilliCPU + other.MilliCPU,
+		MemoryMiB: r.MemoryMiB + other.MemoryMiB,
+		DiskMiB:   r.DiskMiB + other.DiskMiB,
+	}
+}
+
+// Max returns the largest of 'r' and 'other', per resource.
+func (r Resources) Max(other Resources) Resources {
+	return Resources{
+		MilliCPU:  max(r.MilliCPU, other.MilliCPU),
+		MemoryMiB: max(r.MemoryMiB, other.MemoryMiB),
+		DiskMiB:   max(r.DiskMiB, other.DiskMiB),
+	}
+}
+
+// Fits reports whether 'r' fits in 'capacity'.
+func (r Resources) Fits(capacity Resources) bool {
+	return r.MilliCPU <= capacity.MilliCPU && r.MemoryMiB <= capacity.MemoryMiB && r.DiskMiB <= capacity.DiskMiB
+}
+
+// Instances returns how many nodes requiring 'r' fit in 'capacity' at once, e.g. the
+// concurrency of a matrix build on a runner.
+func (r Resources) Instances(capacity Resources) int {
+	n := int64(-1)
+	for _, pair := range [][2]int64{
+		{r.MilliCPU, capacity.MilliCPU},
+		{r.MemoryMiB, capacity.MemoryMiB},
+		{r.DiskMiB, capacity.DiskMiB},
+	} {
+		if pair[0] <= 0 {
+			continue
+		}
+
+		if fit := pair[1] / pair[0]; n < 0 || fit < n {
+			n = fit
+		}
+	}
+
+	if n < 0 {
+		// Nothing is required.
+		return 0
+	}
+
+	return int(n)
+}
+
+// ResourcesOf returns the resources of the node 'n': its Resources, or the DefaultResources of
+// the tool its command runs, or FallbackResources.
+func ResourcesOf(n Node) Resources {
+	if n.Resources != nil {
+		return *n.Resources
+	}
+
+	if len(n.Command) > 0 {
+		if r, ok := DefaultResources[path.Base(n.Command[0])]; ok {
+			return r
+		}
+	}
+
+	return FallbackResources
+}
+
+// EstimateResources returns the resources of the nodes of the pipeline, of its stages and of the
+// whole pipeline (see ResourcesOf).
+//
+// Returns:
+//   - The estimate.
+//   - An error if the pipeline is invalid.
+func (p *Pipeline) EstimateResources() (*ResourceEstimate, error) {
+	stages, err := p.Stages()
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &ResourceEstimate{
+		Nodes:  make(map[string]Resources, len(p.nodes)),
+		Stages: make([]Resources, 0, len(stages)),
+	}
+
+	for _, stage := range stages {
+		var sum Resources
+		for _, id := range stage {
+			r := ResourcesOf(p.nodes[p.index[id]])
+			estimate.Nodes[id] = r
+			sum = sum.Add(r)
+		}
+
+		estimate.Stages = append(estimate.Stages, sum)
+		estimate.Peak = estimate.Peak.Max(sum)
+		estimate.Total = estimate.Total.Add(sum)
+	}
+
+	return estimate, nil
+}
@@ -0,0 +1,58 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourcesOf(t *testing.T) {
+	assert.Equal(t, DefaultResources["apko"], ResourcesOf(Node{Command: types.DaggerCMD{"/usr/bin/apko", "build"}}))
+	assert.Equal(t, FallbackResources, ResourcesOf(Node{Command: types.DaggerCMD{"sh", "-c", "true"}}))
+
+	custom := Resources{MilliCPU: 100, MemoryMiB: 64}
+	assert.Equal(t, custom, ResourcesOf(Node{Command: types.DaggerCMD{"apko"}, Resources: &custom}))
+}
+
+func TestEstimateResources(t *testing.T) {
+	p := New("matrix")
+	nodes := []Node{
+		{ID: "amd64", Command: types.DaggerCMD{"apko", "build"}},
+		{ID: "arm64", Command: types.DaggerCMD{"apko", "build"}},
+		{
+			ID:        "sign",
+			Command:   types.DaggerCMD{"cosign", "sign"},
+			DependsOn: []string{"amd64", "arm64"},
+			Resources: &Resources{MilliCPU: 8000, MemoryMiB: 128, DiskMiB: 10},
+		},
+	}
+	for _, n := range nodes {
+		require.NoError(t, p.AddNode(n))
+	}
+
+	estimate, err := p.EstimateResources()
+	require.NoError(t, err)
+
+	apko := DefaultResources["apko"]
+	assert.Equal(t, apko, estimate.Nodes["arm64"])
+	assert.Equal(t, []Resources{apko.Add(apko), {MilliCPU: 8000, MemoryMiB: 128, DiskMiB: 10}}, estimate.Stages)
+	assert.Equal(t, Resources{MilliCPU: 8000, MemoryMiB: 4096, DiskMiB: 8192}, estimate.Peak)
+	assert.Equal(t, Resources{MilliCPU: 12000, MemoryMiB: 4224, DiskMiB: 8202}, estimate.Total)
+
+	require.NoError(t, p.AddNode(Node{ID: "loop", Command: types.DaggerCMD{"x"}, DependsOn: []string{"loop"}}))
+	_, err = p.EstimateResources()
+	assert.Error(t, err)
+}
+
+func TestResourcesFits(t *testing.T) {
+	runner := Resources{MilliCPU: 8000, MemoryMiB: 16384, DiskMiB: 10240}
+
+	assert.True(t, DefaultResources["apko"].Fits(runner))
+	assert.False(t, Resources{MilliCPU: 16000}.Fits(runner))
+
+	assert.Equal(t, 2, DefaultResources["apko"].Instances(runner), "the disk limits the apko builds")
+	assert.Equal(t, 16, Resources{MilliCPU: 500}.Instances(runner))
+	assert.Zero(t, Resources{}.Instances(runner))
+}
@@ -0,0 +1,177 @@
+package planx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/execx"
+)
+
+// TaskResult is the outcome of an executed task.
+type TaskResult struct {
+	// ID is the identifier of the task.
+	ID string
+	// Result is the result returned by the executor, nil if the task could not run.
+	Result *execx.Result
+	// Err is the error of the task: an execution failure or a non-zero exit code.
+	Err error
+	// Started is when the task started, zero if it never started.
+	Started time.Time
+	// Duration is the time the task took.
+	Duration time.Duration
+	// Skipped reports whether the task was not started because the plan was cancelled.
+	Skipped bool
+	// Required reports whether the task was required by the plan (see Plan.WithRequired).
+	Required bool
+}
+
+// Succeeded reports whether the task ran and exited with a zero exit code.
+func (r *TaskResult) Succeeded() bool {
+	return r.Err == nil && !r.Skipped
+}
+
+// Report holds the outcome of an executed plan.
+type Report struct {
+	// Name is the name of the plan.
+	Name string
+	// Policy is the failure policy the plan ran with.
+	Policy FailurePolicy
+	// Results holds the result of every task, in the insertion order of the plan.
+	Results []TaskResult
+	// Duration is the wall-clock time of the execution.
+	Duration time.Duration
+}
+
+// Failed returns the results of the tasks that failed or were skipped.
+func (r *Report) Failed() []TaskResult {
+	var failed []TaskResult
+	for _, res := range r.Results {
+		if !res.Succeeded() {
+			failed = append(failed, res)
+		}
+	}
+
+	return failed
+}
+
+// Err returns the failures failing the plan joined in a single error, or nil if every task
+// succeeded. Under PolicyBestEffort, only the failures of the required tasks fail the plan.
+func (r *Report) Err() error {
+	var errs []error
+	for _, res := range r.Failed() {
+		if r.Policy == PolicyBestEffort && !res.Required {
+			continue
+		}
+
+		errs = append(errs, fmt.Errorf("task %s: %w", res.ID, res.Err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// taskKey is the context key of the task run by the executor.
+type taskKey struct{}
+
+// TaskFromContext returns the task whose command the executor runs, from the context Execute
+// passes to the executor, which lets executor wrappers attribute their work to the task.
+func TaskFromContext(ctx context.Context) (Task, bool) {
+	task, ok := ctx.Value(taskKey{}).(Task)
+	return task, ok
+}
+
+// Execute runs every task of the plan with 'executor', running up to the concurrency limit of the
+// plan at once. A task fails when the executor returns an error or the command exits with a
+// non-zero exit code. With PolicyFailFast, tasks not started yet are skipped after the first
+// failure; otherwise every task runs. Cancelling 'ctx' skips the tasks not started yet.
+//
+// Returns:
+//   - A Report holding the result of every task.
+//   - The aggregated failures failing the plan under its failure policy, as returned by
+//     Report.Err, or an error if the plan has no executor, an unsupported failure policy or
+//     requires unknown tasks.
+func (p *Plan) Execute(ctx context.Context, executor execx.Executor) (*Report, error) {
+	if executor == nil {
+		return nil, fmt.Errorf("plan %s requires an executor", p.name)
+	}
+
+	if err := p.validatePolicy(); err != nil {
+		return nil, err
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	report := &Report{
+		Name:    p.name,
+		Policy:  p.policy,
+		Results: make([]TaskResult, len(p.tasks)),
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	workers := min(p.concurrency, len(p.tasks))
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				res := p.runTask(ctx, executor, p.tasks[i])
+				if p.policy == PolicyFailFast && !res.Succeeded() {
+					cancel()
+				}
+				report.Results[i] = res
+			}
+		}()
+	}
+
+	start := time.Now()
+	for i := range p.tasks {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	report.Duration = time.Since(start)
+
+	return report, report.Err()
+}
+
+// runTask runs a single task, skipping it if the context is already done.
+func (p *Plan) runTask(ctx context.Context, executor execx.Executor, task Task) TaskResult {
+	res := TaskResult{ID: task.ID, Required: p.required[task.ID]}
+
+	if err := ctx.Err(); err != nil {
+		res.Skipped = true
+		res.Err = fmt.Errorf("skipped: %w", err)
+		return res
+	}
+
+	ctx = context.WithValue(ctx, taskKey{}, task)
+	if p.output != nil {
+		ctx = execx.ContextWithLineHandler(ctx, p.output.Handler(task.ID))
+	}
+
+	res.Started = time.Now()
+	res.Result, res.Err = executor.Run(ctx, task.Command, task.Env, task.Mounts)
+	res.Duration = time.Since(res.Started)
+
+	switch {
+	case res.Err != nil:
+	case res.Result == nil:
+		res.Err = fmt.Errorf("executor returned no result for command %q", task.Command[0])
+	case !res.Result.Succeeded() && len(res.Result.Tail) > 0:
+		res.Err = fmt.Errorf("command %q exited with code %d, last output lines:\n%s",
+			task.Command[0], res.Result.ExitCode, res.Result.Summary())
+	case !res.Result.Succeeded():
+		res.Err = fmt.Errorf("command %q exited with code %d", task.Command[0], res.Result.ExitCode)
+	}
+
+	return res
+}
@@ -0,0 +1,160 @@
+package planx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/execx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExecutor records the peak number of concurrent runs. Commands named "fail" exit with
+// code 1, commands named "error" return an error.
+type fakeExecutor struct {
+	mu      sync.Mutex
+	running int
+	peak    int
+	delay   time.Duration
+}
+
+func (e *fakeExecutor) Run(_ context.Context, cmd types.DaggerCMD, _ []types.DaggerEnvVars, _ []types.Mount) (*execx.Result, error) {
+	e.mu.Lock()
+	e.running++
+	e.peak = max(e.peak, e.running)
+	e.mu.Unlock()
+
+	time.Sleep(e.delay)
+
+	e.mu.Lock()
+	e.running--
+	e.mu.Unlock()
+
+	switch cmd[0] {
+	case "fail":
+		return &execx.Result{ExitCode: 1}, nil
+	case "error":
+		return nil, errors.New("engine unavailable")
+	default:
+		return &execx.Result{Stdout: cmd[0]}, nil
+	}
+}
+
+func newTestPlan(t *testing.T, commands ...string) *Plan {
+	t.Helper()
+
+	p := NewPlan("test")
+	for i, c := range commands {
+		require.NoError(t, p.AddTask(Task{ID: fmt.Sprintf("task-%d", i), Command: types.DaggerCMD{c}}))
+	}
+
+	return p
+}
+
+func TestExecuteConcurrencyLimit(t *testing.T) {
+	p := newTestPlan(t, "a", "b", "c", "d", "e", "f").WithConcurrency(2)
+	executor := &fakeExecutor{delay: 20 * time.Millisecond}
+
+	report, err := p.Execute(context.Background(), executor)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, executor.peak)
+	require.Len(t, report.Results, 6)
+	for i, res := range report.Results {
+		assert.Equal(t, fmt.Sprintf("task-%d", i), res.ID)
+		assert.True(t, res.Succeeded())
+		assert.GreaterOrEqual(t, res.Duration, 20*time.Millisecond)
+		assert.False(t, res.Started.IsZero())
+	}
+	assert.Empty(t, report.Failed())
+}
+
+func TestExecuteAggregatesErrors(t *testing.T) {
+	p := newTestPlan(t, "a", "fail", "b", "error")
+
+	report, err := p.Execute(context.Background(), &fakeExecutor{})
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), `task task-1: command "fail" exited with code 1`)
+	assert.Contains(t, err.Error(), "task task-3: engine unavailable")
+	assert.Len(t, report.Failed(), 2)
+	assert.True(t, report.Results[2].Succeeded())
+}
+
+func TestExecuteFailFast(t *testing.T) {
+	p := newTestPlan(t, "fail", "a", "b", "c").WithConcurrency(1).WithFailFast(true)
+
+	report, err := p.Execute(context.Background(), &fakeExecutor{})
+	require.Error(t, err)
+
+	assert.False(t, report.Results[0].Skipped)
+	for _, res := range report.Results[1:] {
+		assert.True(t, res.Skipped)
+		assert.ErrorIs(t, res.Err, context.Canceled)
+	}
+}
+
+func TestExecuteCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report, err := newTestPlan(t, "a", "b").Execute(ctx, &fakeExecutor{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, report.Failed(), 2)
+}
+
+func TestExecuteRequiresExecutor(t *testing.T) {
+	_, err := newTestPlan(t, "a").Execute(context.Background(), nil)
+	assert.EqualError(t, err, "plan test requires an executor")
+}
+
+func TestExecuteTaggedOutputAndTail(t *testing.T) {
+	var out bytes.Buffer
+	p := NewPlan("test").WithOutput(&out)
+	require.NoError(t, p.AddTask(Task{ID: "ok", Command: types.DaggerCMD{"sh", "-c", "echo built"}}))
+	require.NoError(t, p.AddTask(Task{ID: "ko", Command: types.DaggerCMD{"sh", "-c", "echo broken >&2; exit 2"}}))
+
+	_, err := p.Execute(context.Background(), execx.NewLocalExecutor())
+	require.Error(t, err)
+
+	assert.Contains(t, out.String(), "[ok] built\n")
+	assert.Contains(t, out.String(), "[ko stderr] broken\n")
+	assert.Contains(t, err.Error(), "exited with code 2, last output lines:\n  stderr: broken")
+}
+
+// taskRecorder records the task identifiers found in the contexts of the runs.
+type taskRecorder struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (e *taskRecorder) Run(ctx context.Context, _ types.DaggerCMD, _ []types.DaggerEnvVars, _ []types.Mount) (*execx.Result, error) {
+	task, ok := TaskFromContext(ctx)
+	if !ok {
+		return nil, errors.New("no task in context")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.ids = append(e.ids, task.ID)
+
+	return &execx.Result{}, nil
+}
+
+func TestExecuteTaskFromContext(t *testing.T) {
+	executor := &taskRecorder{}
+
+	_, err := newTestPlan(t, "a", "b").Execute(context.Background(), executor)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"task-0", "task-1"}, executor.ids)
+
+	_, ok := TaskFromContext(context.Background())
+	assert.False(t, ok)
+}
@@ -0,0 +1,208 @@
+// Package planx runs large sets of independent generated commands, such as the image × architecture
+// matrix of a release, through an execx.Executor with a bounded number of concurrent executions.
+//
+// A Plan collects tasks, each a generated command with its environment and mounts. Execute runs
+// them with a worker pool, records the timing and outcome of every task, and aggregates the
+// failures into a single error. The failure policy decides whether the first failure skips the
+// remaining tasks (fail-fast), every failure is collected (continue-on-error), or only the failures
+// of required tasks fail the plan (best-effort), as matrix builds often need.
+//
+// Example usage:
+//
+//	plan := planx.NewPlan("base-images").WithConcurrency(4)
+//
+//	err := plan.AddMatrix(map[string][]string{
+//	    "image": {"static", "busybox", "python"},
+//	    "arch":  {"x86_64", "aarch64"},
+//	}, func(v map[string]string) (planx.Task, error) {
+//	    cmd, err := apkox.NewApkoBuilder().
+//	        WithConfigFile(v["image"] + ".yaml").
+//	        WithOutputImage("registry.example.com/" + v["image"]).
+//	        WithOutputTarball("/out/" + v["image"] + "-" + v["arch"] + ".tar").
+//	        WithBuildArch(apkox.Architecture(v["arch"])).
+//	        BuildCommand()
+//	    return planx.Task{Command: cmd}, err
+//	})
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	report, err := plan.Execute(ctx, execx.NewLocalExecutor())
+package planx
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/execx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// Task is a single command of a plan.
+type Task struct {
+	// ID uniquely identifies the task within the plan.
+	ID string `json:"id"`
+	// Command is the generated command executed by the task.
+	Command types.DaggerCMD `json:"command"`
+	// Env holds the environment variables the command requires.
+	Env []types.DaggerEnvVars `json:"env,omitempty"`
+	// Mounts holds the mounts the command requires.
+	Mounts []types.Mount `json:"mounts,omitempty"`
+}
+
+// Plan is a set of independent tasks executed with a bounded concurrency.
+type Plan struct {
+	// name is the name of the plan.
+	name string
+	// concurrency is the maximum number of tasks executed at once.
+	concurrency int
+	// policy decides which failures fail the plan and whether they cancel the remaining tasks.
+	policy FailurePolicy
+	// required holds the identifiers of the tasks whose failure fails a best-effort plan.
+	required map[string]bool
+	// output receives the output lines of the tasks, tagged with their task. It may be nil.
+	output *execx.TaggedOutput
+	// tasks holds the tasks in insertion order.
+	tasks []Task
+	// ids holds the identifiers of the tasks.
+	ids map[string]struct{}
+}
+
+// NewPlan creates an empty plan executing up to GOMAXPROCS tasks at once, with the
+// PolicyContinueOnError failure policy.
+func NewPlan(name string) *Plan {
+	return &Plan{
+		name:        name,
+		concurrency: runtime.GOMAXPROCS(0),
+		policy:      PolicyContinueOnError,
+		ids:         map[string]struct{}{},
+	}
+}
+
+// Name returns the name of the plan.
+func (p *Plan) Name() string {
+	return p.name
+}
+
+// WithConcurrency sets the maximum number of tasks executed at once. Values below 1 are treated as 1.
+func (p *Plan) WithConcurrency(n int) *Plan {
+	if n < 1 {
+		n = 1
+	}
+	p.concurrency = n
+	return p
+}
+
+// WithFailFast cancels the tasks that have not started yet once a task fails. It is a shorthand
+// for the PolicyFailFast policy, or PolicyContinueOnError when 'failFast' is false.
+func (p *Plan) WithFailFast(failFast bool) *Plan {
+	if failFast {
+		return p.WithFailurePolicy(PolicyFailFast)
+	}
+
+	return p.WithFailurePolicy(PolicyContinueOnError)
+}
+
+// WithOutput writes the output lines of the tasks to 'w' while they run, each line prefixed with
+// the identifier of its task ("[static-x86_64] ..."), so parallel runs remain readable.
+func (p *Plan) WithOutput(w io.Writer) *Plan {
+	p.output = execx.NewTaggedOutput(w)
+	return p
+}
+
+// Concurrency returns the maximum number of tasks executed at once.
+func (p *Plan) Concurrency() int {
+	return p.concurrency
+}
+
+// AddTask adds a task to the plan.
+//
+// Returns:
+//   - An error if the task has no identifier, no command, or if its identifier is already used.
+func (p *Plan) AddTask(task Task) error {
+	if task.ID == "" {
+		return fmt.Errorf("task id is required")
+	}
+
+	if len(task.Command) == 0 {
+		return fmt.Errorf("task %s requires a command", task.ID)
+	}
+
+	if _, exists := p.ids[task.ID]; exists {
+		return fmt.Errorf("task %s already exists", task.ID)
+	}
+
+	p.ids[task.ID] = struct{}{}
+	p.tasks = append(p.tasks, task)
+
+	return nil
+}
+
+// AddMatrix adds one task per combination of the axis values, built by 'build'. Axes are
+// combined in the alphabetical order of their names, and the values of an axis in their given
+// order. Tasks built without an identifier get one joining the combination's values with "-".
+//
+// Returns:
+//   - An error if an axis has no value, or if building or adding a task fails.
+func (p *Plan) AddMatrix(axes map[string][]string, build func(values map[string]string) (Task, error)) error {
+	names := make([]string, 0, len(axes))
+	for name, values := range axes {
+		if len(values) == 0 {
+			return fmt.Errorf("matrix axis %s has no values", name)
+		}
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, combination := range combinations(names, axes) {
+		task, err := build(combination)
+		if err != nil {
+			return fmt.Errorf("failed to build task for %v: %w", combination, err)
+		}
+
+		if task.ID == "" {
+			parts := make([]string, len(names))
+			for i, name := range names {
+				parts[i] = combination[name]
+			}
+			task.ID = strings.Join(parts, "-")
+		}
+
+		if err := p.AddTask(task); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// combinations returns the cartesian product of the axis values.
+func combinations(names []string, axes map[string][]string) []map[string]string {
+	result := []map[string]string{{}}
+
+	for _, name := range names {
+		next := make([]map[string]string, 0, len(result)*len(axes[name]))
+		for _, partial := range result {
+			for _, value := range axes[name] {
+				combination := make(map[string]string, len(partial)+1)
+				for k, v := range partial {
+					combination[k] = v
+				}
+				combination[name] = value
+				next = append(next, combination)
+			}
+		}
+		result = next
+	}
+
+	return result
+}
+
+// Tasks returns the tasks in insertion order.
+func (p *Plan) Tasks() []Task {
+	return append([]Task(nil), p.tasks...)
+}
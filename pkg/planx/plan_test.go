@@ -0,0 +1,51 @@
+package planx
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddTask(t *testing.T) {
+	p := NewPlan("test")
+	require.NoError(t, p.AddTask(Task{ID: "a", Command: types.DaggerCMD{"echo"}}))
+
+	assert.EqualError(t, p.AddTask(Task{Command: types.DaggerCMD{"echo"}}), "task id is required")
+	assert.EqualError(t, p.AddTask(Task{ID: "b"}), "task b requires a command")
+	assert.EqualError(t, p.AddTask(Task{ID: "a", Command: types.DaggerCMD{"echo"}}), "task a already exists")
+	assert.Len(t, p.Tasks(), 1)
+}
+
+func TestWithConcurrency(t *testing.T) {
+	assert.Equal(t, 3, NewPlan("test").WithConcurrency(3).Concurrency())
+	assert.Equal(t, 1, NewPlan("test").WithConcurrency(0).Concurrency())
+	assert.GreaterOrEqual(t, NewPlan("test").Concurrency(), 1)
+}
+
+func TestAddMatrix(t *testing.T) {
+	p := NewPlan("matrix")
+	err := p.AddMatrix(map[string][]string{
+		"image": {"static", "python"},
+		"arch":  {"x86_64", "aarch64"},
+	}, func(v map[string]string) (Task, error) {
+		return Task{Command: types.DaggerCMD{"apko", "build", v["image"] + ".yaml", "--arch", v["arch"]}}, nil
+	})
+	require.NoError(t, err)
+
+	var ids []string
+	for _, task := range p.Tasks() {
+		ids = append(ids, task.ID)
+	}
+	assert.Equal(t, []string{"x86_64-static", "x86_64-python", "aarch64-static", "aarch64-python"}, ids)
+
+	err = NewPlan("empty").AddMatrix(map[string][]string{"arch": {}}, nil)
+	assert.EqualError(t, err, "matrix axis arch has no values")
+
+	err = NewPlan("failing").AddMatrix(map[string][]string{"arch": {"x86_64"}}, func(map[string]string) (Task, error) {
+		return Task{}, fmt.Errorf("boom")
+	})
+	assert.EqualError(t, err, "failed to build task for map[arch:x86_64]: boom")
+}
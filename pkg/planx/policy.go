@@ -0,0 +1,80 @@
+package planx
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// FailurePolicy decides how a plan reacts to failed tasks.
+type FailurePolicy string
+
+const (
+	// PolicyFailFast skips the tasks not started yet after the first failure, and fails the plan.
+	PolicyFailFast FailurePolicy = "fail-fast"
+	// PolicyContinueOnError runs every task, and fails the plan with all the failures aggregated.
+	PolicyContinueOnError FailurePolicy = "continue-on-error"
+	// PolicyBestEffort runs every task, and fails the plan only when a required task fails (see
+	// WithRequired). The failures of the other tasks are tolerated, and reported by
+	// Report.Tolerated.
+	PolicyBestEffort FailurePolicy = "best-effort"
+)
+
+// WithFailurePolicy sets how the plan reacts to failed tasks.
+func (p *Plan) WithFailurePolicy(policy FailurePolicy) *Plan {
+	p.policy = policy
+	return p
+}
+
+// WithRequired marks the tasks 'ids' as required: under PolicyBestEffort, the plan fails only
+// when one of them fails, e.g. the architectures a release cannot ship without.
+func (p *Plan) WithRequired(ids ...string) *Plan {
+	if p.required == nil {
+		p.required = map[string]bool{}
+	}
+
+	for _, id := range ids {
+		p.required[id] = true
+	}
+
+	return p
+}
+
+// FailurePolicy returns how the plan reacts to failed tasks.
+func (p *Plan) FailurePolicy() FailurePolicy {
+	return p.policy
+}
+
+// validatePolicy checks that the failure policy is known and the required tasks exist.
+func (p *Plan) validatePolicy() error {
+	switch p.policy {
+	case PolicyFailFast, PolicyContinueOnError, PolicyBestEffort:
+	default:
+		return fmt.Errorf("plan %s has unsupported failure policy %q", p.name, p.policy)
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(p.required)) {
+		if _, ok := p.ids[id]; !ok {
+			return fmt.Errorf("plan %s requires unknown task %s", p.name, id)
+		}
+	}
+
+	return nil
+}
+
+// Tolerated returns the results of the tasks that failed or were skipped without failing the
+// plan: the tasks not required by a best-effort plan.
+func (r *Report) Tolerated() []TaskResult {
+	if r.Policy != PolicyBestEffort {
+		return nil
+	}
+
+	var tolerated []TaskResult
+	for _, res := range r.Failed() {
+		if !res.Required {
+			tolerated = append(tolerated, res)
+		}
+	}
+
+	return tolerated
+}
@@ -0,0 +1,71 @@
+package planx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteContinueOnErrorByDefault(t *testing.T) {
+	p := newTestPlan(t, "fail", "a", "error").WithConcurrency(1)
+	assert.Equal(t, PolicyContinueOnError, p.FailurePolicy())
+
+	report, err := p.Execute(context.Background(), &fakeExecutor{})
+	require.Error(t, err)
+
+	assert.Equal(t, PolicyContinueOnError, report.Policy)
+	assert.True(t, report.Results[1].Succeeded())
+	assert.Len(t, report.Failed(), 2)
+	assert.Empty(t, report.Tolerated())
+}
+
+func TestExecuteBestEffort(t *testing.T) {
+	p := newTestPlan(t, "a", "fail", "error", "b").
+		WithConcurrency(1).
+		WithFailurePolicy(PolicyBestEffort).
+		WithRequired("task-0", "task-3")
+
+	report, err := p.Execute(context.Background(), &fakeExecutor{})
+	require.NoError(t, err)
+
+	assert.Len(t, report.Failed(), 2)
+	tolerated := report.Tolerated()
+	require.Len(t, tolerated, 2)
+	assert.Equal(t, "task-1", tolerated[0].ID)
+	assert.Equal(t, "task-2", tolerated[1].ID)
+	assert.True(t, report.Results[0].Required)
+	assert.False(t, report.Results[1].Required)
+}
+
+func TestExecuteBestEffortRequiredFailure(t *testing.T) {
+	p := newTestPlan(t, "fail", "error", "a").
+		WithConcurrency(1).
+		WithFailurePolicy(PolicyBestEffort).
+		WithRequired("task-0")
+
+	report, err := p.Execute(context.Background(), &fakeExecutor{})
+	require.Error(t, err)
+
+	// Every task still runs, but only the required failure fails the plan.
+	assert.True(t, report.Results[2].Succeeded())
+	assert.Contains(t, err.Error(), `task task-0: command "fail" exited with code 1`)
+	assert.NotContains(t, err.Error(), "task-1")
+	assert.Len(t, report.Tolerated(), 1)
+}
+
+func TestExecuteWithFailFastSetsPolicy(t *testing.T) {
+	p := newTestPlan(t, "a")
+	assert.Equal(t, PolicyFailFast, p.WithFailFast(true).FailurePolicy())
+	assert.Equal(t, PolicyContinueOnError, p.WithFailFast(false).FailurePolicy())
+}
+
+func TestExecuteInvalidPolicy(t *testing.T) {
+	_, err := newTestPlan(t, "a").WithFailurePolicy("retry").Execute(context.Background(), &fakeExecutor{})
+	assert.EqualError(t, err, `plan test has unsupported failure policy "retry"`)
+
+	_, err = newTestPlan(t, "a").WithFailurePolicy(PolicyBestEffort).WithRequired("missing").
+		Execute(context.Background(), &fakeExecutor{})
+	assert.EqualError(t, err, "plan test requires unknown task missing")
+}
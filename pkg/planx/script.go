@@ -0,0 +1,58 @@
+package planx
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// Script generates the sh command running the tasks of the plan one after another, in insertion
+// order, for the executors running a single command. The failure policy applies as in Execute: a
+// fail-fast plan stops at the first failure, and the other policies run every task and fail the
+// command when a task whose failure fails the plan does. The environment variables of a task are
+// set with env; its mounts are left to the caller.
+//
+// Returns:
+//   - The sh command.
+//   - An error if the plan has no task, or its failure policy or required tasks are invalid.
+func (p *Plan) Script() (types.DaggerCMD, error) {
+	if err := p.validatePolicy(); err != nil {
+		return nil, err
+	}
+
+	if len(p.tasks) == 0 {
+		return nil, fmt.Errorf("plan %s has no tasks", p.name)
+	}
+
+	steps := make([]string, 0, len(p.tasks))
+	for _, task := range p.tasks {
+		cmd := []string(task.Command)
+		if len(task.Env) > 0 {
+			env := []string{"env"}
+			for _, e := range task.Env {
+				env = append(env, e.Name+"="+e.Value)
+			}
+			cmd = append(env, cmd...)
+		}
+
+		steps = append(steps, cmdx.ShellJoin(cmd))
+	}
+
+	if p.policy == PolicyFailFast {
+		return types.DaggerCMD{"sh", "-c", strings.Join(steps, " && ")}, nil
+	}
+
+	script := "status=0"
+	for i, task := range p.tasks {
+		if p.policy == PolicyBestEffort && !p.required[task.ID] {
+			script += "; " + steps[i] + " || true"
+			continue
+		}
+
+		script += "; " + steps[i] + " || status=1"
+	}
+
+	return types.DaggerCMD{"sh", "-c", script + `; exit "$status"`}, nil
+}
@@ -0,0 +1,67 @@
+package planx
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newScriptPlan returns a plan whose tasks print their identifier, the "fail" tasks then failing.
+func newScriptPlan(t *testing.T, policy FailurePolicy, ids ...string) *Plan {
+	t.Helper()
+
+	p := NewPlan("script").WithFailurePolicy(policy)
+	for _, id := range ids {
+		cmd := types.DaggerCMD{"sh", "-c", `echo "$ID"`}
+		if id == "fail" {
+			cmd = types.DaggerCMD{"sh", "-c", `echo "$ID"; exit 3`}
+		}
+		require.NoError(t, p.AddTask(Task{ID: id, Command: cmd, Env: []types.DaggerEnvVars{{Name: "ID", Value: id}}}))
+	}
+
+	return p
+}
+
+// runScript runs the script of 'p' and returns its output and whether it succeeded.
+func runScript(t *testing.T, p *Plan) (string, bool) {
+	t.Helper()
+
+	cmd, err := p.Script()
+	require.NoError(t, err)
+
+	out, err := exec.Command(cmd[0], cmd[1:]...).Output()
+	return string(out), err == nil
+}
+
+func TestScript(t *testing.T) {
+	cmd, err := newScriptPlan(t, PolicyFailFast, "a", "b").Script()
+	require.NoError(t, err)
+	assert.Equal(t, types.DaggerCMD{"sh", "-c",
+		`env ID=a sh -c 'echo "$ID"' && env ID=b sh -c 'echo "$ID"'`}, cmd)
+
+	out, ok := runScript(t, newScriptPlan(t, PolicyFailFast, "a", "fail", "b"))
+	assert.False(t, ok)
+	assert.Equal(t, "a\nfail\n", out)
+
+	out, ok = runScript(t, newScriptPlan(t, PolicyContinueOnError, "a", "fail", "b"))
+	assert.False(t, ok)
+	assert.Equal(t, "a\nfail\nb\n", out)
+
+	out, ok = runScript(t, newScriptPlan(t, PolicyBestEffort, "a", "fail", "b").WithRequired("a"))
+	assert.True(t, ok)
+	assert.Equal(t, "a\nfail\nb\n", out)
+
+	_, ok = runScript(t, newScriptPlan(t, PolicyBestEffort, "a", "fail").WithRequired("fail"))
+	assert.False(t, ok)
+}
+
+func TestScriptErrors(t *testing.T) {
+	_, err := NewPlan("empty").Script()
+	assert.EqualError(t, err, "plan empty has no tasks")
+
+	_, err = newScriptPlan(t, PolicyBestEffort, "a").WithRequired("b").Script()
+	assert.EqualError(t, err, "plan script requires unknown task b")
+}
@@ -0,0 +1,137 @@
+// Package platformx provides the platform helpers of developers driving Linux builds from macOS
+// or Windows hosts: the host platform, which the tools installed locally must match (e.g.
+// darwin/arm64 on Apple silicon), the Linux platform of the containers the builds run in, the
+// normalization of the architecture names of the tools (x86_64, aarch64) to Go's (amd64,
+// arm64), and the paths of containers, which are slash-separated whatever the host.
+//
+// Example usage:
+//
+//	// A binary for the host, e.g. darwin/arm64.
+//	local, err := installerx.GetApkoInstallCommand(installerx.ApkoInstallParams{
+//	    Version: "0.19.6",
+//	    OS:      platformx.Host().OS,
+//	    Arch:    platformx.Host().Arch,
+//	})
+//
+//	// A binary for the containers, e.g. linux/arm64.
+//	target := platformx.ContainerTarget()
+//
+//	// "/mnt/src/app", even on Windows hosts.
+//	dir := platformx.ContainerPath(fixtures.MntPrefix, `src\app`)
+package platformx
+
+import (
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// builderName identifies the platform helpers in errorsx errors.
+const builderName = "platform"
+
+const (
+	// OSLinux is the operating system of the containers.
+	OSLinux = "linux"
+	// OSDarwin is the operating system of macOS hosts.
+	OSDarwin = "darwin"
+	// OSWindows is the operating system of Windows hosts.
+	OSWindows = "windows"
+)
+
+// archAliases maps the architecture names of the tools (uname, apko, Docker) to Go's.
+var archAliases = map[string]string{
+	"x86_64":  "amd64",
+	"x86-64":  "amd64",
+	"x64":     "amd64",
+	"aarch64": "arm64",
+	"armv8":   "arm64",
+	"i386":    "386",
+	"i686":    "386",
+}
+
+// Platform is an operating system and an architecture, with Go's names (e.g. "linux/amd64").
+type Platform struct {
+	// OS is the operating system, e.g. "linux" or "darwin".
+	OS string
+	// Arch is the architecture, e.g. "amd64" or "arm64".
+	Arch string
+}
+
+// String returns the platform as "os/arch".
+func (p Platform) String() string {
+	return p.OS + "/" + p.Arch
+}
+
+// ExeSuffix returns the suffix of the executables of the platform: ".exe" on Windows, none
+// otherwise.
+func (p Platform) ExeSuffix() string {
+	if p.OS == OSWindows {
+		return ".exe"
+	}
+
+	return ""
+}
+
+// Host returns the platform of the host running the code, the one the tools installed locally
+// must be built for.
+func Host() Platform {
+	return Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
+}
+
+// ContainerTarget returns the platform of the containers the host runs natively: Linux, on the
+// architecture of the host (e.g. linux/arm64 on Apple silicon, under Docker Desktop).
+func ContainerTarget() Platform {
+	return Platform{OS: OSLinux, Arch: runtime.GOARCH}
+}
+
+// NormalizeArch returns the Go name of the architecture 'arch' (e.g. "amd64" for "x86_64",
+// "arm64" for "aarch64"). Other names are returned lowercased.
+func NormalizeArch(arch string) string {
+	arch = strings.ToLower(strings.TrimSpace(arch))
+	if alias, ok := archAliases[arch]; ok {
+		return alias
+	}
+
+	return arch
+}
+
+// ParsePlatform parses a platform given as "os/arch" (e.g. "darwin/arm64"), or as an
+// architecture alone, which is a Linux one. The architecture is normalized (see NormalizeArch).
+//
+// Returns:
+//   - The platform.
+//   - An error if the platform is empty or malformed.
+func ParsePlatform(s string) (Platform, error) {
+	value := strings.ToLower(strings.TrimSpace(s))
+	if value == "" {
+		return Platform{}, errorsx.MissingField(builderName, "platform", "platform is required")
+	}
+
+	osName, arch, found := strings.Cut(value, "/")
+	if !found {
+		osName, arch = OSLinux, value
+	}
+
+	if osName == "" || arch == "" || strings.Contains(arch, "/") {
+		return Platform{}, errorsx.InvalidValue(builderName, "platform", s,
+			"invalid platform %s, expected os/arch", s)
+	}
+
+	return Platform{OS: osName, Arch: NormalizeArch(arch)}, nil
+}
+
+// ContainerPath joins the path elements 'elem' into a path of a container: slash-separated and
+// cleaned, whatever the separator of the host. Use it instead of filepath.Join for the paths
+// passed to commands run in containers, which filepath.Join would separate with backslashes on
+// Windows hosts.
+func ContainerPath(elem ...string) string {
+	parts := make([]string, len(elem))
+	for i, e := range elem {
+		parts[i] = filepath.ToSlash(e)
+	}
+
+	return path.Join(parts...)
+}
@@ -0,0 +1,62 @@
+package platformx
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostAndContainerTarget(t *testing.T) {
+	assert.Equal(t, Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}, Host())
+	assert.Equal(t, Platform{OS: OSLinux, Arch: runtime.GOARCH}, ContainerTarget())
+}
+
+func TestPlatform(t *testing.T) {
+	assert.Equal(t, "darwin/arm64", Platform{OS: OSDarwin, Arch: "arm64"}.String())
+	assert.Equal(t, ".exe", Platform{OS: OSWindows, Arch: "amd64"}.ExeSuffix())
+	assert.Empty(t, Platform{OS: OSLinux, Arch: "amd64"}.ExeSuffix())
+}
+
+func TestNormalizeArch(t *testing.T) {
+	tests := map[string]string{
+		"x86_64":  "amd64",
+		"AARCH64": "arm64",
+		"amd64":   "amd64",
+		" arm64 ": "arm64",
+		"s390x":   "s390x",
+	}
+
+	for arch, want := range tests {
+		assert.Equal(t, want, NormalizeArch(arch), arch)
+	}
+}
+
+func TestParsePlatform(t *testing.T) {
+	p, err := ParsePlatform("darwin/aarch64")
+	require.NoError(t, err)
+	assert.Equal(t, Platform{OS: OSDarwin, Arch: "arm64"}, p)
+
+	p, err = ParsePlatform("x86_64")
+	require.NoError(t, err)
+	assert.Equal(t, Platform{OS: OSLinux, Arch: "amd64"}, p)
+
+	_, err = ParsePlatform("")
+	require.ErrorIs(t, err, errorsx.ErrMissingField)
+
+	for _, s := range []string{"linux/", "/amd64", "linux/arm/v7"} {
+		_, err = ParsePlatform(s)
+		require.ErrorIs(t, err, errorsx.ErrInvalidValue, s)
+	}
+}
+
+func TestContainerPath(t *testing.T) {
+	assert.Equal(t, "/mnt/src/app", ContainerPath("/mnt", "src", "app"))
+	assert.Equal(t, "/mnt/app", ContainerPath("/mnt/", "./src/../app"))
+
+	elem := []string{"/mnt", "src"}
+	ContainerPath(elem...)
+	assert.Equal(t, []string{"/mnt", "src"}, elem, "the elements are not modified")
+}
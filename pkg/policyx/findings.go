@@ -0,0 +1,259 @@
+package policyx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Severity is the severity of a vulnerability finding. Severities are ordered, so they can be
+// compared with the usual operators.
+type Severity int
+
+const (
+	// SeverityUnknown is the severity of findings without a known severity.
+	SeverityUnknown Severity = iota
+	// SeverityNegligible is the negligible severity.
+	SeverityNegligible
+	// SeverityLow is the low severity.
+	SeverityLow
+	// SeverityMedium is the medium severity.
+	SeverityMedium
+	// SeverityHigh is the high severity.
+	SeverityHigh
+	// SeverityCritical is the critical severity.
+	SeverityCritical
+)
+
+// severityNames maps severities to their names.
+var severityNames = map[Severity]string{
+	SeverityUnknown:    "unknown",
+	SeverityNegligible: "negligible",
+	SeverityLow:        "low",
+	SeverityMedium:     "medium",
+	SeverityHigh:       "high",
+	SeverityCritical:   "critical",
+}
+
+// String returns the lowercase name of the severity.
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+
+	return severityNames[SeverityUnknown]
+}
+
+// MarshalText encodes the severity as its name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity name.
+func (s *Severity) UnmarshalText(text []byte) error {
+	*s = ParseSeverity(string(text))
+	return nil
+}
+
+// ParseSeverity parses a severity name, case-insensitively. Unrecognized names map to
+// SeverityUnknown.
+func ParseSeverity(name string) Severity {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "negligible":
+		return SeverityNegligible
+	case "low":
+		return SeverityLow
+	case "medium", "moderate":
+		return SeverityMedium
+	case "high":
+		return SeverityHigh
+	case "critical":
+		return SeverityCritical
+	default:
+		return SeverityUnknown
+	}
+}
+
+// Finding is a vulnerability finding reported by a scanner.
+type Finding struct {
+	// ID is the vulnerability identifier (e.g. "CVE-2024-0001").
+	ID string `json:"id"`
+	// Package is the name of the affected package.
+	Package string `json:"package"`
+	// Version is the installed version of the affected package.
+	Version string `json:"version"`
+	// Severity is the severity of the vulnerability.
+	Severity Severity `json:"severity"`
+}
+
+// grypeReport is the subset of a grype JSON report used to extract findings.
+type grypeReport struct {
+	Matches []struct {
+		Vulnerability struct {
+			ID       string `json:"id"`
+			Severity string `json:"severity"`
+		} `json:"vulnerability"`
+		Artifact struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"artifact"`
+	} `json:"matches"`
+}
+
+// trivyReport is the subset of a trivy JSON report used to extract findings.
+type trivyReport struct {
+	Results []struct {
+		Vulnerabilities []struct {
+			VulnerabilityID  string `json:"VulnerabilityID"`
+			PkgName          string `json:"PkgName"`
+			InstalledVersion string `json:"InstalledVersion"`
+			Severity         string `json:"Severity"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+// ParseGrypeReport extracts the findings of a grype JSON report (`grype -o json`).
+func ParseGrypeReport(data []byte) ([]Finding, error) {
+	var report grypeReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("invalid grype report: %w", err)
+	}
+
+	findings := make([]Finding, 0, len(report.Matches))
+	for _, m := range report.Matches {
+		findings = append(findings, Finding{
+			ID:       m.Vulnerability.ID,
+			Package:  m.Artifact.Name,
+			Version:  m.Artifact.Version,
+			Severity: ParseSeverity(m.Vulnerability.Severity),
+		})
+	}
+
+	return findings, nil
+}
+
+// ParseTrivyReport extracts the findings of a trivy JSON report (`trivy image -f json`).
+func ParseTrivyReport(data []byte) ([]Finding, error) {
+	var report trivyReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("invalid trivy report: %w", err)
+	}
+
+	var findings []Finding
+	for _, r := range report.Results {
+		for _, v := range r.Vulnerabilities {
+			findings = append(findings, Finding{
+				ID:       v.VulnerabilityID,
+				Package:  v.PkgName,
+				Version:  v.InstalledVersion,
+				Severity: ParseSeverity(v.Severity),
+			})
+		}
+	}
+
+	return findings, nil
+}
+
+// sarifReport is the subset of a SARIF 2.1.0 log used to extract findings.
+type sarifReport struct {
+	Runs []struct {
+		Tool struct {
+			Driver struct {
+				Rules []struct {
+					ID         string `json:"id"`
+					Properties struct {
+						SecuritySeverity string `json:"security-severity"`
+					} `json:"properties"`
+				} `json:"rules"`
+			} `json:"driver"`
+		} `json:"tool"`
+		Results []struct {
+			RuleID    string `json:"ruleId"`
+			Level     string `json:"level"`
+			Locations []struct {
+				PhysicalLocation struct {
+					ArtifactLocation struct {
+						URI string `json:"uri"`
+					} `json:"artifactLocation"`
+					Region struct {
+						StartLine int `json:"startLine"`
+					} `json:"region"`
+				} `json:"physicalLocation"`
+			} `json:"locations"`
+		} `json:"results"`
+	} `json:"runs"`
+}
+
+// ParseSARIFReport extracts the findings of a SARIF log, as written by IaC and code scanners
+// (checkov, tfsec, trivy config). The package of a finding is the location of the result
+// (e.g. "main.tf:12"), and its severity is derived from the security-severity score of its
+// rule or, without one, from the level of the result (error: high, warning: medium, note: low).
+func ParseSARIFReport(data []byte) ([]Finding, error) {
+	var report sarifReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("invalid SARIF report: %w", err)
+	}
+
+	var findings []Finding
+	for _, run := range report.Runs {
+		scores := map[string]string{}
+		for _, r := range run.Tool.Driver.Rules {
+			scores[r.ID] = r.Properties.SecuritySeverity
+		}
+
+		for _, r := range run.Results {
+			f := Finding{ID: r.RuleID, Severity: sarifSeverity(scores[r.RuleID], r.Level)}
+			if len(r.Locations) > 0 {
+				loc := r.Locations[0].PhysicalLocation
+				f.Package = loc.ArtifactLocation.URI
+				if loc.Region.StartLine > 0 {
+					f.Package += ":" + strconv.Itoa(loc.Region.StartLine)
+				}
+			}
+			findings = append(findings, f)
+		}
+	}
+
+	return findings, nil
+}
+
+// sarifSeverity returns the severity of a SARIF result from the security-severity score of its
+// rule (the CVSS ranges) or its level.
+func sarifSeverity(score, level string) Severity {
+	if s, err := strconv.ParseFloat(score, 64); err == nil {
+		switch {
+		case s >= 9:
+			return SeverityCritical
+		case s >= 7:
+			return SeverityHigh
+		case s >= 4:
+			return SeverityMedium
+		case s > 0:
+			return SeverityLow
+		}
+	}
+
+	switch level {
+	case "error", "":
+		return SeverityHigh
+	case "warning":
+		return SeverityMedium
+	case "note":
+		return SeverityLow
+	default:
+		return SeverityUnknown
+	}
+}
+
+// MaxSeverity returns the highest severity among the findings.
+func MaxSeverity(findings []Finding) Severity {
+	maxSeverity := SeverityUnknown
+	for _, f := range findings {
+		if f.Severity > maxSeverity {
+			maxSeverity = f.Severity
+		}
+	}
+
+	return maxSeverity
+}
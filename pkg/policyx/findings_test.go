@@ -0,0 +1,158 @@
+package policyx
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		name string
+		want Severity
+	}{
+		{"Critical", SeverityCritical},
+		{"HIGH", SeverityHigh},
+		{"moderate", SeverityMedium},
+		{"low", SeverityLow},
+		{"Negligible", SeverityNegligible},
+		{"", SeverityUnknown},
+		{"bogus", SeverityUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseSeverity(tt.name); got != tt.want {
+				t.Errorf("ParseSeverity(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeverityJSON(t *testing.T) {
+	data, err := json.Marshal(Finding{ID: "CVE-1", Severity: SeverityHigh})
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error: %v", err)
+	}
+
+	var f Finding
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatalf("Unmarshal returned unexpected error: %v", err)
+	}
+
+	if f.Severity != SeverityHigh {
+		t.Errorf("Expected severity high after round trip, got %v (%s)", f.Severity, data)
+	}
+}
+
+func TestParseGrypeReport(t *testing.T) {
+	report := `{"matches": [
+		{"vulnerability": {"id": "CVE-2024-0001", "severity": "High"}, "artifact": {"name": "openssl", "version": "3.1.0-r0"}},
+		{"vulnerability": {"id": "CVE-2024-0002", "severity": "Low"}, "artifact": {"name": "zlib", "version": "1.3-r0"}}
+	]}`
+
+	findings, err := ParseGrypeReport([]byte(report))
+	if err != nil {
+		t.Fatalf("ParseGrypeReport returned unexpected error: %v", err)
+	}
+
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 findings, got %d", len(findings))
+	}
+
+	want := Finding{ID: "CVE-2024-0001", Package: "openssl", Version: "3.1.0-r0", Severity: SeverityHigh}
+	if findings[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, findings[0])
+	}
+
+	if MaxSeverity(findings) != SeverityHigh {
+		t.Errorf("Expected max severity high, got %v", MaxSeverity(findings))
+	}
+
+	if _, err := ParseGrypeReport([]byte("{")); err == nil {
+		t.Error("Expected error for invalid report, got none")
+	}
+}
+
+func TestParseTrivyReport(t *testing.T) {
+	report := `{"Results": [
+		{"Target": "image", "Vulnerabilities": [
+			{"VulnerabilityID": "CVE-2024-0003", "PkgName": "busybox", "InstalledVersion": "1.36-r0", "Severity": "CRITICAL"}
+		]},
+		{"Target": "app"}
+	]}`
+
+	findings, err := ParseTrivyReport([]byte(report))
+	if err != nil {
+		t.Fatalf("ParseTrivyReport returned unexpected error: %v", err)
+	}
+
+	if len(findings) != 1 || findings[0].Severity != SeverityCritical || findings[0].Package != "busybox" {
+		t.Errorf("Unexpected findings %+v", findings)
+	}
+
+	if _, err := ParseTrivyReport([]byte("[")); err == nil {
+		t.Error("Expected error for invalid report, got none")
+	}
+}
+
+func TestParseSARIFReport(t *testing.T) {
+	report := `{"version": "2.1.0", "runs": [{
+		"tool": {"driver": {"name": "trivy", "rules": [
+			{"id": "AVD-AWS-0086", "properties": {"security-severity": "8.0"}},
+			{"id": "AVD-AWS-0089", "properties": {"security-severity": "2.0"}}
+		]}},
+		"results": [
+			{"ruleId": "AVD-AWS-0086", "level": "error", "locations": [{"physicalLocation": {
+				"artifactLocation": {"uri": "s3.tf"}, "region": {"startLine": 12}}}]},
+			{"ruleId": "AVD-AWS-0089", "level": "note", "locations": [{"physicalLocation": {
+				"artifactLocation": {"uri": "s3.tf"}}}]},
+			{"ruleId": "CKV_AWS_20", "level": "warning"}
+		]
+	}]}`
+
+	findings, err := ParseSARIFReport([]byte(report))
+	if err != nil {
+		t.Fatalf("ParseSARIFReport returned unexpected error: %v", err)
+	}
+
+	want := []Finding{
+		{ID: "AVD-AWS-0086", Package: "s3.tf:12", Severity: SeverityHigh},
+		{ID: "AVD-AWS-0089", Package: "s3.tf", Severity: SeverityLow},
+		{ID: "CKV_AWS_20", Severity: SeverityMedium},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("Expected %d findings, got %+v", len(want), findings)
+	}
+	for i := range want {
+		if findings[i] != want[i] {
+			t.Errorf("Finding %d = %+v, want %+v", i, findings[i], want[i])
+		}
+	}
+
+	if _, err := ParseSARIFReport([]byte("[")); err == nil {
+		t.Error("Expected error for invalid report, got none")
+	}
+}
+
+func TestSARIFSeverity(t *testing.T) {
+	tests := []struct {
+		score, level string
+		want         Severity
+	}{
+		{"9.8", "warning", SeverityCritical},
+		{"7.0", "", SeverityHigh},
+		{"5.5", "error", SeverityMedium},
+		{"0.1", "error", SeverityLow},
+		{"", "error", SeverityHigh},
+		{"", "", SeverityHigh},
+		{"", "warning", SeverityMedium},
+		{"", "note", SeverityLow},
+		{"", "none", SeverityUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := sarifSeverity(tt.score, tt.level); got != tt.want {
+			t.Errorf("sarifSeverity(%q, %q) = %s, want %s", tt.score, tt.level, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// Package policyx provides a small policy engine for gate steps in pipelines. It evaluates the
+// structured results produced by other daggerx packages (image size, labels, installed packages,
+// vulnerability findings, base image references) and returns pass/fail with the reasons.
+//
+// Example usage:
+//
+//	findings, err := policyx.ParseGrypeReport(report)
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	p := policyx.NewPolicy().
+//	    WithMaxSize(200 * 1024 * 1024).
+//	    WithRequiredLabels("org.opencontainers.image.source").
+//	    WithForbiddenPackages("apk-tools").
+//	    WithMaxSeverity(policyx.SeverityHigh).
+//	    WithDigestPinnedBaseRefs()
+//
+//	result := p.Evaluate(policyx.Input{Findings: findings, BaseRefs: []string{"cgr.dev/chainguard/static:latest"}})
+//	if !result.Passed {
+//	    return result.Err()
+//	}
+package policyx
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule identifies a policy rule.
+type Rule string
+
+const (
+	// RuleMaxSize limits the image size.
+	RuleMaxSize Rule = "max-size"
+	// RuleRequiredLabel requires a label to be set.
+	RuleRequiredLabel Rule = "required-label"
+	// RuleForbiddenPackage forbids a package from being installed.
+	RuleForbiddenPackage Rule = "forbidden-package"
+	// RuleMaxSeverity limits the severity of vulnerability findings.
+	RuleMaxSeverity Rule = "max-severity"
+	// RuleDigestPinned requires base image references to be pinned by digest.
+	RuleDigestPinned Rule = "digest-pinned"
+)
+
+// pinnedRefRegex matches image references pinned by a sha256 digest.
+var pinnedRefRegex = regexp.MustCompile(`@sha256:[a-fA-F0-9]{64}$`)
+
+// Input holds the results evaluated by a policy.
+type Input struct {
+	// SizeBytes is the size of the image, in bytes.
+	SizeBytes int64
+	// Labels holds the labels of the image.
+	Labels map[string]string
+	// Packages holds the names of the packages installed in the image.
+	Packages []string
+	// Findings holds the vulnerability findings of the image.
+	Findings []Finding
+	// BaseRefs holds the base image references used by the build.
+	BaseRefs []string
+}
+
+// Violation describes a rule the input does not satisfy.
+type Violation struct {
+	// Rule is the violated rule.
+	Rule Rule `json:"rule"`
+	// Message explains the violation.
+	Message string `json:"message"`
+}
+
+// Result is the outcome of a policy evaluation.
+type Result struct {
+	// Passed reports whether the input satisfies every rule.
+	Passed bool `json:"passed"`
+	// Violations holds the violated rules, in evaluation order.
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// Err returns an error listing the violations, or nil if the evaluation passed.
+func (r Result) Err() error {
+	if r.Passed {
+		return nil
+	}
+
+	msgs := make([]string, 0, len(r.Violations))
+	for _, v := range r.Violations {
+		msgs = append(msgs, fmt.Sprintf("[%s] %s", v.Rule, v.Message))
+	}
+
+	return fmt.Errorf("policy failed: %s", strings.Join(msgs, "; "))
+}
+
+// Policy holds the rules evaluated against an Input. Rules left unset are not evaluated.
+type Policy struct {
+	maxSizeBytes        int64
+	requiredLabels      []string
+	forbiddenPackages   []string
+	maxSeverity         Severity
+	requireDigestPinned bool
+}
+
+// NewPolicy creates a Policy without rules.
+func NewPolicy() *Policy {
+	return &Policy{}
+}
+
+// WithMaxSize limits the image size to 'bytes'.
+func (p *Policy) WithMaxSize(bytes int64) *Policy {
+	p.maxSizeBytes = bytes
+	return p
+}
+
+// WithRequiredLabels requires the given labels to be set to a non-empty value.
+func (p *Policy) WithRequiredLabels(labels ...string) *Policy {
+	p.requiredLabels = append(p.requiredLabels, labels...)
+	return p
+}
+
+// WithForbiddenPackages forbids the given packages from being installed.
+func (p *Policy) WithForbiddenPackages(packages ...string) *Policy {
+	p.forbiddenPackages = append(p.forbiddenPackages, packages...)
+	return p
+}
+
+// WithMaxSeverity fails the evaluation on findings more severe than 'severity'.
+func (p *Policy) WithMaxSeverity(severity Severity) *Policy {
+	p.maxSeverity = severity
+	return p
+}
+
+// WithDigestPinnedBaseRefs requires every base image reference to be pinned by digest.
+func (p *Policy) WithDigestPinnedBaseRefs() *Policy {
+	p.requireDigestPinned = true
+	return p
+}
+
+// Evaluate evaluates the policy rules against the input.
+func (p *Policy) Evaluate(in Input) Result {
+	var violations []Violation
+
+	if p.maxSizeBytes > 0 && in.SizeBytes > p.maxSizeBytes {
+		violations = append(violations, Violation{
+			Rule:    RuleMaxSize,
+			Message: fmt.Sprintf("image is %d bytes, exceeding the maximum of %d bytes", in.SizeBytes, p.maxSizeBytes),
+		})
+	}
+
+	for _, label := range p.requiredLabels {
+		if in.Labels[label] == "" {
+			violations = append(violations, Violation{
+				Rule:    RuleRequiredLabel,
+				Message: fmt.Sprintf("label %s is required", label),
+			})
+		}
+	}
+
+	installed := make(map[string]bool, len(in.Packages))
+	for _, pkg := range in.Packages {
+		installed[pkg] = true
+	}
+	for _, pkg := range p.forbiddenPackages {
+		if installed[pkg] {
+			violations = append(violations, Violation{
+				Rule:    RuleForbiddenPackage,
+				Message: fmt.Sprintf("package %s is forbidden", pkg),
+			})
+		}
+	}
+
+	if p.maxSeverity != SeverityUnknown {
+		violations = append(violations, p.severityViolations(in.Findings)...)
+	}
+
+	if p.requireDigestPinned {
+		for _, ref := range in.BaseRefs {
+			if !pinnedRefRegex.MatchString(ref) {
+				violations = append(violations, Violation{
+					Rule:    RuleDigestPinned,
+					Message: fmt.Sprintf("base image %s is not pinned by digest", ref),
+				})
+			}
+		}
+	}
+
+	return Result{
+		Passed:     len(violations) == 0,
+		Violations: violations,
+	}
+}
+
+// severityViolations returns one violation per finding above the maximum severity, the most
+// severe first.
+func (p *Policy) severityViolations(findings []Finding) []Violation {
+	var exceeding []Finding
+	for _, f := range findings {
+		if f.Severity > p.maxSeverity {
+			exceeding = append(exceeding, f)
+		}
+	}
+
+	sort.SliceStable(exceeding, func(i, j int) bool {
+		return exceeding[i].Severity > exceeding[j].Severity
+	})
+
+	violations := make([]Violation, 0, len(exceeding))
+	for _, f := range exceeding {
+		violations = append(violations, Violation{
+			Rule: RuleMaxSeverity,
+			Message: fmt.Sprintf("%s in %s has severity %s, above the maximum of %s",
+				f.ID, strings.TrimSpace(f.Package+" "+f.Version), f.Severity, p.maxSeverity),
+		})
+	}
+
+	return violations
+}
@@ -0,0 +1,94 @@
+package policyx
+
+import (
+	"strings"
+	"testing"
+)
+
+const pinnedRef = "cgr.dev/chainguard/static@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestPolicyEvaluate(t *testing.T) {
+	p := NewPolicy().
+		WithMaxSize(100).
+		WithRequiredLabels("org.opencontainers.image.source").
+		WithForbiddenPackages("apk-tools").
+		WithMaxSeverity(SeverityMedium).
+		WithDigestPinnedBaseRefs()
+
+	tests := []struct {
+		name      string
+		input     Input
+		wantRules []Rule
+	}{
+		{
+			name: "Compliant input",
+			input: Input{
+				SizeBytes: 50,
+				Labels:    map[string]string{"org.opencontainers.image.source": "https://github.com/x/y"},
+				Packages:  []string{"busybox"},
+				Findings:  []Finding{{ID: "CVE-1", Severity: SeverityMedium}},
+				BaseRefs:  []string{pinnedRef},
+			},
+		},
+		{
+			name: "Every rule violated",
+			input: Input{
+				SizeBytes: 500,
+				Packages:  []string{"apk-tools"},
+				Findings:  []Finding{{ID: "CVE-1", Severity: SeverityHigh}, {ID: "CVE-2", Severity: SeverityCritical}},
+				BaseRefs:  []string{"cgr.dev/chainguard/static:latest"},
+			},
+			wantRules: []Rule{RuleMaxSize, RuleRequiredLabel, RuleForbiddenPackage, RuleMaxSeverity, RuleMaxSeverity, RuleDigestPinned},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := p.Evaluate(tt.input)
+
+			if res.Passed != (len(tt.wantRules) == 0) {
+				t.Errorf("Passed = %v, violations %+v", res.Passed, res.Violations)
+			}
+
+			if len(res.Violations) != len(tt.wantRules) {
+				t.Fatalf("Expected %d violations, got %+v", len(tt.wantRules), res.Violations)
+			}
+
+			for i, v := range res.Violations {
+				if v.Rule != tt.wantRules[i] {
+					t.Errorf("Violation %d: expected rule %s, got %s", i, tt.wantRules[i], v.Rule)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicySeverityOrder(t *testing.T) {
+	res := NewPolicy().WithMaxSeverity(SeverityLow).Evaluate(Input{
+		Findings: []Finding{{ID: "CVE-1", Severity: SeverityMedium}, {ID: "CVE-2", Severity: SeverityCritical}},
+	})
+
+	if len(res.Violations) != 2 || !strings.HasPrefix(res.Violations[0].Message, "CVE-2") {
+		t.Errorf("Expected the critical finding first, got %+v", res.Violations)
+	}
+}
+
+func TestPolicyWithoutRules(t *testing.T) {
+	res := NewPolicy().Evaluate(Input{SizeBytes: 1 << 40, BaseRefs: []string{"alpine"}})
+	if !res.Passed || res.Err() != nil {
+		t.Errorf("Expected a policy without rules to pass, got %+v", res)
+	}
+}
+
+func TestResultErr(t *testing.T) {
+	res := NewPolicy().WithRequiredLabels("version").Evaluate(Input{})
+
+	err := res.Err()
+	if err == nil {
+		t.Fatal("Expected error, got none")
+	}
+
+	if !strings.Contains(err.Error(), "[required-label] label version is required") {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+// Package portx provides helpers to declare the ports, protocols and health endpoints of service
+// containers generated in pipelines, and to convert them to the arguments Dagger's
+// Container.WithExposedPort expects.
+//
+// Example usage:
+//
+//	port, err := portx.ParsePort("8080/tcp")
+//	if err != nil {
+//	    // handle error
+//	}
+//	port.Description = "HTTP API"
+//	port.Health = &portx.HealthEndpoint{Path: "/healthz"}
+//
+//	ctr, err = portx.Expose(ctr, port)
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	fmt.Println(port.HealthURL("api")) // Output: http://api:8080/healthz
+package portx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"dagger.io/dagger"
+)
+
+// Protocol is the network protocol of an exposed port.
+type Protocol string
+
+const (
+	// ProtocolTCP is the TCP protocol.
+	ProtocolTCP Protocol = "tcp"
+	// ProtocolUDP is the UDP protocol.
+	ProtocolUDP Protocol = "udp"
+)
+
+// HealthEndpoint describes an HTTP endpoint used to check the health of a service.
+type HealthEndpoint struct {
+	// Path is the HTTP path of the endpoint, starting with '/'.
+	Path string
+	// Scheme is the URL scheme, "http" or "https". Defaults to "http".
+	Scheme string
+}
+
+// Port describes a port exposed by a service container.
+type Port struct {
+	// Number is the port number.
+	Number int
+	// Protocol is the network protocol. Defaults to ProtocolTCP.
+	Protocol Protocol
+	// Description is a human-readable description of the port.
+	Description string
+	// Health is the optional HTTP health endpoint served on the port.
+	Health *HealthEndpoint
+	// SkipHealthcheck disables Dagger's built-in port healthcheck when the container runs as a service.
+	SkipHealthcheck bool
+}
+
+// ParsePort parses a port declaration in the "number[/protocol]" format, e.g. "8080" or "53/udp".
+//
+// Returns:
+//   - The parsed Port.
+//   - An error if the number or the protocol is invalid.
+func ParsePort(s string) (Port, error) {
+	number, proto, _ := strings.Cut(strings.TrimSpace(s), "/")
+
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return Port{}, fmt.Errorf("invalid port number: %q", number)
+	}
+
+	p := Port{
+		Number:   n,
+		Protocol: Protocol(strings.ToLower(proto)),
+	}
+
+	if p.Protocol == "" {
+		p.Protocol = ProtocolTCP
+	}
+
+	if err := p.Validate(); err != nil {
+		return Port{}, err
+	}
+
+	return p, nil
+}
+
+// Validate checks that the port declaration is valid.
+func (p *Port) Validate() error {
+	if p.Number < 1 || p.Number > 65535 {
+		return fmt.Errorf("port number must be between 1 and 65535: %d", p.Number)
+	}
+
+	switch p.Protocol {
+	case "", ProtocolTCP, ProtocolUDP:
+	default:
+		return fmt.Errorf("unsupported protocol for port %d: %s", p.Number, p.Protocol)
+	}
+
+	if p.Health != nil {
+		if p.Protocol == ProtocolUDP {
+			return fmt.Errorf("health endpoint is not supported on UDP port %d", p.Number)
+		}
+
+		if !strings.HasPrefix(p.Health.Path, "/") {
+			return fmt.Errorf("health endpoint path must start with '/': %q", p.Health.Path)
+		}
+
+		switch p.Health.Scheme {
+		case "", "http", "https":
+		default:
+			return fmt.Errorf("unsupported health endpoint scheme: %s", p.Health.Scheme)
+		}
+	}
+
+	return nil
+}
+
+// String returns the port in the "number/protocol" format.
+func (p *Port) String() string {
+	proto := p.Protocol
+	if proto == "" {
+		proto = ProtocolTCP
+	}
+
+	return fmt.Sprintf("%d/%s", p.Number, proto)
+}
+
+// HealthURL returns the URL of the health endpoint on the given host, or an empty string if the
+// port has no health endpoint.
+func (p *Port) HealthURL(host string) string {
+	if p.Health == nil {
+		return ""
+	}
+
+	scheme := p.Health.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	return fmt.Sprintf("%s://%s:%d%s", scheme, host, p.Number, p.Health.Path)
+}
+
+// ToDaggerOpts converts the port to the arguments of Container.WithExposedPort.
+//
+// Returns:
+//   - The port number.
+//   - The exposed port options.
+//   - An error if the port is invalid.
+func (p *Port) ToDaggerOpts() (int, dagger.ContainerWithExposedPortOpts, error) {
+	if err := p.Validate(); err != nil {
+		return 0, dagger.ContainerWithExposedPortOpts{}, err
+	}
+
+	protocol := dagger.NetworkProtocolTcp
+	if p.Protocol == ProtocolUDP {
+		protocol = dagger.NetworkProtocolUdp
+	}
+
+	return p.Number, dagger.ContainerWithExposedPortOpts{
+		Protocol:                    protocol,
+		Description:                 p.Description,
+		ExperimentalSkipHealthcheck: p.SkipHealthcheck,
+	}, nil
+}
+
+// Expose exposes every port on the container.
+//
+// Returns:
+//   - The container with the ports exposed.
+//   - An error if a port is invalid or declared twice with the same protocol.
+func Expose(ctr *dagger.Container, ports ...Port) (*dagger.Container, error) {
+	if err := ValidatePorts(ports); err != nil {
+		return nil, err
+	}
+
+	for i := range ports {
+		number, opts, err := ports[i].ToDaggerOpts()
+		if err != nil {
+			return nil, err
+		}
+		ctr = ctr.WithExposedPort(number, opts)
+	}
+
+	return ctr, nil
+}
+
+// ValidatePorts validates every port and checks that no number/protocol pair is declared twice.
+func ValidatePorts(ports []Port) error {
+	seen := make(map[string]bool, len(ports))
+	for i := range ports {
+		if err := ports[i].Validate(); err != nil {
+			return err
+		}
+
+		key := ports[i].String()
+		if seen[key] {
+			return fmt.Errorf("port %s is declared more than once", key)
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package portx
+
+import (
+	"testing"
+
+	"dagger.io/dagger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePort(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Port
+		wantErr bool
+	}{
+		{input: "8080", want: Port{Number: 8080, Protocol: ProtocolTCP}},
+		{input: "53/udp", want: Port{Number: 53, Protocol: ProtocolUDP}},
+		{input: " 443/TCP ", want: Port{Number: 443, Protocol: ProtocolTCP}},
+		{input: "http", wantErr: true},
+		{input: "0", wantErr: true},
+		{input: "70000", wantErr: true},
+		{input: "8080/sctp", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParsePort(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPortValidateHealth(t *testing.T) {
+	tests := []struct {
+		name    string
+		port    Port
+		wantErr string
+	}{
+		{
+			name: "Valid health endpoint",
+			port: Port{Number: 8080, Health: &HealthEndpoint{Path: "/healthz", Scheme: "https"}},
+		},
+		{
+			name:    "Relative path",
+			port:    Port{Number: 8080, Health: &HealthEndpoint{Path: "healthz"}},
+			wantErr: `health endpoint path must start with '/': "healthz"`,
+		},
+		{
+			name:    "UDP port",
+			port:    Port{Number: 53, Protocol: ProtocolUDP, Health: &HealthEndpoint{Path: "/"}},
+			wantErr: "health endpoint is not supported on UDP port 53",
+		},
+		{
+			name:    "Unsupported scheme",
+			port:    Port{Number: 8080, Health: &HealthEndpoint{Path: "/", Scheme: "grpc"}},
+			wantErr: "unsupported health endpoint scheme: grpc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.port.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestHealthURL(t *testing.T) {
+	p := Port{Number: 8080, Health: &HealthEndpoint{Path: "/healthz"}}
+	assert.Equal(t, "http://api:8080/healthz", p.HealthURL("api"))
+
+	p = Port{Number: 8080}
+	assert.Empty(t, p.HealthURL("api"))
+}
+
+func TestToDaggerOpts(t *testing.T) {
+	p := Port{Number: 53, Protocol: ProtocolUDP, Description: "dns", SkipHealthcheck: true}
+
+	number, opts, err := p.ToDaggerOpts()
+	require.NoError(t, err)
+	assert.Equal(t, 53, number)
+	assert.Equal(t, dagger.ContainerWithExposedPortOpts{
+		Protocol:                    dagger.NetworkProtocolUdp,
+		Description:                 "dns",
+		ExperimentalSkipHealthcheck: true,
+	}, opts)
+
+	p = Port{Number: 8080}
+	_, opts, err = p.ToDaggerOpts()
+	require.NoError(t, err)
+	assert.Equal(t, dagger.NetworkProtocolTcp, opts.Protocol)
+}
+
+func TestValidatePorts(t *testing.T) {
+	assert.NoError(t, ValidatePorts([]Port{{Number: 53}, {Number: 53, Protocol: ProtocolUDP}}))
+	assert.EqualError(t, ValidatePorts([]Port{{Number: 8080}, {Number: 8080, Protocol: ProtocolTCP}}),
+		"port 8080/tcp is declared more than once")
+}
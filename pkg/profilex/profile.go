@@ -0,0 +1,291 @@
+// Package profilex provides profiles: named sets of organization defaults applied across the
+// builders of a delivery pipeline with one call, so platform teams can enforce them uniformly.
+//
+// The predefined profiles are "hardened" (SBOMs, non-root images, signed images and scans failing
+// on high vulnerabilities), "production" (hardened, refusing the development-only insecure options
+// of builds), "debug" (verbose logs) and "airgapped" (no network access: offline builds, scans and
+// verification from pre-populated caches and mirrors).
+//
+// Example usage:
+//
+//	profile, err := profilex.Get("hardened")
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	apko := apkox.NewApkoBuilder().WithConfigPreset(apkox.ConfigPresetStatic)
+//	trivy := trivyx.NewTrivyBuilder("registry.example.com/app:v1")
+//	if err := profile.Apply(apko, trivy); err != nil {
+//	    // handle error: unsupported builder
+//	}
+//
+//	grypeCmd := append([]string{"grype", "registry.example.com/app:v1"}, profile.GrypeFlags()...)
+//
+//	if err := profile.ValidatePipeline(p); err != nil {
+//	    // handle error: the pipeline doesn't sign the image
+//	}
+package profilex
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/cosignx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/pipeline"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/trivyx"
+)
+
+// builderName identifies the profiles in errorsx errors.
+const builderName = "profile"
+
+const (
+	// ProfileHardened is the name of the hardened profile.
+	ProfileHardened = "hardened"
+	// ProfileDebug is the name of the debug profile.
+	ProfileDebug = "debug"
+	// ProfileAirgapped is the name of the airgapped profile.
+	ProfileAirgapped = "airgapped"
+	// ProfileProduction is the name of the production profile.
+	ProfileProduction = "production"
+)
+
+// trivySeverities are the severities trivy reports, in increasing order.
+var trivySeverities = []policyx.Severity{
+	policyx.SeverityLow, policyx.SeverityMedium, policyx.SeverityHigh, policyx.SeverityCritical,
+}
+
+// Profile is a named set of options applied across builders. Zero fields leave the builder
+// options unchanged.
+type Profile struct {
+	// Name is the name of the profile.
+	Name string
+
+	// Offline builds images and verifies signatures without network access, and scans without
+	// updating the vulnerability databases.
+	Offline bool
+
+	// Repositories are the package repositories (e.g. internal mirrors) appended to apko builds.
+	Repositories []string
+
+	// SBOM generates SBOMs of apko builds.
+	SBOM bool
+
+	// RequireNonRoot requires apko images not to run as root (see apkox.WithRequireNonRoot).
+	RequireNonRoot bool
+
+	// LogLevel is the log level of apko builds.
+	LogLevel string
+
+	// FailOn fails scans and policies on findings of this severity or higher. SeverityUnknown
+	// sets no threshold.
+	FailOn policyx.Severity
+
+	// RequireSignature requires pipelines to sign their images with cosign.
+	RequireSignature bool
+
+	// Production refuses the development-only insecure options of apko builds (see
+	// apkox.WithProduction).
+	Production bool
+}
+
+// Hardened returns the hardened profile: SBOMs, non-root images, signed images and scans failing
+// on high vulnerabilities.
+func Hardened() Profile {
+	return Profile{
+		Name:             ProfileHardened,
+		SBOM:             true,
+		RequireNonRoot:   true,
+		FailOn:           policyx.SeverityHigh,
+		RequireSignature: true,
+	}
+}
+
+// Production returns the production profile: the hardened profile, refusing the development-only
+// insecure options of builds.
+func Production() Profile {
+	p := Hardened()
+	p.Name = ProfileProduction
+	p.Production = true
+
+	return p
+}
+
+// Debug returns the debug profile: verbose apko logs.
+func Debug() Profile {
+	return Profile{Name: ProfileDebug, LogLevel: "debug"}
+}
+
+// Airgapped returns the airgapped profile: no network access. Builds then require the package
+// repositories to be mirrored (see WithRepositories) and the caches to be pre-populated.
+func Airgapped() Profile {
+	return Profile{Name: ProfileAirgapped, Offline: true}
+}
+
+// profiles maps the names of the predefined profiles to their constructor.
+var profiles = map[string]func() Profile{
+	ProfileHardened:   Hardened,
+	ProfileDebug:      Debug,
+	ProfileAirgapped:  Airgapped,
+	ProfileProduction: Production,
+}
+
+// Names returns the names of the predefined profiles, sorted.
+func Names() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Get returns the predefined profile 'name'.
+//
+// Returns:
+//   - The profile.
+//   - An error if the profile is unknown.
+func Get(name string) (Profile, error) {
+	p, ok := profiles[name]
+	if !ok {
+		return Profile{}, errorsx.Unsupported(builderName, "name", name, "unsupported profile: %s", name)
+	}
+
+	return p(), nil
+}
+
+// WithRepositories returns a copy of the profile appending 'repos' to apko builds, e.g. the
+// pinned internal mirrors of an organization.
+func (p Profile) WithRepositories(repos ...string) Profile {
+	p.Repositories = append(append([]string(nil), p.Repositories...), repos...)
+	return p
+}
+
+// Merge returns a copy of the profile with the options of 'other' added: boolean options are
+// enabled by either profile, and the strictest severity threshold and the last log level win.
+func (p Profile) Merge(other Profile) Profile {
+	merged := p.WithRepositories(other.Repositories...)
+	merged.Name = strings.Trim(p.Name+"+"+other.Name, "+")
+	merged.Offline = p.Offline || other.Offline
+	merged.SBOM = p.SBOM || other.SBOM
+	merged.RequireNonRoot = p.RequireNonRoot || other.RequireNonRoot
+	merged.RequireSignature = p.RequireSignature || other.RequireSignature
+	merged.Production = p.Production || other.Production
+
+	if other.LogLevel != "" {
+		merged.LogLevel = other.LogLevel
+	}
+
+	if other.FailOn != policyx.SeverityUnknown && (p.FailOn == policyx.SeverityUnknown || other.FailOn < p.FailOn) {
+		merged.FailOn = other.FailOn
+	}
+
+	return merged
+}
+
+// Apply applies the profile to 'targets': *apkox.ApkoBuilder, *trivyx.TrivyBuilder,
+// *cosignx.VerifyBuilder and *policyx.Policy values.
+//
+// Returns:
+//   - An error if a target is of an unsupported type. Targets before it are already updated.
+func (p Profile) Apply(targets ...any) error {
+	for _, target := range targets {
+		switch t := target.(type) {
+		case *apkox.ApkoBuilder:
+			p.applyApko(t)
+		case *trivyx.TrivyBuilder:
+			p.applyTrivy(t)
+		case *cosignx.VerifyBuilder:
+			if p.Offline {
+				t.WithOffline(true)
+			}
+		case *policyx.Policy:
+			if p.FailOn != policyx.SeverityUnknown {
+				t.WithMaxSeverity(p.FailOn - 1)
+			}
+		default:
+			return errorsx.Unsupported(builderName, "targets", target,
+				"profile %s cannot be applied to %T", p.Name, target)
+		}
+	}
+
+	return nil
+}
+
+// applyApko applies the build options of the profile.
+func (p Profile) applyApko(b *apkox.ApkoBuilder) {
+	if p.Offline {
+		b.WithOffline()
+	}
+
+	for _, repo := range p.Repositories {
+		b.WithRepositoryAppend(repo)
+	}
+
+	if p.SBOM {
+		b.WithSBOM(true)
+	}
+
+	if p.RequireNonRoot {
+		b.WithRequireNonRoot()
+	}
+
+	if p.LogLevel != "" {
+		b.WithLogLevel(p.LogLevel)
+	}
+
+	if p.Production {
+		b.WithProduction(true)
+	}
+}
+
+// applyTrivy applies the scan options of the profile: only the severities failing the scan are
+// reported, with a non-zero exit code.
+func (p Profile) applyTrivy(b *trivyx.TrivyBuilder) {
+	if p.Offline {
+		b.WithSkipDBUpdate(true).WithSkipJavaDBUpdate(true)
+	}
+
+	if p.FailOn == policyx.SeverityUnknown {
+		return
+	}
+
+	var severities []string
+	for _, s := range trivySeverities {
+		if s >= p.FailOn {
+			severities = append(severities, strings.ToUpper(s.String()))
+		}
+	}
+
+	b.WithSeverity(severities...).WithExtraArg("--exit-code=1")
+}
+
+// GrypeFlags returns the grype flags of the profile: --fail-on with the severity threshold.
+func (p Profile) GrypeFlags() []string {
+	if p.FailOn == policyx.SeverityUnknown {
+		return nil
+	}
+
+	return []string{"--fail-on", p.FailOn.String()}
+}
+
+// ValidatePipeline checks that the pipeline 'pl' meets the requirements of the profile: a
+// `cosign sign` node when signatures are required.
+func (p Profile) ValidatePipeline(pl *pipeline.Pipeline) error {
+	if !p.RequireSignature {
+		return nil
+	}
+
+	for _, n := range pl.Nodes() {
+		if len(n.Command) >= 2 && n.Command[0] == "cosign" && n.Command[1] == "sign" {
+			return nil
+		}
+	}
+
+	return errorsx.MissingField(builderName, "sign",
+		"profile %s requires pipeline %s to sign its images with cosign", p.Name, pl.Name())
+}
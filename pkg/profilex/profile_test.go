@@ -0,0 +1,145 @@
+package profilex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/cosignx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/pipeline"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/trivyx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	assert.Equal(t, []string{"airgapped", "debug", "hardened", "production"}, Names())
+
+	for _, name := range Names() {
+		p, err := Get(name)
+		require.NoError(t, err)
+		assert.Equal(t, name, p.Name)
+	}
+
+	_, err := Get("paranoid")
+	assert.True(t, errors.Is(err, errorsx.ErrUnsupported))
+}
+
+func TestApplyHardened(t *testing.T) {
+	apko := apkox.NewApkoBuilder().
+		WithConfigPreset(apkox.ConfigPresetStatic).
+		WithOutputImage("example/app").
+		WithTag("v1").
+		WithOutputTarball("/mnt/image.tar")
+	trivy := trivyx.NewTrivyBuilder("example/app:v1")
+	policy := policyx.NewPolicy()
+
+	require.NoError(t, Hardened().Apply(apko, trivy, policy))
+
+	spec := apko.Spec()
+	assert.True(t, spec.SBOM)
+	assert.True(t, spec.RequireNonRoot)
+
+	_, err := apko.BuildCommand()
+	require.NoError(t, err, "the static preset runs as nonroot")
+
+	cmd, err := trivy.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"trivy", "image", "--cache-dir", trivyx.GetCacheDir(""),
+		"--severity", "HIGH,CRITICAL", "--exit-code=1", "example/app:v1",
+	}, cmd)
+
+	high := []policyx.Finding{{ID: "CVE-1", Severity: policyx.SeverityHigh}}
+	res := policy.Evaluate(policyx.Input{Findings: high})
+	assert.Error(t, res.Err())
+	medium := []policyx.Finding{{ID: "CVE-2", Severity: policyx.SeverityMedium}}
+	res = policy.Evaluate(policyx.Input{Findings: medium})
+	assert.NoError(t, res.Err())
+
+	assert.Equal(t, []string{"--fail-on", "high"}, Hardened().GrypeFlags())
+	assert.Nil(t, Debug().GrypeFlags())
+}
+
+func TestApplyAirgapped(t *testing.T) {
+	profile := Airgapped().WithRepositories("https://mirror.example.com/wolfi")
+
+	apko := apkox.NewApkoBuilder().
+		WithConfigFile("apko.yaml").
+		WithOutputImage("example/app").
+		WithTag("v1").
+		WithOutputTarball("/mnt/image.tar")
+	trivy := trivyx.NewTrivyBuilder("example/app:v1")
+	verify := cosignx.NewVerifyBuilder("example/app:v1").
+		WithKey("cosign.pub").
+		WithBundle("/mnt/bundle.json")
+
+	require.NoError(t, profile.Apply(apko, trivy, verify))
+
+	cmd, err := apko.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"apko", "build", "--repository-append", "https://mirror.example.com/wolfi",
+		"--sbom=false", "--vcs=false", "--offline",
+		"apko.yaml", "example/app:v1", "/mnt/image.tar",
+	}, cmd)
+
+	cmd, err = trivy.BuildCommand()
+	require.NoError(t, err)
+	assert.Contains(t, cmd, "--skip-db-update")
+	assert.Contains(t, cmd, "--skip-java-db-update")
+
+	cmd, err = verify.BuildCommand()
+	require.NoError(t, err)
+	assert.Contains(t, cmd, "--offline")
+
+	assert.Empty(t, Airgapped().Repositories, "WithRepositories must not modify the original profile")
+
+	err = profile.Apply(apko, "trivy")
+	assert.True(t, errors.Is(err, errorsx.ErrUnsupported))
+}
+
+func TestApplyProduction(t *testing.T) {
+	apko := apkox.NewApkoBuilder().WithConfigFile("apko.yaml").WithOutputImage("app").WithTag("v1").
+		WithOutputTarball("/out/app.tar").WithInsecure(apkox.InsecureOptions{IgnoreSignatures: true})
+
+	require.NoError(t, Production().Apply(apko))
+	assert.True(t, apko.Spec().Production)
+	assert.True(t, apko.Spec().SBOM)
+
+	_, err := apko.BuildCommand()
+	assert.True(t, errors.Is(err, errorsx.ErrConflictingOptions))
+
+	assert.True(t, Debug().Merge(Production()).Production)
+}
+
+func TestMerge(t *testing.T) {
+	strict := Profile{Name: "strict", FailOn: policyx.SeverityMedium}
+	merged := Hardened().Merge(Airgapped()).Merge(strict).Merge(Debug())
+
+	assert.Equal(t, "hardened+airgapped+strict+debug", merged.Name)
+	assert.True(t, merged.Offline)
+	assert.True(t, merged.SBOM)
+	assert.True(t, merged.RequireSignature)
+	assert.Equal(t, policyx.SeverityMedium, merged.FailOn)
+	assert.Equal(t, "debug", merged.LogLevel)
+
+	assert.Equal(t, policyx.SeverityHigh, Hardened().Merge(Profile{FailOn: policyx.SeverityCritical}).FailOn)
+}
+
+func TestValidatePipeline(t *testing.T) {
+	p := pipeline.New("release")
+	require.NoError(t, p.AddNode(pipeline.Node{ID: "apko", Command: []string{"apko", "build"}}))
+
+	assert.NoError(t, Debug().ValidatePipeline(p))
+	assert.True(t, errors.Is(Hardened().ValidatePipeline(p), errorsx.ErrMissingField))
+
+	require.NoError(t, p.AddNode(pipeline.Node{
+		ID:        "sign",
+		Command:   []string{"cosign", "sign", "--yes", "example/app:v1"},
+		DependsOn: []string{"apko"},
+	}))
+	assert.NoError(t, Hardened().ValidatePipeline(p))
+}
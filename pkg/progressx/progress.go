@@ -0,0 +1,286 @@
+// Package progressx instruments the execution of planx plans: every task runs in an
+// OpenTelemetry span carrying its builder, target, duration and exit status, under a span of the
+// whole plan, and a Tracker exposes a snapshot of the progress while the plan runs.
+//
+// Spans are created with the tracer provider set on the Tracker, or the global one (see
+// otel.SetTracerProvider), so daggerx-driven pipelines show up in the traces of the existing
+// observability stack. Without a configured provider, spans are no-ops.
+//
+// Example usage:
+//
+//	tracker := progressx.NewTracker(plan).WithTracerProvider(tp)
+//
+//	go func() {
+//	    for range time.Tick(5 * time.Second) {
+//	        fmt.Println(tracker.Snapshot()) // e.g. "release: 3/8 done (1 failed), 2 running"
+//	    }
+//	}()
+//
+//	report, err := tracker.Execute(ctx, execx.NewLocalExecutor())
+package progressx
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/execx"
+	"github.com/Excoriate/daggerx/pkg/planx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the tracer creating the spans.
+const TracerName = "github.com/Excoriate/daggerx/pkg/progressx"
+
+// Span names.
+const (
+	// PlanSpan is the name of the span of a plan execution.
+	PlanSpan = "daggerx.plan"
+	// TaskSpan is the name of the span of a task execution.
+	TaskSpan = "daggerx.task"
+)
+
+// Span attribute keys.
+const (
+	// AttrPlan is the name of the plan.
+	AttrPlan = attribute.Key("daggerx.plan")
+	// AttrTasks is the number of tasks of the plan.
+	AttrTasks = attribute.Key("daggerx.plan.tasks")
+	// AttrFailed is the number of failed or skipped tasks of the plan.
+	AttrFailed = attribute.Key("daggerx.plan.failed")
+	// AttrBuilder is the builder type of the task: the base name of its binary (e.g. "apko").
+	AttrBuilder = attribute.Key("daggerx.builder")
+	// AttrTarget is the target of the task: its identifier in the plan, e.g. "static-x86_64".
+	AttrTarget = attribute.Key("daggerx.target")
+	// AttrExitCode is the exit code of the command.
+	AttrExitCode = attribute.Key("daggerx.exit_code")
+	// AttrDuration is the duration of the task, in milliseconds.
+	AttrDuration = attribute.Key("daggerx.duration_ms")
+)
+
+// Tracker instruments the execution of a plan and tracks its progress. It is safe for concurrent
+// use: Snapshot is meant to be called while Execute runs.
+type Tracker struct {
+	// plan is the instrumented plan.
+	plan *planx.Plan
+	// provider creates the tracer. A nil provider is the global one.
+	provider trace.TracerProvider
+
+	mu sync.Mutex
+	// started is when the execution started, zero before.
+	started time.Time
+	// finished is when the execution finished, zero before.
+	finished time.Time
+	// tasks holds the progress of the tasks, in plan order.
+	tasks []TaskProgress
+	// index maps task identifiers to their position in tasks.
+	index map[string]int
+}
+
+// NewTracker creates a tracker of the plan 'plan', whose tasks are all pending.
+func NewTracker(plan *planx.Plan) *Tracker {
+	t := &Tracker{plan: plan, index: map[string]int{}}
+
+	for _, task := range plan.Tasks() {
+		t.index[task.ID] = len(t.tasks)
+		t.tasks = append(t.tasks, TaskProgress{
+			ID:      task.ID,
+			Builder: builderType(task.Command),
+			State:   StatePending,
+		})
+	}
+
+	return t
+}
+
+// WithTracerProvider sets the provider of the tracer creating the spans, instead of the global one.
+// It returns the updated Tracker instance.
+func (t *Tracker) WithTracerProvider(provider trace.TracerProvider) *Tracker {
+	t.provider = provider
+	return t
+}
+
+// tracer returns the tracer creating the spans.
+func (t *Tracker) tracer() trace.Tracer {
+	provider := t.provider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	return provider.Tracer(TracerName)
+}
+
+// Execute runs the plan with 'executor' (see planx.Plan.Execute) in a plan span, each task in a
+// child span, tracking the progress of the tasks.
+//
+// Returns:
+//   - The report of the plan.
+//   - The aggregated task failures, as returned by planx.Report.Err.
+func (t *Tracker) Execute(ctx context.Context, executor execx.Executor) (*planx.Report, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, span := t.tracer().Start(ctx, PlanSpan, trace.WithAttributes(
+		AttrPlan.String(t.plan.Name()),
+		AttrTasks.Int(len(t.tasks)),
+	))
+	defer span.End()
+
+	t.mu.Lock()
+	t.started, t.finished = time.Now(), time.Time{}
+	t.mu.Unlock()
+
+	var inner execx.Executor
+	if executor != nil {
+		inner = t.Executor(executor)
+	}
+
+	report, err := t.plan.Execute(ctx, inner)
+
+	t.mu.Lock()
+	t.finished = time.Now()
+	if report != nil {
+		for _, res := range report.Results {
+			if i, ok := t.index[res.ID]; ok && res.Skipped {
+				t.tasks[i].State = StateSkipped
+			}
+		}
+	}
+	t.mu.Unlock()
+
+	if report != nil {
+		span.SetAttributes(AttrFailed.Int(len(report.Failed())))
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "plan failed")
+	}
+
+	return report, err
+}
+
+// Executor returns an executor running the commands with 'inner', each in a task span, and
+// tracking their progress. The task of a run is taken from the context (see
+// planx.TaskFromContext); runs outside a plan are tracked as tasks named after their builder.
+func (t *Tracker) Executor(inner execx.Executor) execx.Executor {
+	return &tracedExecutor{tracker: t, inner: inner}
+}
+
+// tracedExecutor runs commands in task spans.
+type tracedExecutor struct {
+	tracker *Tracker
+	inner   execx.Executor
+}
+
+// Run runs the command with the inner executor in a task span.
+func (e *tracedExecutor) Run(
+	ctx context.Context,
+	cmd types.DaggerCMD,
+	env []types.DaggerEnvVars,
+	mounts []types.Mount,
+) (*execx.Result, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	builder := builderType(cmd)
+	id := builder
+	if task, ok := planx.TaskFromContext(ctx); ok {
+		id = task.ID
+	}
+
+	ctx, span := e.tracker.tracer().Start(ctx, TaskSpan, trace.WithAttributes(
+		AttrBuilder.String(builder),
+		AttrTarget.String(id),
+	))
+	defer span.End()
+
+	i := e.tracker.start(id, builder)
+	started := time.Now()
+
+	res, err := e.inner.Run(ctx, cmd, env, mounts)
+
+	duration := time.Since(started)
+	span.SetAttributes(AttrDuration.Int64(duration.Milliseconds()))
+
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case res == nil:
+		span.SetStatus(codes.Error, "executor returned no result")
+	case !res.Succeeded():
+		span.SetAttributes(AttrExitCode.Int(res.ExitCode))
+		span.SetStatus(codes.Error, fmt.Sprintf("exited with code %d", res.ExitCode))
+	default:
+		span.SetAttributes(AttrExitCode.Int(res.ExitCode))
+		span.SetStatus(codes.Ok, "")
+	}
+
+	e.tracker.finish(i, duration, res, err)
+
+	return res, err
+}
+
+// start marks the task 'id' as running, registering it when unknown, and returns its position.
+func (t *Tracker) start(id, builder string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	i, ok := t.index[id]
+	if !ok {
+		i = len(t.tasks)
+		t.index[id] = i
+		t.tasks = append(t.tasks, TaskProgress{ID: id, Builder: builder})
+	}
+
+	if t.started.IsZero() {
+		t.started = time.Now()
+	}
+
+	t.tasks[i].State = StateRunning
+	t.tasks[i].Started = time.Now()
+
+	return i
+}
+
+// finish records the outcome of the task at position 'i'.
+func (t *Tracker) finish(i int, duration time.Duration, res *execx.Result, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	task := &t.tasks[i]
+	task.Duration = duration
+
+	switch {
+	case err != nil:
+		task.State = StateFailed
+		task.Error = err.Error()
+	case res == nil:
+		task.State = StateFailed
+		task.Error = "executor returned no result"
+	default:
+		task.ExitCode = res.ExitCode
+		task.State = StateSucceeded
+		if !res.Succeeded() {
+			task.State = StateFailed
+		}
+	}
+}
+
+// builderType returns the builder type of a command: the base name of its binary.
+func builderType(cmd types.DaggerCMD) string {
+	if len(cmd) == 0 || cmd[0] == "" {
+		return "unknown"
+	}
+
+	return filepath.Base(cmd[0])
+}
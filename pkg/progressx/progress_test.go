@@ -0,0 +1,157 @@
+package progressx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/execx"
+	"github.com/Excoriate/daggerx/pkg/planx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// fakeExecutor fails the commands named "fail" with exit code 2, errors on the commands named
+// "error", and blocks on 'gate' when set.
+type fakeExecutor struct {
+	gate chan struct{}
+}
+
+func (e *fakeExecutor) Run(_ context.Context, cmd types.DaggerCMD, _ []types.DaggerEnvVars, _ []types.Mount) (*execx.Result, error) {
+	if e.gate != nil {
+		<-e.gate
+	}
+
+	switch cmd[0] {
+	case "fail":
+		return &execx.Result{ExitCode: 2}, nil
+	case "error":
+		return nil, errors.New("engine unavailable")
+	default:
+		return &execx.Result{}, nil
+	}
+}
+
+func newTestPlan(t *testing.T) *planx.Plan {
+	t.Helper()
+
+	plan := planx.NewPlan("release").WithConcurrency(1)
+	require.NoError(t, plan.AddTask(planx.Task{ID: "static-x86_64", Command: types.DaggerCMD{"/usr/bin/apko", "build"}}))
+	require.NoError(t, plan.AddTask(planx.Task{ID: "sbom", Command: types.DaggerCMD{"fail"}}))
+	require.NoError(t, plan.AddTask(planx.Task{ID: "sign", Command: types.DaggerCMD{"error"}}))
+
+	return plan
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+
+	return attrs
+}
+
+func TestTrackerExecuteSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	tracker := NewTracker(newTestPlan(t)).WithTracerProvider(provider)
+	_, err := tracker.Execute(context.Background(), &fakeExecutor{})
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 4)
+
+	plan := spans[3]
+	assert.Equal(t, PlanSpan, plan.Name())
+	assert.Equal(t, codes.Error, plan.Status().Code)
+	assert.Equal(t, "release", attributes(plan)[AttrPlan].AsString())
+	assert.Equal(t, int64(3), attributes(plan)[AttrTasks].AsInt64())
+	assert.Equal(t, int64(2), attributes(plan)[AttrFailed].AsInt64())
+
+	apko := spans[0]
+	assert.Equal(t, TaskSpan, apko.Name())
+	assert.Equal(t, plan.SpanContext().SpanID(), apko.Parent().SpanID())
+	assert.Equal(t, codes.Ok, apko.Status().Code)
+	assert.Equal(t, "apko", attributes(apko)[AttrBuilder].AsString())
+	assert.Equal(t, "static-x86_64", attributes(apko)[AttrTarget].AsString())
+	assert.Equal(t, int64(0), attributes(apko)[AttrExitCode].AsInt64())
+	assert.Contains(t, attributes(apko), AttrDuration)
+
+	sbom := spans[1]
+	assert.Equal(t, codes.Error, sbom.Status().Code)
+	assert.Equal(t, int64(2), attributes(sbom)[AttrExitCode].AsInt64())
+
+	sign := spans[2]
+	assert.Equal(t, codes.Error, sign.Status().Code)
+	assert.NotContains(t, attributes(sign), AttrExitCode)
+	require.Len(t, sign.Events(), 1)
+}
+
+func TestTrackerSnapshot(t *testing.T) {
+	gate := make(chan struct{})
+	tracker := NewTracker(newTestPlan(t))
+
+	snap := tracker.Snapshot()
+	assert.Equal(t, 3, snap.Counts[StatePending])
+	assert.Equal(t, "release: 0/3 done", snap.String())
+	assert.Zero(t, snap.Elapsed)
+
+	done := make(chan error)
+	go func() {
+		_, err := tracker.Execute(context.Background(), &fakeExecutor{gate: gate})
+		done <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		return tracker.Snapshot().Counts[StateRunning] == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, "release: 0/3 done, 1 running", tracker.Snapshot().String())
+
+	close(gate)
+	require.Error(t, <-done)
+
+	snap = tracker.Snapshot()
+	assert.True(t, snap.Finished)
+	assert.InDelta(t, 100, snap.Percent(), 0.001)
+	assert.Equal(t, "release: 3/3 done (2 failed)", snap.String())
+
+	assert.Equal(t, StateSucceeded, snap.Tasks[0].State)
+	assert.Equal(t, "apko", snap.Tasks[0].Builder)
+	assert.Equal(t, StateFailed, snap.Tasks[1].State)
+	assert.Equal(t, 2, snap.Tasks[1].ExitCode)
+	assert.Equal(t, "engine unavailable", snap.Tasks[2].Error)
+}
+
+func TestTrackerSkippedTasks(t *testing.T) {
+	plan := planx.NewPlan("release").WithConcurrency(1).WithFailFast(true)
+	require.NoError(t, plan.AddTask(planx.Task{ID: "first", Command: types.DaggerCMD{"fail"}}))
+	require.NoError(t, plan.AddTask(planx.Task{ID: "second", Command: types.DaggerCMD{"apko"}}))
+
+	tracker := NewTracker(plan)
+	_, err := tracker.Execute(context.Background(), &fakeExecutor{})
+	require.Error(t, err)
+
+	snap := tracker.Snapshot()
+	assert.Equal(t, StateSkipped, snap.Tasks[1].State)
+	assert.Equal(t, "release: 2/2 done (1 failed, 1 skipped)", snap.String())
+}
+
+func TestTrackerExecutorOutsidePlan(t *testing.T) {
+	tracker := NewTracker(planx.NewPlan("adhoc"))
+
+	_, err := tracker.Executor(&fakeExecutor{}).Run(context.Background(), types.DaggerCMD{"melange", "build"}, nil, nil)
+	require.NoError(t, err)
+
+	snap := tracker.Snapshot()
+	require.Len(t, snap.Tasks, 1)
+	assert.Equal(t, "melange", snap.Tasks[0].ID)
+	assert.Equal(t, StateSucceeded, snap.Tasks[0].State)
+}
@@ -0,0 +1,122 @@
+package progressx
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// State is the state of a task.
+type State string
+
+const (
+	// StatePending is the state of the tasks not started yet.
+	StatePending State = "pending"
+	// StateRunning is the state of the running tasks.
+	StateRunning State = "running"
+	// StateSucceeded is the state of the tasks whose command exited with a zero exit code.
+	StateSucceeded State = "succeeded"
+	// StateFailed is the state of the tasks which couldn't run or exited with a non-zero exit code.
+	StateFailed State = "failed"
+	// StateSkipped is the state of the tasks skipped because the plan was cancelled.
+	StateSkipped State = "skipped"
+)
+
+// Done reports whether the state is final.
+func (s State) Done() bool {
+	return s == StateSucceeded || s == StateFailed || s == StateSkipped
+}
+
+// TaskProgress is the progress of a task.
+type TaskProgress struct {
+	// ID is the identifier of the task.
+	ID string `json:"id"`
+	// Builder is the builder type of the task (e.g. "apko").
+	Builder string `json:"builder"`
+	// State is the state of the task.
+	State State `json:"state"`
+	// Started is when the task started, zero if it hasn't.
+	Started time.Time `json:"started,omitempty"`
+	// Duration is the time the task took, zero until it is done.
+	Duration time.Duration `json:"duration,omitempty"`
+	// ExitCode is the exit code of the command, when it ran.
+	ExitCode int `json:"exitCode,omitempty"`
+	// Error is the error of the executor, when it couldn't run the command.
+	Error string `json:"error,omitempty"`
+}
+
+// Snapshot is the progress of a plan at a point in time.
+type Snapshot struct {
+	// Plan is the name of the plan.
+	Plan string `json:"plan"`
+	// Tasks holds the progress of the tasks, in plan order.
+	Tasks []TaskProgress `json:"tasks"`
+	// Counts holds the number of tasks of each state.
+	Counts map[State]int `json:"counts"`
+	// Elapsed is the time since the execution started, or its duration once finished.
+	Elapsed time.Duration `json:"elapsed"`
+	// Finished reports whether the execution finished.
+	Finished bool `json:"finished"`
+}
+
+// Snapshot returns the current progress of the plan.
+func (t *Tracker) Snapshot() Snapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snap := Snapshot{
+		Plan:     t.plan.Name(),
+		Tasks:    append([]TaskProgress(nil), t.tasks...),
+		Counts:   map[State]int{},
+		Finished: !t.finished.IsZero(),
+	}
+
+	for _, task := range t.tasks {
+		snap.Counts[task.State]++
+	}
+
+	switch {
+	case snap.Finished:
+		snap.Elapsed = t.finished.Sub(t.started)
+	case !t.started.IsZero():
+		snap.Elapsed = time.Since(t.started)
+	}
+
+	return snap
+}
+
+// Done returns the number of tasks in a final state.
+func (s Snapshot) Done() int {
+	return s.Counts[StateSucceeded] + s.Counts[StateFailed] + s.Counts[StateSkipped]
+}
+
+// Percent returns the percentage of tasks in a final state, 100 for plans without tasks.
+func (s Snapshot) Percent() float64 {
+	if len(s.Tasks) == 0 {
+		return 100
+	}
+
+	return float64(s.Done()) * 100 / float64(len(s.Tasks))
+}
+
+// String renders the snapshot as a single line, e.g. "release: 3/8 done (1 failed), 2 running".
+func (s Snapshot) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d/%d done", s.Plan, s.Done(), len(s.Tasks))
+
+	var issues []string
+	for _, state := range []State{StateFailed, StateSkipped} {
+		if n := s.Counts[state]; n > 0 {
+			issues = append(issues, fmt.Sprintf("%d %s", n, state))
+		}
+	}
+	if len(issues) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(issues, ", "))
+	}
+
+	if n := s.Counts[StateRunning]; n > 0 {
+		fmt.Fprintf(&b, ", %d running", n)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,395 @@
+// Package protosx provides builders for the protocol buffers checks and code generation of
+// API-first pipelines: buf lint, buf breaking against a baseline, buf generate, and raw protoc
+// invocations with plugin paths for repositories not using buf.
+//
+// buf caches the dependencies of its modules in BUF_CACHE_DIR; Mounts returns the cache volume
+// mount backing it, so the Buf Schema Registry is only queried for new dependencies.
+//
+// Example usage:
+//
+//	buf := protosx.NewBufBuilder("proto").
+//	    WithAgainst(protosx.GitAgainst("https://github.com/acme/api.git", "main", "proto")).
+//	    WithErrorFormat("github-actions")
+//
+//	lint, err := buf.LintCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	breaking, err := buf.BreakingCommand()
+//	env, mounts := buf.Env(), buf.Mounts()
+package protosx
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/builderx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the protocol buffers builders in errorsx errors.
+const builderName = "protos"
+
+// BufDefaultImage is the default container image providing buf.
+const BufDefaultImage = "bufbuild/buf:1.47.2"
+
+// CacheVolumeKey is the cache volume key of the buf cache directory.
+const CacheVolumeKey = "buf-cache"
+
+// TokenEnvVar is the environment variable buf reads the Buf Schema Registry token from.
+const TokenEnvVar = "BUF_TOKEN"
+
+// ErrorFormats are the error formats of buf lint and buf breaking.
+var ErrorFormats = []string{"text", "json", "msvs", "junit", "github-actions", "gitlab-code-quality"}
+
+// GetCacheDir returns the conventional buf cache directory inside the container. If mntPrefix
+// is empty, fixtures.MntPrefix is used.
+func GetCacheDir(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, ".buf-cache")
+}
+
+// GitAgainst returns the buf input of the git repository 'url' (e.g. ".git" for the local
+// repository) at 'branch', in the subdirectory 'subdir' if set, as the baseline of buf
+// breaking.
+func GitAgainst(url, branch, subdir string) string {
+	var opts []string
+	if branch != "" {
+		opts = append(opts, "branch="+branch)
+	}
+
+	if subdir != "" {
+		opts = append(opts, "subdir="+subdir)
+	}
+
+	if len(opts) == 0 {
+		return url
+	}
+
+	return url + "#" + strings.Join(opts, ",")
+}
+
+// BufBuilder generates the buf lint, breaking and generate commands of an input (a module or
+// workspace directory, a git repository or a Buf Schema Registry module).
+type BufBuilder struct {
+	// input is the buf input. Empty uses the working directory.
+	input string
+
+	// config is the buf configuration file or data.
+	config string
+
+	// paths limit the checks and generation to these files or directories of the input.
+	paths []string
+
+	// excludePaths exclude these files or directories of the input.
+	excludePaths []string
+
+	// errorFormat is the error format of lint and breaking.
+	errorFormat string
+
+	// against is the baseline input of breaking.
+	against string
+
+	// template is the generation template of generate.
+	template string
+
+	// output is the base output directory of generate.
+	output string
+
+	// includeImports also generates the imported files.
+	includeImports bool
+
+	// cacheDir is the buf cache directory (BUF_CACHE_DIR), backed by a cache volume.
+	cacheDir string
+
+	// token is the secret holding the Buf Schema Registry token.
+	token *secretsx.SecretRef
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+var _ builderx.SecretBuilder = (*BufBuilder)(nil)
+
+// NewBufBuilder creates a BufBuilder of 'input' (e.g. "proto"; empty uses the working
+// directory), caching the dependencies in GetCacheDir("").
+func NewBufBuilder(input string) *BufBuilder {
+	return &BufBuilder{input: input, cacheDir: GetCacheDir("")}
+}
+
+// WithConfig sets the buf configuration file (e.g. "buf.yaml").
+func (b *BufBuilder) WithConfig(config string) *BufBuilder {
+	b.config = config
+	return b
+}
+
+// WithPath limits the commands to files or directories of the input.
+func (b *BufBuilder) WithPath(paths ...string) *BufBuilder {
+	b.paths = append(b.paths, paths...)
+	return b
+}
+
+// WithExcludePath excludes files or directories of the input.
+func (b *BufBuilder) WithExcludePath(paths ...string) *BufBuilder {
+	b.excludePaths = append(b.excludePaths, paths...)
+	return b
+}
+
+// WithErrorFormat sets the error format of lint and breaking, one of ErrorFormats (e.g.
+// "github-actions" for annotations).
+func (b *BufBuilder) WithErrorFormat(format string) *BufBuilder {
+	b.errorFormat = format
+	return b
+}
+
+// WithAgainst sets the baseline input of breaking (see GitAgainst), e.g.
+// "buf.build/acme/api" or ".git#branch=main".
+func (b *BufBuilder) WithAgainst(against string) *BufBuilder {
+	b.against = against
+	return b
+}
+
+// WithTemplate sets the generation template of generate (e.g. "buf.gen.yaml").
+func (b *BufBuilder) WithTemplate(template string) *BufBuilder {
+	b.template = template
+	return b
+}
+
+// WithOutput sets the base output directory of generate.
+func (b *BufBuilder) WithOutput(dir string) *BufBuilder {
+	b.output = dir
+	return b
+}
+
+// WithIncludeImports sets whether generate also generates the imported files.
+func (b *BufBuilder) WithIncludeImports(include bool) *BufBuilder {
+	b.includeImports = include
+	return b
+}
+
+// WithCacheDir sets the buf cache directory.
+func (b *BufBuilder) WithCacheDir(dir string) *BufBuilder {
+	b.cacheDir = dir
+	return b
+}
+
+// WithToken uses the Buf Schema Registry token held by 'ref', for private modules. The secret
+// is exposed as TokenEnvVar; 'ref' is left unchanged.
+func (b *BufBuilder) WithToken(ref *secretsx.SecretRef) *BufBuilder {
+	if ref == nil {
+		b.token = nil
+		return b
+	}
+
+	token := *ref
+	b.token = token.AsEnv(TokenEnvVar)
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *BufBuilder) WithLogger(logger *slog.Logger) *BufBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks the options shared by the commands.
+func (b *BufBuilder) validate() error {
+	if b.errorFormat != "" && !slices.Contains(ErrorFormats, b.errorFormat) {
+		return errorsx.InvalidValue(builderName, "errorFormat", b.errorFormat, "unsupported error format: %q", b.errorFormat)
+	}
+
+	if b.token != nil {
+		if err := b.token.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// command generates the buf command 'name' with the shared flags.
+func (b *BufBuilder) command(name string) []string {
+	cmd := []string{"buf", name}
+	if b.input != "" {
+		cmd = append(cmd, b.input)
+	}
+
+	if b.config != "" {
+		cmd = append(cmd, "--config", b.config)
+	}
+
+	for _, p := range b.paths {
+		cmd = append(cmd, "--path", p)
+	}
+
+	for _, p := range b.excludePaths {
+		cmd = append(cmd, "--exclude-path", p)
+	}
+
+	return cmd
+}
+
+// LintCommand generates the buf lint command.
+//
+// Returns:
+//   - The buf lint command.
+//   - An error if the error format or token is invalid, or generate options are set.
+func (b *BufBuilder) LintCommand() ([]string, error) {
+	if err := b.validateCheck("lint"); err != nil {
+		return nil, err
+	}
+
+	cmd := b.command("lint")
+	if b.errorFormat != "" {
+		cmd = append(cmd, "--error-format", b.errorFormat)
+	}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// BreakingCommand generates the buf breaking command, checking the input against the baseline.
+//
+// Returns:
+//   - The buf breaking command.
+//   - An error if the baseline is missing, the error format or token is invalid, or generate
+//     options are set.
+func (b *BufBuilder) BreakingCommand() ([]string, error) {
+	if err := b.validateCheck("breaking"); err != nil {
+		return nil, err
+	}
+
+	if b.against == "" {
+		return nil, errorsx.MissingField(builderName, "against", "breaking change detection requires a baseline input")
+	}
+
+	cmd := b.command("breaking")
+	cmd = append(cmd, "--against", b.against)
+	if b.errorFormat != "" {
+		cmd = append(cmd, "--error-format", b.errorFormat)
+	}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// validateCheck checks the options of lint and breaking.
+func (b *BufBuilder) validateCheck(name string) error {
+	if err := b.validate(); err != nil {
+		return err
+	}
+
+	for _, o := range []struct {
+		option string
+		set    bool
+	}{
+		{"template", b.template != ""},
+		{"output", b.output != ""},
+		{"includeImports", b.includeImports},
+	} {
+		if o.set {
+			return errorsx.Unsupported(builderName, o.option, name, "buf %s does not support %s", name, o.option)
+		}
+	}
+
+	return nil
+}
+
+// GenerateCommand generates the buf generate command.
+//
+// Returns:
+//   - The buf generate command.
+//   - An error if the token is invalid, or lint and breaking options are set.
+func (b *BufBuilder) GenerateCommand() ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	if b.errorFormat != "" {
+		return nil, errorsx.Unsupported(builderName, "errorFormat", "generate", "buf generate does not support errorFormat")
+	}
+
+	cmd := b.command("generate")
+	if b.template != "" {
+		cmd = append(cmd, "--template", b.template)
+	}
+
+	if b.output != "" {
+		cmd = append(cmd, "--output", b.output)
+	}
+
+	if b.includeImports {
+		cmd = append(cmd, "--include-imports")
+	}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Env returns the environment variables of the commands: the buf cache directory. The token is
+// a secret (see Secrets).
+func (b *BufBuilder) Env() []types.DaggerEnvVars {
+	if b.cacheDir == "" {
+		return nil
+	}
+
+	return []types.DaggerEnvVars{{Name: "BUF_CACHE_DIR", Value: b.cacheDir}}
+}
+
+// Secrets returns the secrets the commands require: the Buf Schema Registry token, if any.
+func (b *BufBuilder) Secrets() []*secretsx.SecretRef {
+	if b.token == nil {
+		return nil
+	}
+
+	return []*secretsx.SecretRef{b.token}
+}
+
+// Mounts returns the cache volume mount of the buf cache directory, keyed CacheVolumeKey.
+func (b *BufBuilder) Mounts() []types.Mount {
+	if b.cacheDir == "" {
+		return nil
+	}
+
+	return []types.Mount{{Kind: types.MountKindCache, Source: CacheVolumeKey, Target: b.cacheDir}}
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *BufBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// BuildCommand generates the buf lint command, as LintCommand does.
+func (b *BufBuilder) BuildCommand() ([]string, error) {
+	return b.LintCommand()
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the buf lint, breaking and generate commands.
+func (b *BufBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("buf", b, NewBufBuilder(""),
+		explainx.Generate("LintCommand", b.LintCommand),
+		explainx.Generate("BreakingCommand", b.BreakingCommand),
+		explainx.Generate("GenerateCommand", b.GenerateCommand),
+	)
+}
@@ -0,0 +1,98 @@
+package protosx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitAgainst(t *testing.T) {
+	assert.Equal(t, ".git", GitAgainst(".git", "", ""))
+	assert.Equal(t, ".git#branch=main", GitAgainst(".git", "main", ""))
+	assert.Equal(t, "https://github.com/acme/api.git#branch=main,subdir=proto",
+		GitAgainst("https://github.com/acme/api.git", "main", "proto"))
+}
+
+func TestBufBuilderCommands(t *testing.T) {
+	b := NewBufBuilder("proto").
+		WithConfig("buf.yaml").
+		WithPath("proto/acme/v1").
+		WithExcludePath("proto/acme/v1/internal").
+		WithErrorFormat("github-actions").
+		WithAgainst(GitAgainst(".git", "main", "proto"))
+
+	lint, err := b.LintCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"buf", "lint", "proto", "--config", "buf.yaml", "--path", "proto/acme/v1",
+		"--exclude-path", "proto/acme/v1/internal", "--error-format", "github-actions",
+	}, lint)
+
+	breaking, err := b.BreakingCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"buf", "breaking", "proto", "--config", "buf.yaml", "--path", "proto/acme/v1",
+		"--exclude-path", "proto/acme/v1/internal", "--against", ".git#branch=main,subdir=proto",
+		"--error-format", "github-actions",
+	}, breaking)
+
+	generate, err := NewBufBuilder("").
+		WithTemplate("buf.gen.yaml").
+		WithOutput("gen").
+		WithIncludeImports(true).
+		GenerateCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"buf", "generate", "--template", "buf.gen.yaml", "--output", "gen", "--include-imports",
+	}, generate)
+}
+
+func TestBufBuilderEnvAndMounts(t *testing.T) {
+	token := secretsx.FromEnv("buf-token", "CI_BUF_TOKEN")
+	b := NewBufBuilder("proto").WithToken(token)
+
+	assert.Equal(t, []types.DaggerEnvVars{{Name: "BUF_CACHE_DIR", Value: "/mnt/.buf-cache"}}, b.Env())
+	assert.Equal(t, []types.Mount{
+		{Kind: types.MountKindCache, Source: CacheVolumeKey, Target: "/mnt/.buf-cache"},
+	}, b.Mounts())
+
+	secrets := b.Secrets()
+	require.Len(t, secrets, 1)
+	assert.Equal(t, TokenEnvVar, secrets[0].Target)
+	assert.Equal(t, "CI_BUF_TOKEN", token.Target, "the secret reference must be left unchanged")
+
+	b.WithCacheDir("").WithToken(nil)
+	assert.Nil(t, b.Env())
+	assert.Nil(t, b.Mounts())
+	assert.Nil(t, b.Secrets())
+}
+
+func TestBufBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func() error
+		kind error
+	}{
+		{"Missing baseline", func() error { _, err := NewBufBuilder("proto").BreakingCommand(); return err }, errorsx.ErrMissingField},
+		{"Invalid error format", func() error { _, err := NewBufBuilder("proto").WithErrorFormat("xml").LintCommand(); return err }, errorsx.ErrInvalidValue},
+		{"Lint template", func() error { _, err := NewBufBuilder("proto").WithTemplate("buf.gen.yaml").LintCommand(); return err }, errorsx.ErrUnsupported},
+		{"Generate error format", func() error { _, err := NewBufBuilder("proto").WithErrorFormat("json").GenerateCommand(); return err }, errorsx.ErrUnsupported},
+		{"Invalid token", func() error {
+			_, err := NewBufBuilder("proto").WithToken(&secretsx.SecretRef{Name: "buf-token"}).LintCommand()
+			return err
+		}, errorsx.ErrUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.kind), "expected %v, got %v", tt.kind, err)
+		})
+	}
+}
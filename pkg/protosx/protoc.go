@@ -0,0 +1,208 @@
+package protosx
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// pluginNameRegex matches the names of protoc plugins and generators (e.g. "go", "go-grpc").
+var pluginNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// protocPlugin is a plugin executable, passed as --plugin=protoc-gen-<name>=<path>.
+type protocPlugin struct {
+	name string
+	path string
+}
+
+// protocOutput is a generator output, passed as --<name>_out=<dir> and --<name>_opt=<opts>.
+type protocOutput struct {
+	name string
+	dir  string
+	opts []string
+}
+
+// ProtocBuilder generates the protoc command compiling .proto files with built-in generators
+// and plugins.
+type ProtocBuilder struct {
+	// files are the compiled .proto files.
+	files []string
+
+	// protoPaths are the import directories.
+	protoPaths []string
+
+	// plugins are the plugin executables not found in PATH.
+	plugins []protocPlugin
+
+	// outputs are the generator outputs, in order.
+	outputs []protocOutput
+
+	// descriptorSetOut is the file the FileDescriptorSet is written to.
+	descriptorSetOut string
+
+	// includeImports includes the imported files in the descriptor set.
+	includeImports bool
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewProtocBuilder creates a ProtocBuilder compiling 'files' (e.g. "api/v1/service.proto").
+func NewProtocBuilder(files ...string) *ProtocBuilder {
+	return &ProtocBuilder{files: files}
+}
+
+// WithProtoPath adds import directories (-I).
+func (b *ProtocBuilder) WithProtoPath(dirs ...string) *ProtocBuilder {
+	b.protoPaths = append(b.protoPaths, dirs...)
+	return b
+}
+
+// WithPlugin sets the executable of the plugin 'name' (e.g. "go-grpc" for
+// protoc-gen-go-grpc) to 'path', for plugins not installed in PATH.
+func (b *ProtocBuilder) WithPlugin(name, path string) *ProtocBuilder {
+	b.plugins = append(b.plugins, protocPlugin{name: name, path: path})
+	return b
+}
+
+// WithOut adds the output of the generator 'name' (e.g. "go", "python", or a plugin name) to
+// 'dir', with the generator options 'opts' (e.g. "paths=source_relative").
+func (b *ProtocBuilder) WithOut(name, dir string, opts ...string) *ProtocBuilder {
+	b.outputs = append(b.outputs, protocOutput{name: name, dir: dir, opts: opts})
+	return b
+}
+
+// WithDescriptorSetOut writes the FileDescriptorSet of the files to 'path', e.g. for gRPC
+// reflection or schema registries.
+func (b *ProtocBuilder) WithDescriptorSetOut(path string) *ProtocBuilder {
+	b.descriptorSetOut = path
+	return b
+}
+
+// WithIncludeImports sets whether the descriptor set includes the imported files.
+func (b *ProtocBuilder) WithIncludeImports(include bool) *ProtocBuilder {
+	b.includeImports = include
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *ProtocBuilder) WithLogger(logger *slog.Logger) *ProtocBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks that the builder configuration is complete and consistent.
+func (b *ProtocBuilder) validate() error {
+	if len(b.files) == 0 {
+		return errorsx.MissingField(builderName, "files", "at least one .proto file is required")
+	}
+
+	for _, f := range b.files {
+		if !strings.HasSuffix(f, ".proto") {
+			return errorsx.InvalidValue(builderName, "files", f, "not a .proto file: %q", f)
+		}
+	}
+
+	if len(b.outputs) == 0 && b.descriptorSetOut == "" {
+		return errorsx.MissingField(builderName, "outputs", "at least one output or a descriptor set is required")
+	}
+
+	if b.includeImports && b.descriptorSetOut == "" {
+		return errorsx.ConflictingOptions(builderName, []string{"includeImports", "descriptorSetOut"},
+			"including imports requires a descriptor set output")
+	}
+
+	seen := map[string]bool{}
+	for _, p := range b.plugins {
+		if !pluginNameRegex.MatchString(p.name) || p.path == "" {
+			return errorsx.InvalidValue(builderName, "plugins", p.name, "plugin %q requires a name and a path", p.name)
+		}
+
+		if seen[p.name] {
+			return errorsx.InvalidValue(builderName, "plugins", p.name, "duplicate plugin %s", p.name)
+		}
+		seen[p.name] = true
+	}
+
+	for _, o := range b.outputs {
+		if !pluginNameRegex.MatchString(o.name) || o.dir == "" {
+			return errorsx.InvalidValue(builderName, "outputs", o.name, "output %q requires a generator name and a directory", o.name)
+		}
+	}
+
+	return nil
+}
+
+// BuildCommand generates the protoc command.
+//
+// Returns:
+//   - The protoc command.
+//   - An error if no file or output is set, a file is not a .proto file, or a plugin or output
+//     is invalid.
+func (b *ProtocBuilder) BuildCommand() ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"protoc"}
+	for _, dir := range b.protoPaths {
+		cmd = append(cmd, "--proto_path="+dir)
+	}
+
+	for _, p := range b.plugins {
+		cmd = append(cmd, "--plugin=protoc-gen-"+p.name+"="+p.path)
+	}
+
+	for _, o := range b.outputs {
+		cmd = append(cmd, "--"+o.name+"_out="+o.dir)
+		if len(o.opts) > 0 {
+			cmd = append(cmd, "--"+o.name+"_opt="+strings.Join(o.opts, ","))
+		}
+	}
+
+	if b.descriptorSetOut != "" {
+		cmd = append(cmd, "--descriptor_set_out="+b.descriptorSetOut)
+	}
+
+	if b.includeImports {
+		cmd = append(cmd, "--include_imports")
+	}
+
+	cmd = append(cmd, b.files...)
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *ProtocBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *ProtocBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *ProtocBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the protoc command.
+func (b *ProtocBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("protoc", b, NewProtocBuilder(), explainx.Generate("BuildCommand", b.BuildCommand))
+}
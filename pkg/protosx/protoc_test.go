@@ -0,0 +1,58 @@
+package protosx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtocBuilderBuildCommand(t *testing.T) {
+	cmd, err := NewProtocBuilder("acme/v1/service.proto", "acme/v1/types.proto").
+		WithProtoPath("proto", "third_party").
+		WithPlugin("go-grpc", "/go/bin/protoc-gen-go-grpc").
+		WithOut("go", "gen/go", "paths=source_relative").
+		WithOut("go-grpc", "gen/go", "paths=source_relative", "require_unimplemented_servers=false").
+		WithOut("python", "gen/python").
+		WithDescriptorSetOut("gen/descriptor.binpb").
+		WithIncludeImports(true).
+		BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"protoc",
+		"--proto_path=proto", "--proto_path=third_party",
+		"--plugin=protoc-gen-go-grpc=/go/bin/protoc-gen-go-grpc",
+		"--go_out=gen/go", "--go_opt=paths=source_relative",
+		"--go-grpc_out=gen/go", "--go-grpc_opt=paths=source_relative,require_unimplemented_servers=false",
+		"--python_out=gen/python",
+		"--descriptor_set_out=gen/descriptor.binpb", "--include_imports",
+		"acme/v1/service.proto", "acme/v1/types.proto",
+	}, cmd)
+}
+
+func TestProtocBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *ProtocBuilder
+		kind    error
+	}{
+		{"Missing files", NewProtocBuilder().WithOut("go", "gen"), errorsx.ErrMissingField},
+		{"Not a proto file", NewProtocBuilder("service.txt").WithOut("go", "gen"), errorsx.ErrInvalidValue},
+		{"Missing outputs", NewProtocBuilder("service.proto"), errorsx.ErrMissingField},
+		{"Imports without descriptor set", NewProtocBuilder("service.proto").WithOut("go", "gen").WithIncludeImports(true), errorsx.ErrConflictingOptions},
+		{"Plugin without path", NewProtocBuilder("service.proto").WithOut("go", "gen").WithPlugin("go", ""), errorsx.ErrInvalidValue},
+		{"Duplicate plugin", NewProtocBuilder("service.proto").WithOut("go", "gen").WithPlugin("go", "/bin/a").WithPlugin("go", "/bin/b"), errorsx.ErrInvalidValue},
+		{"Output without directory", NewProtocBuilder("service.proto").WithOut("go", ""), errorsx.ErrInvalidValue},
+		{"Invalid output name", NewProtocBuilder("service.proto").WithOut("Go Lang", "gen"), errorsx.ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.BuildCommand()
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.kind), "expected %v, got %v", tt.kind, err)
+		})
+	}
+}
@@ -0,0 +1,325 @@
+// Package provenancex assembles in-toto SLSA v1 provenance predicates for the images and
+// artifacts produced by daggerx builders, as JSON ready to be attached with `cosign attest`.
+//
+// The predicate records the builder identity, the invocation parameters (typically the command
+// generated by a builder such as apkox.ApkoBuilder), and the resolved dependencies, taken from
+// apko lockfiles or from known artifact digests.
+//
+// Example usage:
+//
+//	lock, err := apkox.ReadLockfile("apko.lock.json")
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	g := provenancex.NewGenerator("https://github.com/Excoriate/daggerx").
+//	    WithExternalParameter("config", "apko.yaml")
+//
+//	if err := g.WithBuilderCommand(apkoBuilder); err != nil {
+//	    // handle error
+//	}
+//	if err := g.WithApkoLock(lock); err != nil {
+//	    // handle error
+//	}
+//
+//	predicate, err := g.JSON()
+//	cmd := provenancex.AttestCommand("registry.example.com/base:v1", "/mnt/provenance.json")
+package provenancex
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+const (
+	// StatementType is the in-toto statement type wrapping the predicate.
+	StatementType = "https://in-toto.io/Statement/v1"
+	// PredicateType is the SLSA v1 provenance predicate type.
+	PredicateType = "https://slsa.dev/provenance/v1"
+	// CosignPredicateType is the predicate type name `cosign attest` expects for SLSA v1.
+	CosignPredicateType = "slsaprovenance1"
+	// DefaultBuildType is the build type recorded when none is set.
+	DefaultBuildType = "https://github.com/Excoriate/daggerx/buildtypes/dagger/v1"
+)
+
+// Predicate is a SLSA v1 provenance predicate.
+type Predicate struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+// BuildDefinition describes the inputs of the build.
+type BuildDefinition struct {
+	// BuildType identifies the template of the build.
+	BuildType string `json:"buildType"`
+	// ExternalParameters are the parameters under the control of the caller.
+	ExternalParameters map[string]any `json:"externalParameters"`
+	// InternalParameters are the parameters set by the builder itself.
+	InternalParameters map[string]any `json:"internalParameters,omitempty"`
+	// ResolvedDependencies are the materials consumed by the build.
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+// ResourceDescriptor identifies a material of the build.
+type ResourceDescriptor struct {
+	// URI is the location of the material.
+	URI string `json:"uri,omitempty"`
+	// Digest maps digest algorithms to hexadecimal digests.
+	Digest map[string]string `json:"digest,omitempty"`
+	// Name is a human-readable name of the material.
+	Name string `json:"name,omitempty"`
+}
+
+// RunDetails describes the execution of the build.
+type RunDetails struct {
+	Builder  Builder   `json:"builder"`
+	Metadata *Metadata `json:"metadata,omitempty"`
+}
+
+// Builder identifies the entity that ran the build.
+type Builder struct {
+	// ID is the URI of the builder.
+	ID string `json:"id"`
+	// Version maps builder components to their versions.
+	Version map[string]string `json:"version,omitempty"`
+}
+
+// Metadata holds the metadata of a build run.
+type Metadata struct {
+	InvocationID string     `json:"invocationID,omitempty"`
+	StartedOn    *time.Time `json:"startedOn,omitempty"`
+	FinishedOn   *time.Time `json:"finishedOn,omitempty"`
+}
+
+// CommandBuilder is implemented by the daggerx builders generating a command, such as
+// apkox.ApkoBuilder.
+type CommandBuilder interface {
+	BuildCommand() ([]string, error)
+}
+
+// Generator assembles a provenance predicate.
+type Generator struct {
+	builderID    string
+	buildType    string
+	versions     map[string]string
+	external     map[string]any
+	internal     map[string]any
+	materials    []ResourceDescriptor
+	invocationID string
+	startedOn    *time.Time
+	finishedOn   *time.Time
+}
+
+// NewGenerator creates a Generator for the builder identified by 'builderID'.
+func NewGenerator(builderID string) *Generator {
+	return &Generator{
+		builderID: builderID,
+		buildType: DefaultBuildType,
+		versions:  map[string]string{},
+		external:  map[string]any{},
+		internal:  map[string]any{},
+	}
+}
+
+// WithBuildType sets the build type of the predicate.
+func (g *Generator) WithBuildType(buildType string) *Generator {
+	g.buildType = buildType
+	return g
+}
+
+// WithBuilderVersion records the version of a builder component (e.g. "dagger", "apko").
+func (g *Generator) WithBuilderVersion(component, version string) *Generator {
+	g.versions[component] = version
+	return g
+}
+
+// WithExternalParameter records a parameter under the control of the caller.
+func (g *Generator) WithExternalParameter(key string, value any) *Generator {
+	g.external[key] = value
+	return g
+}
+
+// WithInternalParameter records a parameter set by the builder itself.
+func (g *Generator) WithInternalParameter(key string, value any) *Generator {
+	g.internal[key] = value
+	return g
+}
+
+// WithCommand records the build command as the "command" external parameter.
+func (g *Generator) WithCommand(cmd types.DaggerCMD) *Generator {
+	g.external["command"] = []string(cmd)
+	return g
+}
+
+// WithBuilderCommand records the command generated by 'b' as the "command" external parameter.
+func (g *Generator) WithBuilderCommand(b CommandBuilder) error {
+	if b == nil {
+		return fmt.Errorf("builder is required")
+	}
+
+	cmd, err := b.BuildCommand()
+	if err != nil {
+		return fmt.Errorf("failed to build command for provenance: %w", err)
+	}
+
+	g.WithCommand(cmd)
+
+	return nil
+}
+
+// WithMaterial records a resolved dependency identified by its URI and digest ("algo:hex").
+func (g *Generator) WithMaterial(uri, digest string) error {
+	algo, hex, ok := strings.Cut(digest, ":")
+	if uri == "" || !ok || algo == "" || hex == "" {
+		return fmt.Errorf("invalid material %q with digest %q", uri, digest)
+	}
+
+	g.materials = append(g.materials, ResourceDescriptor{
+		URI:    uri,
+		Digest: map[string]string{algo: hex},
+	})
+
+	return nil
+}
+
+// WithApkoLock records every package of an apko lockfile as a resolved dependency.
+func (g *Generator) WithApkoLock(lock *apkox.ApkoLock) error {
+	if lock == nil {
+		return fmt.Errorf("apko lockfile is required")
+	}
+
+	for _, p := range lock.Contents.Packages {
+		rd := ResourceDescriptor{
+			URI:  p.URL,
+			Name: fmt.Sprintf("%s@%s", p.Name, p.Version),
+		}
+
+		if p.Checksum != "" {
+			algo, digest, err := apkox.ChecksumDigest(p.Checksum)
+			if err != nil {
+				return fmt.Errorf("package %s: %w", p.Name, err)
+			}
+			rd.Digest = map[string]string{algo: digest}
+		}
+
+		g.materials = append(g.materials, rd)
+	}
+
+	return nil
+}
+
+// WithInvocationID sets the identifier of the build run.
+func (g *Generator) WithInvocationID(id string) *Generator {
+	g.invocationID = id
+	return g
+}
+
+// WithTimes sets the start and end times of the build run.
+func (g *Generator) WithTimes(startedOn, finishedOn time.Time) *Generator {
+	s, f := startedOn.UTC(), finishedOn.UTC()
+	g.startedOn, g.finishedOn = &s, &f
+	return g
+}
+
+// Predicate assembles the provenance predicate.
+//
+// Returns:
+//   - The predicate, with materials sorted by URI then name.
+//   - An error if the builder id is missing or the run times are inconsistent.
+func (g *Generator) Predicate() (*Predicate, error) {
+	if g.builderID == "" {
+		return nil, fmt.Errorf("builder id is required")
+	}
+
+	if g.buildType == "" {
+		return nil, fmt.Errorf("build type is required")
+	}
+
+	if g.startedOn != nil && g.finishedOn != nil && g.finishedOn.Before(*g.startedOn) {
+		return nil, fmt.Errorf("build finished before it started")
+	}
+
+	materials := append([]ResourceDescriptor(nil), g.materials...)
+	sort.SliceStable(materials, func(i, j int) bool {
+		if materials[i].URI != materials[j].URI {
+			return materials[i].URI < materials[j].URI
+		}
+		return materials[i].Name < materials[j].Name
+	})
+
+	p := &Predicate{
+		BuildDefinition: BuildDefinition{
+			BuildType:            g.buildType,
+			ExternalParameters:   copyMap(g.external),
+			ResolvedDependencies: materials,
+		},
+		RunDetails: RunDetails{
+			Builder: Builder{ID: g.builderID},
+		},
+	}
+
+	if len(g.internal) > 0 {
+		p.BuildDefinition.InternalParameters = copyMap(g.internal)
+	}
+
+	if len(g.versions) > 0 {
+		p.RunDetails.Builder.Version = make(map[string]string, len(g.versions))
+		for k, v := range g.versions {
+			p.RunDetails.Builder.Version[k] = v
+		}
+	}
+
+	if g.invocationID != "" || g.startedOn != nil || g.finishedOn != nil {
+		p.RunDetails.Metadata = &Metadata{
+			InvocationID: g.invocationID,
+			StartedOn:    g.startedOn,
+			FinishedOn:   g.finishedOn,
+		}
+	}
+
+	return p, nil
+}
+
+// JSON returns the indented JSON encoding of the predicate, as passed to `cosign attest --predicate`,
+// with the secrets registered with the default logx redactor scrubbed.
+func (g *Generator) JSON() ([]byte, error) {
+	p, err := g.Predicate()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode provenance predicate: %w", err)
+	}
+
+	return logx.Default().Bytes(data), nil
+}
+
+// AttestCommand returns the `cosign attest` command attaching the predicate file at
+// 'predicatePath' to the image 'imageRef'.
+func AttestCommand(imageRef, predicatePath string) types.DaggerCMD {
+	return types.DaggerCMD{
+		"cosign", "attest", "--yes",
+		"--type", CosignPredicateType,
+		"--predicate", predicatePath,
+		imageRef,
+	}
+}
+
+// copyMap returns a shallow copy of 'm'.
+func copyMap(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+
+	return out
+}
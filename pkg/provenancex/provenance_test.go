@@ -0,0 +1,134 @@
+package provenancex
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+type fakeBuilder struct {
+	cmd []string
+	err error
+}
+
+func (f fakeBuilder) BuildCommand() ([]string, error) {
+	return f.cmd, f.err
+}
+
+func TestGeneratorPredicate(t *testing.T) {
+	g := NewGenerator("https://example.com/builder").
+		WithBuilderVersion("dagger", "v0.18.5").
+		WithExternalParameter("config", "apko.yaml").
+		WithInvocationID("run-1").
+		WithTimes(time.Unix(100, 0), time.Unix(200, 0))
+
+	if err := g.WithBuilderCommand(fakeBuilder{cmd: []string{"apko", "build"}}); err != nil {
+		t.Fatalf("WithBuilderCommand returned unexpected error: %v", err)
+	}
+
+	if err := g.WithMaterial("oci://cgr.dev/chainguard/apko", "sha256:abc"); err != nil {
+		t.Fatalf("WithMaterial returned unexpected error: %v", err)
+	}
+
+	lock := &apkox.ApkoLock{
+		Version: "v1",
+		Contents: apkox.ApkoLockContents{
+			Packages: []apkox.ApkoLockPackage{
+				{Name: "busybox", Version: "1.36-r0", URL: "https://packages.wolfi.dev/os/busybox.apk", Checksum: "Q1qqsJ9iUMRQpp17wLvPx5ExHxXHI="},
+			},
+		},
+	}
+	if err := g.WithApkoLock(lock); err != nil {
+		t.Fatalf("WithApkoLock returned unexpected error: %v", err)
+	}
+
+	p, err := g.Predicate()
+	if err != nil {
+		t.Fatalf("Predicate returned unexpected error: %v", err)
+	}
+
+	if p.RunDetails.Builder.ID != "https://example.com/builder" || p.RunDetails.Builder.Version["dagger"] != "v0.18.5" {
+		t.Errorf("Unexpected builder %+v", p.RunDetails.Builder)
+	}
+
+	if got := p.BuildDefinition.ExternalParameters["command"]; !reflect.DeepEqual(got, []string{"apko", "build"}) {
+		t.Errorf("Unexpected command parameter %v", got)
+	}
+
+	deps := p.BuildDefinition.ResolvedDependencies
+	if len(deps) != 2 {
+		t.Fatalf("Expected 2 materials, got %d", len(deps))
+	}
+
+	if deps[0].Name != "busybox@1.36-r0" || deps[0].Digest["sha1"] != "aaab09f6250c450a69d7bc0bbcfc791311f15c72" {
+		t.Errorf("Unexpected lockfile material %+v", deps[0])
+	}
+
+	if deps[1].Digest["sha256"] != "abc" {
+		t.Errorf("Unexpected digest material %+v", deps[1])
+	}
+
+	if p.RunDetails.Metadata == nil || p.RunDetails.Metadata.InvocationID != "run-1" {
+		t.Errorf("Unexpected metadata %+v", p.RunDetails.Metadata)
+	}
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	if _, err := NewGenerator("").Predicate(); err == nil {
+		t.Error("Expected error for missing builder id, got none")
+	}
+
+	g := NewGenerator("b").WithTimes(time.Unix(200, 0), time.Unix(100, 0))
+	if _, err := g.Predicate(); err == nil {
+		t.Error("Expected error for inconsistent times, got none")
+	}
+
+	if err := NewGenerator("b").WithMaterial("uri", "nodigest"); err == nil {
+		t.Error("Expected error for invalid digest, got none")
+	}
+
+	if err := NewGenerator("b").WithBuilderCommand(fakeBuilder{err: errTest}); err == nil {
+		t.Error("Expected error from builder, got none")
+	}
+
+	if err := NewGenerator("b").WithApkoLock(nil); err == nil {
+		t.Error("Expected error for nil lockfile, got none")
+	}
+}
+
+func TestGeneratorJSON(t *testing.T) {
+	data, err := NewGenerator("b").WithCommand(types.DaggerCMD{"apko", "build"}).JSON()
+	if err != nil {
+		t.Fatalf("JSON returned unexpected error: %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Generated JSON is invalid: %v", err)
+	}
+
+	def, ok := decoded["buildDefinition"].(map[string]any)
+	if !ok || def["buildType"] != DefaultBuildType {
+		t.Errorf("Unexpected buildDefinition %v", decoded["buildDefinition"])
+	}
+
+	if _, ok := decoded["runDetails"].(map[string]any)["metadata"]; ok {
+		t.Error("Expected metadata to be omitted when unset")
+	}
+}
+
+func TestAttestCommand(t *testing.T) {
+	got := AttestCommand("example.com/img:v1", "/mnt/provenance.json")
+	want := types.DaggerCMD{"cosign", "attest", "--yes", "--type", "slsaprovenance1", "--predicate", "/mnt/provenance.json", "example.com/img:v1"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AttestCommand() = %v, want %v", got, want)
+	}
+}
+
+var errTest = errors.New("test error")
@@ -0,0 +1,289 @@
+// Package ratelimitx throttles the requests this module sends to registries and APIs, with a
+// token bucket per host shared by the digest resolver and the metadata fetchers, so large
+// matrices resolving many references concurrently don't get banned by Docker Hub rate limits.
+//
+// Default returns the process-wide HostLimiter used by containerx, apkox and githubx. Its hosts
+// are allowed DefaultRate requests per second, and Docker Hub DockerHubRate.
+//
+// Example usage:
+//
+//	limiter := ratelimitx.NewHostLimiter(5, 10).WithHost("ghcr.io", 20, 20)
+//
+//	if err := limiter.Wait(ctx, "ghcr.io"); err != nil {
+//	    // the context is done
+//	}
+//
+//	client := &http.Client{Transport: limiter.Transport(nil)}
+package ratelimitx
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/timex"
+)
+
+const (
+	// DefaultRate is the number of requests per second the default limiter allows each host.
+	DefaultRate = 10.0
+	// DefaultBurst is the number of requests the default limiter allows each host at once.
+	DefaultBurst = 10
+	// DockerHubRate is the number of requests per second the default limiter allows Docker Hub.
+	DockerHubRate = 2.0
+	// DockerHubBurst is the number of requests the default limiter allows Docker Hub at once.
+	DockerHubBurst = 5
+)
+
+// dockerHubHosts are the hosts of Docker Hub, which share a bucket.
+var dockerHubHosts = map[string]bool{
+	"docker.io":            true,
+	"index.docker.io":      true,
+	"registry-1.docker.io": true,
+	"auth.docker.io":       true,
+}
+
+// Limiter is a token bucket allowing 'rate' requests per second on average, and bursts of up to
+// 'burst' requests. It is safe for concurrent use; waiting callers are served in order.
+type Limiter struct {
+	mu sync.Mutex
+
+	// rate is the number of tokens added per second. A non-positive rate means no limit.
+	rate float64
+
+	// burst is the capacity of the bucket.
+	burst int
+
+	// tokens are the available tokens. It is negative when callers wait for tokens.
+	tokens float64
+
+	// last is the time the tokens were last computed.
+	last time.Time
+
+	// clock reads the current time.
+	clock timex.Clock
+
+	// sleep waits for a token; it is replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewLimiter creates a full Limiter allowing 'rate' requests per second and bursts of 'burst'
+// requests. A non-positive rate means no limit, and a burst below 1 is 1.
+func NewLimiter(rate float64, burst int) *Limiter {
+	burst = max(burst, 1)
+
+	return &Limiter{rate: rate, burst: burst, tokens: float64(burst), clock: timex.System, sleep: sleep}
+}
+
+// reserve takes a token and returns the wait until it is available.
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return 0
+	}
+
+	now := l.clock.Now()
+	if !l.last.IsZero() {
+		l.tokens = min(float64(l.burst), l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel returns a token taken by reserve.
+func (l *Limiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens = min(float64(l.burst), l.tokens+1)
+}
+
+// Allow takes a token if one is available now, without waiting.
+func (l *Limiter) Allow() bool {
+	if l.reserve() == 0 {
+		return true
+	}
+
+	l.cancel()
+
+	return false
+}
+
+// Wait takes a token, waiting until one is available.
+//
+// Returns:
+//   - An error if 'ctx' is done before a token is available; the token is given back.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	wait := l.reserve()
+	if wait == 0 {
+		return nil
+	}
+
+	if err := l.sleep(ctx, wait); err != nil {
+		l.cancel()
+		return err
+	}
+
+	return nil
+}
+
+// HostLimiter holds a Limiter per host. It is safe for concurrent use.
+type HostLimiter struct {
+	mu sync.Mutex
+
+	// rate and burst configure the limiters of the hosts without an override.
+	rate  float64
+	burst int
+
+	// overrides hold the rate and burst of specific hosts.
+	overrides map[string]override
+
+	// limiters are the limiters of the hosts, created on first use.
+	limiters map[string]*Limiter
+
+	// clock and sleep are given to the limiters; they are replaced in tests.
+	clock timex.Clock
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// override is the rate and burst of a host.
+type override struct {
+	rate  float64
+	burst int
+}
+
+// NewHostLimiter creates a HostLimiter allowing each host 'rate' requests per second and bursts
+// of 'burst' requests.
+func NewHostLimiter(rate float64, burst int) *HostLimiter {
+	return &HostLimiter{
+		rate:      rate,
+		burst:     burst,
+		overrides: map[string]override{},
+		limiters:  map[string]*Limiter{},
+		clock:     timex.System,
+		sleep:     sleep,
+	}
+}
+
+// WithHost sets the rate and burst of 'host'. It replaces the limiter of the host if it exists.
+func (h *HostLimiter) WithHost(host string, rate float64, burst int) *HostLimiter {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	host = canonicalHost(host)
+	h.overrides[host] = override{rate: rate, burst: burst}
+	delete(h.limiters, host)
+
+	return h
+}
+
+// Limiter returns the limiter of 'host', creating it on first use. Docker Hub hosts share a
+// limiter, and hosts are case-insensitive.
+func (h *HostLimiter) Limiter(host string) *Limiter {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	host = canonicalHost(host)
+	if l, ok := h.limiters[host]; ok {
+		return l
+	}
+
+	rate, burst := h.rate, h.burst
+	if o, ok := h.overrides[host]; ok {
+		rate, burst = o.rate, o.burst
+	}
+
+	l := NewLimiter(rate, burst)
+	l.clock, l.sleep = h.clock, h.sleep
+	h.limiters[host] = l
+
+	return l
+}
+
+// Wait takes a token of the limiter of 'host', see Limiter.Wait. A nil HostLimiter doesn't
+// limit requests.
+func (h *HostLimiter) Wait(ctx context.Context, host string) error {
+	if h == nil {
+		return ctx.Err()
+	}
+
+	return h.Limiter(host).Wait(ctx)
+}
+
+// Transport returns an http.RoundTripper throttling the requests sent with 'base' by host. A
+// nil base is http.DefaultTransport.
+func (h *HostLimiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &transport{limiter: h, base: base}
+}
+
+// transport is the http.RoundTripper returned by HostLimiter.Transport.
+type transport struct {
+	limiter *HostLimiter
+	base    http.RoundTripper
+}
+
+// RoundTrip waits for a token of the host of the request, and sends it.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context(), req.URL.Host); err != nil {
+		return nil, err
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultLimiter *HostLimiter
+)
+
+// Default returns the HostLimiter shared by the resolvers and fetchers of this module.
+func Default() *HostLimiter {
+	defaultOnce.Do(func() {
+		defaultLimiter = NewHostLimiter(DefaultRate, DefaultBurst).
+			WithHost("registry-1.docker.io", DockerHubRate, DockerHubBurst)
+	})
+
+	return defaultLimiter
+}
+
+// canonicalHost lowercases 'host', strips the default HTTPS port, and maps the Docker Hub hosts
+// to registry-1.docker.io.
+func canonicalHost(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ":443")
+	if dockerHubHosts[host] {
+		return "registry-1.docker.io"
+	}
+
+	return host
+}
+
+// sleep waits for 'd', or until 'ctx' is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
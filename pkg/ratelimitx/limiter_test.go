@@ -0,0 +1,155 @@
+package ratelimitx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTime is a clock advanced by the waits of the limiters using it.
+type fakeTime struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func newFakeTime() *fakeTime {
+	return &fakeTime{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (f *fakeTime) clock() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *fakeTime) sleep(_ context.Context, d time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.waits = append(f.waits, d)
+	f.now = f.now.Add(d)
+
+	return nil
+}
+
+func (f *fakeTime) advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+}
+
+func newTestLimiter(rate float64, burst int) (*Limiter, *fakeTime) {
+	ft := newFakeTime()
+	l := NewLimiter(rate, burst)
+	l.clock, l.sleep = ft.clock, ft.sleep
+
+	return l, ft
+}
+
+func TestLimiterBurstThenRate(t *testing.T) {
+	l, ft := newTestLimiter(2, 3)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, l.Wait(context.Background()))
+	}
+
+	// The burst is served at once, then one request every 500ms.
+	assert.Equal(t, []time.Duration{500 * time.Millisecond, 500 * time.Millisecond}, ft.waits)
+}
+
+func TestLimiterRefill(t *testing.T) {
+	l, ft := newTestLimiter(1, 2)
+
+	assert.True(t, l.Allow())
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	ft.advance(time.Second)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	ft.advance(time.Hour)
+	assert.True(t, l.Allow())
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow(), "tokens are capped by the burst")
+}
+
+func TestLimiterUnlimited(t *testing.T) {
+	l, ft := newTestLimiter(0, 1)
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, l.Wait(context.Background()))
+	}
+	assert.Empty(t, ft.waits)
+}
+
+func TestLimiterWaitCancelled(t *testing.T) {
+	l := NewLimiter(0.001, 1)
+	require.True(t, l.Allow())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, l.Wait(ctx), context.DeadlineExceeded)
+	assert.ErrorIs(t, l.Wait(ctx), context.DeadlineExceeded, "done contexts fail at once")
+}
+
+func TestHostLimiter(t *testing.T) {
+	ft := newFakeTime()
+	h := NewHostLimiter(1, 1).WithHost("ghcr.io", 10, 10)
+	h.clock, h.sleep = ft.clock, ft.sleep
+
+	assert.Same(t, h.Limiter("docker.io"), h.Limiter("REGISTRY-1.docker.io:443"))
+	assert.Same(t, h.Limiter("index.docker.io"), h.Limiter("auth.docker.io"))
+	assert.NotSame(t, h.Limiter("quay.io"), h.Limiter("ghcr.io"))
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, h.Wait(context.Background(), "ghcr.io"))
+	}
+	assert.Empty(t, ft.waits, "ghcr.io has its own burst")
+
+	require.NoError(t, h.Wait(context.Background(), "cgr.dev"))
+	require.NoError(t, h.Wait(context.Background(), "cgr.dev"))
+	assert.Equal(t, []time.Duration{time.Second}, ft.waits)
+
+	var unlimited *HostLimiter
+	assert.NoError(t, unlimited.Wait(context.Background(), "docker.io"))
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	h := NewHostLimiter(0.001, 1)
+	client := &http.Client{Transport: h.Transport(nil)}
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "the second request waits for the bucket")
+}
+
+func TestDefault(t *testing.T) {
+	assert.Same(t, Default(), Default())
+	assert.Equal(t, DockerHubRate, Default().Limiter("docker.io").rate)
+	assert.Equal(t, DefaultRate, Default().Limiter("cgr.dev").rate)
+}
@@ -0,0 +1,320 @@
+// Package registrypolicy gates pipelines on the signatures of the base and repository images
+// they build from: each image reference is matched to the verification its registry scope
+// requires (cosign or notation), the verification commands are generated and run, and the
+// pipeline fails when an image is unsigned, its signature doesn't satisfy the policy, or no
+// verification covers it.
+//
+// Scopes are image reference prefixes ("cgr.dev/chainguard", "registry.example.com/base/") or
+// "*" for every image; the longest matching scope applies. Exempt scopes skip verification.
+//
+// Example usage:
+//
+//	gate := registrypolicy.NewGate().
+//	    WithCosign("cgr.dev/chainguard", "", cosignx.NewVerifyPolicy().
+//	        WithIdentity("https://github.com/chainguard-images/images/.github/workflows/release.yaml@refs/heads/main").
+//	        WithIssuer("https://token.actions.githubusercontent.com")).
+//	    WithNotation("registry.example.com", stores, trustPolicy).
+//	    WithExempt("localhost:5000")
+//
+//	result, err := gate.Run(ctx, executor, "cgr.dev/chainguard/static@sha256:...")
+//	if err != nil {
+//	    // handle error
+//	}
+//	if !result.Passed {
+//	    return result.Err()
+//	}
+package registrypolicy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/cosignx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/execx"
+	"github.com/Excoriate/daggerx/pkg/notaryx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the registry policy gate in errorsx errors.
+const builderName = "registrypolicy"
+
+// Method is how the signature of an image is verified.
+type Method string
+
+const (
+	// MethodCosign verifies Sigstore signatures with cosign verify.
+	MethodCosign Method = "cosign"
+	// MethodNotation verifies Notary Project signatures with notation verify.
+	MethodNotation Method = "notation"
+	// MethodExempt skips the verification.
+	MethodExempt Method = "exempt"
+)
+
+const (
+	// RuleSigned requires the images to carry a valid signature.
+	RuleSigned policyx.Rule = "signed"
+	// RuleCovered requires the images to be covered by a verification scope.
+	RuleCovered policyx.Rule = "covered"
+)
+
+// requirement is the verification required for the images of a scope.
+type requirement struct {
+	scope  string
+	method Method
+
+	// cosignKey and cosignPolicy configure cosign verifications.
+	cosignKey    string
+	cosignPolicy *cosignx.VerifyPolicy
+
+	// notationStores and notationPolicies configure notation verifications.
+	notationStores   []notaryx.TrustStore
+	notationPolicies []*notaryx.TrustPolicy
+}
+
+// Check is the verification of an image.
+type Check struct {
+	// Ref is the image reference.
+	Ref string
+	// Scope is the scope matching the reference. It is empty when none does.
+	Scope string
+	// Method is the verification method. It is empty when no scope matches the reference.
+	Method Method
+	// Command is the verification command. It is empty for exempt and uncovered images.
+	Command []string
+	// Env are the environment variables of the command.
+	Env []types.DaggerEnvVars
+	// Mounts are the mounts of the command.
+	Mounts []types.Mount
+
+	// policy evaluates the output of cosign verifications.
+	policy *cosignx.VerifyPolicy
+}
+
+// Gate holds the verifications required per registry scope.
+type Gate struct {
+	requirements []requirement
+}
+
+// NewGate creates a Gate without scopes: every image fails until a scope covers it.
+func NewGate() *Gate {
+	return &Gate{}
+}
+
+// WithCosign requires the images of 'scope' to carry a cosign signature verified with the
+// public key 'key', or keyless when it is empty, and accepted by 'policy'.
+func (g *Gate) WithCosign(scope, key string, policy *cosignx.VerifyPolicy) *Gate {
+	g.requirements = append(g.requirements, requirement{
+		scope: scope, method: MethodCosign, cosignKey: key, cosignPolicy: policy,
+	})
+	return g
+}
+
+// WithNotation requires the images of 'scope' to carry a notation signature trusted by
+// 'policies', verified with the certificates of 'stores'.
+func (g *Gate) WithNotation(scope string, stores []notaryx.TrustStore, policies ...*notaryx.TrustPolicy) *Gate {
+	g.requirements = append(g.requirements, requirement{
+		scope: scope, method: MethodNotation, notationStores: stores, notationPolicies: policies,
+	})
+	return g
+}
+
+// WithExempt exempts the images of 'scope' from verification (e.g. a local registry).
+func (g *Gate) WithExempt(scope string) *Gate {
+	g.requirements = append(g.requirements, requirement{scope: scope, method: MethodExempt})
+	return g
+}
+
+// matchLength returns the length of 'scope' when it matches 'ref', or -1. The wildcard matches
+// every reference with length 0.
+func matchLength(scope, ref string) int {
+	if scope == "*" {
+		return 0
+	}
+
+	if strings.HasSuffix(scope, "/") && strings.HasPrefix(ref, scope) {
+		return len(scope)
+	}
+
+	if ref == scope {
+		return len(scope)
+	}
+
+	if strings.HasPrefix(ref, scope) && strings.ContainsRune("/:@", rune(ref[len(scope)])) {
+		return len(scope)
+	}
+
+	return -1
+}
+
+// requirementOf returns the requirement of the longest scope matching 'ref'.
+func (g *Gate) requirementOf(ref string) (requirement, bool) {
+	best, length := requirement{}, -1
+	for _, r := range g.requirements {
+		if n := matchLength(r.scope, ref); n > length {
+			best, length = r, n
+		}
+	}
+
+	return best, length >= 0
+}
+
+// validate checks the scopes of the gate.
+func (g *Gate) validate() error {
+	seen := map[string]bool{}
+	for _, r := range g.requirements {
+		if r.scope == "" {
+			return errorsx.MissingField(builderName, "scope", "verification scope is required")
+		}
+
+		if seen[r.scope] {
+			return errorsx.InvalidValue(builderName, "scope", r.scope, "duplicate verification scope %s", r.scope)
+		}
+		seen[r.scope] = true
+	}
+
+	return nil
+}
+
+// Checks returns the verifications of 'refs', in order.
+//
+// Returns:
+//   - The checks.
+//   - An error if a scope is empty or duplicated, or a verification command can't be generated.
+func (g *Gate) Checks(refs ...string) ([]Check, error) {
+	if err := g.validate(); err != nil {
+		return nil, err
+	}
+
+	checks := make([]Check, 0, len(refs))
+	for _, ref := range refs {
+		check, err := g.check(ref)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
+
+// check returns the verification of 'ref'.
+func (g *Gate) check(ref string) (Check, error) {
+	r, ok := g.requirementOf(ref)
+	if !ok {
+		return Check{Ref: ref}, nil
+	}
+
+	check := Check{Ref: ref, Scope: r.scope, Method: r.method}
+
+	switch r.method {
+	case MethodCosign:
+		cmd, err := cosignx.NewVerifyBuilder(ref).WithKey(r.cosignKey).WithPolicy(r.cosignPolicy).BuildCommand()
+		if err != nil {
+			return Check{}, err
+		}
+		check.Command, check.policy = cmd, r.cosignPolicy
+	case MethodNotation:
+		b := notaryx.NewVerifyBuilder(ref).WithTrustStores(r.notationStores...).WithTrustPolicies(r.notationPolicies...)
+
+		cmd, err := b.BuildCommand()
+		if err != nil {
+			return Check{}, err
+		}
+
+		check.Command, check.Env, check.Mounts = cmd, b.Env(), b.Mounts()
+	}
+
+	return check, nil
+}
+
+// Evaluate returns the violations of the check given the result of its command ('res' is nil
+// for exempt and uncovered images): uncovered images, failed verifications, and cosign
+// signatures not accepted by the policy of the scope.
+//
+// Returns:
+//   - The violations.
+//   - An error if the cosign output can't be parsed.
+func (c Check) Evaluate(res *execx.Result) ([]policyx.Violation, error) {
+	switch c.Method {
+	case "":
+		return []policyx.Violation{{
+			Rule:    RuleCovered,
+			Message: fmt.Sprintf("image %s is not covered by a verification scope", c.Ref),
+		}}, nil
+	case MethodExempt:
+		return nil, nil
+	}
+
+	if !res.Succeeded() {
+		return []policyx.Violation{{
+			Rule:    RuleSigned,
+			Message: fmt.Sprintf("image %s failed %s verification: %s", c.Ref, c.Method, summarize(res)),
+		}}, nil
+	}
+
+	if c.Method != MethodCosign || c.policy == nil {
+		return nil, nil
+	}
+
+	result, err := c.policy.Evaluate([]byte(res.Stdout))
+	if err != nil {
+		return nil, err
+	}
+
+	violations := make([]policyx.Violation, 0, len(result.Violations))
+	for _, v := range result.Violations {
+		v.Message = fmt.Sprintf("image %s: %s", c.Ref, v.Message)
+		violations = append(violations, v)
+	}
+
+	return violations, nil
+}
+
+// Run verifies 'refs' with 'executor', and evaluates the results.
+//
+// Returns:
+//   - The evaluation result, failing when an image is unsigned, not accepted, or not covered.
+//   - An error if the checks can't be generated, a command can't be run, or a cosign output
+//     can't be parsed.
+func (g *Gate) Run(ctx context.Context, executor execx.Executor, refs ...string) (policyx.Result, error) {
+	checks, err := g.Checks(refs...)
+	if err != nil {
+		return policyx.Result{}, err
+	}
+
+	var violations []policyx.Violation
+	for _, c := range checks {
+		var res *execx.Result
+
+		if len(c.Command) > 0 {
+			if res, err = executor.Run(ctx, c.Command, c.Env, c.Mounts); err != nil {
+				return policyx.Result{}, fmt.Errorf("failed to verify %s: %w", c.Ref, err)
+			}
+		}
+
+		v, err := c.Evaluate(res)
+		if err != nil {
+			return policyx.Result{}, err
+		}
+		violations = append(violations, v...)
+	}
+
+	return policyx.Result{Passed: len(violations) == 0, Violations: violations}, nil
+}
+
+// summarize returns the last line of the standard error of a failed command, or its exit code.
+func summarize(res *execx.Result) string {
+	if res == nil {
+		return "no result"
+	}
+
+	lines := strings.Split(strings.TrimSpace(res.Stderr), "\n")
+	if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+		return last
+	}
+
+	return fmt.Sprintf("exit code %d", res.ExitCode)
+}
@@ -0,0 +1,192 @@
+package registrypolicy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/cosignx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/execx"
+	"github.com/Excoriate/daggerx/pkg/notaryx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	chainguardRef = "cgr.dev/chainguard/static@sha256:abc"
+	internalRef   = "registry.example.com/base/debian:12"
+	localRef      = "localhost:5000/app:dev"
+)
+
+const verifyOutput = `[
+  {
+    "critical": {
+      "identity": {"docker-reference": "cgr.dev/chainguard/static"},
+      "image": {"docker-manifest-digest": "sha256:abc"}
+    },
+    "optional": {
+      "Bundle": {"SignedEntryTimestamp": "MEUC"},
+      "Issuer": "https://token.actions.githubusercontent.com",
+      "Subject": "https://github.com/chainguard-images/images/.github/workflows/release.yaml@refs/heads/main"
+    }
+  }
+]`
+
+// scriptedExecutor returns the results of the commands by image reference.
+type scriptedExecutor struct {
+	results map[string]*execx.Result
+	cmds    []types.DaggerCMD
+}
+
+func (e *scriptedExecutor) Run(
+	_ context.Context,
+	cmd types.DaggerCMD,
+	_ []types.DaggerEnvVars,
+	_ []types.Mount,
+) (*execx.Result, error) {
+	e.cmds = append(e.cmds, cmd)
+	return e.results[cmd[len(cmd)-1]], nil
+}
+
+func newTestGate() *Gate {
+	store := notaryx.TrustStore{Type: notaryx.StoreCA, Name: "acme", Certificates: []string{"root.crt"}}
+	policy := notaryx.NewTrustPolicy("base", "registry.example.com/base/debian").
+		WithTrustStores(store).
+		WithTrustedIdentities("*")
+
+	return NewGate().
+		WithCosign("cgr.dev/chainguard", "", cosignx.NewVerifyPolicy().
+			WithIdentity("https://github.com/chainguard-images/images/.github/workflows/release.yaml@refs/heads/main").
+			WithIssuer("https://token.actions.githubusercontent.com")).
+		WithNotation("registry.example.com", []notaryx.TrustStore{store}, policy).
+		WithExempt("localhost:5000")
+}
+
+func TestMatchLength(t *testing.T) {
+	tests := []struct {
+		scope, ref string
+		want       int
+	}{
+		{"*", "docker.io/library/alpine", 0},
+		{"cgr.dev/chainguard", "cgr.dev/chainguard/static", 18},
+		{"cgr.dev/chainguard/static", "cgr.dev/chainguard/static:latest", 25},
+		{"cgr.dev/chainguard/static", "cgr.dev/chainguard/static@sha256:abc", 25},
+		{"cgr.dev/chainguard/static", "cgr.dev/chainguard/static", 25},
+		{"cgr.dev/chainguard/", "cgr.dev/chainguard/static", 19},
+		{"cgr.dev/chain", "cgr.dev/chainguard/static", -1},
+		{"registry.example.com", "docker.io/library/alpine", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scope+" "+tt.ref, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchLength(tt.scope, tt.ref))
+		})
+	}
+}
+
+func TestGateChecks(t *testing.T) {
+	checks, err := newTestGate().
+		WithCosign("cgr.dev/chainguard/static", "cosign.pub", nil).
+		Checks(chainguardRef, internalRef, localRef, "docker.io/library/alpine:3.20")
+	require.NoError(t, err)
+	require.Len(t, checks, 4)
+
+	// The longest scope applies.
+	assert.Equal(t, "cgr.dev/chainguard/static", checks[0].Scope)
+	assert.Equal(t, MethodCosign, checks[0].Method)
+	assert.Equal(t, []string{"cosign", "verify", "--key", "cosign.pub", chainguardRef}, checks[0].Command)
+
+	assert.Equal(t, MethodNotation, checks[1].Method)
+	assert.Equal(t, []string{"notation", "verify", internalRef}, checks[1].Command)
+	assert.NotEmpty(t, checks[1].Env)
+	assert.NotEmpty(t, checks[1].Mounts)
+
+	assert.Equal(t, MethodExempt, checks[2].Method)
+	assert.Empty(t, checks[2].Command)
+
+	assert.Empty(t, checks[3].Method)
+	assert.Empty(t, checks[3].Command)
+}
+
+func TestGateChecksValidation(t *testing.T) {
+	_, err := NewGate().WithExempt("").Checks(localRef)
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+
+	_, err = NewGate().WithExempt("localhost:5000").WithExempt("localhost:5000").Checks(localRef)
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+
+	// Keyless cosign verification requires an identity.
+	_, err = NewGate().WithCosign("*", "", nil).Checks(chainguardRef)
+	assert.Error(t, err)
+}
+
+func TestCheckEvaluate(t *testing.T) {
+	checks, err := newTestGate().Checks(chainguardRef, internalRef, localRef, "docker.io/library/alpine")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		check Check
+		res   *execx.Result
+		rules []string
+	}{
+		{"signed", checks[0], &execx.Result{Stdout: verifyOutput}, nil},
+		{"unsigned", checks[0], &execx.Result{Stderr: "Error: no signatures found\n", ExitCode: 1}, []string{"signed"}},
+		{"identity mismatch", checks[0], &execx.Result{Stdout: `[{"optional": {"Issuer": "https://token.actions.githubusercontent.com", "Subject": "other", "Bundle": {}}}]`}, []string{"certificate-identity"}},
+		{"notation signed", checks[1], &execx.Result{}, nil},
+		{"notation unsigned", checks[1], &execx.Result{ExitCode: 1}, []string{"signed"}},
+		{"exempt", checks[2], nil, nil},
+		{"uncovered", checks[3], nil, []string{"covered"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := tt.check.Evaluate(tt.res)
+			require.NoError(t, err)
+
+			var rules []string
+			for _, v := range violations {
+				rules = append(rules, string(v.Rule))
+				assert.Contains(t, v.Message, tt.check.Ref)
+			}
+			assert.Equal(t, tt.rules, rules)
+		})
+	}
+}
+
+func TestCheckEvaluateMessage(t *testing.T) {
+	violations, err := Check{Ref: internalRef, Method: MethodNotation}.Evaluate(&execx.Result{
+		Stderr:   "verifying...\nError: signature verification failed: no signature is associated\n",
+		ExitCode: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "image "+internalRef+" failed notation verification: "+
+		"Error: signature verification failed: no signature is associated", violations[0].Message)
+
+	violations, err = Check{Ref: internalRef, Method: MethodNotation}.Evaluate(&execx.Result{ExitCode: 2})
+	require.NoError(t, err)
+	assert.Contains(t, violations[0].Message, "exit code 2")
+}
+
+func TestGateRun(t *testing.T) {
+	executor := &scriptedExecutor{results: map[string]*execx.Result{
+		chainguardRef: {Stdout: verifyOutput},
+		internalRef:   {Stderr: "Error: no signature is associated\n", ExitCode: 1},
+	}}
+
+	result, err := newTestGate().Run(context.Background(), executor, chainguardRef, internalRef, localRef)
+	require.NoError(t, err)
+	assert.Len(t, executor.cmds, 2, "exempt images must not be verified")
+	assert.False(t, result.Passed)
+	require.Len(t, result.Violations, 1)
+	assert.Equal(t, RuleSigned, result.Violations[0].Rule)
+	assert.Error(t, result.Err())
+
+	result, err = newTestGate().Run(context.Background(), executor, chainguardRef, localRef)
+	require.NoError(t, err)
+	assert.True(t, result.Passed)
+	assert.NoError(t, result.Err())
+}
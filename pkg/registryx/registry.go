@@ -0,0 +1,272 @@
+// Package registryx manages the lifecycle of a local OCI registry used by integration tests of
+// the builders publishing or copying images: defining it, starting it through an execx.Executor
+// (docker run) or as a servicex.ServiceSpec, waiting until it is ready, seeding it with images
+// and tearing it down.
+//
+// Example usage:
+//
+//	reg, err := registryx.NewLocalRegistry(registryx.Opts{})
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	ex := execx.NewLocalExecutor()
+//	if err := reg.Start(ctx, ex); err != nil {
+//	    // handle error
+//	}
+//	defer reg.Stop(context.Background(), ex)
+//
+//	if err := reg.Seed(ctx, ex, "cgr.dev/chainguard/static:latest"); err != nil {
+//	    // handle error
+//	}
+//
+//	ref := reg.Reference("app:test") // e.g. 127.0.0.1:49213/app:test
+package registryx
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/cranex"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/execx"
+	"github.com/Excoriate/daggerx/pkg/servicex"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the local registry in errorsx errors.
+const builderName = "registry"
+
+const (
+	// DefaultHost is the host interface the registry port is published on.
+	DefaultHost = "127.0.0.1"
+	// DefaultReadyTimeout is how long WaitReady waits when no timeout is given.
+	DefaultReadyTimeout = 30 * time.Second
+	// containerPort is the port the registry listens on inside its container.
+	containerPort = servicex.RegistryDefaultPort
+)
+
+// readyPollInterval is the interval between readiness checks.
+var readyPollInterval = 200 * time.Millisecond
+
+// Opts holds the options of a local registry.
+type Opts struct {
+	// Name is the container name. Defaults to "daggerx-registry-<port>".
+	Name string
+	// Image is the registry image. Defaults to servicex.RegistryImage.
+	Image string
+	// Port is the host port. Zero selects a free port.
+	Port int
+	// ReadyTimeout is how long Start waits for the registry. Defaults to DefaultReadyTimeout.
+	ReadyTimeout time.Duration
+}
+
+// LocalRegistry is a local OCI registry, published on DefaultHost.
+type LocalRegistry struct {
+	// name is the container name.
+	name string
+
+	// image is the registry image.
+	image string
+
+	// port is the host port.
+	port int
+
+	// readyTimeout is how long Start waits for the registry.
+	readyTimeout time.Duration
+}
+
+// FreePort returns a TCP port free on DefaultHost. The port may be taken by another process
+// before it is used, which is unlikely in tests.
+func FreePort() (int, error) {
+	l, err := net.Listen("tcp", net.JoinHostPort(DefaultHost, "0"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to find a free port: %w", err)
+	}
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// NewLocalRegistry defines a local registry, selecting a free port unless one is given.
+//
+// Returns:
+//   - The local registry.
+//   - An error if the port is out of range or no free port is found.
+func NewLocalRegistry(opts Opts) (*LocalRegistry, error) {
+	port := opts.Port
+	if port == 0 {
+		p, err := FreePort()
+		if err != nil {
+			return nil, err
+		}
+		port = p
+	}
+
+	if port < 1 || port > 65535 {
+		return nil, errorsx.InvalidValue(builderName, "port", port, "invalid registry port: %d", port)
+	}
+
+	r := &LocalRegistry{
+		name:         opts.Name,
+		image:        opts.Image,
+		port:         port,
+		readyTimeout: opts.ReadyTimeout,
+	}
+
+	if r.name == "" {
+		r.name = "daggerx-registry-" + strconv.Itoa(port)
+	}
+
+	if r.image == "" {
+		r.image = servicex.RegistryImage
+	}
+
+	if r.readyTimeout <= 0 {
+		r.readyTimeout = DefaultReadyTimeout
+	}
+
+	return r, nil
+}
+
+// Name returns the container name.
+func (r *LocalRegistry) Name() string {
+	return r.name
+}
+
+// Address returns the host:port address of the registry.
+func (r *LocalRegistry) Address() string {
+	return net.JoinHostPort(DefaultHost, strconv.Itoa(r.port))
+}
+
+// Reference returns the reference of 'repo' (e.g. "app:test") in the registry.
+func (r *LocalRegistry) Reference(repo string) string {
+	return r.Address() + "/" + strings.TrimPrefix(repo, "/")
+}
+
+// ReadinessURL returns the URL of the registry API root, which answers once the registry is ready.
+func (r *LocalRegistry) ReadinessURL() string {
+	return "http://" + r.Address() + "/v2/"
+}
+
+// StartCommand generates the docker run command starting the registry in the background.
+func (r *LocalRegistry) StartCommand() types.DaggerCMD {
+	return types.DaggerCMD{
+		"docker", "run", "--detach", "--rm",
+		"--name", r.name,
+		"--publish", fmt.Sprintf("%s:%d:%d", DefaultHost, r.port, containerPort),
+		r.image,
+	}
+}
+
+// StopCommand generates the docker rm command stopping and removing the registry.
+func (r *LocalRegistry) StopCommand() types.DaggerCMD {
+	return types.DaggerCMD{"docker", "rm", "--force", r.name}
+}
+
+// SeedCommand generates the crane copy command copying the image 'src' into the registry,
+// under the same repository and tag (e.g. "cgr.dev/chainguard/static:latest" is copied to
+// "<address>/chainguard/static:latest").
+func (r *LocalRegistry) SeedCommand(src string) types.DaggerCMD {
+	return cranex.CopyCommand(src, r.Reference(repositoryPath(src)))
+}
+
+// ServiceSpec returns the specification of the registry as a Dagger service, for tests running
+// their consumers in Dagger containers instead of on the host.
+func (r *LocalRegistry) ServiceSpec() servicex.ServiceSpec {
+	return servicex.NewRegistryService(servicex.RegistryOpts{Image: r.image})
+}
+
+// Start starts the registry with 'ex' and waits until it is ready.
+//
+// Returns:
+//   - An error if the registry cannot be started or is not ready within the ready timeout.
+func (r *LocalRegistry) Start(ctx context.Context, ex execx.Executor) error {
+	if err := run(ctx, ex, r.StartCommand()); err != nil {
+		return fmt.Errorf("failed to start registry %s: %w", r.name, err)
+	}
+
+	return r.WaitReady(ctx, r.readyTimeout)
+}
+
+// Seed copies the images 'images' into the registry with 'ex'. See SeedCommand.
+func (r *LocalRegistry) Seed(ctx context.Context, ex execx.Executor, images ...string) error {
+	for _, img := range images {
+		if err := run(ctx, ex, r.SeedCommand(img)); err != nil {
+			return fmt.Errorf("failed to seed registry %s with %s: %w", r.name, img, err)
+		}
+	}
+
+	return nil
+}
+
+// Stop stops and removes the registry with 'ex'.
+func (r *LocalRegistry) Stop(ctx context.Context, ex execx.Executor) error {
+	if err := run(ctx, ex, r.StopCommand()); err != nil {
+		return fmt.Errorf("failed to stop registry %s: %w", r.name, err)
+	}
+
+	return nil
+}
+
+// WaitReady polls ReadinessURL until the registry answers, the timeout elapses or the context is
+// cancelled. A non-positive timeout defaults to DefaultReadyTimeout.
+func (r *LocalRegistry) WaitReady(ctx context.Context, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = DefaultReadyTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := &http.Client{Timeout: time.Second}
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.ReadinessURL(), nil)
+		if err != nil {
+			return fmt.Errorf("failed to create readiness request: %w", err)
+		}
+
+		if resp, err := client.Do(req); err == nil {
+			resp.Body.Close()
+			// The API root answers 401 on registries requiring authentication, which are ready too.
+			if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusUnauthorized {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("registry %s is not ready after %s: %w", r.name, timeout, ctx.Err())
+		case <-time.After(readyPollInterval):
+		}
+	}
+}
+
+// run runs 'cmd' with 'ex' and turns non-zero exit codes into errors.
+func run(ctx context.Context, ex execx.Executor, cmd types.DaggerCMD) error {
+	res, err := ex.Run(ctx, cmd, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	if !res.Succeeded() {
+		return fmt.Errorf("%s exited with code %d: %s", cmd[0], res.ExitCode, strings.TrimSpace(res.Stderr))
+	}
+
+	return nil
+}
+
+// repositoryPath returns the repository path and tag or digest of 'ref', without its registry host.
+func repositoryPath(ref string) string {
+	host, rest, ok := strings.Cut(ref, "/")
+	if ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		return rest
+	}
+
+	return ref
+}
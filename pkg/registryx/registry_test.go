@@ -0,0 +1,166 @@
+package registryx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/execx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExecutor records the commands it runs. Commands whose subcommand is 'failOn' exit with
+// code 1.
+type fakeExecutor struct {
+	cmds   []types.DaggerCMD
+	failOn string
+}
+
+func (e *fakeExecutor) Run(_ context.Context, cmd types.DaggerCMD, _ []types.DaggerEnvVars, _ []types.Mount) (*execx.Result, error) {
+	e.cmds = append(e.cmds, cmd)
+	if e.failOn != "" && cmd[1] == e.failOn {
+		return &execx.Result{ExitCode: 1, Stderr: "boom\n"}, nil
+	}
+
+	return &execx.Result{}, nil
+}
+
+// newTestRegistry returns a LocalRegistry whose port is the port of 'srv'.
+func newTestRegistry(t *testing.T, srv *httptest.Server) *LocalRegistry {
+	t.Helper()
+
+	_, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	require.NoError(t, err)
+
+	p, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	r, err := NewLocalRegistry(Opts{Name: "reg", Port: p, ReadyTimeout: 2 * time.Second})
+	require.NoError(t, err)
+
+	return r
+}
+
+func TestNewLocalRegistry(t *testing.T) {
+	r, err := NewLocalRegistry(Opts{Port: 5001})
+	require.NoError(t, err)
+
+	assert.Equal(t, "daggerx-registry-5001", r.Name())
+	assert.Equal(t, "127.0.0.1:5001", r.Address())
+	assert.Equal(t, "127.0.0.1:5001/app:test", r.Reference("app:test"))
+	assert.Equal(t, "http://127.0.0.1:5001/v2/", r.ReadinessURL())
+	assert.Equal(t, types.DaggerCMD{
+		"docker", "run", "--detach", "--rm", "--name", "daggerx-registry-5001",
+		"--publish", "127.0.0.1:5001:5000", "registry:2",
+	}, r.StartCommand())
+	assert.Equal(t, types.DaggerCMD{"docker", "rm", "--force", "daggerx-registry-5001"}, r.StopCommand())
+	assert.Equal(t, "registry:2", r.ServiceSpec().Image)
+
+	_, err = NewLocalRegistry(Opts{Port: 70000})
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+}
+
+func TestNewLocalRegistryFreePort(t *testing.T) {
+	r, err := NewLocalRegistry(Opts{})
+	require.NoError(t, err)
+	assert.NotEqual(t, "127.0.0.1:0", r.Address())
+
+	l, err := net.Listen("tcp", r.Address())
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+}
+
+func TestSeedCommand(t *testing.T) {
+	r, err := NewLocalRegistry(Opts{Port: 5001})
+	require.NoError(t, err)
+
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"cgr.dev/chainguard/static:latest", "127.0.0.1:5001/chainguard/static:latest"},
+		{"localhost:5000/app:v1", "127.0.0.1:5001/app:v1"},
+		{"library/alpine:3.20", "127.0.0.1:5001/library/alpine:3.20"},
+		{"alpine@sha256:abc", "127.0.0.1:5001/alpine@sha256:abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			assert.Equal(t, types.DaggerCMD{"crane", "copy", tt.src, tt.want}, r.SeedCommand(tt.src))
+		})
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/v2/", req.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	r := newTestRegistry(t, srv)
+	ex := &fakeExecutor{}
+
+	require.NoError(t, r.Start(context.Background(), ex))
+	require.NoError(t, r.Seed(context.Background(), ex, "alpine:3.20"))
+	require.NoError(t, r.Stop(context.Background(), ex))
+
+	require.Len(t, ex.cmds, 3)
+	assert.Equal(t, r.StartCommand(), ex.cmds[0])
+	assert.Equal(t, r.SeedCommand("alpine:3.20"), ex.cmds[1])
+	assert.Equal(t, r.StopCommand(), ex.cmds[2])
+}
+
+func TestLifecycleFailures(t *testing.T) {
+	r, err := NewLocalRegistry(Opts{Name: "reg", Port: 5001})
+	require.NoError(t, err)
+
+	err = r.Start(context.Background(), &fakeExecutor{failOn: "run"})
+	assert.ErrorContains(t, err, "failed to start registry reg: docker exited with code 1: boom")
+
+	err = r.Seed(context.Background(), &fakeExecutor{failOn: "copy"}, "alpine:3.20")
+	assert.ErrorContains(t, err, "failed to seed registry reg with alpine:3.20")
+
+	err = r.Stop(context.Background(), &fakeExecutor{failOn: "rm"})
+	assert.ErrorContains(t, err, "failed to stop registry reg")
+}
+
+func TestWaitReady(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	readyPollInterval = 10 * time.Millisecond
+	defer func() { readyPollInterval = 200 * time.Millisecond }()
+
+	r := newTestRegistry(t, srv)
+	require.NoError(t, r.WaitReady(context.Background(), time.Second))
+	assert.Equal(t, 3, calls)
+}
+
+func TestWaitReadyTimeout(t *testing.T) {
+	port, err := FreePort()
+	require.NoError(t, err)
+
+	r, err := NewLocalRegistry(Opts{Name: "reg", Port: port})
+	require.NoError(t, err)
+
+	err = r.WaitReady(context.Background(), 100*time.Millisecond)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "got %v", err)
+	assert.ErrorContains(t, err, "registry reg is not ready after 100ms")
+}
@@ -0,0 +1,275 @@
+// Package reportx aggregates the outputs of a build (builder configurations, commands, durations,
+// artifact digests, SBOM summaries, scan results and policy outcomes) into a single report that
+// can be rendered as JSON or Markdown, so a Dagger function can return one structured output.
+//
+// Example usage:
+//
+//	r := reportx.NewReport("base image").
+//	    AddStep(reportx.Step{Name: "build", Command: cmd, Duration: elapsed}).
+//	    AddArtifacts(collector.Artifacts()...).
+//	    AddScan("grype", "registry.example.com/base:v1", findings)
+//
+//	md := r.Markdown()
+//	data, err := r.JSON()
+package reportx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+)
+
+// StepStatus is the outcome of a build step.
+type StepStatus string
+
+const (
+	// StepSucceeded marks a step that succeeded.
+	StepSucceeded StepStatus = "succeeded"
+	// StepFailed marks a step that failed.
+	StepFailed StepStatus = "failed"
+	// StepSkipped marks a step that did not run.
+	StepSkipped StepStatus = "skipped"
+)
+
+// Step describes a build step.
+type Step struct {
+	// Name is the name of the step.
+	Name string `json:"name"`
+	// Status is the outcome of the step. Defaults to StepSucceeded.
+	Status StepStatus `json:"status"`
+	// Command is the command run by the step.
+	Command []string `json:"command,omitempty"`
+	// Config holds the builder configuration of the step.
+	Config map[string]any `json:"config,omitempty"`
+	// Duration is the duration of the step.
+	Duration time.Duration `json:"-"`
+	// Error holds the error message of a failed step.
+	Error string `json:"error,omitempty"`
+}
+
+// MarshalJSON encodes the step with its duration in milliseconds.
+func (s Step) MarshalJSON() ([]byte, error) {
+	type alias Step
+	return json.Marshal(struct {
+		alias
+		DurationMs int64 `json:"durationMs"`
+	}{alias(s), s.Duration.Milliseconds()})
+}
+
+// SBOMSummary summarizes a software bill of materials.
+type SBOMSummary struct {
+	// Path is the path of the SBOM document.
+	Path string `json:"path"`
+	// Format is the format of the SBOM (e.g. "spdx-json").
+	Format string `json:"format"`
+	// Packages is the number of packages listed in the SBOM.
+	Packages int `json:"packages"`
+}
+
+// ScanResult summarizes the findings of a vulnerability scan.
+type ScanResult struct {
+	// Scanner is the name of the scanner (e.g. "grype").
+	Scanner string `json:"scanner"`
+	// Target is the scanned image or directory.
+	Target string `json:"target"`
+	// Counts holds the number of findings per severity.
+	Counts map[string]int `json:"counts"`
+	// Findings holds the individual findings.
+	Findings []policyx.Finding `json:"findings,omitempty"`
+}
+
+// Report aggregates the outputs of a build.
+type Report struct {
+	// Title is the title of the report.
+	Title string `json:"title"`
+	// GeneratedAt is the generation time of the report.
+	GeneratedAt time.Time `json:"generatedAt"`
+	// Steps holds the build steps, in execution order.
+	Steps []Step `json:"steps,omitempty"`
+	// Artifacts holds the produced artifacts.
+	Artifacts []artifactx.Artifact `json:"artifacts,omitempty"`
+	// SBOMs holds the SBOM summaries.
+	SBOMs []SBOMSummary `json:"sboms,omitempty"`
+	// Scans holds the scan results.
+	Scans []ScanResult `json:"scans,omitempty"`
+	// Policy holds the outcome of the policy evaluation, if any.
+	Policy *policyx.Result `json:"policy,omitempty"`
+}
+
+// NewReport creates an empty report with the given title, generated now.
+func NewReport(title string) *Report {
+	return &Report{
+		Title:       title,
+		GeneratedAt: time.Now().UTC(),
+	}
+}
+
+// AddStep appends a build step, defaulting its status to StepSucceeded.
+func (r *Report) AddStep(step Step) *Report {
+	if step.Status == "" {
+		step.Status = StepSucceeded
+	}
+
+	r.Steps = append(r.Steps, step)
+	return r
+}
+
+// AddArtifacts appends produced artifacts.
+func (r *Report) AddArtifacts(artifacts ...artifactx.Artifact) *Report {
+	r.Artifacts = append(r.Artifacts, artifacts...)
+	return r
+}
+
+// AddSBOM appends an SBOM summary.
+func (r *Report) AddSBOM(summary SBOMSummary) *Report {
+	r.SBOMs = append(r.SBOMs, summary)
+	return r
+}
+
+// AddScan appends the findings of a scan of 'target' by 'scanner', counted per severity.
+func (r *Report) AddScan(scanner, target string, findings []policyx.Finding) *Report {
+	counts := map[string]int{}
+	for _, f := range findings {
+		counts[f.Severity.String()]++
+	}
+
+	r.Scans = append(r.Scans, ScanResult{
+		Scanner:  scanner,
+		Target:   target,
+		Counts:   counts,
+		Findings: findings,
+	})
+
+	return r
+}
+
+// WithPolicyResult records the outcome of a policy evaluation.
+func (r *Report) WithPolicyResult(result policyx.Result) *Report {
+	r.Policy = &result
+	return r
+}
+
+// Succeeded reports whether every step succeeded or was skipped and the policy, if any, passed.
+func (r *Report) Succeeded() bool {
+	for _, s := range r.Steps {
+		if s.Status == StepFailed {
+			return false
+		}
+	}
+
+	return r.Policy == nil || r.Policy.Passed
+}
+
+// TotalDuration returns the sum of the step durations.
+func (r *Report) TotalDuration() time.Duration {
+	var total time.Duration
+	for _, s := range r.Steps {
+		total += s.Duration
+	}
+
+	return total
+}
+
+// JSON returns the indented JSON encoding of the report, with the secrets registered with the
+// default logx redactor scrubbed.
+func (r *Report) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	return logx.Default().Bytes(data), nil
+}
+
+// severityColumns lists the severities rendered in the Markdown scan table, most severe first.
+var severityColumns = []policyx.Severity{
+	policyx.SeverityCritical,
+	policyx.SeverityHigh,
+	policyx.SeverityMedium,
+	policyx.SeverityLow,
+	policyx.SeverityNegligible,
+	policyx.SeverityUnknown,
+}
+
+// Markdown renders the report as a Markdown document, with the secrets registered with the default
+// logx redactor scrubbed.
+func (r *Report) Markdown() string {
+	var b strings.Builder
+
+	status := "succeeded"
+	if !r.Succeeded() {
+		status = "failed"
+	}
+
+	fmt.Fprintf(&b, "# %s\n\n", r.Title)
+	fmt.Fprintf(&b, "Status: **%s** — generated at %s, total duration %s.\n", status,
+		r.GeneratedAt.Format(time.RFC3339), r.TotalDuration())
+
+	if len(r.Steps) > 0 {
+		b.WriteString("\n## Steps\n\n| Step | Status | Duration | Command |\n| --- | --- | --- | --- |\n")
+		for _, s := range r.Steps {
+			fmt.Fprintf(&b, "| %s | %s | %s | `%s` |\n", escapeCell(s.Name), s.Status, s.Duration,
+				escapeCell(strings.Join(s.Command, " ")))
+		}
+	}
+
+	if len(r.Artifacts) > 0 {
+		b.WriteString("\n## Artifacts\n\n| Path | Kind | Media type | Digest |\n| --- | --- | --- | --- |\n")
+		for _, a := range r.Artifacts {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", escapeCell(a.Path), a.Kind, a.MediaType, orDash(a.Digest))
+		}
+	}
+
+	if len(r.SBOMs) > 0 {
+		b.WriteString("\n## SBOMs\n\n| Path | Format | Packages |\n| --- | --- | --- |\n")
+		for _, s := range r.SBOMs {
+			fmt.Fprintf(&b, "| %s | %s | %d |\n", escapeCell(s.Path), s.Format, s.Packages)
+		}
+	}
+
+	if len(r.Scans) > 0 {
+		b.WriteString("\n## Scans\n\n| Scanner | Target |")
+		for _, sev := range severityColumns {
+			fmt.Fprintf(&b, " %s |", sev)
+		}
+		b.WriteString("\n| --- | --- |" + strings.Repeat(" --- |", len(severityColumns)) + "\n")
+		for _, s := range r.Scans {
+			fmt.Fprintf(&b, "| %s | %s |", s.Scanner, escapeCell(s.Target))
+			for _, sev := range severityColumns {
+				fmt.Fprintf(&b, " %d |", s.Counts[sev.String()])
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if r.Policy != nil {
+		b.WriteString("\n## Policy\n\n")
+		if r.Policy.Passed {
+			b.WriteString("All policy rules passed.\n")
+		}
+		for _, v := range r.Policy.Violations {
+			fmt.Fprintf(&b, "- **%s**: %s\n", v.Rule, v.Message)
+		}
+	}
+
+	return logx.Default().String(b.String())
+}
+
+// escapeCell escapes the pipes of a Markdown table cell.
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// orDash returns '-' for empty values.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
@@ -0,0 +1,132 @@
+package reportx
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+)
+
+func newTestReport() *Report {
+	return NewReport("base image").
+		AddStep(Step{Name: "build", Command: []string{"apko", "build"}, Duration: 1500 * time.Millisecond}).
+		AddStep(Step{Name: "scan", Status: StepSkipped}).
+		AddArtifacts(artifactx.Artifact{Kind: artifactx.KindImageTarball, Path: "/mnt/image.tar", MediaType: artifactx.MediaTypeTar, Digest: "sha256:abc"}).
+		AddSBOM(SBOMSummary{Path: "/mnt/sbom.spdx.json", Format: "spdx-json", Packages: 12}).
+		AddScan("grype", "base:v1", []policyx.Finding{
+			{ID: "CVE-1", Severity: policyx.SeverityHigh},
+			{ID: "CVE-2", Severity: policyx.SeverityHigh},
+			{ID: "CVE-3", Severity: policyx.SeverityLow},
+		})
+}
+
+func TestReportAggregation(t *testing.T) {
+	r := newTestReport()
+
+	if r.Steps[0].Status != StepSucceeded {
+		t.Errorf("Expected default status succeeded, got %s", r.Steps[0].Status)
+	}
+
+	if got := r.Scans[0].Counts["high"]; got != 2 {
+		t.Errorf("Expected 2 high findings, got %d", got)
+	}
+
+	if r.TotalDuration() != 1500*time.Millisecond {
+		t.Errorf("Unexpected total duration %s", r.TotalDuration())
+	}
+
+	if !r.Succeeded() {
+		t.Error("Expected report to succeed")
+	}
+
+	r.WithPolicyResult(policyx.Result{Passed: false, Violations: []policyx.Violation{{Rule: policyx.RuleMaxSeverity, Message: "too severe"}}})
+	if r.Succeeded() {
+		t.Error("Expected report with failed policy to fail")
+	}
+
+	r = NewReport("x").AddStep(Step{Name: "build", Status: StepFailed, Error: "boom"})
+	if r.Succeeded() {
+		t.Error("Expected report with failed step to fail")
+	}
+}
+
+func TestReportJSON(t *testing.T) {
+	data, err := newTestReport().JSON()
+	if err != nil {
+		t.Fatalf("JSON returned unexpected error: %v", err)
+	}
+
+	var decoded struct {
+		Title string `json:"title"`
+		Steps []struct {
+			Name       string `json:"name"`
+			DurationMs int64  `json:"durationMs"`
+		} `json:"steps"`
+		Scans []struct {
+			Findings []struct {
+				Severity string `json:"severity"`
+			} `json:"findings"`
+		} `json:"scans"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Generated JSON is invalid: %v", err)
+	}
+
+	if decoded.Title != "base image" || len(decoded.Steps) != 2 || decoded.Steps[0].DurationMs != 1500 {
+		t.Errorf("Unexpected decoded report %+v", decoded)
+	}
+
+	if decoded.Scans[0].Findings[0].Severity != "high" {
+		t.Errorf("Expected severities encoded by name, got %+v", decoded.Scans[0].Findings)
+	}
+}
+
+func TestReportMarkdown(t *testing.T) {
+	r := newTestReport().WithPolicyResult(policyx.Result{Passed: true})
+	md := r.Markdown()
+
+	for _, want := range []string{
+		"# base image",
+		"Status: **succeeded**",
+		"| build | succeeded | 1.5s | `apko build` |",
+		"| /mnt/image.tar | image-tarball | application/x-tar | sha256:abc |",
+		"| /mnt/sbom.spdx.json | spdx-json | 12 |",
+		"| grype | base:v1 | 0 | 2 | 0 | 1 | 0 | 0 |",
+		"All policy rules passed.",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown is missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestReportMarkdownEmptySections(t *testing.T) {
+	md := NewReport("empty").Markdown()
+
+	if strings.Contains(md, "## Steps") || strings.Contains(md, "## Policy") {
+		t.Errorf("Expected empty sections to be omitted:\n%s", md)
+	}
+}
+
+func TestReportRedactsRegisteredSecrets(t *testing.T) {
+	t.Cleanup(logx.Default().Reset)
+	logx.RegisterSecret("hunter22")
+
+	r := NewReport("publish").
+		AddStep(Step{Name: "login", Command: []string{"crane", "auth", "login", "-p", "hunter22"}})
+
+	data, err := r.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+
+	for name, out := range map[string]string{"JSON": string(data), "Markdown": r.Markdown()} {
+		if strings.Contains(out, "hunter22") {
+			t.Errorf("%s output leaks a registered secret:\n%s", name, out)
+		}
+	}
+}
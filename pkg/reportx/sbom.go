@@ -0,0 +1,33 @@
+package reportx
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SummarizeSBOM summarizes the SPDX or CycloneDX JSON document 'data' found at 'path'.
+//
+// Returns:
+//   - The summary, with the detected format and the number of packages or components.
+//   - An error if the document is neither an SPDX nor a CycloneDX JSON document.
+func SummarizeSBOM(path string, data []byte) (SBOMSummary, error) {
+	var doc struct {
+		SPDXVersion string            `json:"spdxVersion"`
+		Packages    []json.RawMessage `json:"packages"`
+		BOMFormat   string            `json:"bomFormat"`
+		Components  []json.RawMessage `json:"components"`
+	}
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return SBOMSummary{}, fmt.Errorf("invalid SBOM %s: %w", path, err)
+	}
+
+	switch {
+	case doc.SPDXVersion != "":
+		return SBOMSummary{Path: path, Format: "spdx-json", Packages: len(doc.Packages)}, nil
+	case doc.BOMFormat == "CycloneDX":
+		return SBOMSummary{Path: path, Format: "cyclonedx-json", Packages: len(doc.Components)}, nil
+	default:
+		return SBOMSummary{}, fmt.Errorf("unsupported SBOM format in %s", path)
+	}
+}
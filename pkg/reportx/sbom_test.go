@@ -0,0 +1,38 @@
+package reportx
+
+import "testing"
+
+func TestSummarizeSBOM(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    SBOMSummary
+		wantErr bool
+	}{
+		{
+			name: "SPDX",
+			data: `{"spdxVersion": "SPDX-2.3", "packages": [{"name": "a"}, {"name": "b"}]}`,
+			want: SBOMSummary{Path: "sbom.json", Format: "spdx-json", Packages: 2},
+		},
+		{
+			name: "CycloneDX",
+			data: `{"bomFormat": "CycloneDX", "components": [{"name": "a"}]}`,
+			want: SBOMSummary{Path: "sbom.json", Format: "cyclonedx-json", Packages: 1},
+		},
+		{name: "Unknown format", data: `{"foo": 1}`, wantErr: true},
+		{name: "Invalid JSON", data: `{`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SummarizeSBOM("sbom.json", []byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SummarizeSBOM() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("SummarizeSBOM() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package repositoryx
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// indexEntry is the name of the index in the APKINDEX.tar.gz archive.
+const indexEntry = "APKINDEX"
+
+// maxIndexSize is the maximum size of the uncompressed index read from an archive.
+const maxIndexSize = 512 << 20
+
+// Package is a package of an APK repository index.
+type Package struct {
+	// Name is the package name.
+	Name string `json:"name"`
+	// Version is the package version (e.g. "8.5.0-r0").
+	Version string `json:"version"`
+	// Arch is the architecture of the package.
+	Arch string `json:"arch,omitempty"`
+	// Description is the description of the package.
+	Description string `json:"description,omitempty"`
+	// License is the license of the package.
+	License string `json:"license,omitempty"`
+	// Origin is the name of the source package the package was built from.
+	Origin string `json:"origin,omitempty"`
+	// Checksum is the APK checksum of the package ("Q1" followed by a base64 encoded SHA-1).
+	Checksum string `json:"checksum,omitempty"`
+	// Size is the size of the package archive, in bytes.
+	Size int64 `json:"size,omitempty"`
+	// InstalledSize is the size of the installed package, in bytes.
+	InstalledSize int64 `json:"installedSize,omitempty"`
+	// BuildTime is the build time of the package.
+	BuildTime time.Time `json:"buildTime,omitempty"`
+	// Depends are the dependencies of the package.
+	Depends []string `json:"depends,omitempty"`
+	// Provides are the names the package provides (e.g. "so:libcurl.so.4=4").
+	Provides []string `json:"provides,omitempty"`
+}
+
+// Index is a parsed APK repository index.
+type Index struct {
+	// Packages are the packages of the index, in index order.
+	Packages []Package `json:"packages"`
+}
+
+// ParseIndex parses an APKINDEX.tar.gz archive. The signature of the archive is not verified:
+// apko verifies the packages it installs against its keyring.
+//
+// Returns:
+//   - The parsed index.
+//   - An error if the archive is invalid or holds no index.
+func ParseIndex(data []byte) (*Index, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errorsx.InvalidValue(builderName, "index", nil, "invalid APKINDEX archive: %w", err)
+	}
+	defer gz.Close()
+
+	// The archive is the concatenation of the signature and index gzip streams, read as one tar.
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, errorsx.MissingField(builderName, "index", "APKINDEX archive holds no %s entry", indexEntry)
+		}
+		if err != nil {
+			return nil, errorsx.InvalidValue(builderName, "index", nil, "invalid APKINDEX archive: %w", err)
+		}
+
+		if hdr.Name == indexEntry {
+			return ParseIndexText(io.LimitReader(tr, maxIndexSize))
+		}
+	}
+}
+
+// ParseIndexText parses the uncompressed APKINDEX content: records of "K:value" lines separated
+// by blank lines. Unknown keys are ignored.
+//
+// Returns:
+//   - The parsed index.
+//   - An error if a line is malformed, or a record has no name or version.
+func ParseIndexText(r io.Reader) (*Index, error) {
+	index := &Index{}
+
+	var (
+		current Package
+		line    int
+		empty   = true
+	)
+
+	flush := func() error {
+		if empty {
+			return nil
+		}
+		if current.Name == "" || current.Version == "" {
+			return errorsx.MissingField(builderName, "index",
+				"APKINDEX record ending at line %d has no name or version", line)
+		}
+		index.Packages = append(index.Packages, current)
+		current, empty = Package{}, true
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if text == "" {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, ":")
+		if !ok || len(key) != 1 {
+			return nil, errorsx.InvalidValue(builderName, "index", text, "malformed APKINDEX line %d: %q", line, text)
+		}
+		empty = false
+
+		if err := current.set(key[0], value); err != nil {
+			return nil, errorsx.InvalidValue(builderName, "index", text, "malformed APKINDEX line %d: %w", line, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read APKINDEX: %w", err)
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
+
+// set sets the field of the package named by the APKINDEX key 'key'.
+func (p *Package) set(key byte, value string) error {
+	var err error
+
+	switch key {
+	case 'P':
+		p.Name = value
+	case 'V':
+		p.Version = value
+	case 'A':
+		p.Arch = value
+	case 'T':
+		p.Description = value
+	case 'L':
+		p.License = value
+	case 'o':
+		p.Origin = value
+	case 'C':
+		p.Checksum = value
+	case 'S':
+		p.Size, err = strconv.ParseInt(value, 10, 64)
+	case 'I':
+		p.InstalledSize, err = strconv.ParseInt(value, 10, 64)
+	case 't':
+		var sec int64
+		if sec, err = strconv.ParseInt(value, 10, 64); err == nil {
+			p.BuildTime = time.Unix(sec, 0).UTC()
+		}
+	case 'D':
+		p.Depends = strings.Fields(value)
+	case 'p':
+		p.Provides = strings.Fields(value)
+	}
+
+	return err
+}
+
+// Versions returns the packages named 'name', sorted by ascending version.
+func (i *Index) Versions(name string) []Package {
+	var packages []Package
+	for _, p := range i.Packages {
+		if p.Name == name {
+			packages = append(packages, p)
+		}
+	}
+
+	slices.SortStableFunc(packages, func(a, b Package) int {
+		return CompareVersions(a.Version, b.Version)
+	})
+
+	return packages
+}
+
+// Latest returns the latest version of the package 'name', and whether the index has it.
+func (i *Index) Latest(name string) (Package, bool) {
+	versions := i.Versions(name)
+	if len(versions) == 0 {
+		return Package{}, false
+	}
+
+	return versions[len(versions)-1], true
+}
+
+// Resolve returns the latest version of the package satisfying the constraint 'c' (see
+// apkox.ParsePackageConstraint), and whether the index has one.
+func (i *Index) Resolve(c apkox.PackageConstraint) (Package, bool) {
+	versions := i.Versions(c.Name)
+	for j := len(versions) - 1; j >= 0; j-- {
+		if Satisfies(versions[j].Version, c) {
+			return versions[j], true
+		}
+	}
+
+	return Package{}, false
+}
+
+// Has reports whether the index has the version 'version' of the package 'name'.
+func (i *Index) Has(name, version string) bool {
+	return slices.ContainsFunc(i.Packages, func(p Package) bool {
+		return p.Name == name && p.Version == version
+	})
+}
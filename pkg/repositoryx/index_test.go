@@ -0,0 +1,138 @@
+package repositoryx
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testIndex = `C:Q1qqsJ9iUMRQpp17wLvPx5ExHxXHI=
+P:curl
+V:8.5.0-r0
+A:x86_64
+S:1024
+I:4096
+T:URL retrival utility and library
+L:MIT
+o:curl
+t:1700000000
+D:so:libc.so.6 so:libcurl.so.4
+p:cmd:curl=8.5.0-r0
+
+P:curl
+V:8.10.1-r0
+A:x86_64
+
+P:curl
+V:8.9.0-r2
+A:x86_64
+
+P:jq
+V:1.7.1-r0
+A:x86_64
+`
+
+// testArchive returns an APKINDEX.tar.gz archive: a signature stream followed by the index
+// stream, as repositories serve them.
+func testArchive(t *testing.T, index string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	for _, entry := range []struct {
+		name, content string
+		end           bool
+	}{
+		{name: ".SIGN.RSA.wolfi-signing.rsa.pub", content: "signature"},
+		{name: "DESCRIPTION", content: "wolfi"},
+		{name: "APKINDEX", content: index, end: true},
+	} {
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: entry.name, Mode: 0o644, Size: int64(len(entry.content))}))
+		_, err := tw.Write([]byte(entry.content))
+		require.NoError(t, err)
+		if entry.end {
+			require.NoError(t, tw.Close())
+		} else {
+			require.NoError(t, tw.Flush())
+		}
+		require.NoError(t, gz.Close())
+	}
+
+	return buf.Bytes()
+}
+
+func TestParseIndex(t *testing.T) {
+	index, err := ParseIndex(testArchive(t, testIndex))
+	require.NoError(t, err)
+	require.Len(t, index.Packages, 4)
+
+	curl := index.Packages[0]
+	assert.Equal(t, "curl", curl.Name)
+	assert.Equal(t, int64(4096), curl.InstalledSize)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), curl.BuildTime)
+	assert.Equal(t, []string{"so:libc.so.6", "so:libcurl.so.4"}, curl.Depends)
+	assert.Equal(t, []string{"cmd:curl=8.5.0-r0"}, curl.Provides)
+
+	latest, ok := index.Latest("curl")
+	require.True(t, ok)
+	assert.Equal(t, "8.10.1-r0", latest.Version)
+
+	var versions []string
+	for _, p := range index.Versions("curl") {
+		versions = append(versions, p.Version)
+	}
+	assert.Equal(t, []string{"8.5.0-r0", "8.9.0-r2", "8.10.1-r0"}, versions)
+
+	assert.True(t, index.Has("jq", "1.7.1-r0"))
+	assert.False(t, index.Has("jq", "1.7.0-r0"))
+
+	_, ok = index.Latest("wget")
+	assert.False(t, ok)
+
+	for constraint, want := range map[string]string{
+		"curl":          "8.10.1-r0",
+		"curl<8.10":     "8.9.0-r2",
+		"curl~8.9":      "8.9.0-r2",
+		"curl=8.5.0-r0": "8.5.0-r0",
+	} {
+		c, err := apkox.ParsePackageConstraint(constraint)
+		require.NoError(t, err)
+
+		p, ok := index.Resolve(c)
+		require.True(t, ok, constraint)
+		assert.Equal(t, want, p.Version, constraint)
+	}
+
+	_, ok = index.Resolve(apkox.PackageConstraint{Name: "curl", Op: apkox.OpGreater, Version: "9"})
+	assert.False(t, ok)
+}
+
+func TestParseIndexErrors(t *testing.T) {
+	_, err := ParseIndex([]byte("not gzip"))
+	assert.ErrorIs(t, err, errorsx.ErrInvalidValue)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	require.NoError(t, tar.NewWriter(gz).Close())
+	require.NoError(t, gz.Close())
+	_, err = ParseIndex(buf.Bytes())
+	assert.ErrorIs(t, err, errorsx.ErrMissingField)
+
+	_, err = ParseIndexText(strings.NewReader("P:curl\n\n"))
+	assert.ErrorIs(t, err, errorsx.ErrMissingField)
+
+	_, err = ParseIndexText(strings.NewReader("curl\n"))
+	assert.ErrorIs(t, err, errorsx.ErrInvalidValue)
+
+	_, err = ParseIndexText(strings.NewReader("P:curl\nV:1.0-r0\nS:big\n"))
+	assert.ErrorIs(t, err, errorsx.ErrInvalidValue)
+}
@@ -0,0 +1,172 @@
+// Package repositoryx reads the indexes of APK repositories, such as the Wolfi and Alpine ones,
+// to answer "what is the latest version of package X" and "does this pin exist" before a build
+// runs, and to propose dependency bumps of the packages pinned in apko configurations.
+//
+// Indexes are fetched from "<repository>/<arch>/APKINDEX.tar.gz" with the httpfetchx fetcher, and
+// optionally cached in a cachex.Cache. Versions are ordered as apk orders them (see
+// CompareVersions), and constraints are those parsed by apkox.ParsePackageConstraint.
+//
+// Example usage:
+//
+//	client := repositoryx.NewClient().WithCache(cachex.NewCache(time.Hour))
+//
+//	pkg, err := client.Latest(ctx, repositoryx.WolfiRepository, apkox.ArchX8664, "curl")
+//	if err != nil {
+//	    // handle error
+//	}
+//	fmt.Println(pkg.Version) // e.g. 8.11.1-r0
+//
+//	if err := client.CheckPins(ctx, repositoryx.WolfiRepository, apkox.ArchX8664, "curl=8.5.0-r0"); err != nil {
+//	    // the pin doesn't exist in the repository
+//	}
+package repositoryx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/cachex"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/httpfetchx"
+)
+
+// builderName identifies the repository client in errorsx errors.
+const builderName = "repository"
+
+const (
+	// WolfiRepository is the Wolfi OS repository.
+	WolfiRepository = "https://packages.wolfi.dev/os"
+	// AlpineRepository is the main repository of Alpine edge.
+	AlpineRepository = "https://dl-cdn.alpinelinux.org/alpine/edge/main"
+	// IndexFile is the name of the index archive of a repository architecture.
+	IndexFile = "APKINDEX.tar.gz"
+)
+
+// Client fetches and queries the indexes of APK repositories.
+type Client struct {
+	// fetcher downloads the indexes. A nil fetcher is httpfetchx.Default.
+	fetcher *httpfetchx.Fetcher
+
+	// cache caches the index archives. A nil cache disables caching.
+	cache *cachex.Cache
+}
+
+// NewClient creates a client using the default fetcher, without cache.
+func NewClient() *Client {
+	return &Client{}
+}
+
+// WithFetcher sets the fetcher downloading the indexes.
+// It returns the updated Client instance.
+func (c *Client) WithFetcher(f *httpfetchx.Fetcher) *Client {
+	c.fetcher = f
+	return c
+}
+
+// WithCache caches the index archives in 'cache', under the "apkindex:" prefix.
+// It returns the updated Client instance.
+func (c *Client) WithCache(cache *cachex.Cache) *Client {
+	c.cache = cache
+	return c
+}
+
+// IndexURL returns the URL of the index of the architecture 'arch' of 'repository'.
+func IndexURL(repository string, arch apkox.Architecture) string {
+	return strings.TrimRight(repository, "/") + "/" + string(arch) + "/" + IndexFile
+}
+
+// Index fetches and parses the index of the architecture 'arch' of 'repository'.
+//
+// Returns:
+//   - The parsed index.
+//   - An error if the repository or architecture is missing, or the index cannot be fetched or
+//     parsed.
+func (c *Client) Index(ctx context.Context, repository string, arch apkox.Architecture) (*Index, error) {
+	if repository == "" {
+		return nil, errorsx.MissingField(builderName, "repository", "repository is required")
+	}
+
+	if arch == "" {
+		return nil, errorsx.MissingField(builderName, "arch", "architecture is required")
+	}
+
+	indexURL := IndexURL(repository, arch)
+
+	fetcher := c.fetcher
+	if fetcher == nil {
+		fetcher = httpfetchx.Default()
+	}
+
+	data, err := c.cache.GetOrFetch(ctx, "apkindex:"+indexURL, func(ctx context.Context) ([]byte, error) {
+		return fetcher.Get(ctx, indexURL)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", indexURL, err)
+	}
+
+	index, err := ParseIndex(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", indexURL, err)
+	}
+
+	return index, nil
+}
+
+// Latest returns the latest version of the package 'name' in the index of 'arch' of
+// 'repository'.
+//
+// Returns:
+//   - The package.
+//   - An error if the index cannot be fetched, or doesn't have the package.
+func (c *Client) Latest(ctx context.Context, repository string, arch apkox.Architecture, name string) (Package, error) {
+	index, err := c.Index(ctx, repository, arch)
+	if err != nil {
+		return Package{}, err
+	}
+
+	p, ok := index.Latest(name)
+	if !ok {
+		return Package{}, errorsx.InvalidValue(builderName, "package", name,
+			"package %s not found in %s for %s", name, repository, arch)
+	}
+
+	return p, nil
+}
+
+// CheckPins checks that the index of 'arch' of 'repository' has a version satisfying each of
+// the package constraints 'packages' (e.g. "curl=8.5.0-r0", "jq>=1.7").
+//
+// Returns:
+//   - An error if a constraint is malformed, the index cannot be fetched, or no version of a
+//     package satisfies its constraint. The error names every unsatisfied constraint.
+func (c *Client) CheckPins(ctx context.Context, repository string, arch apkox.Architecture, packages ...string) error {
+	constraints := make([]apkox.PackageConstraint, 0, len(packages))
+	for _, p := range packages {
+		constraint, err := apkox.ParsePackageConstraint(p)
+		if err != nil {
+			return err
+		}
+		constraints = append(constraints, constraint)
+	}
+
+	index, err := c.Index(ctx, repository, arch)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, constraint := range constraints {
+		if _, ok := index.Resolve(constraint); !ok {
+			missing = append(missing, constraint.String())
+		}
+	}
+
+	if len(missing) > 0 {
+		return errorsx.InvalidValue(builderName, "package", missing,
+			"no package satisfies %s in %s for %s", strings.Join(missing, ", "), repository, arch)
+	}
+
+	return nil
+}
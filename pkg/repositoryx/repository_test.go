@@ -0,0 +1,67 @@
+package repositoryx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/Excoriate/daggerx/pkg/cachex"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/httpfetchx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRepositoryServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	archive := testArchive(t, testIndex)
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/os/x86_64/APKINDEX.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(archive)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &requests
+}
+
+func TestClient(t *testing.T) {
+	srv, requests := newRepositoryServer(t)
+	repository := srv.URL + "/os/"
+	ctx := context.Background()
+
+	client := NewClient().WithFetcher(httpfetchx.NewFetcher()).WithCache(cachex.NewCache(time.Hour))
+
+	p, err := client.Latest(ctx, repository, apkox.ArchX8664, "curl")
+	require.NoError(t, err)
+	assert.Equal(t, "8.10.1-r0", p.Version)
+
+	require.NoError(t, client.CheckPins(ctx, repository, apkox.ArchX8664, "curl=8.5.0-r0", "jq>=1.7"))
+	assert.Equal(t, int32(1), requests.Load())
+
+	err = client.CheckPins(ctx, repository, apkox.ArchX8664, "curl=8.4.0-r0", "jq", "wget")
+	assert.ErrorIs(t, err, errorsx.ErrInvalidValue)
+	assert.ErrorContains(t, err, "curl=8.4.0-r0, wget")
+
+	err = client.CheckPins(ctx, repository, apkox.ArchX8664, "curl=latest")
+	assert.ErrorIs(t, err, errorsx.ErrInvalidValue)
+
+	_, err = client.Latest(ctx, repository, apkox.ArchX8664, "wget")
+	assert.ErrorIs(t, err, errorsx.ErrInvalidValue)
+
+	_, err = client.Index(ctx, repository, "")
+	assert.ErrorIs(t, err, errorsx.ErrMissingField)
+}
+
+func TestIndexURL(t *testing.T) {
+	assert.Equal(t, "https://packages.wolfi.dev/os/aarch64/APKINDEX.tar.gz", IndexURL(WolfiRepository+"/", apkox.ArchAarch64))
+}
@@ -0,0 +1,169 @@
+package repositoryx
+
+import (
+	"cmp"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+)
+
+// versionRegex matches APK versions: dot-separated numbers, an optional letter, suffixes, and an
+// optional release (e.g. "1.2.3b_rc1_p2-r4").
+var versionRegex = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)*)([a-z]?)((?:_(?:alpha|beta|pre|rc|cvs|svn|git|hg|p)[0-9]*)*)(?:-r([0-9]+))?$`)
+
+// suffixRanks orders the version suffixes: pre-release suffixes sort before a version without a
+// suffix (rank 0), and post-release suffixes after it.
+var suffixRanks = map[string]int{
+	"alpha": -4,
+	"beta":  -3,
+	"pre":   -2,
+	"rc":    -1,
+	"cvs":   1,
+	"svn":   2,
+	"git":   3,
+	"hg":    4,
+	"p":     5,
+}
+
+// suffix is a version suffix, with its rank and number.
+type suffix struct {
+	rank   int
+	number uint64
+}
+
+// apkVersion is a parsed APK version.
+type apkVersion struct {
+	numbers  []uint64
+	letter   byte
+	suffixes []suffix
+	release  uint64
+}
+
+// parseVersion parses an APK version, and reports whether it is valid.
+func parseVersion(s string) (apkVersion, bool) {
+	m := versionRegex.FindStringSubmatch(s)
+	if m == nil {
+		return apkVersion{}, false
+	}
+
+	var v apkVersion
+	for _, n := range strings.Split(m[1], ".") {
+		number, err := strconv.ParseUint(n, 10, 64)
+		if err != nil {
+			return apkVersion{}, false
+		}
+		v.numbers = append(v.numbers, number)
+	}
+
+	if m[2] != "" {
+		v.letter = m[2][0]
+	}
+
+	for _, part := range strings.Split(m[3], "_")[1:] {
+		name := strings.TrimRight(part, "0123456789")
+		sfx := suffix{rank: suffixRanks[name]}
+		if digits := part[len(name):]; digits != "" {
+			number, err := strconv.ParseUint(digits, 10, 64)
+			if err != nil {
+				return apkVersion{}, false
+			}
+			sfx.number = number
+		}
+		v.suffixes = append(v.suffixes, sfx)
+	}
+
+	if m[4] != "" {
+		release, err := strconv.ParseUint(m[4], 10, 64)
+		if err != nil {
+			return apkVersion{}, false
+		}
+		v.release = release
+	}
+
+	return v, true
+}
+
+// CompareVersions compares two APK versions as apk does, returning -1, 0 or 1. Numbers compare
+// numerically ("1.10" > "1.9"), pre-release suffixes sort before the plain version
+// ("1.0_rc1" < "1.0") and post-release suffixes after it ("1.0_p1" > "1.0"), then releases
+// compare ("1.0-r1" > "1.0-r0"). Invalid versions sort before valid ones, and compare as strings
+// between themselves.
+func CompareVersions(a, b string) int {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+
+	switch {
+	case !okA && !okB:
+		return strings.Compare(a, b)
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+
+	for i := 0; i < min(len(va.numbers), len(vb.numbers)); i++ {
+		if c := cmp.Compare(va.numbers[i], vb.numbers[i]); c != 0 {
+			return c
+		}
+	}
+
+	if c := cmp.Compare(len(va.numbers), len(vb.numbers)); c != 0 {
+		return c
+	}
+
+	if c := cmp.Compare(va.letter, vb.letter); c != 0 {
+		return c
+	}
+
+	// A missing suffix has rank 0, between the pre-release and the post-release suffixes.
+	for i := 0; i < max(len(va.suffixes), len(vb.suffixes)); i++ {
+		var sa, sb suffix
+		if i < len(va.suffixes) {
+			sa = va.suffixes[i]
+		}
+		if i < len(vb.suffixes) {
+			sb = vb.suffixes[i]
+		}
+
+		if c := cmp.Compare(sa.rank, sb.rank); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(sa.number, sb.number); c != 0 {
+			return c
+		}
+	}
+
+	return cmp.Compare(va.release, vb.release)
+}
+
+// Satisfies reports whether 'version' satisfies the constraint 'c'. Fuzzy constraints ("~8.5")
+// match the versions whose components start with those of the constraint ("8.5", "8.5.1-r0",
+// but not "8.50").
+func Satisfies(version string, c apkox.PackageConstraint) bool {
+	switch c.Op {
+	case apkox.OpAny:
+		return true
+	case apkox.OpEqual:
+		return CompareVersions(version, c.Version) == 0
+	case apkox.OpFuzzy:
+		rest, ok := strings.CutPrefix(version, c.Version)
+		return ok && (rest == "" || !isDigit(rest[0]))
+	case apkox.OpGreater:
+		return CompareVersions(version, c.Version) > 0
+	case apkox.OpGreaterEqual:
+		return CompareVersions(version, c.Version) >= 0
+	case apkox.OpLess:
+		return CompareVersions(version, c.Version) < 0
+	case apkox.OpLessEqual:
+		return CompareVersions(version, c.Version) <= 0
+	default:
+		return false
+	}
+}
+
+// isDigit reports whether 'c' is an ASCII digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
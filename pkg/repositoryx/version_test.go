@@ -0,0 +1,59 @@
+package repositoryx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareVersions(t *testing.T) {
+	ordered := []string{
+		"invalid",
+		"1.0_alpha1",
+		"1.0_beta",
+		"1.0_rc1",
+		"1.0",
+		"1.0-r1",
+		"1.0_git20240101",
+		"1.0_p1",
+		"1.0a",
+		"1.0.1",
+		"1.9",
+		"1.10",
+	}
+
+	for i, a := range ordered {
+		for j, b := range ordered {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			assert.Equal(t, want, CompareVersions(a, b), "CompareVersions(%q, %q)", a, b)
+		}
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	tests := []struct {
+		version string
+		c       apkox.PackageConstraint
+		want    bool
+	}{
+		{"8.5.0-r0", apkox.PackageConstraint{Name: "curl"}, true},
+		{"8.5.0-r0", apkox.PackageConstraint{Op: apkox.OpEqual, Version: "8.5.0-r0"}, true},
+		{"8.5.0-r1", apkox.PackageConstraint{Op: apkox.OpEqual, Version: "8.5.0-r0"}, false},
+		{"8.5.1-r0", apkox.PackageConstraint{Op: apkox.OpFuzzy, Version: "8.5"}, true},
+		{"8.50.0-r0", apkox.PackageConstraint{Op: apkox.OpFuzzy, Version: "8.5"}, false},
+		{"8.10.0-r0", apkox.PackageConstraint{Op: apkox.OpGreater, Version: "8.9"}, true},
+		{"8.9", apkox.PackageConstraint{Op: apkox.OpGreaterEqual, Version: "8.9"}, true},
+		{"8.9", apkox.PackageConstraint{Op: apkox.OpLess, Version: "8.9"}, false},
+		{"8.9", apkox.PackageConstraint{Op: apkox.OpLessEqual, Version: "8.9"}, true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Satisfies(tt.version, tt.c), "Satisfies(%q, %v)", tt.version, tt.c)
+	}
+}
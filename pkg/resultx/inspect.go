@@ -0,0 +1,91 @@
+package resultx
+
+import "github.com/Excoriate/daggerx/pkg/cranex"
+
+// Layer is the contract of a layer of an image.
+type Layer struct {
+	// Digest is the digest of the layer.
+	Digest string `json:"digest"`
+	// MediaType is the media type of the layer.
+	MediaType string `json:"mediaType"`
+	// Size is the size of the layer, in bytes.
+	Size int64 `json:"size"`
+}
+
+// PlatformImage is the contract of the image of a platform in an index.
+type PlatformImage struct {
+	// Platform is the platform, as os/arch[/variant].
+	Platform string `json:"platform"`
+	// Digest is the digest of the image manifest.
+	Digest string `json:"digest"`
+}
+
+// InspectResult is the contract of the inspection of an image manifest or index.
+type InspectResult struct {
+	Header
+	// Reference is the inspected image reference. It may be empty.
+	Reference string `json:"reference"`
+	// MediaType is the media type of the manifest.
+	MediaType string `json:"mediaType"`
+	// Index reports whether the manifest is an index of per-platform images.
+	Index bool `json:"index"`
+	// Config is the digest of the image configuration of image manifests.
+	Config string `json:"config"`
+	// Size is the total size of the layers of image manifests, in bytes.
+	Size int64 `json:"size"`
+	// Layers holds the layers of image manifests, in order.
+	Layers []Layer `json:"layers"`
+	// Platforms holds the images of indexes, in index order. Entries without a platform, such as
+	// attestation manifests, are skipped.
+	Platforms []PlatformImage `json:"platforms"`
+	// Annotations holds the annotations of the manifest.
+	Annotations map[string]string `json:"annotations"`
+}
+
+// NewInspectResult creates the result of the manifest 'm' of the image 'ref'.
+func NewInspectResult(ref string, m *cranex.Manifest) *InspectResult {
+	r := &InspectResult{
+		Header:      newHeader(KindInspectResult),
+		Reference:   ref,
+		MediaType:   m.MediaType,
+		Index:       m.IsIndex(),
+		Layers:      make([]Layer, 0, len(m.Layers)),
+		Platforms:   []PlatformImage{},
+		Annotations: map[string]string{},
+	}
+
+	if m.Config != nil {
+		r.Config = m.Config.Digest
+	}
+
+	for _, l := range m.Layers {
+		r.Layers = append(r.Layers, Layer{Digest: l.Digest, MediaType: l.MediaType, Size: l.Size})
+		r.Size += l.Size
+	}
+
+	for _, p := range m.Platforms() {
+		digest, _ := m.PlatformDigest(p)
+		r.Platforms = append(r.Platforms, PlatformImage{Platform: p, Digest: digest})
+	}
+
+	for k, v := range m.Annotations {
+		r.Annotations[k] = v
+	}
+
+	return r
+}
+
+// ParseInspectResult inspects the output of crane manifest for the image 'ref' (see
+// cranex.ParseManifest).
+//
+// Returns:
+//   - The inspection result.
+//   - An error if the output is not a valid manifest.
+func ParseInspectResult(ref string, data []byte) (*InspectResult, error) {
+	m, err := cranex.ParseManifest(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewInspectResult(ref, m), nil
+}
@@ -0,0 +1,84 @@
+package resultx
+
+import "github.com/Excoriate/daggerx/pkg/terraformx"
+
+// ResourceChange is the contract of the planned change of a terraform resource.
+type ResourceChange struct {
+	// Address is the absolute address of the resource (e.g. "module.db.aws_db_instance.main").
+	Address string `json:"address"`
+	// Type is the resource type (e.g. "aws_db_instance").
+	Type string `json:"type"`
+	// Name is the resource name.
+	Name string `json:"name"`
+	// Mode is "managed" for resources and "data" for data sources.
+	Mode string `json:"mode"`
+	// Action is the planned action: "create", "update", "delete" or "replace".
+	Action string `json:"action"`
+}
+
+// PlanSummary is the contract of the summary of a terraform plan.
+type PlanSummary struct {
+	Header
+	// Add is the number of resources to create, replaced resources included.
+	Add int `json:"add"`
+	// Change is the number of resources to update in place.
+	Change int `json:"change"`
+	// Destroy is the number of resources to destroy, replaced resources included.
+	Destroy int `json:"destroy"`
+	// HasChanges reports whether applying the plan changes any resource.
+	HasChanges bool `json:"hasChanges"`
+	// HasDrift reports whether resources were changed outside of terraform.
+	HasDrift bool `json:"hasDrift"`
+	// Summary is the one-line summary terraform prints.
+	Summary string `json:"summary"`
+	// Changes holds the resource changes.
+	Changes []ResourceChange `json:"changes"`
+	// Drift holds the changes made outside of terraform.
+	Drift []ResourceChange `json:"drift"`
+}
+
+// NewPlanSummary creates the result of the terraform plan 'p'.
+func NewPlanSummary(p *terraformx.Plan) *PlanSummary {
+	return &PlanSummary{
+		Header:     newHeader(KindPlanSummary),
+		Add:        p.Add,
+		Change:     p.Change,
+		Destroy:    p.Destroy,
+		HasChanges: p.HasChanges(),
+		HasDrift:   p.HasDrift(),
+		Summary:    p.Summary(),
+		Changes:    resourceChanges(p.Changes),
+		Drift:      resourceChanges(p.Drift),
+	}
+}
+
+// ParsePlanSummary summarizes the JSON representation of a plan, as printed by
+// `terraform show -json <plan>` (see terraformx.ParsePlan).
+//
+// Returns:
+//   - The summary.
+//   - An error if the data is not a JSON plan representation.
+func ParsePlanSummary(data []byte) (*PlanSummary, error) {
+	p, err := terraformx.ParsePlan(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPlanSummary(p), nil
+}
+
+// resourceChanges converts terraform resource changes to their contract.
+func resourceChanges(changes []terraformx.ResourceChange) []ResourceChange {
+	out := make([]ResourceChange, 0, len(changes))
+	for _, c := range changes {
+		out = append(out, ResourceChange{
+			Address: c.Address,
+			Type:    c.Type,
+			Name:    c.Name,
+			Mode:    c.Mode,
+			Action:  string(c.Action),
+		})
+	}
+
+	return out
+}
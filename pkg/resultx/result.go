@@ -0,0 +1,142 @@
+// Package resultx defines the versioned JSON contracts of the parser outputs of this module (SBOM
+// summaries, scan summaries, terraform plan summaries and image inspection results), so tooling
+// consuming the outputs of Dagger functions keeps working across daggerx releases.
+//
+// Every result embeds a Header holding its Kind and the SchemaVersion it was produced with. Within
+// a major schema version, fields are only added, never renamed or removed; collections are always
+// encoded, as empty arrays rather than null. The internal parser types (reportx.SBOMSummary,
+// policyx.Finding, terraformx.Plan, cranex.Manifest) may change freely: results copy them into
+// their own types.
+//
+// Example usage:
+//
+//	summary, err := resultx.ParsePlanSummary([]byte(res.Stdout))
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	data, err := json.Marshal(summary)
+//	// {"schemaVersion":"1.0","kind":"PlanSummary","add":1,...}
+//
+//	result, err := resultx.Decode(data)
+//	if plan, ok := result.(*resultx.PlanSummary); ok {
+//	    // use plan
+//	}
+package resultx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// builderName identifies the result contracts in errorsx errors.
+const builderName = "result"
+
+// SchemaVersion is the version of the contracts produced by this release, as "major.minor".
+// Decoders accept every version with the same major version.
+const SchemaVersion = "1.0"
+
+// Kind identifies the contract of a result.
+type Kind string
+
+const (
+	// KindSBOMSummary is the kind of SBOMSummary.
+	KindSBOMSummary Kind = "SBOMSummary"
+	// KindScanSummary is the kind of ScanSummary.
+	KindScanSummary Kind = "ScanSummary"
+	// KindPlanSummary is the kind of PlanSummary.
+	KindPlanSummary Kind = "PlanSummary"
+	// KindInspectResult is the kind of InspectResult.
+	KindInspectResult Kind = "InspectResult"
+)
+
+// Header identifies the contract of a result. It is embedded by every result.
+type Header struct {
+	// SchemaVersion is the schema version the result was produced with.
+	SchemaVersion string `json:"schemaVersion"`
+	// Kind is the contract of the result.
+	Kind Kind `json:"kind"`
+}
+
+// Result is a versioned parser output.
+type Result interface {
+	// ResultHeader returns the header of the result.
+	ResultHeader() Header
+}
+
+// newHeader returns the header of a result of kind 'kind' at SchemaVersion.
+func newHeader(kind Kind) Header {
+	return Header{SchemaVersion: SchemaVersion, Kind: kind}
+}
+
+// ResultHeader returns the header.
+func (h Header) ResultHeader() Header {
+	return h
+}
+
+// Compatible reports whether results of schema version 'version' can be decoded by this release,
+// i.e. whether it has the major version of SchemaVersion.
+func Compatible(version string) bool {
+	major, _, _ := strings.Cut(version, ".")
+	want, _, _ := strings.Cut(SchemaVersion, ".")
+
+	return major == want
+}
+
+// Decode decodes the JSON encoding of a result into the type of its kind.
+//
+// Returns:
+//   - The result: a *SBOMSummary, *ScanSummary, *PlanSummary or *InspectResult.
+//   - An error if the data is not a result, has no schema version, an incompatible schema
+//     version or an unknown kind.
+func Decode(data []byte) (Result, error) {
+	var h Header
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("failed to decode result: %w", err)
+	}
+
+	if err := h.validate(); err != nil {
+		return nil, err
+	}
+
+	var r Result
+	switch h.Kind {
+	case KindSBOMSummary:
+		r = &SBOMSummary{}
+	case KindScanSummary:
+		r = &ScanSummary{}
+	case KindPlanSummary:
+		r = &PlanSummary{}
+	case KindInspectResult:
+		r = &InspectResult{}
+	default:
+		return nil, errorsx.Unsupported(builderName, "kind", h.Kind, "unsupported result kind %q", h.Kind)
+	}
+
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", h.Kind, err)
+	}
+
+	return r, nil
+}
+
+// validate checks that the header has a kind and a compatible schema version.
+func (h Header) validate() error {
+	if h.SchemaVersion == "" {
+		return errorsx.MissingField(builderName, "schemaVersion", "result has no schema version")
+	}
+
+	if h.Kind == "" {
+		return errorsx.MissingField(builderName, "kind", "result has no kind")
+	}
+
+	if !Compatible(h.SchemaVersion) {
+		return errorsx.Unsupported(builderName, "schemaVersion", h.SchemaVersion,
+			"unsupported schema version %s of %s, this release supports %s", h.SchemaVersion, h.Kind, SchemaVersion)
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package resultx
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/goldenx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPlan = `{
+  "format_version": "1.2",
+  "resource_drift": [
+    {"address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "name": "logs", "change": {"actions": ["update"]}}
+  ],
+  "resource_changes": [
+    {"address": "aws_s3_bucket.assets", "mode": "managed", "type": "aws_s3_bucket", "name": "assets", "change": {"actions": ["create"]}},
+    {"address": "module.db.aws_db_instance.main", "mode": "managed", "type": "aws_db_instance", "name": "main", "change": {"actions": ["delete", "create"]}},
+    {"address": "data.aws_caller_identity.current", "mode": "data", "type": "aws_caller_identity", "name": "current", "change": {"actions": ["read"]}}
+  ]
+}`
+
+const testIndex = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.index.v1+json",
+  "manifests": [
+    {"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:amd", "size": 100, "platform": {"os": "linux", "architecture": "amd64"}},
+    {"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:arm", "size": 100, "platform": {"os": "linux", "architecture": "arm64", "variant": "v8"}},
+    {"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:att", "size": 50, "platform": {"os": "unknown", "architecture": "unknown"}}
+  ],
+  "annotations": {"org.opencontainers.image.version": "1.2.0"}
+}`
+
+const testImageManifest = `{
+  "schemaVersion": 2,
+  "config": {"mediaType": "application/vnd.oci.image.config.v1+json", "digest": "sha256:cfg", "size": 10},
+  "layers": [
+    {"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": "sha256:l1", "size": 1000},
+    {"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": "sha256:l2", "size": 2000}
+  ]
+}`
+
+const testGrypeReport = `{"matches": [
+	{"vulnerability": {"id": "CVE-2024-0002", "severity": "Low"}, "artifact": {"name": "zlib", "version": "1.3-r0"}},
+	{"vulnerability": {"id": "CVE-2024-0001", "severity": "High"}, "artifact": {"name": "openssl", "version": "3.1.0-r0"}}
+]}`
+
+// TestContracts guards the JSON encoding of every result: a golden file change is a contract
+// change, which requires a new SchemaVersion when fields are renamed or removed.
+func TestContracts(t *testing.T) {
+	sbom, err := ParseSBOMSummary("sbom.spdx.json", []byte(`{"spdxVersion": "SPDX-2.3", "packages": [{}, {}]}`))
+	require.NoError(t, err)
+
+	scan, err := ParseScanSummary("grype", "registry.example.com/app:v1", []byte(testGrypeReport))
+	require.NoError(t, err)
+
+	plan, err := ParsePlanSummary([]byte(testPlan))
+	require.NoError(t, err)
+
+	index, err := ParseInspectResult("registry.example.com/app:v1", []byte(testIndex))
+	require.NoError(t, err)
+
+	image, err := ParseInspectResult("", []byte(testImageManifest))
+	require.NoError(t, err)
+
+	g := goldenx.New(t)
+	g.AssertJSON("sbom-summary", sbom)
+	g.AssertJSON("scan-summary", scan)
+	g.AssertJSON("plan-summary", plan)
+	g.AssertJSON("inspect-index", index)
+	g.AssertJSON("inspect-image", image)
+}
+
+func TestEmptyCollectionsAreEncoded(t *testing.T) {
+	scan := NewScanSummary(reportx.ScanResult{Scanner: "trivy", Target: "app"})
+	data, err := json.Marshal(scan)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"findings":[]`)
+	assert.Contains(t, string(data), `"maxSeverity":""`)
+	assert.Contains(t, string(data), `"critical":0`)
+
+	plan, err := ParsePlanSummary([]byte(`{"format_version": "1.2"}`))
+	require.NoError(t, err)
+	data, err = json.Marshal(plan)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"changes":[]`)
+	assert.Contains(t, string(data), `"drift":[]`)
+	assert.Contains(t, string(data), `"summary":"No changes."`)
+}
+
+func TestScanSummaryDoesNotReorderInput(t *testing.T) {
+	findings := []policyx.Finding{
+		{ID: "CVE-2", Package: "a", Severity: policyx.SeverityLow},
+		{ID: "CVE-1", Package: "b", Severity: policyx.SeverityCritical},
+	}
+
+	scan := NewScanSummary(reportx.ScanResult{Scanner: "grype", Findings: findings})
+	assert.Equal(t, "CVE-1", scan.Findings[0].ID)
+	assert.Equal(t, "critical", scan.MaxSeverity)
+	assert.Equal(t, "CVE-2", findings[0].ID)
+}
+
+func TestDecode(t *testing.T) {
+	plan, err := ParsePlanSummary([]byte(testPlan))
+	require.NoError(t, err)
+
+	data, err := json.Marshal(plan)
+	require.NoError(t, err)
+
+	r, err := Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, plan, r)
+	assert.Equal(t, Header{SchemaVersion: SchemaVersion, Kind: KindPlanSummary}, r.ResultHeader())
+
+	// Minor versions add fields only, so later minor versions decode.
+	r, err = Decode([]byte(`{"schemaVersion": "1.7", "kind": "SBOMSummary", "path": "a.json", "packages": 3, "added": true}`))
+	require.NoError(t, err)
+	assert.Equal(t, &SBOMSummary{Header: Header{SchemaVersion: "1.7", Kind: KindSBOMSummary}, Path: "a.json", Packages: 3}, r)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{name: "Missing schema version", data: `{"kind": "PlanSummary"}`, wantErr: errorsx.ErrMissingField},
+		{name: "Missing kind", data: `{"schemaVersion": "1.0"}`, wantErr: errorsx.ErrMissingField},
+		{name: "Incompatible major version", data: `{"schemaVersion": "2.0", "kind": "PlanSummary"}`, wantErr: errorsx.ErrUnsupported},
+		{name: "Unknown kind", data: `{"schemaVersion": "1.0", "kind": "Coverage"}`, wantErr: errorsx.ErrUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode([]byte(tt.data))
+			assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+		})
+	}
+
+	_, err := Decode([]byte(`[`))
+	assert.ErrorContains(t, err, "failed to decode result")
+
+	_, err = ParseScanSummary("clair", "app", nil)
+	assert.ErrorIs(t, err, errorsx.ErrUnsupported)
+}
+
+func TestCompatible(t *testing.T) {
+	assert.True(t, Compatible("1.0"))
+	assert.True(t, Compatible("1.3"))
+	assert.True(t, Compatible("1"))
+	assert.False(t, Compatible("2.0"))
+	assert.False(t, Compatible(""))
+}
@@ -0,0 +1,39 @@
+package resultx
+
+import "github.com/Excoriate/daggerx/pkg/reportx"
+
+// SBOMSummary is the contract of the summary of a software bill of materials.
+type SBOMSummary struct {
+	Header
+	// Path is the path of the SBOM document.
+	Path string `json:"path"`
+	// Format is the format of the SBOM: "spdx-json" or "cyclonedx-json".
+	Format string `json:"format"`
+	// Packages is the number of packages, or components, listed in the SBOM.
+	Packages int `json:"packages"`
+}
+
+// NewSBOMSummary creates the result of the SBOM summary 's'.
+func NewSBOMSummary(s reportx.SBOMSummary) *SBOMSummary {
+	return &SBOMSummary{
+		Header:   newHeader(KindSBOMSummary),
+		Path:     s.Path,
+		Format:   s.Format,
+		Packages: s.Packages,
+	}
+}
+
+// ParseSBOMSummary summarizes the SPDX or CycloneDX JSON document 'data' found at 'path' (see
+// reportx.SummarizeSBOM).
+//
+// Returns:
+//   - The summary.
+//   - An error if the document is neither an SPDX nor a CycloneDX JSON document.
+func ParseSBOMSummary(path string, data []byte) (*SBOMSummary, error) {
+	s, err := reportx.SummarizeSBOM(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSBOMSummary(s), nil
+}
@@ -0,0 +1,120 @@
+package resultx
+
+import (
+	"sort"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+)
+
+// scanParsers maps the report formats ParseScanSummary supports to their parser.
+var scanParsers = map[string]func([]byte) ([]policyx.Finding, error){
+	"grype": policyx.ParseGrypeReport,
+	"trivy": policyx.ParseTrivyReport,
+	"sarif": policyx.ParseSARIFReport,
+}
+
+// severities lists the severities counted by scan summaries, most severe first.
+var severities = []policyx.Severity{
+	policyx.SeverityCritical,
+	policyx.SeverityHigh,
+	policyx.SeverityMedium,
+	policyx.SeverityLow,
+	policyx.SeverityNegligible,
+	policyx.SeverityUnknown,
+}
+
+// Finding is the contract of a vulnerability finding.
+type Finding struct {
+	// ID is the vulnerability identifier (e.g. "CVE-2024-0001").
+	ID string `json:"id"`
+	// Package is the name of the affected package.
+	Package string `json:"package"`
+	// Version is the installed version of the affected package.
+	Version string `json:"version"`
+	// Severity is the severity: "critical", "high", "medium", "low", "negligible" or "unknown".
+	Severity string `json:"severity"`
+}
+
+// ScanSummary is the contract of the summary of a vulnerability scan.
+type ScanSummary struct {
+	Header
+	// Scanner is the name of the scanner (e.g. "grype").
+	Scanner string `json:"scanner"`
+	// Target is the scanned image or directory.
+	Target string `json:"target"`
+	// Total is the number of findings.
+	Total int `json:"total"`
+	// MaxSeverity is the highest severity of the findings, empty when there is none.
+	MaxSeverity string `json:"maxSeverity"`
+	// Counts holds the number of findings per severity, every severity being present.
+	Counts map[string]int `json:"counts"`
+	// Findings holds the findings, sorted by decreasing severity, then ID and package.
+	Findings []Finding `json:"findings"`
+}
+
+// NewScanSummary creates the result of the scan result 'scan'.
+func NewScanSummary(scan reportx.ScanResult) *ScanSummary {
+	s := &ScanSummary{
+		Header:   newHeader(KindScanSummary),
+		Scanner:  scan.Scanner,
+		Target:   scan.Target,
+		Total:    len(scan.Findings),
+		Counts:   make(map[string]int, len(severities)),
+		Findings: make([]Finding, 0, len(scan.Findings)),
+	}
+
+	for _, sev := range severities {
+		s.Counts[sev.String()] = 0
+	}
+
+	for _, f := range scan.Findings {
+		s.Counts[f.Severity.String()]++
+	}
+
+	findings := append([]policyx.Finding(nil), scan.Findings...)
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity != b.Severity {
+			return a.Severity > b.Severity
+		}
+
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+
+		return a.Package < b.Package
+	})
+
+	for _, f := range findings {
+		s.Findings = append(s.Findings, Finding{ID: f.ID, Package: f.Package, Version: f.Version, Severity: f.Severity.String()})
+	}
+
+	if len(findings) > 0 {
+		s.MaxSeverity = policyx.MaxSeverity(findings).String()
+	}
+
+	return s
+}
+
+// ParseScanSummary summarizes the scan report 'data' of 'target' by 'scanner'. The report is a
+// grype or trivy JSON report, or a SARIF report for the "sarif" scanner.
+//
+// Returns:
+//   - The summary.
+//   - An error if the scanner is not supported or the report is invalid.
+func ParseScanSummary(scanner, target string, data []byte) (*ScanSummary, error) {
+	parse, ok := scanParsers[scanner]
+	if !ok {
+		return nil, errorsx.Unsupported(builderName, "scanner", scanner,
+			"unsupported scanner %q, expected grype, trivy or sarif", scanner)
+	}
+
+	findings, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewScanSummary(reportx.ScanResult{Scanner: scanner, Target: target, Findings: findings}), nil
+}
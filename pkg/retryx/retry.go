@@ -0,0 +1,328 @@
+// Package retryx retries the network-touching operations of this module (keyring downloads,
+// digest resolution, command execution) with exponential backoff and jitter, so transient
+// registry and network failures don't fail pipelines.
+//
+// Only retryable errors are retried: IsRetryable accepts timeouts, connection resets and
+// refusals, truncated responses, and the HTTP statuses 408, 429, 500, 502, 503 and 504 reported
+// with StatusError. Operations mark other errors with Retryable, or stop retries with Permanent.
+//
+// Example usage:
+//
+//	policy := retryx.NewPolicy().WithMaxAttempts(5).WithMaxElapsed(time.Minute)
+//
+//	digest, err := retryx.DoValue(ctx, policy, func(ctx context.Context) (string, error) {
+//	    return containerx.ResolveDigestContext(ctx, "cgr.dev/chainguard/static:latest", nil)
+//	})
+package retryx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/timex"
+)
+
+const (
+	// DefaultMaxAttempts is the number of attempts of an operation, the first one included.
+	DefaultMaxAttempts = 3
+	// DefaultInitialInterval is the wait before the first retry.
+	DefaultInitialInterval = 200 * time.Millisecond
+	// DefaultMaxInterval caps the wait between two attempts.
+	DefaultMaxInterval = 5 * time.Second
+	// DefaultMultiplier is the factor applied to the wait after each retry.
+	DefaultMultiplier = 2.0
+	// DefaultJitter is the fraction of the wait randomized, so concurrent callers spread out.
+	DefaultJitter = 0.2
+)
+
+// StatusError reports an unexpected HTTP response status. IsRetryable accepts the statuses of
+// transient server failures and rate limits.
+type StatusError struct {
+	// StatusCode is the HTTP status code (e.g. 503).
+	StatusCode int
+	// Status is the HTTP status line (e.g. "503 Service Unavailable").
+	Status string
+}
+
+// Error returns the status of the error.
+func (e *StatusError) Error() string {
+	return "unexpected status " + e.Status
+}
+
+// retryableStatuses are the HTTP statuses of transient failures.
+var retryableStatuses = map[int]bool{
+	http.StatusRequestTimeout:      true,
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// retryableError marks an error as retryable.
+type retryableError struct{ err error }
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// permanentError marks an error as not retryable.
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Retryable marks 'err' as retryable, whatever its cause. It returns nil for a nil error.
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &retryableError{err: err}
+}
+
+// Permanent marks 'err' as not retryable, whatever its cause. Do returns the unmarked error. It
+// returns nil for a nil error.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanentError{err: err}
+}
+
+// IsRetryable reports whether 'err' is a transient failure worth retrying. Errors marked with
+// Permanent, cancellations and unknown errors are not.
+func IsRetryable(err error) bool {
+	var (
+		permanent *permanentError
+		retryable *retryableError
+		status    *StatusError
+		netErr    net.Error
+		opErr     *net.OpError
+	)
+
+	switch {
+	case err == nil, errors.As(err, &permanent), errors.Is(err, context.Canceled):
+		return false
+	case errors.As(err, &retryable):
+		return true
+	case errors.As(err, &status):
+		return retryableStatuses[status.StatusCode]
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return true
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.EPIPE):
+		return true
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return true
+	default:
+		return false
+	}
+}
+
+// Policy describes how an operation is retried.
+type Policy struct {
+	// maxAttempts is the number of attempts, the first one included.
+	maxAttempts int
+
+	// initialInterval is the wait before the first retry.
+	initialInterval time.Duration
+
+	// maxInterval caps the wait between two attempts.
+	maxInterval time.Duration
+
+	// multiplier is the factor applied to the wait after each retry.
+	multiplier float64
+
+	// jitter is the fraction of the wait randomized.
+	jitter float64
+
+	// maxElapsed caps the time spent retrying. Zero means no limit.
+	maxElapsed time.Duration
+
+	// retryable classifies the errors.
+	retryable func(error) bool
+
+	// clock measures the elapsed time.
+	clock timex.Clock
+
+	// sleep waits between attempts; it is replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+
+	// random returns a number in [0, 1) for the jitter; it is replaced in tests.
+	random func() float64
+}
+
+// NewPolicy creates a Policy with the default attempts and backoff, retrying the errors
+// accepted by IsRetryable.
+func NewPolicy() *Policy {
+	return &Policy{
+		maxAttempts:     DefaultMaxAttempts,
+		initialInterval: DefaultInitialInterval,
+		maxInterval:     DefaultMaxInterval,
+		multiplier:      DefaultMultiplier,
+		jitter:          DefaultJitter,
+		retryable:       IsRetryable,
+		clock:           timex.System,
+		sleep:           sleep,
+		random:          rand.Float64,
+	}
+}
+
+// WithMaxAttempts sets the number of attempts, the first one included. A value below 1 means a
+// single attempt.
+func (p *Policy) WithMaxAttempts(n int) *Policy {
+	p.maxAttempts = n
+	return p
+}
+
+// WithBackoff sets the wait before the first retry and the cap of the wait between attempts.
+func (p *Policy) WithBackoff(initial, maxInterval time.Duration) *Policy {
+	p.initialInterval = initial
+	p.maxInterval = maxInterval
+	return p
+}
+
+// WithMultiplier sets the factor applied to the wait after each retry. Values below 1 are
+// treated as 1, a constant backoff.
+func (p *Policy) WithMultiplier(multiplier float64) *Policy {
+	p.multiplier = multiplier
+	return p
+}
+
+// WithJitter sets the fraction of the wait randomized, between 0 (none) and 1.
+func (p *Policy) WithJitter(jitter float64) *Policy {
+	p.jitter = min(max(jitter, 0), 1)
+	return p
+}
+
+// WithMaxElapsed caps the time spent retrying: no retry starts after 'd' since the first
+// attempt. Zero means no limit.
+func (p *Policy) WithMaxElapsed(d time.Duration) *Policy {
+	p.maxElapsed = d
+	return p
+}
+
+// WithRetryable sets the classifier of the retryable errors. It defaults to IsRetryable.
+func (p *Policy) WithRetryable(retryable func(error) bool) *Policy {
+	p.retryable = retryable
+	return p
+}
+
+// WithClock sets the clock measuring the elapsed time, for tests.
+func (p *Policy) WithClock(clock timex.Clock) *Policy {
+	p.clock = clock
+	return p
+}
+
+// Delay returns the wait before the retry 'n' (1 for the first retry), before jitter.
+func (p *Policy) Delay(n int) time.Duration {
+	d := float64(p.initialInterval)
+	for i := 1; i < n; i++ {
+		d *= max(p.multiplier, 1)
+		if p.maxInterval > 0 && d >= float64(p.maxInterval) {
+			break
+		}
+	}
+
+	if p.maxInterval > 0 && d > float64(p.maxInterval) {
+		return p.maxInterval
+	}
+
+	return time.Duration(d)
+}
+
+// wait returns the jittered wait before the retry 'n'.
+func (p *Policy) wait(n int) time.Duration {
+	d := float64(p.Delay(n))
+	if p.jitter > 0 {
+		d += d * p.jitter * (2*p.random() - 1)
+	}
+
+	return time.Duration(d)
+}
+
+// Do runs 'fn' until it succeeds, returns an error the policy doesn't retry, or the attempts or
+// the elapsed time are exhausted. A nil policy is the default policy.
+//
+// Returns:
+//   - nil on success.
+//   - The error of the last attempt otherwise: unmarked when it was marked with Permanent, and
+//     annotated with the number of attempts when they are exhausted. The error of 'ctx' is
+//     returned when it is done while waiting.
+func Do(ctx context.Context, p *Policy, fn func(ctx context.Context) error) error {
+	if p == nil {
+		p = NewPolicy()
+	}
+
+	start := p.clock.Now()
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+
+		if ctx.Err() != nil || !p.retryable(err) {
+			return err
+		}
+
+		if attempt >= p.maxAttempts {
+			if attempt == 1 {
+				return err
+			}
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		wait := p.wait(attempt)
+		if p.maxElapsed > 0 && p.clock.Now().Add(wait).Sub(start) > p.maxElapsed {
+			return fmt.Errorf("giving up after %d attempts in %s: %w", attempt, p.maxElapsed, err)
+		}
+
+		if err := p.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// DoValue runs 'fn' like Do, and returns the value of its successful attempt.
+func DoValue[T any](ctx context.Context, p *Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	var value T
+
+	err := Do(ctx, p, func(ctx context.Context) error {
+		v, err := fn(ctx)
+		if err != nil {
+			return err
+		}
+		value = v
+
+		return nil
+	})
+
+	return value, err
+}
+
+// sleep waits for 'd', or until 'ctx' is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
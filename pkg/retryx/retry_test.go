@@ -0,0 +1,193 @@
+package retryx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTransient = Retryable(errors.New("transient"))
+
+// newTestPolicy returns a policy recording its waits instead of sleeping, with a clock advanced
+// by the waits.
+func newTestPolicy() (*Policy, *[]time.Duration) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var waits []time.Duration
+
+	p := NewPolicy().WithJitter(0).WithClock(func() time.Time { return now })
+	p.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		now = now.Add(d)
+		return nil
+	}
+
+	return p, &waits
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"Nil", nil, false},
+		{"Unknown", errors.New("invalid reference"), false},
+		{"Marked retryable", Retryable(errors.New("flaky")), true},
+		{"Marked permanent", Permanent(&StatusError{StatusCode: 503}), false},
+		{"Canceled", fmt.Errorf("request: %w", context.Canceled), false},
+		{"Deadline exceeded", context.DeadlineExceeded, true},
+		{"Service unavailable", fmt.Errorf("fetch: %w", &StatusError{StatusCode: 503, Status: "503 Service Unavailable"}), true},
+		{"Too many requests", &StatusError{StatusCode: 429}, true},
+		{"Not found", &StatusError{StatusCode: 404, Status: "404 Not Found"}, false},
+		{"Unexpected EOF", fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{"Connection reset", &net.OpError{Op: "read", Err: syscall.ECONNRESET}, true},
+		{"Dial failure", &net.OpError{Op: "dial", Err: errors.New("no route to host")}, true},
+		{"DNS timeout", &net.DNSError{IsTimeout: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsRetryable(tt.err))
+		})
+	}
+
+	assert.Nil(t, Retryable(nil))
+	assert.Nil(t, Permanent(nil))
+	assert.Equal(t, "unexpected status 404 Not Found", (&StatusError{StatusCode: 404, Status: "404 Not Found"}).Error())
+}
+
+func TestDelay(t *testing.T) {
+	p := NewPolicy().WithBackoff(100*time.Millisecond, time.Second)
+
+	assert.Equal(t, 100*time.Millisecond, p.Delay(1))
+	assert.Equal(t, 200*time.Millisecond, p.Delay(2))
+	assert.Equal(t, 800*time.Millisecond, p.Delay(4))
+	assert.Equal(t, time.Second, p.Delay(5))
+	assert.Equal(t, time.Second, p.Delay(100))
+
+	assert.Equal(t, 100*time.Millisecond, p.WithMultiplier(0.5).Delay(3), "multipliers below 1 are constant")
+}
+
+func TestJitter(t *testing.T) {
+	p := NewPolicy().WithBackoff(time.Second, time.Minute).WithJitter(0.5)
+
+	p.random = func() float64 { return 0 }
+	assert.Equal(t, 500*time.Millisecond, p.wait(1))
+
+	p.random = func() float64 { return 0.75 }
+	assert.Equal(t, 1250*time.Millisecond, p.wait(1))
+
+	assert.Equal(t, 1.0, NewPolicy().WithJitter(3).jitter)
+}
+
+func TestDo(t *testing.T) {
+	p, waits := newTestPolicy()
+
+	calls := 0
+	err := Do(context.Background(), p, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errTransient
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []time.Duration{200 * time.Millisecond, 400 * time.Millisecond}, *waits)
+}
+
+func TestDoExhausted(t *testing.T) {
+	p, _ := newTestPolicy()
+
+	calls := 0
+	err := Do(context.Background(), p.WithMaxAttempts(4), func(context.Context) error {
+		calls++
+		return errTransient
+	})
+	assert.ErrorIs(t, err, errTransient)
+	assert.ErrorContains(t, err, "giving up after 4 attempts")
+	assert.Equal(t, 4, calls)
+}
+
+func TestDoNotRetried(t *testing.T) {
+	p, waits := newTestPolicy()
+	notFound := &StatusError{StatusCode: 404, Status: "404 Not Found"}
+
+	calls := 0
+	err := Do(context.Background(), p, func(context.Context) error {
+		calls++
+		return notFound
+	})
+	assert.Equal(t, notFound, err)
+	assert.Equal(t, 1, calls)
+	assert.Empty(t, *waits)
+
+	permanent := errors.New("bad credentials")
+	err = Do(context.Background(), p.WithRetryable(func(error) bool { return true }), func(context.Context) error {
+		return Permanent(permanent)
+	})
+	assert.Equal(t, permanent, err, "permanent errors are unmarked")
+}
+
+func TestDoMaxElapsed(t *testing.T) {
+	p, waits := newTestPolicy()
+	p.WithMaxAttempts(10).WithBackoff(time.Second, 10*time.Second).WithMaxElapsed(5 * time.Second)
+
+	calls := 0
+	err := Do(context.Background(), p, func(context.Context) error {
+		calls++
+		return errTransient
+	})
+	assert.ErrorIs(t, err, errTransient)
+	assert.ErrorContains(t, err, "giving up after 3 attempts in 5s")
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *waits)
+}
+
+func TestDoContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	err := Do(ctx, NewPolicy().WithBackoff(time.Hour, time.Hour), func(context.Context) error {
+		calls++
+		cancel()
+		return errTransient
+	})
+	assert.ErrorIs(t, err, errTransient)
+	assert.Equal(t, 1, calls)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = Do(ctx, NewPolicy().WithBackoff(time.Hour, time.Hour), func(context.Context) error {
+		return errTransient
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestDoValue(t *testing.T) {
+	p, _ := newTestPolicy()
+
+	calls := 0
+	value, err := DoValue(context.Background(), p, func(context.Context) (string, error) {
+		calls++
+		if calls == 1 {
+			return "", errTransient
+		}
+		return "sha256:abc", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:abc", value)
+
+	_, err = DoValue(context.Background(), nil, func(context.Context) (int, error) {
+		return 0, errors.New("invalid")
+	})
+	assert.EqualError(t, err, "invalid")
+}
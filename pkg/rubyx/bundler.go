@@ -0,0 +1,336 @@
+// Package rubyx provides builders for the bundler commands of Ruby pipelines: installing the
+// gems of a Gemfile into a cached bundle path, adding the platforms of the target images to the
+// lockfile, and running commands in the bundle.
+//
+// Bundler reads its settings from BUNDLE_* environment variables rather than deprecated
+// install flags, so the builder returns them with Env and the same settings apply to install
+// and exec.
+//
+// Example usage:
+//
+//	bundler := rubyx.NewBundlerBuilder().
+//	    WithDeployment(true).
+//	    WithWithout("development", "test").
+//	    WithJobs(4)
+//
+//	install, err := bundler.InstallCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	exec, err := bundler.ExecCommand("rake", "assets:precompile")
+//	env, mounts := bundler.Env(), bundler.Mounts()
+package rubyx
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/builderx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the bundler builder in errorsx errors.
+const builderName = "bundler"
+
+// RubyDefaultImage is the default container image providing ruby and bundler.
+const RubyDefaultImage = "ruby:3.3-alpine"
+
+// BundleVolumeKey is the cache volume key of the bundle path.
+const BundleVolumeKey = "ruby-bundle"
+
+// platformRegex matches the gem platforms (e.g. "x86_64-linux", "aarch64-linux-musl", "ruby").
+var platformRegex = regexp.MustCompile(`^[a-z0-9_]+(-[a-z0-9_.]+)*$`)
+
+// groupRegex matches the Gemfile group names.
+var groupRegex = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// GetBundlePath returns the conventional directory the gems are installed to inside the
+// container. If mntPrefix is empty, fixtures.MntPrefix is used.
+func GetBundlePath(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, "vendor", "bundle")
+}
+
+// BundlerBuilder generates the bundle install, lock and exec commands of a Gemfile.
+type BundlerBuilder struct {
+	// gemfile is the Gemfile (BUNDLE_GEMFILE). Empty uses the Gemfile of the working directory.
+	gemfile string
+
+	// path is the directory the gems are installed to (BUNDLE_PATH), backed by a cache volume.
+	path string
+
+	// without are the Gemfile groups not installed (BUNDLE_WITHOUT).
+	without []string
+
+	// deployment installs in deployment mode (BUNDLE_DEPLOYMENT): the lockfile must be up to
+	// date, and the gems are installed to the bundle path.
+	deployment bool
+
+	// frozen refuses to update the lockfile (BUNDLE_FROZEN).
+	frozen bool
+
+	// forceRubyPlatform installs the pure ruby variant of the gems (BUNDLE_FORCE_RUBY_PLATFORM).
+	forceRubyPlatform bool
+
+	// platforms are the gem platforms added to the lockfile.
+	platforms []string
+
+	// jobs is the number of parallel installs. Zero keeps the bundler default.
+	jobs int
+
+	// retry is the number of retries of failed network requests. Zero keeps the bundler default.
+	retry int
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+var _ builderx.Builder = (*BundlerBuilder)(nil)
+
+// NewBundlerBuilder creates a BundlerBuilder installing the gems to GetBundlePath("").
+func NewBundlerBuilder() *BundlerBuilder {
+	return &BundlerBuilder{path: GetBundlePath("")}
+}
+
+// WithGemfile sets the Gemfile (e.g. "services/api/Gemfile"), for monorepos with several
+// bundles.
+func (b *BundlerBuilder) WithGemfile(path string) *BundlerBuilder {
+	b.gemfile = path
+	return b
+}
+
+// WithPath sets the directory the gems are installed to.
+func (b *BundlerBuilder) WithPath(dir string) *BundlerBuilder {
+	b.path = dir
+	return b
+}
+
+// WithWithout adds Gemfile groups not to install (e.g. "development", "test").
+func (b *BundlerBuilder) WithWithout(groups ...string) *BundlerBuilder {
+	b.without = append(b.without, groups...)
+	return b
+}
+
+// WithDeployment sets whether gems are installed in deployment mode, which requires an
+// up-to-date lockfile.
+func (b *BundlerBuilder) WithDeployment(deployment bool) *BundlerBuilder {
+	b.deployment = deployment
+	return b
+}
+
+// WithFrozen sets whether the lockfile may be updated.
+func (b *BundlerBuilder) WithFrozen(frozen bool) *BundlerBuilder {
+	b.frozen = frozen
+	return b
+}
+
+// WithForceRubyPlatform sets whether the pure ruby variant of the gems is installed instead of
+// the precompiled ones, e.g. for platforms without precompiled gems.
+func (b *BundlerBuilder) WithForceRubyPlatform(force bool) *BundlerBuilder {
+	b.forceRubyPlatform = force
+	return b
+}
+
+// WithPlatform adds gem platforms (e.g. "x86_64-linux", "aarch64-linux-musl") to the lockfile
+// (see LockPlatformsCommand), so the precompiled gems of the target images resolve.
+func (b *BundlerBuilder) WithPlatform(platforms ...string) *BundlerBuilder {
+	b.platforms = append(b.platforms, platforms...)
+	return b
+}
+
+// WithJobs sets the number of gems installed in parallel.
+func (b *BundlerBuilder) WithJobs(jobs int) *BundlerBuilder {
+	b.jobs = jobs
+	return b
+}
+
+// WithRetry sets the number of retries of failed network requests.
+func (b *BundlerBuilder) WithRetry(retry int) *BundlerBuilder {
+	b.retry = retry
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *BundlerBuilder) WithLogger(logger *slog.Logger) *BundlerBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks that the builder configuration is consistent.
+func (b *BundlerBuilder) validate() error {
+	if b.path == "" {
+		return errorsx.MissingField(builderName, "path", "bundle path is required")
+	}
+
+	for _, g := range b.without {
+		if !groupRegex.MatchString(g) {
+			return errorsx.InvalidValue(builderName, "without", g, "invalid Gemfile group: %q", g)
+		}
+	}
+
+	for _, p := range b.platforms {
+		if !platformRegex.MatchString(p) {
+			return errorsx.InvalidValue(builderName, "platforms", p, "invalid gem platform: %q", p)
+		}
+	}
+
+	if b.jobs < 0 {
+		return errorsx.InvalidValue(builderName, "jobs", b.jobs, "jobs cannot be negative: %d", b.jobs)
+	}
+
+	if b.retry < 0 {
+		return errorsx.InvalidValue(builderName, "retry", b.retry, "retry cannot be negative: %d", b.retry)
+	}
+
+	if b.frozen && len(b.platforms) > 0 {
+		return errorsx.ConflictingOptions(builderName, []string{"frozen", "platforms"},
+			"adding platforms updates the lockfile, which frozen bundles refuse")
+	}
+
+	return nil
+}
+
+// InstallCommand generates the bundle install command. The settings are provided by Env.
+//
+// Returns:
+//   - The bundle install command.
+//   - An error if a group, platform, jobs or retry value is invalid.
+func (b *BundlerBuilder) InstallCommand() ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"bundle", "install"}
+	if b.jobs > 0 {
+		cmd = append(cmd, "--jobs", strconv.Itoa(b.jobs))
+	}
+
+	if b.retry > 0 {
+		cmd = append(cmd, "--retry", strconv.Itoa(b.retry))
+	}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// LockPlatformsCommand generates the bundle lock command adding the platforms to the lockfile,
+// run before InstallCommand.
+//
+// Returns:
+//   - The bundle lock command.
+//   - An error if no platform is set or the configuration is invalid.
+func (b *BundlerBuilder) LockPlatformsCommand() ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	if len(b.platforms) == 0 {
+		return nil, errorsx.MissingField(builderName, "platforms", "at least one platform is required")
+	}
+
+	cmd := []string{"bundle", "lock"}
+	for _, p := range b.platforms {
+		cmd = append(cmd, "--add-platform", p)
+	}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// ExecCommand generates the bundle exec command running 'args' (e.g. "rspec") with the gems of
+// the bundle.
+//
+// Returns:
+//   - The bundle exec command.
+//   - An error if no command is set or the configuration is invalid.
+func (b *BundlerBuilder) ExecCommand(args ...string) ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	if len(args) == 0 || args[0] == "" {
+		return nil, errorsx.MissingField(builderName, "command", "command to execute is required")
+	}
+
+	cmd := append([]string{"bundle", "exec"}, args...)
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Env returns the BUNDLE_* environment variables holding the bundler settings.
+func (b *BundlerBuilder) Env() []types.DaggerEnvVars {
+	env := []types.DaggerEnvVars{{Name: "BUNDLE_PATH", Value: b.path}}
+
+	if b.gemfile != "" {
+		env = append(env, types.DaggerEnvVars{Name: "BUNDLE_GEMFILE", Value: b.gemfile})
+	}
+
+	if len(b.without) > 0 {
+		env = append(env, types.DaggerEnvVars{Name: "BUNDLE_WITHOUT", Value: strings.Join(b.without, ":")})
+	}
+
+	flags := []struct {
+		name string
+		set  bool
+	}{
+		{"BUNDLE_DEPLOYMENT", b.deployment},
+		{"BUNDLE_FROZEN", b.frozen},
+		{"BUNDLE_FORCE_RUBY_PLATFORM", b.forceRubyPlatform},
+	}
+	for _, f := range flags {
+		if f.set {
+			env = append(env, types.DaggerEnvVars{Name: f.name, Value: "true"})
+		}
+	}
+
+	return env
+}
+
+// Mounts returns the cache volume mount of the bundle path, keyed BundleVolumeKey.
+func (b *BundlerBuilder) Mounts() []types.Mount {
+	if b.path == "" {
+		return nil
+	}
+
+	return []types.Mount{{Kind: types.MountKindCache, Source: BundleVolumeKey, Target: b.path}}
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *BundlerBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// BuildCommand generates the bundle install command, as InstallCommand does.
+func (b *BundlerBuilder) BuildCommand() ([]string, error) {
+	return b.InstallCommand()
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the bundle install and lock commands.
+func (b *BundlerBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewBundlerBuilder(),
+		explainx.Generate("InstallCommand", b.InstallCommand),
+		explainx.Generate("LockPlatformsCommand", b.LockPlatformsCommand),
+	)
+}
@@ -0,0 +1,93 @@
+package rubyx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBundlePath(t *testing.T) {
+	assert.Equal(t, "/mnt/vendor/bundle", GetBundlePath(""))
+	assert.Equal(t, "/src/vendor/bundle", GetBundlePath("/src"))
+}
+
+func TestBundlerBuilderCommands(t *testing.T) {
+	b := NewBundlerBuilder().
+		WithGemfile("services/api/Gemfile").
+		WithWithout("development", "test").
+		WithDeployment(true).
+		WithPlatform("x86_64-linux", "aarch64-linux-musl").
+		WithJobs(4).
+		WithRetry(3)
+
+	install, err := b.InstallCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bundle", "install", "--jobs", "4", "--retry", "3"}, install)
+
+	lock, err := b.LockPlatformsCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"bundle", "lock", "--add-platform", "x86_64-linux", "--add-platform", "aarch64-linux-musl",
+	}, lock)
+
+	exec, err := b.ExecCommand("rake", "assets:precompile")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bundle", "exec", "rake", "assets:precompile"}, exec)
+
+	assert.Equal(t, []types.DaggerEnvVars{
+		{Name: "BUNDLE_PATH", Value: "/mnt/vendor/bundle"},
+		{Name: "BUNDLE_GEMFILE", Value: "services/api/Gemfile"},
+		{Name: "BUNDLE_WITHOUT", Value: "development:test"},
+		{Name: "BUNDLE_DEPLOYMENT", Value: "true"},
+	}, b.Env())
+
+	assert.Equal(t, []types.Mount{
+		{Kind: types.MountKindCache, Source: BundleVolumeKey, Target: "/mnt/vendor/bundle"},
+	}, b.Mounts())
+}
+
+func TestBundlerBuilderDefaults(t *testing.T) {
+	b := NewBundlerBuilder().WithPath("/cache/bundle").WithFrozen(true).WithForceRubyPlatform(true)
+
+	install, err := b.InstallCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bundle", "install"}, install)
+
+	assert.Equal(t, []types.DaggerEnvVars{
+		{Name: "BUNDLE_PATH", Value: "/cache/bundle"},
+		{Name: "BUNDLE_FROZEN", Value: "true"},
+		{Name: "BUNDLE_FORCE_RUBY_PLATFORM", Value: "true"},
+	}, b.Env())
+}
+
+func TestBundlerBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func() error
+		kind error
+	}{
+		{"Missing path", func() error { _, err := NewBundlerBuilder().WithPath("").InstallCommand(); return err }, errorsx.ErrMissingField},
+		{"Invalid group", func() error { _, err := NewBundlerBuilder().WithWithout("dev test").InstallCommand(); return err }, errorsx.ErrInvalidValue},
+		{"Invalid platform", func() error { _, err := NewBundlerBuilder().WithPlatform("Linux AMD64").InstallCommand(); return err }, errorsx.ErrInvalidValue},
+		{"Negative jobs", func() error { _, err := NewBundlerBuilder().WithJobs(-1).InstallCommand(); return err }, errorsx.ErrInvalidValue},
+		{"Negative retry", func() error { _, err := NewBundlerBuilder().WithRetry(-1).InstallCommand(); return err }, errorsx.ErrInvalidValue},
+		{"Frozen platforms", func() error {
+			_, err := NewBundlerBuilder().WithFrozen(true).WithPlatform("x86_64-linux").LockPlatformsCommand()
+			return err
+		}, errorsx.ErrConflictingOptions},
+		{"Missing platforms", func() error { _, err := NewBundlerBuilder().LockPlatformsCommand(); return err }, errorsx.ErrMissingField},
+		{"Missing exec command", func() error { _, err := NewBundlerBuilder().ExecCommand(); return err }, errorsx.ErrMissingField},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.kind), "expected %v, got %v", tt.kind, err)
+		})
+	}
+}
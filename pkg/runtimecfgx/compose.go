@@ -0,0 +1,94 @@
+package runtimecfgx
+
+import (
+	"fmt"
+)
+
+// ComposeFile is a docker-compose file.
+type ComposeFile struct {
+	// Services holds the services by name.
+	Services map[string]ComposeService `yaml:"services"`
+}
+
+// ComposeService is a docker-compose service block.
+type ComposeService struct {
+	// Image is the image of the service.
+	Image string `yaml:"image"`
+	// Entrypoint overrides the image entrypoint.
+	Entrypoint []string `yaml:"entrypoint,omitempty"`
+	// Command overrides the image command.
+	Command []string `yaml:"command,omitempty"`
+	// Ports publishes the ports on the host, as "host:container/protocol".
+	Ports []string `yaml:"ports,omitempty"`
+	// Environment holds the environment variables by name.
+	Environment map[string]string `yaml:"environment,omitempty"`
+	// Labels holds the labels of the service.
+	Labels map[string]string `yaml:"labels,omitempty"`
+	// Healthcheck checks the first health endpoint of the ports.
+	Healthcheck *ComposeHealthcheck `yaml:"healthcheck,omitempty"`
+}
+
+// ComposeHealthcheck is the healthcheck of a docker-compose service.
+type ComposeHealthcheck struct {
+	// Test is the healthcheck command.
+	Test []string `yaml:"test"`
+	// Interval is the time between checks.
+	Interval string `yaml:"interval"`
+	// Timeout is the time after which a check fails.
+	Timeout string `yaml:"timeout"`
+	// Retries is the number of failed checks after which the service is unhealthy.
+	Retries int `yaml:"retries"`
+}
+
+// ComposeService returns the docker-compose service block of the workload. Every port is
+// published on the same host port. The healthcheck, set from the first port with a health
+// endpoint, runs wget in the container, which the image must provide.
+//
+// Returns:
+//   - The service block.
+//   - An error if the workload is invalid.
+func (w *Workload) ComposeService() (ComposeService, error) {
+	if err := w.Validate(); err != nil {
+		return ComposeService{}, err
+	}
+
+	svc := ComposeService{
+		Image:      w.Image,
+		Entrypoint: w.Command,
+		Command:    w.Args,
+		Labels:     w.labels(),
+	}
+
+	for i := range w.Ports {
+		p := w.Ports[i]
+		svc.Ports = append(svc.Ports, fmt.Sprintf("%d:%s", p.Number, p.String()))
+
+		if svc.Healthcheck == nil && p.Health != nil {
+			svc.Healthcheck = &ComposeHealthcheck{
+				Test:     []string{"CMD", "wget", "-q", "--spider", p.HealthURL("localhost")},
+				Interval: "5s",
+				Timeout:  "3s",
+				Retries:  12,
+			}
+		}
+	}
+
+	if len(w.Env) > 0 {
+		svc.Environment = make(map[string]string, len(w.Env))
+		for _, e := range w.Env {
+			svc.Environment[e.Name] = e.Value
+		}
+	}
+
+	return svc, nil
+}
+
+// ComposeYAML returns the docker-compose file holding the service block of the workload.
+func (w *Workload) ComposeYAML() ([]byte, error) {
+	svc, err := w.ComposeService()
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeYAML(ComposeFile{Services: map[string]ComposeService{w.Name: svc}})
+}
@@ -0,0 +1,36 @@
+package runtimecfgx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComposeService(t *testing.T) {
+	w := newTestWorkload()
+	w.Labels = map[string]string{"team": "platform"}
+
+	svc, err := w.ComposeService()
+	require.NoError(t, err)
+
+	assert.Equal(t, "registry.example.com/api:1.4.0", svc.Image)
+	assert.Equal(t, []string{"8080:8080/tcp", "5353:5353/udp"}, svc.Ports)
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "debug", "MODE": "smoke"}, svc.Environment)
+	assert.Equal(t, []string{"serve"}, svc.Command)
+	assert.Equal(t, map[string]string{"team": "platform"}, svc.Labels)
+	require.NotNil(t, svc.Healthcheck)
+	assert.Equal(t, []string{"CMD", "wget", "-q", "--spider", "http://localhost:8080/healthz"}, svc.Healthcheck.Test)
+
+	w.Image = ""
+	_, err = w.ComposeService()
+	require.Error(t, err)
+}
+
+func TestComposeYAML(t *testing.T) {
+	w := &Workload{Name: "api", Image: "api:dev", Env: nil}
+
+	out, err := w.ComposeYAML()
+	require.NoError(t, err)
+	assert.Equal(t, "services:\n  api:\n    image: api:dev\n", string(out))
+}
@@ -0,0 +1,279 @@
+package runtimecfgx
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/namingx"
+	"github.com/Excoriate/daggerx/pkg/portx"
+)
+
+const (
+	// LabelName is the Kubernetes label holding the name of the workload.
+	LabelName = "app.kubernetes.io/name"
+	// LabelManagedBy is the Kubernetes label holding the generator of the resources.
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+	// ManagedBy is the value of LabelManagedBy.
+	ManagedBy = "daggerx"
+)
+
+// K8sMetadata is the metadata of a Kubernetes object.
+type K8sMetadata struct {
+	Name   string            `yaml:"name,omitempty"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+// K8sDeployment is a minimal apps/v1 Deployment.
+type K8sDeployment struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   K8sMetadata       `yaml:"metadata"`
+	Spec       K8sDeploymentSpec `yaml:"spec"`
+}
+
+// K8sDeploymentSpec is the spec of a K8sDeployment.
+type K8sDeploymentSpec struct {
+	Replicas int            `yaml:"replicas"`
+	Selector K8sSelector    `yaml:"selector"`
+	Template K8sPodTemplate `yaml:"template"`
+}
+
+// K8sSelector is a label selector.
+type K8sSelector struct {
+	MatchLabels map[string]string `yaml:"matchLabels"`
+}
+
+// K8sPodTemplate is the pod template of a K8sDeployment.
+type K8sPodTemplate struct {
+	Metadata K8sMetadata `yaml:"metadata"`
+	Spec     K8sPodSpec  `yaml:"spec"`
+}
+
+// K8sPodSpec is the spec of a pod.
+type K8sPodSpec struct {
+	Containers []K8sContainer `yaml:"containers"`
+}
+
+// K8sContainer is a container of a pod.
+type K8sContainer struct {
+	Name           string             `yaml:"name"`
+	Image          string             `yaml:"image"`
+	Command        []string           `yaml:"command,omitempty"`
+	Args           []string           `yaml:"args,omitempty"`
+	Ports          []K8sContainerPort `yaml:"ports,omitempty"`
+	Env            []K8sEnvVar        `yaml:"env,omitempty"`
+	ReadinessProbe *K8sProbe          `yaml:"readinessProbe,omitempty"`
+}
+
+// K8sContainerPort is a port of a container.
+type K8sContainerPort struct {
+	Name          string `yaml:"name"`
+	ContainerPort int    `yaml:"containerPort"`
+	Protocol      string `yaml:"protocol"`
+}
+
+// K8sEnvVar is an environment variable of a container.
+type K8sEnvVar struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+// K8sProbe is an HTTP probe of a container.
+type K8sProbe struct {
+	HTTPGet          K8sHTTPGetAction `yaml:"httpGet"`
+	PeriodSeconds    int              `yaml:"periodSeconds"`
+	FailureThreshold int              `yaml:"failureThreshold"`
+}
+
+// K8sHTTPGetAction is the HTTP request of a probe.
+type K8sHTTPGetAction struct {
+	Path   string `yaml:"path"`
+	Port   string `yaml:"port"`
+	Scheme string `yaml:"scheme"`
+}
+
+// K8sService is a minimal v1 Service.
+type K8sService struct {
+	APIVersion string         `yaml:"apiVersion"`
+	Kind       string         `yaml:"kind"`
+	Metadata   K8sMetadata    `yaml:"metadata"`
+	Spec       K8sServiceSpec `yaml:"spec"`
+}
+
+// K8sServiceSpec is the spec of a K8sService.
+type K8sServiceSpec struct {
+	Selector map[string]string `yaml:"selector"`
+	Ports    []K8sServicePort  `yaml:"ports"`
+}
+
+// K8sServicePort is a port of a K8sService.
+type K8sServicePort struct {
+	Name       string `yaml:"name"`
+	Port       int    `yaml:"port"`
+	TargetPort string `yaml:"targetPort"`
+	Protocol   string `yaml:"protocol"`
+}
+
+// KubernetesName returns the name of the Kubernetes resources of the workload, a DNS label.
+func (w *Workload) KubernetesName() (string, error) {
+	return namingx.Format(namingx.KubernetesName, w.Name)
+}
+
+// Deployment returns the Kubernetes Deployment running the workload. Its single container exposes
+// every port, named after its protocol and number, and is ready once the first health endpoint
+// of the ports answers.
+//
+// Returns:
+//   - The Deployment.
+//   - An error if the workload is invalid or its name has no valid DNS label character.
+func (w *Workload) Deployment() (K8sDeployment, error) {
+	if err := w.Validate(); err != nil {
+		return K8sDeployment{}, err
+	}
+
+	name, err := w.KubernetesName()
+	if err != nil {
+		return K8sDeployment{}, err
+	}
+
+	replicas := w.Replicas
+	if replicas == 0 {
+		replicas = 1
+	}
+
+	ctr := K8sContainer{Name: name, Image: w.Image, Command: w.Command, Args: w.Args}
+	for i := range w.Ports {
+		p := w.Ports[i]
+		ctr.Ports = append(ctr.Ports, K8sContainerPort{
+			Name: portName(p), ContainerPort: p.Number, Protocol: k8sProtocol(p),
+		})
+
+		if ctr.ReadinessProbe == nil && p.Health != nil {
+			ctr.ReadinessProbe = &K8sProbe{
+				HTTPGet: K8sHTTPGetAction{
+					Path: p.Health.Path, Port: portName(p), Scheme: strings.ToUpper(healthScheme(p.Health)),
+				},
+				PeriodSeconds:    5,
+				FailureThreshold: 12,
+			}
+		}
+	}
+
+	for _, e := range w.Env {
+		ctr.Env = append(ctr.Env, K8sEnvVar{Name: e.Name, Value: e.Value})
+	}
+
+	labels := w.k8sLabels()
+
+	return K8sDeployment{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Metadata:   K8sMetadata{Name: name, Labels: labels},
+		Spec: K8sDeploymentSpec{
+			Replicas: replicas,
+			Selector: K8sSelector{MatchLabels: map[string]string{LabelName: labels[LabelName]}},
+			Template: K8sPodTemplate{
+				Metadata: K8sMetadata{Labels: labels},
+				Spec:     K8sPodSpec{Containers: []K8sContainer{ctr}},
+			},
+		},
+	}, nil
+}
+
+// Service returns the Kubernetes Service exposing every port of the workload Deployment.
+//
+// Returns:
+//   - The Service.
+//   - An error if the workload is invalid, its name has no valid DNS label character, or it has no
+//     port.
+func (w *Workload) Service() (K8sService, error) {
+	if err := w.Validate(); err != nil {
+		return K8sService{}, err
+	}
+
+	if len(w.Ports) == 0 {
+		return K8sService{}, errorsx.MissingField(builderName, "ports", "workload %s exposes no port", w.Name)
+	}
+
+	name, err := w.KubernetesName()
+	if err != nil {
+		return K8sService{}, err
+	}
+
+	labels := w.k8sLabels()
+	svc := K8sService{
+		APIVersion: "v1",
+		Kind:       "Service",
+		Metadata:   K8sMetadata{Name: name, Labels: labels},
+		Spec:       K8sServiceSpec{Selector: map[string]string{LabelName: labels[LabelName]}},
+	}
+
+	for i := range w.Ports {
+		p := w.Ports[i]
+		svc.Spec.Ports = append(svc.Spec.Ports, K8sServicePort{
+			Name: portName(p), Port: p.Number, TargetPort: portName(p), Protocol: k8sProtocol(p),
+		})
+	}
+
+	return svc, nil
+}
+
+// KubernetesYAML returns the Kubernetes manifests of the workload: its Deployment, followed by
+// its Service when it has ports.
+func (w *Workload) KubernetesYAML() ([]byte, error) {
+	deployment, err := w.Deployment()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(w.Ports) == 0 {
+		return encodeYAML(deployment)
+	}
+
+	svc, err := w.Service()
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeYAML(deployment, svc)
+}
+
+// k8sLabels returns the labels of the Kubernetes resources: the extra labels of the workload,
+// its name and the generator.
+func (w *Workload) k8sLabels() map[string]string {
+	labels := w.labels()
+	labels[LabelName] = namingx.MustFormat(namingx.KubernetesLabel, w.Name)
+	labels[LabelManagedBy] = ManagedBy
+
+	return labels
+}
+
+// portName returns the name of the port 'p' (e.g. "tcp-8080"), at most 15 characters as
+// Kubernetes requires.
+func portName(p portx.Port) string {
+	proto := p.Protocol
+	if proto == "" {
+		proto = portx.ProtocolTCP
+	}
+
+	return fmt.Sprintf("%s-%d", proto, p.Number)
+}
+
+// k8sProtocol returns the Kubernetes protocol of the port 'p'.
+func k8sProtocol(p portx.Port) string {
+	if p.Protocol == portx.ProtocolUDP {
+		return "UDP"
+	}
+
+	return "TCP"
+}
+
+// healthScheme returns the URL scheme of the health endpoint 'h'.
+func healthScheme(h *portx.HealthEndpoint) string {
+	if h.Scheme == "" {
+		return "http"
+	}
+
+	return h.Scheme
+}
@@ -0,0 +1,91 @@
+package runtimecfgx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/portx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDeployment(t *testing.T) {
+	d, err := newTestWorkload().Deployment()
+	require.NoError(t, err)
+
+	assert.Equal(t, "api-server", d.Metadata.Name)
+	assert.Equal(t, 1, d.Spec.Replicas)
+	assert.Equal(t, map[string]string{LabelName: "API-Server"}, d.Spec.Selector.MatchLabels)
+	assert.Equal(t, ManagedBy, d.Spec.Template.Metadata.Labels[LabelManagedBy])
+
+	require.Len(t, d.Spec.Template.Spec.Containers, 1)
+	ctr := d.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, []K8sContainerPort{
+		{Name: "tcp-8080", ContainerPort: 8080, Protocol: "TCP"},
+		{Name: "udp-5353", ContainerPort: 5353, Protocol: "UDP"},
+	}, ctr.Ports)
+	assert.Equal(t, []K8sEnvVar{{Name: "LOG_LEVEL", Value: "debug"}, {Name: "MODE", Value: "smoke"}}, ctr.Env)
+	require.NotNil(t, ctr.ReadinessProbe)
+	assert.Equal(t, K8sHTTPGetAction{Path: "/healthz", Port: "tcp-8080", Scheme: "HTTP"}, ctr.ReadinessProbe.HTTPGet)
+}
+
+func TestService(t *testing.T) {
+	svc, err := newTestWorkload().Service()
+	require.NoError(t, err)
+
+	assert.Equal(t, "Service", svc.Kind)
+	assert.Equal(t, map[string]string{LabelName: "API-Server"}, svc.Spec.Selector)
+	assert.Equal(t, K8sServicePort{Name: "udp-5353", Port: 5353, TargetPort: "udp-5353", Protocol: "UDP"},
+		svc.Spec.Ports[1])
+
+	_, err = (&Workload{Name: "worker", Image: "worker:dev"}).Service()
+	require.ErrorIs(t, err, errorsx.ErrMissingField)
+}
+
+func TestKubernetesYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		workload *Workload
+		wantDocs []string
+		wantErr  bool
+	}{
+		{name: "deployment and service", workload: newTestWorkload(), wantDocs: []string{"Deployment", "Service"}},
+		{
+			name:     "deployment without ports",
+			workload: &Workload{Name: "worker", Image: "worker:dev", Replicas: 2},
+			wantDocs: []string{"Deployment"},
+		},
+		{
+			name: "https probe",
+			workload: &Workload{Name: "web", Image: "web:dev", Ports: []portx.Port{
+				{Number: 8443, Health: &portx.HealthEndpoint{Path: "/ready", Scheme: "https"}},
+			}},
+			wantDocs: []string{"Deployment", "Service"},
+		},
+		{name: "invalid name", workload: &Workload{Name: "///", Image: "web:dev"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := tt.workload.KubernetesYAML()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			dec := yaml.NewDecoder(strings.NewReader(string(out)))
+			var kinds []string
+			for {
+				var doc map[string]any
+				if err := dec.Decode(&doc); err != nil {
+					break
+				}
+				kinds = append(kinds, doc["kind"].(string))
+			}
+			assert.Equal(t, tt.wantDocs, kinds)
+		})
+	}
+}
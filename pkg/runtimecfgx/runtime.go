@@ -0,0 +1,164 @@
+// Package runtimecfgx generates container runtime configurations from the descriptors of built
+// images: a docker-compose service or a minimal Kubernetes Deployment and Service, so later
+// pipeline stages can smoke-test an image on a real runtime with the ports, environment and
+// health endpoints declared while building it.
+//
+// A Workload gathers the image, the ports (see portx) and the environment of the container. It
+// can be created from a base image description (containerx.NewBaseContainerOpts) or from a
+// service specification (servicex.ServiceSpec).
+//
+// Example usage:
+//
+//	workload, err := runtimecfgx.FromBaseImage("api", &containerx.NewBaseContainerOpts{
+//	    Image:   "registry.example.com/api",
+//	    Version: "1.4.0",
+//	})
+//	if err != nil {
+//	    // handle error
+//	}
+//	workload.Ports = []portx.Port{{Number: 8080, Health: &portx.HealthEndpoint{Path: "/healthz"}}}
+//	workload.Env = []types.DaggerEnvVars{{Name: "LOG_LEVEL", Value: "debug"}}
+//
+//	compose, err := workload.ComposeYAML()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	manifests, err := workload.KubernetesYAML()
+//	if err != nil {
+//	    // handle error
+//	}
+package runtimecfgx
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/Excoriate/daggerx/pkg/containerx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/portx"
+	"github.com/Excoriate/daggerx/pkg/servicex"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// builderName identifies the runtime configuration generators in errorsx errors.
+const builderName = "runtimecfgx"
+
+// Workload describes a container to run from a built image.
+type Workload struct {
+	// Name is the name of the compose service and Kubernetes resources.
+	Name string
+	// Image is the image reference of the container.
+	Image string
+	// Ports are the ports exposed by the container.
+	Ports []portx.Port
+	// Env holds the environment variables of the container.
+	Env []types.DaggerEnvVars
+	// Command overrides the image entrypoint when not empty.
+	Command []string
+	// Args overrides the image command when not empty.
+	Args []string
+	// Replicas is the number of Kubernetes replicas. Defaults to 1.
+	Replicas int
+	// Labels holds extra labels of the compose service and Kubernetes resources.
+	Labels map[string]string
+}
+
+// FromBaseImage creates the workload 'name' running the image of the base image description
+// 'opts', resolved with containerx.GetImageURL.
+//
+// Returns:
+//   - The workload, without ports nor environment.
+//   - An error if the base image description is nil.
+func FromBaseImage(name string, opts *containerx.NewBaseContainerOpts) (*Workload, error) {
+	image, err := containerx.GetImageURL(opts)
+	if err != nil {
+		return nil, errorsx.MissingField(builderName, "image", "%v", err)
+	}
+
+	return &Workload{Name: name, Image: image}, nil
+}
+
+// FromServiceSpec creates the workload of the service specification 'spec': its TCP ports,
+// environment and arguments.
+func FromServiceSpec(spec *servicex.ServiceSpec) *Workload {
+	if spec == nil {
+		return &Workload{}
+	}
+
+	w := &Workload{
+		Name:  spec.Name,
+		Image: spec.Image,
+		Env:   append([]types.DaggerEnvVars(nil), spec.Env...),
+		Args:  append([]string(nil), spec.Args...),
+	}
+
+	for _, p := range spec.Ports {
+		w.Ports = append(w.Ports, portx.Port{Number: p, Protocol: portx.ProtocolTCP})
+	}
+
+	return w
+}
+
+// Validate checks that the workload can be rendered.
+//
+// Returns:
+//   - An error if the name or image is missing, a port is invalid or declared twice, an
+//     environment variable has no name, or the number of replicas is negative.
+func (w *Workload) Validate() error {
+	if w.Name == "" {
+		return errorsx.MissingField(builderName, "name", "workload name is required")
+	}
+
+	if w.Image == "" {
+		return errorsx.MissingField(builderName, "image", "workload %s has no image", w.Name)
+	}
+
+	if err := portx.ValidatePorts(w.Ports); err != nil {
+		return err
+	}
+
+	for _, e := range w.Env {
+		if e.Name == "" {
+			return errorsx.InvalidValue(builderName, "env", e.Value,
+				"workload %s has an environment variable without name", w.Name)
+		}
+	}
+
+	if w.Replicas < 0 {
+		return errorsx.InvalidValue(builderName, "replicas", w.Replicas,
+			"workload %s has a negative number of replicas: %d", w.Name, w.Replicas)
+	}
+
+	return nil
+}
+
+// labels returns a copy of the extra labels of the workload.
+func (w *Workload) labels() map[string]string {
+	labels := make(map[string]string, len(w.Labels)+2)
+	for k, v := range w.Labels {
+		labels[k] = v
+	}
+
+	return labels
+}
+
+// encodeYAML encodes the documents 'docs' in YAML, indented by two spaces.
+func encodeYAML(docs ...any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return nil, fmt.Errorf("failed to encode runtime configuration: %w", err)
+		}
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode runtime configuration: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
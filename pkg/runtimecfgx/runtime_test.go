@@ -0,0 +1,94 @@
+package runtimecfgx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/containerx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/portx"
+	"github.com/Excoriate/daggerx/pkg/servicex"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWorkload() *Workload {
+	return &Workload{
+		Name:  "API Server",
+		Image: "registry.example.com/api:1.4.0",
+		Ports: []portx.Port{
+			{Number: 8080, Health: &portx.HealthEndpoint{Path: "/healthz"}},
+			{Number: 5353, Protocol: portx.ProtocolUDP},
+		},
+		Env:  []types.DaggerEnvVars{{Name: "LOG_LEVEL", Value: "debug"}, {Name: "MODE", Value: "smoke"}},
+		Args: []string{"serve"},
+	}
+}
+
+func TestFromBaseImage(t *testing.T) {
+	w, err := FromBaseImage("api", &containerx.NewBaseContainerOpts{FallbackImage: "alpine", FallBackVersion: "3.20"})
+	require.NoError(t, err)
+	assert.Equal(t, "alpine:3.20", w.Image)
+	assert.Equal(t, "api", w.Name)
+
+	_, err = FromBaseImage("api", nil)
+	require.ErrorIs(t, err, errorsx.ErrMissingField)
+}
+
+func TestFromServiceSpec(t *testing.T) {
+	spec := servicex.NewRegistryService(servicex.RegistryOpts{})
+	w := FromServiceSpec(&spec)
+
+	assert.Equal(t, spec.Name, w.Name)
+	assert.Equal(t, spec.Image, w.Image)
+	require.Len(t, w.Ports, len(spec.Ports))
+	assert.Equal(t, portx.ProtocolTCP, w.Ports[0].Protocol)
+	require.NoError(t, w.Validate())
+
+	assert.Equal(t, &Workload{}, FromServiceSpec(nil))
+}
+
+func TestWorkloadValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(w *Workload)
+		wantErr bool
+		wantIs  error
+	}{
+		{name: "valid", mutate: func(*Workload) {}},
+		{name: "missing name", mutate: func(w *Workload) { w.Name = "" }, wantErr: true, wantIs: errorsx.ErrMissingField},
+		{name: "missing image", mutate: func(w *Workload) { w.Image = "" }, wantErr: true, wantIs: errorsx.ErrMissingField},
+		{
+			name:    "invalid port",
+			mutate:  func(w *Workload) { w.Ports[0].Number = 70000 },
+			wantErr: true,
+		},
+		{
+			name:    "unnamed environment variable",
+			mutate:  func(w *Workload) { w.Env[0].Name = "" },
+			wantErr: true, wantIs: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "negative replicas",
+			mutate:  func(w *Workload) { w.Replicas = -1 },
+			wantErr: true, wantIs: errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorkload()
+			tt.mutate(w)
+
+			err := w.Validate()
+			if !tt.wantErr {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			if tt.wantIs != nil {
+				require.ErrorIs(t, err, tt.wantIs)
+			}
+		})
+	}
+}
@@ -0,0 +1,431 @@
+// Package s3x provides a builder for the `aws s3 cp` and `aws s3 sync` commands exporting
+// artifacts to Amazon S3 or an S3 compatible object storage, with the server-side encryption,
+// cache-control and storage options set consistently, and the AWS credentials described as
+// secrets (see secretsx) rather than passed on the command line.
+//
+// Example usage:
+//
+//	upload := s3x.NewSyncBuilder("/mnt/dist", "s3://acme-artifacts/checkout/v1.2.0").
+//	    WithSSE(s3x.SSEKMS).
+//	    WithSSEKMSKeyID("alias/artifacts").
+//	    WithCacheControl("public, max-age=31536000, immutable").
+//	    WithCredentials(
+//	        secretsx.FromEnv("aws-access-key-id", "CI_AWS_ACCESS_KEY_ID"),
+//	        secretsx.FromEnv("aws-secret-access-key", "CI_AWS_SECRET_ACCESS_KEY"),
+//	    )
+//
+//	cmd, err := upload.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	res, err := executor.Run(ctx, cmd, upload.Env(), nil)
+package s3x
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the S3 builder in errorsx errors.
+const builderName = "s3"
+
+// AWSCLIDefaultImage is the default container image providing the AWS CLI.
+const AWSCLIDefaultImage = "amazon/aws-cli:2.22.0"
+
+const (
+	// AccessKeyIDEnvVar is the environment variable the AWS CLI reads the access key ID from.
+	AccessKeyIDEnvVar = "AWS_ACCESS_KEY_ID"
+	// SecretAccessKeyEnvVar is the environment variable the AWS CLI reads the secret access key
+	// from.
+	SecretAccessKeyEnvVar = "AWS_SECRET_ACCESS_KEY"
+	// SessionTokenEnvVar is the environment variable the AWS CLI reads the session token of
+	// temporary credentials from.
+	SessionTokenEnvVar = "AWS_SESSION_TOKEN"
+)
+
+// Operation is an aws s3 transfer command.
+type Operation string
+
+const (
+	// OperationCopy copies a file, or a directory with WithRecursive (aws s3 cp).
+	OperationCopy Operation = "cp"
+	// OperationSync synchronizes a directory, transferring the new and updated files only
+	// (aws s3 sync).
+	OperationSync Operation = "sync"
+)
+
+// SSE is a server-side encryption of the uploaded objects.
+type SSE string
+
+const (
+	// SSEAES256 encrypts the objects with the S3 managed keys (SSE-S3).
+	SSEAES256 SSE = "AES256"
+	// SSEKMS encrypts the objects with an AWS KMS key (SSE-KMS).
+	SSEKMS SSE = "aws:kms"
+)
+
+// ACLs are the canned ACLs of the uploaded objects.
+var ACLs = []string{
+	"private", "public-read", "public-read-write", "authenticated-read", "aws-exec-read",
+	"bucket-owner-read", "bucket-owner-full-control", "log-delivery-write",
+}
+
+// StorageClasses are the storage classes of the uploaded objects.
+var StorageClasses = []string{
+	"STANDARD", "REDUCED_REDUNDANCY", "STANDARD_IA", "ONEZONE_IA", "INTELLIGENT_TIERING",
+	"GLACIER", "DEEP_ARCHIVE", "GLACIER_IR",
+}
+
+// s3URIRegex matches S3 URIs: the bucket, and an optional key prefix.
+var s3URIRegex = regexp.MustCompile(`^s3://[a-z0-9][a-z0-9.-]{1,61}[a-z0-9](/.*)?$`)
+
+// UploadBuilder generates the aws s3 cp or sync command uploading artifacts to S3.
+type UploadBuilder struct {
+	// operation is the transfer command.
+	operation Operation
+
+	// source is the local file or directory.
+	source string
+
+	// destination is the S3 URI.
+	destination string
+
+	// recursive copies a directory.
+	recursive bool
+
+	// delete removes the destination objects missing from the source.
+	delete bool
+
+	// excludes are the patterns of the excluded files.
+	excludes []string
+
+	// includes are the patterns of the files included back after the excludes.
+	includes []string
+
+	// cacheControl is the Cache-Control header of the objects.
+	cacheControl string
+
+	// contentType is the Content-Type of the objects. Empty guesses it from the file extensions.
+	contentType string
+
+	// acl is the canned ACL of the objects.
+	acl string
+
+	// storageClass is the storage class of the objects.
+	storageClass string
+
+	// sse is the server-side encryption of the objects.
+	sse SSE
+
+	// sseKMSKeyID is the AWS KMS key of SSE-KMS.
+	sseKMSKeyID string
+
+	// region is the region of the bucket.
+	region string
+
+	// endpointURL is the endpoint of an S3 compatible object storage.
+	endpointURL string
+
+	// dryRun shows the transfers without running them.
+	dryRun bool
+
+	// accessKeyID is the secret holding the access key ID.
+	accessKeyID *secretsx.SecretRef
+
+	// secretAccessKey is the secret holding the secret access key.
+	secretAccessKey *secretsx.SecretRef
+
+	// sessionToken is the secret holding the session token of temporary credentials.
+	sessionToken *secretsx.SecretRef
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewCopyBuilder creates an UploadBuilder copying the local file 'source' to the S3 URI
+// 'destination' (e.g. "s3://acme-artifacts/checkout/app.tar.gz").
+func NewCopyBuilder(source, destination string) *UploadBuilder {
+	return &UploadBuilder{operation: OperationCopy, source: source, destination: destination}
+}
+
+// NewSyncBuilder creates an UploadBuilder synchronizing the local directory 'source' to the S3
+// URI 'destination' (e.g. "s3://acme-artifacts/checkout/v1.2.0").
+func NewSyncBuilder(source, destination string) *UploadBuilder {
+	return &UploadBuilder{operation: OperationSync, source: source, destination: destination}
+}
+
+// WithRecursive sets whether a directory is copied. sync is always recursive.
+func (b *UploadBuilder) WithRecursive(recursive bool) *UploadBuilder {
+	b.recursive = recursive
+	return b
+}
+
+// WithDelete sets whether the destination objects missing from the source are removed (sync
+// only).
+func (b *UploadBuilder) WithDelete(del bool) *UploadBuilder {
+	b.delete = del
+	return b
+}
+
+// WithExclude adds patterns of the excluded files (e.g. "*.map").
+func (b *UploadBuilder) WithExclude(patterns ...string) *UploadBuilder {
+	b.excludes = append(b.excludes, patterns...)
+	return b
+}
+
+// WithInclude adds patterns of the files included back after the excludes.
+func (b *UploadBuilder) WithInclude(patterns ...string) *UploadBuilder {
+	b.includes = append(b.includes, patterns...)
+	return b
+}
+
+// WithCacheControl sets the Cache-Control header of the objects (e.g. "public, max-age=300").
+func (b *UploadBuilder) WithCacheControl(cacheControl string) *UploadBuilder {
+	b.cacheControl = cacheControl
+	return b
+}
+
+// WithContentType sets the Content-Type of the objects. The AWS CLI otherwise guesses it from
+// the file extensions.
+func (b *UploadBuilder) WithContentType(contentType string) *UploadBuilder {
+	b.contentType = contentType
+	return b
+}
+
+// WithACL sets the canned ACL of the objects, one of ACLs.
+func (b *UploadBuilder) WithACL(acl string) *UploadBuilder {
+	b.acl = acl
+	return b
+}
+
+// WithStorageClass sets the storage class of the objects, one of StorageClasses.
+func (b *UploadBuilder) WithStorageClass(class string) *UploadBuilder {
+	b.storageClass = class
+	return b
+}
+
+// WithSSE sets the server-side encryption of the objects.
+func (b *UploadBuilder) WithSSE(sse SSE) *UploadBuilder {
+	b.sse = sse
+	return b
+}
+
+// WithSSEKMSKeyID sets the AWS KMS key of SSE-KMS: a key ID, ARN or alias. The bucket default
+// key is used otherwise.
+func (b *UploadBuilder) WithSSEKMSKeyID(keyID string) *UploadBuilder {
+	b.sseKMSKeyID = keyID
+	return b
+}
+
+// WithRegion sets the region of the bucket.
+func (b *UploadBuilder) WithRegion(region string) *UploadBuilder {
+	b.region = region
+	return b
+}
+
+// WithEndpointURL sets the endpoint of an S3 compatible object storage (e.g. MinIO, R2).
+func (b *UploadBuilder) WithEndpointURL(endpointURL string) *UploadBuilder {
+	b.endpointURL = endpointURL
+	return b
+}
+
+// WithDryRun sets whether the transfers are only shown.
+func (b *UploadBuilder) WithDryRun(dryRun bool) *UploadBuilder {
+	b.dryRun = dryRun
+	return b
+}
+
+// WithCredentials sets the secrets holding the access key ID and secret access key. They are
+// exposed as AccessKeyIDEnvVar and SecretAccessKeyEnvVar; the refs are left unchanged. Without
+// credentials, the AWS CLI uses its default chain (e.g. an instance or web identity role).
+func (b *UploadBuilder) WithCredentials(accessKeyID, secretAccessKey *secretsx.SecretRef) *UploadBuilder {
+	b.accessKeyID = asEnv(accessKeyID, AccessKeyIDEnvVar)
+	b.secretAccessKey = asEnv(secretAccessKey, SecretAccessKeyEnvVar)
+	return b
+}
+
+// WithSessionToken sets the secret holding the session token of temporary credentials. It is
+// exposed as SessionTokenEnvVar; 'ref' is left unchanged.
+func (b *UploadBuilder) WithSessionToken(ref *secretsx.SecretRef) *UploadBuilder {
+	b.sessionToken = asEnv(ref, SessionTokenEnvVar)
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *UploadBuilder) WithLogger(logger *slog.Logger) *UploadBuilder {
+	b.logger = logger
+	return b
+}
+
+// asEnv returns a copy of 'ref' exposed as the environment variable 'envVar', or nil.
+func asEnv(ref *secretsx.SecretRef, envVar string) *secretsx.SecretRef {
+	if ref == nil {
+		return nil
+	}
+
+	secret := *ref
+
+	return secret.AsEnv(envVar)
+}
+
+// validate checks the options of the upload.
+func (b *UploadBuilder) validate() error {
+	if b.source == "" {
+		return errorsx.MissingField(builderName, "source", "source path is required")
+	}
+
+	if !s3URIRegex.MatchString(b.destination) {
+		return errorsx.InvalidValue(builderName, "destination", b.destination,
+			"destination must be an S3 URI (s3://bucket/prefix), got %q", b.destination)
+	}
+
+	if b.operation == OperationSync && b.recursive {
+		return errorsx.ConflictingOptions(builderName, []string{"operation", "recursive"}, "sync is always recursive")
+	}
+
+	if b.operation == OperationCopy && b.delete {
+		return errorsx.ConflictingOptions(builderName, []string{"operation", "delete"}, "only sync removes objects")
+	}
+
+	if strings.ContainsAny(b.cacheControl, "\r\n") {
+		return errorsx.InvalidValue(builderName, "cacheControl", b.cacheControl, "invalid Cache-Control header")
+	}
+
+	if b.acl != "" && !slices.Contains(ACLs, b.acl) {
+		return errorsx.InvalidValue(builderName, "acl", b.acl, "unsupported canned ACL: %q", b.acl)
+	}
+
+	if b.storageClass != "" && !slices.Contains(StorageClasses, b.storageClass) {
+		return errorsx.InvalidValue(builderName, "storageClass", b.storageClass,
+			"unsupported storage class: %q", b.storageClass)
+	}
+
+	switch b.sse {
+	case "", SSEAES256, SSEKMS:
+	default:
+		return errorsx.Unsupported(builderName, "sse", b.sse, "unsupported server-side encryption: %q", b.sse)
+	}
+
+	if b.sseKMSKeyID != "" && b.sse != SSEKMS {
+		return errorsx.ConflictingOptions(builderName, []string{"sse", "sseKMSKeyID"}, "a KMS key requires SSE-KMS")
+	}
+
+	if (b.accessKeyID == nil) != (b.secretAccessKey == nil) {
+		return errorsx.MissingField(builderName, "credentials",
+			"the access key ID and secret access key are set together")
+	}
+
+	if b.sessionToken != nil && b.accessKeyID == nil {
+		return errorsx.MissingField(builderName, "credentials", "a session token requires the access keys")
+	}
+
+	for _, s := range b.Secrets() {
+		if err := s.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BuildCommand generates the aws s3 cp or sync command.
+//
+// Returns:
+//   - The upload command.
+//   - An error if the source is missing, the destination is not an S3 URI, recursive is set on
+//     sync or delete on cp, the ACL, storage class or encryption is unsupported, a KMS key is set
+//     without SSE-KMS, or the credentials are incomplete or invalid.
+func (b *UploadBuilder) BuildCommand() ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"aws", "s3", string(b.operation), b.source, b.destination, "--no-progress"}
+	if b.recursive {
+		cmd = append(cmd, "--recursive")
+	}
+
+	if b.delete {
+		cmd = append(cmd, "--delete")
+	}
+
+	for _, p := range b.excludes {
+		cmd = append(cmd, "--exclude", p)
+	}
+
+	for _, p := range b.includes {
+		cmd = append(cmd, "--include", p)
+	}
+
+	for _, o := range []struct{ flag, value string }{
+		{"--cache-control", b.cacheControl},
+		{"--content-type", b.contentType},
+		{"--acl", b.acl},
+		{"--storage-class", b.storageClass},
+		{"--sse", string(b.sse)},
+		{"--sse-kms-key-id", b.sseKMSKeyID},
+		{"--region", b.region},
+		{"--endpoint-url", b.endpointURL},
+	} {
+		if o.value != "" {
+			cmd = append(cmd, o.flag, o.value)
+		}
+	}
+
+	if b.dryRun {
+		cmd = append(cmd, "--dryrun")
+	}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Env returns the environment variables of the upload: the pager is disabled. The credentials
+// are secrets (see Secrets).
+func (b *UploadBuilder) Env() []types.DaggerEnvVars {
+	return []types.DaggerEnvVars{{Name: "AWS_PAGER", Value: ""}}
+}
+
+// Secrets returns the secrets the upload requires: the access keys and session token, if any.
+func (b *UploadBuilder) Secrets() []*secretsx.SecretRef {
+	var secrets []*secretsx.SecretRef
+	for _, s := range []*secretsx.SecretRef{b.accessKeyID, b.secretAccessKey, b.sessionToken} {
+		if s != nil {
+			secrets = append(secrets, s)
+		}
+	}
+
+	return secrets
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *UploadBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *UploadBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the aws s3 command.
+func (b *UploadBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewCopyBuilder("", ""), explainx.Generate("BuildCommand", b.BuildCommand))
+}
@@ -0,0 +1,151 @@
+package s3x
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKeys() (*secretsx.SecretRef, *secretsx.SecretRef) {
+	return secretsx.FromEnv("aws-access-key-id", "CI_AWS_ACCESS_KEY_ID"),
+		secretsx.FromEnv("aws-secret-access-key", "CI_AWS_SECRET_ACCESS_KEY")
+}
+
+func TestUploadBuilderBuildCommand(t *testing.T) {
+	id, secret := testKeys()
+
+	tests := []struct {
+		name    string
+		builder *UploadBuilder
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "copy",
+			builder: NewCopyBuilder("/mnt/app.tar.gz", "s3://acme-artifacts/checkout/app.tar.gz"),
+			want:    []string{"aws", "s3", "cp", "/mnt/app.tar.gz", "s3://acme-artifacts/checkout/app.tar.gz", "--no-progress"},
+		},
+		{
+			name: "copy directory with options",
+			builder: NewCopyBuilder("/mnt/dist", "s3://acme-artifacts/checkout").
+				WithRecursive(true).
+				WithExclude("*").
+				WithInclude("*.js").
+				WithCacheControl("public, max-age=300").
+				WithContentType("text/javascript").
+				WithACL("bucket-owner-full-control").
+				WithStorageClass("STANDARD_IA").
+				WithSSE(SSEAES256).
+				WithRegion("eu-west-1").
+				WithDryRun(true),
+			want: []string{
+				"aws", "s3", "cp", "/mnt/dist", "s3://acme-artifacts/checkout", "--no-progress", "--recursive",
+				"--exclude", "*", "--include", "*.js", "--cache-control", "public, max-age=300",
+				"--content-type", "text/javascript", "--acl", "bucket-owner-full-control",
+				"--storage-class", "STANDARD_IA", "--sse", "AES256", "--region", "eu-west-1", "--dryrun",
+			},
+		},
+		{
+			name: "sync with KMS and endpoint",
+			builder: NewSyncBuilder("/mnt/dist", "s3://acme-artifacts/checkout/v1.2.0").
+				WithDelete(true).
+				WithSSE(SSEKMS).
+				WithSSEKMSKeyID("alias/artifacts").
+				WithEndpointURL("https://minio:9000").
+				WithCredentials(id, secret),
+			want: []string{
+				"aws", "s3", "sync", "/mnt/dist", "s3://acme-artifacts/checkout/v1.2.0", "--no-progress", "--delete",
+				"--sse", "aws:kms", "--sse-kms-key-id", "alias/artifacts", "--endpoint-url", "https://minio:9000",
+			},
+		},
+		{
+			name:    "missing source",
+			builder: NewCopyBuilder("", "s3://acme-artifacts/app"),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "invalid destination",
+			builder: NewCopyBuilder("/mnt/app", "acme-artifacts/app"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "recursive sync",
+			builder: NewSyncBuilder("/mnt/dist", "s3://acme-artifacts").WithRecursive(true),
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+		{
+			name:    "delete on copy",
+			builder: NewCopyBuilder("/mnt/dist", "s3://acme-artifacts").WithDelete(true),
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+		{
+			name:    "invalid cache control",
+			builder: NewCopyBuilder("/mnt/app", "s3://acme-artifacts").WithCacheControl("a\nb"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "unsupported ACL",
+			builder: NewCopyBuilder("/mnt/app", "s3://acme-artifacts").WithACL("public"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "unsupported storage class",
+			builder: NewCopyBuilder("/mnt/app", "s3://acme-artifacts").WithStorageClass("COLD"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "unsupported encryption",
+			builder: NewCopyBuilder("/mnt/app", "s3://acme-artifacts").WithSSE("aws:kms:dsse"),
+			wantErr: errorsx.ErrUnsupported,
+		},
+		{
+			name:    "KMS key without SSE-KMS",
+			builder: NewCopyBuilder("/mnt/app", "s3://acme-artifacts").WithSSEKMSKeyID("alias/artifacts"),
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+		{
+			name:    "incomplete credentials",
+			builder: NewCopyBuilder("/mnt/app", "s3://acme-artifacts").WithCredentials(id, nil),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name: "session token without access keys",
+			builder: NewCopyBuilder("/mnt/app", "s3://acme-artifacts").
+				WithSessionToken(secretsx.FromEnv("aws-session-token", "CI_AWS_SESSION_TOKEN")),
+			wantErr: errorsx.ErrMissingField,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestUploadBuilderSecrets(t *testing.T) {
+	id, secret := testKeys()
+
+	b := NewCopyBuilder("/mnt/app", "s3://acme-artifacts").
+		WithCredentials(id, secret).
+		WithSessionToken(secretsx.FromEnv("aws-session-token", "CI_AWS_SESSION_TOKEN"))
+
+	secrets := b.Secrets()
+	require.Len(t, secrets, 3)
+	assert.Equal(t, AccessKeyIDEnvVar, secrets[0].Target)
+	assert.Equal(t, SecretAccessKeyEnvVar, secrets[1].Target)
+	assert.Equal(t, SessionTokenEnvVar, secrets[2].Target)
+	assert.Equal(t, "CI_AWS_ACCESS_KEY_ID", id.Target)
+
+	assert.Nil(t, NewCopyBuilder("/mnt/app", "s3://acme-artifacts").Secrets())
+}
@@ -0,0 +1,325 @@
+// Package sbomx merges the software bills of materials of a release into a single document:
+// the per-architecture SBOMs apko writes, and the SBOMs tools such as syft generate for the
+// other stages of the build. Registries and compliance teams expect one SBOM per image index.
+//
+// Packages are read from SPDX or CycloneDX JSON documents and deduplicated by package URL (with
+// the arch qualifier left out) or, without one, by name and version. Each merged package lists
+// the platforms and the tools it was found by; the SPDX document records them as annotations.
+//
+// Example usage:
+//
+//	doc, err := sbomx.NewMerger("registry.example.com/app:v1.2.3").
+//	    WithSBOM("linux/amd64", "apko", amd64SBOM).
+//	    WithSBOM("linux/arm64", "apko", arm64SBOM).
+//	    WithSBOM("linux/amd64", "syft", binariesSBOM).
+//	    Merge()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	spdx, err := doc.SPDX()
+package sbomx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/timex"
+)
+
+// builderName identifies the SBOM merger in errorsx errors.
+const builderName = "sbom"
+
+// Creator is the tool recorded as the creator of the merged SPDX documents.
+const Creator = "daggerx-sbomx"
+
+// Package is a package of the merged SBOM.
+type Package struct {
+	// Name is the name of the package.
+	Name string `json:"name"`
+	// Version is the version of the package.
+	Version string `json:"version,omitempty"`
+	// PURL is the package URL, without its arch qualifier.
+	PURL string `json:"purl,omitempty"`
+	// License is the declared license expression of the package.
+	License string `json:"license,omitempty"`
+	// Platforms are the platforms the package was found on, sorted.
+	Platforms []string `json:"platforms"`
+	// Tools are the tools which reported the package, sorted.
+	Tools []string `json:"tools"`
+}
+
+// key identifies the duplicates of a package across documents.
+func (p Package) key() string {
+	if p.PURL != "" {
+		return p.PURL
+	}
+
+	return p.Name + "@" + p.Version
+}
+
+// Document is a merged SBOM.
+type Document struct {
+	// Name is the name of the release the document describes (e.g. the image index reference).
+	Name string `json:"name"`
+	// Namespace is the SPDX document namespace.
+	Namespace string `json:"namespace"`
+	// Created is the creation time of the document.
+	Created time.Time `json:"created"`
+	// Platforms are the platforms of the merged documents, sorted.
+	Platforms []string `json:"platforms"`
+	// Tools are the tools which produced the merged documents, sorted.
+	Tools []string `json:"tools"`
+	// Packages are the deduplicated packages, sorted by name, version and package URL.
+	Packages []Package `json:"packages"`
+}
+
+// source is an SBOM added to a Merger.
+type source struct {
+	platform string
+	tool     string
+	data     []byte
+}
+
+// Merger merges SBOMs into a Document.
+type Merger struct {
+	// name is the name of the release.
+	name string
+
+	// namespace is the SPDX document namespace. It is derived from the name when empty.
+	namespace string
+
+	// clock provides the creation time of the document.
+	clock timex.Clock
+
+	// sources are the SBOMs to merge, in order.
+	sources []source
+}
+
+// NewMerger creates a Merger of the SBOMs of the release 'name' (e.g. the image index reference).
+func NewMerger(name string) *Merger {
+	return &Merger{name: name, clock: timex.System}
+}
+
+// WithNamespace sets the SPDX document namespace. It defaults to a URI derived from the name.
+func (m *Merger) WithNamespace(namespace string) *Merger {
+	m.namespace = namespace
+	return m
+}
+
+// WithClock sets the clock providing the creation time of the document, for reproducible
+// documents (see timex.Fixed).
+func (m *Merger) WithClock(clock timex.Clock) *Merger {
+	m.clock = clock
+	return m
+}
+
+// WithSBOM adds the SPDX or CycloneDX JSON document 'data' generated by 'tool' (e.g. "apko",
+// "syft") for 'platform' (e.g. "linux/amd64"). Invalid documents are reported by Merge.
+func (m *Merger) WithSBOM(platform, tool string, data []byte) *Merger {
+	m.sources = append(m.sources, source{platform: platform, tool: tool, data: data})
+	return m
+}
+
+// Merge parses the SBOMs and merges their packages.
+//
+// Returns:
+//   - The merged document.
+//   - An error if the name or the SBOMs are missing, a platform or tool is empty, or an SBOM is
+//     neither an SPDX nor a CycloneDX JSON document.
+func (m *Merger) Merge() (*Document, error) {
+	if m.name == "" {
+		return nil, errorsx.MissingField(builderName, "name", "release name is required")
+	}
+
+	if len(m.sources) == 0 {
+		return nil, errorsx.MissingField(builderName, "sboms", "at least one SBOM is required")
+	}
+
+	doc := &Document{
+		Name:      m.name,
+		Namespace: m.namespace,
+		Created:   timex.Normalize(m.clock.Now()),
+	}
+	if doc.Namespace == "" {
+		doc.Namespace = "https://spdx.org/spdxdocs/" + url.PathEscape(m.name)
+	}
+
+	index := map[string]int{}
+	for i, s := range m.sources {
+		if s.platform == "" || s.tool == "" {
+			return nil, errorsx.MissingField(builderName, "sboms",
+				"SBOM %d requires a platform and a tool", i)
+		}
+
+		packages, err := ParsePackages(s.data)
+		if err != nil {
+			return nil, errorsx.InvalidValue(builderName, "sboms", s.platform,
+				"invalid %s SBOM of %s: %v", s.tool, s.platform, err)
+		}
+
+		doc.Platforms = appendUnique(doc.Platforms, s.platform)
+		doc.Tools = appendUnique(doc.Tools, s.tool)
+
+		for _, p := range packages {
+			j, ok := index[p.key()]
+			if !ok {
+				j = len(doc.Packages)
+				index[p.key()] = j
+				doc.Packages = append(doc.Packages, p)
+			}
+
+			merged := &doc.Packages[j]
+			merged.Platforms = appendUnique(merged.Platforms, s.platform)
+			merged.Tools = appendUnique(merged.Tools, s.tool)
+			if merged.License == "" {
+				merged.License = p.License
+			}
+		}
+	}
+
+	slices.Sort(doc.Platforms)
+	slices.Sort(doc.Tools)
+	for i := range doc.Packages {
+		slices.Sort(doc.Packages[i].Platforms)
+		slices.Sort(doc.Packages[i].Tools)
+	}
+	slices.SortFunc(doc.Packages, func(a, b Package) int {
+		return strings.Compare(a.Name+"\x00"+a.Version+"\x00"+a.PURL, b.Name+"\x00"+b.Version+"\x00"+b.PURL)
+	})
+
+	return doc, nil
+}
+
+// appendUnique appends 'v' to 's' unless it holds it.
+func appendUnique(s []string, v string) []string {
+	if slices.Contains(s, v) {
+		return s
+	}
+
+	return append(s, v)
+}
+
+// ParsePackages returns the packages of an SPDX or CycloneDX JSON document, without platforms
+// and tools. The packages the SPDX document describes itself (the scanned image or directory)
+// are skipped, and licenses left unasserted (NOASSERTION, NONE) are empty.
+//
+// Returns:
+//   - The packages of the document.
+//   - An error if the document is neither an SPDX nor a CycloneDX JSON document.
+func ParsePackages(data []byte) ([]Package, error) {
+	var doc struct {
+		SPDXVersion       string   `json:"spdxVersion"`
+		DocumentDescribes []string `json:"documentDescribes"`
+		Packages          []struct {
+			SPDXID          string `json:"SPDXID"`
+			Name            string `json:"name"`
+			VersionInfo     string `json:"versionInfo"`
+			LicenseDeclared string `json:"licenseDeclared"`
+			ExternalRefs    []struct {
+				ReferenceType    string `json:"referenceType"`
+				ReferenceLocator string `json:"referenceLocator"`
+			} `json:"externalRefs"`
+		} `json:"packages"`
+		BOMFormat  string `json:"bomFormat"`
+		Components []struct {
+			Name     string `json:"name"`
+			Version  string `json:"version"`
+			PURL     string `json:"purl"`
+			Licenses []struct {
+				Expression string `json:"expression"`
+				License    struct {
+					ID   string `json:"id"`
+					Name string `json:"name"`
+				} `json:"license"`
+			} `json:"licenses"`
+		} `json:"components"`
+	}
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var packages []Package
+
+	switch {
+	case doc.SPDXVersion != "":
+		for _, p := range doc.Packages {
+			if slices.Contains(doc.DocumentDescribes, p.SPDXID) {
+				continue
+			}
+
+			pkg := Package{Name: p.Name, Version: p.VersionInfo, License: spdxLicense(p.LicenseDeclared)}
+			for _, ref := range p.ExternalRefs {
+				if ref.ReferenceType == "purl" {
+					pkg.PURL = stripArch(ref.ReferenceLocator)
+					break
+				}
+			}
+			packages = append(packages, pkg)
+		}
+	case doc.BOMFormat == "CycloneDX":
+		for _, c := range doc.Components {
+			pkg := Package{Name: c.Name, Version: c.Version, PURL: stripArch(c.PURL)}
+			for _, l := range c.Licenses {
+				pkg.License = firstNonEmpty(l.Expression, l.License.ID, l.License.Name)
+				if pkg.License != "" {
+					break
+				}
+			}
+			packages = append(packages, pkg)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported SBOM format")
+	}
+
+	return packages, nil
+}
+
+// spdxLicense returns the license expression 'l', or an empty string when it is not asserted.
+func spdxLicense(l string) string {
+	if l == "NOASSERTION" || l == "NONE" {
+		return ""
+	}
+
+	return l
+}
+
+// firstNonEmpty returns the first non-empty value.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// stripArch removes the arch qualifier of the package URL 'purl' (e.g.
+// "pkg:apk/wolfi/glibc@2.39-r1?arch=x86_64&distro=wolfi"), so the package of every platform
+// has the same URL. The other qualifiers keep their order.
+func stripArch(purl string) string {
+	base, query, ok := strings.Cut(purl, "?")
+	if !ok {
+		return purl
+	}
+
+	var kept []string
+	for _, q := range strings.Split(query, "&") {
+		if !strings.HasPrefix(q, "arch=") {
+			kept = append(kept, q)
+		}
+	}
+
+	if len(kept) == 0 {
+		return base
+	}
+
+	return base + "?" + strings.Join(kept, "&")
+}
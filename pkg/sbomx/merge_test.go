@@ -0,0 +1,101 @@
+package sbomx
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/timex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func apkoSBOM(arch string) []byte {
+	return []byte(`{
+  "spdxVersion": "SPDX-2.3",
+  "documentDescribes": ["SPDXRef-image"],
+  "packages": [
+    {"SPDXID": "SPDXRef-image", "name": "sha256:abc"},
+    {
+      "SPDXID": "SPDXRef-glibc", "name": "glibc", "versionInfo": "2.39-r1", "licenseDeclared": "LGPL-2.1-or-later",
+      "externalRefs": [{"referenceType": "purl", "referenceLocator": "pkg:apk/wolfi/glibc@2.39-r1?arch=` + arch + `&distro=wolfi"}]
+    },
+    {
+      "SPDXID": "SPDXRef-` + arch + `", "name": "ld-linux-` + arch + `", "versionInfo": "2.39-r1", "licenseDeclared": "NOASSERTION"
+    }
+  ]
+}`)
+}
+
+const syftSBOM = `{
+  "bomFormat": "CycloneDX",
+  "components": [
+    {"name": "github.com/acme/app", "version": "v1.2.3", "purl": "pkg:golang/github.com/acme/app@v1.2.3",
+     "licenses": [{"license": {"id": "Apache-2.0"}}]},
+    {"name": "glibc", "version": "2.39-r1", "purl": "pkg:apk/wolfi/glibc@2.39-r1?arch=x86_64&distro=wolfi"}
+  ]
+}`
+
+func TestMerge(t *testing.T) {
+	created := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	doc, err := NewMerger("registry.example.com/app:v1.2.3").
+		WithClock(timex.Fixed(created)).
+		WithSBOM("linux/arm64", "apko", apkoSBOM("aarch64")).
+		WithSBOM("linux/amd64", "apko", apkoSBOM("x86_64")).
+		WithSBOM("linux/amd64", "syft", []byte(syftSBOM)).
+		Merge()
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://spdx.org/spdxdocs/registry.example.com%2Fapp:v1.2.3", doc.Namespace)
+	assert.Equal(t, created, doc.Created)
+	assert.Equal(t, []string{"linux/amd64", "linux/arm64"}, doc.Platforms)
+	assert.Equal(t, []string{"apko", "syft"}, doc.Tools)
+	assert.Equal(t, []Package{
+		{
+			Name: "github.com/acme/app", Version: "v1.2.3", PURL: "pkg:golang/github.com/acme/app@v1.2.3",
+			License: "Apache-2.0", Platforms: []string{"linux/amd64"}, Tools: []string{"syft"},
+		},
+		{
+			Name: "glibc", Version: "2.39-r1", PURL: "pkg:apk/wolfi/glibc@2.39-r1?distro=wolfi",
+			License: "LGPL-2.1-or-later", Platforms: []string{"linux/amd64", "linux/arm64"},
+			Tools: []string{"apko", "syft"},
+		},
+		{Name: "ld-linux-aarch64", Version: "2.39-r1", Platforms: []string{"linux/arm64"}, Tools: []string{"apko"}},
+		{Name: "ld-linux-x86_64", Version: "2.39-r1", Platforms: []string{"linux/amd64"}, Tools: []string{"apko"}},
+	}, doc.Packages)
+}
+
+func TestMergeErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		merger *Merger
+		want   error
+	}{
+		{name: "Missing name", merger: NewMerger("").WithSBOM("linux/amd64", "apko", apkoSBOM("x86_64")), want: errorsx.ErrMissingField},
+		{name: "Missing SBOMs", merger: NewMerger("app"), want: errorsx.ErrMissingField},
+		{name: "Missing platform", merger: NewMerger("app").WithSBOM("", "apko", apkoSBOM("x86_64")), want: errorsx.ErrMissingField},
+		{name: "Invalid JSON", merger: NewMerger("app").WithSBOM("linux/amd64", "apko", []byte("{")), want: errorsx.ErrInvalidValue},
+		{name: "Unknown format", merger: NewMerger("app").WithSBOM("linux/amd64", "apko", []byte("{}")), want: errorsx.ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.merger.Merge()
+			assert.True(t, errors.Is(err, tt.want), "Merge() error = %v, want %v", err, tt.want)
+		})
+	}
+}
+
+func TestStripArch(t *testing.T) {
+	tests := map[string]string{
+		"pkg:apk/wolfi/glibc@2.39-r1?arch=x86_64&distro=wolfi": "pkg:apk/wolfi/glibc@2.39-r1?distro=wolfi",
+		"pkg:apk/wolfi/glibc@2.39-r1?arch=x86_64":              "pkg:apk/wolfi/glibc@2.39-r1",
+		"pkg:golang/github.com/acme/app@v1.2.3":                "pkg:golang/github.com/acme/app@v1.2.3",
+	}
+
+	for in, want := range tests {
+		assert.Equal(t, want, stripArch(in), in)
+	}
+}
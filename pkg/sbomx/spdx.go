@@ -0,0 +1,139 @@
+package sbomx
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/timex"
+)
+
+// SPDXVersion is the SPDX version of the documents written by Document.SPDX.
+const SPDXVersion = "SPDX-2.3"
+
+// rootID is the SPDX identifier of the release package the documents describe.
+const rootID = "SPDXRef-Release"
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	DocumentDescribes []string           `json:"documentDescribes"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string           `json:"SPDXID"`
+	Name             string           `json:"name"`
+	VersionInfo      string           `json:"versionInfo,omitempty"`
+	DownloadLocation string           `json:"downloadLocation"`
+	FilesAnalyzed    bool             `json:"filesAnalyzed"`
+	LicenseDeclared  string           `json:"licenseDeclared"`
+	ExternalRefs     []spdxRef        `json:"externalRefs,omitempty"`
+	Annotations      []spdxAnnotation `json:"annotations,omitempty"`
+}
+
+type spdxRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxAnnotation struct {
+	AnnotationType string `json:"annotationType"`
+	Annotator      string `json:"annotator"`
+	AnnotationDate string `json:"annotationDate"`
+	Comment        string `json:"comment"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// SPDX renders the document as an SPDX 2.3 JSON document. It describes a release package
+// containing the merged packages; the platforms and tools of each package are recorded as
+// annotations ("platforms: linux/amd64, linux/arm64" and "tools: apko, syft").
+func (d *Document) SPDX() ([]byte, error) {
+	created := timex.RFC3339(d.Created)
+	creators := []string{"Tool: " + Creator}
+	for _, t := range d.Tools {
+		creators = append(creators, "Tool: "+t)
+	}
+
+	out := spdxDocument{
+		SPDXVersion:       SPDXVersion,
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              d.Name,
+		DocumentNamespace: d.Namespace,
+		CreationInfo:      spdxCreationInfo{Created: created, Creators: creators},
+		DocumentDescribes: []string{rootID},
+		Packages: []spdxPackage{{
+			SPDXID:           rootID,
+			Name:             d.Name,
+			DownloadLocation: "NOASSERTION",
+			LicenseDeclared:  "NOASSERTION",
+			Annotations:      annotations(created, d.Platforms, d.Tools),
+		}},
+	}
+
+	for i, p := range d.Packages {
+		id := "SPDXRef-Package-" + strconv.Itoa(i+1)
+
+		pkg := spdxPackage{
+			SPDXID:           id,
+			Name:             p.Name,
+			VersionInfo:      p.Version,
+			DownloadLocation: "NOASSERTION",
+			LicenseDeclared:  "NOASSERTION",
+			Annotations:      annotations(created, p.Platforms, p.Tools),
+		}
+		if p.License != "" {
+			pkg.LicenseDeclared = p.License
+		}
+		if p.PURL != "" {
+			pkg.ExternalRefs = []spdxRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  p.PURL,
+			}}
+		}
+
+		out.Packages = append(out.Packages, pkg)
+		out.Relationships = append(out.Relationships, spdxRelationship{
+			SPDXElementID:      rootID,
+			RelationshipType:   "CONTAINS",
+			RelatedSPDXElement: id,
+		})
+	}
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// annotations returns the annotations recording the platforms and tools of a package.
+func annotations(date string, platforms, tools []string) []spdxAnnotation {
+	annotation := func(comment string) spdxAnnotation {
+		return spdxAnnotation{
+			AnnotationType: "OTHER",
+			Annotator:      "Tool: " + Creator,
+			AnnotationDate: date,
+			Comment:        comment,
+		}
+	}
+
+	return []spdxAnnotation{
+		annotation("platforms: " + strings.Join(platforms, ", ")),
+		annotation("tools: " + strings.Join(tools, ", ")),
+	}
+}
@@ -0,0 +1,53 @@
+package sbomx
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/syftx"
+	"github.com/Excoriate/daggerx/pkg/timex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentSPDX(t *testing.T) {
+	doc, err := NewMerger("app:v1").
+		WithNamespace("https://sbom.example.com/app/v1").
+		WithClock(timex.Fixed(time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC))).
+		WithSBOM("linux/amd64", "apko", apkoSBOM("x86_64")).
+		WithSBOM("linux/arm64", "apko", apkoSBOM("aarch64")).
+		Merge()
+	require.NoError(t, err)
+
+	data, err := doc.SPDX()
+	require.NoError(t, err)
+
+	var out spdxDocument
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, SPDXVersion, out.SPDXVersion)
+	assert.Equal(t, "https://sbom.example.com/app/v1", out.DocumentNamespace)
+	assert.Equal(t, spdxCreationInfo{
+		Created:  "2025-03-01T12:00:00Z",
+		Creators: []string{"Tool: daggerx-sbomx", "Tool: apko"},
+	}, out.CreationInfo)
+	assert.Equal(t, []string{rootID}, out.DocumentDescribes)
+	require.Len(t, out.Packages, 4)
+	assert.Len(t, out.Relationships, 3)
+
+	glibc := out.Packages[1]
+	assert.Equal(t, "glibc", glibc.Name)
+	assert.Equal(t, "LGPL-2.1-or-later", glibc.LicenseDeclared)
+	assert.Equal(t, "pkg:apk/wolfi/glibc@2.39-r1?distro=wolfi", glibc.ExternalRefs[0].ReferenceLocator)
+	assert.Equal(t, "platforms: linux/amd64, linux/arm64", glibc.Annotations[0].Comment)
+	assert.Equal(t, "tools: apko", glibc.Annotations[1].Comment)
+
+	// The merged document is read back like the documents it merges.
+	packages, err := syftx.ParseSBOMPackages(data)
+	require.NoError(t, err)
+	assert.Equal(t, []syftx.Package{
+		{Name: "glibc", Version: "2.39-r1"},
+		{Name: "ld-linux-aarch64", Version: "2.39-r1"},
+		{Name: "ld-linux-x86_64", Version: "2.39-r1"},
+	}, packages)
+}
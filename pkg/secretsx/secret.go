@@ -0,0 +1,292 @@
+// Package secretsx provides descriptors for the credentials builders need (registry tokens, signing
+// keys, terraform backend credentials), so Dagger modules translate them to Dagger Secrets consistently.
+//
+// A SecretRef describes where a secret comes from (an environment variable, a file or the output of
+// a command), how it is provided to the container (as an environment variable or as a mounted file),
+// and how its value is redacted from previews and logs.
+//
+// Example usage:
+//
+//	ref := secretsx.FromEnv("registry-token", "REGISTRY_TOKEN").AsEnv("REGISTRY_PASSWORD")
+//
+//	secret, err := ref.ToDaggerSecret(client)
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	ctr = ref.Apply(ctr, secret)
+package secretsx
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"dagger.io/dagger"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// SourceKind describes where the value of a secret comes from.
+type SourceKind string
+
+const (
+	// SourceEnv reads the secret from an environment variable.
+	SourceEnv SourceKind = "env"
+	// SourceFile reads the secret from a file.
+	SourceFile SourceKind = "file"
+	// SourceCommand reads the secret from the standard output of a command.
+	SourceCommand SourceKind = "cmd"
+)
+
+// MountStyle describes how a secret is provided to the container.
+type MountStyle string
+
+const (
+	// MountAsEnv exposes the secret as an environment variable.
+	MountAsEnv MountStyle = "env"
+	// MountAsFile mounts the secret as a file.
+	MountAsFile MountStyle = "file"
+)
+
+// RedactedPlaceholder replaces secret values in redacted output.
+const RedactedPlaceholder = "***"
+
+// builderName identifies secret references in errorsx errors.
+const builderName = "secret"
+
+// SecretRef describes a secret consumed by a builder.
+type SecretRef struct {
+	// Name is the logical name of the secret, also used as the Dagger secret name.
+	Name string
+	// Source is where the secret value comes from.
+	Source SourceKind
+	// Ref is the environment variable name, file path or command, depending on Source.
+	Ref string
+	// Mount is how the secret is provided to the container.
+	Mount MountStyle
+	// Target is the environment variable name or the file path inside the container, depending on Mount.
+	Target string
+	// RedactPatterns are additional regular expressions whose matches are redacted from output,
+	// e.g. to catch derived values such as base64 encoded credentials.
+	RedactPatterns []string
+}
+
+// FromEnv creates a SecretRef whose value is read from the environment variable 'envVar'.
+// The secret is exposed under the same environment variable name by default.
+func FromEnv(name, envVar string) *SecretRef {
+	return &SecretRef{
+		Name:   name,
+		Source: SourceEnv,
+		Ref:    envVar,
+		Mount:  MountAsEnv,
+		Target: envVar,
+	}
+}
+
+// FromFile creates a SecretRef whose value is read from the file at 'path'.
+// The secret is mounted at the same path by default.
+func FromFile(name, path string) *SecretRef {
+	return &SecretRef{
+		Name:   name,
+		Source: SourceFile,
+		Ref:    path,
+		Mount:  MountAsFile,
+		Target: path,
+	}
+}
+
+// FromCommand creates a SecretRef whose value is the standard output of 'command', run with `sh -c`.
+// The secret must be given a target with AsEnv or AsFile.
+func FromCommand(name, command string) *SecretRef {
+	return &SecretRef{
+		Name:   name,
+		Source: SourceCommand,
+		Ref:    command,
+	}
+}
+
+// AsEnv exposes the secret as the environment variable 'envVar'.
+func (s *SecretRef) AsEnv(envVar string) *SecretRef {
+	s.Mount = MountAsEnv
+	s.Target = envVar
+	return s
+}
+
+// AsFile mounts the secret as a file at 'path'.
+func (s *SecretRef) AsFile(path string) *SecretRef {
+	s.Mount = MountAsFile
+	s.Target = path
+	return s
+}
+
+// WithRedactPattern adds a regular expression whose matches are redacted from output.
+func (s *SecretRef) WithRedactPattern(pattern string) *SecretRef {
+	s.RedactPatterns = append(s.RedactPatterns, pattern)
+	return s
+}
+
+// Validate checks that the secret reference is complete and consistent.
+func (s *SecretRef) Validate() error {
+	if s.Name == "" {
+		return errorsx.MissingField(builderName, "name", "secret name is required")
+	}
+
+	switch s.Source {
+	case SourceEnv, SourceFile, SourceCommand:
+	default:
+		return errorsx.Unsupported(builderName, "source", s.Source, "secret %s has an unsupported source: %q", s.Name, s.Source)
+	}
+
+	if s.Ref == "" {
+		return errorsx.MissingField(builderName, "ref", "secret %s requires a %s reference", s.Name, s.Source)
+	}
+
+	switch s.Mount {
+	case MountAsEnv, MountAsFile:
+	default:
+		return errorsx.Unsupported(builderName, "mount", s.Mount, "secret %s has an unsupported mount style: %q", s.Name, s.Mount)
+	}
+
+	if s.Target == "" {
+		return errorsx.MissingField(builderName, "target", "secret %s requires a target", s.Name)
+	}
+
+	if s.Mount == MountAsFile && !strings.HasPrefix(s.Target, "/") {
+		return errorsx.InvalidValue(builderName, "target", s.Target, "secret %s must be mounted at an absolute path: %s", s.Name, s.Target)
+	}
+
+	for _, p := range s.RedactPatterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return errorsx.InvalidValue(builderName, "redactPatterns", p, "secret %s has an invalid redact pattern %q: %w", s.Name, p, err)
+		}
+	}
+
+	return nil
+}
+
+// URI returns the Dagger secret provider URI of the secret (env://, file:// or cmd://).
+func (s *SecretRef) URI() string {
+	return fmt.Sprintf("%s://%s", s.Source, s.Ref)
+}
+
+// ToDaggerSecret returns the Dagger secret backed by the secret provider URI.
+//
+// Parameters:
+//   - client: The Dagger client used to create the secret.
+//
+// Returns:
+//   - The Dagger secret.
+//   - An error if the client is nil or the reference is invalid.
+func (s *SecretRef) ToDaggerSecret(client *dagger.Client) (*dagger.Secret, error) {
+	if client == nil {
+		return nil, errorsx.MissingField(builderName, "client", "dagger client is required")
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	return client.Secret(s.URI()), nil
+}
+
+// Apply provides the Dagger secret to the container according to the mount style.
+func (s *SecretRef) Apply(ctr *dagger.Container, secret *dagger.Secret) *dagger.Container {
+	if s.Mount == MountAsFile {
+		return ctr.WithMountedSecret(s.Target, secret)
+	}
+
+	return ctr.WithSecretVariable(s.Target, secret)
+}
+
+// MountRequirement returns the mount a file-style secret requires, and false for env-style secrets.
+func (s *SecretRef) MountRequirement() (types.Mount, bool) {
+	if s.Mount != MountAsFile {
+		return types.Mount{}, false
+	}
+
+	return types.Mount{
+		Kind:     types.MountKindSecret,
+		Source:   s.Name,
+		Target:   s.Target,
+		ReadOnly: true,
+	}, true
+}
+
+// Resolve reads the secret value on the local host.
+// It is intended for local executions; Dagger modules should use ToDaggerSecret instead.
+//
+// Returns:
+//   - The secret value, with a single trailing newline removed for file and command sources.
+//   - An error if the value cannot be read.
+func (s *SecretRef) Resolve(ctx context.Context) (string, error) {
+	if err := s.Validate(); err != nil {
+		return "", err
+	}
+
+	switch s.Source {
+	case SourceEnv:
+		value, ok := os.LookupEnv(s.Ref)
+		if !ok {
+			return "", fmt.Errorf("secret %s: environment variable %s is not set", s.Name, s.Ref)
+		}
+		return value, nil
+	case SourceFile:
+		content, err := os.ReadFile(s.Ref)
+		if err != nil {
+			return "", fmt.Errorf("secret %s: %w", s.Name, err)
+		}
+		return strings.TrimSuffix(string(content), "\n"), nil
+	default:
+		//nolint:gosec // The command is provided by the caller on purpose.
+		out, err := exec.CommandContext(ctx, "sh", "-c", s.Ref).Output()
+		if err != nil {
+			return "", fmt.Errorf("secret %s: command failed: %w", s.Name, err)
+		}
+		return strings.TrimSuffix(string(out), "\n"), nil
+	}
+}
+
+// Register registers the secret value and the redact patterns with the default logx redactor, so
+// they are scrubbed from logged commands, reports and exports of every package. Call it once the
+// value is resolved (see Resolve).
+//
+// Returns:
+//   - An error if the reference is invalid.
+func (s *SecretRef) Register(value string) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	logx.RegisterSecret(value)
+
+	for _, p := range s.RedactPatterns {
+		if err := logx.RegisterPattern(p); err != nil {
+			return errorsx.InvalidValue(builderName, "redactPatterns", p, "secret %s: %w", s.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Redact replaces the secret value and every match of the redact patterns in 'text' with
+// RedactedPlaceholder. Invalid patterns are ignored; use Validate to detect them.
+func (s *SecretRef) Redact(text, value string) string {
+	if value != "" {
+		text = strings.ReplaceAll(text, value, RedactedPlaceholder)
+	}
+
+	for _, p := range s.RedactPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			continue
+		}
+		text = re.ReplaceAllString(text, RedactedPlaceholder)
+	}
+
+	return text
+}
@@ -0,0 +1,169 @@
+package secretsx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConstructors(t *testing.T) {
+	tests := []struct {
+		name    string
+		ref     *SecretRef
+		wantURI string
+		want    SecretRef
+	}{
+		{
+			name:    "From env",
+			ref:     FromEnv("token", "GITHUB_TOKEN"),
+			wantURI: "env://GITHUB_TOKEN",
+			want:    SecretRef{Name: "token", Source: SourceEnv, Ref: "GITHUB_TOKEN", Mount: MountAsEnv, Target: "GITHUB_TOKEN"},
+		},
+		{
+			name:    "From file",
+			ref:     FromFile("key", "/keys/cosign.key"),
+			wantURI: "file:///keys/cosign.key",
+			want:    SecretRef{Name: "key", Source: SourceFile, Ref: "/keys/cosign.key", Mount: MountAsFile, Target: "/keys/cosign.key"},
+		},
+		{
+			name:    "From command exposed as env",
+			ref:     FromCommand("ecr", "aws ecr get-login-password").AsEnv("REGISTRY_PASSWORD"),
+			wantURI: "cmd://aws ecr get-login-password",
+			want: SecretRef{
+				Name: "ecr", Source: SourceCommand, Ref: "aws ecr get-login-password",
+				Mount: MountAsEnv, Target: "REGISTRY_PASSWORD",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, *tt.ref)
+			assert.Equal(t, tt.wantURI, tt.ref.URI())
+			assert.NoError(t, tt.ref.Validate())
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		ref     *SecretRef
+		wantErr string
+	}{
+		{
+			name:    "Missing name",
+			ref:     FromEnv("", "TOKEN"),
+			wantErr: "secret name is required",
+		},
+		{
+			name:    "Missing reference",
+			ref:     FromEnv("token", ""),
+			wantErr: "secret token requires a env reference",
+		},
+		{
+			name:    "Command without target",
+			ref:     FromCommand("token", "echo secret"),
+			wantErr: `secret token has an unsupported mount style: ""`,
+		},
+		{
+			name:    "Relative file target",
+			ref:     FromEnv("token", "TOKEN").AsFile("token.txt"),
+			wantErr: "secret token must be mounted at an absolute path: token.txt",
+		},
+		{
+			name:    "Invalid redact pattern",
+			ref:     FromEnv("token", "TOKEN").WithRedactPattern("("),
+			wantErr: "secret token has an invalid redact pattern \"(\": error parsing regexp: missing closing ): `(`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, tt.ref.Validate(), tt.wantErr)
+		})
+	}
+}
+
+func TestMountRequirement(t *testing.T) {
+	m, ok := FromFile("key", "/keys/cosign.key").MountRequirement()
+	assert.True(t, ok)
+	assert.Equal(t, types.Mount{Kind: types.MountKindSecret, Source: "key", Target: "/keys/cosign.key", ReadOnly: true}, m)
+
+	_, ok = FromEnv("token", "TOKEN").MountRequirement()
+	assert.False(t, ok)
+}
+
+func TestResolve(t *testing.T) {
+	t.Setenv("DAGGERX_SECRET_TEST", "from-env")
+
+	file := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(file, []byte("from-file\n"), 0o600))
+
+	tests := []struct {
+		name    string
+		ref     *SecretRef
+		want    string
+		wantErr bool
+	}{
+		{name: "Env", ref: FromEnv("s", "DAGGERX_SECRET_TEST"), want: "from-env"},
+		{name: "Missing env", ref: FromEnv("s", "DAGGERX_SECRET_TEST_MISSING"), wantErr: true},
+		{name: "File", ref: FromFile("s", file), want: "from-file"},
+		{name: "Command", ref: FromCommand("s", "echo from-cmd").AsEnv("S"), want: "from-cmd"},
+		{name: "Failing command", ref: FromCommand("s", "exit 1").AsEnv("S"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.ref.Resolve(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	ref := FromEnv("token", "TOKEN").WithRedactPattern(`Basic [A-Za-z0-9+/=]+`)
+
+	got := ref.Redact("curl -H 'Authorization: Basic dXNlcjpzM2NyM3Q=' -u user:s3cr3t", "s3cr3t")
+	assert.Equal(t, "curl -H 'Authorization: ***' -u user:***", got)
+}
+
+func TestRegister(t *testing.T) {
+	t.Cleanup(logx.Default().Reset)
+
+	ref := FromEnv("token", "TOKEN").WithRedactPattern(`Basic [A-Za-z0-9+/=]+`)
+	require.NoError(t, ref.Register("s3cr3t-value"))
+
+	got := logx.Default().String("-u user:s3cr3t-value -H 'Authorization: Basic dXNlcjpzM2NyM3Q='")
+	assert.Equal(t, "-u user:*** -H 'Authorization: ***'", got)
+
+	err := FromEnv("token", "TOKEN").WithRedactPattern("(").Register("value")
+	assert.ErrorIs(t, err, errorsx.ErrInvalidValue)
+}
+
+func TestToDaggerSecretRequiresClient(t *testing.T) {
+	_, err := FromEnv("token", "TOKEN").ToDaggerSecret(nil)
+	assert.EqualError(t, err, "dagger client is required")
+	assert.ErrorIs(t, err, errorsx.ErrMissingField)
+}
+
+func TestValidateTypedErrors(t *testing.T) {
+	err := (&SecretRef{Name: "token", Source: "vault", Ref: "x"}).Validate()
+	assert.ErrorIs(t, err, errorsx.ErrUnsupported)
+
+	err = FromEnv("token", "TOKEN").AsFile("relative/path").Validate()
+	assert.ErrorIs(t, err, errorsx.ErrInvalidValue)
+}
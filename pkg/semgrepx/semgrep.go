@@ -0,0 +1,356 @@
+// Package semgrepx provides a builder for the semgrep static analysis of source code: the
+// `semgrep scan` command with its rulesets, baseline commit, severity filters and SARIF output,
+// with metrics disabled, and the evaluation of the SARIF report against a maximum severity, so
+// the findings join the scans of the run report (see reportx) and gate the pipeline (see
+// policyx).
+//
+// With a baseline commit, only the findings introduced since that commit are reported; the scan
+// then requires the git history of the repository, down to that commit.
+//
+// Example usage:
+//
+//	scan := semgrepx.NewSemgrepBuilder("/src").
+//	    WithConfig("p/ci", "p/secrets", ".semgrep/").
+//	    WithBaselineCommit("origin/main").
+//	    WithSeverity(semgrepx.SeverityWarning, semgrepx.SeverityError)
+//
+//	cmd, err := scan.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	// Run cmd, then read the SARIF report at scan.SARIFPath().
+//	if err := scan.AddScan(report, sarif); err != nil {
+//	    // handle error
+//	}
+//	result, err := scan.Evaluate(sarif)
+package semgrepx
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the semgrep builder in errorsx errors.
+const builderName = "semgrep"
+
+// SemgrepDefaultImage is the default container image providing semgrep.
+const SemgrepDefaultImage = "semgrep/semgrep:1.99.0"
+
+// Severity is the severity of a semgrep rule.
+type Severity string
+
+const (
+	// SeverityInfo is the severity of informational rules.
+	SeverityInfo Severity = "INFO"
+	// SeverityWarning is the severity of rules reporting likely issues.
+	SeverityWarning Severity = "WARNING"
+	// SeverityError is the severity of rules reporting issues.
+	SeverityError Severity = "ERROR"
+)
+
+// Severities are the severities of the semgrep rules.
+var Severities = []Severity{SeverityInfo, SeverityWarning, SeverityError}
+
+// GetSARIFPath returns the conventional path of the semgrep SARIF report inside the container.
+// If mntPrefix is empty, fixtures.MntPrefix is used.
+func GetSARIFPath(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, "semgrep.sarif")
+}
+
+// SemgrepBuilder generates the semgrep scan command of a source directory.
+type SemgrepBuilder struct {
+	// dir is the scanned directory.
+	dir string
+
+	// configs are the rulesets: registry rulesets (e.g. "p/ci"), rule files or directories.
+	configs []string
+
+	// baselineCommit is the commit the reported findings are introduced since.
+	baselineCommit string
+
+	// severities are the severities of the reported findings. Empty reports every severity.
+	severities []Severity
+
+	// excludes are the excluded paths or patterns.
+	excludes []string
+
+	// includes are the only paths or patterns scanned.
+	includes []string
+
+	// excludeRules are the IDs of the excluded rules.
+	excludeRules []string
+
+	// failOnFindings exits with an error when findings are reported.
+	failOnFindings bool
+
+	// metrics sends the anonymous usage metrics.
+	metrics bool
+
+	// sarifPath is the path of the SARIF report.
+	sarifPath string
+
+	// maxSeverity is the highest severity of the findings passing the evaluation.
+	maxSeverity policyx.Severity
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewSemgrepBuilder creates a SemgrepBuilder scanning the directory 'dir', writing its SARIF
+// report to GetSARIFPath(""), without metrics, and failing the evaluation on errors.
+func NewSemgrepBuilder(dir string) *SemgrepBuilder {
+	return &SemgrepBuilder{
+		dir:         dir,
+		sarifPath:   GetSARIFPath(""),
+		maxSeverity: policyx.SeverityMedium,
+	}
+}
+
+// WithConfig adds rulesets: registry rulesets (e.g. "p/ci", "p/owasp-top-ten"), rule files or
+// directories.
+func (b *SemgrepBuilder) WithConfig(configs ...string) *SemgrepBuilder {
+	b.configs = append(b.configs, configs...)
+	return b
+}
+
+// WithBaselineCommit reports only the findings introduced since the commit 'ref' (e.g.
+// "origin/main").
+func (b *SemgrepBuilder) WithBaselineCommit(ref string) *SemgrepBuilder {
+	b.baselineCommit = ref
+	return b
+}
+
+// WithSeverity restricts the reported findings to the severities 'severities'.
+func (b *SemgrepBuilder) WithSeverity(severities ...Severity) *SemgrepBuilder {
+	b.severities = append(b.severities, severities...)
+	return b
+}
+
+// WithExclude excludes paths or patterns from the scan (e.g. "vendor", "*_test.go").
+func (b *SemgrepBuilder) WithExclude(patterns ...string) *SemgrepBuilder {
+	b.excludes = append(b.excludes, patterns...)
+	return b
+}
+
+// WithInclude restricts the scan to paths or patterns.
+func (b *SemgrepBuilder) WithInclude(patterns ...string) *SemgrepBuilder {
+	b.includes = append(b.includes, patterns...)
+	return b
+}
+
+// WithExcludeRule excludes the rules 'ids' from the scan.
+func (b *SemgrepBuilder) WithExcludeRule(ids ...string) *SemgrepBuilder {
+	b.excludeRules = append(b.excludeRules, ids...)
+	return b
+}
+
+// WithFailOnFindings sets whether the command exits with an error when findings are reported.
+// The evaluation of the report is independent of it (see WithMaxSeverity).
+func (b *SemgrepBuilder) WithFailOnFindings(fail bool) *SemgrepBuilder {
+	b.failOnFindings = fail
+	return b
+}
+
+// WithMetrics sets whether the anonymous usage metrics are sent. They are disabled by default.
+func (b *SemgrepBuilder) WithMetrics(enabled bool) *SemgrepBuilder {
+	b.metrics = enabled
+	return b
+}
+
+// WithSARIFPath sets the path of the SARIF report.
+func (b *SemgrepBuilder) WithSARIFPath(path string) *SemgrepBuilder {
+	b.sarifPath = path
+	return b
+}
+
+// WithMaxSeverity sets the highest severity of the findings passing the evaluation. It defaults
+// to medium, so errors fail.
+func (b *SemgrepBuilder) WithMaxSeverity(severity policyx.Severity) *SemgrepBuilder {
+	b.maxSeverity = severity
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *SemgrepBuilder) WithLogger(logger *slog.Logger) *SemgrepBuilder {
+	b.logger = logger
+	return b
+}
+
+// SARIFPath returns the path of the SARIF report.
+func (b *SemgrepBuilder) SARIFPath() string {
+	return b.sarifPath
+}
+
+// validate checks the options of the scan.
+func (b *SemgrepBuilder) validate() error {
+	if b.dir == "" {
+		return errorsx.MissingField(builderName, "dir", "scanned directory is required")
+	}
+
+	if len(b.configs) == 0 {
+		return errorsx.MissingField(builderName, "configs", "at least one ruleset is required")
+	}
+
+	if b.sarifPath == "" {
+		return errorsx.MissingField(builderName, "sarifPath", "SARIF report path is required")
+	}
+
+	if strings.HasPrefix(b.baselineCommit, "-") {
+		return errorsx.InvalidValue(builderName, "baselineCommit", b.baselineCommit,
+			"invalid baseline commit: %q", b.baselineCommit)
+	}
+
+	for _, s := range b.severities {
+		if !slices.Contains(Severities, s) {
+			return errorsx.InvalidValue(builderName, "severities", s, "unsupported semgrep severity: %q", s)
+		}
+	}
+
+	return nil
+}
+
+// BuildCommand generates the semgrep scan command, writing the SARIF report.
+//
+// Returns:
+//   - The semgrep scan command.
+//   - An error if the directory, rulesets or SARIF path are missing, the baseline commit is
+//     invalid, or a severity is unsupported.
+func (b *SemgrepBuilder) BuildCommand() ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"semgrep", "scan"}
+	for _, c := range b.configs {
+		cmd = append(cmd, "--config", c)
+	}
+
+	if b.baselineCommit != "" {
+		cmd = append(cmd, "--baseline-commit", b.baselineCommit)
+	}
+
+	for _, s := range b.severities {
+		cmd = append(cmd, "--severity", string(s))
+	}
+
+	for _, p := range b.excludes {
+		cmd = append(cmd, "--exclude", p)
+	}
+
+	for _, p := range b.includes {
+		cmd = append(cmd, "--include", p)
+	}
+
+	for _, id := range b.excludeRules {
+		cmd = append(cmd, "--exclude-rule", id)
+	}
+
+	if b.failOnFindings {
+		cmd = append(cmd, "--error")
+	}
+
+	metrics := "off"
+	if b.metrics {
+		metrics = "on"
+	}
+
+	cmd = append(cmd, "--metrics", metrics, "--disable-version-check", "--sarif", "--output", b.sarifPath, b.dir)
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Env returns the environment variables of the scan: the version check is disabled, and so is
+// sending the metrics unless they are enabled.
+func (b *SemgrepBuilder) Env() []types.DaggerEnvVars {
+	env := []types.DaggerEnvVars{{Name: "SEMGREP_ENABLE_VERSION_CHECK", Value: "0"}}
+	if !b.metrics {
+		env = append(env, types.DaggerEnvVars{Name: "SEMGREP_SEND_METRICS", Value: "off"})
+	}
+
+	return env
+}
+
+// Findings parses the SARIF report 'sarif' of the scan.
+//
+// Returns:
+//   - The findings, located by file and line.
+//   - An error if the report can't be parsed.
+func (b *SemgrepBuilder) Findings(sarif []byte) ([]policyx.Finding, error) {
+	return policyx.ParseSARIFReport(sarif)
+}
+
+// Evaluate parses the SARIF report 'sarif' and fails on the findings more severe than the
+// maximum severity.
+//
+// Returns:
+//   - The result of the evaluation.
+//   - An error if the report can't be parsed.
+func (b *SemgrepBuilder) Evaluate(sarif []byte) (policyx.Result, error) {
+	findings, err := b.Findings(sarif)
+	if err != nil {
+		return policyx.Result{}, err
+	}
+
+	return policyx.NewPolicy().WithMaxSeverity(b.maxSeverity).Evaluate(policyx.Input{Findings: findings}), nil
+}
+
+// AddScan parses the SARIF report 'sarif' and adds its findings to 'report', as a semgrep scan
+// of the scanned directory.
+//
+// Returns:
+//   - An error if the report can't be parsed.
+func (b *SemgrepBuilder) AddScan(report *reportx.Report, sarif []byte) error {
+	findings, err := b.Findings(sarif)
+	if err != nil {
+		return err
+	}
+
+	report.AddScan(builderName, b.dir, findings)
+
+	return nil
+}
+
+// Artifacts returns the artifacts of the scan: the SARIF report.
+func (b *SemgrepBuilder) Artifacts() []artifactx.Artifact {
+	return []artifactx.Artifact{{Kind: artifactx.KindReport, Path: b.sarifPath}}
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *SemgrepBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *SemgrepBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the semgrep command.
+func (b *SemgrepBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewSemgrepBuilder(""), explainx.Generate("BuildCommand", b.BuildCommand))
+}
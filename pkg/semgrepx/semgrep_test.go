@@ -0,0 +1,148 @@
+package semgrepx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSARIF = `{"version": "2.1.0", "runs": [{
+	"tool": {"driver": {"name": "Semgrep OSS", "rules": [
+		{"id": "go.lang.security.audit.sqli", "properties": {"security-severity": "7.5"}},
+		{"id": "go.lang.best-practice.defer-close"}
+	]}},
+	"results": [
+		{"ruleId": "go.lang.security.audit.sqli", "level": "error", "locations": [{"physicalLocation": {
+			"artifactLocation": {"uri": "db/query.go"}, "region": {"startLine": 42}}}]},
+		{"ruleId": "go.lang.best-practice.defer-close", "level": "note", "locations": [{"physicalLocation": {
+			"artifactLocation": {"uri": "db/conn.go"}, "region": {"startLine": 7}}}]}
+	]
+}]}`
+
+func TestGetSARIFPath(t *testing.T) {
+	assert.Equal(t, "/mnt/semgrep.sarif", GetSARIFPath(""))
+	assert.Equal(t, "/work/semgrep.sarif", GetSARIFPath("/work"))
+}
+
+func TestSemgrepBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *SemgrepBuilder
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "defaults",
+			builder: NewSemgrepBuilder("/src").WithConfig("p/ci"),
+			want: []string{
+				"semgrep", "scan", "--config", "p/ci", "--metrics", "off", "--disable-version-check",
+				"--sarif", "--output", "/mnt/semgrep.sarif", "/src",
+			},
+		},
+		{
+			name: "options",
+			builder: NewSemgrepBuilder("/src").
+				WithConfig("p/ci", ".semgrep/").
+				WithBaselineCommit("origin/main").
+				WithSeverity(SeverityWarning, SeverityError).
+				WithExclude("vendor").
+				WithInclude("*.go").
+				WithExcludeRule("go.lang.best-practice.defer-close").
+				WithFailOnFindings(true).
+				WithMetrics(true).
+				WithSARIFPath("/mnt/reports/semgrep.sarif"),
+			want: []string{
+				"semgrep", "scan", "--config", "p/ci", "--config", ".semgrep/",
+				"--baseline-commit", "origin/main", "--severity", "WARNING", "--severity", "ERROR",
+				"--exclude", "vendor", "--include", "*.go", "--exclude-rule", "go.lang.best-practice.defer-close",
+				"--error", "--metrics", "on", "--disable-version-check",
+				"--sarif", "--output", "/mnt/reports/semgrep.sarif", "/src",
+			},
+		},
+		{
+			name:    "missing directory",
+			builder: NewSemgrepBuilder("").WithConfig("p/ci"),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "missing ruleset",
+			builder: NewSemgrepBuilder("/src"),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "missing SARIF path",
+			builder: NewSemgrepBuilder("/src").WithConfig("p/ci").WithSARIFPath(""),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "invalid baseline commit",
+			builder: NewSemgrepBuilder("/src").WithConfig("p/ci").WithBaselineCommit("--all"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "unsupported severity",
+			builder: NewSemgrepBuilder("/src").WithConfig("p/ci").WithSeverity("HIGH"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestSemgrepBuilderEnv(t *testing.T) {
+	assert.Equal(t, []types.DaggerEnvVars{
+		{Name: "SEMGREP_ENABLE_VERSION_CHECK", Value: "0"},
+		{Name: "SEMGREP_SEND_METRICS", Value: "off"},
+	}, NewSemgrepBuilder("/src").Env())
+
+	assert.Equal(t, []types.DaggerEnvVars{{Name: "SEMGREP_ENABLE_VERSION_CHECK", Value: "0"}},
+		NewSemgrepBuilder("/src").WithMetrics(true).Env())
+}
+
+func TestSemgrepBuilderEvaluate(t *testing.T) {
+	result, err := NewSemgrepBuilder("/src").Evaluate([]byte(testSARIF))
+	require.NoError(t, err)
+	assert.False(t, result.Passed)
+	require.Len(t, result.Violations, 1)
+	assert.Equal(t, "go.lang.security.audit.sqli in db/query.go:42 has severity high, above the maximum of medium",
+		result.Violations[0].Message)
+
+	result, err = NewSemgrepBuilder("/src").WithMaxSeverity(policyx.SeverityHigh).Evaluate([]byte(testSARIF))
+	require.NoError(t, err)
+	assert.True(t, result.Passed)
+
+	_, err = NewSemgrepBuilder("/src").Evaluate([]byte("{"))
+	assert.Error(t, err)
+}
+
+func TestSemgrepBuilderAddScan(t *testing.T) {
+	report := reportx.NewReport("sast")
+	require.NoError(t, NewSemgrepBuilder("/src").AddScan(report, []byte(testSARIF)))
+
+	require.Len(t, report.Scans, 1)
+	assert.Equal(t, "semgrep", report.Scans[0].Scanner)
+	assert.Equal(t, "/src", report.Scans[0].Target)
+	assert.Equal(t, map[string]int{"high": 1, "low": 1}, report.Scans[0].Counts)
+}
+
+func TestSemgrepBuilderArtifacts(t *testing.T) {
+	assert.Equal(t, []artifactx.Artifact{{Kind: artifactx.KindReport, Path: "/mnt/semgrep.sarif"}},
+		NewSemgrepBuilder("/src").Artifacts())
+}
@@ -0,0 +1,125 @@
+package servicex
+
+import (
+	"fmt"
+
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+const (
+	// DockerDindImage is the default image of the Docker daemon sidecar.
+	DockerDindImage = "docker:dind"
+	// DockerDindDefaultName is the default hostname of the Docker daemon sidecar.
+	DockerDindDefaultName = "docker"
+	// DockerDindDefaultPort is the default TCP port of the Docker daemon sidecar (without TLS).
+	DockerDindDefaultPort = 2375
+	// RegistryImage is the default image of the local OCI registry.
+	RegistryImage = "registry:2"
+	// RegistryDefaultName is the default hostname of the local OCI registry.
+	RegistryDefaultName = "registry"
+	// RegistryDefaultPort is the default TCP port of the local OCI registry.
+	RegistryDefaultPort = 5000
+)
+
+// DindOpts holds the options of the Docker daemon sidecar.
+type DindOpts struct {
+	// Name is the hostname of the service. Defaults to DockerDindDefaultName.
+	Name string
+	// Image is the image of the service. Defaults to DockerDindImage.
+	Image string
+	// Port is the TCP port of the daemon. Defaults to DockerDindDefaultPort.
+	Port int
+	// InsecureRegistries are registries the daemon may reach over plain HTTP.
+	InsecureRegistries []string
+}
+
+// RegistryOpts holds the options of the local OCI registry.
+type RegistryOpts struct {
+	// Name is the hostname of the service. Defaults to RegistryDefaultName.
+	Name string
+	// Image is the image of the service. Defaults to RegistryImage.
+	Image string
+	// Port is the TCP port of the registry. Defaults to RegistryDefaultPort.
+	Port int
+}
+
+// NewDockerDindService returns the specification of a Docker daemon sidecar listening on TCP
+// without TLS. Consumers get DOCKER_HOST pointing at the daemon.
+//
+// Example:
+//
+//	spec := NewDockerDindService(DindOpts{})
+//	fmt.Println(spec.Endpoint()) // Output: docker:2375
+func NewDockerDindService(opts DindOpts) ServiceSpec {
+	name := setDefaultIfEmpty(opts.Name, DockerDindDefaultName)
+	image := setDefaultIfEmpty(opts.Image, DockerDindImage)
+	port := opts.Port
+	if port == 0 {
+		port = DockerDindDefaultPort
+	}
+
+	args := []string{
+		"dockerd",
+		fmt.Sprintf("--host=tcp://0.0.0.0:%d", port),
+		"--tls=false",
+	}
+	for _, r := range opts.InsecureRegistries {
+		args = append(args, "--insecure-registry="+r)
+	}
+
+	dockerHost := fmt.Sprintf("tcp://%s:%d", name, port)
+
+	return ServiceSpec{
+		Name:  name,
+		Image: image,
+		Ports: []int{port},
+		Env: []types.DaggerEnvVars{
+			{Name: "DOCKER_TLS_CERTDIR", Value: ""},
+		},
+		Args:                     args,
+		InsecureRootCapabilities: true,
+		ReadinessProbe:           types.DaggerCMD{"docker", "-H", dockerHost, "info"},
+		ConsumerEnv: []types.DaggerEnvVars{
+			{Name: "DOCKER_HOST", Value: dockerHost},
+		},
+	}
+}
+
+// NewRegistryService returns the specification of a local OCI registry.
+// Consumers get REGISTRY_URL set to the registry host and port.
+//
+// Example:
+//
+//	spec := NewRegistryService(RegistryOpts{})
+//	fmt.Println(spec.Endpoint()) // Output: registry:5000
+func NewRegistryService(opts RegistryOpts) ServiceSpec {
+	name := setDefaultIfEmpty(opts.Name, RegistryDefaultName)
+	image := setDefaultIfEmpty(opts.Image, RegistryImage)
+	port := opts.Port
+	if port == 0 {
+		port = RegistryDefaultPort
+	}
+
+	address := fmt.Sprintf("%s:%d", name, port)
+
+	return ServiceSpec{
+		Name:  name,
+		Image: image,
+		Ports: []int{port},
+		Env: []types.DaggerEnvVars{
+			{Name: "REGISTRY_HTTP_ADDR", Value: fmt.Sprintf("0.0.0.0:%d", port)},
+		},
+		ReadinessProbe: types.DaggerCMD{"curl", "-fsS", fmt.Sprintf("http://%s/v2/", address)},
+		ConsumerEnv: []types.DaggerEnvVars{
+			{Name: "REGISTRY_URL", Value: address},
+		},
+	}
+}
+
+func setDefaultIfEmpty(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
@@ -0,0 +1,49 @@
+package servicex
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDockerDindService(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		spec := NewDockerDindService(DindOpts{})
+
+		assert.Equal(t, "docker", spec.Name)
+		assert.Equal(t, DockerDindImage, spec.Image)
+		assert.Equal(t, []int{2375}, spec.Ports)
+		assert.True(t, spec.InsecureRootCapabilities)
+		assert.Equal(t, []string{"dockerd", "--host=tcp://0.0.0.0:2375", "--tls=false"}, spec.Args)
+		assert.Equal(t, types.DaggerCMD{"docker", "-H", "tcp://docker:2375", "info"}, spec.ReadinessProbe)
+		assert.Equal(t, []types.DaggerEnvVars{{Name: "DOCKER_HOST", Value: "tcp://docker:2375"}}, spec.ConsumerEnv)
+		assert.Equal(t, "docker:2375", spec.Endpoint())
+		assert.NoError(t, spec.Validate())
+	})
+
+	t.Run("Custom options", func(t *testing.T) {
+		spec := NewDockerDindService(DindOpts{
+			Name:               "dind",
+			Port:               2376,
+			InsecureRegistries: []string{"registry:5000"},
+		})
+
+		assert.Equal(t, []string{
+			"dockerd", "--host=tcp://0.0.0.0:2376", "--tls=false", "--insecure-registry=registry:5000",
+		}, spec.Args)
+		assert.Equal(t, "tcp://dind:2376", spec.ConsumerEnv[0].Value)
+	})
+}
+
+func TestNewRegistryService(t *testing.T) {
+	spec := NewRegistryService(RegistryOpts{Port: 5001})
+
+	assert.Equal(t, "registry", spec.Name)
+	assert.Equal(t, RegistryImage, spec.Image)
+	assert.Equal(t, []int{5001}, spec.Ports)
+	assert.False(t, spec.InsecureRootCapabilities)
+	assert.Equal(t, []types.DaggerEnvVars{{Name: "REGISTRY_HTTP_ADDR", Value: "0.0.0.0:5001"}}, spec.Env)
+	assert.Equal(t, types.DaggerCMD{"curl", "-fsS", "http://registry:5001/v2/"}, spec.ReadinessProbe)
+	assert.Equal(t, []types.DaggerEnvVars{{Name: "REGISTRY_URL", Value: "registry:5001"}}, spec.ConsumerEnv)
+}
@@ -0,0 +1,134 @@
+// Package servicex provides descriptions of common Dagger service bindings used by builders
+// that must push or pull images during tests, such as a Docker daemon sidecar (docker:dind) or
+// a local OCI registry (registry:2).
+//
+// A ServiceSpec captures everything needed to run the service and to consume it: the image,
+// the exposed ports, the environment, the readiness probe command and the environment variables
+// that consumer containers need (DOCKER_HOST, registry URL).
+//
+// Example usage:
+//
+//	spec := servicex.NewRegistryService(servicex.RegistryOpts{})
+//
+//	svc, err := spec.ToDaggerService(client)
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	ctr = spec.Bind(ctr, svc)
+//	// ctr now resolves "registry:5000" and has REGISTRY_URL set.
+package servicex
+
+import (
+	"fmt"
+
+	"dagger.io/dagger"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies service specs in errorsx errors.
+const builderName = "service"
+
+// ServiceSpec describes a service that can be bound to consumer containers.
+type ServiceSpec struct {
+	// Name is the hostname alias under which consumers reach the service.
+	Name string
+	// Image is the container image running the service.
+	Image string
+	// Ports are the TCP ports exposed by the service.
+	Ports []int
+	// Env holds the environment variables of the service container.
+	Env []types.DaggerEnvVars
+	// Args is the command used to start the service. If empty, the image entrypoint is used.
+	Args []string
+	// InsecureRootCapabilities grants the service extended privileges, as required by docker:dind.
+	InsecureRootCapabilities bool
+	// ReadinessProbe is a command, run from a consumer container, that succeeds once the service is ready.
+	ReadinessProbe types.DaggerCMD
+	// ConsumerEnv holds the environment variables consumer containers need to reach the service.
+	ConsumerEnv []types.DaggerEnvVars
+}
+
+// Validate checks that the service specification is complete.
+//
+// Returns:
+//   - An error if the name, image or ports are missing, or if a port is out of range.
+func (s *ServiceSpec) Validate() error {
+	if s.Name == "" {
+		return errorsx.MissingField(builderName, "name", "service name is required")
+	}
+
+	if s.Image == "" {
+		return errorsx.MissingField(builderName, "image", "service image is required")
+	}
+
+	if len(s.Ports) == 0 {
+		return errorsx.MissingField(builderName, "ports", "service %s must expose at least one port", s.Name)
+	}
+
+	for _, p := range s.Ports {
+		if p < 1 || p > 65535 {
+			return errorsx.InvalidValue(builderName, "ports", p, "service %s has an invalid port: %d", s.Name, p)
+		}
+	}
+
+	return nil
+}
+
+// Endpoint returns the host:port address consumers use to reach the first exposed port.
+func (s *ServiceSpec) Endpoint() string {
+	if len(s.Ports) == 0 {
+		return s.Name
+	}
+
+	return fmt.Sprintf("%s:%d", s.Name, s.Ports[0])
+}
+
+// ToDaggerService creates the Dagger service described by the specification.
+//
+// Parameters:
+//   - client: The Dagger client used to create the service container.
+//
+// Returns:
+//   - The Dagger service.
+//   - An error if the client is nil or the specification is invalid.
+func (s *ServiceSpec) ToDaggerService(client *dagger.Client) (*dagger.Service, error) {
+	if client == nil {
+		return nil, errorsx.MissingField(builderName, "client", "dagger client is required")
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctr := client.Container().From(s.Image)
+	for _, e := range s.Env {
+		ctr = ctr.WithEnvVariable(e.Name, e.Value, dagger.ContainerWithEnvVariableOpts{
+			Expand: e.Expand,
+		})
+	}
+
+	for _, p := range s.Ports {
+		ctr = ctr.WithExposedPort(p)
+	}
+
+	return ctr.AsService(dagger.ContainerAsServiceOpts{
+		Args:                     s.Args,
+		UseEntrypoint:            len(s.Args) == 0,
+		InsecureRootCapabilities: s.InsecureRootCapabilities,
+	}), nil
+}
+
+// Bind binds the service to the consumer container under the service name and sets the
+// consumer environment variables.
+func (s *ServiceSpec) Bind(ctr *dagger.Container, svc *dagger.Service) *dagger.Container {
+	ctr = ctr.WithServiceBinding(s.Name, svc)
+	for _, e := range s.ConsumerEnv {
+		ctr = ctr.WithEnvVariable(e.Name, e.Value, dagger.ContainerWithEnvVariableOpts{
+			Expand: e.Expand,
+		})
+	}
+
+	return ctr
+}
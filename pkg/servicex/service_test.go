@@ -0,0 +1,60 @@
+package servicex
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceSpecValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    ServiceSpec
+		wantErr string
+	}{
+		{
+			name:    "Missing name",
+			spec:    ServiceSpec{Image: "registry:2", Ports: []int{5000}},
+			wantErr: "service name is required",
+		},
+		{
+			name:    "Missing image",
+			spec:    ServiceSpec{Name: "registry", Ports: []int{5000}},
+			wantErr: "service image is required",
+		},
+		{
+			name:    "Missing ports",
+			spec:    ServiceSpec{Name: "registry", Image: "registry:2"},
+			wantErr: "service registry must expose at least one port",
+		},
+		{
+			name:    "Invalid port",
+			spec:    ServiceSpec{Name: "registry", Image: "registry:2", Ports: []int{70000}},
+			wantErr: "service registry has an invalid port: 70000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, tt.spec.Validate(), tt.wantErr)
+		})
+	}
+}
+
+func TestToDaggerServiceRequiresClient(t *testing.T) {
+	spec := NewRegistryService(RegistryOpts{})
+	_, err := spec.ToDaggerService(nil)
+	assert.EqualError(t, err, "dagger client is required")
+	assert.ErrorIs(t, err, errorsx.ErrMissingField)
+}
+
+func TestServiceSpecValidateTypedErrors(t *testing.T) {
+	spec := &ServiceSpec{Name: "db", Image: "postgres", Ports: []int{70000}}
+	err := spec.Validate()
+	assert.ErrorIs(t, err, errorsx.ErrInvalidValue)
+
+	field, ok := errorsx.FieldOf(err)
+	assert.True(t, ok)
+	assert.Equal(t, "ports", field)
+}
@@ -0,0 +1,309 @@
+// Package shellcheckx provides a builder for the shellcheck lint of shell scripts: the
+// `shellcheck` command with its minimum severity, shell dialect and excluded checks, with JSON
+// output, and the parser of that output into findings, so the lint joins the scans of the run
+// report (see reportx) and gates the pipeline (see policyx).
+//
+// shellcheck levels map to severities: error to high, warning to medium, info to low and style
+// to negligible.
+//
+// Example usage:
+//
+//	lint := shellcheckx.NewShellcheckBuilder("scripts/install.sh", "scripts/release.sh").
+//	    WithShell("bash").
+//	    WithSeverity(shellcheckx.LevelWarning).
+//	    WithExclude("SC1091")
+//
+//	cmd, err := lint.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	// Run cmd, then parse its standard output.
+//	if err := lint.AddScan(report, stdout); err != nil {
+//	    // handle error
+//	}
+//	result, err := lint.Evaluate(stdout)
+package shellcheckx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the shellcheck builder in errorsx errors.
+const builderName = "shellcheck"
+
+// ShellcheckDefaultImage is the default container image providing shellcheck.
+const ShellcheckDefaultImage = "koalaman/shellcheck-alpine:v0.10.0"
+
+// Level is the level of a shellcheck comment.
+type Level string
+
+const (
+	// LevelError reports code that breaks.
+	LevelError Level = "error"
+	// LevelWarning reports code that likely misbehaves.
+	LevelWarning Level = "warning"
+	// LevelInfo reports code that may misbehave.
+	LevelInfo Level = "info"
+	// LevelStyle reports stylistic issues.
+	LevelStyle Level = "style"
+)
+
+// levelSeverities are the severities of the findings of each level.
+var levelSeverities = map[Level]policyx.Severity{
+	LevelError:   policyx.SeverityHigh,
+	LevelWarning: policyx.SeverityMedium,
+	LevelInfo:    policyx.SeverityLow,
+	LevelStyle:   policyx.SeverityNegligible,
+}
+
+// Severity returns the severity of the findings of the level.
+func (l Level) Severity() policyx.Severity {
+	return levelSeverities[l]
+}
+
+// Shells are the shell dialects shellcheck supports.
+var Shells = []string{"sh", "bash", "dash", "ksh", "busybox"}
+
+// checkRegex matches shellcheck check codes (e.g. "SC2086").
+var checkRegex = regexp.MustCompile(`^SC[0-9]{4}$`)
+
+// Comment is a comment of shellcheck on a script.
+type Comment struct {
+	// File is the path of the script.
+	File string `json:"file"`
+	// Line is the line of the comment.
+	Line int `json:"line"`
+	// Column is the column of the comment.
+	Column int `json:"column"`
+	// Level is the level of the comment.
+	Level Level `json:"level"`
+	// Code is the number of the check (e.g. 2086 for SC2086).
+	Code int `json:"code"`
+	// Message explains the comment.
+	Message string `json:"message"`
+}
+
+// ParseOutput parses the JSON output of shellcheck (--format json1).
+//
+// Returns:
+//   - The comments, in output order.
+//   - An error if the output is not a shellcheck json1 output.
+func ParseOutput(data []byte) ([]Comment, error) {
+	var out struct {
+		Comments *[]Comment `json:"comments"`
+	}
+
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("invalid shellcheck output: %w", err)
+	}
+
+	if out.Comments == nil {
+		return nil, fmt.Errorf("invalid shellcheck output: no comments")
+	}
+
+	return *out.Comments, nil
+}
+
+// Findings converts the comments to findings: the ID is the check code (e.g. "SC2086"), the
+// package the location of the comment (e.g. "install.sh:12").
+func Findings(comments []Comment) []policyx.Finding {
+	findings := make([]policyx.Finding, len(comments))
+	for i, c := range comments {
+		findings[i] = policyx.Finding{
+			ID:       fmt.Sprintf("SC%04d", c.Code),
+			Package:  c.File + ":" + strconv.Itoa(c.Line),
+			Severity: c.Level.Severity(),
+		}
+	}
+
+	return findings
+}
+
+// ShellcheckBuilder generates the shellcheck command of shell scripts.
+type ShellcheckBuilder struct {
+	// files are the linted scripts.
+	files []string
+
+	// shell is the shell dialect of the scripts. Empty infers it from the shebang.
+	shell string
+
+	// severity is the lowest level of the reported comments.
+	severity Level
+
+	// excludes are the excluded checks.
+	excludes []string
+
+	// externalSources follows the sourced files outside the linted scripts.
+	externalSources bool
+
+	// maxSeverity is the highest severity of the findings passing the evaluation.
+	maxSeverity policyx.Severity
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewShellcheckBuilder creates a ShellcheckBuilder linting the scripts 'files', failing the
+// evaluation on errors.
+func NewShellcheckBuilder(files ...string) *ShellcheckBuilder {
+	return &ShellcheckBuilder{files: files, maxSeverity: policyx.SeverityMedium}
+}
+
+// WithShell sets the shell dialect of the scripts, one of Shells. shellcheck otherwise infers it
+// from the shebang.
+func (b *ShellcheckBuilder) WithShell(shell string) *ShellcheckBuilder {
+	b.shell = shell
+	return b
+}
+
+// WithSeverity sets the lowest level of the reported comments. It defaults to style.
+func (b *ShellcheckBuilder) WithSeverity(level Level) *ShellcheckBuilder {
+	b.severity = level
+	return b
+}
+
+// WithExclude excludes the checks 'codes' (e.g. "SC1091").
+func (b *ShellcheckBuilder) WithExclude(codes ...string) *ShellcheckBuilder {
+	b.excludes = append(b.excludes, codes...)
+	return b
+}
+
+// WithExternalSources sets whether the sourced files outside the linted scripts are followed.
+func (b *ShellcheckBuilder) WithExternalSources(follow bool) *ShellcheckBuilder {
+	b.externalSources = follow
+	return b
+}
+
+// WithMaxSeverity sets the highest severity of the findings passing the evaluation. It defaults
+// to medium, so errors fail.
+func (b *ShellcheckBuilder) WithMaxSeverity(severity policyx.Severity) *ShellcheckBuilder {
+	b.maxSeverity = severity
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *ShellcheckBuilder) WithLogger(logger *slog.Logger) *ShellcheckBuilder {
+	b.logger = logger
+	return b
+}
+
+// BuildCommand generates the shellcheck command, with JSON output.
+//
+// Returns:
+//   - The shellcheck command.
+//   - An error if no script is set, the shell or severity is unsupported, or an excluded check
+//     is invalid.
+func (b *ShellcheckBuilder) BuildCommand() ([]string, error) {
+	if len(b.files) == 0 {
+		return nil, errorsx.MissingField(builderName, "files", "at least one script is required")
+	}
+
+	if b.shell != "" && !slices.Contains(Shells, b.shell) {
+		return nil, errorsx.InvalidValue(builderName, "shell", b.shell, "unsupported shell dialect: %q", b.shell)
+	}
+
+	if _, ok := levelSeverities[b.severity]; b.severity != "" && !ok {
+		return nil, errorsx.InvalidValue(builderName, "severity", b.severity, "unsupported shellcheck severity: %q", b.severity)
+	}
+
+	for _, c := range b.excludes {
+		if !checkRegex.MatchString(c) {
+			return nil, errorsx.InvalidValue(builderName, "excludes", c, "invalid shellcheck check: %q", c)
+		}
+	}
+
+	cmd := []string{"shellcheck", "--format", "json1"}
+	if b.shell != "" {
+		cmd = append(cmd, "--shell", b.shell)
+	}
+
+	if b.severity != "" {
+		cmd = append(cmd, "--severity", string(b.severity))
+	}
+
+	if len(b.excludes) > 0 {
+		cmd = append(cmd, "--exclude", strings.Join(b.excludes, ","))
+	}
+
+	if b.externalSources {
+		cmd = append(cmd, "--external-sources")
+	}
+
+	cmd = append(cmd, b.files...)
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Evaluate parses the JSON output of the command and fails on the findings more severe than
+// the maximum severity.
+//
+// Returns:
+//   - The result of the evaluation.
+//   - An error if the output can't be parsed.
+func (b *ShellcheckBuilder) Evaluate(output []byte) (policyx.Result, error) {
+	comments, err := ParseOutput(output)
+	if err != nil {
+		return policyx.Result{}, err
+	}
+
+	return policyx.NewPolicy().WithMaxSeverity(b.maxSeverity).Evaluate(policyx.Input{Findings: Findings(comments)}), nil
+}
+
+// AddScan parses the JSON output of the command and adds its findings to 'report', as a
+// shellcheck scan of the scripts.
+//
+// Returns:
+//   - An error if the output can't be parsed.
+func (b *ShellcheckBuilder) AddScan(report *reportx.Report, output []byte) error {
+	comments, err := ParseOutput(output)
+	if err != nil {
+		return err
+	}
+
+	report.AddScan(builderName, strings.Join(b.files, " "), Findings(comments))
+
+	return nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *ShellcheckBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *ShellcheckBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *ShellcheckBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the shellcheck command.
+func (b *ShellcheckBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewShellcheckBuilder(), explainx.Generate("BuildCommand", b.BuildCommand))
+}
@@ -0,0 +1,144 @@
+package shellcheckx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOutput = `{"comments": [
+	{"file": "install.sh", "line": 12, "endLine": 12, "column": 6, "endColumn": 10,
+	 "level": "error", "code": 2068, "message": "Double quote array expansions.", "fix": null},
+	{"file": "install.sh", "line": 20, "column": 3, "level": "info", "code": 2086,
+	 "message": "Double quote to prevent globbing and word splitting."}
+]}`
+
+func TestLevelSeverity(t *testing.T) {
+	assert.Equal(t, policyx.SeverityHigh, LevelError.Severity())
+	assert.Equal(t, policyx.SeverityMedium, LevelWarning.Severity())
+	assert.Equal(t, policyx.SeverityLow, LevelInfo.Severity())
+	assert.Equal(t, policyx.SeverityNegligible, LevelStyle.Severity())
+	assert.Equal(t, policyx.SeverityUnknown, Level("fatal").Severity())
+}
+
+func TestParseOutput(t *testing.T) {
+	comments, err := ParseOutput([]byte(testOutput))
+	require.NoError(t, err)
+	require.Len(t, comments, 2)
+	assert.Equal(t, Comment{
+		File: "install.sh", Line: 12, Column: 6, Level: LevelError, Code: 2068,
+		Message: "Double quote array expansions.",
+	}, comments[0])
+
+	comments, err = ParseOutput([]byte(`{"comments": []}`))
+	require.NoError(t, err)
+	assert.Empty(t, comments)
+
+	_, err = ParseOutput([]byte(`[]`))
+	assert.Error(t, err)
+
+	_, err = ParseOutput([]byte(`{}`))
+	assert.Error(t, err)
+}
+
+func TestFindings(t *testing.T) {
+	comments, err := ParseOutput([]byte(testOutput))
+	require.NoError(t, err)
+
+	assert.Equal(t, []policyx.Finding{
+		{ID: "SC2068", Package: "install.sh:12", Severity: policyx.SeverityHigh},
+		{ID: "SC2086", Package: "install.sh:20", Severity: policyx.SeverityLow},
+	}, Findings(comments))
+}
+
+func TestShellcheckBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *ShellcheckBuilder
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "defaults",
+			builder: NewShellcheckBuilder("install.sh"),
+			want:    []string{"shellcheck", "--format", "json1", "install.sh"},
+		},
+		{
+			name: "options",
+			builder: NewShellcheckBuilder("install.sh", "release.sh").
+				WithShell("bash").
+				WithSeverity(LevelWarning).
+				WithExclude("SC1091", "SC2034").
+				WithExternalSources(true),
+			want: []string{
+				"shellcheck", "--format", "json1", "--shell", "bash", "--severity", "warning",
+				"--exclude", "SC1091,SC2034", "--external-sources", "install.sh", "release.sh",
+			},
+		},
+		{
+			name:    "no script",
+			builder: NewShellcheckBuilder(),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "unsupported shell",
+			builder: NewShellcheckBuilder("install.sh").WithShell("zsh"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "unsupported severity",
+			builder: NewShellcheckBuilder("install.sh").WithSeverity("fatal"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "invalid excluded check",
+			builder: NewShellcheckBuilder("install.sh").WithExclude("2086"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestShellcheckBuilderEvaluate(t *testing.T) {
+	result, err := NewShellcheckBuilder("install.sh").Evaluate([]byte(testOutput))
+	require.NoError(t, err)
+	assert.False(t, result.Passed)
+	require.Len(t, result.Violations, 1)
+	assert.Equal(t, "SC2068 in install.sh:12 has severity high, above the maximum of medium",
+		result.Violations[0].Message)
+
+	result, err = NewShellcheckBuilder("install.sh").WithMaxSeverity(policyx.SeverityHigh).Evaluate([]byte(testOutput))
+	require.NoError(t, err)
+	assert.True(t, result.Passed)
+
+	_, err = NewShellcheckBuilder("install.sh").Evaluate([]byte("{"))
+	assert.Error(t, err)
+}
+
+func TestShellcheckBuilderAddScan(t *testing.T) {
+	report := reportx.NewReport("lint")
+	require.NoError(t, NewShellcheckBuilder("install.sh", "release.sh").AddScan(report, []byte(testOutput)))
+
+	require.Len(t, report.Scans, 1)
+	assert.Equal(t, "shellcheck", report.Scans[0].Scanner)
+	assert.Equal(t, "install.sh release.sh", report.Scans[0].Target)
+	assert.Equal(t, map[string]int{"high": 1, "low": 1}, report.Scans[0].Counts)
+
+	assert.Error(t, NewShellcheckBuilder("install.sh").AddScan(report, []byte("{")))
+}
@@ -0,0 +1,238 @@
+// Package signingx provides the signing key references shared by the signing builders (cosignx,
+// melangex), so pipelines choose where the private key lives once, and key material never needs
+// to exist on disk.
+//
+// A KeyProvider is a key file, a host environment variable holding the key, or a key held by a
+// KMS (awskms://, gcpkms://, azurekms://, hashivault://). It renders the key flags and the
+// secrets each tool needs: cosign reads environment keys from its environment (env://) and signs
+// with KMS keys remotely; melange reads environment keys from a secret file, which Dagger keeps
+// in memory.
+//
+// Example usage:
+//
+//	key, err := signingx.ParseKey("awskms:///alias/release-signing")
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	sign := cosignx.NewSignBuilder("registry.example.com/app@sha256:...").WithKeyProvider(key)
+//	cmd, err := sign.BuildCommand()
+package signingx
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+)
+
+// builderName identifies the key providers in errorsx errors.
+const builderName = "signing"
+
+const (
+	// CosignKeyEnvVar is the environment variable cosign reads environment keys from.
+	CosignKeyEnvVar = "COSIGN_PRIVATE_KEY"
+	// CosignPasswordEnvVar is the environment variable cosign reads the key password from.
+	CosignPasswordEnvVar = "COSIGN_PASSWORD"
+	// MelangeKeyPath is the path of the secret file melange reads environment keys from.
+	MelangeKeyPath = "/run/secrets/melange.rsa"
+)
+
+const (
+	// cosignKeySecret is the name of the secret holding the cosign environment key.
+	cosignKeySecret = "cosign-private-key"
+	// cosignPasswordSecret is the name of the secret holding the cosign key password.
+	cosignPasswordSecret = "cosign-password"
+	// melangeKeySecret is the name of the secret holding the melange environment key.
+	melangeKeySecret = "melange-signing-key"
+)
+
+// KeyKind is where a signing key lives.
+type KeyKind string
+
+const (
+	// KeyKindFile is a key file, e.g. a mounted key.
+	KeyKindFile KeyKind = "file"
+	// KeyKindEnv is a key held by a host environment variable.
+	KeyKindEnv KeyKind = "env"
+	// KeyKindKMS is a key held by a KMS, which signs remotely.
+	KeyKindKMS KeyKind = "kms"
+)
+
+// KMSSchemes are the schemes of the KMS URIs cosign supports.
+var KMSSchemes = []string{"awskms", "gcpkms", "azurekms", "hashivault"}
+
+// KeyProvider references a private signing key.
+type KeyProvider struct {
+	// kind is where the key lives.
+	kind KeyKind
+	// ref is the path of the key file, the environment variable or the KMS URI.
+	ref string
+	// passwordEnv is the host environment variable holding the key password. It may be empty.
+	passwordEnv string
+}
+
+// NewFileKey creates a KeyProvider of the key file at 'path' in the container.
+func NewFileKey(path string) *KeyProvider {
+	return &KeyProvider{kind: KeyKindFile, ref: path}
+}
+
+// NewEnvKey creates a KeyProvider of the key held by the host environment variable 'envVar'.
+func NewEnvKey(envVar string) *KeyProvider {
+	return &KeyProvider{kind: KeyKindEnv, ref: envVar}
+}
+
+// NewKMSKey creates a KeyProvider of the key held by a KMS, e.g. "awskms:///alias/signing" or
+// "hashivault://release".
+func NewKMSKey(uri string) *KeyProvider {
+	return &KeyProvider{kind: KeyKindKMS, ref: uri}
+}
+
+// ParseKey parses a key reference: "env://VAR" for an environment key, a KMS URI (see
+// KMSSchemes), or a path, optionally prefixed with "file://".
+//
+// Returns:
+//   - The key provider.
+//   - An error if the reference is empty, has an unsupported scheme or is invalid.
+func ParseKey(ref string) (*KeyProvider, error) {
+	var k *KeyProvider
+
+	scheme, rest, found := strings.Cut(ref, "://")
+	switch {
+	case !found:
+		k = NewFileKey(ref)
+	case scheme == "file":
+		k = NewFileKey(rest)
+	case scheme == string(KeyKindEnv):
+		k = NewEnvKey(rest)
+	case slices.Contains(KMSSchemes, scheme):
+		k = NewKMSKey(ref)
+	default:
+		return nil, errorsx.Unsupported(builderName, "key", ref, "unsupported key scheme %s://", scheme)
+	}
+
+	if err := k.Validate(); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// WithPasswordEnv sets the host environment variable holding the password of an encrypted
+// cosign key.
+// It returns the updated KeyProvider instance.
+func (k *KeyProvider) WithPasswordEnv(envVar string) *KeyProvider {
+	k.passwordEnv = envVar
+	return k
+}
+
+// Kind returns where the key lives.
+func (k *KeyProvider) Kind() KeyKind {
+	return k.kind
+}
+
+// Ref returns the path of the key file, the environment variable or the KMS URI.
+func (k *KeyProvider) Ref() string {
+	return k.ref
+}
+
+// Validate checks that the key reference is complete.
+//
+// Returns:
+//   - An error if the reference is empty, or a KMS URI has an unsupported scheme or no key.
+func (k *KeyProvider) Validate() error {
+	if k.ref == "" {
+		return errorsx.MissingField(builderName, "key", "%s key requires a reference", k.kind)
+	}
+
+	switch k.kind {
+	case KeyKindFile, KeyKindEnv:
+		return nil
+	case KeyKindKMS:
+	default:
+		return errorsx.Unsupported(builderName, "kind", k.kind, "unsupported key kind: %s", k.kind)
+	}
+
+	scheme, rest, _ := strings.Cut(k.ref, "://")
+	if !slices.Contains(KMSSchemes, scheme) {
+		return errorsx.Unsupported(builderName, "key", k.ref, "unsupported KMS %s, expected one of %s",
+			scheme, strings.Join(KMSSchemes, ", "))
+	}
+
+	if strings.Trim(rest, "/") == "" {
+		return errorsx.InvalidValue(builderName, "key", k.ref, "KMS URI %s names no key", k.ref)
+	}
+
+	if scheme == "gcpkms" && !strings.HasPrefix(rest, "projects/") {
+		return errorsx.InvalidValue(builderName, "key", k.ref,
+			"GCP KMS URI must be gcpkms://projects/PROJECT/locations/LOCATION/keyRings/RING/cryptoKeys/KEY, got %s", k.ref)
+	}
+
+	return nil
+}
+
+// CosignKey returns the value of the cosign --key flag: the path, "env://COSIGN_PRIVATE_KEY" or
+// the KMS URI.
+//
+// Returns:
+//   - The key flag value.
+//   - An error if the key reference is invalid.
+func (k *KeyProvider) CosignKey() (string, error) {
+	if err := k.Validate(); err != nil {
+		return "", err
+	}
+
+	if k.kind == KeyKindEnv {
+		return "env://" + CosignKeyEnvVar, nil
+	}
+
+	return k.ref, nil
+}
+
+// CosignSecrets returns the secrets cosign requires: the environment key exposed as
+// CosignKeyEnvVar, and the password exposed as CosignPasswordEnvVar.
+func (k *KeyProvider) CosignSecrets() []*secretsx.SecretRef {
+	var secrets []*secretsx.SecretRef
+	if k.kind == KeyKindEnv {
+		secrets = append(secrets, secretsx.FromEnv(cosignKeySecret, k.ref).AsEnv(CosignKeyEnvVar))
+	}
+
+	if k.passwordEnv != "" {
+		secrets = append(secrets, secretsx.FromEnv(cosignPasswordSecret, k.passwordEnv).AsEnv(CosignPasswordEnvVar))
+	}
+
+	return secrets
+}
+
+// MelangeKey returns the value of the melange --signing-key flag: the path, or MelangeKeyPath
+// for environment keys.
+//
+// Returns:
+//   - The key path.
+//   - An error if the key reference is invalid, or the key is held by a KMS, which melange
+//     cannot sign with.
+func (k *KeyProvider) MelangeKey() (string, error) {
+	if err := k.Validate(); err != nil {
+		return "", err
+	}
+
+	switch k.kind {
+	case KeyKindEnv:
+		return MelangeKeyPath, nil
+	case KeyKindKMS:
+		return "", errorsx.Unsupported(builderName, "key", k.ref, "melange cannot sign with KMS key %s", k.ref)
+	default:
+		return k.ref, nil
+	}
+}
+
+// MelangeSecrets returns the secrets melange requires: the environment key mounted at
+// MelangeKeyPath.
+func (k *KeyProvider) MelangeSecrets() []*secretsx.SecretRef {
+	if k.kind != KeyKindEnv {
+		return nil
+	}
+
+	return []*secretsx.SecretRef{secretsx.FromEnv(melangeKeySecret, k.ref).AsFile(MelangeKeyPath)}
+}
@@ -0,0 +1,94 @@
+package signingx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := map[string]struct {
+		kind KeyKind
+		ref  string
+	}{
+		"/mnt/cosign.key":                  {KeyKindFile, "/mnt/cosign.key"},
+		"file:///mnt/cosign.key":           {KeyKindFile, "/mnt/cosign.key"},
+		"env://SIGNING_KEY":                {KeyKindEnv, "SIGNING_KEY"},
+		"awskms:///alias/release":          {KeyKindKMS, "awskms:///alias/release"},
+		"azurekms://vault.example.com/key": {KeyKindKMS, "azurekms://vault.example.com/key"},
+		"hashivault://release":             {KeyKindKMS, "hashivault://release"},
+		"gcpkms://projects/p/locations/global/keyRings/r/cryptoKeys/k": {
+			KeyKindKMS, "gcpkms://projects/p/locations/global/keyRings/r/cryptoKeys/k",
+		},
+	}
+
+	for ref, want := range tests {
+		k, err := ParseKey(ref)
+		require.NoError(t, err, ref)
+		assert.Equal(t, want.kind, k.Kind(), ref)
+		assert.Equal(t, want.ref, k.Ref(), ref)
+	}
+}
+
+func TestParseKeyErrors(t *testing.T) {
+	_, err := ParseKey("")
+	require.ErrorIs(t, err, errorsx.ErrMissingField)
+
+	_, err = ParseKey("env://")
+	require.ErrorIs(t, err, errorsx.ErrMissingField)
+
+	_, err = ParseKey("k8s://ns/secret")
+	require.ErrorIs(t, err, errorsx.ErrUnsupported)
+
+	_, err = ParseKey("awskms:///")
+	require.ErrorIs(t, err, errorsx.ErrInvalidValue)
+
+	_, err = ParseKey("gcpkms://release")
+	require.ErrorIs(t, err, errorsx.ErrInvalidValue)
+
+	require.ErrorIs(t, NewKMSKey("vault://release").Validate(), errorsx.ErrUnsupported)
+}
+
+func TestCosign(t *testing.T) {
+	key, err := NewFileKey("/mnt/cosign.key").CosignKey()
+	require.NoError(t, err)
+	assert.Equal(t, "/mnt/cosign.key", key)
+
+	env := NewEnvKey("SIGNING_KEY").WithPasswordEnv("SIGNING_PASSWORD")
+	key, err = env.CosignKey()
+	require.NoError(t, err)
+	assert.Equal(t, "env://"+CosignKeyEnvVar, key)
+	assert.Equal(t, []*secretsx.SecretRef{
+		secretsx.FromEnv(cosignKeySecret, "SIGNING_KEY").AsEnv(CosignKeyEnvVar),
+		secretsx.FromEnv(cosignPasswordSecret, "SIGNING_PASSWORD").AsEnv(CosignPasswordEnvVar),
+	}, env.CosignSecrets())
+
+	kms := NewKMSKey("hashivault://release")
+	key, err = kms.CosignKey()
+	require.NoError(t, err)
+	assert.Equal(t, "hashivault://release", key)
+	assert.Empty(t, kms.CosignSecrets())
+}
+
+func TestMelange(t *testing.T) {
+	key, err := NewFileKey("/mnt/keys/melange.rsa").MelangeKey()
+	require.NoError(t, err)
+	assert.Equal(t, "/mnt/keys/melange.rsa", key)
+
+	env := NewEnvKey("MELANGE_KEY")
+	key, err = env.MelangeKey()
+	require.NoError(t, err)
+	assert.Equal(t, MelangeKeyPath, key)
+
+	secrets := env.MelangeSecrets()
+	require.Len(t, secrets, 1)
+	assert.Equal(t, secretsx.MountAsFile, secrets[0].Mount)
+	assert.Equal(t, MelangeKeyPath, secrets[0].Target)
+	require.NoError(t, secrets[0].Validate())
+
+	_, err = NewKMSKey("awskms:///alias/release").MelangeKey()
+	require.ErrorIs(t, err, errorsx.ErrUnsupported)
+}
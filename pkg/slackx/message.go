@@ -0,0 +1,327 @@
+// Package slackx provides builders of the Slack notifications of a pipeline run: the Block Kit
+// message summarizing the run report (see reportx), with its status, failed steps, scan findings
+// and policy violations, and the curl command posting it to an incoming webhook, whose URL is
+// exposed as a secret.
+//
+// Example usage:
+//
+//	msg, err := slackx.NewMessageBuilder(report).
+//	    WithRunURL("https://ci.example.com/runs/42").
+//	    WithMentionOnFailure("<!here>").
+//	    JSON()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	send := slackx.NewSendBuilder(msg).
+//	    WithWebhookURL(secretsx.FromEnv("slack-webhook", "SLACK_WEBHOOK_URL"))
+//
+//	cmd, err := send.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	res, err := executor.Run(ctx, cmd, nil, send.Mounts())
+package slackx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+)
+
+// builderName identifies the Slack builders in errorsx errors.
+const builderName = "slack"
+
+const (
+	// maxHeaderLength is the maximum length of the text of a header block.
+	maxHeaderLength = 150
+	// maxSectionLength is the maximum length of the text of a section block.
+	maxSectionLength = 3000
+)
+
+// DefaultMaxItems is the default number of failed steps and policy violations listed.
+const DefaultMaxItems = 10
+
+// severityOrder lists the severities of the scan summaries, most severe first.
+var severityOrder = []policyx.Severity{
+	policyx.SeverityCritical,
+	policyx.SeverityHigh,
+	policyx.SeverityMedium,
+	policyx.SeverityLow,
+	policyx.SeverityNegligible,
+	policyx.SeverityUnknown,
+}
+
+// Text is a Block Kit text object.
+type Text struct {
+	// Type is "plain_text" or "mrkdwn".
+	Type string `json:"type"`
+	// Text is the text.
+	Text string `json:"text"`
+}
+
+// Button is a Block Kit button element linking to a URL.
+type Button struct {
+	// Type is "button".
+	Type string `json:"type"`
+	// Text is the label of the button.
+	Text Text `json:"text"`
+	// URL is the URL the button opens.
+	URL string `json:"url"`
+}
+
+// Block is a Block Kit layout block: a header, section, actions or context block.
+type Block struct {
+	// Type is the type of the block.
+	Type string `json:"type"`
+	// Text is the text of header and section blocks.
+	Text *Text `json:"text,omitempty"`
+	// Fields are the fields of section blocks, rendered in two columns.
+	Fields []Text `json:"fields,omitempty"`
+	// Elements are the buttons of actions blocks, or the texts of context blocks.
+	Elements []any `json:"elements,omitempty"`
+}
+
+// Message is a Slack message with Block Kit blocks.
+type Message struct {
+	// Text is the fallback text of the notifications.
+	Text string `json:"text"`
+	// Blocks are the layout blocks of the message.
+	Blocks []Block `json:"blocks"`
+}
+
+// MessageBuilder builds the Slack message summarizing a run report.
+type MessageBuilder struct {
+	// report is the summarized run report.
+	report *reportx.Report
+
+	// runURL is the URL of the pipeline run.
+	runURL string
+
+	// mention is the mention added to the message of failed runs (e.g. "<!here>").
+	mention string
+
+	// maxItems is the number of failed steps and policy violations listed.
+	maxItems int
+}
+
+// NewMessageBuilder creates a MessageBuilder summarizing the run report 'report', listing up to
+// DefaultMaxItems failed steps and policy violations.
+func NewMessageBuilder(report *reportx.Report) *MessageBuilder {
+	return &MessageBuilder{report: report, maxItems: DefaultMaxItems}
+}
+
+// WithRunURL links the message to the pipeline run at 'runURL'.
+func (b *MessageBuilder) WithRunURL(runURL string) *MessageBuilder {
+	b.runURL = runURL
+	return b
+}
+
+// WithMentionOnFailure adds the mention 'mention' (e.g. "<!here>", "<@U024BE7LH>",
+// "<!subteam^SAZ94GDB8>") to the message of failed runs.
+func (b *MessageBuilder) WithMentionOnFailure(mention string) *MessageBuilder {
+	b.mention = mention
+	return b
+}
+
+// WithMaxItems sets the number of failed steps and policy violations listed; the others are
+// counted.
+func (b *MessageBuilder) WithMaxItems(n int) *MessageBuilder {
+	b.maxItems = n
+	return b
+}
+
+// Build builds the message.
+//
+// Returns:
+//   - The message.
+//   - An error if the report is missing, the run URL is not an http(s) URL, or the number of
+//     listed items is not positive.
+func (b *MessageBuilder) Build() (Message, error) {
+	if b.report == nil {
+		return Message{}, errorsx.MissingField(builderName, "report", "run report is required")
+	}
+
+	if b.runURL != "" {
+		if u, err := url.Parse(b.runURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Message{}, errorsx.InvalidValue(builderName, "runURL", b.runURL, "invalid run URL: %q", b.runURL)
+		}
+	}
+
+	if b.maxItems <= 0 {
+		return Message{}, errorsx.InvalidValue(builderName, "maxItems", b.maxItems, "number of listed items must be positive")
+	}
+
+	r := b.report
+
+	status, emoji := "succeeded", ":white_check_mark:"
+	if !r.Succeeded() {
+		status, emoji = "failed", ":x:"
+	}
+
+	summary := fmt.Sprintf("%s %s", r.Title, status)
+	msg := Message{Text: summary}
+	msg.Blocks = append(msg.Blocks, Block{
+		Type: "header",
+		Text: &Text{Type: "plain_text", Text: truncate(emoji+" "+summary, maxHeaderLength)},
+	})
+
+	fields := []Text{
+		{Type: "mrkdwn", Text: "*Status*\n" + status},
+		{Type: "mrkdwn", Text: "*Duration*\n" + r.TotalDuration().String()},
+	}
+
+	if len(r.Steps) > 0 {
+		fields = append(fields, Text{Type: "mrkdwn", Text: "*Steps*\n" + stepCounts(r.Steps)})
+	}
+
+	if len(r.Artifacts) > 0 {
+		fields = append(fields, Text{Type: "mrkdwn", Text: fmt.Sprintf("*Artifacts*\n%d", len(r.Artifacts))})
+	}
+
+	msg.Blocks = append(msg.Blocks, Block{Type: "section", Fields: fields})
+
+	if b.mention != "" && !r.Succeeded() {
+		msg.Blocks = append(msg.Blocks, section(b.mention+" the run failed."))
+	}
+
+	var failed []string
+	for _, s := range r.Steps {
+		if s.Status != reportx.StepFailed {
+			continue
+		}
+
+		line := "• " + escape(s.Name)
+		if s.Error != "" {
+			line += ": " + escape(s.Error)
+		}
+		failed = append(failed, line)
+	}
+
+	if len(failed) > 0 {
+		msg.Blocks = append(msg.Blocks, section("*Failed steps*\n"+b.list(failed)))
+	}
+
+	if len(r.Scans) > 0 {
+		scans := make([]string, len(r.Scans))
+		for i, s := range r.Scans {
+			scans[i] = fmt.Sprintf("• %s `%s`: %s", escape(s.Scanner), escape(s.Target), findingCounts(s.Counts))
+		}
+		msg.Blocks = append(msg.Blocks, section("*Scans*\n"+strings.Join(scans, "\n")))
+	}
+
+	if r.Policy != nil && len(r.Policy.Violations) > 0 {
+		violations := make([]string, len(r.Policy.Violations))
+		for i, v := range r.Policy.Violations {
+			violations[i] = fmt.Sprintf("• *%s*: %s", v.Rule, escape(v.Message))
+		}
+		msg.Blocks = append(msg.Blocks, section("*Policy violations*\n"+b.list(violations)))
+	}
+
+	if b.runURL != "" {
+		msg.Blocks = append(msg.Blocks, Block{Type: "actions", Elements: []any{
+			Button{Type: "button", Text: Text{Type: "plain_text", Text: "View run"}, URL: b.runURL},
+		}})
+	}
+
+	msg.Blocks = append(msg.Blocks, Block{Type: "context", Elements: []any{
+		Text{Type: "mrkdwn", Text: "Generated at " + r.GeneratedAt.Format(time.RFC3339)},
+	}})
+
+	return msg, nil
+}
+
+// JSON builds the message and returns its JSON encoding, with the secrets registered with the
+// default logx redactor scrubbed.
+//
+// Returns:
+//   - The JSON payload of the message.
+//   - An error if the message can't be built, see Build.
+func (b *MessageBuilder) JSON() ([]byte, error) {
+	msg, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Slack message: %w", err)
+	}
+
+	return logx.Default().Bytes(data), nil
+}
+
+// list joins the first maxItems lines of 'lines', counting the others.
+func (b *MessageBuilder) list(lines []string) string {
+	if len(lines) <= b.maxItems {
+		return strings.Join(lines, "\n")
+	}
+
+	return strings.Join(lines[:b.maxItems], "\n") + fmt.Sprintf("\n…and %d more", len(lines)-b.maxItems)
+}
+
+// section returns a section block of the mrkdwn text 'text', truncated to the maximum length.
+func section(text string) Block {
+	return Block{Type: "section", Text: &Text{Type: "mrkdwn", Text: truncate(text, maxSectionLength)}}
+}
+
+// stepCounts describes the number of steps of each status (e.g. "3 succeeded, 1 failed").
+func stepCounts(steps []reportx.Step) string {
+	counts := map[reportx.StepStatus]int{}
+	for _, s := range steps {
+		status := s.Status
+		if status == "" {
+			status = reportx.StepSucceeded
+		}
+		counts[status]++
+	}
+
+	var parts []string
+	for _, status := range []reportx.StepStatus{reportx.StepSucceeded, reportx.StepFailed, reportx.StepSkipped} {
+		if counts[status] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// findingCounts describes the non-zero finding counts of a scan, most severe first (e.g.
+// "1 critical, 2 high"), or "no findings".
+func findingCounts(counts map[string]int) string {
+	var parts []string
+	for _, sev := range severityOrder {
+		if n := counts[sev.String()]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, sev))
+		}
+	}
+
+	if len(parts) == 0 {
+		return "no findings"
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// escape escapes the control characters of Slack mrkdwn.
+func escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// truncate truncates 's' to 'n' characters, ending it with an ellipsis when truncated.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,114 @@
+package slackx
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReport() *reportx.Report {
+	r := reportx.NewReport("checkout build")
+	r.GeneratedAt = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	r.AddStep(reportx.Step{Name: "build", Duration: time.Minute})
+	r.AddStep(reportx.Step{Name: "scan", Status: reportx.StepFailed, Duration: 30 * time.Second, Error: "exit <1>"})
+	r.AddScan("grype", "app:v1", []policyx.Finding{
+		{ID: "CVE-1", Severity: policyx.SeverityCritical},
+		{ID: "CVE-2", Severity: policyx.SeverityHigh},
+		{ID: "CVE-3", Severity: policyx.SeverityHigh},
+	})
+	r.AddScan("hadolint", "Dockerfile", nil)
+	r.WithPolicyResult(policyx.Result{Violations: []policyx.Violation{
+		{Rule: policyx.RuleMaxSeverity, Message: "CVE-1 in openssl 3.1 has severity critical"},
+	}})
+
+	return r
+}
+
+func TestMessageBuilderBuild(t *testing.T) {
+	msg, err := NewMessageBuilder(testReport()).
+		WithRunURL("https://ci.example.com/runs/42").
+		WithMentionOnFailure("<!here>").
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, "checkout build failed", msg.Text)
+	require.Len(t, msg.Blocks, 8)
+
+	assert.Equal(t, Block{Type: "header", Text: &Text{Type: "plain_text", Text: ":x: checkout build failed"}}, msg.Blocks[0])
+	assert.Equal(t, Block{Type: "section", Fields: []Text{
+		{Type: "mrkdwn", Text: "*Status*\nfailed"},
+		{Type: "mrkdwn", Text: "*Duration*\n1m30s"},
+		{Type: "mrkdwn", Text: "*Steps*\n1 succeeded, 1 failed"},
+	}}, msg.Blocks[1])
+	assert.Equal(t, "<!here> the run failed.", msg.Blocks[2].Text.Text)
+	assert.Equal(t, "*Failed steps*\n• scan: exit &lt;1&gt;", msg.Blocks[3].Text.Text)
+	assert.Equal(t, "*Scans*\n• grype `app:v1`: 1 critical, 2 high\n• hadolint `Dockerfile`: no findings",
+		msg.Blocks[4].Text.Text)
+	assert.Equal(t, "*Policy violations*\n• *max-severity*: CVE-1 in openssl 3.1 has severity critical",
+		msg.Blocks[5].Text.Text)
+	assert.Equal(t, Block{Type: "actions", Elements: []any{
+		Button{Type: "button", Text: Text{Type: "plain_text", Text: "View run"}, URL: "https://ci.example.com/runs/42"},
+	}}, msg.Blocks[6])
+	assert.Equal(t, Block{Type: "context", Elements: []any{
+		Text{Type: "mrkdwn", Text: "Generated at 2024-05-01T12:00:00Z"},
+	}}, msg.Blocks[7])
+}
+
+func TestMessageBuilderBuildSucceeded(t *testing.T) {
+	r := reportx.NewReport("docs")
+	r.AddStep(reportx.Step{Name: "build", Duration: time.Second})
+
+	msg, err := NewMessageBuilder(r).WithMentionOnFailure("<!here>").Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, ":white_check_mark: docs succeeded", msg.Blocks[0].Text.Text)
+	require.Len(t, msg.Blocks, 3)
+	assert.Equal(t, "context", msg.Blocks[2].Type)
+}
+
+func TestMessageBuilderMaxItems(t *testing.T) {
+	r := reportx.NewReport("build")
+	for _, name := range []string{"a", "b", "c"} {
+		r.AddStep(reportx.Step{Name: name, Status: reportx.StepFailed})
+	}
+
+	msg, err := NewMessageBuilder(r).WithMaxItems(2).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "*Failed steps*\n• a\n• b\n…and 1 more", msg.Blocks[2].Text.Text)
+}
+
+func TestMessageBuilderErrors(t *testing.T) {
+	_, err := NewMessageBuilder(nil).Build()
+	require.ErrorIs(t, err, errorsx.ErrMissingField)
+
+	_, err = NewMessageBuilder(testReport()).WithRunURL("ci.example.com/runs/42").Build()
+	require.ErrorIs(t, err, errorsx.ErrInvalidValue)
+
+	_, err = NewMessageBuilder(testReport()).WithMaxItems(0).Build()
+	require.ErrorIs(t, err, errorsx.ErrInvalidValue)
+}
+
+func TestMessageBuilderJSON(t *testing.T) {
+	data, err := NewMessageBuilder(testReport()).JSON()
+	require.NoError(t, err)
+
+	var msg map[string]any
+	require.NoError(t, json.Unmarshal(data, &msg))
+	assert.Equal(t, "checkout build failed", msg["text"])
+	assert.Len(t, msg["blocks"], 6)
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "abc", truncate("abc", 3))
+	assert.Equal(t, "ab…", truncate("abcd", 3))
+
+	long := section(strings.Repeat("é", maxSectionLength+10))
+	assert.Len(t, []rune(long.Text.Text), maxSectionLength)
+}
@@ -0,0 +1,168 @@
+package slackx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path"
+	"strconv"
+
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// WebhookURLEnvVar is the environment variable the send command reads the webhook URL from.
+const WebhookURLEnvVar = "SLACK_WEBHOOK_URL"
+
+// GetPayloadPath returns the path of the JSON payload 'payload' inside the container. The path
+// depends on the payload, so different messages don't overwrite each other. If mntPrefix is
+// empty, fixtures.MntPrefix is used.
+func GetPayloadPath(mntPrefix string, payload []byte) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	sum := sha256.Sum256(payload)
+
+	return path.Join(mntPrefix, ".daggerx", "slack-"+hex.EncodeToString(sum[:6])+".json")
+}
+
+// SendBuilder generates the curl command posting a message to a Slack incoming webhook.
+type SendBuilder struct {
+	// payload is the JSON payload of the message.
+	payload []byte
+
+	// webhookURL is the secret holding the URL of the incoming webhook.
+	webhookURL *secretsx.SecretRef
+
+	// retries is the number of retries of transient failures.
+	retries int
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewSendBuilder creates a SendBuilder posting the JSON payload 'payload' (see
+// MessageBuilder.JSON), retrying transient failures three times.
+func NewSendBuilder(payload []byte) *SendBuilder {
+	return &SendBuilder{payload: payload, retries: 3}
+}
+
+// WithWebhookURL sets the secret holding the URL of the incoming webhook, which is itself a
+// credential. It is exposed as WebhookURLEnvVar; 'ref' is left unchanged.
+func (b *SendBuilder) WithWebhookURL(ref *secretsx.SecretRef) *SendBuilder {
+	if ref == nil {
+		b.webhookURL = nil
+		return b
+	}
+
+	webhookURL := *ref
+	b.webhookURL = webhookURL.AsEnv(WebhookURLEnvVar)
+	return b
+}
+
+// WithRetries sets the number of retries of transient failures.
+func (b *SendBuilder) WithRetries(retries int) *SendBuilder {
+	b.retries = retries
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *SendBuilder) WithLogger(logger *slog.Logger) *SendBuilder {
+	b.logger = logger
+	return b
+}
+
+// PayloadPath returns the path of the payload inside the container.
+func (b *SendBuilder) PayloadPath() string {
+	return GetPayloadPath("", b.payload)
+}
+
+// BuildCommand generates the curl command posting the message.
+//
+// Returns:
+//   - The send command.
+//   - An error if the payload is missing or not JSON, the webhook URL is missing or invalid,
+//     or the number of retries is negative.
+func (b *SendBuilder) BuildCommand() ([]string, error) {
+	if len(b.payload) == 0 {
+		return nil, errorsx.MissingField(builderName, "payload", "message payload is required")
+	}
+
+	if !json.Valid(b.payload) {
+		return nil, errorsx.InvalidValue(builderName, "payload", "", "message payload must be JSON")
+	}
+
+	if b.webhookURL == nil {
+		return nil, errorsx.MissingField(builderName, "webhookURL", "webhook URL is required")
+	}
+
+	if err := b.webhookURL.Validate(); err != nil {
+		return nil, err
+	}
+
+	if b.retries < 0 {
+		return nil, errorsx.InvalidValue(builderName, "retries", b.retries, "retries cannot be negative")
+	}
+
+	curl := []string{
+		"curl", "-sS", "--fail-with-body", "--retry", strconv.Itoa(b.retries),
+		"-X", "POST", "-H", "Content-Type: application/json", "--data-binary", "@" + b.PayloadPath(),
+	}
+
+	cmd := []string{"sh", "-c", fmt.Sprintf(`%s "$%s"`, cmdx.ShellJoin(curl), WebhookURLEnvVar)}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Mounts returns the mounts the generated command requires: the inline mount of the payload.
+func (b *SendBuilder) Mounts() []types.Mount {
+	if len(b.payload) == 0 {
+		return nil
+	}
+
+	return []types.Mount{{
+		Kind: types.MountKindInline, Target: b.PayloadPath(), Content: string(b.payload), ReadOnly: true,
+	}}
+}
+
+// Secrets returns the secrets the command requires: the webhook URL.
+func (b *SendBuilder) Secrets() []*secretsx.SecretRef {
+	if b.webhookURL == nil {
+		return nil
+	}
+
+	return []*secretsx.SecretRef{b.webhookURL}
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *SendBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Env returns the environment variables the generated command requires: none. The
+// credentials are secrets (see Secrets).
+func (b *SendBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the curl command.
+func (b *SendBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewSendBuilder(nil), explainx.Generate("BuildCommand", b.BuildCommand))
+}
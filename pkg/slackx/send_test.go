@@ -0,0 +1,99 @@
+package slackx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testWebhookURL() *secretsx.SecretRef {
+	return secretsx.FromEnv("slack-webhook", "CI_SLACK_WEBHOOK")
+}
+
+func TestGetPayloadPath(t *testing.T) {
+	path := GetPayloadPath("", []byte(`{"text":"a"}`))
+	assert.True(t, strings.HasPrefix(path, "/mnt/.daggerx/slack-"), path)
+	assert.True(t, strings.HasSuffix(path, ".json"), path)
+	assert.NotEqual(t, path, GetPayloadPath("", []byte(`{"text":"b"}`)))
+}
+
+func TestSendBuilderBuildCommand(t *testing.T) {
+	payload := []byte(`{"text":"build failed"}`)
+
+	tests := []struct {
+		name    string
+		builder *SendBuilder
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "defaults",
+			builder: NewSendBuilder(payload).WithWebhookURL(testWebhookURL()),
+			want: "curl -sS --fail-with-body --retry 3 -X POST -H 'Content-Type: application/json' " +
+				"--data-binary '@" + GetPayloadPath("", payload) + `' "$SLACK_WEBHOOK_URL"`,
+		},
+		{
+			name:    "without retries",
+			builder: NewSendBuilder(payload).WithWebhookURL(testWebhookURL()).WithRetries(0),
+			want: "curl -sS --fail-with-body --retry 0 -X POST -H 'Content-Type: application/json' " +
+				"--data-binary '@" + GetPayloadPath("", payload) + `' "$SLACK_WEBHOOK_URL"`,
+		},
+		{
+			name:    "missing payload",
+			builder: NewSendBuilder(nil).WithWebhookURL(testWebhookURL()),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "invalid payload",
+			builder: NewSendBuilder([]byte("build failed")).WithWebhookURL(testWebhookURL()),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "missing webhook URL",
+			builder: NewSendBuilder(payload),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "negative retries",
+			builder: NewSendBuilder(payload).WithWebhookURL(testWebhookURL()).WithRetries(-1),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, []string{"sh", "-c", tt.want}, cmd)
+		})
+	}
+}
+
+func TestSendBuilderMountsAndSecrets(t *testing.T) {
+	ref := testWebhookURL()
+	b := NewSendBuilder([]byte(`{"text":"a"}`)).WithWebhookURL(ref)
+
+	assert.Equal(t, []types.Mount{{
+		Kind:     types.MountKindInline,
+		Target:   b.PayloadPath(),
+		Content:  `{"text":"a"}`,
+		ReadOnly: true,
+	}}, b.Mounts())
+
+	require.Len(t, b.Secrets(), 1)
+	assert.Equal(t, WebhookURLEnvVar, b.Secrets()[0].Target)
+	assert.Equal(t, "CI_SLACK_WEBHOOK", ref.Target)
+
+	assert.Nil(t, NewSendBuilder(nil).Mounts())
+	assert.Nil(t, NewSendBuilder(nil).Secrets())
+}
@@ -0,0 +1,377 @@
+// Package smoketestx standardizes the "does the image even start" checks of built images: smoke
+// tests declared as commands run in the image, with their expected exit code and stdout, and
+// HTTP probes against its exposed ports, turned into a planx.Plan of generated commands.
+//
+// Command tests run in the built image (e.g. with an execx.DaggerExecutor of the image
+// container). HTTP probes run curl from a container reaching the exposed ports, such as a consumer
+// bound to the image running as a service, retrying until the service answers.
+//
+// Example usage:
+//
+//	plan, err := smoketestx.NewSmokeTestBuilder().
+//	    WithCommandTest(smoketestx.CommandTest{
+//	        Name:        "version",
+//	        Command:     []string{"/usr/bin/app", "--version"},
+//	        StdoutRegex: `^app v[0-9]+\.[0-9]+\.[0-9]+`,
+//	    }).
+//	    WithCommandTest(smoketestx.CommandTest{
+//	        Name:     "bad-flag",
+//	        Command:  []string{"/usr/bin/app", "--no-such-flag"},
+//	        ExitCode: 2,
+//	    }).
+//	    WithHTTPProbe(smoketestx.HTTPProbe{
+//	        Name: "healthz",
+//	        Port: portx.Port{Number: 8080, Health: &portx.HealthEndpoint{Path: "/healthz"}},
+//	    }).
+//	    WithProbeHost("app").
+//	    Plan("smoke-app")
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	report, err := plan.Execute(ctx, executor)
+package smoketestx
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/builderx"
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/planx"
+	"github.com/Excoriate/daggerx/pkg/portx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the smoke test builder in errorsx errors.
+const builderName = "smoketest"
+
+const (
+	// DefaultProbeHost is the host the HTTP probes target by default.
+	DefaultProbeHost = "localhost"
+	// DefaultProbeAttempts is the number of attempts of an HTTP probe by default.
+	DefaultProbeAttempts = 30
+	// DefaultProbeInterval is the time between the attempts of an HTTP probe by default.
+	DefaultProbeInterval = time.Second
+)
+
+// nameRegex matches the names of smoke tests, used as plan task identifiers.
+var nameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// CommandTest is a smoke test running a command in the built image.
+type CommandTest struct {
+	// Name identifies the test.
+	Name string
+	// Command is the command to run.
+	Command []string
+	// Env holds the environment variables of the command.
+	Env []types.DaggerEnvVars
+	// ExitCode is the expected exit code of the command. Defaults to 0.
+	ExitCode int
+	// StdoutRegex is an extended regular expression (grep -E) a line of the standard output must
+	// match, when not empty.
+	StdoutRegex string
+}
+
+// HTTPProbe is a smoke test requesting an HTTP endpoint of an exposed port until it answers with
+// the expected status.
+type HTTPProbe struct {
+	// Name identifies the probe.
+	Name string
+	// Port is the exposed TCP port.
+	Port portx.Port
+	// Path is the requested path. Defaults to the path of the port health endpoint, or "/".
+	Path string
+	// ExpectedStatus is the expected HTTP status. Defaults to 200.
+	ExpectedStatus int
+	// Attempts is the number of requests before the probe fails. Defaults to DefaultProbeAttempts.
+	Attempts int
+	// Interval is the time between the requests. Defaults to DefaultProbeInterval.
+	Interval time.Duration
+	// Insecure skips the verification of the TLS certificate of https endpoints.
+	Insecure bool
+}
+
+// URL returns the URL the probe requests on 'host'.
+func (p *HTTPProbe) URL(host string) string {
+	scheme, path := "http", p.Path
+	if p.Port.Health != nil {
+		if p.Port.Health.Scheme != "" {
+			scheme = p.Port.Health.Scheme
+		}
+		if path == "" {
+			path = p.Port.Health.Path
+		}
+	}
+
+	if path == "" {
+		path = "/"
+	}
+
+	return fmt.Sprintf("%s://%s:%d%s", scheme, host, p.Port.Number, path)
+}
+
+// SmokeTestBuilder generates the execution plan of the smoke tests of an image.
+type SmokeTestBuilder struct {
+	// commands are the command tests, in declaration order.
+	commands []CommandTest
+
+	// probes are the HTTP probes, in declaration order.
+	probes []HTTPProbe
+
+	// probeHost is the host the HTTP probes target.
+	probeHost string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+var _ builderx.Builder = (*SmokeTestBuilder)(nil)
+
+// NewSmokeTestBuilder creates a SmokeTestBuilder probing DefaultProbeHost.
+func NewSmokeTestBuilder() *SmokeTestBuilder {
+	return &SmokeTestBuilder{probeHost: DefaultProbeHost}
+}
+
+// WithCommandTest adds the command test 'test'.
+func (b *SmokeTestBuilder) WithCommandTest(test CommandTest) *SmokeTestBuilder {
+	b.commands = append(b.commands, test)
+	return b
+}
+
+// WithCommand adds the command test 'name' expecting 'cmd' to exit with code 0.
+func (b *SmokeTestBuilder) WithCommand(name string, cmd ...string) *SmokeTestBuilder {
+	return b.WithCommandTest(CommandTest{Name: name, Command: cmd})
+}
+
+// WithHTTPProbe adds the HTTP probe 'probe'.
+func (b *SmokeTestBuilder) WithHTTPProbe(probe HTTPProbe) *SmokeTestBuilder {
+	b.probes = append(b.probes, probe)
+	return b
+}
+
+// WithProbeHost sets the host the HTTP probes target (e.g. the alias of the service binding).
+func (b *SmokeTestBuilder) WithProbeHost(host string) *SmokeTestBuilder {
+	b.probeHost = host
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *SmokeTestBuilder) WithLogger(logger *slog.Logger) *SmokeTestBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks the smoke tests.
+func (b *SmokeTestBuilder) validate() error {
+	if len(b.commands) == 0 && len(b.probes) == 0 {
+		return errorsx.MissingField(builderName, "tests", "at least one command test or HTTP probe is required")
+	}
+
+	names := map[string]bool{}
+	checkName := func(field, name string) error {
+		if !nameRegex.MatchString(name) {
+			return errorsx.InvalidValue(builderName, field, name, "invalid smoke test name: %q", name)
+		}
+
+		if names[name] {
+			return errorsx.ConflictingOptions(builderName, []string{"commands", "probes"},
+				"smoke test %s is declared twice", name)
+		}
+		names[name] = true
+
+		return nil
+	}
+
+	for _, c := range b.commands {
+		if err := checkName("commands", c.Name); err != nil {
+			return err
+		}
+
+		if len(c.Command) == 0 {
+			return errorsx.MissingField(builderName, "commands", "smoke test %s has no command", c.Name)
+		}
+
+		if c.ExitCode < 0 || c.ExitCode > 255 {
+			return errorsx.InvalidValue(builderName, "commands", c.ExitCode,
+				"smoke test %s expects an invalid exit code: %d", c.Name, c.ExitCode)
+		}
+
+		if _, err := regexp.Compile(c.StdoutRegex); err != nil {
+			return errorsx.InvalidValue(builderName, "commands", c.StdoutRegex,
+				"smoke test %s has an invalid stdout regex: %v", c.Name, err)
+		}
+	}
+
+	if len(b.probes) > 0 && b.probeHost == "" {
+		return errorsx.MissingField(builderName, "probeHost", "HTTP probes require a host")
+	}
+
+	for i := range b.probes {
+		p := &b.probes[i]
+		if err := checkName("probes", p.Name); err != nil {
+			return err
+		}
+
+		if err := p.Port.Validate(); err != nil {
+			return errorsx.InvalidValue(builderName, "probes", p.Port.Number, "smoke test %s: %v", p.Name, err)
+		}
+
+		if p.Port.Protocol == portx.ProtocolUDP {
+			return errorsx.Unsupported(builderName, "probes", p.Port.String(),
+				"smoke test %s probes a UDP port over HTTP", p.Name)
+		}
+
+		if p.ExpectedStatus != 0 && (p.ExpectedStatus < 100 || p.ExpectedStatus > 599) {
+			return errorsx.InvalidValue(builderName, "probes", p.ExpectedStatus,
+				"smoke test %s expects an invalid HTTP status: %d", p.Name, p.ExpectedStatus)
+		}
+
+		if p.Attempts < 0 || p.Interval < 0 {
+			return errorsx.InvalidValue(builderName, "probes", p.Attempts,
+				"smoke test %s has a negative number of attempts or interval", p.Name)
+		}
+	}
+
+	return nil
+}
+
+// Plan generates the execution plan of the smoke tests: one task per test, named after it, the
+// command tests first, then the HTTP probes, each in declaration order. Every test runs, even
+// after a failure, so the report tells every broken check.
+//
+// Returns:
+//   - The plan.
+//   - An error if a test is invalid or two tests share a name.
+func (b *SmokeTestBuilder) Plan(name string) (*planx.Plan, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+
+	tasks := make([]planx.Task, 0, len(b.commands)+len(b.probes))
+	for _, c := range b.commands {
+		tasks = append(tasks, planx.Task{ID: c.Name, Command: commandTest(c), Env: c.Env})
+	}
+
+	for i := range b.probes {
+		tasks = append(tasks, planx.Task{ID: b.probes[i].Name, Command: b.httpProbe(&b.probes[i])})
+	}
+
+	plan := planx.NewPlan(name).WithConcurrency(1).WithFailFast(false)
+	for _, t := range tasks {
+		logx.Command(ctx, b.logger, builderName, t.Command)
+
+		if err := plan.AddTask(t); err != nil {
+			return nil, errorsx.ConflictingOptions(builderName, []string{"commands", "probes"}, "%v", err)
+		}
+	}
+
+	return plan, nil
+}
+
+// commandTest returns the command of the command test 'c': the command itself when it only
+// expects a zero exit code, otherwise a script checking its exit code and standard output.
+func commandTest(c CommandTest) types.DaggerCMD {
+	if c.ExitCode == 0 && c.StdoutRegex == "" {
+		return append(types.DaggerCMD(nil), c.Command...)
+	}
+
+	script := fmt.Sprintf(`out=$(%s); code=$?; printf '%%s\n' "$out"; `+
+		`[ "$code" -eq %d ] || { echo %s"$code" >&2; exit 1; }`,
+		cmdx.ShellJoin(c.Command), c.ExitCode,
+		cmdx.ShellQuote(fmt.Sprintf("smoketest: %s expected exit code %d, got ", c.Name, c.ExitCode)))
+
+	if c.StdoutRegex != "" {
+		script += fmt.Sprintf(`; printf '%%s\n' "$out" | grep -Eq -- %s || { echo %s >&2; exit 1; }`,
+			cmdx.ShellQuote(c.StdoutRegex),
+			cmdx.ShellQuote(fmt.Sprintf("smoketest: stdout of %s does not match %s", c.Name, c.StdoutRegex)))
+	}
+
+	return types.DaggerCMD{"sh", "-c", script}
+}
+
+// httpProbe returns the command of the HTTP probe 'p': a curl loop requesting its URL until it
+// answers with the expected status or the attempts run out.
+func (b *SmokeTestBuilder) httpProbe(p *HTTPProbe) types.DaggerCMD {
+	status := p.ExpectedStatus
+	if status == 0 {
+		status = 200
+	}
+
+	attempts := p.Attempts
+	if attempts == 0 {
+		attempts = DefaultProbeAttempts
+	}
+
+	interval := p.Interval
+	if interval == 0 {
+		interval = DefaultProbeInterval
+	}
+
+	curl := []string{"curl", "-s", "-o", "/dev/null", "-w", "%{http_code}"}
+	if p.Insecure {
+		curl = append(curl, "-k")
+	}
+	url := p.URL(b.probeHost)
+	curl = append(curl, url)
+
+	script := fmt.Sprintf(`i=0; while :; do code=$(%s); [ "$code" = %d ] && exit 0; i=$((i+1)); `+
+		`[ "$i" -ge %d ] && { echo %s"$code" >&2; exit 1; }; sleep %s; done`,
+		cmdx.ShellJoin(curl), status, attempts,
+		cmdx.ShellQuote(fmt.Sprintf("smoketest: %s expected HTTP %d from %s after %d attempts, got ",
+			p.Name, status, url, attempts)),
+		strconv.FormatFloat(interval.Seconds(), 'f', -1, 64))
+
+	return types.DaggerCMD{"sh", "-c", script}
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *SmokeTestBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// BuildCommand generates the sh command running the tasks of the plan one after another, every
+// test running even after a failure (see planx.Plan.Script). The environment variables of the
+// command tests are set by the command.
+//
+// Returns:
+//   - The sh command.
+//   - An error if a test is invalid or two tests share a name.
+func (b *SmokeTestBuilder) BuildCommand() ([]string, error) {
+	plan, err := b.Plan(builderName)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.Script()
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *SmokeTestBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *SmokeTestBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the commands of the tasks
+// of its plan.
+func (b *SmokeTestBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewSmokeTestBuilder(), explainx.PlanCommands(b.Plan(builderName))...)
+}
@@ -0,0 +1,248 @@
+package smoketestx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/planx"
+	"github.com/Excoriate/daggerx/pkg/portx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func taskByID(t *testing.T, plan *planx.Plan, id string) planx.Task {
+	t.Helper()
+
+	for _, task := range plan.Tasks() {
+		if task.ID == id {
+			return task
+		}
+	}
+	t.Fatalf("task %s not found", id)
+
+	return planx.Task{}
+}
+
+func run(t *testing.T, cmd types.DaggerCMD) (string, error) {
+	t.Helper()
+
+	out, err := exec.Command(cmd[0], cmd[1:]...).CombinedOutput()
+
+	return string(out), err
+}
+
+func TestSmokeTestBuilderPlan(t *testing.T) {
+	plan, err := NewSmokeTestBuilder().
+		WithCommand("starts", "true").
+		WithCommandTest(CommandTest{
+			Name:        "version",
+			Command:     []string{"echo", "app v1.2.3"},
+			StdoutRegex: `^app v[0-9]+\.[0-9]+\.[0-9]+$`,
+			Env:         []types.DaggerEnvVars{{Name: "APP_ENV", Value: "smoke"}},
+		}).
+		WithHTTPProbe(HTTPProbe{Name: "healthz", Port: portx.Port{Number: 8080}}).
+		Plan("smoke")
+	require.NoError(t, err)
+
+	var ids []string
+	for _, task := range plan.Tasks() {
+		ids = append(ids, task.ID)
+	}
+	assert.Equal(t, []string{"starts", "version", "healthz"}, ids)
+	assert.Equal(t, 1, plan.Concurrency())
+
+	assert.Equal(t, types.DaggerCMD{"true"}, taskByID(t, plan, "starts").Command)
+	assert.Equal(t, []types.DaggerEnvVars{{Name: "APP_ENV", Value: "smoke"}}, taskByID(t, plan, "version").Env)
+	assert.Contains(t, taskByID(t, plan, "healthz").Command[2], "http://localhost:8080/")
+}
+
+func TestSmokeTestBuilderBuildCommand(t *testing.T) {
+	b := NewSmokeTestBuilder().
+		WithCommand("fails", "false").
+		WithCommandTest(CommandTest{
+			Name:        "env",
+			Command:     []string{"sh", "-c", `echo "$APP_ENV"`},
+			StdoutRegex: "^smoke$",
+			Env:         []types.DaggerEnvVars{{Name: "APP_ENV", Value: "smoke"}},
+		})
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	require.NoError(t, b.Validate())
+
+	out, err := run(t, cmd)
+	require.Error(t, err, "the failing test fails the command")
+	assert.Equal(t, "smoke\n", out, "the tests after a failure run, with their environment")
+
+	_, err = NewSmokeTestBuilder().BuildCommand()
+	assert.ErrorIs(t, err, errorsx.ErrMissingField)
+}
+
+func TestCommandTestScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		test    CommandTest
+		wantErr string
+	}{
+		{
+			name: "matching stdout",
+			test: CommandTest{Command: []string{"echo", "app v1.2.3"}, StdoutRegex: `^app v[0-9.]+$`},
+		},
+		{
+			name:    "mismatching stdout",
+			test:    CommandTest{Command: []string{"echo", "it's broken"}, StdoutRegex: `^app v[0-9.]+$`},
+			wantErr: "does not match",
+		},
+		{
+			name: "expected exit code",
+			test: CommandTest{Command: []string{"sh", "-c", "echo usage; exit 2"}, ExitCode: 2, StdoutRegex: "usage"},
+		},
+		{
+			name:    "unexpected exit code",
+			test:    CommandTest{Command: []string{"false"}, ExitCode: 2},
+			wantErr: "expected exit code 2, got 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test.Name = "check"
+
+			out, err := run(t, commandTest(tt.test))
+			if tt.wantErr == "" {
+				require.NoError(t, err, out)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, out, tt.wantErr)
+		})
+	}
+}
+
+func TestHTTPProbeScript(t *testing.T) {
+	if _, err := exec.LookPath("curl"); err != nil {
+		t.Skip("curl is not available")
+	}
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n := requests.Add(1); r.URL.Path != "/healthz" || n < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	b := NewSmokeTestBuilder().WithProbeHost(u.Hostname())
+
+	t.Run("retries until the expected status", func(t *testing.T) {
+		out, err := run(t, b.httpProbe(&HTTPProbe{
+			Name:           "healthz",
+			Port:           portx.Port{Number: port, Health: &portx.HealthEndpoint{Path: "/healthz"}},
+			ExpectedStatus: http.StatusNoContent,
+			Attempts:       5,
+			Interval:       10 * time.Millisecond,
+		}))
+		require.NoError(t, err, out)
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("fails after the attempts", func(t *testing.T) {
+		out, err := run(t, b.httpProbe(&HTTPProbe{
+			Name: "ready", Port: portx.Port{Number: port}, Path: "/ready", Attempts: 2, Interval: 10 * time.Millisecond,
+		}))
+		require.Error(t, err)
+		assert.True(t, strings.Contains(out, "expected HTTP 200"), out)
+		assert.Contains(t, out, "got 503")
+	})
+}
+
+func TestHTTPProbeURL(t *testing.T) {
+	p := HTTPProbe{Port: portx.Port{Number: 8443, Health: &portx.HealthEndpoint{Path: "/healthz", Scheme: "https"}}}
+	assert.Equal(t, "https://app:8443/healthz", p.URL("app"))
+
+	p.Path = "/ready"
+	assert.Equal(t, "https://app:8443/ready", p.URL("app"))
+
+	assert.Equal(t, "http://app:80/", (&HTTPProbe{Port: portx.Port{Number: 80}}).URL("app"))
+}
+
+func TestSmokeTestBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *SmokeTestBuilder
+		wantErr error
+	}{
+		{name: "no tests", builder: NewSmokeTestBuilder(), wantErr: errorsx.ErrMissingField},
+		{
+			name:    "invalid name",
+			builder: NewSmokeTestBuilder().WithCommand("my check", "true"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name: "duplicate name",
+			builder: NewSmokeTestBuilder().WithCommand("check", "true").
+				WithHTTPProbe(HTTPProbe{Name: "check", Port: portx.Port{Number: 80}}),
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+		{name: "no command", builder: NewSmokeTestBuilder().WithCommand("check"), wantErr: errorsx.ErrMissingField},
+		{
+			name:    "invalid exit code",
+			builder: NewSmokeTestBuilder().WithCommandTest(CommandTest{Name: "check", Command: []string{"true"}, ExitCode: 300}),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name: "invalid regex",
+			builder: NewSmokeTestBuilder().WithCommandTest(CommandTest{
+				Name: "check", Command: []string{"true"}, StdoutRegex: "(",
+			}),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name: "udp probe",
+			builder: NewSmokeTestBuilder().WithHTTPProbe(HTTPProbe{
+				Name: "dns", Port: portx.Port{Number: 53, Protocol: portx.ProtocolUDP},
+			}),
+			wantErr: errorsx.ErrUnsupported,
+		},
+		{
+			name:    "invalid port",
+			builder: NewSmokeTestBuilder().WithHTTPProbe(HTTPProbe{Name: "web"}),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name: "invalid status",
+			builder: NewSmokeTestBuilder().WithHTTPProbe(HTTPProbe{
+				Name: "web", Port: portx.Port{Number: 80}, ExpectedStatus: 42,
+			}),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "missing host",
+			builder: NewSmokeTestBuilder().WithProbeHost("").WithHTTPProbe(HTTPProbe{Name: "web", Port: portx.Port{Number: 80}}),
+			wantErr: errorsx.ErrMissingField,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Plan("smoke")
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
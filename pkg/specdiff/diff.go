@@ -0,0 +1,505 @@
+// Package specdiff computes the semantic difference between two builder specs (e.g. two
+// apkox.ApkoSpec values, or two configx spec files), so pipelines can print "what changed since
+// the last release" summaries.
+//
+// Specs are compared field by field through their JSON form. Lists of values, such as the
+// packages and repositories, are compared as sets and report the added and removed entries;
+// maps, such as the annotations, report their keys. Inline configurations (inlineConfig) are
+// parsed and compared the same way, so the packages they list are diffed too. The diff reports
+// whether the change invalidates caches: changes to fields which only name or report the build
+// (tags, output paths, logging) don't.
+//
+// Example usage:
+//
+//	diff, err := specdiff.Diff(previous.Spec(), current.Spec())
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	fmt.Print(diff.String())
+//	if diff.InvalidatesCache {
+//	    // rebuild from scratch
+//	}
+package specdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/mapx"
+	"gopkg.in/yaml.v3"
+)
+
+// ChangeKind is the kind of a field change.
+type ChangeKind string
+
+const (
+	// Added is a field only set in the current spec.
+	Added ChangeKind = "added"
+	// Removed is a field only set in the previous spec.
+	Removed ChangeKind = "removed"
+	// Modified is a field set to different values in both specs.
+	Modified ChangeKind = "modified"
+)
+
+// DefaultCacheNeutralFields are the top-level spec fields whose changes don't invalidate
+// caches: they name, place or report the build without changing its inputs.
+var DefaultCacheNeutralFields = []string{
+	"tag",
+	"outputImage",
+	"outputTarball",
+	"sbomPath",
+	"logLevel",
+	"logPolicy",
+	"debug",
+	"releaseRegistries",
+	"extraArgsPolicy",
+	"apkoVersion",
+	"imageRefs",
+	"strict",
+}
+
+// EmbeddedConfigFields are the spec fields holding a YAML configuration, compared as documents.
+var EmbeddedConfigFields = []string{"inlineConfig"}
+
+// PackageFields are the fields listing packages, reported in Result.AddedPackages and
+// Result.RemovedPackages.
+var PackageFields = []string{"packageAppend", "inlineConfig.contents.packages"}
+
+// RepositoryFields are the fields listing repositories, reported in Result.AddedRepositories
+// and Result.RemovedRepositories.
+var RepositoryFields = []string{"repositoryAppend", "inlineConfig.contents.repositories"}
+
+// Change is a field that differs between two specs.
+type Change struct {
+	// Field is the dotted path of the field (e.g. "tag", "annotations.team").
+	Field string `json:"field"`
+	// Kind is the kind of the change.
+	Kind ChangeKind `json:"kind"`
+	// From is the previous value, nil when added.
+	From any `json:"from,omitempty"`
+	// To is the current value, nil when removed.
+	To any `json:"to,omitempty"`
+	// AddedItems are the entries only in the current list, for lists of values.
+	AddedItems []string `json:"addedItems,omitempty"`
+	// RemovedItems are the entries only in the previous list, for lists of values.
+	RemovedItems []string `json:"removedItems,omitempty"`
+}
+
+// Result holds the changes between two specs, sorted by field.
+type Result struct {
+	// Changes are the changed fields.
+	Changes []Change `json:"changes,omitempty"`
+	// AddedPackages are the packages only in the current spec.
+	AddedPackages []string `json:"addedPackages,omitempty"`
+	// RemovedPackages are the packages only in the previous spec.
+	RemovedPackages []string `json:"removedPackages,omitempty"`
+	// AddedRepositories are the repositories only in the current spec.
+	AddedRepositories []string `json:"addedRepositories,omitempty"`
+	// RemovedRepositories are the repositories only in the previous spec.
+	RemovedRepositories []string `json:"removedRepositories,omitempty"`
+	// InvalidatesCache reports whether a change affects the build inputs.
+	InvalidatesCache bool `json:"invalidatesCache"`
+}
+
+// Empty reports whether the specs are equivalent.
+func (r Result) Empty() bool {
+	return len(r.Changes) == 0
+}
+
+// String renders the diff as text, one line per change: "+" for added fields, "-" for removed
+// ones and "~" for modified ones.
+func (r Result) String() string {
+	if r.Empty() {
+		return "No spec changes.\n"
+	}
+
+	var b strings.Builder
+	for _, c := range r.Changes {
+		switch {
+		case len(c.AddedItems) > 0 || len(c.RemovedItems) > 0:
+			fmt.Fprintf(&b, "%s %s:%s\n", c.Kind.symbol(), c.Field, items(c))
+		case c.Kind == Added:
+			fmt.Fprintf(&b, "+ %s: %s\n", c.Field, formatValue(c.To))
+		case c.Kind == Removed:
+			fmt.Fprintf(&b, "- %s: %s\n", c.Field, formatValue(c.From))
+		default:
+			fmt.Fprintf(&b, "~ %s: %s -> %s\n", c.Field, formatValue(c.From), formatValue(c.To))
+		}
+	}
+
+	if r.InvalidatesCache {
+		b.WriteString("Caches are invalidated.\n")
+	} else {
+		b.WriteString("Caches are kept.\n")
+	}
+
+	return b.String()
+}
+
+// Markdown renders the diff as a Markdown section, suitable for release notes and pull request
+// comments.
+func (r Result) Markdown() string {
+	var b strings.Builder
+
+	b.WriteString("## Spec changes\n\n")
+	if r.Empty() {
+		b.WriteString("No spec changes.\n")
+		return b.String()
+	}
+
+	if r.InvalidatesCache {
+		fmt.Fprintf(&b, "%d fields changed; caches are invalidated.\n", len(r.Changes))
+	} else {
+		fmt.Fprintf(&b, "%d fields changed; caches are kept.\n", len(r.Changes))
+	}
+
+	b.WriteString("\n| Field | Change | From | To |\n| --- | --- | --- | --- |\n")
+	for _, c := range r.Changes {
+		from, to := formatValue(c.From), formatValue(c.To)
+		if len(c.AddedItems) > 0 || len(c.RemovedItems) > 0 {
+			from, to = strings.Join(c.RemovedItems, ", "), strings.Join(c.AddedItems, ", ")
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n",
+			escapeCell(c.Field), c.Kind, escapeCell(orDash(from)), escapeCell(orDash(to)))
+	}
+
+	return b.String()
+}
+
+// Differ compares specs.
+type Differ struct {
+	// cacheNeutral are the top-level fields whose changes don't invalidate caches.
+	cacheNeutral []string
+}
+
+// NewDiffer creates a Differ treating DefaultCacheNeutralFields as cache neutral.
+func NewDiffer() *Differ {
+	return &Differ{cacheNeutral: slices.Clone(DefaultCacheNeutralFields)}
+}
+
+// WithCacheNeutral adds top-level fields whose changes don't invalidate caches.
+func (d *Differ) WithCacheNeutral(fields ...string) *Differ {
+	d.cacheNeutral = append(d.cacheNeutral, fields...)
+	return d
+}
+
+// Diff compares the specs with the default Differ, see Differ.Diff.
+func Diff(previous, current any) (Result, error) {
+	return NewDiffer().Diff(previous, current)
+}
+
+// DiffBytes compares serialized specs with the default Differ, see Differ.DiffBytes.
+func DiffBytes(previous, current []byte) (Result, error) {
+	return NewDiffer().DiffBytes(previous, current)
+}
+
+// Diff compares a previous and a current spec, any values with a JSON form (e.g. apkox.ApkoSpec).
+//
+// Returns:
+//   - The changes between the specs.
+//   - An error if a spec cannot be encoded, or an inline configuration is not valid YAML.
+func (d *Differ) Diff(previous, current any) (Result, error) {
+	before, err := normalize(previous)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to encode previous spec: %w", err)
+	}
+
+	after, err := normalize(current)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to encode current spec: %w", err)
+	}
+
+	return d.diff(before, after)
+}
+
+// DiffBytes compares a previous and a current serialized spec, YAML or JSON. Spec files with a
+// kind and a spec (see configx) are compared by their spec.
+//
+// Returns:
+//   - The changes between the specs.
+//   - An error if a spec cannot be parsed, or the files describe different kinds of builders.
+func (d *Differ) DiffBytes(previous, current []byte) (Result, error) {
+	before, beforeKind, err := parseSpec(previous)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to parse previous spec: %w", err)
+	}
+
+	after, afterKind, err := parseSpec(current)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to parse current spec: %w", err)
+	}
+
+	if beforeKind != afterKind {
+		return Result{}, fmt.Errorf("cannot diff a %q spec with a %q spec", beforeKind, afterKind)
+	}
+
+	return d.diff(before, after)
+}
+
+// diff compares normalized specs.
+func (d *Differ) diff(before, after any) (Result, error) {
+	var changes []Change
+	if err := compare("", before, after, &changes); err != nil {
+		return Result{}, err
+	}
+
+	slices.SortFunc(changes, func(a, b Change) int { return strings.Compare(a.Field, b.Field) })
+
+	result := Result{Changes: changes}
+	for _, c := range changes {
+		if slices.Contains(PackageFields, c.Field) {
+			result.AddedPackages = append(result.AddedPackages, c.AddedItems...)
+			result.RemovedPackages = append(result.RemovedPackages, c.RemovedItems...)
+		}
+		if slices.Contains(RepositoryFields, c.Field) {
+			result.AddedRepositories = append(result.AddedRepositories, c.AddedItems...)
+			result.RemovedRepositories = append(result.RemovedRepositories, c.RemovedItems...)
+		}
+
+		top, _, _ := strings.Cut(c.Field, ".")
+		if !slices.Contains(d.cacheNeutral, top) {
+			result.InvalidatesCache = true
+		}
+	}
+
+	return result, nil
+}
+
+// compare appends the changes between 'a' and 'b' at 'path' to 'changes'.
+func compare(path string, a, b any, changes *[]Change) error {
+	if slices.Contains(EmbeddedConfigFields, path) {
+		var err error
+		if a, err = parseEmbedded(a); err != nil {
+			return fmt.Errorf("invalid previous %s: %w", path, err)
+		}
+		if b, err = parseEmbedded(b); err != nil {
+			return fmt.Errorf("invalid current %s: %w", path, err)
+		}
+	}
+
+	am, aIsMap := asMap(a)
+	bm, bIsMap := asMap(b)
+	if aIsMap && bIsMap {
+		keys := map[string]bool{}
+		for k := range am {
+			keys[k] = true
+		}
+		for k := range bm {
+			keys[k] = true
+		}
+
+		for _, k := range mapx.SortedKeys(keys) {
+			if err := compare(join(path, k), am[k], bm[k], changes); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if reflect.DeepEqual(a, b) || (isZero(a) && isZero(b)) {
+		return nil
+	}
+
+	change := Change{Field: path, Kind: Modified, From: a, To: b}
+	switch {
+	case isZero(a):
+		change.Kind, change.From = Added, nil
+	case isZero(b):
+		change.Kind, change.To = Removed, nil
+	}
+
+	if as, ok := asStrings(a); ok {
+		if bs, ok := asStrings(b); ok {
+			change.AddedItems, change.RemovedItems = subtract(bs, as), subtract(as, bs)
+		}
+	}
+
+	*changes = append(*changes, change)
+
+	return nil
+}
+
+// normalize returns the JSON form of 'v' as maps, slices and scalars.
+func normalize(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// parseSpec parses a serialized spec, and unwraps the spec of spec files.
+func parseSpec(data []byte) (any, string, error) {
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, "", err
+	}
+
+	doc, err := normalize(doc)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if m, ok := doc.(map[string]any); ok {
+		if kind, ok := m["kind"].(string); ok {
+			if spec, ok := m["spec"]; ok {
+				return spec, kind, nil
+			}
+		}
+	}
+
+	return doc, "", nil
+}
+
+// parseEmbedded parses a YAML configuration held in a string field.
+func parseEmbedded(v any) (any, error) {
+	s, ok := v.(string)
+	if !ok || s == "" {
+		return v, nil
+	}
+
+	var doc any
+	if err := yaml.Unmarshal([]byte(s), &doc); err != nil {
+		return nil, err
+	}
+
+	return normalize(doc)
+}
+
+// asMap returns 'v' as a map. Zero values are empty maps, so the keys of a map added or removed
+// are reported one by one.
+func asMap(v any) (map[string]any, bool) {
+	if m, ok := v.(map[string]any); ok {
+		return m, true
+	}
+
+	return nil, v == nil
+}
+
+// asStrings returns the entries of a list of scalar values. Zero values are empty lists.
+func asStrings(v any) ([]string, bool) {
+	if isZero(v) {
+		return nil, true
+	}
+
+	list, ok := v.([]any)
+	if !ok {
+		return nil, false
+	}
+
+	out := make([]string, 0, len(list))
+	for _, e := range list {
+		switch e.(type) {
+		case map[string]any, []any:
+			return nil, false
+		}
+		out = append(out, fmt.Sprint(e))
+	}
+
+	return out, true
+}
+
+// isZero reports whether 'v' is absent or empty.
+func isZero(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+// subtract returns the entries of 'a' not in 'b', in order of 'a'.
+func subtract(a, b []string) []string {
+	var out []string
+	for _, e := range a {
+		if !slices.Contains(b, e) {
+			out = append(out, e)
+		}
+	}
+
+	return out
+}
+
+// join appends 'key' to the dotted 'path'.
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// symbol returns the prefix of the change in String.
+func (k ChangeKind) symbol() string {
+	switch k {
+	case Added:
+		return "+"
+	case Removed:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+// items renders the added and removed entries of a list change.
+func items(c Change) string {
+	var s string
+	for _, e := range c.AddedItems {
+		s += " +" + e
+	}
+	for _, e := range c.RemovedItems {
+		s += " -" + e
+	}
+
+	return s
+}
+
+// formatValue renders a spec value: strings verbatim, other values as JSON.
+func formatValue(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(data)
+}
+
+// orDash returns "-" for empty cells.
+func orDash(s string) string {
+	if s == "" || s == "null" {
+		return "-"
+	}
+
+	return s
+}
+
+// escapeCell escapes the characters of 's' that would break a Markdown table cell.
+func escapeCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
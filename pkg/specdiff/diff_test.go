@@ -0,0 +1,183 @@
+package specdiff
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const previousConfig = `contents:
+  repositories:
+    - https://packages.wolfi.dev/os
+  packages:
+    - wolfi-base
+    - curl
+`
+
+const currentConfig = `contents:
+  repositories:
+    - https://packages.wolfi.dev/os
+    - https://packages.example.com/extras
+  packages:
+    - wolfi-base
+    - jq
+`
+
+func TestDiff(t *testing.T) {
+	base := apkox.ApkoSpec{
+		ConfigFile:    "apko.yaml",
+		OutputImage:   "registry.example.com/base",
+		Tag:           "v1",
+		OutputTarball: "/mnt/image.tar",
+		Keyrings:      []string{"/keys/a.rsa.pub"},
+		Annotations:   map[string]string{"team": "platform"},
+	}
+
+	tests := []struct {
+		name       string
+		change     func(s *apkox.ApkoSpec)
+		want       []Change
+		invalidate bool
+	}{
+		{
+			name:   "no changes",
+			change: func(*apkox.ApkoSpec) {},
+		},
+		{
+			name:   "tag changes keep caches",
+			change: func(s *apkox.ApkoSpec) { s.Tag = "v2"; s.LogLevel = "debug" },
+			want: []Change{
+				{Field: "logLevel", Kind: Added, To: "debug"},
+				{Field: "tag", Kind: Modified, From: "v1", To: "v2"},
+			},
+		},
+		{
+			name: "keyrings and annotations invalidate caches",
+			change: func(s *apkox.ApkoSpec) {
+				s.Keyrings = []string{"/keys/b.rsa.pub"}
+				s.Annotations = map[string]string{"team": "platform", "env": "prod"}
+			},
+			want: []Change{
+				{Field: "annotations.env", Kind: Added, To: "prod"},
+				{
+					Field: "keyrings", Kind: Modified,
+					From: []any{"/keys/a.rsa.pub"}, To: []any{"/keys/b.rsa.pub"},
+					AddedItems: []string{"/keys/b.rsa.pub"}, RemovedItems: []string{"/keys/a.rsa.pub"},
+				},
+			},
+			invalidate: true,
+		},
+		{
+			name:   "removed fields",
+			change: func(s *apkox.ApkoSpec) { s.Annotations = nil },
+			want: []Change{
+				{Field: "annotations.team", Kind: Removed, From: "platform"},
+			},
+			invalidate: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := base
+			tt.change(&current)
+
+			diff, err := Diff(base, current)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, diff.Changes)
+			assert.Equal(t, tt.invalidate, diff.InvalidatesCache)
+			assert.Equal(t, len(tt.want) == 0, diff.Empty())
+		})
+	}
+}
+
+func TestDiffPackagesAndRepositories(t *testing.T) {
+	previous := apkox.ApkoSpec{InlineConfig: previousConfig, PackageAppend: []string{"bash"}}
+	current := apkox.ApkoSpec{InlineConfig: currentConfig, PackageAppend: []string{"bash", "git"}}
+
+	diff, err := Diff(previous, current)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"jq", "git"}, diff.AddedPackages)
+	assert.Equal(t, []string{"curl"}, diff.RemovedPackages)
+	assert.Equal(t, []string{"https://packages.example.com/extras"}, diff.AddedRepositories)
+	assert.Empty(t, diff.RemovedRepositories)
+	assert.True(t, diff.InvalidatesCache)
+
+	// Changes are sorted by field, whatever the source of the packages.
+	fields := make([]string, 0, len(diff.Changes))
+	for _, c := range diff.Changes {
+		fields = append(fields, c.Field)
+	}
+	assert.Equal(t, []string{
+		"inlineConfig.contents.packages",
+		"inlineConfig.contents.repositories",
+		"packageAppend",
+	}, fields)
+}
+
+func TestDiffInvalidInlineConfig(t *testing.T) {
+	_, err := Diff(apkox.ApkoSpec{InlineConfig: "a: [b"}, apkox.ApkoSpec{})
+	assert.Error(t, err)
+}
+
+func TestDifferWithCacheNeutral(t *testing.T) {
+	previous := apkox.ApkoSpec{Workdir: "/a"}
+	current := apkox.ApkoSpec{Workdir: "/b"}
+
+	diff, err := Diff(previous, current)
+	require.NoError(t, err)
+	assert.True(t, diff.InvalidatesCache)
+
+	diff, err = NewDiffer().WithCacheNeutral("workdir").Diff(previous, current)
+	require.NoError(t, err)
+	assert.False(t, diff.InvalidatesCache)
+}
+
+func TestDiffBytes(t *testing.T) {
+	previous := []byte(`apiVersion: daggerx/v1
+kind: apko
+spec:
+  configFile: apko.yaml
+  tag: v1
+`)
+	current := []byte(`{"apiVersion": "daggerx/v1", "kind": "apko",
+  "spec": {"configFile": "apko.yaml", "tag": "v2"}}`)
+
+	diff, err := DiffBytes(previous, current)
+	require.NoError(t, err)
+	assert.Equal(t, []Change{{Field: "tag", Kind: Modified, From: "v1", To: "v2"}}, diff.Changes)
+
+	_, err = DiffBytes(previous, []byte("kind: melange\nspec: {}\n"))
+	assert.ErrorContains(t, err, `cannot diff a "apko" spec with a "melange" spec`)
+
+	_, err = DiffBytes([]byte("a: [b"), current)
+	assert.ErrorContains(t, err, "failed to parse previous spec")
+}
+
+func TestResultString(t *testing.T) {
+	diff, err := Diff(
+		apkox.ApkoSpec{Tag: "v1", PackageAppend: []string{"curl"}, CacheDir: "/cache"},
+		apkox.ApkoSpec{Tag: "v2", PackageAppend: []string{"jq"}},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "- cacheDir: /cache\n"+
+		"~ packageAppend: +jq -curl\n"+
+		"~ tag: v1 -> v2\n"+
+		"Caches are invalidated.\n", diff.String())
+	assert.Equal(t, "No spec changes.\n", Result{}.String())
+}
+
+func TestResultMarkdown(t *testing.T) {
+	diff, err := Diff(apkox.ApkoSpec{Tag: "v1"}, apkox.ApkoSpec{Tag: "v2", OutputImage: "a|b"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "## Spec changes\n\n2 fields changed; caches are kept.\n\n"+
+		"| Field | Change | From | To |\n| --- | --- | --- | --- |\n"+
+		"| outputImage | added | - | a\\|b |\n"+
+		"| tag | modified | v1 | v2 |\n", diff.Markdown())
+	assert.Contains(t, Result{}.Markdown(), "No spec changes.")
+}
@@ -0,0 +1,99 @@
+package sqlmigratex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAtlasBuilderCommand(t *testing.T) {
+	dsn := secretsx.FromEnv("dsn", "CI_DATABASE_URL")
+
+	tests := []struct {
+		name    string
+		builder *AtlasBuilder
+		mode    Mode
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "apply",
+			builder: NewAtlasBuilder("migrations").WithDSN(dsn).WithRevisionsSchema("atlas"),
+			mode:    ModeApply,
+			want:    `atlas migrate apply --dir file://migrations --revisions-schema atlas --url "$ATLAS_URL"`,
+		},
+		{
+			name: "dry-run",
+			builder: NewAtlasBuilder("file://db migrations").WithDSN(dsn).
+				WithBaseline("20240102150405").WithAllowDirty(true).WithAmount(2),
+			mode: ModeDryRun,
+			want: `atlas migrate apply --dir 'file://db migrations' --baseline 20240102150405 --allow-dirty ` +
+				`--dry-run --url "$ATLAS_URL" 2`,
+		},
+		{
+			name:    "status",
+			builder: NewAtlasBuilder("migrations").WithDatabase(NewPostgresDatabase(DatabaseOpts{})),
+			mode:    ModeStatus,
+			want:    `atlas migrate status --dir file://migrations --url "$ATLAS_URL"`,
+		},
+		{
+			name:    "missing directory",
+			builder: NewAtlasBuilder("").WithDSN(dsn),
+			mode:    ModeApply,
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "missing database",
+			builder: NewAtlasBuilder("migrations"),
+			mode:    ModeDryRun,
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "negative amount",
+			builder: NewAtlasBuilder("migrations").WithDSN(dsn).WithAmount(-1),
+			mode:    ModeApply,
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "invalid baseline",
+			builder: NewAtlasBuilder("migrations").WithDSN(dsn).WithBaseline("v1"),
+			mode:    ModeApply,
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "amount with status",
+			builder: NewAtlasBuilder("migrations").WithDSN(dsn).WithAmount(1),
+			mode:    ModeStatus,
+			wantErr: errorsx.ErrUnsupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.Command(tt.mode)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []string{"sh", "-c", tt.want}, cmd)
+		})
+	}
+}
+
+func TestAtlasBuilderEnvAndSecrets(t *testing.T) {
+	b := NewAtlasBuilder("migrations").WithDSN(secretsx.FromEnv("dsn", "CI_DATABASE_URL"))
+	assert.Nil(t, b.Env())
+	require.Len(t, b.Secrets(), 1)
+	assert.Equal(t, AtlasURLEnvVar, b.Secrets()[0].Target)
+
+	db := NewPostgresDatabase(DatabaseOpts{})
+	ephemeral := NewAtlasBuilder("migrations").WithDatabase(db)
+	assert.Equal(t, []types.DaggerEnvVars{{Name: AtlasURLEnvVar, Value: db.URL()}}, ephemeral.Env())
+	assert.Nil(t, ephemeral.Secrets())
+}
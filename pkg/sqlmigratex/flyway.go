@@ -0,0 +1,271 @@
+package sqlmigratex
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+const (
+	// FlywayURLEnvVar is the environment variable flyway reads the JDBC URL from.
+	FlywayURLEnvVar = "FLYWAY_URL"
+	// FlywayUserEnvVar is the environment variable flyway reads the database user from.
+	FlywayUserEnvVar = "FLYWAY_USER"
+	// FlywayPasswordEnvVar is the environment variable flyway reads the database password from.
+	FlywayPasswordEnvVar = "FLYWAY_PASSWORD"
+)
+
+// FlywayBuilder generates the flyway commands of migration locations. The dry-run of flyway
+// requires a commercial edition: ModeDryRun runs flyway validate, which checks the applied
+// migrations against the available ones.
+type FlywayBuilder struct {
+	// locations are the migration locations (e.g. "filesystem:sql").
+	locations []string
+
+	// schemas are the schemas managed by flyway.
+	schemas []string
+
+	// target is the version ModeApply migrates up to.
+	target string
+
+	// baselineOnMigrate baselines a non-empty database without a schema history table.
+	baselineOnMigrate bool
+
+	// baselineVersion is the version of the baseline.
+	baselineVersion string
+
+	// user is the database user.
+	user string
+
+	// password is the secret holding the password of the user.
+	password *secretsx.SecretRef
+
+	// conn is the database.
+	conn connection
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewFlywayBuilder creates a FlywayBuilder of the migrations in the local directories 'dirs'.
+// Locations with a prefix (e.g. "classpath:db/migration") are kept as they are.
+func NewFlywayBuilder(dirs ...string) *FlywayBuilder {
+	b := &FlywayBuilder{}
+	for _, d := range dirs {
+		if !strings.Contains(d, ":") {
+			d = "filesystem:" + d
+		}
+		b.locations = append(b.locations, d)
+	}
+
+	return b
+}
+
+// WithSchema adds schemas managed by flyway. The first one holds the schema history table.
+func (b *FlywayBuilder) WithSchema(schemas ...string) *FlywayBuilder {
+	b.schemas = append(b.schemas, schemas...)
+	return b
+}
+
+// WithTarget sets the version ModeApply migrates up to.
+func (b *FlywayBuilder) WithTarget(version string) *FlywayBuilder {
+	b.target = version
+	return b
+}
+
+// WithBaselineOnMigrate baselines a non-empty database without a schema history table at
+// 'version' (empty uses the flyway default) before migrating it.
+func (b *FlywayBuilder) WithBaselineOnMigrate(version string) *FlywayBuilder {
+	b.baselineOnMigrate = true
+	b.baselineVersion = version
+	return b
+}
+
+// WithDSN uses the JDBC URL held by 'ref'. The secret is exposed as FlywayURLEnvVar; 'ref' is
+// left unchanged.
+func (b *FlywayBuilder) WithDSN(ref *secretsx.SecretRef) *FlywayBuilder {
+	b.conn.setDSN(ref, FlywayURLEnvVar)
+	return b
+}
+
+// WithUser sets the database user of the DSN.
+func (b *FlywayBuilder) WithUser(user string) *FlywayBuilder {
+	b.user = user
+	return b
+}
+
+// WithPassword uses the password held by 'ref' for the user of the DSN. The secret is exposed
+// as FlywayPasswordEnvVar; 'ref' is left unchanged.
+func (b *FlywayBuilder) WithPassword(ref *secretsx.SecretRef) *FlywayBuilder {
+	if ref == nil {
+		b.password = nil
+		return b
+	}
+
+	password := *ref
+	b.password = password.AsEnv(FlywayPasswordEnvVar)
+	return b
+}
+
+// WithDatabase connects to the ephemeral database 'db', with its user and password.
+func (b *FlywayBuilder) WithDatabase(db Database) *FlywayBuilder {
+	b.conn.setDatabase(db)
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *FlywayBuilder) WithLogger(logger *slog.Logger) *FlywayBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks the options shared by the commands.
+func (b *FlywayBuilder) validate() error {
+	if len(b.locations) == 0 {
+		return errorsx.MissingField(builderName, "locations", "at least one migration location is required")
+	}
+
+	if err := b.conn.validate(); err != nil {
+		return err
+	}
+
+	if b.conn.database != nil && (b.user != "" || b.password != nil) {
+		return errorsx.ConflictingOptions(builderName, []string{"database", "user", "password"},
+			"the ephemeral database sets its own user and password")
+	}
+
+	if b.password != nil {
+		if err := b.password.Validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range []struct {
+		field   string
+		version string
+	}{
+		{"target", b.target},
+		{"baselineVersion", b.baselineVersion},
+	} {
+		if v.version != "" && !versionRegex.MatchString(v.version) {
+			return errorsx.InvalidValue(builderName, v.field, v.version, "invalid migration version: %q", v.version)
+		}
+	}
+
+	return nil
+}
+
+// Command generates the flyway command of 'mode': migrate, validate or info.
+//
+// Returns:
+//   - The flyway command.
+//   - An error if the locations or database are missing or invalid, or migrate options are set
+//     outside ModeApply.
+func (b *FlywayBuilder) Command(mode Mode) ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	var verb string
+	switch mode {
+	case ModeApply:
+		verb = "migrate"
+	case ModeDryRun:
+		verb = "validate"
+	case ModeStatus:
+		verb = "info"
+	default:
+		return nil, errorsx.InvalidValue(builderName, "mode", mode, "unsupported migration mode: %q", mode)
+	}
+
+	if mode != ModeApply {
+		for _, o := range []struct {
+			option string
+			set    bool
+		}{
+			{"target", b.target != ""},
+			{"baselineOnMigrate", b.baselineOnMigrate},
+		} {
+			if o.set {
+				return nil, errorsx.Unsupported(builderName, o.option, mode, "flyway %s does not support %s", verb, o.option)
+			}
+		}
+	}
+
+	cmd := []string{"flyway", "-locations=" + strings.Join(b.locations, ",")}
+	if len(b.schemas) > 0 {
+		cmd = append(cmd, "-schemas="+strings.Join(b.schemas, ","))
+	}
+
+	if b.target != "" {
+		cmd = append(cmd, "-target="+b.target)
+	}
+
+	if b.baselineOnMigrate {
+		cmd = append(cmd, "-baselineOnMigrate=true")
+		if b.baselineVersion != "" {
+			cmd = append(cmd, "-baselineVersion="+b.baselineVersion)
+		}
+	}
+
+	cmd = append(cmd, verb)
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Env returns the environment variables of the commands: the user and, for an ephemeral
+// database, its JDBC URL, user and password. The DSN and password secrets are not included
+// (see Secrets).
+func (b *FlywayBuilder) Env() []types.DaggerEnvVars {
+	if db := b.conn.database; db != nil {
+		return []types.DaggerEnvVars{
+			{Name: FlywayURLEnvVar, Value: db.JDBCURL()},
+			{Name: FlywayUserEnvVar, Value: db.User},
+			{Name: FlywayPasswordEnvVar, Value: db.Password},
+		}
+	}
+
+	if b.user == "" {
+		return nil
+	}
+
+	return []types.DaggerEnvVars{{Name: FlywayUserEnvVar, Value: b.user}}
+}
+
+// Secrets returns the secrets the commands require: the DSN and the password, if any.
+func (b *FlywayBuilder) Secrets() []*secretsx.SecretRef {
+	secrets := b.conn.secrets()
+	if b.password != nil {
+		secrets = append(secrets, b.password)
+	}
+
+	return secrets
+}
+
+// ForMode binds the builder to 'mode', for the pipeline and executor layers running a single
+// command (see builderx).
+func (b *FlywayBuilder) ForMode(mode Mode) *ModeBuilder {
+	return &ModeBuilder{migration: b, mode: mode}
+}
+
+// quiet returns a copy of the builder without logger.
+func (b *FlywayBuilder) quiet() migration {
+	quiet := *b
+	quiet.logger = nil
+
+	return &quiet
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to the
+// flyway commands of every mode.
+func (b *FlywayBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("flyway", b, NewFlywayBuilder(), explainModes(b.Command)...)
+}
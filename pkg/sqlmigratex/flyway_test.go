@@ -0,0 +1,104 @@
+package sqlmigratex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlywayBuilderCommand(t *testing.T) {
+	dsn := secretsx.FromEnv("dsn", "CI_JDBC_URL")
+
+	tests := []struct {
+		name    string
+		builder *FlywayBuilder
+		mode    Mode
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "apply",
+			builder: NewFlywayBuilder("sql", "classpath:db/migration").WithDSN(dsn).
+				WithSchema("app", "audit").WithTarget("1.4").WithBaselineOnMigrate("1"),
+			mode: ModeApply,
+			want: []string{
+				"flyway", "-locations=filesystem:sql,classpath:db/migration", "-schemas=app,audit",
+				"-target=1.4", "-baselineOnMigrate=true", "-baselineVersion=1", "migrate",
+			},
+		},
+		{
+			name:    "dry-run",
+			builder: NewFlywayBuilder("sql").WithDSN(dsn),
+			mode:    ModeDryRun,
+			want:    []string{"flyway", "-locations=filesystem:sql", "validate"},
+		},
+		{
+			name:    "status",
+			builder: NewFlywayBuilder("sql").WithDatabase(NewPostgresDatabase(DatabaseOpts{})),
+			mode:    ModeStatus,
+			want:    []string{"flyway", "-locations=filesystem:sql", "info"},
+		},
+		{
+			name:    "missing locations",
+			builder: NewFlywayBuilder().WithDSN(dsn),
+			mode:    ModeApply,
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "user with an ephemeral database",
+			builder: NewFlywayBuilder("sql").WithDatabase(NewPostgresDatabase(DatabaseOpts{})).WithUser("app"),
+			mode:    ModeApply,
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+		{
+			name:    "invalid target",
+			builder: NewFlywayBuilder("sql").WithDSN(dsn).WithTarget("latest"),
+			mode:    ModeApply,
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "target with status",
+			builder: NewFlywayBuilder("sql").WithDSN(dsn).WithTarget("2"),
+			mode:    ModeStatus,
+			wantErr: errorsx.ErrUnsupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.Command(tt.mode)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestFlywayBuilderEnvAndSecrets(t *testing.T) {
+	password := secretsx.FromEnv("db-password", "CI_DB_PASSWORD")
+	b := NewFlywayBuilder("sql").
+		WithDSN(secretsx.FromEnv("dsn", "CI_JDBC_URL")).
+		WithUser("app").
+		WithPassword(password)
+
+	assert.Equal(t, []types.DaggerEnvVars{{Name: FlywayUserEnvVar, Value: "app"}}, b.Env())
+	require.Len(t, b.Secrets(), 2)
+	assert.Equal(t, FlywayURLEnvVar, b.Secrets()[0].Target)
+	assert.Equal(t, FlywayPasswordEnvVar, b.Secrets()[1].Target)
+	assert.Equal(t, "CI_DB_PASSWORD", password.Target)
+
+	db := NewPostgresDatabase(DatabaseOpts{})
+	assert.Equal(t, []types.DaggerEnvVars{
+		{Name: FlywayURLEnvVar, Value: db.JDBCURL()},
+		{Name: FlywayUserEnvVar, Value: "postgres"},
+		{Name: FlywayPasswordEnvVar, Value: "postgres"},
+	}, NewFlywayBuilder("sql").WithDatabase(db).Env())
+}
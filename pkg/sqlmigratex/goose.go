@@ -0,0 +1,214 @@
+package sqlmigratex
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// GooseDSNEnvVar is the environment variable goose reads the connection string from.
+const GooseDSNEnvVar = "GOOSE_DBSTRING"
+
+// GooseDrivers are the goose database drivers.
+var GooseDrivers = []string{
+	"postgres", "pgx", "mysql", "sqlite3", "mssql", "redshift", "tidb", "clickhouse", "vertica",
+	"ydb", "turso", "starrocks",
+}
+
+// GooseBuilder generates the goose commands of a migrations directory. goose has no dry-run:
+// ModeDryRun runs goose validate, which checks the migration files without a database.
+type GooseBuilder struct {
+	// dir is the migrations directory.
+	dir string
+
+	// driver is the goose database driver (GOOSE_DRIVER).
+	driver string
+
+	// table is the version table.
+	table string
+
+	// version is the version to migrate up to. Empty migrates to the latest version.
+	version string
+
+	// allowMissing applies the missing (out-of-order) migrations.
+	allowMissing bool
+
+	// conn is the database.
+	conn connection
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewGooseBuilder creates a GooseBuilder of the migrations in 'dir'.
+func NewGooseBuilder(dir string) *GooseBuilder {
+	return &GooseBuilder{dir: dir}
+}
+
+// WithDriver sets the goose database driver, one of GooseDrivers. It defaults to the engine of
+// the ephemeral database.
+func (b *GooseBuilder) WithDriver(driver string) *GooseBuilder {
+	b.driver = driver
+	return b
+}
+
+// WithTable sets the version table. It defaults to goose_db_version.
+func (b *GooseBuilder) WithTable(table string) *GooseBuilder {
+	b.table = table
+	return b
+}
+
+// WithVersion sets the version ModeApply migrates up to.
+func (b *GooseBuilder) WithVersion(version string) *GooseBuilder {
+	b.version = version
+	return b
+}
+
+// WithAllowMissing sets whether missing (out-of-order) migrations are applied.
+func (b *GooseBuilder) WithAllowMissing(allow bool) *GooseBuilder {
+	b.allowMissing = allow
+	return b
+}
+
+// WithDSN uses the connection string held by 'ref'. The secret is exposed as GooseDSNEnvVar;
+// 'ref' is left unchanged.
+func (b *GooseBuilder) WithDSN(ref *secretsx.SecretRef) *GooseBuilder {
+	b.conn.setDSN(ref, GooseDSNEnvVar)
+	return b
+}
+
+// WithDatabase connects to the ephemeral database 'db'.
+func (b *GooseBuilder) WithDatabase(db Database) *GooseBuilder {
+	b.conn.setDatabase(db)
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *GooseBuilder) WithLogger(logger *slog.Logger) *GooseBuilder {
+	b.logger = logger
+	return b
+}
+
+// resolvedDriver returns the driver, defaulting to the engine of the ephemeral database.
+func (b *GooseBuilder) resolvedDriver() string {
+	if b.driver == "" && b.conn.database != nil {
+		return string(b.conn.database.Engine)
+	}
+
+	return b.driver
+}
+
+// Command generates the goose command of 'mode': up (or up-to the version), validate or
+// status.
+//
+// Returns:
+//   - The goose command.
+//   - An error if the directory, driver or database is missing or invalid, or the version is
+//     set outside ModeApply.
+func (b *GooseBuilder) Command(mode Mode) ([]string, error) {
+	if b.dir == "" {
+		return nil, errorsx.MissingField(builderName, "dir", "migrations directory is required")
+	}
+
+	if b.version != "" {
+		if mode != ModeApply {
+			return nil, errorsx.Unsupported(builderName, "version", mode, "goose %s does not support a version", mode)
+		}
+
+		if !versionRegex.MatchString(b.version) {
+			return nil, errorsx.InvalidValue(builderName, "version", b.version, "invalid migration version: %q", b.version)
+		}
+	}
+
+	cmd := []string{"goose", "-dir", b.dir}
+	if b.table != "" {
+		cmd = append(cmd, "-table", b.table)
+	}
+
+	switch mode {
+	case ModeDryRun:
+		cmd = append(cmd, "validate")
+		logx.Command(context.Background(), b.logger, builderName, cmd)
+
+		return cmd, nil
+	case ModeApply, ModeStatus:
+	default:
+		return nil, errorsx.InvalidValue(builderName, "mode", mode, "unsupported migration mode: %q", mode)
+	}
+
+	driver := b.resolvedDriver()
+	if driver == "" {
+		return nil, errorsx.MissingField(builderName, "driver", "goose driver is required")
+	}
+
+	if !slices.Contains(GooseDrivers, driver) {
+		return nil, errorsx.InvalidValue(builderName, "driver", driver, "unsupported goose driver: %q", driver)
+	}
+
+	if err := b.conn.validate(); err != nil {
+		return nil, err
+	}
+
+	if mode == ModeApply && b.allowMissing {
+		cmd = append(cmd, "-allow-missing")
+	}
+
+	switch {
+	case mode == ModeStatus:
+		cmd = append(cmd, "status")
+	case b.version != "":
+		cmd = append(cmd, "up-to", b.version)
+	default:
+		cmd = append(cmd, "up")
+	}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Env returns the environment variables of the commands: the driver and, for an ephemeral
+// database, its connection string. The DSN secret is not included (see Secrets).
+func (b *GooseBuilder) Env() []types.DaggerEnvVars {
+	var env []types.DaggerEnvVars
+	if driver := b.resolvedDriver(); driver != "" {
+		env = append(env, types.DaggerEnvVars{Name: "GOOSE_DRIVER", Value: driver})
+	}
+
+	if b.conn.database != nil {
+		env = append(env, types.DaggerEnvVars{Name: GooseDSNEnvVar, Value: b.conn.database.GooseDSN()})
+	}
+
+	return env
+}
+
+// Secrets returns the secrets the commands require: the DSN, if any.
+func (b *GooseBuilder) Secrets() []*secretsx.SecretRef {
+	return b.conn.secrets()
+}
+
+// ForMode binds the builder to 'mode', for the pipeline and executor layers running a single
+// command (see builderx).
+func (b *GooseBuilder) ForMode(mode Mode) *ModeBuilder {
+	return &ModeBuilder{migration: b, mode: mode}
+}
+
+// quiet returns a copy of the builder without logger.
+func (b *GooseBuilder) quiet() migration {
+	quiet := *b
+	quiet.logger = nil
+
+	return &quiet
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to the
+// goose commands of every mode.
+func (b *GooseBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("goose", b, NewGooseBuilder(""), explainModes(b.Command)...)
+}
@@ -0,0 +1,122 @@
+package sqlmigratex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGooseBuilderCommand(t *testing.T) {
+	dsn := secretsx.FromEnv("dsn", "CI_DATABASE_URL")
+
+	tests := []struct {
+		name    string
+		builder *GooseBuilder
+		mode    Mode
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "apply",
+			builder: NewGooseBuilder("db/migrations").WithDriver("postgres").WithDSN(dsn).WithTable("schema_versions"),
+			mode:    ModeApply,
+			want:    []string{"goose", "-dir", "db/migrations", "-table", "schema_versions", "up"},
+		},
+		{
+			name: "apply up to a version",
+			builder: NewGooseBuilder("db/migrations").WithDriver("postgres").WithDSN(dsn).
+				WithVersion("20240102150405").WithAllowMissing(true),
+			mode: ModeApply,
+			want: []string{"goose", "-dir", "db/migrations", "-allow-missing", "up-to", "20240102150405"},
+		},
+		{
+			name:    "dry-run needs no database",
+			builder: NewGooseBuilder("db/migrations"),
+			mode:    ModeDryRun,
+			want:    []string{"goose", "-dir", "db/migrations", "validate"},
+		},
+		{
+			name:    "status with an ephemeral database",
+			builder: NewGooseBuilder("db/migrations").WithDatabase(NewMySQLDatabase(DatabaseOpts{})),
+			mode:    ModeStatus,
+			want:    []string{"goose", "-dir", "db/migrations", "status"},
+		},
+		{
+			name:    "missing directory",
+			builder: NewGooseBuilder(""),
+			mode:    ModeApply,
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "missing driver",
+			builder: NewGooseBuilder("db/migrations").WithDSN(dsn),
+			mode:    ModeApply,
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "unknown driver",
+			builder: NewGooseBuilder("db/migrations").WithDriver("oracle").WithDSN(dsn),
+			mode:    ModeApply,
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "missing database",
+			builder: NewGooseBuilder("db/migrations").WithDriver("postgres"),
+			mode:    ModeStatus,
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "version with status",
+			builder: NewGooseBuilder("db/migrations").WithDriver("postgres").WithDSN(dsn).WithVersion("3"),
+			mode:    ModeStatus,
+			wantErr: errorsx.ErrUnsupported,
+		},
+		{
+			name:    "invalid version",
+			builder: NewGooseBuilder("db/migrations").WithDriver("postgres").WithDSN(dsn).WithVersion("latest"),
+			mode:    ModeApply,
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "unknown mode",
+			builder: NewGooseBuilder("db/migrations").WithDriver("postgres").WithDSN(dsn),
+			mode:    Mode("down"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.Command(tt.mode)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestGooseBuilderEnvAndSecrets(t *testing.T) {
+	dsn := secretsx.FromEnv("dsn", "CI_DATABASE_URL")
+	b := NewGooseBuilder("db/migrations").WithDriver("pgx").WithDSN(dsn)
+
+	assert.Equal(t, []types.DaggerEnvVars{{Name: "GOOSE_DRIVER", Value: "pgx"}}, b.Env())
+	require.Len(t, b.Secrets(), 1)
+	assert.Equal(t, GooseDSNEnvVar, b.Secrets()[0].Target)
+	assert.Equal(t, "CI_DATABASE_URL", dsn.Target)
+
+	db := NewMySQLDatabase(DatabaseOpts{})
+	ephemeral := NewGooseBuilder("db/migrations").WithDatabase(db)
+	assert.Equal(t, []types.DaggerEnvVars{
+		{Name: "GOOSE_DRIVER", Value: "mysql"},
+		{Name: GooseDSNEnvVar, Value: db.GooseDSN()},
+	}, ephemeral.Env())
+	assert.Nil(t, ephemeral.Secrets())
+}
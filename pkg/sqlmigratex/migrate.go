@@ -0,0 +1,183 @@
+// Package sqlmigratex provides builders for the commands of common database migration tools
+// (goose, atlas and flyway): applying migrations, checking them without applying them (dry-run),
+// and reporting their status.
+//
+// The DSN of a database holds its credentials, so the builders take it as a secret exposed as
+// the environment variable the tool reads; it never appears in the generated commands. For the
+// validation of migrations in pipelines, an ephemeral database (see NewPostgresDatabase) can be
+// bound to the migration container and passed to the builders with WithDatabase instead.
+//
+// The commands of the builders depend on a Mode; ForMode binds a builder to one, so the pipeline
+// and executor layers treat it as any other builderx.Builder.
+//
+// Example usage:
+//
+//	db := sqlmigratex.NewPostgresDatabase(sqlmigratex.DatabaseOpts{})
+//
+//	svc, err := db.Service.ToDaggerService(client)
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	goose := sqlmigratex.NewGooseBuilder("db/migrations").WithDatabase(db)
+//
+//	cmd, err := goose.Command(sqlmigratex.ModeApply)
+//	env := goose.Env()
+//	// Run cmd in a container bound with db.Service.Bind(ctr, svc) and env set.
+//
+//	node, err := builderx.Node("migrate", goose.ForMode(sqlmigratex.ModeDryRun))
+package sqlmigratex
+
+import (
+	"regexp"
+
+	"github.com/Excoriate/daggerx/pkg/builderx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the migration builders in errorsx errors.
+const builderName = "sqlmigrate"
+
+// Mode is the mode of a migration command.
+type Mode string
+
+const (
+	// ModeApply applies the pending migrations.
+	ModeApply Mode = "apply"
+	// ModeDryRun checks the pending migrations without applying them.
+	ModeDryRun Mode = "dry-run"
+	// ModeStatus reports the applied and pending migrations.
+	ModeStatus Mode = "status"
+)
+
+// migration is implemented by the migration builders, whose commands depend on a Mode.
+type migration interface {
+	Command(mode Mode) ([]string, error)
+	Env() []types.DaggerEnvVars
+	Secrets() []*secretsx.SecretRef
+	Explain() explainx.Explanation
+	// quiet returns a copy of the builder without logger.
+	quiet() migration
+}
+
+// ModeBuilder is a migration builder bound to a mode, implementing builderx.SecretBuilder. It is
+// created by the ForMode methods of the builders.
+type ModeBuilder struct {
+	// migration is the migration builder.
+	migration migration
+
+	// mode is the mode of the generated command.
+	mode Mode
+}
+
+var _ builderx.SecretBuilder = (*ModeBuilder)(nil)
+
+// Mode returns the mode of the generated command.
+func (b *ModeBuilder) Mode() Mode {
+	return b.mode
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *ModeBuilder) Validate() error {
+	_, err := b.migration.quiet().Command(b.mode)
+	return err
+}
+
+// BuildCommand generates the migration command of the mode.
+func (b *ModeBuilder) BuildCommand() ([]string, error) {
+	return b.migration.Command(b.mode)
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *ModeBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables of the migration builder.
+func (b *ModeBuilder) Env() []types.DaggerEnvVars {
+	return b.migration.Env()
+}
+
+// Secrets returns the secrets of the migration builder.
+func (b *ModeBuilder) Secrets() []*secretsx.SecretRef {
+	return b.migration.Secrets()
+}
+
+// Explain describes the options of the migration builder and its commands in every mode.
+func (b *ModeBuilder) Explain() explainx.Explanation {
+	return b.migration.Explain()
+}
+
+// versionRegex matches migration versions (e.g. "20240102150405", "1.2").
+var versionRegex = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`)
+
+// connection is the database a migration builder connects to: either a DSN secret, or an
+// ephemeral database whose throwaway URL is passed in plain text.
+type connection struct {
+	// dsn is the secret holding the DSN, exposed as the environment variable of the tool.
+	dsn *secretsx.SecretRef
+
+	// database is the ephemeral database.
+	database *Database
+}
+
+// setDSN sets the DSN secret, exposed as 'envVar'. 'ref' is left unchanged.
+func (c *connection) setDSN(ref *secretsx.SecretRef, envVar string) {
+	if ref == nil {
+		c.dsn = nil
+		return
+	}
+
+	dsn := *ref
+	c.dsn = dsn.AsEnv(envVar)
+}
+
+// setDatabase sets the ephemeral database.
+func (c *connection) setDatabase(db Database) {
+	c.database = &db
+}
+
+// validate checks that exactly one of the DSN secret and the ephemeral database is set.
+func (c *connection) validate() error {
+	if c.dsn != nil && c.database != nil {
+		return errorsx.ConflictingOptions(builderName, []string{"dsn", "database"},
+			"a DSN secret and an ephemeral database are mutually exclusive")
+	}
+
+	if c.dsn == nil && c.database == nil {
+		return errorsx.MissingField(builderName, "dsn", "a DSN secret or an ephemeral database is required")
+	}
+
+	if c.dsn != nil {
+		return c.dsn.Validate()
+	}
+
+	return nil
+}
+
+// secrets returns the DSN secret, if any.
+func (c *connection) secrets() []*secretsx.SecretRef {
+	if c.dsn == nil {
+		return nil
+	}
+
+	return []*secretsx.SecretRef{c.dsn}
+}
+
+// explainModes returns the commands of the migration builder 'command' in every mode, for the
+// Explain methods of the builders.
+func explainModes(command func(Mode) ([]string, error)) []explainx.Command {
+	modes := []Mode{ModeApply, ModeDryRun, ModeStatus}
+	commands := make([]explainx.Command, 0, len(modes))
+	for _, mode := range modes {
+		commands = append(commands, explainx.Generate(string(mode), func() ([]string, error) {
+			return command(mode)
+		}))
+	}
+
+	return commands
+}
@@ -0,0 +1,96 @@
+package sqlmigratex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionValidate(t *testing.T) {
+	dsn := secretsx.FromEnv("dsn", "CI_DATABASE_URL")
+
+	tests := []struct {
+		name    string
+		conn    func(c *connection)
+		wantErr error
+	}{
+		{name: "dsn", conn: func(c *connection) { c.setDSN(dsn, GooseDSNEnvVar) }},
+		{name: "database", conn: func(c *connection) { c.setDatabase(NewPostgresDatabase(DatabaseOpts{})) }},
+		{name: "missing", conn: func(*connection) {}, wantErr: errorsx.ErrMissingField},
+		{
+			name: "both",
+			conn: func(c *connection) {
+				c.setDSN(dsn, GooseDSNEnvVar)
+				c.setDatabase(NewPostgresDatabase(DatabaseOpts{}))
+			},
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+		{
+			name: "invalid secret",
+			conn: func(c *connection) {
+				c.setDSN(&secretsx.SecretRef{Name: "dsn", Source: secretsx.SourceEnv}, GooseDSNEnvVar)
+			},
+			wantErr: errorsx.ErrMissingField,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c connection
+			tt.conn(&c)
+
+			err := c.validate()
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+		})
+	}
+}
+
+func TestConnectionCopiesDSN(t *testing.T) {
+	dsn := secretsx.FromEnv("dsn", "CI_DATABASE_URL")
+
+	var c connection
+	c.setDSN(dsn, AtlasURLEnvVar)
+
+	assert.Equal(t, "CI_DATABASE_URL", dsn.Target)
+	require.Len(t, c.secrets(), 1)
+	assert.Equal(t, AtlasURLEnvVar, c.secrets()[0].Target)
+	assert.Equal(t, "CI_DATABASE_URL", c.secrets()[0].Ref)
+
+	c.setDSN(nil, AtlasURLEnvVar)
+	assert.Nil(t, c.secrets())
+}
+
+func TestModeBuilder(t *testing.T) {
+	dsn := secretsx.FromEnv("dsn", "CI_DATABASE_URL")
+	goose := NewGooseBuilder("db/migrations").WithDriver("postgres").WithDSN(dsn)
+
+	b := goose.ForMode(ModeStatus)
+	assert.Equal(t, ModeStatus, b.Mode())
+	require.NoError(t, b.Validate())
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	want, err := goose.Command(ModeStatus)
+	require.NoError(t, err)
+	assert.Equal(t, want, cmd)
+
+	assert.Nil(t, b.Mounts())
+	assert.Equal(t, goose.Env(), b.Env())
+	assert.Equal(t, goose.Secrets(), b.Secrets())
+	assert.Equal(t, goose.Explain(), b.Explain())
+
+	b = NewAtlasBuilder("db/migrations").ForMode(ModeApply)
+	assert.ErrorIs(t, b.Validate(), errorsx.ErrMissingField)
+	_, err = b.BuildCommand()
+	assert.Equal(t, err, b.Validate())
+
+	assert.ErrorIs(t, goose.ForMode("rollback").Validate(), errorsx.ErrInvalidValue)
+}
@@ -0,0 +1,312 @@
+package structuretestx
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the schema version of the generated structure test configurations.
+const SchemaVersion = "2.0.0"
+
+// permissionsRegex matches the permissions of file existence tests (e.g. "-rwxr-xr-x").
+var permissionsRegex = regexp.MustCompile(`^[-dlcbps][-r][-w][-xsS][-r][-w][-xsS][-r][-w][-xtT]$`)
+
+// Config is a container-structure-test configuration.
+type Config struct {
+	SchemaVersion      string              `yaml:"schemaVersion"`
+	GlobalEnvVars      []EnvVar            `yaml:"globalEnvVars,omitempty"`
+	CommandTests       []CommandTest       `yaml:"commandTests,omitempty"`
+	FileExistenceTests []FileExistenceTest `yaml:"fileExistenceTests,omitempty"`
+	FileContentTests   []FileContentTest   `yaml:"fileContentTests,omitempty"`
+	MetadataTest       *MetadataTest       `yaml:"metadataTest,omitempty"`
+}
+
+// EnvVar is an environment variable of a command or of the image metadata.
+type EnvVar struct {
+	Key   string `yaml:"key"`
+	Value string `yaml:"value"`
+}
+
+// CommandTest runs a command in the image and checks its exit code and output. The output checks
+// are regular expressions.
+type CommandTest struct {
+	Name           string     `yaml:"name"`
+	Setup          [][]string `yaml:"setup,omitempty"`
+	Teardown       [][]string `yaml:"teardown,omitempty"`
+	EnvVars        []EnvVar   `yaml:"envVars,omitempty"`
+	Command        string     `yaml:"command"`
+	Args           []string   `yaml:"args,omitempty"`
+	ExitCode       int        `yaml:"exitCode,omitempty"`
+	ExpectedOutput []string   `yaml:"expectedOutput,omitempty"`
+	ExcludedOutput []string   `yaml:"excludedOutput,omitempty"`
+	ExpectedError  []string   `yaml:"expectedError,omitempty"`
+	ExcludedError  []string   `yaml:"excludedError,omitempty"`
+}
+
+// FileExistenceTest checks that a path exists, or not, in the image, with its permissions and
+// owner.
+type FileExistenceTest struct {
+	Name           string `yaml:"name"`
+	Path           string `yaml:"path"`
+	ShouldExist    bool   `yaml:"shouldExist"`
+	Permissions    string `yaml:"permissions,omitempty"`
+	UID            *int   `yaml:"uid,omitempty"`
+	GID            *int   `yaml:"gid,omitempty"`
+	IsExecutableBy string `yaml:"isExecutableBy,omitempty"`
+}
+
+// FileContentTest checks the content of a file of the image against regular expressions.
+type FileContentTest struct {
+	Name             string   `yaml:"name"`
+	Path             string   `yaml:"path"`
+	ExpectedContents []string `yaml:"expectedContents,omitempty"`
+	ExcludedContents []string `yaml:"excludedContents,omitempty"`
+}
+
+// MetadataTest checks the configuration of the image.
+type MetadataTest struct {
+	Env          []EnvVar `yaml:"envVars,omitempty"`
+	Labels       []Label  `yaml:"labels,omitempty"`
+	ExposedPorts []string `yaml:"exposedPorts,omitempty"`
+	Volumes      []string `yaml:"volumes,omitempty"`
+	Entrypoint   []string `yaml:"entrypoint,omitempty"`
+	Cmd          []string `yaml:"cmd,omitempty"`
+	Workdir      string   `yaml:"workdir,omitempty"`
+	User         string   `yaml:"user,omitempty"`
+}
+
+// Label is a label of the image metadata.
+type Label struct {
+	Key     string `yaml:"key"`
+	Value   string `yaml:"value"`
+	IsRegex bool   `yaml:"isRegex,omitempty"`
+}
+
+// ParseConfig parses a container-structure-test configuration in YAML.
+//
+// Returns:
+//   - The configuration.
+//   - An error if the data is not a valid configuration.
+func ParseConfig(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid structure test config: %w", err)
+	}
+
+	return &cfg, cfg.Validate()
+}
+
+// Validate checks that the configuration is complete.
+//
+// Returns:
+//   - An error if the schema version or a test is missing, a test has no name, a command test
+//     no command, a file test no path, a permission is malformed, or a regular expression is
+//     invalid.
+func (c *Config) Validate() error {
+	if c.SchemaVersion == "" {
+		return errorsx.MissingField(builderName, "schemaVersion", "schema version is required")
+	}
+
+	if len(c.CommandTests) == 0 && len(c.FileExistenceTests) == 0 && len(c.FileContentTests) == 0 &&
+		c.MetadataTest == nil {
+		return errorsx.MissingField(builderName, "tests", "at least one test is required")
+	}
+
+	for _, t := range c.CommandTests {
+		if t.Name == "" || t.Command == "" {
+			return errorsx.MissingField(builderName, "commandTests", "command test %q requires a name and a command", t.Name)
+		}
+
+		if err := validateRegexes("commandTests", t.Name, t.ExpectedOutput, t.ExcludedOutput, t.ExpectedError,
+			t.ExcludedError); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range c.FileExistenceTests {
+		if t.Name == "" || t.Path == "" {
+			return errorsx.MissingField(builderName, "fileExistenceTests",
+				"file existence test %q requires a name and a path", t.Name)
+		}
+
+		if t.Permissions != "" && !permissionsRegex.MatchString(t.Permissions) {
+			return errorsx.InvalidValue(builderName, "fileExistenceTests", t.Permissions,
+				"file existence test %s has invalid permissions: %q", t.Name, t.Permissions)
+		}
+	}
+
+	for _, t := range c.FileContentTests {
+		if t.Name == "" || t.Path == "" {
+			return errorsx.MissingField(builderName, "fileContentTests",
+				"file content test %q requires a name and a path", t.Name)
+		}
+
+		if err := validateRegexes("fileContentTests", t.Name, t.ExpectedContents, t.ExcludedContents); err != nil {
+			return err
+		}
+	}
+
+	if c.MetadataTest != nil {
+		for _, l := range c.MetadataTest.Labels {
+			if l.Key == "" {
+				return errorsx.MissingField(builderName, "metadataTest", "metadata label without key")
+			}
+
+			if l.IsRegex {
+				if err := validateRegexes("metadataTest", l.Key, []string{l.Value}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateRegexes checks the regular expressions of the test 'name'.
+func validateRegexes(field, name string, groups ...[]string) error {
+	for _, g := range groups {
+		for _, r := range g {
+			if _, err := regexp.Compile(r); err != nil {
+				return errorsx.InvalidValue(builderName, field, r, "test %s has an invalid regular expression: %v", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// YAML returns the YAML encoding of the configuration, indented by two spaces.
+func (c *Config) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(c); err != nil {
+		return nil, fmt.Errorf("failed to encode structure test config: %w", err)
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode structure test config: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ConfigBuilder builds a container-structure-test configuration.
+type ConfigBuilder struct {
+	cfg Config
+}
+
+// NewConfigBuilder creates a ConfigBuilder of a configuration at SchemaVersion.
+func NewConfigBuilder() *ConfigBuilder {
+	return &ConfigBuilder{cfg: Config{SchemaVersion: SchemaVersion}}
+}
+
+// WithGlobalEnv sets an environment variable of every command test.
+func (b *ConfigBuilder) WithGlobalEnv(key, value string) *ConfigBuilder {
+	b.cfg.GlobalEnvVars = append(b.cfg.GlobalEnvVars, EnvVar{Key: key, Value: value})
+	return b
+}
+
+// WithCommandTest adds the command test 'test'.
+func (b *ConfigBuilder) WithCommandTest(test CommandTest) *ConfigBuilder {
+	b.cfg.CommandTests = append(b.cfg.CommandTests, test)
+	return b
+}
+
+// WithCommand adds the command test 'name' running 'command' with 'args', whose standard output
+// must match the regular expressions 'expectedOutput'.
+func (b *ConfigBuilder) WithCommand(name, command string, args []string, expectedOutput ...string) *ConfigBuilder {
+	return b.WithCommandTest(CommandTest{Name: name, Command: command, Args: args, ExpectedOutput: expectedOutput})
+}
+
+// WithFileExistenceTest adds the file existence test 'test'.
+func (b *ConfigBuilder) WithFileExistenceTest(test FileExistenceTest) *ConfigBuilder {
+	b.cfg.FileExistenceTests = append(b.cfg.FileExistenceTests, test)
+	return b
+}
+
+// WithFileExists adds the file existence test 'name' checking that 'path' exists.
+func (b *ConfigBuilder) WithFileExists(name, path string) *ConfigBuilder {
+	return b.WithFileExistenceTest(FileExistenceTest{Name: name, Path: path, ShouldExist: true})
+}
+
+// WithFileAbsent adds the file existence test 'name' checking that 'path' doesn't exist.
+func (b *ConfigBuilder) WithFileAbsent(name, path string) *ConfigBuilder {
+	return b.WithFileExistenceTest(FileExistenceTest{Name: name, Path: path})
+}
+
+// WithFileContentTest adds the file content test 'test'.
+func (b *ConfigBuilder) WithFileContentTest(test FileContentTest) *ConfigBuilder {
+	b.cfg.FileContentTests = append(b.cfg.FileContentTests, test)
+	return b
+}
+
+// metadata returns the metadata test, creating it if needed.
+func (b *ConfigBuilder) metadata() *MetadataTest {
+	if b.cfg.MetadataTest == nil {
+		b.cfg.MetadataTest = &MetadataTest{}
+	}
+
+	return b.cfg.MetadataTest
+}
+
+// WithMetadataEnv checks that the image sets the environment variable 'key' to 'value'.
+func (b *ConfigBuilder) WithMetadataEnv(key, value string) *ConfigBuilder {
+	m := b.metadata()
+	m.Env = append(m.Env, EnvVar{Key: key, Value: value})
+	return b
+}
+
+// WithMetadataLabel checks that the image has the label 'key' set to 'value'.
+func (b *ConfigBuilder) WithMetadataLabel(key, value string) *ConfigBuilder {
+	m := b.metadata()
+	m.Labels = append(m.Labels, Label{Key: key, Value: value})
+	return b
+}
+
+// WithExposedPorts checks that the image exposes the ports 'ports' (e.g. "8080/tcp").
+func (b *ConfigBuilder) WithExposedPorts(ports ...string) *ConfigBuilder {
+	m := b.metadata()
+	m.ExposedPorts = append(m.ExposedPorts, ports...)
+	return b
+}
+
+// WithEntrypoint checks the entrypoint of the image.
+func (b *ConfigBuilder) WithEntrypoint(entrypoint ...string) *ConfigBuilder {
+	b.metadata().Entrypoint = entrypoint
+	return b
+}
+
+// WithCmd checks the command of the image.
+func (b *ConfigBuilder) WithCmd(cmd ...string) *ConfigBuilder {
+	b.metadata().Cmd = cmd
+	return b
+}
+
+// WithWorkdir checks the working directory of the image.
+func (b *ConfigBuilder) WithWorkdir(workdir string) *ConfigBuilder {
+	b.metadata().Workdir = workdir
+	return b
+}
+
+// WithUser checks the user of the image (e.g. "65532" for distroless nonroot images).
+func (b *ConfigBuilder) WithUser(user string) *ConfigBuilder {
+	b.metadata().User = user
+	return b
+}
+
+// Build validates and returns the configuration.
+func (b *ConfigBuilder) Build() (*Config, error) {
+	cfg := b.cfg
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,147 @@
+package structuretestx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigBuilder(t *testing.T) {
+	uid := 0
+	cfg, err := NewConfigBuilder().
+		WithGlobalEnv("PATH", "/usr/bin").
+		WithCommand("version", "/usr/bin/app", []string{"--version"}, `^app v1\.`).
+		WithCommandTest(CommandTest{Name: "bad-flag", Command: "/usr/bin/app", Args: []string{"--nope"}, ExitCode: 2}).
+		WithFileExists("certs", "/etc/ssl/certs/ca-certificates.crt").
+		WithFileExistenceTest(FileExistenceTest{
+			Name: "binary", Path: "/usr/bin/app", ShouldExist: true, Permissions: "-rwxr-xr-x", UID: &uid,
+		}).
+		WithFileAbsent("no-shell", "/bin/sh").
+		WithFileContentTest(FileContentTest{Name: "passwd", Path: "/etc/passwd", ExpectedContents: []string{"nonroot"}}).
+		WithMetadataEnv("APP_ENV", "prod").
+		WithMetadataLabel("org.opencontainers.image.source", "https://github.com/acme/app").
+		WithExposedPorts("8080/tcp").
+		WithEntrypoint("/usr/bin/app").
+		WithCmd("serve").
+		WithWorkdir("/app").
+		WithUser("65532").
+		Build()
+	require.NoError(t, err)
+
+	data, err := cfg.YAML()
+	require.NoError(t, err)
+
+	want := `schemaVersion: 2.0.0
+globalEnvVars:
+  - key: PATH
+    value: /usr/bin
+commandTests:
+  - name: version
+    command: /usr/bin/app
+    args:
+      - --version
+    expectedOutput:
+      - ^app v1\.
+  - name: bad-flag
+    command: /usr/bin/app
+    args:
+      - --nope
+    exitCode: 2
+fileExistenceTests:
+  - name: certs
+    path: /etc/ssl/certs/ca-certificates.crt
+    shouldExist: true
+  - name: binary
+    path: /usr/bin/app
+    shouldExist: true
+    permissions: -rwxr-xr-x
+    uid: 0
+  - name: no-shell
+    path: /bin/sh
+    shouldExist: false
+fileContentTests:
+  - name: passwd
+    path: /etc/passwd
+    expectedContents:
+      - nonroot
+metadataTest:
+  envVars:
+    - key: APP_ENV
+      value: prod
+  labels:
+    - key: org.opencontainers.image.source
+      value: https://github.com/acme/app
+  exposedPorts:
+    - 8080/tcp
+  entrypoint:
+    - /usr/bin/app
+  cmd:
+    - serve
+  workdir: /app
+  user: "65532"
+`
+	assert.Equal(t, want, string(data))
+
+	parsed, err := ParseConfig(data)
+	require.NoError(t, err)
+	assert.Equal(t, cfg, parsed)
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *ConfigBuilder
+		wantErr error
+	}{
+		{name: "no test", builder: NewConfigBuilder(), wantErr: errorsx.ErrMissingField},
+		{
+			name:    "command test without command",
+			builder: NewConfigBuilder().WithCommandTest(CommandTest{Name: "version"}),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "invalid output regex",
+			builder: NewConfigBuilder().WithCommand("version", "app", nil, "("),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "file test without path",
+			builder: NewConfigBuilder().WithFileExists("certs", ""),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name: "invalid permissions",
+			builder: NewConfigBuilder().WithFileExistenceTest(FileExistenceTest{
+				Name: "binary", Path: "/usr/bin/app", ShouldExist: true, Permissions: "0755",
+			}),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "file content test without name",
+			builder: NewConfigBuilder().WithFileContentTest(FileContentTest{Path: "/etc/passwd"}),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "label without key",
+			builder: NewConfigBuilder().WithMetadataLabel("", "value"),
+			wantErr: errorsx.ErrMissingField,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Build()
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	_, err := ParseConfig([]byte("schemaVersion: [\n"))
+	require.Error(t, err)
+
+	_, err = ParseConfig([]byte("schemaVersion: 2.0.0\n"))
+	require.ErrorIs(t, err, errorsx.ErrMissingField)
+}
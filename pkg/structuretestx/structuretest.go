@@ -0,0 +1,236 @@
+// Package structuretestx provides a builder for the structural tests of built images: the
+// `container-structure-test test` command of an image with its configuration files, and Go types
+// generating those configurations from code (command, file existence, file content and metadata
+// tests), so images are checked for what they contain rather than only whether they start.
+//
+// Example usage:
+//
+//	cfg, err := structuretestx.NewConfigBuilder().
+//	    WithCommand("version", "/usr/bin/app", []string{"--version"}, `^app v1\.`).
+//	    WithFileExists("ca-certificates", "/etc/ssl/certs/ca-certificates.crt").
+//	    WithFileAbsent("no-shell", "/bin/sh").
+//	    WithUser("65532").
+//	    WithExposedPorts("8080/tcp").
+//	    Build()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	data, err := cfg.YAML()
+//	// Write data to /mnt/structure-test.yaml, then:
+//
+//	cmd, err := structuretestx.NewStructureTestBuilder("registry.example.com/app:v1.3.0").
+//	    WithConfigFile("/mnt/structure-test.yaml").
+//	    WithOutput(structuretestx.OutputJUnit).
+//	    WithTestReport("/mnt/structure-test.xml").
+//	    BuildCommand()
+package structuretestx
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the container-structure-test builder in errorsx errors.
+const builderName = "container-structure-test"
+
+// StructureTestDefaultImage is the default container image providing container-structure-test.
+const StructureTestDefaultImage = "gcr.io/gcp-runtimes/container-structure-test:v1.19.3"
+
+// Driver is the driver container-structure-test runs the tests with.
+type Driver string
+
+const (
+	// DriverDocker runs the tests in containers of a Docker daemon.
+	DriverDocker Driver = "docker"
+	// DriverTar runs the tests against the file system of an image tarball, without daemon. It
+	// doesn't support command tests.
+	DriverTar Driver = "tar"
+	// DriverHost runs the tests against the host running container-structure-test, such as the
+	// image container itself.
+	DriverHost Driver = "host"
+)
+
+// Output is the output format of the test results.
+type Output string
+
+const (
+	// OutputText is the human-readable format.
+	OutputText Output = "text"
+	// OutputJSON is the JSON format.
+	OutputJSON Output = "json"
+	// OutputJUnit is the JUnit XML format.
+	OutputJUnit Output = "junit"
+)
+
+// StructureTestBuilder generates the container-structure-test command of an image.
+type StructureTestBuilder struct {
+	// image is the tested image: a reference for the docker driver, a tarball for the tar driver.
+	image string
+
+	// configFiles are the test configuration files.
+	configFiles []string
+
+	// driver is the driver running the tests. Empty uses the docker driver.
+	driver Driver
+
+	// platform is the platform of the tested image (e.g. "linux/arm64").
+	platform string
+
+	// pull pulls the image before testing it.
+	pull bool
+
+	// output is the output format of the results. Empty uses the text format.
+	output Output
+
+	// testReport is the file the results are written to.
+	testReport string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewStructureTestBuilder creates a StructureTestBuilder testing the image 'image'.
+func NewStructureTestBuilder(image string) *StructureTestBuilder {
+	return &StructureTestBuilder{image: image}
+}
+
+// WithConfigFile adds test configuration files.
+func (b *StructureTestBuilder) WithConfigFile(paths ...string) *StructureTestBuilder {
+	b.configFiles = append(b.configFiles, paths...)
+	return b
+}
+
+// WithDriver sets the driver running the tests.
+func (b *StructureTestBuilder) WithDriver(driver Driver) *StructureTestBuilder {
+	b.driver = driver
+	return b
+}
+
+// WithPlatform sets the platform of the tested image (e.g. "linux/arm64").
+func (b *StructureTestBuilder) WithPlatform(platform string) *StructureTestBuilder {
+	b.platform = platform
+	return b
+}
+
+// WithPull pulls the image before testing it.
+func (b *StructureTestBuilder) WithPull() *StructureTestBuilder {
+	b.pull = true
+	return b
+}
+
+// WithOutput sets the output format of the results.
+func (b *StructureTestBuilder) WithOutput(output Output) *StructureTestBuilder {
+	b.output = output
+	return b
+}
+
+// WithTestReport writes the results to the file 'path', in the output format.
+func (b *StructureTestBuilder) WithTestReport(path string) *StructureTestBuilder {
+	b.testReport = path
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *StructureTestBuilder) WithLogger(logger *slog.Logger) *StructureTestBuilder {
+	b.logger = logger
+	return b
+}
+
+// BuildCommand generates the container-structure-test test command.
+//
+// Returns:
+//   - The container-structure-test command.
+//   - An error if the image or configuration files are missing, the driver or output format is
+//     unsupported, or the host driver is combined with an image pull.
+func (b *StructureTestBuilder) BuildCommand() ([]string, error) {
+	if b.image == "" && b.driver != DriverHost {
+		return nil, errorsx.MissingField(builderName, "image", "image is required")
+	}
+
+	if len(b.configFiles) == 0 {
+		return nil, errorsx.MissingField(builderName, "configFiles", "at least one configuration file is required")
+	}
+
+	switch b.driver {
+	case "", DriverDocker, DriverTar, DriverHost:
+	default:
+		return nil, errorsx.Unsupported(builderName, "driver", b.driver, "unsupported driver: %q", b.driver)
+	}
+
+	switch b.output {
+	case "", OutputText, OutputJSON, OutputJUnit:
+	default:
+		return nil, errorsx.Unsupported(builderName, "output", b.output, "unsupported output format: %q", b.output)
+	}
+
+	if b.pull && b.driver != "" && b.driver != DriverDocker {
+		return nil, errorsx.ConflictingOptions(builderName, []string{"pull", "driver"},
+			"only the docker driver pulls images, not the %s driver", b.driver)
+	}
+
+	cmd := []string{"container-structure-test", "test"}
+	if b.driver != "" {
+		cmd = append(cmd, "--driver", string(b.driver))
+	}
+
+	if b.image != "" {
+		cmd = append(cmd, "--image", b.image)
+	}
+
+	for _, c := range b.configFiles {
+		cmd = append(cmd, "--config", c)
+	}
+
+	if b.platform != "" {
+		cmd = append(cmd, "--platform", b.platform)
+	}
+
+	if b.pull {
+		cmd = append(cmd, "--pull")
+	}
+
+	if b.output != "" {
+		cmd = append(cmd, "--output", string(b.output))
+	}
+
+	if b.testReport != "" {
+		cmd = append(cmd, "--test-report", b.testReport)
+	}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *StructureTestBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *StructureTestBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *StructureTestBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the container-structure-test command.
+func (b *StructureTestBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewStructureTestBuilder(""),
+		explainx.Generate("BuildCommand", b.BuildCommand))
+}
@@ -0,0 +1,81 @@
+package structuretestx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructureTestBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *StructureTestBuilder
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "minimal",
+			builder: NewStructureTestBuilder("app:dev").WithConfigFile("cst.yaml"),
+			want:    []string{"container-structure-test", "test", "--image", "app:dev", "--config", "cst.yaml"},
+		},
+		{
+			name: "every option",
+			builder: NewStructureTestBuilder("registry.example.com/app:v1").
+				WithConfigFile("a.yaml", "b.yaml").
+				WithDriver(DriverDocker).
+				WithPlatform("linux/arm64").
+				WithPull().
+				WithOutput(OutputJUnit).
+				WithTestReport("/mnt/cst.xml"),
+			want: []string{
+				"container-structure-test", "test", "--driver", "docker", "--image", "registry.example.com/app:v1",
+				"--config", "a.yaml", "--config", "b.yaml", "--platform", "linux/arm64", "--pull",
+				"--output", "junit", "--test-report", "/mnt/cst.xml",
+			},
+		},
+		{
+			name:    "host driver without image",
+			builder: NewStructureTestBuilder("").WithDriver(DriverHost).WithConfigFile("cst.yaml"),
+			want:    []string{"container-structure-test", "test", "--driver", "host", "--config", "cst.yaml"},
+		},
+		{
+			name:    "missing image",
+			builder: NewStructureTestBuilder("").WithConfigFile("cst.yaml"),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "missing config",
+			builder: NewStructureTestBuilder("app:dev"),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "unsupported driver",
+			builder: NewStructureTestBuilder("app:dev").WithConfigFile("cst.yaml").WithDriver("podman"),
+			wantErr: errorsx.ErrUnsupported,
+		},
+		{
+			name:    "unsupported output",
+			builder: NewStructureTestBuilder("app:dev").WithConfigFile("cst.yaml").WithOutput("xml"),
+			wantErr: errorsx.ErrUnsupported,
+		},
+		{
+			name:    "pull with tar driver",
+			builder: NewStructureTestBuilder("app.tar").WithConfigFile("cst.yaml").WithDriver(DriverTar).WithPull(),
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
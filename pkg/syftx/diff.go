@@ -0,0 +1,340 @@
+// Package syftx provides helpers around the software bills of materials produced by syft, such as
+// diffing the packages of two builds to summarize what changed in an image.
+//
+// Packages are read from SPDX or CycloneDX JSON documents, or from apko lockfiles, which resolve
+// the same packages before the image is built.
+//
+// Example usage:
+//
+//	diff, err := syftx.DiffSBOMs(previousSBOM, currentSBOM)
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	if !diff.Empty() {
+//	    comment := diff.Markdown()
+//	}
+package syftx
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+)
+
+// Package is a package listed in an SBOM or lockfile.
+type Package struct {
+	// Name is the name of the package.
+	Name string `json:"name"`
+	// Version is the version of the package.
+	Version string `json:"version"`
+}
+
+// Change is a package whose version changed between two builds.
+type Change struct {
+	// Name is the name of the package.
+	Name string `json:"name"`
+	// From is the previous version.
+	From string `json:"from"`
+	// To is the current version.
+	To string `json:"to"`
+}
+
+// Diff holds the package changes between two builds, each list sorted by package name.
+type Diff struct {
+	// Added holds the packages only present in the current build.
+	Added []Package `json:"added,omitempty"`
+	// Removed holds the packages only present in the previous build.
+	Removed []Package `json:"removed,omitempty"`
+	// Upgraded holds the packages whose version increased.
+	Upgraded []Change `json:"upgraded,omitempty"`
+	// Downgraded holds the packages whose version decreased.
+	Downgraded []Change `json:"downgraded,omitempty"`
+}
+
+// Empty reports whether the builds have the same packages.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Upgraded) == 0 && len(d.Downgraded) == 0
+}
+
+// Markdown renders the diff as a Markdown section, suitable for pull request comments.
+func (d Diff) Markdown() string {
+	var b strings.Builder
+
+	b.WriteString("## Package changes\n\n")
+	if d.Empty() {
+		b.WriteString("No package changes.\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "%d added, %d removed, %d upgraded, %d downgraded.\n",
+		len(d.Added), len(d.Removed), len(d.Upgraded), len(d.Downgraded))
+
+	b.WriteString("\n| Package | Change | From | To |\n| --- | --- | --- | --- |\n")
+	for _, p := range d.Added {
+		fmt.Fprintf(&b, "| %s | added | - | %s |\n", escapeCell(p.Name), escapeCell(p.Version))
+	}
+	for _, p := range d.Removed {
+		fmt.Fprintf(&b, "| %s | removed | %s | - |\n", escapeCell(p.Name), escapeCell(p.Version))
+	}
+	for _, c := range d.Upgraded {
+		fmt.Fprintf(&b, "| %s | upgraded | %s | %s |\n", escapeCell(c.Name), escapeCell(c.From), escapeCell(c.To))
+	}
+	for _, c := range d.Downgraded {
+		fmt.Fprintf(&b, "| %s | downgraded | %s | %s |\n", escapeCell(c.Name), escapeCell(c.From), escapeCell(c.To))
+	}
+
+	return b.String()
+}
+
+// ParseSBOMPackages returns the packages of the SPDX or CycloneDX JSON document 'data'. The SPDX
+// packages described by the document itself (the scanned image or directory) are skipped.
+//
+// Returns:
+//   - The packages of the document.
+//   - An error if the document is neither an SPDX nor a CycloneDX JSON document.
+func ParseSBOMPackages(data []byte) ([]Package, error) {
+	var doc struct {
+		SPDXVersion       string   `json:"spdxVersion"`
+		DocumentDescribes []string `json:"documentDescribes"`
+		Packages          []struct {
+			SPDXID      string `json:"SPDXID"`
+			Name        string `json:"name"`
+			VersionInfo string `json:"versionInfo"`
+		} `json:"packages"`
+		BOMFormat  string `json:"bomFormat"`
+		Components []struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"components"`
+	}
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid SBOM: %w", err)
+	}
+
+	var packages []Package
+
+	switch {
+	case doc.SPDXVersion != "":
+		roots := make(map[string]bool, len(doc.DocumentDescribes))
+		for _, id := range doc.DocumentDescribes {
+			roots[id] = true
+		}
+
+		for _, p := range doc.Packages {
+			if !roots[p.SPDXID] {
+				packages = append(packages, Package{Name: p.Name, Version: p.VersionInfo})
+			}
+		}
+	case doc.BOMFormat == "CycloneDX":
+		for _, c := range doc.Components {
+			packages = append(packages, Package{Name: c.Name, Version: c.Version})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported SBOM format")
+	}
+
+	return packages, nil
+}
+
+// LockfilePackages returns the packages of an apko lockfile. Packages resolved for several
+// architectures are listed once per version.
+func LockfilePackages(lock *apkox.ApkoLock) []Package {
+	seen := map[Package]bool{}
+
+	var packages []Package
+	for _, p := range lock.Contents.Packages {
+		pkg := Package{Name: p.Name, Version: p.Version}
+		if !seen[pkg] {
+			seen[pkg] = true
+			packages = append(packages, pkg)
+		}
+	}
+
+	return packages
+}
+
+// DiffSBOMs diffs the packages of the SBOM documents of a previous and a current build.
+//
+// Returns:
+//   - The package changes.
+//   - An error if a document cannot be parsed.
+func DiffSBOMs(previous, current []byte) (Diff, error) {
+	before, err := ParseSBOMPackages(previous)
+	if err != nil {
+		return Diff{}, fmt.Errorf("failed to parse previous SBOM: %w", err)
+	}
+
+	after, err := ParseSBOMPackages(current)
+	if err != nil {
+		return Diff{}, fmt.Errorf("failed to parse current SBOM: %w", err)
+	}
+
+	return DiffPackages(before, after), nil
+}
+
+// DiffLockfiles diffs the packages of the apko lockfiles of a previous and a current build.
+func DiffLockfiles(previous, current *apkox.ApkoLock) Diff {
+	return DiffPackages(LockfilePackages(previous), LockfilePackages(current))
+}
+
+// DiffPackages diffs the packages of a previous and a current build.
+//
+// Packages are matched by name. A package present with a single different version in each build
+// is reported as upgraded or downgraded; when several versions of a package are involved, the
+// versions only present in one build are reported as added or removed.
+func DiffPackages(previous, current []Package) Diff {
+	before := versionsByName(previous)
+	after := versionsByName(current)
+
+	names := map[string]bool{}
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var d Diff
+	for _, name := range sorted {
+		removed := subtract(before[name], after[name])
+		added := subtract(after[name], before[name])
+
+		if len(removed) == 1 && len(added) == 1 {
+			c := Change{Name: name, From: removed[0], To: added[0]}
+			if CompareVersions(c.From, c.To) <= 0 {
+				d.Upgraded = append(d.Upgraded, c)
+			} else {
+				d.Downgraded = append(d.Downgraded, c)
+			}
+
+			continue
+		}
+
+		for _, v := range removed {
+			d.Removed = append(d.Removed, Package{Name: name, Version: v})
+		}
+		for _, v := range added {
+			d.Added = append(d.Added, Package{Name: name, Version: v})
+		}
+	}
+
+	return d
+}
+
+// CompareVersions compares two package versions segment by segment, numeric segments
+// numerically and the others lexically, so "1.10.0-r1" is greater than "1.9.2-r3".
+// It returns -1, 0 or 1.
+func CompareVersions(a, b string) int {
+	as, bs := versionSegments(a), versionSegments(b)
+
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareSegment(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// versionSegments splits a version into runs of digits and runs of letters, dropping separators.
+func versionSegments(v string) []string {
+	var segments []string
+
+	start := -1
+	digits := false
+	for i, r := range v {
+		isDigit := unicode.IsDigit(r)
+		isAlnum := isDigit || unicode.IsLetter(r)
+
+		if start >= 0 && (!isAlnum || isDigit != digits) {
+			segments = append(segments, v[start:i])
+			start = -1
+		}
+
+		if isAlnum && start < 0 {
+			start, digits = i, isDigit
+		}
+	}
+
+	if start >= 0 {
+		segments = append(segments, v[start:])
+	}
+
+	return segments
+}
+
+// compareSegment compares two version segments; numeric segments sort after alphabetic ones.
+func compareSegment(a, b string) int {
+	aNum, bNum := unicode.IsDigit(rune(a[0])), unicode.IsDigit(rune(b[0]))
+
+	switch {
+	case aNum && bNum:
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	case aNum:
+		return 1
+	case bNum:
+		return -1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// versionsByName groups the versions of the packages by name.
+func versionsByName(packages []Package) map[string][]string {
+	out := map[string][]string{}
+	for _, p := range packages {
+		out[p.Name] = append(out[p.Name], p.Version)
+	}
+
+	return out
+}
+
+// subtract returns the sorted versions of 'a' missing from 'b'.
+func subtract(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, v := range b {
+		in[v] = true
+	}
+
+	var out []string
+	for _, v := range a {
+		if !in[v] {
+			out = append(out, v)
+			in[v] = true
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return CompareVersions(out[i], out[j]) < 0 })
+
+	return out
+}
+
+// escapeCell escapes the pipes of a Markdown table cell.
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,107 @@
+package syftx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/apkox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const previousSPDX = `{
+  "spdxVersion": "SPDX-2.3",
+  "documentDescribes": ["SPDXRef-DocumentRoot-Image"],
+  "packages": [
+    {"SPDXID": "SPDXRef-DocumentRoot-Image", "name": "app", "versionInfo": "sha256:aaa"},
+    {"SPDXID": "SPDXRef-Package-1", "name": "busybox", "versionInfo": "1.36.1-r5"},
+    {"SPDXID": "SPDXRef-Package-2", "name": "openssl", "versionInfo": "3.1.4-r0"},
+    {"SPDXID": "SPDXRef-Package-3", "name": "zlib", "versionInfo": "1.3-r1"}
+  ]
+}`
+
+const currentCycloneDX = `{
+  "bomFormat": "CycloneDX",
+  "components": [
+    {"name": "busybox", "version": "1.36.1-r5"},
+    {"name": "openssl", "version": "3.1.10-r0"},
+    {"name": "ca-certificates-bundle", "version": "20240226-r0"}
+  ]
+}`
+
+func TestDiffSBOMs(t *testing.T) {
+	diff, err := DiffSBOMs([]byte(previousSPDX), []byte(currentCycloneDX))
+	require.NoError(t, err)
+	assert.Equal(t, Diff{
+		Added:    []Package{{Name: "ca-certificates-bundle", Version: "20240226-r0"}},
+		Removed:  []Package{{Name: "zlib", Version: "1.3-r1"}},
+		Upgraded: []Change{{Name: "openssl", From: "3.1.4-r0", To: "3.1.10-r0"}},
+	}, diff)
+	assert.False(t, diff.Empty())
+
+	assert.Equal(t, `## Package changes
+
+1 added, 1 removed, 1 upgraded, 0 downgraded.
+
+| Package | Change | From | To |
+| --- | --- | --- | --- |
+| ca-certificates-bundle | added | - | 20240226-r0 |
+| zlib | removed | 1.3-r1 | - |
+| openssl | upgraded | 3.1.4-r0 | 3.1.10-r0 |
+`, diff.Markdown())
+
+	_, err = DiffSBOMs([]byte(`{"foo": 1}`), []byte(currentCycloneDX))
+	assert.ErrorContains(t, err, "previous SBOM")
+}
+
+func TestDiffLockfiles(t *testing.T) {
+	previous := &apkox.ApkoLock{Contents: apkox.ApkoLockContents{Packages: []apkox.ApkoLockPackage{
+		{Name: "glibc", Version: "2.39-r2", Architecture: "x86_64"},
+		{Name: "glibc", Version: "2.39-r2", Architecture: "aarch64"},
+	}}}
+	current := &apkox.ApkoLock{Contents: apkox.ApkoLockContents{Packages: []apkox.ApkoLockPackage{
+		{Name: "glibc", Version: "2.38-r9", Architecture: "x86_64"},
+	}}}
+
+	diff := DiffLockfiles(previous, current)
+	assert.Equal(t, Diff{Downgraded: []Change{{Name: "glibc", From: "2.39-r2", To: "2.38-r9"}}}, diff)
+
+	assert.True(t, DiffLockfiles(previous, previous).Empty())
+	assert.Equal(t, "## Package changes\n\nNo package changes.\n", DiffLockfiles(previous, previous).Markdown())
+}
+
+func TestDiffPackagesMultipleVersions(t *testing.T) {
+	diff := DiffPackages(
+		[]Package{{Name: "golang.org/x/net", Version: "v0.20.0"}, {Name: "golang.org/x/net", Version: "v0.21.0"}},
+		[]Package{{Name: "golang.org/x/net", Version: "v0.23.0"}, {Name: "golang.org/x/net", Version: "v0.22.0"}},
+	)
+	assert.Equal(t, Diff{
+		Added: []Package{
+			{Name: "golang.org/x/net", Version: "v0.22.0"},
+			{Name: "golang.org/x/net", Version: "v0.23.0"},
+		},
+		Removed: []Package{
+			{Name: "golang.org/x/net", Version: "v0.20.0"},
+			{Name: "golang.org/x/net", Version: "v0.21.0"},
+		},
+	}, diff)
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.10.0-r1", "1.9.2-r3", 1},
+		{"1.2.3-r0", "1.2.3-r1", -1},
+		{"1.2.3", "1.2.3", 0},
+		{"1.2", "1.2.1", -1},
+		{"007", "7", 0},
+		{"1.0_rc1", "1.0.1", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.want, CompareVersions(tt.a, tt.b))
+		})
+	}
+}
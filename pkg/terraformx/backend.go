@@ -0,0 +1,336 @@
+package terraformx
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/mapx"
+)
+
+// BackendFileName is the conventional name of the file holding the backend block.
+const BackendFileName = "backend.tf"
+
+// BackendType is a terraform state backend type.
+type BackendType string
+
+const (
+	// BackendS3 stores the state in an S3 bucket.
+	BackendS3 BackendType = "s3"
+	// BackendGCS stores the state in a Google Cloud Storage bucket.
+	BackendGCS BackendType = "gcs"
+	// BackendAzureRM stores the state in an Azure storage container.
+	BackendAzureRM BackendType = "azurerm"
+)
+
+// Setting is a backend configuration setting. Value is a string, a bool or a map[string]string
+// rendered as an HCL object.
+type Setting struct {
+	// Name is the setting name (e.g. "bucket").
+	Name string
+	// Value is the setting value.
+	Value any
+}
+
+// Backend is a typed terraform backend configuration.
+type Backend interface {
+	// Type returns the backend type.
+	Type() BackendType
+	// Settings returns the non-empty settings, in a stable order.
+	Settings() []Setting
+	// Validate checks that the configuration is complete.
+	Validate() error
+}
+
+// s3BucketRegex matches S3 bucket names.
+var s3BucketRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// gcsBucketRegex matches Google Cloud Storage bucket names.
+var gcsBucketRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{1,220}[a-z0-9]$`)
+
+// dynamoDBTableRegex matches DynamoDB table names.
+var dynamoDBTableRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,255}$`)
+
+// S3Backend configures the s3 backend.
+type S3Backend struct {
+	// Bucket is the bucket holding the state.
+	Bucket string
+	// Key is the path of the state object in the bucket.
+	Key string
+	// Region is the region of the bucket.
+	Region string
+	// DynamoDBTable is the DynamoDB table used for state locking.
+	DynamoDBTable string
+	// UseLockfile locks the state with a lock object stored next to it (terraform >= 1.10).
+	UseLockfile bool
+	// Encrypt enables server-side encryption of the state.
+	Encrypt bool
+	// Profile is the AWS profile used to access the bucket.
+	Profile string
+	// RoleARN is the ARN of the role assumed to access the bucket.
+	RoleARN string
+}
+
+// Type returns BackendS3.
+func (b *S3Backend) Type() BackendType {
+	return BackendS3
+}
+
+// Settings returns the non-empty s3 settings.
+func (b *S3Backend) Settings() []Setting {
+	var settings []Setting
+	settings = appendString(settings, "bucket", b.Bucket)
+	settings = appendString(settings, "key", b.Key)
+	settings = appendString(settings, "region", b.Region)
+	settings = appendString(settings, "dynamodb_table", b.DynamoDBTable)
+	settings = appendBool(settings, "use_lockfile", b.UseLockfile)
+	settings = appendBool(settings, "encrypt", b.Encrypt)
+	settings = appendString(settings, "profile", b.Profile)
+
+	if b.RoleARN != "" {
+		// The top level role_arn is deprecated in favor of the assume_role object.
+		settings = append(settings, Setting{Name: "assume_role", Value: map[string]string{"role_arn": b.RoleARN}})
+	}
+
+	return settings
+}
+
+// Validate checks that the bucket, key and region are set and that the state is locked.
+func (b *S3Backend) Validate() error {
+	if b.Bucket == "" {
+		return errorsx.MissingField(builderName, "bucket", "s3 backend requires a bucket")
+	}
+
+	if !s3BucketRegex.MatchString(b.Bucket) {
+		return errorsx.InvalidValue(builderName, "bucket", b.Bucket, "invalid s3 bucket name")
+	}
+
+	if err := validateStateKey("key", b.Key); err != nil {
+		return err
+	}
+
+	if b.Region == "" {
+		return errorsx.MissingField(builderName, "region", "s3 backend requires a region")
+	}
+
+	if b.DynamoDBTable == "" && !b.UseLockfile {
+		return errorsx.MissingField(builderName, "dynamodb_table",
+			"s3 backend requires a dynamodb_table or use_lockfile to lock the state")
+	}
+
+	if b.DynamoDBTable != "" && !dynamoDBTableRegex.MatchString(b.DynamoDBTable) {
+		return errorsx.InvalidValue(builderName, "dynamodb_table", b.DynamoDBTable, "invalid DynamoDB table name")
+	}
+
+	return nil
+}
+
+// GCSBackend configures the gcs backend. State locking is built in.
+type GCSBackend struct {
+	// Bucket is the bucket holding the state.
+	Bucket string
+	// Prefix is the directory of the state objects in the bucket.
+	Prefix string
+	// ImpersonateServiceAccount is the service account impersonated to access the bucket.
+	ImpersonateServiceAccount string
+}
+
+// Type returns BackendGCS.
+func (b *GCSBackend) Type() BackendType {
+	return BackendGCS
+}
+
+// Settings returns the non-empty gcs settings.
+func (b *GCSBackend) Settings() []Setting {
+	var settings []Setting
+	settings = appendString(settings, "bucket", b.Bucket)
+	settings = appendString(settings, "prefix", b.Prefix)
+	settings = appendString(settings, "impersonate_service_account", b.ImpersonateServiceAccount)
+
+	return settings
+}
+
+// Validate checks that the bucket is set and the prefix is relative.
+func (b *GCSBackend) Validate() error {
+	if b.Bucket == "" {
+		return errorsx.MissingField(builderName, "bucket", "gcs backend requires a bucket")
+	}
+
+	if !gcsBucketRegex.MatchString(b.Bucket) {
+		return errorsx.InvalidValue(builderName, "bucket", b.Bucket, "invalid gcs bucket name")
+	}
+
+	if strings.HasPrefix(b.Prefix, "/") {
+		return errorsx.InvalidValue(builderName, "prefix", b.Prefix, "prefix must be relative to the bucket")
+	}
+
+	return nil
+}
+
+// AzureRMBackend configures the azurerm backend. State locking is built in.
+type AzureRMBackend struct {
+	// ResourceGroupName is the resource group of the storage account.
+	ResourceGroupName string
+	// StorageAccountName is the storage account holding the container.
+	StorageAccountName string
+	// ContainerName is the container holding the state.
+	ContainerName string
+	// Key is the name of the state blob in the container.
+	Key string
+	// UseAzureADAuth authenticates to the storage account with Azure AD instead of access keys.
+	UseAzureADAuth bool
+}
+
+// Type returns BackendAzureRM.
+func (b *AzureRMBackend) Type() BackendType {
+	return BackendAzureRM
+}
+
+// Settings returns the non-empty azurerm settings.
+func (b *AzureRMBackend) Settings() []Setting {
+	var settings []Setting
+	settings = appendString(settings, "resource_group_name", b.ResourceGroupName)
+	settings = appendString(settings, "storage_account_name", b.StorageAccountName)
+	settings = appendString(settings, "container_name", b.ContainerName)
+	settings = appendString(settings, "key", b.Key)
+	settings = appendBool(settings, "use_azuread_auth", b.UseAzureADAuth)
+
+	return settings
+}
+
+// Validate checks that the storage account, container and key are set.
+func (b *AzureRMBackend) Validate() error {
+	if b.StorageAccountName == "" {
+		return errorsx.MissingField(builderName, "storage_account_name", "azurerm backend requires a storage account")
+	}
+
+	if b.ContainerName == "" {
+		return errorsx.MissingField(builderName, "container_name", "azurerm backend requires a container")
+	}
+
+	return validateStateKey("key", b.Key)
+}
+
+// validateStateKey checks that the state object key 'key' is set and relative.
+func validateStateKey(field, key string) error {
+	if key == "" {
+		return errorsx.MissingField(builderName, field, "backend requires a state key")
+	}
+
+	if strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+		return errorsx.InvalidValue(builderName, field, key, "state key must be an object path without leading or trailing slash")
+	}
+
+	return nil
+}
+
+// appendString appends the setting 'name' when 'value' is not empty.
+func appendString(settings []Setting, name, value string) []Setting {
+	if value == "" {
+		return settings
+	}
+
+	return append(settings, Setting{Name: name, Value: value})
+}
+
+// appendBool appends the setting 'name' when 'value' is true.
+func appendBool(settings []Setting, name string, value bool) []Setting {
+	if !value {
+		return settings
+	}
+
+	return append(settings, Setting{Name: name, Value: value})
+}
+
+// BackendConfigFlags renders the backend configuration as `terraform init` flags, for a partial
+// backend block (e.g. `backend "s3" {}`) declared in the configuration.
+//
+// Returns:
+//   - The -backend-config flags, one per setting.
+//   - An error if the backend is nil or invalid.
+func BackendConfigFlags(b Backend) ([]string, error) {
+	if err := validateBackend(b); err != nil {
+		return nil, err
+	}
+
+	var flags []string
+	for _, s := range b.Settings() {
+		flags = append(flags, fmt.Sprintf("-backend-config=%s=%s", s.Name, flagValue(s.Value)))
+	}
+
+	return flags, nil
+}
+
+// BackendFile renders the backend configuration as the content of a backend.tf file.
+//
+// Returns:
+//   - The terraform block declaring the backend.
+//   - An error if the backend is nil or invalid.
+func BackendFile(b Backend) (string, error) {
+	if err := validateBackend(b); err != nil {
+		return "", err
+	}
+
+	var body strings.Builder
+	for _, s := range b.Settings() {
+		fmt.Fprintf(&body, "    %s = %s\n", s.Name, hclValue(s.Value))
+	}
+
+	return fmt.Sprintf("terraform {\n  backend %q {\n%s  }\n}\n", b.Type(), body.String()), nil
+}
+
+// InitCommand generates a non-interactive `terraform init` command configuring the backend
+// through -backend-config flags.
+//
+// Returns:
+//   - The terraform init command.
+//   - An error if the backend is nil or invalid.
+func InitCommand(b Backend, extraArgs ...string) ([]string, error) {
+	flags, err := BackendConfigFlags(b)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := append([]string{"terraform", "init", "-input=false"}, flags...)
+
+	return append(cmd, extraArgs...), nil
+}
+
+// validateBackend checks that 'b' is set and valid.
+func validateBackend(b Backend) error {
+	if b == nil {
+		return errorsx.MissingField(builderName, "backend", "backend is required")
+	}
+
+	return b.Validate()
+}
+
+// flagValue renders a setting value for a -backend-config flag. Strings are passed verbatim,
+// objects as HCL.
+func flagValue(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	return hclValue(v)
+}
+
+// hclValue renders a setting value as an HCL literal.
+func hclValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case map[string]string:
+		keys := mapx.SortedKeys(v)
+
+		attrs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			attrs = append(attrs, fmt.Sprintf("%s = %q", k, v[k]))
+		}
+
+		return "{ " + strings.Join(attrs, ", ") + " }"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
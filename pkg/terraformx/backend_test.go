@@ -0,0 +1,133 @@
+package terraformx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackendConfigFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		backend  Backend
+		expected []string
+	}{
+		{
+			name: "S3",
+			backend: &S3Backend{
+				Bucket:        "acme-tfstate",
+				Key:           "network/prod.tfstate",
+				Region:        "eu-west-1",
+				DynamoDBTable: "tf-locks",
+				Encrypt:       true,
+				RoleARN:       "arn:aws:iam::123456789012:role/terraform",
+			},
+			expected: []string{
+				"-backend-config=bucket=acme-tfstate",
+				"-backend-config=key=network/prod.tfstate",
+				"-backend-config=region=eu-west-1",
+				"-backend-config=dynamodb_table=tf-locks",
+				"-backend-config=encrypt=true",
+				`-backend-config=assume_role={ role_arn = "arn:aws:iam::123456789012:role/terraform" }`,
+			},
+		},
+		{
+			name:    "GCS",
+			backend: &GCSBackend{Bucket: "acme-tfstate", Prefix: "network/prod"},
+			expected: []string{
+				"-backend-config=bucket=acme-tfstate",
+				"-backend-config=prefix=network/prod",
+			},
+		},
+		{
+			name: "AzureRM",
+			backend: &AzureRMBackend{
+				ResourceGroupName:  "tfstate",
+				StorageAccountName: "acmetfstate",
+				ContainerName:      "tfstate",
+				Key:                "prod.terraform.tfstate",
+				UseAzureADAuth:     true,
+			},
+			expected: []string{
+				"-backend-config=resource_group_name=tfstate",
+				"-backend-config=storage_account_name=acmetfstate",
+				"-backend-config=container_name=tfstate",
+				"-backend-config=key=prod.terraform.tfstate",
+				"-backend-config=use_azuread_auth=true",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, err := BackendConfigFlags(tt.backend)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, flags)
+		})
+	}
+}
+
+func TestBackendFile(t *testing.T) {
+	content, err := BackendFile(&S3Backend{
+		Bucket:      "acme-tfstate",
+		Key:         "prod.tfstate",
+		Region:      "eu-west-1",
+		UseLockfile: true,
+		RoleARN:     "arn:aws:iam::123456789012:role/terraform",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `terraform {
+  backend "s3" {
+    bucket = "acme-tfstate"
+    key = "prod.tfstate"
+    region = "eu-west-1"
+    use_lockfile = true
+    assume_role = { role_arn = "arn:aws:iam::123456789012:role/terraform" }
+  }
+}
+`, content)
+}
+
+func TestInitCommand(t *testing.T) {
+	cmd, err := InitCommand(&GCSBackend{Bucket: "acme-tfstate"}, "-upgrade")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"terraform", "init", "-input=false", "-backend-config=bucket=acme-tfstate", "-upgrade"}, cmd)
+}
+
+func TestBackendValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend Backend
+		field   string
+		kind    error
+	}{
+		{"Nil backend", nil, "backend", errorsx.ErrMissingField},
+		{"S3 missing bucket", &S3Backend{Key: "k", Region: "r", UseLockfile: true}, "bucket", errorsx.ErrMissingField},
+		{"S3 invalid bucket", &S3Backend{Bucket: "Acme_State", Key: "k", Region: "r", UseLockfile: true}, "bucket", errorsx.ErrInvalidValue},
+		{"S3 missing key", &S3Backend{Bucket: "acme", Region: "r", UseLockfile: true}, "key", errorsx.ErrMissingField},
+		{"S3 absolute key", &S3Backend{Bucket: "acme", Key: "/prod.tfstate", Region: "r", UseLockfile: true}, "key", errorsx.ErrInvalidValue},
+		{"S3 missing region", &S3Backend{Bucket: "acme", Key: "k", UseLockfile: true}, "region", errorsx.ErrMissingField},
+		{"S3 missing lock", &S3Backend{Bucket: "acme", Key: "k", Region: "r"}, "dynamodb_table", errorsx.ErrMissingField},
+		{"S3 invalid lock table", &S3Backend{Bucket: "acme", Key: "k", Region: "r", DynamoDBTable: "a b"}, "dynamodb_table", errorsx.ErrInvalidValue},
+		{"GCS missing bucket", &GCSBackend{}, "bucket", errorsx.ErrMissingField},
+		{"GCS absolute prefix", &GCSBackend{Bucket: "acme", Prefix: "/prod"}, "prefix", errorsx.ErrInvalidValue},
+		{"AzureRM missing account", &AzureRMBackend{ContainerName: "c", Key: "k"}, "storage_account_name", errorsx.ErrMissingField},
+		{"AzureRM missing container", &AzureRMBackend{StorageAccountName: "a", Key: "k"}, "container_name", errorsx.ErrMissingField},
+		{"AzureRM missing key", &AzureRMBackend{StorageAccountName: "a", ContainerName: "c"}, "key", errorsx.ErrMissingField},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := BackendConfigFlags(tt.backend)
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+			field, _ := errorsx.FieldOf(err)
+			assert.Equal(t, tt.field, field)
+
+			_, err = BackendFile(tt.backend)
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+		})
+	}
+}
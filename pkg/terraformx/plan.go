@@ -0,0 +1,216 @@
+// Package terraformx provides helpers for terraform pipelines: parsing the JSON plans produced by
+// `terraform show -json`, rendering backend configurations and managing workspaces.
+//
+// Example usage:
+//
+//	res, err := executor.Run(ctx, types.DaggerCMD{"terraform", "show", "-json", "plan.tfplan"}, nil, mounts)
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	plan, err := terraformx.ParsePlan([]byte(res.Stdout))
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	if plan.Destroy > 0 {
+//	    // require a manual approval before applying
+//	}
+//
+//	comment := plan.Markdown()
+package terraformx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// builderName identifies the terraform helpers in errorsx errors.
+const builderName = "terraform"
+
+// Action is the planned action of a resource change.
+type Action string
+
+const (
+	// ActionCreate creates the resource.
+	ActionCreate Action = "create"
+	// ActionUpdate updates the resource in place.
+	ActionUpdate Action = "update"
+	// ActionDelete destroys the resource.
+	ActionDelete Action = "delete"
+	// ActionReplace destroys and recreates the resource.
+	ActionReplace Action = "replace"
+	// ActionRead reads the data source.
+	ActionRead Action = "read"
+	// ActionNoop leaves the resource unchanged.
+	ActionNoop Action = "no-op"
+)
+
+// ResourceChange is the planned change of a resource.
+type ResourceChange struct {
+	// Address is the absolute address of the resource (e.g. "module.db.aws_db_instance.main").
+	Address string `json:"address"`
+	// Type is the resource type (e.g. "aws_db_instance").
+	Type string `json:"type"`
+	// Name is the resource name.
+	Name string `json:"name"`
+	// Mode is "managed" for resources and "data" for data sources.
+	Mode string `json:"mode"`
+	// Action is the planned action.
+	Action Action `json:"action"`
+}
+
+// Plan summarizes a terraform plan. As in the terraform plan output, replaced resources count
+// both as added and destroyed.
+type Plan struct {
+	// Add is the number of resources to create.
+	Add int `json:"add"`
+	// Change is the number of resources to update in place.
+	Change int `json:"change"`
+	// Destroy is the number of resources to destroy.
+	Destroy int `json:"destroy"`
+	// Changes holds the resource changes, excluding no-op changes and data source reads.
+	Changes []ResourceChange `json:"changes,omitempty"`
+	// Drift holds the changes made outside of terraform, detected while refreshing the state.
+	Drift []ResourceChange `json:"drift,omitempty"`
+}
+
+// planJSON is the subset of the `terraform show -json` plan representation used by ParsePlan.
+type planJSON struct {
+	FormatVersion   string           `json:"format_version"`
+	ResourceChanges []resourceChange `json:"resource_changes"`
+	ResourceDrift   []resourceChange `json:"resource_drift"`
+}
+
+// resourceChange is a resource change of the JSON plan representation.
+type resourceChange struct {
+	Address string `json:"address"`
+	Mode    string `json:"mode"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Change  struct {
+		Actions []string `json:"actions"`
+	} `json:"change"`
+}
+
+// action maps the actions of a change to a single Action. Terraform plans replacements as
+// ["delete", "create"] or ["create", "delete"].
+func (c resourceChange) action() Action {
+	actions := c.Change.Actions
+	if len(actions) == 2 {
+		return ActionReplace
+	}
+
+	if len(actions) == 1 {
+		return Action(actions[0])
+	}
+
+	return ActionNoop
+}
+
+// toResourceChange converts the change to a ResourceChange.
+func (c resourceChange) toResourceChange() ResourceChange {
+	return ResourceChange{Address: c.Address, Type: c.Type, Name: c.Name, Mode: c.Mode, Action: c.action()}
+}
+
+// ParsePlan parses the JSON representation of a plan, as printed by `terraform show -json <plan>`.
+//
+// Returns:
+//   - The plan summary.
+//   - An error if the data is not a JSON plan representation.
+func ParsePlan(data []byte) (*Plan, error) {
+	var raw planJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse terraform plan: %w", err)
+	}
+
+	if raw.FormatVersion == "" {
+		return nil, errorsx.InvalidValue(builderName, "format_version", "", "terraform plan JSON has no format version")
+	}
+
+	plan := &Plan{}
+	for _, rc := range raw.ResourceChanges {
+		change := rc.toResourceChange()
+		switch change.Action {
+		case ActionCreate:
+			plan.Add++
+		case ActionUpdate:
+			plan.Change++
+		case ActionDelete:
+			plan.Destroy++
+		case ActionReplace:
+			plan.Add++
+			plan.Destroy++
+		default:
+			continue
+		}
+
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	for _, rc := range raw.ResourceDrift {
+		if change := rc.toResourceChange(); change.Action != ActionNoop && change.Action != ActionRead {
+			plan.Drift = append(plan.Drift, change)
+		}
+	}
+
+	return plan, nil
+}
+
+// HasChanges reports whether applying the plan changes any resource.
+func (p *Plan) HasChanges() bool {
+	return len(p.Changes) > 0
+}
+
+// HasDrift reports whether resources were changed outside of terraform.
+func (p *Plan) HasDrift() bool {
+	return len(p.Drift) > 0
+}
+
+// ChangesByAction returns the resource changes with the action 'action'.
+func (p *Plan) ChangesByAction(action Action) []ResourceChange {
+	var changes []ResourceChange
+	for _, c := range p.Changes {
+		if c.Action == action {
+			changes = append(changes, c)
+		}
+	}
+
+	return changes
+}
+
+// Summary returns the one-line summary terraform prints, e.g. "Plan: 1 to add, 0 to change, 2 to destroy."
+func (p *Plan) Summary() string {
+	if !p.HasChanges() {
+		return "No changes."
+	}
+
+	return fmt.Sprintf("Plan: %d to add, %d to change, %d to destroy.", p.Add, p.Change, p.Destroy)
+}
+
+// Markdown renders the plan as a Markdown section listing the resource changes and the drift,
+// suitable for pull request comments.
+func (p *Plan) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "## Terraform plan\n\n%s\n", p.Summary())
+
+	if p.HasChanges() {
+		b.WriteString("\n| Action | Resource |\n| --- | --- |\n")
+		for _, c := range p.Changes {
+			fmt.Fprintf(&b, "| %s | `%s` |\n", c.Action, c.Address)
+		}
+	}
+
+	if p.HasDrift() {
+		b.WriteString("\n### Drift\n\nChanged outside of terraform:\n\n")
+		for _, c := range p.Drift {
+			fmt.Fprintf(&b, "- %s `%s`\n", c.Action, c.Address)
+		}
+	}
+
+	return b.String()
+}
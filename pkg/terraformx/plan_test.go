@@ -0,0 +1,73 @@
+package terraformx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPlan = `{
+  "format_version": "1.2",
+  "terraform_version": "1.9.0",
+  "resource_drift": [
+    {"address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "name": "logs", "change": {"actions": ["update"]}}
+  ],
+  "resource_changes": [
+    {"address": "aws_s3_bucket.assets", "mode": "managed", "type": "aws_s3_bucket", "name": "assets", "change": {"actions": ["create"]}},
+    {"address": "aws_iam_role.app", "mode": "managed", "type": "aws_iam_role", "name": "app", "change": {"actions": ["update"]}},
+    {"address": "module.db.aws_db_instance.main", "mode": "managed", "type": "aws_db_instance", "name": "main", "change": {"actions": ["delete", "create"]}},
+    {"address": "aws_sqs_queue.old", "mode": "managed", "type": "aws_sqs_queue", "name": "old", "change": {"actions": ["delete"]}},
+    {"address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "name": "logs", "change": {"actions": ["no-op"]}},
+    {"address": "data.aws_caller_identity.current", "mode": "data", "type": "aws_caller_identity", "name": "current", "change": {"actions": ["read"]}}
+  ]
+}`
+
+func TestParsePlan(t *testing.T) {
+	plan, err := ParsePlan([]byte(testPlan))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, plan.Add)
+	assert.Equal(t, 1, plan.Change)
+	assert.Equal(t, 2, plan.Destroy)
+	assert.Len(t, plan.Changes, 4)
+	assert.True(t, plan.HasChanges())
+	assert.True(t, plan.HasDrift())
+
+	assert.Equal(t, []ResourceChange{{
+		Address: "module.db.aws_db_instance.main",
+		Type:    "aws_db_instance",
+		Name:    "main",
+		Mode:    "managed",
+		Action:  ActionReplace,
+	}}, plan.ChangesByAction(ActionReplace))
+
+	assert.Equal(t, "Plan: 2 to add, 1 to change, 2 to destroy.", plan.Summary())
+	assert.Equal(t, "## Terraform plan\n\n"+
+		"Plan: 2 to add, 1 to change, 2 to destroy.\n\n"+
+		"| Action | Resource |\n| --- | --- |\n"+
+		"| create | `aws_s3_bucket.assets` |\n"+
+		"| update | `aws_iam_role.app` |\n"+
+		"| replace | `module.db.aws_db_instance.main` |\n"+
+		"| delete | `aws_sqs_queue.old` |\n"+
+		"\n### Drift\n\nChanged outside of terraform:\n\n"+
+		"- update `aws_s3_bucket.logs`\n", plan.Markdown())
+}
+
+func TestParsePlanNoChanges(t *testing.T) {
+	plan, err := ParsePlan([]byte(`{"format_version": "1.2", "resource_changes": []}`))
+	require.NoError(t, err)
+	assert.False(t, plan.HasChanges())
+	assert.Equal(t, "No changes.", plan.Summary())
+	assert.Equal(t, "## Terraform plan\n\nNo changes.\n", plan.Markdown())
+}
+
+func TestParsePlanErrors(t *testing.T) {
+	_, err := ParsePlan([]byte("{"))
+	assert.Error(t, err)
+
+	_, err = ParsePlan([]byte(`{"resource_changes": []}`))
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+}
@@ -0,0 +1,211 @@
+package terraformx
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/builderx"
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// DefaultWorkspace is the workspace every terraform configuration starts in. It always exists.
+const DefaultWorkspace = "default"
+
+// workspaceNameRegex matches the workspace names accepted by the backends.
+var workspaceNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// WorkspaceBuilder generates the `terraform workspace` commands of a workspace.
+type WorkspaceBuilder struct {
+	// name is the workspace name.
+	name string
+
+	// chdir is the directory of the terraform configuration, passed as -chdir.
+	chdir string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+var _ builderx.Builder = (*WorkspaceBuilder)(nil)
+
+// NewWorkspaceBuilder creates a WorkspaceBuilder for the workspace 'name'. The name is not
+// required by ListCommand.
+func NewWorkspaceBuilder(name string) *WorkspaceBuilder {
+	return &WorkspaceBuilder{name: name}
+}
+
+// WithChdir sets the directory of the terraform configuration.
+func (b *WorkspaceBuilder) WithChdir(dir string) *WorkspaceBuilder {
+	b.chdir = dir
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *WorkspaceBuilder) WithLogger(logger *slog.Logger) *WorkspaceBuilder {
+	b.logger = logger
+	return b
+}
+
+// validate checks that the workspace name is set and valid.
+func (b *WorkspaceBuilder) validate() error {
+	if b.name == "" {
+		return errorsx.MissingField(builderName, "workspace", "workspace name is required")
+	}
+
+	if !workspaceNameRegex.MatchString(b.name) {
+		return errorsx.InvalidValue(builderName, "workspace", b.name,
+			"workspace name may only contain letters, digits, '_', '.' and '-'")
+	}
+
+	return nil
+}
+
+// command generates the `terraform workspace <sub>` command with 'args'.
+func (b *WorkspaceBuilder) command(sub string, args ...string) []string {
+	cmd := []string{"terraform"}
+	if b.chdir != "" {
+		cmd = append(cmd, "-chdir="+b.chdir)
+	}
+
+	cmd = append(cmd, "workspace", sub)
+
+	return append(cmd, args...)
+}
+
+// build validates the builder and generates the `terraform workspace <sub>` command of the workspace.
+func (b *WorkspaceBuilder) build(sub string) ([]string, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := b.command(sub, b.name)
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// NewCommand generates the `terraform workspace new` command, which fails if the workspace exists.
+//
+// Returns:
+//   - The terraform workspace new command.
+//   - An error if the name is missing or invalid, or is the default workspace.
+func (b *WorkspaceBuilder) NewCommand() ([]string, error) {
+	if b.name == DefaultWorkspace {
+		return nil, errorsx.InvalidValue(builderName, "workspace", b.name, "the default workspace always exists")
+	}
+
+	return b.build("new")
+}
+
+// SelectCommand generates the `terraform workspace select` command, which fails if the workspace
+// does not exist.
+//
+// Returns:
+//   - The terraform workspace select command.
+//   - An error if the name is missing or invalid.
+func (b *WorkspaceBuilder) SelectCommand() ([]string, error) {
+	return b.build("select")
+}
+
+// SelectOrCreateCommand generates an idempotent command selecting the workspace, creating it
+// when it does not exist: `terraform workspace select <name> || terraform workspace new <name>`,
+// run by sh. The default workspace is selected only.
+//
+// Returns:
+//   - The sh command running the chain.
+//   - An error if the name is missing or invalid.
+func (b *WorkspaceBuilder) SelectOrCreateCommand() ([]string, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	chain := cmdx.ShellJoin(b.command("select", b.name))
+	if b.name != DefaultWorkspace {
+		chain += " || " + cmdx.ShellJoin(b.command("new", b.name))
+	}
+
+	cmd := []string{"sh", "-c", chain}
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// ListCommand generates the `terraform workspace list` command. Its output is parsed by
+// ParseWorkspaceList.
+func (b *WorkspaceBuilder) ListCommand() []string {
+	cmd := b.command("list")
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd
+}
+
+// ParseWorkspaceList parses the output of `terraform workspace list`, where the current
+// workspace is marked with '*'.
+//
+// Returns:
+//   - The workspace names, in output order.
+//   - The current workspace, empty if none is marked.
+func ParseWorkspaceList(output string) (workspaces []string, current string) {
+	for _, line := range strings.Split(output, "\n") {
+		name := strings.TrimSpace(line)
+		if marked := strings.TrimPrefix(name, "*"); marked != name {
+			name = strings.TrimSpace(marked)
+			current = name
+		}
+
+		if name != "" {
+			workspaces = append(workspaces, name)
+		}
+	}
+
+	return workspaces, current
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *WorkspaceBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// BuildCommand generates the idempotent command selecting the workspace, as
+// SelectOrCreateCommand does.
+func (b *WorkspaceBuilder) BuildCommand() ([]string, error) {
+	return b.SelectOrCreateCommand()
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *WorkspaceBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *WorkspaceBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the terraform workspace commands.
+func (b *WorkspaceBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("terraform workspace", b, NewWorkspaceBuilder(""),
+		explainx.Generate("NewCommand", b.NewCommand),
+		explainx.Generate("SelectCommand", b.SelectCommand),
+		explainx.Generate("SelectOrCreateCommand", b.SelectOrCreateCommand),
+	)
+}
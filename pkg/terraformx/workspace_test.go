@@ -0,0 +1,78 @@
+package terraformx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceBuilderCommands(t *testing.T) {
+	b := NewWorkspaceBuilder("prod").WithChdir("infra/network")
+
+	cmd, err := b.NewCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"terraform", "-chdir=infra/network", "workspace", "new", "prod"}, cmd)
+
+	cmd, err = b.SelectCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"terraform", "-chdir=infra/network", "workspace", "select", "prod"}, cmd)
+
+	cmd, err = b.SelectOrCreateCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"sh", "-c",
+		"terraform -chdir=infra/network workspace select prod || terraform -chdir=infra/network workspace new prod",
+	}, cmd)
+
+	assert.Equal(t, []string{"terraform", "-chdir=infra/network", "workspace", "list"}, b.ListCommand())
+}
+
+func TestWorkspaceBuilderDefault(t *testing.T) {
+	b := NewWorkspaceBuilder(DefaultWorkspace).WithChdir("my infra")
+
+	cmd, err := b.SelectOrCreateCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sh", "-c", "terraform '-chdir=my infra' workspace select default"}, cmd)
+
+	_, err = b.NewCommand()
+	assert.True(t, errors.Is(err, errorsx.ErrInvalidValue))
+}
+
+func TestWorkspaceBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		workspace string
+		kind      error
+	}{
+		{"Missing name", "", errorsx.ErrMissingField},
+		{"Invalid name", "prod eu", errorsx.ErrInvalidValue},
+		{"Slash in name", "team/prod", errorsx.ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewWorkspaceBuilder(tt.workspace)
+
+			_, err := b.SelectCommand()
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+
+			_, err = b.SelectOrCreateCommand()
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+		})
+	}
+
+	assert.Equal(t, []string{"terraform", "workspace", "list"}, NewWorkspaceBuilder("").ListCommand())
+}
+
+func TestParseWorkspaceList(t *testing.T) {
+	workspaces, current := ParseWorkspaceList("  default\n* prod\n  staging\n\n")
+	assert.Equal(t, []string{"default", "prod", "staging"}, workspaces)
+	assert.Equal(t, "prod", current)
+
+	workspaces, current = ParseWorkspaceList("")
+	assert.Empty(t, workspaces)
+	assert.Empty(t, current)
+}
@@ -0,0 +1,295 @@
+// Package tfsecx provides builders for the security scans of terraform code with tfsec and its
+// successor, `trivy config`: the scan command of a directory with its minimum severity, excluded
+// checks and SARIF output, and the evaluation of the SARIF report against a maximum severity, so
+// the findings join the scans of the run report (see reportx) and gate the pipeline (see
+// policyx).
+//
+// Checks are excluded for the whole scan with the builders, or ignored for a resource with an
+// ignore annotation in the terraform code (see IgnoreAnnotation and TrivyIgnoreAnnotation).
+//
+// Example usage:
+//
+//	scan := tfsecx.NewTrivyConfigBuilder("/mnt/infra").
+//	    WithMinimumSeverity(policyx.SeverityMedium).
+//	    WithMaxSeverity(policyx.SeverityMedium)
+//
+//	cmd, err := scan.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	// Run cmd with scan.Mounts(), then read the SARIF report at scan.SARIFPath().
+//	if err := scan.AddScan(report, sarif); err != nil {
+//	    // handle error
+//	}
+//	result, err := scan.Evaluate(sarif)
+package tfsecx
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the terraform scan builders in errorsx errors.
+const builderName = "tfsec"
+
+// TfsecDefaultImage is the default container image providing tfsec.
+const TfsecDefaultImage = "aquasec/tfsec:v1.28"
+
+// GetSARIFPath returns the conventional path of the SARIF report of 'scanner' (e.g. "tfsec")
+// inside the container. If mntPrefix is empty, fixtures.MntPrefix is used.
+func GetSARIFPath(mntPrefix, scanner string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, scanner+".sarif")
+}
+
+// IgnoreAnnotation returns the comment ignoring the tfsec check 'id' for the resource it is
+// placed in (e.g. "#tfsec:ignore:aws-s3-enable-bucket-logging").
+func IgnoreAnnotation(id string) string {
+	return "#tfsec:ignore:" + id
+}
+
+// severityNames are the names of the severities of tfsec and trivy, which only know these four.
+var severityNames = map[policyx.Severity]string{
+	policyx.SeverityLow:      "LOW",
+	policyx.SeverityMedium:   "MEDIUM",
+	policyx.SeverityHigh:     "HIGH",
+	policyx.SeverityCritical: "CRITICAL",
+}
+
+// validateSeverity checks that 'severity' is unset or known to the scanners.
+func validateSeverity(field string, severity policyx.Severity) error {
+	if _, ok := severityNames[severity]; severity != policyx.SeverityUnknown && !ok {
+		return errorsx.InvalidValue(builderName, field, severity.String(),
+			"severity must be low, medium, high or critical, got %s", severity)
+	}
+
+	return nil
+}
+
+// evaluate fails on the findings of 'sarif' more severe than 'max'.
+func evaluate(sarif []byte, max policyx.Severity) (policyx.Result, error) {
+	findings, err := policyx.ParseSARIFReport(sarif)
+	if err != nil {
+		return policyx.Result{}, err
+	}
+
+	return policyx.NewPolicy().WithMaxSeverity(max).Evaluate(policyx.Input{Findings: findings}), nil
+}
+
+// addScan adds the findings of 'sarif' to 'report', as a scan of 'dir' by 'scanner'.
+func addScan(report *reportx.Report, scanner, dir string, sarif []byte) error {
+	findings, err := policyx.ParseSARIFReport(sarif)
+	if err != nil {
+		return err
+	}
+
+	report.AddScan(scanner, dir, findings)
+
+	return nil
+}
+
+// TfsecBuilder generates the tfsec command of a terraform directory.
+type TfsecBuilder struct {
+	// dir is the scanned directory.
+	dir string
+
+	// minimumSeverity is the lowest severity of the reported findings.
+	minimumSeverity policyx.Severity
+
+	// excludes are the checks excluded from the scan.
+	excludes []string
+
+	// tfvarsFiles are the variable files of the terraform code.
+	tfvarsFiles []string
+
+	// softFail exits successfully regardless of the findings.
+	softFail bool
+
+	// noIgnores disregards the ignore annotations of the code.
+	noIgnores bool
+
+	// sarifPath is the path of the SARIF report.
+	sarifPath string
+
+	// maxSeverity is the highest severity of the findings passing the evaluation.
+	maxSeverity policyx.Severity
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewTfsecBuilder creates a TfsecBuilder scanning the terraform directory 'dir', writing its
+// SARIF report to GetSARIFPath("", "tfsec") and failing the evaluation on high and critical
+// findings.
+func NewTfsecBuilder(dir string) *TfsecBuilder {
+	return &TfsecBuilder{
+		dir:         dir,
+		sarifPath:   GetSARIFPath("", "tfsec"),
+		maxSeverity: policyx.SeverityMedium,
+	}
+}
+
+// WithMinimumSeverity sets the lowest severity of the reported findings.
+func (b *TfsecBuilder) WithMinimumSeverity(severity policyx.Severity) *TfsecBuilder {
+	b.minimumSeverity = severity
+	return b
+}
+
+// WithExclude excludes the checks 'ids' (e.g. "aws-s3-enable-bucket-logging") from the scan.
+func (b *TfsecBuilder) WithExclude(ids ...string) *TfsecBuilder {
+	b.excludes = append(b.excludes, ids...)
+	return b
+}
+
+// WithTfvarsFile adds variable files of the terraform code.
+func (b *TfsecBuilder) WithTfvarsFile(paths ...string) *TfsecBuilder {
+	b.tfvarsFiles = append(b.tfvarsFiles, paths...)
+	return b
+}
+
+// WithSoftFail sets whether tfsec exits successfully regardless of the findings, leaving the
+// decision to Evaluate.
+func (b *TfsecBuilder) WithSoftFail(softFail bool) *TfsecBuilder {
+	b.softFail = softFail
+	return b
+}
+
+// WithNoIgnores sets whether the ignore annotations of the code are disregarded.
+func (b *TfsecBuilder) WithNoIgnores(noIgnores bool) *TfsecBuilder {
+	b.noIgnores = noIgnores
+	return b
+}
+
+// WithSARIFPath sets the path of the SARIF report.
+func (b *TfsecBuilder) WithSARIFPath(path string) *TfsecBuilder {
+	b.sarifPath = path
+	return b
+}
+
+// WithMaxSeverity sets the highest severity of the findings passing the evaluation. It defaults
+// to medium.
+func (b *TfsecBuilder) WithMaxSeverity(severity policyx.Severity) *TfsecBuilder {
+	b.maxSeverity = severity
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *TfsecBuilder) WithLogger(logger *slog.Logger) *TfsecBuilder {
+	b.logger = logger
+	return b
+}
+
+// SARIFPath returns the path of the SARIF report.
+func (b *TfsecBuilder) SARIFPath() string {
+	return b.sarifPath
+}
+
+// BuildCommand generates the tfsec command, writing the SARIF report.
+//
+// Returns:
+//   - The tfsec command.
+//   - An error if the directory or SARIF path is missing, or the minimum severity is not known
+//     to tfsec.
+func (b *TfsecBuilder) BuildCommand() ([]string, error) {
+	if b.dir == "" {
+		return nil, errorsx.MissingField(builderName, "dir", "scanned directory is required")
+	}
+
+	if b.sarifPath == "" {
+		return nil, errorsx.MissingField(builderName, "sarifPath", "SARIF report path is required")
+	}
+
+	if err := validateSeverity("minimumSeverity", b.minimumSeverity); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"tfsec", b.dir, "--no-color", "--format", "sarif", "--out", b.sarifPath}
+	if b.minimumSeverity != policyx.SeverityUnknown {
+		cmd = append(cmd, "--minimum-severity", severityNames[b.minimumSeverity])
+	}
+
+	if len(b.excludes) > 0 {
+		cmd = append(cmd, "--exclude", strings.Join(b.excludes, ","))
+	}
+
+	for _, f := range b.tfvarsFiles {
+		cmd = append(cmd, "--tfvars-file", f)
+	}
+
+	if b.softFail {
+		cmd = append(cmd, "--soft-fail")
+	}
+
+	if b.noIgnores {
+		cmd = append(cmd, "--no-ignores")
+	}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Evaluate parses the SARIF report 'sarif' and fails on the findings more severe than the
+// maximum severity.
+//
+// Returns:
+//   - The result of the evaluation.
+//   - An error if the report can't be parsed.
+func (b *TfsecBuilder) Evaluate(sarif []byte) (policyx.Result, error) {
+	return evaluate(sarif, b.maxSeverity)
+}
+
+// AddScan parses the SARIF report 'sarif' and adds its findings to 'report', as a tfsec scan of
+// the scanned directory.
+//
+// Returns:
+//   - An error if the report can't be parsed.
+func (b *TfsecBuilder) AddScan(report *reportx.Report, sarif []byte) error {
+	return addScan(report, "tfsec", b.dir, sarif)
+}
+
+// Artifacts returns the artifacts of the scan: the SARIF report.
+func (b *TfsecBuilder) Artifacts() []artifactx.Artifact {
+	return []artifactx.Artifact{{Kind: artifactx.KindReport, Path: b.sarifPath}}
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *TfsecBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *TfsecBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *TfsecBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the tfsec command.
+func (b *TfsecBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewTfsecBuilder(""), explainx.Generate("BuildCommand", b.BuildCommand))
+}
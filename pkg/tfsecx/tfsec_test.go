@@ -0,0 +1,123 @@
+package tfsecx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSARIF = `{"version": "2.1.0", "runs": [{
+	"tool": {"driver": {"name": "tfsec", "rules": [
+		{"id": "aws-s3-enable-bucket-encryption", "properties": {"security-severity": "8.0"}},
+		{"id": "aws-s3-enable-bucket-logging", "properties": {"security-severity": "5.0"}}
+	]}},
+	"results": [
+		{"ruleId": "aws-s3-enable-bucket-encryption", "level": "error", "locations": [{"physicalLocation": {
+			"artifactLocation": {"uri": "s3.tf"}, "region": {"startLine": 3}}}]},
+		{"ruleId": "aws-s3-enable-bucket-logging", "level": "warning", "locations": [{"physicalLocation": {
+			"artifactLocation": {"uri": "s3.tf"}, "region": {"startLine": 3}}}]}
+	]
+}]}`
+
+func TestGetSARIFPath(t *testing.T) {
+	assert.Equal(t, "/mnt/tfsec.sarif", GetSARIFPath("", "tfsec"))
+	assert.Equal(t, "/work/trivy-config.sarif", GetSARIFPath("/work", "trivy-config"))
+}
+
+func TestIgnoreAnnotation(t *testing.T) {
+	assert.Equal(t, "#tfsec:ignore:aws-s3-enable-bucket-logging", IgnoreAnnotation("aws-s3-enable-bucket-logging"))
+}
+
+func TestTfsecBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *TfsecBuilder
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "defaults",
+			builder: NewTfsecBuilder("/mnt/infra"),
+			want:    []string{"tfsec", "/mnt/infra", "--no-color", "--format", "sarif", "--out", "/mnt/tfsec.sarif"},
+		},
+		{
+			name: "options",
+			builder: NewTfsecBuilder("/mnt/infra").
+				WithMinimumSeverity(policyx.SeverityHigh).
+				WithExclude("aws-s3-enable-bucket-logging", "aws-s3-enable-versioning").
+				WithTfvarsFile("prod.tfvars").
+				WithSoftFail(true).
+				WithNoIgnores(true).
+				WithSARIFPath("/mnt/reports/tfsec.sarif"),
+			want: []string{
+				"tfsec", "/mnt/infra", "--no-color", "--format", "sarif", "--out", "/mnt/reports/tfsec.sarif",
+				"--minimum-severity", "HIGH", "--exclude", "aws-s3-enable-bucket-logging,aws-s3-enable-versioning",
+				"--tfvars-file", "prod.tfvars", "--soft-fail", "--no-ignores",
+			},
+		},
+		{
+			name:    "missing directory",
+			builder: NewTfsecBuilder(""),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "missing SARIF path",
+			builder: NewTfsecBuilder("/mnt/infra").WithSARIFPath(""),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "unknown severity",
+			builder: NewTfsecBuilder("/mnt/infra").WithMinimumSeverity(policyx.SeverityNegligible),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestTfsecBuilderEvaluate(t *testing.T) {
+	result, err := NewTfsecBuilder("/mnt/infra").Evaluate([]byte(testSARIF))
+	require.NoError(t, err)
+	assert.False(t, result.Passed)
+	require.Len(t, result.Violations, 1)
+	assert.Equal(t, "aws-s3-enable-bucket-encryption in s3.tf:3 has severity high, above the maximum of medium",
+		result.Violations[0].Message)
+
+	result, err = NewTfsecBuilder("/mnt/infra").WithMaxSeverity(policyx.SeverityLow).Evaluate([]byte(testSARIF))
+	require.NoError(t, err)
+	assert.Len(t, result.Violations, 2)
+
+	_, err = NewTfsecBuilder("/mnt/infra").Evaluate([]byte("{"))
+	assert.Error(t, err)
+}
+
+func TestTfsecBuilderAddScan(t *testing.T) {
+	report := reportx.NewReport("iac")
+	require.NoError(t, NewTfsecBuilder("/mnt/infra").AddScan(report, []byte(testSARIF)))
+
+	require.Len(t, report.Scans, 1)
+	assert.Equal(t, "tfsec", report.Scans[0].Scanner)
+	assert.Equal(t, "/mnt/infra", report.Scans[0].Target)
+	assert.Equal(t, map[string]int{"high": 1, "medium": 1}, report.Scans[0].Counts)
+}
+
+func TestTfsecBuilderArtifacts(t *testing.T) {
+	assert.Equal(t, []artifactx.Artifact{{Kind: artifactx.KindReport, Path: "/mnt/tfsec.sarif"}},
+		NewTfsecBuilder("/mnt/infra").Artifacts())
+}
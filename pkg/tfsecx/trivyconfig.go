@@ -0,0 +1,256 @@
+package tfsecx
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/Excoriate/daggerx/pkg/trivyx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// TrivyIgnoreAnnotation returns the comment ignoring the trivy check 'id' for the resource it is
+// placed in (e.g. "#trivy:ignore:AVD-AWS-0086").
+func TrivyIgnoreAnnotation(id string) string {
+	return "#trivy:ignore:" + id
+}
+
+// TrivyConfigBuilder generates the trivy config command of a terraform directory. The trivy
+// cache, holding the checks bundle, is shared with the image scans (see trivyx).
+type TrivyConfigBuilder struct {
+	// dir is the scanned directory.
+	dir string
+
+	// minimumSeverity is the lowest severity of the reported findings.
+	minimumSeverity policyx.Severity
+
+	// skipDirs are the directories excluded from the scan.
+	skipDirs []string
+
+	// ignoreFile is the file of the ignored check IDs (.trivyignore).
+	ignoreFile string
+
+	// tfvarsFiles are the variable files of the terraform code.
+	tfvarsFiles []string
+
+	// skipCheckUpdate reuses the cached checks bundle.
+	skipCheckUpdate bool
+
+	// exitCode is the exit code of the command when findings are reported. Zero exits
+	// successfully.
+	exitCode int
+
+	// cacheDir is the trivy cache directory.
+	cacheDir string
+
+	// sarifPath is the path of the SARIF report.
+	sarifPath string
+
+	// maxSeverity is the highest severity of the findings passing the evaluation.
+	maxSeverity policyx.Severity
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewTrivyConfigBuilder creates a TrivyConfigBuilder scanning the terraform directory 'dir',
+// writing its SARIF report to GetSARIFPath("", "trivy-config") and failing the evaluation on
+// high and critical findings.
+func NewTrivyConfigBuilder(dir string) *TrivyConfigBuilder {
+	return &TrivyConfigBuilder{
+		dir:         dir,
+		cacheDir:    trivyx.GetCacheDir(""),
+		sarifPath:   GetSARIFPath("", "trivy-config"),
+		maxSeverity: policyx.SeverityMedium,
+	}
+}
+
+// WithMinimumSeverity sets the lowest severity of the reported findings.
+func (b *TrivyConfigBuilder) WithMinimumSeverity(severity policyx.Severity) *TrivyConfigBuilder {
+	b.minimumSeverity = severity
+	return b
+}
+
+// WithSkipDirs excludes directories from the scan (e.g. "examples").
+func (b *TrivyConfigBuilder) WithSkipDirs(dirs ...string) *TrivyConfigBuilder {
+	b.skipDirs = append(b.skipDirs, dirs...)
+	return b
+}
+
+// WithIgnoreFile sets the file of the check IDs ignored for the whole scan (.trivyignore).
+func (b *TrivyConfigBuilder) WithIgnoreFile(path string) *TrivyConfigBuilder {
+	b.ignoreFile = path
+	return b
+}
+
+// WithTfvarsFile adds variable files of the terraform code.
+func (b *TrivyConfigBuilder) WithTfvarsFile(paths ...string) *TrivyConfigBuilder {
+	b.tfvarsFiles = append(b.tfvarsFiles, paths...)
+	return b
+}
+
+// WithSkipCheckUpdate sets whether the cached checks bundle is reused rather than updated.
+func (b *TrivyConfigBuilder) WithSkipCheckUpdate(skip bool) *TrivyConfigBuilder {
+	b.skipCheckUpdate = skip
+	return b
+}
+
+// WithExitCode sets the exit code of the command when findings are reported. It defaults to
+// zero, leaving the decision to Evaluate.
+func (b *TrivyConfigBuilder) WithExitCode(code int) *TrivyConfigBuilder {
+	b.exitCode = code
+	return b
+}
+
+// WithCacheDir sets the trivy cache directory. It defaults to trivyx.GetCacheDir("").
+func (b *TrivyConfigBuilder) WithCacheDir(dir string) *TrivyConfigBuilder {
+	b.cacheDir = dir
+	return b
+}
+
+// WithSARIFPath sets the path of the SARIF report.
+func (b *TrivyConfigBuilder) WithSARIFPath(path string) *TrivyConfigBuilder {
+	b.sarifPath = path
+	return b
+}
+
+// WithMaxSeverity sets the highest severity of the findings passing the evaluation. It defaults
+// to medium.
+func (b *TrivyConfigBuilder) WithMaxSeverity(severity policyx.Severity) *TrivyConfigBuilder {
+	b.maxSeverity = severity
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *TrivyConfigBuilder) WithLogger(logger *slog.Logger) *TrivyConfigBuilder {
+	b.logger = logger
+	return b
+}
+
+// SARIFPath returns the path of the SARIF report.
+func (b *TrivyConfigBuilder) SARIFPath() string {
+	return b.sarifPath
+}
+
+// Mounts returns the mounts the generated command requires: the cache volume of the trivy
+// cache directory.
+func (b *TrivyConfigBuilder) Mounts() []types.Mount {
+	return []types.Mount{{Kind: types.MountKindCache, Source: trivyx.CacheVolumeKey, Target: b.cacheDir}}
+}
+
+// BuildCommand generates the trivy config command, writing the SARIF report.
+//
+// Returns:
+//   - The trivy config command.
+//   - An error if the directory, cache directory or SARIF path is missing, the minimum severity
+//     is not known to trivy, or the exit code is negative.
+func (b *TrivyConfigBuilder) BuildCommand() ([]string, error) {
+	for _, f := range []struct{ field, value string }{
+		{"dir", b.dir},
+		{"cacheDir", b.cacheDir},
+		{"sarifPath", b.sarifPath},
+	} {
+		if f.value == "" {
+			return nil, errorsx.MissingField(builderName, f.field, "%s is required", f.field)
+		}
+	}
+
+	if err := validateSeverity("minimumSeverity", b.minimumSeverity); err != nil {
+		return nil, err
+	}
+
+	if b.exitCode < 0 {
+		return nil, errorsx.InvalidValue(builderName, "exitCode", b.exitCode, "exit code cannot be negative")
+	}
+
+	cmd := []string{
+		"trivy", "config", "--cache-dir", b.cacheDir, "--format", "sarif", "--output", b.sarifPath,
+	}
+
+	if b.minimumSeverity != policyx.SeverityUnknown {
+		var severities []string
+		for s := b.minimumSeverity; s <= policyx.SeverityCritical; s++ {
+			severities = append(severities, severityNames[s])
+		}
+		cmd = append(cmd, "--severity", strings.Join(severities, ","))
+	}
+
+	if len(b.skipDirs) > 0 {
+		cmd = append(cmd, "--skip-dirs", strings.Join(b.skipDirs, ","))
+	}
+
+	if b.ignoreFile != "" {
+		cmd = append(cmd, "--ignorefile", b.ignoreFile)
+	}
+
+	for _, f := range b.tfvarsFiles {
+		cmd = append(cmd, "--tf-vars", f)
+	}
+
+	if b.skipCheckUpdate {
+		cmd = append(cmd, "--skip-check-update")
+	}
+
+	if b.exitCode > 0 {
+		cmd = append(cmd, "--exit-code", strconv.Itoa(b.exitCode))
+	}
+
+	cmd = append(cmd, b.dir)
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Evaluate parses the SARIF report 'sarif' and fails on the findings more severe than the
+// maximum severity.
+//
+// Returns:
+//   - The result of the evaluation.
+//   - An error if the report can't be parsed.
+func (b *TrivyConfigBuilder) Evaluate(sarif []byte) (policyx.Result, error) {
+	return evaluate(sarif, b.maxSeverity)
+}
+
+// AddScan parses the SARIF report 'sarif' and adds its findings to 'report', as a trivy config
+// scan of the scanned directory.
+//
+// Returns:
+//   - An error if the report can't be parsed.
+func (b *TrivyConfigBuilder) AddScan(report *reportx.Report, sarif []byte) error {
+	return addScan(report, "trivy-config", b.dir, sarif)
+}
+
+// Artifacts returns the artifacts of the scan: the SARIF report.
+func (b *TrivyConfigBuilder) Artifacts() []artifactx.Artifact {
+	return []artifactx.Artifact{{Kind: artifactx.KindReport, Path: b.sarifPath}}
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *TrivyConfigBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *TrivyConfigBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the trivy config command.
+func (b *TrivyConfigBuilder) Explain() explainx.Explanation {
+	return explainx.Explain("trivy config", b, NewTrivyConfigBuilder(""),
+		explainx.Generate("BuildCommand", b.BuildCommand))
+}
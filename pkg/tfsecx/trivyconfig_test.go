@@ -0,0 +1,108 @@
+package tfsecx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/Excoriate/daggerx/pkg/trivyx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrivyIgnoreAnnotation(t *testing.T) {
+	assert.Equal(t, "#trivy:ignore:AVD-AWS-0086", TrivyIgnoreAnnotation("AVD-AWS-0086"))
+}
+
+func TestTrivyConfigBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *TrivyConfigBuilder
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "defaults",
+			builder: NewTrivyConfigBuilder("/mnt/infra"),
+			want: []string{
+				"trivy", "config", "--cache-dir", "/mnt/var/cache/trivy",
+				"--format", "sarif", "--output", "/mnt/trivy-config.sarif", "/mnt/infra",
+			},
+		},
+		{
+			name: "options",
+			builder: NewTrivyConfigBuilder("/mnt/infra").
+				WithMinimumSeverity(policyx.SeverityMedium).
+				WithSkipDirs("examples", "test").
+				WithIgnoreFile(".trivyignore").
+				WithTfvarsFile("prod.tfvars").
+				WithSkipCheckUpdate(true).
+				WithExitCode(1).
+				WithCacheDir("/cache/trivy").
+				WithSARIFPath("/mnt/reports/trivy.sarif"),
+			want: []string{
+				"trivy", "config", "--cache-dir", "/cache/trivy",
+				"--format", "sarif", "--output", "/mnt/reports/trivy.sarif",
+				"--severity", "MEDIUM,HIGH,CRITICAL", "--skip-dirs", "examples,test",
+				"--ignorefile", ".trivyignore", "--tf-vars", "prod.tfvars", "--skip-check-update",
+				"--exit-code", "1", "/mnt/infra",
+			},
+		},
+		{
+			name:    "missing directory",
+			builder: NewTrivyConfigBuilder(""),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "missing cache directory",
+			builder: NewTrivyConfigBuilder("/mnt/infra").WithCacheDir(""),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "unknown severity",
+			builder: NewTrivyConfigBuilder("/mnt/infra").WithMinimumSeverity(policyx.SeverityNegligible),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "negative exit code",
+			builder: NewTrivyConfigBuilder("/mnt/infra").WithExitCode(-1),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestTrivyConfigBuilderMounts(t *testing.T) {
+	assert.Equal(t,
+		[]types.Mount{{Kind: types.MountKindCache, Source: trivyx.CacheVolumeKey, Target: "/mnt/var/cache/trivy"}},
+		NewTrivyConfigBuilder("/mnt/infra").Mounts())
+}
+
+func TestTrivyConfigBuilderEvaluateAndAddScan(t *testing.T) {
+	b := NewTrivyConfigBuilder("/mnt/infra").WithMaxSeverity(policyx.SeverityHigh)
+
+	result, err := b.Evaluate([]byte(testSARIF))
+	require.NoError(t, err)
+	assert.True(t, result.Passed)
+
+	report := reportx.NewReport("iac")
+	require.NoError(t, b.AddScan(report, []byte(testSARIF)))
+	require.Len(t, report.Scans, 1)
+	assert.Equal(t, "trivy-config", report.Scans[0].Scanner)
+
+	assert.Error(t, b.AddScan(report, []byte("{")))
+}
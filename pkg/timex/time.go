@@ -0,0 +1,137 @@
+// Package timex provides the timestamp helpers of reproducible builds: parsing build timestamps
+// given as RFC 3339 times, Unix epochs (SOURCE_DATE_EPOCH) or git dates, normalizing them to
+// whole UTC seconds, and rendering them in the formats the build tools expect.
+//
+// Code reading the current time takes a Clock, so tests inject a fixed time instead of patching
+// time.Now.
+//
+// Example usage:
+//
+//	built, err := timex.Resolve(os.Getenv(timex.SourceDateEpochEnv), timex.System)
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	apko := apkox.NewApkoBuilder().WithBuildDate(timex.RFC3339(built))
+//	buildx := buildxpkg.NewBuildxBuilder().WithSourceDateEpoch(built)
+package timex
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+)
+
+// builderName identifies the time helpers in errorsx errors.
+const builderName = "time"
+
+// SourceDateEpochEnv is the environment variable holding the build timestamp of reproducible
+// builds, as Unix seconds.
+const SourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+// gitLayouts are the layouts of the dates printed by git: %ci, %cD and the default format.
+var gitLayouts = []string{
+	"2006-01-02 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon Jan 2 15:04:05 2006 -0700",
+}
+
+// Clock returns the current time. A nil Clock is the system clock.
+type Clock func() time.Time
+
+// System is the system clock.
+var System Clock = time.Now
+
+// Fixed returns a clock always returning 't', for tests.
+func Fixed(t time.Time) Clock {
+	return func() time.Time { return t }
+}
+
+// Now returns the current time of the clock.
+func (c Clock) Now() time.Time {
+	if c == nil {
+		return time.Now()
+	}
+
+	return c()
+}
+
+// Normalize returns 't' in UTC, truncated to whole seconds, the precision of tar headers and
+// SOURCE_DATE_EPOCH.
+func Normalize(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
+// Parse parses a build timestamp given as Unix seconds ("1700000000", "@1700000000"), a git raw
+// date ("1700000000 +0100"), an RFC 3339 time, or a date printed by git (%ci, %cD, or the
+// default format).
+//
+// Returns:
+//   - The normalized timestamp, see Normalize.
+//   - An error if the value matches none of the formats.
+func Parse(value string) (time.Time, error) {
+	s := strings.TrimSpace(value)
+	if s == "" {
+		return time.Time{}, errorsx.MissingField(builderName, "timestamp", "timestamp is required")
+	}
+
+	epoch := strings.TrimPrefix(s, "@")
+	if secs, offset, ok := strings.Cut(epoch, " "); ok && isOffset(offset) {
+		epoch = secs
+	}
+	if secs, err := strconv.ParseInt(epoch, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return Normalize(t), nil
+	}
+
+	for _, layout := range gitLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return Normalize(t), nil
+		}
+	}
+
+	return time.Time{}, errorsx.InvalidValue(builderName, "timestamp", value,
+		"timestamp is neither Unix seconds, RFC 3339 nor a git date: %q", value)
+}
+
+// isOffset reports whether 's' is a time zone offset such as "+0100".
+func isOffset(s string) bool {
+	if len(s) != 5 || (s[0] != '+' && s[0] != '-') {
+		return false
+	}
+
+	_, err := strconv.Atoi(s[1:])
+
+	return err == nil
+}
+
+// Resolve returns the build timestamp 'value' (see Parse), or the current time of 'clock' when
+// it is empty, so builds are reproducible when SOURCE_DATE_EPOCH is set.
+//
+// Returns:
+//   - The normalized timestamp.
+//   - An error if the value is set but invalid.
+func Resolve(value string, clock Clock) (time.Time, error) {
+	if strings.TrimSpace(value) == "" {
+		return Normalize(clock.Now()), nil
+	}
+
+	return Parse(value)
+}
+
+// Epoch renders 't' as Unix seconds, the format of SOURCE_DATE_EPOCH, of buildx build
+// arguments and of tar --mtime=@.
+func Epoch(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+// RFC3339 renders 't' as a whole-second UTC RFC 3339 time, the format of the apko --build-date
+// flag and of the org.opencontainers.image.created annotation.
+func RFC3339(t time.Time) string {
+	return Normalize(t).Format(time.RFC3339)
+}
@@ -0,0 +1,78 @@
+package timex
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	want := time.Date(2023, time.November, 14, 22, 13, 20, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"Epoch", "1700000000"},
+		{"Epoch with at sign", "@1700000000\n"},
+		{"Git raw", "1700000000 +0100"},
+		{"RFC 3339", "2023-11-14T22:13:20Z"},
+		{"RFC 3339 with offset and nanoseconds", "2023-11-14T23:13:20.999+01:00"},
+		{"Git ISO", "2023-11-14 23:13:20 +0100"},
+		{"Git RFC 2822", "Tue, 14 Nov 2023 23:13:20 +0100"},
+		{"Git default", "Tue Nov 14 23:13:20 2023 +0100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.value)
+			require.NoError(t, err)
+			assert.True(t, want.Equal(got), "got %s", got)
+			assert.Equal(t, time.UTC, got.Location())
+		})
+	}
+
+	_, err := Parse("")
+	assert.True(t, errors.Is(err, errorsx.ErrMissingField))
+
+	for _, value := range []string{"yesterday", "1700000000 CET", "2023-11-14"} {
+		_, err := Parse(value)
+		assert.True(t, errors.Is(err, errorsx.ErrInvalidValue), value)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 500, time.FixedZone("CEST", 2*3600))
+
+	got, err := Resolve("", Fixed(now))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC), got)
+
+	got, err = Resolve("1700000000", Fixed(now))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1700000000), got.Unix())
+
+	_, err = Resolve("not a time", Fixed(now))
+	assert.Error(t, err)
+}
+
+func TestClock(t *testing.T) {
+	var nilClock Clock
+	assert.WithinDuration(t, time.Now(), nilClock.Now(), time.Minute)
+	assert.WithinDuration(t, time.Now(), System.Now(), time.Minute)
+
+	fixed := time.Unix(42, 0)
+	assert.Equal(t, fixed, Fixed(fixed).Now())
+}
+
+func TestRender(t *testing.T) {
+	ts := time.Date(2023, time.November, 14, 23, 13, 20, 999, time.FixedZone("CET", 3600))
+
+	assert.Equal(t, "1700000000", Epoch(ts))
+	assert.Equal(t, "2023-11-14T22:13:20Z", RFC3339(ts))
+	assert.Equal(t, time.Date(2023, time.November, 14, 22, 13, 20, 0, time.UTC), Normalize(ts))
+}
@@ -0,0 +1,328 @@
+// Package trivyx provides a builder for trivy, the vulnerability scanner, with control over its
+// cache and vulnerability database so repeated and air-gapped scans don't download the database.
+//
+// By convention, the trivy cache, holding the vulnerability databases, lives at
+// GetCacheDir(mntPrefix) and is backed by a cache volume keyed CacheVolumeKey. Air-gapped
+// pipelines provide a pre-downloaded database bundle with WithOfflineDB instead.
+//
+// Example usage:
+//
+//	builder := trivyx.NewTrivyBuilder("registry.example.com/app:v1").
+//	    WithFormat("json").
+//	    WithSeverity("HIGH", "CRITICAL").
+//	    WithDBRepository("registry.example.com/mirror/trivy-db")
+//
+//	cmd, err := builder.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	res, err := executor.Run(ctx, cmd, nil, builder.Mounts())
+package trivyx
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the trivy builder in errorsx errors.
+const builderName = "trivy"
+
+// TrivyDefaultImage is the default container image providing trivy.
+const TrivyDefaultImage = "aquasec/trivy"
+
+// CacheVolumeKey is the cache volume key of the trivy cache.
+const CacheVolumeKey = "trivy-cache"
+
+// TrivyBuilder generates the trivy image scan command.
+type TrivyBuilder struct {
+	// target is the scanned image reference.
+	target string
+
+	// format is the report format (e.g. "json", "table", "sarif").
+	format string
+
+	// output is the path the report is written to.
+	output string
+
+	// severities are the reported severities.
+	severities []string
+
+	// cacheDir is the trivy cache directory.
+	cacheDir string
+
+	// noCacheVolume disables the cache volume mount of the cache directory.
+	noCacheVolume bool
+
+	// skipDBUpdate skips the vulnerability database update.
+	skipDBUpdate bool
+
+	// skipJavaDBUpdate skips the Java index database update.
+	skipJavaDBUpdate bool
+
+	// dbRepository is the OCI repository the vulnerability database is downloaded from.
+	dbRepository string
+
+	// javaDBRepository is the OCI repository the Java index database is downloaded from.
+	javaDBRepository string
+
+	// offlineDB is the host directory holding an extracted vulnerability database bundle.
+	offlineDB string
+
+	// extraArgs are additional arguments appended before the target.
+	extraArgs []string
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewTrivyBuilder creates a TrivyBuilder scanning the image 'target'.
+func NewTrivyBuilder(target string) *TrivyBuilder {
+	return &TrivyBuilder{target: target}
+}
+
+// GetCacheDir returns the trivy cache directory. If mntPrefix is empty, fixtures.MntPrefix is used.
+func GetCacheDir(mntPrefix string) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	return path.Join(mntPrefix, "var", "cache", "trivy")
+}
+
+// WithFormat sets the report format (e.g. "json", "table", "sarif").
+func (b *TrivyBuilder) WithFormat(format string) *TrivyBuilder {
+	b.format = format
+	return b
+}
+
+// WithOutput sets the path the report is written to.
+func (b *TrivyBuilder) WithOutput(path string) *TrivyBuilder {
+	b.output = path
+	return b
+}
+
+// WithSeverity adds reported severities (e.g. "HIGH", "CRITICAL").
+func (b *TrivyBuilder) WithSeverity(severities ...string) *TrivyBuilder {
+	b.severities = append(b.severities, severities...)
+	return b
+}
+
+// WithCacheDir sets the trivy cache directory. It defaults to GetCacheDir("").
+func (b *TrivyBuilder) WithCacheDir(dir string) *TrivyBuilder {
+	b.cacheDir = dir
+	return b
+}
+
+// WithoutCacheVolume disables the cache volume mount of the cache directory, e.g. when the
+// executor provides the cache directory itself.
+func (b *TrivyBuilder) WithoutCacheVolume() *TrivyBuilder {
+	b.noCacheVolume = true
+	return b
+}
+
+// WithSkipDBUpdate skips the vulnerability database update, reusing the cached database.
+func (b *TrivyBuilder) WithSkipDBUpdate(skip bool) *TrivyBuilder {
+	b.skipDBUpdate = skip
+	return b
+}
+
+// WithSkipJavaDBUpdate skips the Java index database update, reusing the cached database.
+func (b *TrivyBuilder) WithSkipJavaDBUpdate(skip bool) *TrivyBuilder {
+	b.skipJavaDBUpdate = skip
+	return b
+}
+
+// WithDBRepository sets the OCI repository the vulnerability database is downloaded from,
+// e.g. a mirror of ghcr.io/aquasecurity/trivy-db.
+func (b *TrivyBuilder) WithDBRepository(repo string) *TrivyBuilder {
+	b.dbRepository = repo
+	return b
+}
+
+// WithJavaDBRepository sets the OCI repository the Java index database is downloaded from.
+func (b *TrivyBuilder) WithJavaDBRepository(repo string) *TrivyBuilder {
+	b.javaDBRepository = repo
+	return b
+}
+
+// WithOfflineDB scans air-gapped with the vulnerability database bundle extracted in the host
+// directory 'dir' (holding trivy.db and metadata.json). The bundle is mounted in the database
+// directory of the cache, and database updates and online lookups are disabled.
+func (b *TrivyBuilder) WithOfflineDB(dir string) *TrivyBuilder {
+	b.offlineDB = dir
+	return b
+}
+
+// WithExtraArg adds an argument appended before the target.
+func (b *TrivyBuilder) WithExtraArg(arg string) *TrivyBuilder {
+	b.extraArgs = append(b.extraArgs, arg)
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *TrivyBuilder) WithLogger(logger *slog.Logger) *TrivyBuilder {
+	b.logger = logger
+	return b
+}
+
+// getCacheDir returns the configured cache directory, or the conventional one.
+func (b *TrivyBuilder) getCacheDir() string {
+	if b.cacheDir != "" {
+		return b.cacheDir
+	}
+
+	return GetCacheDir("")
+}
+
+// DBDir returns the directory of the vulnerability database inside the cache directory.
+func (b *TrivyBuilder) DBDir() string {
+	return filepath.Join(b.getCacheDir(), "db")
+}
+
+// Mounts returns the mounts the generated command requires: the cache volume of the cache
+// directory, and the offline database bundle when set.
+func (b *TrivyBuilder) Mounts() []types.Mount {
+	var mounts []types.Mount
+
+	if !b.noCacheVolume {
+		mounts = append(mounts, types.Mount{
+			Kind:   types.MountKindCache,
+			Source: CacheVolumeKey,
+			Target: b.getCacheDir(),
+		})
+	}
+
+	if b.offlineDB != "" {
+		mounts = append(mounts, types.Mount{
+			Kind:   types.MountKindDirectory,
+			Source: b.offlineDB,
+			Target: b.DBDir(),
+		})
+	}
+
+	return mounts
+}
+
+// validate checks that the builder configuration is complete and consistent.
+func (b *TrivyBuilder) validate() error {
+	if b.target == "" {
+		return errorsx.MissingField(builderName, "target", "scan target is required")
+	}
+
+	if b.offlineDB != "" && b.dbRepository != "" {
+		return errorsx.ConflictingOptions(builderName, []string{"offlineDB", "dbRepository"},
+			"an offline database is never downloaded from a repository")
+	}
+
+	if b.skipDBUpdate && b.dbRepository != "" {
+		return errorsx.ConflictingOptions(builderName, []string{"skipDBUpdate", "dbRepository"},
+			"the database repository is unused when database updates are skipped")
+	}
+
+	if b.skipJavaDBUpdate && b.javaDBRepository != "" {
+		return errorsx.ConflictingOptions(builderName, []string{"skipJavaDBUpdate", "javaDBRepository"},
+			"the Java database repository is unused when Java database updates are skipped")
+	}
+
+	return nil
+}
+
+// BuildCommand generates the trivy image command.
+//
+// Returns:
+//   - The trivy image command.
+//   - An error if the target is missing or the database options are inconsistent.
+func (b *TrivyBuilder) BuildCommand() ([]string, error) {
+	ctx := context.Background()
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := []string{"trivy", "image", "--cache-dir", b.getCacheDir()}
+
+	if b.format != "" {
+		cmd = append(cmd, "--format", b.format)
+	}
+
+	if b.output != "" {
+		cmd = append(cmd, "--output", b.output)
+	}
+
+	if len(b.severities) > 0 {
+		cmd = append(cmd, "--severity", strings.Join(b.severities, ","))
+	}
+
+	if b.skipDBUpdate || b.offlineDB != "" {
+		cmd = append(cmd, "--skip-db-update")
+	}
+
+	if b.skipJavaDBUpdate || b.offlineDB != "" {
+		cmd = append(cmd, "--skip-java-db-update")
+	}
+
+	if b.offlineDB != "" {
+		cmd = append(cmd, "--offline-scan")
+	}
+
+	if b.dbRepository != "" {
+		cmd = append(cmd, "--db-repository", b.dbRepository)
+	}
+
+	if b.javaDBRepository != "" {
+		cmd = append(cmd, "--java-db-repository", b.javaDBRepository)
+	}
+
+	cmd = append(cmd, b.extraArgs...)
+	cmd = append(cmd, b.target)
+
+	logx.Command(ctx, b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// DebugRecipe returns the interactive container reproducing the scan by hand: the trivy image
+// with the mounts of the scan (see Mounts), and the scan command, left for the user to run.
+//
+// Returns:
+//   - The debug recipe.
+//   - An error if the scan command cannot be generated, see BuildCommand.
+func (b *TrivyBuilder) DebugRecipe() (types.DebugRecipe, error) {
+	cmd, err := b.BuildCommand()
+	if err != nil {
+		return types.DebugRecipe{}, err
+	}
+
+	return types.DebugRecipe{Image: TrivyDefaultImage, Mounts: b.Mounts(), Command: cmd}, nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *TrivyBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *TrivyBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the trivy command.
+func (b *TrivyBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewTrivyBuilder(""), explainx.Generate("BuildCommand", b.BuildCommand))
+}
@@ -0,0 +1,100 @@
+package trivyx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCacheDir(t *testing.T) {
+	assert.Equal(t, "/mnt/var/cache/trivy", GetCacheDir(""))
+	assert.Equal(t, "/work/var/cache/trivy", GetCacheDir("/work"))
+}
+
+func TestTrivyBuilderBuildCommand(t *testing.T) {
+	b := NewTrivyBuilder("registry.example.com/app:v1").
+		WithFormat("json").
+		WithOutput("/mnt/trivy.json").
+		WithSeverity("HIGH", "CRITICAL").
+		WithDBRepository("registry.example.com/mirror/trivy-db").
+		WithSkipJavaDBUpdate(true)
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"trivy", "image",
+		"--cache-dir", "/mnt/var/cache/trivy",
+		"--format", "json",
+		"--output", "/mnt/trivy.json",
+		"--severity", "HIGH,CRITICAL",
+		"--skip-java-db-update",
+		"--db-repository", "registry.example.com/mirror/trivy-db",
+		"registry.example.com/app:v1",
+	}, cmd)
+
+	assert.Equal(t, []types.Mount{
+		{Kind: types.MountKindCache, Source: CacheVolumeKey, Target: "/mnt/var/cache/trivy"},
+	}, b.Mounts())
+}
+
+func TestTrivyBuilderOfflineDB(t *testing.T) {
+	b := NewTrivyBuilder("app.tar").
+		WithCacheDir("/cache/trivy").
+		WithOfflineDB("./trivy-db").
+		WithoutCacheVolume()
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"trivy", "image",
+		"--cache-dir", "/cache/trivy",
+		"--skip-db-update",
+		"--skip-java-db-update",
+		"--offline-scan",
+		"app.tar",
+	}, cmd)
+
+	assert.Equal(t, []types.Mount{
+		{Kind: types.MountKindDirectory, Source: "./trivy-db", Target: "/cache/trivy/db"},
+	}, b.Mounts())
+}
+
+func TestTrivyBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *TrivyBuilder
+		kind    error
+	}{
+		{"Missing target", NewTrivyBuilder(""), errorsx.ErrMissingField},
+		{"Offline with repository", NewTrivyBuilder("img").WithOfflineDB("db").WithDBRepository("repo"), errorsx.ErrConflictingOptions},
+		{"Skip update with repository", NewTrivyBuilder("img").WithSkipDBUpdate(true).WithDBRepository("repo"), errorsx.ErrConflictingOptions},
+		{"Skip Java update with repository", NewTrivyBuilder("img").WithSkipJavaDBUpdate(true).WithJavaDBRepository("repo"), errorsx.ErrConflictingOptions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.BuildCommand()
+			assert.True(t, errors.Is(err, tt.kind), "got %v", err)
+		})
+	}
+}
+
+func TestTrivyBuilderDebugRecipe(t *testing.T) {
+	b := NewTrivyBuilder("registry.example.com/app:v1")
+
+	recipe, err := b.DebugRecipe()
+	require.NoError(t, err)
+
+	cmd, err := b.BuildCommand()
+	require.NoError(t, err)
+	assert.Equal(t, TrivyDefaultImage, recipe.Image)
+	assert.Equal(t, types.DaggerCMD(cmd), recipe.Command)
+	assert.Equal(t, b.Mounts(), recipe.Mounts)
+
+	_, err = NewTrivyBuilder("").DebugRecipe()
+	assert.ErrorIs(t, err, errorsx.ErrMissingField)
+}
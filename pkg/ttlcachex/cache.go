@@ -0,0 +1,181 @@
+// Package ttlcachex caches short-lived values whose expiry is known when they are obtained, such
+// as the registry tokens printed by cloud auth helpers, and refreshes them before they expire.
+//
+// Unlike cachex, whose entries share a TTL, every entry carries its own expiry, and a refresh
+// margin makes the cache fetch a new value while the cached one is still valid, so a push never
+// starts with a token about to expire. Concurrent lookups of the same key share a single fetch.
+//
+// TokenCache applies the cache to registry tokens keyed by registry host, so a pipeline pushing
+// 20 images to ECR runs `aws ecr get-login-password` once instead of 20 times.
+//
+// Example usage:
+//
+//	tokens := ttlcachex.NewTokenCache(execx.NewLocalExecutor())
+//
+//	for _, image := range images {
+//	    cred, err := tokens.Credential(ctx, dockerauthx.ECR("123456789012", "eu-west-1"))
+//	    if err != nil {
+//	        // handle error
+//	    }
+//
+//	    config, err := ttlcachex.DockerConfig(cred)
+//	    if err != nil {
+//	        // handle error
+//	    }
+//
+//	    // write config to $DOCKER_CONFIG/config.json and push the image
+//	}
+package ttlcachex
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/timex"
+)
+
+// DefaultRefreshMargin is how long before their expiry values are refreshed by default.
+const DefaultRefreshMargin = 5 * time.Minute
+
+// Fetcher obtains a value and its expiry.
+type Fetcher[V any] func(ctx context.Context) (V, time.Time, error)
+
+// entry is a cached value with its expiry.
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// call is an in-flight fetch, shared by the concurrent lookups of its key.
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// Cache is a concurrency-safe cache of values expiring at their own time.
+type Cache[V any] struct {
+	mu       sync.Mutex
+	entries  map[string]entry[V]
+	inflight map[string]*call[V]
+	// margin is how long before their expiry values are refreshed.
+	margin time.Duration
+	// now is the clock of the cache.
+	now timex.Clock
+}
+
+// NewCache creates an empty cache refreshing values DefaultRefreshMargin before their expiry.
+func NewCache[V any]() *Cache[V] {
+	return &Cache[V]{
+		entries:  map[string]entry[V]{},
+		inflight: map[string]*call[V]{},
+		margin:   DefaultRefreshMargin,
+		now:      timex.System,
+	}
+}
+
+// WithRefreshMargin sets how long before their expiry values are refreshed. Negative margins are
+// treated as zero.
+// It returns the updated Cache instance.
+func (c *Cache[V]) WithRefreshMargin(margin time.Duration) *Cache[V] {
+	c.margin = max(margin, 0)
+	return c
+}
+
+// WithClock sets the clock of the cache, for tests.
+// It returns the updated Cache instance.
+func (c *Cache[V]) WithClock(clock timex.Clock) *Cache[V] {
+	c.now = clock
+	return c
+}
+
+// fresh reports whether the entry is valid beyond the refresh margin.
+func (c *Cache[V]) fresh(e entry[V]) bool {
+	return c.now.Now().Add(c.margin).Before(e.expiresAt)
+}
+
+// Get returns the value of 'key' if it is cached and not due for refresh.
+func (c *Cache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || !c.fresh(e) {
+		var zero V
+		return zero, false
+	}
+
+	return e.value, true
+}
+
+// Set caches 'value' for 'key' until 'expiresAt'.
+func (c *Cache[V]) Set(key string, value V, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = entry[V]{value: value, expiresAt: expiresAt}
+}
+
+// Delete removes the entry of 'key', e.g. after the registry rejected its token.
+func (c *Cache[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+// Len returns the number of cached entries, including those due for refresh.
+func (c *Cache[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+// GetOrFetch returns the cached value of 'key', calling 'fetch' when it is missing or due for
+// refresh. Concurrent calls for the same key wait for a single fetch. Fetch errors are not
+// cached, and values already expired when fetched are returned without being cached.
+//
+// Returns:
+//   - The cached or fetched value.
+//   - The error returned by 'fetch', or the context error when 'ctx' is done while waiting for
+//     the fetch of another call.
+func (c *Cache[V]) GetOrFetch(ctx context.Context, key string, fetch Fetcher[V]) (V, error) {
+	c.mu.Lock()
+
+	if e, ok := c.entries[key]; ok && c.fresh(e) {
+		c.mu.Unlock()
+		return e.value, nil
+	}
+
+	if inflight, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+
+		select {
+		case <-inflight.done:
+			return inflight.value, inflight.err
+		case <-ctx.Done():
+			var zero V
+			return zero, ctx.Err()
+		}
+	}
+
+	current := &call[V]{done: make(chan struct{})}
+	c.inflight[key] = current
+	c.mu.Unlock()
+
+	value, expiresAt, err := fetch(ctx)
+	current.value, current.err = value, err
+
+	c.mu.Lock()
+	if err == nil && c.now.Now().Before(expiresAt) {
+		c.entries[key] = entry[V]{value: value, expiresAt: expiresAt}
+	}
+	delete(c.inflight, key)
+	c.mu.Unlock()
+
+	close(current.done)
+
+	return value, err
+}
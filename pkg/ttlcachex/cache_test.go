@@ -0,0 +1,144 @@
+package ttlcachex
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testClock is a settable clock.
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestCacheRefreshMargin(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cache := NewCache[string]().WithClock(clock.Now).WithRefreshMargin(10 * time.Minute)
+
+	var fetches int
+	fetch := func(context.Context) (string, time.Time, error) {
+		fetches++
+		return "token", clock.Now().Add(time.Hour), nil
+	}
+
+	for range 3 {
+		value, err := cache.GetOrFetch(context.Background(), "ecr", fetch)
+		require.NoError(t, err)
+		assert.Equal(t, "token", value)
+	}
+	assert.Equal(t, 1, fetches)
+
+	clock.Advance(49 * time.Minute)
+	_, ok := cache.Get("ecr")
+	assert.True(t, ok)
+
+	clock.Advance(time.Minute)
+	_, ok = cache.Get("ecr")
+	assert.False(t, ok, "values are refreshed within the margin")
+
+	_, err := cache.GetOrFetch(context.Background(), "ecr", fetch)
+	require.NoError(t, err)
+	assert.Equal(t, 2, fetches)
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestCacheSingleFetch(t *testing.T) {
+	cache := NewCache[int]()
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	fetch := func(context.Context) (int, time.Time, error) {
+		fetches.Add(1)
+		<-release
+		return 42, time.Now().Add(time.Hour), nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = cache.GetOrFetch(context.Background(), "key", fetch)
+		}()
+	}
+
+	require.Eventually(t, func() bool { return fetches.Load() == 1 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), fetches.Load())
+	for _, r := range results {
+		assert.Equal(t, 42, r)
+	}
+}
+
+func TestCacheErrorsAndExpiredValues(t *testing.T) {
+	cache := NewCache[string]()
+
+	_, err := cache.GetOrFetch(context.Background(), "key", func(context.Context) (string, time.Time, error) {
+		return "", time.Time{}, errors.New("denied")
+	})
+	require.EqualError(t, err, "denied")
+	assert.Zero(t, cache.Len())
+
+	value, err := cache.GetOrFetch(context.Background(), "key", func(context.Context) (string, time.Time, error) {
+		return "stale", time.Now().Add(-time.Second), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "stale", value)
+	assert.Zero(t, cache.Len())
+
+	cache.Set("key", "set", time.Now().Add(time.Hour))
+	value, ok := cache.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, "set", value)
+
+	cache.Delete("key")
+	_, ok = cache.Get("key")
+	assert.False(t, ok)
+}
+
+func TestCacheWaitHonoursContext(t *testing.T) {
+	cache := NewCache[string]()
+	release := make(chan struct{})
+	defer close(release)
+
+	started := make(chan struct{})
+	go func() {
+		_, _ = cache.GetOrFetch(context.Background(), "key", func(context.Context) (string, time.Time, error) {
+			close(started)
+			<-release
+			return "", time.Time{}, nil
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := cache.GetOrFetch(ctx, "key", func(context.Context) (string, time.Time, error) {
+		t.Error("unexpected second fetch")
+		return "", time.Time{}, nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,168 @@
+package ttlcachex
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/dockerauthx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/execx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/timex"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the token cache in errorsx errors.
+const builderName = "ttlcache"
+
+// Credential is a short-lived registry credential.
+type Credential struct {
+	// Host is the registry host.
+	Host string `json:"host"`
+	// Username is the username the registry expects with the token.
+	Username string `json:"username"`
+	// Token is the registry token. It is a secret.
+	Token string `json:"-"`
+	// ExpiresAt is when the token expires.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// String describes the credential without its token.
+func (c Credential) String() string {
+	return fmt.Sprintf("%s@%s:%s (expires %s)", c.Username, c.Host, logx.Redacted, c.ExpiresAt.Format(time.RFC3339))
+}
+
+// Auth returns the docker config "auth" value of the credential: the base64 encoded
+// "username:token".
+func (c Credential) Auth() string {
+	return base64.StdEncoding.EncodeToString([]byte(c.Username + ":" + c.Token))
+}
+
+// DockerConfig returns the docker config.json authenticating to the registries of 'creds'.
+//
+// Returns:
+//   - The JSON docker config. It holds the tokens: write it with restricted permissions.
+//   - An error if a credential has no host.
+func DockerConfig(creds ...Credential) ([]byte, error) {
+	type auth struct {
+		Auth string `json:"auth"`
+	}
+
+	auths := make(map[string]auth, len(creds))
+	for _, c := range creds {
+		if c.Host == "" {
+			return nil, errorsx.MissingField(builderName, "host", "credential has no registry host")
+		}
+		auths[c.Host] = auth{Auth: c.Auth()}
+	}
+
+	data, err := json.Marshal(map[string]any{"auths": auths})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode docker config: %w", err)
+	}
+
+	return data, nil
+}
+
+// TokenCache caches the registry tokens printed by the cloud auth helpers, keyed by registry
+// host, running the helper again only when the token is about to expire.
+type TokenCache struct {
+	// cache holds the credentials by registry host.
+	cache *Cache[Credential]
+	// executor runs the token commands.
+	executor execx.Executor
+	// env holds the environment variables of the token commands, e.g. the cloud credentials.
+	env []types.DaggerEnvVars
+	// now is the clock computing the token expiries.
+	now timex.Clock
+}
+
+// NewTokenCache creates a token cache running the token commands with 'executor', refreshing the
+// tokens DefaultRefreshMargin before they expire.
+func NewTokenCache(executor execx.Executor) *TokenCache {
+	return &TokenCache{
+		cache:    NewCache[Credential](),
+		executor: executor,
+		now:      timex.System,
+	}
+}
+
+// WithEnv adds environment variables to the token commands (e.g. AWS_PROFILE).
+// It returns the updated TokenCache instance.
+func (t *TokenCache) WithEnv(env ...types.DaggerEnvVars) *TokenCache {
+	t.env = append(t.env, env...)
+	return t
+}
+
+// WithRefreshMargin sets how long before their expiry tokens are refreshed.
+// It returns the updated TokenCache instance.
+func (t *TokenCache) WithRefreshMargin(margin time.Duration) *TokenCache {
+	t.cache.WithRefreshMargin(margin)
+	return t
+}
+
+// WithClock sets the clock of the cache, for tests.
+// It returns the updated TokenCache instance.
+func (t *TokenCache) WithClock(clock timex.Clock) *TokenCache {
+	t.now = clock
+	t.cache.WithClock(clock)
+	return t
+}
+
+// Credential returns the credential of 'registry', running its token command (see
+// dockerauthx.Registry.TokenCommand) when no cached token is valid beyond the refresh margin.
+// The token expires after the token lifetime of the registry, counted from when the command
+// started.
+//
+// Returns:
+//   - The credential.
+//   - An error if the registry is invalid, or the token command fails or prints no token. The
+//     token never appears in the error.
+func (t *TokenCache) Credential(ctx context.Context, registry dockerauthx.Registry) (Credential, error) {
+	cmd, err := registry.TokenCommand()
+	if err != nil {
+		return Credential{}, err
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return t.cache.GetOrFetch(ctx, registry.Host, func(ctx context.Context) (Credential, time.Time, error) {
+		started := t.now.Now()
+
+		res, err := t.executor.Run(ctx, cmd, t.env, nil)
+		if err != nil {
+			return Credential{}, time.Time{}, fmt.Errorf("failed to get the token of %s: %w", registry.Host, err)
+		}
+
+		if !res.Succeeded() {
+			return Credential{}, time.Time{}, fmt.Errorf("failed to get the token of %s: exit code %d: %s",
+				registry.Host, res.ExitCode, strings.TrimSpace(res.Stderr))
+		}
+
+		token := strings.TrimSpace(res.Stdout)
+		if token == "" || strings.ContainsAny(token, "\n\r") {
+			return Credential{}, time.Time{}, errorsx.InvalidValue(builderName, "token", logx.Redacted,
+				"token command of %s printed no single-line token", registry.Host)
+		}
+
+		cred := Credential{
+			Host:      registry.Host,
+			Username:  registry.Username(),
+			Token:     token,
+			ExpiresAt: started.Add(registry.TokenTTL()),
+		}
+
+		return cred, cred.ExpiresAt, nil
+	})
+}
+
+// Invalidate drops the cached token of the registry 'host', e.g. after the registry rejected it.
+func (t *TokenCache) Invalidate(host string) {
+	t.cache.Delete(host)
+}
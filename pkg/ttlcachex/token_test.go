@@ -0,0 +1,94 @@
+package ttlcachex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/dockerauthx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/execx"
+	"github.com/Excoriate/daggerx/pkg/timex"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenExecutor prints the configured output, recording the commands it runs.
+type tokenExecutor struct {
+	mu       sync.Mutex
+	commands []types.DaggerCMD
+	result   *execx.Result
+	err      error
+}
+
+func (e *tokenExecutor) Run(_ context.Context, cmd types.DaggerCMD, _ []types.DaggerEnvVars, _ []types.Mount) (*execx.Result, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.commands = append(e.commands, cmd)
+	return e.result, e.err
+}
+
+func TestTokenCacheCredential(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	executor := &tokenExecutor{result: &execx.Result{Stdout: "s3cr3t\n"}}
+	tokens := NewTokenCache(executor).WithClock(timex.Fixed(now))
+
+	registry := dockerauthx.ECR("123456789012", "eu-west-1")
+	for range 20 {
+		cred, err := tokens.Credential(context.Background(), registry)
+		require.NoError(t, err)
+
+		assert.Equal(t, "123456789012.dkr.ecr.eu-west-1.amazonaws.com", cred.Host)
+		assert.Equal(t, "AWS", cred.Username)
+		assert.Equal(t, "s3cr3t", cred.Token)
+		assert.Equal(t, now.Add(dockerauthx.ECRTokenTTL), cred.ExpiresAt)
+		assert.NotContains(t, cred.String(), "s3cr3t")
+	}
+
+	require.Len(t, executor.commands, 1)
+	assert.Equal(t, types.DaggerCMD{"sh", "-c", "aws ecr get-login-password --region eu-west-1"}, executor.commands[0])
+
+	tokens.Invalidate(registry.Host)
+	_, err := tokens.Credential(context.Background(), registry)
+	require.NoError(t, err)
+	assert.Len(t, executor.commands, 2)
+}
+
+func TestTokenCacheErrors(t *testing.T) {
+	_, err := NewTokenCache(&tokenExecutor{}).Credential(context.Background(), dockerauthx.ECR("123", "eu-west-1"))
+	require.ErrorIs(t, err, errorsx.ErrInvalidValue)
+
+	failing := &tokenExecutor{result: &execx.Result{ExitCode: 255, Stderr: "Unable to locate credentials\n"}}
+	_, err = NewTokenCache(failing).Credential(context.Background(), dockerauthx.GCR("gcr.io"))
+	require.ErrorContains(t, err, "Unable to locate credentials")
+
+	broken := &tokenExecutor{err: errors.New("engine unavailable")}
+	_, err = NewTokenCache(broken).Credential(context.Background(), dockerauthx.GCR("gcr.io"))
+	require.ErrorContains(t, err, "engine unavailable")
+
+	multiline := &tokenExecutor{result: &execx.Result{Stdout: "line1\nline2\n"}}
+	_, err = NewTokenCache(multiline).Credential(context.Background(), dockerauthx.GCR("gcr.io"))
+	require.ErrorIs(t, err, errorsx.ErrInvalidValue)
+	assert.NotContains(t, err.Error(), "line1")
+}
+
+func TestDockerConfig(t *testing.T) {
+	data, err := DockerConfig(Credential{Host: "gcr.io", Username: "oauth2accesstoken", Token: "tok"})
+	require.NoError(t, err)
+
+	var config struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	require.NoError(t, json.Unmarshal(data, &config))
+	assert.Equal(t, "b2F1dGgyYWNjZXNzdG9rZW46dG9r", config.Auths["gcr.io"].Auth)
+
+	_, err = DockerConfig(Credential{Token: "tok"})
+	require.ErrorIs(t, err, errorsx.ErrMissingField)
+}
@@ -0,0 +1,123 @@
+package types
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Excoriate/daggerx/internal/shellquote"
+)
+
+// DefaultDebugShell is the shell of debug recipes without one.
+var DefaultDebugShell = []string{"sh"}
+
+// DebugRecipe describes the interactive container reproducing a generated command by hand: the
+// container of the command, with its mounts, environment and working directory prepared, and the
+// command left for the user to run from a shell.
+type DebugRecipe struct {
+	// Image is the container image providing the tool (e.g. "cgr.dev/chainguard/apko").
+	Image string `json:"image"`
+	// Workdir is the working directory of the command. It may be empty.
+	Workdir string `json:"workdir,omitempty"`
+	// Env holds the environment variables the command requires.
+	Env []DaggerEnvVars `json:"env,omitempty"`
+	// Mounts holds the mounts the command requires.
+	Mounts []Mount `json:"mounts,omitempty"`
+	// Command is the generated command, which the recipe doesn't run.
+	Command DaggerCMD `json:"command"`
+	// Shell is the interactive shell. It defaults to DefaultDebugShell.
+	Shell []string `json:"shell,omitempty"`
+}
+
+// ShellCommand returns the interactive shell of the recipe.
+func (r DebugRecipe) ShellCommand() []string {
+	if len(r.Shell) == 0 {
+		return DefaultDebugShell
+	}
+
+	return r.Shell
+}
+
+// Steps returns the steps reproducing the command by hand, as sentences.
+func (r DebugRecipe) Steps() []string {
+	steps := []string{"Start a container from " + r.Image + "."}
+
+	for _, m := range r.Mounts {
+		var step string
+		switch m.Kind {
+		case MountKindCache:
+			step = "Mount the cache volume " + m.Source + " at " + m.Target
+		case MountKindSecret:
+			step = "Mount the secret " + m.Source + " as the file " + m.Target
+		case MountKindInline:
+			step = "Write the file " + m.Target + " (" + lineCount(m.Content) + ")"
+		case MountKindSocket:
+			step = "Forward the socket " + m.Source + " to " + m.Target
+		default:
+			step = "Mount the " + string(m.Kind) + " " + m.Source + " at " + m.Target
+		}
+		if m.ReadOnly {
+			step += ", read-only"
+		}
+		steps = append(steps, step+".")
+	}
+
+	for _, v := range r.Env {
+		steps = append(steps, "Set "+v.Name+"="+shellquote.Quote(v.Value)+".")
+	}
+
+	if r.Workdir != "" {
+		steps = append(steps, "Change to the directory "+r.Workdir+".")
+	}
+
+	return append(steps,
+		"Open a shell: "+shellquote.Join(r.ShellCommand())+".",
+		"Run the command: "+shellquote.Join(r.Command)+".",
+	)
+}
+
+// DockerRunCommand returns the `docker run` command starting the interactive container. Host
+// directories, files and sockets are bind-mounted, and caches are named volumes. Secrets and
+// inline files have no host path and are left out; Steps lists them.
+func (r DebugRecipe) DockerRunCommand() []string {
+	cmd := []string{"docker", "run", "--rm", "-it"}
+
+	for _, m := range r.Mounts {
+		var volume string
+		switch m.Kind {
+		case MountKindDirectory, MountKindFile, MountKindSocket, MountKindCache:
+			volume = m.Source + ":" + m.Target
+		default:
+			continue
+		}
+		if m.ReadOnly {
+			volume += ":ro"
+		}
+		cmd = append(cmd, "-v", volume)
+	}
+
+	for _, v := range r.Env {
+		cmd = append(cmd, "-e", v.Name+"="+v.Value)
+	}
+
+	if r.Workdir != "" {
+		cmd = append(cmd, "-w", r.Workdir)
+	}
+
+	shell := r.ShellCommand()
+	cmd = append(cmd, "--entrypoint", shell[0], r.Image)
+
+	return append(cmd, shell[1:]...)
+}
+
+// lineCount describes the number of lines of 'content'.
+func lineCount(content string) string {
+	n := 0
+	if content != "" {
+		n = strings.Count(strings.TrimSuffix(content, "\n"), "\n") + 1
+	}
+	if n == 1 {
+		return "1 line"
+	}
+
+	return strconv.Itoa(n) + " lines"
+}
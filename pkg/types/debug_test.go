@@ -0,0 +1,56 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRecipe() DebugRecipe {
+	return DebugRecipe{
+		Image:   "cgr.dev/chainguard/apko",
+		Workdir: "/mnt",
+		Env:     []DaggerEnvVars{{Name: "TZ", Value: "UTC"}},
+		Mounts: []Mount{
+			{Kind: MountKindDirectory, Source: "./src", Target: "/mnt", ReadOnly: true},
+			{Kind: MountKindCache, Source: "apko-cache", Target: "/mnt/var/cache/apko"},
+			{Kind: MountKindSecret, Source: "token", Target: "/run/secrets/token"},
+			{Kind: MountKindInline, Target: "/mnt/apko.yaml", Content: "a: 1\nb: 2\n"},
+		},
+		Command: DaggerCMD{"apko", "build", "apko.yaml", "my image:latest", "image.tar"},
+	}
+}
+
+func TestDebugRecipeSteps(t *testing.T) {
+	assert.Equal(t, []string{
+		"Start a container from cgr.dev/chainguard/apko.",
+		"Mount the directory ./src at /mnt, read-only.",
+		"Mount the cache volume apko-cache at /mnt/var/cache/apko.",
+		"Mount the secret token as the file /run/secrets/token.",
+		"Write the file /mnt/apko.yaml (2 lines).",
+		"Set TZ=UTC.",
+		"Change to the directory /mnt.",
+		"Open a shell: sh.",
+		"Run the command: apko build apko.yaml 'my image:latest' image.tar.",
+	}, testRecipe().Steps())
+}
+
+func TestDebugRecipeDockerRunCommand(t *testing.T) {
+	assert.Equal(t, []string{
+		"docker", "run", "--rm", "-it",
+		"-v", "./src:/mnt:ro",
+		"-v", "apko-cache:/mnt/var/cache/apko",
+		"-e", "TZ=UTC",
+		"-w", "/mnt",
+		"--entrypoint", "sh", "cgr.dev/chainguard/apko",
+	}, testRecipe().DockerRunCommand())
+
+	r := DebugRecipe{Image: "alpine", Shell: []string{"bash", "-l"}}
+	assert.Equal(t, []string{"docker", "run", "--rm", "-it", "--entrypoint", "bash", "alpine", "-l"},
+		r.DockerRunCommand())
+}
+
+func TestDebugRecipeShellCommand(t *testing.T) {
+	assert.Equal(t, DefaultDebugShell, DebugRecipe{}.ShellCommand())
+	assert.Equal(t, []string{"bash"}, DebugRecipe{Shell: []string{"bash"}}.ShellCommand())
+}
@@ -3,9 +3,9 @@ package types
 // DaggerEnvVars represents the environment variables for a Dagger task
 type DaggerEnvVars struct {
 	// Name is the name of the environment variable
-	Name string
+	Name string `json:"name"`
 	// Value is the value of the environment variable
-	Value string
+	Value string `json:"value"`
 	// Expand is a boolean that determines whether to expand the value of the environment variable
-	Expand bool
+	Expand bool `json:"expand,omitempty"`
 }
@@ -0,0 +1,36 @@
+package types
+
+// MountKind describes how a mount is provided to a container.
+type MountKind string
+
+const (
+	// MountKindDirectory mounts a host directory into the container.
+	MountKindDirectory MountKind = "directory"
+	// MountKindFile mounts a single host file into the container.
+	MountKindFile MountKind = "file"
+	// MountKindCache mounts a cache volume identified by its key into the container.
+	MountKindCache MountKind = "cache"
+	// MountKindSecret mounts a secret identified by its name as a file into the container.
+	MountKindSecret MountKind = "secret"
+	// MountKindInline writes the inline content of the mount as a new file in the container.
+	MountKindInline MountKind = "inline"
+	// MountKindSocket mounts a host unix socket, such as an SSH agent socket, into the container.
+	MountKindSocket MountKind = "socket"
+)
+
+// Mount represents a mount requirement of a generated command.
+// Builders describe their mounts with this type so executors (local, Dagger, etc.)
+// can provide them consistently.
+type Mount struct {
+	// Kind is the type of the mount.
+	Kind MountKind `json:"kind"`
+	// Source is the host path for directories, files and sockets, or the cache volume key for
+	// caches, or the secret name for secrets. It is unused for inline mounts.
+	Source string `json:"source"`
+	// Target is the path inside the container where the mount is placed.
+	Target string `json:"target"`
+	// ReadOnly indicates that the command is not expected to write to the mount.
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// Content is the content of the file written by inline mounts.
+	Content string `json:"content,omitempty"`
+}
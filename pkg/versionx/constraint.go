@@ -0,0 +1,308 @@
+package versionx
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operator is a comparison operator of a constraint.
+type Operator string
+
+const (
+	// OpEqual matches the same version.
+	OpEqual Operator = "="
+	// OpNotEqual matches any other version.
+	OpNotEqual Operator = "!="
+	// OpGreater matches higher versions.
+	OpGreater Operator = ">"
+	// OpGreaterEqual matches the same or higher versions.
+	OpGreaterEqual Operator = ">="
+	// OpLess matches lower versions.
+	OpLess Operator = "<"
+	// OpLessEqual matches the same or lower versions.
+	OpLessEqual Operator = "<="
+	// OpTilde matches patch updates (~1.2.3 is >=1.2.3, <1.3.0).
+	OpTilde Operator = "~"
+	// OpCaret matches updates that do not change the left-most non-zero part (^1.2.3 is >=1.2.3, <2.0.0).
+	OpCaret Operator = "^"
+)
+
+// operators lists the operators, longest first so prefixes are matched greedily.
+var operators = []Operator{OpNotEqual, OpGreaterEqual, OpLessEqual, OpEqual, OpGreater, OpLess, OpTilde, OpCaret}
+
+// term is a single comparison of a constraint.
+type term struct {
+	op      Operator
+	version Version
+	// parts is the number of version parts written in the constraint (1 for "1", 3 for "1.2.3").
+	parts int
+}
+
+// Constraint is a set of version constraints. Terms separated by commas or spaces must all match;
+// groups separated by "||" are alternatives.
+type Constraint struct {
+	raw    string
+	groups [][]term
+}
+
+// ParseConstraint parses a constraint such as ">= 1.2, < 2.0 || ^3.1". Versions in a constraint may
+// omit their minor and patch parts. A version without operator is an exact match.
+//
+// Returns:
+//   - The parsed constraint.
+//   - An error if the constraint is empty or contains invalid terms.
+func ParseConstraint(s string) (*Constraint, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, fmt.Errorf("constraint cannot be empty")
+	}
+
+	c := &Constraint{raw: s}
+	for _, group := range strings.Split(s, "||") {
+		terms, err := parseGroup(group)
+		if err != nil {
+			return nil, fmt.Errorf("invalid constraint %q: %w", s, err)
+		}
+		c.groups = append(c.groups, terms)
+	}
+
+	return c, nil
+}
+
+// String returns the constraint as written.
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+// Check reports whether the version satisfies the constraint. Prerelease versions only satisfy a
+// term whose version is a prerelease of the same major, minor and patch, so ">=1.0.0" does not
+// select "2.0.0-rc.1".
+func (c *Constraint) Check(v Version) bool {
+	for _, group := range c.groups {
+		if groupMatches(group, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckString parses 'version' and reports whether it satisfies the constraint.
+func (c *Constraint) CheckString(version string) (bool, error) {
+	v, err := Parse(version)
+	if err != nil {
+		return false, err
+	}
+
+	return c.Check(v), nil
+}
+
+// Latest returns the highest of 'versions' satisfying the constraint. Invalid versions are ignored.
+//
+// Returns:
+//   - The highest matching version, as written in 'versions'.
+//   - An error if no version satisfies the constraint.
+func (c *Constraint) Latest(versions []string) (string, error) {
+	var matching []Version
+	raw := map[string]string{}
+
+	for _, s := range versions {
+		v, err := Parse(s)
+		if err != nil || !c.Check(v) {
+			continue
+		}
+		matching = append(matching, v)
+		raw[v.String()] = s
+	}
+
+	if len(matching) == 0 {
+		return "", fmt.Errorf("no version satisfies constraint %q", c.raw)
+	}
+
+	sort.SliceStable(matching, func(i, j int) bool {
+		return matching[i].LessThan(matching[j])
+	})
+
+	return raw[matching[len(matching)-1].String()], nil
+}
+
+// Sort sorts versions in ascending precedence order.
+func Sort(versions []Version) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].LessThan(versions[j])
+	})
+}
+
+// parseGroup parses the terms of a group, separated by commas or spaces. An operator separated
+// from its version by spaces (">= 1.2") is supported.
+func parseGroup(group string) ([]term, error) {
+	fields := strings.Fields(strings.ReplaceAll(group, ",", " "))
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty constraint group")
+	}
+
+	var terms []term
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if isOperator(field) && i+1 < len(fields) {
+			i++
+			field += fields[i]
+		}
+
+		t, err := parseTerm(field)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+	}
+
+	return terms, nil
+}
+
+// parseTerm parses a single term such as ">=1.2" or "~1.2.3".
+func parseTerm(s string) (term, error) {
+	op := OpEqual
+	for _, candidate := range operators {
+		if strings.HasPrefix(s, string(candidate)) {
+			op = candidate
+			s = s[len(candidate):]
+			break
+		}
+	}
+
+	version, parts, err := parsePartial(s)
+	if err != nil {
+		return term{}, err
+	}
+
+	return term{op: op, version: version, parts: parts}, nil
+}
+
+// parsePartial parses a version that may omit its minor and patch parts ("1", "1.2", "1.2-rc.1",
+// "v1.2.3-rc.1").
+func parsePartial(s string) (Version, int, error) {
+	// The prerelease and build suffix follows the patch part, so partial versions are padded
+	// before it ("1.2-rc.1" is "1.2.0-rc.1").
+	head, suffix := s, ""
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		head, suffix = s[:i], s[i:]
+	}
+
+	parts := strings.Split(strings.TrimPrefix(head, "v"), ".")
+	switch len(parts) {
+	case 1, 2:
+		for _, p := range parts {
+			if _, err := strconv.ParseUint(p, 10, 64); err != nil {
+				return Version{}, 0, fmt.Errorf("invalid version %q", s)
+			}
+		}
+		padded := head + strings.Repeat(".0", 3-len(parts)) + suffix
+		v, err := Parse(padded)
+		return v, len(parts), err
+	case 3:
+		v, err := Parse(s)
+		return v, 3, err
+	default:
+		return Version{}, 0, fmt.Errorf("invalid version %q", s)
+	}
+}
+
+// isOperator reports whether 's' is a bare operator.
+func isOperator(s string) bool {
+	for _, op := range operators {
+		if s == string(op) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// groupMatches reports whether the version satisfies every term of the group.
+func groupMatches(group []term, v Version) bool {
+	if v.IsPrerelease() && !prereleaseAllowed(group, v) {
+		return false
+	}
+
+	for _, t := range group {
+		if !t.matches(v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// prereleaseAllowed reports whether a term of the group is a prerelease of the same version core.
+func prereleaseAllowed(group []term, v Version) bool {
+	for _, t := range group {
+		if t.version.IsPrerelease() && t.version.Major == v.Major &&
+			t.version.Minor == v.Minor && t.version.Patch == v.Patch {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matches reports whether the version satisfies the term.
+func (t term) matches(v Version) bool {
+	c := v.Compare(t.version)
+
+	switch t.op {
+	case OpEqual:
+		if t.parts < 3 {
+			return c >= 0 && v.LessThan(t.upperBound())
+		}
+		return c == 0
+	case OpNotEqual:
+		return c != 0
+	case OpGreater:
+		if t.parts < 3 {
+			return !v.LessThan(t.upperBound())
+		}
+		return c > 0
+	case OpGreaterEqual:
+		return c >= 0
+	case OpLess:
+		return c < 0
+	case OpLessEqual:
+		if t.parts < 3 {
+			return v.LessThan(t.upperBound())
+		}
+		return c <= 0
+	case OpTilde, OpCaret:
+		return c >= 0 && v.LessThan(t.upperBound())
+	default:
+		return false
+	}
+}
+
+// upperBound returns the exclusive upper bound of the range selected by the term.
+func (t term) upperBound() Version {
+	v := t.version
+
+	switch t.op {
+	case OpCaret:
+		switch {
+		case v.Major > 0 || t.parts == 1:
+			return Version{Major: v.Major + 1}
+		case v.Minor > 0 || t.parts == 2:
+			return Version{Minor: v.Minor + 1}
+		default:
+			return Version{Patch: v.Patch + 1}
+		}
+	case OpTilde:
+		if t.parts == 1 {
+			return Version{Major: v.Major + 1}
+		}
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	default:
+		// Partial versions select every version sharing the written parts.
+		if t.parts == 1 {
+			return Version{Major: v.Major + 1}
+		}
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	}
+}
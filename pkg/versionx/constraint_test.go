@@ -0,0 +1,112 @@
+package versionx
+
+import (
+	"testing"
+)
+
+func TestConstraintCheck(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"1.2.3", "1.2.3", true},
+		{"=1.2.3", "1.2.4", false},
+		{"1.2", "1.2.9", true},
+		{"1.2", "1.3.0", false},
+		{"!=1.2.3", "1.2.4", true},
+		{">1.2.3", "1.2.4", true},
+		{">1.2", "1.2.9", false},
+		{">1.2", "1.3.0", true},
+		{">= 1.2, < 2.0", "1.9.9", true},
+		{">= 1.2, < 2.0", "2.0.0", false},
+		{">=1.2 <2.0", "1.1.0", false},
+		{"<=1.2", "1.2.7", true},
+		{"<=1.2.3", "1.2.4", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"~1", "1.9.0", true},
+		{"^1.2.3", "1.9.0", true},
+		{"^1.2.3", "2.0.0", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.4", false},
+		{"^1.2 || ^3.0", "3.4.0", true},
+		{"^1.2 || ^3.0", "2.1.0", false},
+		{">=1.0.0", "2.0.0-rc.1", false},
+		{">=2.0.0-rc.1", "2.0.0-rc.2", true},
+		{">=2.0.0-rc.1", "2.0.0", true},
+		{">=1.2-rc.1", "1.2.0-rc.2", true},
+		{">=1.2-rc.1", "1.2.0-beta.1", false},
+		{"^v1-rc.1", "1.3.0", true},
+		{"v1.x", "1.0.0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint+"/"+tt.version, func(t *testing.T) {
+			c, err := ParseConstraint(tt.constraint)
+			if err != nil {
+				if tt.want {
+					t.Fatalf("ParseConstraint(%q) returned unexpected error: %v", tt.constraint, err)
+				}
+				return
+			}
+
+			if got := c.Check(MustParse(tt.version)); got != tt.want {
+				t.Errorf("%q.Check(%s) = %v, want %v", tt.constraint, tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConstraintErrors(t *testing.T) {
+	for _, s := range []string{"", "   ", ">=", "1.2.3.4", "^abc", "1.0 ||"} {
+		if _, err := ParseConstraint(s); err == nil {
+			t.Errorf("Expected error for constraint %q, got none", s)
+		}
+	}
+}
+
+func TestConstraintLatest(t *testing.T) {
+	c, err := ParseConstraint("^1.2")
+	if err != nil {
+		t.Fatalf("ParseConstraint returned unexpected error: %v", err)
+	}
+
+	got, err := c.Latest([]string{"v1.1.0", "v1.4.0", "v1.10.0", "v2.0.0", "v1.11.0-rc.1", "not-a-version"})
+	if err != nil {
+		t.Fatalf("Latest returned unexpected error: %v", err)
+	}
+
+	if got != "v1.10.0" {
+		t.Errorf("Latest() = %s, want v1.10.0", got)
+	}
+
+	if _, err := c.Latest([]string{"0.1.0"}); err == nil {
+		t.Error("Expected error when no version matches, got none")
+	}
+}
+
+func TestCheckString(t *testing.T) {
+	c, _ := ParseConstraint(">=1.0.0")
+
+	if ok, err := c.CheckString("v1.2.0"); err != nil || !ok {
+		t.Errorf("CheckString() = %v, %v", ok, err)
+	}
+
+	if _, err := c.CheckString("bogus"); err == nil {
+		t.Error("Expected error for invalid version, got none")
+	}
+}
+
+func TestSort(t *testing.T) {
+	versions := []Version{MustParse("1.10.0"), MustParse("1.2.0"), MustParse("1.2.0-rc.1")}
+	Sort(versions)
+
+	want := []string{"1.2.0-rc.1", "1.2.0", "1.10.0"}
+	for i, v := range versions {
+		if v.String() != want[i] {
+			t.Errorf("Sort()[%d] = %s, want %s", i, v, want[i])
+		}
+	}
+}
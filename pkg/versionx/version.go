@@ -0,0 +1,240 @@
+// Package versionx provides semantic version handling shared by the daggerx packages: parsing,
+// comparison following the semver 2.0.0 precedence rules, constraint matching, and bumping.
+//
+// Example usage:
+//
+//	v, err := versionx.Parse("v1.4.2-rc.1")
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	next := v.BumpMinor() // v1.5.0
+//
+//	c, err := versionx.ParseConstraint(">= 1.2, < 2.0")
+//	if err != nil {
+//	    // handle error
+//	}
+//	ok := c.Check(next) // true
+package versionx
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// versionRegex matches semantic versions with an optional "v" prefix.
+var versionRegex = regexp.MustCompile(
+	`^(v)?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+		`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+		`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+// Version is a semantic version.
+type Version struct {
+	// Major is the major version.
+	Major uint64
+	// Minor is the minor version.
+	Minor uint64
+	// Patch is the patch version.
+	Patch uint64
+	// Prerelease holds the dot-separated prerelease identifiers (e.g. "rc.1").
+	Prerelease string
+	// Metadata holds the build metadata, ignored in comparisons.
+	Metadata string
+	// prefixed records whether the version was written with a "v" prefix.
+	prefixed bool
+}
+
+// Parse parses a semantic version, with an optional "v" prefix.
+func Parse(s string) (Version, error) {
+	m := versionRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return Version{}, fmt.Errorf("invalid semantic version: %q", s)
+	}
+
+	v := Version{
+		Prerelease: m[5],
+		Metadata:   m[6],
+		prefixed:   m[1] == "v",
+	}
+
+	var err error
+	if v.Major, err = strconv.ParseUint(m[2], 10, 64); err != nil {
+		return Version{}, fmt.Errorf("invalid major version in %q: %w", s, err)
+	}
+	if v.Minor, err = strconv.ParseUint(m[3], 10, 64); err != nil {
+		return Version{}, fmt.Errorf("invalid minor version in %q: %w", s, err)
+	}
+	if v.Patch, err = strconv.ParseUint(m[4], 10, 64); err != nil {
+		return Version{}, fmt.Errorf("invalid patch version in %q: %w", s, err)
+	}
+
+	return v, nil
+}
+
+// MustParse is like Parse but panics if the version is invalid.
+func MustParse(s string) Version {
+	v, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+// IsValid reports whether 's' is a valid semantic version.
+func IsValid(s string) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+// String returns the version, with its "v" prefix if it was parsed with one.
+func (v Version) String() string {
+	var b strings.Builder
+	if v.prefixed {
+		b.WriteString("v")
+	}
+
+	fmt.Fprintf(&b, "%d.%d.%d", v.Major, v.Minor, v.Patch)
+
+	if v.Prerelease != "" {
+		b.WriteString("-" + v.Prerelease)
+	}
+	if v.Metadata != "" {
+		b.WriteString("+" + v.Metadata)
+	}
+
+	return b.String()
+}
+
+// WithPrefix returns a copy of the version rendered with ('true') or without a "v" prefix.
+func (v Version) WithPrefix(prefixed bool) Version {
+	v.prefixed = prefixed
+	return v
+}
+
+// IsPrerelease reports whether the version has prerelease identifiers.
+func (v Version) IsPrerelease() bool {
+	return v.Prerelease != ""
+}
+
+// Compare returns -1, 0 or 1 if 'v' has a lower, equal or higher precedence than 'o'.
+// Build metadata is ignored.
+func (v Version) Compare(o Version) int {
+	if c := compareUint(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+
+	return comparePrerelease(v.Prerelease, o.Prerelease)
+}
+
+// LessThan reports whether 'v' has a lower precedence than 'o'.
+func (v Version) LessThan(o Version) bool {
+	return v.Compare(o) < 0
+}
+
+// Equal reports whether 'v' and 'o' have the same precedence.
+func (v Version) Equal(o Version) bool {
+	return v.Compare(o) == 0
+}
+
+// BumpMajor returns the next major version, dropping prerelease and metadata.
+func (v Version) BumpMajor() Version {
+	return Version{Major: v.Major + 1, prefixed: v.prefixed}
+}
+
+// BumpMinor returns the next minor version, dropping prerelease and metadata.
+func (v Version) BumpMinor() Version {
+	return Version{Major: v.Major, Minor: v.Minor + 1, prefixed: v.prefixed}
+}
+
+// BumpPatch returns the next patch version, dropping prerelease and metadata. A prerelease is
+// released as its own version (1.2.3-rc.1 becomes 1.2.3).
+func (v Version) BumpPatch() Version {
+	next := Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch, prefixed: v.prefixed}
+	if !v.IsPrerelease() {
+		next.Patch++
+	}
+
+	return next
+}
+
+// BumpPrerelease returns the next prerelease of the version for the identifier 'id' (e.g. "rc").
+// 1.2.3 becomes 1.2.4-rc.1, 1.2.4-rc.1 becomes 1.2.4-rc.2, and 1.2.4-beta.3 becomes 1.2.4-rc.1.
+func (v Version) BumpPrerelease(id string) (Version, error) {
+	if id == "" {
+		return Version{}, fmt.Errorf("prerelease identifier is required")
+	}
+
+	next := Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch, Prerelease: id + ".1", prefixed: v.prefixed}
+	if !v.IsPrerelease() {
+		next.Patch++
+	} else if name, num, ok := strings.Cut(v.Prerelease, "."); ok && name == id {
+		if n, err := strconv.ParseUint(num, 10, 64); err == nil {
+			next.Prerelease = fmt.Sprintf("%s.%d", id, n+1)
+		}
+	}
+
+	if _, err := Parse(next.String()); err != nil {
+		return Version{}, fmt.Errorf("invalid prerelease identifier %q", id)
+	}
+
+	return next, nil
+}
+
+// compareUint compares two unsigned integers.
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// comparePrerelease compares prerelease strings following the semver precedence rules: a version
+// without prerelease has a higher precedence, numeric identifiers compare numerically and have a
+// lower precedence than alphanumeric ones, and a shorter set of identifiers loses ties.
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.ParseUint(as[i], 10, 64)
+		bn, bErr := strconv.ParseUint(bs[i], 10, 64)
+
+		var c int
+		switch {
+		case aErr == nil && bErr == nil:
+			c = compareUint(an, bn)
+		case aErr == nil:
+			c = -1
+		case bErr == nil:
+			c = 1
+		default:
+			c = strings.Compare(as[i], bs[i])
+		}
+
+		if c != 0 {
+			return c
+		}
+	}
+
+	return compareUint(uint64(len(as)), uint64(len(bs)))
+}
@@ -0,0 +1,123 @@
+package versionx
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Version
+		wantErr bool
+	}{
+		{input: "1.2.3", want: Version{Major: 1, Minor: 2, Patch: 3}},
+		{input: "v0.18.5", want: Version{Minor: 18, Patch: 5, prefixed: true}},
+		{input: "1.0.0-rc.1+build.5", want: Version{Major: 1, Prerelease: "rc.1", Metadata: "build.5"}},
+		{input: "1.2", wantErr: true},
+		{input: "01.2.3", wantErr: true},
+		{input: "1.2.3-", wantErr: true},
+		{input: "1.2.3-01", wantErr: true},
+		{input: "latest", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+
+			if !tt.wantErr && got.String() != tt.input {
+				t.Errorf("String() = %q, want %q", got.String(), tt.input)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// Ordered by ascending precedence, from the semver 2.0.0 specification.
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+	}
+
+	for i := 0; i < len(ordered)-1; i++ {
+		a, b := MustParse(ordered[i]), MustParse(ordered[i+1])
+		if a.Compare(b) != -1 || b.Compare(a) != 1 {
+			t.Errorf("Expected %s < %s", a, b)
+		}
+	}
+
+	if !MustParse("v1.0.0+build.1").Equal(MustParse("1.0.0+build.2")) {
+		t.Error("Expected metadata and prefix to be ignored in comparisons")
+	}
+}
+
+func TestBump(t *testing.T) {
+	v := MustParse("v1.2.3-rc.1+meta")
+
+	if got := v.BumpMajor().String(); got != "v2.0.0" {
+		t.Errorf("BumpMajor() = %s", got)
+	}
+	if got := v.BumpMinor().String(); got != "v1.3.0" {
+		t.Errorf("BumpMinor() = %s", got)
+	}
+	if got := v.BumpPatch().String(); got != "v1.2.3" {
+		t.Errorf("BumpPatch() of a prerelease = %s", got)
+	}
+	if got := MustParse("1.2.3").BumpPatch().String(); got != "1.2.4" {
+		t.Errorf("BumpPatch() = %s", got)
+	}
+}
+
+func TestBumpPrerelease(t *testing.T) {
+	tests := []struct {
+		input string
+		id    string
+		want  string
+	}{
+		{input: "1.2.3", id: "rc", want: "1.2.4-rc.1"},
+		{input: "1.2.4-rc.1", id: "rc", want: "1.2.4-rc.2"},
+		{input: "1.2.4-beta.3", id: "rc", want: "1.2.4-rc.1"},
+		{input: "1.2.4-rc", id: "rc", want: "1.2.4-rc.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := MustParse(tt.input).BumpPrerelease(tt.id)
+			if err != nil {
+				t.Fatalf("BumpPrerelease returned unexpected error: %v", err)
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("BumpPrerelease(%q) = %s, want %s", tt.id, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := MustParse("1.0.0").BumpPrerelease(""); err == nil {
+		t.Error("Expected error for empty identifier, got none")
+	}
+	if _, err := MustParse("1.0.0").BumpPrerelease("r c"); err == nil {
+		t.Error("Expected error for invalid identifier, got none")
+	}
+}
+
+func TestIsValid(t *testing.T) {
+	if !IsValid("v1.0.0") || IsValid("v1") {
+		t.Error("IsValid returned unexpected results")
+	}
+}
@@ -0,0 +1,208 @@
+// Package webhookx provides builders of the generic webhook notifications of a pipeline run:
+// the JSON payload summarizing the run report (see reportx), with its status, steps, finding
+// counts and policy violations, and the curl command posting it to an HTTP endpoint, with the
+// URL or a bearer token exposed as secrets.
+//
+// Example usage:
+//
+//	payload, err := webhookx.NewPayloadBuilder(report).
+//	    WithRunURL("https://ci.example.com/runs/42").
+//	    WithMetadata("repository", "acme/checkout").
+//	    JSON()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	send := webhookx.NewSendBuilder(payload).
+//	    WithURL("https://hooks.example.com/pipelines").
+//	    WithBearerToken(secretsx.FromEnv("hooks-token", "HOOKS_TOKEN"))
+//
+//	cmd, err := send.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	res, err := executor.Run(ctx, cmd, nil, send.Mounts())
+package webhookx
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/artifactx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+)
+
+// builderName identifies the webhook builders in errorsx errors.
+const builderName = "webhook"
+
+// EventPipelineCompleted is the default event of the payloads.
+const EventPipelineCompleted = "pipeline.completed"
+
+// StepSummary summarizes a step of the run.
+type StepSummary struct {
+	// Name is the name of the step.
+	Name string `json:"name"`
+	// Status is the outcome of the step.
+	Status reportx.StepStatus `json:"status"`
+	// DurationMs is the duration of the step, in milliseconds.
+	DurationMs int64 `json:"durationMs"`
+	// Error is the error message of a failed step.
+	Error string `json:"error,omitempty"`
+}
+
+// Payload is the JSON payload of a webhook notification.
+type Payload struct {
+	// Event is the event of the notification (e.g. "pipeline.completed").
+	Event string `json:"event"`
+	// Title is the title of the run report.
+	Title string `json:"title"`
+	// Status is "succeeded" or "failed".
+	Status string `json:"status"`
+	// Succeeded reports whether the run succeeded.
+	Succeeded bool `json:"succeeded"`
+	// GeneratedAt is the generation time of the run report.
+	GeneratedAt time.Time `json:"generatedAt"`
+	// DurationMs is the total duration of the steps, in milliseconds.
+	DurationMs int64 `json:"durationMs"`
+	// RunURL is the URL of the pipeline run, if any.
+	RunURL string `json:"runUrl,omitempty"`
+	// Steps are the steps of the run, in execution order.
+	Steps []StepSummary `json:"steps"`
+	// Findings are the numbers of scan findings per severity, across every scan.
+	Findings map[string]int `json:"findings"`
+	// Violations are the policy violations, if any.
+	Violations []policyx.Violation `json:"violations,omitempty"`
+	// Artifacts are the produced artifacts.
+	Artifacts []artifactx.Artifact `json:"artifacts,omitempty"`
+	// Metadata are custom fields of the notification (e.g. the repository or commit).
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// PayloadBuilder builds the webhook payload summarizing a run report.
+type PayloadBuilder struct {
+	// report is the summarized run report.
+	report *reportx.Report
+
+	// event is the event of the notification.
+	event string
+
+	// runURL is the URL of the pipeline run.
+	runURL string
+
+	// metadata are custom fields of the notification.
+	metadata map[string]string
+}
+
+// NewPayloadBuilder creates a PayloadBuilder summarizing the run report 'report' as an
+// EventPipelineCompleted event.
+func NewPayloadBuilder(report *reportx.Report) *PayloadBuilder {
+	return &PayloadBuilder{report: report, event: EventPipelineCompleted, metadata: map[string]string{}}
+}
+
+// WithEvent sets the event of the notification.
+func (b *PayloadBuilder) WithEvent(event string) *PayloadBuilder {
+	b.event = event
+	return b
+}
+
+// WithRunURL sets the URL of the pipeline run.
+func (b *PayloadBuilder) WithRunURL(runURL string) *PayloadBuilder {
+	b.runURL = runURL
+	return b
+}
+
+// WithMetadata sets the custom field 'key' of the notification.
+func (b *PayloadBuilder) WithMetadata(key, value string) *PayloadBuilder {
+	b.metadata[key] = value
+	return b
+}
+
+// Build builds the payload.
+//
+// Returns:
+//   - The payload.
+//   - An error if the report or event is missing, or a custom field has an empty key.
+func (b *PayloadBuilder) Build() (Payload, error) {
+	if b.report == nil {
+		return Payload{}, errorsx.MissingField(builderName, "report", "run report is required")
+	}
+
+	if b.event == "" {
+		return Payload{}, errorsx.MissingField(builderName, "event", "event is required")
+	}
+
+	if _, ok := b.metadata[""]; ok {
+		return Payload{}, errorsx.InvalidValue(builderName, "metadata", "", "custom fields require a key")
+	}
+
+	r := b.report
+	p := Payload{
+		Event:       b.event,
+		Title:       r.Title,
+		Status:      "succeeded",
+		Succeeded:   r.Succeeded(),
+		GeneratedAt: r.GeneratedAt,
+		DurationMs:  r.TotalDuration().Milliseconds(),
+		RunURL:      b.runURL,
+		Steps:       make([]StepSummary, len(r.Steps)),
+		Findings:    map[string]int{},
+		Artifacts:   r.Artifacts,
+	}
+
+	if !p.Succeeded {
+		p.Status = "failed"
+	}
+
+	for i, s := range r.Steps {
+		status := s.Status
+		if status == "" {
+			status = reportx.StepSucceeded
+		}
+
+		p.Steps[i] = StepSummary{Name: s.Name, Status: status, DurationMs: s.Duration.Milliseconds(), Error: s.Error}
+	}
+
+	for _, s := range r.Scans {
+		for sev, n := range s.Counts {
+			p.Findings[sev] += n
+		}
+	}
+
+	if r.Policy != nil {
+		p.Violations = r.Policy.Violations
+	}
+
+	if len(b.metadata) > 0 {
+		p.Metadata = make(map[string]string, len(b.metadata))
+		for k, v := range b.metadata {
+			p.Metadata[k] = v
+		}
+	}
+
+	return p, nil
+}
+
+// JSON builds the payload and returns its JSON encoding, with the secrets registered with the
+// default logx redactor scrubbed.
+//
+// Returns:
+//   - The JSON payload.
+//   - An error if the payload can't be built, see Build.
+func (b *PayloadBuilder) JSON() ([]byte, error) {
+	p, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	return logx.Default().Bytes(data), nil
+}
@@ -0,0 +1,101 @@
+package webhookx
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReport() *reportx.Report {
+	r := reportx.NewReport("checkout build")
+	r.GeneratedAt = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	r.AddStep(reportx.Step{Name: "build", Duration: time.Minute})
+	r.AddStep(reportx.Step{Name: "scan", Status: reportx.StepFailed, Duration: 30 * time.Second, Error: "exit 1"})
+	r.AddScan("grype", "app:v1", []policyx.Finding{
+		{ID: "CVE-1", Severity: policyx.SeverityCritical},
+		{ID: "CVE-2", Severity: policyx.SeverityHigh},
+	})
+	r.AddScan("trivy", "app:v1", []policyx.Finding{{ID: "CVE-3", Severity: policyx.SeverityHigh}})
+	r.WithPolicyResult(policyx.Result{Violations: []policyx.Violation{
+		{Rule: policyx.RuleMaxSeverity, Message: "CVE-1 in openssl 3.1 has severity critical"},
+	}})
+
+	return r
+}
+
+func TestPayloadBuilderBuild(t *testing.T) {
+	p, err := NewPayloadBuilder(testReport()).
+		WithRunURL("https://ci.example.com/runs/42").
+		WithMetadata("repository", "acme/checkout").
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, EventPipelineCompleted, p.Event)
+	assert.Equal(t, "checkout build", p.Title)
+	assert.Equal(t, "failed", p.Status)
+	assert.False(t, p.Succeeded)
+	assert.Equal(t, int64(90000), p.DurationMs)
+	assert.Equal(t, "https://ci.example.com/runs/42", p.RunURL)
+	assert.Equal(t, []StepSummary{
+		{Name: "build", Status: reportx.StepSucceeded, DurationMs: 60000},
+		{Name: "scan", Status: reportx.StepFailed, DurationMs: 30000, Error: "exit 1"},
+	}, p.Steps)
+	assert.Equal(t, map[string]int{"critical": 1, "high": 2}, p.Findings)
+	assert.Len(t, p.Violations, 1)
+	assert.Equal(t, map[string]string{"repository": "acme/checkout"}, p.Metadata)
+}
+
+func TestPayloadBuilderBuildSucceeded(t *testing.T) {
+	r := reportx.NewReport("docs")
+	r.AddStep(reportx.Step{Name: "build", Duration: time.Second})
+
+	p, err := NewPayloadBuilder(r).WithEvent("docs.published").Build()
+	require.NoError(t, err)
+	assert.Equal(t, "docs.published", p.Event)
+	assert.Equal(t, "succeeded", p.Status)
+	assert.True(t, p.Succeeded)
+	assert.Empty(t, p.Findings)
+	assert.Nil(t, p.Violations)
+	assert.Nil(t, p.Metadata)
+}
+
+func TestPayloadBuilderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *PayloadBuilder
+		wantErr error
+	}{
+		{name: "missing report", builder: NewPayloadBuilder(nil), wantErr: errorsx.ErrMissingField},
+		{name: "missing event", builder: NewPayloadBuilder(testReport()).WithEvent(""), wantErr: errorsx.ErrMissingField},
+		{
+			name:    "empty metadata key",
+			builder: NewPayloadBuilder(testReport()).WithMetadata("", "x"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Build()
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestPayloadBuilderJSON(t *testing.T) {
+	data, err := NewPayloadBuilder(testReport()).JSON()
+	require.NoError(t, err)
+
+	var p map[string]any
+	require.NoError(t, json.Unmarshal(data, &p))
+	assert.Equal(t, "pipeline.completed", p["event"])
+	assert.Equal(t, "failed", p["status"])
+	assert.Len(t, p["steps"], 2)
+	assert.NotContains(t, p, "runUrl")
+}
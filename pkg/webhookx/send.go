@@ -0,0 +1,288 @@
+package webhookx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/cmdx"
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/fixtures"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+const (
+	// URLEnvVar is the environment variable the send command reads a secret URL from.
+	URLEnvVar = "WEBHOOK_URL"
+	// TokenEnvVar is the environment variable the send command reads the bearer token from.
+	TokenEnvVar = "WEBHOOK_TOKEN"
+)
+
+// GetPayloadPath returns the path of the JSON payload 'payload' inside the container. The path
+// depends on the payload, so different notifications don't overwrite each other. If mntPrefix
+// is empty, fixtures.MntPrefix is used.
+func GetPayloadPath(mntPrefix string, payload []byte) string {
+	if mntPrefix == "" {
+		mntPrefix = fixtures.MntPrefix
+	}
+
+	sum := sha256.Sum256(payload)
+
+	return path.Join(mntPrefix, ".daggerx", "webhook-"+hex.EncodeToString(sum[:6])+".json")
+}
+
+// SendBuilder generates the curl command sending a JSON payload to a webhook.
+type SendBuilder struct {
+	// payload is the JSON payload.
+	payload []byte
+
+	// url is the URL of the webhook.
+	url string
+
+	// urlSecret is the secret holding the URL of the webhook, when it embeds a credential.
+	urlSecret *secretsx.SecretRef
+
+	// token is the secret holding the bearer token.
+	token *secretsx.SecretRef
+
+	// method is the HTTP method.
+	method string
+
+	// headers are the additional HTTP headers.
+	headers map[string]string
+
+	// retries is the number of retries of transient failures.
+	retries int
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewSendBuilder creates a SendBuilder posting the JSON payload 'payload' (see
+// PayloadBuilder.JSON), retrying transient failures three times.
+func NewSendBuilder(payload []byte) *SendBuilder {
+	return &SendBuilder{payload: payload, method: http.MethodPost, headers: map[string]string{}, retries: 3}
+}
+
+// WithURL sets the URL of the webhook.
+func (b *SendBuilder) WithURL(rawURL string) *SendBuilder {
+	b.url = rawURL
+	return b
+}
+
+// WithURLSecret sets the secret holding the URL of the webhook, for URLs embedding a credential.
+// It is exposed as URLEnvVar; 'ref' is left unchanged.
+func (b *SendBuilder) WithURLSecret(ref *secretsx.SecretRef) *SendBuilder {
+	if ref == nil {
+		b.urlSecret = nil
+		return b
+	}
+
+	secret := *ref
+	b.urlSecret = secret.AsEnv(URLEnvVar)
+	return b
+}
+
+// WithBearerToken sets the secret holding the bearer token of the webhook. It is exposed as
+// TokenEnvVar; 'ref' is left unchanged.
+func (b *SendBuilder) WithBearerToken(ref *secretsx.SecretRef) *SendBuilder {
+	if ref == nil {
+		b.token = nil
+		return b
+	}
+
+	token := *ref
+	b.token = token.AsEnv(TokenEnvVar)
+	return b
+}
+
+// WithMethod sets the HTTP method. It defaults to POST.
+func (b *SendBuilder) WithMethod(method string) *SendBuilder {
+	b.method = method
+	return b
+}
+
+// WithHeader sets the HTTP header 'name'.
+func (b *SendBuilder) WithHeader(name, value string) *SendBuilder {
+	b.headers[textproto.CanonicalMIMEHeaderKey(name)] = value
+	return b
+}
+
+// WithRetries sets the number of retries of transient failures.
+func (b *SendBuilder) WithRetries(retries int) *SendBuilder {
+	b.retries = retries
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *SendBuilder) WithLogger(logger *slog.Logger) *SendBuilder {
+	b.logger = logger
+	return b
+}
+
+// PayloadPath returns the path of the payload inside the container.
+func (b *SendBuilder) PayloadPath() string {
+	return GetPayloadPath("", b.payload)
+}
+
+// validate checks the options of the send command.
+func (b *SendBuilder) validate() error {
+	if len(b.payload) == 0 {
+		return errorsx.MissingField(builderName, "payload", "payload is required")
+	}
+
+	if !json.Valid(b.payload) {
+		return errorsx.InvalidValue(builderName, "payload", "", "payload must be JSON")
+	}
+
+	switch {
+	case b.url != "" && b.urlSecret != nil:
+		return errorsx.ConflictingOptions(builderName, []string{"url", "urlSecret"},
+			"the URL is either plain or a secret")
+	case b.url == "" && b.urlSecret == nil:
+		return errorsx.MissingField(builderName, "url", "webhook URL is required")
+	case b.urlSecret != nil:
+		if err := b.urlSecret.Validate(); err != nil {
+			return err
+		}
+	default:
+		if u, err := url.Parse(b.url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errorsx.InvalidValue(builderName, "url", b.url, "invalid webhook URL: %q", b.url)
+		}
+	}
+
+	if b.token != nil {
+		if err := b.token.Validate(); err != nil {
+			return err
+		}
+	}
+
+	switch b.method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return errorsx.InvalidValue(builderName, "method", b.method,
+			"webhook method must be POST, PUT or PATCH, got %q", b.method)
+	}
+
+	for name, value := range b.headers {
+		if name == "" || strings.ContainsAny(value, "\r\n") {
+			return errorsx.InvalidValue(builderName, "headers", name, "invalid HTTP header: %q", name)
+		}
+
+		if name == "Authorization" && b.token != nil {
+			return errorsx.ConflictingOptions(builderName, []string{"headers", "token"},
+				"the bearer token sets the Authorization header")
+		}
+	}
+
+	if b.retries < 0 {
+		return errorsx.InvalidValue(builderName, "retries", b.retries, "retries cannot be negative")
+	}
+
+	return nil
+}
+
+// BuildCommand generates the curl command sending the payload.
+//
+// Returns:
+//   - The send command.
+//   - An error if the payload is missing or not JSON, the URL is missing, invalid or set twice,
+//     a secret is invalid, the method or a header is invalid, or the number of retries is
+//     negative.
+func (b *SendBuilder) BuildCommand() ([]string, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	curl := []string{
+		"curl", "-sS", "--fail-with-body", "--retry", strconv.Itoa(b.retries), "-X", b.method,
+		"-H", "Content-Type: application/json",
+	}
+
+	names := make([]string, 0, len(b.headers))
+	for name := range b.headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		curl = append(curl, "-H", name+": "+b.headers[name])
+	}
+
+	curl = append(curl, "--data-binary", "@"+b.PayloadPath())
+
+	script := cmdx.ShellJoin(curl)
+	if b.token != nil {
+		script += fmt.Sprintf(` -H "Authorization: Bearer $%s"`, TokenEnvVar)
+	}
+
+	if b.urlSecret != nil {
+		script += fmt.Sprintf(` "$%s"`, URLEnvVar)
+	} else {
+		script += " " + cmdx.ShellQuote(b.url)
+	}
+
+	cmd := []string{"sh", "-c", script}
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Mounts returns the mounts the generated command requires: the inline mount of the payload.
+func (b *SendBuilder) Mounts() []types.Mount {
+	if len(b.payload) == 0 {
+		return nil
+	}
+
+	return []types.Mount{{
+		Kind: types.MountKindInline, Target: b.PayloadPath(), Content: string(b.payload), ReadOnly: true,
+	}}
+}
+
+// Secrets returns the secrets the command requires: the URL and bearer token, if any.
+func (b *SendBuilder) Secrets() []*secretsx.SecretRef {
+	var secrets []*secretsx.SecretRef
+	for _, s := range []*secretsx.SecretRef{b.urlSecret, b.token} {
+		if s != nil {
+			secrets = append(secrets, s)
+		}
+	}
+
+	return secrets
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *SendBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Env returns the environment variables the generated command requires: none. The
+// credentials are secrets (see Secrets).
+func (b *SendBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the curl command.
+func (b *SendBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewSendBuilder(nil), explainx.Generate("BuildCommand", b.BuildCommand))
+}
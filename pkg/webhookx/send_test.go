@@ -0,0 +1,136 @@
+package webhookx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/secretsx"
+	"github.com/Excoriate/daggerx/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPayloadPath(t *testing.T) {
+	path := GetPayloadPath("", []byte(`{"event":"a"}`))
+	assert.True(t, strings.HasPrefix(path, "/mnt/.daggerx/webhook-"), path)
+	assert.True(t, strings.HasSuffix(path, ".json"), path)
+	assert.NotEqual(t, path, GetPayloadPath("", []byte(`{"event":"b"}`)))
+}
+
+func TestSendBuilderBuildCommand(t *testing.T) {
+	payload := []byte(`{"event":"pipeline.completed"}`)
+	data := "--data-binary '@" + GetPayloadPath("", payload) + "'"
+	token := secretsx.FromEnv("hooks-token", "CI_HOOKS_TOKEN")
+
+	tests := []struct {
+		name    string
+		builder *SendBuilder
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "plain URL",
+			builder: NewSendBuilder(payload).WithURL("https://hooks.example.com/pipelines"),
+			want: "curl -sS --fail-with-body --retry 3 -X POST -H 'Content-Type: application/json' " +
+				data + " https://hooks.example.com/pipelines",
+		},
+		{
+			name: "secret URL, token, headers and method",
+			builder: NewSendBuilder(payload).
+				WithURLSecret(secretsx.FromEnv("hooks-url", "CI_HOOKS_URL")).
+				WithBearerToken(token).
+				WithHeader("x-source", "daggerx").
+				WithHeader("X-Event", "pipeline").
+				WithMethod("PUT").
+				WithRetries(0),
+			want: "curl -sS --fail-with-body --retry 0 -X PUT -H 'Content-Type: application/json' " +
+				"-H 'X-Event: pipeline' -H 'X-Source: daggerx' " + data +
+				` -H "Authorization: Bearer $WEBHOOK_TOKEN" "$WEBHOOK_URL"`,
+		},
+		{
+			name:    "missing payload",
+			builder: NewSendBuilder(nil).WithURL("https://hooks.example.com"),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "invalid payload",
+			builder: NewSendBuilder([]byte("done")).WithURL("https://hooks.example.com"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "missing URL",
+			builder: NewSendBuilder(payload),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "invalid URL",
+			builder: NewSendBuilder(payload).WithURL("hooks.example.com"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name: "plain and secret URL",
+			builder: NewSendBuilder(payload).WithURL("https://hooks.example.com").
+				WithURLSecret(secretsx.FromEnv("hooks-url", "CI_HOOKS_URL")),
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+		{
+			name:    "unsupported method",
+			builder: NewSendBuilder(payload).WithURL("https://hooks.example.com").WithMethod("GET"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "invalid header",
+			builder: NewSendBuilder(payload).WithURL("https://hooks.example.com").WithHeader("X-A", "a\nb"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name: "authorization header and token",
+			builder: NewSendBuilder(payload).WithURL("https://hooks.example.com").
+				WithHeader("authorization", "Basic x").WithBearerToken(token),
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+		{
+			name:    "negative retries",
+			builder: NewSendBuilder(payload).WithURL("https://hooks.example.com").WithRetries(-1),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, []string{"sh", "-c", tt.want}, cmd)
+		})
+	}
+}
+
+func TestSendBuilderSecrets(t *testing.T) {
+	url := secretsx.FromEnv("hooks-url", "CI_HOOKS_URL")
+	token := secretsx.FromEnv("hooks-token", "CI_HOOKS_TOKEN")
+
+	b := NewSendBuilder([]byte(`{}`)).WithURLSecret(url).WithBearerToken(token)
+	secrets := b.Secrets()
+	require.Len(t, secrets, 2)
+	assert.Equal(t, URLEnvVar, secrets[0].Target)
+	assert.Equal(t, TokenEnvVar, secrets[1].Target)
+	assert.Equal(t, "CI_HOOKS_URL", url.Target)
+
+	assert.Nil(t, NewSendBuilder([]byte(`{}`)).WithURL("https://hooks.example.com").Secrets())
+}
+
+func TestSendBuilderMounts(t *testing.T) {
+	payload := []byte(`{"event":"pipeline.completed"}`)
+	b := NewSendBuilder(payload)
+
+	assert.Equal(t, []types.Mount{{
+		Kind: types.MountKindInline, Target: b.PayloadPath(), Content: string(payload), ReadOnly: true,
+	}}, b.Mounts())
+	assert.Nil(t, NewSendBuilder(nil).Mounts())
+}
@@ -38,9 +38,11 @@ package workdir
 
 import (
 	"fmt"
+	"path"
 	"path/filepath"
 	"strings"
 
+	"github.com/Excoriate/daggerx/pkg/filesystemx"
 	"github.com/Excoriate/daggerx/pkg/fixtures"
 )
 
@@ -56,13 +58,17 @@ func GetDefault() string {
 //   - workdir: The workdir path to validate.
 //
 // Returns:
-//   - The provided workdir if it is not empty and starts with '/'.
+//   - The provided workdir if it is not empty, slash-separated (on Windows hosts too) and
+//     starting with '/'.
 //   - The default workdir if the provided workdir is empty.
 func SetOrDefault(workdir string) string {
 	if workdir == "" {
 		return GetDefault()
 	}
 
+	// The workdir is a path of the container, whatever the separator of the host.
+	workdir = filepath.ToSlash(workdir)
+
 	// Ensure the workdir starts with a '/'
 	if !strings.HasPrefix(workdir, "/") {
 		workdir = "/" + workdir
@@ -74,8 +80,8 @@ func SetOrDefault(workdir string) string {
 // IsValid checks if the provided workdir is valid.
 //
 // A valid workdir must:
-// - Be an absolute path.
-// - Start with the mount prefix from fixtures.
+// - Be an absolute, slash-separated path of the container (on Windows hosts too).
+// - Be the mount prefix from fixtures or a path under it ("/mnt/../etc" is not).
 //
 // Parameters:
 //   - workdir: The workdir path to validate.
@@ -83,11 +89,11 @@ func SetOrDefault(workdir string) string {
 // Returns:
 //   - An error if the workdir is invalid; otherwise, nil.
 func IsValid(workdir string) error {
-	if !filepath.IsAbs(workdir) {
+	if !path.IsAbs(workdir) {
 		return fmt.Errorf("workdir must be an absolute path: %s", workdir)
 	}
 
-	if !strings.HasPrefix(workdir, fixtures.MntPrefix) {
+	if !filesystemx.IsWithin(fixtures.MntPrefix, workdir) {
 		return fmt.Errorf("workdir must start with %s: %s", fixtures.MntPrefix, workdir)
 	}
 
@@ -69,3 +69,20 @@ func TestIsValid_InvalidInput_NotAbsolutePath(t *testing.T) {
 		assert.EqualError(t, err, "workdir must be an absolute path: "+test.input)
 	}
 }
+
+func TestIsValid_InvalidInput_OutsideMountPrefix(t *testing.T) {
+	tests := []struct {
+		input string
+	}{{
+		input: fixtures.MntPrefix + "x/path",
+	}, {
+		input: fixtures.MntPrefix + "/../etc",
+	}, {
+		input: "/etc",
+	}}
+
+	for _, test := range tests {
+		err := IsValid(test.input)
+		assert.EqualError(t, err, "workdir must start with "+fixtures.MntPrefix+": "+test.input)
+	}
+}
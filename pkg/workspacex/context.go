@@ -0,0 +1,242 @@
+// Package workspacex provides utilities to assemble build context descriptions, so Dagger modules
+// can filter host directories before mounting them for apko, melange or buildx builds.
+//
+// A BuildContext collects include and exclude globs (optionally parsed from a .dockerignore-style
+// file), computes the selected files, enforces a maximum context size, and converts the patterns to
+// the options Dagger's Host.Directory expects.
+//
+// Example usage:
+//
+//	bc := workspacex.NewBuildContext("./images/base").
+//	    WithExclude("**/*.md").
+//	    WithMaxSize(50 * 1024 * 1024)
+//
+//	if err := bc.WithIgnoreFile(".dockerignore"); err != nil {
+//	    // handle error
+//	}
+//
+//	if err := bc.CheckSize(); err != nil {
+//	    // handle error
+//	}
+//
+//	dir := client.Host().Directory(bc.Root(), bc.ToDaggerOpts())
+package workspacex
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"dagger.io/dagger"
+)
+
+// BuildContext describes the subset of a host directory used as build context.
+type BuildContext struct {
+	// root is the host directory of the build context.
+	root string
+	// includes holds the include patterns.
+	includes []string
+	// excludes holds the exclude patterns, in evaluation order.
+	excludes []string
+	// maxSize is the maximum total size of the selected files in bytes. Zero disables the check.
+	maxSize int64
+}
+
+// NewBuildContext creates a BuildContext rooted at the host directory 'root'.
+func NewBuildContext(root string) *BuildContext {
+	return &BuildContext{
+		root: root,
+	}
+}
+
+// Root returns the host directory of the build context.
+func (c *BuildContext) Root() string {
+	return c.root
+}
+
+// WithInclude adds include patterns. When set, only matching files are part of the context.
+func (c *BuildContext) WithInclude(patterns ...string) *BuildContext {
+	c.includes = append(c.includes, patterns...)
+	return c
+}
+
+// WithExclude adds exclude patterns. Patterns prefixed with '!' re-include matching files.
+func (c *BuildContext) WithExclude(patterns ...string) *BuildContext {
+	c.excludes = append(c.excludes, patterns...)
+	return c
+}
+
+// WithMaxSize sets the maximum total size of the selected files, in bytes.
+func (c *BuildContext) WithMaxSize(bytes int64) *BuildContext {
+	c.maxSize = bytes
+	return c
+}
+
+// WithIgnoreFile appends the patterns of a .dockerignore-style file to the exclude patterns.
+// A relative path is resolved against the context root.
+func (c *BuildContext) WithIgnoreFile(path string) error {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(c.root, path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open ignore file: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	patterns, err := ParseIgnore(f)
+	if err != nil {
+		return fmt.Errorf("failed to parse ignore file %s: %w", path, err)
+	}
+
+	c.excludes = append(c.excludes, patterns...)
+
+	return nil
+}
+
+// Includes returns the include patterns.
+func (c *BuildContext) Includes() []string {
+	return append([]string(nil), c.includes...)
+}
+
+// Excludes returns the exclude patterns.
+func (c *BuildContext) Excludes() []string {
+	return append([]string(nil), c.excludes...)
+}
+
+// ToDaggerOpts converts the patterns to the options of Host.Directory.
+func (c *BuildContext) ToDaggerOpts() dagger.HostDirectoryOpts {
+	return dagger.HostDirectoryOpts{
+		Include: c.Includes(),
+		Exclude: c.Excludes(),
+	}
+}
+
+// Files returns the slash-separated paths, relative to the root, of the files selected by the
+// build context, sorted lexically.
+func (c *BuildContext) Files() ([]string, error) {
+	files, _, err := c.walk()
+	return files, err
+}
+
+// Size returns the total size in bytes of the files selected by the build context.
+func (c *BuildContext) Size() (int64, error) {
+	_, size, err := c.walk()
+	return size, err
+}
+
+// CheckSize returns an error if the selected files exceed the maximum size.
+func (c *BuildContext) CheckSize() error {
+	if c.maxSize <= 0 {
+		return nil
+	}
+
+	size, err := c.Size()
+	if err != nil {
+		return err
+	}
+
+	if size > c.maxSize {
+		return fmt.Errorf("build context %s is %d bytes, exceeding the maximum of %d bytes", c.root, size, c.maxSize)
+	}
+
+	return nil
+}
+
+// walk walks the context root and returns the selected files and their total size.
+func (c *BuildContext) walk() ([]string, int64, error) {
+	if c.root == "" {
+		return nil, 0, fmt.Errorf("build context root is required")
+	}
+
+	m, err := NewMatcher(c.includes, c.excludes)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var files []string
+	var size int64
+
+	err = filepath.WalkDir(c.root, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		rel, err := filepath.Rel(c.root, path)
+		if err != nil {
+			return err
+		}
+
+		if rel == "." {
+			return nil
+		}
+
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if m.CanSkipDir(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !m.Match(rel) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		files = append(files, rel)
+		size += info.Size()
+
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to walk build context %s: %w", c.root, err)
+	}
+
+	sort.Strings(files)
+
+	return files, size, nil
+}
+
+// ParseIgnore parses .dockerignore-style content. Blank lines and lines starting with '#' are
+// ignored; other lines are trimmed and returned as patterns, keeping a leading '!' for negations.
+func ParseIgnore(r io.Reader) ([]string, error) {
+	var patterns []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		negated := strings.HasPrefix(line, "!")
+		p := normalizePath(strings.TrimSpace(strings.TrimPrefix(line, "!")))
+		if p == "" || p == "." {
+			continue
+		}
+
+		if negated {
+			p = "!" + p
+		}
+		patterns = append(patterns, p)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return patterns, nil
+}
@@ -0,0 +1,77 @@
+package workspacex
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dagger.io/dagger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for p, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(p))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0o600))
+	}
+
+	return root
+}
+
+func TestBuildContextFiles(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"apko.yaml":           "contents: {}",
+		"README.md":           "readme",
+		"CHANGELOG.md":        "changes",
+		".git/config":         "git",
+		"rootfs/etc/app.conf": "conf",
+		".dockerignore":       "# comment\n\n.git\n**/*.md\n!CHANGELOG.md\n",
+	})
+
+	bc := NewBuildContext(root)
+	require.NoError(t, bc.WithIgnoreFile(".dockerignore"))
+
+	files, err := bc.Files()
+	require.NoError(t, err)
+	assert.Equal(t, []string{".dockerignore", "CHANGELOG.md", "apko.yaml", "rootfs/etc/app.conf"}, files)
+
+	size, err := bc.Size()
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(".git\n**/*.md\n!CHANGELOG.md\n")+len("# comment\n\n")+len("changes")+len("contents: {}")+len("conf")), size)
+
+	assert.Equal(t, dagger.HostDirectoryOpts{
+		Exclude: []string{".git", "**/*.md", "!CHANGELOG.md"},
+	}, bc.ToDaggerOpts())
+}
+
+func TestBuildContextCheckSize(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"big.bin":   strings.Repeat("x", 100),
+		"small.txt": "x",
+	})
+
+	assert.NoError(t, NewBuildContext(root).WithMaxSize(101).CheckSize())
+	assert.NoError(t, NewBuildContext(root).WithMaxSize(10).WithExclude("*.bin").CheckSize())
+	assert.ErrorContains(t, NewBuildContext(root).WithMaxSize(10).CheckSize(), "exceeding the maximum of 10 bytes")
+	assert.NoError(t, NewBuildContext(root).CheckSize(), "zero max size disables the check")
+}
+
+func TestBuildContextErrors(t *testing.T) {
+	_, err := NewBuildContext("").Files()
+	assert.EqualError(t, err, "build context root is required")
+
+	err = NewBuildContext(t.TempDir()).WithIgnoreFile(".dockerignore")
+	assert.ErrorContains(t, err, "failed to open ignore file")
+}
+
+func TestParseIgnore(t *testing.T) {
+	patterns, err := ParseIgnore(strings.NewReader("# header\n  node_modules/  \n\n!/keep\n./dist\n!\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node_modules", "!keep", "dist"}, patterns)
+}
@@ -0,0 +1,181 @@
+package workspacex
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// pattern is a compiled include/exclude pattern.
+type pattern struct {
+	// raw is the pattern as written by the user, without the negation prefix.
+	raw string
+	// negated indicates that the pattern re-includes matching paths ("!pattern").
+	negated bool
+	// re is the compiled regular expression of the pattern.
+	re *regexp.Regexp
+}
+
+// Matcher decides whether a slash-separated relative path belongs to a build context.
+//
+// Exclude patterns follow .dockerignore semantics: patterns are evaluated in order, the last
+// matching pattern wins, and patterns prefixed with '!' re-include paths. A pattern matching a
+// directory also matches everything below it. When include patterns are set, only paths matching
+// at least one of them are selected.
+type Matcher struct {
+	includes []pattern
+	excludes []pattern
+}
+
+// NewMatcher compiles the include and exclude patterns.
+//
+// Returns:
+//   - The Matcher.
+//   - An error if a pattern is empty or cannot be compiled.
+func NewMatcher(includes, excludes []string) (*Matcher, error) {
+	m := &Matcher{}
+
+	for _, p := range includes {
+		compiled, err := compilePattern(p)
+		if err != nil {
+			return nil, err
+		}
+		if compiled.negated {
+			return nil, fmt.Errorf("include pattern cannot be negated: %s", p)
+		}
+		m.includes = append(m.includes, compiled)
+	}
+
+	for _, p := range excludes {
+		compiled, err := compilePattern(p)
+		if err != nil {
+			return nil, err
+		}
+		m.excludes = append(m.excludes, compiled)
+	}
+
+	return m, nil
+}
+
+// Match reports whether the relative path is selected by the matcher.
+func (m *Matcher) Match(relPath string) bool {
+	relPath = normalizePath(relPath)
+
+	if len(m.includes) > 0 {
+		included := false
+		for _, p := range m.includes {
+			if p.matches(relPath) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+
+	return !m.Excluded(relPath)
+}
+
+// Excluded reports whether the relative path is excluded by the exclude patterns.
+func (m *Matcher) Excluded(relPath string) bool {
+	relPath = normalizePath(relPath)
+
+	excluded := false
+	for _, p := range m.excludes {
+		if p.matches(relPath) {
+			excluded = !p.negated
+		}
+	}
+
+	return excluded
+}
+
+// CanSkipDir reports whether a directory and all its content can be skipped while walking,
+// which is the case when it is excluded and no negated pattern could re-include its content.
+func (m *Matcher) CanSkipDir(relPath string) bool {
+	if !m.Excluded(relPath) {
+		return false
+	}
+
+	for _, p := range m.excludes {
+		if p.negated {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matches reports whether the pattern matches the path or one of its parent directories.
+func (p *pattern) matches(relPath string) bool {
+	if p.re.MatchString(relPath) {
+		return true
+	}
+
+	for dir := path.Dir(relPath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if p.re.MatchString(dir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compilePattern converts a glob pattern supporting '*', '?' and '**' into a regular expression.
+func compilePattern(raw string) (pattern, error) {
+	p := pattern{}
+
+	raw = strings.TrimSpace(raw)
+	if strings.HasPrefix(raw, "!") {
+		p.negated = true
+		raw = strings.TrimSpace(raw[1:])
+	}
+
+	raw = normalizePath(raw)
+	if raw == "" || raw == "." {
+		return pattern{}, fmt.Errorf("pattern cannot be empty")
+	}
+	p.raw = raw
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '*' && i+1 < len(raw) && raw[i+1] == '*':
+			i++
+			if i+1 < len(raw) && raw[i+1] == '/' {
+				i++
+				b.WriteString("(?:.*/)?")
+			} else {
+				b.WriteString(".*")
+			}
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return pattern{}, fmt.Errorf("invalid pattern %s: %w", raw, err)
+	}
+	p.re = re
+
+	return p, nil
+}
+
+// normalizePath converts a path to a clean slash-separated path relative to the context root.
+func normalizePath(p string) string {
+	p = strings.ReplaceAll(p, "\\", "/")
+	p = path.Clean(p)
+	p = strings.TrimPrefix(p, "/")
+
+	return strings.TrimPrefix(p, "./")
+}
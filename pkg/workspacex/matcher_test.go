@@ -0,0 +1,59 @@
+package workspacex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcher(t *testing.T) {
+	tests := []struct {
+		name     string
+		includes []string
+		excludes []string
+		path     string
+		want     bool
+	}{
+		{name: "No patterns", path: "apko.yaml", want: true},
+		{name: "Star in file name", excludes: []string{"*.md"}, path: "README.md", want: false},
+		{name: "Star does not cross directories", excludes: []string{"*.md"}, path: "docs/README.md", want: true},
+		{name: "Double star crosses directories", excludes: []string{"**/*.md"}, path: "docs/a/README.md", want: false},
+		{name: "Double star matches root files", excludes: []string{"**/*.md"}, path: "README.md", want: false},
+		{name: "Directory pattern excludes content", excludes: []string{".git"}, path: ".git/config", want: false},
+		{name: "Question mark", excludes: []string{"file?.txt"}, path: "file1.txt", want: false},
+		{name: "Negation re-includes", excludes: []string{"**/*.md", "!CHANGELOG.md"}, path: "CHANGELOG.md", want: true},
+		{name: "Last pattern wins", excludes: []string{"!keep.txt", "*.txt"}, path: "keep.txt", want: false},
+		{name: "Include restricts", includes: []string{"configs"}, path: "src/main.go", want: false},
+		{name: "Include selects directory content", includes: []string{"configs"}, path: "configs/apko.yaml", want: true},
+		{name: "Include and exclude", includes: []string{"configs"}, excludes: []string{"configs/*.bak"}, path: "configs/a.bak", want: false},
+		{name: "Leading slash and dot are normalized", excludes: []string{"/build"}, path: "./build/out.tar", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewMatcher(tt.includes, tt.excludes)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, m.Match(tt.path))
+		})
+	}
+}
+
+func TestMatcherCanSkipDir(t *testing.T) {
+	m, err := NewMatcher(nil, []string{"node_modules"})
+	require.NoError(t, err)
+	assert.True(t, m.CanSkipDir("node_modules"))
+	assert.False(t, m.CanSkipDir("src"))
+
+	m, err = NewMatcher(nil, []string{"node_modules", "!node_modules/keep"})
+	require.NoError(t, err)
+	assert.False(t, m.CanSkipDir("node_modules"))
+}
+
+func TestNewMatcherErrors(t *testing.T) {
+	_, err := NewMatcher([]string{"!configs"}, nil)
+	assert.EqualError(t, err, "include pattern cannot be negated: !configs")
+
+	_, err = NewMatcher(nil, []string{"  "})
+	assert.EqualError(t, err, "pattern cannot be empty")
+}
@@ -0,0 +1,216 @@
+// Package yamlfmtx provides a builder for yamlfmt, the YAML formatter: the `yamlfmt` command
+// formatting YAML files in place, checking their formatting, or printing the changes it would
+// make. With WithGeneratedStyle, the files are formatted as melangex encodes its configurations,
+// so hand-written and generated apko and melange configurations share one style and their
+// yamllint validation (see yamllintx).
+//
+// Example usage:
+//
+//	formatter := yamlfmtx.NewYamlfmtBuilder("/mnt/apko.yaml", "/mnt/melange.yaml").
+//	    WithMode(yamlfmtx.ModeLint).
+//	    WithGeneratedStyle()
+//
+//	cmd, err := formatter.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+package yamlfmtx
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the yamlfmt builder in errorsx errors.
+const builderName = "yamlfmt"
+
+// YamlfmtDefaultImage is the default container image providing yamlfmt.
+const YamlfmtDefaultImage = "ghcr.io/google/yamlfmt:0.14.0"
+
+// Mode is what the command does with the files.
+type Mode string
+
+const (
+	// ModeFormat formats the files in place.
+	ModeFormat Mode = "format"
+	// ModeLint fails if a file is not formatted, printing the differences.
+	ModeLint Mode = "lint"
+	// ModeDryRun prints the changes formatting would make, without writing them.
+	ModeDryRun Mode = "dry-run"
+)
+
+// GeneratedStyle are the formatter options of the style of the generated configurations:
+// two-space indentation, and no document start marker.
+var GeneratedStyle = map[string]string{
+	"indent":                 "2",
+	"include_document_start": "false",
+	"retain_line_breaks":     "true",
+}
+
+// YamlfmtBuilder generates the yamlfmt command of YAML files.
+type YamlfmtBuilder struct {
+	// paths are the formatted files, directories or doublestar patterns.
+	paths []string
+
+	// mode is what the command does with the files.
+	mode Mode
+
+	// configFile is the yamlfmt configuration file.
+	configFile string
+
+	// formatter are the formatter options overriding the configuration.
+	formatter map[string]string
+
+	// excludes are the excluded paths or patterns.
+	excludes []string
+
+	// doublestar interprets the paths as doublestar patterns (e.g. "**/*.yaml").
+	doublestar bool
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewYamlfmtBuilder creates a YamlfmtBuilder formatting the files or directories 'paths' in
+// place.
+func NewYamlfmtBuilder(paths ...string) *YamlfmtBuilder {
+	return &YamlfmtBuilder{paths: paths, mode: ModeFormat, formatter: map[string]string{}}
+}
+
+// WithMode sets what the command does with the files.
+func (b *YamlfmtBuilder) WithMode(mode Mode) *YamlfmtBuilder {
+	b.mode = mode
+	return b
+}
+
+// WithConfigFile sets the yamlfmt configuration file (e.g. ".yamlfmt").
+func (b *YamlfmtBuilder) WithConfigFile(path string) *YamlfmtBuilder {
+	b.configFile = path
+	return b
+}
+
+// WithFormatterOption sets the formatter option 'key' (e.g. "indent"), overriding the
+// configuration.
+func (b *YamlfmtBuilder) WithFormatterOption(key, value string) *YamlfmtBuilder {
+	b.formatter[key] = value
+	return b
+}
+
+// WithGeneratedStyle sets the formatter options of GeneratedStyle.
+func (b *YamlfmtBuilder) WithGeneratedStyle() *YamlfmtBuilder {
+	for k, v := range GeneratedStyle {
+		b.formatter[k] = v
+	}
+
+	return b
+}
+
+// WithExclude excludes paths or patterns from the formatting.
+func (b *YamlfmtBuilder) WithExclude(paths ...string) *YamlfmtBuilder {
+	b.excludes = append(b.excludes, paths...)
+	return b
+}
+
+// WithDoublestar sets whether the paths are doublestar patterns (e.g. "**/*.yaml").
+func (b *YamlfmtBuilder) WithDoublestar(doublestar bool) *YamlfmtBuilder {
+	b.doublestar = doublestar
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *YamlfmtBuilder) WithLogger(logger *slog.Logger) *YamlfmtBuilder {
+	b.logger = logger
+	return b
+}
+
+// BuildCommand generates the yamlfmt command.
+//
+// Returns:
+//   - The yamlfmt command.
+//   - An error if no path is set, the mode is unsupported, or a formatter option has an empty
+//     key or a comma in its value.
+func (b *YamlfmtBuilder) BuildCommand() ([]string, error) {
+	if len(b.paths) == 0 {
+		return nil, errorsx.MissingField(builderName, "paths", "at least one path is required")
+	}
+
+	cmd := []string{"yamlfmt"}
+	switch b.mode {
+	case ModeFormat:
+	case ModeLint:
+		cmd = append(cmd, "-lint")
+	case ModeDryRun:
+		cmd = append(cmd, "-dry")
+	default:
+		return nil, errorsx.InvalidValue(builderName, "mode", b.mode, "unsupported yamlfmt mode: %q", b.mode)
+	}
+
+	if b.configFile != "" {
+		cmd = append(cmd, "-conf", b.configFile)
+	}
+
+	if len(b.formatter) > 0 {
+		keys := make([]string, 0, len(b.formatter))
+		for k := range b.formatter {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		options := make([]string, len(keys))
+		for i, k := range keys {
+			if k == "" || strings.Contains(b.formatter[k], ",") {
+				return nil, errorsx.InvalidValue(builderName, "formatter", k,
+					"formatter options require a key and a value without commas")
+			}
+			options[i] = k + "=" + b.formatter[k]
+		}
+		cmd = append(cmd, "-formatter", strings.Join(options, ","))
+	}
+
+	if len(b.excludes) > 0 {
+		cmd = append(cmd, "-exclude", strings.Join(b.excludes, ","))
+	}
+
+	if b.doublestar {
+		cmd = append(cmd, "-dstar")
+	}
+
+	cmd = append(cmd, b.paths...)
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *YamlfmtBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *YamlfmtBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *YamlfmtBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the yamlfmt command.
+func (b *YamlfmtBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewYamlfmtBuilder(), explainx.Generate("BuildCommand", b.BuildCommand))
+}
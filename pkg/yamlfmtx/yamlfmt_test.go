@@ -0,0 +1,86 @@
+package yamlfmtx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYamlfmtBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *YamlfmtBuilder
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "format",
+			builder: NewYamlfmtBuilder("/mnt/apko.yaml"),
+			want:    []string{"yamlfmt", "/mnt/apko.yaml"},
+		},
+		{
+			name:    "lint with the generated style",
+			builder: NewYamlfmtBuilder("/mnt/apko.yaml", "/mnt/melange.yaml").WithMode(ModeLint).WithGeneratedStyle(),
+			want: []string{
+				"yamlfmt", "-lint",
+				"-formatter", "include_document_start=false,indent=2,retain_line_breaks=true",
+				"/mnt/apko.yaml", "/mnt/melange.yaml",
+			},
+		},
+		{
+			name: "dry run with options",
+			builder: NewYamlfmtBuilder("**/*.yaml").
+				WithMode(ModeDryRun).
+				WithConfigFile(".yamlfmt").
+				WithFormatterOption("indent", "4").
+				WithExclude("vendor/**", "charts/**").
+				WithDoublestar(true),
+			want: []string{
+				"yamlfmt", "-dry", "-conf", ".yamlfmt", "-formatter", "indent=4",
+				"-exclude", "vendor/**,charts/**", "-dstar", "**/*.yaml",
+			},
+		},
+		{
+			name:    "no path",
+			builder: NewYamlfmtBuilder(),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "unsupported mode",
+			builder: NewYamlfmtBuilder("config").WithMode("check"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "formatter option with a comma",
+			builder: NewYamlfmtBuilder("config").WithFormatterOption("line_ending", "lf,crlf"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+		{
+			name:    "formatter option without a key",
+			builder: NewYamlfmtBuilder("config").WithFormatterOption("", "2"),
+			wantErr: errorsx.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestWithGeneratedStyleCopiesOptions(t *testing.T) {
+	b := NewYamlfmtBuilder("config").WithGeneratedStyle().WithFormatterOption("indent", "4")
+
+	assert.Equal(t, "4", b.formatter["indent"])
+	assert.Equal(t, "2", GeneratedStyle["indent"])
+}
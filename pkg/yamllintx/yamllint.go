@@ -0,0 +1,339 @@
+// Package yamllintx provides a builder for the yamllint validation of YAML files, such as the
+// apko and melange configurations generated by apkox and melangex, so configurations are
+// generated and validated in the same pipeline stage. The parser of the parsable output turns
+// the problems into findings, which join the scans of the run report (see reportx) and gate the
+// pipeline (see policyx).
+//
+// GeneratedConfig is the yamllint configuration accepting the YAML these packages generate:
+// documents without a start marker, indentation of two or four spaces, and long lines.
+//
+// Example usage:
+//
+//	lint := yamllintx.NewYamllintBuilder("/mnt/apko.yaml", "/mnt/melange.yaml").
+//	    WithGeneratedConfig().
+//	    WithStrict(true)
+//
+//	cmd, err := lint.BuildCommand()
+//	if err != nil {
+//	    // handle error
+//	}
+//
+//	// Run cmd, then parse its standard output.
+//	if err := lint.AddScan(report, stdout); err != nil {
+//	    // handle error
+//	}
+//	result, err := lint.Evaluate(stdout)
+package yamllintx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/explainx"
+	"github.com/Excoriate/daggerx/pkg/logx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/Excoriate/daggerx/pkg/types"
+)
+
+// builderName identifies the yamllint builder in errorsx errors.
+const builderName = "yamllint"
+
+// YamllintDefaultImage is the default container image providing yamllint.
+const YamllintDefaultImage = "cytopia/yamllint:1.35"
+
+// GeneratedConfig is the yamllint configuration of the YAML generated by apkox and melangex:
+// the default rules, without the document start marker and line length rules, with the
+// consistent indentation of any width, and with the truthy rule ignoring keys (such as "on").
+const GeneratedConfig = `extends: default
+rules:
+  document-start: disable
+  line-length: disable
+  indentation:
+    spaces: consistent
+    indent-sequences: whatever
+  truthy:
+    check-keys: false
+`
+
+// Presets are the configurations bundled with yamllint.
+var Presets = []string{"default", "relaxed"}
+
+// Level is the level of a yamllint problem.
+type Level string
+
+const (
+	// LevelError reports problems failing the validation.
+	LevelError Level = "error"
+	// LevelWarning reports problems failing the validation in strict mode only.
+	LevelWarning Level = "warning"
+)
+
+// Severity returns the severity of the findings of the level: high for errors, medium for
+// warnings.
+func (l Level) Severity() policyx.Severity {
+	switch l {
+	case LevelError:
+		return policyx.SeverityHigh
+	case LevelWarning:
+		return policyx.SeverityMedium
+	default:
+		return policyx.SeverityUnknown
+	}
+}
+
+// problemRegex matches a line of the parsable output:
+// "file:line:column: [level] message (rule)". The rule is absent for syntax errors.
+var problemRegex = regexp.MustCompile(`^(.+):(\d+):(\d+): \[(error|warning)\] (.*?)(?: \(([a-z-]+)\))?$`)
+
+// Problem is a problem yamllint reports in a YAML file.
+type Problem struct {
+	// File is the path of the file.
+	File string `json:"file"`
+	// Line is the line of the problem.
+	Line int `json:"line"`
+	// Column is the column of the problem.
+	Column int `json:"column"`
+	// Level is the level of the problem.
+	Level Level `json:"level"`
+	// Message explains the problem.
+	Message string `json:"message"`
+	// Rule is the rule reporting the problem (e.g. "indentation"). It is "syntax" for syntax
+	// errors.
+	Rule string `json:"rule"`
+}
+
+// ParseOutput parses the parsable output of yamllint (-f parsable). Empty lines are skipped.
+//
+// Returns:
+//   - The problems, in output order.
+//   - An error if a line is not a problem.
+func ParseOutput(data []byte) ([]Problem, error) {
+	var problems []Problem
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		m := problemRegex.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("invalid yamllint output: %q", line)
+		}
+
+		p := Problem{File: m[1], Level: Level(m[4]), Message: m[5], Rule: m[6]}
+		p.Line, _ = strconv.Atoi(m[2])
+		p.Column, _ = strconv.Atoi(m[3])
+		if p.Rule == "" {
+			p.Rule = "syntax"
+		}
+
+		problems = append(problems, p)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid yamllint output: %w", err)
+	}
+
+	return problems, nil
+}
+
+// Findings converts the problems to findings: the ID is the rule (e.g. "indentation"), the
+// package the location of the problem (e.g. "apko.yaml:3").
+func Findings(problems []Problem) []policyx.Finding {
+	findings := make([]policyx.Finding, len(problems))
+	for i, p := range problems {
+		findings[i] = policyx.Finding{
+			ID:       p.Rule,
+			Package:  p.File + ":" + strconv.Itoa(p.Line),
+			Severity: p.Level.Severity(),
+		}
+	}
+
+	return findings
+}
+
+// YamllintBuilder generates the yamllint command of YAML files.
+type YamllintBuilder struct {
+	// files are the validated files or directories.
+	files []string
+
+	// configFile is the yamllint configuration file.
+	configFile string
+
+	// configData is the inline yamllint configuration, or the name of a preset.
+	configData string
+
+	// strict fails on warnings.
+	strict bool
+
+	// noWarnings reports errors only.
+	noWarnings bool
+
+	// maxSeverity is the highest severity of the findings passing the evaluation.
+	maxSeverity policyx.Severity
+
+	// logger receives the generated commands. It may be nil.
+	logger *slog.Logger
+}
+
+// NewYamllintBuilder creates a YamllintBuilder validating the files or directories 'files',
+// failing the evaluation on errors.
+func NewYamllintBuilder(files ...string) *YamllintBuilder {
+	return &YamllintBuilder{files: files, maxSeverity: policyx.SeverityMedium}
+}
+
+// WithConfigFile sets the yamllint configuration file (e.g. ".yamllint.yaml").
+func (b *YamllintBuilder) WithConfigFile(path string) *YamllintBuilder {
+	b.configFile = path
+	return b
+}
+
+// WithConfigData sets the inline yamllint configuration, or the name of one of Presets.
+func (b *YamllintBuilder) WithConfigData(data string) *YamllintBuilder {
+	b.configData = data
+	return b
+}
+
+// WithGeneratedConfig validates the files with GeneratedConfig, for the YAML generated by apkox
+// and melangex.
+func (b *YamllintBuilder) WithGeneratedConfig() *YamllintBuilder {
+	return b.WithConfigData(GeneratedConfig)
+}
+
+// WithStrict sets whether warnings fail the command. The evaluation of the output is independent
+// of it (see WithMaxSeverity).
+func (b *YamllintBuilder) WithStrict(strict bool) *YamllintBuilder {
+	b.strict = strict
+	return b
+}
+
+// WithNoWarnings sets whether only errors are reported.
+func (b *YamllintBuilder) WithNoWarnings(noWarnings bool) *YamllintBuilder {
+	b.noWarnings = noWarnings
+	return b
+}
+
+// WithMaxSeverity sets the highest severity of the findings passing the evaluation. It defaults
+// to medium, so errors fail; low makes warnings fail as well.
+func (b *YamllintBuilder) WithMaxSeverity(severity policyx.Severity) *YamllintBuilder {
+	b.maxSeverity = severity
+	return b
+}
+
+// WithLogger sets the logger receiving the generated commands.
+func (b *YamllintBuilder) WithLogger(logger *slog.Logger) *YamllintBuilder {
+	b.logger = logger
+	return b
+}
+
+// BuildCommand generates the yamllint command, with parsable output.
+//
+// Returns:
+//   - The yamllint command.
+//   - An error if no file is set, both a configuration file and inline configuration are set,
+//     or strict mode is combined with errors only.
+func (b *YamllintBuilder) BuildCommand() ([]string, error) {
+	if len(b.files) == 0 {
+		return nil, errorsx.MissingField(builderName, "files", "at least one file is required")
+	}
+
+	if b.configFile != "" && b.configData != "" {
+		return nil, errorsx.ConflictingOptions(builderName, []string{"configFile", "configData"},
+			"a configuration file and an inline configuration are mutually exclusive")
+	}
+
+	if b.strict && b.noWarnings {
+		return nil, errorsx.ConflictingOptions(builderName, []string{"strict", "noWarnings"},
+			"strict mode fails on the warnings errors-only mode doesn't report")
+	}
+
+	cmd := []string{"yamllint", "-f", "parsable"}
+	if b.configFile != "" {
+		cmd = append(cmd, "-c", b.configFile)
+	}
+
+	if b.configData != "" {
+		cmd = append(cmd, "-d", b.configData)
+	}
+
+	if b.strict {
+		cmd = append(cmd, "--strict")
+	}
+
+	if b.noWarnings {
+		cmd = append(cmd, "--no-warnings")
+	}
+
+	cmd = append(cmd, b.files...)
+
+	logx.Command(context.Background(), b.logger, builderName, cmd)
+
+	return cmd, nil
+}
+
+// Evaluate parses the parsable output of the command and fails on the findings more severe than
+// the maximum severity.
+//
+// Returns:
+//   - The result of the evaluation.
+//   - An error if the output can't be parsed.
+func (b *YamllintBuilder) Evaluate(output []byte) (policyx.Result, error) {
+	problems, err := ParseOutput(output)
+	if err != nil {
+		return policyx.Result{}, err
+	}
+
+	return policyx.NewPolicy().WithMaxSeverity(b.maxSeverity).Evaluate(policyx.Input{Findings: Findings(problems)}), nil
+}
+
+// AddScan parses the parsable output of the command and adds its findings to 'report', as a
+// yamllint scan of the files.
+//
+// Returns:
+//   - An error if the output can't be parsed.
+func (b *YamllintBuilder) AddScan(report *reportx.Report, output []byte) error {
+	problems, err := ParseOutput(output)
+	if err != nil {
+		return err
+	}
+
+	report.AddScan(builderName, strings.Join(b.files, " "), Findings(problems))
+
+	return nil
+}
+
+// Validate checks the configuration of the builder as BuildCommand does, without logging the
+// command.
+func (b *YamllintBuilder) Validate() error {
+	quiet := *b
+	quiet.logger = nil
+
+	_, err := quiet.BuildCommand()
+	return err
+}
+
+// Mounts returns the mounts the generated command requires: none.
+func (b *YamllintBuilder) Mounts() []types.Mount {
+	return nil
+}
+
+// Env returns the environment variables the generated command requires: none.
+func (b *YamllintBuilder) Env() []types.DaggerEnvVars {
+	return nil
+}
+
+// Explain describes the options of the builder, with their defaults and the flags they add to
+// the yamllint command.
+func (b *YamllintBuilder) Explain() explainx.Explanation {
+	return explainx.Explain(builderName, b, NewYamllintBuilder(), explainx.Generate("BuildCommand", b.BuildCommand))
+}
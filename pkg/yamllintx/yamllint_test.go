@@ -0,0 +1,164 @@
+package yamllintx
+
+import (
+	"testing"
+
+	"github.com/Excoriate/daggerx/pkg/errorsx"
+	"github.com/Excoriate/daggerx/pkg/policyx"
+	"github.com/Excoriate/daggerx/pkg/reportx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testOutput = `/mnt/apko.yaml:3:5: [error] wrong indentation: expected 4 but found 2 (indentation)
+/mnt/apko.yaml:9:1: [warning] too many blank lines (2 > 0) (empty-lines)
+
+/mnt/melange.yaml:4:1: [error] syntax error: expected <block end>, but found '-'
+`
+
+func TestGeneratedConfig(t *testing.T) {
+	var config struct {
+		Extends string         `yaml:"extends"`
+		Rules   map[string]any `yaml:"rules"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte(GeneratedConfig), &config))
+
+	assert.Equal(t, "default", config.Extends)
+	assert.Equal(t, "disable", config.Rules["document-start"])
+	assert.Equal(t, "disable", config.Rules["line-length"])
+	assert.Equal(t, map[string]any{"spaces": "consistent", "indent-sequences": "whatever"},
+		config.Rules["indentation"])
+}
+
+func TestLevelSeverity(t *testing.T) {
+	assert.Equal(t, policyx.SeverityHigh, LevelError.Severity())
+	assert.Equal(t, policyx.SeverityMedium, LevelWarning.Severity())
+	assert.Equal(t, policyx.SeverityUnknown, Level("info").Severity())
+}
+
+func TestParseOutput(t *testing.T) {
+	problems, err := ParseOutput([]byte(testOutput))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Problem{
+		{
+			File: "/mnt/apko.yaml", Line: 3, Column: 5, Level: LevelError,
+			Message: "wrong indentation: expected 4 but found 2", Rule: "indentation",
+		},
+		{
+			File: "/mnt/apko.yaml", Line: 9, Column: 1, Level: LevelWarning,
+			Message: "too many blank lines (2 > 0)", Rule: "empty-lines",
+		},
+		{
+			File: "/mnt/melange.yaml", Line: 4, Column: 1, Level: LevelError,
+			Message: "syntax error: expected <block end>, but found '-'", Rule: "syntax",
+		},
+	}, problems)
+
+	problems, err = ParseOutput(nil)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+
+	_, err = ParseOutput([]byte("apko.yaml\n  3:5  error  wrong indentation  (indentation)\n"))
+	assert.Error(t, err)
+}
+
+func TestFindings(t *testing.T) {
+	problems, err := ParseOutput([]byte(testOutput))
+	require.NoError(t, err)
+
+	assert.Equal(t, []policyx.Finding{
+		{ID: "indentation", Package: "/mnt/apko.yaml:3", Severity: policyx.SeverityHigh},
+		{ID: "empty-lines", Package: "/mnt/apko.yaml:9", Severity: policyx.SeverityMedium},
+		{ID: "syntax", Package: "/mnt/melange.yaml:4", Severity: policyx.SeverityHigh},
+	}, Findings(problems))
+}
+
+func TestYamllintBuilderBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *YamllintBuilder
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "defaults",
+			builder: NewYamllintBuilder("/mnt/apko.yaml"),
+			want:    []string{"yamllint", "-f", "parsable", "/mnt/apko.yaml"},
+		},
+		{
+			name:    "generated config",
+			builder: NewYamllintBuilder("/mnt/apko.yaml", "/mnt/melange.yaml").WithGeneratedConfig().WithStrict(true),
+			want: []string{
+				"yamllint", "-f", "parsable", "-d", GeneratedConfig, "--strict",
+				"/mnt/apko.yaml", "/mnt/melange.yaml",
+			},
+		},
+		{
+			name:    "config file and errors only",
+			builder: NewYamllintBuilder("config").WithConfigFile(".yamllint.yaml").WithNoWarnings(true),
+			want:    []string{"yamllint", "-f", "parsable", "-c", ".yamllint.yaml", "--no-warnings", "config"},
+		},
+		{
+			name:    "preset",
+			builder: NewYamllintBuilder("config").WithConfigData("relaxed"),
+			want:    []string{"yamllint", "-f", "parsable", "-d", "relaxed", "config"},
+		},
+		{
+			name:    "no file",
+			builder: NewYamllintBuilder(),
+			wantErr: errorsx.ErrMissingField,
+		},
+		{
+			name:    "config file and inline config",
+			builder: NewYamllintBuilder("config").WithConfigFile(".yamllint.yaml").WithGeneratedConfig(),
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+		{
+			name:    "strict and errors only",
+			builder: NewYamllintBuilder("config").WithStrict(true).WithNoWarnings(true),
+			wantErr: errorsx.ErrConflictingOptions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.builder.BuildCommand()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
+
+func TestYamllintBuilderEvaluate(t *testing.T) {
+	result, err := NewYamllintBuilder("/mnt").Evaluate([]byte(testOutput))
+	require.NoError(t, err)
+	assert.False(t, result.Passed)
+	assert.Len(t, result.Violations, 2)
+
+	result, err = NewYamllintBuilder("/mnt").WithMaxSeverity(policyx.SeverityLow).Evaluate([]byte(testOutput))
+	require.NoError(t, err)
+	assert.Len(t, result.Violations, 3)
+
+	result, err = NewYamllintBuilder("/mnt").Evaluate(nil)
+	require.NoError(t, err)
+	assert.True(t, result.Passed)
+}
+
+func TestYamllintBuilderAddScan(t *testing.T) {
+	report := reportx.NewReport("lint")
+	require.NoError(t, NewYamllintBuilder("/mnt/apko.yaml", "/mnt/melange.yaml").AddScan(report, []byte(testOutput)))
+
+	require.Len(t, report.Scans, 1)
+	assert.Equal(t, "yamllint", report.Scans[0].Scanner)
+	assert.Equal(t, "/mnt/apko.yaml /mnt/melange.yaml", report.Scans[0].Target)
+	assert.Equal(t, map[string]int{"high": 2, "medium": 1}, report.Scans[0].Counts)
+
+	assert.Error(t, NewYamllintBuilder("/mnt").AddScan(report, []byte("not parsable")))
+}